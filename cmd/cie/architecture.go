@@ -0,0 +1,137 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/internal/output"
+	"github.com/kraklabs/cie/internal/ui"
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// ArchitectureOutput is the JSON shape of `cie architecture --json`.
+type ArchitectureOutput struct {
+	ProjectID string `json:"project_id"`
+	Output    string `json:"output,omitempty"`
+	Markdown  string `json:"markdown"`
+}
+
+// runArchitecture executes the 'architecture' CLI command, writing an
+// architecture overview (component diagram, dependency matrix, hotspots)
+// built from the index.
+//
+// Examples:
+//
+//	cie architecture                          Print the overview to stdout
+//	cie architecture -o docs/ARCHITECTURE.md  Regenerate a checked-in doc
+//	cie architecture --depth 1                Coarser components
+func runArchitecture(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("architecture", flag.ExitOnError)
+	outPath := fs.StringP("output", "o", "", "Write the document to this file instead of stdout")
+	depth := fs.Int("depth", 2, "Directory depth that defines a component")
+	components := fs.Int("components", 12, "Maximum components in the diagram and matrix")
+	hotspots := fs.Int("hotspots", 15, "Number of hotspot functions to list")
+	timeout := fs.Duration("timeout", 2*time.Minute, "Overall timeout")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: cie architecture [options]
+
+Description:
+  Generate an architecture overview document from the index:
+  a Mermaid component diagram, a component dependency matrix and a
+  list of hotspot functions (most callers plus callees).
+
+  Components are source directories grouped to --depth path segments.
+  Tests and generated code are excluded. Re-run after 'cie index' to
+  keep a checked-in document in sync with the code.
+
+Options:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Examples:
+  cie architecture
+  cie architecture -o docs/ARCHITECTURE.md
+  cie architecture --depth 1 --components 8
+
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+
+	backend := openLocalBackend(cfg, globals)
+	defer func() { _ = backend.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	report, err := tools.ArchitectureReport(ctx, tools.NewEmbeddedQuerier(backend), tools.ArchitectureArgs{
+		Title:         fmt.Sprintf("%s — Architecture Overview", cfg.ProjectID),
+		Depth:         *depth,
+		MaxComponents: *components,
+		MaxHotspots:   *hotspots,
+	})
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Cannot build architecture overview",
+			fmt.Sprintf("Index query failed: %v", err),
+			"Run 'cie status' to check the index, or 'cie index' to rebuild it",
+			err,
+		), globals.JSON)
+	}
+
+	if *outPath != "" {
+		if err := os.WriteFile(*outPath, []byte(report), 0644); err != nil {
+			errors.FatalError(errors.NewPermissionError(
+				"Cannot write architecture overview",
+				fmt.Sprintf("Failed to write %s", *outPath),
+				"Check that the directory exists and is writable",
+				err,
+			), globals.JSON)
+		}
+	}
+
+	if globals.JSON {
+		_ = output.JSON(ArchitectureOutput{
+			ProjectID: cfg.ProjectID,
+			Output:    *outPath,
+			Markdown:  report,
+		})
+		return
+	}
+	if *outPath != "" {
+		ui.Successf("Architecture overview written to %s", *outPath)
+		return
+	}
+	fmt.Print(report)
+}
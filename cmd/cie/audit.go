@@ -0,0 +1,283 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/internal/output"
+	"github.com/kraklabs/cie/internal/ui"
+	"github.com/kraklabs/cie/pkg/storage"
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// countingQuerier wraps a Querier and tallies the rows returned by every
+// query, so the audit log can record how much data a tool call touched.
+type countingQuerier struct {
+	tools.Querier
+	rows atomic.Int64
+}
+
+func (c *countingQuerier) Query(ctx context.Context, script string) (*tools.QueryResult, error) {
+	result, err := c.Querier.Query(ctx, script)
+	if result != nil {
+		c.rows.Add(int64(len(result.Rows)))
+	}
+	return result, err
+}
+
+// QueryWithParams forwards parameterized queries when the wrapped client
+// supports them, counting rows like Query.
+func (c *countingQuerier) QueryWithParams(ctx context.Context, script string, params map[string]any) (*tools.QueryResult, error) {
+	pq, ok := c.Querier.(tools.ParamQuerier)
+	if !ok {
+		return nil, fmt.Errorf("this client does not support query parameters; write the values into the script")
+	}
+	result, err := pq.QueryWithParams(ctx, script, params)
+	if result != nil {
+		c.rows.Add(int64(len(result.Rows)))
+	}
+	return result, err
+}
+
+// ExecuteWithParams forwards parameterized writes like Execute.
+func (c *countingQuerier) ExecuteWithParams(ctx context.Context, script string, params map[string]any) (*tools.QueryResult, error) {
+	exec, ok := c.Querier.(tools.ParamExecutor)
+	if !ok {
+		return c.QueryWithParams(ctx, script, params)
+	}
+	return exec.ExecuteWithParams(ctx, script, params)
+}
+
+// Execute forwards writes when the wrapped client supports them, keeping
+// cie_raw_query's write path intact while auditing.
+func (c *countingQuerier) Execute(ctx context.Context, script string) (*tools.QueryResult, error) {
+	exec, ok := c.Querier.(tools.Executor)
+	if !ok {
+		return c.Query(ctx, script)
+	}
+	return exec.Execute(ctx, script)
+}
+
+// hashToolArgs returns a short, stable fingerprint of tool arguments.
+// Arguments themselves are not stored: they may contain code or secrets.
+func hashToolArgs(args map[string]any) string {
+	data, err := json.Marshal(args) // map keys are sorted by encoding/json
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// newSessionID returns a random identifier for one MCP server process.
+func newSessionID() string {
+	var b [6]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("s%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// setupAuditLog opens the audit log for an MCP session. Failures are reported
+// and auditing is disabled rather than preventing the server from starting.
+func setupAuditLog(server *mcpServer, cfg *Config) {
+	if cfg.MCP.DisableAudit {
+		return
+	}
+	path, err := storage.DefaultAuditLogPath(cfg.ProjectID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  Audit log disabled: %v\n", err)
+		return
+	}
+	audit, err := storage.OpenAuditLog(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  Audit log disabled: %v\n", err)
+		return
+	}
+	server.audit = audit
+	server.sessionID = newSessionID()
+	fmt.Fprintf(os.Stderr, "  Audit log: %s (session %s)\n", path, server.sessionID)
+}
+
+// recordAudit appends a tool invocation to the audit log, if enabled.
+func (s *mcpServer) recordAudit(tool string, args map[string]any, start time.Time, rows int64, isError bool) {
+	if s.audit == nil {
+		return
+	}
+	err := s.audit.Record(storage.AuditEntry{
+		Timestamp:  start,
+		SessionID:  s.sessionID,
+		Tool:       tool,
+		ArgsHash:   hashToolArgs(args),
+		DurationMS: time.Since(start).Milliseconds(),
+		Rows:       int(rows),
+		IsError:    isError,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "audit: %v\n", err)
+	}
+}
+
+// AuditOutput is the JSON shape of `cie audit --json`.
+type AuditOutput struct {
+	ProjectID string               `json:"project_id"`
+	Path      string               `json:"path"`
+	Entries   []storage.AuditEntry `json:"entries"`
+}
+
+// runAudit executes the 'audit' CLI command, listing MCP tool invocations.
+//
+// Examples:
+//
+//	cie audit                     Show the 50 most recent tool calls
+//	cie audit --tool cie_grep     Only cie_grep calls
+//	cie audit --since 1h --json   Last hour as JSON
+func runAudit(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	limit := fs.Int("limit", 50, "Maximum number of entries to show (0 = all)")
+	tool := fs.String("tool", "", "Only show calls to this tool")
+	session := fs.String("session", "", "Only show calls from this session")
+	since := fs.Duration("since", 0, "Only show calls newer than this (e.g. 30m, 24h)")
+	clear := fs.Bool("clear", false, "Delete all audit entries for this project")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: cie audit [options]
+
+Description:
+  Show the log of MCP tool invocations recorded by 'cie --mcp'.
+
+  Every tool call is stored with its name, a hash of its arguments,
+  duration, number of database rows touched, and whether it failed.
+  Arguments themselves are never stored.
+
+  The log lives in ~/.cie/audit/<project_id>/ and is not removed
+  by 'cie reset'. Disable recording with 'mcp.disable_audit: true'.
+
+Options:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Examples:
+  cie audit
+  cie audit --tool cie_semantic_search --since 24h
+  cie audit --json | jq '.entries[] | select(.duration_ms > 1000)'
+  cie audit --clear
+
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+
+	path, err := storage.DefaultAuditLogPath(cfg.ProjectID)
+	if err != nil {
+		errors.FatalError(errors.NewInternalError(
+			"Cannot determine audit log location",
+			"Operating system failed to provide user home directory path",
+			"Check your system configuration or set the HOME environment variable",
+			err,
+		), globals.JSON)
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if globals.JSON {
+			_ = output.JSON(AuditOutput{ProjectID: cfg.ProjectID, Path: path, Entries: []storage.AuditEntry{}})
+			return
+		}
+		ui.Info("No audit entries yet. Tool calls are recorded while 'cie --mcp' runs.")
+		return
+	}
+
+	audit, err := storage.OpenAuditLog(path)
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Cannot open audit log",
+			"The audit database may be corrupted or permission denied",
+			fmt.Sprintf("Check permissions on %s, or delete it to start a fresh log", path),
+			err,
+		), globals.JSON)
+	}
+	defer func() { _ = audit.Close() }()
+
+	if *clear {
+		if err := audit.Clear(); err != nil {
+			errors.FatalError(errors.NewDatabaseError("Cannot clear audit log", err.Error(), "", err), globals.JSON)
+		}
+		ui.Success("Audit log cleared")
+		return
+	}
+
+	filter := storage.AuditFilter{Tool: *tool, Session: *session, Limit: *limit}
+	if *since > 0 {
+		filter.Since = time.Now().Add(-*since)
+	}
+	entries, err := audit.Entries(filter)
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError("Cannot read audit log", err.Error(), "", err), globals.JSON)
+	}
+
+	if globals.JSON {
+		if err := output.JSON(AuditOutput{ProjectID: cfg.ProjectID, Path: path, Entries: entries}); err != nil {
+			errors.FatalError(errors.NewInternalError("Cannot encode audit log", err.Error(), "", err), true)
+		}
+		return
+	}
+	printAuditEntries(entries)
+}
+
+// printAuditEntries renders audit entries as a table, newest first.
+func printAuditEntries(entries []storage.AuditEntry) {
+	if len(entries) == 0 {
+		ui.Info("No matching audit entries.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "TIME\tSESSION\tTOOL\tDURATION\tROWS\tSTATUS\tARGS")
+	var totalMS int64
+	for _, e := range entries {
+		status := "ok"
+		if e.IsError {
+			status = "error"
+		}
+		totalMS += e.DurationMS
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%dms\t%d\t%s\t%s\n",
+			e.Timestamp.Local().Format("2006-01-02 15:04:05"), e.SessionID, e.Tool, e.DurationMS, e.Rows, status, e.ArgsHash)
+	}
+	_ = w.Flush()
+	fmt.Printf("\n%d calls, %dms total\n", len(entries), totalMS)
+}
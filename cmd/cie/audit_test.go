@@ -0,0 +1,70 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+type stubQuerier struct {
+	rows int
+}
+
+func (s *stubQuerier) Query(_ context.Context, _ string) (*tools.QueryResult, error) {
+	return &tools.QueryResult{Rows: make([][]any, s.rows)}, nil
+}
+
+func (s *stubQuerier) QueryRaw(_ context.Context, _ string) (map[string]any, error) {
+	return map[string]any{}, nil
+}
+
+func TestHashToolArgs_Stable(t *testing.T) {
+	a := hashToolArgs(map[string]any{"query": "auth", "limit": 10})
+	b := hashToolArgs(map[string]any{"limit": 10, "query": "auth"})
+	if a != b {
+		t.Errorf("hash depends on key order: %q vs %q", a, b)
+	}
+	if len(a) != 16 {
+		t.Errorf("hash length = %d, want 16", len(a))
+	}
+	if c := hashToolArgs(map[string]any{"query": "other"}); c == a {
+		t.Error("different args produced the same hash")
+	}
+}
+
+func TestCountingQuerier_SumsRows(t *testing.T) {
+	c := &countingQuerier{Querier: &stubQuerier{rows: 3}}
+	for i := 0; i < 2; i++ {
+		if _, err := c.Query(context.Background(), "?[x] := x = 1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := c.rows.Load(); got != 6 {
+		t.Errorf("rows = %d, want 6", got)
+	}
+}
+
+func TestNewSessionID_Unique(t *testing.T) {
+	if newSessionID() == newSessionID() {
+		t.Error("session IDs should differ")
+	}
+}
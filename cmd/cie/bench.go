@@ -0,0 +1,196 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	flag "github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/internal/output"
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// runBench executes the 'bench' CLI command, scoring semantic retrieval
+// against a YAML file of queries and the code they should find.
+//
+// Examples:
+//
+//	cie bench bench.yaml                          Score the current index
+//	cie bench bench.yaml --compare openai.yaml    Compare two configurations
+//	cie bench bench.yaml -k 20 --verbose          Show which queries missed
+//	cie bench --feedback --since 720h             Summarize ratings from MCP clients
+func runBench(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	compare := fs.StringArray("compare", nil, "Additional project config to evaluate (repeatable)")
+	k := fs.IntP("k", "k", 0, "Cutoff for recall@k (overrides the file's k, default 10)")
+	verbose := fs.BoolP("verbose", "v", false, "List queries that were not ranked first")
+	timeout := fs.Duration("timeout", 10*time.Minute, "Overall timeout")
+	feedback := fs.Bool("feedback", false, "Summarize search feedback from MCP clients instead of running a suite")
+	since := fs.Duration("since", 0, "With --feedback, only ratings from this long ago (e.g. 720h; default all)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: cie bench <suite.yaml> [options]
+       cie bench --feedback [--since <duration>]
+
+Description:
+  Evaluate semantic search quality on your own repository. The suite
+  lists queries and the files or functions each should retrieve:
+
+    k: 10
+    queries:
+      - query: "where are HTTP routes registered"
+        expect:
+          - function: RegisterRoutes
+      - query: "database migrations"
+        expect:
+          - file: internal/store/migrate.go
+
+  Reports recall@k (share of expected targets in the top k) and MRR
+  (mean reciprocal rank of the first expected hit). Use --compare with
+  other project configs, each indexed with a different embedding
+  provider or model, to compare them side by side. Results are ranked
+  with each config's search.ranking weights, so --compare also measures
+  ranking changes.
+
+  With --feedback, summarize the ratings MCP clients reported with
+  cie_search_feedback instead, by search tool and query type (bare
+  identifier, code fragment, question or other prose): the share of
+  useful results, where they ranked and how similar they scored, with
+  the k and min_similarity that would have kept 90%% of useful results.
+
+Options:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Examples:
+  cie bench bench.yaml
+  cie bench bench.yaml --compare .cie/openai.yaml --verbose
+  cie bench --feedback --since 720h
+
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+	if *feedback {
+		cfg, err := LoadConfig(configPath)
+		if err != nil {
+			errors.FatalError(err, globals.JSON)
+		}
+		runFeedbackReport(cfg, *since, globals)
+		return
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	suite, err := loadBenchSuite(fs.Arg(0))
+	if err != nil {
+		errors.FatalError(errors.NewInputError(
+			"Cannot load benchmark suite",
+			err.Error(),
+			"Check the file path and that it lists queries with expected files or functions",
+		), globals.JSON)
+	}
+	if *k > 0 {
+		suite.K = *k
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	var results []*tools.BenchResult
+	for _, path := range append([]string{configPath}, *compare...) {
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			errors.FatalError(err, globals.JSON)
+		}
+		res := runBenchForConfig(ctx, cfg, suite, globals)
+		results = append(results, res)
+	}
+
+	if globals.JSON {
+		_ = output.JSON(results)
+		return
+	}
+	fmt.Print(tools.FormatBenchResults(results, *verbose))
+}
+
+func runBenchForConfig(ctx context.Context, cfg *Config, suite *tools.BenchSuite, globals GlobalFlags) *tools.BenchResult {
+	backend := openLocalBackend(cfg, globals)
+	defer func() { _ = backend.Close() }()
+
+	bench := tools.BenchConfig{
+		Name:           fmt.Sprintf("%s (%s)", cfg.ProjectID, cfg.Embedding.Model),
+		EmbeddingURL:   cfg.Embedding.BaseURL,
+		EmbeddingModel: cfg.Embedding.Model,
+		Ranking:        cfg.Search.Ranking.Weights(),
+	}
+	if bench.Ranking.Recency != 0 {
+		// Recency comes from the git history of the working directory;
+		// without a repository the boost is simply not applied.
+		if cwd, err := os.Getwd(); err == nil {
+			if git, err := tools.NewGitExecutor(cwd); err == nil {
+				bench.Git = git
+			}
+		}
+	}
+	res, err := tools.RunRetrievalBench(ctx, tools.NewEmbeddedQuerier(backend), *suite, bench)
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Benchmark run failed",
+			fmt.Sprintf("Project %s: %v", cfg.ProjectID, err),
+			"Run 'cie status' to check the index and embedding provider",
+			err,
+		), globals.JSON)
+	}
+	return res
+}
+
+// loadBenchSuite reads and validates a benchmark YAML file.
+func loadBenchSuite(path string) (*tools.BenchSuite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var suite tools.BenchSuite
+	if err := yaml.Unmarshal(data, &suite); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if len(suite.Queries) == 0 {
+		return nil, fmt.Errorf("%s has no queries", path)
+	}
+	for i, q := range suite.Queries {
+		if q.Query == "" {
+			return nil, fmt.Errorf("query %d has no text", i+1)
+		}
+		if len(q.Expect) == 0 {
+			return nil, fmt.Errorf("query %q has no expected targets", q.Query)
+		}
+	}
+	return &suite, nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadBenchSuite(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	suite, err := loadBenchSuite(write("ok.yaml", `
+k: 5
+queries:
+  - query: where are routes registered
+    expect:
+      - function: RegisterRoutes
+      - file: internal/http/router.go
+`))
+	if err != nil {
+		t.Fatalf("loadBenchSuite() error = %v", err)
+	}
+	if suite.K != 5 || len(suite.Queries) != 1 || len(suite.Queries[0].Expect) != 2 {
+		t.Errorf("unexpected suite: %+v", suite)
+	}
+	if suite.Queries[0].Expect[1].File != "internal/http/router.go" {
+		t.Errorf("file target = %q", suite.Queries[0].Expect[1].File)
+	}
+
+	tests := []struct {
+		name, body, wantErr string
+	}{
+		{"empty.yaml", "k: 3\n", "no queries"},
+		{"notext.yaml", "queries:\n  - expect:\n      - function: X\n", "query 1 has no text"},
+		{"noexpect.yaml", "queries:\n  - query: foo\n", `"foo" has no expected targets`},
+	}
+	for _, tt := range tests {
+		_, err := loadBenchSuite(write(tt.name, tt.body))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
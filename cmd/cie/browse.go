@@ -0,0 +1,640 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	flag "github.com/spf13/pflag"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// runBrowse executes the 'browse' CLI command, an interactive terminal
+// explorer over the local index.
+//
+// Examples:
+//
+//	cie browse              Start at the package list
+//	cie browse -s "auth"    Start with a search
+func runBrowse(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("browse", flag.ExitOnError)
+	search := fs.StringP("search", "s", "", "Start with a search for this text")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: cie browse [options]
+
+Description:
+  Browse the index in the terminal: packages and their functions,
+  semantic search (name search when no embedding provider is reachable),
+  function code, and caller/callee trees.
+
+Keys:
+  up/down, j/k    Move            enter, right    Open / expand
+  /               Search          esc, left       Back / collapse
+  c               Callers tree    e               Callees tree
+  q, ctrl+c       Quit
+
+Options:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Examples:
+  cie browse
+  cie browse --search "retry with backoff"
+
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+	backend := openLocalBackend(cfg, globals)
+	defer func() { _ = backend.Close() }()
+
+	src := &indexBrowseSource{
+		client:         tools.NewEmbeddedQuerier(backend),
+		embeddingURL:   cfg.Embedding.BaseURL,
+		embeddingModel: cfg.Embedding.Model,
+	}
+	model := newBrowseModel(context.Background(), src, cfg.ProjectID, *search)
+	if _, err := tea.NewProgram(model, tea.WithAltScreen()).Run(); err != nil {
+		errors.FatalError(errors.NewInternalError(
+			"Cannot run the terminal UI",
+			err.Error(),
+			"Run cie browse in an interactive terminal",
+			err,
+		), globals.JSON)
+	}
+}
+
+// browseSource is the index as the browser sees it.
+type browseSource interface {
+	Packages(ctx context.Context) ([]tools.PackageRef, error)
+	Functions(ctx context.Context, dir string) ([]tools.FunctionRef, error)
+	Search(ctx context.Context, query string) ([]tools.FunctionRef, bool, error)
+	Code(ctx context.Context, id string) (string, error)
+	Callers(ctx context.Context, id string) ([]tools.FunctionRef, error)
+	Callees(ctx context.Context, id string) ([]tools.FunctionRef, error)
+}
+
+// indexBrowseSource reads a local index through the tools package.
+type indexBrowseSource struct {
+	client         tools.Querier
+	embeddingURL   string
+	embeddingModel string
+}
+
+func (s *indexBrowseSource) Packages(ctx context.Context) ([]tools.PackageRef, error) {
+	return tools.BrowsePackages(ctx, s.client)
+}
+
+func (s *indexBrowseSource) Functions(ctx context.Context, dir string) ([]tools.FunctionRef, error) {
+	return tools.BrowseFunctions(ctx, s.client, dir)
+}
+
+func (s *indexBrowseSource) Search(ctx context.Context, query string) ([]tools.FunctionRef, bool, error) {
+	return tools.BrowseSearch(ctx, s.client, query, s.embeddingURL, s.embeddingModel, 50)
+}
+
+func (s *indexBrowseSource) Code(ctx context.Context, id string) (string, error) {
+	return tools.BrowseCode(ctx, s.client, id)
+}
+
+func (s *indexBrowseSource) Callers(ctx context.Context, id string) ([]tools.FunctionRef, error) {
+	return tools.BrowseCallers(ctx, s.client, id)
+}
+
+func (s *indexBrowseSource) Callees(ctx context.Context, id string) ([]tools.FunctionRef, error) {
+	return tools.BrowseCallees(ctx, s.client, id)
+}
+
+// browseQueryTimeout bounds each lookup, including the embedding request
+// of a semantic search.
+const browseQueryTimeout = 30 * time.Second
+
+type screenKind int
+
+const (
+	screenPackages screenKind = iota
+	screenFunctions
+	screenCode
+	screenTree
+)
+
+// browseRow is one selectable line of a list or tree screen.
+type browseRow struct {
+	pkg      *tools.PackageRef
+	fn       *tools.FunctionRef
+	depth    int  // tree nesting
+	expanded bool // tree node children are shown
+}
+
+// browseScreen is one level of the navigation stack.
+type browseScreen struct {
+	kind    screenKind
+	title   string
+	rows    []browseRow
+	lines   []string // code screen
+	fn      *tools.FunctionRef
+	callers bool // tree direction
+	cursor  int
+	offset  int
+}
+
+// browseModel is the bubbletea model of cie browse.
+type browseModel struct {
+	ctx     context.Context
+	src     browseSource
+	project string
+	stack   []*browseScreen
+	width   int
+	height  int
+
+	pending   string // --search, run after the package list loads
+	searching bool
+	input     string
+	status    string
+	err       error
+}
+
+// Messages carrying the results of source lookups back to Update.
+type (
+	screenLoadedMsg struct {
+		screen *browseScreen
+		err    error
+	}
+	childrenLoadedMsg struct {
+		screen *browseScreen
+		index  int
+		refs   []tools.FunctionRef
+		err    error
+	}
+)
+
+var (
+	browseTitleStyle    = lipgloss.NewStyle().Bold(true)
+	browseSelectedStyle = lipgloss.NewStyle().Reverse(true)
+	browseDimStyle      = lipgloss.NewStyle().Faint(true)
+	browseErrorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+)
+
+func newBrowseModel(ctx context.Context, src browseSource, project, search string) *browseModel {
+	return &browseModel{ctx: ctx, src: src, project: project, pending: search, width: 80, height: 24}
+}
+
+// Init loads the package list. The initial search, if any, runs once it is
+// shown so the search results stack on top of it.
+func (m *browseModel) Init() tea.Cmd {
+	return m.loadPackages()
+}
+
+// lookup returns the context of one source lookup.
+func (m *browseModel) lookup() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(m.ctx, browseQueryTimeout)
+}
+
+func (m *browseModel) loadPackages() tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := m.lookup()
+		defer cancel()
+		pkgs, err := m.src.Packages(ctx)
+		screen := &browseScreen{kind: screenPackages, title: fmt.Sprintf("%s: %d packages", m.project, len(pkgs))}
+		for i := range pkgs {
+			screen.rows = append(screen.rows, browseRow{pkg: &pkgs[i]})
+		}
+		return screenLoadedMsg{screen: screen, err: err}
+	}
+}
+
+func (m *browseModel) loadFunctions(dir string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := m.lookup()
+		defer cancel()
+		refs, err := m.src.Functions(ctx, dir)
+		return screenLoadedMsg{screen: functionScreen(fmt.Sprintf("%s: %d functions", dir, len(refs)), refs), err: err}
+	}
+}
+
+func (m *browseModel) loadSearch(query string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := m.lookup()
+		defer cancel()
+		refs, semantic, err := m.src.Search(ctx, query)
+		how := "name"
+		if semantic {
+			how = "semantic"
+		}
+		return screenLoadedMsg{screen: functionScreen(fmt.Sprintf("Search %q (%s): %d results", query, how, len(refs)), refs), err: err}
+	}
+}
+
+func (m *browseModel) loadCode(fn tools.FunctionRef) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := m.lookup()
+		defer cancel()
+		code, err := m.src.Code(ctx, fn.ID)
+		screen := &browseScreen{
+			kind:  screenCode,
+			title: fmt.Sprintf("%s  %s:%d", fn.Name, fn.FilePath, fn.StartLine),
+			fn:    &fn,
+			lines: numberLines(code, fn.StartLine),
+		}
+		return screenLoadedMsg{screen: screen, err: err}
+	}
+}
+
+func (m *browseModel) loadTree(fn tools.FunctionRef, callers bool) tea.Cmd {
+	return func() tea.Msg {
+		screen := &browseScreen{kind: screenTree, fn: &fn, callers: callers}
+		screen.rows = []browseRow{{fn: &fn}}
+		if callers {
+			screen.title = "Callers of " + fn.Name
+		} else {
+			screen.title = "Callees of " + fn.Name
+		}
+		refs, err := m.children(fn.ID, callers)
+		if err == nil {
+			screen.expand(0, refs)
+		}
+		return screenLoadedMsg{screen: screen, err: err}
+	}
+}
+
+func (m *browseModel) loadChildren(screen *browseScreen, index int) tea.Cmd {
+	fn := screen.rows[index].fn
+	return func() tea.Msg {
+		refs, err := m.children(fn.ID, screen.callers)
+		return childrenLoadedMsg{screen: screen, index: index, refs: refs, err: err}
+	}
+}
+
+func (m *browseModel) children(id string, callers bool) ([]tools.FunctionRef, error) {
+	ctx, cancel := m.lookup()
+	defer cancel()
+	if callers {
+		return m.src.Callers(ctx, id)
+	}
+	return m.src.Callees(ctx, id)
+}
+
+func functionScreen(title string, refs []tools.FunctionRef) *browseScreen {
+	screen := &browseScreen{kind: screenFunctions, title: title}
+	for i := range refs {
+		screen.rows = append(screen.rows, browseRow{fn: &refs[i]})
+	}
+	return screen
+}
+
+// numberLines prefixes each code line with its file line number.
+func numberLines(code string, start int) []string {
+	if code == "" {
+		return []string{"(no code stored for this function)"}
+	}
+	lines := strings.Split(strings.TrimRight(code, "\n"), "\n")
+	width := len(fmt.Sprint(start + len(lines)))
+	for i, line := range lines {
+		lines[i] = fmt.Sprintf("%*d  %s", width, start+i, strings.ReplaceAll(line, "\t", "    "))
+	}
+	return lines
+}
+
+// expand inserts refs as the children of the tree row at index.
+func (s *browseScreen) expand(index int, refs []tools.FunctionRef) {
+	row := &s.rows[index]
+	row.expanded = true
+	children := make([]browseRow, len(refs))
+	for i := range refs {
+		children[i] = browseRow{fn: &refs[i], depth: row.depth + 1}
+	}
+	s.rows = append(s.rows[:index+1], append(children, s.rows[index+1:]...)...)
+}
+
+// collapse removes the descendants of the tree row at index.
+func (s *browseScreen) collapse(index int) {
+	depth := s.rows[index].depth
+	end := index + 1
+	for end < len(s.rows) && s.rows[end].depth > depth {
+		end++
+	}
+	s.rows[index].expanded = false
+	s.rows = append(s.rows[:index+1], s.rows[end:]...)
+}
+
+func (m *browseModel) top() *browseScreen {
+	if len(m.stack) == 0 {
+		return nil
+	}
+	return m.stack[len(m.stack)-1]
+}
+
+// bodyHeight is the number of lines available to a screen's content.
+func (m *browseModel) bodyHeight() int {
+	return max(1, m.height-4)
+}
+
+// Update handles keys, window sizes and finished lookups.
+func (m *browseModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case screenLoadedMsg:
+		m.status = ""
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.err = nil
+		m.stack = append(m.stack, msg.screen)
+		if query := m.pending; query != "" {
+			m.pending = ""
+			return m, m.loadSearch(query)
+		}
+	case childrenLoadedMsg:
+		m.status = ""
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		if msg.screen == m.top() && msg.index < len(msg.screen.rows) && !msg.screen.rows[msg.index].expanded {
+			msg.screen.expand(msg.index, msg.refs)
+		}
+	case tea.KeyMsg:
+		if m.searching {
+			return m.updateSearch(msg)
+		}
+		return m.updateKey(msg)
+	}
+	return m, nil
+}
+
+func (m *browseModel) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.searching, m.input = false, ""
+	case tea.KeyEnter:
+		m.searching = false
+		query := strings.TrimSpace(m.input)
+		m.input = ""
+		if query == "" {
+			return m, nil
+		}
+		m.status = "Searching..."
+		return m, m.loadSearch(query)
+	case tea.KeyBackspace:
+		if r := []rune(m.input); len(r) > 0 {
+			m.input = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.input += string(msg.Runes)
+	}
+	return m, nil
+}
+
+func (m *browseModel) updateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	screen := m.top()
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "/":
+		m.searching, m.input = true, ""
+		return m, nil
+	}
+	if screen == nil {
+		return m, nil
+	}
+	page := m.bodyHeight()
+	switch msg.String() {
+	case "up", "k":
+		m.move(screen, -1)
+	case "down", "j":
+		m.move(screen, 1)
+	case "pgup", "ctrl+u":
+		m.move(screen, -page)
+	case "pgdown", "ctrl+d", " ":
+		m.move(screen, page)
+	case "home", "g":
+		m.move(screen, -m.length(screen))
+	case "end", "G":
+		m.move(screen, m.length(screen))
+	case "enter", "right", "l":
+		return m, m.open(screen)
+	case "esc", "left", "h", "backspace":
+		if screen.kind == screenTree && msg.String() != "esc" && screen.cursor < len(screen.rows) && screen.rows[screen.cursor].expanded {
+			screen.collapse(screen.cursor)
+			return m, nil
+		}
+		if len(m.stack) > 1 {
+			m.stack = m.stack[:len(m.stack)-1]
+		}
+		m.err = nil
+	case "c", "e":
+		if fn := m.selected(screen); fn != nil {
+			m.status = "Loading..."
+			return m, m.loadTree(*fn, msg.String() == "c")
+		}
+	case "o":
+		if fn := m.selected(screen); fn != nil && screen.kind == screenTree {
+			return m, m.loadCode(*fn)
+		}
+	}
+	return m, nil
+}
+
+// length is the number of scrollable lines of a screen.
+func (m *browseModel) length(screen *browseScreen) int {
+	if screen.kind == screenCode {
+		return len(screen.lines)
+	}
+	return len(screen.rows)
+}
+
+// move shifts the cursor (or the code view) by delta and keeps it visible.
+func (m *browseModel) move(screen *browseScreen, delta int) {
+	n := m.length(screen)
+	page := m.bodyHeight()
+	if screen.kind == screenCode {
+		screen.offset = max(0, min(screen.offset+delta, n-page))
+		return
+	}
+	if n == 0 {
+		return
+	}
+	screen.cursor = max(0, min(screen.cursor+delta, n-1))
+	if screen.cursor < screen.offset {
+		screen.offset = screen.cursor
+	}
+	if screen.cursor >= screen.offset+page {
+		screen.offset = screen.cursor - page + 1
+	}
+}
+
+// selected returns the function under the cursor, or shown on a code screen.
+func (m *browseModel) selected(screen *browseScreen) *tools.FunctionRef {
+	if screen.kind == screenCode {
+		return screen.fn
+	}
+	if screen.cursor < len(screen.rows) {
+		return screen.rows[screen.cursor].fn
+	}
+	return nil
+}
+
+// open acts on the selected row: a package lists its functions, a function
+// shows its code, and a tree node expands.
+func (m *browseModel) open(screen *browseScreen) tea.Cmd {
+	if screen.kind == screenCode || screen.cursor >= len(screen.rows) {
+		return nil
+	}
+	row := screen.rows[screen.cursor]
+	switch {
+	case row.pkg != nil:
+		m.status = "Loading..."
+		return m.loadFunctions(row.pkg.Path)
+	case screen.kind == screenTree:
+		if row.expanded {
+			return nil
+		}
+		m.status = "Loading..."
+		return m.loadChildren(screen, screen.cursor)
+	case row.fn != nil:
+		m.status = "Loading..."
+		return m.loadCode(*row.fn)
+	}
+	return nil
+}
+
+// View renders the current screen.
+func (m *browseModel) View() string {
+	var sb strings.Builder
+	screen := m.top()
+	title := "cie browse: loading..."
+	if screen != nil {
+		title = screen.title
+	}
+	sb.WriteString(browseTitleStyle.Render(truncate(title, m.width)) + "\n\n")
+
+	page := m.bodyHeight()
+	var body []string
+	if screen != nil {
+		body = m.renderBody(screen, page)
+	}
+	for i := 0; i < page; i++ {
+		if i < len(body) {
+			sb.WriteString(body[i])
+		}
+		sb.WriteString("\n")
+	}
+
+	switch {
+	case m.searching:
+		sb.WriteString("Search: " + m.input + "█")
+	case m.err != nil:
+		sb.WriteString(browseErrorStyle.Render(truncate("Error: "+m.err.Error(), m.width)))
+	case m.status != "":
+		sb.WriteString(browseDimStyle.Render(m.status))
+	default:
+		sb.WriteString(browseDimStyle.Render(truncate(m.help(screen), m.width)))
+	}
+	return sb.String()
+}
+
+func (m *browseModel) help(screen *browseScreen) string {
+	if screen == nil {
+		return "q quit"
+	}
+	switch screen.kind {
+	case screenCode:
+		return "↑↓ scroll  c callers  e callees  / search  esc back  q quit"
+	case screenTree:
+		return "enter expand  ← collapse  o code  c/e callers/callees  / search  esc back  q quit"
+	case screenFunctions:
+		return "enter code  c callers  e callees  / search  esc back  q quit"
+	}
+	return "enter open  / search  q quit"
+}
+
+func (m *browseModel) renderBody(screen *browseScreen, page int) []string {
+	if screen.kind == screenCode {
+		end := min(len(screen.lines), screen.offset+page)
+		lines := make([]string, 0, page)
+		for _, line := range screen.lines[screen.offset:end] {
+			lines = append(lines, truncate(line, m.width))
+		}
+		return lines
+	}
+	if len(screen.rows) == 0 {
+		return []string{browseDimStyle.Render("(nothing here)")}
+	}
+	end := min(len(screen.rows), screen.offset+page)
+	lines := make([]string, 0, page)
+	for i := screen.offset; i < end; i++ {
+		line := truncate(m.renderRow(screen, screen.rows[i]), m.width)
+		if i == screen.cursor {
+			line = browseSelectedStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func (m *browseModel) renderRow(screen *browseScreen, row browseRow) string {
+	if row.pkg != nil {
+		return fmt.Sprintf("%-50s %5d files %6d functions", row.pkg.Path, row.pkg.Files, row.pkg.Functions)
+	}
+	fn := row.fn
+	loc := fmt.Sprintf("%s:%d", fn.FilePath, fn.StartLine)
+	if screen.kind == screenTree {
+		marker := "▸ "
+		if row.expanded {
+			marker = "▾ "
+		}
+		return strings.Repeat("  ", row.depth) + marker + fn.Name + "  " + loc
+	}
+	if fn.Similarity > 0 {
+		return fmt.Sprintf("%3.0f%%  %s  %s", fn.Similarity*100, fn.Name, loc)
+	}
+	return fn.Name + "  " + loc
+}
+
+// truncate cuts plain text s to width display columns.
+func truncate(s string, width int) string {
+	if width <= 0 || lipgloss.Width(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && lipgloss.Width(string(r)) > width-1 {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "…"
+}
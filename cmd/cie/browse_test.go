@@ -0,0 +1,181 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// fakeBrowseSource serves a tiny call graph: main -> Serve -> handle.
+type fakeBrowseSource struct{}
+
+var browseFns = map[string]tools.FunctionRef{
+	"main":   {ID: "main", Name: "main", FilePath: "cmd/app/main.go", StartLine: 3},
+	"serve":  {ID: "serve", Name: "Serve", FilePath: "internal/http/server.go", StartLine: 10},
+	"handle": {ID: "handle", Name: "handle", FilePath: "internal/http/server.go", StartLine: 20},
+}
+
+func (fakeBrowseSource) Packages(context.Context) ([]tools.PackageRef, error) {
+	return []tools.PackageRef{{Path: "cmd/app", Files: 1, Functions: 1}, {Path: "internal/http", Files: 1, Functions: 2}}, nil
+}
+
+func (fakeBrowseSource) Functions(_ context.Context, dir string) ([]tools.FunctionRef, error) {
+	var refs []tools.FunctionRef
+	for _, id := range []string{"main", "serve", "handle"} {
+		if tools.ExtractDir(browseFns[id].FilePath) == dir {
+			refs = append(refs, browseFns[id])
+		}
+	}
+	return refs, nil
+}
+
+func (fakeBrowseSource) Search(_ context.Context, query string) ([]tools.FunctionRef, bool, error) {
+	ref := browseFns["serve"]
+	ref.Similarity = 0.9
+	return []tools.FunctionRef{ref}, true, nil
+}
+
+func (fakeBrowseSource) Code(_ context.Context, id string) (string, error) {
+	return "func " + browseFns[id].Name + "() {\n\tdoWork()\n}\n", nil
+}
+
+func (fakeBrowseSource) Callers(_ context.Context, id string) ([]tools.FunctionRef, error) {
+	switch id {
+	case "serve":
+		return []tools.FunctionRef{browseFns["main"]}, nil
+	case "handle":
+		return []tools.FunctionRef{browseFns["serve"]}, nil
+	}
+	return nil, nil
+}
+
+func (fakeBrowseSource) Callees(_ context.Context, id string) ([]tools.FunctionRef, error) {
+	switch id {
+	case "main":
+		return []tools.FunctionRef{browseFns["serve"]}, nil
+	case "serve":
+		return []tools.FunctionRef{browseFns["handle"]}, nil
+	}
+	return nil, nil
+}
+
+// browseDriver feeds keys to a browse model, running the commands they
+// return synchronously.
+type browseDriver struct {
+	t *testing.T
+	m *browseModel
+}
+
+func newBrowseDriver(t *testing.T, search string) *browseDriver {
+	d := &browseDriver{t: t, m: newBrowseModel(context.Background(), fakeBrowseSource{}, "demo", search)}
+	d.run(d.m.Init())
+	return d
+}
+
+func (d *browseDriver) run(cmd tea.Cmd) {
+	for cmd != nil {
+		msg := cmd()
+		if _, ok := msg.(tea.QuitMsg); ok {
+			return
+		}
+		_, cmd = d.m.Update(msg)
+	}
+}
+
+func (d *browseDriver) keys(keys ...string) {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		case "left":
+			msg = tea.KeyMsg{Type: tea.KeyLeft}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		_, cmd := d.m.Update(msg)
+		d.run(cmd)
+	}
+}
+
+func (d *browseDriver) expect(substrings ...string) {
+	d.t.Helper()
+	view := d.m.View()
+	for _, s := range substrings {
+		if !strings.Contains(view, s) {
+			d.t.Errorf("view lacks %q:\n%s", s, view)
+		}
+	}
+}
+
+func TestBrowse_PackagesToCode(t *testing.T) {
+	d := newBrowseDriver(t, "")
+	d.expect("demo: 2 packages", "cmd/app", "internal/http")
+
+	d.keys("down", "enter")
+	d.expect("internal/http: 2 functions", "Serve", "handle")
+
+	d.keys("enter")
+	d.expect("Serve  internal/http/server.go:10", "10  func Serve() {", "11      doWork()")
+
+	d.keys("esc", "esc")
+	d.expect("demo: 2 packages")
+}
+
+func TestBrowse_SearchAndCallTree(t *testing.T) {
+	d := newBrowseDriver(t, "")
+	d.keys("/", "h", "t", "t", "p", "enter")
+	d.expect(`Search "http" (semantic): 1 results`, "90%  Serve")
+
+	d.keys("e")
+	d.expect("Callees of Serve", "▾ Serve", "  ▸ handle")
+
+	d.keys("c")
+	d.expect("Callers of Serve", "main  cmd/app/main.go:3")
+
+	// Expand main's callers (none) and collapse the root again.
+	d.keys("esc", "left")
+	if got := len(d.m.top().rows); got != 1 {
+		t.Errorf("collapsed tree has %d rows", got)
+	}
+	d.keys("enter")
+	if got := len(d.m.top().rows); got != 2 {
+		t.Errorf("expanded tree has %d rows", got)
+	}
+}
+
+func TestBrowse_InitialSearch(t *testing.T) {
+	d := newBrowseDriver(t, "serve")
+	d.expect(`Search "serve"`)
+	if len(d.m.stack) != 2 {
+		t.Errorf("stack depth = %d, want packages + search", len(d.m.stack))
+	}
+	d.keys("esc")
+	d.expect("demo: 2 packages")
+}
@@ -0,0 +1,147 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCacheEntries = 256
+	defaultCacheTTL     = 10 * time.Minute
+)
+
+// cacheableTools are the tools whose results are worth memoizing: each call
+// hits an embedding/LLM provider or walks a large part of the call graph.
+var cacheableTools = map[string]bool{
+	"cie_semantic_search": true,
+	"cie_analyze":         true,
+	"cie_get_call_graph":  true,
+}
+
+// resultCache memoizes tool results for one MCP session. Entries are keyed
+// by tool name and argument hash and are dropped whenever the index version
+// changes, so a re-index never serves stale answers.
+type resultCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	version    string
+	lru        *list.List // front = most recently used
+	entries    map[string]*list.Element
+	now        func() time.Time
+}
+
+type cacheEntry struct {
+	key     string
+	result  *mcpToolResult
+	created time.Time
+}
+
+// newResultCache returns a cache configured from cfg, or nil when disabled.
+func newResultCache(cfg CacheConfig) *resultCache {
+	if cfg.Disabled {
+		return nil
+	}
+	c := &resultCache{
+		maxEntries: defaultCacheEntries,
+		ttl:        defaultCacheTTL,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+	if cfg.MaxEntries > 0 {
+		c.maxEntries = cfg.MaxEntries
+	}
+	if cfg.TTLSeconds > 0 {
+		c.ttl = time.Duration(cfg.TTLSeconds) * time.Second
+	}
+	return c
+}
+
+// cacheKey returns the cache key for a call, or "" if the tool is not cached.
+func (c *resultCache) cacheKey(tool string, args map[string]any) string {
+	if c == nil || !cacheableTools[tool] {
+		return ""
+	}
+	return tool + ":" + hashToolArgs(args)
+}
+
+// get returns a cached result for key computed against indexVersion.
+// A version change empties the whole cache.
+func (c *resultCache) get(key, indexVersion string) (*mcpToolResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.syncVersion(indexVersion)
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if c.now().Sub(entry.created) > c.ttl {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return entry.result, true
+}
+
+// put stores a result, evicting the least recently used entry when full.
+func (c *resultCache) put(key, indexVersion string, result *mcpToolResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.syncVersion(indexVersion)
+	if el, ok := c.entries[key]; ok {
+		el.Value = &cacheEntry{key: key, result: result, created: c.now()}
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, result: result, created: c.now()})
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// reset empties the cache. It is safe to call on a nil cache.
+func (c *resultCache) reset() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+}
+
+// syncVersion clears the cache if the index changed. Caller holds c.mu.
+func (c *resultCache) syncVersion(indexVersion string) {
+	if indexVersion == c.version {
+		return
+	}
+	c.version = indexVersion
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+}
@@ -0,0 +1,125 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+func textResult(s string) *mcpToolResult {
+	return &mcpToolResult{Content: []mcpContent{{Type: "text", Text: s}}}
+}
+
+func TestResultCache_KeyOnlyForCacheableTools(t *testing.T) {
+	c := newResultCache(CacheConfig{})
+	if c.cacheKey("cie_grep", map[string]any{"text": "x"}) != "" {
+		t.Error("cie_grep should not be cached")
+	}
+	a := c.cacheKey("cie_semantic_search", map[string]any{"query": "auth"})
+	b := c.cacheKey("cie_semantic_search", map[string]any{"query": "db"})
+	if a == "" || a == b {
+		t.Errorf("expected distinct keys, got %q and %q", a, b)
+	}
+
+	disabled := newResultCache(CacheConfig{Disabled: true})
+	if disabled.cacheKey("cie_analyze", nil) != "" {
+		t.Error("disabled cache should not produce keys")
+	}
+}
+
+func TestResultCache_InvalidatedOnIndexVersion(t *testing.T) {
+	c := newResultCache(CacheConfig{})
+	c.put("k", "v1", textResult("old"))
+
+	if got, ok := c.get("k", "v1"); !ok || got.Content[0].Text != "old" {
+		t.Fatalf("expected hit, got %v %v", got, ok)
+	}
+	if _, ok := c.get("k", "v2"); ok {
+		t.Fatal("entry should be dropped after re-index")
+	}
+	if len(c.entries) != 0 {
+		t.Errorf("cache should be empty after version change, has %d", len(c.entries))
+	}
+}
+
+func TestResultCache_InvalidatedAfterRepair(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "proj")
+	config := storage.EmbeddedConfig{DataDir: dir, Engine: "sqlite", EmbeddingDimensions: 4}
+	backend, err := storage.NewEmbeddedBackend(config)
+	if err != nil {
+		t.Fatalf("NewEmbeddedBackend: %v", err)
+	}
+	if err := backend.EnsureSchema(); err != nil {
+		t.Fatalf("EnsureSchema: %v", err)
+	}
+	before, err := backend.BumpIndexVersion()
+	if err != nil {
+		t.Fatalf("BumpIndexVersion: %v", err)
+	}
+	_ = backend.Close()
+
+	c := newResultCache(CacheConfig{})
+	c.put("k", before, textResult("before repair"))
+
+	if _, err := storage.RepairDatabase(storage.RepairOptions{DataDir: dir, Engine: "sqlite", EmbeddingDimensions: 4, Force: true}); err != nil {
+		t.Fatalf("RepairDatabase: %v", err)
+	}
+	backend, err = storage.NewEmbeddedBackend(config)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer func() { _ = backend.Close() }()
+	after, err := backend.GetProjectMeta(storage.IndexVersionMetaKey)
+	if err != nil {
+		t.Fatalf("GetProjectMeta: %v", err)
+	}
+	if after == before {
+		t.Fatalf("index version %q is unchanged by the repair", after)
+	}
+	if _, ok := c.get("k", after); ok {
+		t.Error("results cached before the repair are still served")
+	}
+}
+
+func TestResultCache_TTLAndEviction(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	c := newResultCache(CacheConfig{MaxEntries: 2, TTLSeconds: 60})
+	c.now = func() time.Time { return now }
+
+	c.put("a", "", textResult("a"))
+	c.put("b", "", textResult("b"))
+	c.get("a", "") // a becomes most recently used
+	c.put("c", "", textResult("c"))
+
+	if _, ok := c.get("b", ""); ok {
+		t.Error("b should have been evicted as least recently used")
+	}
+	if _, ok := c.get("a", ""); !ok {
+		t.Error("a should still be cached")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.get("a", ""); ok {
+		t.Error("a should have expired")
+	}
+}
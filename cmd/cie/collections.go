@@ -0,0 +1,78 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// collectionScoped reports whether a tool can be scoped to a collection:
+// it filters by a path_pattern regex (not a literal git path).
+func collectionScoped(tool mcpTool) bool {
+	props, _ := tool.InputSchema["properties"].(map[string]any)
+	_, ok := props["path_pattern"]
+	return ok && !literalPathPatternTools[tool.Name]
+}
+
+// withCollectionArgument adds an optional "collection" property to every
+// tool that filters by path.
+func withCollectionArgument(list []mcpTool) []mcpTool {
+	prop := map[string]any{
+		"type":        "string",
+		"description": "Optional collection (see cie_collection_list); restricts results to its files. Replaces path_pattern.",
+	}
+	for _, tool := range list {
+		if collectionScoped(tool) {
+			tool.InputSchema["properties"].(map[string]any)["collection"] = prop
+		}
+	}
+	return list
+}
+
+// applyCollection turns a "collection" argument into the path_pattern
+// matching the collection's files.
+func (s *mcpServer) applyCollection(ctx context.Context, toolName string, args map[string]any) error {
+	name, _ := args["collection"].(string)
+	if name == "" {
+		return nil
+	}
+	scoped := false
+	for _, tool := range s.getTools() {
+		if tool.Name == toolName {
+			scoped = collectionScoped(tool)
+			break
+		}
+	}
+	if !scoped {
+		return fmt.Errorf("%s cannot be scoped to a collection", toolName)
+	}
+	if pattern, _ := args["path_pattern"].(string); pattern != "" {
+		return fmt.Errorf("pass either collection or path_pattern, not both")
+	}
+	pattern, err := tools.CollectionPathPattern(ctx, s.client, name)
+	if err != nil {
+		return err
+	}
+	args["path_pattern"] = pattern
+	delete(args, "collection")
+	return nil
+}
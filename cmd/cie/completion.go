@@ -42,7 +42,7 @@ const bashCompletionTemplate = `#!/bin/bash
 
 _cie_completion() {
     local cur prev commands
-    commands="init index status query reset install-hook completion"
+    commands="init index watch embed-backfill embed-check embed-normalize status manifest push-index pull-index export import query grep explain browse reset repair audit onboard architecture bench precommit install-hook completion"
 
     # Current word being completed
     cur="${COMP_WORDS[COMP_CWORD]}"
@@ -50,7 +50,7 @@ _cie_completion() {
 
     # Global flags (including short forms)
     if [[ ${cur} == -* ]] ; then
-        COMPREPLY=( $(compgen -W "-V --version --mcp -c --config --json --no-color -v --verbose -q --quiet" -- ${cur}) )
+        COMPREPLY=( $(compgen -W "-V --version --mcp -c --config --set --profile --json --no-color -v --verbose -q --quiet" -- ${cur}) )
         return 0
     fi
 
@@ -65,15 +65,50 @@ _cie_completion() {
     case "${cmd}" in
         index)
             if [[ ${cur} == -* ]] ; then
-                COMPREPLY=( $(compgen -W "--full --force-full-reindex --embed-workers --debug --metrics-addr" -- ${cur}) )
+                COMPREPLY=( $(compgen -W "--full --force-full-reindex --embed-workers --debug --metrics-addr --timings --timings-output --cpuprofile --skip-embeddings --manifest --no-manifest --profile" -- ${cur}) )
+            fi
+            ;;
+        watch)
+            if [[ ${cur} == -* ]] ; then
+                COMPREPLY=( $(compgen -W "--debounce --embed-workers --skip-embeddings --debug" -- ${cur}) )
+            fi
+            ;;
+        embed-backfill)
+            if [[ ${cur} == -* ]] ; then
+                COMPREPLY=( $(compgen -W "--batch-size --embed-workers --detach --dry-run" -- ${cur}) )
+            fi
+            ;;
+        embed-check)
+            if [[ ${cur} == -* ]] ; then
+                COMPREPLY=( $(compgen -W "--sample --threshold --seed" -- ${cur}) )
+            fi
+            ;;
+        embed-normalize)
+            if [[ ${cur} == -* ]] ; then
+                COMPREPLY=( $(compgen -W "--check" -- ${cur}) )
             fi
             ;;
         status)
             # No command-specific flags (uses global --json)
             ;;
+        manifest)
+            if [[ ${cur} == -* ]] ; then
+                COMPREPLY=( $(compgen -W "--check --any-commit" -- ${cur}) )
+            fi
+            ;;
+        pull-index|import)
+            if [[ ${cur} == -* ]] ; then
+                COMPREPLY=( $(compgen -W "--force" -- ${cur}) )
+            fi
+            ;;
+        export)
+            if [[ ${cur} == -* ]] ; then
+                COMPREPLY=( $(compgen -W "--since" -- ${cur}) )
+            fi
+            ;;
         query)
             if [[ ${cur} == -* ]] ; then
-                COMPREPLY=( $(compgen -W "--timeout --limit" -- ${cur}) )
+                COMPREPLY=( $(compgen -W "--timeout --limit --format --columns --no-header" -- ${cur}) )
             fi
             ;;
         reset)
@@ -81,6 +116,51 @@ _cie_completion() {
                 COMPREPLY=( $(compgen -W "--yes" -- ${cur}) )
             fi
             ;;
+        repair)
+            if [[ ${cur} == -* ]] ; then
+                COMPREPLY=( $(compgen -W "--force" -- ${cur}) )
+            fi
+            ;;
+        audit)
+            if [[ ${cur} == -* ]] ; then
+                COMPREPLY=( $(compgen -W "--limit --tool --session --since --clear" -- ${cur}) )
+            fi
+            ;;
+        grep)
+            if [[ ${cur} == -* ]] ; then
+                COMPREPLY=( $(compgen -W "--path --exclude --context --limit --case-sensitive --regex --scope --group-by --timeout" -- ${cur}) )
+            fi
+            ;;
+        explain)
+            if [[ ${cur} == -* ]] ; then
+                COMPREPLY=( $(compgen -W "--full --no-llm --max-related --timeout" -- ${cur}) )
+            fi
+            ;;
+        browse)
+            if [[ ${cur} == -* ]] ; then
+                COMPREPLY=( $(compgen -W "--search" -- ${cur}) )
+            fi
+            ;;
+        onboard)
+            if [[ ${cur} == -* ]] ; then
+                COMPREPLY=( $(compgen -W "--output --no-llm --packages --timeout" -- ${cur}) )
+            fi
+            ;;
+        architecture)
+            if [[ ${cur} == -* ]] ; then
+                COMPREPLY=( $(compgen -W "--output --depth --components --hotspots --timeout" -- ${cur}) )
+            fi
+            ;;
+        bench)
+            if [[ ${cur} == -* ]] ; then
+                COMPREPLY=( $(compgen -W "--compare --k --verbose --timeout --feedback --since" -- ${cur}) )
+            fi
+            ;;
+        precommit)
+            if [[ ${cur} == -* ]] ; then
+                COMPREPLY=( $(compgen -W "--strict --checks --timeout" -- ${cur}) )
+            fi
+            ;;
         install-hook)
             if [[ ${cur} == -* ]] ; then
                 COMPREPLY=( $(compgen -W "--force --remove" -- ${cur}) )
@@ -118,10 +198,28 @@ _cie() {
     commands=(
         'init:Create .cie/project.yaml configuration'
         'index:Index the current repository'
+        'watch:Re-index files as they change'
+        'embed-backfill:Generate embeddings skipped by index --skip-embeddings'
+        'embed-check:Detect drift between stored embeddings and the provider'
+        'embed-normalize:Check stored embeddings are unit length and fix them'
         'status:Show project status'
+        'manifest:Print or check the index manifest'
+        'push-index:Upload the index to an artifact store'
+        'pull-index:Download a prebuilt index from an artifact store'
+        'export:Write the index changes since a commit as a delta'
+        'import:Apply a delta written by export'
         'query:Execute CozoScript query'
+        'grep:Search indexed code for text'
+        'explain:Explain a function from the index'
+        'browse:Browse the index in an interactive terminal UI'
         'reset:Reset local project data'
-        'install-hook:Install git post-commit hook'
+        'repair:Recover a damaged local index'
+        'audit:Show MCP tool invocation log'
+        'onboard:Generate a markdown repo tour from the index'
+        'architecture:Generate an architecture overview document'
+        'bench:Measure semantic search recall and MRR on your repo'
+        'precommit:Check staged changes against the index'
+        'install-hook:Install git hooks for auto-indexing'
         'completion:Generate shell completion script'
     )
 
@@ -129,6 +227,8 @@ _cie() {
         '(- *){-V,--version}[Show version and exit]' \
         '--mcp[Start as MCP server (JSON-RPC over stdio)]' \
         '(-c --config)'{-c,--config}'[Path to .cie/project.yaml]:config file:_files -g "*.yaml"' \
+        '*--set[Override a config key (key=value)]:key=value:' \
+        '--profile[Use a named embedding/LLM profile]:profile:' \
         '--json[Output in JSON format]' \
         '--no-color[Disable color output]' \
         '(-v --verbose)'{-v,--verbose}'[Increase verbosity (-v info, -vv debug)]' \
@@ -148,21 +248,139 @@ _cie() {
                         '--force-full-reindex[Force full re-index (ignore incremental)]' \
                         '--embed-workers[Number of embedding workers]:workers:' \
                         '--debug[Enable debug logging]' \
-                        '--metrics-addr[Prometheus metrics address]:address:'
+                        '--metrics-addr[Prometheus metrics address]:address:' \
+                        '--timings[Record a JSON timing report]' \
+                        '--timings-output[Path of the timing report]:file:_files' \
+                        '--cpuprofile[Write a pprof CPU profile]:file:_files' \
+                        '--skip-embeddings[Build the structural index without embeddings]' \
+                        '--manifest[Path of the index manifest]:file:_files' \
+                        '--no-manifest[Do not write the index manifest]' \
+                        '--profile[Use a named embedding/LLM profile]:profile:'
+                    ;;
+                watch)
+                    _arguments \
+                        '--debounce[Quiet period before indexing a change]:duration:' \
+                        '--embed-workers[Number of embedding workers]:workers:' \
+                        '--skip-embeddings[Index changes without embeddings]' \
+                        '--debug[Enable debug logging]'
+                    ;;
+                embed-backfill)
+                    _arguments \
+                        '--batch-size[Entities per batch]:size:' \
+                        '--embed-workers[Number of embedding workers]:workers:' \
+                        '--detach[Run in the background]' \
+                        '--dry-run[Only count pending embeddings]'
+                    ;;
+                embed-check)
+                    _arguments \
+                        '--sample[Number of functions to re-embed]:count:' \
+                        '--threshold[Similarity below which a function drifted]:similarity:' \
+                        '--seed[Seed selecting the sample]:seed:'
+                    ;;
+                embed-normalize)
+                    _arguments \
+                        '--check[Only report vectors that are not unit length]'
                     ;;
                 status)
                     # No command-specific flags (uses global --json)
                     ;;
+                manifest)
+                    _arguments \
+                        '--check[Check a cached manifest]:file:_files' \
+                        '--any-commit[Accept an index built at another commit]'
+                    ;;
+                pull-index)
+                    _arguments \
+                        '--force[Replace an existing local index]'
+                    ;;
+                export)
+                    _arguments \
+                        '--since[Commit the receiving index was built at]:commit:'
+                    ;;
+                import)
+                    _arguments \
+                        '--force[Apply to an index at another commit]'
+                    ;;
                 query)
                     _arguments \
                         '--timeout[Query timeout duration]:duration:' \
                         '--limit[Add :limit to query]:limit:' \
+                        '--format[Output format]:format:(table json csv tsv jsonl)' \
+                        '--columns[Comma-separated columns to print]:columns:' \
+                        '--no-header[Omit the header line in csv and tsv output]' \
                         '1:cozoscript query:'
                     ;;
                 reset)
                     _arguments \
                         '--yes[Skip confirmation prompt]'
                     ;;
+                repair)
+                    _arguments \
+                        '--force[Rebuild even if the database looks healthy]'
+                    ;;
+                audit)
+                    _arguments \
+                        '--limit[Maximum number of entries]:limit:' \
+                        '--tool[Only show calls to this tool]:tool:' \
+                        '--session[Only show calls from this session]:session:' \
+                        '--since[Only show calls newer than duration]:duration:' \
+                        '--clear[Delete all audit entries]'
+                    ;;
+                grep)
+                    _arguments \
+                        '--path[Only search files whose path contains this text]:path:_files' \
+                        '--exclude[Skip files whose path matches this regex]:regex:' \
+                        '--context[Lines of context around each match]:lines:' \
+                        '--limit[Maximum number of matches]:limit:' \
+                        '--case-sensitive[Match case]' \
+                        '--regex[Treat the pattern as a regular expression]' \
+                        '--scope[Search function bodies or stored file text]:scope:(functions files)' \
+                        '--group-by[Report match counts per group]:group:(file package function)' \
+                        '--timeout[Search timeout]:duration:' \
+                        '*:pattern:'
+                    ;;
+                explain)
+                    _arguments \
+                        '--full[Print the whole function body]' \
+                        '--no-llm[Do not ask the LLM for a summary]' \
+                        '--max-related[Callers and callees listed each]:count:' \
+                        '--timeout[Overall timeout]:duration:' \
+                        '1:function:'
+                    ;;
+                browse)
+                    _arguments \
+                        '--search[Start with a search]:search:'
+                    ;;
+                onboard)
+                    _arguments \
+                        '--output[Write the report to a file]:output:' \
+                        '--no-llm[Skip LLM package overviews]' \
+                        '--packages[Number of main packages to describe]:packages:' \
+                        '--timeout[Overall timeout]:timeout:'
+                    ;;
+                architecture)
+                    _arguments \
+                        '--output[Write the document to a file]:output:' \
+                        '--depth[Directory depth that defines a component]:depth:' \
+                        '--components[Maximum components shown]:components:' \
+                        '--hotspots[Number of hotspot functions]:hotspots:' \
+                        '--timeout[Overall timeout]:timeout:'
+                    ;;
+                bench)
+                    _arguments \
+                        '--compare[Additional project config to evaluate]:compare:' \
+                        '--k[Cutoff for recall@k]:k:' \
+                        '--verbose[List queries not ranked first]' \
+                        '--timeout[Overall timeout]:timeout:' \
+                        '--feedback[Summarize search feedback from MCP clients]' \
+                        '--since[With --feedback, only recent ratings]:duration:'
+                    ;;
+                precommit)
+                    _arguments \
+                        '--strict[Fail on warnings too]' \
+                        '--checks[Checks to run]:checks:(absence tests callers complexity)' \
+                        '--timeout[Overall timeout]:timeout:'
+                    ;;
                 install-hook)
                     _arguments \
                         '--force[Overwrite existing hook]' \
@@ -194,16 +412,36 @@ const fishCompletionTemplate = `# Fish completion script for CIE (Code Intellige
 # Commands
 complete -c cie -f -n "__fish_use_subcommand" -a "init" -d "Create .cie/project.yaml configuration"
 complete -c cie -f -n "__fish_use_subcommand" -a "index" -d "Index the current repository"
+complete -c cie -f -n "__fish_use_subcommand" -a "watch" -d "Re-index files as they change"
+complete -c cie -f -n "__fish_use_subcommand" -a "embed-backfill" -d "Generate embeddings skipped by index --skip-embeddings"
+complete -c cie -f -n "__fish_use_subcommand" -a "embed-check" -d "Detect drift between stored embeddings and the provider"
+complete -c cie -f -n "__fish_use_subcommand" -a "embed-normalize" -d "Check stored embeddings are unit length and fix them"
 complete -c cie -f -n "__fish_use_subcommand" -a "status" -d "Show project status"
+complete -c cie -f -n "__fish_use_subcommand" -a "manifest" -d "Print or check the index manifest"
+complete -c cie -f -n "__fish_use_subcommand" -a "push-index" -d "Upload the index to an artifact store"
+complete -c cie -f -n "__fish_use_subcommand" -a "pull-index" -d "Download a prebuilt index from an artifact store"
+complete -c cie -f -n "__fish_use_subcommand" -a "export" -d "Write the index changes since a commit as a delta"
+complete -c cie -f -n "__fish_use_subcommand" -a "import" -d "Apply a delta written by export"
 complete -c cie -f -n "__fish_use_subcommand" -a "query" -d "Execute CozoScript query"
+complete -c cie -f -n "__fish_use_subcommand" -a "grep" -d "Search indexed code for text"
+complete -c cie -f -n "__fish_use_subcommand" -a "explain" -d "Explain a function from the index"
+complete -c cie -f -n "__fish_use_subcommand" -a "browse" -d "Browse the index in an interactive terminal UI"
 complete -c cie -f -n "__fish_use_subcommand" -a "reset" -d "Reset local project data (destructive!)"
-complete -c cie -f -n "__fish_use_subcommand" -a "install-hook" -d "Install git post-commit hook"
+complete -c cie -f -n "__fish_use_subcommand" -a "repair" -d "Recover a damaged local index"
+complete -c cie -f -n "__fish_use_subcommand" -a "audit" -d "Show MCP tool invocation log"
+complete -c cie -f -n "__fish_use_subcommand" -a "onboard" -d "Generate a markdown repo tour from the index"
+complete -c cie -f -n "__fish_use_subcommand" -a "architecture" -d "Generate an architecture overview document"
+complete -c cie -f -n "__fish_use_subcommand" -a "bench" -d "Measure semantic search recall and MRR on your repo"
+complete -c cie -f -n "__fish_use_subcommand" -a "precommit" -d "Check staged changes against the index"
+complete -c cie -f -n "__fish_use_subcommand" -a "install-hook" -d "Install git hooks for auto-indexing"
 complete -c cie -f -n "__fish_use_subcommand" -a "completion" -d "Generate shell completion script"
 
 # Global flags (with short forms)
 complete -c cie -s V -l version -d "Show version and exit"
 complete -c cie -l mcp -d "Start as MCP server (JSON-RPC over stdio)"
 complete -c cie -s c -l config -d "Path to .cie/project.yaml" -r
+complete -c cie -l set -d "Override a config key (key=value)" -r
+complete -c cie -n "__fish_use_subcommand" -l profile -d "Use a named embedding/LLM profile" -r
 complete -c cie -l json -d "Output in JSON format"
 complete -c cie -l no-color -d "Disable color output"
 complete -c cie -s v -l verbose -d "Increase verbosity (-v info, -vv debug)"
@@ -215,6 +453,44 @@ complete -c cie -n "__fish_seen_subcommand_from index" -l force-full-reindex -d
 complete -c cie -n "__fish_seen_subcommand_from index" -l embed-workers -d "Number of embedding workers" -r
 complete -c cie -n "__fish_seen_subcommand_from index" -l debug -d "Enable debug logging"
 complete -c cie -n "__fish_seen_subcommand_from index" -l metrics-addr -d "Prometheus metrics address" -r
+complete -c cie -n "__fish_seen_subcommand_from index" -l timings -d "Record a JSON timing report"
+complete -c cie -n "__fish_seen_subcommand_from index" -l timings-output -d "Path of the timing report" -r
+complete -c cie -n "__fish_seen_subcommand_from index" -l cpuprofile -d "Write a pprof CPU profile" -r
+complete -c cie -n "__fish_seen_subcommand_from index" -l skip-embeddings -d "Build the structural index without embeddings"
+complete -c cie -n "__fish_seen_subcommand_from index" -l manifest -d "Path of the index manifest" -r
+complete -c cie -n "__fish_seen_subcommand_from index" -l no-manifest -d "Do not write the index manifest"
+complete -c cie -n "__fish_seen_subcommand_from index" -l profile -d "Use a named embedding/LLM profile" -r
+
+# watch command flags
+complete -c cie -n "__fish_seen_subcommand_from watch" -l debounce -d "Quiet period before indexing a change" -r
+complete -c cie -n "__fish_seen_subcommand_from watch" -l embed-workers -d "Number of embedding workers" -r
+complete -c cie -n "__fish_seen_subcommand_from watch" -l skip-embeddings -d "Index changes without embeddings"
+complete -c cie -n "__fish_seen_subcommand_from watch" -l debug -d "Enable debug logging"
+
+# manifest command flags
+complete -c cie -n "__fish_seen_subcommand_from manifest" -l check -d "Check a cached manifest" -r
+complete -c cie -n "__fish_seen_subcommand_from manifest" -l any-commit -d "Accept an index built at another commit"
+
+# pull-index command flags
+complete -c cie -n "__fish_seen_subcommand_from pull-index" -l force -d "Replace an existing local index"
+
+# export and import command flags
+complete -c cie -n "__fish_seen_subcommand_from export" -l since -d "Commit the receiving index was built at" -r
+complete -c cie -n "__fish_seen_subcommand_from import" -l force -d "Apply to an index at another commit"
+
+# embed-backfill command flags
+complete -c cie -n "__fish_seen_subcommand_from embed-backfill" -l batch-size -d "Entities per batch" -r
+complete -c cie -n "__fish_seen_subcommand_from embed-backfill" -l embed-workers -d "Number of embedding workers" -r
+complete -c cie -n "__fish_seen_subcommand_from embed-backfill" -l detach -d "Run in the background"
+complete -c cie -n "__fish_seen_subcommand_from embed-backfill" -l dry-run -d "Only count pending embeddings"
+
+# embed-check command flags
+complete -c cie -n "__fish_seen_subcommand_from embed-check" -l sample -d "Number of functions to re-embed" -r
+complete -c cie -n "__fish_seen_subcommand_from embed-check" -l threshold -d "Similarity below which a function drifted" -r
+complete -c cie -n "__fish_seen_subcommand_from embed-check" -l seed -d "Seed selecting the sample" -r
+
+# embed-normalize command flags
+complete -c cie -n "__fish_seen_subcommand_from embed-normalize" -l check -d "Only report vectors that are not unit length"
 
 # status command flags
 # (uses global --json flag)
@@ -222,10 +498,69 @@ complete -c cie -n "__fish_seen_subcommand_from index" -l metrics-addr -d "Prome
 # query command flags
 complete -c cie -n "__fish_seen_subcommand_from query" -l timeout -d "Query timeout duration" -r
 complete -c cie -n "__fish_seen_subcommand_from query" -l limit -d "Add :limit to query" -r
+complete -c cie -n "__fish_seen_subcommand_from query" -l format -d "Output format" -r -a "table json csv tsv jsonl"
+complete -c cie -n "__fish_seen_subcommand_from query" -l columns -d "Comma-separated columns to print" -r
+complete -c cie -n "__fish_seen_subcommand_from query" -l no-header -d "Omit the header line in csv and tsv output"
 
 # reset command flags
 complete -c cie -n "__fish_seen_subcommand_from reset" -l yes -d "Skip confirmation prompt"
 
+# repair command flags
+complete -c cie -n "__fish_seen_subcommand_from repair" -l force -d "Rebuild even if the database looks healthy"
+
+# audit command flags
+complete -c cie -n "__fish_seen_subcommand_from audit" -l limit -d "Maximum number of entries" -r
+complete -c cie -n "__fish_seen_subcommand_from audit" -l tool -d "Only show calls to this tool" -r
+complete -c cie -n "__fish_seen_subcommand_from audit" -l session -d "Only show calls from this session" -r
+complete -c cie -n "__fish_seen_subcommand_from audit" -l since -d "Only show calls newer than duration" -r
+complete -c cie -n "__fish_seen_subcommand_from audit" -l clear -d "Delete all audit entries"
+
+# grep command flags
+complete -c cie -n "__fish_seen_subcommand_from grep" -s p -l path -d "Only search files whose path contains this text" -r
+complete -c cie -n "__fish_seen_subcommand_from grep" -l exclude -d "Skip files whose path matches this regex" -r
+complete -c cie -n "__fish_seen_subcommand_from grep" -s C -l context -d "Lines of context around each match" -r
+complete -c cie -n "__fish_seen_subcommand_from grep" -l limit -d "Maximum number of matches" -r
+complete -c cie -n "__fish_seen_subcommand_from grep" -s s -l case-sensitive -d "Match case"
+complete -c cie -n "__fish_seen_subcommand_from grep" -s E -l regex -d "Treat the pattern as a regular expression"
+complete -c cie -n "__fish_seen_subcommand_from grep" -l scope -d "Search function bodies or stored file text" -r -a "functions files"
+complete -c cie -n "__fish_seen_subcommand_from grep" -l group-by -d "Report match counts per group" -r -a "file package function"
+complete -c cie -n "__fish_seen_subcommand_from grep" -l timeout -d "Search timeout" -r
+
+# explain command flags
+complete -c cie -n "__fish_seen_subcommand_from explain" -l full -d "Print the whole function body"
+complete -c cie -n "__fish_seen_subcommand_from explain" -l no-llm -d "Do not ask the LLM for a summary"
+complete -c cie -n "__fish_seen_subcommand_from explain" -l max-related -d "Callers and callees listed each" -r
+complete -c cie -n "__fish_seen_subcommand_from explain" -l timeout -d "Overall timeout" -r
+
+# browse command flags
+complete -c cie -n "__fish_seen_subcommand_from browse" -s s -l search -d "Start with a search" -r
+
+# onboard command flags
+complete -c cie -n "__fish_seen_subcommand_from onboard" -l output -d "Write the report to a file" -r
+complete -c cie -n "__fish_seen_subcommand_from onboard" -l no-llm -d "Skip LLM package overviews"
+complete -c cie -n "__fish_seen_subcommand_from onboard" -l packages -d "Number of main packages to describe" -r
+complete -c cie -n "__fish_seen_subcommand_from onboard" -l timeout -d "Overall timeout" -r
+
+# architecture command flags
+complete -c cie -n "__fish_seen_subcommand_from architecture" -l output -d "Write the document to a file" -r
+complete -c cie -n "__fish_seen_subcommand_from architecture" -l depth -d "Directory depth that defines a component" -r
+complete -c cie -n "__fish_seen_subcommand_from architecture" -l components -d "Maximum components shown" -r
+complete -c cie -n "__fish_seen_subcommand_from architecture" -l hotspots -d "Number of hotspot functions" -r
+complete -c cie -n "__fish_seen_subcommand_from architecture" -l timeout -d "Overall timeout" -r
+
+# bench command flags
+complete -c cie -n "__fish_seen_subcommand_from bench" -l compare -d "Additional project config to evaluate" -r
+complete -c cie -n "__fish_seen_subcommand_from bench" -l k -d "Cutoff for recall@k" -r
+complete -c cie -n "__fish_seen_subcommand_from bench" -l verbose -d "List queries not ranked first"
+complete -c cie -n "__fish_seen_subcommand_from bench" -l timeout -d "Overall timeout" -r
+complete -c cie -n "__fish_seen_subcommand_from bench" -l feedback -d "Summarize search feedback from MCP clients"
+complete -c cie -n "__fish_seen_subcommand_from bench" -l since -d "With --feedback, only recent ratings" -r
+
+# precommit command flags
+complete -c cie -n "__fish_seen_subcommand_from precommit" -l strict -d "Fail on warnings too"
+complete -c cie -n "__fish_seen_subcommand_from precommit" -l checks -d "Checks to run" -r
+complete -c cie -n "__fish_seen_subcommand_from precommit" -l timeout -d "Overall timeout" -r
+
 # install-hook command flags
 complete -c cie -n "__fish_seen_subcommand_from install-hook" -l force -d "Overwrite existing hook"
 complete -c cie -n "__fish_seen_subcommand_from install-hook" -l remove -d "Remove the hook"
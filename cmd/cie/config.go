@@ -23,8 +23,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/pkg/embedprefix"
+	"github.com/kraklabs/cie/pkg/tools"
 	"gopkg.in/yaml.v3"
 )
 
@@ -41,7 +46,30 @@ type Config struct {
 	CIE       CIEConfig       `yaml:"cie"`
 	Embedding EmbeddingConfig `yaml:"embedding"`
 	Indexing  IndexingConfig  `yaml:"indexing"`
-	Roles     RolesConfig     `yaml:"roles,omitempty"` // Custom role patterns
+	Storage   StorageConfig   `yaml:"storage,omitempty"`   // Local database settings
+	Roles     RolesConfig     `yaml:"roles,omitempty"`     // Custom role patterns
+	MCP       MCPConfig       `yaml:"mcp,omitempty"`       // MCP server guardrails
+	Search    SearchConfig    `yaml:"search,omitempty"`    // Result ranking
+	LLM       LLMConfig       `yaml:"llm,omitempty"`       // Optional narrative generation
+	Precommit PrecommitConfig `yaml:"precommit,omitempty"` // Checks run by `cie precommit`
+
+	// Reindex schedules incremental index runs inside `cie serve` and
+	// MCP mode, e.g. "every 30m" or "hourly". Empty disables it.
+	Reindex string `yaml:"reindex,omitempty"`
+
+	// Profiles are named embedding/LLM setups selected with --profile,
+	// CIE_PROFILE or DefaultProfile.
+	Profiles       map[string]ProviderProfile `yaml:"profiles,omitempty"`
+	DefaultProfile string                     `yaml:"default_profile,omitempty"`
+}
+
+// ProviderProfile overrides the embedding and LLM sections when selected.
+// Only the keys a profile sets replace the base values, so a profile can
+// switch the model without restating the URL. The sections are kept as raw
+// YAML until applied to preserve that distinction.
+type ProviderProfile struct {
+	Embedding yaml.Node `yaml:"embedding,omitempty"`
+	LLM       yaml.Node `yaml:"llm,omitempty"`
 }
 
 // CIEConfig contains CIE server configuration.
@@ -57,6 +85,47 @@ type EmbeddingConfig struct {
 	Model      string `yaml:"model"`
 	Dimensions int    `yaml:"dimensions,omitempty"` // embedding dimensions (768 for nomic, 1536 for openai)
 	APIKey     string `yaml:"api_key,omitempty"`    // API key (optional for local models)
+
+	// DisableCache stops reading and writing the embedding cache shared by
+	// all projects in ~/.cie/cache.
+	DisableCache bool `yaml:"disable_cache,omitempty"`
+
+	// QueryPrefix and DocumentPrefix replace the text put in front of search
+	// queries and indexed code for asymmetric models. Unset keeps the
+	// defaults for the provider and model (see embedprefix.Defaults);
+	// "" sends text as-is. Set them per model in a profile.
+	QueryPrefix    *string `yaml:"query_prefix,omitempty"`
+	DocumentPrefix *string `yaml:"document_prefix,omitempty"`
+
+	// NameEmbeddings also embeds each function's name and signature, which
+	// semantic search blends with the body match. Doubles embedding calls.
+	NameEmbeddings bool `yaml:"name_embeddings,omitempty"`
+
+	// Distance is the metric the vector indexes are created with: cosine
+	// (default), l2 or ip. Changing it takes a reset and a new index.
+	Distance string `yaml:"distance,omitempty"`
+}
+
+// Prefixes resolves the query and document prefixes for this embedding
+// setup: the configured ones over the defaults of its provider and model.
+func (c EmbeddingConfig) Prefixes() embedprefix.Prefixes {
+	prefixes := embedprefix.Defaults(mapEmbeddingProvider(c.Provider), c.Model)
+	if c.QueryPrefix != nil {
+		prefixes.Query = *c.QueryPrefix
+	}
+	if c.DocumentPrefix != nil {
+		prefixes.Document = *c.DocumentPrefix
+	}
+	return prefixes
+}
+
+// LLMConfig configures the optional LLM used to write narrative summaries.
+type LLMConfig struct {
+	Enabled   bool   `yaml:"enabled,omitempty"`
+	BaseURL   string `yaml:"base_url,omitempty"` // OpenAI-compatible, Ollama or Anthropic endpoint
+	Model     string `yaml:"model,omitempty"`
+	APIKey    string `yaml:"api_key,omitempty"`
+	MaxTokens int    `yaml:"max_tokens,omitempty"` // default 2000
 }
 
 // IndexingConfig contains indexing settings.
@@ -65,6 +134,176 @@ type IndexingConfig struct {
 	BatchTarget int      `yaml:"batch_target"`  // mutations per batch
 	MaxFileSize int64    `yaml:"max_file_size"` // bytes
 	Exclude     []string `yaml:"exclude"`       // glob patterns
+
+	// CompressCode stores function code_text zstd-compressed to shrink the index.
+	// Grep-style tools then match text client-side instead of inside CozoDB.
+	CompressCode bool `yaml:"compress_code,omitempty"`
+
+	// StoreFileText keeps the full text of every file so searches can reach
+	// code outside functions and non-code files.
+	StoreFileText bool `yaml:"store_file_text,omitempty"`
+
+	// ContentHash selects what file hashes ignore: line_endings (default),
+	// whitespace, or exact.
+	ContentHash string `yaml:"content_hash,omitempty"`
+
+	// Languages overrides the size limits and adds excludes per language,
+	// keyed by language name (go, python, javascript, ...).
+	Languages map[string]LanguageIndexingConfig `yaml:"languages,omitempty"`
+
+	// AnalysisScripts are Starlark scripts, relative to the project root,
+	// run against the index after each index run.
+	AnalysisScripts []string `yaml:"analysis_scripts,omitempty"`
+}
+
+// LanguageIndexingConfig holds the indexing overrides for one language.
+type LanguageIndexingConfig struct {
+	MaxFileSize int64    `yaml:"max_file_size,omitempty"` // bytes; 0 keeps indexing.max_file_size
+	MaxCodeText int64    `yaml:"max_code_text,omitempty"` // bytes of function code kept; 0 keeps the default
+	Exclude     []string `yaml:"exclude,omitempty"`       // glob patterns, added to indexing.exclude
+}
+
+// StorageConfig contains settings for the local CozoDB database.
+type StorageConfig struct {
+	Engine string `yaml:"engine,omitempty"` // rocksdb (default), sqlite
+
+	// Sharded keeps each top-level directory of the repository in its own
+	// store, for monorepos too large to scan as one.
+	Sharded bool `yaml:"sharded,omitempty"`
+
+	// Snapshots keeps a copy of the index for each of the last N indexed
+	// commits, for `cie query --at` and mcp.at (default: 0, off).
+	Snapshots int `yaml:"snapshots,omitempty"`
+}
+
+// StorageEngine returns the configured CozoDB engine, defaulting to rocksdb.
+func (c *Config) StorageEngine() string {
+	if c.Storage.Engine == "" {
+		return "rocksdb"
+	}
+	return c.Storage.Engine
+}
+
+// MCPConfig contains settings for the MCP server.
+type MCPConfig struct {
+	RawQuery RawQueryConfig `yaml:"raw_query,omitempty"`
+
+	// DisableAudit turns off the tool invocation log read by `cie audit`.
+	DisableAudit bool `yaml:"disable_audit,omitempty"`
+
+	RateLimits RateLimitConfig `yaml:"rate_limits,omitempty"`
+	Cache      CacheConfig     `yaml:"cache,omitempty"`
+
+	// Warmup preloads relations and HNSW indexes in the background on start.
+	Warmup bool `yaml:"warmup,omitempty"`
+
+	// DisableFreshness omits the index staleness indicator from tool results.
+	DisableFreshness bool `yaml:"disable_freshness,omitempty"`
+
+	// LiveParse answers cie_get_function_code and cie_grep from files that
+	// are not indexed yet, and queues them for indexing.
+	LiveParse bool `yaml:"live_parse,omitempty"`
+
+	// Overlay extends LiveParse to files with uncommitted edits, whose
+	// working-tree version then shadows the indexed one.
+	Overlay bool `yaml:"overlay,omitempty"`
+
+	// IndexWatch polls for index rebuilds made by other processes.
+	IndexWatch IndexWatchConfig `yaml:"index_watch,omitempty"`
+
+	// At serves the tools from the index snapshot taken at this commit
+	// (full or abbreviated SHA) instead of the live index. Needs
+	// storage.snapshots. Overridden by CIE_INDEX_AT.
+	At string `yaml:"at,omitempty"`
+}
+
+// CacheConfig controls the per-session result cache for expensive tools.
+type CacheConfig struct {
+	Disabled   bool `yaml:"disabled,omitempty"`
+	MaxEntries int  `yaml:"max_entries,omitempty"` // default 256
+	TTLSeconds int  `yaml:"ttl_seconds,omitempty"` // default 600
+}
+
+// RateLimitConfig bounds how fast an agent may call MCP tools.
+type RateLimitConfig struct {
+	// SessionPerMinute caps calls across all tools (0 = unlimited).
+	SessionPerMinute int `yaml:"session_per_minute,omitempty"`
+	// Tools overrides the per-tool limits, keyed by tool name.
+	Tools map[string]ToolRateLimit `yaml:"tools,omitempty"`
+}
+
+// ToolRateLimit limits a single MCP tool. Zero values mean unlimited,
+// so an empty entry removes a built-in default.
+type ToolRateLimit struct {
+	PerMinute     int `yaml:"per_minute,omitempty"`
+	MaxConcurrent int `yaml:"max_concurrent,omitempty"`
+}
+
+// RawQueryConfig controls what cie_raw_query lets an AI agent run.
+// The tool is read-only unless AllowWrites is explicitly set.
+type RawQueryConfig struct {
+	AllowWrites      bool     `yaml:"allow_writes,omitempty"`      // permit :put/:rm/::remove etc.
+	AllowedRelations []string `yaml:"allowed_relations,omitempty"` // empty = all relations
+	MaxRows          int      `yaml:"max_rows,omitempty"`          // default 1000
+	TimeoutSeconds   int      `yaml:"timeout_seconds,omitempty"`   // default 30
+
+	// DisableAutoLimit stops appending :limit to queries that set none.
+	DisableAutoLimit bool `yaml:"disable_auto_limit,omitempty"`
+	// AllowUnboundedScans runs scripts that read every row of a source-text
+	// relation, with a warning, instead of refusing them.
+	AllowUnboundedScans bool `yaml:"allow_unbounded_scans,omitempty"`
+}
+
+// Policy converts the config into a tools.RawQueryPolicy, filling defaults.
+func (c RawQueryConfig) Policy() tools.RawQueryPolicy {
+	policy := tools.DefaultRawQueryPolicy()
+	policy.AllowWrites = c.AllowWrites
+	policy.AllowedRelations = c.AllowedRelations
+	policy.AutoLimit = !c.DisableAutoLimit
+	policy.RejectUnboundedScans = !c.AllowUnboundedScans
+	if c.MaxRows > 0 {
+		policy.MaxRows = c.MaxRows
+	}
+	if c.TimeoutSeconds > 0 {
+		policy.Timeout = time.Duration(c.TimeoutSeconds) * time.Second
+	}
+	return policy
+}
+
+// SearchConfig tunes how search results are ordered.
+type SearchConfig struct {
+	Ranking RankingConfig `yaml:"ranking,omitempty"`
+}
+
+// RankingConfig weighs the signals that order cie_semantic_search results.
+// Boosts are added to the weighted similarity (0-1); all default to 0, which
+// ranks by similarity alone.
+type RankingConfig struct {
+	Similarity   float64            `yaml:"similarity,omitempty"`    // weight of vector similarity (default 1)
+	NameMatch    float64            `yaml:"name_match,omitempty"`    // added when all query terms are in the name
+	PathPrefixes map[string]float64 `yaml:"path_prefixes,omitempty"` // boost by path prefix; longest match wins
+	Recency      float64            `yaml:"recency,omitempty"`       // added for a file changed today in git
+	RecencyDays  int                `yaml:"recency_days,omitempty"`  // window of the recency boost (default 90)
+	Centrality   float64            `yaml:"centrality,omitempty"`    // added for the most-called candidate
+}
+
+// Weights converts the config into tools.RankingWeights, filling defaults.
+func (c RankingConfig) Weights() tools.RankingWeights {
+	w := tools.DefaultRankingWeights()
+	if c.Similarity != 0 {
+		w.Similarity = c.Similarity
+	}
+	w.NameMatch = c.NameMatch
+	w.Recency = c.Recency
+	if c.RecencyDays > 0 {
+		w.RecencyWindow = time.Duration(c.RecencyDays) * 24 * time.Hour
+	}
+	w.Centrality = c.Centrality
+	for prefix, boost := range c.PathPrefixes {
+		w.PathBoosts = append(w.PathBoosts, tools.PathBoost{Prefix: strings.TrimPrefix(prefix, "./"), Boost: boost})
+	}
+	sort.Slice(w.PathBoosts, func(i, j int) bool { return w.PathBoosts[i].Prefix < w.PathBoosts[j].Prefix })
+	return w
 }
 
 // RolesConfig contains custom role pattern definitions.
@@ -82,10 +321,26 @@ type RolePattern struct {
 	NamePattern string `yaml:"name_pattern,omitempty"`
 	// CodePattern is a regex to match code content (e.g., "\\.GET\\(")
 	CodePattern string `yaml:"code_pattern,omitempty"`
+	// Paths are globs matching file paths (e.g., "internal/experimental/**")
+	Paths []string `yaml:"paths,omitempty"`
+	// ExcludeFromSource hides the role's functions from the default "source" role
+	ExcludeFromSource bool `yaml:"exclude_from_source,omitempty"`
 	// Description explains what this role represents
 	Description string `yaml:"description,omitempty"`
 }
 
+// rolePatterns converts custom roles for the tools package.
+func rolePatterns(custom map[string]RolePattern) map[string]tools.RolePattern {
+	if len(custom) == 0 {
+		return nil
+	}
+	patterns := make(map[string]tools.RolePattern, len(custom))
+	for name, p := range custom {
+		patterns[name] = tools.RolePattern(p)
+	}
+	return patterns
+}
+
 // DefaultConfig returns a config with sensible defaults for local development.
 //
 // The default configuration uses localhost URLs for both Primary Hub and Edge Cache,
@@ -140,7 +395,11 @@ func DefaultConfig(projectID string) *Config {
 // and parent directories. The CIE_CONFIG_PATH environment variable can override the
 // search path.
 //
-// After loading, environment variables are applied to override file-based configuration.
+// Settings are layered, later layers overriding earlier ones:
+//  1. Global defaults from ~/.cie/config.yaml (or CIE_GLOBAL_CONFIG), if present
+//  2. The project file
+//  3. Environment variables (see applyEnvOverrides)
+//  4. Global --set key=value flags
 //
 // Parameters:
 //   - configPath: Path to config file (empty string to auto-detect)
@@ -161,40 +420,8 @@ func LoadConfig(configPath string) (*Config, error) {
 		}
 	}
 
-	data, err := os.ReadFile(configPath) //nolint:gosec // G304: Path comes from user config or discovery
-	if err != nil {
-		return nil, errors.NewConfigError(
-			"Cannot read configuration file",
-			fmt.Sprintf("Failed to read %s", configPath),
-			"Check file permissions and ensure the file exists",
-			err,
-		)
-	}
-
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, errors.NewConfigError(
-			"Invalid configuration format",
-			"YAML parsing failed - the config file contains syntax errors",
-			fmt.Sprintf("Edit %s to fix syntax errors, or run 'cie init --force' to recreate", configPath),
-			err,
-		)
-	}
-
-	// Validate version
-	if cfg.Version != configVersion {
-		return nil, errors.NewConfigError(
-			"Unsupported configuration version",
-			fmt.Sprintf("Config version '%s' is not supported (expected '%s')", cfg.Version, configVersion),
-			"Run 'cie init --force' to regenerate the configuration file",
-			nil,
-		)
-	}
-
-	// Override with environment variables if set
-	cfg.applyEnvOverrides()
-
-	return &cfg, nil
+	cfg, _, err := loadLayeredConfig(configPath, configOverrides)
+	return cfg, err
 }
 
 // SaveConfig writes the configuration to the specified path as YAML.
@@ -269,9 +496,10 @@ func ConfigDir(dir string) string {
 //
 // The search algorithm:
 //  1. If CIE_CONFIG_PATH is set, use that path directly
-//  2. Otherwise, start from current directory and walk up to filesystem root
-//  3. In each directory, check for .cie/project.yaml
-//  4. Return the first match found
+//  2. Otherwise, walk up from the current directory to the git repository root
+//     (or the filesystem root outside a repository), see discoverConfig
+//  3. In each directory, check for .cie/project.yaml, then .cie/workspace.yaml
+//  4. For a workspace, return the config of its first (default) project
 //
 // Returns the absolute path to the config file, or an error if not found.
 func findConfigFile() (string, error) {
@@ -298,26 +526,19 @@ func findConfigFile() (string, error) {
 		)
 	}
 
-	for {
-		configPath := ConfigPath(dir)
-		if _, err := os.Stat(configPath); err == nil {
-			return configPath, nil
-		}
-
-		parent := filepath.Dir(dir)
-		if parent == dir {
-			// Reached root
-			break
-		}
-		dir = parent
+	configPath, workspacePath, err := discoverConfig(dir)
+	if err != nil || configPath != "" {
+		return configPath, err
 	}
-
-	return "", errors.NewConfigError(
-		"Configuration not found",
-		"No .cie/project.yaml file found in current directory or any parent directory",
-		"Run 'cie init' to create a new configuration",
-		nil,
-	)
+	ws, err := LoadWorkspace(workspacePath)
+	if err != nil {
+		return "", err
+	}
+	paths, err := ws.ProjectConfigPaths()
+	if err != nil {
+		return "", err
+	}
+	return paths[0], nil
 }
 
 // applyEnvOverrides applies environment variable overrides to the configuration.
@@ -331,6 +552,8 @@ func findConfigFile() (string, error) {
 //   - CIE_BASE_URL: Override Edge Cache HTTP URL
 //   - OLLAMA_HOST: Override Ollama base URL
 //   - OLLAMA_EMBED_MODEL: Override embedding model
+//   - CIE_LLM_URL: Enable the narrative LLM and set its base URL
+//   - CIE_LLM_MODEL, CIE_LLM_API_KEY: Override LLM model and API key
 func (c *Config) applyEnvOverrides() {
 	if url := os.Getenv("CIE_BASE_URL"); url != "" {
 		c.CIE.EdgeCache = url
@@ -347,6 +570,25 @@ func (c *Config) applyEnvOverrides() {
 	if model := os.Getenv("OLLAMA_EMBED_MODEL"); model != "" {
 		c.Embedding.Model = model
 	}
+	if os.Getenv("CIE_MCP_ALLOW_WRITES") == "true" {
+		c.MCP.RawQuery.AllowWrites = true
+	}
+	if at := os.Getenv("CIE_INDEX_AT"); at != "" {
+		c.MCP.At = at
+	}
+	if os.Getenv("CIE_MCP_WARMUP") == "true" {
+		c.MCP.Warmup = true
+	}
+	if url := os.Getenv("CIE_LLM_URL"); url != "" {
+		c.LLM.Enabled = true
+		c.LLM.BaseURL = url
+	}
+	if model := os.Getenv("CIE_LLM_MODEL"); model != "" {
+		c.LLM.Model = model
+	}
+	if key := os.Getenv("CIE_LLM_API_KEY"); key != "" {
+		c.LLM.APIKey = key
+	}
 }
 
 // getCIEDir returns the path to ~/.cie directory, creating it if needed.
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	flag "github.com/spf13/pflag"
 
@@ -63,6 +64,21 @@ type IndexingOutput struct {
 	BatchTarget int      `json:"batch_target"`
 	MaxFileSize int64    `json:"max_file_size"`
 	Exclude     []string `json:"exclude"`
+
+	CompressCode  bool   `json:"compress_code"`
+	StoreFileText bool   `json:"store_file_text"`
+	ContentHash   string `json:"content_hash,omitempty"`
+
+	Languages map[string]LanguageIndexingOutput `json:"languages,omitempty"`
+
+	AnalysisScripts []string `json:"analysis_scripts,omitempty"`
+}
+
+// LanguageIndexingOutput represents per-language indexing overrides for JSON output.
+type LanguageIndexingOutput struct {
+	MaxFileSize int64    `json:"max_file_size,omitempty"`
+	MaxCodeText int64    `json:"max_code_text,omitempty"`
+	Exclude     []string `json:"exclude,omitempty"`
 }
 
 // RolesConfigOutput represents custom role patterns for JSON output.
@@ -72,10 +88,12 @@ type RolesConfigOutput struct {
 
 // RolePatternOutput represents a role pattern for JSON output.
 type RolePatternOutput struct {
-	FilePattern string `json:"file_pattern,omitempty"`
-	NamePattern string `json:"name_pattern,omitempty"`
-	CodePattern string `json:"code_pattern,omitempty"`
-	Description string `json:"description,omitempty"`
+	FilePattern       string   `json:"file_pattern,omitempty"`
+	NamePattern       string   `json:"name_pattern,omitempty"`
+	CodePattern       string   `json:"code_pattern,omitempty"`
+	Paths             []string `json:"paths,omitempty"`
+	ExcludeFromSource bool     `json:"exclude_from_source,omitempty"`
+	Description       string   `json:"description,omitempty"`
 }
 
 // runConfig executes the 'config' CLI command, displaying current configuration.
@@ -92,10 +110,16 @@ type RolePatternOutput struct {
 //	cie config           Display formatted configuration
 //	cie config --json    Output as JSON for programmatic use
 func runConfig(args []string, configPath string, globals GlobalFlags) {
+	if len(args) > 0 && args[0] == "show" {
+		runConfigShow(args[1:], configPath, globals)
+		return
+	}
+
 	fs := flag.NewFlagSet("config", flag.ExitOnError)
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: cie config [options]
+       cie config show [--effective]
 
 Description:
   Display the current CIE configuration including project settings,
@@ -106,6 +130,9 @@ Description:
 
   Note: API keys are never displayed for security reasons.
 
+  'cie config show' prints the project file as YAML; add --effective to
+  print the merged configuration with the layer that set each key.
+
 Options:
 `)
 		fs.PrintDefaults()
@@ -181,50 +208,120 @@ Output Fields:
 	}
 }
 
-// findConfigPath finds the configuration file path without loading it.
-func findConfigPath() (string, error) {
-	// Check for explicit config path from environment
-	if cfgPath := os.Getenv("CIE_CONFIG_PATH"); cfgPath != "" {
-		if _, err := os.Stat(cfgPath); err == nil {
-			return cfgPath, nil
+// runConfigShow executes 'cie config show', printing the project file or,
+// with --effective, the merged configuration annotated with each key's layer.
+func runConfigShow(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("config show", flag.ExitOnError)
+	effective := fs.Bool("effective", false, "Print the merged configuration and where each key came from")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: cie config show [--effective]
+
+Description:
+  Print configuration as YAML. API keys are masked.
+
+  Without --effective, prints the project file (.cie/project.yaml) alone.
+  With --effective, prints the configuration CIE actually uses, merged
+  from these layers (later wins):
+
+    global   ~/.cie/config.yaml (or $CIE_GLOBAL_CONFIG)
+    project  .cie/project.yaml
+    env      CIE_*, OLLAMA_* environment variables
+    flag     cie --set key=value
+
+  Each key is annotated with the layer that set it.
+
+Options:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Examples:
+  cie config show --effective
+  cie --set embedding.model=mxbai-embed-large config show --effective
+  cie config show --effective --json | jq '.sources'
+
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	cfgPath := configPath
+	if cfgPath == "" {
+		if env := os.Getenv("CIE_CONFIG_PATH"); env != "" {
+			cfgPath = env
+		} else {
+			var err error
+			if cfgPath, err = findConfigPath(); err != nil {
+				errors.FatalError(err, globals.JSON)
+			}
 		}
-		return "", errors.NewConfigError(
-			"Configuration file not found",
-			fmt.Sprintf("CIE_CONFIG_PATH is set to '%s' but the file does not exist", cfgPath),
-			"Fix the CIE_CONFIG_PATH environment variable or run 'cie init' to create a config",
-			nil,
-		)
 	}
 
-	dir, err := os.Getwd()
+	cfg := &Config{}
+	prov := &ConfigProvenance{Layers: []ConfigLayer{{Name: layerProject, Path: cfgPath, Loaded: true}}}
+	var err error
+	if *effective {
+		cfg, prov, err = loadLayeredConfig(cfgPath, configOverrides)
+	} else {
+		err = decodeProjectConfig(cfgPath, cfg)
+	}
 	if err != nil {
-		return "", errors.NewInternalError(
-			"Cannot access working directory",
-			"Failed to determine current directory path",
-			"Check system permissions and try again",
-			err,
-		)
+		errors.FatalError(err, globals.JSON)
 	}
 
-	for {
-		cfgPath := ConfigPath(dir)
-		if _, err := os.Stat(cfgPath); err == nil {
-			return cfgPath, nil
+	if globals.JSON {
+		result := map[string]any{
+			"layers": prov.Layers,
+			"values": redactedConfigValues(cfg),
+		}
+		if *effective {
+			result["sources"] = prov.Sources
 		}
+		if err := output.JSON(result); err != nil {
+			errors.FatalError(errors.NewInternalError(
+				"Cannot encode configuration as JSON",
+				"JSON encoding failed unexpectedly",
+				"This is a bug. Please report it",
+				err,
+			), globals.JSON)
+		}
+		return
+	}
 
-		parent := filepath.Dir(dir)
-		if parent == dir {
-			break
+	if *effective {
+		for _, layer := range prov.Layers {
+			status := "not found"
+			if layer.Loaded {
+				status = "loaded"
+			} else if layer.Path == "" {
+				status = "none set"
+			}
+			where := ""
+			if layer.Path != "" {
+				where = " " + layer.Path
+			}
+			fmt.Printf("# %-8s %s%s\n", layer.Name, status, where)
 		}
-		dir = parent
+		fmt.Println()
 	}
 
-	return "", errors.NewConfigError(
-		"Configuration not found",
-		"No .cie/project.yaml file found in current directory or any parent directory",
-		"Run 'cie init' to create a new configuration",
-		nil,
-	)
+	text, err := effectiveConfigYAML(cfg, prov)
+	if err != nil {
+		errors.FatalError(errors.NewInternalError(
+			"Cannot encode configuration",
+			"YAML marshaling failed unexpectedly",
+			"This is a bug. Please report it",
+			err,
+		), globals.JSON)
+	}
+	fmt.Print(text)
+}
+
+// findConfigPath finds the configuration file path without loading it.
+func findConfigPath() (string, error) {
+	return findConfigFile()
 }
 
 // buildConfigOutput converts a Config to ConfigOutput for JSON serialization.
@@ -247,9 +344,22 @@ func buildConfigOutput(configPath string, cfg *Config) *ConfigOutput {
 			BatchTarget: cfg.Indexing.BatchTarget,
 			MaxFileSize: cfg.Indexing.MaxFileSize,
 			Exclude:     cfg.Indexing.Exclude,
+
+			CompressCode:  cfg.Indexing.CompressCode,
+			StoreFileText: cfg.Indexing.StoreFileText,
+			ContentHash:   cfg.Indexing.ContentHash,
+
+			AnalysisScripts: cfg.Indexing.AnalysisScripts,
 		},
 	}
 
+	if len(cfg.Indexing.Languages) > 0 {
+		result.Indexing.Languages = make(map[string]LanguageIndexingOutput, len(cfg.Indexing.Languages))
+		for language, lc := range cfg.Indexing.Languages {
+			result.Indexing.Languages[language] = LanguageIndexingOutput(lc)
+		}
+	}
+
 	// Add roles if defined
 	if len(cfg.Roles.Custom) > 0 {
 		rolesOutput := &RolesConfigOutput{
@@ -298,12 +408,24 @@ func printConfigHuman(cfg *ConfigOutput) {
 	fmt.Printf("  Parser Mode:  %s\n", cfg.Indexing.ParserMode)
 	fmt.Printf("  Batch Target: %d\n", cfg.Indexing.BatchTarget)
 	fmt.Printf("  Max File:     %d bytes\n", cfg.Indexing.MaxFileSize)
+	fmt.Printf("  Compress:     %t\n", cfg.Indexing.CompressCode)
+	fmt.Printf("  File text:    %t\n", cfg.Indexing.StoreFileText)
+	if cfg.Indexing.ContentHash != "" {
+		fmt.Printf("  Content hash: %s\n", cfg.Indexing.ContentHash)
+	}
+	for _, script := range cfg.Indexing.AnalysisScripts {
+		fmt.Printf("  Script:       %s\n", script)
+	}
 	if len(cfg.Indexing.Exclude) > 0 {
 		fmt.Printf("  Exclude:      %d patterns\n", len(cfg.Indexing.Exclude))
 		for _, pattern := range cfg.Indexing.Exclude {
 			fmt.Printf("                - %s\n", ui.DimText(pattern))
 		}
 	}
+	for _, language := range sortedKeys(cfg.Indexing.Languages) {
+		lc := cfg.Indexing.Languages[language]
+		fmt.Printf("  %-13s max file %d, max code %d, %d excludes\n", language+":", lc.MaxFileSize, lc.MaxCodeText, len(lc.Exclude))
+	}
 
 	// Roles (if defined)
 	if cfg.Roles != nil && len(cfg.Roles.Custom) > 0 {
@@ -320,6 +442,12 @@ func printConfigHuman(cfg *ConfigOutput) {
 			if pattern.CodePattern != "" {
 				fmt.Printf("    code_pattern: %s\n", pattern.CodePattern)
 			}
+			if len(pattern.Paths) > 0 {
+				fmt.Printf("    paths:        %s\n", strings.Join(pattern.Paths, ", "))
+			}
+			if pattern.ExcludeFromSource {
+				fmt.Printf("    excluded from source results\n")
+			}
 			if pattern.Description != "" {
 				fmt.Printf("    description:  %s\n", ui.DimText(pattern.Description))
 			}
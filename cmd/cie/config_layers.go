@@ -0,0 +1,388 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// Configuration layers, lowest precedence first. Each layer only changes the
+// keys it sets; maps are merged and lists are replaced.
+const (
+	layerDefault = "default" // zero value, nothing set it
+	layerGlobal  = "global"  // ~/.cie/config.yaml
+	layerProject = "project" // .cie/project.yaml
+	layerProfile = "profile" // profiles.<name>, reported as "profile:<name>"
+	layerEnv     = "env"     // environment variables
+	layerFlag    = "flag"    // --set key=value
+)
+
+const globalConfigFile = "config.yaml"
+
+// configOverrides holds the global --set flags and selectedProfile the
+// global --profile flag. main fills them before any command runs, so every
+// LoadConfig call applies the same overrides.
+var (
+	configOverrides []string
+	selectedProfile string
+)
+
+// ConfigLayer describes one configuration source considered by LoadConfig.
+type ConfigLayer struct {
+	Name   string `json:"name"`
+	Path   string `json:"path,omitempty"`
+	Loaded bool   `json:"loaded"` // file was read, or variables/flags were set
+}
+
+// ConfigProvenance records which layer supplied each effective key.
+type ConfigProvenance struct {
+	Layers  []ConfigLayer     `json:"layers"`
+	Sources map[string]string `json:"sources"` // dotted key -> layer name
+}
+
+// GlobalConfigPath returns the path of the user-wide defaults file,
+// ~/.cie/config.yaml, or CIE_GLOBAL_CONFIG when set.
+func GlobalConfigPath() string {
+	if p := os.Getenv("CIE_GLOBAL_CONFIG"); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, defaultConfigDir, globalConfigFile)
+}
+
+// loadLayeredConfig builds the effective configuration from the global
+// defaults file, the project file, environment variables and --set
+// overrides, in that order, recording where each key came from.
+func loadLayeredConfig(projectPath string, overrides []string) (*Config, *ConfigProvenance, error) {
+	cfg := &Config{}
+	prov := &ConfigProvenance{Sources: make(map[string]string)}
+	before := flattenConfig(cfg)
+	record := func(layer string) bool {
+		changed := false
+		after := flattenConfig(cfg)
+		for key, value := range after {
+			if prev, ok := before[key]; !ok || prev != value {
+				prov.Sources[key] = layer
+				changed = true
+			}
+		}
+		before = after
+		return changed
+	}
+
+	global := ConfigLayer{Name: layerGlobal, Path: GlobalConfigPath()}
+	if global.Path != "" {
+		data, err := os.ReadFile(global.Path) //nolint:gosec // G304: user's own config file
+		switch {
+		case err == nil:
+			if err := yaml.Unmarshal(data, cfg); err != nil {
+				return nil, nil, errors.NewConfigError(
+					"Invalid global configuration",
+					fmt.Sprintf("YAML parsing failed for %s", global.Path),
+					"Fix the syntax errors or remove the file to use built-in defaults",
+					err,
+				)
+			}
+			global.Loaded = true
+			record(layerGlobal)
+		case !os.IsNotExist(err):
+			return nil, nil, errors.NewConfigError(
+				"Cannot read global configuration",
+				fmt.Sprintf("Failed to read %s", global.Path),
+				"Check file permissions",
+				err,
+			)
+		}
+	}
+	prov.Layers = append(prov.Layers, global)
+
+	if err := decodeProjectConfig(projectPath, cfg); err != nil {
+		return nil, nil, err
+	}
+	prov.Layers = append(prov.Layers, ConfigLayer{Name: layerProject, Path: projectPath, Loaded: true})
+	record(layerProject)
+
+	if name := activeProfileName(cfg); name != "" {
+		if err := cfg.ApplyProfile(name); err != nil {
+			return nil, nil, err
+		}
+		layer := layerProfile + ":" + name
+		record(layer)
+		prov.Layers = append(prov.Layers, ConfigLayer{Name: layer, Loaded: true})
+	}
+
+	cfg.applyEnvOverrides()
+	prov.Layers = append(prov.Layers, ConfigLayer{Name: layerEnv, Loaded: record(layerEnv)})
+
+	if err := applyConfigOverrides(cfg, overrides); err != nil {
+		return nil, nil, err
+	}
+	record(layerFlag)
+	prov.Layers = append(prov.Layers, ConfigLayer{Name: layerFlag, Loaded: len(overrides) > 0})
+
+	// Queries embedded with the configured model use its prefixes.
+	tools.SetEmbeddingPrefixes(cfg.Embedding.Model, cfg.Embedding.Prefixes())
+	return cfg, prov, nil
+}
+
+// decodeProjectConfig overlays the project file at path onto cfg and checks
+// its version. The version must come from the project file itself.
+func decodeProjectConfig(path string, cfg *Config) error {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: Path comes from user config or discovery
+	if err != nil {
+		return errors.NewConfigError(
+			"Cannot read configuration file",
+			fmt.Sprintf("Failed to read %s", path),
+			"Check file permissions and ensure the file exists",
+			err,
+		)
+	}
+	cfg.Version = ""
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return errors.NewConfigError(
+			"Invalid configuration format",
+			"YAML parsing failed - the config file contains syntax errors",
+			fmt.Sprintf("Edit %s to fix syntax errors, or run 'cie init --force' to recreate", path),
+			err,
+		)
+	}
+	if cfg.Version != configVersion {
+		return errors.NewConfigError(
+			"Unsupported configuration version",
+			fmt.Sprintf("Config version '%s' is not supported (expected '%s')", cfg.Version, configVersion),
+			"Run 'cie init --force' to regenerate the configuration file",
+			nil,
+		)
+	}
+	return nil
+}
+
+// activeProfileName picks the profile to apply: the --profile flag, then
+// CIE_PROFILE, then default_profile from the configuration.
+func activeProfileName(cfg *Config) string {
+	if selectedProfile != "" {
+		return selectedProfile
+	}
+	if name := os.Getenv("CIE_PROFILE"); name != "" {
+		return name
+	}
+	return cfg.DefaultProfile
+}
+
+// ApplyProfile overlays the named profile's embedding and LLM settings onto c.
+func (c *Config) ApplyProfile(name string) error {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		available := "none are defined"
+		if len(c.Profiles) > 0 {
+			available = "available: " + strings.Join(c.ProfileNames(), ", ")
+		}
+		return errors.NewConfigError(
+			fmt.Sprintf("Unknown profile %q", name),
+			fmt.Sprintf("No profiles.%s entry in the configuration (%s)", name, available),
+			"Define it under 'profiles:' in .cie/project.yaml or ~/.cie/config.yaml",
+			nil,
+		)
+	}
+	sections := []struct {
+		key  string
+		node *yaml.Node
+		out  any
+	}{
+		{"embedding", &profile.Embedding, &c.Embedding},
+		{"llm", &profile.LLM, &c.LLM},
+	}
+	for _, sec := range sections {
+		if sec.node.IsZero() {
+			continue
+		}
+		data, err := yaml.Marshal(sec.node)
+		if err == nil {
+			dec := yaml.NewDecoder(bytes.NewReader(data))
+			dec.KnownFields(true)
+			err = dec.Decode(sec.out)
+		}
+		if err != nil {
+			return errors.NewConfigError(
+				fmt.Sprintf("Invalid profile %q", name),
+				fmt.Sprintf("profiles.%s.%s: %v", name, sec.key, err),
+				"Profiles accept the same keys as the top-level embedding and llm sections",
+				err,
+			)
+		}
+	}
+	return nil
+}
+
+// ProfileNames returns the defined profile names in order.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyConfigOverrides applies "dotted.key=value" assignments to cfg. Values
+// are parsed as YAML, so numbers, booleans and [a, b] lists keep their types.
+// Unknown keys are rejected so typos do not pass silently.
+func applyConfigOverrides(cfg *Config, overrides []string) error {
+	for _, o := range overrides {
+		key, raw, ok := strings.Cut(o, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return errors.NewInputError(
+				fmt.Sprintf("Invalid --set value %q", o),
+				"Expected key=value, e.g. --set embedding.model=nomic-embed-text",
+				"Use the YAML key path from .cie/project.yaml, separated by dots",
+			)
+		}
+
+		var value any
+		if err := yaml.Unmarshal([]byte(raw), &value); err != nil || raw == "" {
+			value = raw
+		}
+		parts := strings.Split(key, ".")
+		doc := map[string]any{parts[len(parts)-1]: value}
+		for i := len(parts) - 2; i >= 0; i-- {
+			doc = map[string]any{parts[i]: doc}
+		}
+		data, err := yaml.Marshal(doc)
+		if err != nil {
+			return errors.NewInputError(fmt.Sprintf("Invalid --set value %q", o), err.Error(), "Quote the value or simplify it")
+		}
+
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil {
+			return errors.NewInputError(
+				fmt.Sprintf("Cannot apply --set %s", key),
+				err.Error(),
+				"Check the key against 'cie config show --effective' and the value's type",
+			)
+		}
+	}
+	return nil
+}
+
+// flattenConfig returns the non-empty leaves of cfg keyed by dotted YAML path.
+// Lists are treated as single values.
+func flattenConfig(cfg *Config) map[string]string {
+	out := make(map[string]string)
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return out
+	}
+	var tree map[string]any
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return out
+	}
+	var walk func(prefix string, v any)
+	walk = func(prefix string, v any) {
+		if m, ok := v.(map[string]any); ok {
+			for k, child := range m {
+				walk(joinKey(prefix, k), child)
+			}
+			return
+		}
+		if v == nil || v == "" {
+			return
+		}
+		leaf, _ := yaml.Marshal(v)
+		out[prefix] = strings.TrimSpace(string(leaf))
+	}
+	walk("", tree)
+	return out
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// effectiveConfigYAML renders cfg as YAML with each key annotated by the
+// layer that set it. Secrets are masked.
+func effectiveConfigYAML(cfg *Config, prov *ConfigProvenance) (string, error) {
+	var root yaml.Node
+	if err := root.Encode(cfg); err != nil {
+		return "", err
+	}
+	annotateConfigNode(&root, "", prov.Sources)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&root); err != nil {
+		return "", err
+	}
+	return buf.String(), enc.Close()
+}
+
+func annotateConfigNode(n *yaml.Node, prefix string, sources map[string]string) {
+	if n.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		path := joinKey(prefix, key.Value)
+		if isSecretKey(key.Value) && value.Kind == yaml.ScalarNode && value.Value != "" {
+			value.Value = "********"
+			value.Style = 0
+		}
+		if value.Kind == yaml.MappingNode {
+			annotateConfigNode(value, path, sources)
+			continue
+		}
+		if src, ok := sources[path]; ok {
+			key.LineComment = "# " + src
+		}
+	}
+}
+
+// redactedConfigValues returns the flattened effective config with secrets
+// masked, for JSON output.
+func redactedConfigValues(cfg *Config) map[string]string {
+	values := flattenConfig(cfg)
+	for key := range values {
+		if isSecretKey(key[strings.LastIndex(key, ".")+1:]) {
+			values[key] = "********"
+		}
+	}
+	return values
+}
+
+func isSecretKey(key string) bool {
+	return key == "api_key"
+}
@@ -0,0 +1,280 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/pkg/embedprefix"
+)
+
+// writeLayerFiles creates a project file and a global defaults file and
+// points CIE_GLOBAL_CONFIG at the latter. Env overrides are cleared.
+func writeLayerFiles(t *testing.T, global, project string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, key := range []string{"CIE_BASE_URL", "CIE_PRIMARY_HUB", "CIE_PROJECT_ID", "OLLAMA_HOST", "OLLAMA_EMBED_MODEL", "CIE_LLM_URL", "CIE_LLM_MODEL", "CIE_LLM_API_KEY", "CIE_MCP_ALLOW_WRITES", "CIE_MCP_WARMUP", "CIE_PROFILE"} {
+		t.Setenv(key, "")
+	}
+
+	globalPath := filepath.Join(dir, "global.yaml")
+	if global != "" {
+		if err := os.WriteFile(globalPath, []byte(global), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("CIE_GLOBAL_CONFIG", globalPath)
+
+	projectPath := filepath.Join(dir, "project.yaml")
+	if err := os.WriteFile(projectPath, []byte(project), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return projectPath
+}
+
+func TestLoadLayeredConfig_Precedence(t *testing.T) {
+	path := writeLayerFiles(t, `
+embedding:
+  model: global-model
+  dimensions: 1024
+  base_url: http://global:11434
+indexing:
+  exclude: ["global/**"]
+roles:
+  custom:
+    handler: {name_pattern: "Handler$"}
+`, `
+version: "1"
+project_id: demo
+embedding:
+  model: project-model
+  base_url: http://project:11434
+roles:
+  custom:
+    route: {file_pattern: "routes/"}
+`)
+	t.Setenv("OLLAMA_HOST", "http://env:11434")
+
+	cfg, prov, err := loadLayeredConfig(path, []string{"indexing.batch_target=250", "embedding.dimensions=512"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checks := []struct {
+		key, layer string
+		got, want  any
+	}{
+		{"embedding.model", layerProject, cfg.Embedding.Model, "project-model"},
+		{"embedding.base_url", layerEnv, cfg.Embedding.BaseURL, "http://env:11434"},
+		{"embedding.dimensions", layerFlag, cfg.Embedding.Dimensions, 512},
+		{"indexing.batch_target", layerFlag, cfg.Indexing.BatchTarget, 250},
+		{"indexing.exclude", layerGlobal, strings.Join(cfg.Indexing.Exclude, ","), "global/**"},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %v, want %v", c.key, c.got, c.want)
+		}
+		if src := prov.Sources[c.key]; src != c.layer {
+			t.Errorf("%s source = %q, want %q", c.key, src, c.layer)
+		}
+	}
+
+	// Maps merge across layers rather than replacing each other.
+	if len(cfg.Roles.Custom) != 2 {
+		t.Errorf("roles.custom = %v, want entries from both files", cfg.Roles.Custom)
+	}
+}
+
+func TestLoadLayeredConfig_VersionFromProjectOnly(t *testing.T) {
+	path := writeLayerFiles(t, `version: "1"`, `project_id: demo`)
+	if _, _, err := loadLayeredConfig(path, nil); err == nil {
+		t.Fatal("expected version error when only the global file sets version")
+	}
+}
+
+func TestLoadLayeredConfig_MissingGlobal(t *testing.T) {
+	path := writeLayerFiles(t, "", "version: \"1\"\nproject_id: demo\n")
+	cfg, prov, err := loadLayeredConfig(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ProjectID != "demo" {
+		t.Errorf("project_id = %q", cfg.ProjectID)
+	}
+	if prov.Layers[0].Name != layerGlobal || prov.Layers[0].Loaded {
+		t.Errorf("global layer = %+v, want not loaded", prov.Layers[0])
+	}
+}
+
+const profileProject = `
+version: "1"
+project_id: demo
+embedding:
+  provider: openai
+  model: text-embedding-3-small
+  base_url: https://api.openai.com/v1
+llm:
+  enabled: true
+  model: gpt-4o-mini
+profiles:
+  offline:
+    embedding:
+      provider: ollama
+      base_url: http://localhost:11434
+    llm:
+      enabled: false
+  local-llm:
+    llm:
+      model: llama3
+`
+
+func TestLoadLayeredConfig_Profile(t *testing.T) {
+	path := writeLayerFiles(t, "", profileProject)
+	t.Cleanup(func() { selectedProfile = "" })
+	selectedProfile = "offline"
+
+	cfg, prov, err := loadLayeredConfig(path, []string{"embedding.base_url=http://flag:11434"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Embedding.Provider != "ollama" || prov.Sources["embedding.provider"] != "profile:offline" {
+		t.Errorf("embedding.provider = %q from %q", cfg.Embedding.Provider, prov.Sources["embedding.provider"])
+	}
+	// Keys the profile does not set keep their base values.
+	if cfg.Embedding.Model != "text-embedding-3-small" {
+		t.Errorf("embedding.model = %q, want base value", cfg.Embedding.Model)
+	}
+	if cfg.LLM.Enabled {
+		t.Error("llm.enabled should be switched off by the profile")
+	}
+	// --set still wins over the profile.
+	if cfg.Embedding.BaseURL != "http://flag:11434" {
+		t.Errorf("embedding.base_url = %q, want flag value", cfg.Embedding.BaseURL)
+	}
+}
+
+func TestLoadLayeredConfig_ProfileSelection(t *testing.T) {
+	project := profileProject + "default_profile: local-llm\n"
+
+	path := writeLayerFiles(t, "", project)
+	cfg, _, err := loadLayeredConfig(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LLM.Model != "llama3" || !cfg.LLM.Enabled {
+		t.Errorf("default_profile not applied: %+v", cfg.LLM)
+	}
+
+	t.Setenv("CIE_PROFILE", "offline")
+	cfg, _, err = loadLayeredConfig(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LLM.Enabled || cfg.LLM.Model != "gpt-4o-mini" {
+		t.Errorf("CIE_PROFILE should take precedence over default_profile: %+v", cfg.LLM)
+	}
+
+	t.Setenv("CIE_PROFILE", "missing")
+	_, _, err = loadLayeredConfig(path, nil)
+	var uerr *errors.UserError
+	if !stderrors.As(err, &uerr) || !strings.Contains(uerr.Cause, "local-llm, offline") {
+		t.Errorf("expected unknown profile error listing names, got %v", err)
+	}
+}
+
+func TestEmbeddingConfig_Prefixes(t *testing.T) {
+	path := writeLayerFiles(t, "", `version: "1"
+project_id: demo
+embedding:
+  provider: ollama
+  model: nomic-embed-text
+profiles:
+  bge:
+    embedding:
+      provider: openai
+      model: bge-base-en-v1.5
+      query_prefix: "Represent this query: "
+      document_prefix: ""
+`)
+	cfg, _, err := loadLayeredConfig(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := embedprefix.Prefixes{Query: "search_query: ", Document: "search_document: "}
+	if got := cfg.Embedding.Prefixes(); got != want {
+		t.Errorf("default prefixes = %+v, want %+v", got, want)
+	}
+
+	if err := cfg.ApplyProfile("bge"); err != nil {
+		t.Fatal(err)
+	}
+	want = embedprefix.Prefixes{Query: "Represent this query: "}
+	if got := cfg.Embedding.Prefixes(); got != want {
+		t.Errorf("profile prefixes = %+v, want %+v", got, want)
+	}
+	if cfg.Embedding.DocumentPrefix == nil {
+		t.Error(`document_prefix: "" should be kept as an explicit empty prefix`)
+	}
+}
+
+func TestApplyConfigOverrides(t *testing.T) {
+	cfg := &Config{}
+	err := applyConfigOverrides(cfg, []string{
+		"mcp.warmup=true",
+		"indexing.exclude=[a/**, b/**]",
+		"embedding.model=nomic-embed-text:v1.5",
+		"project_id=123",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.MCP.Warmup || len(cfg.Indexing.Exclude) != 2 || cfg.Embedding.Model != "nomic-embed-text:v1.5" || cfg.ProjectID != "123" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	for _, bad := range []string{"indexing.unknown=1", "no-equals-sign", "indexing.batch_target=lots"} {
+		if err := applyConfigOverrides(&Config{}, []string{bad}); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestEffectiveConfigYAML_MasksSecretsAndAnnotates(t *testing.T) {
+	cfg := &Config{Version: "1", Embedding: EmbeddingConfig{Model: "m", APIKey: "sk-secret"}}
+	prov := &ConfigProvenance{Sources: map[string]string{"embedding.model": layerFlag}}
+
+	text, err := effectiveConfigYAML(cfg, prov)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(text, "sk-secret") {
+		t.Errorf("api key leaked:\n%s", text)
+	}
+	if !strings.Contains(text, "model: m # flag") {
+		t.Errorf("missing source annotation:\n%s", text)
+	}
+	if v := redactedConfigValues(cfg)["embedding.api_key"]; v != "********" {
+		t.Errorf("redacted api_key = %q", v)
+	}
+}
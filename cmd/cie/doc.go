@@ -53,10 +53,11 @@
 //
 //	init           Initialize a new CIE project (creates .cie/project.yaml)
 //	index          Index the current repository for code intelligence
+//	watch          Re-index files as they change, without git hooks
 //	status         Show project status (files, functions, types indexed)
 //	query          Execute CozoScript queries on the indexed codebase
 //	reset          Reset local project data (destructive operation)
-//	install-hook   Install git post-commit hook for automatic re-indexing
+//	install-hook   Install git hooks for automatic re-indexing
 //
 // Global flags:
 //
@@ -128,9 +129,10 @@
 //
 // # Git Integration
 //
-// The install-hook command adds a post-commit hook that automatically
-// triggers background re-indexing after each commit, keeping the index
-// up-to-date without manual intervention.
+// The install-hook command adds post-commit, post-merge and post-checkout
+// hooks that trigger background re-indexing after commits, pulls and branch
+// switches, keeping the index up-to-date without manual intervention. Large
+// changes are deferred to a single full index so hooks never slow git down.
 //
 // See cie --help for complete usage information.
 package main
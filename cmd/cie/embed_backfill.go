@@ -0,0 +1,319 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+	flag "github.com/spf13/pflag"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/internal/output"
+	"github.com/kraklabs/cie/internal/ui"
+	"github.com/kraklabs/cie/pkg/errcode"
+	"github.com/kraklabs/cie/pkg/ingestion"
+)
+
+// backfillLogName is the log file of a detached backfill, under .cie/.
+const backfillLogName = "embed-backfill.log"
+
+// EmbedBackfillOutput is the --json result of 'cie embed-backfill'.
+type EmbedBackfillOutput struct {
+	Functions  int   `json:"functions"`
+	Types      int   `json:"types"`
+	Errors     int   `json:"errors"`
+	CacheHits  int   `json:"cache_hits,omitempty"`
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// runEmbedBackfill executes the 'embed-backfill' CLI command, which adds
+// embeddings to an index built with 'cie index --skip-embeddings'.
+//
+// Only functions and types without an embedding are sent to the provider,
+// so the command is cheap to re-run and resumes an interrupted backfill.
+// It runs at low CPU priority and holds the project's index lock, so hook
+// triggered index runs queue behind it instead of racing it. With --detach
+// it re-executes itself in the background and logs to .cie/embed-backfill.log.
+func runEmbedBackfill(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("embed-backfill", flag.ExitOnError)
+	batchSize := fs.Int("batch-size", ingestion.DefaultBackfillBatchSize, "Entities embedded and written per batch")
+	embedWorkers := fs.Int("embed-workers", 8, "Number of parallel embedding workers")
+	detach := fs.Bool("detach", false, "Run in the background and log to .cie/"+backfillLogName)
+	dryRun := fs.Bool("dry-run", false, "Only report how many functions and types lack embeddings")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: cie embed-backfill [options]
+
+Description:
+  Generate the embeddings that 'cie index --skip-embeddings' left out.
+  Search, call graph and grep tools work on the structural index right
+  away; semantic search starts returning results as embeddings are
+  written, batch by batch.
+
+  Functions in recently changed files and functions with many callers
+  are embedded first, so semantic search covers the code under active
+  work long before a large backfill finishes.
+
+  Only entities without an embedding are processed, so the command can
+  be interrupted and re-run. Entities the provider fails to embed stay
+  pending for the next run.
+
+Options:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Examples:
+  cie index --skip-embeddings && cie embed-backfill --detach
+  cie embed-backfill --dry-run
+  cie embed-backfill --embed-workers 16
+
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+
+	if *detach {
+		startDetachedBackfill(args, configPath, globals)
+		return
+	}
+
+	queue, err := NewIndexQueue(cfg.ProjectID)
+	if err != nil {
+		errors.FatalError(errors.NewPermissionError(
+			"Cannot access the index lock",
+			err.Error(),
+			"Check permissions on ~/.cie/",
+			err,
+		), globals.JSON)
+	}
+	if !*dryRun {
+		acquired, err := queue.TryAcquireLock()
+		if err != nil || !acquired {
+			errors.FatalError(errors.NewInputError(
+				"An index run is in progress",
+				"The project's index lock is held by another process",
+				"Run 'cie embed-backfill' again when it finishes",
+			).WithCode(errcode.DBLocked), globals.JSON)
+		}
+		defer queue.ReleaseLock()
+		// Leave the CPU to the developer; the backfill is not urgent.
+		_ = syscall.Setpriority(syscall.PRIO_PROCESS, 0, 10)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	pipeline := openBackfillPipeline(cfg, *embedWorkers, globals)
+	defer func() { _ = pipeline.Close() }()
+
+	if *dryRun {
+		fns, types, err := pipeline.PendingEmbeddings(ctx)
+		if err != nil {
+			errors.FatalError(errors.NewDatabaseError(
+				"Cannot count pending embeddings",
+				"Query against the local index failed",
+				"Run 'cie status' to check the index",
+				err,
+			), globals.JSON)
+		}
+		if globals.JSON {
+			_ = output.JSON(EmbedBackfillOutput{Functions: fns, Types: types})
+			return
+		}
+		ui.Infof("%d functions and %d types have no embedding", fns, types)
+		return
+	}
+
+	progressCfg := NewProgressConfig(globals)
+	var bar *progressbar.ProgressBar
+	pipeline.SetProgressCallback(func(current, total int64, phase string) {
+		if phase != "backfill" {
+			return // per-batch embedding progress would restart the bar
+		}
+		if bar == nil {
+			bar = NewProgressBar(progressCfg, total, phaseDescription(phase))
+		}
+		if bar != nil {
+			_ = bar.Set64(current)
+		}
+	})
+
+	res, err := pipeline.BackfillEmbeddings(ctx, *batchSize)
+	if bar != nil {
+		_ = bar.Finish()
+	}
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Embedding backfill failed",
+			"Batches written before the failure are kept",
+			"Check the embedding provider, then run 'cie embed-backfill' again to resume",
+			err,
+		), globals.JSON)
+	}
+
+	if globals.JSON {
+		_ = output.JSON(EmbedBackfillOutput{
+			Functions:  res.Functions,
+			Types:      res.Types,
+			Errors:     res.Errors,
+			CacheHits:  res.CacheHits,
+			DurationMs: res.Duration.Milliseconds(),
+		})
+		return
+	}
+	fmt.Println()
+	if res.Functions+res.Types+res.Errors == 0 {
+		ui.Success("All functions and types already have embeddings")
+		return
+	}
+	ui.Successf("Embedded %d functions and %d types in %s", res.Functions, res.Types, res.Duration.Round(time.Millisecond))
+	if res.CacheHits > 0 {
+		ui.Infof("%d embeddings were reused from the shared cache", res.CacheHits)
+	}
+	if res.Errors > 0 {
+		ui.Warningf("%d entities failed to embed; run 'cie embed-backfill' again to retry them", res.Errors)
+	}
+}
+
+// openBackfillPipeline opens the local pipeline with the configured
+// embedding provider, for backfilling only.
+// The repository path lets the backfill put recently changed files first.
+func openBackfillPipeline(cfg *Config, embedWorkers int, globals GlobalFlags) *ingestion.LocalPipeline {
+	provider := mapEmbeddingProvider(cfg.Embedding.Provider)
+	setEmbeddingEnv(cfg, provider)
+
+	logLevel := slog.LevelWarn
+	if globals.Verbose > 0 {
+		logLevel = slog.LevelInfo
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}))
+
+	repoPath, err := os.Getwd()
+	if err != nil {
+		errors.FatalError(errors.NewInternalError(
+			"Cannot access current directory",
+			"Failed to determine working directory",
+			"Run the command from inside the repository",
+			err,
+		), globals.JSON)
+	}
+	pipeline, err := ingestion.NewLocalPipeline(ingestion.Config{
+		ProjectID:  cfg.ProjectID,
+		RepoSource: ingestion.RepoSource{Type: "local_path", Value: repoPath},
+		IngestionConfig: ingestion.IngestionConfig{
+			EmbeddingProvider:       provider,
+			EmbeddingDimensions:     cfg.Embedding.Dimensions,
+			EmbeddingCachePath:      embeddingCachePath(cfg, provider),
+			EmbeddingModelID:        embeddingModelID(cfg, provider),
+			EmbeddingDocumentPrefix: cfg.Embedding.DocumentPrefix,
+			NameEmbeddings:          cfg.Embedding.NameEmbeddings,
+			HNSWDistance:            cfg.Embedding.Distance,
+			LocalEngine:             cfg.StorageEngine(),
+			LocalSharded:            cfg.Storage.Sharded,
+			Concurrency: ingestion.ConcurrencyConfig{
+				EmbedWorkers: embedWorkers,
+			},
+		},
+	}, logger)
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Cannot open the local index",
+			"Failed to open or initialize the database",
+			"Run 'cie index --skip-embeddings' first, or close other CIE instances",
+			err,
+		), globals.JSON)
+	}
+	return pipeline
+}
+
+// startDetachedBackfill re-runs this command without --detach as a
+// background process whose output goes to .cie/embed-backfill.log.
+func startDetachedBackfill(args []string, configPath string, globals GlobalFlags) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		errors.FatalError(errors.NewInternalError(
+			"Cannot access current directory",
+			"Failed to determine working directory",
+			"This is unexpected. Please report this issue at github.com/kraklabs/kraken/issues",
+			err,
+		), globals.JSON)
+	}
+	logPath := filepath.Join(ConfigDir(cwd), backfillLogName)
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600) //nolint:gosec // G304: path under .cie/
+	if err != nil {
+		errors.FatalError(errors.NewPermissionError(
+			"Cannot open the backfill log",
+			fmt.Sprintf("Failed to open %s", logPath),
+			"Check permissions on .cie/",
+			err,
+		), globals.JSON)
+	}
+	defer func() { _ = logFile.Close() }()
+
+	self, err := os.Executable()
+	if err != nil {
+		self = "cie"
+	}
+	var childArgs []string
+	if configPath != "" {
+		childArgs = append(childArgs, "--config", configPath)
+	}
+	childArgs = append(childArgs, "--quiet", "embed-backfill")
+	for _, a := range args {
+		if a != "--detach" && a != "--detach=true" {
+			childArgs = append(childArgs, a)
+		}
+	}
+	cmd := exec.Command(self, childArgs...) //nolint:gosec // G204: re-executes this binary
+	cmd.Stdout, cmd.Stderr = logFile, logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		errors.FatalError(errors.NewInternalError(
+			"Cannot start the background backfill",
+			err.Error(),
+			"Run 'cie embed-backfill' in the foreground instead",
+			err,
+		), globals.JSON)
+	}
+	pid := cmd.Process.Pid
+	_ = cmd.Process.Release()
+
+	if globals.JSON {
+		_ = output.JSON(map[string]any{"pid": pid, "log": logPath})
+		return
+	}
+	ui.Successf("Embedding backfill started in the background (pid %d)", pid)
+	ui.Infof("Progress is logged to %s; 'cie status' shows embeddings as they land", logPath)
+}
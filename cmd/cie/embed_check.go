@@ -0,0 +1,195 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/internal/output"
+	"github.com/kraklabs/cie/pkg/ingestion"
+)
+
+// embedCheckShown is the number of lowest-similarity functions listed by
+// 'cie embed-check' without --json.
+const embedCheckShown = 5
+
+// runEmbedCheck executes the 'embed-check' CLI command: re-embed a sample
+// of indexed functions with the configured provider and compare the result
+// with the stored vectors.
+//
+// The provider is used without the shared embedding cache, which would hand
+// back the stored vectors and hide the drift the command looks for.
+func runEmbedCheck(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("embed-check", flag.ExitOnError)
+	sample := fs.Int("sample", ingestion.DefaultDriftSample, "Number of functions to re-embed")
+	threshold := fs.Float64("threshold", ingestion.DefaultDriftThreshold, "Cosine similarity below which a function counts as drifted")
+	seed := fs.Int64("seed", 0, "Seed selecting the sample; the same seed re-checks the same functions")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: cie embed-check [options]
+
+Description:
+  Detect embedding drift: re-embed a random sample of indexed functions
+  with the provider configured in .cie/project.yaml and compare the new
+  vectors with the stored ones.
+
+  Queries are embedded by the current provider and searched against the
+  stored vectors, so a model upgrade, a changed dimension or a changed
+  document prefix degrades semantic search without any error. The same
+  model reproduces its vectors almost exactly; similarities below the
+  threshold mean the index should be rebuilt with 'cie index --full'.
+
+  The command exits 1 when drift is detected, so it can gate CI jobs.
+
+Options:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Examples:
+  cie embed-check
+  cie embed-check --sample 100 --threshold 0.99
+  cie embed-check || cie index --full
+
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+	if *sample <= 0 || *threshold <= 0 || *threshold > 1 {
+		errors.FatalError(errors.NewInputError(
+			"Invalid options",
+			"--sample must be positive and --threshold between 0 and 1",
+			"Run 'cie embed-check --help' for usage",
+		), globals.JSON)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+
+	providerName := mapEmbeddingProvider(cfg.Embedding.Provider)
+	setEmbeddingEnv(cfg, providerName)
+	logLevel := slog.LevelWarn
+	if globals.Verbose > 0 {
+		logLevel = slog.LevelInfo
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}))
+	provider, err := ingestion.CreateEmbeddingProvider(providerName, logger)
+	if err != nil {
+		errors.FatalError(errors.NewConfigError(
+			"Cannot create the embedding provider",
+			err.Error(),
+			"Check the embedding section of .cie/project.yaml",
+			err,
+		), globals.JSON)
+	}
+	// Re-embed documents with the configured prefix, as indexing does.
+	if prefixer, ok := provider.(ingestion.DocumentPrefixer); ok && cfg.Embedding.DocumentPrefix != nil {
+		prefixer.SetDocumentPrefix(*cfg.Embedding.DocumentPrefix)
+	}
+
+	backend, err := openProjectBackend(cfg)
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Cannot open the local index",
+			err.Error(),
+			"Run 'cie index' first, and stop 'cie serve' or 'cie --mcp' if the database is locked",
+			err,
+		), globals.JSON)
+	}
+	defer func() { _ = backend.Close() }()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	report, err := ingestion.CheckEmbeddingDrift(ctx, backend, provider, ingestion.DriftOptions{
+		Sample:    *sample,
+		Threshold: *threshold,
+		Seed:      *seed,
+	})
+	if err != nil {
+		errors.FatalError(errors.NewNetworkError(
+			"Embedding drift check failed",
+			err.Error(),
+			"Check that the embedding provider is running and reachable",
+			err,
+		), globals.JSON)
+	}
+
+	if !printEmbedCheck(os.Stdout, report, globals.JSON) {
+		os.Exit(1)
+	}
+}
+
+// printEmbedCheck reports a drift check and whether the stored embeddings
+// still match the provider.
+func printEmbedCheck(w io.Writer, r *ingestion.DriftReport, jsonOut bool) bool {
+	healthy := r.Verdict == ingestion.DriftOK || r.Verdict == ingestion.DriftNoEmbeddings
+	if jsonOut {
+		_ = output.JSONTo(w, r)
+		return healthy
+	}
+	switch r.Verdict {
+	case ingestion.DriftNoEmbeddings:
+		_, _ = fmt.Fprintln(w, "No function embeddings to check; run 'cie embed-backfill' or 'cie index'")
+		return true
+	case ingestion.DriftOK:
+		_, _ = fmt.Fprintf(w, "No drift: %d functions re-embedded, mean similarity %.4f (min %.4f)\n",
+			r.Checked, r.MeanSimilarity, r.MinSimilarity)
+	case ingestion.DriftDimensionMismatch:
+		for _, s := range r.Samples {
+			if s.Error == "" && s.StoredDim != s.CurrentDim {
+				_, _ = fmt.Fprintf(w, "Dimension mismatch: %d of %d functions were stored with %d dimensions, the provider returns %d\n",
+					r.DimMismatches, r.Checked, s.StoredDim, s.CurrentDim)
+				break
+			}
+		}
+	default:
+		_, _ = fmt.Fprintf(w, "Drift detected: %d of %d functions below %.2f, mean similarity %.4f (min %.4f)\n",
+			r.Drifted, r.Checked, r.Threshold, r.MeanSimilarity, r.MinSimilarity)
+	}
+	if r.Errors > 0 {
+		_, _ = fmt.Fprintf(w, "%d functions could not be re-embedded\n", r.Errors)
+	}
+	if healthy {
+		return true
+	}
+	_, _ = fmt.Fprintln(w, "Lowest similarities:")
+	for i, s := range r.Samples {
+		if i == embedCheckShown {
+			break
+		}
+		if s.Error == "" {
+			_, _ = fmt.Fprintf(w, "  %.4f  %s\n", s.Similarity, s.FunctionID)
+		}
+	}
+	_, _ = fmt.Fprintln(w, "Rebuild the embeddings with 'cie index --full'")
+	return false
+}
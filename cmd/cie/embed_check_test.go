@@ -0,0 +1,57 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/ingestion"
+)
+
+func TestPrintEmbedCheck(t *testing.T) {
+	var buf bytes.Buffer
+	ok := printEmbedCheck(&buf, &ingestion.DriftReport{Verdict: ingestion.DriftOK, Checked: 20, MeanSimilarity: 0.9999, MinSimilarity: 0.9998}, false)
+	if !ok || !strings.Contains(buf.String(), "No drift") {
+		t.Errorf("ok report: %v %q", ok, buf.String())
+	}
+
+	buf.Reset()
+	drift := &ingestion.DriftReport{
+		Verdict: ingestion.DriftDimensionMismatch, Checked: 2, DimMismatches: 2,
+		Samples: []ingestion.DriftSample{
+			{FunctionID: "fn:a", Error: "timeout", StoredDim: 768},
+			{FunctionID: "fn:b", StoredDim: 768, CurrentDim: 1024},
+		},
+		Errors: 1,
+	}
+	if printEmbedCheck(&buf, drift, false) {
+		t.Error("a dimension mismatch must fail the check")
+	}
+	out := buf.String()
+	if !strings.Contains(out, "stored with 768 dimensions, the provider returns 1024") || !strings.Contains(out, "cie index --full") {
+		t.Errorf("mismatch report:\n%s", out)
+	}
+
+	buf.Reset()
+	if printEmbedCheck(&buf, drift, true) || !strings.Contains(buf.String(), `"verdict": "dimension_mismatch"`) {
+		t.Errorf("json report:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/internal/output"
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// runEmbedNormalize executes the 'embed-normalize' CLI command: check that
+// every stored embedding is at unit length and rescale the ones that are
+// not.
+//
+// Embeddings are normalized when they are written, so only indexes built by
+// older versions, or filled in by a mix of providers, need it. No embedding
+// provider is called.
+func runEmbedNormalize(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("embed-normalize", flag.ExitOnError)
+	check := fs.Bool("check", false, "Only report vectors that are not at unit length; exit 1 if there are any")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: cie embed-normalize [options]
+
+Description:
+  Verify that the stored embeddings are L2-normalized (unit length) and
+  rescale the ones that are not. Their direction, and so their cosine
+  similarity to everything else, is unchanged.
+
+  Vectors of different lengths put similarity scores on different
+  scales, and break ranking with the l2 and ip metrics (see
+  embedding.distance). This happens when an index was built by an
+  older version of CIE or filled in by several providers.
+
+  All-zero vectors are reported but left alone; re-embed them with
+  'cie index --full'.
+
+Options:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Examples:
+  cie embed-normalize --check
+  cie embed-normalize
+
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+	backend, err := openProjectBackend(cfg)
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Cannot open the local index",
+			err.Error(),
+			"Run 'cie index' first, and stop 'cie serve' or 'cie --mcp' if the database is locked",
+			err,
+		), globals.JSON)
+	}
+	defer func() { _ = backend.Close() }()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	var report *storage.NormReport
+	if *check {
+		report, err = backend.CheckEmbeddingNorms(ctx)
+	} else {
+		report, err = backend.NormalizeEmbeddings(ctx)
+	}
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Cannot normalize the stored embeddings",
+			err.Error(),
+			"Check the error details above. If this persists, run 'cie repair'",
+			err,
+		), globals.JSON)
+	}
+
+	if !printEmbedNormalize(os.Stdout, report, *check, globals.JSON) {
+		os.Exit(1)
+	}
+}
+
+// printEmbedNormalize reports a norm check or repair, and whether the
+// stored embeddings are at unit length afterwards.
+func printEmbedNormalize(w io.Writer, r *storage.NormReport, check, jsonOut bool) bool {
+	healthy := r.Unnormalized == r.Normalized
+	if jsonOut {
+		_ = output.JSONTo(w, r)
+		return healthy
+	}
+	for _, rel := range r.Relations {
+		if rel.Vectors == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "%-28s %7d vectors, length %.4f-%.4f", rel.Relation, rel.Vectors, rel.MinNorm, rel.MaxNorm)
+		switch {
+		case rel.Normalized > 0:
+			_, _ = fmt.Fprintf(w, ", %d normalized", rel.Normalized)
+		case rel.Unnormalized > rel.Zero:
+			_, _ = fmt.Fprintf(w, ", %d not normalized", rel.Unnormalized-rel.Zero)
+		}
+		if rel.Zero > 0 {
+			_, _ = fmt.Fprintf(w, ", %d all zeros", rel.Zero)
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+	switch {
+	case r.Unnormalized == 0:
+		_, _ = fmt.Fprintln(w, "All stored embeddings are at unit length")
+	case check:
+		_, _ = fmt.Fprintf(w, "%d embeddings are not at unit length; run 'cie embed-normalize' to fix them\n", r.Unnormalized)
+	case !healthy:
+		_, _ = fmt.Fprintf(w, "%d all-zero embeddings cannot be normalized; re-embed them with 'cie index --full'\n", r.Unnormalized-r.Normalized)
+	}
+	return healthy
+}
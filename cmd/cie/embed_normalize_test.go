@@ -0,0 +1,56 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+func TestPrintEmbedNormalize(t *testing.T) {
+	var buf bytes.Buffer
+	clean := &storage.NormReport{Relations: []storage.RelationNorms{{Relation: "cie_function_embedding", Vectors: 10, MinNorm: 1, MaxNorm: 1}}}
+	if !printEmbedNormalize(&buf, clean, true, false) || !strings.Contains(buf.String(), "All stored embeddings are at unit length") {
+		t.Errorf("clean report:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	off := &storage.NormReport{
+		Relations:    []storage.RelationNorms{{Relation: "cie_function_embedding", Vectors: 10, Unnormalized: 3, Zero: 1, MinNorm: 0, MaxNorm: 12.5}},
+		Unnormalized: 3,
+	}
+	if printEmbedNormalize(&buf, off, true, false) {
+		t.Error("a check finding unnormalized vectors must fail")
+	}
+	if out := buf.String(); !strings.Contains(out, "2 not normalized, 1 all zeros") || !strings.Contains(out, "run 'cie embed-normalize'") {
+		t.Errorf("check report:\n%s", out)
+	}
+
+	buf.Reset()
+	off.Relations[0].Normalized, off.Normalized = 2, 2
+	if printEmbedNormalize(&buf, off, false, false) {
+		t.Error("zero vectors left behind must fail the repair")
+	}
+	if out := buf.String(); !strings.Contains(out, "2 normalized") || !strings.Contains(out, "cie index --full") {
+		t.Errorf("repair report:\n%s", out)
+	}
+}
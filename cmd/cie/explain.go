@@ -0,0 +1,129 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/internal/output"
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// ExplainOutput is the JSON shape of `cie explain --json`.
+type ExplainOutput struct {
+	Function string `json:"function"`
+	Markdown string `json:"markdown"`
+}
+
+// runExplain executes the 'explain' CLI command, printing a function's code,
+// its callers and callees, and an LLM-written summary.
+func runExplain(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	full := fs.Bool("full", false, "Print the whole function body, however long")
+	noLLM := fs.Bool("no-llm", false, "Do not ask the configured LLM for a summary")
+	maxRelated := fs.Int("max-related", 20, "Callers and callees listed each")
+	timeout := fs.Duration("timeout", 2*time.Minute, "Overall timeout (the LLM summary can be slow)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: cie explain [options] <function>
+
+Description:
+  Explain a function from the index: its location, signature and code,
+  the functions that call it and the functions it calls. When an LLM is
+  configured (llm.enabled), a short written summary comes first.
+
+  Names resolve like cie_get_function_code: Name, pkg.Name,
+  pkg.Type.Method or path/to/pkg.Name. An ambiguous name lists the
+  qualified names to choose from.
+
+Options:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Examples:
+  cie explain NewPipeline
+  cie explain ingestion.Pipeline.Run --full
+  cie explain HandleLogin --no-llm --max-related 50
+
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		errors.FatalError(errors.NewInputError(
+			"Function name required",
+			"No function to explain was given",
+			"Pass a function name: cie explain NewPipeline",
+		), globals.JSON)
+	}
+	name := strings.Join(fs.Args(), " ")
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+	backend := openLocalBackend(cfg, globals)
+	defer func() { _ = backend.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	explain := tools.ExplainArgs{
+		FunctionName: name,
+		FullCode:     *full,
+		MaxRelated:   *maxRelated,
+		MaxTokens:    llmMaxTokens(cfg.LLM),
+	}
+	if !*noLLM {
+		provider, err := newLLMProvider(cfg.LLM)
+		if err != nil {
+			errors.FatalError(llmConfigError(err), globals.JSON)
+		}
+		explain.LLM = provider
+	}
+
+	result, err := tools.ExplainFunction(ctx, tools.NewEmbeddedQuerier(backend), explain)
+	if err == nil && result.IsError {
+		err = fmt.Errorf("%s", result.Text)
+	}
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Cannot explain function",
+			fmt.Sprintf("Index query failed: %v", err),
+			"Run 'cie status' to check the index, or 'cie index' to rebuild it",
+			err,
+		), globals.JSON)
+	}
+
+	if globals.JSON {
+		_ = output.JSON(ExplainOutput{Function: name, Markdown: result.Text})
+		return
+	}
+	fmt.Println(result.Text)
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/internal/output"
+	"github.com/kraklabs/cie/pkg/storage"
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// setupFeedbackLog opens the search feedback log for an MCP session.
+// Failures are reported on stderr; cie_search_feedback then returns an
+// error instead of recording.
+func setupFeedbackLog(server *mcpServer, cfg *Config) {
+	path, err := storage.DefaultFeedbackLogPath(cfg.ProjectID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  Search feedback disabled: %v\n", err)
+		return
+	}
+	log, err := storage.OpenFeedbackLog(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  Search feedback disabled: %v\n", err)
+		return
+	}
+	server.feedback = log
+	if server.sessionID == "" {
+		server.sessionID = newSessionID()
+	}
+}
+
+func handleSearchFeedback(_ context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	query, _ := args["query"].(string)
+	result, _ := args["result"].(string)
+	tool, _ := args["tool"].(string)
+	useful, ok := args["useful"].(bool)
+	if !ok {
+		return tools.NewInputError("Error: 'useful' (true or false) is required"), nil
+	}
+	rank, _ := getIntArg(args, "rank", 0)
+	similarity, _ := getFloatArg(args, "similarity", 0)
+	return tools.RecordSearchFeedback(s.feedback, tools.SearchFeedbackArgs{
+		Tool:       tool,
+		Query:      query,
+		Result:     result,
+		Useful:     useful,
+		Rank:       rank,
+		Similarity: similarity,
+		SessionID:  s.sessionID,
+	})
+}
+
+// runFeedbackReport prints the search feedback recorded for cfg's project
+// in the last window (all of it when window is zero), for 'cie bench
+// --feedback'.
+func runFeedbackReport(cfg *Config, window time.Duration, globals GlobalFlags) {
+	path, err := storage.DefaultFeedbackLogPath(cfg.ProjectID)
+	if err != nil {
+		errors.FatalError(errors.NewInternalError("Cannot locate the feedback log", err.Error(), "", err), globals.JSON)
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if globals.JSON {
+			_ = output.JSON([]tools.FeedbackSummary{})
+			return
+		}
+		fmt.Print(tools.FormatFeedbackSummary(nil))
+		return
+	}
+	log, err := storage.OpenFeedbackLog(path)
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Cannot open the feedback log",
+			err.Error(),
+			"Check permissions on ~/.cie/feedback/",
+			err,
+		), globals.JSON)
+	}
+	defer func() { _ = log.Close() }()
+
+	var since time.Time
+	if window > 0 {
+		since = time.Now().Add(-window)
+	}
+	entries, err := log.Entries(since)
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError("Cannot read the feedback log", err.Error(), "", err), globals.JSON)
+	}
+	summaries := tools.SummarizeFeedback(entries)
+	if globals.JSON {
+		_ = output.JSON(summaries)
+		return
+	}
+	fmt.Print(tools.FormatFeedbackSummary(summaries))
+}
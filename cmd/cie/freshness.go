@@ -0,0 +1,96 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// freshnessTTL bounds how often the git commands behind the staleness
+// indicator run; a burst of tool calls shares one check.
+const freshnessTTL = 5 * time.Second
+
+// freshnessMetaKey is the _meta key carrying tools.Freshness on tool results.
+const freshnessMetaKey = "cie/freshness"
+
+// freshnessCache memoizes the last freshness check for freshnessTTL.
+type freshnessCache struct {
+	mu      sync.Mutex
+	checked time.Time
+	value   *tools.Freshness
+	now     func() time.Time
+}
+
+func newFreshnessCache() *freshnessCache {
+	return &freshnessCache{now: time.Now}
+}
+
+// reset forces the next call to check again, e.g. after a reindex.
+func (c *freshnessCache) reset() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checked = time.Time{}
+}
+
+// indexFreshness returns the current freshness of the index, or nil when it
+// cannot be determined (no git repository, or no indexed commit recorded).
+func (s *mcpServer) indexFreshness(ctx context.Context) *tools.Freshness {
+	if s.freshness == nil || s.gitExecutor == nil {
+		return nil
+	}
+	c := s.freshness
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.checked.IsZero() && c.now().Sub(c.checked) < freshnessTTL {
+		return c.value
+	}
+	f, err := tools.CheckFreshness(ctx, s.client, s.gitExecutor)
+	if err != nil {
+		f = nil
+	}
+	c.value, c.checked = f, c.now()
+	return f
+}
+
+// withFreshness returns result annotated with index freshness: the full
+// details in _meta, and a warning line ahead of the content when the index
+// is stale. result itself is not modified, since it may be cached.
+func (s *mcpServer) withFreshness(ctx context.Context, result *mcpToolResult) *mcpToolResult {
+	f := s.indexFreshness(ctx)
+	if f == nil {
+		return result
+	}
+	annotated := *result
+	annotated.Meta = make(map[string]any, len(result.Meta)+1)
+	for k, v := range result.Meta {
+		annotated.Meta[k] = v
+	}
+	annotated.Meta[freshnessMetaKey] = f
+	if header := f.Header(); header != "" && !result.IsError {
+		annotated.Content = append([]mcpContent{{Type: "text", Text: header + "\n\n"}}, result.Content...)
+	}
+	return &annotated
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// staticGit answers every git command with the same canned output per
+// subcommand.
+type staticGit map[string]string
+
+func (g staticGit) Run(_ context.Context, args ...string) (string, error) {
+	return g[args[0]], nil
+}
+
+func (g staticGit) RepoPath() string { return "/repo" }
+
+func TestWithFreshness(t *testing.T) {
+	q := &recordingQuerier{headers: []string{"value"}, rows: [][]any{{"1111111aaaa"}}}
+	s := &mcpServer{
+		client:      q,
+		gitExecutor: staticGit{"rev-parse": "2222222bbbb\n", "rev-list": "2\n", "diff": "a.go\n"},
+		freshness:   newFreshnessCache(),
+	}
+	ctx := context.Background()
+	original := &mcpToolResult{Content: []mcpContent{{Type: "text", Text: "answer"}}}
+
+	got := s.withFreshness(ctx, original)
+	f, ok := got.Meta[freshnessMetaKey].(*tools.Freshness)
+	if !ok || f.CommitsBehind != 2 || f.ChangedFiles != 1 {
+		t.Fatalf("meta = %+v", got.Meta)
+	}
+	if len(got.Content) != 2 || !strings.Contains(got.Content[0].Text, "Index may be stale") || got.Content[1].Text != "answer" {
+		t.Errorf("content = %+v", got.Content)
+	}
+	if len(original.Content) != 1 || original.Meta != nil {
+		t.Error("the original (possibly cached) result must not be modified")
+	}
+
+	failed := toolErrorResult("DB_LOCKED", "locked")
+	if got := s.withFreshness(ctx, failed); got.Meta[errorCodeMetaKey] == nil || got.Meta[freshnessMetaKey] == nil {
+		t.Errorf("meta = %+v, want both error code and freshness", got.Meta)
+	}
+
+	// A second call within the TTL reuses the check.
+	before := len(q.Scripts())
+	s.withFreshness(ctx, original)
+	if len(q.Scripts()) != before {
+		t.Error("freshness should be cached between calls")
+	}
+
+	s.freshness = nil
+	if got := s.withFreshness(ctx, original); got != original {
+		t.Error("disabled freshness should return the result unchanged")
+	}
+}
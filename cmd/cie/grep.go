@@ -0,0 +1,269 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// grepOptions are the parsed flags of `cie grep`.
+type grepOptions struct {
+	patterns      []string
+	path          string
+	exclude       string
+	context       int
+	limit         int
+	caseSensitive bool
+	regex         bool
+	scope         string
+	groupBy       string
+}
+
+// runGrep executes the 'grep' CLI command: a text search of the local index
+// that prints matching lines the way grep does.
+func runGrep(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("grep", flag.ExitOnError)
+	var opts grepOptions
+	fs.StringVarP(&opts.path, "path", "p", "", "Only search files whose path contains this text")
+	fs.StringVar(&opts.exclude, "exclude", "", "Skip files whose path matches this regex")
+	fs.IntVarP(&opts.context, "context", "C", 0, "Lines of context around each match")
+	fs.IntVar(&opts.limit, "limit", 30, "Maximum number of matching lines (groups with --group-by)")
+	fs.BoolVarP(&opts.caseSensitive, "case-sensitive", "s", false, "Match case (default: case-insensitive)")
+	fs.BoolVarP(&opts.regex, "regex", "E", false, "Treat the pattern as a regular expression")
+	fs.StringVar(&opts.scope, "scope", "", "Search function bodies (functions, default) or stored file text (files)")
+	fs.StringVar(&opts.groupBy, "group-by", "", "Print match counts per file, package or function")
+	timeout := fs.Duration("timeout", 30*time.Second, "Search timeout")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: cie grep [options] <pattern> [<pattern>...]
+
+Description:
+  Search the indexed code for literal text and print each matching line
+  as path:line:text, like grep. Several patterns are searched in one pass.
+  With --regex the pattern is a regular expression. Context lines print
+  as path-line-text. --json prints one JSON object per line.
+
+  The index answers without reading the working tree, so searches stay
+  fast on large repositories. Run 'cie index' to pick up recent edits.
+
+Exit status:
+  0 when a line matched, 1 when nothing matched, another status on error.
+
+Options:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Examples:
+  cie grep "http.Client{"
+  cie grep --path internal/auth -C 3 "jwt.Parse"
+  cie grep "TODO" "FIXME" --exclude "_test[.]go"
+  cie grep --group-by package "context.TODO()"
+  cie grep -E "func (Get|Set)[A-Z]\w+" --path pkg/config
+  cie grep --json "os.Exit" | jq -r .path | sort -u
+
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+	opts.patterns = fs.Args()
+
+	search, err := grepLineSearch(opts)
+	if err != nil {
+		fs.Usage()
+		errors.FatalError(errors.NewInputError(
+			"Invalid grep arguments",
+			err.Error(),
+			"Run 'cie grep --help' for usage",
+		), globals.JSON)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+	backend := openLocalBackend(cfg, globals)
+	defer func() { _ = backend.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	lines, err := tools.GrepLines(ctx, tools.NewEmbeddedQuerier(backend), search)
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Search failed",
+			err.Error(),
+			"Check the pattern, or run 'cie status' to verify the index",
+			err,
+		), globals.JSON)
+	}
+	if opts.groupBy != "" {
+		writeGrepCounts(os.Stdout, lines, opts.groupBy, opts.limit, globals.JSON)
+	} else {
+		writeGrepLines(os.Stdout, lines, opts.context > 0, globals.JSON)
+	}
+	// Like grep, exit with status 1 when nothing matched.
+	if len(lines) == 0 {
+		_ = backend.Close()
+		os.Exit(1)
+	}
+}
+
+// maxGroupedGrepLines caps the matching lines counted for --group-by.
+const maxGroupedGrepLines = 5000
+
+// grepLineSearch maps grep options onto a line search. Literal patterns are
+// quoted and joined into one alternation.
+func grepLineSearch(opts grepOptions) (tools.LineSearchArgs, error) {
+	if len(opts.patterns) == 0 {
+		return tools.LineSearchArgs{}, fmt.Errorf("no pattern given")
+	}
+	switch opts.scope {
+	case "", "functions", "files":
+	default:
+		return tools.LineSearchArgs{}, fmt.Errorf("invalid --scope %q (use functions or files)", opts.scope)
+	}
+	switch opts.groupBy {
+	case "", "file", "package", "function":
+	default:
+		return tools.LineSearchArgs{}, fmt.Errorf("invalid --group-by %q (use file, package or function)", opts.groupBy)
+	}
+
+	var pattern string
+	if opts.regex {
+		if len(opts.patterns) > 1 {
+			return tools.LineSearchArgs{}, fmt.Errorf("--regex takes a single pattern; combine alternatives with |")
+		}
+		pattern = opts.patterns[0]
+		if _, err := regexp.Compile(pattern); err != nil {
+			return tools.LineSearchArgs{}, fmt.Errorf("invalid --regex pattern: %v", err)
+		}
+	} else {
+		quoted := make([]string, len(opts.patterns))
+		for i, p := range opts.patterns {
+			quoted[i] = regexp.QuoteMeta(p)
+		}
+		pattern = strings.Join(quoted, "|")
+	}
+	if !opts.caseSensitive {
+		pattern = "(?i)" + pattern
+	}
+
+	search := tools.LineSearchArgs{
+		Pattern:        pattern,
+		ExcludePattern: opts.exclude,
+		Files:          opts.scope == "files",
+		ContextLines:   opts.context,
+		Limit:          opts.limit,
+	}
+	if opts.path != "" {
+		search.Path = tools.NormalizePath(opts.path)
+	}
+	if opts.groupBy != "" {
+		// The limit caps groups, so count every match up to a safety cap.
+		search.ContextLines = 0
+		search.Limit = maxGroupedGrepLines
+	}
+	return search, nil
+}
+
+// writeGrepLines prints lines the way grep does: path:line:text for
+// matches, path-line-text for context lines and "--" between separate
+// stretches when withContext is set. With asJSON it prints one JSON object
+// per line.
+func writeGrepLines(w io.Writer, lines []tools.GrepLine, withContext, asJSON bool) {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		for _, line := range lines {
+			_ = enc.Encode(line)
+		}
+		return
+	}
+	for i, line := range lines {
+		if withContext && i > 0 && (lines[i-1].Path != line.Path || lines[i-1].Line+1 != line.Line) {
+			_, _ = fmt.Fprintln(w, "--")
+		}
+		sep := ":"
+		if line.Context {
+			sep = "-"
+		}
+		_, _ = fmt.Fprintf(w, "%s%s%d%s%s\n", line.Path, sep, line.Line, sep, line.Text)
+	}
+}
+
+// grepCount is the number of matching lines of one --group-by group.
+type grepCount struct {
+	Group string `json:"group"`
+	Count int    `json:"count"`
+}
+
+// writeGrepCounts prints the number of matching lines per file, package or
+// function, most matches first, as group:count lines (grep -c style). At
+// most limit groups are printed.
+func writeGrepCounts(w io.Writer, lines []tools.GrepLine, groupBy string, limit int, asJSON bool) {
+	counts := make(map[string]int)
+	for _, line := range lines {
+		group := line.Path
+		switch groupBy {
+		case "package":
+			group = tools.ExtractDir(line.Path)
+		case "function":
+			if line.Function != "" {
+				group = line.Path + ":" + line.Function
+			}
+		}
+		counts[group]++
+	}
+	groups := make([]grepCount, 0, len(counts))
+	for group, n := range counts {
+		groups = append(groups, grepCount{Group: group, Count: n})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Group < groups[j].Group
+	})
+	if limit > 0 && len(groups) > limit {
+		groups = groups[:limit]
+	}
+
+	enc := json.NewEncoder(w)
+	for _, g := range groups {
+		if asJSON {
+			_ = enc.Encode(g)
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "%s:%d\n", g.Group, g.Count)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+func TestGrepLineSearch(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    grepOptions
+		want    tools.LineSearchArgs
+		wantErr string
+	}{
+		{
+			name: "literal with path and context",
+			opts: grepOptions{patterns: []string{"jwt.Parse"}, path: "./internal/auth/", context: 3, limit: 30},
+			want: tools.LineSearchArgs{Pattern: `(?i)jwt\.Parse`, Path: "internal/auth/", ContextLines: 3, Limit: 30},
+		},
+		{
+			name: "several patterns",
+			opts: grepOptions{patterns: []string{"TODO", "FIXME("}, exclude: "_test.go", caseSensitive: true, limit: 10},
+			want: tools.LineSearchArgs{Pattern: `TODO|FIXME\(`, ExcludePattern: "_test.go", Limit: 10},
+		},
+		{
+			name: "regex over files",
+			opts: grepOptions{patterns: []string{`func Get\w+`}, regex: true, scope: "files", limit: 30},
+			want: tools.LineSearchArgs{Pattern: `(?i)func Get\w+`, Files: true, Limit: 30},
+		},
+		{
+			name: "group-by counts every match",
+			opts: grepOptions{patterns: []string{"x"}, caseSensitive: true, groupBy: "file", context: 2, limit: 5},
+			want: tools.LineSearchArgs{Pattern: "x", Limit: maxGroupedGrepLines},
+		},
+		{name: "no pattern", opts: grepOptions{}, wantErr: "no pattern"},
+		{name: "regex with two patterns", opts: grepOptions{patterns: []string{"a", "b"}, regex: true}, wantErr: "single pattern"},
+		{name: "invalid regex", opts: grepOptions{patterns: []string{"("}, regex: true}, wantErr: "invalid --regex"},
+		{name: "invalid scope", opts: grepOptions{patterns: []string{"a"}, scope: "docs"}, wantErr: "--scope"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := grepLineSearch(tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("grepLineSearch: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("search = %#v\nwant %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteGrepLines(t *testing.T) {
+	lines := []tools.GrepLine{
+		{Path: "a.go", Line: 9, Text: "func A() {", Context: true},
+		{Path: "a.go", Line: 10, Text: "\tos.Exit(1)"},
+		{Path: "b.go", Line: 3, Text: "os.Exit(2)"},
+	}
+
+	var out bytes.Buffer
+	writeGrepLines(&out, lines, true, false)
+	want := "a.go-9-func A() {\na.go:10:\tos.Exit(1)\n--\nb.go:3:os.Exit(2)\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	writeGrepLines(&out, lines[1:2], false, true)
+	if got := out.String(); got != `{"path":"a.go","line":10,"text":"\tos.Exit(1)"}`+"\n" {
+		t.Errorf("json = %s", got)
+	}
+}
+
+func TestWriteGrepCounts(t *testing.T) {
+	lines := []tools.GrepLine{
+		{Path: "pkg/a/x.go", Line: 1, Function: "X"},
+		{Path: "pkg/a/y.go", Line: 2, Function: "Y"},
+		{Path: "pkg/a/y.go", Line: 5, Function: "Y"},
+		{Path: "pkg/b/z.go", Line: 1, Function: "Z"},
+	}
+	var out bytes.Buffer
+	writeGrepCounts(&out, lines, "package", 0, false)
+	if want := "pkg/a:3\npkg/b:1\n"; out.String() != want {
+		t.Errorf("package counts = %q, want %q", out.String(), want)
+	}
+	out.Reset()
+	writeGrepCounts(&out, lines, "function", 1, false)
+	if want := "pkg/a/y.go:Y:2\n"; out.String() != want {
+		t.Errorf("function counts = %q, want %q", out.String(), want)
+	}
+}
@@ -20,74 +20,104 @@
 package main
 
 import (
+	stderrors "errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	flag "github.com/spf13/pflag"
 
 	"github.com/kraklabs/cie/internal/errors"
 )
 
-const postCommitHookContent = `#!/bin/sh
-# CIE auto-index hook - queues incremental indexing for this commit
+// Hooks installed by default. post-merge and post-checkout keep the index in
+// step with pulls and branch switches, not just local commits.
+var defaultHooks = []string{"post-commit", "post-merge", "post-checkout"}
+
+// defaultHookMaxFiles is the change size above which a hook schedules a
+// deferred full index instead of indexing right away.
+const defaultHookMaxFiles = 200
+
+// hookScript returns the script installed as the named git hook. It hands the
+// hook arguments to 'cie hook-run' in the background so git never waits on it.
+func hookScript(name string, maxFiles int) string {
+	return fmt.Sprintf(`#!/bin/sh
+# CIE auto-index hook (%[1]s) - re-indexes files changed by git
 # Installed by: cie install-hook
 # Remove with: cie install-hook --remove
+#
+# Runs in the background at low priority. Changes touching more than
+# %[2]d files schedule a deferred full index instead.
 
-COMMIT=$(git rev-parse HEAD)
-cie index --incremental --until="$COMMIT" --skip-checks --queue 2>/dev/null &
-`
+cie hook-run %[1]s --max-files=%[2]d -- "$@" </dev/null >/dev/null 2>&1 &
+`, name, maxFiles)
+}
 
-// runInstallHook executes the 'install-hook' CLI command, managing git post-commit hooks.
+// runInstallHook executes the 'install-hook' CLI command, managing git hooks.
 //
-// It installs or removes a git post-commit hook that automatically triggers incremental
-// indexing after each commit. The hook runs in the background using the queue system
-// to handle concurrent commits gracefully.
+// It installs or removes the post-commit, post-merge and post-checkout hooks
+// that trigger incremental indexing after git changes the working tree. The
+// hooks run in the background and hand off to 'cie hook-run', which skips
+// no-op checkouts and defers large changes to a full index.
 //
 // Flags:
-//   - --force: Overwrite existing hook (default: false)
-//   - --remove: Remove the hook instead of installing (default: false)
+//   - --force: Overwrite existing hooks (default: false)
+//   - --remove: Remove the hooks instead of installing (default: false)
+//   - --hooks: Hooks to manage (default: post-commit,post-merge,post-checkout)
+//   - --max-files: Changed-file count above which a full index is scheduled (default: 200)
 //
 // Examples:
 //
-//	cie install-hook           Install the post-commit hook
-//	cie install-hook --force   Overwrite existing hook
-//	cie install-hook --remove  Remove the hook
+//	cie install-hook                     Install all hooks
+//	cie install-hook --hooks post-commit Install only the post-commit hook
+//	cie install-hook --force             Overwrite existing hooks
+//	cie install-hook --remove            Remove the hooks
 func runInstallHook(args []string, configPath string, globals GlobalFlags) {
 	fs := flag.NewFlagSet("install-hook", flag.ExitOnError)
-	force := fs.Bool("force", false, "Overwrite existing hook")
-	remove := fs.Bool("remove", false, "Remove the hook instead of installing")
+	force := fs.Bool("force", false, "Overwrite existing hooks")
+	remove := fs.Bool("remove", false, "Remove the hooks instead of installing")
+	hooks := fs.StringSlice("hooks", defaultHooks, "Hooks to manage (post-commit, post-merge, post-checkout)")
+	maxFiles := fs.Int("max-files", defaultHookMaxFiles, "Schedule a deferred full index when more files than this change (0 = never)")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: cie install-hook [options]
 
 Description:
-  Install a git post-commit hook that automatically triggers incremental
-  indexing after each commit.
+  Install git hooks that automatically trigger incremental indexing
+  after commits (post-commit), pulls and merges (post-merge) and branch
+  switches (post-checkout).
 
   This ensures your CIE database stays up-to-date as you write code,
   making AI-powered code intelligence always current.
 
-  The hook installs to .git/hooks/post-commit. If a hook already exists,
-  use --force to overwrite.
+  The hooks install to .git/hooks/. If a hook already exists, use
+  --force to overwrite.
 
 Options:
 `)
 		fs.PrintDefaults()
 		fmt.Fprintf(os.Stderr, `
 Examples:
-  # Install the post-commit hook
+  # Install all hooks
   cie install-hook
 
-  # Force overwrite existing hook
-  cie install-hook --force
+  # Only re-index after local commits
+  cie install-hook --hooks post-commit
+
+  # Defer anything touching more than 50 files to a full index
+  cie install-hook --force --max-files 50
 
-  # Remove the installed hook
+  # Remove the installed hooks
   cie install-hook --remove
 
 Notes:
-  The hook runs 'cie index' in the background after each commit.
-  You can also install the hook during 'cie init' with --hook flag.
+  The hooks run in the background at low priority and never block git.
+  File checkouts and checkouts that keep HEAD are ignored. When a change
+  touches more than --max-files files, a full index is scheduled after
+  a short delay so a burst of checkouts (e.g. a rebase) runs it once.
+  Concurrent triggers queue behind the running index.
+  You can also install the hooks during 'cie init' with --hook flag.
 
 `)
 	}
@@ -95,6 +125,15 @@ Notes:
 	if err := fs.Parse(args); err != nil {
 		os.Exit(1)
 	}
+	for _, name := range *hooks {
+		if !isSupportedHook(name) {
+			errors.FatalError(errors.NewInputError(
+				fmt.Sprintf("Unsupported hook %q", name),
+				"CIE only installs post-commit, post-merge and post-checkout hooks",
+				"Pass a subset of: "+strings.Join(defaultHooks, ","),
+			), false)
+		}
+	}
 
 	// Find git directory
 	gitDir, err := findGitDir()
@@ -102,20 +141,62 @@ Notes:
 		errors.FatalError(err, false) // findGitDir returns UserError
 	}
 
-	hookPath := filepath.Join(gitDir, "hooks", "post-commit")
-
 	if *remove {
-		if err := removeHook(hookPath); err != nil {
-			errors.FatalError(err, false) // removeHook returns UserError
+		removed := 0
+		for _, name := range *hooks {
+			hookPath := filepath.Join(gitDir, "hooks", name)
+			err := removeHook(hookPath)
+			var ue *errors.UserError
+			switch {
+			case err == nil:
+				removed++
+			case stderrors.As(err, &ue) && ue.ExitCode == errors.ExitNotFound:
+				// Not installed; nothing to do.
+			case stderrors.As(err, &ue) && ue.ExitCode == errors.ExitInput:
+				fmt.Printf("Leaving %s: not installed by CIE.\n", hookPath)
+			default:
+				errors.FatalError(err, false) // removeHook returns UserError
+			}
+		}
+		if removed == 0 {
+			fmt.Println("No CIE git hooks were installed.")
+			return
 		}
-		fmt.Println("Git hook removed successfully.")
+		fmt.Println("Git hooks removed successfully.")
 		return
 	}
 
-	if err := installHook(hookPath, *force); err != nil {
-		errors.FatalError(err, false) // installHook returns UserError
+	installed, err := installHooks(gitDir, *hooks, *maxFiles, *force)
+	for _, path := range installed {
+		fmt.Printf("Git hook installed: %s\n", path)
+	}
+	if err != nil {
+		errors.FatalError(err, false) // installHooks returns UserError
+	}
+}
+
+// isSupportedHook reports whether name is one of the hooks CIE can install.
+func isSupportedHook(name string) bool {
+	for _, h := range defaultHooks {
+		if name == h {
+			return true
+		}
 	}
-	fmt.Printf("Git hook installed: %s\n", hookPath)
+	return false
+}
+
+// installHooks writes the named hooks into gitDir/hooks and returns the paths
+// written. It stops at the first hook that cannot be installed.
+func installHooks(gitDir string, names []string, maxFiles int, force bool) ([]string, error) {
+	var installed []string
+	for _, name := range names {
+		hookPath := filepath.Join(gitDir, "hooks", name)
+		if err := writeHook(hookPath, hookScript(name, maxFiles), force); err != nil {
+			return installed, err
+		}
+		installed = append(installed, hookPath)
+	}
+	return installed, nil
 }
 
 // findGitDir finds the .git directory by walking up the directory tree.
@@ -177,18 +258,25 @@ func findGitDir() (string, error) {
 	)
 }
 
-// installHook writes the CIE post-commit hook to the specified path.
+// installHook writes the CIE hook named by the base of hookPath (for example
+// .git/hooks/post-merge) with the default change threshold.
+func installHook(hookPath string, force bool) error {
+	return writeHook(hookPath, hookScript(filepath.Base(hookPath), defaultHookMaxFiles), force)
+}
+
+// writeHook writes a CIE hook script to the specified path.
 //
 // If the hook file already exists and force is false, it checks whether the existing
 // hook is a CIE hook. If force is true, it overwrites any existing hook.
 //
 // Parameters:
-//   - hookPath: Absolute path to the hook file (.git/hooks/post-commit)
+//   - hookPath: Absolute path to the hook file (e.g. .git/hooks/post-commit)
+//   - content: Hook script
 //   - force: Whether to overwrite existing hooks
 //
 // Returns an error if the file cannot be written or if an existing non-CIE hook
 // would be overwritten without force=true.
-func installHook(hookPath string, force bool) error {
+func writeHook(hookPath, content string, force bool) error {
 	// Check if hooks directory exists
 	hookDir := filepath.Dir(hookPath)
 	if err := os.MkdirAll(hookDir, 0750); err != nil {
@@ -204,21 +292,21 @@ func installHook(hookPath string, force bool) error {
 	if _, err := os.Stat(hookPath); err == nil {
 		if !force {
 			// Check if it's our hook
-			content, err := os.ReadFile(hookPath) //nolint:gosec // G304: hookPath from user's git repo
-			if err == nil && containsCIEMarker(string(content)) {
-				fmt.Println("CIE hook already installed. Use --force to reinstall.")
+			existing, err := os.ReadFile(hookPath) //nolint:gosec // G304: hookPath from user's git repo
+			if err == nil && containsCIEMarker(string(existing)) {
+				fmt.Printf("CIE %s hook already installed. Use --force to reinstall.\n", filepath.Base(hookPath))
 				return nil
 			}
 			return errors.NewInputError(
 				"Hook already exists",
-				fmt.Sprintf("A post-commit hook already exists at %s", hookPath),
+				fmt.Sprintf("A %s hook already exists at %s", filepath.Base(hookPath), hookPath),
 				"Use 'cie install-hook --force' to overwrite the existing hook, or manually edit it",
 			)
 		}
 	}
 
 	// Write the hook (needs exec permission)
-	if err := os.WriteFile(hookPath, []byte(postCommitHookContent), 0750); err != nil { //nolint:gosec // G306: Hook needs exec permission
+	if err := os.WriteFile(hookPath, []byte(content), 0750); err != nil { //nolint:gosec // G306: Hook needs exec permission
 		return errors.NewPermissionError(
 			"Cannot write hook file",
 			fmt.Sprintf("Permission denied writing to %s", hookPath),
@@ -230,13 +318,13 @@ func installHook(hookPath string, force bool) error {
 	return nil
 }
 
-// removeHook removes the CIE post-commit hook if it exists and is a CIE hook.
+// removeHook removes a CIE hook if it exists and is a CIE hook.
 //
 // It only removes the hook if it contains the CIE marker comment, preventing
 // accidental removal of user-created hooks.
 //
 // Parameters:
-//   - hookPath: Absolute path to the hook file (.git/hooks/post-commit)
+//   - hookPath: Absolute path to the hook file (e.g. .git/hooks/post-commit)
 //
 // Returns an error if the file cannot be read or deleted, or if the hook
 // is not a CIE hook (protection against accidental removal).
@@ -247,7 +335,7 @@ func removeHook(hookPath string) error {
 		if os.IsNotExist(err) {
 			return errors.NewNotFoundError(
 				"Hook not found",
-				fmt.Sprintf("No post-commit hook exists at %s", hookPath),
+				fmt.Sprintf("No %s hook exists at %s", filepath.Base(hookPath), hookPath),
 				"Run 'cie install-hook' to install the CIE hook first",
 			)
 		}
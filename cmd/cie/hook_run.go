@@ -0,0 +1,210 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	flag "github.com/spf13/pflag"
+)
+
+// hookAction is what a git hook invocation asks the indexer to do.
+type hookAction int
+
+const (
+	hookSkip        hookAction = iota // nothing relevant changed
+	hookIncremental                   // index the changed files now
+	hookFull                          // too many changes: schedule a full index
+)
+
+// nullCommit is the all-zero hash git passes for "no previous commit".
+const nullCommit = "0000000000000000000000000000000000000000"
+
+// fullQueueEntry marks a queued full index in the index queue, which
+// otherwise holds commit hashes.
+const fullQueueEntry = "full"
+
+// queryTimeHook is the pseudo-hook the MCP server uses to request indexing
+// of files it had to parse on the fly.
+const queryTimeHook = "query-time"
+
+// hookDecision explains the action chosen for one hook invocation.
+type hookDecision struct {
+	Action  hookAction
+	Changed int    // files changed between the two commits (-1 if unknown)
+	Reason  string // shown in the hook log
+}
+
+// hookRange returns the commits a hook invocation moved between. ok is false
+// when the invocation should be ignored, with reason saying why.
+//
+// git passes post-checkout "<prev> <new> <branch-flag>" and post-merge
+// "<squash-flag>"; post-commit gets no arguments.
+func hookRange(hook string, args []string) (from, to string, ok bool, reason string) {
+	switch hook {
+	case "post-commit":
+		return "HEAD~1", "HEAD", true, ""
+	case "post-merge":
+		return "ORIG_HEAD", "HEAD", true, ""
+	case "post-checkout":
+		if len(args) < 3 {
+			return "", "", false, "missing post-checkout arguments"
+		}
+		switch {
+		case args[2] != "1":
+			return "", "", false, "file checkout"
+		case args[0] == args[1]:
+			return "", "", false, "HEAD unchanged"
+		case args[0] == nullCommit:
+			return "", "", false, "initial clone"
+		}
+		return args[0], args[1], true, ""
+	}
+	return "", "", false, fmt.Sprintf("unsupported hook %q", hook)
+}
+
+// decideHookAction picks the action for a hook invocation. countChanged
+// returns the number of files changed between two commits; when it fails
+// (for example on the root commit) the change is indexed incrementally.
+// maxFiles <= 0 disables the full-index threshold.
+func decideHookAction(hook string, args []string, maxFiles int, countChanged func(from, to string) (int, error)) hookDecision {
+	if hook == queryTimeHook {
+		return hookDecision{Action: hookIncremental, Changed: -1, Reason: "unindexed files were requested through MCP"}
+	}
+	from, to, ok, reason := hookRange(hook, args)
+	if !ok {
+		return hookDecision{Action: hookSkip, Changed: -1, Reason: reason}
+	}
+	n, err := countChanged(from, to)
+	switch {
+	case err != nil:
+		return hookDecision{Action: hookIncremental, Changed: -1, Reason: "change size unknown"}
+	case n == 0:
+		return hookDecision{Action: hookSkip, Reason: "no files changed"}
+	case maxFiles > 0 && n > maxFiles:
+		return hookDecision{Action: hookFull, Changed: n, Reason: fmt.Sprintf("%d files changed (> %d)", n, maxFiles)}
+	}
+	return hookDecision{Action: hookIncremental, Changed: n, Reason: fmt.Sprintf("%d files changed", n)}
+}
+
+// gitChangedFiles counts the files that differ between two commits.
+func gitChangedFiles(from, to string) (int, error) {
+	out, err := exec.Command("git", "diff", "--name-only", from, to).Output() //nolint:gosec // G204: revisions come from git itself
+	if err != nil {
+		return 0, err
+	}
+	return len(strings.Fields(string(out))), nil
+}
+
+// runHookRun executes the hidden 'hook-run' command invoked by the scripts
+// that 'cie install-hook' writes. It is not meant to be run by hand.
+//
+// It decides from the hook name and git's arguments whether anything needs
+// indexing, then runs 'cie index' as a child process at low CPU priority
+// while holding the project's index lock. A trigger that finds the lock
+// taken queues itself; the lock holder drains the queue before exiting, so
+// bursts of hooks collapse into at most one extra run.
+func runHookRun(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("hook-run", flag.ExitOnError)
+	maxFiles := fs.Int("max-files", defaultHookMaxFiles, "Schedule a full index when more files than this change (0 = never)")
+	delay := fs.Duration("delay", 30*time.Second, "How long a scheduled full index waits for further triggers")
+	if err := fs.Parse(args); err != nil || fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: cie hook-run <hook> [--max-files N] [--delay D] -- [git hook arguments]")
+		os.Exit(1)
+	}
+	hook := fs.Arg(0)
+
+	decision := decideHookAction(hook, fs.Args()[1:], *maxFiles, gitChangedFiles)
+	fmt.Fprintf(os.Stderr, "cie %s: %s\n", hook, decision.Reason)
+	if decision.Action == hookSkip {
+		return
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cie %s: %v\n", hook, err)
+		return
+	}
+	queue, err := NewIndexQueue(cfg.ProjectID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cie %s: %v\n", hook, err)
+		return
+	}
+
+	entry := "HEAD"
+	if decision.Action == hookFull {
+		entry = fullQueueEntry
+	}
+	acquired, err := queue.TryAcquireLock()
+	if err != nil || !acquired {
+		// Another index is running; it picks this trigger up when it finishes.
+		_ = queue.AddToQueue(entry)
+		return
+	}
+	defer queue.ReleaseLock()
+
+	// Never compete with the developer for CPU.
+	_ = syscall.Setpriority(syscall.PRIO_PROCESS, 0, 10)
+
+	full := decision.Action == hookFull
+	if full {
+		// Let a burst of checkouts (rebase, bisect) settle into one run.
+		time.Sleep(*delay)
+	}
+	for {
+		queued, _ := queue.DrainQueue()
+		for _, q := range queued {
+			full = full || q == fullQueueEntry
+		}
+		runHookIndex(configPath, full)
+
+		pending, _ := queue.GetQueuedCommits()
+		if len(pending) == 0 {
+			return
+		}
+		full = false
+	}
+}
+
+// runHookIndex runs 'cie index' (with --full when requested) as a child
+// process so a failing index cannot take the queue loop down with it.
+func runHookIndex(configPath string, full bool) {
+	self, err := os.Executable()
+	if err != nil {
+		self = "cie"
+	}
+	var args []string
+	if configPath != "" {
+		args = append(args, "--config", configPath)
+	}
+	args = append(args, "--quiet", "index")
+	if full {
+		args = append(args, "--full")
+	}
+	cmd := exec.Command(self, args...) //nolint:gosec // G204: re-executes this binary
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "cie index failed: %v\n", err)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecideHookAction(t *testing.T) {
+	count := func(n int, err error) func(string, string) (int, error) {
+		return func(string, string) (int, error) { return n, err }
+	}
+	sha := strings.Repeat("a", 40)
+	tests := []struct {
+		name    string
+		hook    string
+		args    []string
+		changed func(string, string) (int, error)
+		want    hookAction
+	}{
+		{"commit small", "post-commit", nil, count(3, nil), hookIncremental},
+		{"commit root", "post-commit", nil, count(0, fmt.Errorf("no parent")), hookIncremental},
+		{"merge large", "post-merge", []string{"0"}, count(500, nil), hookFull},
+		{"merge nothing", "post-merge", []string{"0"}, count(0, nil), hookSkip},
+		{"branch switch", "post-checkout", []string{strings.Repeat("b", 40), sha, "1"}, count(12, nil), hookIncremental},
+		{"file checkout", "post-checkout", []string{sha, sha, "0"}, count(12, nil), hookSkip},
+		{"same head", "post-checkout", []string{sha, sha, "1"}, count(12, nil), hookSkip},
+		{"clone", "post-checkout", []string{nullCommit, sha, "1"}, count(900, nil), hookSkip},
+		{"unknown hook", "pre-push", nil, count(1, nil), hookSkip},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := decideHookAction(tt.hook, tt.args, 200, tt.changed)
+			if got.Action != tt.want {
+				t.Errorf("action = %v (%s), want %v", got.Action, got.Reason, tt.want)
+			}
+		})
+	}
+
+	if got := decideHookAction("post-merge", nil, 0, count(5000, nil)); got.Action != hookIncremental {
+		t.Errorf("max-files 0 should disable the full-index threshold, got %v", got.Action)
+	}
+}
+
+func TestInstallHooks(t *testing.T) {
+	gitDir := t.TempDir()
+	custom := filepath.Join(gitDir, "hooks", "post-merge")
+	if err := os.MkdirAll(filepath.Dir(custom), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(custom, []byte("#!/bin/sh\necho mine\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	installed, err := installHooks(gitDir, defaultHooks, 50, false)
+	if err == nil {
+		t.Fatal("expected error for existing non-CIE post-merge hook")
+	}
+	if len(installed) != 1 || filepath.Base(installed[0]) != "post-commit" {
+		t.Errorf("installed = %v, want only post-commit before the conflict", installed)
+	}
+
+	installed, err = installHooks(gitDir, defaultHooks, 50, true)
+	if err != nil || len(installed) != 3 {
+		t.Fatalf("installHooks(force) = %v, %v", installed, err)
+	}
+	for _, path := range installed {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := filepath.Base(path)
+		if !containsCIEMarker(string(content)) || !strings.Contains(string(content), "cie hook-run "+name+" --max-files=50") {
+			t.Errorf("%s script:\n%s", name, content)
+		}
+	}
+
+	for _, path := range installed {
+		if err := removeHook(path); err != nil {
+			t.Errorf("removeHook(%s): %v", path, err)
+		}
+	}
+}
@@ -29,6 +29,9 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -54,12 +57,14 @@ import (
 //   - --embed-workers: Number of parallel embedding workers (default: 8)
 //   - --debug: Enable debug logging (default: false)
 //   - --metrics-addr: HTTP address for Prometheus metrics (default: disabled)
+//   - --skip-embeddings: Build the structural index only; see 'cie embed-backfill'
 //
 // Examples:
 //
 //	cie index                  Incremental index (only changed files)
 //	cie index --full           Force full reindex
 //	cie index --embed-workers 16  Use 16 parallel workers for embeddings
+//	cie index --skip-embeddings   Index structure now, embed later
 func runIndex(args []string, configPath string, globals GlobalFlags) {
 	// Check if we should delegate to remote server
 	baseURL := os.Getenv("CIE_BASE_URL")
@@ -74,6 +79,14 @@ func runIndex(args []string, configPath string, globals GlobalFlags) {
 	embedWorkers := fs.Int("embed-workers", 8, "Number of parallel embedding workers")
 	debug := fs.Bool("debug", false, "Enable debug logging")
 	metricsAddr := fs.String("metrics-addr", "", "HTTP listen address for Prometheus metrics (empty to disable)")
+	timings := fs.Bool("timings", false, "Record stage timings, parse throughput and embedding latency to a JSON report")
+	timingsOutput := fs.String("timings-output", "", "Path of the --timings JSON report (default: .cie/index-timings.json)")
+	cpuProfile := fs.String("cpuprofile", "", "Write a pprof CPU profile of the run to this file")
+	skipEmbeddings := fs.Bool("skip-embeddings", false, "Build the structural index without embeddings (fill them in later with 'cie embed-backfill')")
+	manifestPath := fs.String("manifest", "", "Path of the index manifest written after the run (default: .cie/index-manifest.json)")
+	noManifest := fs.Bool("no-manifest", false, "Do not write the index manifest")
+	shardList := fs.StringSlice("shard", nil, "Rebuild only these top-level directories of a sharded index (repeatable or comma-separated)")
+	profileName := fs.String("profile", "", "Use a named embedding/LLM profile from the configuration (same as the global --profile)")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: cie index [options]
@@ -85,7 +98,9 @@ Description:
 
   The indexing process runs incrementally by default, only processing
   changed files since the last index. Use --full to force a complete
-  reindex from scratch.
+  reindex from scratch. A full reindex is built in a staging directory
+  and swapped in only when it succeeds, so the previous index stays
+  usable while it runs and survives a failed or interrupted run.
 
   Indexed data is stored locally in ~/.cie/data/<project_id>/
 
@@ -109,6 +124,22 @@ Examples:
   # Enable debug logging and expose metrics
   cie index --debug --metrics-addr :9090
 
+  # Index with the embedding setup of the "offline" profile
+  cie index --profile offline
+
+  # Profile a full run and capture a CPU profile for 'go tool pprof'
+  cie index --full --timings --cpuprofile cpu.pprof
+
+  # Make search, call graph and grep usable right away; embed afterwards
+  cie index --skip-embeddings
+  cie embed-backfill --detach
+
+  # Rebuild two directories of a sharded monorepo index (storage.sharded)
+  cie index --shard services,web
+
+  # Save the manifest next to a cached index artifact in CI
+  cie index --manifest "$HOME/.cie/data/index-manifest.json"
+
 Notes:
   Indexing may take several minutes for large repositories. Progress
   indicators will show files processed and errors encountered.
@@ -119,6 +150,9 @@ Notes:
 	if err := fs.Parse(args); err != nil {
 		os.Exit(1)
 	}
+	if *profileName != "" {
+		selectedProfile = *profileName
+	}
 
 	// Load configuration
 	cfg, err := LoadConfig(configPath)
@@ -187,21 +221,51 @@ Notes:
 		), false)
 	}
 
+	shards := cleanShardNames(*shardList)
+	if len(shards) > 0 && !cfg.Storage.Sharded {
+		errors.FatalError(errors.NewInputError(
+			"--shard needs sharded storage",
+			"The index stores the whole repository in one database",
+			"Set 'storage.sharded: true' in .cie/project.yaml and run 'cie index --full' first",
+		), globals.JSON)
+	}
+	if len(shards) > 0 && (*full || *forceFullReindex) {
+		errors.FatalError(errors.NewInputError(
+			"Conflicting flags",
+			"--shard rebuilds part of the index and cannot be combined with --full",
+			"Use either --shard or --full",
+		), globals.JSON)
+	}
+
 	// Map embedding provider
 	embeddingProvider := mapEmbeddingProvider(cfg.Embedding.Provider)
 
-	// Delete local data if force-full-reindex is requested
-	if *forceFullReindex {
-		homeDir, _ := os.UserHomeDir()
-		dataDir := filepath.Join(homeDir, ".cie", "data", cfg.ProjectID)
-		if err := os.RemoveAll(dataDir); err == nil {
-			logger.Info("data.deleted", "path", dataDir)
-		} else if !os.IsNotExist(err) {
-			logger.Warn("data.delete.error", "path", dataDir, "err", err)
+	var profiler *ingestion.Profiler
+	if *timings {
+		profiler = ingestion.NewProfiler()
+	}
+	if *cpuProfile != "" {
+		stop := startCPUProfile(*cpuProfile, globals)
+		defer stop()
+	}
+
+	runLocalIndex(ctx, logger, cfg, cwd, embeddingProvider, *embedWorkers, *full || *forceFullReindex, *skipEmbeddings, shards, profiler, globals)
+
+	if !*noManifest {
+		path := *manifestPath
+		if path == "" {
+			path = filepath.Join(ConfigDir(cwd), indexManifestFile)
 		}
+		emitIndexManifest(ctx, cfg, path, globals)
 	}
 
-	runLocalIndex(ctx, logger, cfg, cwd, embeddingProvider, *embedWorkers, *full, globals)
+	if profiler != nil {
+		path := *timingsOutput
+		if path == "" {
+			path = filepath.Join(ConfigDir(cwd), "index-timings.json")
+		}
+		writeIndexProfile(profiler.Report(), path, globals)
+	}
 }
 
 // checkLocalData checks if local indexed data exists and returns the function count.
@@ -223,7 +287,8 @@ func checkLocalData(cfg *Config) (bool, int, error) {
 
 	backend, err := storage.NewEmbeddedBackend(storage.EmbeddedConfig{
 		ProjectID:           cfg.ProjectID,
-		Engine:              "rocksdb",
+		Engine:              cfg.StorageEngine(),
+		Sharded:             cfg.Storage.Sharded,
 		EmbeddingDimensions: cfg.Embedding.Dimensions,
 	})
 	if err != nil {
@@ -232,21 +297,10 @@ func checkLocalData(cfg *Config) (bool, int, error) {
 	defer backend.Close()
 
 	result, err := backend.Query(context.Background(), "?[count(id)] := *cie_function{id}")
-	if err != nil || len(result.Rows) == 0 {
+	if err != nil {
 		return true, 0, nil
 	}
-
-	count := 0
-	if row := result.Rows[0]; len(row) > 0 {
-		if v, ok := row[0].(float64); ok {
-			count = int(v)
-		}
-		if v, ok := row[0].(int); ok {
-			count = v
-		}
-	}
-
-	return true, count, nil
+	return true, sumCountRows(result), nil
 }
 
 // runLocalIndex executes the local indexing pipeline, writing results to the embedded database.
@@ -261,8 +315,13 @@ func checkLocalData(cfg *Config) (bool, int, error) {
 //   - repoPath: Absolute path to the repository root
 //   - embeddingProvider: Embedding provider name (ollama, nomic, mock)
 //   - embedWorkers: Number of parallel workers for embedding generation
+//   - forceReindex: Rebuild every file into a staging directory and swap it
+//     in on success, leaving the previous index untouched on failure
+//   - skipEmbeddings: Write the structural index without generating embeddings
+//   - shards: Top-level directories of a sharded index to rebuild (nil for all)
+//   - profiler: Optional timing collector for --timings (nil to disable)
 //   - globals: Global CLI flags for progress/output control
-func runLocalIndex(ctx context.Context, logger *slog.Logger, cfg *Config, repoPath, embeddingProvider string, embedWorkers int, forceReindex bool, globals GlobalFlags) {
+func runLocalIndex(ctx context.Context, logger *slog.Logger, cfg *Config, repoPath, embeddingProvider string, embedWorkers int, forceReindex, skipEmbeddings bool, shards []string, profiler *ingestion.Profiler, globals GlobalFlags) {
 	// Ensure checkpoint directory exists
 	checkpointDir := filepath.Join(ConfigDir(repoPath), "checkpoints")
 	if err := os.MkdirAll(checkpointDir, 0750); err != nil {
@@ -274,55 +333,43 @@ func runLocalIndex(ctx context.Context, logger *slog.Logger, cfg *Config, repoPa
 		), false)
 	}
 
-	// Combine default excludes with user-specified ones
-	defaults := ingestion.DefaultConfig()
-	excludeGlobs := append(defaults.ExcludeGlobs, cfg.Indexing.Exclude...)
-
-	config := ingestion.Config{
-		ProjectID: cfg.ProjectID,
-		RepoSource: ingestion.RepoSource{
-			Type:  "local_path",
-			Value: repoPath,
-		},
-		IngestionConfig: ingestion.IngestionConfig{
-			ParserMode:           ingestion.ParserMode(cfg.Indexing.ParserMode),
-			EmbeddingProvider:    embeddingProvider,
-			EmbeddingDimensions:  cfg.Embedding.Dimensions,
-			BatchTargetMutations: cfg.Indexing.BatchTarget,
-			MaxFileSizeBytes:     cfg.Indexing.MaxFileSize,
-			CheckpointPath:       checkpointDir,
-			ExcludeGlobs:         excludeGlobs,
-			ForceReindex:         forceReindex,
-			Concurrency: ingestion.ConcurrencyConfig{
-				ParseWorkers: 4,
-				EmbedWorkers: embedWorkers,
-			},
-		},
-	}
+	config := localIngestionConfig(cfg, repoPath, checkpointDir, embeddingProvider, embedWorkers, forceReindex, skipEmbeddings)
+	config.IngestionConfig.ReindexShards = shards
 
-	// Set embedding environment based on provider
-	switch embeddingProvider {
-	case "ollama":
-		_ = os.Setenv("OLLAMA_BASE_URL", cfg.Embedding.BaseURL)
-		_ = os.Setenv("OLLAMA_EMBED_MODEL", cfg.Embedding.Model)
-	case "openai":
-		_ = os.Setenv("OPENAI_API_BASE", cfg.Embedding.BaseURL)
-		_ = os.Setenv("OPENAI_EMBED_MODEL", cfg.Embedding.Model)
-		if cfg.Embedding.APIKey != "" {
-			_ = os.Setenv("OPENAI_API_KEY", cfg.Embedding.APIKey)
+	// A full rebuild goes to a staging directory so readers of the live
+	// index never see it half-built and a failed run leaves it intact.
+	var liveDir, stagingDir string
+	if forceReindex {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			errors.FatalError(errors.NewInternalError(
+				"Cannot locate the data directory",
+				"Failed to determine home directory",
+				"Check that $HOME is set",
+				err,
+			), false)
 		}
+		liveDir = filepath.Join(homeDir, ".cie", "data", cfg.ProjectID)
+		stagingDir = storage.StagingDir(liveDir)
+		_ = os.RemoveAll(stagingDir) // leftover from an interrupted run
+		config.IngestionConfig.LocalDataDir = stagingDir
 	}
 
+	setEmbeddingEnv(cfg, embeddingProvider)
+
 	pipeline, err := ingestion.NewLocalPipeline(config, logger)
 	if err != nil {
 		errors.FatalError(errors.NewDatabaseError(
 			"Cannot initialize indexing pipeline",
 			"Failed to open or initialize the database",
-			"Try 'cie reset' to rebuild the database, or close other CIE instances",
+			openFailureFix(err, "Close other CIE instances, or run 'cie repair' if the problem persists"),
 			err,
 		), false)
 	}
 	defer func() { _ = pipeline.Close() }()
+	if profiler != nil {
+		pipeline.SetProfiler(profiler)
+	}
 
 	// Set up progress reporting
 	progressCfg := NewProgressConfig(globals)
@@ -358,15 +405,181 @@ func runLocalIndex(ctx context.Context, logger *slog.Logger, cfg *Config, repoPa
 	}
 
 	if err != nil {
+		if stagingDir != "" {
+			_ = pipeline.Close()
+			_ = os.RemoveAll(stagingDir)
+		}
+		fix := openFailureFix(err, "Check the error details above. If this persists, run 'cie repair'")
+		if stagingDir != "" {
+			fix = "Check the error details above. The previous index was left in place"
+		}
 		errors.FatalError(errors.NewDatabaseError(
 			"Indexing operation failed",
 			"An error occurred during repository indexing",
-			"Check the error details above. If this persists, try 'cie reset --yes'",
+			fix,
 			err,
 		), false)
 	}
 
+	if stagingDir != "" {
+		_ = pipeline.Close()
+		if err := installStagedIndex(liveDir, stagingDir, cfg.StorageEngine(), cfg.Storage.Snapshots); err != nil {
+			errors.FatalError(errors.NewDatabaseError(
+				"Cannot install the rebuilt index",
+				err.Error(),
+				openFailureFix(err, "Close other CIE instances and run the re-index again. The previous index was left in place"),
+				err,
+			), false)
+		}
+		logger.Info("index.swapped", "path", liveDir)
+	}
+
 	printResult(result)
+	if skipEmbeddings && result.FunctionsExtracted+result.TypesExtracted > 0 {
+		fmt.Println("Embeddings were skipped; semantic search stays empty until you run:")
+		fmt.Println("  cie embed-backfill --detach")
+	}
+}
+
+// installStagedIndex replaces the index at live with the one built at
+// staging. It waits for an MCP server to give live up, carries notes, facts,
+// collections and snapshots over (storage.CarryOverUserData) and swaps the
+// directories. When it fails before the swap, staging is removed and live is
+// left as it was.
+func installStagedIndex(live, staging, engine string, keepSnapshots int) error {
+	lock, err := storage.LockDataDir(live, 0)
+	if err != nil {
+		_ = os.RemoveAll(staging)
+		return err
+	}
+	defer lock.Unlock()
+	if err := storage.CarryOverUserData(live, staging, engine, keepSnapshots); err != nil {
+		_ = os.RemoveAll(staging)
+		return fmt.Errorf("carry notes and collections over: %w", err)
+	}
+	return storage.SwapDataDir(live, staging)
+}
+
+// localIngestionConfig builds the pipeline configuration shared by `cie index`
+// and the scheduled reindex in serve and MCP modes.
+func localIngestionConfig(cfg *Config, repoPath, checkpointDir, embeddingProvider string, embedWorkers int, forceReindex, skipEmbeddings bool) ingestion.Config {
+	// Combine default excludes with user-specified ones
+	defaults := ingestion.DefaultConfig()
+	excludeGlobs := append(defaults.ExcludeGlobs, cfg.Indexing.Exclude...)
+
+	return ingestion.Config{
+		ProjectID: cfg.ProjectID,
+		RepoSource: ingestion.RepoSource{
+			Type:  "local_path",
+			Value: repoPath,
+		},
+		IngestionConfig: ingestion.IngestionConfig{
+			ParserMode:              ingestion.ParserMode(cfg.Indexing.ParserMode),
+			EmbeddingProvider:       embeddingProvider,
+			EmbeddingDimensions:     cfg.Embedding.Dimensions,
+			BatchTargetMutations:    cfg.Indexing.BatchTarget,
+			MaxFileSizeBytes:        cfg.Indexing.MaxFileSize,
+			CompressCodeText:        cfg.Indexing.CompressCode,
+			StoreFileText:           cfg.Indexing.StoreFileText,
+			ContentHashMode:         ingestion.ContentHashMode(cfg.Indexing.ContentHash),
+			LanguageLimits:          languageLimits(cfg.Indexing.Languages),
+			CheckpointPath:          checkpointDir,
+			ExcludeGlobs:            excludeGlobs,
+			ForceReindex:            forceReindex,
+			SkipEmbeddings:          skipEmbeddings,
+			EmbeddingCachePath:      embeddingCachePath(cfg, embeddingProvider),
+			EmbeddingModelID:        embeddingModelID(cfg, embeddingProvider),
+			EmbeddingDocumentPrefix: cfg.Embedding.DocumentPrefix,
+			NameEmbeddings:          cfg.Embedding.NameEmbeddings,
+			HNSWDistance:            cfg.Embedding.Distance,
+			LocalEngine:             cfg.StorageEngine(),
+			LocalSharded:            cfg.Storage.Sharded,
+			KeepSnapshots:           cfg.Storage.Snapshots,
+			AnalysisScripts:         analysisScriptPaths(repoPath, cfg.Indexing.AnalysisScripts),
+			Concurrency: ingestion.ConcurrencyConfig{
+				ParseWorkers: 4,
+				EmbedWorkers: embedWorkers,
+			},
+		},
+	}
+}
+
+// analysisScriptPaths resolves indexing.analysis_scripts against the
+// project root.
+func analysisScriptPaths(repoPath string, scripts []string) []string {
+	paths := make([]string, 0, len(scripts))
+	for _, script := range scripts {
+		if !filepath.IsAbs(script) {
+			script = filepath.Join(repoPath, script)
+		}
+		paths = append(paths, script)
+	}
+	return paths
+}
+
+// cleanShardNames drops empty --shard values and the slashes of "web/".
+func cleanShardNames(values []string) []string {
+	var shards []string
+	for _, name := range values {
+		if name = strings.Trim(filepath.ToSlash(name), "/"); name != "" {
+			shards = append(shards, name)
+		}
+	}
+	return shards
+}
+
+// languageLimits converts the per-language indexing settings of the config
+// file into ingestion overrides.
+func languageLimits(languages map[string]LanguageIndexingConfig) map[string]ingestion.LanguageLimits {
+	if len(languages) == 0 {
+		return nil
+	}
+	limits := make(map[string]ingestion.LanguageLimits, len(languages))
+	for language, lc := range languages {
+		limits[language] = ingestion.LanguageLimits{
+			MaxFileSizeBytes: lc.MaxFileSize,
+			MaxCodeTextBytes: lc.MaxCodeText,
+			ExcludeGlobs:     lc.Exclude,
+		}
+	}
+	return limits
+}
+
+// setEmbeddingEnv exports the configured embedding endpoint and model in the
+// environment variables the ingestion providers read.
+func setEmbeddingEnv(cfg *Config, embeddingProvider string) {
+	switch embeddingProvider {
+	case "ollama":
+		_ = os.Setenv("OLLAMA_BASE_URL", cfg.Embedding.BaseURL)
+		_ = os.Setenv("OLLAMA_EMBED_MODEL", cfg.Embedding.Model)
+	case "openai":
+		_ = os.Setenv("OPENAI_API_BASE", cfg.Embedding.BaseURL)
+		_ = os.Setenv("OPENAI_EMBED_MODEL", cfg.Embedding.Model)
+		if cfg.Embedding.APIKey != "" {
+			_ = os.Setenv("OPENAI_API_KEY", cfg.Embedding.APIKey)
+		}
+	}
+}
+
+// embeddingCachePath returns the shared embedding cache location, or "" when
+// the cache is disabled. The mock provider is cheap and deterministic, so
+// caching it would only fill the cache with test vectors.
+func embeddingCachePath(cfg *Config, embeddingProvider string) string {
+	if cfg.Embedding.DisableCache || embeddingProvider == "mock" {
+		return ""
+	}
+	path, err := storage.DefaultEmbeddingCachePath()
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// embeddingModelID identifies the vectors a configuration produces: the same
+// text embedded by another provider, model or dimension count must not hit.
+// The pipeline adds the document prefix it resolves for the provider.
+func embeddingModelID(cfg *Config, embeddingProvider string) string {
+	return fmt.Sprintf("%s/%s/%d", embeddingProvider, cfg.Embedding.Model, cfg.Embedding.Dimensions)
 }
 
 // phaseDescription returns a human-readable description for each pipeline phase.
@@ -380,6 +593,8 @@ func phaseDescription(phase string) string {
 		return "Embedding types"
 	case "writing":
 		return "Writing to database"
+	case "backfill":
+		return "Backfilling embeddings"
 	default:
 		return phase
 	}
@@ -451,10 +666,26 @@ func printResult(result *ingestion.IngestionResult) {
 	if result.EmbeddingErrors > 0 {
 		_, _ = ui.Yellow.Printf("Embedding Errors: %d\n", result.EmbeddingErrors)
 	}
+	if result.EmbeddingCacheHits > 0 {
+		fmt.Printf("Embeddings Reused: %s %s\n", ui.CountText(result.EmbeddingCacheHits), ui.DimText("(shared cache)"))
+	}
 	if result.CodeTextTruncated > 0 {
 		_, _ = ui.Dim.Printf("CodeText Truncated: %d\n", result.CodeTextTruncated)
 	}
 
+	if len(result.ScriptMetrics) > 0 {
+		fmt.Println()
+		ui.SubHeader("Script Metrics:")
+		names := make([]string, 0, len(result.ScriptMetrics))
+		for name := range result.ScriptMetrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("  %s: %s\n", name, ui.DimText(strconv.FormatFloat(result.ScriptMetrics[name], 'g', -1, 64)))
+		}
+	}
+
 	if len(result.TopSkipReasons) > 0 {
 		fmt.Println()
 		ui.SubHeader("Skipped Files:")
@@ -0,0 +1,278 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/internal/output"
+	"github.com/kraklabs/cie/internal/ui"
+	"github.com/kraklabs/cie/pkg/artifact"
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// IndexArtifactOutput is the JSON shape of `cie push-index` and
+// `cie pull-index`.
+type IndexArtifactOutput struct {
+	Ref      string         `json:"ref"`
+	Manifest *IndexManifest `json:"manifest"`
+	DataDir  string         `json:"data_dir,omitempty"`
+}
+
+// indexArtifactUsageRefs documents the references the artifact commands
+// accept.
+const indexArtifactUsageRefs = `References:
+  registry.example.com/team/repo:tag   OCI registry (oci:// prefix optional)
+  s3://bucket/path/index.tar.zst       S3 or an S3-compatible service
+  file:///shared/indexes/repo.tar.zst  Local or mounted path
+
+  Registries use CIE_REGISTRY_USERNAME/CIE_REGISTRY_PASSWORD or the
+  credentials saved by 'docker login'. S3 uses AWS_ACCESS_KEY_ID,
+  AWS_SECRET_ACCESS_KEY, AWS_REGION and, for other services,
+  AWS_ENDPOINT_URL.
+`
+
+// runPushIndex executes the 'push-index' CLI command: package the local
+// index with its manifest and upload it to an artifact store.
+func runPushIndex(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("push-index", flag.ExitOnError)
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: cie push-index [options] <ref>
+
+Description:
+  Package the local index of the current project, with its manifest, and
+  upload it so that other machines can fetch it with 'cie pull-index'
+  instead of indexing the repository themselves. Run it from CI after
+  'cie index', typically on a nightly schedule.
+
+Options:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+%s
+Examples:
+  cie push-index ghcr.io/acme/api-index:nightly
+  cie push-index s3://acme-ci/cie/api.tar.zst
+
+`, indexArtifactUsageRefs)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+	store, err := artifact.Open(fs.Arg(0))
+	if err != nil {
+		errors.FatalError(errors.NewInputError("Invalid artifact reference", err.Error(), "See 'cie push-index --help' for the accepted references"), globals.JSON)
+	}
+
+	ctx := context.Background()
+	tmp, err := os.MkdirTemp("", "cie-push-")
+	if err != nil {
+		errors.FatalError(errors.NewInternalError("Cannot create a temporary directory", err.Error(), "", err), globals.JSON)
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	m, err := backupIndex(ctx, cfg, filepath.Join(tmp, artifact.DatabaseEntry))
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Cannot read the index",
+			err.Error(),
+			"Run 'cie index' first, and stop 'cie serve' or 'cie --mcp' if the database is locked",
+			err,
+		), globals.JSON)
+	}
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		errors.FatalError(errors.NewInternalError("Cannot encode the manifest", err.Error(), "", err), globals.JSON)
+	}
+	bundle := filepath.Join(tmp, cfg.ProjectID+".tar.zst")
+	if err := artifact.WriteBundle(bundle, manifest, filepath.Join(tmp, artifact.DatabaseEntry)); err != nil {
+		errors.FatalError(errors.NewInternalError("Cannot package the index", err.Error(), "Check free space in the temporary directory", err), globals.JSON)
+	}
+
+	if !globals.Quiet {
+		ui.Infof("Uploading index of %s (commit %s) to %s...", cfg.ProjectID, shortSHA(m.Commit), store)
+	}
+	if err := store.Push(ctx, bundle, manifest); err != nil {
+		errors.FatalError(errors.NewNetworkError(
+			"Cannot upload the index",
+			err.Error(),
+			"Check the reference and the credentials for the artifact store",
+			err,
+		), globals.JSON)
+	}
+
+	if globals.JSON {
+		_ = output.JSON(IndexArtifactOutput{Ref: store.String(), Manifest: m})
+		return
+	}
+	ui.Successf("Pushed index of %s to %s", cfg.ProjectID, store)
+}
+
+// backupIndex writes a backup of the project's index to path and returns
+// the manifest describing it.
+func backupIndex(ctx context.Context, cfg *Config, path string) (*IndexManifest, error) {
+	backend, err := openProjectBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = backend.Close() }()
+	m, err := buildIndexManifest(ctx, backend, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := backend.Backup(path); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// runPullIndex executes the 'pull-index' CLI command: download an index
+// pushed by 'cie push-index' and install it as the project's local index.
+func runPullIndex(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("pull-index", flag.ExitOnError)
+	force := fs.Bool("force", false, "Replace an existing local index, and accept an index built with a different configuration")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: cie pull-index [options] <ref>
+
+Description:
+  Download an index pushed by 'cie push-index' and install it as the local
+  index of the current project, in ~/.cie/data/<project_id>/. Run
+  'cie index' afterwards to catch it up with your checkout; only files
+  changed since the indexed commit are re-indexed.
+
+  The index is checked against .cie/project.yaml first: one built for
+  another project, schema, configuration or embedding model is refused
+  unless --force is given.
+
+Options:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+%s
+Examples:
+  cie pull-index ghcr.io/acme/api-index:nightly && cie index
+  cie pull-index --force s3://acme-ci/cie/api.tar.zst
+
+`, indexArtifactUsageRefs)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+	store, err := artifact.Open(fs.Arg(0))
+	if err != nil {
+		errors.FatalError(errors.NewInputError("Invalid artifact reference", err.Error(), "See 'cie pull-index --help' for the accepted references"), globals.JSON)
+	}
+	cieDir, err := getCIEDir()
+	if err != nil {
+		errors.FatalError(errors.NewInternalError("Failed to find CIE directory", err.Error(), "", err), globals.JSON)
+	}
+	dataDir := filepath.Join(cieDir, "data", cfg.ProjectID)
+	if _, err := os.Stat(dataDir); err == nil && !*force {
+		errors.FatalError(errors.NewInputError(
+			"A local index already exists",
+			dataDir,
+			"Run 'cie pull-index --force' to replace it; notes, facts, collections and snapshots are kept",
+		), globals.JSON)
+	}
+
+	tmp, err := os.MkdirTemp(filepath.Dir(dataDir), ".pull-")
+	if err != nil {
+		errors.FatalError(errors.NewInternalError("Cannot create a temporary directory", err.Error(), "", err), globals.JSON)
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	if !globals.Quiet {
+		ui.Infof("Downloading %s...", store)
+	}
+	bundle := filepath.Join(tmp, "index.tar.zst")
+	if err := store.Pull(context.Background(), bundle); err != nil {
+		errors.FatalError(errors.NewNetworkError(
+			"Cannot download the index",
+			err.Error(),
+			"Check the reference and the credentials for the artifact store",
+			err,
+		), globals.JSON)
+	}
+	manifestData, backup, err := artifact.ReadBundle(bundle, tmp)
+	if err != nil {
+		errors.FatalError(errors.NewInputError("Cannot unpack the index", err.Error(), "Make sure the reference was written by 'cie push-index'"), globals.JSON)
+	}
+	var m IndexManifest
+	if err := json.Unmarshal(manifestData, &m); err != nil {
+		errors.FatalError(errors.NewInputError("Cannot read the index manifest", err.Error(), "Make sure the reference was written by 'cie push-index'"), globals.JSON)
+	}
+	if reasons := manifestMismatches(&m, cfg, ""); len(reasons) > 0 && !*force {
+		errors.FatalError(errors.NewInputError(
+			"The downloaded index does not match this project",
+			fmt.Sprintf("%v", reasons),
+			"Push an index built with the same configuration, or pass --force and run 'cie index --full'",
+		), globals.JSON)
+	}
+
+	staging := filepath.Join(tmp, "data")
+	if err := storage.RestoreBackup(backup, staging, cfg.StorageEngine()); err != nil {
+		errors.FatalError(errors.NewDatabaseError("Cannot install the index", err.Error(), "Check free space in ~/.cie/data", err), globals.JSON)
+	}
+	if err := installStagedIndex(dataDir, staging, cfg.StorageEngine(), cfg.Storage.Snapshots); err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Cannot install the index",
+			err.Error(),
+			openFailureFix(err, "Stop 'cie serve' or 'cie --mcp' and retry. The previous index was left in place"),
+			err,
+		), globals.JSON)
+	}
+
+	if globals.JSON {
+		_ = output.JSON(IndexArtifactOutput{Ref: store.String(), Manifest: &m, DataDir: dataDir})
+		return
+	}
+	ui.Successf("Installed index of %s at commit %s (%d functions)", m.ProjectID, shortSHA(m.Commit), m.Counts["functions"])
+	fmt.Println()
+	fmt.Println("Next steps:")
+	fmt.Println("  cie index    Catch the index up with your checkout")
+}
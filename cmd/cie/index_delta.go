@@ -0,0 +1,389 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/internal/output"
+	"github.com/kraklabs/cie/internal/ui"
+	"github.com/kraklabs/cie/pkg/artifact"
+	cozo "github.com/kraklabs/cie/pkg/cozodb"
+	"github.com/kraklabs/cie/pkg/ingestion"
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// IndexDelta is the delta.json of a delta bundle: what an index at Commit
+// holds for every file that changed since BaseCommit. Applying it to an
+// index at BaseCommit replaces the rows of Files with Relations.
+type IndexDelta struct {
+	BaseCommit string                   `json:"base_commit"`
+	Commit     string                   `json:"commit"`
+	Files      []string                 `json:"files"`
+	Relations  map[string]DeltaRelation `json:"relations"`
+}
+
+// DeltaRelation holds the rows of one relation in a delta.
+type DeltaRelation struct {
+	Headers []string `json:"headers"`
+	Rows    [][]any  `json:"rows"`
+}
+
+// IndexDeltaOutput is the JSON shape of `cie export` and `cie import`.
+type IndexDeltaOutput struct {
+	Ref        string `json:"ref"`
+	BaseCommit string `json:"base_commit"`
+	Commit     string `json:"commit"`
+	Files      int    `json:"files"`
+	Rows       int    `json:"rows"`
+	UpToDate   bool   `json:"up_to_date,omitempty"`
+}
+
+// rowCount returns the number of rows carried by d.
+func (d *IndexDelta) rowCount() int {
+	n := 0
+	for _, rel := range d.Relations {
+		n += len(rel.Rows)
+	}
+	return n
+}
+
+// encodeIndexDelta serializes d for a delta bundle.
+func encodeIndexDelta(d *IndexDelta) ([]byte, error) {
+	return json.Marshal(d)
+}
+
+// decodeIndexDelta parses a delta.json. Numbers are kept as written so
+// integer columns are not turned into floats on their way back into the
+// database.
+func decodeIndexDelta(data []byte) (*IndexDelta, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var d IndexDelta
+	if err := dec.Decode(&d); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", artifact.DeltaEntry, err)
+	}
+	if d.Commit == "" || d.BaseCommit == "" {
+		return nil, fmt.Errorf("parse %s: base_commit and commit are required", artifact.DeltaEntry)
+	}
+	return &d, nil
+}
+
+// runExport executes the 'export' CLI command: write the rows of the files
+// changed since a commit as a delta bundle.
+func runExport(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	since := fs.String("since", "", "Commit the receiving index was built at (required)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: cie export --since <sha> [options] <ref>
+
+Description:
+  Write a delta bundle holding what the local index stores for every file
+  changed between --since and the indexed commit. 'cie import' applies it
+  to an index built at --since, so a daily sync downloads only what
+  changed instead of the whole index ('cie push-index').
+
+  Run it in the checkout the index was built from; both commits must be in
+  its history.
+
+Options:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+%s
+Examples:
+  cie export --since $(git rev-parse HEAD~1) delta.tar.zst
+  cie export --since $LAST_NIGHTLY ghcr.io/acme/api-index:delta-$LAST_NIGHTLY
+
+`, indexArtifactUsageRefs)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+	if fs.NArg() != 1 || *since == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+	store, err := artifact.Open(fs.Arg(0))
+	if err != nil {
+		errors.FatalError(errors.NewInputError("Invalid artifact reference", err.Error(), "See 'cie export --help' for the accepted references"), globals.JSON)
+	}
+
+	ctx := context.Background()
+	backend, err := openProjectBackend(cfg)
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Cannot open the index",
+			err.Error(),
+			openFailureFix(err, "Run 'cie index' first, and stop 'cie serve' or 'cie --mcp' if the database is locked"),
+			err,
+		), globals.JSON)
+	}
+	m, delta, err := buildIndexDelta(ctx, backend, cfg, *since)
+	_ = backend.Close()
+	if err != nil {
+		errors.FatalError(errors.NewInputError(
+			"Cannot export the delta",
+			err.Error(),
+			"Make sure --since and the indexed commit are both in this checkout's history (fetch-depth: 0 in CI)",
+		), globals.JSON)
+	}
+
+	tmp, err := os.MkdirTemp("", "cie-export-")
+	if err != nil {
+		errors.FatalError(errors.NewInternalError("Cannot create a temporary directory", err.Error(), "", err), globals.JSON)
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err == nil {
+		var data []byte
+		if data, err = encodeIndexDelta(delta); err == nil {
+			err = artifact.WriteDeltaBundle(filepath.Join(tmp, "delta.tar.zst"), manifest, data)
+		}
+	}
+	if err != nil {
+		errors.FatalError(errors.NewInternalError("Cannot package the delta", err.Error(), "Check free space in the temporary directory", err), globals.JSON)
+	}
+	if err := store.Push(ctx, filepath.Join(tmp, "delta.tar.zst"), manifest); err != nil {
+		errors.FatalError(errors.NewNetworkError(
+			"Cannot upload the delta",
+			err.Error(),
+			"Check the reference and the credentials for the artifact store",
+			err,
+		), globals.JSON)
+	}
+
+	out := IndexDeltaOutput{Ref: store.String(), BaseCommit: delta.BaseCommit, Commit: delta.Commit, Files: len(delta.Files), Rows: delta.rowCount()}
+	if globals.JSON {
+		_ = output.JSON(out)
+		return
+	}
+	ui.Successf("Exported %d changed files (%d rows) from %s to %s to %s",
+		out.Files, out.Rows, shortSHA(out.BaseCommit), shortSHA(out.Commit), store)
+}
+
+// buildIndexDelta collects the rows of the files changed between since and
+// the commit the index in backend was built at.
+func buildIndexDelta(ctx context.Context, backend *storage.EmbeddedBackend, cfg *Config, since string) (*IndexManifest, *IndexDelta, error) {
+	m, err := buildIndexManifest(ctx, backend, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if m.Commit == "" {
+		return nil, nil, fmt.Errorf("the index has no indexed commit; run 'cie index' in a git repository")
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, nil, err
+	}
+	dd := ingestion.NewDeltaDetector(cwd, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	diff, err := dd.DetectDelta(since, m.Commit)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := backend.ExportFileRows(ctx, diff.All)
+	if err != nil {
+		return nil, nil, err
+	}
+	delta := &IndexDelta{
+		BaseCommit: diff.BaseSHA,
+		Commit:     m.Commit,
+		Files:      diff.All,
+		Relations:  make(map[string]DeltaRelation, len(rows)),
+	}
+	for name, named := range rows {
+		delta.Relations[name] = DeltaRelation{Headers: named.Headers, Rows: named.Rows}
+	}
+	return m, delta, nil
+}
+
+// openProjectBackend opens the configured project's local index.
+func openProjectBackend(cfg *Config) (*storage.EmbeddedBackend, error) {
+	cieDir, err := getCIEDir()
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewEmbeddedBackend(storage.EmbeddedConfig{
+		DataDir:             filepath.Join(cieDir, "data", cfg.ProjectID),
+		Engine:              cfg.StorageEngine(),
+		Sharded:             cfg.Storage.Sharded,
+		ProjectID:           cfg.ProjectID,
+		EmbeddingDimensions: cfg.Embedding.Dimensions,
+	})
+}
+
+// runImport executes the 'import' CLI command: apply a delta bundle written
+// by 'cie export' to the local index.
+func runImport(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	force := fs.Bool("force", false, "Apply even if the local index is at another commit or was built with a different configuration")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: cie import [options] <ref>
+
+Description:
+  Apply a delta bundle written by 'cie export' to the local index of the
+  current project. The index must be at the delta's base commit, for
+  example one fetched with 'cie pull-index' or brought up to date by an
+  earlier import; afterwards it is at the delta's commit.
+
+  The changed files' rows are replaced in one transaction, so a failed
+  import leaves the index as it was.
+
+Options:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+%s
+Examples:
+  cie import delta.tar.zst
+  cie import ghcr.io/acme/api-index:delta-3f2a9c1 && cie index
+
+`, indexArtifactUsageRefs)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+	store, err := artifact.Open(fs.Arg(0))
+	if err != nil {
+		errors.FatalError(errors.NewInputError("Invalid artifact reference", err.Error(), "See 'cie import --help' for the accepted references"), globals.JSON)
+	}
+
+	tmp, err := os.MkdirTemp("", "cie-import-")
+	if err != nil {
+		errors.FatalError(errors.NewInternalError("Cannot create a temporary directory", err.Error(), "", err), globals.JSON)
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	ctx := context.Background()
+	bundle := filepath.Join(tmp, "delta.tar.zst")
+	if err := store.Pull(ctx, bundle); err != nil {
+		errors.FatalError(errors.NewNetworkError(
+			"Cannot download the delta",
+			err.Error(),
+			"Check the reference and the credentials for the artifact store",
+			err,
+		), globals.JSON)
+	}
+	manifestData, deltaData, err := artifact.ReadDeltaBundle(bundle)
+	var m IndexManifest
+	var delta *IndexDelta
+	if err == nil {
+		if err = json.Unmarshal(manifestData, &m); err == nil {
+			delta, err = decodeIndexDelta(deltaData)
+		}
+	}
+	if err != nil {
+		errors.FatalError(errors.NewInputError("Cannot read the delta", err.Error(), "Make sure the reference was written by 'cie export'"), globals.JSON)
+	}
+	if reasons := manifestMismatches(&m, cfg, ""); len(reasons) > 0 && !*force {
+		errors.FatalError(errors.NewInputError(
+			"The delta does not match this project",
+			strings.Join(reasons, "; "),
+			"Pull a full index with 'cie pull-index' instead",
+		), globals.JSON)
+	}
+
+	backend, err := openProjectBackend(cfg)
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Cannot open the index",
+			err.Error(),
+			openFailureFix(err, "Stop 'cie serve' or 'cie --mcp' if the database is locked"),
+			err,
+		), globals.JSON)
+	}
+	defer func() { _ = backend.Close() }()
+
+	out := IndexDeltaOutput{Ref: store.String(), BaseCommit: delta.BaseCommit, Commit: delta.Commit, Files: len(delta.Files), Rows: delta.rowCount()}
+	local, err := backend.GetLastIndexedSHA()
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError("Cannot read the indexed commit", err.Error(), "Run 'cie repair' if the index is damaged", err), globals.JSON)
+	}
+	switch {
+	case local == delta.Commit:
+		out.UpToDate = true
+	case local != delta.BaseCommit && !*force:
+		errors.FatalError(errors.NewInputError(
+			"The delta does not start at the local index's commit",
+			fmt.Sprintf("local index is at %s, delta goes from %s to %s", shortSHA(local), shortSHA(delta.BaseCommit), shortSHA(delta.Commit)),
+			"Import the deltas in order, or fetch a full index with 'cie pull-index --force'",
+		), globals.JSON)
+	default:
+		if err := applyIndexDelta(ctx, backend, delta); err != nil {
+			errors.FatalError(errors.NewDatabaseError("Cannot apply the delta", err.Error(), "The index was left unchanged; retry or run 'cie index'", err), globals.JSON)
+		}
+	}
+
+	if globals.JSON {
+		_ = output.JSON(out)
+		return
+	}
+	if out.UpToDate {
+		ui.Infof("The index is already at %s", shortSHA(delta.Commit))
+		return
+	}
+	ui.Successf("Applied %d changed files (%d rows); the index is now at %s", out.Files, out.Rows, shortSHA(out.Commit))
+}
+
+// applyIndexDelta writes delta to backend and records its commit.
+func applyIndexDelta(ctx context.Context, backend *storage.EmbeddedBackend, delta *IndexDelta) error {
+	rows := make(map[string]cozo.NamedRows, len(delta.Relations))
+	for name, rel := range delta.Relations {
+		rows[name] = cozo.NamedRows{Headers: rel.Headers, Rows: rel.Rows}
+	}
+	if err := backend.ApplyFileRows(ctx, delta.Files, rows); err != nil {
+		return err
+	}
+	if err := backend.SetLastIndexedSHA(delta.Commit); err != nil {
+		return fmt.Errorf("record indexed commit: %w", err)
+	}
+	if _, err := backend.BumpIndexVersion(); err != nil {
+		return fmt.Errorf("bump index version: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestIndexDeltaRoundTrip(t *testing.T) {
+	d := &IndexDelta{
+		BaseCommit: "aaaa",
+		Commit:     "bbbb",
+		Files:      []string{"a.go", "b.go"},
+		Relations: map[string]DeltaRelation{
+			"cie_file": {Headers: []string{"id", "path", "hash", "language", "size"}, Rows: [][]any{{"file:a", "a.go", "h", "go", 12}}},
+		},
+	}
+	data, err := encodeIndexDelta(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodeIndexDelta(data)
+	if err != nil {
+		t.Fatalf("decodeIndexDelta: %v", err)
+	}
+	if got.BaseCommit != "aaaa" || got.Commit != "bbbb" || len(got.Files) != 2 || got.rowCount() != 1 {
+		t.Errorf("decoded %+v", got)
+	}
+	// Integers come back as written, not as float64.
+	size := got.Relations["cie_file"].Rows[0][4]
+	if n, ok := size.(json.Number); !ok || n.String() != "12" {
+		t.Errorf("size = %#v, want json.Number 12", size)
+	}
+}
+
+func TestDecodeIndexDeltaRequiresCommits(t *testing.T) {
+	if _, err := decodeIndexDelta([]byte(`{"files":["a.go"]}`)); err == nil {
+		t.Error("accepted a delta without commits")
+	}
+	if _, err := decodeIndexDelta([]byte(`not json`)); err == nil {
+		t.Error("accepted malformed JSON")
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/internal/ui"
+	"github.com/kraklabs/cie/pkg/ingestion"
+)
+
+// startCPUProfile begins a pprof CPU profile written to path and returns the
+// function that stops it.
+func startCPUProfile(path string, globals GlobalFlags) func() {
+	f, err := os.Create(path) //nolint:gosec // user-provided output path
+	if err != nil {
+		errors.FatalError(errors.NewPermissionError(
+			"Cannot create CPU profile",
+			fmt.Sprintf("Failed to create %s", path),
+			"Check that the directory exists and is writable",
+			err,
+		), globals.JSON)
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		_ = f.Close()
+		errors.FatalError(errors.NewInternalError(
+			"Cannot start CPU profile",
+			"The Go runtime refused to start profiling",
+			"Run without --cpuprofile",
+			err,
+		), globals.JSON)
+	}
+	return func() {
+		pprof.StopCPUProfile()
+		_ = f.Close()
+		if !globals.Quiet {
+			ui.Infof("CPU profile written to %s (inspect with 'go tool pprof %s')", path, path)
+		}
+	}
+}
+
+// writeIndexProfile saves the --timings report as JSON and prints a short
+// summary of where the time went.
+func writeIndexProfile(report *ingestion.ProfileReport, path string, globals GlobalFlags) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err == nil {
+		if mkErr := os.MkdirAll(filepath.Dir(path), 0750); mkErr != nil {
+			err = mkErr
+		} else {
+			err = os.WriteFile(path, data, 0600)
+		}
+	}
+	if err != nil {
+		errors.FatalError(errors.NewPermissionError(
+			"Cannot write indexing profile",
+			fmt.Sprintf("Failed to write %s", path),
+			"Pass --timings-output with a writable path",
+			err,
+		), globals.JSON)
+	}
+	if globals.Quiet {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("Indexing Profile")
+	fmt.Println("================")
+	for _, st := range report.Stages {
+		fmt.Printf("  %-16s %10.0f ms  %5.1f%%\n", st.Name, st.DurationMs, st.Percent)
+	}
+	if e := report.Embeddings; e.Calls > 0 {
+		fmt.Printf("  embeddings       %d calls, p50 %.0f ms, p90 %.0f ms, p99 %.0f ms, %d errors\n",
+			e.Calls, e.P50Ms, e.P90Ms, e.P99Ms, e.Errors)
+	}
+	for _, lt := range report.Languages {
+		fmt.Printf("  %-16s %d files, %.1f files/s, %.2f MB/s\n", lt.Language, lt.Files, lt.FilesPerSec, lt.MBPerSec)
+	}
+	if len(report.SlowestFiles) > 0 {
+		top := report.SlowestFiles[0]
+		fmt.Printf("  slowest file     %s (%.0f ms)\n", top.Path, top.DurationMs)
+	}
+	ui.Infof("Full report written to %s", path)
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+const defaultIndexWatchInterval = 10 * time.Second
+
+// IndexWatchConfig controls how the MCP server notices that the index was
+// rebuilt by another process.
+type IndexWatchConfig struct {
+	Disabled        bool `yaml:"disabled,omitempty"`
+	IntervalSeconds int  `yaml:"interval_seconds,omitempty"` // default 10
+}
+
+// interval returns how often to poll the index version, or 0 when disabled.
+func (c IndexWatchConfig) interval() time.Duration {
+	if c.Disabled {
+		return 0
+	}
+	if c.IntervalSeconds > 0 {
+		return time.Duration(c.IntervalSeconds) * time.Second
+	}
+	return defaultIndexWatchInterval
+}
+
+// indexUpdate is the payload of the notification sent after a reindex. It
+// carries both snapshots so an agent can tell how much changed and which
+// commit the index now reflects.
+type indexUpdate struct {
+	Event     string              `json:"event"` // always "index_updated"
+	ProjectID string              `json:"project_id"`
+	Message   string              `json:"message"`
+	Previous  tools.IndexSnapshot `json:"previous"`
+	Current   tools.IndexSnapshot `json:"current"`
+}
+
+// pollIndex compares the index version with the last one seen and, when it
+// changed, refreshes the session state and describes the change. The first
+// call only records the baseline. It is called from a single goroutine.
+func (s *mcpServer) pollIndex(ctx context.Context) *indexUpdate {
+	if s.indexSeen == nil {
+		baseline := tools.ReadIndexSnapshot(ctx, s.client)
+		s.indexSeen = &baseline
+		return nil
+	}
+	version := tools.IndexVersion(ctx, s.client)
+	// An empty version means the read failed (or the index predates
+	// versions); neither is evidence of a rebuild.
+	if version == "" || version == s.indexSeen.Version {
+		return nil
+	}
+
+	previous := *s.indexSeen
+	current := tools.ReadIndexSnapshot(ctx, s.client)
+	s.indexSeen = &current
+	s.refreshAfterReindex()
+
+	return &indexUpdate{
+		Event:     "index_updated",
+		ProjectID: s.projectID,
+		Message:   describeIndexChange(s.projectID, previous, current),
+		Previous:  previous,
+		Current:   current,
+	}
+}
+
+// refreshAfterReindex drops state computed against the previous index.
+func (s *mcpServer) refreshAfterReindex() {
+	s.cache.reset()
+	s.freshness.reset()
+	s.live.reset()
+	if s.rewarm && s.warmup != nil {
+		s.warmup.start(s.client, true)
+	}
+}
+
+// describeIndexChange summarizes a rebuild in one line.
+func describeIndexChange(projectID string, prev, cur tools.IndexSnapshot) string {
+	msg := fmt.Sprintf("Index for %s was rebuilt: %d files (%+d), %d functions (%+d)",
+		projectID, cur.Files, cur.Files-prev.Files, cur.Functions, cur.Functions-prev.Functions)
+	if cur.Commit != "" && cur.Commit != prev.Commit {
+		msg += fmt.Sprintf(", now at commit %s", tools.ShortCommit(cur.Commit))
+	}
+	return msg + ". Earlier results may be stale."
+}
+
+// watchIndex polls every project served by s until ctx is cancelled and
+// calls notify for each rebuild it detects.
+func (s *mcpServer) watchIndex(ctx context.Context, notify func(method string, params any)) {
+	servers := []*mcpServer{s}
+	if s.projects != nil {
+		servers = servers[:0]
+		for _, name := range s.projectNames() {
+			servers = append(servers, s.projects[name])
+		}
+	}
+
+	ticker := time.NewTicker(s.indexWatch)
+	defer ticker.Stop()
+	for {
+		for _, srv := range servers {
+			update := srv.pollIndex(ctx)
+			if update == nil {
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "  %s\n", update.Message)
+			if s.logLevel.allows(mcpLogInfo) {
+				notify("notifications/message", mcpLogMessage{Level: mcpLogInfo, Logger: mcpServerName, Data: update})
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
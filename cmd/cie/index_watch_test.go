@@ -0,0 +1,141 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// indexStateQuerier serves the project metadata and counts the index
+// watcher reads, with a version tests can change mid-session.
+type indexStateQuerier struct {
+	mu        sync.Mutex
+	version   string
+	functions int
+}
+
+func (q *indexStateQuerier) rebuild(version string, functions int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.version, q.functions = version, functions
+}
+
+func (q *indexStateQuerier) Query(_ context.Context, script string) (*tools.QueryResult, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	switch {
+	case strings.Contains(script, `"index_version"`):
+		return &tools.QueryResult{Headers: []string{"value"}, Rows: [][]any{{q.version}}}, nil
+	case strings.Contains(script, `"last_indexed_sha"`):
+		return &tools.QueryResult{Headers: []string{"value"}, Rows: [][]any{{"0123456789abcdef0123"}}}, nil
+	case strings.Contains(script, "*cie_function {"):
+		return &tools.QueryResult{Headers: []string{"count"}, Rows: [][]any{{float64(q.functions)}}}, nil
+	}
+	return &tools.QueryResult{}, nil
+}
+
+func (q *indexStateQuerier) QueryRaw(ctx context.Context, script string) (map[string]any, error) {
+	return nil, nil
+}
+
+func TestPollIndex(t *testing.T) {
+	q := &indexStateQuerier{version: "1", functions: 10}
+	cache := newResultCache(CacheConfig{})
+	s := &mcpServer{client: q, projectID: "demo", cache: cache}
+	ctx := context.Background()
+
+	if u := s.pollIndex(ctx); u != nil {
+		t.Fatalf("first poll should record the baseline, got %+v", u)
+	}
+	if u := s.pollIndex(ctx); u != nil {
+		t.Fatalf("unchanged version reported as rebuild: %+v", u)
+	}
+
+	cache.put("k", "1", &mcpToolResult{})
+	q.rebuild("2", 14)
+	u := s.pollIndex(ctx)
+	if u == nil {
+		t.Fatal("version change was not detected")
+	}
+	if u.Previous.Functions != 10 || u.Current.Functions != 14 || u.Current.Version != "2" {
+		t.Errorf("snapshots = %+v -> %+v", u.Previous, u.Current)
+	}
+	if !strings.Contains(u.Message, "14 functions (+4)") || strings.Contains(u.Message, "commit") {
+		t.Errorf("message = %q (commit did not change)", u.Message)
+	}
+	if len(cache.entries) != 0 {
+		t.Error("cache should be emptied after a rebuild")
+	}
+
+	q.rebuild("", 14)
+	if u := s.pollIndex(ctx); u != nil {
+		t.Errorf("an unreadable version must not count as a rebuild: %+v", u)
+	}
+}
+
+func TestMCPServer_IndexUpdateNotification(t *testing.T) {
+	q := &indexStateQuerier{version: "1", functions: 3}
+	c := newMCPTestClient(t, &mcpServer{
+		client:     q,
+		projectID:  "demo",
+		indexWatch: 10 * time.Millisecond,
+		indexSeen:  &tools.IndexSnapshot{Version: "1", Functions: 3},
+	})
+
+	if init := c.Initialize(); init.Capabilities.Logging == nil {
+		t.Error("initialize should declare the logging capability")
+	}
+	q.rebuild("2", 5)
+
+	n := c.Notification()
+	if n.Method != "notifications/message" {
+		t.Fatalf("method = %q", n.Method)
+	}
+	var msg struct {
+		Level string      `json:"level"`
+		Data  indexUpdate `json:"data"`
+	}
+	if err := json.Unmarshal(n.Params, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Level != "info" || msg.Data.Event != "index_updated" || msg.Data.Current.Functions != 5 {
+		t.Errorf("notification = %+v", msg)
+	}
+}
+
+func TestMCPLogLevel(t *testing.T) {
+	var l *mcpLogLevel
+	if !l.allows("debug") {
+		t.Error("nil level should allow everything")
+	}
+	l = &mcpLogLevel{}
+	if !l.set("warning") || l.allows("info") || !l.allows("error") {
+		t.Error("warning should filter info and pass error")
+	}
+	if l.set("loud") {
+		t.Error("unknown level accepted")
+	}
+}
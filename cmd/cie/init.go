@@ -40,7 +40,7 @@ import (
 //
 // It creates the configuration directory, generates a default configuration, and optionally
 // prompts the user for customization in interactive mode. The command can also install
-// a git post-commit hook for automatic re-indexing.
+// git hooks for automatic re-indexing.
 //
 // Flags:
 //   - --force: Overwrite existing configuration (default: false)
@@ -49,7 +49,9 @@ import (
 //   - --ip: CIE server IP for Tailscale/NodePort setup (sets edge-cache and primary-hub)
 //   - --edge-cache: Edge Cache URL (overrides --ip)
 //   - --primary-hub: Primary Hub gRPC address (overrides --ip)
-//   - --embedding-provider: Embedding provider (ollama, nomic, mock)
+//   - --provider: Embedding provider (ollama, openai, nomic, llamacpp, mock)
+//   - --language: Add exclude templates for these languages (go, ts, js, python)
+//   - --engine: Local storage engine (rocksdb, sqlite)
 //   - --no-hook: Skip git hook installation
 //   - --hook: Install git hook without prompting
 //
@@ -59,12 +61,14 @@ import (
 //	cie init -y                        Use all defaults
 //	cie init --ip 100.117.59.45        Configure with Tailscale IP
 //	cie init --hook                    Initialize and install git hook
+//	cie init -y --language go,ts --provider ollama --engine rocksdb --no-hook
 //
 // initFlags holds parsed flags for the init command.
 type initFlags struct {
 	force, nonInteractive, noHook, withHook bool
 	projectID, serverIP, edgeCache          string
-	primaryHub, embeddingProvider           string
+	primaryHub, embeddingProvider, engine   string
+	languages                               []string
 }
 
 func runInit(args []string, globals GlobalFlags) {
@@ -98,7 +102,10 @@ func runInit(args []string, globals GlobalFlags) {
 		), false)
 	}
 
-	cfg := createInitConfig(cwd, flags)
+	cfg, err := createInitConfig(cwd, flags)
+	if err != nil {
+		errors.FatalError(err, false)
+	}
 	reader := bufio.NewReader(os.Stdin)
 
 	if !flags.nonInteractive {
@@ -119,7 +126,11 @@ func parseInitFlags(args []string) initFlags {
 	fs.StringVar(&f.serverIP, "ip", "", "CIE server IP (sets edge-cache to http://IP:30080 and primary-hub to IP:30051)")
 	fs.StringVar(&f.edgeCache, "edge-cache", "", "Edge Cache URL (overrides --ip)")
 	fs.StringVar(&f.primaryHub, "primary-hub", "", "Primary Hub gRPC address (overrides --ip)")
-	fs.StringVar(&f.embeddingProvider, "embedding-provider", "", "Embedding provider (ollama, nomic, mock)")
+	fs.StringVar(&f.embeddingProvider, "provider", "", "Embedding provider (ollama, openai, nomic, llamacpp, mock)")
+	fs.StringVar(&f.embeddingProvider, "embedding-provider", "", "Embedding provider (alias of --provider)")
+	_ = fs.MarkHidden("embedding-provider")
+	fs.StringSliceVar(&f.languages, "language", nil, "Add exclude templates for these languages (go, typescript, javascript, python)")
+	fs.StringVar(&f.engine, "engine", "", "Local storage engine (rocksdb, sqlite)")
 	fs.BoolVar(&f.noHook, "no-hook", false, "Skip git hook installation (hook is installed by default)")
 	fs.BoolVar(&f.withHook, "hook", false, "Install git hook without prompting (for scripts)")
 
@@ -148,6 +159,9 @@ Examples:
   # Non-interactive with all defaults
   cie init -y
 
+  # Fully scripted bootstrap for CI or a devcontainer
+  cie init -y --language go,ts --provider ollama --engine rocksdb --no-hook
+
   # Configure for Tailscale/NodePort setup (sets edge-cache and primary-hub)
   cie init --ip 100.117.59.45
 
@@ -183,7 +197,7 @@ func applyServerIPDefaults(f *initFlags) {
 	}
 }
 
-func createInitConfig(cwd string, f initFlags) *Config {
+func createInitConfig(cwd string, f initFlags) (*Config, error) {
 	pid := f.projectID
 	if pid == "" {
 		pid = filepath.Base(cwd)
@@ -196,9 +210,22 @@ func createInitConfig(cwd string, f initFlags) *Config {
 		cfg.CIE.PrimaryHub = f.primaryHub
 	}
 	if f.embeddingProvider != "" {
-		cfg.Embedding.Provider = f.embeddingProvider
+		if err := applyProvider(cfg, f.embeddingProvider); err != nil {
+			return nil, err
+		}
 	}
-	return cfg
+	if f.engine != "" {
+		if err := validateEngine(f.engine); err != nil {
+			return nil, err
+		}
+		cfg.Storage.Engine = f.engine
+	}
+	langs, err := resolveLanguages(f.languages)
+	if err != nil {
+		return nil, err
+	}
+	applyLanguageTemplates(cfg, langs)
+	return cfg, nil
 }
 
 func runInteractiveConfig(reader *bufio.Reader, cfg *Config) {
@@ -262,12 +289,13 @@ func handleHookInstallation(reader *bufio.Reader, f initFlags) {
 		ui.Warningf("cannot find .git directory: %v", err)
 		return
 	}
-	hookPath := filepath.Join(gitDir, "hooks", "post-commit")
-	if err := installHook(hookPath, false); err != nil {
-		ui.Warningf("cannot install git hook: %v", err)
-	} else {
+	installed, err := installHooks(gitDir, defaultHooks, defaultHookMaxFiles, false)
+	for _, hookPath := range installed {
 		ui.Successf("Git hook installed: %s", hookPath)
 	}
+	if err != nil {
+		ui.Warningf("cannot install git hook: %v", err)
+	}
 }
 
 func printNextSteps(noHook bool) {
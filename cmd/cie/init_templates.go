@@ -0,0 +1,177 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kraklabs/cie/internal/errors"
+)
+
+// languageExcludes are the exclude templates `cie init --language` adds on top
+// of the defaults. They cover dependency caches, build output and generated
+// files that the built-in ingestion excludes do not already skip.
+var languageExcludes = map[string][]string{
+	"go": {
+		"testdata/**",
+		"mocks/**",
+		"*_mock.go",
+		"mock_*.go",
+	},
+	"typescript": {
+		"*.d.ts",
+		"*.map",
+		"__snapshots__/**",
+		".turbo/**",
+		"storybook-static/**",
+	},
+	"javascript": {
+		"*.map",
+		"__snapshots__/**",
+		"bower_components/**",
+		".turbo/**",
+	},
+	"python": {
+		"__pycache__/**",
+		".venv/**",
+		"venv/**",
+		".tox/**",
+		".mypy_cache/**",
+		".pytest_cache/**",
+		"*.egg-info/**",
+		"*.pyc",
+	},
+}
+
+// languageAliases maps the short names accepted by --language to template keys.
+var languageAliases = map[string]string{
+	"golang": "go",
+	"ts":     "typescript",
+	"tsx":    "typescript",
+	"js":     "javascript",
+	"jsx":    "javascript",
+	"py":     "python",
+}
+
+// providerDefaults are the embedding settings `cie init --provider` writes for
+// each provider. Ollama uses the DefaultConfig values.
+var providerDefaults = map[string]EmbeddingConfig{
+	"ollama":   {},
+	"openai":   {BaseURL: "https://api.openai.com/v1", Model: "text-embedding-3-small", Dimensions: 1536},
+	"nomic":    {BaseURL: "https://api-atlas.nomic.ai/v1", Model: "nomic-embed-text-v1.5", Dimensions: 768},
+	"llamacpp": {BaseURL: "http://localhost:8090", Model: "qodo-embed-1-1.5b", Dimensions: 1536},
+	"mock":     {BaseURL: "", Model: "mock", Dimensions: 768},
+}
+
+// storageEngines are the CozoDB engines that persist an index between runs.
+var storageEngines = []string{"rocksdb", "sqlite"}
+
+// resolveLanguages normalizes --language values (which may be comma-separated
+// or repeated) to template keys, dropping duplicates.
+func resolveLanguages(values []string) ([]string, error) {
+	var langs []string
+	seen := map[string]bool{}
+	for _, v := range values {
+		name := strings.ToLower(strings.TrimSpace(v))
+		if name == "" {
+			continue
+		}
+		if alias, ok := languageAliases[name]; ok {
+			name = alias
+		}
+		if _, ok := languageExcludes[name]; !ok {
+			return nil, errors.NewInputError(
+				fmt.Sprintf("Unknown language %q", v),
+				"No exclude template exists for this language",
+				fmt.Sprintf("Use one of: %s", strings.Join(sortedKeys(languageExcludes), ", ")),
+			)
+		}
+		if !seen[name] {
+			seen[name] = true
+			langs = append(langs, name)
+		}
+	}
+	return langs, nil
+}
+
+// applyLanguageTemplates appends the exclude templates of langs to cfg,
+// skipping patterns that are already listed.
+func applyLanguageTemplates(cfg *Config, langs []string) {
+	present := map[string]bool{}
+	for _, p := range cfg.Indexing.Exclude {
+		present[p] = true
+	}
+	for _, lang := range langs {
+		for _, p := range languageExcludes[lang] {
+			if !present[p] {
+				present[p] = true
+				cfg.Indexing.Exclude = append(cfg.Indexing.Exclude, p)
+			}
+		}
+	}
+}
+
+// applyProvider switches cfg to the named embedding provider and its
+// default endpoint, model and dimensions.
+func applyProvider(cfg *Config, provider string) error {
+	if provider == "qodo" {
+		provider = "llamacpp"
+	}
+	defaults, ok := providerDefaults[provider]
+	if !ok {
+		return errors.NewInputError(
+			fmt.Sprintf("Unknown embedding provider %q", provider),
+			"CIE cannot generate embeddings with this provider",
+			fmt.Sprintf("Use one of: %s", strings.Join(sortedKeys(providerDefaults), ", ")),
+		)
+	}
+	cfg.Embedding.Provider = provider
+	if provider == "ollama" {
+		return nil
+	}
+	cfg.Embedding.BaseURL = defaults.BaseURL
+	cfg.Embedding.Model = defaults.Model
+	cfg.Embedding.Dimensions = defaults.Dimensions
+	return nil
+}
+
+// validateEngine checks a --engine value.
+func validateEngine(engine string) error {
+	for _, e := range storageEngines {
+		if engine == e {
+			return nil
+		}
+	}
+	return errors.NewInputError(
+		fmt.Sprintf("Unknown storage engine %q", engine),
+		"The local index must use a persistent CozoDB engine",
+		fmt.Sprintf("Use one of: %s", strings.Join(storageEngines, ", ")),
+	)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
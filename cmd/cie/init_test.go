@@ -0,0 +1,82 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCreateInitConfig_Flags(t *testing.T) {
+	t.Setenv("OLLAMA_HOST", "")
+	cfg, err := createInitConfig("/src/app", initFlags{
+		embeddingProvider: "openai",
+		engine:            "sqlite",
+		languages:         []string{"go", "TS", "golang"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ProjectID != "app" {
+		t.Errorf("project_id = %q, want directory name", cfg.ProjectID)
+	}
+	if cfg.Embedding.Provider != "openai" || cfg.Embedding.Dimensions != 1536 || cfg.Embedding.Model != "text-embedding-3-small" {
+		t.Errorf("embedding = %+v", cfg.Embedding)
+	}
+	if cfg.StorageEngine() != "sqlite" {
+		t.Errorf("engine = %q", cfg.StorageEngine())
+	}
+	for _, want := range []string{"vendor/**", "testdata/**", "*.d.ts"} {
+		if !slices.Contains(cfg.Indexing.Exclude, want) {
+			t.Errorf("exclude missing %q: %v", want, cfg.Indexing.Exclude)
+		}
+	}
+	seen := map[string]bool{}
+	for _, p := range cfg.Indexing.Exclude {
+		if seen[p] {
+			t.Errorf("duplicate exclude %q", p)
+		}
+		seen[p] = true
+	}
+}
+
+func TestCreateInitConfig_InvalidFlags(t *testing.T) {
+	for name, f := range map[string]initFlags{
+		"language": {languages: []string{"cobol"}},
+		"provider": {embeddingProvider: "word2vec"},
+		"engine":   {engine: "mem"},
+	} {
+		if _, err := createInitConfig("/src/app", f); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestCreateInitConfig_DefaultsUnchanged(t *testing.T) {
+	cfg, err := createInitConfig("/src/app", initFlags{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Storage.Engine != "" || cfg.StorageEngine() != "rocksdb" {
+		t.Errorf("engine = %q", cfg.Storage.Engine)
+	}
+	if got, want := len(cfg.Indexing.Exclude), len(DefaultConfig("app").Indexing.Exclude); got != want {
+		t.Errorf("excludes = %d, want defaults only (%d)", got, want)
+	}
+}
@@ -0,0 +1,337 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kraklabs/cie/pkg/ingestion"
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+const (
+	maxLiveFiles    = 50      // unindexed files parsed per listing
+	maxLiveFileSize = 1 << 20 // larger files are left to the indexer
+	liveListTTL     = 5 * time.Second
+	liveIndexGap    = time.Minute // minimum time between background index requests
+)
+
+// liveSource implements tools.LiveSource for an MCP session: it finds files
+// that git knows about (new, untracked or added since the indexed commit)
+// but the index does not, parses them on demand, and requests a background
+// index run when one of them is used to answer a query.
+//
+// In overlay mode it also serves files edited since the indexed commit,
+// committed or not, so their working-tree version shadows the indexed one.
+// Edited files are not queued for indexing: the overlay already answers
+// for them, and reindexing on every edit would keep the indexer busy.
+type liveSource struct {
+	client  tools.Querier
+	git     tools.GitRunner
+	parser  *ingestion.TreeSitterParser
+	overlay bool
+
+	mu         sync.Mutex
+	listed     time.Time
+	files      []tools.LiveFile
+	added      map[string]bool // files in l.files that are not indexed at all
+	omitted    int             // files left out by maxLiveFiles
+	fns        []tools.LiveFunction
+	parsed     bool
+	lastQueued time.Time
+	now        func() time.Time
+	startIndex func(root string) // spawns the background index run
+}
+
+func newLiveSource(client tools.Querier, git tools.GitRunner, overlay bool) *liveSource {
+	return &liveSource{
+		client:     client,
+		git:        git,
+		overlay:    overlay,
+		parser:     ingestion.NewTreeSitterParser(nil),
+		now:        time.Now,
+		startIndex: startQueryTimeIndex,
+	}
+}
+
+// liveSource returns the session's LiveSource, or nil when disabled. A nil
+// *liveSource must not leak into the interface, where it would look set.
+func (s *mcpServer) liveSource() tools.LiveSource {
+	if s.live == nil {
+		return nil
+	}
+	return s.live
+}
+
+// reset drops the cached listing, e.g. after a reindex.
+func (l *liveSource) reset() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.listed = time.Time{}
+}
+
+// Files implements tools.LiveSource.
+func (l *liveSource) Files(ctx context.Context) ([]tools.LiveFile, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return l.files, nil
+}
+
+// Functions implements tools.LiveSource.
+func (l *liveSource) Functions(ctx context.Context) ([]tools.LiveFunction, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.refresh(ctx); err != nil {
+		return nil, err
+	}
+	if l.parsed {
+		return l.fns, nil
+	}
+	l.fns = nil
+	root := l.git.RepoPath()
+	for _, f := range l.files {
+		result, err := l.parser.ParseFile(ingestion.FileInfo{
+			Path:     f.Path,
+			FullPath: filepath.Join(root, filepath.FromSlash(f.Path)),
+			Size:     int64(len(f.Content)),
+			Language: ingestion.DetectLanguage(f.Path),
+		})
+		if err != nil {
+			continue
+		}
+		for _, fn := range result.Functions {
+			l.fns = append(l.fns, tools.LiveFunction{
+				Name:      fn.Name,
+				FilePath:  f.Path,
+				Signature: fn.Signature,
+				CodeText:  fn.CodeText,
+				StartLine: fn.StartLine,
+				EndLine:   fn.EndLine,
+			})
+		}
+	}
+	l.parsed = true
+	return l.fns, nil
+}
+
+// Omitted implements tools.LiveSource.
+func (l *liveSource) Omitted(ctx context.Context) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.refresh(ctx); err != nil {
+		return 0
+	}
+	return l.omitted
+}
+
+// Queue implements tools.LiveSource. Requests within liveIndexGap of the
+// previous one are dropped; the pending run will pick those files up too.
+func (l *liveSource) Queue(paths []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var added []string
+	for _, p := range paths {
+		if l.added[p] {
+			added = append(added, p)
+		}
+	}
+	paths = added
+	if len(paths) == 0 {
+		return
+	}
+	if !l.lastQueued.IsZero() && l.now().Sub(l.lastQueued) < liveIndexGap {
+		return
+	}
+	l.lastQueued = l.now()
+	fmt.Fprintf(os.Stderr, "  Queued indexing for %d unindexed file(s)\n", len(paths))
+	go l.startIndex(l.git.RepoPath())
+}
+
+// refresh re-lists the unindexed files when the listing is older than
+// liveListTTL. Caller holds l.mu.
+func (l *liveSource) refresh(ctx context.Context) error {
+	if !l.listed.IsZero() && l.now().Sub(l.listed) < liveListTTL {
+		return nil
+	}
+	candidates, modified, err := l.candidates(ctx)
+	if err != nil {
+		return err
+	}
+	indexed, err := l.indexedPaths(ctx, candidates)
+	if err != nil {
+		return err
+	}
+
+	root := l.git.RepoPath()
+	var files []tools.LiveFile
+	added := map[string]bool{}
+	omitted := 0
+	for _, path := range candidates {
+		if indexed[path] && !modified[path] {
+			continue
+		}
+		if len(files) >= maxLiveFiles {
+			omitted++
+			continue
+		}
+		full := filepath.Join(root, filepath.FromSlash(path))
+		info, err := os.Stat(full)
+		if err != nil || info.IsDir() || info.Size() > maxLiveFileSize {
+			continue
+		}
+		content, err := os.ReadFile(full) //nolint:gosec // G304: path listed by git inside the repository
+		if err != nil {
+			continue
+		}
+		files = append(files, tools.LiveFile{Path: path, Content: string(content)})
+		if !indexed[path] {
+			added[path] = true
+		}
+	}
+	if omitted > 0 && omitted != l.omitted {
+		fmt.Fprintf(os.Stderr, "  Working tree: %d changed files exceed the %d-file limit and are not served until indexed\n", omitted, maxLiveFiles)
+	}
+	l.files, l.added, l.omitted, l.listed, l.parsed = files, added, omitted, l.now(), false
+	return nil
+}
+
+// candidates lists source files that are untracked, staged as new, or added
+// in commits after the indexed one. In overlay mode it also lists files
+// modified in the working tree or since the indexed commit, reported in
+// modified.
+func (l *liveSource) candidates(ctx context.Context) (paths []string, modified map[string]bool, err error) {
+	status, err := l.git.Run(ctx, "status", "--porcelain", "--untracked-files=all")
+	if err != nil {
+		return nil, nil, err
+	}
+	seen := map[string]bool{}
+	modified = map[string]bool{}
+	add := func(path string, edited bool) {
+		if path == "" || ingestion.DetectLanguage(path) == "" {
+			return
+		}
+		if edited {
+			modified[path] = true
+		}
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	newFiles, editedFiles := parsePorcelain(status)
+	for _, path := range newFiles {
+		add(path, false)
+	}
+	if l.overlay {
+		for _, path := range editedFiles {
+			add(path, true)
+		}
+	}
+	if sha := tools.IndexedCommit(ctx, l.client); sha != "" {
+		filter := "--diff-filter=AR"
+		if l.overlay {
+			filter = "--diff-filter=AMR"
+		}
+		if out, err := l.git.Run(ctx, "diff", "--name-status", filter, sha, "HEAD"); err == nil {
+			for _, line := range strings.Split(out, "\n") {
+				fields := strings.Split(line, "\t")
+				if len(fields) < 2 {
+					continue
+				}
+				add(fields[len(fields)-1], strings.HasPrefix(fields[0], "M"))
+			}
+		}
+	}
+	return paths, modified, nil
+}
+
+// parsePorcelain splits `git status --porcelain` into new paths (untracked,
+// added, renamed or copied; the new name for renames) and paths with edits
+// to a tracked file, staged or not.
+func parsePorcelain(status string) (newFiles, editedFiles []string) {
+	for _, line := range strings.Split(status, "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		code, path := line[:2], line[3:]
+		if i := strings.Index(path, " -> "); i >= 0 {
+			path = path[i+4:]
+		}
+		path = strings.Trim(path, `"`)
+		switch {
+		case code == "??", code[0] == 'A', code[0] == 'R', code[0] == 'C':
+			newFiles = append(newFiles, path)
+		case code[0] == 'M', code[1] == 'M':
+			editedFiles = append(editedFiles, path)
+		}
+	}
+	return newFiles, editedFiles
+}
+
+// indexedPaths returns which of paths already have a cie_file entry.
+func (l *liveSource) indexedPaths(ctx context.Context, paths []string) (map[string]bool, error) {
+	indexed := map[string]bool{}
+	if len(paths) == 0 {
+		return indexed, nil
+	}
+	quoted := make([]string, len(paths))
+	for i, p := range paths {
+		quoted[i] = fmt.Sprintf("%q", p)
+	}
+	result, err := l.client.Query(ctx, fmt.Sprintf("?[path] := *cie_file { path }, is_in(path, [%s])", strings.Join(quoted, ", ")))
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range result.Rows {
+		if len(row) > 0 {
+			indexed[tools.AnyToString(row[0])] = true
+		}
+	}
+	return indexed, nil
+}
+
+// startQueryTimeIndex runs `cie hook-run query-time` detached in root, which
+// indexes behind the project lock or queues behind a run in progress.
+func startQueryTimeIndex(root string) {
+	self, err := os.Executable()
+	if err != nil {
+		self = "cie"
+	}
+	cmd := exec.Command(self, "hook-run", queryTimeHook) //nolint:gosec // G204: re-executes this binary
+	cmd.Dir = root
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "  Warning: cannot start background index: %v\n", err)
+		return
+	}
+	_ = cmd.Wait()
+}
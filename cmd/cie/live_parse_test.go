@@ -0,0 +1,165 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+func TestParsePorcelain(t *testing.T) {
+	status := "?? pkg/new.go\nA  pkg/added.py\n M pkg/changed.go\nMM pkg/staged.go\nR  old.ts -> src/renamed.ts\n D gone.go\n"
+	newFiles, edited := parsePorcelain(status)
+	if want := []string{"pkg/new.go", "pkg/added.py", "src/renamed.ts"}; !reflect.DeepEqual(newFiles, want) {
+		t.Errorf("new = %v, want %v", newFiles, want)
+	}
+	if want := []string{"pkg/changed.go", "pkg/staged.go"}; !reflect.DeepEqual(edited, want) {
+		t.Errorf("edited = %v, want %v", edited, want)
+	}
+}
+
+func TestLiveSource(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	mkfile(t, dir, "pkg/fresh.go", "package pkg\n\nfunc Fresh() int {\n\treturn 1\n}\n")
+	mkfile(t, dir, "notes.txt", "not source\n")
+
+	git, err := tools.NewGitExecutor(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := newLiveSource(&recordingQuerier{}, git, false)
+	started := make(chan string, 1)
+	live.startIndex = func(root string) { started <- root }
+
+	files, err := live.Files(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Path != "pkg/fresh.go" {
+		t.Fatalf("files = %+v", files)
+	}
+	fns, err := live.Functions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fns) != 1 || fns[0].Name != "Fresh" || fns[0].StartLine != 3 {
+		t.Fatalf("functions = %+v", fns)
+	}
+
+	live.Queue([]string{"pkg/fresh.go"})
+	live.Queue([]string{"pkg/fresh.go"}) // within liveIndexGap: dropped
+	if root := <-started; root != git.RepoPath() {
+		t.Errorf("index started in %q", root)
+	}
+	select {
+	case <-started:
+		t.Error("second request should be coalesced")
+	default:
+	}
+
+	var nilLive *liveSource
+	s := &mcpServer{live: nilLive}
+	if s.liveSource() != nil {
+		t.Error("disabled live source must be a nil interface")
+	}
+}
+
+func TestLiveSource_Overlay(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	gitIn := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@t"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	gitIn("init", "-q")
+	mkfile(t, dir, "pkg/old.go", "package pkg\n\nfunc Old() int {\n\treturn 1\n}\n")
+	gitIn("add", ".")
+	gitIn("commit", "-q", "-m", "init")
+	mkfile(t, dir, "pkg/old.go", "package pkg\n\nfunc Old() int {\n\treturn 2\n}\n")
+
+	git, err := tools.NewGitExecutor(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The querier reports every candidate as indexed.
+	indexed := &recordingQuerier{headers: []string{"file_path"}, rows: [][]any{{"pkg/old.go"}}}
+
+	plain := newLiveSource(indexed, git, false)
+	if files, err := plain.Files(context.Background()); err != nil || len(files) != 0 {
+		t.Fatalf("without overlay: files = %+v, err = %v", files, err)
+	}
+
+	live := newLiveSource(indexed, git, true)
+	live.startIndex = func(string) { t.Error("edited files must not trigger indexing") }
+	fns, err := live.Functions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fns) != 1 || fns[0].Name != "Old" || !strings.Contains(fns[0].CodeText, "return 2") {
+		t.Fatalf("functions = %+v", fns)
+	}
+	live.Queue([]string{"pkg/old.go"})
+}
+
+func TestLiveSource_ReportsOmittedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	for i := 0; i < maxLiveFiles+3; i++ {
+		mkfile(t, dir, fmt.Sprintf("pkg/f%02d.go", i), "package pkg\n")
+	}
+
+	git, err := tools.NewGitExecutor(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := newLiveSource(&recordingQuerier{}, git, false)
+	files, err := live.Files(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != maxLiveFiles {
+		t.Errorf("files = %d, want %d", len(files), maxLiveFiles)
+	}
+	if n := live.Omitted(context.Background()); n != 3 {
+		t.Errorf("Omitted() = %d, want 3", n)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/pkg/llm"
+)
+
+// newLLMProvider returns the narrative LLM configured under `llm:`, or nil
+// when it is disabled or incomplete. The provider type is inferred from the
+// base URL: Ollama's default port or host, Anthropic's API, otherwise any
+// OpenAI-compatible endpoint. An enabled provider that cannot be built is an
+// error rather than a silent fallback to running without an LLM.
+func newLLMProvider(cfg LLMConfig) (llm.Provider, error) {
+	if !cfg.Enabled || cfg.BaseURL == "" {
+		return nil, nil
+	}
+	provider, err := llm.NewProvider(llm.ProviderConfig{
+		Type:         llmProviderType(cfg.BaseURL),
+		BaseURL:      cfg.BaseURL,
+		APIKey:       cfg.APIKey,
+		DefaultModel: cfg.Model,
+		Timeout:      90 * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("llm provider for %s: %w", cfg.BaseURL, err)
+	}
+	return provider, nil
+}
+
+// llmConfigError reports an `llm:` block that newLLMProvider rejected.
+func llmConfigError(err error) *errors.UserError {
+	return errors.NewConfigError(
+		"Invalid llm configuration",
+		fmt.Sprintf("Cannot create the LLM provider: %v", err),
+		"Fix or disable the llm: block in .cie/project.yaml",
+		err,
+	)
+}
+
+func llmProviderType(baseURL string) string {
+	u := strings.ToLower(baseURL)
+	switch {
+	case strings.Contains(u, "anthropic.com"):
+		return "anthropic"
+	case strings.Contains(u, ":11434"), strings.Contains(u, "ollama"):
+		return "ollama"
+	}
+	return "openai"
+}
+
+// llmMaxTokens returns the configured response budget (default 2000).
+func llmMaxTokens(cfg LLMConfig) int {
+	if cfg.MaxTokens <= 0 {
+		return 2000
+	}
+	return cfg.MaxTokens
+}
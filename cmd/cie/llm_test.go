@@ -0,0 +1,49 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import "testing"
+
+func TestNewLLMProvider(t *testing.T) {
+	if p, err := newLLMProvider(LLMConfig{BaseURL: "http://localhost:11434"}); p != nil || err != nil {
+		t.Errorf("disabled config should not create a provider, got %v, %v", p, err)
+	}
+	if p, err := newLLMProvider(LLMConfig{Enabled: true}); p != nil || err != nil {
+		t.Errorf("config without base_url should not create a provider, got %v, %v", p, err)
+	}
+	if p, err := newLLMProvider(LLMConfig{Enabled: true, BaseURL: "http://localhost:8000/v1"}); p == nil || err != nil {
+		t.Errorf("enabled config should create a provider, got %v, %v", p, err)
+	}
+
+	tests := map[string]string{
+		"http://localhost:11434":       "ollama",
+		"http://ollama.internal:8080":  "ollama",
+		"https://api.anthropic.com/v1": "anthropic",
+		"https://api.openai.com/v1":    "openai",
+		"http://localhost:8000/v1":     "openai",
+	}
+	for url, want := range tests {
+		if got := llmProviderType(url); got != want {
+			t.Errorf("llmProviderType(%q) = %q, want %q", url, got, want)
+		}
+	}
+	if got := llmMaxTokens(LLMConfig{}); got != 2000 {
+		t.Errorf("default max tokens = %d, want 2000", got)
+	}
+}
@@ -88,16 +88,20 @@ func logError(globals GlobalFlags, format string, args ...interface{}) { //nolin
 // Commands:
 //   - init: Create .cie/project.yaml configuration
 //   - index: Index the current repository
+//   - watch: Re-index files as they change
 //   - status: Show project status
 //   - query: Execute CozoScript query
 //   - reset: Reset local project data (destructive!)
-//   - install-hook: Install git post-commit hook for auto-indexing
+//   - repair: Recover a damaged local index
+//   - install-hook: Install git hooks for auto-indexing
 func main() {
 	// Global flags with short forms
 	var (
 		showVersion = flag.BoolP("version", "V", false, "Show version and exit")
 		mcpMode     = flag.Bool("mcp", false, "Start as MCP server (JSON-RPC over stdio)")
 		configPath  = flag.StringP("config", "c", "", "Path to .cie/project.yaml (default: ./.cie/project.yaml)")
+		setFlags    = flag.StringArray("set", nil, "Override a config key for this run (key=value, repeatable)")
+		profileName = flag.String("profile", "", "Use a named embedding/LLM profile from the configuration")
 		jsonOutput  = flag.Bool("json", false, "Output in JSON format (for applicable commands)")
 		noColor     = flag.Bool("no-color", false, "Disable color output")
 		verbose     = flag.CountP("verbose", "v", "Increase verbosity (-v for info, -vv for debug)")
@@ -124,12 +128,30 @@ Usage:
 Commands:
   init          Create .cie/project.yaml configuration
   index         Index the current repository
+  watch         Re-index files as they change (no git hooks needed)
+  embed-backfill Generate embeddings skipped by 'index --skip-embeddings'
+  embed-check   Detect drift between stored embeddings and the provider
+  embed-normalize Check stored embeddings are unit length and fix them
   status        Show project status
+  manifest      Print or check the index manifest (for CI caches)
+  push-index    Upload the index to an OCI registry, S3 or a path
+  pull-index    Download a prebuilt index instead of indexing locally
+  export        Write the index changes since a commit as a delta bundle
+  import        Apply a delta bundle written by 'cie export'
   config        Show current configuration
   query         Execute CozoScript query
+  grep          Search indexed code for text (as cie_grep)
+  explain       Explain a function: code, callers, callees, summary
+  browse        Browse the index in an interactive terminal UI
   serve         Start local HTTP server for MCP tools
   reset         Reset local project data (destructive!)
-  install-hook  Install git post-commit hook for auto-indexing
+  repair        Recover a damaged local index, keeping config
+  audit         Show MCP tool invocation log
+  onboard       Generate a markdown repo tour from the index
+  architecture  Generate an architecture overview document
+  bench         Measure semantic search recall and MRR on your repo
+  precommit     Check staged changes against the index
+  install-hook  Install git hooks for auto-indexing
   completion    Generate shell completion script (bash|zsh|fish)
 
 Global Options:
@@ -139,6 +161,8 @@ Global Options:
   -q, --quiet       Suppress non-essential output (progress, info messages)
   --mcp             Start as MCP server (JSON-RPC over stdio)
   -c, --config      Path to .cie/project.yaml
+  --set key=value   Override a config key for this run (repeatable)
+  --profile NAME    Use a named embedding/LLM profile (see 'profiles:' in config)
   -V, --version     Show version and exit
 
 Examples:
@@ -161,9 +185,15 @@ Getting Started:
 Data Storage:
   Data is stored locally in ~/.cie/data/<project_id>/
 
+Configuration Precedence (later wins):
+  ~/.cie/config.yaml  <  .cie/project.yaml  <  --profile  <  environment  <  --set
+  Run 'cie config show --effective' to see the merged result.
+
 Environment Variables:
   OLLAMA_HOST        Ollama URL (default: http://localhost:11434)
   OLLAMA_EMBED_MODEL Embedding model (default: nomic-embed-text)
+  CIE_GLOBAL_CONFIG  Global defaults file (default: ~/.cie/config.yaml)
+  CIE_PROFILE        Profile to use when --profile is not given
 
 For detailed command help: cie <command> --help
 
@@ -206,6 +236,9 @@ For detailed command help: cie <command> --help
 	// Initialize color output based on flags
 	ui.InitColors(globals.NoColor)
 
+	configOverrides = *setFlags
+	selectedProfile = *profileName
+
 	// MCP mode takes precedence
 	if *mcpMode {
 		runMCPServer(*configPath)
@@ -226,16 +259,54 @@ For detailed command help: cie <command> --help
 		runInit(cmdArgs, globals)
 	case "index":
 		runIndex(cmdArgs, *configPath, globals)
+	case "watch":
+		runWatch(cmdArgs, *configPath, globals)
+	case "embed-backfill":
+		runEmbedBackfill(cmdArgs, *configPath, globals)
+	case "embed-check":
+		runEmbedCheck(cmdArgs, *configPath, globals)
+	case "embed-normalize":
+		runEmbedNormalize(cmdArgs, *configPath, globals)
 	case "status":
 		runStatus(cmdArgs, *configPath, globals)
+	case "manifest":
+		runManifest(cmdArgs, *configPath, globals)
+	case "push-index":
+		runPushIndex(cmdArgs, *configPath, globals)
+	case "pull-index":
+		runPullIndex(cmdArgs, *configPath, globals)
+	case "export":
+		runExport(cmdArgs, *configPath, globals)
+	case "import":
+		runImport(cmdArgs, *configPath, globals)
 	case "config":
 		runConfig(cmdArgs, *configPath, globals)
 	case "query":
 		runQuery(cmdArgs, *configPath, globals)
+	case "grep":
+		runGrep(cmdArgs, *configPath, globals)
+	case "explain":
+		runExplain(cmdArgs, *configPath, globals)
+	case "browse":
+		runBrowse(cmdArgs, *configPath, globals)
 	case "reset":
 		runReset(cmdArgs, *configPath, globals)
+	case "repair":
+		runRepair(cmdArgs, *configPath, globals)
+	case "audit":
+		runAudit(cmdArgs, *configPath, globals)
+	case "onboard":
+		runOnboard(cmdArgs, *configPath, globals)
+	case "architecture":
+		runArchitecture(cmdArgs, *configPath, globals)
+	case "bench":
+		runBench(cmdArgs, *configPath, globals)
+	case "precommit":
+		runPrecommit(cmdArgs, *configPath, globals)
 	case "install-hook":
 		runInstallHook(cmdArgs, *configPath, globals)
+	case "hook-run":
+		runHookRun(cmdArgs, *configPath, globals)
 	case "completion":
 		runCompletion(cmdArgs, *configPath, globals)
 	case "serve":
@@ -0,0 +1,380 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/internal/output"
+	"github.com/kraklabs/cie/internal/ui"
+	"github.com/kraklabs/cie/pkg/ingestion"
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// indexManifestFile is the default manifest path, relative to .cie/.
+const indexManifestFile = "index-manifest.json"
+
+// IndexManifest describes an index for build caches: a CI job compares it
+// with the checkout it is about to index to decide whether a cached copy
+// of ~/.cie/data/<project_id> can be reused.
+type IndexManifest struct {
+	ManifestVersion int               `json:"manifest_version"`
+	ProjectID       string            `json:"project_id"`
+	CIEVersion      string            `json:"cie_version"`
+	SchemaVersion   int               `json:"schema_version"`
+	SchemaHash      string            `json:"schema_hash"`
+	Commit          string            `json:"commit,omitempty"`
+	ConfigHash      string            `json:"config_hash"`
+	Embedding       ManifestEmbedding `json:"embedding"`
+	Counts          map[string]int    `json:"counts"`
+	IndexedAt       time.Time         `json:"indexed_at"`
+}
+
+// ManifestEmbedding identifies the vectors stored in the index: the model,
+// the prefixes its inputs were embedded with, whether names were embedded
+// too, and the metric the vector indexes compare them with.
+type ManifestEmbedding struct {
+	Provider       string `json:"provider"`
+	Model          string `json:"model,omitempty"`
+	Dimensions     int    `json:"dimensions,omitempty"`
+	QueryPrefix    string `json:"query_prefix"`
+	DocumentPrefix string `json:"document_prefix"`
+	NameEmbeddings bool   `json:"name_embeddings,omitempty"`
+	Distance       string `json:"distance"`
+}
+
+// manifestCounts are the relations counted in the manifest.
+var manifestCounts = map[string]string{
+	"files":      "?[count(id)] := *cie_file{id}",
+	"functions":  "?[count(id)] := *cie_function{id}",
+	"types":      "?[count(id)] := *cie_type{id}",
+	"calls":      "?[count(caller_id)] := *cie_calls{caller_id}",
+	"embeddings": "?[count(function_id)] := *cie_function_embedding{function_id}",
+}
+
+// indexConfigHash fingerprints the settings that change what an index
+// contains or how it is stored. Settings that only affect how it is built
+// or queried, such as batch sizes and endpoints, are left out.
+func indexConfigHash(cfg *Config) string {
+	inputs := struct {
+		ParserMode    string                            `json:"parser_mode"`
+		MaxFileSize   int64                             `json:"max_file_size"`
+		Exclude       []string                          `json:"exclude"`
+		CompressCode  bool                              `json:"compress_code"`
+		StoreFileText bool                              `json:"store_file_text"`
+		ContentHash   string                            `json:"content_hash"`
+		Languages     map[string]LanguageIndexingConfig `json:"languages"`
+		Scripts       []string                          `json:"analysis_scripts"`
+		Embedding     ManifestEmbedding                 `json:"embedding"`
+		Engine        string                            `json:"engine"`
+		Sharded       bool                              `json:"sharded"`
+	}{
+		ParserMode:    cfg.Indexing.ParserMode,
+		MaxFileSize:   cfg.Indexing.MaxFileSize,
+		Exclude:       cfg.Indexing.Exclude,
+		CompressCode:  cfg.Indexing.CompressCode,
+		StoreFileText: cfg.Indexing.StoreFileText,
+		ContentHash:   cfg.Indexing.ContentHash,
+		Languages:     cfg.Indexing.Languages,
+		Scripts:       cfg.Indexing.AnalysisScripts,
+		Embedding:     manifestEmbedding(cfg),
+		Engine:        cfg.StorageEngine(),
+		Sharded:       cfg.Storage.Sharded,
+	}
+	data, _ := json.Marshal(inputs) // map keys are sorted, so this is stable
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+func manifestEmbedding(cfg *Config) ManifestEmbedding {
+	prefixes := cfg.Embedding.Prefixes()
+	distance := strings.ToLower(strings.TrimSpace(cfg.Embedding.Distance))
+	if distance == "" {
+		distance = storage.DistanceCosine
+	}
+	return ManifestEmbedding{
+		Provider:       mapEmbeddingProvider(cfg.Embedding.Provider),
+		Model:          cfg.Embedding.Model,
+		Dimensions:     cfg.Embedding.Dimensions,
+		QueryPrefix:    prefixes.Query,
+		DocumentPrefix: prefixes.Document,
+		NameEmbeddings: cfg.Embedding.NameEmbeddings,
+		Distance:       distance,
+	}
+}
+
+// buildIndexManifest describes the index open in backend.
+func buildIndexManifest(ctx context.Context, backend *storage.EmbeddedBackend, cfg *Config) (*IndexManifest, error) {
+	m := &IndexManifest{
+		ManifestVersion: 1,
+		ProjectID:       cfg.ProjectID,
+		CIEVersion:      version,
+		SchemaVersion:   ingestion.SchemaVersion,
+		SchemaHash:      ingestion.SchemaHash(),
+		ConfigHash:      indexConfigHash(cfg),
+		Embedding:       manifestEmbedding(cfg),
+		Counts:          make(map[string]int, len(manifestCounts)),
+		IndexedAt:       time.Now().UTC().Truncate(time.Second),
+	}
+	commit, err := backend.GetLastIndexedSHA()
+	if err != nil {
+		return nil, fmt.Errorf("read indexed commit: %w", err)
+	}
+	m.Commit = commit
+	for name, script := range manifestCounts {
+		result, err := backend.Query(ctx, script)
+		if err != nil {
+			return nil, fmt.Errorf("count %s: %w", name, err)
+		}
+		m.Counts[name] = sumCountRows(result)
+	}
+	return m, nil
+}
+
+// writeIndexManifest saves m as indented JSON, creating the directory.
+func writeIndexManifest(m *IndexManifest, path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// readIndexManifest loads a manifest written by writeIndexManifest.
+func readIndexManifest(path string) (*IndexManifest, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // user-provided manifest path
+	if err != nil {
+		return nil, err
+	}
+	var m IndexManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// manifestMismatches lists why an index described by cached cannot stand in
+// for one built from cfg at commit. An empty commit skips the commit check;
+// a differing commit is reported because the cached index would then need
+// an incremental run rather than none.
+func manifestMismatches(cached *IndexManifest, cfg *Config, commit string) []string {
+	var reasons []string
+	if cached.ProjectID != cfg.ProjectID {
+		reasons = append(reasons, fmt.Sprintf("project_id %q, want %q", cached.ProjectID, cfg.ProjectID))
+	}
+	if cached.SchemaHash != ingestion.SchemaHash() {
+		reasons = append(reasons, fmt.Sprintf("schema %d/%s, this cie writes %d/%s",
+			cached.SchemaVersion, cached.SchemaHash, ingestion.SchemaVersion, ingestion.SchemaHash()))
+	}
+	if cached.ConfigHash != indexConfigHash(cfg) {
+		reasons = append(reasons, "indexing configuration changed")
+	}
+	if want := manifestEmbedding(cfg); cached.Embedding != want {
+		reasons = append(reasons, fmt.Sprintf("embedding %s, want %s", cached.Embedding, want))
+	}
+	if commit != "" && cached.Commit != commit {
+		reasons = append(reasons, fmt.Sprintf("indexed at %s, checkout is %s", shortSHA(cached.Commit), shortSHA(commit)))
+	}
+	return reasons
+}
+
+// String describes e for mismatch reports.
+func (e ManifestEmbedding) String() string {
+	s := e.Provider + "/" + e.Model
+	if e.Dimensions > 0 {
+		s += fmt.Sprintf(" (%d dims)", e.Dimensions)
+	}
+	s += fmt.Sprintf(", query prefix %q, document prefix %q, %s distance", e.QueryPrefix, e.DocumentPrefix, e.Distance)
+	if e.NameEmbeddings {
+		s += ", name embeddings"
+	}
+	return s
+}
+
+func shortSHA(sha string) string {
+	if sha == "" {
+		return "(none)"
+	}
+	return sha[:min(12, len(sha))]
+}
+
+// emitIndexManifest writes the manifest of the project's index after an
+// index run. Failures only warn: the index itself is complete.
+func emitIndexManifest(ctx context.Context, cfg *Config, path string, globals GlobalFlags) {
+	m, err := loadIndexManifest(ctx, cfg)
+	if err == nil {
+		err = writeIndexManifest(m, path)
+	}
+	if err != nil {
+		ui.Warningf("Index manifest not written: %v", err)
+		return
+	}
+	if !globals.Quiet && !globals.JSON {
+		ui.Infof("Index manifest written to %s", path)
+	}
+}
+
+// loadIndexManifest opens the project's index and describes it.
+func loadIndexManifest(ctx context.Context, cfg *Config) (*IndexManifest, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	backend, err := storage.NewEmbeddedBackend(storage.EmbeddedConfig{
+		DataDir:             filepath.Join(homeDir, ".cie", "data", cfg.ProjectID),
+		Engine:              cfg.StorageEngine(),
+		Sharded:             cfg.Storage.Sharded,
+		ProjectID:           cfg.ProjectID,
+		EmbeddingDimensions: cfg.Embedding.Dimensions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = backend.Close() }()
+	return buildIndexManifest(ctx, backend, cfg)
+}
+
+// ManifestCheckOutput is the JSON shape of `cie manifest --check --json`.
+type ManifestCheckOutput struct {
+	Reusable bool     `json:"reusable"`
+	Reasons  []string `json:"reasons,omitempty"`
+}
+
+// runManifest executes the 'manifest' CLI command: print the manifest of
+// the current index, or check a cached manifest against the checkout.
+func runManifest(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("manifest", flag.ExitOnError)
+	check := fs.String("check", "", "Check a cached manifest against the current checkout and configuration")
+	anyCommit := fs.Bool("any-commit", false, "With --check, accept an index built at another commit (an incremental run catches it up)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: cie manifest [options]
+
+Description:
+  Print the manifest of the current index as JSON: schema version, indexed
+  commit, configuration hash, embedding model and entity counts. 'cie index'
+  writes the same manifest to .cie/%s after every run.
+
+  With --check, compare a manifest saved next to a cached index with the
+  current checkout and .cie/project.yaml. The command exits 0 when the
+  cached index can be reused and 1, listing the reasons, when it cannot.
+
+Options:
+`, indexManifestFile)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Examples:
+  cie manifest > index-manifest.json
+
+  # CI: restore the cache, then reuse it or rebuild
+  cie manifest --check cache/index-manifest.json || cie index --full
+
+  # Accept a cache from an older commit and update it incrementally
+  cie manifest --check cache/index-manifest.json --any-commit && cie index
+
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+
+	if *check == "" {
+		m, err := loadIndexManifest(context.Background(), cfg)
+		if err != nil {
+			errors.FatalError(errors.NewDatabaseError(
+				"Cannot read the index",
+				err.Error(),
+				"Run 'cie index' first, or 'cie status' to check the index",
+				err,
+			), globals.JSON)
+		}
+		_ = output.JSON(m)
+		return
+	}
+
+	cached, err := readIndexManifest(*check)
+	if err != nil {
+		errors.FatalError(errors.NewInputError(
+			"Cannot read manifest",
+			err.Error(),
+			"Pass the index-manifest.json saved with the cached index",
+		), globals.JSON)
+	}
+	commit := ""
+	if !*anyCommit {
+		commit = checkoutCommit()
+	}
+	reasons := manifestMismatches(cached, cfg, commit)
+	if !printManifestCheck(os.Stdout, reasons, globals.JSON) {
+		os.Exit(1)
+	}
+}
+
+// printManifestCheck reports the outcome of --check and whether the
+// cached index is reusable.
+func printManifestCheck(w io.Writer, reasons []string, jsonOut bool) bool {
+	reusable := len(reasons) == 0
+	if jsonOut {
+		_ = output.JSONTo(w, ManifestCheckOutput{Reusable: reusable, Reasons: reasons})
+		return reusable
+	}
+	if reusable {
+		_, _ = fmt.Fprintln(w, "Cached index is reusable")
+		return true
+	}
+	_, _ = fmt.Fprintf(w, "Cached index is not reusable:\n  - %s\n", strings.Join(reasons, "\n  - "))
+	return false
+}
+
+// checkoutCommit returns HEAD of the repository in the working directory,
+// or "" outside a git repository.
+func checkoutCommit() string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	dd := ingestion.NewDeltaDetector(cwd, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if !dd.IsGitRepository() {
+		return ""
+	}
+	sha, _ := dd.GetHeadSHA()
+	return sha
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/ingestion"
+)
+
+func manifestTestConfig() *Config {
+	cfg := &Config{ProjectID: "acme"}
+	cfg.Embedding = EmbeddingConfig{Provider: "ollama", Model: "nomic-embed-text", Dimensions: 768, BaseURL: "http://localhost:11434"}
+	cfg.Indexing = IndexingConfig{ParserMode: "auto", BatchTarget: 500, Exclude: []string{"vendor/**"}}
+	return cfg
+}
+
+func TestIndexConfigHash(t *testing.T) {
+	base := indexConfigHash(manifestTestConfig())
+	if base != indexConfigHash(manifestTestConfig()) {
+		t.Fatal("hash is not stable")
+	}
+
+	// Build-time settings do not change what the index holds.
+	cfg := manifestTestConfig()
+	cfg.Indexing.BatchTarget = 2000
+	cfg.Embedding.BaseURL = "http://gpu-box:11434"
+	if got := indexConfigHash(cfg); got != base {
+		t.Errorf("batch size or endpoint changed the hash")
+	}
+	cfg.Embedding.Distance = "cosine"
+	if got := indexConfigHash(cfg); got != base {
+		t.Errorf("the default distance spelled out changed the hash")
+	}
+
+	changes := map[string]func(*Config){
+		"exclude":       func(c *Config) { c.Indexing.Exclude = append(c.Indexing.Exclude, "dist/**") },
+		"compress":      func(c *Config) { c.Indexing.CompressCode = true },
+		"model":         func(c *Config) { c.Embedding.Model = "mxbai-embed-large" },
+		"language caps": func(c *Config) { c.Indexing.Languages = map[string]LanguageIndexingConfig{"go": {MaxCodeText: 1000}} },
+		"sharded":       func(c *Config) { c.Storage.Sharded = true },
+		"query prefix":  func(c *Config) { c.Embedding.QueryPrefix = new(string) },
+		"doc prefix":    func(c *Config) { c.Embedding.DocumentPrefix = new(string) },
+		"names":         func(c *Config) { c.Embedding.NameEmbeddings = true },
+		"distance":      func(c *Config) { c.Embedding.Distance = "ip" },
+	}
+	for name, change := range changes {
+		cfg := manifestTestConfig()
+		change(cfg)
+		if indexConfigHash(cfg) == base {
+			t.Errorf("%s did not change the hash", name)
+		}
+	}
+}
+
+func TestIndexManifest_RoundTripAndCheck(t *testing.T) {
+	cfg := manifestTestConfig()
+	m := &IndexManifest{
+		ManifestVersion: 1,
+		ProjectID:       "acme",
+		SchemaVersion:   ingestion.SchemaVersion,
+		SchemaHash:      ingestion.SchemaHash(),
+		Commit:          "3f2c9e1a7b5d4c3e2f1a0b9c8d7e6f5a4b3c2d1e",
+		ConfigHash:      indexConfigHash(cfg),
+		Embedding:       manifestEmbedding(cfg),
+		Counts:          map[string]int{"functions": 120, "files": 14},
+	}
+	path := filepath.Join(t.TempDir(), "cache", indexManifestFile)
+	if err := writeIndexManifest(m, path); err != nil {
+		t.Fatalf("writeIndexManifest: %v", err)
+	}
+	cached, err := readIndexManifest(path)
+	if err != nil {
+		t.Fatalf("readIndexManifest: %v", err)
+	}
+	if cached.Counts["functions"] != 120 || cached.Embedding.Model != "nomic-embed-text" {
+		t.Errorf("round trip lost fields: %+v", cached)
+	}
+
+	if reasons := manifestMismatches(cached, cfg, m.Commit); len(reasons) != 0 {
+		t.Errorf("same commit and config: reasons = %v", reasons)
+	}
+	if reasons := manifestMismatches(cached, cfg, ""); len(reasons) != 0 {
+		t.Errorf("commit check skipped: reasons = %v", reasons)
+	}
+
+	cfg.Embedding.Model = "mxbai-embed-large"
+	cached.SchemaHash = "0000000000000000"
+	reasons := manifestMismatches(cached, cfg, "aaaabbbbccccdddd")
+	joined := strings.Join(reasons, "; ")
+	for _, want := range []string{"schema", "indexing configuration changed", "embedding ollama/nomic-embed-text", "indexed at 3f2c9e1a7b5d, checkout is aaaabbbbcccc"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("reasons %q missing %q", joined, want)
+		}
+	}
+}
+
+func TestPrintManifestCheck(t *testing.T) {
+	var buf bytes.Buffer
+	if !printManifestCheck(&buf, nil, false) || !strings.Contains(buf.String(), "reusable") {
+		t.Errorf("reusable: %q", buf.String())
+	}
+
+	buf.Reset()
+	if printManifestCheck(&buf, []string{"indexing configuration changed"}, true) {
+		t.Error("mismatch reported as reusable")
+	}
+	if !strings.Contains(buf.String(), `"reusable": false`) || !strings.Contains(buf.String(), "indexing configuration changed") {
+		t.Errorf("json: %s", buf.String())
+	}
+}
@@ -24,14 +24,24 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/pkg/embedprefix"
+	"github.com/kraklabs/cie/pkg/errcode"
+	"github.com/kraklabs/cie/pkg/ingestion"
+	"github.com/kraklabs/cie/pkg/llm"
 	"github.com/kraklabs/cie/pkg/storage"
 	"github.com/kraklabs/cie/pkg/tools"
 )
@@ -57,15 +67,19 @@ All CIE tool queries MUST be in English. The keyword boost algorithm matches que
 | List HTTP/REST endpoints | cie_list_endpoints | path_pattern="apps/gateway" |
 | Trace call path to a function | cie_trace_path | target="RegisterRoutes" |
 | Semantic/meaning-based search | cie_semantic_search | query="authentication logic" |
+| Duplicates of / alternatives to a function | cie_similar_to_function | function_name="ParseConfig" |
 | Architectural questions | cie_analyze | question="What are the entry points?" |
 | Find function by name | cie_find_function | name="BuildRouter" |
 | What calls a function? | cie_find_callers | function_name="HandleAuth" |
 | What does a function call? | cie_find_callees | function_name="HandleAuth" |
 | Get function source code | cie_get_function_code | function_name="BuildRouter" |
+| Get exact lines of a file | cie_get_lines | path="batcher.go", start_line=120 |
 | Find interface implementations | cie_find_implementations | interface_name="Repository" |
 | Find type/interface/struct | cie_find_type | name="UserService" |
+| Type definition, fields and methods | cie_get_type_code | type_name="Server", outline=true |
 | Explore directory structure | cie_directory_summary | path="internal/cie" |
 | Check index health | cie_index_status | (no args = check entire index) |
+| Qualify index quality | cie_index_health | (no args) |
 | Function git commit history | cie_function_history | function_name="HandleAuth" |
 | Find when code was introduced | cie_find_introduction | code_snippet="jwt.Generate()" |
 | Function code ownership/blame | cie_blame_function | function_name="Parse" |
@@ -83,6 +97,7 @@ Follow this progression for most code exploration tasks:
 3. **Navigate** — Follow the call graph with cie_find_callers, cie_find_callees, or cie_trace_path.
 4. **Inspect** — Read specific function code with cie_get_function_code (use full_code=true for long functions).
 5. **Analyze** — For architectural questions that span multiple functions, use cie_analyze.
+6. **Remember** — Call cie_recall_facts early for project conventions and gotchas earlier sessions stored; save new ones with cie_store_fact.
 
 ## Tool Categories and When to Use Each
 
@@ -112,6 +127,8 @@ Follow this progression for most code exploration tasks:
 - min_similarity: Set threshold (0.7 = high confidence only)
 - Confidence indicators in results: 🟢 High (≥75%), 🟡 Medium (50-75%), 🔴 Low (<50%)
 
+**cie_similar_to_function** — Nearest neighbors of an indexed function by its stored embedding. Needs no embedding provider, so it works offline. Use to find duplicates or alternative implementations before writing new code. Pass function_name (or function_id); results of 95% or more are flagged as likely duplicates.
+
 **cie_analyze** — Architectural Q&A with LLM narrative. Use for high-level questions that span multiple functions. Combines semantic search with keyword boosting and generates a narrative answer. Use for:
 - "What are the main entry points?"
 - "How does authentication work?"
@@ -124,18 +141,25 @@ Follow this progression for most code exploration tasks:
 
 **cie_get_function_code** — Get full source code of a function. Always use full_code=true for long functions — without it, output may be truncated.
 
+**cie_get_lines** — Exact lines of a file (path + start_line/end_line), verbatim from the stored file text or from disk. Use it for code outside functions or past the indexed code-text size limit.
+
 **cie_find_callers** — Who calls this function? Set include_indirect=true for transitive callers (callers of callers).
 
 **cie_find_callees** — What does this function call? Shows all outgoing dependencies. Resolves method calls through both interface-typed and concrete-typed struct fields (e.g., b.db.Run() where db is *CozoDB). Also resolves calls through interface-typed function parameters.
 
 **cie_get_call_graph** — Combined view: both callers and callees in one call.
 
-**cie_trace_path** — Trace execution path from entry point to target function. Auto-detects entry points (main for Go, index exports for JS/TS, __main__ for Python). Use source parameter to trace between arbitrary functions. Increase max_depth for deeply nested targets. Resolves calls through concrete struct fields and interface parameters with fan-out reduction.
+**cie_trace_path** — Trace execution path from entry point to target function. Auto-detects entry points (main for Go, index exports for JS/TS, __main__ for Python, plus CLI commands, servers, Lambda handlers and cron jobs found during indexing). Use source parameter to trace between arbitrary functions. Increase max_depth for deeply nested targets. Resolves calls through concrete struct fields and interface parameters with fan-out reduction.
 
 ### Type & Interface Tools
 
 **cie_find_type** — Find types, structs, interfaces, classes by name. Filter by kind: "struct", "interface", "class", "type_alias".
 
+**cie_get_type_code** — Source of a type, interface or class, optionally with its methods' code. outline=true returns only fields and method signatures.
+
+**cie_type_api** — List all methods of a type grouped by file, with signatures and implemented interfaces. Use file_path when several types share the name.
+**cie_type_graph** — Data-model dependencies of a type: the types it references through fields or embeds, and the types referencing it, with depth control.
+
 **cie_find_implementations** — Find concrete types that implement an interface. Works for Go (struct method matching) and TypeScript (implements keyword). Resolves embedded interfaces (e.g., ReadWriter embedding Reader+Writer) and common stdlib interfaces.
 
 **cie_find_by_signature** — Find functions by parameter type or return type. Searches function signatures for a given base type name, matching regardless of pointer/slice/package prefix. Useful for discovering which functions accept a specific interface or struct.
@@ -150,10 +174,38 @@ Follow this progression for most code exploration tasks:
 
 **cie_get_file_summary** — All entities (functions, types, constants) in a file. More detailed than list_functions_in_file.
 
-**cie_list_endpoints** — HTTP/REST endpoints from Go frameworks (Gin, Echo, Chi, Fiber, net/http). Returns [Method] [Path] [Handler] [File].
+Both take outline=true for a compact listing: one line per declaration with its full signature (and field names in file summaries), no code. Prefer it when context is tight.
+
+**cie_list_endpoints** — HTTP/REST endpoints from Go frameworks (Gin, Echo, Chi, Fiber, net/http). Returns [Method] [Path] [Handler] [Middleware] [File], with per-route middleware chains and coverage.
+
+**cie_templates** — Templates (Go, Jinja, ERB) with the variables and blocks they use and the handlers that render them.
+
+**cie_tables** — Database tables used by each function, from SQL strings and ORM calls (gorm, sqlx, SQLAlchemy, Prisma). Use table to see who reads and writes it before a migration.
+
+**cie_ci_jobs** — CI jobs from GitHub Actions and GitLab CI with their steps, scripts and env vars. Use query to find which workflow runs something (e.g., 'integration').
 
 **cie_list_services** — gRPC service definitions and RPC methods from .proto files.
 
+### Notes
+
+**cie_add_note** — Attach a persistent note to a function or file (e.g., "legacy path, don't extend"). Notes survive re-indexing and show up with that code in cie_semantic_search and cie_find_function results.
+
+**cie_get_notes** — List notes, optionally for one function or file or containing some text.
+
+**cie_collection_add** / **cie_collection_remove** — Curate named sets of functions and files (e.g., 'payment-critical-path'). Pass collection="payment-critical-path" to any tool with path_pattern to search or analyze only those files.
+
+**cie_collection_list** — List collections, or the members of one.
+
+### Memory
+
+**cie_store_fact** — Save project knowledge for later sessions: conventions, gotchas, decisions (e.g., "handlers never call the DB directly"). Facts are embedded for semantic recall and survive re-indexing.
+
+**cie_recall_facts** — Find stored facts by meaning (query="how are tests run") or list them, optionally by tag.
+
+### Feedback
+
+**cie_search_feedback** — After using a search result, report whether it helped (query, result, useful, and the rank and similarity it was shown with). Ratings tune search defaults; see 'cie bench --feedback'.
+
 ### Git History Tools
 
 **cie_function_history** — Git commit history for a specific function. Use since="2024-01-01" to filter by date. Use path_pattern to disambiguate functions with the same name in different files.
@@ -170,6 +222,10 @@ Follow this progression for most code exploration tasks:
 
 **cie_index_status** — Check index health. Use this FIRST when searches return no results — the path might not be indexed.
 
+**cie_index_health** — Grade index quality: embedding coverage, HNSW index, parse errors by language, unresolved calls and stale files. Call it before answering from the index when completeness matters, and mention failing checks in your answer.
+
+**cie_resolution_report** — Call-graph coverage and why calls stayed unresolved. Use it when callers/callees or traced paths look incomplete.
+
 ## Common Parameters
 
 Several tools share these parameters:
@@ -224,7 +280,58 @@ type mcpServerInfo struct {
 }
 
 type mcpCapabilities struct {
-	Tools map[string]any `json:"tools,omitempty"` // Tool capabilities declaration
+	Tools   map[string]any `json:"tools,omitempty"`   // Tool capabilities declaration
+	Logging *struct{}      `json:"logging,omitempty"` // Server sends notifications/message
+}
+
+// jsonRPCNotification is a server-initiated JSON-RPC message with no ID.
+type jsonRPCNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// mcpLogMessage is the params object of a notifications/message notification.
+type mcpLogMessage struct {
+	Level  string `json:"level"`
+	Logger string `json:"logger"`
+	Data   any    `json:"data"`
+}
+
+// mcpLogLevels are the MCP (syslog) log levels from least to most severe.
+var mcpLogLevels = []string{"debug", "info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+const mcpLogInfo = "info"
+
+// mcpLogLevel holds the minimum severity the client asked for with
+// logging/setLevel. It is shared with background goroutines that send
+// notifications. A nil or unset level allows every message.
+type mcpLogLevel struct {
+	min atomic.Int32
+}
+
+// set records the minimum level, reporting false for an unknown name.
+func (l *mcpLogLevel) set(level string) bool {
+	for i, name := range mcpLogLevels {
+		if name == level {
+			l.min.Store(int32(i))
+			return true
+		}
+	}
+	return false
+}
+
+// allows reports whether a message at level should be sent.
+func (l *mcpLogLevel) allows(level string) bool {
+	if l == nil {
+		return true
+	}
+	for i, name := range mcpLogLevels {
+		if name == level {
+			return int32(i) >= l.min.Load()
+		}
+	}
+	return false
 }
 
 // mcpInitializeResult is the response to the MCP initialize request.
@@ -263,8 +370,22 @@ type mcpToolCallParams struct {
 //
 // Contains the tool's output as an array of content blocks (typically text).
 type mcpToolResult struct {
-	Content []mcpContent `json:"content"`
-	IsError bool         `json:"isError,omitempty"` // True if tool execution failed
+	Content []mcpContent   `json:"content"`
+	IsError bool           `json:"isError,omitempty"` // True if tool execution failed
+	Meta    map[string]any `json:"_meta,omitempty"`   // Index freshness and error code
+}
+
+// errorCodeMetaKey is the _meta key carrying the errcode.Code of a failed
+// tool call, so clients can branch on the failure kind instead of the text.
+const errorCodeMetaKey = "cie/error_code"
+
+// toolErrorResult builds an error result tagged with code.
+func toolErrorResult(code errcode.Code, text string) *mcpToolResult {
+	return &mcpToolResult{
+		Content: []mcpContent{{Type: "text", Text: text}},
+		IsError: true,
+		Meta:    map[string]any{errorCodeMetaKey: code},
+	}
 }
 
 // mcpContent represents a single content block in a tool result.
@@ -288,6 +409,98 @@ type mcpServer struct {
 	embeddingModel string
 	customRoles    map[string]RolePattern // Custom role patterns from config
 	gitExecutor    tools.GitRunner        // Git executor for history tools (may be nil)
+	rawQuery       tools.RawQueryPolicy   // Guardrails for cie_raw_query
+	ranking        tools.RankingWeights   // Ordering of cie_semantic_search results
+	audit          *storage.AuditLog      // Tool invocation log (nil when disabled)
+	feedback       *storage.FeedbackLog   // Ratings from cie_search_feedback (nil if it failed to open)
+	sessionID      string                 // Identifies this server process in the audit log
+	limiter        *rateLimiter           // Per-tool/session call limits (nil = unlimited)
+	cache          *resultCache           // Memoized results of expensive tools (nil = disabled)
+	warmup         *warmupState           // Shared state of the index warm-up run
+	llm            llm.Provider           // Narrative LLM (nil when llm.enabled is off)
+	llmMaxTokens   int
+	profiles       map[string]mcpProfile  // Provider profiles selectable per tool call
+	projects       map[string]*mcpServer  // Workspace projects by ID (nil outside a workspace)
+	logLevel       *mcpLogLevel           // Minimum level for notifications/message
+	indexWatch     time.Duration          // How often to poll for rebuilds (0 = off)
+	indexSeen      *tools.IndexSnapshot   // Last index state seen by the watcher
+	rewarm         bool                   // Re-run the warm-up after a rebuild
+	freshness      *freshnessCache        // Staleness check for tool results (nil = disabled)
+	live           *liveSource            // On-the-fly parsing of unindexed files (nil = disabled)
+	savedQueries   map[string]SavedQuery  // From .cie/queries.yaml; exposed ones are extra tools
+
+	// contentHash is how indexed file hashes were normalized, so
+	// cie_get_lines can tell whether a file changed on disk.
+	contentHash ingestion.ContentHashMode
+
+	// embeddingPrefixes and embeddingDimensions complete the embedding
+	// setup of embeddingURL and embeddingModel (nil prefixes: the ones
+	// configured for the model). indexDimensions is the vector size the
+	// index was opened with; profiles do not change it.
+	embeddingPrefixes   *embedprefix.Prefixes
+	embeddingDimensions int
+	indexDimensions     int
+}
+
+// mcpProfile holds the provider settings of one configured profile, resolved
+// at startup so a tool call can switch to it with a "profile" argument.
+type mcpProfile struct {
+	embedding    EmbeddingConfig
+	llm          llm.Provider
+	llmMaxTokens int
+}
+
+// newMCPProfiles resolves every profile in cfg against the loaded settings.
+// Profiles that fail to apply are logged and skipped; a profile whose LLM
+// provider cannot be built is an error.
+func newMCPProfiles(cfg *Config) (map[string]mcpProfile, error) {
+	profiles := make(map[string]mcpProfile, len(cfg.Profiles))
+	for _, name := range cfg.ProfileNames() {
+		resolved := *cfg
+		if err := resolved.ApplyProfile(name); err != nil {
+			fmt.Fprintf(os.Stderr, "  Warning: profile %q ignored: %v\n", name, err)
+			continue
+		}
+		provider, err := newLLMProvider(resolved.LLM)
+		if err != nil {
+			return nil, fmt.Errorf("profile %q: %w", name, err)
+		}
+		profiles[name] = mcpProfile{
+			embedding:    resolved.Embedding,
+			llm:          provider,
+			llmMaxTokens: llmMaxTokens(resolved.LLM),
+		}
+	}
+	return profiles, nil
+}
+
+// withProfile returns a copy of s using the named profile's providers.
+func (s *mcpServer) withProfile(name string) (*mcpServer, error) {
+	p, ok := s.profiles[name]
+	if !ok {
+		names := make([]string, 0, len(s.profiles))
+		for n := range s.profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return nil, fmt.Errorf("unknown profile %q: no profiles are configured", name)
+		}
+		return nil, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	copied := *s
+	copied.setEmbedding(p.embedding)
+	copied.llm, copied.llmMaxTokens = p.llm, p.llmMaxTokens
+	return &copied, nil
+}
+
+// setEmbedding makes s embed queries with emb: its endpoint, model, resolved
+// prefixes and dimensions.
+func (s *mcpServer) setEmbedding(emb EmbeddingConfig) {
+	prefixes := emb.Prefixes()
+	s.embeddingURL, s.embeddingModel = emb.BaseURL, emb.Model
+	s.embeddingPrefixes = &prefixes
+	s.embeddingDimensions = emb.Dimensions
 }
 
 // runMCPServer starts the CIE Model Context Protocol server.
@@ -324,29 +537,186 @@ func runMCPServer(configPath string) {
 	fmt.Fprintf(os.Stderr, "MCP Server CWD: %s\n", cwd)
 	fmt.Fprintf(os.Stderr, "Config path arg: %q\n", configPath)
 
+	// Started from a workspace root (typically by an IDE): serve every project.
+	if configPath == "" && os.Getenv("CIE_CONFIG_PATH") == "" {
+		if _, wsPath, err := discoverConfig(cwd); err == nil && wsPath != "" {
+			server, err := newWorkspaceMCPServer(wsPath, cwd)
+			if err != nil {
+				errors.FatalError(err, false)
+			}
+			fmt.Fprintf(os.Stderr, "CIE MCP Server v%s starting (workspace %s)...\n", mcpVersion, wsPath)
+			for _, name := range server.projectNames() {
+				fmt.Fprintf(os.Stderr, "  Project: %s (%s mode)\n", name, server.projects[name].mode)
+			}
+			serveMCPLoop(server)
+			return
+		}
+	}
+
 	cfg := loadMCPConfig(configPath)
+	server, err := newMCPServer(cfg, configPath, cwd)
+	if err != nil {
+		errors.FatalError(err, false)
+	}
+	server.savedQueries = loadServerSavedQueries(configPath)
+
+	fmt.Fprintf(os.Stderr, "CIE MCP Server v%s starting (%s mode)...\n", mcpVersion, server.mode)
+	if server.mode == "remote" {
+		fmt.Fprintf(os.Stderr, "  Edge Cache: %s\n", cfg.CIE.EdgeCache)
+	}
+	fmt.Fprintf(os.Stderr, "  Project: %s\n", server.projectID)
+	if cfg.MCP.Warmup {
+		server.startBackgroundWarmup()
+	}
+	if cfg.Reindex != "" {
+		server.startScheduledReindex(context.Background(), cfg)
+	}
+
+	serveMCPLoop(server)
+}
+
+// newMCPServer opens the project described by cfg and wires up its git
+// history, audit log and guardrails. gitPath locates the repository for the
+// history tools; when empty, cwd is used.
+func newMCPServer(cfg *Config, gitPath, cwd string) (*mcpServer, error) {
+	provider, err := newLLMProvider(cfg.LLM)
+	if err != nil {
+		return nil, llmConfigError(err)
+	}
+	profiles, err := newMCPProfiles(cfg)
+	if err != nil {
+		return nil, llmConfigError(err)
+	}
 	client, mode, projectID := setupMCPClient(cfg)
 
 	fmt.Fprintf(os.Stderr, "  Embedding configured: %s (%s)\n", cfg.Embedding.BaseURL, cfg.Embedding.Model)
 
 	server := &mcpServer{
-		client:         client,
-		projectID:      projectID,
-		mode:           mode,
-		embeddingURL:   cfg.Embedding.BaseURL,
-		embeddingModel: cfg.Embedding.Model,
-		customRoles:    cfg.Roles.Custom,
+		client:       client,
+		projectID:    projectID,
+		mode:         mode,
+		customRoles:  cfg.Roles.Custom,
+		rawQuery:     cfg.MCP.RawQuery.Policy(),
+		ranking:      cfg.Search.Ranking.Weights(),
+		limiter:      newRateLimiter(cfg.MCP.RateLimits),
+		cache:        newResultCache(cfg.MCP.Cache),
+		warmup:       &warmupState{},
+		llm:          provider,
+		llmMaxTokens: llmMaxTokens(cfg.LLM),
+		profiles:     profiles,
+		indexWatch:   cfg.MCP.IndexWatch.interval(),
+		rewarm:       cfg.MCP.Warmup,
+		contentHash:  ingestion.ContentHashMode(cfg.Indexing.ContentHash),
+	}
+	server.setEmbedding(cfg.Embedding)
+	server.indexDimensions = cfg.Embedding.Dimensions
+	if !cfg.MCP.DisableFreshness {
+		server.freshness = newFreshnessCache()
+	}
+	if server.rawQuery.AllowWrites {
+		fmt.Fprintf(os.Stderr, "  Warning: cie_raw_query writes are ENABLED (mcp.raw_query.allow_writes)\n")
+	}
+	if err := tools.ValidateRoles(rolePatterns(cfg.Roles.Custom)); err != nil {
+		fmt.Fprintf(os.Stderr, "  Warning: roles.custom: %v; searches filtering by role will fail\n", err)
 	}
 
-	setupGitExecutor(server, configPath, cwd)
+	setupGitExecutor(server, gitPath, cwd)
+	setupAuditLog(server, cfg)
+	setupFeedbackLog(server, cfg)
+	if (cfg.MCP.LiveParse || cfg.MCP.Overlay) && server.gitExecutor != nil {
+		server.live = newLiveSource(server.client, server.gitExecutor, cfg.MCP.Overlay)
+	}
+	return server, nil
+}
 
-	fmt.Fprintf(os.Stderr, "CIE MCP Server v%s starting (%s mode)...\n", mcpVersion, server.mode)
-	if server.mode == "remote" {
-		fmt.Fprintf(os.Stderr, "  Edge Cache: %s\n", cfg.CIE.EdgeCache)
+// newWorkspaceMCPServer opens every project listed in a workspace file. The
+// returned server is the first project's; it routes calls carrying a
+// "project" argument to the matching project.
+func newWorkspaceMCPServer(wsPath, cwd string) (*mcpServer, error) {
+	ws, err := LoadWorkspace(wsPath)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := ws.ProjectConfigPaths()
+	if err != nil {
+		return nil, err
 	}
-	fmt.Fprintf(os.Stderr, "  Project: %s\n", server.projectID)
 
-	serveMCPLoop(server)
+	var primary *mcpServer
+	projects := make(map[string]*mcpServer, len(paths))
+	for _, path := range paths {
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := projects[cfg.ProjectID]; dup {
+			return nil, errors.NewConfigError(
+				"Duplicate workspace project",
+				fmt.Sprintf("More than one project in %s uses project_id %q", wsPath, cfg.ProjectID),
+				"Give each project a distinct project_id in its .cie/project.yaml",
+				nil,
+			)
+		}
+		server, err := newMCPServer(cfg, ProjectRoot(path), cwd)
+		if err != nil {
+			return nil, err
+		}
+		server.savedQueries = loadServerSavedQueries(path)
+		if cfg.MCP.Warmup {
+			server.startBackgroundWarmup()
+		}
+		projects[cfg.ProjectID] = server
+		if primary == nil {
+			primary = server
+		}
+	}
+	primary.projects = projects
+	return primary, nil
+}
+
+// projectNames returns the workspace project IDs in sorted order.
+func (s *mcpServer) projectNames() []string {
+	names := make([]string, 0, len(s.projects))
+	for name := range s.projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// projectServer returns the server for the "project" argument of a tool
+// call, or s itself when the argument is absent or names s's project.
+func (s *mcpServer) projectServer(args map[string]any) (*mcpServer, error) {
+	name, _ := args["project"].(string)
+	if name == "" || name == s.projectID {
+		return s, nil
+	}
+	if target, ok := s.projects[name]; ok {
+		return target, nil
+	}
+	if len(s.projects) == 0 {
+		return nil, fmt.Errorf("unknown project %q: this server only serves %q", name, s.projectID)
+	}
+	return nil, fmt.Errorf("unknown project %q (available: %s)", name, strings.Join(s.projectNames(), ", "))
+}
+
+// withProjectArgument adds an optional "project" property to every tool
+// schema when the server fronts a workspace.
+func (s *mcpServer) withProjectArgument(list []mcpTool) []mcpTool {
+	if len(s.projects) < 2 {
+		return list
+	}
+	prop := map[string]any{
+		"type":        "string",
+		"enum":        s.projectNames(),
+		"description": fmt.Sprintf("Workspace project to query (default: %s)", s.projectID),
+	}
+	for _, tool := range list {
+		if props, ok := tool.InputSchema["properties"].(map[string]any); ok {
+			props["project"] = prop
+		}
+	}
+	return list
 }
 
 // loadMCPConfig loads the config file or falls back to environment variables.
@@ -390,13 +760,28 @@ func setupMCPClient(cfg *Config) (tools.Querier, string, string) {
 
 // setupEmbeddedClient opens a local CozoDB backend and returns an EmbeddedQuerier.
 func setupEmbeddedClient(cfg *Config, title, detail, suggestion, mode string) (tools.Querier, string, string) {
-	backend, err := storage.NewEmbeddedBackend(storage.EmbeddedConfig{
-		ProjectID:           cfg.ProjectID,
-		Engine:              "rocksdb",
-		EmbeddingDimensions: cfg.Embedding.Dimensions,
-	})
-	if err != nil {
-		errors.FatalError(errors.NewDatabaseError(title, detail, suggestion, err), false)
+	var backend *storage.EmbeddedBackend
+	var err error
+	if cfg.MCP.At != "" {
+		backend, err = openSnapshotBackend(cfg, cfg.MCP.At)
+		if err != nil {
+			errors.FatalError(snapshotOpenError(cfg.MCP.At, err), false)
+		}
+		fmt.Fprintf(os.Stderr, "Serving index snapshot %s (read-only)\n", cfg.MCP.At)
+		mode += ", snapshot " + cfg.MCP.At
+	} else {
+		backend, err = storage.NewEmbeddedBackend(storage.EmbeddedConfig{
+			ProjectID:           cfg.ProjectID,
+			Engine:              cfg.StorageEngine(),
+			EmbeddingDimensions: cfg.Embedding.Dimensions,
+			Sharded:             cfg.Storage.Sharded,
+			// Give the database up between requests so `cie index` can
+			// run while the server is up.
+			Shared: true,
+		})
+		if err != nil {
+			errors.FatalError(errors.NewDatabaseError(title, detail, openFailureFix(err, suggestion), err), false)
+		}
 	}
 	go func() {
 		sigCh := make(chan os.Signal, 1)
@@ -452,8 +837,35 @@ func setupGitExecutor(server *mcpServer, configPath, cwd string) {
 
 // serveMCPLoop reads JSON-RPC requests from stdin and writes responses to stdout.
 func serveMCPLoop(server *mcpServer) {
-	scanner := bufio.NewScanner(os.Stdin)
+	if err := serveMCP(server, os.Stdin, os.Stdout); err != nil {
+		ue := errors.NewInternalError(
+			"MCP server input error",
+			"Failed to read from stdin",
+			"Check if stdin is closed or if there's a pipe issue.",
+			err,
+		)
+		errors.FatalError(ue, false)
+	}
+}
+
+// serveMCP runs the JSON-RPC loop over an arbitrary reader/writer pair until
+// the reader is exhausted. It returns the scanner error, if any, so callers
+// other than the stdio entry point (such as in-process test harnesses) can
+// decide how to handle it.
+//
+// Once the client sends notifications/initialized, a background goroutine
+// watches for index rebuilds and reports them as notifications/message.
+func serveMCP(server *mcpServer, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
 	scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024)
+	out := &mcpWriter{w: w}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if server.logLevel == nil {
+		server.logLevel = &mcpLogLevel{}
+	}
+	watching := false
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -474,15 +886,18 @@ func serveMCPLoop(server *mcpServer) {
 
 		fmt.Fprintf(os.Stderr, "-> %s\n", req.Method)
 
-		ctx := context.Background()
+		if req.Method == "notifications/initialized" && !watching && server.indexWatch > 0 {
+			watching = true
+			go server.watchIndex(ctx, out.notify)
+		}
+
 		resp := server.handleRequest(ctx, req)
 
 		if resp.ID == nil && resp.Result == nil && resp.Error == nil {
 			continue
 		}
 
-		respBytes, err := json.Marshal(resp)
-		if err != nil {
+		if err := out.send(resp); err != nil {
 			ue := errors.NewInternalError(
 				"Cannot encode MCP response",
 				"Failed to marshal response to JSON",
@@ -493,23 +908,68 @@ func serveMCPLoop(server *mcpServer) {
 			continue
 		}
 
-		_, _ = fmt.Fprintf(os.Stdout, "%s\n", respBytes)
-		_ = os.Stdout.Sync()
-
 		fmt.Fprintf(os.Stderr, "<- response sent for %s\n", req.Method)
 	}
 
-	if err := scanner.Err(); err != nil {
-		ue := errors.NewInternalError(
-			"MCP server input error",
-			"Failed to read from stdin",
-			"Check if stdin is closed or if there's a pipe issue.",
-			err,
-		)
-		errors.FatalError(ue, false)
+	return scanner.Err()
+}
+
+// mcpWriter serializes messages onto the transport, one JSON object per
+// line. Responses and background notifications share it.
+type mcpWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// send writes msg as a single line.
+func (o *mcpWriter) send(msg any) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_, _ = fmt.Fprintf(o.w, "%s\n", data)
+	if f, ok := o.w.(*os.File); ok {
+		_ = f.Sync()
+	}
+	return nil
+}
+
+// notify sends a JSON-RPC notification, logging failures to stderr.
+func (o *mcpWriter) notify(method string, params any) {
+	if err := o.send(jsonRPCNotification{JSONRPC: "2.0", Method: method, Params: params}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot send %s: %v\n", method, err)
 	}
 }
 
+// roleEnum returns the built-in roles a tool accepts followed by the
+// project's custom roles.
+func (s *mcpServer) roleEnum(builtin ...string) []string {
+	names := make([]string, 0, len(s.customRoles))
+	for name := range s.customRoles {
+		if !slices.Contains(tools.BuiltinRoleNames(), name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append(builtin, names...)
+}
+
+// withLanguageFilter adds the optional "language" and "dialect" properties
+// to the schema properties of a tool that filters functions by language.
+func withLanguageFilter(props map[string]any) map[string]any {
+	props["language"] = map[string]any{
+		"type":        "string",
+		"description": "Optional: only functions in this language as recorded at index time (e.g., 'go', 'python', 'typescript'; aliases 'ts', 'js', 'py' work)",
+	}
+	props["dialect"] = map[string]any{
+		"type":        "string",
+		"description": "Optional: only functions with this framework hint, matched as a case-insensitive substring (e.g., 'gin handler', 'react component', 'fastapi route', 'go test')",
+	}
+	return props
+}
+
 func (s *mcpServer) getTools() []mcpTool {
 	return []mcpTool{
 		{
@@ -527,20 +987,73 @@ func (s *mcpServer) getTools() []mcpTool {
 			},
 		},
 		{
-			Name:        "cie_schema",
-			Description: "Get the CIE database schema, available tables, fields, operators, and example queries. Call this first to understand what data is available and how to query it.",
+			Name:        "cie_index_health",
+			Description: "Grade the quality of the index with one verdict: embedding coverage (% of functions with vectors), HNSW index presence, parse-error rate by language, unresolved-call ratio and files changed since indexing. Call it before relying on the index for answers that must be complete (e.g. 'nothing calls X'), and qualify your answer with any warnings it reports.",
 			InputSchema: map[string]any{
 				"type":       "object",
 				"properties": map[string]any{},
 				"required":   []string{},
 			},
 		},
+		{
+			Name:        "cie_resolution_report",
+			Description: "Report call-graph coverage: how many calls resolved to an edge, how many did not and why (external, unexported, unknown_receiver, not_found), and the most frequent unresolved callees. Use this when cie_find_callers or cie_trace_path miss edges you expect.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"path_pattern": map[string]any{
+						"type":        "string",
+						"description": "Optional regex on the caller's file path (e.g., 'internal/api')",
+					},
+					"reason": map[string]any{
+						"type":        "string",
+						"enum":        []string{"external", "unexported", "unknown_receiver", "not_found"},
+						"description": "Only this reason; also lists example call sites",
+					},
+					"limit": map[string]any{
+						"type":        "integer",
+						"description": "Top callees and examples to show (default: 20)",
+						"default":     20,
+					},
+				},
+				"required": []string{},
+			},
+		},
+		{
+			Name:        "cie_schema",
+			Description: "Get the CIE database schema: every relation with its columns, keys, descriptions and joins, plus operators and example queries. Call this first to understand what data is available and how to query it. Pass relation for one relation's ready-to-run cie_raw_query examples.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"relation": map[string]any{
+						"type":        "string",
+						"description": "Describe only this relation (e.g. 'cie_calls' or 'calls'), with example queries generated from its columns and joins",
+					},
+				},
+				"required": []string{},
+			},
+		},
+		{
+			Name:        "cie_warmup",
+			Description: "Preload the index (RocksDB blocks and HNSW graphs) so subsequent queries, especially semantic search, respond quickly. Waits for an in-progress warm-up instead of starting a second one. Returns per-step timings.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"force": map[string]any{
+						"type":        "boolean",
+						"description": "Run again even if a warm-up already completed this session",
+						"default":     false,
+					},
+				},
+				"required": []string{},
+			},
+		},
 		{
 			Name:        "cie_search_text",
 			Description: "Search for text patterns in function code, signatures, or names. Returns matching functions with file path, line numbers, and context. IMPORTANT: Use literal=true for exact code patterns like '.GET(', '->', '::' etc. Only use regex mode for complex patterns.",
 			InputSchema: map[string]any{
 				"type": "object",
-				"properties": map[string]any{
+				"properties": withLanguageFilter(map[string]any{
 					"pattern": map[string]any{
 						"type":        "string",
 						"description": "Pattern to search for. For exact code (e.g., '.GET(', '->'), use with literal=true. For regex (e.g., '(?i)handler.*error'), use literal=false.",
@@ -552,8 +1065,8 @@ func (s *mcpServer) getTools() []mcpTool {
 					},
 					"search_in": map[string]any{
 						"type":        "string",
-						"enum":        []string{"code", "signature", "name", "all"},
-						"description": "Where to search: 'code' (function body), 'signature', 'name', or 'all'",
+						"enum":        []string{"code", "signature", "name", "all", "files"},
+						"description": "Where to search: 'code' (function body), 'signature', 'name', 'all', or 'files' (full file text, including code outside functions; requires indexing.store_file_text)",
 						"default":     "all",
 					},
 					"file_pattern": map[string]any{
@@ -565,7 +1078,12 @@ func (s *mcpServer) getTools() []mcpTool {
 						"description": "Maximum results to return (default: 20)",
 						"default":     20,
 					},
-				},
+					"group_by": map[string]any{
+						"type":        "string",
+						"enum":        []string{"file", "package", "function"},
+						"description": "Optional: aggregate matches per file, package or function with counts and a few previews each; 'limit' then caps the number of groups. Use when one file (e.g. generated code) would otherwise dominate the results.",
+					},
+				}),
 				"required": []string{"pattern"},
 			},
 		},
@@ -574,10 +1092,10 @@ func (s *mcpServer) getTools() []mcpTool {
 			Description: "Find functions by name. Handles Go receiver syntax (e.g., searching 'Batch' finds 'Batcher.Batch'). Returns function details including signature, location, and code.",
 			InputSchema: map[string]any{
 				"type": "object",
-				"properties": map[string]any{
+				"properties": withLanguageFilter(map[string]any{
 					"name": map[string]any{
 						"type":        "string",
-						"description": "Function name to find. Can be exact ('NewBatcher') or partial ('Batch' finds 'Batcher.Batch')",
+						"description": "Function name to find. Can be exact ('NewBatcher'), partial ('Batch' finds 'Batcher.Batch'), or package-qualified ('tools.FindFunction', 'pkg/storage.EmbeddedBackend.Close')",
 					},
 					"exact_match": map[string]any{
 						"type":        "boolean",
@@ -589,7 +1107,12 @@ func (s *mcpServer) getTools() []mcpTool {
 						"description": "If true, include full function code in results",
 						"default":     false,
 					},
-				},
+					"fuzzy": map[string]any{
+						"type":        "boolean",
+						"description": "Rank names by similarity instead of matching: tolerates typos, abbreviations ('hndlusr') and camelCase words ('user handler'). Misses are always followed by fuzzy suggestions.",
+						"default":     false,
+					},
+				}),
 				"required": []string{"name"},
 			},
 		},
@@ -628,7 +1151,7 @@ func (s *mcpServer) getTools() []mcpTool {
 		},
 		{
 			Name:        "cie_find_type",
-			Description: "Find types, interfaces, classes, or structs by name or pattern. Works across all languages: Go (struct/interface), Python (class), TypeScript (interface/class). Use this to find architectural definitions.",
+			Description: "Find types, interfaces, classes, or structs by name or pattern. Works across all languages: Go (struct/interface), Python (class), TypeScript (interface/class), C# (class/struct/record/interface/enum). Use this to find architectural definitions.",
 			InputSchema: map[string]any{
 				"type": "object",
 				"properties": map[string]any{
@@ -651,72 +1174,173 @@ func (s *mcpServer) getTools() []mcpTool {
 						"description": "Maximum results (default: 20)",
 						"default":     20,
 					},
+					"fuzzy": map[string]any{
+						"type":        "boolean",
+						"description": "Rank names by similarity instead of matching: tolerates typos, abbreviations ('hndlusr') and camelCase words ('user handler'). Misses are always followed by fuzzy suggestions.",
+						"default":     false,
+					},
 				},
 				"required": []string{"name"},
 			},
 		},
 		{
-			Name:        "cie_list_files",
-			Description: "List files in the indexed codebase. Can filter by language, path pattern, or role.",
+			Name:        "cie_get_type_code",
+			Description: "Get the source code of a type, interface, or class definition, optionally followed by the code of its methods. With outline=true, returns only the declaration with its fields and the method signatures.",
 			InputSchema: map[string]any{
 				"type": "object",
 				"properties": map[string]any{
-					"path_pattern": map[string]any{
+					"type_name": map[string]any{
 						"type":        "string",
-						"description": "Regex pattern to filter file paths (e.g., '.*batcher.*', 'internal/cie/.*')",
+						"description": "Type, struct, class or interface name (e.g., 'Server', 'UserRepository')",
 					},
-					"language": map[string]any{
+					"file_path": map[string]any{
 						"type":        "string",
-						"description": "Filter by language (e.g., 'go', 'typescript', 'python')",
+						"description": "Optional file defining the type, to disambiguate types with the same name",
 					},
-					"role": map[string]any{
-						"type":        "string",
-						"enum":        []string{"any", "source", "test", "generated"},
-						"description": "Filter by file role: 'source' (exclude tests/generated), 'test', 'generated', or 'any'",
-						"default":     "source",
+					"include_methods": map[string]any{
+						"type":        "boolean",
+						"description": "Append the code of every method of the type",
+						"default":     false,
 					},
-					"limit": map[string]any{
-						"type":        "integer",
-						"description": "Maximum results (default: 50)",
-						"default":     50,
+					"outline": map[string]any{
+						"type":        "boolean",
+						"description": "Fields and method signatures only, no code bodies",
+						"default":     false,
 					},
 				},
-				"required": []string{},
+				"required": []string{"type_name"},
 			},
 		},
 		{
-			Name:        "cie_raw_query",
-			Description: "Execute a raw CozoScript query against the CIE database. Use cie_schema first to understand the available tables and operators.",
+			Name:        "cie_type_api",
+			Description: "List the full method surface of a type, grouped by file, with signatures and the interfaces it implements. Go methods are found across every file of the package. Use this instead of several cie_find_function calls when you need to know what a type can do.",
 			InputSchema: map[string]any{
 				"type": "object",
 				"properties": map[string]any{
-					"script": map[string]any{
+					"type_name": map[string]any{
 						"type":        "string",
-						"description": "CozoScript query to execute. Example: ?[name, file_path] := *cie_function { name, file_path } :limit 10",
+						"description": "Type, struct, class or interface name (e.g., 'Server', 'UserService')",
+					},
+					"file_path": map[string]any{
+						"type":        "string",
+						"description": "Optional file defining the type, to disambiguate types with the same name",
 					},
 				},
-				"required": []string{"script"},
+				"required": []string{"type_name"},
 			},
 		},
 		{
-			Name:        "cie_get_function_code",
-			Description: "Get the full source code of a specific function by name. Returns the complete function implementation.",
+			Name:        "cie_type_graph",
+			Description: "Show the data-model dependency graph of a type: the types it references through fields or embeds (uses), and the types that reference or embed it (used_by), followed several levels deep. The type counterpart of cie_get_call_graph; use it before changing a struct or message to see what depends on it.",
 			InputSchema: map[string]any{
 				"type": "object",
 				"properties": map[string]any{
-					"function_name": map[string]any{
+					"type_name": map[string]any{
 						"type":        "string",
-						"description": "Name of the function to get code for (e.g., 'NewBatcher', 'Pipeline.Run')",
+						"description": "Type, struct, class or message name (e.g., 'User', 'OrderRequest')",
 					},
-					"full_code": map[string]any{
-						"type":        "boolean",
-						"description": "If true, return complete code without truncation. Default: false (truncates long functions with hint to view full code)",
-						"default":     false,
+					"direction": map[string]any{
+						"type":        "string",
+						"enum":        []string{"both", "uses", "used_by"},
+						"description": "Which edges to follow: 'uses' (types it references), 'used_by' (types referencing it) or 'both' (default)",
 					},
-				},
+					"depth": map[string]any{
+						"type":        "integer",
+						"description": "Levels to follow (default: 2, max: 5)",
+						"default":     2,
+					},
+				},
+				"required": []string{"type_name"},
+			},
+		},
+		{
+			Name:        "cie_list_files",
+			Description: "List files in the indexed codebase. Can filter by language, path pattern, or role.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"path_pattern": map[string]any{
+						"type":        "string",
+						"description": "Regex pattern to filter file paths (e.g., '.*batcher.*', 'internal/cie/.*')",
+					},
+					"language": map[string]any{
+						"type":        "string",
+						"description": "Filter by language (e.g., 'go', 'typescript', 'python')",
+					},
+					"role": map[string]any{
+						"type":        "string",
+						"enum":        s.roleEnum("any", "source", "test", "generated"),
+						"description": "Filter by file role: 'source' (exclude tests/generated), 'test', 'generated', 'any', or a custom role from project.yaml",
+						"default":     "any",
+					},
+					"limit": map[string]any{
+						"type":        "integer",
+						"description": "Maximum results (default: 50)",
+						"default":     50,
+					},
+				},
+				"required": []string{},
+			},
+		},
+		{
+			Name:        "cie_raw_query",
+			Description: "Execute a raw CozoScript query against the CIE database. Use cie_schema first to understand the available tables and operators. Read-only by default: mutations (:put, :rm, ::remove, ...) are rejected, queries without :limit get one added, results are capped by a row limit, and long queries time out. Reading cie_function_code (or other source-text relations) before binding its key scans every row and is flagged; bind the key through cie_function first.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"script": map[string]any{
+						"type":        "string",
+						"description": "CozoScript query to execute. Example: ?[name, file_path] := *cie_function { name, file_path } :limit 10",
+					},
+					"params": map[string]any{
+						"type":        "object",
+						"description": "Values for $name placeholders in the script, bound by the database instead of spliced into it. Use them for names, paths and other user-supplied text. Example: script '?[file_path] := *cie_function { name, file_path }, name = $name' with params {\"name\": \"NewBatcher\"}",
+					},
+				},
+				"required": []string{"script"},
+			},
+		},
+		{
+			Name:        "cie_get_function_code",
+			Description: "Get the full source code of a specific function by name. Returns the complete function implementation.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"function_name": map[string]any{
+						"type":        "string",
+						"description": "Name of the function to get code for (e.g., 'NewBatcher', 'Pipeline.Run'). Qualify with the package to pick one of several same-named functions: 'ingestion.Pipeline.Run', 'pkg/tools.FindFunction'. Ambiguous names return a list of qualified candidates.",
+					},
+					"full_code": map[string]any{
+						"type":        "boolean",
+						"description": "If true, return complete code without truncation. Default: false (truncates long functions with hint to view full code)",
+						"default":     false,
+					},
+				},
 				"required": []string{"function_name"},
 			},
 		},
+		{
+			Name:        "cie_get_lines",
+			Description: "Get a line range of a file verbatim, from the stored file text or from disk (flagged when the file changed since indexing). Use it to read code cut off by the indexed code-text size limit or outside any function.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"path": map[string]any{
+						"type":        "string",
+						"description": "File path, or a unique suffix of it (e.g., 'ingestion/batcher.go')",
+					},
+					"start_line": map[string]any{
+						"type":        "integer",
+						"description": "First line to return (1-based)",
+					},
+					"end_line": map[string]any{
+						"type":        "integer",
+						"description": "Last line to return, inclusive (default: start_line + 49; at most 500 lines per call)",
+					},
+				},
+				"required": []string{"path", "start_line"},
+			},
+		},
 		{
 			Name:        "cie_list_functions_in_file",
 			Description: "List all functions defined in a specific file. Useful for understanding file structure.",
@@ -727,10 +1351,42 @@ func (s *mcpServer) getTools() []mcpTool {
 						"type":        "string",
 						"description": "Path to the file (e.g., 'internal/cie/ingestion/batcher.go')",
 					},
+					"outline": map[string]any{
+						"type":        "boolean",
+						"description": "Compact listing: one line per function with its full signature",
+						"default":     false,
+					},
 				},
 				"required": []string{"file_path"},
 			},
 		},
+		{
+			Name:        "cie_enclosing",
+			Description: "Find the function, type and package that contain a given file:line, with code. Use it to turn stack-trace frames, compiler errors or diff hunks into indexed entities.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"location": map[string]any{
+						"type":        "string",
+						"description": "Location as 'path/to/file.go:42' (a trailing ':col' is ignored). Alternative to file_path + line.",
+					},
+					"file_path": map[string]any{
+						"type":        "string",
+						"description": "File path, or a unique suffix of it (e.g., 'ingestion/batcher.go')",
+					},
+					"line": map[string]any{
+						"type":        "integer",
+						"description": "1-based line number",
+					},
+					"include_code": map[string]any{
+						"type":        "boolean",
+						"description": "Include the code of the enclosing function (default: true)",
+						"default":     true,
+					},
+				},
+				"required": []string{},
+			},
+		},
 		{
 			Name:        "cie_get_call_graph",
 			Description: "Get the complete call graph for a function - both who calls it and what it calls.",
@@ -769,6 +1425,11 @@ func (s *mcpServer) getTools() []mcpTool {
 						"type":        "string",
 						"description": "Path to the file to summarize",
 					},
+					"outline": map[string]any{
+						"type":        "boolean",
+						"description": "Compact listing in line order: type names with their field names and function signatures, no code",
+						"default":     false,
+					},
 				},
 				"required": []string{"file_path"},
 			},
@@ -778,15 +1439,15 @@ func (s *mcpServer) getTools() []mcpTool {
 			Description: "Search for code by meaning/concept using vector similarity. Use natural language to describe what you're looking for (e.g., 'function that handles user authentication', 'code that parses JSON responses'). Returns the most semantically similar functions.",
 			InputSchema: map[string]any{
 				"type": "object",
-				"properties": map[string]any{
+				"properties": withLanguageFilter(map[string]any{
 					"query": map[string]any{
 						"type":        "string",
 						"description": "Natural language description of what you're looking for",
 					},
 					"role": map[string]any{
 						"type":        "string",
-						"enum":        []string{"any", "source", "test", "generated", "entry_point", "router", "handler"},
-						"description": "Filter by code role: 'source' (exclude tests/generated), 'entry_point' (main functions), 'router' (route definitions), 'handler' (HTTP handlers), 'test', 'generated', or 'any' (no filter)",
+						"enum":        s.roleEnum("any", "source", "test", "generated", "entry_point", "router", "handler"),
+						"description": "Filter by code role: 'source' (exclude tests/generated), 'entry_point' (main, CLI commands, server setup, Lambda handlers, scheduled jobs), 'router' (route definitions), 'handler' (HTTP handlers), 'test', 'generated', 'any' (no filter), or a custom role from project.yaml",
 						"default":     "source",
 					},
 					"path_pattern": map[string]any{
@@ -799,22 +1460,67 @@ func (s *mcpServer) getTools() []mcpTool {
 					},
 					"exclude_anonymous": map[string]any{
 						"type":        "boolean",
-						"description": "Exclude anonymous/arrow functions like $arrow_X, $anon_X from results (default: true). Set to false to include them.",
+						"description": "Exclude anonymous functions like Run.closure#1 or handler.arrow#2 from results (default: true). Set to false to include them.",
 						"default":     true,
 					},
 					"min_similarity": map[string]any{
 						"type":        "number",
 						"description": "Minimum similarity threshold (0.0-1.0, e.g., 0.5 = 50%). Only return results above this similarity score.",
 					},
+					"name_weight": map[string]any{
+						"type":        "number",
+						"description": "Share of the name/signature match in the score (0.0-1.0, default 0.3; 0 = body only). Only applies when the index was built with embedding.name_embeddings.",
+						"default":     0.3,
+					},
 					"limit": map[string]any{
 						"type":        "integer",
 						"description": "Maximum number of results (default: 10, max: 50)",
 						"default":     10,
 					},
-				},
+					"profile": map[string]any{
+						"type":        "string",
+						"description": "Optional provider profile from config (e.g., 'offline') to embed the query with",
+					},
+				}),
 				"required": []string{"query"},
 			},
 		},
+		{
+			Name:        "cie_similar_to_function",
+			Description: "Find the functions most similar to an indexed function, using its stored embedding: no re-embedding and no embedding provider call, so it works while the provider is offline. Use it to discover duplicates and alternative implementations. Neighbors at 95% similarity or more are flagged as likely duplicates.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"function_id": map[string]any{
+						"type":        "string",
+						"description": "ID of the function to start from (cie_function.id, e.g. from cie_raw_query or the header of an earlier cie_similar_to_function result)",
+					},
+					"function_name": map[string]any{
+						"type":        "string",
+						"description": "Name of the function to start from when no function_id is given (e.g., 'ParseConfig', 'config.ParseConfig', 'Server.Start')",
+					},
+					"role": map[string]any{
+						"type":        "string",
+						"enum":        []string{"any", "source", "test", "generated"},
+						"description": "Filter neighbors by code role: 'source' (exclude tests/generated), 'test', 'generated', 'any' (no filter)",
+						"default":     "source",
+					},
+					"path_pattern": map[string]any{
+						"type":        "string",
+						"description": "Optional regex to filter neighbors by file path (e.g., 'internal/')",
+					},
+					"min_similarity": map[string]any{
+						"type":        "number",
+						"description": "Minimum similarity threshold (0.0-1.0, e.g., 0.8 for close matches only)",
+					},
+					"limit": map[string]any{
+						"type":        "integer",
+						"description": "Maximum number of results (default: 10, max: 50)",
+						"default":     10,
+					},
+				},
+			},
+		},
 		{
 			Name:        "cie_analyze",
 			Description: "Analyze codebase structure and answer architectural questions. Use natural language to ask about: entry points, routes/endpoints, module organization, dependencies, patterns used, etc. Examples: 'What are the main entry points?', 'How are HTTP routes organized?', 'What's the architecture of the gateway service?'. By default, excludes test files for cleaner results.",
@@ -831,8 +1537,8 @@ func (s *mcpServer) getTools() []mcpTool {
 					},
 					"role": map[string]any{
 						"type":        "string",
-						"enum":        []string{"source", "test", "any"},
-						"description": "Filter results: 'source' (default, excludes tests), 'test' (only tests), 'any' (include all)",
+						"enum":        s.roleEnum("source", "test", "any"),
+						"description": "Filter results: 'source' (default, excludes tests), 'test' (only tests), 'any' (include all), or a custom role from project.yaml",
 						"default":     "source",
 					},
 				},
@@ -844,7 +1550,7 @@ func (s *mcpServer) getTools() []mcpTool {
 			Description: "Ultra-fast literal text search (like grep). Searches for EXACT text - no regex. Supports multi-pattern search via 'texts' array for batch searches (reduces API calls). Perfect for searching code patterns like '.GET(', '->', '::new', 'import'.",
 			InputSchema: map[string]any{
 				"type": "object",
-				"properties": map[string]any{
+				"properties": withLanguageFilter(map[string]any{
 					"text": map[string]any{
 						"type":        "string",
 						"description": "Single exact text to search for (e.g., '.GET(', 'func main'). Use 'texts' array for multiple patterns.",
@@ -877,104 +1583,480 @@ func (s *mcpServer) getTools() []mcpTool {
 						"description": "Maximum results per pattern (default: 30)",
 						"default":     30,
 					},
+					"scope": map[string]any{
+						"type":        "string",
+						"enum":        []string{"functions", "files"},
+						"description": "What to search: 'functions' (default, function bodies) or 'files' (full file text, including package-level code and non-code files; requires indexing.store_file_text)",
+						"default":     "functions",
+					},
+					"group_by": map[string]any{
+						"type":        "string",
+						"enum":        []string{"file", "package", "function"},
+						"description": "Optional: aggregate matches per file, package or function with counts and a few previews each; 'limit' then caps the number of groups. Use when one file (e.g. generated code) would otherwise dominate the results.",
+					},
+				}),
+				"required": []string{},
+			},
+		},
+		{
+			Name:        "cie_structural_search",
+			Description: "Structural (comby-style) code search. Templates match across lines and ignore formatting: ':[name]' matches balanced text, ':[[name]]' one identifier, ':[_]' anything without binding. A name used twice must match the same text. Example: 'http.Client{ Timeout: :[t] }' or 'if :[x] != nil { return :[x] }'. Returns each match with its location and hole bindings.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"pattern": map[string]any{
+						"type":        "string",
+						"description": "Template to match, e.g. 'errors.Wrap(:[err], :[msg])'",
+					},
+					"path": map[string]any{
+						"type":        "string",
+						"description": "Optional: filter by file path substring",
+					},
+					"exclude_pattern": map[string]any{
+						"type":        "string",
+						"description": "Optional: regex pattern to EXCLUDE files (e.g., '_test\\.go')",
+					},
+					"scope": map[string]any{
+						"type":        "string",
+						"enum":        []string{"functions", "files"},
+						"description": "What to search: 'functions' (default) or 'files' (full file text; requires indexing.store_file_text)",
+						"default":     "functions",
+					},
+					"limit": map[string]any{
+						"type":        "integer",
+						"description": "Maximum matches to return (default: 20)",
+						"default":     20,
+					},
+				},
+				"required": []string{"pattern"},
+			},
+		},
+		{
+			Name:        "cie_verify_absence",
+			Description: "Verify that specific patterns do NOT exist in code. Returns PASS/FAIL with detailed violations. Perfect for security audits (no hardcoded secrets, tokens, credentials) and CI/CD checks. Example: verify absence of 'access_token', 'api_key', 'password' in frontend code.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"patterns": map[string]any{
+						"type":        "array",
+						"items":       map[string]any{"type": "string"},
+						"description": "Patterns that should NOT exist in code. Example: ['access_token', 'refresh_token', 'api_key', 'secret']",
+					},
+					"path": map[string]any{
+						"type":        "string",
+						"description": "Optional: limit check to specific path (e.g., 'ui/src', 'frontend/')",
+					},
+					"exclude_pattern": map[string]any{
+						"type":        "string",
+						"description": "Optional: regex pattern to EXCLUDE files from check (e.g., '_test\\.go|mock')",
+					},
+					"case_sensitive": map[string]any{
+						"type":        "boolean",
+						"description": "If true, pattern matching is case-sensitive. Default: false",
+						"default":     false,
+					},
+					"severity": map[string]any{
+						"type":        "string",
+						"enum":        []string{"critical", "warning", "info"},
+						"description": "Severity level for violations. Default: 'warning'",
+						"default":     "warning",
+					},
+				},
+				"required": []string{"patterns"},
+			},
+		},
+		{
+			Name:        "cie_list_services",
+			Description: "List gRPC services and RPC methods from .proto files. Shows service definitions, RPC methods, their request/response types, and for each RPC the server methods implementing it and the client calls reaching it from any language. Useful for understanding API contracts in gRPC-based projects.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"path_pattern": map[string]any{
+						"type":        "string",
+						"description": "Optional: filter by file path (e.g., 'api/proto')",
+					},
+					"service_name": map[string]any{
+						"type":        "string",
+						"description": "Optional: filter by service name",
+					},
+				},
+				"required": []string{},
+			},
+		},
+		{
+			Name:        "cie_tree",
+			Description: "Render the indexed directory tree with per-directory file and function counts, dominant language, and share of test/generated files. Depth-limited. The best first map of an unfamiliar repository; follow up with cie_directory_summary on interesting directories.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"path": map[string]any{
+						"type":        "string",
+						"description": "Optional: root directory (default: repository root)",
+					},
+					"depth": map[string]any{
+						"type":        "integer",
+						"description": "Directory levels to expand below the root (default: 3)",
+						"default":     3,
+					},
+				},
+				"required": []string{},
+			},
+		},
+		{
+			Name:        "cie_package_summary",
+			Description: "Summarize one package (Go directory) or module (TS/JS/Python file or directory): exported types, key functions ranked by number of callers, imports, the packages that call into it and the packages it calls. Optionally adds an LLM-written overview paragraph.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"path": map[string]any{
+						"type":        "string",
+						"description": "Package directory (e.g., 'pkg/storage') or module file (e.g., 'src/api/users.ts')",
+					},
+					"narrative": map[string]any{
+						"type":        "boolean",
+						"description": "Add a short LLM-written overview (requires llm.enabled in config)",
+						"default":     false,
+					},
+					"profile": map[string]any{
+						"type":        "string",
+						"description": "Optional provider profile from config whose LLM writes the overview",
+					},
+				},
+				"required": []string{"path"},
+			},
+		},
+		{
+			Name:        "cie_external_api",
+			Description: "Show which stdlib and third-party packages each local package calls into, and which of their functions and methods, with call counts. Use this for dependency-risk reviews or before replacing a library. Go only.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"path_pattern": map[string]any{
+						"type":        "string",
+						"description": "Optional regex on the calling file path (e.g., 'internal/api')",
+					},
+					"package": map[string]any{
+						"type":        "string",
+						"description": "Optional substring of the external import path to focus on (e.g., 'net/http', 'aws-sdk')",
+					},
+					"limit": map[string]any{
+						"type":        "integer",
+						"description": "Maximum local packages to list (default: 30)",
+						"default":     30,
+					},
+				},
+				"required": []string{},
+			},
+		},
+		{
+			Name:        "cie_directory_summary",
+			Description: "Get a summary of a directory showing files with their main exported functions. Perfect for understanding the architecture of a module or package quickly. Shows file list with the most important functions in each.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"path": map[string]any{
+						"type":        "string",
+						"description": "Directory path to summarize (e.g., 'apps/gateway/internal/http', 'internal/cie/ingestion')",
+					},
+					"max_functions_per_file": map[string]any{
+						"type":        "integer",
+						"description": "Maximum number of functions to show per file (default: 5)",
+						"default":     5,
+					},
+				},
+				"required": []string{"path"},
+			},
+		},
+		{
+			Name:        "cie_list_endpoints",
+			Description: "List HTTP/REST endpoints defined in the codebase. Detects route definitions from common Go frameworks (Gin, Echo, Chi, Fiber, net/http). Returns a table of [Method] [Path] [Handler] [Middleware] [File], where Middleware is the ordered chain (outermost first) reconstructed from Use/Group/With/Route calls and handler wrapping for Gin, Echo, Chi and net/http, plus a per-middleware coverage summary. Perfect for understanding API structure in gateway/server code.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"path_pattern": map[string]any{
+						"type":        "string",
+						"description": "Optional: filter by file path (e.g., 'apps/gateway', 'internal/http')",
+					},
+					"path_filter": map[string]any{
+						"type":        "string",
+						"description": "Optional: filter by endpoint path substring (e.g., '/health', 'connections', '/api/v1'). Case-insensitive.",
+					},
+					"method": map[string]any{
+						"type":        "string",
+						"enum":        []string{"GET", "POST", "PUT", "DELETE", "PATCH", "ANY", ""},
+						"description": "Optional: filter by HTTP method",
+					},
+					"limit": map[string]any{
+						"type":        "integer",
+						"description": "Maximum results (default: 100)",
+						"default":     100,
+					},
+				},
+				"required": []string{},
+			},
+		},
+		{
+			Name:        "cie_templates",
+			Description: "Show indexed templates (Go html/template and text/template, Jinja, ERB): the variables, blocks and templates each one references, and the handler functions that render it. Pass a function to list the templates it renders. Combine with cie_list_endpoints and cie_trace_path to follow a request from route to page.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"template": map[string]any{
+						"type":        "string",
+						"description": "Optional substring of the template path (e.g., 'users/list', 'base.html'). Up to 3 matches are shown in detail.",
+					},
+					"function": map[string]any{
+						"type":        "string",
+						"description": "Optional substring of a function name; lists the templates it renders (e.g., 'ListUsers')",
+					},
+					"limit": map[string]any{
+						"type":        "integer",
+						"description": "Maximum templates to list (default: 20)",
+						"default":     20,
+					},
+				},
+				"required": []string{},
+			},
+		},
+		{
+			Name:        "cie_tables",
+			Description: "Map functions to the database tables they use. Tables are found at index time in raw SQL strings (including sqlx/database/sql queries) and ORM calls: gorm Model/Table chains, SQLAlchemy query/select/insert/update/delete and Prisma client calls. Without arguments, lists tables with their reader and writer counts; with table, lists the functions that read, insert, update, delete or alter it (useful for migration impact analysis); with function, lists the tables it touches.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"table": map[string]any{
+						"type":        "string",
+						"description": "Optional substring of a table or ORM model name (e.g., 'users', 'OrderItem')",
+					},
+					"function": map[string]any{
+						"type":        "string",
+						"description": "Optional substring of a function name; lists the tables it uses (e.g., 'CreateOrder')",
+					},
+					"path_pattern": map[string]any{
+						"type":        "string",
+						"description": "Optional regex on the file path (e.g., 'internal/store')",
+					},
+					"limit": map[string]any{
+						"type":        "integer",
+						"description": "Maximum tables to list (default: 20)",
+						"default":     20,
+					},
+				},
+				"required": []string{},
+			},
+		},
+		{
+			Name:        "cie_add_note",
+			Description: "Attach a persistent note to a function or file, e.g. \"this is the legacy path, don't extend\" or \"must stay backwards compatible with v1 clients\". Notes are kept when the project is re-indexed and are shown with the function or file in later cie_semantic_search and cie_find_function results.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"target": map[string]any{
+						"type":        "string",
+						"description": "Function name (e.g., 'HandleLogin', 'Store.Save') or file path (e.g., 'internal/auth/legacy.go')",
+					},
+					"text": map[string]any{
+						"type":        "string",
+						"description": "The note",
+					},
+					"file_path": map[string]any{
+						"type":        "string",
+						"description": "Optional file of the function, when the name matches functions in several files",
+					},
+					"author": map[string]any{
+						"type":        "string",
+						"description": "Optional author shown with the note (default: 'agent')",
+					},
+				},
+				"required": []string{"target", "text"},
+			},
+		},
+		{
+			Name:        "cie_get_notes",
+			Description: "List notes attached to functions and files with cie_add_note, newest first. Filter by target or by text.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"target": map[string]any{
+						"type":        "string",
+						"description": "Optional function name or file path",
+					},
+					"query": map[string]any{
+						"type":        "string",
+						"description": "Optional text to find in the notes",
+					},
+					"limit": map[string]any{
+						"type":        "integer",
+						"description": "Maximum notes to list (default: 50)",
+						"default":     50,
+					},
+				},
+				"required": []string{},
+			},
+		},
+		{
+			Name:        "cie_collection_add",
+			Description: "Add functions or files to a named collection, creating it on first use. Collections are curated sets such as 'payment-critical-path' that are kept across re-indexing; pass collection to any tool with a path_pattern argument to scope it to the collection's files.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"collection": map[string]any{
+						"type":        "string",
+						"description": "Collection name: letters, digits, '.', '_' or '-' (e.g., 'payment-critical-path')",
+					},
+					"targets": map[string]any{
+						"type":        "array",
+						"items":       map[string]any{"type": "string"},
+						"description": "Function names (e.g., 'ChargeCard', 'Ledger.Post') or file paths",
+					},
+					"file_path": map[string]any{
+						"type":        "string",
+						"description": "Optional file of the functions, when a name matches functions in several files",
+					},
+				},
+				"required": []string{"collection", "targets"},
+			},
+		},
+		{
+			Name:        "cie_collection_remove",
+			Description: "Remove functions or files from a collection. A collection with no members no longer exists.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"collection": map[string]any{
+						"type":        "string",
+						"description": "Collection name: letters, digits, '.', '_' or '-' (e.g., 'payment-critical-path')",
+					},
+					"targets": map[string]any{
+						"type":        "array",
+						"items":       map[string]any{"type": "string"},
+						"description": "Function names (e.g., 'ChargeCard', 'Ledger.Post') or file paths",
+					},
+					"file_path": map[string]any{
+						"type":        "string",
+						"description": "Optional file of the functions, when a name matches functions in several files",
+					},
 				},
-				"required": []string{},
+				"required": []string{"collection", "targets"},
 			},
 		},
 		{
-			Name:        "cie_verify_absence",
-			Description: "Verify that specific patterns do NOT exist in code. Returns PASS/FAIL with detailed violations. Perfect for security audits (no hardcoded secrets, tokens, credentials) and CI/CD checks. Example: verify absence of 'access_token', 'api_key', 'password' in frontend code.",
+			Name:        "cie_collection_list",
+			Description: "List collections with their function and file counts, or the members of one collection.",
 			InputSchema: map[string]any{
 				"type": "object",
 				"properties": map[string]any{
-					"patterns": map[string]any{
-						"type":        "array",
-						"items":       map[string]any{"type": "string"},
-						"description": "Patterns that should NOT exist in code. Example: ['access_token', 'refresh_token', 'api_key', 'secret']",
-					},
-					"path": map[string]any{
+					"collection": map[string]any{
 						"type":        "string",
-						"description": "Optional: limit check to specific path (e.g., 'ui/src', 'frontend/')",
+						"description": "Optional collection whose members to list",
 					},
-					"exclude_pattern": map[string]any{
+				},
+				"required": []string{},
+			},
+		},
+		{
+			Name:        "cie_store_fact",
+			Description: "Store a piece of project knowledge for later sessions: a convention, gotcha or decision that is not obvious from the code (e.g., 'Handlers never call the DB directly; go through service/', 'Integration tests need TEST_DATABASE_URL'). Facts are embedded so cie_recall_facts finds them by meaning, and are kept across re-indexing. Storing the same text again keeps one copy.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"text": map[string]any{
 						"type":        "string",
-						"description": "Optional: regex pattern to EXCLUDE files from check (e.g., '_test\\.go|mock')",
+						"description": "The fact, as one self-contained sentence or short paragraph",
 					},
-					"case_sensitive": map[string]any{
-						"type":        "boolean",
-						"description": "If true, pattern matching is case-sensitive. Default: false",
-						"default":     false,
+					"tags": map[string]any{
+						"type":        "array",
+						"items":       map[string]any{"type": "string"},
+						"description": "Optional tags (e.g., ['conventions'], ['testing', 'gotchas'])",
 					},
-					"severity": map[string]any{
+					"author": map[string]any{
 						"type":        "string",
-						"enum":        []string{"critical", "warning", "info"},
-						"description": "Severity level for violations. Default: 'warning'",
-						"default":     "warning",
+						"description": "Optional author (default: 'agent')",
 					},
 				},
-				"required": []string{"patterns"},
+				"required": []string{"text"},
 			},
 		},
 		{
-			Name:        "cie_list_services",
-			Description: "List gRPC services and RPC methods from .proto files. Shows service definitions, RPC methods, and their request/response types. Useful for understanding API contracts in gRPC-based projects.",
+			Name:        "cie_recall_facts",
+			Description: "Recall project knowledge stored with cie_store_fact. With a query, returns the facts closest in meaning (and keyword matches); without one, lists facts newest first. Call early in a session to pick up conventions and gotchas.",
 			InputSchema: map[string]any{
 				"type": "object",
 				"properties": map[string]any{
-					"path_pattern": map[string]any{
+					"query": map[string]any{
 						"type":        "string",
-						"description": "Optional: filter by file path (e.g., 'api/proto')",
+						"description": "Optional: what you want to know (e.g., 'how are integration tests run')",
 					},
-					"service_name": map[string]any{
+					"tag": map[string]any{
 						"type":        "string",
-						"description": "Optional: filter by service name",
+						"description": "Optional tag to filter by",
+					},
+					"limit": map[string]any{
+						"type":        "integer",
+						"description": "Maximum facts to return (default: 10)",
+						"default":     10,
+					},
+					"min_similarity": map[string]any{
+						"type":        "number",
+						"description": "Minimum similarity (0.0-1.0) for a fact to be recalled by meaning; facts below it need a keyword match (default: 0.7)",
 					},
 				},
 				"required": []string{},
 			},
 		},
 		{
-			Name:        "cie_directory_summary",
-			Description: "Get a summary of a directory showing files with their main exported functions. Perfect for understanding the architecture of a module or package quickly. Shows file list with the most important functions in each.",
+			Name:        "cie_search_feedback",
+			Description: "Report whether a search result was useful. Call it after acting on (or discarding) a result of cie_semantic_search, cie_grep, cie_find_function or another search tool. Ratings are aggregated by query type and used to tune ranking, k and minimum-similarity defaults.",
 			InputSchema: map[string]any{
 				"type": "object",
 				"properties": map[string]any{
-					"path": map[string]any{
+					"query": map[string]any{
 						"type":        "string",
-						"description": "Directory path to summarize (e.g., 'apps/gateway/internal/http', 'internal/cie/ingestion')",
+						"description": "The query the result was returned for, exactly as sent",
 					},
-					"max_functions_per_file": map[string]any{
+					"result": map[string]any{
+						"type":        "string",
+						"description": "The rated result: function name, file path or file:line",
+					},
+					"useful": map[string]any{
+						"type":        "boolean",
+						"description": "true if the result helped with the task, false if it was irrelevant",
+					},
+					"tool": map[string]any{
+						"type":        "string",
+						"description": "Search tool that returned the result (default: cie_semantic_search)",
+						"default":     "cie_semantic_search",
+					},
+					"rank": map[string]any{
 						"type":        "integer",
-						"description": "Maximum number of functions to show per file (default: 5)",
-						"default":     5,
+						"description": "Optional 1-based position of the result in the list",
+					},
+					"similarity": map[string]any{
+						"type":        "number",
+						"description": "Optional similarity shown with the result (0-1, or a percentage such as 82)",
 					},
 				},
-				"required": []string{"path"},
+				"required": []string{"query", "result", "useful"},
 			},
 		},
 		{
-			Name:        "cie_list_endpoints",
-			Description: "List HTTP/REST endpoints defined in the codebase. Detects route definitions from common Go frameworks (Gin, Echo, Chi, Fiber, net/http). Returns a table of [Method] [Path] [Handler] [File]. Perfect for understanding API structure in gateway/server code.",
+			Name:        "cie_ci_jobs",
+			Description: "Show CI jobs from GitHub Actions workflows (.github/workflows) and GitLab CI files (.gitlab-ci.yml): triggers or stage, steps, and the scripts, make targets, actions, env vars and secrets each job uses. Pass a query to find the jobs whose name, steps or references mention it (e.g., 'which workflow runs the integration tests' → query 'integration').",
 			InputSchema: map[string]any{
 				"type": "object",
 				"properties": map[string]any{
-					"path_pattern": map[string]any{
-						"type":        "string",
-						"description": "Optional: filter by file path (e.g., 'apps/gateway', 'internal/http')",
-					},
-					"path_filter": map[string]any{
+					"query": map[string]any{
 						"type":        "string",
-						"description": "Optional: filter by endpoint path substring (e.g., '/health', 'connections', '/api/v1'). Case-insensitive.",
+						"description": "Optional text to find in job names, step commands and references (e.g., 'integration', 'scripts/deploy.sh', 'DATABASE_URL')",
 					},
-					"method": map[string]any{
+					"job": map[string]any{
 						"type":        "string",
-						"enum":        []string{"GET", "POST", "PUT", "DELETE", "PATCH", "ANY", ""},
-						"description": "Optional: filter by HTTP method",
+						"description": "Optional job name; shows its steps and references in detail",
 					},
 					"limit": map[string]any{
 						"type":        "integer",
-						"description": "Maximum results (default: 100)",
-						"default":     100,
+						"description": "Maximum jobs to list (default: 20)",
+						"default":     20,
 					},
 				},
 				"required": []string{},
@@ -1008,7 +2090,7 @@ func (s *mcpServer) getTools() []mcpTool {
 			Description: "Find functions by parameter type or return type. Useful for discovering which functions accept a specific interface or struct as input (e.g., all functions taking a 'Backend' or 'Querier' parameter). Matches base type names regardless of pointer/slice/package prefix.",
 			InputSchema: map[string]any{
 				"type": "object",
-				"properties": map[string]any{
+				"properties": withLanguageFilter(map[string]any{
 					"param_type": map[string]any{
 						"type":        "string",
 						"description": "Base type name to search in parameters (e.g., 'Backend', 'Querier'). Matches regardless of pointer/slice/package prefix.",
@@ -1030,13 +2112,13 @@ func (s *mcpServer) getTools() []mcpTool {
 						"description": "Maximum results (default: 20)",
 						"default":     20,
 					},
-				},
+				}),
 				"required": []string{},
 			},
 		},
 		{
 			Name:        "cie_trace_path",
-			Description: "Trace call paths from source function(s) to a target function. Uses the call graph to find how execution reaches a specific function. Returns the shortest paths with full call chain and file locations. If no source is specified, auto-detects entry points based on language conventions (main for Go/Rust, index/app exports for JS/TS, __main__ for Python). Useful for understanding initialization flows, debugging, security audits, and refactoring impact analysis.",
+			Description: "Trace call paths from source function(s) to a target function. Uses the call graph to find how execution reaches a specific function. Returns the shortest paths with full call chain and file locations. If no source is specified, auto-detects entry points based on language conventions (main for Go/Rust, index/app exports for JS/TS, __main__ for Python) and the entry points found during indexing (cobra commands, HTTP/gRPC server setup, Lambda handlers, cron jobs). Paths cross language boundaries through gRPC: a client call (Go, Python, TypeScript) leads to the .proto RPC and on to its server implementation. Useful for understanding initialization flows, debugging, security audits, and refactoring impact analysis.",
 			InputSchema: map[string]any{
 				"type": "object",
 				"properties": map[string]any{
@@ -1152,6 +2234,7 @@ type toolHandler func(ctx context.Context, s *mcpServer, args map[string]any) (*
 // toolHandlers maps tool names to their handlers.
 var toolHandlers = map[string]toolHandler{
 	"cie_schema":                 handleSchema,
+	"cie_warmup":                 handleWarmup,
 	"cie_search_text":            handleSearchText,
 	"cie_find_function":          handleFindFunction,
 	"cie_find_callers":           handleFindCallers,
@@ -1159,19 +2242,42 @@ var toolHandlers = map[string]toolHandler{
 	"cie_list_files":             handleListFiles,
 	"cie_raw_query":              handleRawQuery,
 	"cie_get_function_code":      handleGetFunctionCode,
+	"cie_get_lines":              handleGetLines,
 	"cie_list_functions_in_file": handleListFunctionsInFile,
+	"cie_enclosing":              handleEnclosing,
 	"cie_get_call_graph":         handleGetCallGraph,
 	"cie_find_similar_functions": handleFindSimilarFunctions,
 	"cie_get_file_summary":       handleGetFileSummary,
 	"cie_semantic_search":        handleSemanticSearch,
+	"cie_similar_to_function":    handleSimilarToFunction,
 	"cie_analyze":                handleAnalyze,
 	"cie_find_type":              handleFindType,
+	"cie_get_type_code":          handleGetTypeCode,
+	"cie_type_api":               handleTypeAPI,
+	"cie_type_graph":             handleTypeGraph,
 	"cie_index_status":           handleIndexStatus,
+	"cie_index_health":           handleIndexHealth,
+	"cie_resolution_report":      handleResolutionReport,
 	"cie_grep":                   handleGrep,
+	"cie_structural_search":      handleStructuralSearch,
 	"cie_verify_absence":         handleVerifyAbsence,
 	"cie_list_services":          handleListServices,
 	"cie_directory_summary":      handleDirectorySummary,
+	"cie_tree":                   handleTree,
+	"cie_package_summary":        handlePackageSummary,
+	"cie_external_api":           handleExternalAPI,
 	"cie_list_endpoints":         handleListEndpoints,
+	"cie_templates":              handleTemplates,
+	"cie_tables":                 handleTables,
+	"cie_ci_jobs":                handleCIJobs,
+	"cie_add_note":               handleAddNote,
+	"cie_get_notes":              handleGetNotes,
+	"cie_collection_add":         handleCollectionAdd,
+	"cie_collection_remove":      handleCollectionRemove,
+	"cie_collection_list":        handleCollectionList,
+	"cie_store_fact":             handleStoreFact,
+	"cie_recall_facts":           handleRecallFacts,
+	"cie_search_feedback":        handleSearchFeedback,
 	"cie_find_implementations":   handleFindImplementations,
 	"cie_find_by_signature":      handleFindBySignature,
 	"cie_trace_path":             handleTracePath,
@@ -1180,28 +2286,115 @@ var toolHandlers = map[string]toolHandler{
 	"cie_blame_function":         handleBlameFunction,
 }
 
+// literalPathPatternTools take path_pattern as a plain path (a git pathspec
+// or a substring), not a regex.
+var literalPathPatternTools = map[string]bool{
+	"cie_function_history":  true,
+	"cie_find_introduction": true,
+	"cie_blame_function":    true,
+}
+
+// normalizePathArgs rewrites path arguments in place so Windows-style paths
+// and differently cased patterns match the forward-slash paths in the index.
+func normalizePathArgs(tool string, args map[string]any) {
+	for _, key := range []string{"path", "file_path"} {
+		if v, ok := args[key].(string); ok {
+			args[key] = tools.NormalizePath(v)
+		}
+	}
+	for _, key := range []string{"path_pattern", "file_pattern", "exclude_paths"} {
+		v, ok := args[key].(string)
+		if !ok {
+			continue
+		}
+		if key == "path_pattern" && literalPathPatternTools[tool] {
+			args[key] = tools.NormalizePath(v)
+		} else {
+			args[key] = tools.NormalizePathPattern(v)
+		}
+	}
+}
+
 func (s *mcpServer) handleToolCall(ctx context.Context, params mcpToolCallParams) (*mcpToolResult, error) {
+	target, err := s.projectServer(params.Arguments)
+	if err != nil {
+		return toolErrorResult(errcode.InvalidInput, fmt.Sprintf("⚠️ %v", err)), nil
+	}
+	if target != s {
+		return target.handleToolCall(ctx, params)
+	}
 	handler, ok := toolHandlers[params.Name]
 	if !ok {
-		return &mcpToolResult{
-			Content: []mcpContent{{Type: "text", Text: fmt.Sprintf("Unknown tool: %s", params.Name)}},
-			IsError: true,
-		}, nil
+		handler, ok = s.savedQueryHandler(params.Name)
+	}
+	if !ok {
+		return toolErrorResult(errcode.InvalidInput, fmt.Sprintf("Unknown tool: %s", params.Name)), nil
+	}
+	normalizePathArgs(params.Name, params.Arguments)
+	if err := s.applyCollection(ctx, params.Name, params.Arguments); err != nil {
+		return toolErrorResult(errcode.InvalidInput, fmt.Sprintf("⚠️ %v", err)), nil
+	}
+
+	// Cache hits skip the rate limiter: they cost no provider calls.
+	cacheKey, indexVersion := s.cache.cacheKey(params.Name, params.Arguments), ""
+	if cacheKey != "" {
+		indexVersion = tools.IndexVersion(ctx, s.client)
+		if cached, ok := s.cache.get(cacheKey, indexVersion); ok {
+			s.recordAudit(params.Name, params.Arguments, time.Now(), 0, false)
+			return s.withFreshness(ctx, cached), nil
+		}
+	}
+
+	release, err := s.limiter.acquire(params.Name)
+	if err != nil {
+		s.recordAudit(params.Name, params.Arguments, time.Now(), 0, true)
+		return toolErrorResult(errcode.RateLimited, fmt.Sprintf("⚠️ %v", err)), nil
+	}
+	defer release()
+
+	start := time.Now()
+	scoped := s
+	if name, _ := params.Arguments["profile"].(string); name != "" {
+		if scoped, err = s.withProfile(name); err != nil {
+			return toolErrorResult(errcode.InvalidInput, fmt.Sprintf("⚠️ %v", err)), nil
+		}
+	}
+	var counter *countingQuerier
+	if s.audit != nil {
+		counter = &countingQuerier{Querier: s.client}
+		copied := *scoped
+		copied.client = counter
+		scoped = &copied
 	}
 
-	result, err := handler(ctx, s, params.Arguments)
+	result, err := handler(ctx, scoped, params.Arguments)
+	if counter != nil {
+		s.recordAudit(params.Name, params.Arguments, start, counter.rows.Load(), err != nil || (result != nil && result.IsError))
+	}
 	if err != nil {
 		return s.formatError(params.Name, err), nil
 	}
 
-	return &mcpToolResult{
+	toolResult := &mcpToolResult{
 		Content: []mcpContent{{Type: "text", Text: result.Text}},
 		IsError: result.IsError,
-	}, nil
+	}
+	if result.IsError {
+		code := result.Code
+		if code == "" {
+			code = errcode.ToolFailed
+		}
+		toolResult.Meta = map[string]any{errorCodeMetaKey: code}
+	}
+	if cacheKey != "" && !result.IsError {
+		s.cache.put(cacheKey, indexVersion, toolResult)
+	}
+	return s.withFreshness(ctx, toolResult), nil
 }
 
-func handleSchema(ctx context.Context, _ *mcpServer, _ map[string]any) (*tools.ToolResult, error) {
-	return tools.GetSchema(ctx)
+func handleSchema(ctx context.Context, _ *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	relation, _ := args["relation"].(string)
+	return tools.GetSchema(ctx, tools.GetSchemaArgs{Relation: relation})
 }
 
 func handleSearchText(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
@@ -1211,6 +2404,9 @@ func handleSearchText(ctx context.Context, s *mcpServer, args map[string]any) (*
 	filePattern, _ := args["file_pattern"].(string)
 	excludePattern, _ := args["exclude_pattern"].(string)
 	limit, _ := getIntArg(args, "limit", 20)
+	groupBy, _ := args["group_by"].(string)
+	language, _ := args["language"].(string)
+	dialect, _ := args["dialect"].(string)
 
 	return tools.SearchText(ctx, s.client, tools.SearchTextArgs{
 		Pattern:        pattern,
@@ -1219,6 +2415,10 @@ func handleSearchText(ctx context.Context, s *mcpServer, args map[string]any) (*
 		SearchIn:       searchIn,
 		Literal:        literal,
 		Limit:          limit,
+		GroupBy:        groupBy,
+		Language:       language,
+		Dialect:        dialect,
+		Live:           s.liveSource(),
 	})
 }
 
@@ -1226,10 +2426,17 @@ func handleFindFunction(ctx context.Context, s *mcpServer, args map[string]any)
 	name, _ := args["name"].(string)
 	exactMatch, _ := args["exact_match"].(bool)
 	includeCode, _ := args["include_code"].(bool)
+	fuzzy, _ := args["fuzzy"].(bool)
+	language, _ := args["language"].(string)
+	dialect, _ := args["dialect"].(string)
 	return tools.FindFunction(ctx, s.client, tools.FindFunctionArgs{
 		Name:        name,
 		ExactMatch:  exactMatch,
 		IncludeCode: includeCode,
+		Fuzzy:       fuzzy,
+		Language:    language,
+		Dialect:     dialect,
+		Live:        s.liveSource(),
 	})
 }
 
@@ -1256,17 +2463,24 @@ func handleListFiles(ctx context.Context, s *mcpServer, args map[string]any) (*t
 	if l, ok := args["limit"].(float64); ok {
 		limit = int(l)
 	}
+	role, _ := args["role"].(string)
 	return tools.ListFiles(ctx, s.client, tools.ListFilesArgs{
 		PathPattern: pathPattern,
 		Language:    language,
+		Role:        role,
+		CustomRoles: rolePatterns(s.customRoles),
 		Limit:       limit,
 	})
 }
 
 func handleRawQuery(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
 	script, _ := args["script"].(string)
+	params, _ := args["params"].(map[string]any)
+	policy := s.rawQuery
 	return tools.RawQuery(ctx, s.client, tools.RawQueryArgs{
 		Script: script,
+		Params: params,
+		Policy: &policy,
 	})
 }
 
@@ -1276,13 +2490,51 @@ func handleGetFunctionCode(ctx context.Context, s *mcpServer, args map[string]an
 	return tools.GetFunctionCode(ctx, s.client, tools.GetFunctionCodeArgs{
 		FunctionName: funcName,
 		FullCode:     fullCode,
+		Live:         s.liveSource(),
+	})
+}
+
+func handleGetLines(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	path, _ := args["path"].(string)
+	startLine, _ := getIntArg(args, "start_line", 0)
+	endLine, _ := getIntArg(args, "end_line", 0)
+	linesArgs := tools.GetLinesArgs{
+		Path:      path,
+		StartLine: startLine,
+		EndLine:   endLine,
+		Hash: func(content []byte) string {
+			return ingestion.ContentHash(content, s.contentHash)
+		},
+	}
+	if s.gitExecutor != nil {
+		linesArgs.Root = s.gitExecutor.RepoPath()
+	}
+	return tools.GetLines(ctx, s.client, linesArgs)
+}
+
+func handleEnclosing(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	location, _ := args["location"].(string)
+	filePath, _ := args["file_path"].(string)
+	line, _ := getIntArg(args, "line", 0)
+	includeCode := true
+	if v, ok := args["include_code"].(bool); ok {
+		includeCode = v
+	}
+	return tools.Enclosing(ctx, s.client, tools.EnclosingArgs{
+		Location:    location,
+		FilePath:    filePath,
+		Line:        line,
+		IncludeCode: includeCode,
 	})
 }
 
 func handleListFunctionsInFile(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
 	filePath, _ := args["file_path"].(string)
+	outline, _ := args["outline"].(bool)
 	return tools.ListFunctionsInFile(ctx, s.client, tools.ListFunctionsInFileArgs{
 		FilePath: filePath,
+		Outline:  outline,
+		Live:     s.liveSource(),
 	})
 }
 
@@ -1300,10 +2552,29 @@ func handleFindSimilarFunctions(ctx context.Context, s *mcpServer, args map[stri
 	})
 }
 
+func handleSimilarToFunction(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	functionID, _ := args["function_id"].(string)
+	functionName, _ := args["function_name"].(string)
+	limit, _ := getIntArg(args, "limit", 10)
+	role, _ := args["role"].(string)
+	pathPattern, _ := args["path_pattern"].(string)
+	minSimilarity, _ := getFloatArg(args, "min_similarity", 0)
+	return tools.SimilarToFunction(ctx, s.client, tools.SimilarToFunctionArgs{
+		FunctionID:    functionID,
+		FunctionName:  functionName,
+		Limit:         limit,
+		Role:          role,
+		PathPattern:   pathPattern,
+		MinSimilarity: minSimilarity,
+	})
+}
+
 func handleGetFileSummary(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
 	filePath, _ := args["file_path"].(string)
+	outline, _ := args["outline"].(bool)
 	return tools.GetFileSummary(ctx, s.client, tools.GetFileSummaryArgs{
 		FilePath: filePath,
+		Outline:  outline,
 	})
 }
 
@@ -1318,6 +2589,13 @@ func handleSemanticSearch(ctx context.Context, s *mcpServer, args map[string]any
 		excludeAnonymous = v
 	}
 	minSimilarity, _ := getFloatArg(args, "min_similarity", 0)
+	nameWeight, _ := getFloatArg(args, "name_weight", tools.DefaultNameWeight)
+	language, _ := args["language"].(string)
+	dialect, _ := args["dialect"].(string)
+	if s.embeddingDimensions != 0 && s.indexDimensions != 0 && s.embeddingDimensions != s.indexDimensions {
+		return tools.NewInputError(fmt.Sprintf("Error: this embedding setup produces %d-dimensional vectors but the index holds %d-dimensional ones; "+
+			"re-index with it (`cie index --profile NAME --full`) or pick a profile with matching dimensions", s.embeddingDimensions, s.indexDimensions)), nil
+	}
 
 	return tools.SemanticSearch(ctx, s.client, tools.SemanticSearchArgs{
 		Query:            query,
@@ -1327,8 +2605,15 @@ func handleSemanticSearch(ctx context.Context, s *mcpServer, args map[string]any
 		ExcludePaths:     excludePaths,
 		ExcludeAnonymous: excludeAnonymous,
 		MinSimilarity:    minSimilarity,
+		NameWeight:       nameWeight,
 		EmbeddingURL:     s.embeddingURL,
 		EmbeddingModel:   s.embeddingModel,
+		Prefixes:         s.embeddingPrefixes,
+		Ranking:          s.ranking,
+		Git:              s.gitExecutor,
+		CustomRoles:      rolePatterns(s.customRoles),
+		Language:         language,
+		Dialect:          dialect,
 	})
 }
 
@@ -1340,6 +2625,7 @@ func handleAnalyze(ctx context.Context, s *mcpServer, args map[string]any) (*too
 		Question:    question,
 		PathPattern: pathPattern,
 		Role:        role,
+		CustomRoles: rolePatterns(s.customRoles),
 	})
 }
 
@@ -1348,11 +2634,46 @@ func handleFindType(ctx context.Context, s *mcpServer, args map[string]any) (*to
 	kind, _ := args["kind"].(string)
 	pathPattern, _ := args["path_pattern"].(string)
 	limit, _ := getIntArg(args, "limit", 20)
+	fuzzy, _ := args["fuzzy"].(bool)
 	return tools.FindType(ctx, s.client, tools.FindTypeArgs{
 		Name:        name,
 		Kind:        kind,
 		PathPattern: pathPattern,
 		Limit:       limit,
+		Fuzzy:       fuzzy,
+	})
+}
+
+func handleGetTypeCode(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	typeName, _ := args["type_name"].(string)
+	filePath, _ := args["file_path"].(string)
+	includeMethods, _ := args["include_methods"].(bool)
+	outline, _ := args["outline"].(bool)
+	return tools.GetTypeCode(ctx, s.client, tools.GetTypeCodeArgs{
+		Name:           typeName,
+		FilePath:       filePath,
+		IncludeMethods: includeMethods,
+		Outline:        outline,
+	})
+}
+
+func handleTypeAPI(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	typeName, _ := args["type_name"].(string)
+	filePath, _ := args["file_path"].(string)
+	return tools.TypeAPI(ctx, s.client, tools.TypeAPIArgs{
+		TypeName: typeName,
+		FilePath: filePath,
+	})
+}
+
+func handleTypeGraph(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	typeName, _ := args["type_name"].(string)
+	direction, _ := args["direction"].(string)
+	depth, _ := getIntArg(args, "depth", 2)
+	return tools.TypeGraph(ctx, s.client, tools.TypeGraphArgs{
+		TypeName:  typeName,
+		Direction: direction,
+		Depth:     depth,
 	})
 }
 
@@ -1361,6 +2682,21 @@ func handleIndexStatus(ctx context.Context, s *mcpServer, args map[string]any) (
 	return tools.IndexStatus(ctx, s.client, pathPattern, s.projectID, s.mode)
 }
 
+func handleIndexHealth(ctx context.Context, s *mcpServer, _ map[string]any) (*tools.ToolResult, error) {
+	return tools.IndexHealth(ctx, s.client, tools.IndexHealthArgs{Freshness: s.indexFreshness(ctx)})
+}
+
+func handleResolutionReport(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	pathPattern, _ := args["path_pattern"].(string)
+	reason, _ := args["reason"].(string)
+	limit, _ := getIntArg(args, "limit", 20)
+	return tools.ResolutionReport(ctx, s.client, tools.ResolutionReportArgs{
+		PathPattern: pathPattern,
+		Reason:      reason,
+		Limit:       limit,
+	})
+}
+
 func handleGrep(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
 	text, _ := args["text"].(string)
 	path, _ := args["path"].(string)
@@ -1368,6 +2704,10 @@ func handleGrep(ctx context.Context, s *mcpServer, args map[string]any) (*tools.
 	caseSensitive, _ := args["case_sensitive"].(bool)
 	contextLines, _ := getIntArg(args, "context", 0)
 	limit, _ := getIntArg(args, "limit", 30)
+	scope, _ := args["scope"].(string)
+	groupBy, _ := args["group_by"].(string)
+	language, _ := args["language"].(string)
+	dialect, _ := args["dialect"].(string)
 
 	texts := extractStringArray(args, "texts")
 
@@ -1379,6 +2719,26 @@ func handleGrep(ctx context.Context, s *mcpServer, args map[string]any) (*tools.
 		CaseSensitive:  caseSensitive,
 		ContextLines:   contextLines,
 		Limit:          limit,
+		Scope:          scope,
+		GroupBy:        groupBy,
+		Language:       language,
+		Dialect:        dialect,
+		Live:           s.liveSource(),
+	})
+}
+
+func handleStructuralSearch(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	pattern, _ := args["pattern"].(string)
+	path, _ := args["path"].(string)
+	excludePattern, _ := args["exclude_pattern"].(string)
+	scope, _ := args["scope"].(string)
+	limit, _ := getIntArg(args, "limit", 20)
+	return tools.StructuralSearch(ctx, s.client, tools.StructuralSearchArgs{
+		Pattern:        pattern,
+		Path:           path,
+		ExcludePattern: excludePattern,
+		Scope:          scope,
+		Limit:          limit,
 	})
 }
 
@@ -1411,6 +2771,154 @@ func handleDirectorySummary(ctx context.Context, s *mcpServer, args map[string]a
 	return tools.DirectorySummary(ctx, s.client, path, maxFuncs)
 }
 
+func handleTree(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	path, _ := args["path"].(string)
+	depth, _ := getIntArg(args, "depth", 3)
+	return tools.Tree(ctx, s.client, tools.TreeArgs{Path: path, Depth: depth})
+}
+
+func handleExternalAPI(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	pathPattern, _ := args["path_pattern"].(string)
+	pkg, _ := args["package"].(string)
+	limit, _ := getIntArg(args, "limit", 30)
+	return tools.ExternalAPI(ctx, s.client, tools.ExternalAPIArgs{
+		PathPattern: pathPattern,
+		Package:     pkg,
+		Limit:       limit,
+	})
+}
+
+func handlePackageSummary(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	path, _ := args["path"].(string)
+	narrative, _ := args["narrative"].(bool)
+	return tools.PackageSummary(ctx, s.client, tools.PackageSummaryArgs{
+		Path:      path,
+		Narrative: narrative,
+		LLM:       s.llm,
+		MaxTokens: s.llmMaxTokens,
+	})
+}
+
+func handleTemplates(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	template, _ := args["template"].(string)
+	function, _ := args["function"].(string)
+	limit, _ := getIntArg(args, "limit", 20)
+	return tools.Templates(ctx, s.client, tools.TemplatesArgs{
+		Template: template,
+		Function: function,
+		Limit:    limit,
+	})
+}
+
+func handleTables(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	table, _ := args["table"].(string)
+	function, _ := args["function"].(string)
+	pathPattern, _ := args["path_pattern"].(string)
+	limit, _ := getIntArg(args, "limit", 20)
+	return tools.Tables(ctx, s.client, tools.TablesArgs{
+		Table:       table,
+		Function:    function,
+		PathPattern: pathPattern,
+		Limit:       limit,
+	})
+}
+
+func handleCIJobs(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	query, _ := args["query"].(string)
+	job, _ := args["job"].(string)
+	limit, _ := getIntArg(args, "limit", 20)
+	return tools.CIJobs(ctx, s.client, tools.CIJobsArgs{
+		Query: query,
+		Job:   job,
+		Limit: limit,
+	})
+}
+
+func handleAddNote(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	target, _ := args["target"].(string)
+	text, _ := args["text"].(string)
+	filePath, _ := args["file_path"].(string)
+	author, _ := args["author"].(string)
+	result, err := tools.AddNote(ctx, s.client, tools.AddNoteArgs{
+		Target:   target,
+		FilePath: filePath,
+		Text:     text,
+		Author:   author,
+	})
+	if err == nil && !result.IsError {
+		// Cached search results would hide the new note.
+		s.cache.reset()
+	}
+	return result, err
+}
+
+func handleGetNotes(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	target, _ := args["target"].(string)
+	query, _ := args["query"].(string)
+	limit, _ := getIntArg(args, "limit", 50)
+	return tools.GetNotes(ctx, s.client, tools.GetNotesArgs{
+		Target: target,
+		Query:  query,
+		Limit:  limit,
+	})
+}
+
+func handleCollectionAdd(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	return tools.AddToCollection(ctx, s.client, collectionArgs(args))
+}
+
+func handleCollectionRemove(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	return tools.RemoveFromCollection(ctx, s.client, collectionArgs(args))
+}
+
+// collectionArgs reads the arguments shared by cie_collection_add and
+// cie_collection_remove.
+func collectionArgs(args map[string]any) tools.CollectionArgs {
+	collection, _ := args["collection"].(string)
+	filePath, _ := args["file_path"].(string)
+	targets := extractStringArray(args, "targets")
+	if single, ok := args["targets"].(string); ok {
+		targets = []string{single}
+	}
+	return tools.CollectionArgs{
+		Collection: collection,
+		Targets:    targets,
+		FilePath:   filePath,
+	}
+}
+
+func handleCollectionList(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	collection, _ := args["collection"].(string)
+	return tools.ListCollections(ctx, s.client, tools.ListCollectionsArgs{Collection: collection})
+}
+
+func handleStoreFact(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	text, _ := args["text"].(string)
+	author, _ := args["author"].(string)
+	return tools.StoreFact(ctx, s.client, tools.StoreFactArgs{
+		Text:           text,
+		Tags:           extractStringArray(args, "tags"),
+		Author:         author,
+		EmbeddingURL:   s.embeddingURL,
+		EmbeddingModel: s.embeddingModel,
+	})
+}
+
+func handleRecallFacts(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	query, _ := args["query"].(string)
+	tag, _ := args["tag"].(string)
+	limit, _ := getIntArg(args, "limit", 10)
+	minSimilarity, _ := getFloatArg(args, "min_similarity", 0)
+	return tools.RecallFacts(ctx, s.client, tools.RecallFactsArgs{
+		Query:          query,
+		Tag:            tag,
+		Limit:          limit,
+		MinSimilarity:  minSimilarity,
+		EmbeddingURL:   s.embeddingURL,
+		EmbeddingModel: s.embeddingModel,
+	})
+}
+
 func handleListEndpoints(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
 	pathPattern, _ := args["path_pattern"].(string)
 	pathFilter, _ := args["path_filter"].(string)
@@ -1441,11 +2949,15 @@ func handleFindBySignature(ctx context.Context, s *mcpServer, args map[string]an
 	pathPattern, _ := args["path_pattern"].(string)
 	excludePattern, _ := args["exclude_pattern"].(string)
 	limit, _ := getIntArg(args, "limit", 20)
+	language, _ := args["language"].(string)
+	dialect, _ := args["dialect"].(string)
 	return tools.FindBySignature(ctx, s.client, tools.FindBySignatureArgs{
 		ParamType:      paramType,
 		ReturnType:     returnType,
 		PathPattern:    pathPattern,
 		ExcludePattern: excludePattern,
+		Language:       language,
+		Dialect:        dialect,
 		Limit:          limit,
 	})
 }
@@ -1636,10 +3148,11 @@ func (s *mcpServer) formatError(toolName string, err error) *mcpToolResult {
 		msg += "\n_These tools work directly on the filesystem without requiring CIE._\n"
 	}
 
-	return &mcpToolResult{
-		Content: []mcpContent{{Type: "text", Text: msg}},
-		IsError: true,
+	code := errcode.Of(err)
+	if code == "" {
+		code = errcode.Internal
 	}
+	return toolErrorResult(code, msg)
 }
 
 func (s *mcpServer) handleRequest(ctx context.Context, req jsonRPCRequest) jsonRPCResponse {
@@ -1651,7 +3164,8 @@ func (s *mcpServer) handleRequest(ctx context.Context, req jsonRPCRequest) jsonR
 			Result: mcpInitializeResult{
 				ProtocolVersion: "2024-11-05",
 				Capabilities: mcpCapabilities{
-					Tools: map[string]any{"listChanged": true},
+					Tools:   map[string]any{"listChanged": true},
+					Logging: &struct{}{},
 				},
 				ServerInfo: mcpServerInfo{
 					Name:    mcpServerName,
@@ -1664,12 +3178,29 @@ func (s *mcpServer) handleRequest(ctx context.Context, req jsonRPCRequest) jsonR
 	case "notifications/initialized":
 		return jsonRPCResponse{}
 
+	case "logging/setLevel":
+		var params struct {
+			Level string `json:"level"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil || s.logLevel == nil || !s.logLevel.set(params.Level) {
+			return jsonRPCResponse{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Error: &rpcError{
+					Code:    -32602,
+					Message: "Invalid params",
+					Data:    "level must be one of: " + strings.Join(mcpLogLevels, ", "),
+				},
+			}
+		}
+		return jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: map[string]any{}}
+
 	case "tools/list":
 		return jsonRPCResponse{
 			JSONRPC: "2.0",
 			ID:      req.ID,
 			Result: mcpToolsListResult{
-				Tools: s.getTools(),
+				Tools: s.withProjectArgument(withCollectionArgument(append(s.getTools(), savedQueryTools(s.savedQueries)...))),
 			},
 		}
 
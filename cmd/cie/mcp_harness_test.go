@@ -0,0 +1,266 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// mcpHarnessTimeout bounds how long the harness waits for a single response,
+// so a server that never answers fails the test instead of hanging it.
+const mcpHarnessTimeout = 5 * time.Second
+
+// mcpTestClient drives an in-process mcpServer over a pair of in-memory pipes
+// using the same newline-delimited JSON-RPC framing as the stdio transport.
+//
+// Usage:
+//
+//	c := newMCPTestClient(t, &mcpServer{client: q})
+//	c.Initialize()
+//	res := c.CallTool("cie_list_files", map[string]any{"limit": 5})
+type mcpTestClient struct {
+	t      *testing.T
+	in     *io.PipeWriter
+	out    *bufio.Scanner
+	nextID int
+	mu     sync.Mutex
+}
+
+// mcpTestResponse is a JSON-RPC response with the result left undecoded so
+// typed helpers can unmarshal it into the expected shape.
+type mcpTestResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      any             `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *rpcError       `json:"error"`
+}
+
+// newMCPTestClient starts serveMCP for server on a background goroutine and
+// returns a client connected to it. The server is shut down when the test ends.
+func newMCPTestClient(t *testing.T, server *mcpServer) *mcpTestClient {
+	t.Helper()
+	if server.warmup == nil {
+		server.warmup = &warmupState{}
+	}
+
+	reqR, reqW := io.Pipe()
+	respR, respW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := serveMCP(server, reqR, respW)
+		_ = respW.Close()
+		done <- err
+	}()
+
+	scanner := bufio.NewScanner(respR)
+	scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024)
+
+	t.Cleanup(func() {
+		_ = reqW.Close()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("serveMCP returned error: %v", err)
+			}
+		case <-time.After(mcpHarnessTimeout):
+			t.Error("serveMCP did not stop after input was closed")
+		}
+		_ = respR.Close()
+	})
+
+	return &mcpTestClient{t: t, in: reqW, out: scanner}
+}
+
+// Send writes a raw line to the server without waiting for a reply. It is
+// used for notifications and for feeding malformed input.
+func (c *mcpTestClient) Send(line string) {
+	c.t.Helper()
+	if _, err := io.WriteString(c.in, line+"\n"); err != nil {
+		c.t.Fatalf("write to MCP server: %v", err)
+	}
+}
+
+// Call sends a request for method and returns the decoded response envelope.
+func (c *mcpTestClient) Call(method string, params any) mcpTestResponse {
+	c.t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextID++
+	req := map[string]any{"jsonrpc": "2.0", "id": c.nextID, "method": method}
+	if params != nil {
+		req["params"] = params
+	}
+	line, err := json.Marshal(req)
+	if err != nil {
+		c.t.Fatalf("marshal %s request: %v", method, err)
+	}
+	c.Send(string(line))
+
+	resp := c.read(method)
+	if id, ok := resp.ID.(float64); !ok || int(id) != c.nextID {
+		c.t.Fatalf("%s: response id = %v, want %d", method, resp.ID, c.nextID)
+	}
+	return resp
+}
+
+// read waits for the next response line, failing the test on timeout.
+func (c *mcpTestClient) read(method string) mcpTestResponse {
+	c.t.Helper()
+	lines := make(chan bool, 1)
+	go func() { lines <- c.out.Scan() }()
+
+	select {
+	case ok := <-lines:
+		if !ok {
+			c.t.Fatalf("%s: server closed output: %v", method, c.out.Err())
+		}
+	case <-time.After(mcpHarnessTimeout):
+		c.t.Fatalf("%s: no response within %s", method, mcpHarnessTimeout)
+	}
+
+	var resp mcpTestResponse
+	if err := json.Unmarshal(c.out.Bytes(), &resp); err != nil {
+		c.t.Fatalf("%s: decode response %q: %v", method, c.out.Text(), err)
+	}
+	if resp.JSONRPC != "2.0" {
+		c.t.Fatalf("%s: jsonrpc = %q, want 2.0", method, resp.JSONRPC)
+	}
+	return resp
+}
+
+// mcpTestNotification is a server-initiated message with undecoded params.
+type mcpTestNotification struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// Notification waits for the next line and decodes it as a notification.
+func (c *mcpTestClient) Notification() mcpTestNotification {
+	c.t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.read("notification")
+	var n mcpTestNotification
+	if err := json.Unmarshal(c.out.Bytes(), &n); err != nil {
+		c.t.Fatalf("decode notification %q: %v", c.out.Text(), err)
+	}
+	if n.Method == "" {
+		c.t.Fatalf("expected a notification, got %q", c.out.Text())
+	}
+	return n
+}
+
+// Initialize performs the MCP handshake and returns the server's answer.
+func (c *mcpTestClient) Initialize() mcpInitializeResult {
+	c.t.Helper()
+	var result mcpInitializeResult
+	c.decode("initialize", c.Call("initialize", map[string]any{}), &result)
+	c.Send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	return result
+}
+
+// ListTools returns the tools advertised by tools/list.
+func (c *mcpTestClient) ListTools() []mcpTool {
+	c.t.Helper()
+	var result mcpToolsListResult
+	c.decode("tools/list", c.Call("tools/list", nil), &result)
+	return result.Tools
+}
+
+// CallTool invokes a tool and returns its result. Protocol-level errors fail
+// the test; tool errors are reported through mcpToolResult.IsError.
+func (c *mcpTestClient) CallTool(name string, args map[string]any) *mcpToolResult {
+	c.t.Helper()
+	var result mcpToolResult
+	c.decode("tools/call "+name, c.Call("tools/call", mcpToolCallParams{Name: name, Arguments: args}), &result)
+	return &result
+}
+
+// toolText joins the text content blocks of a tool result.
+func toolText(r *mcpToolResult) string {
+	var text string
+	for _, block := range r.Content {
+		text += block.Text
+	}
+	return text
+}
+
+func (c *mcpTestClient) decode(method string, resp mcpTestResponse, v any) {
+	c.t.Helper()
+	if resp.Error != nil {
+		c.t.Fatalf("%s: rpc error %d: %s (%v)", method, resp.Error.Code, resp.Error.Message, resp.Error.Data)
+	}
+	if err := json.Unmarshal(resp.Result, v); err != nil {
+		c.t.Fatalf("%s: decode result: %v", method, err)
+	}
+}
+
+// recordingQuerier returns canned rows and remembers every script it was
+// asked to run, so tests can assert on the queries a tool generated.
+type recordingQuerier struct {
+	mu      sync.Mutex
+	headers []string
+	rows    [][]any
+	err     error
+	scripts []string
+	params  []map[string]any
+}
+
+func (q *recordingQuerier) Query(_ context.Context, script string) (*tools.QueryResult, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.scripts = append(q.scripts, script)
+	if q.err != nil {
+		return nil, q.err
+	}
+	return &tools.QueryResult{Headers: q.headers, Rows: q.rows}, nil
+}
+
+// QueryWithParams records params and answers like Query.
+func (q *recordingQuerier) QueryWithParams(ctx context.Context, script string, params map[string]any) (*tools.QueryResult, error) {
+	q.mu.Lock()
+	q.params = append(q.params, params)
+	q.mu.Unlock()
+	return q.Query(ctx, script)
+}
+
+func (q *recordingQuerier) QueryRaw(ctx context.Context, script string) (map[string]any, error) {
+	result, err := q.Query(ctx, script)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"Headers": result.Headers, "Rows": result.Rows}, nil
+}
+
+func (q *recordingQuerier) Scripts() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]string(nil), q.scripts...)
+}
@@ -0,0 +1,366 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/errcode"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMCPServer_Initialize(t *testing.T) {
+	c := newMCPTestClient(t, &mcpServer{client: &recordingQuerier{}})
+
+	result := c.Initialize()
+	if result.ProtocolVersion != "2024-11-05" {
+		t.Errorf("protocolVersion = %q", result.ProtocolVersion)
+	}
+	if result.ServerInfo.Name != mcpServerName || result.ServerInfo.Version != mcpVersion {
+		t.Errorf("serverInfo = %+v", result.ServerInfo)
+	}
+	if result.Instructions == "" {
+		t.Error("instructions should not be empty")
+	}
+}
+
+func TestMCPServer_ToolSchemas(t *testing.T) {
+	c := newMCPTestClient(t, &mcpServer{client: &recordingQuerier{}})
+	c.Initialize()
+
+	listed := c.ListTools()
+	if len(listed) == 0 {
+		t.Fatal("tools/list returned no tools")
+	}
+
+	seen := make(map[string]bool, len(listed))
+	for _, tool := range listed {
+		if seen[tool.Name] {
+			t.Errorf("%s: listed twice", tool.Name)
+		}
+		seen[tool.Name] = true
+
+		if _, ok := toolHandlers[tool.Name]; !ok {
+			t.Errorf("%s: advertised without a handler", tool.Name)
+		}
+		if tool.Description == "" {
+			t.Errorf("%s: missing description", tool.Name)
+		}
+		if tool.InputSchema["type"] != "object" {
+			t.Errorf("%s: inputSchema type = %v, want object", tool.Name, tool.InputSchema["type"])
+		}
+		props, ok := tool.InputSchema["properties"].(map[string]any)
+		if !ok {
+			t.Errorf("%s: inputSchema has no properties object", tool.Name)
+			continue
+		}
+		required, _ := tool.InputSchema["required"].([]any)
+		for _, r := range required {
+			if _, ok := props[fmt.Sprint(r)]; !ok {
+				t.Errorf("%s: required field %v is not a property", tool.Name, r)
+			}
+		}
+		for name, prop := range props {
+			if p, ok := prop.(map[string]any); !ok || p["type"] == nil {
+				t.Errorf("%s: property %s has no type", tool.Name, name)
+			}
+		}
+	}
+}
+
+func TestMCPServer_Pagination(t *testing.T) {
+	q := &recordingQuerier{
+		headers: []string{"path", "language", "size"},
+		rows:    [][]any{{"a.go", "go", 10}, {"b.go", "go", 20}},
+	}
+	c := newMCPTestClient(t, &mcpServer{client: q})
+	c.Initialize()
+
+	res := c.CallTool("cie_list_files", map[string]any{"limit": 2})
+	if res.IsError {
+		t.Fatalf("unexpected tool error: %s", toolText(res))
+	}
+	if text := toolText(res); !strings.Contains(text, "a.go") || !strings.Contains(text, "b.go") {
+		t.Errorf("result missing rows: %s", text)
+	}
+
+	c.CallTool("cie_list_files", map[string]any{})
+
+	scripts := q.Scripts()
+	if len(scripts) != 2 {
+		t.Fatalf("got %d queries, want 2", len(scripts))
+	}
+	if !strings.HasSuffix(scripts[0], ":limit 2") {
+		t.Errorf("explicit limit not applied: %s", scripts[0])
+	}
+	if !strings.HasSuffix(scripts[1], ":limit 50") {
+		t.Errorf("default limit not applied: %s", scripts[1])
+	}
+}
+
+func TestMCPServer_WorkspaceRouting(t *testing.T) {
+	api, web := &recordingQuerier{}, &recordingQuerier{}
+	primary := &mcpServer{client: api, projectID: "api"}
+	primary.projects = map[string]*mcpServer{
+		"api": primary,
+		"web": {client: web, projectID: "web"},
+	}
+	c := newMCPTestClient(t, primary)
+	c.Initialize()
+
+	for _, tool := range c.ListTools() {
+		props, _ := tool.InputSchema["properties"].(map[string]any)
+		if _, ok := props["project"]; !ok {
+			t.Errorf("%s: missing project argument", tool.Name)
+		}
+	}
+
+	c.CallTool("cie_list_files", map[string]any{})
+	c.CallTool("cie_list_files", map[string]any{"project": "web"})
+	if len(api.Scripts()) != 1 || len(web.Scripts()) != 1 {
+		t.Errorf("queries api=%d web=%d, want 1 each", len(api.Scripts()), len(web.Scripts()))
+	}
+
+	res := c.CallTool("cie_list_files", map[string]any{"project": "docs"})
+	if !res.IsError || !strings.Contains(toolText(res), "api, web") {
+		t.Errorf("got %+v", res)
+	}
+}
+
+// embeddingRecorder is an Ollama or OpenAI-compatible embedding endpoint that
+// records the text of every request.
+type embeddingRecorder struct {
+	*httptest.Server
+	mu    sync.Mutex
+	texts []string
+}
+
+func newEmbeddingRecorder(t *testing.T) *embeddingRecorder {
+	t.Helper()
+	rec := &embeddingRecorder{}
+	rec.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Prompt string `json:"prompt"` // Ollama
+			Input  string `json:"input"`  // OpenAI
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		rec.mu.Lock()
+		rec.texts = append(rec.texts, body.Prompt+body.Input)
+		rec.mu.Unlock()
+		if strings.HasPrefix(r.URL.Path, "/v1/") {
+			_, _ = w.Write([]byte(`{"data":[{"embedding":[0.1,0.2]}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"embedding":[0.1,0.2]}`))
+	}))
+	t.Cleanup(rec.Close)
+	return rec
+}
+
+// last returns the text of the latest request, or "" when there was none.
+func (r *embeddingRecorder) last() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.texts) == 0 {
+		return ""
+	}
+	return r.texts[len(r.texts)-1]
+}
+
+func TestMCPServer_ProfileEmbeddingSetup(t *testing.T) {
+	ollama, openai := newEmbeddingRecorder(t), newEmbeddingRecorder(t)
+	var cfg Config
+	err := yaml.Unmarshal([]byte(fmt.Sprintf(`
+embedding:
+  provider: ollama
+  base_url: %s
+  model: nomic-embed-text
+  dimensions: 768
+profiles:
+  openai:
+    embedding:
+      provider: openai
+      base_url: %s/v1
+      model: text-embedding-3-small
+      query_prefix: ""
+  custom:
+    embedding:
+      query_prefix: "find: "
+  wide:
+    embedding:
+      dimensions: 1536
+`, ollama.URL, openai.URL)), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &mcpServer{client: &recordingQuerier{}}
+	s.setEmbedding(cfg.Embedding)
+	s.indexDimensions = cfg.Embedding.Dimensions
+	if s.profiles, err = newMCPProfiles(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	c := newMCPTestClient(t, s)
+	c.Initialize()
+
+	search := func(profile string) *mcpToolResult {
+		args := map[string]any{"query": "auth handler"}
+		if profile != "" {
+			args["profile"] = profile
+		}
+		return c.CallTool("cie_semantic_search", args)
+	}
+
+	search("openai")
+	if got := openai.last(); got != "auth handler" {
+		t.Errorf("openai profile embedded %q, want the query without a prefix", got)
+	}
+	search("custom")
+	if got := ollama.last(); got != "find: auth handler" {
+		t.Errorf("custom profile embedded %q, want its query prefix", got)
+	}
+	// Resolving the profiles must not change the base setup's prefixes.
+	search("")
+	if got := ollama.last(); got != "search_query: auth handler" {
+		t.Errorf("base setup embedded %q, want the nomic query prefix", got)
+	}
+
+	res := search("wide")
+	if !res.IsError || !strings.Contains(toolText(res), "1536-dimensional") {
+		t.Errorf("a profile with other dimensions than the index should be refused: %+v", res)
+	}
+}
+
+func TestMCPServer_Errors(t *testing.T) {
+	q := &recordingQuerier{err: fmt.Errorf("backend offline")}
+	c := newMCPTestClient(t, &mcpServer{client: q})
+	c.Initialize()
+
+	t.Run("unknown tool", func(t *testing.T) {
+		res := c.CallTool("cie_does_not_exist", nil)
+		if !res.IsError || !strings.Contains(toolText(res), "Unknown tool") {
+			t.Errorf("got %+v", res)
+		}
+		if code := res.Meta[errorCodeMetaKey]; code != string(errcode.InvalidInput) {
+			t.Errorf("error code = %v", code)
+		}
+	})
+
+	t.Run("query failure", func(t *testing.T) {
+		res := c.CallTool("cie_list_files", map[string]any{})
+		if !res.IsError || !strings.Contains(toolText(res), "backend offline") {
+			t.Errorf("got %+v", res)
+		}
+		if code := res.Meta[errorCodeMetaKey]; code != string(errcode.ToolFailed) {
+			t.Errorf("error code = %v", code)
+		}
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		res := c.CallTool("cie_list_files", map[string]any{"profile": "offline"})
+		if !res.IsError || !strings.Contains(toolText(res), `unknown profile "offline"`) {
+			t.Errorf("got %+v", res)
+		}
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		resp := c.Call("resources/list", nil)
+		if resp.Error == nil || resp.Error.Code != -32601 {
+			t.Errorf("got %+v", resp.Error)
+		}
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		resp := c.Call("tools/call", "not-an-object")
+		if resp.Error == nil || resp.Error.Code != -32602 {
+			t.Errorf("got %+v", resp.Error)
+		}
+	})
+
+	t.Run("malformed line is skipped", func(t *testing.T) {
+		c.Send("{not json")
+		if listed := c.ListTools(); len(listed) == 0 {
+			t.Error("server stopped answering after malformed input")
+		}
+	})
+}
+
+func TestMCPServer_WindowsPaths(t *testing.T) {
+	q := &recordingQuerier{}
+	c := newMCPTestClient(t, &mcpServer{client: q})
+	c.Initialize()
+
+	c.CallTool("cie_list_files", map[string]any{"path_pattern": `Internal\http`})
+	c.CallTool("cie_grep", map[string]any{"text": "ServeHTTP", "path": `.\internal\http`})
+
+	q.mu.Lock()
+	scripts := strings.Join(q.scripts, "\n")
+	q.mu.Unlock()
+	for _, want := range []string{`(?i)Internal/http`, `internal/http`} {
+		if !strings.Contains(scripts, want) {
+			t.Errorf("queries missing %q:\n%s", want, scripts)
+		}
+	}
+	if strings.Contains(scripts, `\http`) || strings.Contains(scripts, `[\]`) {
+		t.Errorf("backslash separators reached the query:\n%s", scripts)
+	}
+}
+
+func TestMCPServer_CollectionScope(t *testing.T) {
+	q := &recordingQuerier{
+		rows: [][]any{{"col:1", "payments", "file", "", "internal/billing/charge.go", "2026-01-01T10:00:00Z"}},
+	}
+	c := newMCPTestClient(t, &mcpServer{client: q})
+	c.Initialize()
+
+	for _, tool := range c.ListTools() {
+		props, _ := tool.InputSchema["properties"].(map[string]any)
+		_, hasCollection := props["collection"]
+		_, hasPath := props["path_pattern"]
+		if tool.Name == "cie_list_files" && !hasCollection {
+			t.Error("cie_list_files: missing collection argument")
+		}
+		if hasCollection && !hasPath && !strings.HasPrefix(tool.Name, "cie_collection_") {
+			t.Errorf("%s: collection argument without path_pattern", tool.Name)
+		}
+	}
+
+	c.CallTool("cie_list_files", map[string]any{"collection": "payments"})
+	scripts := q.Scripts()
+	if len(scripts) != 2 || !strings.Contains(scripts[0], "*cie_collection") {
+		t.Fatalf("queries = %q", scripts)
+	}
+	if !strings.Contains(scripts[1], `charge[.]go`) {
+		t.Errorf("listing not scoped to the collection: %s", scripts[1])
+	}
+
+	res := c.CallTool("cie_list_files", map[string]any{"collection": "payments", "path_pattern": "internal"})
+	if !res.IsError || !strings.Contains(toolText(res), "not both") {
+		t.Errorf("got %+v", res)
+	}
+	res = c.CallTool("cie_schema", map[string]any{"collection": "payments"})
+	if !res.IsError {
+		t.Error("tools without path_pattern cannot be scoped")
+	}
+}
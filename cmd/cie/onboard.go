@@ -0,0 +1,290 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/internal/output"
+	"github.com/kraklabs/cie/internal/ui"
+	"github.com/kraklabs/cie/pkg/storage"
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// OnboardOutput is the JSON shape of `cie onboard --json`.
+type OnboardOutput struct {
+	ProjectID     string               `json:"project_id"`
+	Output        string               `json:"output,omitempty"`
+	BuildCommands []tools.BuildCommand `json:"build_commands"`
+	Markdown      string               `json:"markdown"`
+}
+
+// runOnboard executes the 'onboard' CLI command, writing a markdown tour of
+// the repository built from the index.
+//
+// Examples:
+//
+//	cie onboard                       Print the tour to stdout
+//	cie onboard -o docs/ONBOARDING.md Write it to a file
+//	cie onboard --no-llm              Skip LLM package overviews
+func runOnboard(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("onboard", flag.ExitOnError)
+	outPath := fs.StringP("output", "o", "", "Write the report to this file instead of stdout")
+	noLLM := fs.Bool("no-llm", false, "Do not ask the configured LLM for package overviews")
+	packages := fs.Int("packages", 8, "Number of main packages to describe")
+	timeout := fs.Duration("timeout", 5*time.Minute, "Overall timeout (LLM overviews can be slow)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: cie onboard [options]
+
+Description:
+  Generate a markdown "repo tour" for new contributors from the index:
+  directory layout, entry points, the most depended-on packages,
+  HTTP endpoints, data model types, and build/test commands detected
+  from the Makefile, package.json, go.mod, pyproject.toml or Cargo.toml
+  at the repository root.
+
+  When an LLM is configured (llm.enabled), each main package also gets
+  a short written overview.
+
+Options:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Examples:
+  cie onboard
+  cie onboard -o ONBOARDING.md
+  cie onboard --no-llm --packages 5
+
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		errors.FatalError(errors.NewInternalError(
+			"Cannot determine working directory",
+			"Operating system failed to provide current directory",
+			"Run the command from inside the repository",
+			err,
+		), globals.JSON)
+	}
+
+	backend := openLocalBackend(cfg, globals)
+	defer func() { _ = backend.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	onboarding := tools.OnboardingArgs{
+		Title:         fmt.Sprintf("%s — Repository Tour", cfg.ProjectID),
+		BuildCommands: detectBuildCommands(cwd),
+		MaxPackages:   *packages,
+		MaxTokens:     llmMaxTokens(cfg.LLM),
+	}
+	if !*noLLM {
+		provider, err := newLLMProvider(cfg.LLM)
+		if err != nil {
+			errors.FatalError(llmConfigError(err), globals.JSON)
+		}
+		onboarding.LLM = provider
+	}
+
+	report, err := tools.OnboardingReport(ctx, tools.NewEmbeddedQuerier(backend), onboarding)
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Cannot build onboarding report",
+			fmt.Sprintf("Index query failed: %v", err),
+			"Run 'cie status' to check the index, or 'cie index' to rebuild it",
+			err,
+		), globals.JSON)
+	}
+
+	if *outPath != "" {
+		if err := os.WriteFile(*outPath, []byte(report), 0644); err != nil {
+			errors.FatalError(errors.NewPermissionError(
+				"Cannot write onboarding report",
+				fmt.Sprintf("Failed to write %s", *outPath),
+				"Check that the directory exists and is writable",
+				err,
+			), globals.JSON)
+		}
+	}
+
+	if globals.JSON {
+		_ = output.JSON(OnboardOutput{
+			ProjectID:     cfg.ProjectID,
+			Output:        *outPath,
+			BuildCommands: onboarding.BuildCommands,
+			Markdown:      report,
+		})
+		return
+	}
+	if *outPath != "" {
+		ui.Successf("Onboarding report written to %s", *outPath)
+		return
+	}
+	fmt.Print(report)
+}
+
+// openLocalBackend opens the project's embedded database for a read-only CLI
+// command, exiting with a helpful error when the project is not indexed.
+func openLocalBackend(cfg *Config, globals GlobalFlags) *storage.EmbeddedBackend {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		errors.FatalError(errors.NewInternalError(
+			"Cannot determine home directory",
+			"Operating system did not provide user home directory path",
+			"Check your system configuration or set HOME environment variable",
+			err,
+		), globals.JSON)
+	}
+	dataDir := filepath.Join(homeDir, ".cie", "data", cfg.ProjectID)
+	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
+		errors.FatalError(errors.NewDatabaseError(
+			fmt.Sprintf("Project '%s' not indexed yet", cfg.ProjectID),
+			"The CIE database does not exist for this project",
+			"Run 'cie index' to index the repository first",
+			err,
+		), globals.JSON)
+	}
+
+	backend, err := storage.NewEmbeddedBackend(storage.EmbeddedConfig{
+		DataDir:             dataDir,
+		Engine:              cfg.StorageEngine(),
+		Sharded:             cfg.Storage.Sharded,
+		ProjectID:           cfg.ProjectID,
+		EmbeddingDimensions: cfg.Embedding.Dimensions,
+	})
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Cannot open CIE database",
+			"The database file may be corrupted or locked by another process",
+			openFailureFix(err, "Try running 'cie status' to check database health, or close other CIE processes"),
+			err,
+		), globals.JSON)
+	}
+	return backend
+}
+
+// makeTargetPattern matches a Makefile rule name at the start of a line.
+var makeTargetPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_.-]*)\s*:([^=]|$)`)
+
+// detectBuildCommands lists the build, test and run commands a newcomer would
+// use, read from the tooling files at the repository root.
+func detectBuildCommands(root string) []tools.BuildCommand {
+	var commands []tools.BuildCommand
+	add := func(source string, cmds ...string) {
+		for _, c := range cmds {
+			commands = append(commands, tools.BuildCommand{Command: c, Source: source})
+		}
+	}
+
+	for _, name := range []string{"Makefile", "makefile", "GNUmakefile"} {
+		if targets := makeTargets(filepath.Join(root, name)); len(targets) > 0 {
+			for _, t := range targets {
+				add(name, "make "+t)
+			}
+			break
+		}
+	}
+
+	if data, err := os.ReadFile(filepath.Join(root, "package.json")); err == nil {
+		var pkg struct {
+			Scripts map[string]string `json:"scripts"`
+		}
+		if json.Unmarshal(data, &pkg) == nil {
+			runner := "npm run"
+			switch {
+			case fileExists(filepath.Join(root, "pnpm-lock.yaml")):
+				runner = "pnpm"
+			case fileExists(filepath.Join(root, "yarn.lock")):
+				runner = "yarn"
+			}
+			names := make([]string, 0, len(pkg.Scripts))
+			for name := range pkg.Scripts {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				add("package.json", runner+" "+name)
+			}
+		}
+	}
+
+	if fileExists(filepath.Join(root, "go.mod")) {
+		add("go.mod", "go build ./...", "go test ./...")
+	}
+	if fileExists(filepath.Join(root, "pyproject.toml")) {
+		add("pyproject.toml", "pip install -e .", "pytest")
+	} else if fileExists(filepath.Join(root, "requirements.txt")) {
+		add("requirements.txt", "pip install -r requirements.txt", "pytest")
+	}
+	if fileExists(filepath.Join(root, "Cargo.toml")) {
+		add("Cargo.toml", "cargo build", "cargo test")
+	}
+	return commands
+}
+
+// makeTargets returns the explicit rule names of a Makefile, skipping
+// special targets (.PHONY) and pattern rules.
+func makeTargets(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+
+	seen := map[string]bool{}
+	var targets []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		m := makeTargetPattern.FindStringSubmatch(scanner.Text())
+		if m == nil || strings.Contains(m[1], "%") || seen[m[1]] {
+			continue
+		}
+		seen[m[1]] = true
+		targets = append(targets, m[1])
+	}
+	return targets
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectBuildCommands(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("Makefile", "GO := go\n.PHONY: build test\nbuild: deps\n\t$(GO) build ./...\ntest:\n\tgo test ./...\n%.o: %.c\n\tcc $<\nbuild:\n")
+	write("package.json", `{"scripts": {"test": "jest", "dev": "vite"}}`)
+	write("yarn.lock", "")
+	write("go.mod", "module example.com/x\n")
+
+	var got []string
+	for _, c := range detectBuildCommands(root) {
+		got = append(got, c.Command+"@"+c.Source)
+	}
+	want := []string{
+		"make build@Makefile",
+		"make test@Makefile",
+		"yarn dev@package.json",
+		"yarn test@package.json",
+		"go build ./...@go.mod",
+		"go test ./...@go.mod",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("detectBuildCommands() =\n  %v\nwant\n  %v", got, want)
+	}
+
+	if cmds := detectBuildCommands(t.TempDir()); len(cmds) != 0 {
+		t.Errorf("empty repository should have no commands, got %v", cmds)
+	}
+}
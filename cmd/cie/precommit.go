@@ -0,0 +1,406 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/internal/output"
+	"github.com/kraklabs/cie/internal/ui"
+	"github.com/kraklabs/cie/pkg/ingestion"
+	"github.com/kraklabs/cie/pkg/storage"
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// PrecommitConfig configures `cie precommit`.
+type PrecommitConfig struct {
+	// Forbid lists patterns that must not appear in added lines.
+	Forbid []PrecommitRule `yaml:"forbid,omitempty"`
+
+	// Checks limits which checks run (absence, tests, callers, complexity).
+	// Empty runs all of them.
+	Checks []string `yaml:"checks,omitempty"`
+
+	// MaxComplexityIncrease is how much a function may grow in complexity
+	// in one commit before it is reported (default 5).
+	MaxComplexityIncrease int `yaml:"max_complexity_increase,omitempty"`
+}
+
+// PrecommitRule is a forbidden pattern, like cie_verify_absence but applied
+// to staged lines only.
+type PrecommitRule struct {
+	Pattern  string `yaml:"pattern"`
+	Path     string `yaml:"path,omitempty"`     // regex the file path must match
+	Severity string `yaml:"severity,omitempty"` // critical (default), warning, info
+	Message  string `yaml:"message,omitempty"`
+}
+
+// PrecommitOutput is the JSON shape of `cie precommit --json`.
+type PrecommitOutput struct {
+	Passed         bool `json:"passed"`
+	IndexAvailable bool `json:"index_available"`
+	*tools.PrecommitReport
+}
+
+// runPrecommit executes the 'precommit' CLI command, checking the staged
+// changes against the index before a commit is made.
+//
+// Only added lines and the functions they touch are analyzed, and a commit
+// with nothing staged returns before the configuration or database is
+// opened, so the command is cheap enough to run on every commit.
+//
+// Examples:
+//
+//	cie precommit                 Check staged changes
+//	cie precommit --strict        Fail on warnings too
+//	cie precommit --checks absence,complexity
+func runPrecommit(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("precommit", flag.ExitOnError)
+	strict := fs.Bool("strict", false, "Fail on warnings as well as critical findings")
+	checks := fs.StringSlice("checks", nil, "Checks to run: absence, tests, callers, complexity (default: all)")
+	timeout := fs.Duration("timeout", 30*time.Second, "Overall timeout")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: cie precommit [options]
+
+Description:
+  Check the staged changes against the index before committing:
+
+    absence     added lines must not match the forbidden patterns in
+                precommit.forbid
+    tests       new functions should have a test that names them
+    callers     new functions should be called from the staged change
+    complexity  changed functions must not grow more complex than
+                precommit.max_complexity_increase
+
+  Only staged hunks are analyzed. Critical findings fail the commit;
+  warnings fail it only with --strict. If the index is missing or locked,
+  only the absence check runs.
+
+Options:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Examples:
+  cie precommit
+  cie precommit --strict
+  cie precommit --checks absence,complexity
+
+pre-commit framework (.pre-commit-config.yaml):
+  - repo: local
+    hooks:
+      - id: cie
+        name: cie precommit
+        entry: cie precommit
+        language: system
+        pass_filenames: false
+
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+	for _, c := range *checks {
+		if !isPrecommitCheck(c) {
+			errors.FatalError(errors.NewInputError(
+				fmt.Sprintf("Unknown check %q", c),
+				"Supported checks: "+strings.Join(tools.AllPrecommitChecks, ", "),
+				"Pass a comma-separated subset, e.g. --checks absence,tests",
+			), globals.JSON)
+		}
+	}
+
+	root, err := gitOutput("", "rev-parse", "--show-toplevel")
+	if err != nil {
+		errors.FatalError(errors.NewInputError(
+			"Not a git repository",
+			"cie precommit checks the git index of the current repository",
+			"Run this command inside a git repository",
+		), globals.JSON)
+	}
+	root = strings.TrimSpace(root)
+
+	diff, err := gitOutput(root, "diff", "--cached", "-U0", "--no-color", "--no-ext-diff", "--diff-filter=ACMR")
+	if err != nil {
+		errors.FatalError(errors.NewInternalError(
+			"Cannot read staged changes",
+			"git diff --cached failed",
+			"Check that git is installed and the repository is not corrupted",
+			err,
+		), globals.JSON)
+	}
+	lines := parseStagedDiff(strings.NewReader(diff))
+	if len(lines) == 0 {
+		// Fast path: nothing staged that adds code.
+		if globals.JSON {
+			_ = output.JSON(PrecommitOutput{Passed: true, PrecommitReport: &tools.PrecommitReport{}})
+		}
+		return
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+	opts := tools.PrecommitOptions{
+		Checks:                cfg.Precommit.Checks,
+		MaxComplexityIncrease: cfg.Precommit.MaxComplexityIncrease,
+	}
+	if len(*checks) > 0 {
+		opts.Checks = *checks
+	}
+	for _, r := range cfg.Precommit.Forbid {
+		opts.Rules = append(opts.Rules, tools.AbsenceRule(r))
+	}
+
+	change := tools.StagedChange{Lines: lines}
+	if needsIndex(opts.Checks) {
+		change.Functions = stagedFunctions(root, lines)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	var client tools.Querier
+	indexAvailable := false
+	if len(change.Functions) > 0 {
+		backend, err := openPrecommitBackend(cfg)
+		if err != nil {
+			if !globals.Quiet && !globals.JSON {
+				ui.Warningf("Index unavailable (%v); running the absence check only", err)
+			}
+			opts.Checks = []string{tools.CheckAbsence}
+		} else {
+			defer func() { _ = backend.Close() }()
+			client = tools.NewEmbeddedQuerier(backend)
+			indexAvailable = true
+		}
+	}
+
+	report, err := tools.Precommit(ctx, client, change, opts)
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Pre-commit check failed",
+			err.Error(),
+			"Check precommit.forbid patterns in .cie/project.yaml, or run 'cie status' to check the index",
+			err,
+		), globals.JSON)
+	}
+
+	failed := report.Failed(*strict)
+	if globals.JSON {
+		_ = output.JSON(PrecommitOutput{Passed: !failed, IndexAvailable: indexAvailable, PrecommitReport: report})
+	} else {
+		printPrecommitReport(report, failed, globals.Quiet)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func isPrecommitCheck(name string) bool {
+	for _, c := range tools.AllPrecommitChecks {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// needsIndex reports whether any of the selected checks query the index.
+func needsIndex(checks []string) bool {
+	if len(checks) == 0 {
+		return true
+	}
+	for _, c := range checks {
+		if c != tools.CheckAbsence {
+			return true
+		}
+	}
+	return false
+}
+
+// openPrecommitBackend opens the project database, returning an error rather
+// than exiting so a missing or locked index does not block the commit.
+func openPrecommitBackend(cfg *Config) (*storage.EmbeddedBackend, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	dataDir := filepath.Join(homeDir, ".cie", "data", cfg.ProjectID)
+	if _, err := os.Stat(dataDir); err != nil {
+		return nil, fmt.Errorf("project not indexed")
+	}
+	return storage.NewEmbeddedBackend(storage.EmbeddedConfig{
+		DataDir:             dataDir,
+		Engine:              cfg.StorageEngine(),
+		Sharded:             cfg.Storage.Sharded,
+		ProjectID:           cfg.ProjectID,
+		EmbeddingDimensions: cfg.Embedding.Dimensions,
+	})
+}
+
+// hunkHeader matches a unified diff hunk header and captures the start line
+// in the new file: "@@ -10,2 +12,3 @@".
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// parseStagedDiff extracts the added lines from `git diff --cached -U0`.
+func parseStagedDiff(r io.Reader) []tools.StagedLine {
+	var lines []tools.StagedLine
+	var file string
+	next := 0
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(text, "+++ "), "b/")
+			if file == "/dev/null" {
+				file = ""
+			}
+		case strings.HasPrefix(text, "@@"):
+			if m := hunkHeader.FindStringSubmatch(text); m != nil {
+				next, _ = strconv.Atoi(m[1])
+			}
+		case strings.HasPrefix(text, "+") && file != "" && next > 0:
+			lines = append(lines, tools.StagedLine{FilePath: file, Line: next, Text: text[1:]})
+			next++
+		}
+	}
+	return lines
+}
+
+// stagedFunctions parses the staged version of each changed file and returns
+// the functions that contain an added line. Files in languages CIE does not
+// parse are skipped.
+func stagedFunctions(root string, lines []tools.StagedLine) []tools.StagedFunction {
+	added := map[string][]int{}
+	var files []string
+	for _, l := range lines {
+		if _, ok := added[l.FilePath]; !ok {
+			files = append(files, l.FilePath)
+		}
+		added[l.FilePath] = append(added[l.FilePath], l.Line)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "cie-precommit-")
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	parser := ingestion.NewTreeSitterParser(nil)
+	var fns []tools.StagedFunction
+	for i, file := range files {
+		lang := ingestion.DetectLanguage(file)
+		if lang == "" {
+			continue
+		}
+		// Parse the staged blob, not the working tree, which may hold
+		// unstaged edits.
+		content, err := gitOutput(root, "show", ":"+file)
+		if err != nil {
+			continue
+		}
+		tmp := filepath.Join(tmpDir, strconv.Itoa(i)+filepath.Ext(file))
+		if err := os.WriteFile(tmp, []byte(content), 0600); err != nil {
+			continue
+		}
+		result, err := parser.ParseFile(ingestion.FileInfo{Path: file, FullPath: tmp, Size: int64(len(content)), Language: lang})
+		if err != nil {
+			continue
+		}
+		for _, fn := range result.Functions {
+			if touchesAny(fn.StartLine, fn.EndLine, added[file]) {
+				fns = append(fns, tools.StagedFunction{
+					Name:      fn.Name,
+					FilePath:  file,
+					StartLine: fn.StartLine,
+					EndLine:   fn.EndLine,
+					CodeText:  fn.CodeText,
+				})
+			}
+		}
+	}
+	return fns
+}
+
+func touchesAny(start, end int, lines []int) bool {
+	for _, l := range lines {
+		if l >= start && l <= end {
+			return true
+		}
+	}
+	return false
+}
+
+// gitOutput runs git in dir (the current directory when empty) and returns
+// its stdout.
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...) //nolint:gosec // G204: fixed git subcommands
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+func printPrecommitReport(report *tools.PrecommitReport, failed, quiet bool) {
+	for _, f := range report.Findings {
+		if quiet && f.Severity == tools.SeverityInfo {
+			continue
+		}
+		loc := fmt.Sprintf("%s:%d", f.FilePath, f.Line)
+		if f.Function != "" {
+			loc += " " + f.Function
+		}
+		msg := fmt.Sprintf("[%s] %s: %s", f.Check, loc, f.Message)
+		switch f.Severity {
+		case tools.SeverityCritical:
+			ui.Error(msg)
+		case tools.SeverityWarning:
+			ui.Warning(msg)
+		default:
+			ui.Info(msg)
+		}
+	}
+	if quiet {
+		return
+	}
+	summary := fmt.Sprintf("%d added lines, %d functions checked (%d new)",
+		report.LinesChecked, report.FunctionsChecked, report.NewFunctions)
+	if failed {
+		ui.Errorf("Pre-commit checks failed: %s", summary)
+		return
+	}
+	ui.Successf("Pre-commit checks passed: %s", summary)
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+func TestParseStagedDiff(t *testing.T) {
+	diff := `diff --git a/pkg/a.go b/pkg/a.go
+index 1111111..2222222 100644
+--- a/pkg/a.go
++++ b/pkg/a.go
+@@ -3,0 +4,2 @@ func A() {
++	x := 1
++	y := 2
+@@ -10 +12 @@ func B() {
+-	old()
++	renamed()
+diff --git a/new.py b/new.py
+new file mode 100644
+--- /dev/null
++++ b/new.py
+@@ -0,0 +1 @@
++def f(): pass
+\ No newline at end of file
+`
+	got := parseStagedDiff(strings.NewReader(diff))
+	want := []tools.StagedLine{
+		{FilePath: "pkg/a.go", Line: 4, Text: "\tx := 1"},
+		{FilePath: "pkg/a.go", Line: 5, Text: "\ty := 2"},
+		{FilePath: "pkg/a.go", Line: 12, Text: "\trenamed()"},
+		{FilePath: "new.py", Line: 1, Text: "def f(): pass"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestNeedsIndex(t *testing.T) {
+	if !needsIndex(nil) {
+		t.Error("all checks should need the index")
+	}
+	if needsIndex([]string{tools.CheckAbsence}) {
+		t.Error("absence alone runs on staged lines only")
+	}
+	if !needsIndex([]string{tools.CheckAbsence, tools.CheckComplexity}) {
+		t.Error("complexity needs the indexed function bodies")
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -49,6 +50,9 @@ import (
 // Command-specific flags:
 //   - --timeout: Query timeout duration (default: 30s)
 //   - --limit: Add :limit clause to query (default: 0, no limit)
+//   - --at: Query the index snapshot taken at a commit instead of the live index
+//   - --format: Output format: table, json, csv, tsv or jsonl
+//   - --columns: Comma-separated result columns to print, in order
 //
 // Examples:
 //
@@ -77,9 +81,15 @@ func runQuery(args []string, configPath string, globals GlobalFlags) {
 	fs := flag.NewFlagSet("query", flag.ExitOnError)
 	timeout := fs.Duration("timeout", 30*time.Second, "Query timeout")
 	limit := fs.Int("limit", 0, "Add :limit to query (0 = no limit)")
+	at := fs.String("at", "", "Query the index snapshot taken at this commit SHA (see storage.snapshots)")
+	name := fs.String("name", "", "Run the saved query with this name from .cie/queries.yaml")
+	params := fs.StringArray("param", nil, "Saved query parameter as key=value (repeatable)")
+	listSaved := fs.Bool("list-saved", false, "List the saved queries in .cie/queries.yaml")
+	format, columns, noHeader := queryOutputFlags(fs)
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: cie query [options] <cozoscript>
+       cie query --name <saved-query> [--param key=value ...]
 
 Description:
   Execute a CozoScript query against the indexed codebase database.
@@ -88,7 +98,8 @@ Description:
   graph queries over your code structure. Use this for advanced code
   analysis beyond what the MCP tools provide.
 
-  Results can be formatted as tables (default) or JSON for programmatic use.
+  Results are printed as a table by default. Use --format csv, tsv or jsonl
+  to pipe them into awk, cut or jq, and --columns to pick columns.
 
 Options:
 `)
@@ -112,6 +123,19 @@ Examples:
   # Output as JSON for scripting
   cie query "?[name] := *cie_function{ name }" --json | jq '.rows[][0]'
 
+  # One object per row for jq, or headerless TSV for awk
+  cie query "?[name, file] := *cie_function{ name, file_path: file }" --format jsonl | jq -r .file
+  cie query "?[name, file] := *cie_function{ name, file_path: file }" --format tsv --no-header | awk -F'\t' '{print $2}'
+
+  # Keep only some columns, in the order given
+  cie query "?[id, name, file] := *cie_function{ id, name, file_path: file }" --columns file,name --format csv
+
+  # Count functions as of an earlier indexed commit
+  cie query "?[count(id)] := *cie_function{ id }" --at 3f2c9e1
+
+  # Run a saved query from .cie/queries.yaml
+  cie query --name callers-of --param name=NewPipeline
+
 Notes:
   Query timeout defaults to 30s. Increase with --timeout flag for complex queries.
   See docs/tools-reference.md for complete schema and query patterns.
@@ -122,17 +146,31 @@ Notes:
 	if err := fs.Parse(args); err != nil {
 		os.Exit(1)
 	}
-
-	if fs.NArg() == 0 {
+	out := parseQueryOutput(*format, *columns, *noHeader, globals)
+
+	var saved *SavedQuery
+	var bound map[string]any
+	if *listSaved || *name != "" {
+		queries := loadCLISavedQueries(configPath, globals)
+		if *listSaved {
+			printSavedQueries(queries)
+			return
+		}
+		saved, bound = bindCLISavedQuery(queries, *name, *params, globals)
+	} else if fs.NArg() == 0 {
 		fs.Usage()
 		errors.FatalError(errors.NewInputError(
 			"Script argument required",
 			"No CozoScript query provided",
-			"Provide a query: cie query '?[name] := *cie_function{name}'",
+			"Provide a query: cie query '?[name] := *cie_function{name}', or run a saved one with --name",
 		), globals.JSON)
 	}
 
 	script := fs.Arg(0)
+	var scriptParams map[string]any
+	if saved != nil {
+		script, scriptParams = saved.Script, saved.scriptParams(bound)
+	}
 
 	// Add limit if specified
 	if *limit > 0 {
@@ -164,17 +202,26 @@ Notes:
 		), globals.JSON)
 	}
 
-	// Open local backend
-	backend, err := storage.NewEmbeddedBackend(storage.EmbeddedConfig{
-		DataDir:   dataDir,
-		Engine:    "rocksdb",
-		ProjectID: cfg.ProjectID,
-	})
+	// Open local backend, or the snapshot asked for
+	var backend *storage.EmbeddedBackend
+	if *at != "" {
+		backend, err = openSnapshotBackend(cfg, *at)
+		if err != nil {
+			errors.FatalError(snapshotOpenError(*at, err), globals.JSON)
+		}
+	} else {
+		backend, err = storage.NewEmbeddedBackend(storage.EmbeddedConfig{
+			DataDir:   dataDir,
+			Engine:    cfg.StorageEngine(),
+			Sharded:   cfg.Storage.Sharded,
+			ProjectID: cfg.ProjectID,
+		})
+	}
 	if err != nil {
 		errors.FatalError(errors.NewDatabaseError(
 			"Cannot open CIE database",
 			"The database file may be corrupted or locked by another process",
-			"Try running 'cie status' to check database health, or 'cie reset' to rebuild",
+			openFailureFix(err, "Try running 'cie status' to check database health, or close other CIE processes"),
 			err,
 		), globals.JSON)
 	}
@@ -183,7 +230,12 @@ Notes:
 	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
 	defer cancel()
 
-	result, err := backend.Query(ctx, script)
+	if saved != nil && saved.Tool != "" {
+		runSavedToolQuery(ctx, cfg, backend, *saved, bound, globals)
+		return
+	}
+
+	result, err := backend.QueryWithParams(ctx, script, scriptParams)
 	if err != nil {
 		// Distinguish between syntax errors and execution errors
 		if strings.Contains(err.Error(), "parse") || strings.Contains(err.Error(), "syntax") {
@@ -201,29 +253,59 @@ Notes:
 		), globals.JSON)
 	}
 
-	// Warn about empty results in non-JSON mode
-	if len(result.Rows) == 0 && !globals.JSON {
+	// Warn about empty results when a person is reading
+	if len(result.Rows) == 0 && !out.machineReadable() {
 		fmt.Fprintf(os.Stderr, "Warning: Query returned no results\n")
 		fmt.Fprintf(os.Stderr, "Hint: Try broadening your query or verify the database is indexed with 'cie status'\n")
 	}
 
-	if globals.JSON {
-		outputQueryJSON(result)
-	} else {
-		printQueryResult(result)
+	printQueryOutput(result, out, globals)
+}
+
+// queryOutputFlags registers the flags shared by local and remote queries
+// that control how results are printed.
+func queryOutputFlags(fs *flag.FlagSet) (format, columns *string, noHeader *bool) {
+	format = fs.String("format", "", "Output format: table, json, csv, tsv or jsonl (default table, or json with --json)")
+	columns = fs.String("columns", "", "Comma-separated columns to print, in order (default: all)")
+	noHeader = fs.Bool("no-header", false, "Omit the header line in csv and tsv output")
+	return format, columns, noHeader
+}
+
+// parseQueryOutput validates the output flags, exiting on bad input.
+func parseQueryOutput(format, columns string, noHeader bool, globals GlobalFlags) queryOutput {
+	out, err := newQueryOutput(format, columns, noHeader, globals.JSON)
+	if err != nil {
+		errors.FatalError(errors.NewInputError(
+			"Invalid --format",
+			err.Error(),
+			"Use one of: "+strings.Join(queryFormats, ", "),
+		), globals.JSON)
+	}
+	return out
+}
+
+// printQueryOutput writes a query result to stdout, exiting if a selected
+// column does not exist.
+func printQueryOutput(result *storage.QueryResult, out queryOutput, globals GlobalFlags) {
+	if err := writeQueryResult(os.Stdout, result, out); err != nil {
+		errors.FatalError(errors.NewInputError(
+			"Invalid --columns",
+			err.Error(),
+			"Pass column names from the query head, e.g. ?[name, file] allows --columns file,name",
+		), globals.JSON)
 	}
 }
 
 // outputQueryJSON writes query results as formatted JSON to stdout.
 //
 // Includes column headers and rows. Used when --json flag is provided.
-func outputQueryJSON(result *storage.QueryResult) {
+func outputQueryJSON(w io.Writer, result *storage.QueryResult) {
 	output := map[string]any{
 		"headers": result.Headers,
 		"rows":    result.Rows,
 		"count":   len(result.Rows),
 	}
-	enc := json.NewEncoder(os.Stdout)
+	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
 	_ = enc.Encode(output)
 }
@@ -232,13 +314,13 @@ func outputQueryJSON(result *storage.QueryResult) {
 //
 // Uses tab-aligned columns for readability. This is the default output format
 // when --json is not specified.
-func printQueryResult(result *storage.QueryResult) {
+func printQueryResult(out io.Writer, result *storage.QueryResult) {
 	if len(result.Rows) == 0 {
-		fmt.Println("No results")
+		_, _ = fmt.Fprintln(out, "No results")
 		return
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
 
 	// Print headers
 	for i, h := range result.Headers {
@@ -271,7 +353,7 @@ func printQueryResult(result *storage.QueryResult) {
 
 	_ = w.Flush()
 
-	fmt.Printf("\n(%d rows)\n", len(result.Rows))
+	_, _ = fmt.Fprintf(out, "\n(%d rows)\n", len(result.Rows))
 }
 
 // formatCell formats a single cell value for display in the query result table.
@@ -307,10 +389,12 @@ func runRemoteQuery(baseURL string, args []string, globals GlobalFlags) {
 	fs := flag.NewFlagSet("query", flag.ExitOnError)
 	timeout := fs.Duration("timeout", 30*time.Second, "Query timeout")
 	limit := fs.Int("limit", 0, "Add :limit to query (0 = no limit)")
+	format, columns, noHeader := queryOutputFlags(fs)
 
 	if err := fs.Parse(args); err != nil {
 		os.Exit(1)
 	}
+	out := parseQueryOutput(*format, *columns, *noHeader, globals)
 
 	if fs.NArg() == 0 {
 		errors.FatalError(errors.NewInputError(
@@ -369,9 +453,5 @@ func runRemoteQuery(baseURL string, args []string, globals GlobalFlags) {
 		), globals.JSON)
 	}
 
-	if globals.JSON {
-		outputQueryJSON(&result)
-	} else {
-		printQueryResult(&result)
-	}
+	printQueryOutput(&result, out, globals)
 }
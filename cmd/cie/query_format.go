@@ -0,0 +1,194 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// queryFormats are the values accepted by `cie query --format`.
+var queryFormats = []string{"table", "json", "csv", "tsv", "jsonl"}
+
+// queryOutput describes how `cie query` prints its result.
+type queryOutput struct {
+	format   string
+	columns  []string // headers to keep, in output order; empty keeps all
+	noHeader bool     // omit the header line of csv and tsv
+}
+
+// newQueryOutput validates the output flags. An empty format means json
+// when --json is set and table otherwise.
+func newQueryOutput(format, columns string, noHeader, jsonFlag bool) (queryOutput, error) {
+	if format == "" {
+		format = "table"
+		if jsonFlag {
+			format = "json"
+		}
+	}
+	format = strings.ToLower(format)
+	if !slices.Contains(queryFormats, format) {
+		return queryOutput{}, fmt.Errorf("unknown format %q (want %s)", format, strings.Join(queryFormats, ", "))
+	}
+	out := queryOutput{format: format, noHeader: noHeader}
+	for _, c := range strings.Split(columns, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			out.columns = append(out.columns, c)
+		}
+	}
+	return out, nil
+}
+
+// machineReadable reports whether the output is meant for another program,
+// in which case hints on stderr are suppressed.
+func (o queryOutput) machineReadable() bool {
+	return o.format != "table"
+}
+
+// selectColumns returns result restricted to the named columns, in the
+// order given.
+func selectColumns(result *storage.QueryResult, columns []string) (*storage.QueryResult, error) {
+	if len(columns) == 0 {
+		return result, nil
+	}
+	idx := make([]int, len(columns))
+	for i, c := range columns {
+		idx[i] = slices.Index(result.Headers, c)
+		if idx[i] < 0 {
+			return nil, fmt.Errorf("unknown column %q (query returns %s)", c, strings.Join(result.Headers, ", "))
+		}
+	}
+	selected := &storage.QueryResult{Headers: columns, Rows: make([][]any, len(result.Rows))}
+	for r, row := range result.Rows {
+		out := make([]any, len(idx))
+		for i, j := range idx {
+			if j < len(row) {
+				out[i] = row[j]
+			}
+		}
+		selected.Rows[r] = out
+	}
+	return selected, nil
+}
+
+// writeQueryResult prints result to w in the requested format.
+func writeQueryResult(w io.Writer, result *storage.QueryResult, out queryOutput) error {
+	result, err := selectColumns(result, out.columns)
+	if err != nil {
+		return err
+	}
+	switch out.format {
+	case "json":
+		outputQueryJSON(w, result)
+	case "csv":
+		return writeQueryCSV(w, result, out.noHeader)
+	case "tsv":
+		writeQueryTSV(w, result, out.noHeader)
+	case "jsonl":
+		return writeQueryJSONL(w, result)
+	default:
+		printQueryResult(w, result)
+	}
+	return nil
+}
+
+// writeQueryCSV prints result as RFC 4180 CSV.
+func writeQueryCSV(w io.Writer, result *storage.QueryResult, noHeader bool) error {
+	cw := csv.NewWriter(w)
+	if !noHeader {
+		_ = cw.Write(result.Headers)
+	}
+	record := make([]string, len(result.Headers))
+	for _, row := range result.Rows {
+		for i := range record {
+			record[i] = plainCell(cellAt(row, i))
+		}
+		_ = cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// tsvEscaper keeps each row on one line, as awk and cut expect.
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// writeQueryTSV prints result as tab-separated values. Tabs, newlines and
+// backslashes inside cells are escaped.
+func writeQueryTSV(w io.Writer, result *storage.QueryResult, noHeader bool) {
+	if !noHeader {
+		_, _ = fmt.Fprintln(w, strings.Join(result.Headers, "\t"))
+	}
+	fields := make([]string, len(result.Headers))
+	for _, row := range result.Rows {
+		for i := range fields {
+			fields[i] = tsvEscaper.Replace(plainCell(cellAt(row, i)))
+		}
+		_, _ = fmt.Fprintln(w, strings.Join(fields, "\t"))
+	}
+}
+
+// writeQueryJSONL prints one JSON object per row, keyed by column header.
+func writeQueryJSONL(w io.Writer, result *storage.QueryResult) error {
+	enc := json.NewEncoder(w)
+	for _, row := range result.Rows {
+		obj := make(map[string]any, len(result.Headers))
+		for i, h := range result.Headers {
+			obj[h] = cellAt(row, i)
+		}
+		if err := enc.Encode(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func cellAt(row []any, i int) any {
+	if i < len(row) {
+		return row[i]
+	}
+	return nil
+}
+
+// plainCell formats a value for csv and tsv output. Unlike formatCell it
+// never truncates, prints null as an empty field and keeps full float
+// precision.
+func plainCell(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		if b, err := json.Marshal(val); err == nil {
+			return string(b)
+		}
+		return fmt.Sprintf("%v", val)
+	}
+}
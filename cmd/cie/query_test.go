@@ -0,0 +1,115 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+func TestNewQueryOutput(t *testing.T) {
+	tests := []struct {
+		format   string
+		jsonFlag bool
+		want     string
+		wantErr  bool
+	}{
+		{format: "", want: "table"},
+		{format: "", jsonFlag: true, want: "json"},
+		{format: "CSV", jsonFlag: true, want: "csv"},
+		{format: "jsonl", want: "jsonl"},
+		{format: "xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		out, err := newQueryOutput(tt.format, "", false, tt.jsonFlag)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("newQueryOutput(%q) error = %v, wantErr %v", tt.format, err, tt.wantErr)
+		}
+		if err == nil && out.format != tt.want {
+			t.Errorf("newQueryOutput(%q, json=%v) = %q, want %q", tt.format, tt.jsonFlag, out.format, tt.want)
+		}
+	}
+
+	out, _ := newQueryOutput("tsv", " file, name ,", false, false)
+	if strings.Join(out.columns, "|") != "file|name" {
+		t.Errorf("columns = %q", out.columns)
+	}
+}
+
+func TestWriteQueryResult(t *testing.T) {
+	result := &storage.QueryResult{
+		Headers: []string{"name", "file", "line"},
+		Rows: [][]any{
+			{"Run", "cmd/main.go", float64(12)},
+			{"say, \"hi\"", "a\tb.go", nil},
+		},
+	}
+	tests := []struct {
+		name string
+		out  queryOutput
+		want string
+	}{
+		{
+			name: "csv",
+			out:  queryOutput{format: "csv"},
+			want: "name,file,line\nRun,cmd/main.go,12\n\"say, \"\"hi\"\"\",a\tb.go,\n",
+		},
+		{
+			name: "tsv escapes tabs",
+			out:  queryOutput{format: "tsv"},
+			want: "name\tfile\tline\nRun\tcmd/main.go\t12\nsay, \"hi\"\ta\\tb.go\t\n",
+		},
+		{
+			name: "tsv columns without header",
+			out:  queryOutput{format: "tsv", columns: []string{"line", "name"}, noHeader: true},
+			want: "12\tRun\n\tsay, \"hi\"\n",
+		},
+		{
+			name: "jsonl",
+			out:  queryOutput{format: "jsonl", columns: []string{"name", "line"}},
+			want: "{\"line\":12,\"name\":\"Run\"}\n{\"line\":null,\"name\":\"say, \\\"hi\\\"\"}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeQueryResult(&buf, result, tt.out); err != nil {
+				t.Fatalf("writeQueryResult: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got:\n%q\nwant:\n%q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteQueryResult_UnknownColumn(t *testing.T) {
+	result := &storage.QueryResult{Headers: []string{"name"}, Rows: [][]any{{"Run"}}}
+	var buf bytes.Buffer
+	err := writeQueryResult(&buf, result, queryOutput{format: "csv", columns: []string{"file"}})
+	if err == nil || !strings.Contains(err.Error(), `unknown column "file"`) {
+		t.Fatalf("err = %v, want unknown column", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("wrote %q before failing", buf.String())
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// defaultToolRateLimits caps the tools that call out to embedding or LLM
+// providers, so a looping agent cannot burn through API quota. Entries in
+// mcp.rate_limits.tools replace these per tool.
+var defaultToolRateLimits = map[string]ToolRateLimit{
+	"cie_semantic_search": {PerMinute: 60, MaxConcurrent: 4},
+	"cie_analyze":         {PerMinute: 10, MaxConcurrent: 1},
+}
+
+// rateLimiter enforces per-tool and per-session call limits for one MCP
+// session. A nil *rateLimiter allows everything.
+type rateLimiter struct {
+	mu       sync.Mutex
+	limits   map[string]ToolRateLimit
+	buckets  map[string]*tokenBucket
+	inflight map[string]int
+	session  *tokenBucket
+	now      func() time.Time
+}
+
+// newRateLimiter builds a limiter from config, merging in the defaults.
+func newRateLimiter(cfg RateLimitConfig) *rateLimiter {
+	r := &rateLimiter{
+		limits:   make(map[string]ToolRateLimit),
+		buckets:  make(map[string]*tokenBucket),
+		inflight: make(map[string]int),
+		now:      time.Now,
+	}
+	for tool, limit := range defaultToolRateLimits {
+		r.limits[tool] = limit
+	}
+	for tool, limit := range cfg.Tools {
+		r.limits[tool] = limit
+	}
+	if cfg.SessionPerMinute > 0 {
+		r.session = newTokenBucket(cfg.SessionPerMinute, r.now())
+	}
+	return r
+}
+
+// acquire reserves a slot for one call to tool. On success the returned
+// release func must be called when the call finishes.
+func (r *rateLimiter) acquire(tool string) (release func(), err error) {
+	if r == nil {
+		return func() {}, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	limit := r.limits[tool]
+
+	if limit.MaxConcurrent > 0 && r.inflight[tool] >= limit.MaxConcurrent {
+		return nil, fmt.Errorf("%s is already running %d concurrent call(s) (limit %d); wait for them to finish",
+			tool, r.inflight[tool], limit.MaxConcurrent)
+	}
+
+	var bucket *tokenBucket
+	if limit.PerMinute > 0 {
+		bucket = r.buckets[tool]
+		if bucket == nil {
+			bucket = newTokenBucket(limit.PerMinute, now)
+			r.buckets[tool] = bucket
+		}
+		if wait := bucket.wait(now); wait > 0 {
+			return nil, fmt.Errorf("rate limit exceeded for %s (%d calls/minute); retry in %s",
+				tool, limit.PerMinute, roundUpSecond(wait))
+		}
+	}
+	if r.session != nil {
+		if wait := r.session.wait(now); wait > 0 {
+			return nil, fmt.Errorf("session rate limit exceeded (%d calls/minute); retry in %s",
+				int(r.session.capacity), roundUpSecond(wait))
+		}
+		r.session.take()
+	}
+	if bucket != nil {
+		bucket.take()
+	}
+
+	r.inflight[tool]++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.mu.Lock()
+			r.inflight[tool]--
+			r.mu.Unlock()
+		})
+	}, nil
+}
+
+// tokenBucket refills continuously at capacity tokens per minute.
+type tokenBucket struct {
+	capacity float64
+	tokens   float64
+	perSec   float64
+	last     time.Time
+}
+
+func newTokenBucket(perMinute int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		capacity: float64(perMinute),
+		tokens:   float64(perMinute),
+		perSec:   float64(perMinute) / 60,
+		last:     now,
+	}
+}
+
+// wait refills the bucket and reports how long until a token is available.
+func (b *tokenBucket) wait(now time.Time) time.Duration {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+elapsed*b.perSec)
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.perSec * float64(time.Second))
+}
+
+func (b *tokenBucket) take() {
+	b.tokens--
+}
+
+func roundUpSecond(d time.Duration) time.Duration {
+	return (d + time.Second - 1).Truncate(time.Second)
+}
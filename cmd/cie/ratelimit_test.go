@@ -0,0 +1,101 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(cfg RateLimitConfig) (*rateLimiter, *time.Time) {
+	now := time.Unix(1700000000, 0)
+	r := newRateLimiter(cfg)
+	r.now = func() time.Time { return now }
+	for _, b := range r.buckets {
+		b.last = now
+	}
+	if r.session != nil {
+		r.session.last = now
+	}
+	return r, &now
+}
+
+func TestRateLimiter_PerMinute(t *testing.T) {
+	r, now := newTestLimiter(RateLimitConfig{Tools: map[string]ToolRateLimit{"cie_grep": {PerMinute: 2}}})
+
+	for i := 0; i < 2; i++ {
+		release, err := r.acquire("cie_grep")
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		release()
+	}
+	if _, err := r.acquire("cie_grep"); err == nil || !strings.Contains(err.Error(), "retry in 30s") {
+		t.Fatalf("expected rate limit error with retry hint, got %v", err)
+	}
+
+	*now = now.Add(30 * time.Second)
+	if _, err := r.acquire("cie_grep"); err != nil {
+		t.Errorf("bucket should have refilled one token: %v", err)
+	}
+}
+
+func TestRateLimiter_MaxConcurrent(t *testing.T) {
+	r, _ := newTestLimiter(RateLimitConfig{})
+
+	release, err := r.acquire("cie_analyze")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.acquire("cie_analyze"); err == nil {
+		t.Fatal("second concurrent cie_analyze should be rejected by the default limit")
+	}
+	release()
+	release() // idempotent
+	if r.inflight["cie_analyze"] != 0 {
+		t.Errorf("inflight = %d after release, want 0", r.inflight["cie_analyze"])
+	}
+}
+
+func TestRateLimiter_SessionLimitAndOverrides(t *testing.T) {
+	r, _ := newTestLimiter(RateLimitConfig{
+		SessionPerMinute: 1,
+		Tools:            map[string]ToolRateLimit{"cie_analyze": {}},
+	})
+	if l := r.limits["cie_analyze"]; l.PerMinute != 0 || l.MaxConcurrent != 0 {
+		t.Errorf("empty override should clear the default, got %+v", l)
+	}
+
+	if _, err := r.acquire("cie_find_function"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.acquire("cie_grep"); err == nil || !strings.Contains(err.Error(), "session") {
+		t.Errorf("expected session limit error, got %v", err)
+	}
+}
+
+func TestRateLimiter_Nil(t *testing.T) {
+	var r *rateLimiter
+	release, err := r.acquire("anything")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+}
@@ -0,0 +1,175 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/kraklabs/cie/pkg/ingestion"
+	"github.com/kraklabs/cie/pkg/storage"
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// minReindexInterval keeps a typo like "every 30s" from reindexing in a loop.
+const minReindexInterval = time.Minute
+
+// parseReindexSchedule turns the reindex setting into an interval. It accepts
+// "every <duration>", a bare Go duration ("45m", "2h"), and the aliases
+// "hourly" and "daily". Empty, "off" and "never" return 0 (disabled).
+func parseReindexSchedule(spec string) (time.Duration, error) {
+	s := strings.ToLower(strings.TrimSpace(spec))
+	switch s {
+	case "", "off", "never":
+		return 0, nil
+	case "hourly":
+		return time.Hour, nil
+	case "daily":
+		return 24 * time.Hour, nil
+	}
+	s = strings.TrimSpace(strings.TrimPrefix(s, "every"))
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid reindex schedule %q: want e.g. \"every 30m\", \"2h\" or \"hourly\"", spec)
+	}
+	if d < minReindexInterval {
+		return 0, fmt.Errorf("reindex interval %s is below the %s minimum", d, minReindexInterval)
+	}
+	return d, nil
+}
+
+// reindexScheduler calls run every interval until its context ends. A tick
+// that fires while the previous run is still going is skipped, so a slow
+// run never queues up behind itself.
+type reindexScheduler struct {
+	interval time.Duration
+	run      func(ctx context.Context) error
+	logf     func(format string, args ...any)
+	running  atomic.Bool
+}
+
+// loop blocks until ctx is cancelled.
+func (r *reindexScheduler) loop(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.tick(ctx)
+		}
+	}
+}
+
+// tick starts one run unless another is in progress and reports whether it
+// ran.
+func (r *reindexScheduler) tick(ctx context.Context) bool {
+	if !r.running.CompareAndSwap(false, true) {
+		r.logf("scheduled reindex skipped: previous run still in progress")
+		return false
+	}
+	defer r.running.Store(false)
+
+	start := time.Now()
+	if err := r.run(ctx); err != nil {
+		r.logf("scheduled reindex failed: %v", err)
+		return true
+	}
+	r.logf("scheduled reindex finished in %s", time.Since(start).Round(time.Millisecond))
+	return true
+}
+
+// startScheduledReindex runs incremental index runs on cfg.Reindex for an
+// embedded MCP server. Runs write through the server's open database, so
+// tool calls are answered from the previous index until each run commits;
+// the index watcher then notices the new version and drops stale state.
+func (s *mcpServer) startScheduledReindex(ctx context.Context, cfg *Config) {
+	interval, err := parseReindexSchedule(cfg.Reindex)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  Warning: reindex schedule ignored: %v\n", err)
+		return
+	}
+	if interval == 0 {
+		return
+	}
+	querier, ok := s.client.(*tools.EmbeddedQuerier)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "  Warning: reindex schedule ignored in %s mode; the server that owns the index must run it\n", s.mode)
+		return
+	}
+	repoPath, _ := os.Getwd()
+	if s.gitExecutor != nil {
+		repoPath = s.gitExecutor.RepoPath()
+	}
+	backend := querier.Backend()
+
+	sched := &reindexScheduler{
+		interval: interval,
+		logf: func(format string, args ...any) {
+			fmt.Fprintf(os.Stderr, "  "+format+"\n", args...)
+		},
+		run: func(ctx context.Context) error {
+			return runScheduledLocalIndex(ctx, cfg, repoPath, backend)
+		},
+	}
+	fmt.Fprintf(os.Stderr, "  Reindex: every %s\n", interval)
+	go sched.loop(ctx)
+}
+
+// runScheduledLocalIndex performs one incremental run through backend,
+// holding the project's index lock so it never overlaps `cie index`.
+func runScheduledLocalIndex(ctx context.Context, cfg *Config, repoPath string, backend *storage.EmbeddedBackend) error {
+	queue, err := NewIndexQueue(cfg.ProjectID)
+	if err != nil {
+		return err
+	}
+	acquired, err := queue.TryAcquireLock()
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return fmt.Errorf("index lock is held by another run; retrying at the next tick")
+	}
+	defer queue.ReleaseLock()
+
+	checkpointDir := filepath.Join(ConfigDir(repoPath), "checkpoints")
+	if err := os.MkdirAll(checkpointDir, 0750); err != nil {
+		return err
+	}
+	provider := mapEmbeddingProvider(cfg.Embedding.Provider)
+	setEmbeddingEnv(cfg, provider)
+	config := localIngestionConfig(cfg, repoPath, checkpointDir, provider, 8, false, false)
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	pipeline, err := ingestion.NewLocalPipelineWithBackend(config, backend.View(), logger)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = pipeline.Close() }()
+
+	_, err = pipeline.Run(ctx)
+	return err
+}
@@ -0,0 +1,84 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParseReindexSchedule(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"off", 0, false},
+		{"every 30m", 30 * time.Minute, false},
+		{"Every 2h", 2 * time.Hour, false},
+		{"45m", 45 * time.Minute, false},
+		{"hourly", time.Hour, false},
+		{"daily", 24 * time.Hour, false},
+		{"every 30s", 0, true},
+		{"every tuesday", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseReindexSchedule(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseReindexSchedule(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseReindexSchedule(%q) = %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestReindexScheduler_SkipsOverlappingRuns(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var logs []string
+	sched := &reindexScheduler{
+		interval: time.Hour,
+		logf:     func(format string, args ...any) { logs = append(logs, fmt.Sprintf(format, args...)) },
+		run: func(ctx context.Context) error {
+			close(started)
+			<-release
+			return errors.New("boom")
+		},
+	}
+
+	done := make(chan bool)
+	go func() { done <- sched.tick(context.Background()) }()
+	<-started
+	if sched.tick(context.Background()) {
+		t.Error("second tick ran while the first was in progress")
+	}
+	close(release)
+	if !<-done {
+		t.Error("first tick did not run")
+	}
+	if len(logs) != 2 || logs[1] != "scheduled reindex failed: boom" {
+		t.Errorf("logs = %q", logs)
+	}
+}
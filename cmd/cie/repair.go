@@ -0,0 +1,166 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/internal/output"
+	"github.com/kraklabs/cie/internal/ui"
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// runRepair executes the 'repair' CLI command, which recovers a damaged
+// local index.
+//
+// Unlike 'cie reset' it never deletes anything: the damaged database is
+// moved aside, .cie/project.yaml and .cie/checkpoints are left alone, and
+// as much of the index as can still be read is carried over.
+func runRepair(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("repair", flag.ExitOnError)
+	force := fs.Bool("force", false, "Rebuild even if the database reads cleanly or fails to open for another reason")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: cie repair [options]
+
+Description:
+  Check the local index of the current project and recover it if it is
+  damaged, for example after a crash or a full disk.
+
+  Recovery tries, in order:
+  1. A full backup of the database, restored into a fresh one
+  2. Copying every relation that can still be read into a fresh database
+  3. Moving the database aside and starting from an empty one
+
+  The damaged database is kept next to the index as
+  ~/.cie/data/<project_id>.corrupt-<time>/ until you delete it.
+  Configuration (.cie/project.yaml) and checkpoints are never touched.
+
+Options:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Examples:
+  cie repair
+  cie repair --force
+
+Notes:
+  The index is locked for the repair. 'cie --mcp' releases it meanwhile;
+  stop 'cie serve' and running index jobs first, or the repair gives up
+  after 30 seconds. Run 'cie index' after a repair to fill in what could
+  not be recovered.
+
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+	cieDir, err := getCIEDir()
+	if err != nil {
+		errors.FatalError(errors.NewInternalError(
+			"Failed to find CIE directory",
+			err.Error(),
+			"",
+			err,
+		), globals.JSON)
+	}
+	dataDir := filepath.Join(cieDir, "data", cfg.ProjectID)
+
+	if !globals.JSON {
+		fmt.Printf("Checking %s...\n", dataDir)
+	}
+	res, err := storage.RepairDatabase(storage.RepairOptions{
+		DataDir:             dataDir,
+		Engine:              cfg.StorageEngine(),
+		EmbeddingDimensions: cfg.Embedding.Dimensions,
+		HNSWDistance:        cfg.Embedding.Distance,
+		Force:               *force,
+	})
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Cannot repair the local index",
+			err.Error(),
+			"Close other CIE processes and retry, or run 'cie repair --force'",
+			err,
+		), globals.JSON)
+	}
+
+	if globals.JSON {
+		_ = output.JSON(res)
+		return
+	}
+	printRepairResult(res)
+}
+
+// printRepairResult describes a repair in human-readable form.
+func printRepairResult(res *storage.RepairResult) {
+	switch res.Outcome {
+	case storage.RepairHealthy:
+		ui.Success("The index is healthy; nothing to repair")
+		return
+	case storage.RepairRestored:
+		ui.Success("The index was restored from a full backup")
+	case storage.RepairSalvaged:
+		total := 0
+		names := make([]string, 0, len(res.Copied))
+		for name, n := range res.Copied {
+			total += n
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		ui.Successf("Salvaged %d rows from %d relations", total, len(names))
+		for _, name := range res.Lost {
+			ui.Warningf("Could not recover %s", name)
+		}
+	case storage.RepairReset:
+		ui.Warningf("The database could not be opened (%s); it was reset", res.Reason)
+	}
+	if res.Quarantine != "" {
+		ui.Infof("The damaged database was kept at %s; delete it once you are satisfied", res.Quarantine)
+	}
+	fmt.Println()
+	fmt.Println("Next steps:")
+	fmt.Println("  cie index    Re-index to fill in anything that was not recovered")
+}
+
+// openFailureFix suggests a next step when the local database fails to
+// open: 'cie repair' for corruption, waiting for a busy index, fallback
+// otherwise.
+func openFailureFix(err error, fallback string) string {
+	if storage.IsCorruption(err) {
+		return "The index looks damaged. Run 'cie repair' to recover it; configuration and checkpoints are kept"
+	}
+	if stderrors.Is(err, storage.ErrDataDirLocked) {
+		return "Another CIE process is using the index. Wait for it to finish and try again"
+	}
+	return fallback
+}
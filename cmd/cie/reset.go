@@ -31,7 +31,8 @@ Description:
   - Embeddings and call graphs
   - Indexing checkpoints
 
-  Use this if the database is corrupted or you want to start fresh.
+  Use this if you want to start fresh. If the database is corrupted,
+  try 'cie repair' first; it keeps whatever can still be read.
   You'll need to re-run 'cie index' after resetting.
 
 Options:
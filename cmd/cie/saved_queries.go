@@ -0,0 +1,549 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/pkg/ingestion"
+	"github.com/kraklabs/cie/pkg/storage"
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// savedQueriesFile holds saved queries, next to project.yaml in .cie/.
+const savedQueriesFile = "queries.yaml"
+
+// savedQueryToolPrefix starts the MCP tool name of an exposed saved query.
+const savedQueryToolPrefix = "cie_query_"
+
+var (
+	savedQueryNamePattern  = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	savedQueryPlaceholder  = regexp.MustCompile(`\$([A-Za-z_][A-Za-z0-9_]*)`)
+	savedQueryParamPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// SavedQueriesConfig represents a .cie/queries.yaml file.
+//
+// Example:
+//
+//	queries:
+//	  callers-of:
+//	    description: Functions calling a function
+//	    script: |
+//	      ?[caller, file] := *cie_function { id: callee, name: $name },
+//	        *cie_calls { caller_id, callee_id: callee },
+//	        *cie_function { id: caller_id, name: caller, file_path: file }
+//	    params:
+//	      - name: name
+//	        required: true
+//	    expose: true
+//	  handlers:
+//	    tool: cie_find_function
+//	    args: { name: "$prefix", include_code: false }
+//	    params:
+//	      - { name: prefix, default: Handle }
+type SavedQueriesConfig struct {
+	Queries map[string]SavedQuery `yaml:"queries"`
+}
+
+// SavedQuery is a parameterized CozoScript query or MCP tool call.
+// Placeholders are written $param. In a script each is replaced by a
+// quoted literal (or the number or boolean for typed params), also inside
+// string literals, so build strings with concat: concat("^", $prefix). In
+// tool args a value that is exactly "$param" takes the typed value and
+// other strings have the placeholder replaced by the text.
+type SavedQuery struct {
+	Description string            `yaml:"description,omitempty"`
+	Script      string            `yaml:"script,omitempty"` // CozoScript; exclusive with Tool
+	Tool        string            `yaml:"tool,omitempty"`   // MCP tool to call, e.g. cie_find_function
+	Args        map[string]any    `yaml:"args,omitempty"`   // arguments of Tool
+	Params      []SavedQueryParam `yaml:"params,omitempty"`
+	Expose      bool              `yaml:"expose,omitempty"` // register as MCP tool cie_query_<name>
+}
+
+// SavedQueryParam declares a placeholder of a saved query.
+type SavedQueryParam struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Type        string `yaml:"type,omitempty"` // string (default), int, float or bool
+	Default     string `yaml:"default,omitempty"`
+	Required    bool   `yaml:"required,omitempty"`
+}
+
+// savedQueriesPath returns the queries.yaml beside the project config at
+// configPath, discovering the config when configPath is empty.
+func savedQueriesPath(configPath string) (string, error) {
+	if configPath == "" {
+		var err error
+		if configPath, err = findConfigFile(); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(filepath.Dir(configPath), savedQueriesFile), nil
+}
+
+// LoadSavedQueries reads and validates a queries file. A missing file
+// means no saved queries.
+func LoadSavedQueries(path string) (map[string]SavedQuery, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: Path is the project's .cie directory
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.NewConfigError(
+			"Cannot read saved queries",
+			fmt.Sprintf("Failed to read %s", path),
+			"Check file permissions",
+			err,
+		)
+	}
+	var file SavedQueriesConfig
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, errors.NewConfigError(
+			"Invalid saved queries format",
+			"YAML parsing failed - the queries file contains syntax errors",
+			fmt.Sprintf("Edit %s to fix syntax errors", path),
+			err,
+		)
+	}
+	for name, q := range file.Queries {
+		if err := q.validate(name); err != nil {
+			return nil, errors.NewConfigError(
+				"Invalid saved query",
+				err.Error(),
+				fmt.Sprintf("Fix query %q in %s", name, path),
+				nil,
+			)
+		}
+	}
+	return file.Queries, nil
+}
+
+// validate checks a saved query's shape and that every placeholder it uses
+// is declared.
+func (q SavedQuery) validate(name string) error {
+	if !savedQueryNamePattern.MatchString(name) {
+		return fmt.Errorf("name %q must be lowercase letters, digits, '-' or '_'", name)
+	}
+	if (q.Script == "") == (q.Tool == "") {
+		return fmt.Errorf("query %q needs exactly one of script or tool", name)
+	}
+	if q.Tool != "" {
+		if _, ok := toolHandlers[q.Tool]; !ok {
+			return fmt.Errorf("query %q calls unknown tool %q", name, q.Tool)
+		}
+	}
+	declared := map[string]bool{}
+	for _, p := range q.Params {
+		if !savedQueryParamPattern.MatchString(p.Name) {
+			return fmt.Errorf("query %q: invalid param name %q", name, p.Name)
+		}
+		if declared[p.Name] {
+			return fmt.Errorf("query %q declares param %q twice", name, p.Name)
+		}
+		switch p.Type {
+		case "", "string", "int", "float", "bool":
+		default:
+			return fmt.Errorf("query %q: param %q has unknown type %q (use string, int, float or bool)", name, p.Name, p.Type)
+		}
+		declared[p.Name] = true
+	}
+	for _, used := range q.placeholders() {
+		if !declared[used] {
+			return fmt.Errorf("query %q uses $%s, which is not declared under params", name, used)
+		}
+	}
+	return nil
+}
+
+// placeholders lists the $params a query's script or args refer to.
+func (q SavedQuery) placeholders() []string {
+	var texts []string
+	if q.Script != "" {
+		// CozoDB binds $params only outside string literals.
+		texts = append(texts, storage.StripLiterals(q.Script))
+	}
+	var walk func(v any)
+	walk = func(v any) {
+		switch x := v.(type) {
+		case string:
+			texts = append(texts, x)
+		case []any:
+			for _, e := range x {
+				walk(e)
+			}
+		}
+	}
+	for _, v := range q.Args {
+		walk(v)
+	}
+	seen := map[string]bool{}
+	var names []string
+	for _, text := range texts {
+		for _, m := range savedQueryPlaceholder.FindAllStringSubmatch(text, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				names = append(names, m[1])
+			}
+		}
+	}
+	return names
+}
+
+// bind checks values against the declared params, fills in defaults and
+// converts them to their types.
+func (q SavedQuery) bind(values map[string]string) (map[string]any, error) {
+	bound := make(map[string]any, len(q.Params))
+	known := map[string]bool{}
+	for _, p := range q.Params {
+		known[p.Name] = true
+		raw, ok := values[p.Name]
+		if !ok {
+			if p.Required {
+				return nil, fmt.Errorf("missing required param %q", p.Name)
+			}
+			raw = p.Default
+		}
+		var v any
+		var err error
+		switch p.Type {
+		case "int":
+			v, err = strconv.Atoi(raw)
+		case "float":
+			v, err = strconv.ParseFloat(raw, 64)
+		case "bool":
+			v, err = strconv.ParseBool(raw)
+		default:
+			v = raw
+		}
+		if err != nil {
+			return nil, fmt.Errorf("param %q: %q is not a valid %s", p.Name, raw, p.Type)
+		}
+		bound[p.Name] = v
+	}
+	for name := range values {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown param %q", name)
+		}
+	}
+	return bound, nil
+}
+
+// scriptParams returns the bound values q.Script refers to, for CozoDB to
+// bind to its $placeholders. Values never become CozoScript text.
+func (q SavedQuery) scriptParams(bound map[string]any) map[string]any {
+	params := make(map[string]any)
+	for _, m := range savedQueryPlaceholder.FindAllStringSubmatch(storage.StripLiterals(q.Script), -1) {
+		if v, ok := bound[m[1]]; ok {
+			params[m[1]] = v
+		}
+	}
+	return params
+}
+
+// expandArgs returns q.Args with placeholders replaced.
+func (q SavedQuery) expandArgs(bound map[string]any) map[string]any {
+	var expand func(v any) any
+	expand = func(v any) any {
+		switch x := v.(type) {
+		case string:
+			if m := savedQueryPlaceholder.FindStringSubmatch(x); m != nil && m[0] == x {
+				if b, ok := bound[m[1]]; ok {
+					return b
+				}
+			}
+			return savedQueryPlaceholder.ReplaceAllStringFunc(x, func(m string) string {
+				if b, ok := bound[m[1:]]; ok {
+					return fmt.Sprint(b)
+				}
+				return m
+			})
+		case []any:
+			out := make([]any, len(x))
+			for i, e := range x {
+				out[i] = expand(e)
+			}
+			return out
+		}
+		return v
+	}
+	args := make(map[string]any, len(q.Args))
+	for k, v := range q.Args {
+		args[k] = expand(v)
+	}
+	return args
+}
+
+// savedQueryToolName returns the MCP tool name of saved query name.
+func savedQueryToolName(name string) string {
+	return savedQueryToolPrefix + strings.ReplaceAll(name, "-", "_")
+}
+
+// savedQueryTools returns the MCP tool definitions of the exposed queries.
+func savedQueryTools(queries map[string]SavedQuery) []mcpTool {
+	names := make([]string, 0, len(queries))
+	for name, q := range queries {
+		if q.Expose {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	list := make([]mcpTool, 0, len(names))
+	for _, name := range names {
+		q := queries[name]
+		props := map[string]any{}
+		required := []string{}
+		for _, p := range q.Params {
+			typ := map[string]string{"int": "integer", "float": "number", "bool": "boolean"}[p.Type]
+			if typ == "" {
+				typ = "string"
+			}
+			prop := map[string]any{"type": typ, "description": p.Description}
+			if p.Default != "" {
+				prop["description"] = strings.TrimSpace(fmt.Sprintf("%s (default: %s)", p.Description, p.Default))
+			}
+			props[p.Name] = prop
+			if p.Required {
+				required = append(required, p.Name)
+			}
+		}
+		desc := q.Description
+		if desc == "" {
+			desc = fmt.Sprintf("Saved query %q from .cie/%s.", name, savedQueriesFile)
+		}
+		list = append(list, mcpTool{
+			Name:        savedQueryToolName(name),
+			Description: desc,
+			InputSchema: map[string]any{"type": "object", "properties": props, "required": required},
+		})
+	}
+	return list
+}
+
+// savedQueryHandler returns the handler of an exposed saved query tool.
+func (s *mcpServer) savedQueryHandler(toolName string) (toolHandler, bool) {
+	for name, q := range s.savedQueries {
+		if q.Expose && savedQueryToolName(name) == toolName {
+			return func(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+				return s.runSavedQuery(ctx, q, args)
+			}, true
+		}
+	}
+	return nil, false
+}
+
+// runSavedQuery runs a saved query from MCP. Scripts go through the
+// cie_raw_query guardrails; tool queries call the tool's handler.
+func (s *mcpServer) runSavedQuery(ctx context.Context, q SavedQuery, args map[string]any) (*tools.ToolResult, error) {
+	values := make(map[string]string, len(args))
+	for k, v := range args {
+		if k == "project" {
+			continue
+		}
+		values[k] = fmt.Sprint(v)
+	}
+	bound, err := q.bind(values)
+	if err != nil {
+		return tools.NewInputError("Error: " + err.Error()), nil
+	}
+	if q.Tool != "" {
+		return toolHandlers[q.Tool](ctx, s, q.expandArgs(bound))
+	}
+	policy := s.rawQuery
+	return tools.RawQuery(ctx, s.client, tools.RawQueryArgs{Script: q.Script, Params: q.scriptParams(bound), Policy: &policy})
+}
+
+// loadServerSavedQueries loads the saved queries of the project at
+// configPath for an MCP server. Problems are reported and leave the server
+// without saved queries.
+func loadServerSavedQueries(configPath string) map[string]SavedQuery {
+	path, err := savedQueriesPath(configPath)
+	if err != nil {
+		return nil
+	}
+	queries, err := LoadSavedQueries(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  Warning: saved queries ignored: %v\n", err)
+		return nil
+	}
+	if n := len(savedQueryTools(queries)); n > 0 {
+		fmt.Fprintf(os.Stderr, "  Saved queries: %d exposed as tools\n", n)
+	}
+	return queries
+}
+
+// loadCLISavedQueries loads the project's saved queries for cie query.
+func loadCLISavedQueries(configPath string, globals GlobalFlags) map[string]SavedQuery {
+	path, err := savedQueriesPath(configPath)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+	queries, err := LoadSavedQueries(path)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+	return queries
+}
+
+// printSavedQueries lists saved queries with their parameters.
+func printSavedQueries(queries map[string]SavedQuery) {
+	if len(queries) == 0 {
+		fmt.Printf("No saved queries. Add them to .cie/%s.\n", savedQueriesFile)
+		return
+	}
+	names := make([]string, 0, len(queries))
+	for name := range queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		q := queries[name]
+		kind := "script"
+		if q.Tool != "" {
+			kind = q.Tool
+		}
+		fmt.Printf("%s (%s)", name, kind)
+		if q.Expose {
+			fmt.Printf(" [MCP: %s]", savedQueryToolName(name))
+		}
+		fmt.Println()
+		if q.Description != "" {
+			fmt.Printf("  %s\n", q.Description)
+		}
+		for _, p := range q.Params {
+			line := "  --param " + p.Name + "=<" + cmp.Or(p.Type, "string") + ">"
+			if p.Required {
+				line += " (required)"
+			} else if p.Default != "" {
+				line += " (default " + p.Default + ")"
+			}
+			fmt.Println(line)
+		}
+	}
+}
+
+// bindCLISavedQuery looks up a saved query and binds its --param values.
+func bindCLISavedQuery(queries map[string]SavedQuery, name string, params []string, globals GlobalFlags) (*SavedQuery, map[string]any) {
+	q, ok := queries[name]
+	if !ok {
+		names := make([]string, 0, len(queries))
+		for n := range queries {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		errors.FatalError(errors.NewInputError(
+			fmt.Sprintf("Unknown saved query %q", name),
+			fmt.Sprintf("Saved queries: %s", cmp.Or(strings.Join(names, ", "), "none")),
+			fmt.Sprintf("Run 'cie query --list-saved', or add %q to .cie/%s", name, savedQueriesFile),
+		), globals.JSON)
+	}
+	values := make(map[string]string, len(params))
+	for _, p := range params {
+		k, v, found := strings.Cut(p, "=")
+		if !found || k == "" {
+			errors.FatalError(errors.NewInputError(
+				"Invalid --param",
+				fmt.Sprintf("%q is not key=value", p),
+				"Pass parameters as --param name=value",
+			), globals.JSON)
+		}
+		values[k] = v
+	}
+	bound, err := q.bind(values)
+	if err != nil {
+		errors.FatalError(errors.NewInputError(
+			fmt.Sprintf("Cannot run saved query %q", name),
+			err.Error(),
+			"Run 'cie query --list-saved' to see its parameters",
+		), globals.JSON)
+	}
+	return &q, bound
+}
+
+// runSavedToolQuery runs a saved tool query against the local index and
+// prints the tool's output.
+func runSavedToolQuery(ctx context.Context, cfg *Config, backend *storage.EmbeddedBackend, q SavedQuery, bound map[string]any, globals GlobalFlags) {
+	server, err := newCLIToolServer(cfg, backend)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+	args := q.expandArgs(bound)
+	normalizePathArgs(q.Tool, args)
+	result, err := toolHandlers[q.Tool](ctx, server, args)
+	if err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Saved query failed",
+			fmt.Sprintf("%s returned an error: %v", q.Tool, err),
+			"Check the query's arguments in .cie/"+savedQueriesFile,
+			err,
+		), globals.JSON)
+	}
+	printToolResult(q.Tool, result, globals)
+}
+
+// newCLIToolServer returns an MCP server over a local backend, so CLI
+// commands can run tool handlers exactly as MCP clients do.
+func newCLIToolServer(cfg *Config, backend *storage.EmbeddedBackend) (*mcpServer, error) {
+	provider, err := newLLMProvider(cfg.LLM)
+	if err != nil {
+		return nil, llmConfigError(err)
+	}
+	server := &mcpServer{
+		client:         tools.NewEmbeddedQuerier(backend),
+		projectID:      cfg.ProjectID,
+		embeddingURL:   cfg.Embedding.BaseURL,
+		embeddingModel: cfg.Embedding.Model,
+		customRoles:    cfg.Roles.Custom,
+		rawQuery:       cfg.MCP.RawQuery.Policy(),
+		llm:            provider,
+		llmMaxTokens:   llmMaxTokens(cfg.LLM),
+		contentHash:    ingestion.ContentHashMode(cfg.Indexing.ContentHash),
+	}
+	if gitExec, err := tools.NewGitExecutor("."); err == nil {
+		server.gitExecutor = gitExec
+	}
+	return server, nil
+}
+
+// printToolResult prints a tool result run from the CLI, exiting with
+// status 1 when the tool reported an error.
+func printToolResult(tool string, result *tools.ToolResult, globals GlobalFlags) {
+	if globals.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(map[string]any{"tool": tool, "text": result.Text, "is_error": result.IsError})
+	} else {
+		fmt.Println(result.Text)
+	}
+	if result.IsError {
+		os.Exit(1)
+	}
+}
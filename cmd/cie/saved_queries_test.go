@@ -0,0 +1,174 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kraklabs/cie/internal/errors"
+)
+
+func writeSavedQueries(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), savedQueriesFile)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadSavedQueries(t *testing.T) {
+	queries, err := LoadSavedQueries(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil || queries != nil {
+		t.Fatalf("missing file: %v, %v", queries, err)
+	}
+
+	path := writeSavedQueries(t, `
+queries:
+  callers-of:
+    script: "?[n] := *cie_function { name: n }, n == $name"
+    params:
+      - { name: name, required: true }
+  handlers:
+    tool: cie_find_function
+    args: { name: "$prefix" }
+    params:
+      - { name: prefix, default: Handle }
+`)
+	queries, err = LoadSavedQueries(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 2 || queries["handlers"].Tool != "cie_find_function" {
+		t.Errorf("queries = %+v", queries)
+	}
+
+	tests := []struct {
+		name, yaml, want string
+	}{
+		{"undeclared", `{queries: {q: {script: "?[x] := x = $x"}}}`, "not declared"},
+		{"both", `{queries: {q: {script: "?[x] := x = 1", tool: cie_schema}}}`, "exactly one"},
+		{"neither", `{queries: {q: {description: nothing}}}`, "exactly one"},
+		{"unknown tool", `{queries: {q: {tool: cie_nope}}}`, "unknown tool"},
+		{"bad name", `{queries: {"Bad Name": {script: "?[x] := x = 1"}}}`, "lowercase"},
+		{"bad type", `{queries: {q: {script: "?[x] := x = $n", params: [{name: n, type: date}]}}}`, "unknown type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadSavedQueries(writeSavedQueries(t, tt.yaml))
+			var uerr *errors.UserError
+			if !stderrors.As(err, &uerr) || !strings.Contains(uerr.Cause, tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSavedQuery_BindAndExpand(t *testing.T) {
+	q := SavedQuery{
+		Script: `?[n] := *cie_function { name: n, start_line: l }, l > $min, regex_matches(n, $pat)`,
+		Params: []SavedQueryParam{
+			{Name: "pat", Required: true},
+			{Name: "min", Type: "int", Default: "10"},
+		},
+	}
+	bound, err := q.bind(map[string]string{"pat": `^Handle"x`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := q.scriptParams(bound)
+	if len(params) != 2 || params["min"] != 10 || params["pat"] != `^Handle"x` {
+		t.Errorf("scriptParams = %v", params)
+	}
+
+	if _, err := q.bind(map[string]string{}); err == nil || !strings.Contains(err.Error(), "required") {
+		t.Errorf("missing required: %v", err)
+	}
+	if _, err := q.bind(map[string]string{"pat": "x", "min": "many"}); err == nil {
+		t.Error("expected type error for min=many")
+	}
+	if _, err := q.bind(map[string]string{"pat": "x", "other": "1"}); err == nil || !strings.Contains(err.Error(), "unknown param") {
+		t.Errorf("unknown param: %v", err)
+	}
+
+	tool := SavedQuery{
+		Tool: "cie_find_function",
+		Args: map[string]any{"name": "$prefix", "path_pattern": "internal/$pkg/", "limit": "$n", "exact": false},
+		Params: []SavedQueryParam{
+			{Name: "prefix"}, {Name: "pkg"}, {Name: "n", Type: "int", Default: "5"},
+		},
+	}
+	bound, err = tool.bind(map[string]string{"prefix": "Serve", "pkg": "http"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := tool.expandArgs(bound)
+	if args["name"] != "Serve" || args["path_pattern"] != "internal/http/" || args["limit"] != 5 || args["exact"] != false {
+		t.Errorf("expandArgs = %#v", args)
+	}
+}
+
+func TestMCPServer_SavedQueryTools(t *testing.T) {
+	q := &recordingQuerier{headers: []string{"n"}, rows: [][]any{{"HandleLogin"}}}
+	server := &mcpServer{client: q, savedQueries: map[string]SavedQuery{
+		"login-handlers": {
+			Description: "Login handlers",
+			Script:      `?[n] := *cie_function { name: n }, starts_with(n, $prefix)`,
+			Params:      []SavedQueryParam{{Name: "prefix", Required: true}},
+			Expose:      true,
+		},
+		"private": {Script: `?[n] := *cie_function { name: n }`},
+	}}
+	c := newMCPTestClient(t, server)
+	c.Initialize()
+
+	var found bool
+	for _, tool := range c.ListTools() {
+		if tool.Name == "cie_query_private" {
+			t.Error("unexposed query listed as a tool")
+		}
+		if tool.Name == "cie_query_login_handlers" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("cie_query_login_handlers not listed")
+	}
+
+	res := c.CallTool("cie_query_login_handlers", map[string]any{"prefix": "Handle"})
+	if res.IsError {
+		t.Fatalf("call failed: %s", toolText(res))
+	}
+	scripts := q.Scripts()
+	if len(scripts) == 0 || !strings.Contains(scripts[len(scripts)-1], `starts_with(n, $prefix)`) {
+		t.Errorf("scripts = %q", scripts)
+	}
+	if len(q.params) != 1 || q.params[0]["prefix"] != "Handle" {
+		t.Errorf("params = %v", q.params)
+	}
+
+	res = c.CallTool("cie_query_login_handlers", map[string]any{})
+	if !res.IsError || !strings.Contains(toolText(res), "required") {
+		t.Errorf("missing param: %s", toolText(res))
+	}
+}
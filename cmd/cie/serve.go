@@ -26,13 +26,21 @@ import (
 
 	cozo "github.com/kraklabs/cie/pkg/cozodb"
 	"github.com/kraklabs/cie/pkg/ingestion"
+	"github.com/kraklabs/cie/pkg/storage"
 )
 
+// sharedDBDir is the directory (under the data dir) holding the database that
+// serves every project when the server runs with --shared.
+const sharedDBDir = "shared"
+
 // serveFlags holds configuration for the serve command.
 type serveFlags struct {
 	port      string
 	projectID string
 	repoPath  string
+	shared    bool
+	reindex   string
+	ui        bool
 }
 
 // indexJob represents an async indexing job.
@@ -64,6 +72,7 @@ type cieServer struct {
 	projectID string
 	dataDir   string
 	repoPath  string
+	shared    bool // one database, one namespace per project
 	db        cozo.CozoDB
 	hasDB     bool
 	dbMu      sync.RWMutex
@@ -94,6 +103,15 @@ func runServe(args []string, cfg *Config) int {
 				f.repoPath = args[i+1]
 				i++
 			}
+		case "--shared":
+			f.shared = true
+		case "--ui":
+			f.ui = true
+		case "--reindex":
+			if i+1 < len(args) {
+				f.reindex = args[i+1]
+				i++
+			}
 		case "--help", "-h":
 			printServeUsage()
 			return 0
@@ -113,6 +131,20 @@ func runServe(args []string, cfg *Config) int {
 	if f.repoPath == "" {
 		f.repoPath = getEnv("CIE_REPO_PATH", "/repo")
 	}
+	if !f.shared {
+		f.shared = getEnv("CIE_SERVE_SHARED", "") == "true"
+	}
+	if !f.ui {
+		f.ui = getEnv("CIE_SERVE_UI", "") == "true"
+	}
+	if f.reindex == "" {
+		f.reindex = getEnv("CIE_SERVE_REINDEX", cfg.Reindex)
+	}
+	reindexEvery, err := parseReindexSchedule(f.reindex)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
 
 	if f.projectID == "" {
 		fmt.Fprintln(os.Stderr, "Error: project_id is required. Set CIE_PROJECT_ID, use --project-id, or set it in .cie/project.yaml")
@@ -136,15 +168,15 @@ func runServe(args []string, cfg *Config) int {
 		return 1
 	}
 
-	dbPath := filepath.Join(dataDir, f.projectID)
-
 	// Create server instance
 	srv := &cieServer{
 		projectID: f.projectID,
 		dataDir:   dataDir,
 		repoPath:  f.repoPath,
+		shared:    f.shared,
 		jobs:      make(map[string]*indexJob),
 	}
+	dbPath := srv.dbPath(f.projectID)
 
 	// Try to open existing database (don't fail if it doesn't exist)
 	if _, err := os.Stat(dbPath); err == nil {
@@ -183,6 +215,19 @@ func runServe(args []string, cfg *Config) int {
 	// Status endpoint
 	mux.HandleFunc("/v1/status", srv.handleStatus)
 
+	// Browse endpoints - structured views used by the web UI
+	browse := &browseAPI{
+		client:         srv.browseClient,
+		embeddingURL:   getEnv("OLLAMA_HOST", "http://localhost:11434"),
+		embeddingModel: getEnv("OLLAMA_EMBED_MODEL", "nomic-embed-text"),
+	}
+	browse.register(mux)
+
+	// Web UI
+	if f.ui {
+		mux.Handle("/", uiHandler())
+	}
+
 	// Start server
 	server := &http.Server{
 		Addr:              ":" + f.port,
@@ -190,6 +235,22 @@ func runServe(args []string, cfg *Config) int {
 		ReadHeaderTimeout: 10 * time.Second,
 	}
 
+	// Scheduled incremental reindex; stopped with the server.
+	schedCtx, stopSched := context.WithCancel(context.Background())
+	defer stopSched()
+	if reindexEvery > 0 {
+		sched := &reindexScheduler{
+			interval: reindexEvery,
+			logf: func(format string, args ...any) {
+				log.Printf("[INFO] "+format, args...)
+			},
+			run: func(ctx context.Context) error {
+				return srv.runScheduledReindex(ctx, f.projectID, f.repoPath)
+			},
+		}
+		go sched.loop(schedCtx)
+	}
+
 	// Handle graceful shutdown
 	go func() {
 		sigChan := make(chan os.Signal, 1)
@@ -203,8 +264,14 @@ func runServe(args []string, cfg *Config) int {
 
 	log.Printf("CIE Server starting on http://0.0.0.0:%s", f.port)
 	log.Printf("Project: %s", f.projectID)
+	if f.shared {
+		log.Printf("Mode: shared (all projects in %s)", dbPath)
+	}
 	log.Printf("Data dir: %s", dataDir)
 	log.Printf("Repo path: %s", f.repoPath)
+	if reindexEvery > 0 {
+		log.Printf("Reindex: every %s", reindexEvery)
+	}
 	log.Println("")
 	log.Println("API Endpoints:")
 	log.Println("  GET  /health           - Health check")
@@ -213,7 +280,13 @@ func runServe(args []string, cfg *Config) int {
 	log.Println("  GET  /v1/index/{id}    - Get indexing job status")
 	log.Println("  GET  /v1/status        - Get project status")
 	log.Println("  POST /v1/query         - Execute CozoScript query")
+	log.Println("  GET  /v1/browse/...    - Browse packages, functions and call graph")
 	log.Println("")
+	if f.ui {
+		log.Println("Web UI:")
+		log.Printf("  http://localhost:%s/", f.port)
+		log.Println("")
+	}
 	log.Println("Use this URL for MCP tools:")
 	log.Printf("  export CIE_BASE_URL=http://localhost:%s", f.port)
 	log.Println("")
@@ -226,6 +299,51 @@ func runServe(args []string, cfg *Config) int {
 	return 0
 }
 
+// dbPath returns the database directory serving a project.
+func (s *cieServer) dbPath(projectID string) string {
+	if s.shared {
+		return filepath.Join(s.dataDir, sharedDBDir)
+	}
+	return filepath.Join(s.dataDir, projectID)
+}
+
+// scopeScript confines a script to a project's namespace in shared mode.
+func (s *cieServer) scopeScript(projectID, script string) string {
+	if !s.shared {
+		return script
+	}
+	if projectID == "" {
+		projectID = s.projectID
+	}
+	return storage.QualifyRelations(script, storage.NormalizeNamespace(projectID))
+}
+
+// tenantScript scopes a script sent by a client like scopeScript and, in
+// shared mode, rejects scripts that reach outside the project's namespace.
+func (s *cieServer) tenantScript(projectID, script string) (string, error) {
+	scoped := s.scopeScript(projectID, script)
+	if !s.shared {
+		return scoped, nil
+	}
+	if projectID == "" {
+		projectID = s.projectID
+	}
+	if err := storage.CheckNamespaceScript(scoped, storage.NormalizeNamespace(projectID)); err != nil {
+		return "", err
+	}
+	return scoped, nil
+}
+
+// runTenant runs a client script produced by tenantScript. Shared mode runs
+// it read-only: tenants must not write to the database other projects share.
+// The caller holds dbMu.
+func (s *cieServer) runTenant(script string, params map[string]any) (cozo.NamedRows, error) {
+	if s.shared {
+		return s.db.RunReadOnly(script, params)
+	}
+	return s.db.Run(script, params)
+}
+
 func (s *cieServer) handleHealth(w http.ResponseWriter, _ *http.Request) {
 	s.dbMu.RLock()
 	hasDB := s.hasDB
@@ -270,8 +388,9 @@ func (s *cieServer) handleQuery(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify project ID matches (optional, for compatibility)
-	if req.ProjectID != "" && req.ProjectID != s.projectID {
+	// Verify project ID matches (optional, for compatibility).
+	// A shared server routes by project_id instead.
+	if !s.shared && req.ProjectID != "" && req.ProjectID != s.projectID {
 		http.Error(w, fmt.Sprintf("project_id mismatch: server is %s, request is %s", s.projectID, req.ProjectID), http.StatusBadRequest)
 		return
 	}
@@ -285,13 +404,19 @@ func (s *cieServer) handleQuery(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), timeout)
 	defer cancel()
 
+	script, err := s.tenantScript(req.ProjectID, req.Script)
+	if err != nil {
+		http.Error(w, "query rejected: "+err.Error(), http.StatusForbidden)
+		return
+	}
+
 	// Run query in a goroutine to respect context cancellation
 	resultCh := make(chan cozo.NamedRows, 1)
 	errCh := make(chan error, 1)
 
 	go func() {
 		s.dbMu.RLock()
-		result, err := s.db.Run(req.Script, req.Params)
+		result, err := s.runTenant(script, req.Params)
 		s.dbMu.RUnlock()
 		if err != nil {
 			errCh <- err
@@ -401,33 +526,17 @@ func (s *cieServer) handleIndex(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if there's already a running job
-	s.jobsMu.RLock()
-	for _, job := range s.jobs {
-		if job.Status == "running" {
-			s.jobsMu.RUnlock()
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusConflict)
-			_ = json.NewEncoder(w).Encode(map[string]any{
-				"error":  "indexing already in progress",
-				"job_id": job.ID,
-			})
-			return
-		}
-	}
-	s.jobsMu.RUnlock()
-
-	// Create job
-	jobID := fmt.Sprintf("idx-%d", time.Now().UnixNano())
-	job := &indexJob{
-		ID:        jobID,
-		Status:    "running",
-		Phase:     "starting",
-		StartedAt: time.Now(),
+	job, running := s.startJob()
+	if running != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"error":  "indexing already in progress",
+			"job_id": running.ID,
+		})
+		return
 	}
-
-	s.jobsMu.Lock()
-	s.jobs[jobID] = job
-	s.jobsMu.Unlock()
+	jobID := job.ID
 
 	// Run indexing in background
 	go s.runIndexJob(job, req.ProjectID, req.RepoPath, req.Full)
@@ -441,33 +550,57 @@ func (s *cieServer) handleIndex(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// startJob registers a new running index job, or returns the job that is
+// already running instead.
+func (s *cieServer) startJob() (job, running *indexJob) {
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+	for _, j := range s.jobs {
+		if j.Status == "running" {
+			return nil, j
+		}
+	}
+	job = &indexJob{
+		ID:        fmt.Sprintf("idx-%d", time.Now().UnixNano()),
+		Status:    "running",
+		Phase:     "starting",
+		StartedAt: time.Now(),
+	}
+	s.jobs[job.ID] = job
+	return job, nil
+}
+
 func (s *cieServer) runIndexJob(job *indexJob, projectID, repoPath string, full bool) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
 	}))
 
-	// Get embedding config from environment (model name used for setup below)
+	setServeEmbeddingEnv()
 
-	// Set environment for embedding
-	_ = os.Setenv("OLLAMA_BASE_URL", getEnv("OLLAMA_HOST", "http://localhost:11434"))
-	_ = os.Setenv("OLLAMA_EMBED_MODEL", getEnv("OLLAMA_EMBED_MODEL", "nomic-embed-text"))
-
-	dbPath := filepath.Join(s.dataDir, projectID)
-
-	// Close existing database to release the lock before pipeline opens it
-	s.dbMu.Lock()
-	if s.hasDB {
-		s.db.Close()
-		s.hasDB = false
-	}
-	s.dbMu.Unlock()
+	dbPath := s.dbPath(projectID)
 
-	// If full reindex, remove existing data
-	if full {
-		if err := os.RemoveAll(dbPath); err != nil && !os.IsNotExist(err) {
-			s.updateJobError(job, fmt.Sprintf("failed to remove existing data: %v", err))
-			return
+	// A full rebuild of a dedicated database is written beside it and
+	// swapped in at the end, so queries are served from the old index
+	// until then and a failed run leaves it untouched.
+	staged := full && !s.shared
+	buildPath := dbPath
+	if staged {
+		buildPath = storage.StagingDir(dbPath)
+		_ = os.RemoveAll(buildPath) // leftover from an interrupted run
+	} else {
+		// Close existing database to release the lock before pipeline opens it.
+		// In shared mode a full reindex only drops this project's relations.
+		s.dbMu.Lock()
+		if s.hasDB {
+			if full {
+				for _, script := range storage.RebuildDropScripts(storage.NormalizeNamespace(projectID)) {
+					_, _ = s.db.Run(script, nil)
+				}
+			}
+			s.db.Close()
+			s.hasDB = false
 		}
+		s.dbMu.Unlock()
 	}
 
 	// Create checkpoint directory
@@ -477,10 +610,67 @@ func (s *cieServer) runIndexJob(job *indexJob, projectID, repoPath string, full
 		return
 	}
 
+	config := s.ingestionConfig(projectID, repoPath, checkpointDir)
+	config.IngestionConfig.LocalDataDir = buildPath // Use full path including project ID
+	config.IngestionConfig.LocalEngine = "rocksdb"
+
+	pipeline, err := ingestion.NewLocalPipeline(config, logger)
+	if err != nil {
+		s.updateJobError(job, fmt.Sprintf("failed to create pipeline: %v", err))
+		return
+	}
+	defer func() { _ = pipeline.Close() }()
+
+	// Run indexing
+	if err := s.runJobPipeline(context.Background(), job, pipeline); err != nil {
+		if staged {
+			_ = pipeline.Close()
+			_ = os.RemoveAll(buildPath)
+		}
+		return
+	}
+
+	// Close pipeline before reopening DB (to release the lock)
+	_ = pipeline.Close()
+
+	// Reopen database
+	s.dbMu.Lock()
+	if staged {
+		if s.hasDB {
+			s.db.Close()
+			s.hasDB = false
+		}
+		if err := storage.CarryOverUserData(dbPath, buildPath, "rocksdb", 0); err != nil {
+			_ = os.RemoveAll(buildPath)
+			s.updateJobError(job, fmt.Sprintf("failed to carry notes and collections over to rebuilt index: %v", err))
+		} else if err := storage.SwapDataDir(dbPath, buildPath); err != nil {
+			// The old index is still in place; reopen it below.
+			s.updateJobError(job, fmt.Sprintf("failed to install rebuilt index: %v", err))
+		}
+	}
+	db, err := cozo.New("rocksdb", dbPath, nil)
+	if err != nil {
+		log.Printf("[ERROR] Failed to reopen database after indexing: %v", err)
+	} else {
+		s.db = db
+		s.hasDB = true
+		log.Printf("[INFO] Database reopened successfully")
+	}
+	s.dbMu.Unlock()
+}
+
+// setServeEmbeddingEnv points the embedding provider at OLLAMA_HOST.
+func setServeEmbeddingEnv() {
+	_ = os.Setenv("OLLAMA_BASE_URL", getEnv("OLLAMA_HOST", "http://localhost:11434"))
+	_ = os.Setenv("OLLAMA_EMBED_MODEL", getEnv("OLLAMA_EMBED_MODEL", "nomic-embed-text"))
+}
+
+// ingestionConfig returns the pipeline settings used for index jobs.
+func (s *cieServer) ingestionConfig(projectID, repoPath, checkpointDir string) ingestion.Config {
 	// Default excludes
 	defaults := ingestion.DefaultConfig()
 
-	config := ingestion.Config{
+	return ingestion.Config{
 		ProjectID: projectID,
 		RepoSource: ingestion.RepoSource{
 			Type:  "local_path",
@@ -494,23 +684,18 @@ func (s *cieServer) runIndexJob(job *indexJob, projectID, repoPath string, full
 			MaxFileSizeBytes:     1048576,
 			CheckpointPath:       checkpointDir,
 			ExcludeGlobs:         defaults.ExcludeGlobs,
-			LocalDataDir:         dbPath, // Use full path including project ID
-			LocalEngine:          "rocksdb",
+			LocalNamespace:       s.namespaceFor(projectID),
 			Concurrency: ingestion.ConcurrencyConfig{
 				ParseWorkers: 4,
 				EmbedWorkers: 8,
 			},
 		},
 	}
+}
 
-	pipeline, err := ingestion.NewLocalPipeline(config, logger)
-	if err != nil {
-		s.updateJobError(job, fmt.Sprintf("failed to create pipeline: %v", err))
-		return
-	}
-	defer func() { _ = pipeline.Close() }()
-
-	// Set up progress reporting
+// runJobPipeline runs pipeline with progress reported on job and records
+// the outcome.
+func (s *cieServer) runJobPipeline(ctx context.Context, job *indexJob, pipeline *ingestion.LocalPipeline) error {
 	pipeline.SetProgressCallback(func(current, total int64, phase string) {
 		s.jobsMu.Lock()
 		job.Phase = phase
@@ -518,15 +703,12 @@ func (s *cieServer) runIndexJob(job *indexJob, projectID, repoPath string, full
 		s.jobsMu.Unlock()
 	})
 
-	// Run indexing
-	ctx := context.Background()
 	result, err := pipeline.Run(ctx)
 	if err != nil {
 		s.updateJobError(job, fmt.Sprintf("indexing failed: %v", err))
-		return
+		return err
 	}
 
-	// Update job with result
 	now := time.Now()
 	s.jobsMu.Lock()
 	job.Status = "completed"
@@ -539,21 +721,45 @@ func (s *cieServer) runIndexJob(job *indexJob, projectID, repoPath string, full
 		Duration:           result.TotalDuration.String(),
 	}
 	s.jobsMu.Unlock()
+	return nil
+}
 
-	// Close pipeline before reopening DB (to release the lock)
-	_ = pipeline.Close()
+// runScheduledReindex runs an incremental index job through the open
+// database. Unlike runIndexJob it keeps the database open, so /v1/query is
+// served from the previous index until the run commits. The job shows up in
+// /v1/index/{id} like one started over HTTP.
+func (s *cieServer) runScheduledReindex(ctx context.Context, projectID, repoPath string) error {
+	s.dbMu.RLock()
+	defer s.dbMu.RUnlock()
+	if !s.hasDB {
+		return fmt.Errorf("no index yet; run POST /v1/index first")
+	}
 
-	// Reopen database
-	s.dbMu.Lock()
-	db, err := cozo.New("rocksdb", dbPath, nil)
+	job, running := s.startJob()
+	if running != nil {
+		return fmt.Errorf("index job %s is still running", running.ID)
+	}
+
+	checkpointDir := filepath.Join(s.dataDir, projectID+"-checkpoints")
+	if err := os.MkdirAll(checkpointDir, 0750); err != nil {
+		s.updateJobError(job, fmt.Sprintf("failed to create checkpoint dir: %v", err))
+		return err
+	}
+	setServeEmbeddingEnv()
+	config := s.ingestionConfig(projectID, repoPath, checkpointDir)
+	backend := storage.NewEmbeddedBackendFromDB(&s.db, storage.EmbeddedConfig{
+		Namespace:           config.IngestionConfig.LocalNamespace,
+		EmbeddingDimensions: config.IngestionConfig.EmbeddingDimensions,
+	})
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	pipeline, err := ingestion.NewLocalPipelineWithBackend(config, backend, logger)
 	if err != nil {
-		log.Printf("[ERROR] Failed to reopen database after indexing: %v", err)
-	} else {
-		s.db = db
-		s.hasDB = true
-		log.Printf("[INFO] Database reopened successfully")
+		s.updateJobError(job, fmt.Sprintf("failed to create pipeline: %v", err))
+		return err
 	}
-	s.dbMu.Unlock()
+	defer func() { _ = pipeline.Close() }()
+
+	return s.runJobPipeline(ctx, job, pipeline)
 }
 
 func (s *cieServer) updateJobError(job *indexJob, errMsg string) {
@@ -605,8 +811,13 @@ func (s *cieServer) handleStatus(w http.ResponseWriter, r *http.Request) {
 	hasDB := s.hasDB
 	s.dbMu.RUnlock()
 
+	projectID := s.projectID
+	if s.shared && r.URL.Query().Get("project_id") != "" {
+		projectID = r.URL.Query().Get("project_id")
+	}
+
 	status := map[string]any{
-		"project_id": s.projectID,
+		"project_id": projectID,
 		"indexed":    hasDB,
 		"data_dir":   s.dataDir,
 		"repo_path":  s.repoPath,
@@ -614,13 +825,17 @@ func (s *cieServer) handleStatus(w http.ResponseWriter, r *http.Request) {
 
 	if hasDB {
 		// Query counts
-		fileCount := s.queryCount("?[count(id)] := *cie_file{id}")
-		funcCount := s.queryCount("?[count(id)] := *cie_function{id}")
-		typeCount := s.queryCount("?[count(id)] := *cie_type{id}")
+		fileCount := s.queryCount(s.scopeScript(projectID, "?[count(id)] := *cie_file{id}"))
+		funcCount := s.queryCount(s.scopeScript(projectID, "?[count(id)] := *cie_function{id}"))
+		typeCount := s.queryCount(s.scopeScript(projectID, "?[count(id)] := *cie_type{id}"))
 
 		status["files"] = fileCount
 		status["functions"] = funcCount
 		status["types"] = typeCount
+
+		if s.shared {
+			status["projects"] = s.listProjects()
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -645,6 +860,33 @@ func (s *cieServer) queryCount(script string) int {
 	return 0
 }
 
+// namespaceFor returns the relation namespace for a project ("" unless shared).
+func (s *cieServer) namespaceFor(projectID string) string {
+	if !s.shared {
+		return ""
+	}
+	return projectID
+}
+
+// listProjects returns the project namespaces present in the shared database.
+func (s *cieServer) listProjects() []string {
+	s.dbMu.RLock()
+	defer s.dbMu.RUnlock()
+	result, err := s.db.Run("::relations", nil)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		if len(row) > 0 {
+			if name, ok := row[0].(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return storage.NamespacesFromRelations(names)
+}
+
 func printServeUsage() {
 	fmt.Println(`Usage: cie serve [options]
 
@@ -656,6 +898,13 @@ Options:
   -p, --port <port>        Port to listen on (default: 8080, or CIE_SERVE_PORT)
   --project-id <id>        Project ID (default: from .cie/project.yaml or CIE_PROJECT_ID)
   --repo-path <path>       Repository path to index (default: /repo or CIE_REPO_PATH)
+  --shared                 Serve many projects from one database; requests are
+                           routed by project_id (or CIE_SERVE_SHARED=true)
+  --reindex <schedule>     Run an incremental index in the background, e.g.
+                           "every 30m" or "hourly" (default: reindex from
+                           .cie/project.yaml, or CIE_SERVE_REINDEX)
+  --ui                     Serve a web UI at / for searching and browsing the
+                           index (or CIE_SERVE_UI=true)
   -h, --help               Show this help message
 
 Environment Variables:
@@ -663,6 +912,9 @@ Environment Variables:
   CIE_PROJECT_ID           Project identifier
   CIE_DATA_DIR             Data directory (default: ~/.cie/data)
   CIE_REPO_PATH            Repository path to index (default: /repo)
+  CIE_SERVE_SHARED         Set to "true" to enable shared multi-project mode
+  CIE_SERVE_REINDEX        Background reindex schedule (e.g. "every 30m")
+  CIE_SERVE_UI             Set to "true" to serve the web UI
   OLLAMA_HOST              Ollama URL for embeddings
   OLLAMA_EMBED_MODEL       Embedding model name
 
@@ -674,6 +926,12 @@ API Endpoints:
   GET  /v1/status          Get project status (file/function counts)
   POST /v1/query           Execute CozoScript query
   POST /v1/ensure-mounted  No-op for local (always ready)
+  GET  /v1/browse/packages          Directories with file and function counts
+  GET  /v1/browse/functions?dir=    Functions defined in a directory
+  GET  /v1/browse/search?q=         Semantic search, falling back to name match
+  GET  /v1/browse/code?id=          Source of a function
+  GET  /v1/browse/callers?id=       Direct callers of a function
+  GET  /v1/browse/callees?id=       Direct callees of a function
 
 Examples:
   # Start server with default settings
@@ -682,6 +940,15 @@ Examples:
   # Start on a specific port with project ID
   cie serve --port 9090 --project-id myproject
 
+  # Shared team server: one database, isolated per project_id
+  cie serve --shared --project-id default
+
+  # Pick up new commits every 30 minutes while serving queries
+  cie serve --reindex "every 30m"
+
+  # Let teammates explore the index in a browser
+  cie serve --ui
+
   # Use with Docker
   docker run -p 8080:8080 -v /code:/repo:ro cie serve --project-id myproject
 
@@ -0,0 +1,152 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"time"
+
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// webUI is the browser app served by `cie serve --ui`. It only talks to the
+// /v1/browse endpoints.
+//
+//go:embed webui
+var webUI embed.FS
+
+// errNoIndex reports a browse request before the first index run.
+var errNoIndex = errors.New("database not initialized, run POST /v1/index first")
+
+// browseAPI serves the /v1/browse endpoints: structured, read-only views of
+// the index for the web UI.
+type browseAPI struct {
+	// client returns the querier for a request's project.
+	client         func(projectID string) (tools.Querier, error)
+	embeddingURL   string
+	embeddingModel string
+}
+
+// register adds the browse endpoints to mux.
+func (a *browseAPI) register(mux *http.ServeMux) {
+	mux.HandleFunc("/v1/browse/packages", a.handle(func(ctx context.Context, c tools.Querier, _ *http.Request) (any, error) {
+		return tools.BrowsePackages(ctx, c)
+	}))
+	mux.HandleFunc("/v1/browse/functions", a.handle(func(ctx context.Context, c tools.Querier, r *http.Request) (any, error) {
+		return tools.BrowseFunctions(ctx, c, r.URL.Query().Get("dir"))
+	}))
+	mux.HandleFunc("/v1/browse/search", a.handle(func(ctx context.Context, c tools.Querier, r *http.Request) (any, error) {
+		refs, semantic, err := tools.BrowseSearch(ctx, c, r.URL.Query().Get("q"), a.embeddingURL, a.embeddingModel, 50)
+		return map[string]any{"semantic": semantic, "results": refs}, err
+	}))
+	mux.HandleFunc("/v1/browse/code", a.handle(func(ctx context.Context, c tools.Querier, r *http.Request) (any, error) {
+		code, err := tools.BrowseCode(ctx, c, r.URL.Query().Get("id"))
+		return map[string]string{"code": code}, err
+	}))
+	mux.HandleFunc("/v1/browse/callers", a.handle(func(ctx context.Context, c tools.Querier, r *http.Request) (any, error) {
+		return tools.BrowseCallers(ctx, c, r.URL.Query().Get("id"))
+	}))
+	mux.HandleFunc("/v1/browse/callees", a.handle(func(ctx context.Context, c tools.Querier, r *http.Request) (any, error) {
+		return tools.BrowseCallees(ctx, c, r.URL.Query().Get("id"))
+	}))
+}
+
+// handle adapts a lookup to a GET endpoint returning JSON.
+func (a *browseAPI) handle(lookup func(context.Context, tools.Querier, *http.Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		client, err := a.client(r.URL.Query().Get("project"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+		defer cancel()
+		result, err := lookup(ctx, client, r)
+		if err != nil {
+			http.Error(w, "query error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(result)
+	}
+}
+
+// uiHandler serves the embedded web app.
+func uiHandler() http.Handler {
+	root, err := fs.Sub(webUI, "webui")
+	if err != nil {
+		panic(err) // the embedded directory is part of the binary
+	}
+	return http.FileServerFS(root)
+}
+
+// serverQuerier runs browse lookups against the server's database, scoped
+// to a project in shared mode. Lookups only read, so they run read-only.
+type serverQuerier struct {
+	s         *cieServer
+	projectID string
+}
+
+// browseClient returns the querier for a project, or errNoIndex before the
+// first index run.
+func (s *cieServer) browseClient(projectID string) (tools.Querier, error) {
+	s.dbMu.RLock()
+	defer s.dbMu.RUnlock()
+	if !s.hasDB {
+		return nil, errNoIndex
+	}
+	return &serverQuerier{s: s, projectID: projectID}, nil
+}
+
+func (q *serverQuerier) Query(ctx context.Context, script string) (*tools.QueryResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	q.s.dbMu.RLock()
+	defer q.s.dbMu.RUnlock()
+	if !q.s.hasDB {
+		return nil, errNoIndex
+	}
+	script, err := q.s.tenantScript(q.projectID, script)
+	if err != nil {
+		return nil, err
+	}
+	result, err := q.s.db.RunReadOnly(script, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &tools.QueryResult{Headers: result.Headers, Rows: result.Rows}, nil
+}
+
+func (q *serverQuerier) QueryRaw(ctx context.Context, script string) (map[string]any, error) {
+	result, err := q.Query(ctx, script)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"Headers": result.Headers, "Rows": result.Rows}, nil
+}
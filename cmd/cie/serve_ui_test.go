@@ -0,0 +1,147 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+func newBrowseTestServer(t *testing.T, q tools.Querier) (*httptest.Server, *[]string) {
+	t.Helper()
+	var projects []string
+	api := &browseAPI{
+		client: func(projectID string) (tools.Querier, error) {
+			projects = append(projects, projectID)
+			if q == nil {
+				return nil, errNoIndex
+			}
+			return q, nil
+		},
+		embeddingURL: "http://127.0.0.1:1",
+	}
+	mux := http.NewServeMux()
+	api.register(mux)
+	mux.Handle("/", uiHandler())
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, &projects
+}
+
+func getBody(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestBrowseAPI_Functions(t *testing.T) {
+	q := &recordingQuerier{
+		headers: []string{"name", "file_path", "id", "signature", "start_line", "end_line"},
+		rows:    [][]any{{"HandleLogin", "internal/auth/login.go", "fn1", "func HandleLogin()", float64(10), float64(20)}},
+	}
+	srv, projects := newBrowseTestServer(t, q)
+
+	status, body := getBody(t, srv.URL+"/v1/browse/functions?dir=internal/auth&project=acme")
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", status, body)
+	}
+	var refs []tools.FunctionRef
+	if err := json.Unmarshal([]byte(body), &refs); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(refs) != 1 || refs[0].ID != "fn1" || refs[0].StartLine != 10 {
+		t.Errorf("refs = %+v", refs)
+	}
+	if !strings.Contains(body, `"file_path":"internal/auth/login.go"`) {
+		t.Errorf("body missing snake_case fields: %s", body)
+	}
+	if len(*projects) != 1 || (*projects)[0] != "acme" {
+		t.Errorf("project routing = %v, want [acme]", *projects)
+	}
+	if len(q.scripts) != 1 || !strings.Contains(q.scripts[0], "internal/auth") {
+		t.Errorf("scripts = %v", q.scripts)
+	}
+}
+
+func TestBrowseAPI_SearchFallsBackToNameMatch(t *testing.T) {
+	q := &recordingQuerier{
+		headers: []string{"name", "file_path", "id", "signature", "start_line", "end_line"},
+		rows:    [][]any{{"HandleLogin", "internal/auth/login.go", "fn1", "", float64(10), float64(20)}},
+	}
+	srv, _ := newBrowseTestServer(t, q)
+
+	status, body := getBody(t, srv.URL+"/v1/browse/search?q=login")
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", status, body)
+	}
+	var res struct {
+		Semantic bool                `json:"semantic"`
+		Results  []tools.FunctionRef `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(body), &res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if res.Semantic || len(res.Results) != 1 {
+		t.Errorf("search = %+v, want one name match", res)
+	}
+}
+
+func TestBrowseAPI_Errors(t *testing.T) {
+	srv, _ := newBrowseTestServer(t, nil)
+	if status, _ := getBody(t, srv.URL+"/v1/browse/packages"); status != http.StatusServiceUnavailable {
+		t.Errorf("no index: status = %d, want 503", status)
+	}
+
+	resp, err := http.Post(srv.URL+"/v1/browse/packages", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestUIHandler_ServesApp(t *testing.T) {
+	srv, _ := newBrowseTestServer(t, nil)
+	for path, want := range map[string]string{
+		"/":          `<script src="app.js">`,
+		"/app.js":    "/v1/browse/",
+		"/style.css": "#graph",
+	} {
+		status, body := getBody(t, srv.URL+path)
+		if status != http.StatusOK || !strings.Contains(body, want) {
+			t.Errorf("GET %s: status %d, missing %q", path, status, want)
+		}
+	}
+}
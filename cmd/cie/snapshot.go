@@ -0,0 +1,52 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// openSnapshotBackend opens the index snapshot taken at ref (a commit SHA
+// or unique prefix of one) for the configured project.
+func openSnapshotBackend(cfg *Config, ref string) (*storage.EmbeddedBackend, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return storage.OpenSnapshot(storage.EmbeddedConfig{
+		DataDir:             filepath.Join(homeDir, ".cie", "data", cfg.ProjectID),
+		EmbeddingDimensions: cfg.Embedding.Dimensions,
+	}, ref)
+}
+
+// snapshotOpenError explains why the snapshot at ref could not be opened.
+func snapshotOpenError(ref string, err error) *errors.UserError {
+	return errors.NewDatabaseError(
+		fmt.Sprintf("Cannot open index snapshot %q", ref),
+		err.Error(),
+		"List snapshots with 'cie status'. Snapshots are taken after indexing when storage.snapshots is set in .cie/project.yaml",
+		err,
+	)
+}
@@ -47,6 +47,13 @@ type StatusResult struct {
 	CallEdges  int       `json:"call_edges"`
 	Error      string    `json:"error,omitempty"`
 	Timestamp  time.Time `json:"timestamp"`
+
+	// PendingEmbeddings counts functions without an embedding, e.g. after
+	// 'cie index --skip-embeddings'.
+	PendingEmbeddings int `json:"pending_embeddings,omitempty"`
+
+	// Snapshots lists the saved index snapshots, newest first.
+	Snapshots []storage.Snapshot `json:"snapshots,omitempty"`
 }
 
 // runStatus executes the 'status' CLI command, displaying project index statistics.
@@ -159,14 +166,15 @@ Output Fields:
 	// Open local backend
 	backend, err := storage.NewEmbeddedBackend(storage.EmbeddedConfig{
 		DataDir:   dataDir,
-		Engine:    "rocksdb",
+		Engine:    cfg.StorageEngine(),
+		Sharded:   cfg.Storage.Sharded,
 		ProjectID: cfg.ProjectID,
 	})
 	if err != nil {
 		errors.FatalError(errors.NewDatabaseError(
 			"Cannot open CIE database",
 			"The database file may be corrupted, locked by another process, or permission denied",
-			"Try running 'cie status' again, or run 'cie reset --yes' to rebuild the index",
+			openFailureFix(err, "Try running 'cie status' again, or close other CIE processes"),
 			err,
 		), globals.JSON)
 	}
@@ -181,6 +189,10 @@ Output Fields:
 	result.Types = queryLocalCount(ctx, backend, "cie_type", "id")
 	result.Embeddings = queryLocalCount(ctx, backend, "cie_function_embedding", "function_id")
 	result.CallEdges = queryLocalCount(ctx, backend, "cie_calls", "id")
+	if result.Embeddings < result.Functions {
+		result.PendingEmbeddings = countPendingEmbeddings(ctx, backend)
+	}
+	result.Snapshots, _ = storage.ListSnapshots(dataDir)
 
 	if globals.JSON {
 		outputStatusJSON(result)
@@ -205,21 +217,37 @@ func queryLocalCount(ctx context.Context, backend *storage.EmbeddedBackend, tabl
 	if err != nil {
 		return 0
 	}
+	return sumCountRows(result)
+}
 
-	if len(result.Rows) == 0 || len(result.Rows[0]) == 0 {
+// countPendingEmbeddings counts functions that have no embedding row.
+func countPendingEmbeddings(ctx context.Context, backend *storage.EmbeddedBackend) int {
+	result, err := backend.Query(ctx, "?[count(id)] := *cie_function { id }, not *cie_function_embedding { function_id: id }")
+	if err != nil {
 		return 0
 	}
+	return sumCountRows(result)
+}
 
-	switch v := result.Rows[0][0].(type) {
-	case float64:
-		return int(v)
-	case int:
-		return v
-	case int64:
-		return int(v)
-	default:
-		return 0
+// sumCountRows reads the total of a count query, adding up the first column
+// when it returns several rows (a sharded backend merges them into one for
+// count queries it can combine).
+func sumCountRows(result *storage.QueryResult) int {
+	total := 0
+	for _, row := range result.Rows {
+		if len(row) == 0 {
+			continue
+		}
+		switch v := row[0].(type) {
+		case float64:
+			total += int(v)
+		case int:
+			total += v
+		case int64:
+			total += int(v)
+		}
 	}
+	return total
 }
 
 // outputStatusJSON writes the status result as formatted JSON to stdout.
@@ -248,6 +276,18 @@ func printLocalStatus(result *StatusResult) {
 	fmt.Printf("  Types:         %s\n", ui.CountText(result.Types))
 	fmt.Printf("  Embeddings:    %s\n", ui.CountText(result.Embeddings))
 	fmt.Printf("  Call Edges:    %s\n", ui.CountText(result.CallEdges))
+	if result.PendingEmbeddings > 0 {
+		fmt.Println()
+		ui.Infof("%d functions have no embedding yet; run 'cie embed-backfill' to add them", result.PendingEmbeddings)
+	}
+
+	if len(result.Snapshots) > 0 {
+		fmt.Println()
+		ui.SubHeader("Snapshots:")
+		for _, snap := range result.Snapshots {
+			fmt.Printf("  %-12s   %s\n", snap.Name[:min(12, len(snap.Name))], ui.DimText(snap.Created.Format(time.DateTime)))
+		}
+	}
 
 	if result.Error != "" {
 		fmt.Println()
@@ -0,0 +1,99 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kraklabs/cie/pkg/tools"
+)
+
+// warmupState tracks the single warm-up run of an MCP session so that the
+// background phase and cie_warmup calls share one result.
+type warmupState struct {
+	mu      sync.Mutex
+	done    chan struct{}
+	steps   []tools.WarmupStep
+	elapsed time.Duration
+}
+
+// start launches a warm-up run unless one is already running or finished
+// (force re-runs a finished one) and returns a channel closed on completion.
+func (w *warmupState) start(client tools.Querier, force bool) <-chan struct{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.done != nil {
+		select {
+		case <-w.done:
+			if !force {
+				return w.done
+			}
+		default:
+			return w.done // still running
+		}
+	}
+
+	done := make(chan struct{})
+	w.done = done
+	go func() {
+		start := time.Now()
+		steps := tools.RunWarmup(context.Background(), client)
+		w.mu.Lock()
+		w.steps, w.elapsed = steps, time.Since(start)
+		w.mu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+// report returns the formatted result of the last finished run.
+func (w *warmupState) report() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return tools.FormatWarmup(w.steps, w.elapsed)
+}
+
+// startBackgroundWarmup preloads indexes after the server starts, logging the
+// outcome to stderr. Tool calls are served while it runs.
+func (s *mcpServer) startBackgroundWarmup() {
+	fmt.Fprintf(os.Stderr, "  Warming up indexes in the background...\n")
+	done := s.warmup.start(s.client, false)
+	go func() {
+		<-done
+		s.warmup.mu.Lock()
+		elapsed := s.warmup.elapsed
+		s.warmup.mu.Unlock()
+		fmt.Fprintf(os.Stderr, "  Warm-up finished in %s\n", elapsed.Round(time.Millisecond))
+	}()
+}
+
+func handleWarmup(ctx context.Context, s *mcpServer, args map[string]any) (*tools.ToolResult, error) {
+	force, _ := args["force"].(bool)
+	select {
+	case <-s.warmup.start(s.client, force):
+		return tools.NewResult(s.warmup.report()), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestWarmupState_SharesRun(t *testing.T) {
+	s := &mcpServer{client: &stubQuerier{rows: 1}, warmup: &warmupState{}}
+
+	first := s.warmup.start(s.client, false)
+	second := s.warmup.start(s.client, false)
+	if first != second {
+		t.Error("concurrent starts should share one run")
+	}
+	<-first
+
+	if again := s.warmup.start(s.client, false); again != first {
+		t.Error("finished run should be reused without force")
+	}
+	if forced := s.warmup.start(s.client, true); forced == first {
+		t.Error("force should start a new run")
+	}
+
+	result, err := handleWarmup(context.Background(), s, map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result.Text, "Warm-up") {
+		t.Errorf("unexpected result: %s", result.Text)
+	}
+}
@@ -0,0 +1,325 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	flag "github.com/spf13/pflag"
+
+	"github.com/kraklabs/cie/internal/errors"
+	"github.com/kraklabs/cie/pkg/ingestion"
+)
+
+// defaultWatchDebounce is how long `cie watch` waits for saves to stop
+// before indexing them.
+const defaultWatchDebounce = 500 * time.Millisecond
+
+// watchRetryDelay is how long `cie watch` waits before retrying a batch
+// that failed, typically because another process held the database.
+const watchRetryDelay = 5 * time.Second
+
+// runWatch executes the 'watch' CLI command, which keeps the index in step
+// with the working tree while the developer edits.
+//
+// It first brings the index up to date like 'cie index', then watches the
+// repository and re-indexes the files that change. Saves that arrive within
+// the debounce interval of each other are indexed as one batch. The
+// database is opened per batch, so an MCP server and 'cie index' can use it
+// in between.
+//
+// Flags:
+//   - --debounce: Quiet period before a batch is indexed (default: 500ms)
+//   - --embed-workers: Number of parallel embedding workers (default: 8)
+//   - --skip-embeddings: Index structure only; see 'cie embed-backfill'
+//   - --debug: Enable debug logging (default: false)
+func runWatch(args []string, configPath string, globals GlobalFlags) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	debounce := fs.Duration("debounce", defaultWatchDebounce, "Quiet period after the last change before it is indexed")
+	embedWorkers := fs.Int("embed-workers", 8, "Number of parallel embedding workers")
+	skipEmbeddings := fs.Bool("skip-embeddings", false, "Index changes without embeddings (fill them in later with 'cie embed-backfill')")
+	debug := fs.Bool("debug", false, "Enable debug logging")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: cie watch [options]
+
+Description:
+  Watch the repository and re-index files as they change, without git
+  hooks or manual 'cie index' runs. The index is first brought up to
+  date, then every batch of saves is indexed once the files have been
+  quiet for the debounce interval. Files matching indexing.exclude are
+  ignored. Uncommitted edits are indexed as they are on disk; the next
+  'cie index' still catches up on commits.
+
+  The database is only held while a batch is indexed, so 'cie --mcp'
+  can run alongside and sees each batch as soon as it is written.
+
+Options:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Examples:
+  # Keep the index current while you work
+  cie watch
+
+  # Wait longer for editors that save in several steps
+  cie watch --debounce 2s
+
+  # Index structure only; embed later
+  cie watch --skip-embeddings
+
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		errors.FatalError(err, globals.JSON)
+	}
+	if cfg.CIE.EdgeCache != "" || os.Getenv("CIE_BASE_URL") != "" {
+		errors.FatalError(errors.NewInputError(
+			"cie watch needs a local index",
+			"This project is indexed by a remote CIE server",
+			"Run 'cie index' against the server instead",
+		), globals.JSON)
+	}
+
+	logLevel := slog.LevelWarn
+	if *debug {
+		logLevel = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}))
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		errors.FatalError(errors.NewInternalError(
+			"Cannot access current directory",
+			"Failed to determine working directory",
+			"This is unexpected. Please report this issue at github.com/kraklabs/kraken/issues",
+			err,
+		), false)
+	}
+
+	embeddingProvider := mapEmbeddingProvider(cfg.Embedding.Provider)
+	setEmbeddingEnv(cfg, embeddingProvider)
+	checkpointDir := filepath.Join(ConfigDir(cwd), "checkpoints")
+	if err := os.MkdirAll(checkpointDir, 0750); err != nil {
+		errors.FatalError(errors.NewPermissionError(
+			"Cannot create checkpoint directory",
+			"Permission denied or insufficient disk space",
+			"Check permissions on .cie/checkpoints/ or free up disk space",
+			err,
+		), false)
+	}
+	config := localIngestionConfig(cfg, cwd, checkpointDir, embeddingProvider, *embedWorkers, false, *skipEmbeddings)
+
+	// index runs one batch through a pipeline opened for it alone. A nil
+	// paths catches up on commits like 'cie index'.
+	index := func(ctx context.Context, paths []string) error {
+		start := time.Now()
+		pipeline, err := ingestion.NewLocalPipeline(config, logger)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = pipeline.Close() }()
+
+		var result *ingestion.IngestionResult
+		if paths == nil {
+			result, err = pipeline.Run(ctx)
+		} else {
+			result, err = pipeline.IndexPaths(ctx, paths)
+		}
+		if err != nil {
+			return err
+		}
+		if !globals.Quiet {
+			fmt.Printf("%s  %s\n", time.Now().Format("15:04:05"), watchSummary(result, time.Since(start)))
+		}
+		return nil
+	}
+
+	if err := index(ctx, nil); err != nil {
+		errors.FatalError(errors.NewDatabaseError(
+			"Cannot bring the index up to date",
+			"An error occurred while indexing the repository",
+			openFailureFix(err, "Check the error details above. If this persists, run 'cie repair'"),
+			err,
+		), false)
+	}
+
+	watcher, err := newFileWatcher(cwd, config.IngestionConfig.ExcludeGlobs, *debounce)
+	if err != nil {
+		errors.FatalError(errors.NewInternalError(
+			"Cannot watch the repository",
+			err.Error(),
+			"Raise the inotify watch limit (fs.inotify.max_user_watches) or exclude large directories with indexing.exclude",
+			err,
+		), false)
+	}
+	defer func() { _ = watcher.Close() }()
+
+	if !globals.Quiet {
+		fmt.Printf("Watching %s for changes (Ctrl+C to stop)\n", cwd)
+	}
+	watcher.run(ctx, index, func(err error) {
+		fmt.Fprintf(os.Stderr, "%s  %v\n", time.Now().Format("15:04:05"), err)
+	})
+}
+
+// watchSummary describes one indexed batch.
+func watchSummary(result *ingestion.IngestionResult, elapsed time.Duration) string {
+	if result.FilesProcessed == 0 {
+		return fmt.Sprintf("index up to date (%s)", elapsed.Round(time.Millisecond))
+	}
+	return fmt.Sprintf("indexed %d files: %d functions, %d types (%s)",
+		result.FilesProcessed, result.FunctionsExtracted, result.TypesExtracted, elapsed.Round(time.Millisecond))
+}
+
+// fileWatcher turns filesystem events below a repository root into
+// debounced batches of changed paths.
+type fileWatcher struct {
+	root     string
+	excludes []string
+	debounce time.Duration
+	watcher  *fsnotify.Watcher
+}
+
+// newFileWatcher watches root and every directory below it that excludes
+// does not rule out.
+func newFileWatcher(root string, excludes []string, debounce time.Duration) (*fileWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &fileWatcher{root: root, excludes: excludes, debounce: debounce, watcher: watcher}
+	if _, err := w.addTree(root); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Close stops watching.
+func (w *fileWatcher) Close() error {
+	return w.watcher.Close()
+}
+
+// addTree watches dir and the directories below it, and returns the files
+// it found there: a directory created or moved in holds files that changed
+// before it was watched.
+func (w *fileWatcher) addTree(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil //nolint:nilerr // removed while walking
+		}
+		if w.excluded(path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			files = append(files, path)
+			return nil
+		}
+		return w.watcher.Add(path)
+	})
+	return files, err
+}
+
+// excluded reports whether path lies in a part of the repository that is
+// never indexed.
+func (w *fileWatcher) excluded(path string) bool {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil || rel == "." {
+		return false
+	}
+	return ingestion.ExcludedPath(rel, w.excludes)
+}
+
+// run calls index with the paths changed since the previous batch, once no
+// event came for the debounce interval, until ctx is done. A failed batch
+// is reported to onError and retried after watchRetryDelay, together with
+// any later changes.
+func (w *fileWatcher) run(ctx context.Context, index func(context.Context, []string) error, onError func(error)) {
+	pending := make(map[string]bool)
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod || w.excluded(event.Name) {
+				continue
+			}
+			pending[event.Name] = true
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Lstat(event.Name); err == nil && info.IsDir() {
+					files, _ := w.addTree(event.Name)
+					for _, f := range files {
+						pending[f] = true
+					}
+				}
+			}
+			timer.Reset(w.debounce)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			onError(fmt.Errorf("watch: %w", err))
+		case <-timer.C:
+			paths := make([]string, 0, len(pending))
+			for path := range pending {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			if err := index(ctx, paths); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				onError(fmt.Errorf("indexing failed, retrying in %s: %w", watchRetryDelay, err))
+				timer.Reset(watchRetryDelay)
+				continue
+			}
+			pending = make(map[string]bool)
+		}
+	}
+}
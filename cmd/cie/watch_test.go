@@ -0,0 +1,88 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFileWatcher_DebouncesBatches(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	w, err := newFileWatcher(root, []string{".git/**"}, 100*time.Millisecond)
+	if err != nil {
+		t.Skipf("filesystem watching unavailable: %v", err)
+	}
+	defer func() { _ = w.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	batches := make(chan []string, 4)
+	failed := false
+	go w.run(ctx, func(_ context.Context, paths []string) error {
+		if !failed {
+			// The first attempt fails; its paths are retried with later changes.
+			failed = true
+			return errors.New("database busy")
+		}
+		batches <- paths
+		return nil
+	}, func(error) {})
+
+	write := func(rel string) {
+		t.Helper()
+		full := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("package x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.go")
+	write("b.go")
+	write(".git/index")
+	time.Sleep(300 * time.Millisecond) // first batch fails
+
+	write("pkg/c.go") // a new directory and its file
+	write("a.go")
+
+	select {
+	case got := <-batches:
+		want := []string{
+			filepath.Join(root, "a.go"),
+			filepath.Join(root, "b.go"),
+			filepath.Join(root, "pkg"),
+			filepath.Join(root, "pkg", "c.go"),
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("batch = %v, want %v", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no batch was indexed")
+	}
+}
@@ -0,0 +1,180 @@
+// CIE web UI: a thin client over the /v1/browse endpoints of `cie serve`.
+(function () {
+  "use strict";
+
+  var project = new URLSearchParams(location.search).get("project") || "";
+  var svgNS = "http://www.w3.org/2000/svg";
+
+  function $(id) { return document.getElementById(id); }
+
+  function api(path, params) {
+    var q = new URLSearchParams(params || {});
+    if (project) q.set("project", project);
+    return fetch("/v1/browse/" + path + "?" + q).then(function (r) {
+      if (!r.ok) return r.text().then(function (t) { throw new Error(t.trim() || r.statusText); });
+      return r.json();
+    });
+  }
+
+  function el(tag, cls, text) {
+    var e = document.createElement(tag);
+    if (cls) e.className = cls;
+    if (text !== undefined) e.textContent = text;
+    return e;
+  }
+
+  function status(msg) { $("status").textContent = msg || ""; }
+  function fail(err) { status("Error: " + err.message); }
+
+  function loc(fn) { return fn.file_path + ":" + fn.start_line; }
+
+  // File browser: packages expand into their functions.
+  function loadPackages() {
+    api("packages").then(function (pkgs) {
+      var nav = $("files");
+      nav.textContent = "";
+      (pkgs || []).forEach(function (p) {
+        var row = el("div", "pkg");
+        row.appendChild(document.createTextNode((p.path || ".") + " "));
+        row.appendChild(el("span", "count", p.functions + " funcs"));
+        var list = el("div");
+        list.hidden = true;
+        row.onclick = function () {
+          if (!list.hidden) { list.hidden = true; return; }
+          list.hidden = false;
+          if (list.childElementCount) return;
+          api("functions", { dir: p.path }).then(function (fns) {
+            (fns || []).forEach(function (fn) {
+              var item = el("div", "fn", fn.name);
+              item.title = loc(fn);
+              item.onclick = function () { focus(fn); };
+              list.appendChild(item);
+            });
+          }).catch(fail);
+        };
+        nav.appendChild(row);
+        nav.appendChild(list);
+      });
+      status((pkgs || []).length + " packages");
+    }).catch(fail);
+  }
+
+  // Search results replace the detail panel until one is picked.
+  function search(query) {
+    status("Searching…");
+    api("search", { q: query }).then(function (res) {
+      clearDetail();
+      var box = $("results");
+      (res.results || []).forEach(function (fn) {
+        var row = el("div", "result");
+        row.appendChild(el("div", "", fn.name));
+        row.appendChild(el("div", "loc", loc(fn)));
+        row.onclick = function () { focus(fn); };
+        box.appendChild(row);
+      });
+      var n = (res.results || []).length;
+      status(n + " results" + (res.semantic ? " (semantic)" : " (name match)"));
+    }).catch(fail);
+  }
+
+  function clearDetail() {
+    $("results").textContent = "";
+    $("graph").textContent = "";
+    $("graph").style.height = "0";
+    $("meta").textContent = "";
+    $("code").textContent = "";
+  }
+
+  // focus shows a function's call neighbourhood and source.
+  function focus(fn) {
+    clearDetail();
+    var meta = $("meta");
+    meta.appendChild(el("div", "sig", fn.signature || fn.name));
+    meta.appendChild(el("div", "loc", fn.file_path + ":" + fn.start_line + "-" + fn.end_line));
+    Promise.all([api("callers", { id: fn.id }), api("callees", { id: fn.id })]).then(function (r) {
+      drawGraph(fn, r[0] || [], r[1] || []);
+    }).catch(fail);
+    api("code", { id: fn.id }).then(function (res) { $("code").textContent = res.code; }).catch(fail);
+    status(fn.name);
+  }
+
+  // drawGraph lays out callers on the left, the focus in the middle and
+  // callees on the right. Clicking a node refocuses on it.
+  function drawGraph(fn, callers, callees) {
+    var svg = $("graph");
+    var rowH = 28, boxW = 220, boxH = 22, gap = 120;
+    var rows = Math.max(callers.length, callees.length, 1);
+    var height = rows * rowH + 8;
+    svg.style.height = height + "px";
+    svg.setAttribute("viewBox", "0 0 " + (boxW * 3 + gap * 2) + " " + height);
+
+    var defs = document.createElementNS(svgNS, "defs");
+    var marker = document.createElementNS(svgNS, "marker");
+    marker.setAttribute("id", "arrow");
+    marker.setAttribute("viewBox", "0 0 10 10");
+    marker.setAttribute("refX", "10");
+    marker.setAttribute("refY", "5");
+    marker.setAttribute("markerWidth", "6");
+    marker.setAttribute("markerHeight", "6");
+    marker.setAttribute("orient", "auto");
+    var tip = document.createElementNS(svgNS, "path");
+    tip.setAttribute("d", "M0,0 L10,5 L0,10 z");
+    tip.setAttribute("fill", "#8c959f");
+    marker.appendChild(tip);
+    defs.appendChild(marker);
+    svg.appendChild(defs);
+
+    function rowY(i, n) { return (height - n * rowH) / 2 + i * rowH + (rowH - boxH) / 2; }
+
+    function node(f, x, y, isFocus) {
+      var g = document.createElementNS(svgNS, "g");
+      g.setAttribute("class", isFocus ? "node focus" : "node");
+      var rect = document.createElementNS(svgNS, "rect");
+      rect.setAttribute("x", x);
+      rect.setAttribute("y", y);
+      rect.setAttribute("width", boxW);
+      rect.setAttribute("height", boxH);
+      var text = document.createElementNS(svgNS, "text");
+      text.setAttribute("x", x + 6);
+      text.setAttribute("y", y + 15);
+      text.textContent = f.name.length > 30 ? f.name.slice(0, 29) + "…" : f.name;
+      var title = document.createElementNS(svgNS, "title");
+      title.textContent = f.name + "\n" + loc(f);
+      g.appendChild(title);
+      g.appendChild(rect);
+      g.appendChild(text);
+      if (!isFocus) g.onclick = function () { focus(f); };
+      svg.appendChild(g);
+    }
+
+    function edge(x1, y1, x2, y2) {
+      var line = document.createElementNS(svgNS, "line");
+      line.setAttribute("x1", x1);
+      line.setAttribute("y1", y1 + boxH / 2);
+      line.setAttribute("x2", x2);
+      line.setAttribute("y2", y2 + boxH / 2);
+      svg.appendChild(line);
+    }
+
+    var cx = boxW + gap, cy = rowY(0, 1);
+    callers.forEach(function (f, i) {
+      var y = rowY(i, callers.length);
+      edge(boxW, y, cx, cy);
+      node(f, 0, y, false);
+    });
+    callees.forEach(function (f, i) {
+      var y = rowY(i, callees.length);
+      edge(cx + boxW, cy, cx + boxW + gap, y);
+      node(f, cx + boxW + gap, y, false);
+    });
+    node(fn, cx, cy, true);
+  }
+
+  $("search").onsubmit = function (e) {
+    e.preventDefault();
+    var q = $("query").value.trim();
+    if (q) search(q);
+  };
+
+  loadPackages();
+})();
@@ -0,0 +1,156 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kraklabs/cie/internal/errors"
+)
+
+// defaultWorkspaceFile is the workspace config stored next to project.yaml in
+// a .cie directory. It lists project roots instead of configuring one.
+const defaultWorkspaceFile = "workspace.yaml"
+
+// WorkspaceConfig represents a .cie/workspace.yaml file. It lets one
+// directory, such as an IDE workspace root, stand for several indexed
+// projects.
+type WorkspaceConfig struct {
+	Version string `yaml:"version"`
+	// Projects are project roots, each holding its own .cie/project.yaml.
+	// Relative paths are resolved against the workspace root. The first
+	// entry is the default project.
+	Projects []string `yaml:"projects"`
+
+	root string // Directory containing the .cie directory
+}
+
+// WorkspacePath returns the path to the workspace file in the given directory.
+func WorkspacePath(dir string) string {
+	return filepath.Join(dir, defaultConfigDir, defaultWorkspaceFile)
+}
+
+// discoverConfig walks up from start looking for .cie/project.yaml or
+// .cie/workspace.yaml, preferring the project file when a directory has both.
+//
+// The walk stops at the root of the enclosing git repository so a checkout
+// never picks up configuration from an unrelated parent directory. Outside a
+// git repository it continues to the filesystem root.
+//
+// Exactly one of configPath and workspacePath is set on success.
+func discoverConfig(start string) (configPath, workspacePath string, err error) {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return "", "", err
+	}
+	for {
+		if p := ConfigPath(dir); fileExists(p) {
+			return p, "", nil
+		}
+		if p := WorkspacePath(dir); fileExists(p) {
+			return "", p, nil
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break // reached the git root
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return "", "", errors.NewConfigError(
+		"Configuration not found",
+		"No .cie/project.yaml or .cie/workspace.yaml found between the current directory and the repository root",
+		"Run 'cie init' to create a new configuration",
+		nil,
+	)
+}
+
+// LoadWorkspace reads a .cie/workspace.yaml file.
+func LoadWorkspace(path string) (*WorkspaceConfig, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: Path comes from discovery
+	if err != nil {
+		return nil, errors.NewConfigError(
+			"Cannot read workspace file",
+			fmt.Sprintf("Failed to read %s", path),
+			"Check file permissions and ensure the file exists",
+			err,
+		)
+	}
+	var ws WorkspaceConfig
+	if err := yaml.Unmarshal(data, &ws); err != nil {
+		return nil, errors.NewConfigError(
+			"Invalid workspace format",
+			"YAML parsing failed - the workspace file contains syntax errors",
+			fmt.Sprintf("Edit %s to fix syntax errors", path),
+			err,
+		)
+	}
+	if ws.Version != configVersion {
+		return nil, errors.NewConfigError(
+			"Unsupported workspace version",
+			fmt.Sprintf("Workspace version '%s' is not supported (expected '%s')", ws.Version, configVersion),
+			fmt.Sprintf("Set version: \"%s\" in %s", configVersion, path),
+			nil,
+		)
+	}
+	if len(ws.Projects) == 0 {
+		return nil, errors.NewConfigError(
+			"Empty workspace",
+			fmt.Sprintf("%s does not list any projects", path),
+			"Add project roots under 'projects:', e.g. '- services/api'",
+			nil,
+		)
+	}
+	ws.root = filepath.Dir(filepath.Dir(path))
+	return &ws, nil
+}
+
+// ProjectConfigPaths returns the .cie/project.yaml path of every listed
+// project, in order. A project without a config file is an error.
+func (w *WorkspaceConfig) ProjectConfigPaths() ([]string, error) {
+	paths := make([]string, 0, len(w.Projects))
+	for _, p := range w.Projects {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(w.root, p)
+		}
+		cfgPath := ConfigPath(filepath.Clean(p))
+		if !fileExists(cfgPath) {
+			return nil, errors.NewConfigError(
+				"Workspace project not initialized",
+				fmt.Sprintf("%s is listed in %s but has no .cie/project.yaml", p, WorkspacePath(w.root)),
+				fmt.Sprintf("Run 'cie init' in %s or remove it from the workspace", p),
+				nil,
+			)
+		}
+		paths = append(paths, cfgPath)
+	}
+	return paths, nil
+}
+
+// ProjectRoot returns the project directory that holds configPath.
+func ProjectRoot(configPath string) string {
+	return filepath.Dir(filepath.Dir(configPath))
+}
@@ -0,0 +1,114 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// mkfile writes content to dir/rel, creating parent directories.
+func mkfile(t *testing.T, dir, rel, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDiscoverConfig_StopsAtGitRoot(t *testing.T) {
+	root := t.TempDir()
+	mkfile(t, root, ".cie/project.yaml", "version: \"1\"\nproject_id: outer\n")
+	if err := os.MkdirAll(filepath.Join(root, "repo", ".git"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(root, "repo", "pkg", "deep")
+	if err := os.MkdirAll(sub, 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := discoverConfig(sub); err == nil {
+		t.Fatal("discovery escaped the git repository")
+	}
+
+	want := mkfile(t, root, "repo/.cie/project.yaml", "version: \"1\"\nproject_id: repo\n")
+	got, ws, err := discoverConfig(sub)
+	if err != nil || got != want || ws != "" {
+		t.Errorf("discoverConfig = %q, %q, %v; want %q", got, ws, err, want)
+	}
+}
+
+func TestDiscoverConfig_Workspace(t *testing.T) {
+	root := t.TempDir()
+	wsPath := mkfile(t, root, ".cie/workspace.yaml", "version: \"1\"\nprojects:\n  - services/api\n  - web\n")
+	api := mkfile(t, root, "services/api/.cie/project.yaml", "version: \"1\"\nproject_id: api\n")
+	web := mkfile(t, root, "web/.cie/project.yaml", "version: \"1\"\nproject_id: web\n")
+
+	cfgPath, got, err := discoverConfig(root)
+	if err != nil || cfgPath != "" || got != wsPath {
+		t.Fatalf("discoverConfig = %q, %q, %v; want workspace %q", cfgPath, got, err, wsPath)
+	}
+
+	ws, err := LoadWorkspace(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths, err := ws.ProjectConfigPaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 || paths[0] != api || paths[1] != web {
+		t.Errorf("ProjectConfigPaths = %v", paths)
+	}
+	if ProjectRoot(paths[1]) != filepath.Join(root, "web") {
+		t.Errorf("ProjectRoot = %q", ProjectRoot(paths[1]))
+	}
+}
+
+func TestLoadWorkspace_Errors(t *testing.T) {
+	tests := map[string]string{
+		"bad version": "version: \"2\"\nprojects: [a]\n",
+		"no projects": "version: \"1\"\n",
+		"bad yaml":    "projects: [",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := mkfile(t, t.TempDir(), ".cie/workspace.yaml", content)
+			if _, err := LoadWorkspace(path); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	t.Run("uninitialized project", func(t *testing.T) {
+		path := mkfile(t, t.TempDir(), ".cie/workspace.yaml", "version: \"1\"\nprojects: [missing]\n")
+		ws, err := LoadWorkspace(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ws.ProjectConfigPaths(); err == nil {
+			t.Error("expected error for project without .cie/project.yaml")
+		}
+	})
+}
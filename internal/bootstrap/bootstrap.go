@@ -202,7 +202,7 @@ func ListProjects() ([]string, error) {
 
 	var projects []string
 	for _, entry := range entries {
-		if entry.IsDir() {
+		if entry.IsDir() && !storage.IsSwapDir(entry.Name()) {
 			projects = append(projects, entry.Name())
 		}
 	}
@@ -62,6 +62,10 @@
 //   - ExitPermission (5): Permission denied (file access, etc.)
 //   - ExitNotFound (6): Resource not found (project, file, etc.)
 //   - ExitInternal (10): Internal errors (bugs, panics)
+//
+// Failures with a more specific errcode.Code get their own exit code
+// (ExitDBLocked and up), so wrappers can branch on them without parsing
+// the message. See ExitCodeFor.
 package errors
 
 import (
@@ -71,6 +75,8 @@ import (
 	"strings"
 
 	"github.com/fatih/color"
+
+	"github.com/kraklabs/cie/pkg/errcode"
 )
 
 // Exit codes for different error categories.
@@ -99,8 +105,92 @@ const (
 	// ExitInternal indicates internal errors (bugs, unexpected panics).
 	// Exit code 10 signals "this is a bug that should be reported".
 	ExitInternal = 10
+
+	// ExitDBLocked indicates the database or index lock is held by another process.
+	ExitDBLocked = 11
+
+	// ExitDBCorrupt indicates damaged database files ('cie repair' can help).
+	ExitDBCorrupt = 12
+
+	// ExitSchemaMismatch indicates an index built by an incompatible version.
+	ExitSchemaMismatch = 13
+
+	// ExitProviderUnreachable indicates the embedding or LLM provider could not be reached.
+	ExitProviderUnreachable = 14
+
+	// ExitTimeout indicates a query or request ran past its time limit.
+	ExitTimeout = 15
+
+	// ExitParseLimit indicates a file or statement exceeded a size limit.
+	ExitParseLimit = 16
 )
 
+// ExitCodeFor returns the exit code for a failure code. Codes without a
+// dedicated exit code use their category's.
+func ExitCodeFor(code errcode.Code) int {
+	switch code {
+	case errcode.ConfigInvalid:
+		return ExitConfig
+	case errcode.DBError:
+		return ExitDatabase
+	case errcode.DBLocked:
+		return ExitDBLocked
+	case errcode.DBCorrupt:
+		return ExitDBCorrupt
+	case errcode.SchemaMismatch:
+		return ExitSchemaMismatch
+	case errcode.NetworkError:
+		return ExitNetwork
+	case errcode.ProviderUnreachable:
+		return ExitProviderUnreachable
+	case errcode.Timeout:
+		return ExitTimeout
+	case errcode.InvalidInput, errcode.QueryRejected, errcode.RateLimited:
+		return ExitInput
+	case errcode.PermissionDenied:
+		return ExitPermission
+	case errcode.NotFound:
+		return ExitNotFound
+	case errcode.ParseLimit:
+		return ExitParseLimit
+	default:
+		return ExitInternal
+	}
+}
+
+// codeForExit is the inverse of ExitCodeFor for UserErrors built without a
+// Code.
+func codeForExit(exitCode int) errcode.Code {
+	switch exitCode {
+	case ExitConfig:
+		return errcode.ConfigInvalid
+	case ExitDatabase:
+		return errcode.DBError
+	case ExitDBLocked:
+		return errcode.DBLocked
+	case ExitDBCorrupt:
+		return errcode.DBCorrupt
+	case ExitSchemaMismatch:
+		return errcode.SchemaMismatch
+	case ExitNetwork:
+		return errcode.NetworkError
+	case ExitProviderUnreachable:
+		return errcode.ProviderUnreachable
+	case ExitTimeout:
+		return errcode.Timeout
+	case ExitInput:
+		return errcode.InvalidInput
+	case ExitPermission:
+		return errcode.PermissionDenied
+	case ExitNotFound:
+		return errcode.NotFound
+	case ExitParseLimit:
+		return errcode.ParseLimit
+	default:
+		return errcode.Internal
+	}
+}
+
 // UserError represents an error with structured context for end users.
 //
 // It provides three levels of information:
@@ -123,6 +213,11 @@ type UserError struct {
 	// ExitCode is the exit code that should be used when exiting due to this error.
 	ExitCode int
 
+	// Code classifies the failure for scripts and agents. Constructors set
+	// it from the wrapped error when that is more specific than the
+	// category, e.g. DB_LOCKED instead of DB_ERROR.
+	Code errcode.Code
+
 	// Err is the underlying error that caused this error (optional).
 	// This enables error wrapping and compatibility with errors.Is/As.
 	Err error
@@ -147,6 +242,39 @@ func (e *UserError) Unwrap() error {
 	return e.Err
 }
 
+// ErrorCode implements errcode.Coder.
+func (e *UserError) ErrorCode() errcode.Code {
+	if e.Code != "" {
+		return e.Code
+	}
+	return codeForExit(e.ExitCode)
+}
+
+// WithCode sets the failure code and the matching exit code.
+func (e *UserError) WithCode(code errcode.Code) *UserError {
+	e.Code = code
+	e.ExitCode = ExitCodeFor(code)
+	return e
+}
+
+// newUserError builds a UserError whose code is the one the message of err
+// points to, falling back to the category code. Codes of wrapped
+// UserErrors are not inherited: the outer constructor picks the category.
+func newUserError(msg, cause, fix string, category errcode.Code, err error) *UserError {
+	code := errcode.Classify(err)
+	if code == "" {
+		code = category
+	}
+	return &UserError{
+		Message:  msg,
+		Cause:    cause,
+		Fix:      fix,
+		ExitCode: ExitCodeFor(code),
+		Code:     code,
+		Err:      err,
+	}
+}
+
 // NewConfigError creates a configuration error with exit code ExitConfig.
 //
 // Use this for errors related to missing, invalid, or malformed configuration files.
@@ -160,13 +288,7 @@ func (e *UserError) Unwrap() error {
 //	    nil,
 //	)
 func NewConfigError(msg, cause, fix string, err error) *UserError {
-	return &UserError{
-		Message:  msg,
-		Cause:    cause,
-		Fix:      fix,
-		ExitCode: ExitConfig,
-		Err:      err,
-	}
+	return newUserError(msg, cause, fix, errcode.ConfigInvalid, err)
 }
 
 // NewDatabaseError creates a database error with exit code ExitDatabase.
@@ -183,13 +305,7 @@ func NewConfigError(msg, cause, fix string, err error) *UserError {
 //	    err,
 //	)
 func NewDatabaseError(msg, cause, fix string, err error) *UserError {
-	return &UserError{
-		Message:  msg,
-		Cause:    cause,
-		Fix:      fix,
-		ExitCode: ExitDatabase,
-		Err:      err,
-	}
+	return newUserError(msg, cause, fix, errcode.DBError, err)
 }
 
 // NewNetworkError creates a network error with exit code ExitNetwork.
@@ -205,13 +321,7 @@ func NewDatabaseError(msg, cause, fix string, err error) *UserError {
 //	    err,
 //	)
 func NewNetworkError(msg, cause, fix string, err error) *UserError {
-	return &UserError{
-		Message:  msg,
-		Cause:    cause,
-		Fix:      fix,
-		ExitCode: ExitNetwork,
-		Err:      err,
-	}
+	return newUserError(msg, cause, fix, errcode.NetworkError, err)
 }
 
 // NewInputError creates an input validation error with exit code ExitInput.
@@ -233,6 +343,7 @@ func NewInputError(msg, cause, fix string) *UserError {
 		Cause:    cause,
 		Fix:      fix,
 		ExitCode: ExitInput,
+		Code:     errcode.InvalidInput,
 		Err:      nil, // Input errors typically don't wrap underlying errors
 	}
 }
@@ -251,13 +362,7 @@ func NewInputError(msg, cause, fix string) *UserError {
 //	    err,
 //	)
 func NewPermissionError(msg, cause, fix string, err error) *UserError {
-	return &UserError{
-		Message:  msg,
-		Cause:    cause,
-		Fix:      fix,
-		ExitCode: ExitPermission,
-		Err:      err,
-	}
+	return newUserError(msg, cause, fix, errcode.PermissionDenied, err)
 }
 
 // NewNotFoundError creates a resource not found error with exit code ExitNotFound.
@@ -278,6 +383,7 @@ func NewNotFoundError(msg, cause, fix string) *UserError {
 		Cause:    cause,
 		Fix:      fix,
 		ExitCode: ExitNotFound,
+		Code:     errcode.NotFound,
 		Err:      nil, // Not found errors typically don't wrap underlying errors
 	}
 }
@@ -297,13 +403,7 @@ func NewNotFoundError(msg, cause, fix string) *UserError {
 //	    err,
 //	)
 func NewInternalError(msg, cause, fix string, err error) *UserError {
-	return &UserError{
-		Message:  msg,
-		Cause:    cause,
-		Fix:      fix,
-		ExitCode: ExitInternal,
-		Err:      err,
-	}
+	return newUserError(msg, cause, fix, errcode.Internal, err)
 }
 
 // Color definitions for error formatting.
@@ -363,10 +463,11 @@ func (e *UserError) Format(noColor bool) string {
 // This structure is suitable for machine consumption and integrates with
 // CLI commands that support --json output mode.
 type ErrorJSON struct {
-	Error    string `json:"error"`
-	Cause    string `json:"cause,omitempty"`
-	Fix      string `json:"fix,omitempty"`
-	ExitCode int    `json:"exit_code"`
+	Error    string       `json:"error"`
+	Code     errcode.Code `json:"code"`
+	Cause    string       `json:"cause,omitempty"`
+	Fix      string       `json:"fix,omitempty"`
+	ExitCode int          `json:"exit_code"`
 }
 
 // ToJSON converts the UserError to a JSON-serializable structure.
@@ -377,6 +478,7 @@ type ErrorJSON struct {
 func (e *UserError) ToJSON() ErrorJSON {
 	return ErrorJSON{
 		Error:    e.Message,
+		Code:     e.ErrorCode(),
 		Cause:    e.Cause,
 		Fix:      e.Fix,
 		ExitCode: e.ExitCode,
@@ -416,5 +518,9 @@ func FatalError(err error, jsonOutput bool) {
 
 	// Fallback for non-UserError
 	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-	os.Exit(ExitInternal)
+	code := errcode.Of(err)
+	if code == "" {
+		code = errcode.Internal
+	}
+	os.Exit(ExitCodeFor(code))
 }
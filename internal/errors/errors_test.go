@@ -10,6 +10,8 @@ import (
 	"os"
 	"strings"
 	"testing"
+
+	"github.com/kraklabs/cie/pkg/errcode"
 )
 
 // TestUserError_Error verifies the Error() method implementation.
@@ -121,6 +123,12 @@ func TestExitCodes(t *testing.T) {
 		{"ExitPermission", ExitPermission, 5},
 		{"ExitNotFound", ExitNotFound, 6},
 		{"ExitInternal", ExitInternal, 10},
+		{"ExitDBLocked", ExitDBLocked, 11},
+		{"ExitDBCorrupt", ExitDBCorrupt, 12},
+		{"ExitSchemaMismatch", ExitSchemaMismatch, 13},
+		{"ExitProviderUnreachable", ExitProviderUnreachable, 14},
+		{"ExitTimeout", ExitTimeout, 15},
+		{"ExitParseLimit", ExitParseLimit, 16},
 	}
 
 	for _, tt := range tests {
@@ -143,6 +151,12 @@ func TestExitCodes_Uniqueness(t *testing.T) {
 		ExitPermission,
 		ExitNotFound,
 		ExitInternal,
+		ExitDBLocked,
+		ExitDBCorrupt,
+		ExitSchemaMismatch,
+		ExitProviderUnreachable,
+		ExitTimeout,
+		ExitParseLimit,
 	}
 
 	seen := make(map[int]bool)
@@ -557,3 +571,44 @@ func TestFatalError(t *testing.T) {
 	//   go run cmd/cie/main.go <invalid-command>
 	//   # Should show colored error and exit with proper code
 }
+
+// TestConstructors_Code verifies that constructors refine the category code
+// from the wrapped error and map it to an exit code.
+func TestConstructors_Code(t *testing.T) {
+	locked := NewDatabaseError("Cannot open CIE database", "", "",
+		fmt.Errorf("IO error: While lock file: /tmp/db/LOCK: Resource temporarily unavailable"))
+	if locked.Code != errcode.DBLocked || locked.ExitCode != ExitDBLocked {
+		t.Errorf("locked: Code = %q, ExitCode = %d", locked.Code, locked.ExitCode)
+	}
+
+	generic := NewDatabaseError("Cannot open CIE database", "", "", fmt.Errorf("underlying error"))
+	if generic.Code != errcode.DBError || generic.ExitCode != ExitDatabase {
+		t.Errorf("generic: Code = %q, ExitCode = %d", generic.Code, generic.ExitCode)
+	}
+
+	input := NewInputError("Bad flag", "", "")
+	if got := input.ToJSON().Code; got != errcode.InvalidInput {
+		t.Errorf("input ToJSON().Code = %q, want %q", got, errcode.InvalidInput)
+	}
+
+	provider := NewNetworkError("Embedding failed", "", "", nil).WithCode(errcode.ProviderUnreachable)
+	if provider.ExitCode != ExitProviderUnreachable {
+		t.Errorf("WithCode: ExitCode = %d, want %d", provider.ExitCode, ExitProviderUnreachable)
+	}
+}
+
+// TestErrorCode_FromExitCode verifies that UserErrors built as literals
+// still report a code matching their exit code.
+func TestErrorCode_FromExitCode(t *testing.T) {
+	for _, code := range []errcode.Code{
+		errcode.ConfigInvalid, errcode.DBError, errcode.DBLocked, errcode.DBCorrupt,
+		errcode.SchemaMismatch, errcode.NetworkError, errcode.ProviderUnreachable,
+		errcode.Timeout, errcode.InvalidInput, errcode.PermissionDenied,
+		errcode.NotFound, errcode.ParseLimit, errcode.Internal,
+	} {
+		e := &UserError{Message: "x", ExitCode: ExitCodeFor(code)}
+		if got := e.ErrorCode(); got != code {
+			t.Errorf("exit %d: ErrorCode() = %q, want %q", e.ExitCode, got, code)
+		}
+	}
+}
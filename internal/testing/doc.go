@@ -48,6 +48,27 @@
 //   - InsertTestCalls: Link caller to callee
 //   - InsertTestImport: Record an import statement
 //
+// # Seeding Embeddings
+//
+// SetupTestBackendWithHNSW creates a backend with small vectors and HNSW
+// indexes. SeedSemanticFunction inserts a function, its code and a
+// TestVector of that code, so semantic-search tools can be integration
+// tested without an embedding provider:
+//
+//	backend := testing.SetupTestBackendWithHNSW(t, 8)
+//	testing.SeedSemanticFunction(t, backend, "f1", "Login", "auth.go", code)
+//	// Serve testing.TestVector(code, 8) from a stub embedding server...
+//
+// # Synthetic Repositories
+//
+// GenerateRepo writes a reproducible source tree for scaling tests and
+// benchmarks. RepoSpec controls the number of packages, functions per
+// package and per file, average calls per function, the share of
+// cross-package calls, and the languages emitted:
+//
+//	repo := testing.GenerateRepo(b, testing.RepoSpec{Packages: 100, CallDensity: 3})
+//	// repo.Root holds the files; repo.Functions and repo.Calls give the expected counts.
+//
 // # Querying Test Data
 //
 // Helper functions for common queries:
@@ -55,6 +76,14 @@
 //   - QueryFiles: Get all files
 //   - QueryTypes: Get all types
 //
+// # Mock Providers
+//
+// The mocks subpackage (internal/testing/mocks) builds deterministic
+// embedding and LLM providers driven by a Scenario: injected latency,
+// failures (the first N calls or a seeded rate) and canned responses
+// selected by regex. Flaky, Slow and Outage return common scenarios for
+// exercising retry and fallback paths.
+//
 // # Integration with Root Testcontainers
 //
 // For tests that require Docker/testcontainers, use the root-level
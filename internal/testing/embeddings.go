@@ -0,0 +1,160 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package testing
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// SetupTestBackendWithHNSW creates an in-memory CIE backend whose embedding
+// relations use dim-sized vectors and whose HNSW indexes already exist, so
+// semantic-search queries can run against seeded vectors.
+//
+// Example:
+//
+//	backend := testing.SetupTestBackendWithHNSW(t, 8)
+//	testing.SeedSemanticFunction(t, backend, "f1", "Login", "auth.go", "func Login() {}")
+func SetupTestBackendWithHNSW(t *testing.T, dim int) *storage.EmbeddedBackend {
+	t.Helper()
+
+	backend, err := storage.NewEmbeddedBackend(storage.EmbeddedConfig{
+		Engine:              "mem",
+		DataDir:             t.TempDir(),
+		EmbeddingDimensions: dim,
+	})
+	if err != nil {
+		t.Fatalf("failed to create test backend: %v", err)
+	}
+	t.Cleanup(func() {
+		backend.Close()
+	})
+
+	if err := backend.EnsureSchema(); err != nil {
+		t.Fatalf("failed to ensure schema: %v", err)
+	}
+	CreateTestHNSWIndex(t, backend, dim)
+	return backend
+}
+
+// CreateTestHNSWIndex creates the function and type HNSW indexes. The backend
+// schema must have been created with the same dimension.
+func CreateTestHNSWIndex(t *testing.T, backend *storage.EmbeddedBackend, dim int) {
+	t.Helper()
+
+	if err := backend.CreateHNSWIndex(dim); err != nil {
+		t.Fatalf("failed to create HNSW index: %v", err)
+	}
+}
+
+// TestVector returns a deterministic unit vector for text, identical to what
+// the "mock" embedding provider produces. Seeding with TestVector(code) and
+// querying with TestVector(code) makes that function the nearest neighbour.
+//
+// The hash is reimplemented here rather than imported: pkg/ingestion depends
+// on pkg/tools, whose tests use this package.
+func TestVector(text string, dim int) []float32 {
+	var hash uint64 = 5381
+	for _, c := range text {
+		hash = ((hash << 5) + hash) + uint64(c)
+	}
+
+	vec := make([]float32, dim)
+	var norm float64
+	for i := range vec {
+		val := float32((hash+uint64(i)*7919)%10000) / 10000.0 //nolint:gosec // i is bounded by dim
+		vec[i] = val*2.0 - 1.0
+		norm += float64(vec[i]) * float64(vec[i])
+	}
+	if norm > 0 {
+		n := float32(math.Sqrt(norm))
+		for i := range vec {
+			vec[i] /= n
+		}
+	}
+	return vec
+}
+
+// InsertTestEmbedding stores an embedding for a function.
+//
+// Example:
+//
+//	testing.InsertTestEmbedding(t, backend, "func_123", testing.TestVector("login", 8))
+func InsertTestEmbedding(t *testing.T, backend *storage.EmbeddedBackend, functionID string, vec []float32) {
+	t.Helper()
+
+	query := `?[function_id, embedding] <- [[$function_id, ` + formatTestVector(vec) + `]]
+	:put cie_function_embedding { function_id, embedding }`
+	if _, err := backend.DB().Run(query, map[string]any{"function_id": functionID}); err != nil {
+		t.Fatalf("failed to insert test embedding: %v", err)
+	}
+}
+
+// InsertTestTypeEmbedding stores an embedding for a type.
+func InsertTestTypeEmbedding(t *testing.T, backend *storage.EmbeddedBackend, typeID string, vec []float32) {
+	t.Helper()
+
+	query := `?[type_id, embedding] <- [[$type_id, ` + formatTestVector(vec) + `]]
+	:put cie_type_embedding { type_id, embedding }`
+	if _, err := backend.DB().Run(query, map[string]any{"type_id": typeID}); err != nil {
+		t.Fatalf("failed to insert test type embedding: %v", err)
+	}
+}
+
+// InsertTestFunctionCode stores the source text of a function. Semantic
+// search joins on it, so seeded functions need code to be returned.
+func InsertTestFunctionCode(t *testing.T, backend *storage.EmbeddedBackend, functionID, code string) {
+	t.Helper()
+
+	query := `?[function_id, code_text] <- [[$function_id, $code_text]]
+	:put cie_function_code { function_id, code_text }`
+	if _, err := backend.DB().Run(query, map[string]any{"function_id": functionID, "code_text": code}); err != nil {
+		t.Fatalf("failed to insert test function code: %v", err)
+	}
+}
+
+// SeedSemanticFunction inserts a function with its code and an embedding of
+// that code, sized to the backend's embedding dimension.
+func SeedSemanticFunction(t *testing.T, backend *storage.EmbeddedBackend, id, name, filePath, code string) {
+	t.Helper()
+
+	lines := strings.Count(code, "\n") + 1
+	InsertTestFunctionWithSignature(t, backend, id, name, firstLine(code), filePath, 1, lines)
+	InsertTestFunctionCode(t, backend, id, code)
+	InsertTestEmbedding(t, backend, id, TestVector(code, backend.EmbeddingDimensions()))
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func formatTestVector(vec []float32) string {
+	parts := make([]string, len(vec))
+	for i, v := range vec {
+		parts[i] = strconv.FormatFloat(float64(v), 'g', -1, 32)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
@@ -0,0 +1,85 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package testing
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kraklabs/cie/pkg/ingestion"
+)
+
+// TestTestVector verifies vectors are deterministic, unit length and distinct
+// for distinct texts.
+func TestTestVector(t *testing.T) {
+	a := TestVector("func Login() {}", 16)
+	require.Len(t, a, 16)
+	assert.Equal(t, a, TestVector("func Login() {}", 16))
+	assert.NotEqual(t, a, TestVector("func Logout() {}", 16))
+
+	var norm float64
+	for _, v := range a {
+		norm += float64(v) * float64(v)
+	}
+	assert.InDelta(t, 1.0, math.Sqrt(norm), 1e-5)
+
+	mock, err := ingestion.NewMockEmbeddingProvider(16, nil).Embed(context.Background(), "func Login() {}")
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, mock, a, 1e-6, "TestVector should match the mock provider")
+	assert.Equal(t, "[1, -0.5, 0.25]", formatTestVector([]float32{1, -0.5, 0.25}))
+}
+
+// TestSeedSemanticFunction verifies seeded functions are found by an HNSW
+// query using the same deterministic vector.
+func TestSeedSemanticFunction(t *testing.T) {
+	backend := SetupTestBackendWithHNSW(t, 8)
+	login := "func Login(user string) error {\n\treturn nil\n}"
+	SeedSemanticFunction(t, backend, "f1", "Login", "auth.go", login)
+	SeedSemanticFunction(t, backend, "f2", "Render", "view.go", "func Render() {}")
+
+	query := `?[name, distance] :=
+		~cie_function_embedding:embedding_idx { function_id | query: q, k: 2, ef: 50, bind_distance: distance },
+		q = vec(` + formatTestVector(TestVector(login, 8)) + `),
+		*cie_function { id: function_id, name }
+		:order distance`
+	result, err := backend.Query(context.Background(), query)
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Rows)
+	assert.Equal(t, "Login", result.Rows[0][0])
+
+	code, err := backend.Query(context.Background(), `?[code_text] := *cie_function_code { function_id: "f1", code_text }`)
+	require.NoError(t, err)
+	require.Len(t, code.Rows, 1)
+	assert.Equal(t, login, code.Rows[0][0])
+}
+
+// TestInsertTestTypeEmbedding verifies type vectors can be seeded.
+func TestInsertTestTypeEmbedding(t *testing.T) {
+	backend := SetupTestBackendWithHNSW(t, 4)
+	InsertTestType(t, backend, "t1", "User", "struct", "user.go", 1, 5)
+	InsertTestTypeEmbedding(t, backend, "t1", TestVector("type User struct{}", 4))
+
+	result, err := backend.Query(context.Background(), "?[type_id] := *cie_type_embedding { type_id }")
+	require.NoError(t, err)
+	assert.Len(t, result.Rows, 1)
+}
@@ -0,0 +1,396 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package testing
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// SyntheticModule is the Go module path declared by generated repositories.
+const SyntheticModule = "example.com/synth"
+
+// RepoSpec describes the shape of a synthetic repository.
+//
+// Zero values fall back to the defaults noted on each field, so
+// RepoSpec{Packages: 200} is enough for a quick scaling run.
+type RepoSpec struct {
+	// Packages is the number of top-level packages (directories). Default 10.
+	Packages int
+
+	// FunctionsPerPackage is the number of functions in each package. Default 20.
+	FunctionsPerPackage int
+
+	// FunctionsPerFile caps how many functions go into one file. Default 10.
+	FunctionsPerFile int
+
+	// CallDensity is the average number of outgoing calls per function.
+	// The fractional part is applied randomly. Default 2.
+	CallDensity float64
+
+	// CrossPackageRatio is the fraction of calls that target a function in
+	// another package of the same language. Default 0.2.
+	CrossPackageRatio float64
+
+	// Languages lists the languages to emit: "go", "python", "typescript"
+	// and "javascript". Packages are assigned round-robin. Default ["go"].
+	Languages []string
+
+	// Seed makes generation reproducible. Default 1.
+	Seed int64
+}
+
+// SyntheticRepo describes a repository written by WriteSyntheticRepo.
+type SyntheticRepo struct {
+	// Root is the absolute directory holding the repository.
+	Root string
+
+	// Spec is the spec after defaults were applied.
+	Spec RepoSpec
+
+	// Files lists the generated source files, relative to Root and sorted.
+	Files []string
+
+	// Functions is the total number of generated functions.
+	Functions int
+
+	// Calls is the number of call sites emitted. A function never calls the
+	// same callee twice, so each call site is one distinct call edge.
+	// SameFileCalls and CrossPackageCalls count the calls whose callee is in
+	// the caller's file or in another package; the rest cross files within
+	// one Go package.
+	Calls             int
+	SameFileCalls     int
+	CrossPackageCalls int
+
+	// Languages maps each language to its number of files.
+	Languages map[string]int
+}
+
+// syntheticFunc is a function in the in-memory model of the repository.
+type syntheticFunc struct {
+	pkg, file, index int
+	calls            []*syntheticFunc
+}
+
+type syntheticFile struct {
+	pkg, index int
+	funcs      []*syntheticFunc
+}
+
+type syntheticPackage struct {
+	index    int
+	language string
+	files    []*syntheticFile
+	funcs    []*syntheticFunc
+}
+
+// GenerateRepo writes a synthetic repository into a temporary directory that
+// is removed when the test or benchmark finishes.
+//
+// Example:
+//
+//	repo := testing.GenerateRepo(b, testing.RepoSpec{Packages: 50, CallDensity: 3})
+//	// Index or parse repo.Root...
+func GenerateRepo(tb testing.TB, spec RepoSpec) *SyntheticRepo {
+	tb.Helper()
+	repo, err := WriteSyntheticRepo(tb.TempDir(), spec)
+	if err != nil {
+		tb.Fatalf("generate synthetic repo: %v", err)
+	}
+	return repo
+}
+
+// WriteSyntheticRepo generates a repository described by spec under dir.
+// The same spec always produces byte-identical files.
+func WriteSyntheticRepo(dir string, spec RepoSpec) (*SyntheticRepo, error) {
+	spec = spec.withDefaults()
+	for _, lang := range spec.Languages {
+		if _, ok := syntheticExt[lang]; !ok {
+			return nil, fmt.Errorf("unsupported synthetic language %q", lang)
+		}
+	}
+
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	repo := &SyntheticRepo{Root: root, Spec: spec, Languages: make(map[string]int)}
+
+	pkgs := buildSyntheticModel(spec, repo)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.files {
+			rel := syntheticFilePath(pkg.language, file.pkg, file.index)
+			if err := writeSyntheticFile(root, rel, renderSyntheticFile(pkg.language, file)); err != nil {
+				return nil, err
+			}
+			repo.Files = append(repo.Files, rel)
+			repo.Languages[pkg.language]++
+		}
+	}
+	if repo.Languages["go"] > 0 {
+		gomod := fmt.Sprintf("module %s\n\ngo 1.22\n", SyntheticModule)
+		if err := writeSyntheticFile(root, "go.mod", gomod); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(repo.Files)
+	return repo, nil
+}
+
+func (s RepoSpec) withDefaults() RepoSpec {
+	if s.Packages <= 0 {
+		s.Packages = 10
+	}
+	if s.FunctionsPerPackage <= 0 {
+		s.FunctionsPerPackage = 20
+	}
+	if s.FunctionsPerFile <= 0 {
+		s.FunctionsPerFile = 10
+	}
+	if s.CallDensity <= 0 {
+		s.CallDensity = 2
+	}
+	if s.CrossPackageRatio <= 0 {
+		s.CrossPackageRatio = 0.2
+	}
+	if len(s.Languages) == 0 {
+		s.Languages = []string{"go"}
+	}
+	if s.Seed == 0 {
+		s.Seed = 1
+	}
+	return s
+}
+
+// buildSyntheticModel lays out packages, files and functions, then wires
+// calls. Cross-package calls only target lower-numbered packages, so the
+// generated Go import graph is acyclic.
+func buildSyntheticModel(spec RepoSpec, repo *SyntheticRepo) []*syntheticPackage {
+	rng := rand.New(rand.NewSource(spec.Seed)) //nolint:gosec // deterministic fixtures, not security
+
+	pkgs := make([]*syntheticPackage, spec.Packages)
+	byLang := make(map[string][]*syntheticPackage)
+	for p := range pkgs {
+		pkg := &syntheticPackage{index: p, language: spec.Languages[p%len(spec.Languages)]}
+		for i := 0; i < spec.FunctionsPerPackage; i++ {
+			fi := i / spec.FunctionsPerFile
+			if fi == len(pkg.files) {
+				pkg.files = append(pkg.files, &syntheticFile{pkg: p, index: fi})
+			}
+			fn := &syntheticFunc{pkg: p, file: fi, index: i}
+			pkg.files[fi].funcs = append(pkg.files[fi].funcs, fn)
+			pkg.funcs = append(pkg.funcs, fn)
+		}
+		pkgs[p] = pkg
+		repo.Functions += len(pkg.funcs)
+	}
+
+	for _, pkg := range pkgs {
+		for _, fn := range pkg.funcs {
+			n := int(spec.CallDensity)
+			if rng.Float64() < spec.CallDensity-float64(n) {
+				n++
+			}
+			seen := make(map[*syntheticFunc]bool, n)
+			for c := 0; c < n; c++ {
+				// Retry a few times so each call site names a distinct callee.
+				var callee *syntheticFunc
+				for attempt := 0; attempt < 4 && (callee == nil || seen[callee]); attempt++ {
+					callee = pickSyntheticCallee(rng, spec, pkg, fn, byLang[pkg.language])
+				}
+				if callee == nil || seen[callee] {
+					continue
+				}
+				seen[callee] = true
+				fn.calls = append(fn.calls, callee)
+				repo.Calls++
+				switch {
+				case callee.pkg != fn.pkg:
+					repo.CrossPackageCalls++
+				case callee.file == fn.file:
+					repo.SameFileCalls++
+				}
+			}
+		}
+		byLang[pkg.language] = append(byLang[pkg.language], pkg)
+	}
+	return pkgs
+}
+
+// pickSyntheticCallee chooses a call target for fn. earlier holds the
+// already-wired packages of the same language. Go resolves calls across
+// files of a package; the other languages only call within the same file.
+func pickSyntheticCallee(rng *rand.Rand, spec RepoSpec, pkg *syntheticPackage, fn *syntheticFunc, earlier []*syntheticPackage) *syntheticFunc {
+	if len(earlier) > 0 && rng.Float64() < spec.CrossPackageRatio {
+		target := earlier[rng.Intn(len(earlier))]
+		return target.funcs[rng.Intn(len(target.funcs))]
+	}
+
+	candidates := pkg.funcs
+	if pkg.language != "go" {
+		candidates = pkg.files[fn.file].funcs
+	}
+	if len(candidates) < 2 {
+		return nil
+	}
+	callee := candidates[rng.Intn(len(candidates)-1)]
+	if callee == fn {
+		callee = candidates[len(candidates)-1]
+	}
+	return callee
+}
+
+var syntheticExt = map[string]string{
+	"go":         ".go",
+	"python":     ".py",
+	"typescript": ".ts",
+	"javascript": ".js",
+}
+
+func syntheticPkgName(p int) string { return fmt.Sprintf("pkg%03d", p) }
+
+func syntheticModName(f int) string { return fmt.Sprintf("mod%02d", f) }
+
+func syntheticFilePath(lang string, p, f int) string {
+	return filepath.ToSlash(filepath.Join(syntheticPkgName(p), syntheticModName(f)+syntheticExt[lang]))
+}
+
+// syntheticFuncName returns the declared name of fn in the given language.
+func syntheticFuncName(lang string, fn *syntheticFunc) string {
+	switch lang {
+	case "go":
+		return fmt.Sprintf("Fn%04d", fn.index)
+	case "python":
+		return fmt.Sprintf("fn_%04d", fn.index)
+	default:
+		return fmt.Sprintf("fn%04d", fn.index)
+	}
+}
+
+// syntheticModuleAlias names the import binding for the module holding fn.
+func syntheticModuleAlias(fn *syntheticFunc) string {
+	return syntheticPkgName(fn.pkg) + "_" + syntheticModName(fn.file)
+}
+
+// syntheticCallExpr renders a call from file to callee, qualified when the
+// callee lives elsewhere.
+func syntheticCallExpr(lang string, file *syntheticFile, callee *syntheticFunc) string {
+	name := syntheticFuncName(lang, callee)
+	switch {
+	case callee.pkg == file.pkg && (lang == "go" || callee.file == file.index):
+		return name
+	case lang == "go":
+		return syntheticPkgName(callee.pkg) + "." + name
+	default:
+		return syntheticModuleAlias(callee) + "." + name
+	}
+}
+
+func renderSyntheticFile(lang string, file *syntheticFile) string {
+	imports := make(map[string]*syntheticFunc)
+	for _, fn := range file.funcs {
+		for _, callee := range fn.calls {
+			if callee.pkg == file.pkg {
+				continue
+			}
+			key := syntheticPkgName(callee.pkg)
+			if lang != "go" {
+				key = syntheticModuleAlias(callee)
+			}
+			imports[key] = callee
+		}
+	}
+	keys := make([]string, 0, len(imports))
+	for k := range imports {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	switch lang {
+	case "go":
+		fmt.Fprintf(&sb, "// Code generated by internal/testing.WriteSyntheticRepo. DO NOT EDIT.\n\npackage %s\n", syntheticPkgName(file.pkg))
+		if len(keys) > 0 {
+			sb.WriteString("\nimport (\n")
+			for _, k := range keys {
+				fmt.Fprintf(&sb, "\t%q\n", SyntheticModule+"/"+k)
+			}
+			sb.WriteString(")\n")
+		}
+	case "python":
+		for _, k := range keys {
+			callee := imports[k]
+			fmt.Fprintf(&sb, "import %s.%s as %s\n", syntheticPkgName(callee.pkg), syntheticModName(callee.file), k)
+		}
+	default:
+		for _, k := range keys {
+			callee := imports[k]
+			fmt.Fprintf(&sb, "import * as %s from \"../%s/%s\";\n", k, syntheticPkgName(callee.pkg), syntheticModName(callee.file))
+		}
+	}
+
+	for _, fn := range file.funcs {
+		sb.WriteString("\n")
+		renderSyntheticFunc(&sb, lang, file, fn)
+	}
+	return sb.String()
+}
+
+func renderSyntheticFunc(sb *strings.Builder, lang string, file *syntheticFile, fn *syntheticFunc) {
+	name := syntheticFuncName(lang, fn)
+	switch lang {
+	case "go":
+		fmt.Fprintf(sb, "// %s is synthetic function %d of %s.\nfunc %s(x int) int {\n", name, fn.index, syntheticPkgName(fn.pkg), name)
+		for _, callee := range fn.calls {
+			fmt.Fprintf(sb, "\tx = %s(x)\n", syntheticCallExpr(lang, file, callee))
+		}
+		fmt.Fprintf(sb, "\treturn x + %d\n}\n", fn.index)
+	case "python":
+		fmt.Fprintf(sb, "def %s(x):\n", name)
+		fmt.Fprintf(sb, "    \"\"\"Synthetic function %d of %s.\"\"\"\n", fn.index, syntheticPkgName(fn.pkg))
+		for _, callee := range fn.calls {
+			fmt.Fprintf(sb, "    x = %s(x)\n", syntheticCallExpr(lang, file, callee))
+		}
+		fmt.Fprintf(sb, "    return x + %d\n", fn.index)
+	default:
+		sig := "(x)"
+		if lang == "typescript" {
+			sig = "(x: number): number"
+		}
+		fmt.Fprintf(sb, "// Synthetic function %d of %s.\nexport function %s%s {\n", fn.index, syntheticPkgName(fn.pkg), name, sig)
+		for _, callee := range fn.calls {
+			fmt.Fprintf(sb, "  x = %s(x);\n", syntheticCallExpr(lang, file, callee))
+		}
+		fmt.Fprintf(sb, "  return x + %d;\n}\n", fn.index)
+	}
+}
+
+func writeSyntheticFile(root, rel, content string) error {
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0o600)
+}
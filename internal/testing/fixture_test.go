@@ -0,0 +1,132 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package testing
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kraklabs/cie/pkg/ingestion"
+)
+
+// TestWriteSyntheticRepo_Deterministic verifies the same spec yields the same
+// files byte for byte.
+func TestWriteSyntheticRepo_Deterministic(t *testing.T) {
+	spec := RepoSpec{Packages: 6, FunctionsPerPackage: 12, CallDensity: 2.5, Languages: []string{"go", "python", "typescript"}, Seed: 42}
+	a := GenerateRepo(t, spec)
+	b := GenerateRepo(t, spec)
+
+	require.Equal(t, a.Files, b.Files)
+	assert.Equal(t, a.Calls, b.Calls)
+	for _, rel := range a.Files {
+		x, err := os.ReadFile(filepath.Join(a.Root, rel))
+		require.NoError(t, err)
+		y, err := os.ReadFile(filepath.Join(b.Root, rel))
+		require.NoError(t, err)
+		assert.Equal(t, string(x), string(y), rel)
+	}
+
+	spec.Seed = 7
+	other := GenerateRepo(t, spec)
+	assert.NotEqual(t, readSyntheticRepo(t, a), readSyntheticRepo(t, other), "different seeds should wire different calls")
+}
+
+func readSyntheticRepo(t *testing.T, repo *SyntheticRepo) string {
+	t.Helper()
+	var sb strings.Builder
+	for _, rel := range repo.Files {
+		data, err := os.ReadFile(filepath.Join(repo.Root, rel))
+		require.NoError(t, err)
+		sb.Write(data)
+	}
+	return sb.String()
+}
+
+// TestWriteSyntheticRepo_Shape verifies counts, layout and language split.
+func TestWriteSyntheticRepo_Shape(t *testing.T) {
+	repo := GenerateRepo(t, RepoSpec{Packages: 4, FunctionsPerPackage: 25, FunctionsPerFile: 10, Languages: []string{"go", "javascript"}})
+
+	assert.Equal(t, 100, repo.Functions)
+	assert.Equal(t, map[string]int{"go": 6, "javascript": 6}, repo.Languages)
+	assert.Len(t, repo.Files, 12)
+	assert.Contains(t, repo.Files, "pkg000/mod02.go")
+	assert.Contains(t, repo.Files, "pkg001/mod00.js")
+	assert.FileExists(t, filepath.Join(repo.Root, "go.mod"))
+
+	// Default density is 2 calls per function; only same-file candidates
+	// can shrink it, and every package here has more than one function.
+	assert.Equal(t, 200, repo.Calls)
+	assert.Greater(t, repo.CrossPackageCalls, 0)
+	assert.Less(t, repo.CrossPackageCalls, repo.Calls)
+}
+
+// TestWriteSyntheticRepo_UnsupportedLanguage verifies unknown languages are rejected.
+func TestWriteSyntheticRepo_UnsupportedLanguage(t *testing.T) {
+	_, err := WriteSyntheticRepo(t.TempDir(), RepoSpec{Languages: []string{"cobol"}})
+	assert.Error(t, err)
+}
+
+// TestWriteSyntheticRepo_GoResolves verifies generated Go is syntactically
+// valid, that the indexer's parser sees every function, and that every
+// package-qualified call left for the resolver maps to a local function.
+func TestWriteSyntheticRepo_GoResolves(t *testing.T) {
+	repo := GenerateRepo(t, RepoSpec{Packages: 5, FunctionsPerPackage: 15, CallDensity: 3, CrossPackageRatio: 0.4})
+
+	p := ingestion.NewTreeSitterParser(nil)
+	var (
+		files      []ingestion.FileEntity
+		functions  []ingestion.FunctionEntity
+		imports    []ingestion.ImportEntity
+		unresolved []ingestion.UnresolvedCall
+		qualified  int
+	)
+	packageNames := make(map[string]string)
+	fset := token.NewFileSet()
+	for _, rel := range repo.Files {
+		full := filepath.Join(repo.Root, rel)
+		_, err := parser.ParseFile(fset, full, nil, parser.AllErrors)
+		require.NoError(t, err, rel)
+
+		res, err := p.ParseFile(ingestion.FileInfo{Path: rel, FullPath: full, Language: "go"})
+		require.NoError(t, err, rel)
+		files = append(files, res.File)
+		functions = append(functions, res.Functions...)
+		imports = append(imports, res.Imports...)
+		packageNames[rel] = res.PackageName
+		for _, call := range res.UnresolvedCalls {
+			if strings.HasPrefix(call.CalleeName, "pkg") {
+				qualified++
+				unresolved = append(unresolved, call)
+			}
+		}
+	}
+	require.Len(t, functions, repo.Functions)
+	require.NotZero(t, qualified)
+
+	resolver := ingestion.NewCallResolver()
+	resolver.BuildIndex(files, functions, imports, packageNames)
+	assert.Len(t, resolver.ResolveCalls(unresolved), qualified)
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+// Package mocks provides scriptable embedding and LLM providers for tests.
+//
+// It is separate from internal/testing because it depends on pkg/ingestion,
+// which itself depends on pkg/tools; keeping it apart lets pkg/tools tests
+// use the database helpers in internal/testing without an import cycle.
+package mocks
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/kraklabs/cie/pkg/ingestion"
+	"github.com/kraklabs/cie/pkg/llm"
+)
+
+// Scenario describes how a mock embedding or LLM provider should behave in a
+// test. The zero value is a fast, always-successful provider.
+//
+// Example:
+//
+//	provider := mocks.NewMockEmbeddingProvider(t, 768, mocks.Scenario{
+//	    FailFirst: 2, // exercise the retry path
+//	})
+type Scenario struct {
+	Latency     time.Duration // added to every call
+	Jitter      time.Duration // up to this much extra latency, seeded
+	FailFirst   int           // first N calls fail
+	FailureRate float64       // fraction (0-1) of later calls that fail
+	Err         error         // failure error; defaults to a retryable 503
+	Seed        int64         // makes Jitter and FailureRate reproducible
+	Responses   []ScenarioResponse
+}
+
+// ScenarioResponse is a canned reply for inputs matching Pattern, a Go
+// regular expression. Text is used by LLM mocks, Vector by embedding mocks.
+type ScenarioResponse struct {
+	Pattern string
+	Text    string
+	Vector  []float32
+	Err     error
+}
+
+// Flaky returns a scenario whose first failFirst calls fail with a retryable
+// error and whose later calls fail at the given rate.
+func Flaky(failFirst int, rate float64) Scenario {
+	return Scenario{FailFirst: failFirst, FailureRate: rate, Seed: 1}
+}
+
+// Slow returns a scenario that adds latency to every call.
+func Slow(latency time.Duration) Scenario {
+	return Scenario{Latency: latency}
+}
+
+// Outage returns a scenario in which every call fails with err (or the
+// default retryable error when err is nil).
+func Outage(err error) Scenario {
+	return Scenario{FailureRate: 1, Err: err}
+}
+
+// NewMockEmbeddingProvider returns a deterministic embedding provider that
+// follows the scenario.
+func NewMockEmbeddingProvider(t *testing.T, dimension int, s Scenario) *ingestion.MockEmbeddingProvider {
+	t.Helper()
+	responses := make([]ingestion.MockEmbeddingResponse, 0, len(s.Responses))
+	for _, r := range s.Responses {
+		responses = append(responses, ingestion.MockEmbeddingResponse{
+			Pattern: compileScenarioPattern(t, r.Pattern),
+			Vector:  r.Vector,
+			Err:     r.Err,
+		})
+	}
+	provider := ingestion.NewMockEmbeddingProvider(dimension, nil)
+	provider.SetBehavior(ingestion.MockEmbeddingBehavior{
+		Latency:     s.Latency,
+		Jitter:      s.Jitter,
+		FailFirst:   s.FailFirst,
+		FailureRate: s.FailureRate,
+		Err:         s.Err,
+		Responses:   responses,
+		Seed:        s.Seed,
+	})
+	return provider
+}
+
+// NewMockLLM returns an LLM provider that follows the scenario. Prompts that
+// match no response get the mock's default "[mock] ..." reply.
+func NewMockLLM(t *testing.T, s Scenario) *llm.MockProvider {
+	t.Helper()
+	responses := make([]llm.MockResponse, 0, len(s.Responses))
+	for _, r := range s.Responses {
+		responses = append(responses, llm.MockResponse{
+			Pattern: compileScenarioPattern(t, r.Pattern),
+			Text:    r.Text,
+			Err:     r.Err,
+		})
+	}
+	return &llm.MockProvider{Behavior: llm.MockBehavior{
+		Latency:     s.Latency,
+		Jitter:      s.Jitter,
+		FailFirst:   s.FailFirst,
+		FailureRate: s.FailureRate,
+		Err:         s.Err,
+		Responses:   responses,
+		Seed:        s.Seed,
+	}}
+}
+
+func compileScenarioPattern(t *testing.T, pattern string) *regexp.Regexp {
+	t.Helper()
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		t.Fatalf("invalid scenario pattern %q: %v", pattern, err)
+	}
+	return re
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package mocks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kraklabs/cie/pkg/ingestion"
+	"github.com/kraklabs/cie/pkg/llm"
+)
+
+// TestMockEmbeddingProvider_Flaky verifies the first calls fail and retries
+// in the embedding generator recover from them.
+func TestMockEmbeddingProvider_Flaky(t *testing.T) {
+	provider := NewMockEmbeddingProvider(t, 8, Flaky(2, 0))
+
+	gen := ingestion.NewEmbeddingGenerator(provider, 1, nil)
+	gen.SetRetryConfig(ingestion.RetryConfig{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Multiplier: 1})
+
+	res, err := gen.EmbedFunctions(context.Background(), []ingestion.FunctionEntity{
+		{ID: "f1", Name: "Run", CodeText: "func Run() {}"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, res.ErrorCount, "retries should absorb the scripted failures")
+	assert.Len(t, res.Functions[0].Embedding, 8)
+	assert.Equal(t, 3, provider.Calls())
+}
+
+// TestMockEmbeddingProvider_CannedAndOutage verifies canned vectors and
+// permanent failures.
+func TestMockEmbeddingProvider_CannedAndOutage(t *testing.T) {
+	ctx := context.Background()
+	provider := NewMockEmbeddingProvider(t, 3, Scenario{Responses: []ScenarioResponse{
+		{Pattern: `(?i)auth`, Vector: []float32{1, 0, 0}},
+	}})
+	vec, err := provider.Embed(ctx, "func Authenticate()")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{1, 0, 0}, vec)
+
+	boom := errors.New("quota exceeded")
+	down := NewMockEmbeddingProvider(t, 3, Outage(boom))
+	_, err = down.Embed(ctx, "anything")
+	assert.ErrorIs(t, err, boom)
+}
+
+// TestMockEmbeddingProvider_FailureRateIsSeeded verifies two providers with the
+// same seed fail on the same calls.
+func TestMockEmbeddingProvider_FailureRateIsSeeded(t *testing.T) {
+	outcomes := func() []bool {
+		provider := NewMockEmbeddingProvider(t, 4, Scenario{FailureRate: 0.5, Seed: 42})
+		var out []bool
+		for i := 0; i < 20; i++ {
+			_, err := provider.Embed(context.Background(), "x")
+			out = append(out, err != nil)
+		}
+		return out
+	}
+	first := outcomes()
+	assert.Equal(t, first, outcomes())
+	assert.Contains(t, first, true)
+	assert.Contains(t, first, false)
+}
+
+// TestNewMockLLM verifies canned responses, latency and scripted failures.
+func TestNewMockLLM(t *testing.T) {
+	ctx := context.Background()
+	provider := NewMockLLM(t, Scenario{
+		FailFirst: 1,
+		Responses: []ScenarioResponse{{Pattern: `summar`, Text: "A small package."}},
+	})
+
+	_, err := provider.Generate(ctx, llm.GenerateRequest{Prompt: "summarize pkg/tools"})
+	assert.ErrorIs(t, err, llm.ErrMockUnavailable)
+
+	resp, err := provider.Generate(ctx, llm.GenerateRequest{Prompt: "summarize pkg/tools"})
+	require.NoError(t, err)
+	assert.Equal(t, "A small package.", resp.Text)
+
+	chat, err := provider.Chat(ctx, llm.ChatRequest{Messages: []llm.Message{{Role: "user", Content: "hello"}}})
+	require.NoError(t, err)
+	assert.Contains(t, chat.Message.Content, "[mock]")
+	assert.Equal(t, 3, provider.Calls())
+
+	slow := NewMockLLM(t, Slow(time.Second))
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = slow.Generate(cctx, llm.GenerateRequest{Prompt: "x"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,266 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+// Package artifact moves prebuilt CIE indexes between machines, so that
+// developers can fetch an index built by CI instead of indexing locally.
+//
+// An index travels as a bundle: a zstd-compressed tar holding
+// manifest.json (describing the index) and index.db (a CozoDB backup). A
+// delta bundle holds delta.json instead, the rows of the files that
+// changed between two indexed commits. A Store uploads and downloads
+// bundles of either kind. Open picks the store from a
+// reference:
+//
+//	oci://registry.example.com/team/repo:tag   OCI registry (oci:// optional)
+//	s3://bucket/path/index.tar.zst             S3 or an S3-compatible service
+//	file:///shared/indexes/repo.tar.zst        local or mounted path
+package artifact
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Bundle entry names.
+const (
+	ManifestEntry = "manifest.json"
+	DatabaseEntry = "index.db"
+	DeltaEntry    = "delta.json"
+)
+
+// Media types of the OCI artifact.
+const (
+	ArtifactType      = "application/vnd.kraklabs.cie.index.v1"
+	ManifestMediaType = "application/vnd.kraklabs.cie.manifest.v1+json"
+	BundleMediaType   = "application/vnd.kraklabs.cie.index.v1.tar+zstd"
+)
+
+// Store uploads and downloads index bundles.
+type Store interface {
+	// Push uploads the bundle file at path. manifest is the bundle's
+	// manifest.json, for stores that keep it alongside the bundle.
+	Push(ctx context.Context, path string, manifest []byte) error
+	// Pull downloads the bundle into a new file at path.
+	Pull(ctx context.Context, path string) error
+	// String returns the reference the store was opened with.
+	String() string
+}
+
+// Open returns the store for ref.
+func Open(ref string) (Store, error) {
+	switch {
+	case strings.HasPrefix(ref, "s3://"):
+		return newS3Store(ref)
+	case strings.HasPrefix(ref, "file://"):
+		return &fileStore{path: strings.TrimPrefix(ref, "file://")}, nil
+	case strings.HasPrefix(ref, "oci://"):
+		return newOCIStore(strings.TrimPrefix(ref, "oci://"))
+	case strings.Contains(ref, "://"):
+		return nil, fmt.Errorf("unsupported artifact reference %q (use oci://, s3:// or file://)", ref)
+	case looksLikeOCIRef(ref):
+		return newOCIStore(ref)
+	}
+	return &fileStore{path: ref}, nil
+}
+
+// WriteBundle writes a bundle of manifest and the database backup at
+// dbPath to path.
+func WriteBundle(path string, manifest []byte, dbPath string) error {
+	db, err := os.Open(dbPath) //nolint:gosec // backup written by the caller
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+	info, err := db.Stat()
+	if err != nil {
+		return err
+	}
+	return writeTar(path, manifest, DatabaseEntry, db, info.Size())
+}
+
+// WriteDeltaBundle writes a delta bundle of manifest and delta to path.
+func WriteDeltaBundle(path string, manifest, delta []byte) error {
+	return writeTar(path, manifest, DeltaEntry, bytes.NewReader(delta), int64(len(delta)))
+}
+
+// writeTar writes a bundle of manifest and a second entry of size bytes
+// read from r.
+func writeTar(path string, manifest []byte, name string, r io.Reader, size int64) (err error) {
+	out, err := os.Create(path) //nolint:gosec // caller-chosen temp path
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	zw, err := zstd.NewWriter(out)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(zw)
+
+	if err := tw.WriteHeader(&tar.Header{Name: ManifestEntry, Mode: 0600, Size: int64(len(manifest))}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(manifest); err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: size}); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tw, r); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadBundle unpacks the bundle at path into dir and returns its manifest
+// and the path of the database backup.
+func ReadBundle(path, dir string) (manifest []byte, dbPath string, err error) {
+	err = readTar(path, func(name string, r io.Reader) error {
+		switch name {
+		case ManifestEntry:
+			manifest, err = io.ReadAll(io.LimitReader(r, 1<<20))
+			return err
+		case DatabaseEntry:
+			dbPath = filepath.Join(dir, DatabaseEntry)
+			return copyToFile(dbPath, r)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if manifest == nil || dbPath == "" {
+		return nil, "", fmt.Errorf("%s is not an index bundle: want %s and %s", path, ManifestEntry, DatabaseEntry)
+	}
+	return manifest, dbPath, nil
+}
+
+// ReadDeltaBundle reads the manifest and delta of the delta bundle at path.
+func ReadDeltaBundle(path string) (manifest, delta []byte, err error) {
+	err = readTar(path, func(name string, r io.Reader) error {
+		switch name {
+		case ManifestEntry:
+			manifest, err = io.ReadAll(io.LimitReader(r, 1<<20))
+			return err
+		case DeltaEntry:
+			delta, err = io.ReadAll(r)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if manifest == nil || delta == nil {
+		return nil, nil, fmt.Errorf("%s is not a delta bundle: want %s and %s", path, ManifestEntry, DeltaEntry)
+	}
+	return manifest, delta, nil
+}
+
+// readTar calls entry for each file in the bundle at path.
+func readTar(path string, entry func(name string, r io.Reader) error) error {
+	in, err := os.Open(path) //nolint:gosec // caller-chosen temp path
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	zr, err := zstd.NewReader(in)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read bundle: %w", err)
+		}
+		if err := entry(hdr.Name, tr); err != nil {
+			return fmt.Errorf("read %s: %w", hdr.Name, err)
+		}
+	}
+}
+
+// copyToFile writes r to a new file at path.
+func copyToFile(path string, r io.Reader) (err error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600) //nolint:gosec // caller-chosen path
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	_, err = io.Copy(f, r)
+	return err
+}
+
+// fileStore keeps the bundle at a local or mounted path.
+type fileStore struct {
+	path string
+}
+
+func (s *fileStore) Push(_ context.Context, path string, _ []byte) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0750); err != nil {
+		return err
+	}
+	src, err := os.Open(path) //nolint:gosec // bundle written by the caller
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+	// Written aside and renamed, so a concurrent pull never reads half a bundle.
+	tmp := s.path + ".tmp"
+	_ = os.Remove(tmp)
+	if err := copyToFile(tmp, src); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *fileStore) Pull(_ context.Context, path string) error {
+	src, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+	return copyToFile(path, src)
+}
+
+func (s *fileStore) String() string { return s.path }
@@ -0,0 +1,481 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ociManifestMediaType is the media type of the OCI image manifest that
+// carries the bundle.
+const ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+
+// ociStore keeps the bundle as an OCI artifact: the index manifest is the
+// config blob and the bundle the single layer. Registries that accept OCI
+// artifacts (GHCR, ECR, Harbor, Docker Hub, zot, distribution) can hold it.
+//
+// Credentials come from CIE_REGISTRY_USERNAME and CIE_REGISTRY_PASSWORD, or
+// from the auths in ~/.docker/config.json written by `docker login`.
+type ociStore struct {
+	ref    string
+	scheme string
+	host   string
+	repo   string
+	tag    string // tag or digest
+
+	username string
+	password string
+	token    string // bearer token, once fetched
+	client   *http.Client
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type ociManifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        ociDescriptor     `json:"config"`
+	Layers        []ociDescriptor   `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// looksLikeOCIRef reports whether a reference without a scheme names a
+// registry (registry.example.com/repo:tag) rather than a local path.
+func looksLikeOCIRef(ref string) bool {
+	if ref == "" || strings.ContainsAny(ref[:1], "/.~") || strings.HasSuffix(ref, ".zst") {
+		return false
+	}
+	host, rest, ok := strings.Cut(ref, "/")
+	if !ok || rest == "" {
+		return false
+	}
+	return strings.ContainsAny(host, ".:") || host == "localhost"
+}
+
+func newOCIStore(ref string) (*ociStore, error) {
+	host, rest, ok := strings.Cut(ref, "/")
+	if !ok || host == "" || rest == "" {
+		return nil, fmt.Errorf("invalid OCI reference %q: want registry/repository:tag", ref)
+	}
+	repo, tag := rest, "latest"
+	if i := strings.Index(rest, "@"); i >= 0 {
+		repo, tag = rest[:i], rest[i+1:]
+	} else if i := strings.LastIndex(rest, ":"); i >= 0 {
+		repo, tag = rest[:i], rest[i+1:]
+	}
+	if repo == "" || tag == "" {
+		return nil, fmt.Errorf("invalid OCI reference %q: want registry/repository:tag", ref)
+	}
+	s := &ociStore{
+		ref:    "oci://" + host + "/" + repo + refSeparator(tag) + tag,
+		scheme: "https",
+		host:   host,
+		repo:   repo,
+		tag:    tag,
+		client: &http.Client{Timeout: 30 * time.Minute},
+	}
+	if h := strings.Split(host, ":")[0]; h == "localhost" || h == "127.0.0.1" || os.Getenv("CIE_REGISTRY_INSECURE") != "" {
+		s.scheme = "http"
+	}
+	s.username, s.password = registryCredentials(host)
+	return s, nil
+}
+
+func refSeparator(tag string) string {
+	if strings.Contains(tag, ":") {
+		return "@"
+	}
+	return ":"
+}
+
+func (s *ociStore) Push(ctx context.Context, path string, manifest []byte) error {
+	f, err := os.Open(path) //nolint:gosec // bundle written by the caller
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	sum, err := fileSHA256(f)
+	if err != nil {
+		return err
+	}
+
+	config := ociDescriptor{MediaType: ManifestMediaType, Digest: "sha256:" + sha256Hex(manifest), Size: int64(len(manifest))}
+	layer := ociDescriptor{
+		MediaType:   BundleMediaType,
+		Digest:      "sha256:" + sum,
+		Size:        info.Size(),
+		Annotations: map[string]string{"org.opencontainers.image.title": filepath.Base(path)},
+	}
+	if err := s.pushBlob(ctx, config, func() (io.Reader, error) { return bytes.NewReader(manifest), nil }); err != nil {
+		return err
+	}
+	if err := s.pushBlob(ctx, layer, func() (io.Reader, error) {
+		_, err := f.Seek(0, io.SeekStart)
+		return f, err
+	}); err != nil {
+		return err
+	}
+
+	m := ociManifest{
+		SchemaVersion: 2,
+		MediaType:     ociManifestMediaType,
+		ArtifactType:  ArtifactType,
+		Config:        config,
+		Layers:        []ociDescriptor{layer},
+		Annotations:   map[string]string{"org.opencontainers.image.created": time.Now().UTC().Format(time.RFC3339)},
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, "push", func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.url("/manifests/"+s.tag), bytes.NewReader(data))
+		if err == nil {
+			req.Header.Set("Content-Type", ociManifestMediaType)
+		}
+		return req, err
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return ociError("push manifest", s.ref, resp)
+	}
+	return nil
+}
+
+// pushBlob uploads a blob unless the registry already has it. body is
+// called again when the upload has to be retried after authenticating.
+func (s *ociStore) pushBlob(ctx context.Context, d ociDescriptor, body func() (io.Reader, error)) error {
+	resp, err := s.do(ctx, "push", func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodHead, s.url("/blobs/"+d.Digest), nil)
+	})
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	resp, err = s.do(ctx, "push", func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodPost, s.url("/blobs/uploads/"), nil)
+	})
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return ociError("start upload", s.ref, resp)
+	}
+	loc, err := resp.Location()
+	if err != nil {
+		return fmt.Errorf("start upload %s: %w", s.ref, err)
+	}
+	q := loc.Query()
+	q.Set("digest", d.Digest)
+	loc.RawQuery = q.Encode()
+
+	resp, err = s.do(ctx, "push", func() (*http.Request, error) {
+		r, err := body()
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, loc.String(), r)
+		if err == nil {
+			req.ContentLength = d.Size
+			req.Header.Set("Content-Type", "application/octet-stream")
+		}
+		return req, err
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusCreated {
+		return ociError("upload blob", s.ref, resp)
+	}
+	return nil
+}
+
+func (s *ociStore) Pull(ctx context.Context, path string) error {
+	resp, err := s.do(ctx, "pull", func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url("/manifests/"+s.tag), nil)
+		if err == nil {
+			req.Header.Set("Accept", ociManifestMediaType)
+		}
+		return req, err
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return ociError("pull", s.ref, resp)
+	}
+	var m ociManifest
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&m); err != nil {
+		return fmt.Errorf("pull %s: read manifest: %w", s.ref, err)
+	}
+	var layer *ociDescriptor
+	for i := range m.Layers {
+		if m.Layers[i].MediaType == BundleMediaType {
+			layer = &m.Layers[i]
+		}
+	}
+	if layer == nil {
+		return fmt.Errorf("pull %s: not a CIE index artifact (no %s layer)", s.ref, BundleMediaType)
+	}
+
+	blob, err := s.do(ctx, "pull", func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, s.url("/blobs/"+layer.Digest), nil)
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = blob.Body.Close() }()
+	if blob.StatusCode != http.StatusOK {
+		return ociError("pull blob", s.ref, blob)
+	}
+	if err := copyToFile(path, blob.Body); err != nil {
+		return err
+	}
+	return verifyDigest(path, layer.Digest)
+}
+
+func (s *ociStore) String() string { return s.ref }
+
+func (s *ociStore) url(suffix string) string {
+	return s.scheme + "://" + s.host + "/v2/" + s.repo + suffix
+}
+
+// do sends the request built by newReq, authenticating and retrying once
+// when the registry answers 401. action is "pull" or "push", the access
+// requested for a bearer token.
+func (s *ociStore) do(ctx context.Context, action string, newReq func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newReq()
+		if err != nil {
+			return nil, err
+		}
+		s.authorize(req)
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", action, s.ref, err)
+		}
+		if resp.StatusCode != http.StatusUnauthorized || attempt > 0 {
+			return resp, nil
+		}
+		challenge := resp.Header.Get("WWW-Authenticate")
+		_ = resp.Body.Close()
+		if err := s.authenticate(ctx, challenge, action); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *ociStore) authorize(req *http.Request) {
+	switch {
+	case s.token != "":
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	case s.username != "":
+		req.SetBasicAuth(s.username, s.password)
+	}
+}
+
+// authenticate answers a WWW-Authenticate challenge. Basic challenges are
+// met with the configured credentials; bearer challenges with a token from
+// the realm, which is how most registries authenticate.
+func (s *ociStore) authenticate(ctx context.Context, challenge, action string) error {
+	scheme, params := parseChallenge(challenge)
+	if !strings.EqualFold(scheme, "bearer") {
+		if s.username == "" {
+			return fmt.Errorf("%s %s: registry requires credentials: set CIE_REGISTRY_USERNAME and CIE_REGISTRY_PASSWORD or run 'docker login %s'", action, s.ref, s.host)
+		}
+		return nil
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return fmt.Errorf("%s %s: invalid auth challenge %q", action, s.ref, challenge)
+	}
+	scope := "repository:" + s.repo + ":pull"
+	if action == "push" {
+		scope += ",push"
+	}
+	q := realm.Query()
+	if params["service"] != "" {
+		q.Set("service", params["service"])
+	}
+	q.Set("scope", scope)
+	realm.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: fetch token: %w", action, s.ref, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return ociError("fetch token for", s.ref, resp)
+	}
+	var tok struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return fmt.Errorf("%s %s: read token: %w", action, s.ref, err)
+	}
+	s.token = tok.Token
+	if s.token == "" {
+		s.token = tok.AccessToken
+	}
+	if s.token == "" {
+		return fmt.Errorf("%s %s: registry returned no token", action, s.ref)
+	}
+	return nil
+}
+
+// parseChallenge splits `Bearer realm="...",service="..."` into its scheme
+// and parameters.
+func parseChallenge(h string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(h), " ")
+	params := map[string]string{}
+	for rest != "" {
+		var kv string
+		rest = strings.TrimLeft(rest, " ,")
+		k, after, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		if strings.HasPrefix(after, `"`) {
+			end := strings.Index(after[1:], `"`)
+			if end < 0 {
+				break
+			}
+			kv, rest = after[1:end+1], after[end+2:]
+		} else {
+			kv, rest, _ = strings.Cut(after, ",")
+		}
+		params[strings.ToLower(strings.TrimSpace(k))] = kv
+	}
+	return scheme, params
+}
+
+// registryCredentials returns the credentials for host from the
+// environment or ~/.docker/config.json.
+func registryCredentials(host string) (string, string) {
+	if u := os.Getenv("CIE_REGISTRY_USERNAME"); u != "" {
+		return u, os.Getenv("CIE_REGISTRY_PASSWORD")
+	}
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", ""
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json")) //nolint:gosec // docker's own config file
+	if err != nil {
+		return "", ""
+	}
+	var cfg struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	if json.Unmarshal(data, &cfg) != nil {
+		return "", ""
+	}
+	for _, key := range []string{host, "https://" + host, "https://" + host + "/v1/"} {
+		entry, ok := cfg.Auths[key]
+		if !ok || entry.Auth == "" {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			continue
+		}
+		if u, p, ok := strings.Cut(string(raw), ":"); ok {
+			return u, p
+		}
+	}
+	return "", ""
+}
+
+// verifyDigest checks that the file at path has the sha256 digest want.
+func verifyDigest(path, want string) error {
+	f, err := os.Open(path) //nolint:gosec // file written by the caller
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	sum, err := fileSHA256(f)
+	if err != nil {
+		return err
+	}
+	if got := "sha256:" + sum; got != want {
+		return fmt.Errorf("downloaded bundle has digest %s, want %s", got, want)
+	}
+	return nil
+}
+
+// ociError turns a failed response into an error carrying the registry's
+// error message.
+func ociError(op, ref string, resp *http.Response) error {
+	var body struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	msg := resp.Status
+	if json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&body) == nil && len(body.Errors) > 0 {
+		msg = body.Errors[0].Code + ": " + body.Errors[0].Message
+	}
+	return fmt.Errorf("%s %s: %s", op, ref, msg)
+}
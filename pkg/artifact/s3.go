@@ -0,0 +1,414 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// s3PartSize is the size of each part of a multipart upload. S3 allows
+// 10,000 parts, so bundles up to 640 GiB fit.
+const s3PartSize = 64 << 20
+
+// s3PartTimeout bounds one request of an upload or the wait for a
+// download to start; a whole transfer is bounded only by its context.
+const s3PartTimeout = 10 * time.Minute
+
+// s3DigestHeader carries the bundle's sha256 digest as object metadata, so
+// Pull can verify what it downloaded.
+const s3DigestHeader = "X-Amz-Meta-Cie-Digest"
+
+// s3Store keeps the bundle as one object in S3 or an S3-compatible
+// service. Credentials come from the standard AWS environment variables;
+// AWS_ENDPOINT_URL selects a service other than AWS (MinIO, R2, ...), which
+// is then addressed path-style.
+type s3Store struct {
+	ref      string
+	bucket   string
+	key      string
+	region   string
+	endpoint string // scheme://host, without the bucket
+	pathURL  bool   // bucket in the path rather than the host name
+	access   string
+	secret   string
+	session  string
+	client   *http.Client
+	now      func() time.Time
+	partSize int64
+}
+
+func newS3Store(ref string) (*s3Store, error) {
+	rest := strings.TrimPrefix(ref, "s3://")
+	bucket, key, _ := strings.Cut(rest, "/")
+	if bucket == "" || key == "" || strings.HasSuffix(key, "/") {
+		return nil, fmt.Errorf("invalid S3 reference %q: want s3://bucket/path/index.tar.zst", ref)
+	}
+	s := &s3Store{
+		ref:     ref,
+		bucket:  bucket,
+		key:     key,
+		region:  firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"),
+		access:  os.Getenv("AWS_ACCESS_KEY_ID"),
+		secret:  os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		session: os.Getenv("AWS_SESSION_TOKEN"),
+		client: &http.Client{Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			ResponseHeaderTimeout: s3PartTimeout,
+		}},
+		now:      time.Now,
+		partSize: s3PartSize,
+	}
+	if s.region == "" {
+		s.region = "us-east-1"
+	}
+	if ep := firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"); ep != "" {
+		s.endpoint = strings.TrimSuffix(ep, "/")
+		s.pathURL = true
+	} else {
+		s.endpoint = "https://s3." + s.region + ".amazonaws.com"
+	}
+	if s.access == "" || s.secret == "" {
+		return nil, fmt.Errorf("S3 credentials missing: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	return s, nil
+}
+
+// Push uploads the bundle with a single PUT when it fits in one part, and
+// as a multipart upload otherwise. Either way the bundle's digest is stored
+// as object metadata for Pull to verify.
+func (s *s3Store) Push(ctx context.Context, path string, _ []byte) error {
+	f, err := os.Open(path) //nolint:gosec // bundle written by the caller
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	sum, err := fileSHA256(f)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	digest := "sha256:" + sum
+	if info.Size() <= s.partSize {
+		return s.putObject(ctx, f, info.Size(), sum, digest)
+	}
+	return s.multipartUpload(ctx, f, info.Size(), digest)
+}
+
+// putObject uploads the whole bundle in one request.
+func (s *s3Store) putObject(ctx context.Context, body io.Reader, size int64, sum, digest string) error {
+	ctx, cancel := context.WithTimeout(ctx, s3PartTimeout)
+	defer cancel()
+	req, err := s.request(ctx, http.MethodPut, nil, body, sum, s.objectHeaders(digest))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("upload to %s: %w", s.ref, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		return s3Error("upload", s.ref, resp)
+	}
+	return nil
+}
+
+// objectHeaders returns the headers set on the stored object.
+func (s *s3Store) objectHeaders(digest string) http.Header {
+	return http.Header{"Content-Type": {BundleMediaType}, s3DigestHeader: {digest}}
+}
+
+// multipartUpload uploads the bundle in parts of s.partSize. A failed
+// upload is aborted so S3 does not keep (and bill) the parts.
+func (s *s3Store) multipartUpload(ctx context.Context, f io.ReaderAt, size int64, digest string) error {
+	var created struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := s.call(ctx, http.MethodPost, url.Values{"uploads": {""}}, nil, s.objectHeaders(digest), &created); err != nil {
+		return err
+	}
+	if created.UploadID == "" {
+		return fmt.Errorf("upload to %s: no upload ID returned", s.ref)
+	}
+
+	type part struct {
+		PartNumber int
+		ETag       string
+	}
+	var parts []part
+	err := func() error {
+		buf := make([]byte, s.partSize)
+		for offset, number := int64(0), 1; offset < size; offset, number = offset+s.partSize, number+1 {
+			n, err := f.ReadAt(buf[:min(s.partSize, size-offset)], offset)
+			if err != nil && err != io.EOF {
+				return err
+			}
+			query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {created.UploadID}}
+			etag, err := s.uploadPart(ctx, query, buf[:n])
+			if err != nil {
+				return err
+			}
+			parts = append(parts, part{PartNumber: number, ETag: etag})
+		}
+		complete := struct {
+			XMLName xml.Name `xml:"CompleteMultipartUpload"`
+			Parts   []part   `xml:"Part"`
+		}{Parts: parts}
+		body, err := xml.Marshal(complete)
+		if err != nil {
+			return err
+		}
+		return s.call(ctx, http.MethodPost, url.Values{"uploadId": {created.UploadID}}, body, nil, nil)
+	}()
+	if err != nil {
+		abortCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+		defer cancel()
+		_ = s.call(abortCtx, http.MethodDelete, url.Values{"uploadId": {created.UploadID}}, nil, nil, nil)
+	}
+	return err
+}
+
+// uploadPart sends one part and returns its ETag.
+func (s *s3Store) uploadPart(ctx context.Context, query url.Values, data []byte) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s3PartTimeout)
+	defer cancel()
+	req, err := s.request(ctx, http.MethodPut, query, bytes.NewReader(data), sha256Hex(data), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("upload to %s: %w", s.ref, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		return "", s3Error("upload", s.ref, resp)
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+// call sends a small request about the object and decodes an XML response
+// into out when out is not nil.
+func (s *s3Store) call(ctx context.Context, method string, query url.Values, body []byte, header http.Header, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, s3PartTimeout)
+	defer cancel()
+	req, err := s.request(ctx, method, query, bytes.NewReader(body), sha256Hex(body), header)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("upload to %s: %w", s.ref, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		return s3Error("upload", s.ref, resp)
+	}
+	if out == nil {
+		return nil
+	}
+	return xml.NewDecoder(resp.Body).Decode(out)
+}
+
+// Pull downloads the bundle and checks it against the digest Push stored
+// with it.
+func (s *s3Store) Pull(ctx context.Context, path string) error {
+	req, err := s.request(ctx, http.MethodGet, nil, nil, emptySHA256, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("download %s: %w", s.ref, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return s3Error("download", s.ref, resp)
+	}
+	digest := resp.Header.Get(s3DigestHeader)
+	if digest == "" {
+		return fmt.Errorf("download %s: the object has no digest; push it again with 'cie push-index'", s.ref)
+	}
+	if err := copyToFile(path, resp.Body); err != nil {
+		return err
+	}
+	return verifyDigest(path, digest)
+}
+
+func (s *s3Store) String() string { return s.ref }
+
+// objectURL returns the URL of the bundle object.
+func (s *s3Store) objectURL() (*url.URL, error) {
+	u, err := url.Parse(s.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint %q: %w", s.endpoint, err)
+	}
+	if s.pathURL {
+		u.Path = "/" + s.bucket + "/" + s.key
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = "/" + s.key
+	}
+	return u, nil
+}
+
+// request builds a request for the object signed with AWS Signature
+// Version 4. payloadHash is the hex SHA-256 of body; header is sent, and
+// signed, along with the request.
+func (s *s3Store) request(ctx context.Context, method string, query url.Values, body io.Reader, payloadHash string, header http.Header) (*http.Request, error) {
+	u, err := s.objectURL()
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = canonicalQuery(query)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[http.CanonicalHeaderKey(name)] = values
+	}
+	s.sign(req, payloadHash)
+	return req, nil
+}
+
+// canonicalQuery encodes query as SigV4 expects: sorted, with every key
+// followed by '=' even when its value is empty.
+func canonicalQuery(query url.Values) string {
+	return strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+// emptySHA256 is the hex SHA-256 of an empty payload.
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// sign adds the SigV4 Authorization header for the s3 service.
+func (s *s3Store) sign(req *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	stamp := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", stamp)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.session != "" {
+		req.Header.Set("X-Amz-Security-Token", s.session)
+	}
+	// SigV4 requires every x-amz-* header to be signed.
+	signed := []string{"host"}
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-amz-") {
+			signed = append(signed, lower)
+		}
+	}
+	sort.Strings(signed)
+
+	var canonHeaders strings.Builder
+	for _, h := range signed {
+		v := req.Header.Get(h)
+		if h == "host" {
+			v = req.URL.Host
+		}
+		canonHeaders.WriteString(h + ":" + strings.TrimSpace(v) + "\n")
+	}
+	signedHeaders := strings.Join(signed, ";")
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+	key := hmacSHA256([]byte("AWS4"+s.secret), day)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.access, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// fileSHA256 returns the hex SHA-256 of the rest of r.
+func fileSHA256(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// s3Error turns a failed response into an error carrying S3's error code.
+func s3Error(op, ref string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	msg := strings.TrimSpace(string(body))
+	if i := strings.Index(msg, "<Code>"); i >= 0 {
+		if j := strings.Index(msg[i:], "</Code>"); j > 0 {
+			msg = msg[i+len("<Code>") : i+j]
+		}
+	}
+	if msg == "" {
+		msg = resp.Status
+	}
+	return fmt.Errorf("%s %s: %s (HTTP %d)", op, ref, msg, resp.StatusCode)
+}
+
+func firstEnv(names ...string) string {
+	for _, n := range names {
+		if v := os.Getenv(n); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -275,6 +275,40 @@ func (db *CozoDB) ImportRelations(jsonPayload string) error {
 	return nil
 }
 
+// Import writes rows into stored relations through Cozo's bulk import path
+// instead of a :put script, skipping script parsing and query planning.
+// relations maps each relation name to its rows, with Headers naming the
+// key and value columns. The relations are written in one transaction;
+// triggers do not run for imported rows.
+func (db *CozoDB) Import(relations map[string]NamedRows) error {
+	payload, err := EncodeImport(relations)
+	if err != nil {
+		return err
+	}
+	return db.ImportRelations(string(payload))
+}
+
+// EncodeImport serializes relations into the JSON payload ImportRelations
+// expects. Relations without rows are left out.
+func EncodeImport(relations map[string]NamedRows) ([]byte, error) {
+	type relation struct {
+		Headers []string `json:"headers"`
+		Rows    [][]any  `json:"rows"`
+	}
+	payload := make(map[string]relation, len(relations))
+	for name, rows := range relations {
+		if len(rows.Rows) == 0 {
+			continue
+		}
+		payload[name] = relation{Headers: rows.Headers, Rows: rows.Rows}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode import: %w", err)
+	}
+	return data, nil
+}
+
 // ExportRelations exports relations to a JSON string.
 func (db *CozoDB) ExportRelations(jsonPayload string) (string, error) {
 	if db.closed {
@@ -101,6 +101,17 @@
 //	// Restore from backup
 //	err := db.Restore("/path/to/backup.db")
 //
+// # Bulk Import
+//
+// Import loads many rows without generating a Datalog script:
+//
+//	err := db.Import(map[string]cozodb.NamedRows{
+//	    "cie_file": {
+//	        Headers: []string{"id", "path", "hash", "language", "size"},
+//	        Rows:    [][]any{{"file:1", "main.go", "abc", "go", 120}},
+//	    },
+//	})
+//
 // # CIE Data Model
 //
 // CIE uses these main relations (tables) for code intelligence:
@@ -108,12 +119,35 @@
 //	cie_file            - Indexed source files with metadata
 //	cie_function        - Functions and methods (name, signature, location)
 //	cie_function_code   - Function source code (separate for lazy loading)
+//	cie_function_code_chunk - Function source past the code text size limit
 //	cie_function_embedding - Vector embeddings for semantic search
+//	cie_function_name_embedding - Embeddings of function names and signatures
+//	cie_function_lang   - Function language and dialect or framework hint
 //	cie_type            - Types, interfaces, classes
 //	cie_type_code       - Type definitions source code
 //	cie_type_embedding  - Type embeddings for semantic search
 //	cie_calls           - Function call graph edges
 //	cie_defines         - File defines function relationships
+//	cie_contains        - Nested functions and methods to their parent
+//	cie_method_of       - Methods to the type they belong to
+//	cie_unresolved_call - Calls left out of the call graph, with the reason
+//	cie_proto_option    - Options set in .proto files
+//	cie_generated_from  - Generated types to their .proto message or enum, or mocked interface
+//	cie_generated_file  - Generator and source file named in generated files' headers
+//	cie_generated_func  - Generated functions to the RPC or type they come from
+//	cie_template        - Template files (Go templates, Jinja, ERB)
+//	cie_template_ref    - Variables, blocks and templates a template references
+//	cie_renders         - Template names passed to render calls by functions
+//	cie_ci_job          - CI jobs (GitHub Actions, GitLab CI)
+//	cie_ci_step         - Steps and script lines of CI jobs
+//	cie_ci_ref          - Scripts, make targets, actions and variables CI jobs use
+//	cie_table_ref       - Database tables functions use through SQL strings and ORM calls
+//	cie_rpc_link        - gRPC server methods and client calls linked to .proto RPCs
+//	cie_entry_point     - Functions where execution starts (commands, servers, handlers, jobs)
+//	cie_note            - Notes attached to functions and files (kept across re-indexing)
+//	cie_collection      - Members of named collections of functions and files
+//	cie_fact            - Project knowledge stored by agents for later sessions
+//	cie_fact_embedding  - Fact embeddings for semantic recall
 //	cie_import          - Import statements
 //
 // # Version Compatibility
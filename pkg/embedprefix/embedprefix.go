@@ -0,0 +1,74 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package embedprefix holds the text prefixes asymmetric embedding models
+// expect on queries and documents. It is a dependency-free package that can
+// be imported by both pkg/ingestion (to embed documents) and pkg/tools (to
+// embed queries).
+package embedprefix
+
+import (
+	"strconv"
+	"strings"
+)
+
+// qodoQueryInstruction is the gte-Qwen2 instruction Qodo-Embed expects in
+// front of a search query.
+const qodoQueryInstruction = "Instruct: Given a code search query, retrieve relevant code that matches the query\nQuery: "
+
+// Prefixes are prepended to text before it is embedded. Asymmetric models
+// are trained with one prefix on queries and another on documents, and the
+// document prefix used when indexing must be the one the query prefix was
+// paired with, or similarities degrade without any error.
+type Prefixes struct {
+	Query    string
+	Document string
+}
+
+// Defaults returns the prefixes used for a provider and model when the
+// configuration sets none:
+//   - Qodo-Embed, or no model (the llama.cpp default): the instruct query format, documents as-is
+//   - nomic models on Ollama: "search_query: " and "search_document: "
+//   - other models: "search_query: " queries, documents as-is
+//
+// The Nomic API applies the document side through its task type rather
+// than a text prefix.
+func Defaults(provider, model string) Prefixes {
+	if model == "" || strings.Contains(strings.ToLower(model), "qodo") {
+		return Prefixes{Query: qodoQueryInstruction}
+	}
+	prefixes := Prefixes{Query: "search_query: "}
+	if IsNomicModel(model) && (provider == "ollama" || provider == "local_model") {
+		prefixes.Document = "search_document: "
+	}
+	return prefixes
+}
+
+// IsNomicModel reports whether model is a Nomic embedding model, which is
+// trained with the asymmetric search_query/search_document prefixes.
+func IsNomicModel(model string) bool {
+	return strings.Contains(strings.ToLower(model), "nomic")
+}
+
+// EncodeDocument formats a document prefix for
+// storage.DocumentPrefixMetaKey. It is quoted so an empty prefix can be
+// told apart from an index that never recorded one.
+func EncodeDocument(prefix string) string {
+	return strconv.Quote(prefix)
+}
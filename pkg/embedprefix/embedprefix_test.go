@@ -0,0 +1,41 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package embedprefix
+
+import "testing"
+
+func TestDefaults(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		provider, model string
+		want            Prefixes
+	}{
+		{"llamacpp", "", Prefixes{Query: qodoQueryInstruction}},
+		{"openai", "Qodo-Embed-1-1.5B", Prefixes{Query: qodoQueryInstruction}},
+		{"ollama", "nomic-embed-text", Prefixes{Query: "search_query: ", Document: "search_document: "}},
+		{"nomic", "nomic-embed-text-v1.5", Prefixes{Query: "search_query: "}},
+		{"openai", "text-embedding-3-small", Prefixes{Query: "search_query: "}},
+	}
+	for _, tt := range tests {
+		if got := Defaults(tt.provider, tt.model); got != tt.want {
+			t.Errorf("Defaults(%q, %q) = %+v, want %+v", tt.provider, tt.model, got, tt.want)
+		}
+	}
+}
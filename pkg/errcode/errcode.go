@@ -0,0 +1,188 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+// Package errcode defines the failure taxonomy shared by the CLI, the MCP
+// tools and library callers.
+//
+// Every failure CIE reports carries one Code, so scripts and agents can
+// branch on the kind of failure instead of parsing the message. The CLI maps
+// codes to exit codes and prints them in --json output; MCP tool results
+// carry them in _meta under "cie/error_code".
+//
+// Errors created by CIE carry their code explicitly (see Coder). Classify
+// infers the code of other errors: network and filesystem errors by type,
+// provider failures by ErrProviderUnreachable, and CozoDB errors, which only
+// carry a message, by the message.
+package errcode
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+)
+
+// Code identifies a kind of failure. Values are stable identifiers.
+type Code string
+
+// Failure codes.
+const (
+	ConfigInvalid       Code = "CONFIG_INVALID"       // missing or invalid configuration
+	InvalidInput        Code = "INVALID_INPUT"        // bad arguments or tool parameters
+	NotFound            Code = "NOT_FOUND"            // requested file, project or entity is missing
+	PermissionDenied    Code = "PERMISSION_DENIED"    // filesystem permissions
+	DBError             Code = "DB_ERROR"             // other database failures
+	DBLocked            Code = "DB_LOCKED"            // database or index lock held by another process
+	DBCorrupt           Code = "DB_CORRUPT"           // damaged database files; see 'cie repair'
+	SchemaMismatch      Code = "SCHEMA_MISMATCH"      // index built by an older version; reindex
+	NetworkError        Code = "NETWORK_ERROR"        // remote CIE server unreachable
+	ProviderUnreachable Code = "PROVIDER_UNREACHABLE" // embedding or LLM provider unreachable
+	Timeout             Code = "TIMEOUT"              // query or request exceeded its time limit
+	RateLimited         Code = "RATE_LIMITED"         // MCP rate or concurrency limit hit
+	QueryRejected       Code = "QUERY_REJECTED"       // raw query refused by the guardrails
+	ParseLimit          Code = "PARSE_LIMIT"          // file or statement over a size limit
+	ToolFailed          Code = "TOOL_FAILED"          // a tool failed for an unclassified reason
+	Internal            Code = "INTERNAL"             // a bug
+)
+
+// ErrProviderUnreachable marks a request to an embedding or LLM provider
+// that failed before the provider answered. Provider clients wrap the
+// transport error with it:
+//
+//	fmt.Errorf("ollama chat: %w: %w", errcode.ErrProviderUnreachable, err)
+var ErrProviderUnreachable = errors.New("provider unreachable")
+
+// Coder is implemented by errors that carry their own code.
+type Coder interface {
+	ErrorCode() Code
+}
+
+// Of returns the code carried by the first Coder in err's chain, or
+// Classify's guess when there is none. It returns "" for a nil error or
+// when nothing matches.
+func Of(err error) Code {
+	if err == nil {
+		return ""
+	}
+	var c Coder
+	if errors.As(err, &c) {
+		if code := c.ErrorCode(); code != "" {
+			return code
+		}
+	}
+	return Classify(err)
+}
+
+// Classify infers a code from the errors in err's chain, falling back to
+// its message, or returns "".
+func Classify(err error) Code {
+	if err == nil {
+		return ""
+	}
+	if code := classifyType(err); code != "" {
+		return code
+	}
+	return ClassifyMessage(err.Error())
+}
+
+// classifyType infers a code from the sentinel errors and error types in
+// err's chain, or returns "".
+func classifyType(err error) Code {
+	switch {
+	case errors.Is(err, ErrProviderUnreachable):
+		return ProviderUnreachable
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return Timeout
+	case errors.Is(err, fs.ErrPermission):
+		return PermissionDenied
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return Timeout
+		}
+		return NetworkError
+	}
+	return ""
+}
+
+// Message markers, checked in order: the first group that matches wins.
+var (
+	corruptMarkers = []string{
+		"corruption:",            // RocksDB Corruption status, incl. missing table files
+		"checksum mismatch",      // RocksDB block checks
+		"bad magic number",       // truncated SST or MANIFEST
+		"malformed",              // SQLite "database disk image is malformed"
+		"file is not a database", // SQLite header damage
+	}
+	lockMarkers = []string{
+		"lock file", // RocksDB "While lock file: .../LOCK"
+		"lock hold by current process",
+		"database is locked", // SQLite
+		"index lock",
+		"locked by another cie process", // storage.ErrDataDirLocked
+	}
+	schemaMarkers = []string{
+		"cannot find requested stored relation",
+		"stored relation not found",
+		"arity mismatch",
+		"re-index is required",
+	}
+	parseLimitMarkers = []string{"exceeds max size", "max file size", "file too large"}
+	rateMarkers       = []string{"rate limit", "too many requests", "concurrent call"}
+	networkMarkers    = []string{"connection refused", "no such host", "connection reset", "network is unreachable"}
+	timeoutMarkers    = []string{"deadline exceeded", "timeout", "timed out", "time limit"}
+	permissionMarkers = []string{"permission denied", "operation not permitted"}
+)
+
+// ClassifyMessage infers a code from an error message, or returns "". It
+// cannot tell provider failures from other network failures; those need
+// ErrProviderUnreachable in the error chain.
+func ClassifyMessage(msg string) Code {
+	m := strings.ToLower(msg)
+	switch {
+	case containsAny(m, corruptMarkers):
+		return DBCorrupt
+	case containsAny(m, lockMarkers):
+		return DBLocked
+	case containsAny(m, schemaMarkers):
+		return SchemaMismatch
+	case containsAny(m, parseLimitMarkers):
+		return ParseLimit
+	case containsAny(m, rateMarkers):
+		return RateLimited
+	case containsAny(m, timeoutMarkers):
+		return Timeout
+	case containsAny(m, networkMarkers):
+		return NetworkError
+	case containsAny(m, permissionMarkers):
+		return PermissionDenied
+	}
+	return ""
+}
+
+func containsAny(s string, markers []string) bool {
+	for _, marker := range markers {
+		if strings.Contains(s, marker) {
+			return true
+		}
+	}
+	return false
+}
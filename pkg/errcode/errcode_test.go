@@ -0,0 +1,99 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"testing"
+)
+
+func TestClassifyMessage(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want Code
+	}{
+		{"IO error: While lock file: /home/u/.cie/data/p/LOCK: Resource temporarily unavailable", DBLocked},
+		{"embedded query: data directory is locked by another CIE process: an index run is writing to it", DBLocked},
+		{"Corruption: block checksum mismatch", DBCorrupt},
+		{"Corruption: Can't access /home/u/.cie/data/p/000123.sst: IO error: No such file or directory", DBCorrupt},
+		{"IO error: While open a file for random read: /home/u/.cie/data/p/000123.sst: Too many open files", ""},
+		{"database disk image is malformed", DBCorrupt},
+		{"Cannot find requested stored relation 'cie_type'", SchemaMismatch},
+		{"mutation statement exceeds max size: 900000 bytes", ParseLimit},
+		{"rate limit exceeded for cie_grep (10 calls/minute)", RateLimited},
+		// Messages alone cannot identify a provider; see TestClassify.
+		{"ollama embed: dial tcp 127.0.0.1:11434: connect: connection refused", NetworkError},
+		{"query on cie_function_embedding exceeded time limit of 5s", Timeout},
+		{"read tcp: unexpected EOF", ""},
+		{"Post http://edge:8080/v1/query: dial tcp: connection refused", NetworkError},
+		{"query exceeded time limit of 5s", Timeout},
+		{"open /root/.cie/data: permission denied", PermissionDenied},
+		{"something unexpected", ""},
+	}
+	for _, tt := range tests {
+		if got := ClassifyMessage(tt.msg); got != tt.want {
+			t.Errorf("ClassifyMessage(%q) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", errors.New("connection refused"))}
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"provider sentinel", fmt.Errorf("ollama chat: %w: %w", ErrProviderUnreachable, refused), ProviderUnreachable},
+		{"network error type", fmt.Errorf("query remote: %w", refused), NetworkError},
+		{"deadline", fmt.Errorf("embedded query: %w", context.DeadlineExceeded), Timeout},
+		{"permission", &fs.PathError{Op: "open", Path: "/data", Err: fs.ErrPermission}, PermissionDenied},
+		{"message fallback", errors.New("Corruption: block checksum mismatch"), DBCorrupt},
+		{"embedding relation in a query error", errors.New("Cannot find requested stored relation 'cie_function_embedding'"), SchemaMismatch},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.err); got != tt.want {
+			t.Errorf("%s: Classify(%v) = %q, want %q", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+type codedErr struct{ code Code }
+
+func (e codedErr) Error() string   { return "database is locked" }
+func (e codedErr) ErrorCode() Code { return e.code }
+
+func TestOf(t *testing.T) {
+	if got := Of(nil); got != "" {
+		t.Errorf("Of(nil) = %q", got)
+	}
+	// An explicit code wins over what the message suggests.
+	wrapped := fmt.Errorf("index: %w", codedErr{code: SchemaMismatch})
+	if got := Of(wrapped); got != SchemaMismatch {
+		t.Errorf("Of(coded) = %q, want %q", got, SchemaMismatch)
+	}
+	if got := Of(errors.New("database is locked")); got != DBLocked {
+		t.Errorf("Of(plain) = %q, want %q", got, DBLocked)
+	}
+}
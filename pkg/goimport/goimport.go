@@ -0,0 +1,56 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package goimport names Go imports the way source code refers to them. It is
+// a dependency-free package that can be imported by both pkg/ingestion (to
+// resolve calls through imports) and pkg/tools (to attribute calls to
+// external packages).
+package goimport
+
+import (
+	"path"
+	"strings"
+)
+
+// DefaultAlias returns the name a Go import is referenced by when it has no
+// explicit alias: the last path component, skipping a major-version suffix
+// ("github.com/org/lib/v2" → "lib", "gopkg.in/yaml.v3" → "yaml").
+func DefaultAlias(importPath string) string {
+	base := path.Base(importPath)
+	if IsMajorVersion(base) && path.Dir(importPath) != "." {
+		base = path.Base(path.Dir(importPath))
+	}
+	if i := strings.LastIndex(base, ".v"); i > 0 && IsMajorVersion(base[i+1:]) {
+		base = base[:i]
+	}
+	return base
+}
+
+// IsMajorVersion reports whether s is a module major-version element like "v2".
+func IsMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	for _, c := range s[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
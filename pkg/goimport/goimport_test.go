@@ -0,0 +1,46 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package goimport
+
+import "testing"
+
+func TestDefaultAlias(t *testing.T) {
+	tests := map[string]string{
+		"fmt":                      "fmt",
+		"github.com/org/app/util":  "util",
+		"github.com/org/lib/v2":    "lib",
+		"gopkg.in/yaml.v3":         "yaml",
+		"github.com/org/app/v2/db": "db",
+		"v2":                       "v2",
+	}
+	for importPath, want := range tests {
+		if got := DefaultAlias(importPath); got != want {
+			t.Errorf("DefaultAlias(%q) = %q, want %q", importPath, got, want)
+		}
+	}
+}
+
+func TestIsMajorVersion(t *testing.T) {
+	for s, want := range map[string]bool{"v2": true, "v10": true, "v": false, "v2a": false, "x2": false} {
+		if got := IsMajorVersion(s); got != want {
+			t.Errorf("IsMajorVersion(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// DefaultBackfillBatchSize is the number of entities embedded and written
+// per backfill round trip.
+const DefaultBackfillBatchSize = 256
+
+// BackfillResult summarizes an embedding backfill.
+type BackfillResult struct {
+	// Functions and Types are the entities that received an embedding.
+	Functions int
+	Types     int
+
+	// Errors counts entities the provider failed to embed. They stay
+	// pending and are retried by the next backfill.
+	Errors int
+
+	// CacheHits counts embeddings reused from the shared cache.
+	CacheHits int
+
+	Duration time.Duration
+}
+
+// PendingEmbeddings counts the functions and types that have no embedding,
+// typically because they were indexed with SkipEmbeddings.
+func (p *LocalPipeline) PendingEmbeddings(ctx context.Context) (functions, types int, err error) {
+	fns, err := p.pendingEntities(ctx, "cie_function", "cie_function_embedding", "function_id")
+	if err != nil {
+		return 0, 0, err
+	}
+	pendingTypes, err := p.pendingEntities(ctx, "cie_type", "cie_type_embedding", "type_id")
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(fns), len(pendingTypes), nil
+}
+
+// BackfillEmbeddings generates embeddings for every function and type that
+// lacks one and writes them in batches of batchSize, so an index built with
+// SkipEmbeddings gains semantic search without being rebuilt. Progress is
+// reported under the "backfill" phase. Each batch is committed on its own:
+// a cancelled backfill keeps the work done so far and resumes from there.
+//
+// Entities are embedded in priority order (see orderBackfill): code that
+// changed recently or that many functions call comes first, so semantic
+// search is useful for the parts of the repo people work on long before a
+// large backfill finishes.
+func (p *LocalPipeline) BackfillEmbeddings(ctx context.Context, batchSize int) (*BackfillResult, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultBackfillBatchSize
+	}
+	start := time.Now()
+	result := &BackfillResult{}
+	hitsBefore := p.embeddingCacheHits()
+
+	pendingFns, err := p.pendingEntities(ctx, "cie_function", "cie_function_embedding", "function_id")
+	if err != nil {
+		return nil, err
+	}
+	pendingTypes, err := p.pendingEntities(ctx, "cie_type", "cie_type_embedding", "type_id")
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[string]string, len(pendingFns)+len(pendingTypes))
+	for _, it := range append(append([]backfillItem{}, pendingFns...), pendingTypes...) {
+		paths[it.id] = it.filePath
+	}
+	recent := p.recentFiles()
+	fnIDs := orderBackfill(pendingFns, recent, p.callerCounts(ctx))
+	typeIDs := orderBackfill(pendingTypes, recent, nil)
+	total := int64(len(fnIDs) + len(typeIDs))
+	p.logger.Info("backfill.start", "functions", len(fnIDs), "types", len(typeIDs))
+
+	var done int64
+	for i := 0; i < len(fnIDs); i += batchSize {
+		batch := fnIDs[i:min(i+batchSize, len(fnIDs))]
+		texts, err := p.codeTexts(ctx, "cie_function_code", "function_id", batch)
+		if err != nil {
+			return nil, err
+		}
+		var heads map[string][2]string
+		if p.config.IngestionConfig.NameEmbeddings {
+			if heads, err = p.functionHeads(ctx, batch); err != nil {
+				return nil, err
+			}
+		}
+		fns := make([]FunctionEntity, 0, len(batch))
+		for _, id := range batch {
+			fns = append(fns, FunctionEntity{ID: id, Name: heads[id][0], Signature: heads[id][1], FilePath: paths[id], CodeText: texts[id]})
+		}
+		embedded, err := p.embeddingGen.EmbedFunctions(ctx, fns)
+		if err != nil {
+			return nil, fmt.Errorf("embed functions: %w", err)
+		}
+		functionPath := func(fn FunctionEntity) string { return fn.FilePath }
+		if err := executeByShard(ctx, p.backend, embedded.Functions, functionPath, buildFunctionEmbeddingPuts); err != nil {
+			return nil, fmt.Errorf("write function embeddings: %w", err)
+		}
+		result.Functions += len(batch) - embedded.ErrorCount
+		result.Errors += embedded.ErrorCount
+		done += int64(len(batch))
+		p.reportProgress(done, total, "backfill")
+	}
+
+	for i := 0; i < len(typeIDs); i += batchSize {
+		batch := typeIDs[i:min(i+batchSize, len(typeIDs))]
+		texts, err := p.codeTexts(ctx, "cie_type_code", "type_id", batch)
+		if err != nil {
+			return nil, err
+		}
+		types := make([]TypeEntity, 0, len(batch))
+		for _, id := range batch {
+			types = append(types, TypeEntity{ID: id, FilePath: paths[id], CodeText: texts[id]})
+		}
+		embedded, err := p.embeddingGen.EmbedTypes(ctx, types)
+		if err != nil {
+			return nil, fmt.Errorf("embed types: %w", err)
+		}
+		typePath := func(t TypeEntity) string { return t.FilePath }
+		if err := executeByShard(ctx, p.backend, embedded.Types, typePath, buildTypeEmbeddingPuts); err != nil {
+			return nil, fmt.Errorf("write type embeddings: %w", err)
+		}
+		result.Types += len(batch) - embedded.ErrorCount
+		result.Errors += embedded.ErrorCount
+		done += int64(len(batch))
+		p.reportProgress(done, total, "backfill")
+	}
+
+	if result.Functions+result.Types > 0 {
+		p.recordDocumentPrefix()
+		p.bumpIndexVersion()
+	}
+	result.CacheHits = p.embeddingCacheHits() - hitsBefore
+	result.Duration = time.Since(start)
+	p.logger.Info("backfill.complete",
+		"functions", result.Functions,
+		"types", result.Types,
+		"errors", result.Errors,
+		"duration_ms", result.Duration.Milliseconds(),
+	)
+	return result, nil
+}
+
+// executeByShard writes the puts that build returns for items, sending each
+// item to the shard of its file. Without sharding it is a single Execute.
+func executeByShard[T any](ctx context.Context, backend *storage.EmbeddedBackend, items []T, path func(T) string, build func([]T) string) error {
+	groups := map[string][]T{}
+	for _, item := range items {
+		shard := ""
+		if backend.Sharded() {
+			shard = storage.ShardFor(path(item))
+		}
+		groups[shard] = append(groups[shard], item)
+	}
+	for shard, group := range groups {
+		puts := build(group)
+		if puts == "" {
+			continue
+		}
+		store, err := backend.Shard(shard)
+		if err != nil {
+			return err
+		}
+		if err := store.Execute(ctx, puts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pendingEntities returns the entities in entity that have no row in
+// embeddings.
+func (p *LocalPipeline) pendingEntities(ctx context.Context, entity, embeddings, key string) ([]backfillItem, error) {
+	script := fmt.Sprintf("?[id, file_path] := *%s { id, file_path }, not *%s { %s: id }", entity, embeddings, key)
+	res, err := p.backend.Query(ctx, script)
+	if err != nil {
+		return nil, fmt.Errorf("list %s without embeddings: %w", entity, err)
+	}
+	items := make([]backfillItem, 0, len(res.Rows))
+	for _, row := range res.Rows {
+		id, ok := row[0].(string)
+		if !ok {
+			continue
+		}
+		path, _ := row[1].(string)
+		items = append(items, backfillItem{id: id, filePath: path})
+	}
+	return items, nil
+}
+
+// codeTexts loads the code text of ids from a code relation, decompressing
+// rows written with CompressCodeText.
+func (p *LocalPipeline) codeTexts(ctx context.Context, relation, key string, ids []string) (map[string]string, error) {
+	quoted := make([]string, len(ids))
+	for i, id := range ids {
+		quoted[i] = quoteString(id)
+	}
+	script := fmt.Sprintf("?[id, code_text] := *%s { %s: id, code_text }, is_in(id, [%s])",
+		relation, key, strings.Join(quoted, ", "))
+	res, err := p.backend.Query(ctx, script)
+	if err != nil {
+		return nil, fmt.Errorf("load %s: %w", relation, err)
+	}
+	texts := make(map[string]string, len(res.Rows))
+	for _, row := range res.Rows {
+		id, _ := row[0].(string)
+		text, _ := row[1].(string)
+		decoded, err := storage.DecompressCodeText(text)
+		if err != nil {
+			p.logger.Warn("backfill.code_text.decode.error", "id", id, "err", err)
+			continue
+		}
+		texts[id] = decoded
+	}
+	return texts, nil
+}
+
+// functionHeads loads the name and signature of ids, which their name
+// embeddings are built from.
+func (p *LocalPipeline) functionHeads(ctx context.Context, ids []string) (map[string][2]string, error) {
+	quoted := make([]string, len(ids))
+	for i, id := range ids {
+		quoted[i] = quoteString(id)
+	}
+	script := fmt.Sprintf("?[id, name, signature] := *cie_function { id, name, signature }, is_in(id, [%s])", strings.Join(quoted, ", "))
+	res, err := p.backend.Query(ctx, script)
+	if err != nil {
+		return nil, fmt.Errorf("load function names: %w", err)
+	}
+	heads := make(map[string][2]string, len(res.Rows))
+	for _, row := range res.Rows {
+		id, _ := row[0].(string)
+		name, _ := row[1].(string)
+		signature, _ := row[2].(string)
+		heads[id] = [2]string{name, signature}
+	}
+	return heads, nil
+}
+
+// buildFunctionEmbeddingPuts writes the non-empty body and name embeddings
+// of fns.
+func buildFunctionEmbeddingPuts(fns []FunctionEntity) string {
+	var buf strings.Builder
+	for _, fn := range fns {
+		if len(fn.Embedding) > 0 {
+			fmt.Fprintf(&buf, "{ ?[function_id, embedding] <- [[%s, %s]] :put cie_function_embedding { function_id, embedding } }\n",
+				quoteString(fn.ID), formatFloatArray(fn.Embedding))
+		}
+		if len(fn.NameEmbedding) > 0 {
+			fmt.Fprintf(&buf, "{ ?[function_id, embedding] <- [[%s, %s]] :put cie_function_name_embedding { function_id, embedding } }\n",
+				quoteString(fn.ID), formatFloatArray(fn.NameEmbedding))
+		}
+	}
+	return buf.String()
+}
+
+// buildTypeEmbeddingPuts writes the non-empty embeddings of types.
+func buildTypeEmbeddingPuts(types []TypeEntity) string {
+	var buf strings.Builder
+	for _, t := range types {
+		if len(t.Embedding) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "{ ?[type_id, embedding] <- [[%s, %s]] :put cie_type_embedding { type_id, embedding } }\n",
+			quoteString(t.ID), formatFloatArray(t.Embedding))
+	}
+	return buf.String()
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+// backfillRecentCommits bounds the history scanned for recently changed
+// files when ordering a backfill.
+const backfillRecentCommits = 200
+
+// backfillItem is an entity waiting for an embedding.
+type backfillItem struct {
+	id       string
+	filePath string
+}
+
+// orderBackfill returns the ids of items, highest priority first.
+//
+// An entity scores up to 1 for recency, by how recently its file changed
+// (recent maps path to rank, 0 = most recent), plus up to 1 for centrality,
+// its caller count on a log scale relative to the most-called entity. The
+// two weigh the same so that neither a hot utility in an old file nor a
+// fresh but isolated function waits behind the other. Ties keep id order
+// so runs are reproducible.
+func orderBackfill(items []backfillItem, recent map[string]int, callers map[string]int) []string {
+	maxCallers := 0
+	for _, n := range callers {
+		maxCallers = max(maxCallers, n)
+	}
+	score := func(it backfillItem) float64 {
+		var s float64
+		if rank, ok := recent[it.filePath]; ok {
+			s += 1 - float64(rank)/float64(len(recent))
+		}
+		if maxCallers > 0 {
+			s += math.Log1p(float64(callers[it.id])) / math.Log1p(float64(maxCallers))
+		}
+		return s
+	}
+
+	scored := make([]struct {
+		id    string
+		score float64
+	}, len(items))
+	for i, it := range items {
+		scored[i].id, scored[i].score = it.id, score(it)
+	}
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].score != scored[j].score {
+			return scored[i].score > scored[j].score
+		}
+		return scored[i].id < scored[j].id
+	})
+
+	ids := make([]string, len(scored))
+	for i, s := range scored {
+		ids[i] = s.id
+	}
+	return ids
+}
+
+// recentFiles ranks the files changed in the working tree and the last
+// backfillRecentCommits commits, most recent first. It returns nil when the
+// pipeline has no local git repository.
+func (p *LocalPipeline) recentFiles() map[string]int {
+	src := p.config.RepoSource
+	if src.Type != "local_path" || src.Value == "" {
+		return nil
+	}
+	dd := NewDeltaDetector(src.Value, p.logger)
+	if !dd.IsGitRepository() {
+		return nil
+	}
+	paths, err := dd.RecentlyChangedFiles(backfillRecentCommits)
+	if err != nil {
+		p.logger.Warn("backfill.recent_files.error", "err", err)
+		return nil
+	}
+	ranks := make(map[string]int, len(paths))
+	for i, path := range paths {
+		ranks[path] = i
+	}
+	return ranks
+}
+
+// callerCounts returns the number of distinct callers of each function.
+// Centrality only orders the backfill, so a failed query degrades to
+// recency-only ordering.
+func (p *LocalPipeline) callerCounts(ctx context.Context) map[string]int {
+	res, err := p.backend.Query(ctx, "?[callee_id, count(caller_id)] := *cie_calls { caller_id, callee_id }")
+	if err != nil {
+		p.logger.Warn("backfill.callers.error", "err", err)
+		return nil
+	}
+	counts := make(map[string]int, len(res.Rows))
+	for _, row := range res.Rows {
+		id, _ := row[0].(string)
+		switch v := row[1].(type) {
+		case float64:
+			counts[id] = int(v)
+		case int64:
+			counts[id] = int(v)
+		}
+	}
+	return counts
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildEmbeddingPuts(t *testing.T) {
+	fns := []FunctionEntity{
+		{ID: "fn:ok", Embedding: []float32{0.5, 1}},
+		{ID: "fn:failed", Embedding: []float32{}},
+	}
+	got := buildFunctionEmbeddingPuts(fns)
+	if strings.Count(got, ":put cie_function_embedding") != 1 {
+		t.Fatalf("want one put, got:\n%s", got)
+	}
+	if !strings.Contains(got, "'fn:ok'") || strings.Contains(got, "fn:failed") {
+		t.Errorf("failed embeddings must stay pending, got:\n%s", got)
+	}
+
+	types := []TypeEntity{{ID: "type:ok", Embedding: []float32{1}}, {ID: "type:failed"}}
+	got = buildTypeEmbeddingPuts(types)
+	if strings.Count(got, ":put cie_type_embedding") != 1 || strings.Contains(got, "type:failed") {
+		t.Errorf("unexpected type puts:\n%s", got)
+	}
+
+	if buildFunctionEmbeddingPuts([]FunctionEntity{{ID: "fn:failed"}}) != "" {
+		t.Error("a batch with no embeddings should produce no mutation")
+	}
+}
+
+func TestOrderBackfill(t *testing.T) {
+	items := []backfillItem{
+		{id: "a", filePath: "old.go"},
+		{id: "b", filePath: "old.go"},
+		{id: "hot", filePath: "old.go"},
+		{id: "fresh", filePath: "new.go"},
+		{id: "warm", filePath: "mid.go"},
+	}
+	recent := map[string]int{"new.go": 0, "mid.go": 1}
+	callers := map[string]int{"hot": 40, "warm": 2}
+
+	got := orderBackfill(items, recent, callers)
+	want := []string{"fresh", "hot", "warm", "a", "b"} // fresh and hot tie at 1
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+
+	// Without signals the order is stable by id.
+	got = orderBackfill(items, nil, nil)
+	want = []string{"a", "b", "fresh", "hot", "warm"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestRecentlyChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@t"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	write := func(rel string) {
+		t.Helper()
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(rel+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("a.go")
+	write("b.go")
+	git("add", ".")
+	git("commit", "-q", "-m", "first")
+	write("pkg/c.go")
+	git("add", ".")
+	git("commit", "-q", "-m", "second")
+	write("dirty.go")
+
+	got, err := NewDeltaDetector(dir, nil).RecentlyChangedFiles(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"dirty.go", "pkg/c.go", "a.go", "b.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("recent = %v, want %v", got, want)
+	}
+}
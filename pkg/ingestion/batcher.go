@@ -131,7 +131,9 @@ func (b *Batcher) splitStatements(script string) []string {
 	parser := &statementParser{}
 
 	for _, line := range strings.Split(script, "\n") {
-		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "//") {
+		// Blank and comment lines are only noise between tokens; inside a
+		// multi-line string literal (e.g. code_text) they are content.
+		if trimmed := strings.TrimSpace(line); !parser.inString && (trimmed == "" || strings.HasPrefix(trimmed, "//")) {
 			continue
 		}
 
@@ -484,3 +484,18 @@ func TestBatcher_SplitStatements_UnicodeCharacters(t *testing.T) {
 		t.Errorf("expected 2 statements with math/arabic Unicode, got %d", len(statements2))
 	}
 }
+
+func TestBatcher_SplitStatements_BlankAndCommentLinesInStrings(t *testing.T) {
+	batcher := NewBatcher(10, 10000)
+
+	code := "func a() {\n\n\t// explain\n// flush left\n\treturn\n}"
+	script := "{ ?[function_id, code_text] <- [['f1', '" + code + "']] :put cie_function_code { function_id, code_text } }\n\n// between statements\n{ ?[id] <- [['file1']] :put cie_file { id } }"
+
+	statements := batcher.splitStatements(script)
+	if len(statements) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(statements))
+	}
+	if !strings.Contains(statements[0], code) {
+		t.Errorf("code text was altered: %q", statements[0])
+	}
+}
@@ -0,0 +1,296 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package ingestion
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	cozo "github.com/kraklabs/cie/pkg/cozodb"
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// defaultImportBatchFiles is how many files one bulk import transaction
+// carries when IngestionConfig.ImportBatchFiles is unset.
+const defaultImportBatchFiles = 500
+
+// importHeaders lists the columns bulk import writes for each relation, in
+// the order importRows emits them. They match the :put scripts of
+// DatalogBuilder column for column.
+var importHeaders = map[string][]string{
+	"cie_file":                {"id", "path", "hash", "language", "size"},
+	"cie_file_content":        {"file_id", "content"},
+	"cie_function":            {"id", "name", "signature", "file_path", "start_line", "end_line", "start_col", "end_col"},
+	"cie_function_code":       {"function_id", "code_text"},
+	"cie_function_code_chunk": {"function_id", "chunk", "start_line", "code_text"},
+	"cie_function_lang":       {"function_id", "language", "dialect"},
+	"cie_type":                {"id", "name", "kind", "file_path", "start_line", "end_line", "start_col", "end_col"},
+	"cie_type_code":           {"type_id", "code_text"},
+	"cie_defines":             {"id", "file_id", "function_id"},
+	"cie_defines_type":        {"id", "file_id", "type_id"},
+	"cie_calls":               {"id", "caller_id", "callee_id"},
+	"cie_import":              {"id", "file_path", "import_path", "alias", "start_line"},
+	"cie_field":               {"id", "struct_name", "field_name", "field_type", "file_path", "line"},
+	"cie_implements":          {"id", "type_name", "interface_name", "file_path"},
+	"cie_contains":            {"id", "parent_id", "parent_kind", "child_id", "file_path"},
+	"cie_method_of":           {"id", "method_id", "type_id", "type_name", "file_path"},
+	"cie_unresolved_call":     {"id", "caller_id", "callee_name", "file_path", "line", "reason"},
+	"cie_proto_option":        {"id", "file_path", "scope", "name", "value", "line"},
+	"cie_generated_from":      {"id", "type_id", "proto_type_id", "file_path"},
+	"cie_generated_file":      {"id", "file_path", "generator", "source"},
+	"cie_generated_func":      {"id", "function_id", "source_id", "source_kind", "file_path"},
+	"cie_template":            {"id", "file_path", "dialect"},
+	"cie_template_ref":        {"id", "template_id", "file_path", "kind", "name", "line"},
+	"cie_renders":             {"id", "function_id", "file_path", "template_name", "line"},
+	"cie_ci_job":              {"id", "file_path", "workflow", "name", "title", "stage", "runs_on", "needs", "triggers", "start_line"},
+	"cie_ci_step":             {"id", "job_id", "file_path", "idx", "name", "kind", "command", "line"},
+	"cie_ci_ref":              {"id", "job_id", "file_path", "kind", "name", "line"},
+	"cie_table_ref":           {"id", "function_id", "file_path", "table_name", "model", "op", "source", "line"},
+	"cie_rpc_link":            {"id", "rpc", "function_id", "role", "file_path", "line"},
+	"cie_entry_point":         {"id", "function_id", "file_path", "kind", "detail"},
+}
+
+// importRows collects rows per relation for storage.EmbeddedBackend.Import.
+type importRows map[string]cozo.NamedRows
+
+// add appends one row to relation. String values drop NUL bytes, as
+// quoteString does for scripts.
+func (r importRows) add(relation string, values ...any) {
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			values[i] = strings.ReplaceAll(s, "\x00", "")
+		}
+	}
+	rows := r[relation]
+	if rows.Headers == nil {
+		rows.Headers = importHeaders[relation]
+	}
+	rows.Rows = append(rows.Rows, values)
+	r[relation] = rows
+}
+
+// importRows returns the set as bulk import rows. Embeddings are left out:
+// the HNSW-indexed relations are only kept up to date by :put, so callers
+// write embeddingRows with storage.EmbeddedBackend.Put.
+func (s *entitySet) importRows(db *DatalogBuilder) importRows {
+	r := make(importRows)
+	for _, f := range s.files {
+		r.add("cie_file", f.ID, f.Path, f.Hash, f.Language, f.Size)
+		if f.Content != "" {
+			r.add("cie_file_content", f.ID, db.storedText(f.Content))
+		}
+	}
+	for _, fn := range s.functions {
+		r.add("cie_function", fn.ID, fn.Name, fn.Signature, fn.FilePath, fn.StartLine, fn.EndLine, fn.StartCol, fn.EndCol)
+		r.add("cie_function_code", fn.ID, db.storedText(fn.CodeText))
+		for _, c := range fn.CodeChunks() {
+			r.add("cie_function_code_chunk", fn.ID, c.Index, c.StartLine, db.storedText(c.Text))
+		}
+		if fn.Language != "" {
+			r.add("cie_function_lang", fn.ID, fn.Language, fn.Dialect)
+		}
+	}
+	for _, t := range s.types {
+		r.add("cie_type", t.ID, t.Name, t.Kind, t.FilePath, t.StartLine, t.EndLine, t.StartCol, t.EndCol)
+		r.add("cie_type_code", t.ID, t.CodeText)
+	}
+	for _, e := range s.defines {
+		r.add("cie_defines", "def:"+e.FileID+"|"+e.FunctionID, e.FileID, e.FunctionID)
+	}
+	for _, e := range s.definesTypes {
+		r.add("cie_defines_type", "deft:"+e.FileID+"|"+e.TypeID, e.FileID, e.TypeID)
+	}
+	for _, e := range s.calls {
+		r.add("cie_calls", "call:"+e.CallerID+"|"+e.CalleeID, e.CallerID, e.CalleeID)
+	}
+	for _, imp := range s.imports {
+		r.add("cie_import", imp.ID, imp.FilePath, imp.ImportPath, imp.Alias, imp.StartLine)
+	}
+	for _, f := range s.fields {
+		r.add("cie_field", GenerateFieldID(f.FilePath, f.StructName, f.FieldName), f.StructName, f.FieldName, f.FieldType, f.FilePath, f.Line)
+	}
+	for _, e := range s.implements {
+		r.add("cie_implements", GenerateImplementsID(e.TypeName, e.InterfaceName), e.TypeName, e.InterfaceName, e.FilePath)
+	}
+	for _, e := range s.contains {
+		r.add("cie_contains", GenerateContainsID(e.ParentID, e.ChildID), e.ParentID, e.ParentKind, e.ChildID, e.FilePath)
+	}
+	for _, e := range s.methodOf {
+		r.add("cie_method_of", GenerateMethodOfID(e.MethodID), e.MethodID, e.TypeID, e.TypeName, e.FilePath)
+	}
+	for _, c := range s.unresolved {
+		r.add("cie_unresolved_call", GenerateUnresolvedCallID(c.CallerID, c.CalleeName, c.Line), c.CallerID, c.CalleeName, c.FilePath, c.Line, c.Reason)
+	}
+	for _, o := range s.protoOptions {
+		r.add("cie_proto_option", GenerateProtoOptionID(o.FilePath, o.Scope, o.Name), o.FilePath, o.Scope, o.Name, o.Value, o.Line)
+	}
+	for _, e := range s.generatedFrom {
+		r.add("cie_generated_from", GenerateGeneratedFromID(e.TypeID), e.TypeID, e.ProtoTypeID, e.FilePath)
+	}
+	for _, f := range s.generated {
+		r.add("cie_generated_file", GenerateGeneratedFileID(f.FilePath), f.FilePath, f.Generator, f.Source)
+	}
+	for _, l := range s.generatedFunc {
+		r.add("cie_generated_func", GenerateGeneratedFuncID(l.FunctionID), l.FunctionID, l.SourceID, l.SourceKind, l.FilePath)
+	}
+	for _, t := range s.templates {
+		r.add("cie_template", t.ID, t.FilePath, t.Dialect)
+	}
+	for _, ref := range s.templateRefs {
+		r.add("cie_template_ref", ref.ID, ref.TemplateID, ref.FilePath, ref.Kind, ref.Name, ref.Line)
+	}
+	for _, rc := range s.renders {
+		r.add("cie_renders", GenerateRenderID(rc.FunctionID, rc.TemplateName), rc.FunctionID, rc.FilePath, rc.TemplateName, rc.Line)
+	}
+	for _, j := range s.ciJobs {
+		r.add("cie_ci_job", j.ID, j.FilePath, j.Workflow, j.Name, j.Title, j.Stage, j.RunsOn, j.Needs, j.Triggers, j.StartLine)
+	}
+	for _, st := range s.ciSteps {
+		r.add("cie_ci_step", st.ID, st.JobID, st.FilePath, st.Index, st.Name, st.Kind, st.Command, st.Line)
+	}
+	for _, ref := range s.ciRefs {
+		r.add("cie_ci_ref", ref.ID, ref.JobID, ref.FilePath, ref.Kind, ref.Name, ref.Line)
+	}
+	for _, t := range s.tableRefs {
+		r.add("cie_table_ref", GenerateTableRefID(t.FunctionID, t.Table, t.Op, t.Source), t.FunctionID, t.FilePath, t.Table, t.Model, t.Op, t.Source, t.Line)
+	}
+	for _, l := range s.rpcLinks {
+		r.add("cie_rpc_link", GenerateRPCLinkID(l.FunctionID, l.RPC, l.Role), l.RPC, l.FunctionID, l.Role, l.FilePath, l.Line)
+	}
+	for _, e := range s.entryPoints {
+		r.add("cie_entry_point", GenerateEntryPointID(e.FunctionID, e.Kind), e.FunctionID, e.FilePath, e.Kind, e.Detail)
+	}
+	return r
+}
+
+// embeddingRows returns the non-empty function and type embeddings of the
+// set as rows for storage.EmbeddedBackend.Put.
+func (s *entitySet) embeddingRows() (functions, types []storage.Row) {
+	for _, fn := range s.functions {
+		if len(fn.Embedding) > 0 {
+			functions = append(functions, storage.Row{"function_id": fn.ID, "embedding": finiteVector(fn.Embedding)})
+		}
+	}
+	for _, t := range s.types {
+		if len(t.Embedding) > 0 {
+			types = append(types, storage.Row{"type_id": t.ID, "embedding": finiteVector(t.Embedding)})
+		}
+	}
+	return functions, types
+}
+
+// nameEmbeddingRows returns the non-empty name and signature embeddings of
+// the set's functions.
+func (s *entitySet) nameEmbeddingRows() []storage.Row {
+	var rows []storage.Row
+	for _, fn := range s.functions {
+		if len(fn.NameEmbedding) > 0 {
+			rows = append(rows, storage.Row{"function_id": fn.ID, "embedding": finiteVector(fn.NameEmbedding)})
+		}
+	}
+	return rows
+}
+
+// finiteVector replaces NaN and Inf with 0, as formatFloat does for
+// scripts, since neither survives JSON encoding.
+func finiteVector(v []float32) []float32 {
+	out := v
+	for i, f := range v {
+		if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+			if &out[0] == &v[0] {
+				out = append([]float32(nil), v...)
+			}
+			out[i] = 0
+		}
+	}
+	return out
+}
+
+// importBatch is a run of files stored in the same shard.
+type importBatch struct {
+	key   string
+	shard string
+	files int
+}
+
+// bulkWriteEntities stores a full run through Cozo's bulk import instead of
+// :put scripts. Files are grouped into batches of ImportBatchFiles that never
+// straddle a shard; each batch is one import transaction, so a file's rows
+// land together. Embeddings follow each batch as a :put script.
+func (p *LocalPipeline) bulkWriteEntities(ctx context.Context, entities *entitySet) error {
+	batchFiles := p.config.IngestionConfig.ImportBatchFiles
+	if batchFiles == 0 {
+		batchFiles = defaultImportBatchFiles
+	}
+
+	perFile := entities.splitBy(func(path string) string { return path })
+	paths := make([]string, 0, len(perFile))
+	for path := range perFile {
+		paths = append(paths, path)
+	}
+	// Sorting keeps each top-level directory, and so each shard, contiguous.
+	sort.Strings(paths)
+
+	batchOf := make(map[string]string, len(paths))
+	var batches []importBatch
+	for _, path := range paths {
+		shard := storage.ShardFor(path)
+		if n := len(batches); n == 0 || batches[n-1].shard != shard || batches[n-1].files == batchFiles {
+			batches = append(batches, importBatch{key: strconv.Itoa(n), shard: shard})
+		}
+		b := &batches[len(batches)-1]
+		b.files++
+		batchOf[path] = b.key
+	}
+	parts := entities.splitBy(func(path string) string { return batchOf[path] })
+
+	var written int64
+	total := int64(len(paths))
+	for _, b := range batches {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		store, err := p.backend.Shard(b.shard)
+		if err != nil {
+			return err
+		}
+		part := parts[b.key]
+		if err := store.Import(ctx, part.importRows(p.datalogBuild)); err != nil {
+			return fmt.Errorf("bulk import: %w", err)
+		}
+		fnRows, typeRows := part.embeddingRows()
+		if err := store.Put(ctx, "cie_function_embedding", fnRows...); err != nil {
+			return fmt.Errorf("write function embeddings: %w", err)
+		}
+		if err := store.Put(ctx, "cie_type_embedding", typeRows...); err != nil {
+			return fmt.Errorf("write type embeddings: %w", err)
+		}
+		if err := store.Put(ctx, "cie_function_name_embedding", part.nameEmbeddingRows()...); err != nil {
+			return fmt.Errorf("write function name embeddings: %w", err)
+		}
+		written += int64(b.files)
+		p.reportProgress(written, total, "writing")
+	}
+	return nil
+}
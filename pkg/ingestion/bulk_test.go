@@ -0,0 +1,79 @@
+package ingestion
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fullEntitySet returns a set with one entity of every kind.
+func fullEntitySet() *entitySet {
+	return &entitySet{
+		files:         []FileEntity{{ID: "file:a", Path: "a.go", Content: "package a"}},
+		functions:     []FunctionEntity{{ID: "fn:a", Name: "A", FilePath: "a.go", CodeText: "func A() {\x00}", Language: "go", Dialect: "http handler", CodeOverflow: " return }"}},
+		types:         []TypeEntity{{ID: "type:t", Name: "T", Kind: "struct", FilePath: "a.go"}},
+		defines:       []DefinesEdge{{FileID: "file:a", FunctionID: "fn:a"}},
+		definesTypes:  []DefinesTypeEdge{{FileID: "file:a", TypeID: "type:t"}},
+		calls:         []CallsEdge{{CallerID: "fn:a", CalleeID: "fn:b"}},
+		imports:       []ImportEntity{{ID: "imp:a", FilePath: "a.go", ImportPath: "fmt"}},
+		fields:        []FieldEntity{{StructName: "T", FieldName: "X", FilePath: "a.go"}},
+		implements:    []ImplementsEdge{{TypeName: "T", InterfaceName: "I", FilePath: "a.go"}},
+		contains:      []ContainsEdge{{ParentID: "type:t", ChildID: "fn:a", FilePath: "a.go"}},
+		methodOf:      []MethodOfEdge{{MethodID: "fn:a", TypeID: "type:t", FilePath: "a.go"}},
+		unresolved:    []UnresolvedCall{{CallerID: "fn:a", CalleeName: "x", FilePath: "a.go"}},
+		protoOptions:  []ProtoOption{{FilePath: "a.proto", Name: "go_package"}},
+		generatedFrom: []GeneratedFromEdge{{TypeID: "type:t", FilePath: "a.go"}},
+		generated:     []GeneratedFile{{FilePath: "a.pb.go", Generator: "protoc-gen-go", Source: "a.proto"}},
+		generatedFunc: []GeneratedFuncLink{{FunctionID: "fn:a", SourceID: "fn:b", SourceKind: "function", FilePath: "a.pb.go"}},
+		templates:     []TemplateEntity{{ID: "tpl:a", FilePath: "a.html"}},
+		templateRefs:  []TemplateRef{{ID: "tref:a", TemplateID: "tpl:a", FilePath: "a.html"}},
+		renders:       []RenderCall{{FunctionID: "fn:a", TemplateName: "a.html", FilePath: "a.go"}},
+		ciJobs:        []CIJob{{ID: "job:a", FilePath: "ci.yml"}},
+		ciSteps:       []CIStep{{ID: "step:a", JobID: "job:a", FilePath: "ci.yml"}},
+		ciRefs:        []CIRef{{ID: "ref:a", JobID: "job:a", FilePath: "ci.yml"}},
+		tableRefs:     []TableRef{{FunctionID: "fn:a", FilePath: "a.go", Table: "users", Op: TableOpSelect, Source: TableSourceSQL}},
+		rpcLinks:      []RPCLink{{RPC: "Users.Get", FunctionID: "fn:a", FilePath: "a.go", Role: RPCRoleServer}},
+		entryPoints:   []EntryPoint{{FunctionID: "fn:a", FilePath: "a.go", Kind: EntryPointMain}},
+	}
+}
+
+func TestEntitySet_ImportRowsMatchScript(t *testing.T) {
+	entities := fullEntitySet()
+	db := NewDatalogBuilder()
+	rows := entities.importRows(db)
+
+	// Every relation the script writes, bar the HNSW-indexed embeddings,
+	// must be imported with the same columns.
+	putRe := regexp.MustCompile(`\?\[([^\]]*)\] <- .* :put (\w+) \{`)
+	scriptColumns := make(map[string][]string)
+	for _, line := range strings.Split(entities.mutations(db), "\n") {
+		if m := putRe.FindStringSubmatch(line); m != nil {
+			scriptColumns[m[2]] = strings.Split(m[1], ", ")
+		}
+	}
+	require.Len(t, rows, len(scriptColumns))
+	for relation, columns := range scriptColumns {
+		imported, ok := rows[relation]
+		require.True(t, ok, "missing %s", relation)
+		assert.Equal(t, columns, imported.Headers, relation)
+		for _, row := range imported.Rows {
+			assert.Len(t, row, len(imported.Headers), relation)
+		}
+	}
+
+	assert.Equal(t, "call:fn:a|fn:b", rows["cie_calls"].Rows[0][0])
+	assert.Equal(t, "func A() {}", rows["cie_function_code"].Rows[0][1], "NUL bytes are dropped as in scripts")
+}
+
+func TestEntitySet_ImportRowsSkipEmbeddings(t *testing.T) {
+	entities := twoFileEntities()
+	rows := entities.importRows(NewDatalogBuilder())
+	assert.NotContains(t, rows, "cie_function_embedding")
+	functions, types := entities.embeddingRows()
+	assert.Len(t, functions, 2)
+	assert.Empty(t, types)
+	assert.Equal(t, "fn:a", functions[0]["function_id"])
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// anonymousPlaceholderPattern matches the provisional names parsers give
+// anonymous functions while walking the AST ($anon_N, $arrow_N, $lambda_N).
+var anonymousPlaceholderPattern = regexp.MustCompile(`^\$(anon|arrow|lambda)_\d+$`)
+
+// anonymousKinds maps a placeholder prefix to the kind used in final names.
+var anonymousKinds = map[string]string{
+	"anon":   "closure",
+	"arrow":  "arrow",
+	"lambda": "lambda",
+}
+
+// localizeAnonymousFunctions renames anonymous functions after the function
+// that encloses them: the third closure in Server.Start becomes
+// "Server.Start.closure#3", an arrow inside handle becomes "handle.arrow#1".
+// Numbering is per enclosing function and kind, in source order. Anonymous
+// functions outside any function are numbered per file ("arrow#2").
+//
+// IDs are regenerated for the new names, so this must run before calls are
+// extracted. The link to the enclosing function is recorded in cie_contains
+// (see BuildContainsIndex), not as a call.
+func localizeAnonymousFunctions(functions []FunctionEntity) {
+	order := make([]int, len(functions))
+	for i := range order {
+		order[i] = i
+	}
+	// Outer functions start first (or end last), so parents are named
+	// before their children.
+	sort.SliceStable(order, func(a, b int) bool {
+		fa, fb := functions[order[a]], functions[order[b]]
+		if fa.StartLine != fb.StartLine || fa.StartCol != fb.StartCol {
+			return positionBefore(fa.StartLine, fa.StartCol, fb.StartLine, fb.StartCol)
+		}
+		return positionBefore(fb.EndLine, fb.EndCol, fa.EndLine, fa.EndCol)
+	})
+
+	counters := make(map[string]int)
+	for _, i := range order {
+		fn := &functions[i]
+		m := anonymousPlaceholderPattern.FindStringSubmatch(fn.Name)
+		if m == nil {
+			continue
+		}
+		kind := anonymousKinds[m[1]]
+
+		parent := enclosingFunction(functions, i)
+		prefix := ""
+		if parent >= 0 {
+			prefix = functions[parent].Name + "."
+		}
+		counters[prefix+kind]++
+		fn.Name = fmt.Sprintf("%s%s#%d", prefix, kind, counters[prefix+kind])
+		fn.ID = GenerateFunctionID(fn.FilePath, fn.Name, fn.Signature, fn.StartLine, fn.EndLine, fn.StartCol, fn.EndCol)
+	}
+}
+
+// enclosingFunction returns the index of the innermost function whose range
+// strictly contains functions[child], or -1.
+func enclosingFunction(functions []FunctionEntity, child int) int {
+	c := functions[child]
+	best := -1
+	for i, fn := range functions {
+		if i == child || !containsRange(fn, c) {
+			continue
+		}
+		if best < 0 || containsRange(functions[best], fn) {
+			best = i
+		}
+	}
+	return best
+}
+
+// containsRange reports whether outer's range contains inner's and differs from it.
+func containsRange(outer, inner FunctionEntity) bool {
+	if outer.StartLine == inner.StartLine && outer.StartCol == inner.StartCol &&
+		outer.EndLine == inner.EndLine && outer.EndCol == inner.EndCol {
+		return false
+	}
+	return !positionBefore(inner.StartLine, inner.StartCol, outer.StartLine, outer.StartCol) &&
+		!positionBefore(outer.EndLine, outer.EndCol, inner.EndLine, inner.EndCol)
+}
+
+// positionBefore reports whether (line1, col1) comes before (line2, col2).
+func positionBefore(line1, col1, line2, col2 int) bool {
+	return line1 < line2 || (line1 == line2 && col1 < col2)
+}
@@ -0,0 +1,67 @@
+package ingestion
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalizeAnonymousFunctions(t *testing.T) {
+	functions := []FunctionEntity{
+		{ID: "func:start", Name: "Server.Start", FilePath: "server.go", StartLine: 10, StartCol: 1, EndLine: 40, EndCol: 2},
+		{Name: "$anon_1", FilePath: "server.go", StartLine: 12, StartCol: 5, EndLine: 20, EndCol: 3},
+		{Name: "$anon_2", FilePath: "server.go", StartLine: 14, StartCol: 9, EndLine: 16, EndCol: 4},
+		{Name: "$anon_3", FilePath: "server.go", StartLine: 25, StartCol: 5, EndLine: 30, EndCol: 3},
+		{Name: "$anon_4", FilePath: "server.go", StartLine: 50, StartCol: 13, EndLine: 52, EndCol: 2},
+	}
+
+	localizeAnonymousFunctions(functions)
+
+	names := make([]string, len(functions))
+	for i, fn := range functions {
+		names[i] = fn.Name
+	}
+	assert.Equal(t, []string{
+		"Server.Start",
+		"Server.Start.closure#1",
+		"Server.Start.closure#1.closure#1",
+		"Server.Start.closure#2",
+		"closure#1",
+	}, names)
+
+	for _, fn := range functions[1:] {
+		assert.Equal(t, GenerateFunctionID(fn.FilePath, fn.Name, fn.Signature, fn.StartLine, fn.EndLine, fn.StartCol, fn.EndCol), fn.ID,
+			"ID of %s should follow its new name", fn.Name)
+	}
+}
+
+func TestLocalizeAnonymousFunctions_JavaScriptArrows(t *testing.T) {
+	code := `function register(app) {
+  app.get("/a", (req, res) => res.send("a"));
+  app.get("/b", (req, res) => res.send("b"));
+}
+
+[1, 2].map(x => x * 2);
+`
+	tmpFile := filepath.Join(t.TempDir(), "routes.js")
+	require.NoError(t, os.WriteFile(tmpFile, []byte(code), 0600))
+
+	result, err := NewTreeSitterParser(nil).ParseFile(FileInfo{
+		Path:     "routes.js",
+		FullPath: tmpFile,
+		Size:     int64(len(code)),
+		Language: "javascript",
+	})
+	require.NoError(t, err)
+
+	names := make(map[string]bool)
+	for _, fn := range result.Functions {
+		names[fn.Name] = true
+	}
+	assert.True(t, names["register.arrow#1"], "got %v", names)
+	assert.True(t, names["register.arrow#2"], "got %v", names)
+	assert.True(t, names["arrow#1"], "got %v", names)
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// codeChunkBytes is the target size of one cie_function_code_chunk row.
+// Chunks end at a line break when one falls in the second half of the chunk.
+const codeChunkBytes = 32 * 1024
+
+// CodeChunk is one piece of the code past a function's CodeText size limit.
+type CodeChunk struct {
+	Index     int    // Position of the chunk, from 0
+	StartLine int    // File line the chunk's first byte is on
+	Text      string // Verbatim code
+}
+
+// splitCodeText cuts codeText at limit bytes, backing up to the start of a
+// UTF-8 sequence so neither part holds half a character.
+func splitCodeText(codeText string, limit int64) (kept, rest string) {
+	cut := int(limit)
+	for cut > 0 && !utf8.RuneStart(codeText[cut]) {
+		cut--
+	}
+	return codeText[:cut], codeText[cut:]
+}
+
+// codeOverflow holds the code truncateCodeText cuts off, by file, until
+// ParseFile moves it onto the function entities. The zero value is ready to
+// use and safe for concurrent parses.
+type codeOverflow struct {
+	mu  sync.Mutex
+	cut map[string]map[string]string // file path -> kept code -> rest
+}
+
+// record remembers rest as the code cut from kept in filePath.
+func (o *codeOverflow) record(filePath, kept, rest string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.cut == nil {
+		o.cut = make(map[string]map[string]string)
+	}
+	if o.cut[filePath] == nil {
+		o.cut[filePath] = make(map[string]string)
+	}
+	o.cut[filePath][kept] = rest
+}
+
+// attach sets CodeOverflow on the functions of filePath whose CodeText was
+// cut, and forgets the file's cuts, including those of types.
+func (o *codeOverflow) attach(filePath string, functions []FunctionEntity) {
+	o.mu.Lock()
+	cut := o.cut[filePath]
+	delete(o.cut, filePath)
+	o.mu.Unlock()
+	if len(cut) == 0 {
+		return
+	}
+	for i := range functions {
+		if rest, ok := cut[functions[i].CodeText]; ok {
+			functions[i].CodeOverflow = rest
+		}
+	}
+}
+
+// CodeChunks splits fn's CodeOverflow into the rows of
+// cie_function_code_chunk. It returns nil when CodeText is complete.
+func (fn FunctionEntity) CodeChunks() []CodeChunk {
+	if fn.CodeOverflow == "" {
+		return nil
+	}
+	var chunks []CodeChunk
+	line := fn.StartLine + strings.Count(fn.CodeText, "\n")
+	rest := fn.CodeOverflow
+	for rest != "" {
+		size := len(rest)
+		if size > codeChunkBytes {
+			size = codeChunkBytes
+			if nl := strings.LastIndexByte(rest[:size], '\n'); nl >= codeChunkBytes/2 {
+				size = nl + 1
+			}
+			for size > 1 && !utf8.RuneStart(rest[size]) {
+				size--
+			}
+		}
+		chunks = append(chunks, CodeChunk{Index: len(chunks), StartLine: line, Text: rest[:size]})
+		line += strings.Count(rest[:size], "\n")
+		rest = rest[size:]
+	}
+	return chunks
+}
@@ -0,0 +1,84 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFile_KeepsCodeOverflow(t *testing.T) {
+	dir := t.TempDir()
+	content := "package main\n\nfunc big() {\n" + strings.Repeat("\tprintln(\"line\")\n", 200) + "}\n\nfunc small() {}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "big.go"), []byte(content), 0600))
+
+	parser := NewTreeSitterParser(nil)
+	parser.SetMaxCodeTextSize(1000)
+	result, err := parser.ParseFile(FileInfo{Path: "big.go", FullPath: filepath.Join(dir, "big.go"), Language: "go"})
+	require.NoError(t, err)
+
+	byName := make(map[string]FunctionEntity)
+	for _, fn := range result.Functions {
+		byName[fn.Name] = fn
+	}
+	big, small := byName["big"], byName["small"]
+	assert.Len(t, big.CodeText, 1000)
+	assert.True(t, strings.HasSuffix(big.CodeText+big.CodeOverflow, "\tprintln(\"line\")\n}"), "CodeText and CodeOverflow should add up to the whole function")
+	assert.Equal(t, strings.Count(content, "\n")-2, big.StartLine+strings.Count(big.CodeText+big.CodeOverflow, "\n"))
+	assert.Empty(t, small.CodeOverflow)
+	assert.Empty(t, parser.overflow.cut, "ParseFile should forget the file's cuts")
+}
+
+func TestSplitCodeText_RuneBoundary(t *testing.T) {
+	kept, rest := splitCodeText("ab€cd", 3) // € is 3 bytes, starting at byte 2
+	assert.Equal(t, "ab", kept)
+	assert.Equal(t, "€cd", rest)
+}
+
+func TestCodeChunks(t *testing.T) {
+	line := strings.Repeat("x", 99) + "\n"
+	fn := FunctionEntity{
+		StartLine:    10,
+		CodeText:     "func F() {\n",
+		CodeOverflow: strings.Repeat(line, 1000), // 100,000 bytes
+	}
+	chunks := fn.CodeChunks()
+	require.Len(t, chunks, 4)
+
+	var joined strings.Builder
+	wantLine := 11
+	for i, c := range chunks {
+		assert.Equal(t, i, c.Index)
+		assert.Equal(t, wantLine, c.StartLine, "chunk %d", i)
+		assert.LessOrEqual(t, len(c.Text), codeChunkBytes)
+		if i < len(chunks)-1 {
+			assert.True(t, strings.HasSuffix(c.Text, "\n"), "chunk %d should end at a line break", i)
+		}
+		wantLine += strings.Count(c.Text, "\n")
+		joined.WriteString(c.Text)
+	}
+	assert.Equal(t, fn.CodeOverflow, joined.String())
+
+	assert.Nil(t, FunctionEntity{CodeText: "func F() {}"}.CodeChunks())
+}
@@ -72,14 +72,75 @@ type IngestionConfig struct {
 	MaxFileSizeBytes int64
 
 	// MaxCodeTextBytes is the maximum size for function code_text (default: 100KB).
-	// CodeText exceeding this is truncated with a warning.
+	// Code past it is stored in cie_function_code_chunk; embeddings see only
+	// the first MaxCodeTextBytes.
 	MaxCodeTextBytes int64
 
+	// CompressCodeText stores cie_function_code.code_text zstd-compressed
+	// (default: false). Code text dominates index size, so this typically
+	// shrinks the database several times over at the cost of moving text
+	// matching in grep-style tools from CozoDB to the client.
+	CompressCodeText bool
+
+	// StoreFileText stores the full text of every indexed text file in
+	// cie_file_content (default: false), so searches can reach package-level
+	// declarations, comments between functions and non-code files. Honors
+	// CompressCodeText.
+	StoreFileText bool
+
+	// ImportBatchFiles is how many files each bulk import transaction of a
+	// full run carries (default: 500). Full runs bypass Datalog :put scripts
+	// and load rows through Cozo's import API; set a negative value to write
+	// them file by file, as they are parsed, like incremental runs.
+	ImportBatchFiles int
+
+	// ContentHashMode controls which content differences change a file's
+	// hash (default: ContentHashLineEndings). Normalizing modes also parse
+	// CRLF files with LF endings, so the same commit checked out on Windows
+	// and Linux yields identical hashes, code text and embedding cache keys.
+	ContentHashMode ContentHashMode
+
+	// EmbeddingCachePath is the shared, content-addressed embedding cache
+	// (see storage.EmbeddingCache). Empty disables the cache.
+	EmbeddingCachePath string
+
+	// EmbeddingModelID identifies the provider, model and dimensions in
+	// cache keys, so vectors from different models never mix. The pipeline
+	// adds the effective document prefix to it.
+	EmbeddingModelID string
+
+	// EmbeddingDocumentPrefix replaces the provider's default prefix for
+	// embedded documents (see embedprefix.Defaults). Nil keeps the
+	// default; an empty string embeds documents as-is.
+	EmbeddingDocumentPrefix *string
+
+	// NameEmbeddings also embeds each function's name and signature into
+	// cie_function_name_embedding, so semantic search can match queries
+	// naming a function as well as ones describing its body. It doubles
+	// the embedding calls for functions (default: false).
+	NameEmbeddings bool
+
+	// HNSWDistance is the metric of the vector indexes when they are first
+	// created: "cosine" (default), "l2" or "ip". Existing indexes keep
+	// theirs; see storage.EmbeddedConfig.HNSWDistance.
+	HNSWDistance string
+
+	// SkipEmbeddings writes the structural index without generating
+	// embeddings (default: false). Semantic search stays empty until
+	// LocalPipeline.BackfillEmbeddings fills the gap.
+	SkipEmbeddings bool
+
 	// ExcludeGlobs are glob patterns for files/directories to exclude.
 	// Supports full glob syntax: *, **, ?, [abc], [a-z], [!abc]
 	// Common patterns: ["node_modules/**", ".git/**", "dist/**", "vendor/**"]
 	ExcludeGlobs []string
 
+	// LanguageLimits overrides MaxFileSizeBytes and MaxCodeTextBytes, and adds
+	// exclude globs, for the files of one language. Keys are detected language
+	// names ("go", "javascript", ...). For example, allow larger Go files while
+	// excluding "**/*.min.js" and capping code text for JavaScript.
+	LanguageLimits map[string]LanguageLimits
+
 	// Concurrency controls worker pools.
 	Concurrency ConcurrencyConfig
 
@@ -134,12 +195,47 @@ type IngestionConfig struct {
 	// LocalEngine is the CozoDB storage engine for local mode.
 	// Options: "rocksdb" (default), "sqlite", or "mem".
 	LocalEngine string
+
+	// LocalNamespace writes this project into a database shared with other
+	// projects, prefixing every relation with the namespace. Typically the
+	// project ID; empty means the database belongs to this project alone.
+	LocalNamespace string
+
+	// LocalSharded stores each top-level directory in its own CozoDB store
+	// (see storage.EmbeddedConfig.Sharded).
+	LocalSharded bool
+
+	// KeepSnapshots saves a snapshot of the index named after the commit
+	// SHA after every run that records one, keeping the newest
+	// KeepSnapshots (default: 0, no snapshots). See storage.OpenSnapshot.
+	// Not supported with LocalSharded.
+	KeepSnapshots int
+
+	// AnalysisScripts are Starlark scripts run against the index after
+	// every run that writes to it, in order (see package scripting). A
+	// failing script is logged and does not fail the run.
+	AnalysisScripts []string
+
+	// ReindexShards rebuilds only these top-level directories of a sharded
+	// index: their stores are dropped and their files parsed again, while
+	// other shards and the last indexed SHA are left alone. Calls into other
+	// shards resolve only on a full run.
+	ReindexShards []string
+}
+
+// LanguageLimits holds the per-language overrides of IngestionConfig.
+type LanguageLimits struct {
+	MaxFileSizeBytes int64    // 0 keeps the global MaxFileSizeBytes
+	MaxCodeTextBytes int64    // 0 keeps the global MaxCodeTextBytes
+	ExcludeGlobs     []string // Applied in addition to the global ExcludeGlobs
 }
 
 // ConcurrencyConfig controls worker pool sizes.
 type ConcurrencyConfig struct {
 	ParseWorkers int // Number of parallel file parsers
 	EmbedWorkers int // Number of parallel embedding generators
+	WriteWorkers int // Number of goroutines rendering per-file write scripts (default: ParseWorkers)
+	WriteQueue   int // Rendered scripts waiting for the writer (default: 64)
 }
 
 // RetryConfig controls retry behavior for gRPC calls.
@@ -188,6 +284,7 @@ func DefaultConfig() IngestionConfig {
 		BatchTargetMutations: 2000,           // Increased for fewer replication log entries (reduces edge CPU usage)
 		MaxFileSizeBytes:     1048576,        // 1MB
 		MaxCodeTextBytes:     102400,         // 100KB (balance between coverage and performance)
+		ContentHashMode:      ContentHashLineEndings,
 		ExcludeGlobs: []string{
 			// Version control
 			".git/**",
@@ -212,7 +309,7 @@ func DefaultConfig() IngestionConfig {
 			// Lock files (not code)
 			"package-lock.json", "yarn.lock", "pnpm-lock.yaml", "go.sum",
 		},
-		Concurrency:    ConcurrencyConfig{ParseWorkers: 4, EmbedWorkers: 8},
+		Concurrency:    ConcurrencyConfig{ParseWorkers: 4, EmbedWorkers: 8, WriteWorkers: 4, WriteQueue: defaultWriteQueue},
 		PrimaryHubAddr: "localhost:50051",
 		GRPCTimeout:    120 * time.Second, // Increased for large batches over slow networks
 		RetryConfig: RetryConfig{
@@ -0,0 +1,128 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"path"
+	"strings"
+)
+
+// BuildContainsIndex links every nested function to the definition that
+// contains it: closures and nested functions to the innermost enclosing
+// function, methods to their type (see BuildMethodOfIndex).
+func BuildContainsIndex(types []TypeEntity, functions []FunctionEntity) []ContainsEdge {
+	lookup := newTypeLookup(types)
+	var edges []ContainsEdge
+	for file, fns := range functionsByFile(functions) {
+		for i, fn := range fns {
+			if parent := enclosingFunction(fns, i); parent >= 0 {
+				edges = append(edges, ContainsEdge{ParentID: fns[parent].ID, ParentKind: "function", ChildID: fn.ID, FilePath: file})
+				continue
+			}
+			if t, ok := lookup.owner(fn); ok && t.ID != "" {
+				edges = append(edges, ContainsEdge{ParentID: t.ID, ParentKind: "type", ChildID: fn.ID, FilePath: file})
+			}
+		}
+	}
+	return edges
+}
+
+// BuildMethodOfIndex records the type each method belongs to. Class methods
+// belong to the class whose body contains them. Go methods, declared
+// outside their type, belong to the receiver type: the type of that name
+// in the same package directory, or just the name when the type is not
+// among types.
+func BuildMethodOfIndex(types []TypeEntity, functions []FunctionEntity) []MethodOfEdge {
+	lookup := newTypeLookup(types)
+	var edges []MethodOfEdge
+	for _, fns := range functionsByFile(functions) {
+		for i, fn := range fns {
+			if enclosingFunction(fns, i) >= 0 {
+				continue // nested functions are not methods
+			}
+			if t, ok := lookup.owner(fn); ok {
+				edges = append(edges, MethodOfEdge{MethodID: fn.ID, TypeID: t.ID, TypeName: t.Name, FilePath: fn.FilePath})
+			}
+		}
+	}
+	return edges
+}
+
+// typeLookup finds the type a top-level function belongs to.
+type typeLookup struct {
+	byFile    map[string][]TypeEntity
+	byDirName map[string]TypeEntity
+}
+
+func newTypeLookup(types []TypeEntity) typeLookup {
+	l := typeLookup{byFile: make(map[string][]TypeEntity), byDirName: make(map[string]TypeEntity)}
+	for _, t := range types {
+		l.byFile[t.FilePath] = append(l.byFile[t.FilePath], t)
+		key := path.Dir(t.FilePath) + "|" + t.Name
+		if _, ok := l.byDirName[key]; !ok {
+			l.byDirName[key] = t
+		}
+	}
+	return l
+}
+
+// owner returns the type fn belongs to: the innermost type whose range
+// contains it, or for a Go method ("Type.Method") the receiver type. A
+// receiver type not among the known types is returned with an empty ID.
+func (l typeLookup) owner(fn FunctionEntity) (TypeEntity, bool) {
+	if t, ok := enclosingType(l.byFile[fn.FilePath], fn); ok {
+		return t, true
+	}
+	if !strings.HasSuffix(fn.FilePath, ".go") {
+		return TypeEntity{}, false
+	}
+	receiver, _, ok := strings.Cut(fn.Name, ".")
+	if !ok || receiver == "" || strings.Contains(fn.Name, "#") {
+		return TypeEntity{}, false
+	}
+	if t, ok := l.byDirName[path.Dir(fn.FilePath)+"|"+receiver]; ok {
+		return t, true
+	}
+	return TypeEntity{Name: receiver}, true
+}
+
+// functionsByFile groups functions by file, keeping their order.
+func functionsByFile(functions []FunctionEntity) map[string][]FunctionEntity {
+	byFile := make(map[string][]FunctionEntity)
+	for _, fn := range functions {
+		byFile[fn.FilePath] = append(byFile[fn.FilePath], fn)
+	}
+	return byFile
+}
+
+// enclosingType returns the innermost type whose range contains fn.
+func enclosingType(types []TypeEntity, fn FunctionEntity) (TypeEntity, bool) {
+	var best TypeEntity
+	found := false
+	for _, t := range types {
+		outer := FunctionEntity{StartLine: t.StartLine, StartCol: t.StartCol, EndLine: t.EndLine, EndCol: t.EndCol}
+		if !containsRange(outer, fn) {
+			continue
+		}
+		if !found || positionBefore(best.StartLine, best.StartCol, t.StartLine, t.StartCol) {
+			best, found = t, true
+		}
+	}
+	return best, found
+}
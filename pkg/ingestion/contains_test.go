@@ -0,0 +1,72 @@
+package ingestion
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildContainsIndex(t *testing.T) {
+	types := []TypeEntity{
+		{ID: "type:server", Name: "Server", Kind: "struct", FilePath: "api/types.go", StartLine: 3, EndLine: 6, StartCol: 1, EndCol: 2},
+		{ID: "type:parser", Name: "Parser", Kind: "class", FilePath: "lib/parser.py", StartLine: 1, EndLine: 20, StartCol: 1, EndCol: 30},
+	}
+	functions := []FunctionEntity{
+		// Go method in another file of the same package, with a closure
+		{ID: "fn:start", Name: "Server.Start", FilePath: "api/server.go", StartLine: 10, EndLine: 30, StartCol: 1, EndCol: 2},
+		{ID: "fn:closure", Name: "Server.Start.closure#1", FilePath: "api/server.go", StartLine: 12, EndLine: 15, StartCol: 5, EndCol: 3},
+		// Same type name in another package: not linked
+		{ID: "fn:other", Name: "Server.Stop", FilePath: "cmd/server.go", StartLine: 1, EndLine: 3, StartCol: 1, EndCol: 2},
+		// Python method inside its class, with a nested function
+		{ID: "fn:parse", Name: "Parser.parse", FilePath: "lib/parser.py", StartLine: 5, EndLine: 15, StartCol: 5, EndCol: 20},
+		{ID: "fn:helper", Name: "helper", FilePath: "lib/parser.py", StartLine: 7, EndLine: 9, StartCol: 9, EndCol: 25},
+		// Top-level function
+		{ID: "fn:main", Name: "main", FilePath: "api/server.go", StartLine: 40, EndLine: 42, StartCol: 1, EndCol: 2},
+	}
+
+	edges := BuildContainsIndex(types, functions)
+
+	assert.ElementsMatch(t, []ContainsEdge{
+		{ParentID: "type:server", ParentKind: "type", ChildID: "fn:start", FilePath: "api/server.go"},
+		{ParentID: "fn:start", ParentKind: "function", ChildID: "fn:closure", FilePath: "api/server.go"},
+		{ParentID: "type:parser", ParentKind: "type", ChildID: "fn:parse", FilePath: "lib/parser.py"},
+		{ParentID: "fn:parse", ParentKind: "function", ChildID: "fn:helper", FilePath: "lib/parser.py"},
+	}, edges)
+}
+
+func TestBuildContainsMutations(t *testing.T) {
+	edge := ContainsEdge{ParentID: "fn:start", ParentKind: "function", ChildID: "fn:closure", FilePath: "api/server.go"}
+	script := NewDatalogBuilder().BuildContainsMutations([]ContainsEdge{edge})
+
+	assert.Contains(t, script, ":put cie_contains { id, parent_id, parent_kind, child_id, file_path }")
+	assert.Contains(t, script, GenerateContainsID("fn:start", "fn:closure"))
+	assert.Equal(t, 1, strings.Count(script, ":put"))
+}
+
+func TestBuildMethodOfIndex(t *testing.T) {
+	types := []TypeEntity{
+		{ID: "type:server", Name: "Server", Kind: "struct", FilePath: "api/types.go", StartLine: 3, EndLine: 6, StartCol: 1, EndCol: 2},
+		{ID: "type:parser", Name: "Parser", Kind: "class", FilePath: "lib/parser.py", StartLine: 1, EndLine: 20, StartCol: 1, EndCol: 30},
+	}
+	functions := []FunctionEntity{
+		{ID: "fn:start", Name: "Server.Start", FilePath: "api/server.go", StartLine: 10, EndLine: 30, StartCol: 1, EndCol: 2},
+		{ID: "fn:closure", Name: "Server.Start.closure#1", FilePath: "api/server.go", StartLine: 12, EndLine: 15, StartCol: 5, EndCol: 3},
+		// Receiver type not parsed in this run (incremental indexing)
+		{ID: "fn:stop", Name: "Client.Stop", FilePath: "api/client.go", StartLine: 1, EndLine: 3, StartCol: 1, EndCol: 2},
+		{ID: "fn:parse", Name: "Parser.parse", FilePath: "lib/parser.py", StartLine: 5, EndLine: 15, StartCol: 5, EndCol: 20},
+		{ID: "fn:helper", Name: "helper", FilePath: "lib/parser.py", StartLine: 7, EndLine: 9, StartCol: 9, EndCol: 25},
+		{ID: "fn:main", Name: "main", FilePath: "api/server.go", StartLine: 40, EndLine: 42, StartCol: 1, EndCol: 2},
+	}
+
+	edges := BuildMethodOfIndex(types, functions)
+
+	assert.ElementsMatch(t, []MethodOfEdge{
+		{MethodID: "fn:start", TypeID: "type:server", TypeName: "Server", FilePath: "api/server.go"},
+		{MethodID: "fn:stop", TypeID: "", TypeName: "Client", FilePath: "api/client.go"},
+		{MethodID: "fn:parse", TypeID: "type:parser", TypeName: "Parser", FilePath: "lib/parser.py"},
+	}, edges)
+
+	script := NewDatalogBuilder().BuildMethodOfMutations(edges[:1])
+	assert.Contains(t, script, ":put cie_method_of { id, method_id, type_id, type_name, file_path }")
+}
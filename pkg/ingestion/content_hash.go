@@ -0,0 +1,95 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package ingestion
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// ContentHashMode selects which differences in file content change the
+// hash stored on cie_file.
+type ContentHashMode string
+
+const (
+	// ContentHashExact hashes the bytes on disk.
+	ContentHashExact ContentHashMode = "exact"
+
+	// ContentHashLineEndings treats CRLF as LF (default), so a checkout with
+	// core.autocrlf hashes the same on Windows as on Linux and macOS.
+	ContentHashLineEndings ContentHashMode = "line_endings"
+
+	// ContentHashWhitespace additionally ignores trailing whitespace on each
+	// line and trailing blank lines at the end of the file.
+	ContentHashWhitespace ContentHashMode = "whitespace"
+)
+
+// ValidContentHashMode reports whether mode is a known mode. The empty
+// string is valid and means ContentHashLineEndings.
+func ValidContentHashMode(mode ContentHashMode) bool {
+	switch mode {
+	case "", ContentHashExact, ContentHashLineEndings, ContentHashWhitespace:
+		return true
+	}
+	return false
+}
+
+// ContentHash returns the hex SHA-256 of content after applying mode's
+// normalization.
+func ContentHash(content []byte, mode ContentHashMode) string {
+	hash := sha256.Sum256(normalizeForHash(content, mode))
+	return hex.EncodeToString(hash[:])
+}
+
+// normalizeForHash returns content as mode sees it. content is not modified.
+func normalizeForHash(content []byte, mode ContentHashMode) []byte {
+	switch mode {
+	case ContentHashExact:
+		return content
+	case ContentHashWhitespace:
+		return trimTrailingWhitespace(normalizeLineEndings(content))
+	default:
+		return normalizeLineEndings(content)
+	}
+}
+
+// normalizeLineEndings replaces CRLF with LF. Lone CRs are kept. The input
+// is returned as is when it has no CRLF.
+func normalizeLineEndings(content []byte) []byte {
+	if !bytes.Contains(content, []byte("\r\n")) {
+		return content
+	}
+	return bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+}
+
+// trimTrailingWhitespace drops spaces and tabs at the end of every line and
+// blank lines at the end of content. Lines are joined with LF.
+func trimTrailingWhitespace(content []byte) []byte {
+	lines := bytes.Split(content, []byte("\n"))
+	out := make([]byte, 0, len(content))
+	for i, line := range lines {
+		if i > 0 {
+			out = append(out, '\n')
+		}
+		out = append(out, bytes.TrimRight(line, " \t")...)
+	}
+	return bytes.TrimRight(out, "\n")
+}
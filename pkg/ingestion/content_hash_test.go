@@ -0,0 +1,78 @@
+package ingestion
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentHash(t *testing.T) {
+	lf := []byte("package main\n\nfunc f() {}\n")
+	crlf := []byte("package main\r\n\r\nfunc f() {}\r\n")
+	trailing := []byte("package main \n\nfunc f() {}\t\n\n\n")
+
+	tests := []struct {
+		name      string
+		mode      ContentHashMode
+		a, b      []byte
+		wantEqual bool
+	}{
+		{"default ignores crlf", "", lf, crlf, true},
+		{"line endings ignores crlf", ContentHashLineEndings, lf, crlf, true},
+		{"line endings keeps trailing whitespace", ContentHashLineEndings, lf, trailing, false},
+		{"whitespace ignores trailing whitespace", ContentHashWhitespace, lf, trailing, true},
+		{"whitespace ignores crlf", ContentHashWhitespace, crlf, trailing, true},
+		{"exact sees crlf", ContentHashExact, lf, crlf, false},
+		{"leading whitespace matters", ContentHashWhitespace, lf, []byte("package main\n\n\tfunc f() {}\n"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			equal := ContentHash(tt.a, tt.mode) == ContentHash(tt.b, tt.mode)
+			assert.Equal(t, tt.wantEqual, equal)
+		})
+	}
+}
+
+func TestValidContentHashMode(t *testing.T) {
+	for _, mode := range []ContentHashMode{"", ContentHashExact, ContentHashLineEndings, ContentHashWhitespace} {
+		assert.True(t, ValidContentHashMode(mode), mode)
+	}
+	assert.False(t, ValidContentHashMode("crlf"))
+}
+
+func TestTreeSitterParser_CRLFCheckout(t *testing.T) {
+	dir := t.TempDir()
+	src := "package main\n\n// F returns one.\nfunc F() int {\n\treturn 1\n}\n"
+	lfPath := filepath.Join(dir, "lf.go")
+	crlfPath := filepath.Join(dir, "crlf.go")
+	require.NoError(t, os.WriteFile(lfPath, []byte(src), 0o600))
+	require.NoError(t, os.WriteFile(crlfPath, toCRLF(src), 0o600))
+
+	parse := func(mode ContentHashMode, fullPath string) *ParseResult {
+		parser := NewTreeSitterParser(nil)
+		parser.SetContentHashMode(mode)
+		result, err := parser.ParseFile(FileInfo{Path: "main.go", FullPath: fullPath, Language: "go"})
+		require.NoError(t, err)
+		require.Len(t, result.Functions, 1)
+		return result
+	}
+
+	lf, crlf := parse(ContentHashLineEndings, lfPath), parse(ContentHashLineEndings, crlfPath)
+	assert.Equal(t, lf.File.Hash, crlf.File.Hash)
+	assert.Equal(t, lf.Functions[0].CodeText, crlf.Functions[0].CodeText)
+	assert.Equal(t, lf.Functions[0].StartLine, crlf.Functions[0].StartLine)
+	assert.Equal(t, lf.Functions[0].EndLine, crlf.Functions[0].EndLine)
+
+	lf, crlf = parse(ContentHashExact, lfPath), parse(ContentHashExact, crlfPath)
+	assert.NotEqual(t, lf.File.Hash, crlf.File.Hash)
+	assert.Contains(t, crlf.Functions[0].CodeText, "\r\n")
+}
+
+// toCRLF converts LF line endings in s to CRLF.
+func toCRLF(s string) []byte {
+	return []byte(strings.ReplaceAll(s, "\n", "\r\n"))
+}
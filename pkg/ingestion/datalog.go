@@ -24,6 +24,8 @@ import (
 	"math"
 	"strconv"
 	"strings"
+
+	"github.com/kraklabs/cie/pkg/storage"
 )
 
 // DatalogBuilder generates Datalog mutation scripts from entities.
@@ -31,13 +33,17 @@ import (
 //   - cie_file: id, path, hash, language, size
 //   - cie_function: id, name, signature, file_path, start_line, end_line, start_col, end_col
 //   - cie_function_code: function_id, code_text
+//   - cie_function_code_chunk: function_id, chunk, start_line, code_text
 //   - cie_function_embedding: function_id, embedding
+//   - cie_function_name_embedding: function_id, embedding
+//   - cie_function_lang: function_id, language, dialect
 //   - cie_type: id, name, kind, file_path, start_line, end_line, start_col, end_col
 //   - cie_type_code: type_id, code_text
 //   - cie_type_embedding: type_id, embedding
 //   - cie_defines: file_id, function_id
 //   - cie_calls: caller_id, callee_id
 type DatalogBuilder struct {
+	compressCode bool
 }
 
 // NewDatalogBuilder creates a new Datalog builder.
@@ -45,6 +51,28 @@ func NewDatalogBuilder() *DatalogBuilder {
 	return &DatalogBuilder{}
 }
 
+// SetCompressCodeText enables zstd compression of cie_function_code.code_text.
+// Compressed values carry the storage.CodeTextZstdPrefix marker so readers can
+// tell them apart from plain rows written by earlier runs.
+func (db *DatalogBuilder) SetCompressCodeText(enabled bool) {
+	db.compressCode = enabled
+}
+
+// storedText returns the value to store for source text columns
+// (cie_function_code.code_text, cie_file_content.content). If compression
+// fails for any reason the plain text is stored instead, which readers handle
+// transparently.
+func (db *DatalogBuilder) storedText(codeText string) string {
+	if !db.compressCode {
+		return codeText
+	}
+	compressed, err := storage.CompressCodeText(codeText)
+	if err != nil {
+		return codeText
+	}
+	return compressed
+}
+
 // ValidationError represents a validation error with details.
 type ValidationError struct {
 	EntityType string
@@ -211,6 +239,15 @@ func (db *DatalogBuilder) BuildMutationsWithTypes(
 			fmt.Sprintf("%d", file.Size),
 		}, ", "))
 		buf.WriteString("]] :put cie_file { id, path, hash, language, size } }\n")
+
+		// Full file text (cie_file_content) - only when text storage is enabled
+		if file.Content != "" {
+			buf.WriteString("{ ?[file_id, content] <- [[")
+			buf.WriteString(quoteString(file.ID))
+			buf.WriteString(", ")
+			buf.WriteString(quoteString(db.storedText(file.Content)))
+			buf.WriteString("]] :put cie_file_content { file_id, content } }\n")
+		}
 	}
 
 	// Function entities (v3: split into 3 tables for performance)
@@ -233,10 +270,22 @@ func (db *DatalogBuilder) BuildMutationsWithTypes(
 		buf.WriteString("{ ?[function_id, code_text] <- [[")
 		buf.WriteString(strings.Join([]string{
 			quoteString(fn.ID),
-			quoteString(fn.CodeText),
+			quoteString(db.storedText(fn.CodeText)),
 		}, ", "))
 		buf.WriteString("]] :put cie_function_code { function_id, code_text } }\n")
 
+		// 2b. Code past the size limit (cie_function_code_chunk) - reassembled on read
+		for _, c := range fn.CodeChunks() {
+			buf.WriteString("{ ?[function_id, chunk, start_line, code_text] <- [[")
+			buf.WriteString(strings.Join([]string{
+				quoteString(fn.ID),
+				fmt.Sprintf("%d", c.Index),
+				fmt.Sprintf("%d", c.StartLine),
+				quoteString(db.storedText(c.Text)),
+			}, ", "))
+			buf.WriteString("]] :put cie_function_code_chunk { function_id, chunk, start_line, code_text } }\n")
+		}
+
 		// 3. Embedding (cie_function_embedding) - used by HNSW
 		// Skip if embedding is empty (e.g., embedding provider unavailable)
 		if len(fn.Embedding) > 0 {
@@ -248,6 +297,25 @@ func (db *DatalogBuilder) BuildMutationsWithTypes(
 			}, ", "))
 			buf.WriteString("]] :put cie_function_embedding { function_id, embedding } }\n")
 		}
+		if len(fn.NameEmbedding) > 0 {
+			buf.WriteString("{ ?[function_id, embedding] <- [[")
+			buf.WriteString(strings.Join([]string{
+				quoteString(fn.ID),
+				formatFloatArray(fn.NameEmbedding),
+			}, ", "))
+			buf.WriteString("]] :put cie_function_name_embedding { function_id, embedding } }\n")
+		}
+
+		// 4. Language and dialect (cie_function_lang) - search filters
+		if fn.Language != "" {
+			buf.WriteString("{ ?[function_id, language, dialect] <- [[")
+			buf.WriteString(strings.Join([]string{
+				quoteString(fn.ID),
+				quoteString(fn.Language),
+				quoteString(fn.Dialect),
+			}, ", "))
+			buf.WriteString("]] :put cie_function_lang { function_id, language, dialect } }\n")
+		}
 	}
 
 	// Type entities (v3: split into 3 tables for performance)
@@ -432,6 +500,178 @@ func (db *DatalogBuilder) BuildFieldAndImplementsMutations(fields []FieldEntity,
 	return buf.String()
 }
 
+// BuildContainsMutations generates Datalog :put statements for contains edges.
+func (db *DatalogBuilder) BuildContainsMutations(edges []ContainsEdge) string {
+	var buf strings.Builder
+	for _, e := range edges {
+		buf.WriteString("{ ?[id, parent_id, parent_kind, child_id, file_path] <- [[")
+		buf.WriteString(strings.Join([]string{
+			quoteString(GenerateContainsID(e.ParentID, e.ChildID)),
+			quoteString(e.ParentID),
+			quoteString(e.ParentKind),
+			quoteString(e.ChildID),
+			quoteString(e.FilePath),
+		}, ", "))
+		buf.WriteString("]] :put cie_contains { id, parent_id, parent_kind, child_id, file_path } }\n")
+	}
+	return buf.String()
+}
+
+// BuildMethodOfMutations generates Datalog :put statements for method_of edges.
+func (db *DatalogBuilder) BuildMethodOfMutations(edges []MethodOfEdge) string {
+	var buf strings.Builder
+	for _, e := range edges {
+		buf.WriteString("{ ?[id, method_id, type_id, type_name, file_path] <- [[")
+		buf.WriteString(strings.Join([]string{
+			quoteString(GenerateMethodOfID(e.MethodID)),
+			quoteString(e.MethodID),
+			quoteString(e.TypeID),
+			quoteString(e.TypeName),
+			quoteString(e.FilePath),
+		}, ", "))
+		buf.WriteString("]] :put cie_method_of { id, method_id, type_id, type_name, file_path } }\n")
+	}
+	return buf.String()
+}
+
+// BuildUnresolvedCallMutations generates Datalog :put statements for calls
+// the resolver could not link.
+func (db *DatalogBuilder) BuildUnresolvedCallMutations(calls []UnresolvedCall) string {
+	var buf strings.Builder
+	for _, c := range calls {
+		buf.WriteString("{ ?[id, caller_id, callee_name, file_path, line, reason] <- [[")
+		buf.WriteString(strings.Join([]string{
+			quoteString(GenerateUnresolvedCallID(c.CallerID, c.CalleeName, c.Line)),
+			quoteString(c.CallerID),
+			quoteString(c.CalleeName),
+			quoteString(c.FilePath),
+			strconv.Itoa(c.Line),
+			quoteString(c.Reason),
+		}, ", "))
+		buf.WriteString("]] :put cie_unresolved_call { id, caller_id, callee_name, file_path, line, reason } }\n")
+	}
+	return buf.String()
+}
+
+// BuildProtoOptionMutations generates Datalog :put statements for .proto options.
+func (db *DatalogBuilder) BuildProtoOptionMutations(options []ProtoOption) string {
+	var buf strings.Builder
+	for _, o := range options {
+		buf.WriteString("{ ?[id, file_path, scope, name, value, line] <- [[")
+		buf.WriteString(strings.Join([]string{
+			quoteString(GenerateProtoOptionID(o.FilePath, o.Scope, o.Name)),
+			quoteString(o.FilePath),
+			quoteString(o.Scope),
+			quoteString(o.Name),
+			quoteString(o.Value),
+			strconv.Itoa(o.Line),
+		}, ", "))
+		buf.WriteString("]] :put cie_proto_option { id, file_path, scope, name, value, line } }\n")
+	}
+	return buf.String()
+}
+
+// BuildGeneratedFromMutations generates Datalog :put statements for
+// generated_from edges.
+func (db *DatalogBuilder) BuildGeneratedFromMutations(edges []GeneratedFromEdge) string {
+	var buf strings.Builder
+	for _, e := range edges {
+		buf.WriteString("{ ?[id, type_id, proto_type_id, file_path] <- [[")
+		buf.WriteString(strings.Join([]string{
+			quoteString(GenerateGeneratedFromID(e.TypeID)),
+			quoteString(e.TypeID),
+			quoteString(e.ProtoTypeID),
+			quoteString(e.FilePath),
+		}, ", "))
+		buf.WriteString("]] :put cie_generated_from { id, type_id, proto_type_id, file_path } }\n")
+	}
+	return buf.String()
+}
+
+// BuildTemplateMutations generates Datalog :put statements for templates,
+// their references, and render calls.
+func (db *DatalogBuilder) BuildTemplateMutations(templates []TemplateEntity, refs []TemplateRef, renders []RenderCall) string {
+	var buf strings.Builder
+	for _, t := range templates {
+		buf.WriteString("{ ?[id, file_path, dialect] <- [[")
+		buf.WriteString(strings.Join([]string{quoteString(t.ID), quoteString(t.FilePath), quoteString(t.Dialect)}, ", "))
+		buf.WriteString("]] :put cie_template { id, file_path, dialect } }\n")
+	}
+	for _, r := range refs {
+		buf.WriteString("{ ?[id, template_id, file_path, kind, name, line] <- [[")
+		buf.WriteString(strings.Join([]string{
+			quoteString(r.ID),
+			quoteString(r.TemplateID),
+			quoteString(r.FilePath),
+			quoteString(r.Kind),
+			quoteString(r.Name),
+			strconv.Itoa(r.Line),
+		}, ", "))
+		buf.WriteString("]] :put cie_template_ref { id, template_id, file_path, kind, name, line } }\n")
+	}
+	for _, r := range renders {
+		buf.WriteString("{ ?[id, function_id, file_path, template_name, line] <- [[")
+		buf.WriteString(strings.Join([]string{
+			quoteString(GenerateRenderID(r.FunctionID, r.TemplateName)),
+			quoteString(r.FunctionID),
+			quoteString(r.FilePath),
+			quoteString(r.TemplateName),
+			strconv.Itoa(r.Line),
+		}, ", "))
+		buf.WriteString("]] :put cie_renders { id, function_id, file_path, template_name, line } }\n")
+	}
+	return buf.String()
+}
+
+// BuildCIMutations generates Datalog :put statements for CI jobs, their
+// steps, and their references.
+func (db *DatalogBuilder) BuildCIMutations(jobs []CIJob, steps []CIStep, refs []CIRef) string {
+	var buf strings.Builder
+	for _, j := range jobs {
+		buf.WriteString("{ ?[id, file_path, workflow, name, title, stage, runs_on, needs, triggers, start_line] <- [[")
+		buf.WriteString(strings.Join([]string{
+			quoteString(j.ID),
+			quoteString(j.FilePath),
+			quoteString(j.Workflow),
+			quoteString(j.Name),
+			quoteString(j.Title),
+			quoteString(j.Stage),
+			quoteString(j.RunsOn),
+			quoteString(j.Needs),
+			quoteString(j.Triggers),
+			strconv.Itoa(j.StartLine),
+		}, ", "))
+		buf.WriteString("]] :put cie_ci_job { id, file_path, workflow, name, title, stage, runs_on, needs, triggers, start_line } }\n")
+	}
+	for _, s := range steps {
+		buf.WriteString("{ ?[id, job_id, file_path, idx, name, kind, command, line] <- [[")
+		buf.WriteString(strings.Join([]string{
+			quoteString(s.ID),
+			quoteString(s.JobID),
+			quoteString(s.FilePath),
+			strconv.Itoa(s.Index),
+			quoteString(s.Name),
+			quoteString(s.Kind),
+			quoteString(s.Command),
+			strconv.Itoa(s.Line),
+		}, ", "))
+		buf.WriteString("]] :put cie_ci_step { id, job_id, file_path, idx, name, kind, command, line } }\n")
+	}
+	for _, r := range refs {
+		buf.WriteString("{ ?[id, job_id, file_path, kind, name, line] <- [[")
+		buf.WriteString(strings.Join([]string{
+			quoteString(r.ID),
+			quoteString(r.JobID),
+			quoteString(r.FilePath),
+			quoteString(r.Kind),
+			quoteString(r.Name),
+			strconv.Itoa(r.Line),
+		}, ", "))
+		buf.WriteString("]] :put cie_ci_ref { id, job_id, file_path, kind, name, line } }\n")
+	}
+	return buf.String()
+}
+
 // CountMutations estimates the number of mutations in a Datalog script.
 // This is approximate but useful for batching decisions.
 func CountMutations(script string) int {
@@ -555,10 +795,13 @@ func (db *DatalogBuilder) BuildDeletions(deletions DeletionSet) string {
 	// Delete function entities (v3: cascade to code and embedding tables)
 	for _, id := range deletions.FunctionIDs {
 		qid := quoteString(id)
-		// Delete from all 3 tables using chained queries
+		// Delete from all 5 tables using chained queries
 		buf.WriteString(fmt.Sprintf("{ ?[id] <- [[%s]] :rm cie_function {id} }\n", qid))
 		buf.WriteString(fmt.Sprintf("{ ?[function_id] <- [[%s]] :rm cie_function_code {function_id} }\n", qid))
+		buf.WriteString(fmt.Sprintf("{ ?[function_id, chunk] := *cie_function_code_chunk { function_id, chunk }, function_id = %s :rm cie_function_code_chunk {function_id, chunk} }\n", qid))
 		buf.WriteString(fmt.Sprintf("{ ?[function_id] <- [[%s]] :rm cie_function_embedding {function_id} }\n", qid))
+		buf.WriteString(fmt.Sprintf("{ ?[function_id] <- [[%s]] :rm cie_function_name_embedding {function_id} }\n", qid))
+		buf.WriteString(fmt.Sprintf("{ ?[function_id] <- [[%s]] :rm cie_function_lang {function_id} }\n", qid))
 	}
 
 	// Delete defines_type edges using primary key 'id'
@@ -585,10 +828,11 @@ func (db *DatalogBuilder) BuildDeletions(deletions DeletionSet) string {
 		buf.WriteString(fmt.Sprintf("{ ?[type_id] <- [[%s]] :rm cie_type_embedding {type_id} }\n", qid))
 	}
 
-	// Delete file entities
+	// Delete file entities (and their stored text, if any)
 	for _, id := range deletions.FileIDs {
-		stmt := fmt.Sprintf("{ ?[id] <- [[%s]] :rm cie_file {id} }\n", quoteString(id))
-		buf.WriteString(stmt)
+		qid := quoteString(id)
+		buf.WriteString(fmt.Sprintf("{ ?[id] <- [[%s]] :rm cie_file {id} }\n", qid))
+		buf.WriteString(fmt.Sprintf("{ ?[file_id] <- [[%s]] :rm cie_file_content {file_id} }\n", qid))
 	}
 
 	return buf.String()
@@ -704,3 +948,88 @@ func ComputeIncrementalStats(
 			len(files) + len(functions) + len(defines) + len(calls),
 	}
 }
+
+// BuildEntryPointMutations generates Datalog :put statements for entry
+// points.
+func (db *DatalogBuilder) BuildEntryPointMutations(points []EntryPoint) string {
+	var buf strings.Builder
+	for _, e := range points {
+		buf.WriteString("{ ?[id, function_id, file_path, kind, detail] <- [[")
+		buf.WriteString(strings.Join([]string{
+			quoteString(GenerateEntryPointID(e.FunctionID, e.Kind)),
+			quoteString(e.FunctionID),
+			quoteString(e.FilePath),
+			quoteString(e.Kind),
+			quoteString(e.Detail),
+		}, ", "))
+		buf.WriteString("]] :put cie_entry_point { id, function_id, file_path, kind, detail } }\n")
+	}
+	return buf.String()
+}
+
+// BuildTableRefMutations generates Datalog :put statements for table
+// references.
+func (db *DatalogBuilder) BuildTableRefMutations(refs []TableRef) string {
+	var buf strings.Builder
+	for _, r := range refs {
+		buf.WriteString("{ ?[id, function_id, file_path, table_name, model, op, source, line] <- [[")
+		buf.WriteString(strings.Join([]string{
+			quoteString(GenerateTableRefID(r.FunctionID, r.Table, r.Op, r.Source)),
+			quoteString(r.FunctionID),
+			quoteString(r.FilePath),
+			quoteString(r.Table),
+			quoteString(r.Model),
+			quoteString(r.Op),
+			quoteString(r.Source),
+			strconv.Itoa(r.Line),
+		}, ", "))
+		buf.WriteString("]] :put cie_table_ref { id, function_id, file_path, table_name, model, op, source, line } }\n")
+	}
+	return buf.String()
+}
+
+// BuildRPCLinkMutations generates Datalog :put statements for RPC links.
+func (db *DatalogBuilder) BuildRPCLinkMutations(links []RPCLink) string {
+	var buf strings.Builder
+	for _, l := range links {
+		buf.WriteString("{ ?[id, rpc, function_id, role, file_path, line] <- [[")
+		buf.WriteString(strings.Join([]string{
+			quoteString(GenerateRPCLinkID(l.FunctionID, l.RPC, l.Role)),
+			quoteString(l.RPC),
+			quoteString(l.FunctionID),
+			quoteString(l.Role),
+			quoteString(l.FilePath),
+			strconv.Itoa(l.Line),
+		}, ", "))
+		buf.WriteString("]] :put cie_rpc_link { id, rpc, function_id, role, file_path, line } }\n")
+	}
+	return buf.String()
+}
+
+// BuildGeneratedFileMutations generates Datalog :put statements for
+// generated file records and generated function links.
+func (db *DatalogBuilder) BuildGeneratedFileMutations(files []GeneratedFile, links []GeneratedFuncLink) string {
+	var buf strings.Builder
+	for _, f := range files {
+		buf.WriteString("{ ?[id, file_path, generator, source] <- [[")
+		buf.WriteString(strings.Join([]string{
+			quoteString(GenerateGeneratedFileID(f.FilePath)),
+			quoteString(f.FilePath),
+			quoteString(f.Generator),
+			quoteString(f.Source),
+		}, ", "))
+		buf.WriteString("]] :put cie_generated_file { id, file_path, generator, source } }\n")
+	}
+	for _, l := range links {
+		buf.WriteString("{ ?[id, function_id, source_id, source_kind, file_path] <- [[")
+		buf.WriteString(strings.Join([]string{
+			quoteString(GenerateGeneratedFuncID(l.FunctionID)),
+			quoteString(l.FunctionID),
+			quoteString(l.SourceID),
+			quoteString(l.SourceKind),
+			quoteString(l.FilePath),
+		}, ", "))
+		buf.WriteString("]] :put cie_generated_func { id, function_id, source_id, source_kind, file_path } }\n")
+	}
+	return buf.String()
+}
@@ -0,0 +1,135 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+func TestBuildMutations_CompressCodeText(t *testing.T) {
+	code := strings.Repeat("fmt.Println(\"hello\")\n", 50)
+	functions := []FunctionEntity{{ID: "func:1", Name: "Hello", FilePath: "main.go", CodeText: code, StartLine: 1, EndLine: 50}}
+
+	plain := NewDatalogBuilder().BuildMutations(nil, functions, nil, nil)
+	if strings.Contains(plain, storage.CodeTextZstdPrefix) {
+		t.Error("compression should be off by default")
+	}
+
+	builder := NewDatalogBuilder()
+	builder.SetCompressCodeText(true)
+	compressed := builder.BuildMutations(nil, functions, nil, nil)
+	if !strings.Contains(compressed, "'"+storage.CodeTextZstdPrefix) {
+		t.Fatalf("expected compressed code_text in mutations:\n%s", compressed)
+	}
+	if strings.Contains(compressed, "fmt.Println") {
+		t.Error("plain code text leaked into compressed mutations")
+	}
+	if len(compressed) >= len(plain) {
+		t.Errorf("compressed mutations (%d bytes) not smaller than plain (%d bytes)", len(compressed), len(plain))
+	}
+}
+
+func TestBuildMutations_FileContent(t *testing.T) {
+	files := []FileEntity{
+		{ID: "file:1", Path: "main.go", Content: "package main\n\nvar version = \"1.0\"\n"},
+		{ID: "file:2", Path: "util.go"},
+	}
+
+	mutations := NewDatalogBuilder().BuildMutations(files, nil, nil, nil)
+	if got := strings.Count(mutations, ":put cie_file_content"); got != 1 {
+		t.Fatalf("expected 1 cie_file_content put (files without content are skipped), got %d:\n%s", got, mutations)
+	}
+	if !strings.Contains(mutations, "var version") {
+		t.Error("file content missing from mutations")
+	}
+
+	builder := NewDatalogBuilder()
+	builder.SetCompressCodeText(true)
+	if compressed := builder.BuildMutations(files, nil, nil, nil); strings.Contains(compressed, "var version") {
+		t.Error("plain file content leaked into compressed mutations")
+	}
+}
+
+func TestBuildUnresolvedCallMutations(t *testing.T) {
+	script := NewDatalogBuilder().BuildUnresolvedCallMutations([]UnresolvedCall{
+		{CallerID: "func:1", CalleeName: "fmt.Println", FilePath: "main.go", Line: 12, Reason: UnresolvedReasonExternal},
+		{CallerID: "func:1", CalleeName: "fmt.Println", FilePath: "main.go", Line: 15, Reason: UnresolvedReasonExternal},
+	})
+	for _, want := range []string{
+		`'func:1', 'fmt.Println', 'main.go', 12, 'external'`,
+		":put cie_unresolved_call { id, caller_id, callee_name, file_path, line, reason }",
+		GenerateUnresolvedCallID("func:1", "fmt.Println", 12),
+		GenerateUnresolvedCallID("func:1", "fmt.Println", 15),
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+	if GenerateUnresolvedCallID("func:1", "fmt.Println", 12) == GenerateUnresolvedCallID("func:1", "fmt.Println", 15) {
+		t.Error("calls on different lines share an ID")
+	}
+}
+
+func TestBuildProtoMutations(t *testing.T) {
+	b := NewDatalogBuilder()
+	options := b.BuildProtoOptionMutations([]ProtoOption{
+		{FilePath: "api/users.proto", Scope: "file", Name: "go_package", Value: `"example.com/api"`, Line: 5},
+	})
+	generated := b.BuildGeneratedFromMutations([]GeneratedFromEdge{
+		{TypeID: "type:go", ProtoTypeID: "type:proto", FilePath: "api/users.pb.go"},
+	})
+	for script, wants := range map[string][]string{
+		options: {
+			`'api/users.proto', 'file', 'go_package'`,
+			":put cie_proto_option { id, file_path, scope, name, value, line }",
+		},
+		generated: {
+			`'type:go', 'type:proto', 'api/users.pb.go'`,
+			GenerateGeneratedFromID("type:go"),
+		},
+	} {
+		for _, want := range wants {
+			if !strings.Contains(script, want) {
+				t.Errorf("script missing %q:\n%s", want, script)
+			}
+		}
+	}
+}
+
+func TestBuildCIMutations(t *testing.T) {
+	b := NewDatalogBuilder()
+	script := b.BuildCIMutations(
+		[]CIJob{{ID: "ci:1", FilePath: ".github/workflows/ci.yml", Workflow: "CI", Name: "test", Triggers: "push", StartLine: 9}},
+		[]CIStep{{ID: "cis:1", JobID: "ci:1", FilePath: ".github/workflows/ci.yml", Index: 0, Kind: "run", Command: "echo 'it''s'\ngo test ./...", Line: 12}},
+		[]CIRef{{ID: "cir:1", JobID: "ci:1", FilePath: ".github/workflows/ci.yml", Kind: CIRefEnv, Name: "GOFLAGS", Line: 10}},
+	)
+	for _, want := range []string{
+		`'ci:1', '.github/workflows/ci.yml', 'CI', 'test', '', '', '', '', 'push', 9`,
+		`0, '', 'run', 'echo \'it\'\'s\'`,
+		":put cie_ci_step { id, job_id, file_path, idx, name, kind, command, line }",
+		`'cir:1', 'ci:1', '.github/workflows/ci.yml', 'env', 'GOFLAGS', 10`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+}
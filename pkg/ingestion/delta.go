@@ -28,6 +28,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"log/slog"
@@ -308,6 +309,47 @@ func (dd *DeltaDetector) GetHeadSHA() (string, error) {
 	return dd.resolveRef("HEAD")
 }
 
+// RecentlyChangedFiles lists files with uncommitted changes followed by the
+// files touched in the last maxCommits commits, most recent first and
+// without duplicates.
+func (dd *DeltaDetector) RecentlyChangedFiles(maxCommits int) ([]string, error) {
+	status := exec.Command("git", "status", "--porcelain")
+	status.Dir = dd.repoPath
+	dirty, err := status.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git status: %w", err)
+	}
+	logCmd := exec.Command("git", "log", "--format=", "--name-only", "-n", strconv.Itoa(maxCommits)) //nolint:gosec // G204: fixed arguments
+	logCmd.Dir = dd.repoPath
+	history, err := logCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+
+	var paths []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		path = unquoteGitPath(path)
+		if path != "" && !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	for _, line := range strings.Split(string(dirty), "\n") {
+		if len(line) > 3 {
+			path := line[3:]
+			if i := strings.Index(path, " -> "); i >= 0 {
+				path = path[i+4:]
+			}
+			add(path)
+		}
+	}
+	for _, line := range strings.Split(string(history), "\n") {
+		add(strings.TrimSpace(line))
+	}
+	return paths, nil
+}
+
 // IsGitRepository checks if the repo path is a valid git repository.
 func (dd *DeltaDetector) IsGitRepository() bool {
 	cmd := exec.Command("git", "rev-parse", "--git-dir")
@@ -325,7 +367,13 @@ func (dd *DeltaDetector) IsGitRepository() bool {
 // - maxFileSize: maximum file size in bytes (0 = no limit)
 // - repoPath: path to repository root (for checking file sizes)
 func FilterDelta(delta *GitDelta, excludeGlobs []string, maxFileSize int64, repoPath string) *GitDelta {
-	fc := &filterContext{excludeGlobs: excludeGlobs, maxFileSize: maxFileSize, repoPath: repoPath}
+	return FilterDeltaWithLimits(delta, excludeGlobs, maxFileSize, nil, repoPath)
+}
+
+// FilterDeltaWithLimits is FilterDelta with per-language size limits and
+// exclude globs (see IngestionConfig.LanguageLimits).
+func FilterDeltaWithLimits(delta *GitDelta, excludeGlobs []string, maxFileSize int64, languageLimits map[string]LanguageLimits, repoPath string) *GitDelta {
+	fc := &filterContext{excludeGlobs: excludeGlobs, maxFileSize: maxFileSize, languageLimits: languageLimits, repoPath: repoPath}
 	filtered := &GitDelta{
 		BaseSHA: delta.BaseSHA,
 		HeadSHA: delta.HeadSHA,
@@ -345,9 +393,10 @@ func FilterDelta(delta *GitDelta, excludeGlobs []string, maxFileSize int64, repo
 
 // filterContext holds filtering configuration for delta operations.
 type filterContext struct {
-	excludeGlobs []string
-	maxFileSize  int64
-	repoPath     string
+	excludeGlobs   []string
+	maxFileSize    int64
+	languageLimits map[string]LanguageLimits
+	repoPath       string
 }
 
 // shouldInclude checks if path matches exclude glob patterns.
@@ -358,7 +407,7 @@ func (fc *filterContext) shouldInclude(path string) bool {
 			return false
 		}
 	}
-	return true
+	return !excludedForLanguage(normalizedPath, detectLanguageFromPath(normalizedPath), fc.languageLimits)
 }
 
 // checkFileEligible validates basic constraints (exists, regular file, size, textual).
@@ -371,7 +420,7 @@ func (fc *filterContext) checkFileEligible(path string) bool {
 	if info.Mode()&os.ModeSymlink != 0 || info.IsDir() {
 		return false
 	}
-	if fc.maxFileSize > 0 && info.Size() > fc.maxFileSize {
+	if limit := maxFileSizeFor(detectLanguageFromPath(path), fc.maxFileSize, fc.languageLimits); limit > 0 && info.Size() > limit {
 		return false
 	}
 	return !isBinaryFile(fullPath)
@@ -390,7 +439,7 @@ func isBinaryFile(fullPath string) bool {
 	if n <= 0 {
 		return false
 	}
-	return bytes.IndexByte(buf[:n], 0x00) >= 0
+	return bytes.IndexByte(buf[:n], 0x00) >= 0 && !hasUTF16BOM(buf[:n])
 }
 
 // filterPaths filters a slice of paths using include/eligibility checks.
@@ -0,0 +1,114 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"regexp"
+	"strings"
+)
+
+// dialectRule recognizes a framework role of a function. Every set pattern
+// must match: sig against the signature, code against the code text, name
+// against the short name (the part after the last dot), and file against
+// the whole file, usually its imports.
+type dialectRule struct {
+	language string
+	dialect  string
+	sig      *regexp.Regexp
+	code     *regexp.Regexp
+	name     *regexp.Regexp
+	file     *regexp.Regexp
+}
+
+// Imports that tell frameworks with similar decorators apart.
+var (
+	importsFlask   = regexp.MustCompile(`(?m)^\s*(?:from\s+flask\b|import\s+flask\b)`)
+	importsFastAPI = regexp.MustCompile(`(?m)^\s*(?:from\s+fastapi\b|import\s+fastapi\b)`)
+	importsDjango  = regexp.MustCompile(`(?m)^\s*(?:from\s+django\b|import\s+django\b)`)
+	importsCelery  = regexp.MustCompile(`(?m)^\s*(?:from\s+celery\b|import\s+celery\b)`)
+	importsClick   = regexp.MustCompile(`(?m)^\s*(?:from\s+click\b|import\s+click\b)`)
+	importsTyper   = regexp.MustCompile(`(?m)^\s*(?:from\s+typer\b|import\s+typer\b)`)
+)
+
+// dialectRules are tried in order; the first match wins.
+var dialectRules = []dialectRule{
+	// Go
+	{language: "go", dialect: "go test", sig: regexp.MustCompile(`\*testing\.T\b`), name: regexp.MustCompile(`^Test`)},
+	{language: "go", dialect: "go benchmark", sig: regexp.MustCompile(`\*testing\.B\b`), name: regexp.MustCompile(`^Benchmark`)},
+	{language: "go", dialect: "go fuzz test", sig: regexp.MustCompile(`\*testing\.F\b`), name: regexp.MustCompile(`^Fuzz`)},
+	{language: "go", dialect: "gin handler", sig: regexp.MustCompile(`\*gin\.Context\b`)},
+	{language: "go", dialect: "echo handler", sig: regexp.MustCompile(`\becho\.Context\b`)},
+	{language: "go", dialect: "fiber handler", sig: regexp.MustCompile(`\*fiber\.Ctx\b`)},
+	{language: "go", dialect: "http handler", sig: regexp.MustCompile(`http\.ResponseWriter\b.*\*http\.Request\b`)},
+	{language: "go", dialect: "http middleware", sig: regexp.MustCompile(`\(\s*\w+\s+http\.Handler\s*\)\s*http\.Handler\b`)},
+	{language: "go", dialect: "cobra command", sig: regexp.MustCompile(`\*cobra\.Command\b.*\[\]string`)},
+
+	// Python
+	{language: "python", dialect: "pytest test", name: regexp.MustCompile(`^test_`), file: regexp.MustCompile(`(?m)^\s*(?:import pytest|from pytest\b|def test_)`)},
+	{language: "python", dialect: "flask route", code: regexp.MustCompile(`(?m)^\s*@\w+\.(?:route|get|post|put|patch|delete)\(`), file: importsFlask},
+	{language: "python", dialect: "fastapi route", code: regexp.MustCompile(`(?m)^\s*@\w+\.(?:get|post|put|patch|delete|api_route|websocket)\(`), file: importsFastAPI},
+	{language: "python", dialect: "django view", sig: regexp.MustCompile(`\(\s*(?:self\s*,\s*)?request\b`), file: importsDjango},
+	{language: "python", dialect: "celery task", code: regexp.MustCompile(`(?m)^\s*@(?:\w+\.)?(?:shared_task|task)\b`), file: importsCelery},
+	{language: "python", dialect: "click command", code: regexp.MustCompile(`(?m)^\s*@(?:\w+\.)?command\b`), file: importsClick},
+	{language: "python", dialect: "typer command", code: regexp.MustCompile(`(?m)^\s*@\w+\.command\b`), file: importsTyper},
+
+	// JavaScript and TypeScript
+	{language: "js", dialect: "react hook", name: regexp.MustCompile(`^use[A-Z]`), code: regexp.MustCompile(`\buse[A-Z]\w*\(`)},
+	{language: "js", dialect: "react component", name: regexp.MustCompile(`^[A-Z]`), code: regexp.MustCompile(`(?:return|=>)\s*\(?\s*<(?:[A-Za-z][\w.]*|>)`)},
+	{language: "js", dialect: "express handler", sig: regexp.MustCompile(`\(\s*req\b[^,()]*,\s*res\b`)},
+}
+
+// annotateFunctionLanguage sets the language of each function and the
+// dialect hint of the first matching rule. content is the whole file, used
+// for the rules that depend on imports.
+func annotateFunctionLanguage(functions []FunctionEntity, language, content string) {
+	family := language
+	if language == "javascript" || language == "typescript" {
+		family = "js"
+	}
+	fileMatches := make(map[*regexp.Regexp]bool)
+	matchFile := func(re *regexp.Regexp) bool {
+		m, ok := fileMatches[re]
+		if !ok {
+			m = re.MatchString(content)
+			fileMatches[re] = m
+		}
+		return m
+	}
+
+	for i := range functions {
+		fn := &functions[i]
+		fn.Language = language
+		short := fn.Name
+		if j := strings.LastIndex(short, "."); j >= 0 {
+			short = short[j+1:]
+		}
+		for _, r := range dialectRules {
+			if r.language != family ||
+				(r.sig != nil && !r.sig.MatchString(fn.Signature)) ||
+				(r.name != nil && !r.name.MatchString(short)) ||
+				(r.code != nil && !r.code.MatchString(fn.CodeText)) ||
+				(r.file != nil && !matchFile(r.file)) {
+				continue
+			}
+			fn.Dialect = r.dialect
+			break
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// dialects maps function names to the dialect detected for them.
+func dialects(functions []FunctionEntity, language, content string) map[string]string {
+	annotateFunctionLanguage(functions, language, content)
+	out := make(map[string]string, len(functions))
+	for _, fn := range functions {
+		out[fn.Name] = fn.Dialect
+	}
+	return out
+}
+
+func TestAnnotateFunctionLanguage_Go(t *testing.T) {
+	functions := []FunctionEntity{
+		{Name: "getUser", Signature: "func getUser(c *gin.Context)"},
+		{Name: "Server.list", Signature: "func (s *Server) list(c echo.Context) error"},
+		{Name: "health", Signature: "func health(w http.ResponseWriter, r *http.Request)"},
+		{Name: "logging", Signature: "func logging(next http.Handler) http.Handler"},
+		{Name: "runServe", Signature: "func runServe(cmd *cobra.Command, args []string) error"},
+		{Name: "TestGetUser", Signature: "func TestGetUser(t *testing.T)"},
+		{Name: "BenchmarkParse", Signature: "func BenchmarkParse(b *testing.B)"},
+		{Name: "helper", Signature: "func helper(t *testing.T)"},
+	}
+
+	got := dialects(functions, "go", "package api")
+	assert.Equal(t, "gin handler", got["getUser"])
+	assert.Equal(t, "echo handler", got["Server.list"])
+	assert.Equal(t, "http handler", got["health"])
+	assert.Equal(t, "http middleware", got["logging"])
+	assert.Equal(t, "cobra command", got["runServe"])
+	assert.Equal(t, "go test", got["TestGetUser"])
+	assert.Equal(t, "go benchmark", got["BenchmarkParse"])
+	assert.Empty(t, got["helper"])
+	for _, fn := range functions {
+		assert.Equal(t, "go", fn.Language, fn.Name)
+	}
+}
+
+func TestAnnotateFunctionLanguage_Python(t *testing.T) {
+	flask := []FunctionEntity{
+		{Name: "index", Signature: "def index()", CodeText: "@app.route(\"/\")\ndef index():\n    return 'ok'"},
+		{Name: "helper", Signature: "def helper()", CodeText: "def helper():\n    pass"},
+	}
+	got := dialects(flask, "python", "from flask import Flask\napp = Flask(__name__)\n")
+	assert.Equal(t, "flask route", got["index"])
+	assert.Empty(t, got["helper"])
+
+	fastapi := []FunctionEntity{
+		{Name: "read_user", Signature: "async def read_user(user_id: int)", CodeText: "@router.get(\"/users/{user_id}\")\nasync def read_user(user_id: int):\n    ..."},
+	}
+	got = dialects(fastapi, "python", "from fastapi import APIRouter\n")
+	assert.Equal(t, "fastapi route", got["read_user"])
+
+	tests := []FunctionEntity{{Name: "test_parse", Signature: "def test_parse()"}}
+	got = dialects(tests, "python", "import pytest\n")
+	assert.Equal(t, "pytest test", got["test_parse"])
+}
+
+func TestAnnotateFunctionLanguage_JavaScript(t *testing.T) {
+	functions := []FunctionEntity{
+		{Name: "UserCard", Signature: "function UserCard({ user })", CodeText: "function UserCard({ user }) {\n  return (\n    <div>{user.name}</div>\n  );\n}"},
+		{Name: "useUser", Signature: "function useUser(id)", CodeText: "function useUser(id) {\n  const [u, setU] = useState(null);\n  return u;\n}"},
+		{Name: "getUsers", Signature: "async function getUsers(req, res)", CodeText: "async function getUsers(req, res) {\n  res.json([]);\n}"},
+		{Name: "formatName", Signature: "function formatName(user)", CodeText: "function formatName(user) {\n  return user.name;\n}"},
+	}
+
+	got := dialects(functions, "typescript", "")
+	assert.Equal(t, "react component", got["UserCard"])
+	assert.Equal(t, "react hook", got["useUser"])
+	assert.Equal(t, "express handler", got["getUsers"])
+	assert.Empty(t, got["formatName"])
+	assert.Equal(t, "typescript", functions[0].Language)
+}
@@ -43,6 +43,7 @@
 //   - Python (.py)
 //   - TypeScript (.ts, .tsx)
 //   - JavaScript (.js, .jsx)
+//   - C# (.cs)
 //
 // Additionally, Protocol Buffers (.proto) are supported via regex parsing.
 //
@@ -101,6 +102,7 @@
 //
 //	resolver := ingestion.NewCallResolver()
 //	resolver.BuildIndex(files, functions, imports, packageNames)
+//	resolver.SetGoModules(loadResult.GoModules) // from go.mod / go.work
 //	resolvedCalls := resolver.ResolveCalls(unresolvedCalls)
 //
 // CallResolver maps function calls across package boundaries, enabling
@@ -139,6 +141,10 @@
 //	            ".git/**",
 //	            "vendor/**",
 //	        },
+//	        LanguageLimits: map[string]ingestion.LanguageLimits{
+//	            "go":         {MaxFileSizeBytes: 5 * 1024 * 1024},
+//	            "javascript": {MaxCodeTextBytes: 20 * 1024, ExcludeGlobs: []string{"**/*.min.js"}},
+//	        },
 //	        Concurrency: struct {
 //	            ParseWorkers int
 //	            EmbedWorkers int
@@ -0,0 +1,251 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+const (
+	// DefaultDriftSample is the number of functions re-embedded by a
+	// drift check.
+	DefaultDriftSample = 20
+
+	// DefaultDriftThreshold is the cosine similarity below which a
+	// re-embedded function counts as drifted. The same model and text
+	// reproduce a vector to within rounding, far above this.
+	DefaultDriftThreshold = 0.98
+
+	// driftMaxChars matches the truncation applied when functions are
+	// embedded at index time, so the same text is re-embedded.
+	driftMaxChars = 2000
+)
+
+// Drift verdicts.
+const (
+	DriftOK                = "ok"
+	DriftDetected          = "drift"
+	DriftDimensionMismatch = "dimension_mismatch"
+	DriftNoEmbeddings      = "no_embeddings"
+)
+
+// DriftOptions configures CheckEmbeddingDrift.
+type DriftOptions struct {
+	// Sample is the number of functions to re-embed (DefaultDriftSample
+	// when zero).
+	Sample int
+
+	// Threshold is the per-function similarity below which a function
+	// counts as drifted (DefaultDriftThreshold when zero).
+	Threshold float64
+
+	// Seed selects the sample; the same seed re-checks the same functions.
+	Seed int64
+}
+
+// DriftSample is one re-embedded function.
+type DriftSample struct {
+	FunctionID string  `json:"function_id"`
+	Similarity float64 `json:"similarity"`
+	StoredDim  int     `json:"stored_dim"`
+	CurrentDim int     `json:"current_dim"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// DriftReport compares stored function embeddings against fresh ones from
+// the current provider.
+type DriftReport struct {
+	Verdict        string        `json:"verdict"`
+	Threshold      float64       `json:"threshold"`
+	Checked        int           `json:"checked"`
+	Drifted        int           `json:"drifted"`
+	DimMismatches  int           `json:"dimension_mismatches"`
+	Errors         int           `json:"errors"`
+	MeanSimilarity float64       `json:"mean_similarity"`
+	MinSimilarity  float64       `json:"min_similarity"`
+	Samples        []DriftSample `json:"samples"`
+}
+
+// CheckEmbeddingDrift re-embeds a random sample of indexed functions with
+// provider and compares the result with the stored vectors. A changed model,
+// dimension or document prefix silently degrades semantic search, because
+// queries are embedded by the current provider and searched against vectors
+// written by an older one; the comparison catches it without a full re-index.
+//
+// Samples are ordered by similarity, lowest first. The provider should not be
+// wrapped by the shared embedding cache, which would return the stored vector
+// for unchanged text and hide any drift.
+func CheckEmbeddingDrift(ctx context.Context, backend *storage.EmbeddedBackend, provider EmbeddingProvider, opts DriftOptions) (*DriftReport, error) {
+	if opts.Sample <= 0 {
+		opts.Sample = DefaultDriftSample
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultDriftThreshold
+	}
+	report := &DriftReport{Threshold: opts.Threshold, Samples: []DriftSample{}}
+
+	res, err := backend.Query(ctx, "?[function_id] := *cie_function_embedding { function_id }")
+	if err != nil {
+		return nil, fmt.Errorf("list function embeddings: %w", err)
+	}
+	ids := make([]string, 0, len(res.Rows))
+	for _, row := range res.Rows {
+		if id, ok := row[0].(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		report.Verdict = DriftNoEmbeddings
+		return report, nil
+	}
+	ids = sampleIDs(ids, opts.Sample, opts.Seed)
+
+	stored, texts, err := loadDriftSample(ctx, backend, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var sum float64
+	report.MinSimilarity = 1
+	for _, id := range ids {
+		text, ok := texts[id]
+		vec := stored[id]
+		if !ok || len(vec) == 0 {
+			continue // no code text to re-embed
+		}
+		if len(text) > driftMaxChars {
+			text = text[:driftMaxChars]
+		}
+		s := DriftSample{FunctionID: id, StoredDim: len(vec)}
+		current, err := provider.Embed(ctx, text)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			s.Error = err.Error()
+			report.Errors++
+			report.Samples = append(report.Samples, s)
+			continue
+		}
+		s.CurrentDim = len(current)
+		report.Checked++
+		if s.CurrentDim != s.StoredDim {
+			report.DimMismatches++
+		} else {
+			s.Similarity = cosineSimilarity(vec, current)
+		}
+		if s.Similarity < opts.Threshold {
+			report.Drifted++
+		}
+		sum += s.Similarity
+		report.MinSimilarity = math.Min(report.MinSimilarity, s.Similarity)
+		report.Samples = append(report.Samples, s)
+	}
+	sort.SliceStable(report.Samples, func(i, j int) bool {
+		return report.Samples[i].Similarity < report.Samples[j].Similarity
+	})
+
+	if report.Checked == 0 {
+		report.MinSimilarity = 0
+		if report.Errors > 0 {
+			return report, fmt.Errorf("embedding provider failed for all %d sampled functions: %s", report.Errors, report.Samples[0].Error)
+		}
+		report.Verdict = DriftNoEmbeddings
+		return report, nil
+	}
+	report.MeanSimilarity = sum / float64(report.Checked)
+	switch {
+	case report.DimMismatches > 0:
+		report.Verdict = DriftDimensionMismatch
+	case report.Drifted > 0:
+		report.Verdict = DriftDetected
+	default:
+		report.Verdict = DriftOK
+	}
+	return report, nil
+}
+
+// sampleIDs returns n of ids chosen by seed, or all of them if there are
+// no more than n.
+func sampleIDs(ids []string, n int, seed int64) []string {
+	sort.Strings(ids) // query order is not stable across runs
+	if len(ids) <= n {
+		return ids
+	}
+	rng := rand.New(rand.NewSource(seed)) //nolint:gosec // G404: sampling, not security
+	rng.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+	return ids[:n]
+}
+
+// loadDriftSample loads the stored embedding and code text of ids.
+func loadDriftSample(ctx context.Context, backend *storage.EmbeddedBackend, ids []string) (map[string][]float64, map[string]string, error) {
+	quoted := make([]string, len(ids))
+	for i, id := range ids {
+		quoted[i] = quoteString(id)
+	}
+	script := fmt.Sprintf(`?[id, embedding, code_text] := *cie_function_embedding { function_id: id, embedding },
+		*cie_function_code { function_id: id, code_text }, is_in(id, [%s])`, strings.Join(quoted, ", "))
+	res, err := backend.Query(ctx, script)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load sampled functions: %w", err)
+	}
+	vectors := make(map[string][]float64, len(res.Rows))
+	texts := make(map[string]string, len(res.Rows))
+	for _, row := range res.Rows {
+		id, _ := row[0].(string)
+		values, _ := row[1].([]any)
+		vec := make([]float64, 0, len(values))
+		for _, v := range values {
+			if f, ok := v.(float64); ok {
+				vec = append(vec, f)
+			}
+		}
+		text, _ := row[2].(string)
+		decoded, err := storage.DecompressCodeText(text)
+		if err != nil {
+			continue
+		}
+		vectors[id] = vec
+		texts[id] = decoded
+	}
+	return vectors, texts, nil
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors of the same
+// length, or 0 if either is all zeros.
+func cosineSimilarity(a []float64, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		x, y := a[i], float64(b[i])
+		dot += x * y
+		na += x * x
+		nb += y * y
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+//go:build cgo
+
+package ingestion
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// newDriftTestBackend indexes two functions whose stored embeddings are the
+// mock provider's own vectors for their code, as if embedded by it.
+func newDriftTestBackend(t *testing.T, provider *MockEmbeddingProvider) *storage.EmbeddedBackend {
+	t.Helper()
+	b, err := storage.NewEmbeddedBackend(storage.EmbeddedConfig{DataDir: t.TempDir(), Engine: "mem", EmbeddingDimensions: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = b.Close() })
+	if err := b.EnsureSchema(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, fn := range []struct{ id, code string }{{"fn:a", "func A() {}"}, {"fn:b", "func B() {}"}} {
+		vec, err := provider.Embed(ctx, fn.code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Put(ctx, "cie_function_code", storage.Row{"function_id": fn.id, "code_text": fn.code}); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Put(ctx, "cie_function_embedding", storage.Row{"function_id": fn.id, "embedding": vec}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return b
+}
+
+func TestCheckEmbeddingDrift(t *testing.T) {
+	ctx := context.Background()
+	provider := NewMockEmbeddingProvider(4, nil)
+	backend := newDriftTestBackend(t, provider)
+
+	report, err := CheckEmbeddingDrift(ctx, backend, provider, DriftOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Verdict != DriftOK || report.Checked != 2 || report.MinSimilarity < 0.999 {
+		t.Errorf("same provider: %+v", report)
+	}
+
+	// A different model returns different vectors for B.
+	provider.SetBehavior(MockEmbeddingBehavior{Responses: []MockEmbeddingResponse{
+		{Pattern: regexp.MustCompile(`func B`), Vector: []float32{-1, 0, 0, 0}},
+	}})
+	report, err = CheckEmbeddingDrift(ctx, backend, provider, DriftOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Verdict != DriftDetected || report.Drifted != 1 || report.Samples[0].FunctionID != "fn:b" {
+		t.Errorf("changed model: %+v", report)
+	}
+
+	// A different dimension cannot be compared at all.
+	report, err = CheckEmbeddingDrift(ctx, backend, NewMockEmbeddingProvider(8, nil), DriftOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Verdict != DriftDimensionMismatch || report.DimMismatches != 2 {
+		t.Errorf("changed dimension: %+v", report)
+	}
+}
+
+func TestSampleIDs(t *testing.T) {
+	ids := []string{"d", "b", "a", "c"}
+	if got := sampleIDs(append([]string{}, ids...), 10, 1); !reflect.DeepEqual(got, []string{"a", "b", "c", "d"}) {
+		t.Errorf("small set = %v, want all ids sorted", got)
+	}
+	first := sampleIDs(append([]string{}, ids...), 2, 7)
+	again := sampleIDs([]string{"c", "a", "d", "b"}, 2, 7)
+	if len(first) != 2 || !reflect.DeepEqual(first, again) {
+		t.Errorf("same seed picked %v and %v", first, again)
+	}
+}
@@ -23,17 +23,25 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
+	"math/rand"
 	"net/http"
 	"os"
+	"regexp"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"log/slog"
+
+	"github.com/kraklabs/cie/pkg/embedprefix"
+	"github.com/kraklabs/cie/pkg/errcode"
+	"github.com/kraklabs/cie/pkg/storage"
+	"github.com/kraklabs/cie/pkg/tools"
 )
 
 // EmbeddingProvider generates embeddings for code text.
@@ -43,12 +51,73 @@ type EmbeddingProvider interface {
 	Embed(ctx context.Context, text string) ([]float32, error)
 }
 
+// DocumentPrefixer is implemented by providers that put a prefix in front of
+// every document they embed. The prefix must match the query prefix used at
+// search time (see embedprefix.Prefixes).
+type DocumentPrefixer interface {
+	DocumentPrefix() string
+	SetDocumentPrefix(prefix string)
+}
+
+// documentPrefix is embedded by the HTTP providers to implement
+// DocumentPrefixer.
+type documentPrefix struct {
+	prefix string
+}
+
+// DocumentPrefix returns the prefix put in front of every embedded document.
+func (d *documentPrefix) DocumentPrefix() string { return d.prefix }
+
+// SetDocumentPrefix replaces the provider's default document prefix.
+func (d *documentPrefix) SetDocumentPrefix(prefix string) { d.prefix = prefix }
+
 // MockEmbeddingProvider generates deterministic mock embeddings for testing.
+// SetBehavior scripts latency, failures and canned vectors so retry and
+// fallback paths can be exercised without a real provider.
 type MockEmbeddingProvider struct {
 	dimension int
 	logger    *slog.Logger
+
+	mu       sync.Mutex
+	behavior MockEmbeddingBehavior
+	rng      *rand.Rand
+	calls    int
 }
 
+// MockEmbeddingBehavior scripts how a MockEmbeddingProvider responds.
+// The zero value is the plain deterministic mock.
+type MockEmbeddingBehavior struct {
+	// Latency is added to every call. Jitter adds up to that much more,
+	// drawn from the seeded generator.
+	Latency time.Duration
+	Jitter  time.Duration
+
+	// FailFirst makes the first N calls fail, then calls succeed.
+	FailFirst int
+	// FailureRate makes that fraction (0-1) of the remaining calls fail.
+	FailureRate float64
+	// Err is returned by failing calls. Defaults to a retryable 503 error.
+	Err error
+
+	// Responses are checked in order against the text; the first match
+	// returns its Vector or Err instead of the hashed embedding.
+	Responses []MockEmbeddingResponse
+
+	// Seed makes jitter and FailureRate reproducible.
+	Seed int64
+}
+
+// MockEmbeddingResponse is a canned reply for texts matching Pattern.
+type MockEmbeddingResponse struct {
+	Pattern *regexp.Regexp
+	Vector  []float32
+	Err     error
+}
+
+// errMockEmbeddingUnavailable is the default scripted failure. Its text is
+// classified as retryable by isRetryableEmbeddingError.
+var errMockEmbeddingUnavailable = errors.New("mock embedding API error: 503 Service Unavailable")
+
 // NewMockEmbeddingProvider creates a mock embedding provider.
 func NewMockEmbeddingProvider(dimension int, logger *slog.Logger) *MockEmbeddingProvider {
 	if logger == nil {
@@ -60,8 +129,41 @@ func NewMockEmbeddingProvider(dimension int, logger *slog.Logger) *MockEmbedding
 	}
 }
 
-// Embed generates a deterministic mock embedding based on text hash.
+// SetBehavior replaces the provider's scripted behavior and resets its call
+// counter.
+func (m *MockEmbeddingProvider) SetBehavior(b MockEmbeddingBehavior) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.behavior = b
+	m.rng = rand.New(rand.NewSource(b.Seed)) //nolint:gosec // deterministic test randomness
+	m.calls = 0
+}
+
+// Calls returns how many times Embed has been called since the last
+// SetBehavior.
+func (m *MockEmbeddingProvider) Calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
+
+// Embed generates a deterministic mock embedding based on text hash, after
+// applying any scripted latency, failures or canned responses.
 func (m *MockEmbeddingProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	delay, canned, handled, err := m.script(text)
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if handled {
+		return canned, nil
+	}
 	// Generate deterministic embedding from text hash
 	// This is just for testing - not semantically meaningful
 	hash := hashString(text)
@@ -88,6 +190,40 @@ func (m *MockEmbeddingProvider) Embed(ctx context.Context, text string) ([]float
 	return embedding, nil
 }
 
+// script decides the outcome of one call from the configured behavior.
+func (m *MockEmbeddingProvider) script(text string) (delay time.Duration, vec []float32, handled bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	b := m.behavior
+	if m.rng == nil {
+		m.rng = rand.New(rand.NewSource(b.Seed)) //nolint:gosec // deterministic test randomness
+	}
+
+	delay = b.Latency
+	if b.Jitter > 0 {
+		delay += time.Duration(m.rng.Int63n(int64(b.Jitter)))
+	}
+
+	failErr := b.Err
+	if failErr == nil {
+		failErr = errMockEmbeddingUnavailable
+	}
+	if m.calls <= b.FailFirst {
+		return delay, nil, false, failErr
+	}
+	if b.FailureRate > 0 && m.rng.Float64() < b.FailureRate {
+		return delay, nil, false, failErr
+	}
+
+	for _, r := range b.Responses {
+		if r.Pattern != nil && r.Pattern.MatchString(text) {
+			return delay, r.Vector, true, r.Err
+		}
+	}
+	return delay, nil, false, nil
+}
+
 func hashString(s string) uint64 {
 	var hash uint64 = 5381
 	for _, c := range s {
@@ -103,6 +239,7 @@ type EmbeddingGenerator struct {
 	logger     *slog.Logger
 	retry      RetryConfig
 	onProgress ProgressCallback // Optional callback for progress reporting
+	names      bool             // Also embed each function's name and signature
 }
 
 // NewEmbeddingGenerator creates a new embedding generator.
@@ -131,6 +268,13 @@ func (eg *EmbeddingGenerator) reportProgress(current, total int64, phase string)
 	}
 }
 
+// SetNameEmbeddings makes EmbedFunctions also embed each function's name and
+// signature on their own, into FunctionEntity.NameEmbedding. It doubles the
+// provider calls for functions.
+func (eg *EmbeddingGenerator) SetNameEmbeddings(enabled bool) {
+	eg.names = enabled
+}
+
 // SetRetryConfig sets the retry configuration for embedding operations.
 func (eg *EmbeddingGenerator) SetRetryConfig(cfg RetryConfig) {
 	// Basic sanity defaults to avoid zero values causing busy loops
@@ -210,6 +354,7 @@ func (eg *EmbeddingGenerator) embedFunctionsSequential(ctx context.Context, func
 		}
 
 		fn.Embedding = embedding
+		fn.NameEmbedding = eg.embedName(ctx, fn)
 		results[i] = fn
 		// Report progress after each embedding
 		eg.reportProgress(int64(i+1), totalFunctions, "embedding")
@@ -303,6 +448,7 @@ func (eg *EmbeddingGenerator) embeddingWorker(
 			atomic.AddInt32(truncatedCount, 1)
 		}
 		fn.Embedding = embedding
+		fn.NameEmbedding = eg.embedName(ctx, fn)
 		results <- embeddingJobResult{i, fn, err != nil, wasTruncated}
 		// Report progress after each embedding
 		current := atomic.AddInt64(progressCount, 1)
@@ -532,6 +678,8 @@ func (eg *EmbeddingGenerator) embedType(ctx context.Context, t TypeEntity) ([]fl
 			"error", err,
 		)
 		embedding = []float32{}
+	} else {
+		embedding, _ = storage.NormalizeVector(embedding)
 	}
 
 	return embedding, wasTruncated, err
@@ -552,6 +700,29 @@ func (eg *EmbeddingGenerator) embedFunction(ctx context.Context, fn FunctionEnti
 	}
 
 	// Generate embedding with classified retry + jittered backoff
+	embedding, err := eg.embedWithRetry(ctx, fn.ID, text)
+	if err != nil && ctx.Err() != nil {
+		return nil, wasTruncated, ctx.Err()
+	}
+	if err != nil {
+		// Log the specific function that failed for debugging
+		eg.logger.Error("embedding.function.failed",
+			"function_id", fn.ID,
+			"function_name", fn.Name,
+			"code_text_len", len(fn.CodeText),
+			"error", err,
+		)
+		// Graceful failure: use empty embedding and continue
+		embedding = []float32{} // Empty embedding as placeholder
+	}
+
+	return embedding, wasTruncated, err
+}
+
+// embedWithRetry embeds text, retrying retryable provider errors with
+// jittered exponential backoff, and returns the vector at unit length so
+// every index metric ranks it alike. id identifies the entity in logs.
+func (eg *EmbeddingGenerator) embedWithRetry(ctx context.Context, id, text string) ([]float32, error) {
 	var embedding []float32
 	var err error
 	maxRetries := eg.retry.MaxRetries
@@ -570,27 +741,44 @@ func (eg *EmbeddingGenerator) embedFunction(ctx context.Context, fn FunctionEnti
 		// Exponential backoff with full jitter
 		sleep := computeBackoffWithJitter(base, attempt, mult, maxBackoff)
 		recordEmbedRetry()
-		eg.logger.Warn("embedding.retry", "function_id", fn.ID, "attempt", attempt+1, "sleep_ms", sleep.Milliseconds(), "err", err)
+		eg.logger.Warn("embedding.retry", "function_id", id, "attempt", attempt+1, "sleep_ms", sleep.Milliseconds(), "err", err)
 		select {
 		case <-ctx.Done():
-			return nil, wasTruncated, ctx.Err()
+			return nil, ctx.Err()
 		case <-time.After(sleep):
 		}
 	}
+	if err != nil {
+		return embedding, err
+	}
+	embedding, _ = storage.NormalizeVector(embedding)
+	return embedding, nil
+}
 
+// embedName embeds the name and signature of fn when name embeddings are
+// on. A failure is logged and leaves the function without a name vector;
+// semantic search then ranks it by its body alone.
+func (eg *EmbeddingGenerator) embedName(ctx context.Context, fn FunctionEntity) []float32 {
+	if !eg.names || ctx.Err() != nil {
+		return nil
+	}
+	embedding, err := eg.embedWithRetry(ctx, fn.ID, nameEmbeddingText(fn))
 	if err != nil {
-		// Log the specific function that failed for debugging
-		eg.logger.Error("embedding.function.failed",
-			"function_id", fn.ID,
-			"function_name", fn.Name,
-			"code_text_len", len(fn.CodeText),
-			"error", err,
-		)
-		// Graceful failure: use empty embedding and continue
-		embedding = []float32{} // Empty embedding as placeholder
+		eg.logger.Warn("embedding.function_name.failed", "function_id", fn.ID, "function_name", fn.Name, "error", err)
+		return nil
 	}
+	return embedding
+}
 
-	return embedding, wasTruncated, err
+// nameEmbeddingText is what a function's name vector is built from: the
+// words of its name, which embedding models read better than camelCase,
+// followed by the signature.
+func nameEmbeddingText(fn FunctionEntity) string {
+	text := strings.Join(tools.SplitIdentifier(fn.Name), " ")
+	if fn.Signature != "" {
+		text += "\n" + fn.Signature
+	}
+	return text
 }
 
 // isRetryableEmbeddingError classifies provider errors: network/timeout and HTTP 5xx/429 are retryable.
@@ -746,6 +934,7 @@ type NomicEmbeddingProvider struct {
 	model      string
 	httpClient *http.Client
 	logger     *slog.Logger
+	documentPrefix
 }
 
 // NomicEmbedRequest represents the request body for Nomic embeddings API.
@@ -789,7 +978,7 @@ func NewNomicEmbeddingProvider(apiKey, baseURL, model string, logger *slog.Logge
 func (n *NomicEmbeddingProvider) Embed(ctx context.Context, text string) ([]float32, error) {
 	// Build request
 	reqBody := NomicEmbedRequest{
-		Texts:    []string{text},
+		Texts:    []string{n.prefix + text},
 		Model:    n.model,
 		TaskType: "search_document", // Optimized for retrieval
 	}
@@ -812,7 +1001,7 @@ func (n *NomicEmbeddingProvider) Embed(ctx context.Context, text string) ([]floa
 	// Execute request
 	resp, err := n.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("http request: %w", err)
+		return nil, fmt.Errorf("http request: %w: %w", errcode.ErrProviderUnreachable, err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
@@ -865,6 +1054,7 @@ type OllamaEmbeddingProvider struct {
 	model      string
 	httpClient *http.Client
 	logger     *slog.Logger
+	documentPrefix
 }
 
 // OllamaEmbedRequest represents the request body for Ollama embeddings API.
@@ -883,12 +1073,6 @@ type OllamaErrorResponse struct {
 	Error string `json:"error"`
 }
 
-// isNomicModel checks if the model is a Nomic embedding model that supports
-// asymmetric search prefixes (search_document/search_query).
-func isNomicModel(model string) bool {
-	return strings.Contains(strings.ToLower(model), "nomic")
-}
-
 // NewOllamaEmbeddingProvider creates a new Ollama embedding provider.
 func NewOllamaEmbeddingProvider(baseURL, model string, logger *slog.Logger) *OllamaEmbeddingProvider {
 	if logger == nil {
@@ -897,6 +1081,9 @@ func NewOllamaEmbeddingProvider(baseURL, model string, logger *slog.Logger) *Oll
 	return &OllamaEmbeddingProvider{
 		baseURL: baseURL,
 		model:   model,
+		documentPrefix: documentPrefix{
+			prefix: embedprefix.Defaults("ollama", model).Document,
+		},
 		httpClient: &http.Client{
 			Timeout: 120 * time.Second, // Local models may be slower
 		},
@@ -906,19 +1093,13 @@ func NewOllamaEmbeddingProvider(baseURL, model string, logger *slog.Logger) *Oll
 
 // Embed generates an embedding for the given text using local Ollama.
 func (o *OllamaEmbeddingProvider) Embed(ctx context.Context, text string) ([]float32, error) {
-	// For nomic-embed-text and similar models, add "search_document:" prefix
-	// to enable asymmetric embeddings. This significantly improves retrieval
-	// quality when queries use "search_query:" prefix.
+	// For nomic-embed-text and similar models the default prefix is
+	// "search_document: ", which enables asymmetric embeddings: retrieval
+	// improves significantly when queries use "search_query: ".
 	// See: https://huggingface.co/nomic-ai/nomic-embed-text-v1.5
-	prompt := text
-	if isNomicModel(o.model) {
-		prompt = "search_document: " + text
-	}
-
-	// Build request
 	reqBody := OllamaEmbedRequest{
 		Model:  o.model,
-		Prompt: prompt,
+		Prompt: o.prefix + text,
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
@@ -938,7 +1119,7 @@ func (o *OllamaEmbeddingProvider) Embed(ctx context.Context, text string) ([]flo
 	// Execute request
 	resp, err := o.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("http request (is Ollama running at %s?): %w", o.baseURL, err)
+		return nil, fmt.Errorf("http request (is Ollama running at %s?): %w: %w", o.baseURL, errcode.ErrProviderUnreachable, err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
@@ -991,6 +1172,7 @@ type OpenAIEmbeddingProvider struct {
 	model      string
 	httpClient *http.Client
 	logger     *slog.Logger
+	documentPrefix
 }
 
 // OpenAIEmbedRequest represents the request body for OpenAI embeddings API.
@@ -1044,12 +1226,13 @@ func NewOpenAIEmbeddingProvider(apiKey, baseURL, model string, logger *slog.Logg
 // For Qodo-Embed models (based on gte-Qwen2), documents are embedded as-is without prefix.
 // Asymmetric search is handled by adding "Instruct:\nQuery:" format to queries during search.
 func (o *OpenAIEmbeddingProvider) Embed(ctx context.Context, text string) ([]float32, error) {
-	// Documents (code) are embedded as-is without prefix for Qodo-Embed models
-	// The asymmetric search instruction is added only to queries during search time
+	// Documents (code) are embedded as-is unless a document prefix is
+	// configured. The asymmetric search instruction is added only to
+	// queries during search time
 
 	// Build request
 	reqBody := OpenAIEmbedRequest{
-		Input:          text,
+		Input:          o.prefix + text,
 		Model:          o.model,
 		EncodingFormat: "float",
 	}
@@ -1072,7 +1255,7 @@ func (o *OpenAIEmbeddingProvider) Embed(ctx context.Context, text string) ([]flo
 	// Execute request
 	resp, err := o.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("http request: %w", err)
+		return nil, fmt.Errorf("http request: %w: %w", errcode.ErrProviderUnreachable, err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
@@ -1124,6 +1307,7 @@ type LlamaCppEmbeddingProvider struct {
 	baseURL    string
 	httpClient *http.Client
 	logger     *slog.Logger
+	documentPrefix
 }
 
 // LlamaCppEmbedRequest represents the request body for llama.cpp embeddings API.
@@ -1160,7 +1344,7 @@ func (l *LlamaCppEmbeddingProvider) Embed(ctx context.Context, text string) ([]f
 
 	// Build request
 	reqBody := LlamaCppEmbedRequest{
-		Content: text,
+		Content: l.prefix + text,
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
@@ -1180,7 +1364,7 @@ func (l *LlamaCppEmbeddingProvider) Embed(ctx context.Context, text string) ([]f
 	// Execute request
 	resp, err := l.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("http request (is llama-server running at %s?): %w", l.baseURL, err)
+		return nil, fmt.Errorf("http request (is llama-server running at %s?): %w: %w", l.baseURL, errcode.ErrProviderUnreachable, err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
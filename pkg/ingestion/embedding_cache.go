@@ -0,0 +1,87 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// embeddingStore is the part of storage.EmbeddingCache the provider wrapper
+// needs.
+type embeddingStore interface {
+	Get(key string) ([]float32, bool, error)
+	Put(key string, vec []float32) error
+}
+
+// cachedEmbeddingProvider answers from a content-addressed embedding store
+// and only calls the wrapped provider on a miss. Store errors are logged
+// and treated as misses: the cache must never fail an index run.
+type cachedEmbeddingProvider struct {
+	EmbeddingProvider
+	store  embeddingStore
+	model  string
+	logger *slog.Logger
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+func (cp *cachedEmbeddingProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	key := storage.EmbeddingCacheKey(cp.model, text)
+	vec, ok, err := cp.store.Get(key)
+	if err != nil {
+		cp.logger.Warn("embedding.cache.read.error", "err", err)
+	}
+	if ok {
+		cp.hits.Add(1)
+		return vec, nil
+	}
+	cp.misses.Add(1)
+
+	vec, err = cp.EmbeddingProvider.Embed(ctx, text)
+	if err != nil {
+		return vec, err
+	}
+	if err := cp.store.Put(key, vec); err != nil {
+		cp.logger.Warn("embedding.cache.write.error", "err", err)
+	}
+	return vec, nil
+}
+
+// newCachedEmbeddingProvider wraps provider with the shared cache at path.
+func newCachedEmbeddingProvider(path, model string, provider EmbeddingProvider, logger *slog.Logger) (*cachedEmbeddingProvider, *storage.EmbeddingCache, error) {
+	cache, err := storage.OpenEmbeddingCache(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &cachedEmbeddingProvider{EmbeddingProvider: provider, store: cache, model: model, logger: logger}, cache, nil
+}
+
+// embeddingCacheHits returns the number of vectors served from the shared
+// cache so far, or 0 when the cache is disabled.
+func (p *LocalPipeline) embeddingCacheHits() int {
+	if p.embedCache == nil {
+		return 0
+	}
+	return int(p.embedCache.hits.Load())
+}
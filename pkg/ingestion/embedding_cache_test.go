@@ -0,0 +1,88 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+// mapEmbeddingStore is an in-memory embeddingStore.
+type mapEmbeddingStore struct {
+	vecs    map[string][]float32
+	failGet bool
+}
+
+func (m *mapEmbeddingStore) Get(key string) ([]float32, bool, error) {
+	if m.failGet {
+		return nil, false, errors.New("disk on fire")
+	}
+	v, ok := m.vecs[key]
+	return v, ok, nil
+}
+
+func (m *mapEmbeddingStore) Put(key string, vec []float32) error {
+	m.vecs[key] = vec
+	return nil
+}
+
+func TestCachedEmbeddingProvider(t *testing.T) {
+	ctx := context.Background()
+	inner := NewMockEmbeddingProvider(8, nil)
+	store := &mapEmbeddingStore{vecs: map[string][]float32{}}
+	cp := &cachedEmbeddingProvider{EmbeddingProvider: inner, store: store, model: "mock/m/8", logger: slog.Default()}
+
+	first, err := cp.Embed(ctx, "func A() {}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := cp.Embed(ctx, "func A() {}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inner.Calls() != 1 {
+		t.Errorf("provider calls = %d, want 1 (second call should hit the cache)", inner.Calls())
+	}
+	if len(first) != len(second) || first[0] != second[0] {
+		t.Error("cached vector differs from the original")
+	}
+	if cp.hits.Load() != 1 || cp.misses.Load() != 1 {
+		t.Errorf("hits/misses = %d/%d", cp.hits.Load(), cp.misses.Load())
+	}
+
+	// The same text under another model is a miss.
+	other := &cachedEmbeddingProvider{EmbeddingProvider: inner, store: store, model: "mock/other/8", logger: slog.Default()}
+	if _, err := other.Embed(ctx, "func A() {}"); err != nil {
+		t.Fatal(err)
+	}
+	if inner.Calls() != 2 {
+		t.Errorf("provider calls = %d, want 2", inner.Calls())
+	}
+
+	// A broken store degrades to the provider.
+	store.failGet = true
+	if _, err := cp.Embed(ctx, "func A() {}"); err != nil {
+		t.Fatalf("store errors must not fail embedding: %v", err)
+	}
+	if inner.Calls() != 3 {
+		t.Errorf("provider calls = %d, want 3", inner.Calls())
+	}
+}
@@ -21,7 +21,10 @@ package ingestion
 
 import (
 	"context"
+	"encoding/json"
 	"math"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -258,6 +261,39 @@ func TestOllamaEmbeddingProvider_Structure(t *testing.T) {
 	}
 }
 
+func TestOllamaEmbeddingProvider_DocumentPrefix(t *testing.T) {
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaEmbedRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		prompt = req.Prompt
+		_ = json.NewEncoder(w).Encode(OllamaEmbedResponse{Embedding: []float64{1, 0}})
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	nomic := NewOllamaEmbeddingProvider(server.URL, "nomic-embed-text", nil)
+	if _, err := nomic.Embed(ctx, "func A() {}"); err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if prompt != "search_document: func A() {}" {
+		t.Errorf("nomic prompt = %q, want the search_document prefix", prompt)
+	}
+
+	nomic.SetDocumentPrefix("")
+	if _, err := nomic.Embed(ctx, "func A() {}"); err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if prompt != "func A() {}" {
+		t.Errorf("prompt = %q, want the text as-is after SetDocumentPrefix(\"\")", prompt)
+	}
+
+	other := NewOllamaEmbeddingProvider(server.URL, "mxbai-embed-large", nil)
+	if other.DocumentPrefix() != "" {
+		t.Errorf("DocumentPrefix() = %q, want none for a symmetric model", other.DocumentPrefix())
+	}
+}
+
 func TestOpenAIEmbeddingProvider_Structure(t *testing.T) {
 	provider := NewOpenAIEmbeddingProvider("sk-test", "https://api.openai.com/v1", "text-embedding-3-small", nil)
 
@@ -301,3 +337,38 @@ func TestCreateEmbeddingProvider_EnvVarConfiguration(t *testing.T) {
 		t.Errorf("model = %q, want 'custom-model'", np.model)
 	}
 }
+
+func TestEmbeddingGenerator_NameEmbeddings(t *testing.T) {
+	functions := []FunctionEntity{
+		{ID: "f1", Name: "parseHTTPConfig", Signature: "func parseHTTPConfig(path string) (*Config, error)", CodeText: "func parseHTTPConfig() {}"},
+		{ID: "f2", Name: "Close", CodeText: "func Close() {}"},
+	}
+	if got := nameEmbeddingText(functions[0]); got != "parse http config\nfunc parseHTTPConfig(path string) (*Config, error)" {
+		t.Errorf("nameEmbeddingText() = %q", got)
+	}
+
+	for _, workers := range []int{1, 4} {
+		gen := NewEmbeddingGenerator(NewMockEmbeddingProvider(8, nil), workers, nil)
+		result, err := gen.EmbedFunctions(context.Background(), functions)
+		if err != nil {
+			t.Fatalf("EmbedFunctions() error = %v", err)
+		}
+		for _, fn := range result.Functions {
+			if fn.NameEmbedding != nil {
+				t.Errorf("workers=%d: %s has a name vector with name embeddings off", workers, fn.ID)
+			}
+		}
+
+		gen.SetNameEmbeddings(true)
+		result, err = gen.EmbedFunctions(context.Background(), functions)
+		if err != nil {
+			t.Fatalf("EmbedFunctions() error = %v", err)
+		}
+		for _, fn := range result.Functions {
+			if len(fn.NameEmbedding) != 8 || len(fn.Embedding) != 8 {
+				t.Errorf("workers=%d: %s has %d body and %d name dimensions, want 8 each",
+					workers, fn.ID, len(fn.Embedding), len(fn.NameEmbedding))
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package ingestion
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeUTF16 encodes s as UTF-16 with a byte order mark.
+func encodeUTF16(s string, order binary.ByteOrder) []byte {
+	out := []byte{0xFF, 0xFE}
+	if order == binary.BigEndian {
+		out = []byte{0xFE, 0xFF}
+	}
+	for _, u := range utf16.Encode([]rune(s)) {
+		var unit [2]byte
+		order.PutUint16(unit[:], u)
+		out = append(out, unit[:]...)
+	}
+	return out
+}
+
+func TestDecodeSource(t *testing.T) {
+	src := "// Größe — “quoted” 😀\nfunc f() {}\n"
+
+	tests := []struct {
+		name     string
+		content  []byte
+		want     string
+		encoding string
+	}{
+		{"utf-8", []byte(src), src, encodingUTF8},
+		{"utf-8 bom", append([]byte{0xEF, 0xBB, 0xBF}, src...), src, encodingUTF8BOM},
+		{"utf-16le", encodeUTF16(src, binary.LittleEndian), src, encodingUTF16LE},
+		{"utf-16be", encodeUTF16(src, binary.BigEndian), src, encodingUTF16BE},
+		{"windows-1252", []byte("// Gr\xf6\xdfe \x93quoted\x94 \x80\n"), "// Größe “quoted” €\n", encodingLatin1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, encoding := decodeSource(tt.content)
+			assert.Equal(t, tt.want, string(got))
+			assert.Equal(t, tt.encoding, encoding)
+		})
+	}
+}
+
+func TestTreeSitterParser_UTF16File(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "win.go")
+	src := "package main\r\n\r\n// Größe returns the size.\r\nfunc Größe() int { return 1 }\r\n"
+	require.NoError(t, os.WriteFile(path, encodeUTF16(src, binary.LittleEndian), 0o600))
+	assert.False(t, isBinaryFile(path), "UTF-16 files contain NUL bytes but are text")
+
+	parser := NewTreeSitterParser(nil)
+	result, err := parser.ParseFile(FileInfo{Path: "win.go", FullPath: path, Language: "go"})
+	require.NoError(t, err)
+	require.Len(t, result.Functions, 1)
+	assert.Equal(t, "Größe", result.Functions[0].Name)
+	assert.Contains(t, result.Functions[0].CodeText, "return 1")
+}
@@ -0,0 +1,206 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package ingestion
+
+// entitySet is everything one index run writes to storage.
+type entitySet struct {
+	files         []FileEntity
+	functions     []FunctionEntity
+	types         []TypeEntity
+	defines       []DefinesEdge
+	definesTypes  []DefinesTypeEdge
+	calls         []CallsEdge
+	imports       []ImportEntity
+	fields        []FieldEntity
+	implements    []ImplementsEdge
+	contains      []ContainsEdge
+	methodOf      []MethodOfEdge
+	unresolved    []UnresolvedCall
+	protoOptions  []ProtoOption
+	generatedFrom []GeneratedFromEdge
+	generated     []GeneratedFile
+	generatedFunc []GeneratedFuncLink
+	templates     []TemplateEntity
+	templateRefs  []TemplateRef
+	renders       []RenderCall
+	ciJobs        []CIJob
+	ciSteps       []CIStep
+	ciRefs        []CIRef
+	tableRefs     []TableRef
+	rpcLinks      []RPCLink
+	entryPoints   []EntryPoint
+
+	// functionPaths maps functions written in an earlier set to their
+	// files, so splitBy can place call edges whose caller is not in this
+	// set.
+	functionPaths map[string]string
+}
+
+// mutations returns the Datalog script that writes the set.
+func (s *entitySet) mutations(db *DatalogBuilder) string {
+	mutations := db.BuildMutationsWithTypes(s.files, s.functions, s.types, s.defines, s.definesTypes, s.calls, s.imports)
+	mutations += db.BuildFieldAndImplementsMutations(s.fields, s.implements)
+	mutations += db.BuildContainsMutations(s.contains)
+	mutations += db.BuildMethodOfMutations(s.methodOf)
+	mutations += db.BuildUnresolvedCallMutations(s.unresolved)
+	mutations += db.BuildProtoOptionMutations(s.protoOptions)
+	mutations += db.BuildGeneratedFromMutations(s.generatedFrom)
+	mutations += db.BuildGeneratedFileMutations(s.generated, s.generatedFunc)
+	mutations += db.BuildTemplateMutations(s.templates, s.templateRefs, s.renders)
+	mutations += db.BuildCIMutations(s.ciJobs, s.ciSteps, s.ciRefs)
+	mutations += db.BuildTableRefMutations(s.tableRefs)
+	mutations += db.BuildRPCLinkMutations(s.rpcLinks)
+	mutations += db.BuildEntryPointMutations(s.entryPoints)
+	return mutations
+}
+
+// count returns the number of entities and edges in the set.
+func (s *entitySet) count() int {
+	return len(s.files) + len(s.functions) + len(s.types) +
+		len(s.defines) + len(s.definesTypes) + len(s.calls) + len(s.imports) +
+		len(s.fields) + len(s.implements) + len(s.contains) + len(s.methodOf) + len(s.unresolved) +
+		len(s.protoOptions) + len(s.generatedFrom) + len(s.generated) + len(s.generatedFunc) +
+		len(s.templates) + len(s.templateRefs) + len(s.renders) +
+		len(s.ciJobs) + len(s.ciSteps) + len(s.ciRefs) + len(s.tableRefs) + len(s.rpcLinks) + len(s.entryPoints)
+}
+
+// splitBy partitions the set by key applied to each entity's file path, for
+// example one part per file or per shard. Defines edges follow their file and
+// call edges follow their caller, so a call into another part is stored with
+// the caller. Entities without a known file go to key("").
+func (s *entitySet) splitBy(key func(path string) string) map[string]*entitySet {
+	parts := make(map[string]*entitySet)
+	part := func(path string) *entitySet {
+		k := key(path)
+		if parts[k] == nil {
+			parts[k] = &entitySet{}
+		}
+		return parts[k]
+	}
+
+	filePaths := make(map[string]string, len(s.files))
+	for _, f := range s.files {
+		filePaths[f.ID] = f.Path
+		p := part(f.Path)
+		p.files = append(p.files, f)
+	}
+	functionPaths := make(map[string]string, len(s.functions)+len(s.functionPaths))
+	for id, path := range s.functionPaths {
+		functionPaths[id] = path
+	}
+	for _, fn := range s.functions {
+		functionPaths[fn.ID] = fn.FilePath
+		p := part(fn.FilePath)
+		p.functions = append(p.functions, fn)
+	}
+	for _, t := range s.types {
+		p := part(t.FilePath)
+		p.types = append(p.types, t)
+	}
+	for _, e := range s.defines {
+		p := part(filePaths[e.FileID])
+		p.defines = append(p.defines, e)
+	}
+	for _, e := range s.definesTypes {
+		p := part(filePaths[e.FileID])
+		p.definesTypes = append(p.definesTypes, e)
+	}
+	for _, e := range s.calls {
+		p := part(functionPaths[e.CallerID])
+		p.calls = append(p.calls, e)
+	}
+	for _, e := range s.imports {
+		p := part(e.FilePath)
+		p.imports = append(p.imports, e)
+	}
+	for _, e := range s.fields {
+		p := part(e.FilePath)
+		p.fields = append(p.fields, e)
+	}
+	for _, e := range s.implements {
+		p := part(e.FilePath)
+		p.implements = append(p.implements, e)
+	}
+	for _, e := range s.contains {
+		p := part(e.FilePath)
+		p.contains = append(p.contains, e)
+	}
+	for _, e := range s.methodOf {
+		p := part(e.FilePath)
+		p.methodOf = append(p.methodOf, e)
+	}
+	for _, e := range s.unresolved {
+		p := part(e.FilePath)
+		p.unresolved = append(p.unresolved, e)
+	}
+	for _, e := range s.protoOptions {
+		p := part(e.FilePath)
+		p.protoOptions = append(p.protoOptions, e)
+	}
+	for _, e := range s.generatedFrom {
+		p := part(e.FilePath)
+		p.generatedFrom = append(p.generatedFrom, e)
+	}
+	for _, e := range s.generated {
+		p := part(e.FilePath)
+		p.generated = append(p.generated, e)
+	}
+	for _, e := range s.generatedFunc {
+		p := part(e.FilePath)
+		p.generatedFunc = append(p.generatedFunc, e)
+	}
+	for _, e := range s.templates {
+		p := part(e.FilePath)
+		p.templates = append(p.templates, e)
+	}
+	for _, e := range s.templateRefs {
+		p := part(e.FilePath)
+		p.templateRefs = append(p.templateRefs, e)
+	}
+	for _, e := range s.renders {
+		p := part(e.FilePath)
+		p.renders = append(p.renders, e)
+	}
+	for _, e := range s.ciJobs {
+		p := part(e.FilePath)
+		p.ciJobs = append(p.ciJobs, e)
+	}
+	for _, e := range s.ciSteps {
+		p := part(e.FilePath)
+		p.ciSteps = append(p.ciSteps, e)
+	}
+	for _, e := range s.ciRefs {
+		p := part(e.FilePath)
+		p.ciRefs = append(p.ciRefs, e)
+	}
+	for _, e := range s.tableRefs {
+		p := part(e.FilePath)
+		p.tableRefs = append(p.tableRefs, e)
+	}
+	for _, e := range s.rpcLinks {
+		p := part(e.FilePath)
+		p.rpcLinks = append(p.rpcLinks, e)
+	}
+	for _, e := range s.entryPoints {
+		p := part(e.FilePath)
+		p.entryPoints = append(p.entryPoints, e)
+	}
+	return parts
+}
@@ -0,0 +1,54 @@
+package ingestion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+func TestEntitySet_SplitBy(t *testing.T) {
+	set := &entitySet{
+		files: []FileEntity{
+			{ID: "file:main", Path: "main.go"},
+			{ID: "file:api", Path: "api/server.go"},
+		},
+		functions: []FunctionEntity{
+			{ID: "fn:main", FilePath: "main.go"},
+			{ID: "fn:serve", FilePath: "api/server.go"},
+		},
+		defines: []DefinesEdge{
+			{FileID: "file:main", FunctionID: "fn:main"},
+			{FileID: "file:api", FunctionID: "fn:serve"},
+		},
+		calls:   []CallsEdge{{CallerID: "fn:main", CalleeID: "fn:serve"}},
+		imports: []ImportEntity{{FilePath: "api/server.go", ImportPath: "net/http"}},
+		ciJobs:  []CIJob{{FilePath: ".github/workflows/ci.yml", Name: "test"}},
+	}
+
+	parts := set.splitBy(storage.ShardFor)
+	require.Len(t, parts, 3)
+
+	root, api, ci := parts[""], parts["api"], parts[".github"]
+	require.NotNil(t, root)
+	require.NotNil(t, api)
+	require.NotNil(t, ci)
+	assert.Equal(t, []FileEntity{set.files[0]}, root.files)
+	assert.Equal(t, []DefinesEdge{set.defines[0]}, root.defines)
+	assert.Len(t, root.calls, 1, "calls are stored with the caller")
+	assert.Equal(t, []FunctionEntity{set.functions[1]}, api.functions)
+	assert.Len(t, api.imports, 1)
+	assert.Empty(t, api.calls)
+	assert.Len(t, ci.ciJobs, 1)
+	assert.Equal(t, set.count(), root.count()+api.count()+ci.count())
+}
+
+func TestFilesInShards(t *testing.T) {
+	files := []FileInfo{{Path: "main.go"}, {Path: "api/server.go"}, {Path: "web/app.ts"}, {Path: "api/v2/routes.go"}}
+	got := filesInShards(files, []string{"api"})
+	require.Len(t, got, 2)
+	assert.Equal(t, "api/server.go", got[0].Path)
+	assert.Equal(t, "api/v2/routes.go", got[1].Path)
+}
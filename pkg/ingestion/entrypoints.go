@@ -0,0 +1,207 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Entry point kinds.
+const (
+	EntryPointMain   = "main"   // main() and Python __main__
+	EntryPointCLI    = "cli"    // cobra and urfave/cli commands, click and typer commands
+	EntryPointServer = "server" // functions that start an HTTP or gRPC server
+	EntryPointLambda = "lambda" // AWS Lambda handlers
+	EntryPointCron   = "cron"   // functions registered with a scheduler
+)
+
+// testFilePattern matches test files, which never hold entry points or RPC
+// implementations.
+var testFilePattern = regexp.MustCompile(`(?i)(_test\.go$|\.(test|spec)\.[jt]sx?$|_test\.py$|(^|/)test_[^/]*\.py$|(^|/)(tests|__tests__)/)`)
+
+// entryPointSignatures detect entry points by the parameters they take and,
+// when name is set, by their name.
+var entryPointSignatures = []struct {
+	kind, detail string
+	re, name     *regexp.Regexp
+}{
+	{EntryPointCLI, "cobra.Command", regexp.MustCompile(`\*cobra\.Command\b.*\[\]string`), nil},
+	{EntryPointCLI, "urfave/cli", regexp.MustCompile(`\*cli\.Context\b`), nil},
+	{EntryPointLambda, "Lambda handler", regexp.MustCompile(`\(\s*event\b[^,()]*,\s*context\b`), regexp.MustCompile(`(?i)handler$`)},
+}
+
+// entryPointCalls detect functions that start a server by a call in their
+// body. The first submatch names the call.
+var entryPointCalls = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{EntryPointServer, regexp.MustCompile(`\b((?:http\.)?ListenAndServe(?:TLS)?)\(`)},
+	{EntryPointServer, regexp.MustCompile(`\b(grpc\.NewServer)\(`)},
+	{EntryPointServer, regexp.MustCompile(`\.(Run|Start)\(\s*"[^"]*:\d+"`)},
+	{EntryPointServer, regexp.MustCompile(`\.(listen)\(\s*(?:\d+|PORT\b|port\b|process\.env)`)},
+	{EntryPointServer, regexp.MustCompile(`\b(uvicorn\.run|serve_forever|app\.run)\(`)},
+}
+
+// entryPointRegistrations detect functions passed by name to a runtime that
+// calls them: the submatches are the detail (if any) and the function.
+var entryPointRegistrations = []struct {
+	kind, detail string
+	re           *regexp.Regexp
+}{
+	{EntryPointLambda, "lambda.Start", regexp.MustCompile(`\blambda\.Start(?:WithContext|WithOptions)?\(\s*()([A-Za-z_][\w.]*)`)},
+	{EntryPointCron, "cron", regexp.MustCompile(`\.AddFunc\(\s*"([^"]+)"\s*,\s*([A-Za-z_][\w.]*)`)},
+	{EntryPointCron, "cron", regexp.MustCompile(`\bcron\.schedule\(\s*['"]([^'"]+)['"]\s*,\s*([A-Za-z_][\w.]*)`)},
+	{EntryPointCron, "schedule", regexp.MustCompile(`\bschedule\.(every\([^)]*\)(?:\.\w+)*)\.do\(\s*([A-Za-z_][\w.]*)`)},
+}
+
+// entryPointDecorators detect Python functions by their decorators.
+var entryPointDecorators = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{EntryPointCLI, regexp.MustCompile(`(?m)^\s*@((?:\w+\.)?(?:command|group))\b`)},
+	{EntryPointCron, regexp.MustCompile(`(?m)^\s*@((?:\w+\.)?(?:scheduled_job|periodic_task|shared_task|task))\b`)},
+}
+
+// BuildEntryPointIndex finds the functions where execution starts beyond
+// main(): command handlers, functions that start a server, Lambda handlers
+// and scheduled jobs. Functions passed by name (lambda.Start(handler),
+// c.AddFunc("@hourly", sync)) are resolved in the same file first, then in
+// the same directory. Test files are skipped.
+func BuildEntryPointIndex(functions []FunctionEntity) []EntryPoint {
+	byName := map[string][]int{} // directory and short name -> functions
+	for i, fn := range functions {
+		key := entryPointKey(fn.FilePath, fn.Name)
+		byName[key] = append(byName[key], i)
+	}
+	seen := map[string]bool{}
+	var points []EntryPoint
+	add := func(fn FunctionEntity, kind, detail string) {
+		key := fn.ID + "|" + kind
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		points = append(points, EntryPoint{FunctionID: fn.ID, FilePath: fn.FilePath, Kind: kind, Detail: detail})
+	}
+
+	for _, fn := range functions {
+		if testFilePattern.MatchString(fn.FilePath) {
+			continue
+		}
+		if fn.Name == "main" || fn.Name == "__main__" {
+			add(fn, EntryPointMain, "main()")
+		}
+		for _, s := range entryPointSignatures {
+			if s.re.MatchString(fn.Signature) && (s.name == nil || s.name.MatchString(fn.Name)) {
+				add(fn, s.kind, s.detail)
+			}
+		}
+		if isAnonymousFunction(fn.Name) {
+			// The enclosing function's code includes the closure's, so
+			// body matches are credited to named functions only.
+			continue
+		}
+		for _, c := range entryPointCalls {
+			if m := c.re.FindStringSubmatch(fn.CodeText); m != nil {
+				add(fn, c.kind, m[1])
+			}
+		}
+		for _, d := range entryPointDecorators {
+			if m := d.re.FindStringSubmatch(decoratorLines(fn.CodeText)); m != nil {
+				add(fn, d.kind, "@"+m[1])
+			}
+		}
+		for _, r := range entryPointRegistrations {
+			for _, m := range r.re.FindAllStringSubmatch(fn.CodeText, -1) {
+				detail := r.detail
+				if m[1] != "" {
+					detail += " " + m[1]
+				}
+				target, ok := resolveEntryPointTarget(functions, byName, fn, m[2])
+				if !ok {
+					target = fn // an inline or unresolved function: credit the registration
+				}
+				if !testFilePattern.MatchString(target.FilePath) {
+					add(target, r.kind, detail)
+				}
+			}
+		}
+	}
+	sort.SliceStable(points, func(i, j int) bool {
+		if points[i].FilePath != points[j].FilePath {
+			return points[i].FilePath < points[j].FilePath
+		}
+		return points[i].Kind < points[j].Kind
+	})
+	return points
+}
+
+// resolveEntryPointTarget finds the function a registration names, such as
+// "handler" or "h.Handle", preferring the registering function's file.
+func resolveEntryPointTarget(functions []FunctionEntity, byName map[string][]int, from FunctionEntity, name string) (FunctionEntity, bool) {
+	candidates := byName[entryPointKey(from.FilePath, name)]
+	if len(candidates) == 0 {
+		return FunctionEntity{}, false
+	}
+	for _, i := range candidates {
+		if functions[i].FilePath == from.FilePath {
+			return functions[i], true
+		}
+	}
+	return functions[candidates[0]], true
+}
+
+// entryPointKey keys functions by directory and short name.
+func entryPointKey(filePath, name string) string {
+	return path.Dir(filePath) + "|" + shortFunctionName(name)
+}
+
+// shortFunctionName returns the part of a name after the last dot:
+// "Server.Start" and "s.Start" both become "Start".
+func shortFunctionName(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// isAnonymousFunction reports whether name is a closure, arrow function or
+// lambda as named by localizeAnonymousFunctions.
+func isAnonymousFunction(name string) bool {
+	short := shortFunctionName(name)
+	return strings.Contains(short, "#") || anonymousPlaceholderPattern.MatchString(name)
+}
+
+// decoratorLines returns the leading decorator lines of a Python function's
+// code, so decorators on nested functions are not mistaken for its own.
+func decoratorLines(code string) string {
+	var lines []string
+	for _, line := range strings.Split(code, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "@") {
+			break
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
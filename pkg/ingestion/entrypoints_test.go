@@ -0,0 +1,102 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// entryPointKinds maps function names to the kinds detected for them.
+func entryPointKinds(functions []FunctionEntity) map[string][]string {
+	names := make(map[string]string, len(functions))
+	for _, fn := range functions {
+		names[fn.ID] = fn.Name
+	}
+	kinds := make(map[string][]string)
+	for _, ep := range BuildEntryPointIndex(functions) {
+		kinds[names[ep.FunctionID]] = append(kinds[names[ep.FunctionID]], ep.Kind)
+	}
+	return kinds
+}
+
+func TestBuildEntryPointIndex_Go(t *testing.T) {
+	functions := []FunctionEntity{
+		{ID: "f1", Name: "main", FilePath: "cmd/api/main.go", Signature: "func main()", CodeText: "func main() {\n\tlambda.Start(handleRequest)\n}"},
+		{ID: "f2", Name: "handleRequest", FilePath: "cmd/api/handler.go", Signature: "func handleRequest(ctx context.Context, e Event) (Response, error)"},
+		{ID: "f3", Name: "runServe", FilePath: "cmd/cli/serve.go", Signature: "func runServe(cmd *cobra.Command, args []string) error"},
+		{ID: "f4", Name: "Server.Start", FilePath: "internal/server/server.go", Signature: "func (s *Server) Start() error", CodeText: "func (s *Server) Start() error {\n\treturn http.ListenAndServe(s.addr, s.mux)\n}"},
+		{ID: "f5", Name: "schedule", FilePath: "internal/jobs/jobs.go", Signature: "func schedule(c *cron.Cron)", CodeText: "func schedule(c *cron.Cron) {\n\tc.AddFunc(\"@hourly\", syncUsers)\n}"},
+		{ID: "f6", Name: "syncUsers", FilePath: "internal/jobs/sync.go", Signature: "func syncUsers()"},
+		{ID: "f7", Name: "helper", FilePath: "internal/jobs/sync.go", Signature: "func helper()"},
+	}
+
+	kinds := entryPointKinds(functions)
+	assert.Equal(t, []string{EntryPointMain}, kinds["main"])
+	assert.Equal(t, []string{EntryPointLambda}, kinds["handleRequest"])
+	assert.Equal(t, []string{EntryPointCLI}, kinds["runServe"])
+	assert.Equal(t, []string{EntryPointServer}, kinds["Server.Start"])
+	assert.Equal(t, []string{EntryPointCron}, kinds["syncUsers"])
+	assert.Empty(t, kinds["schedule"])
+	assert.Empty(t, kinds["helper"])
+}
+
+func TestBuildEntryPointIndex_Detail(t *testing.T) {
+	functions := []FunctionEntity{
+		{ID: "f1", Name: "register", FilePath: "jobs/jobs.go", CodeText: `c.AddFunc("0 3 * * *", cleanup)`},
+		{ID: "f2", Name: "cleanup", FilePath: "jobs/jobs.go"},
+	}
+	points := BuildEntryPointIndex(functions)
+	if assert.Len(t, points, 1) {
+		assert.Equal(t, EntryPoint{FunctionID: "f2", FilePath: "jobs/jobs.go", Kind: EntryPointCron, Detail: "cron 0 3 * * *"}, points[0])
+	}
+}
+
+func TestBuildEntryPointIndex_Python(t *testing.T) {
+	functions := []FunctionEntity{
+		{ID: "f1", Name: "deploy", FilePath: "tool/cli.py", Signature: "def deploy(env)", CodeText: "@cli.command()\n@click.option('--env')\ndef deploy(env):\n    pass"},
+		{ID: "f2", Name: "lambda_handler", FilePath: "svc/app.py", Signature: "def lambda_handler(event, context)"},
+		{ID: "f3", Name: "on_click", FilePath: "ui/app.py", Signature: "def on_click(event, context)"},
+		{ID: "f4", Name: "cleanup", FilePath: "tasks/jobs.py", Signature: "def cleanup()", CodeText: "@app.task\ndef cleanup():\n    pass"},
+		{ID: "f5", Name: "serve", FilePath: "svc/server.py", Signature: "def serve()", CodeText: "def serve():\n    uvicorn.run(app, port=8000)"},
+	}
+
+	kinds := entryPointKinds(functions)
+	assert.Equal(t, []string{EntryPointCLI}, kinds["deploy"])
+	assert.Equal(t, []string{EntryPointLambda}, kinds["lambda_handler"])
+	assert.Empty(t, kinds["on_click"], "only handler names count as Lambda handlers")
+	assert.Equal(t, []string{EntryPointCron}, kinds["cleanup"])
+	assert.Equal(t, []string{EntryPointServer}, kinds["serve"])
+}
+
+func TestBuildEntryPointIndex_SkipsTestsAndClosures(t *testing.T) {
+	functions := []FunctionEntity{
+		{ID: "f1", Name: "TestServe", FilePath: "server/server_test.go", CodeText: "http.ListenAndServe(\":0\", nil)"},
+		{ID: "f2", Name: "run", FilePath: "server/run.go", CodeText: "func run() {\n\tgo func() {\n\t\thttp.ListenAndServe(addr, nil)\n\t}()\n}"},
+		{ID: "f3", Name: "run.closure#1", FilePath: "server/run.go", CodeText: "func() {\n\t\thttp.ListenAndServe(addr, nil)\n\t}"},
+		{ID: "f4", Name: "main.closure#1", FilePath: "cmd/root.go", Signature: "func(cmd *cobra.Command, args []string) error"},
+	}
+
+	kinds := entryPointKinds(functions)
+	assert.Empty(t, kinds["TestServe"])
+	assert.Equal(t, []string{EntryPointServer}, kinds["run"])
+	assert.Empty(t, kinds["run.closure#1"], "body matches are credited to the named function")
+	assert.Equal(t, []string{EntryPointCLI}, kinds["main.closure#1"], "cobra RunE closures are commands")
+}
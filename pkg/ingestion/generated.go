@@ -0,0 +1,297 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Generated source kinds of GeneratedFuncLink.
+const (
+	GeneratedSourceFunction = "function"
+	GeneratedSourceType     = "type"
+)
+
+// maxGeneratedHeaderLines bounds the header comment scanned for markers.
+const maxGeneratedHeaderLines = 40
+
+// Header comment markers of generated files.
+var (
+	// Go convention: "Code generated by protoc-gen-go. DO NOT EDIT.",
+	// `Code generated by "stringer -type=Pill"; DO NOT EDIT.`
+	goGeneratedMarker = regexp.MustCompile(`^Code generated\b(?: by (.+?))?\W*DO NOT EDIT\W*$`)
+	// JavaScript and protobuf-es: "@generated by protoc-gen-es v1.4.2".
+	atGeneratedMarker = regexp.MustCompile(`^@generated\b(?: by (\S+))?`)
+	// Python protoc and others: "Generated by the protocol buffer compiler.  DO NOT EDIT!"
+	proseGeneratedMarker = regexp.MustCompile(`(?i)^(?:auto-?generated|generated) by (.+?)\W*DO NOT EDIT\W*$`)
+	// Source file: "source: api/user.proto" (protoc, sqlc), "Source: store.go"
+	// (mockgen), "@generated from file user.proto (package ...)" (protobuf-es).
+	generatedSourceMarker = regexp.MustCompile(`^(?:(?i:source):|@generated from file)\s+(\S+)`)
+)
+
+// Shapes of generated functions.
+var (
+	// gRPC stubs: userServiceClient, UserServiceServer, UnimplementedUserServiceServer.
+	grpcStubReceiver = regexp.MustCompile(`^(?:Unimplemented|Unsafe)?(\w+?)(?:Client|Server)$`)
+	// gRPC handlers: _UserService_GetUser_Handler.
+	grpcHandler = regexp.MustCompile(`^_(\w+?)_(\w+)_Handler$`)
+	// Mocks: MockStore, MockStoreMockRecorder (mockgen) and their constructors.
+	mockRecorder = regexp.MustCompile(`^(Mock\w+?)MockRecorder$`)
+)
+
+// detectGeneratedFile reads the header comment of a file for the markers
+// code generators leave, and returns the generator and source it names, or
+// nil if the file is not generated. protoc output without a recognizable
+// header is still recorded by its file name (".pb.go", "_pb.ts").
+func detectGeneratedFile(content, filePath string) *GeneratedFile {
+	var gen *GeneratedFile
+	lines := strings.SplitN(content, "\n", maxGeneratedHeaderLines+1)
+	for i, line := range lines {
+		if i == maxGeneratedHeaderLines {
+			break
+		}
+		text, ok := headerCommentText(line)
+		if !ok {
+			break
+		}
+		if gen == nil {
+			if generator, found := generatorFromMarker(text); found {
+				gen = &GeneratedFile{FilePath: filePath, Generator: generator}
+			}
+		}
+		if m := generatedSourceMarker.FindStringSubmatch(text); m != nil {
+			if gen == nil {
+				gen = &GeneratedFile{FilePath: filePath}
+			}
+			if gen.Source == "" {
+				gen.Source = m[1]
+			}
+		}
+	}
+	if gen == nil {
+		if base, ok := generatedProtoBase(filePath); ok {
+			gen = &GeneratedFile{FilePath: filePath, Generator: "protoc", Source: base + ".proto"}
+		}
+	}
+	return gen
+}
+
+// headerCommentText strips comment markers from a header line. It returns
+// false at the first line of code, where the header ends.
+func headerCommentText(line string) (string, bool) {
+	text := strings.TrimSpace(line)
+	if text == "" {
+		return "", true
+	}
+	for _, prefix := range []string{"//", "#", "/*", "*/", "*"} {
+		if strings.HasPrefix(text, prefix) {
+			text = strings.TrimSuffix(strings.TrimPrefix(text, prefix), "*/")
+			return strings.TrimSpace(strings.TrimLeft(text, "*/")), true
+		}
+	}
+	return "", false
+}
+
+// generatorFromMarker returns the generator a header line names, and
+// whether the line marks the file as generated at all.
+func generatorFromMarker(text string) (string, bool) {
+	for _, re := range []*regexp.Regexp{goGeneratedMarker, atGeneratedMarker, proseGeneratedMarker} {
+		if m := re.FindStringSubmatch(text); m != nil {
+			return generatorName(m[1]), true
+		}
+	}
+	return "", false
+}
+
+// generatorName reduces a generator description to a tool name:
+// "mockery v2.20.0" → "mockery", `"stringer -type=Pill"` → "stringer",
+// "github.com/99designs/gqlgen" → "gqlgen".
+func generatorName(desc string) string {
+	lower := strings.ToLower(desc)
+	switch {
+	case strings.Contains(lower, "protocol buffer compiler"):
+		return "protoc"
+	case strings.Contains(lower, "grpc") && strings.Contains(lower, "compiler plugin"):
+		return "grpc_tools"
+	}
+	fields := strings.Fields(strings.Trim(desc, `"'`+"`"))
+	if len(fields) == 0 {
+		return ""
+	}
+	return path.Base(strings.TrimRight(strings.Trim(fields[0], `"'`+"`"), ".,;:"))
+}
+
+// ResolveGeneratedSources rewrites the Source of each generated file to the
+// indexed file it names, trying it relative to the generated file's
+// directory (mockgen), then as a repository path (protoc include roots),
+// then as the suffix of a single indexed path. Unmatched sources are kept
+// as written.
+func ResolveGeneratedSources(generated []GeneratedFile, files []FileEntity) []GeneratedFile {
+	if len(generated) == 0 {
+		return generated
+	}
+	indexed := make(map[string]bool, len(files))
+	for _, f := range files {
+		indexed[f.Path] = true
+	}
+	resolved := make([]GeneratedFile, len(generated))
+	for i, g := range generated {
+		resolved[i] = g
+		if g.Source == "" || indexed[g.Source] {
+			continue
+		}
+		if p := path.Join(path.Dir(g.FilePath), g.Source); indexed[p] {
+			resolved[i].Source = p
+			continue
+		}
+		var match string
+		for _, f := range files {
+			if strings.HasSuffix(f.Path, "/"+g.Source) {
+				if match != "" {
+					match = ""
+					break
+				}
+				match = f.Path
+			}
+		}
+		if match != "" {
+			resolved[i].Source = match
+		}
+	}
+	return resolved
+}
+
+// BuildGeneratedMockIndex links mock types generated by mockgen or mockery
+// ("MockStore") to the interface they mock ("Store"). The interface in the
+// generated file's source wins; otherwise a single interface of that name
+// outside generated files is used.
+func BuildGeneratedMockIndex(generated []GeneratedFile, types []TypeEntity) []GeneratedFromEdge {
+	mockFiles := make(map[string]GeneratedFile)
+	for _, g := range generated {
+		if strings.Contains(strings.ToLower(g.Generator), "mock") {
+			mockFiles[g.FilePath] = g
+		}
+	}
+	if len(mockFiles) == 0 {
+		return nil
+	}
+	interfaces := make(map[string][]TypeEntity)
+	for _, t := range types {
+		if _, isMock := mockFiles[t.FilePath]; !isMock && t.Kind == "interface" {
+			interfaces[t.Name] = append(interfaces[t.Name], t)
+		}
+	}
+
+	var edges []GeneratedFromEdge
+	for _, t := range types {
+		g, ok := mockFiles[t.FilePath]
+		if !ok || !strings.HasPrefix(t.Name, "Mock") || mockRecorder.MatchString(t.Name) {
+			continue
+		}
+		candidates := interfaces[strings.TrimPrefix(t.Name, "Mock")]
+		var match *TypeEntity
+		for i, c := range candidates {
+			if c.FilePath == g.Source {
+				match = &candidates[i]
+				break
+			}
+		}
+		if match == nil && len(candidates) == 1 {
+			match = &candidates[0]
+		}
+		if match != nil {
+			edges = append(edges, GeneratedFromEdge{TypeID: t.ID, ProtoTypeID: match.ID, FilePath: t.FilePath})
+		}
+	}
+	return edges
+}
+
+// BuildGeneratedFuncIndex links functions in generated files to the
+// definitions they were generated from: gRPC client and server stubs and
+// handlers to their .proto RPC, and methods and constructors of generated
+// types (messages, mocks) to the source of their type, as given by
+// typeEdges.
+func BuildGeneratedFuncIndex(generated []GeneratedFile, types []TypeEntity, functions []FunctionEntity, typeEdges []GeneratedFromEdge) []GeneratedFuncLink {
+	if len(generated) == 0 {
+		return nil
+	}
+	genFiles := make(map[string]bool, len(generated))
+	for _, g := range generated {
+		genFiles[g.FilePath] = true
+	}
+	rpcs := make(map[string]string)
+	for _, f := range functions {
+		if strings.HasSuffix(f.FilePath, ".proto") && strings.Contains(f.Name, ".") {
+			rpcs[f.Name] = f.ID
+		}
+	}
+	typeByID := make(map[string]TypeEntity, len(types))
+	for _, t := range types {
+		typeByID[t.ID] = t
+	}
+	typeSource := make(map[string]string) // file|type name -> source type ID
+	for _, e := range typeEdges {
+		if t, ok := typeByID[e.TypeID]; ok {
+			typeSource[t.FilePath+"|"+t.Name] = e.ProtoTypeID
+		}
+	}
+
+	var links []GeneratedFuncLink
+	for _, f := range functions {
+		if !genFiles[f.FilePath] {
+			continue
+		}
+		link := func(id, kind string) {
+			links = append(links, GeneratedFuncLink{FunctionID: f.ID, SourceID: id, SourceKind: kind, FilePath: f.FilePath})
+		}
+		recv, method := "", f.Name
+		if i := strings.LastIndex(f.Name, "."); i >= 0 {
+			recv, method = f.Name[:i], f.Name[i+1:]
+		}
+		if recv == "" {
+			if m := grpcHandler.FindStringSubmatch(method); m != nil {
+				if id, ok := rpcs[m[1]+"."+m[2]]; ok {
+					link(id, GeneratedSourceFunction)
+				}
+				continue
+			}
+			if typeName, ok := strings.CutPrefix(method, "New"); ok {
+				if id, ok := typeSource[f.FilePath+"|"+typeName]; ok {
+					link(id, GeneratedSourceType)
+				}
+			}
+			continue
+		}
+		if m := grpcStubReceiver.FindStringSubmatch(recv); m != nil {
+			if id, ok := rpcs[strings.ToUpper(m[1][:1])+m[1][1:]+"."+method]; ok {
+				link(id, GeneratedSourceFunction)
+				continue
+			}
+		}
+		if m := mockRecorder.FindStringSubmatch(recv); m != nil {
+			recv = m[1]
+		}
+		if id, ok := typeSource[f.FilePath+"|"+recv]; ok {
+			link(id, GeneratedSourceType)
+		}
+	}
+	return links
+}
@@ -0,0 +1,205 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectGeneratedFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    *GeneratedFile
+	}{
+		{
+			name: "protoc-gen-go",
+			path: "gen/users.pb.go",
+			content: `// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// source: api/v1/users.proto
+
+package gen
+`,
+			want: &GeneratedFile{Generator: "protoc-gen-go", Source: "api/v1/users.proto"},
+		},
+		{
+			name: "mockgen",
+			path: "store/mocks/store.go",
+			content: `// Code generated by MockGen. DO NOT EDIT.
+// Source: store.go
+//
+// Generated by this command:
+//
+//	mockgen -source=store.go -destination=mocks/store.go
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+`,
+			want: &GeneratedFile{Generator: "MockGen", Source: "store.go"},
+		},
+		{
+			name:    "stringer after build tag",
+			path:    "pill_string.go",
+			content: "//go:build !js\n\n// Code generated by \"stringer -type=Pill\"; DO NOT EDIT.\n\npackage painkiller\n",
+			want:    &GeneratedFile{Generator: "stringer"},
+		},
+		{
+			name:    "module path generator",
+			path:    "graph/generated.go",
+			content: "// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.\n\npackage graph\n",
+			want:    &GeneratedFile{Generator: "gqlgen"},
+		},
+		{
+			name:    "python protoc",
+			path:    "api/users_pb2.py",
+			content: "# -*- coding: utf-8 -*-\n# Generated by the protocol buffer compiler.  DO NOT EDIT!\n# source: users.proto\n\"\"\"Generated protocol buffer code.\"\"\"\n",
+			want:    &GeneratedFile{Generator: "protoc", Source: "users.proto"},
+		},
+		{
+			name:    "protobuf-es",
+			path:    "web/gen/users_pb.ts",
+			content: "// @generated by protoc-gen-es v1.4.2 with parameter \"target=ts\"\n// @generated from file users.proto (package api.v1, syntax proto3)\n/* eslint-disable */\n\nimport { Message } from \"@bufbuild/protobuf\";\n",
+			want:    &GeneratedFile{Generator: "protoc-gen-es", Source: "users.proto"},
+		},
+		{
+			name:    "protoc output without header",
+			path:    "gen/orders.pb.go",
+			content: "package gen\n",
+			want:    &GeneratedFile{Generator: "protoc", Source: "orders.proto"},
+		},
+		{
+			name:    "hand-written",
+			path:    "internal/user.go",
+			content: "// Package user manages users.\n// Generated IDs are UUIDs.\npackage user\n\n// Code generated by hand. DO NOT EDIT.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectGeneratedFile(tt.content, tt.path)
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			require.NotNil(t, got)
+			tt.want.FilePath = tt.path
+			assert.Equal(t, *tt.want, *got)
+		})
+	}
+}
+
+func TestResolveGeneratedSources(t *testing.T) {
+	files := []FileEntity{
+		{Path: "api/v1/users.proto"},
+		{Path: "store/store.go"},
+		{Path: "proto/orders.proto"},
+		{Path: "a/items.proto"},
+		{Path: "b/items.proto"},
+	}
+	got := ResolveGeneratedSources([]GeneratedFile{
+		{FilePath: "gen/users.pb.go", Source: "api/v1/users.proto"},
+		{FilePath: "store/mocks/store.go", Source: "../store.go"},
+		{FilePath: "gen/orders.pb.go", Source: "orders.proto"},
+		{FilePath: "gen/items.pb.go", Source: "items.proto"},
+		{FilePath: "pill_string.go"},
+	}, files)
+
+	sources := make([]string, len(got))
+	for i, g := range got {
+		sources[i] = g.Source
+	}
+	assert.Equal(t, []string{
+		"api/v1/users.proto",
+		"store/store.go",     // relative to the generated file
+		"proto/orders.proto", // unique suffix
+		"items.proto",        // ambiguous: kept as written
+		"",
+	}, sources)
+}
+
+func TestBuildGeneratedLinks(t *testing.T) {
+	generated := []GeneratedFile{
+		{FilePath: "gen/users.pb.go", Generator: "protoc-gen-go", Source: "api/users.proto"},
+		{FilePath: "gen/users_grpc.pb.go", Generator: "protoc-gen-go-grpc", Source: "api/users.proto"},
+		{FilePath: "store/mocks/store.go", Generator: "MockGen", Source: "store/store.go"},
+	}
+	types := []TypeEntity{
+		{ID: "p1", Name: "User", Kind: "message", FilePath: "api/users.proto"},
+		{ID: "g1", Name: "User", Kind: "struct", FilePath: "gen/users.pb.go"},
+		{ID: "i1", Name: "Store", Kind: "interface", FilePath: "store/store.go"},
+		{ID: "i2", Name: "Store", Kind: "interface", FilePath: "cache/store.go"},
+		{ID: "m1", Name: "MockStore", Kind: "struct", FilePath: "store/mocks/store.go"},
+		{ID: "m2", Name: "MockStoreMockRecorder", Kind: "struct", FilePath: "store/mocks/store.go"},
+	}
+	functions := []FunctionEntity{
+		{ID: "rpc1", Name: "UserService.GetUser", FilePath: "api/users.proto"},
+		{ID: "f1", Name: "User.GetName", FilePath: "gen/users.pb.go"},
+		{ID: "f2", Name: "file_users_proto_init", FilePath: "gen/users.pb.go"},
+		{ID: "f3", Name: "userServiceClient.GetUser", FilePath: "gen/users_grpc.pb.go"},
+		{ID: "f4", Name: "UnimplementedUserServiceServer.GetUser", FilePath: "gen/users_grpc.pb.go"},
+		{ID: "f5", Name: "_UserService_GetUser_Handler", FilePath: "gen/users_grpc.pb.go"},
+		{ID: "f6", Name: "MockStore.Get", FilePath: "store/mocks/store.go"},
+		{ID: "f7", Name: "MockStoreMockRecorder.Get", FilePath: "store/mocks/store.go"},
+		{ID: "f8", Name: "NewMockStore", FilePath: "store/mocks/store.go"},
+		{ID: "h1", Name: "userServer.GetUser", FilePath: "internal/server.go"},
+	}
+
+	typeEdges := append(BuildGeneratedFromIndex(types), BuildGeneratedMockIndex(generated, types)...)
+	typeLinks := make(map[string]string)
+	for _, e := range typeEdges {
+		typeLinks[e.TypeID] = e.ProtoTypeID
+	}
+	assert.Equal(t, map[string]string{"g1": "p1", "m1": "i1"}, typeLinks, "the mock links to the Store in its source file")
+
+	funcLinks := make(map[string]string)
+	for _, l := range BuildGeneratedFuncIndex(generated, types, functions, typeEdges) {
+		funcLinks[l.FunctionID] = l.SourceKind + ":" + l.SourceID
+	}
+	assert.Equal(t, map[string]string{
+		"f1": "type:p1",
+		"f3": "function:rpc1",
+		"f4": "function:rpc1",
+		"f5": "function:rpc1",
+		"f6": "type:i1",
+		"f7": "type:i1",
+		"f8": "type:i1",
+	}, funcLinks, "init functions and hand-written servers stay unlinked")
+}
+
+func TestParseFile_RecordsGeneratedHeader(t *testing.T) {
+	result := parseFixture(t, "store/mocks/store.go", "go", `// Code generated by MockGen. DO NOT EDIT.
+// Source: store.go
+
+package mocks
+
+type MockStore struct{}
+
+func (m *MockStore) Get(key string) string { return "" }
+`)
+	require.NotNil(t, result.Generated)
+	assert.Equal(t, GeneratedFile{FilePath: "store/mocks/store.go", Generator: "MockGen", Source: "store.go"}, *result.Generated)
+	require.Len(t, result.Functions, 1)
+	assert.Equal(t, "MockStore.Get", result.Functions[0].Name)
+}
@@ -0,0 +1,246 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GoModule is a Go module found in the repository.
+type GoModule struct {
+	Path string // Module path from the module directive (e.g., "github.com/org/repo/v2")
+	Dir  string // Module root relative to the repository root ("." for the root module)
+}
+
+// DiscoverGoModules returns the modules declared by the go.mod files among
+// files, plus those listed in a go.work at rootPath. Modules replaced by a
+// local directory (replace directives) and modules vendored under a module's
+// vendor/ directory (vendor/modules.txt) are mapped to those directories too,
+// so calls into them resolve to the indexed code. Modules are ordered by
+// descending path length so the first prefix match is the most specific one.
+func DiscoverGoModules(rootPath string, files []FileInfo) []GoModule {
+	dirs := make(map[string]bool)
+	for _, f := range files {
+		if filepath.Base(f.Path) == "go.mod" {
+			dirs[filepath.ToSlash(filepath.Dir(f.Path))] = true
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(rootPath, "go.work")); err == nil {
+		for _, dir := range parseGoWorkUses(data) {
+			if !path.IsAbs(dir) && !strings.HasPrefix(dir, ".") {
+				dir = "./" + dir // go.work paths are always directories
+			}
+			if dir, ok := repoRelativeDir(".", dir); ok {
+				dirs[dir] = true
+			}
+		}
+	}
+
+	// Declared modules take precedence over replacements, which take
+	// precedence over vendored copies of the same module path.
+	seen := make(map[string]bool)
+	var modules, replaced, vendored []GoModule
+	for _, dir := range sortedKeys(dirs) {
+		data, err := os.ReadFile(filepath.Join(rootPath, filepath.FromSlash(dir), "go.mod"))
+		if err != nil {
+			continue
+		}
+		if modPath := parseModulePath(data); modPath != "" && !seen[modPath] {
+			seen[modPath] = true
+			modules = append(modules, GoModule{Path: modPath, Dir: dir})
+		}
+		for _, rep := range parseGoModReplaces(data) {
+			if target, ok := repoRelativeDir(dir, rep.dir); ok {
+				replaced = append(replaced, GoModule{Path: rep.modulePath, Dir: target})
+			}
+		}
+		vendorDir := path.Join(dir, "vendor")
+		if txt, err := os.ReadFile(filepath.Join(rootPath, filepath.FromSlash(vendorDir), "modules.txt")); err == nil {
+			for _, modPath := range parseVendorModules(txt) {
+				vendored = append(vendored, GoModule{Path: modPath, Dir: path.Join(vendorDir, modPath)})
+			}
+		}
+	}
+	for _, m := range append(replaced, vendored...) {
+		if !seen[m.Path] {
+			seen[m.Path] = true
+			modules = append(modules, m)
+		}
+	}
+
+	sort.SliceStable(modules, func(i, j int) bool {
+		return len(modules[i].Path) > len(modules[j].Path)
+	})
+	return modules
+}
+
+// repoRelativeDir resolves a directory from a go.mod or go.work (relative to
+// the module at base) to a repository-relative path. It reports false for
+// module paths and for directories outside the repository.
+func repoRelativeDir(base, dir string) (string, bool) {
+	if dir != "." && dir != ".." && !strings.HasPrefix(dir, "./") && !strings.HasPrefix(dir, "../") {
+		return "", false
+	}
+	joined := path.Join(base, dir)
+	if joined == ".." || strings.HasPrefix(joined, "../") {
+		return "", false
+	}
+	return joined, true
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parseModulePath extracts the module path from go.mod content.
+func parseModulePath(data []byte) string {
+	for _, fields := range goModLines(data) {
+		if len(fields) >= 2 && fields[0] == "module" {
+			return unquoteGoModToken(fields[1])
+		}
+	}
+	return ""
+}
+
+// parseGoWorkUses extracts the directories of use directives, in both the
+// single-line and the block form, from go.work content.
+func parseGoWorkUses(data []byte) []string {
+	var dirs []string
+	inBlock := false
+	for _, fields := range goModLines(data) {
+		switch {
+		case inBlock && fields[0] == ")":
+			inBlock = false
+		case inBlock:
+			dirs = append(dirs, unquoteGoModToken(fields[0]))
+		case fields[0] == "use" && len(fields) >= 2:
+			if fields[1] == "(" {
+				inBlock = true
+			} else {
+				dirs = append(dirs, unquoteGoModToken(fields[1]))
+			}
+		}
+	}
+	return dirs
+}
+
+// goModReplace is a replace directive whose target is a directory.
+type goModReplace struct {
+	modulePath string // Replaced module path (left-hand side)
+	dir        string // Replacement as written (e.g., "../lib"); module paths included
+}
+
+// parseGoModReplaces extracts the replace directives, in both the single-line
+// and the block form, from go.mod content.
+func parseGoModReplaces(data []byte) []goModReplace {
+	var reps []goModReplace
+	inBlock := false
+	add := func(fields []string) {
+		for i, f := range fields {
+			if f == "=>" && i > 0 && i+1 < len(fields) {
+				reps = append(reps, goModReplace{
+					modulePath: unquoteGoModToken(fields[0]),
+					dir:        unquoteGoModToken(fields[i+1]),
+				})
+				return
+			}
+		}
+	}
+	for _, fields := range goModLines(data) {
+		switch {
+		case inBlock && fields[0] == ")":
+			inBlock = false
+		case inBlock:
+			add(fields)
+		case fields[0] == "replace" && len(fields) >= 2:
+			if fields[1] == "(" {
+				inBlock = true
+			} else {
+				add(fields[1:])
+			}
+		}
+	}
+	return reps
+}
+
+// parseVendorModules extracts the module paths listed in vendor/modules.txt
+// ("# github.com/org/lib v1.2.0" lines).
+func parseVendorModules(data []byte) []string {
+	var paths []string
+	for _, fields := range goModLines(data) {
+		if len(fields) >= 2 && fields[0] == "#" {
+			paths = append(paths, fields[1])
+		}
+	}
+	return paths
+}
+
+// goModLines splits go.mod/go.work content into the fields of each
+// non-empty line, with // comments removed.
+func goModLines(data []byte) [][]string {
+	var lines [][]string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		if fields := strings.Fields(line); len(fields) > 0 {
+			lines = append(lines, fields)
+		}
+	}
+	return lines
+}
+
+// unquoteGoModToken strips the quotes of a quoted go.mod token.
+func unquoteGoModToken(tok string) string {
+	if unquoted, err := strconv.Unquote(tok); err == nil {
+		return unquoted
+	}
+	return tok
+}
+
+// modulePackageDir maps importPath to a repository-relative package
+// directory using modules. ok reports whether the import belongs to one of
+// the modules, even when the package directory itself is not indexed.
+func modulePackageDir(modules []GoModule, importPath string) (dir string, ok bool) {
+	for _, m := range modules {
+		if importPath == m.Path {
+			return filepath.FromSlash(m.Dir), true
+		}
+		if strings.HasPrefix(importPath, m.Path+"/") {
+			rel := strings.TrimPrefix(importPath, m.Path+"/")
+			return filepath.FromSlash(path.Join(m.Dir, rel)), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,122 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package ingestion
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiscoverGoModules(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		full := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "// root module\nmodule github.com/org/app/v2 // v2 line\n\ngo 1.22\n")
+	write("tools/go.mod", "module \"github.com/org/app/v2/tools\"\n")
+	write("svc/go.mod", "module github.com/org/svc\n")
+	write("go.work", "go 1.22\n\nuse (\n\t.\n\t./svc // service\n)\nuse ../outside\n")
+
+	// svc/go.mod is not among the files: it is only found through go.work.
+	files := []FileInfo{{Path: "go.mod"}, {Path: filepath.Join("tools", "go.mod")}, {Path: "main.go"}}
+
+	got := DiscoverGoModules(root, files)
+	want := []GoModule{
+		{Path: "github.com/org/app/v2/tools", Dir: "tools"},
+		{Path: "github.com/org/app/v2", Dir: "."},
+		{Path: "github.com/org/svc", Dir: "svc"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiscoverGoModules() = %+v, want %+v", got, want)
+	}
+}
+
+func TestModulePackageDir(t *testing.T) {
+	modules := []GoModule{
+		{Path: "github.com/org/app/v2/tools", Dir: "tools"},
+		{Path: "github.com/org/app/v2", Dir: "."},
+	}
+	tests := []struct {
+		importPath string
+		wantDir    string
+		wantOK     bool
+	}{
+		{"github.com/org/app/v2", ".", true},
+		{"github.com/org/app/v2/internal/util", filepath.Join("internal", "util"), true},
+		{"github.com/org/app/v2/tools/gen", filepath.Join("tools", "gen"), true},
+		{"github.com/org/app/internal/util", "", false},
+		{"github.com/org/app/v20", "", false},
+		{"fmt", "", false},
+	}
+	for _, tt := range tests {
+		dir, ok := modulePackageDir(modules, tt.importPath)
+		if dir != tt.wantDir || ok != tt.wantOK {
+			t.Errorf("modulePackageDir(%q) = %q, %v; want %q, %v", tt.importPath, dir, ok, tt.wantDir, tt.wantOK)
+		}
+	}
+}
+
+func TestDiscoverGoModules_ReplaceAndVendor(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		full := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("app/go.mod", `module example.com/app
+
+replace example.com/lib => ../lib
+
+replace (
+	example.com/tools v1.2.0 => ./third_party/tools // fork
+	example.com/remote => example.com/fork v1.0.0
+	example.com/outside => ../../outside
+)
+`)
+	write("lib/go.mod", "module example.com/lib/v3\n")
+	write("app/vendor/modules.txt", "# example.com/dep v1.4.0\n## explicit\nexample.com/dep/sub\n# example.com/lib v0.0.0 => ../lib\n")
+
+	files := []FileInfo{{Path: filepath.Join("app", "go.mod")}, {Path: filepath.Join("lib", "go.mod")}}
+	got := DiscoverGoModules(root, files)
+	want := []GoModule{
+		{Path: "example.com/lib/v3", Dir: "lib"},
+		{Path: "example.com/tools", Dir: "app/third_party/tools"},
+		{Path: "example.com/app", Dir: "app"},
+		{Path: "example.com/lib", Dir: "lib"},
+		{Path: "example.com/dep", Dir: "app/vendor/example.com/dep"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiscoverGoModules() = %+v, want %+v", got, want)
+	}
+}
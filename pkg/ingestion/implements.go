@@ -29,7 +29,7 @@ var stdlibInterfaceMethods = map[string][]string{
 
 // BuildImplementsIndex determines which concrete types implement which interfaces
 // by matching method sets. A concrete type implements an interface if it has all
-// methods declared by that interface.
+// methods declared by that interface and is written in the same language.
 func BuildImplementsIndex(types []TypeEntity, functions []FunctionEntity) []ImplementsEdge {
 	// 1. Collect interfaces and their required methods
 	interfaces := extractInterfaceMethods(types)
@@ -49,16 +49,17 @@ func BuildImplementsIndex(types []TypeEntity, functions []FunctionEntity) []Impl
 		if len(iface.methods) == 0 {
 			continue
 		}
-		for typeName, methods := range typeMethods {
-			// Skip self-match: interface doesn't implement itself
-			if interfaceNames[typeName] {
+		for key, methods := range typeMethods {
+			// Skip self-match: interface doesn't implement itself. An interface
+			// without a known language (no file path) matches any type.
+			if (iface.language != "" && key.language != iface.language) || interfaceNames[key.name] {
 				continue
 			}
 			if hasAllMethods(methods, iface.methods) {
 				edges = append(edges, ImplementsEdge{
-					TypeName:      typeName,
+					TypeName:      key.name,
 					InterfaceName: iface.name,
-					FilePath:      typeFilePath(typeName, functions),
+					FilePath:      typeFilePath(key, functions),
 				})
 			}
 		}
@@ -68,8 +69,9 @@ func BuildImplementsIndex(types []TypeEntity, functions []FunctionEntity) []Impl
 }
 
 type interfaceInfo struct {
-	name    string
-	methods []string
+	name     string
+	language string
+	methods  []string
 }
 
 // extractInterfaceMethods extracts method names from interface type definitions.
@@ -83,6 +85,10 @@ func extractInterfaceMethods(types []TypeEntity) []interfaceInfo {
 		if t.Kind != "interface" {
 			continue
 		}
+		if isPythonFile(t.FilePath) {
+			directMethods[t.Name] = pythonInterfaceMethods(t.CodeText)
+			continue
+		}
 		methods := interfaceMethodPattern.FindAllStringSubmatch(t.CodeText, -1)
 		var names []string
 		for _, m := range methods {
@@ -105,8 +111,9 @@ func extractInterfaceMethods(types []TypeEntity) []interfaceInfo {
 			methodList = append(methodList, m)
 		}
 		result = append(result, interfaceInfo{
-			name:    t.Name,
-			methods: methodList,
+			name:     t.Name,
+			language: detectLanguageFromPath(t.FilePath),
+			methods:  methodList,
 		})
 	}
 
@@ -121,6 +128,18 @@ func collectAllMethods(t TypeEntity, directMethods map[string][]string) map[stri
 		allMethods[m] = true
 	}
 
+	if isPythonFile(t.FilePath) {
+		// Protocols extend other protocols through their base classes
+		for _, base := range pythonBaseClasses(t.CodeText) {
+			if methods, ok := directMethods[stripPackagePrefix(base)]; ok {
+				for _, m := range methods {
+					allMethods[m] = true
+				}
+			}
+		}
+		return allMethods
+	}
+
 	embeds := embeddedInterfacePattern.FindAllStringSubmatch(t.CodeText, -1)
 	for _, embed := range embeds {
 		baseName := stripPackagePrefix(embed[1])
@@ -129,6 +148,76 @@ func collectAllMethods(t TypeEntity, directMethods map[string][]string) map[stri
 	return allMethods
 }
 
+// pythonMethodPattern matches method definitions in a Python class body,
+// capturing the decorator lines just above each one.
+var pythonMethodPattern = regexp.MustCompile(`(?m)((?:^[ \t]+@[^\n]*\n)*)^[ \t]+(?:async[ \t]+)?def[ \t]+([A-Za-z_]\w*)[ \t]*\(`)
+
+// isPythonFile reports whether path is a Python module or stub.
+func isPythonFile(path string) bool {
+	return strings.HasSuffix(path, ".py") || strings.HasSuffix(path, ".pyi")
+}
+
+// pythonInterfaceMethods returns the methods a Protocol or ABC requires:
+// every public method of a Protocol, and the @abstractmethod ones of an ABC
+// (its concrete methods are inherited, not reimplemented).
+func pythonInterfaceMethods(code string) []string {
+	abstractOnly := true
+	for _, base := range pythonBaseClasses(code) {
+		if stripPackagePrefix(base) == "Protocol" {
+			abstractOnly = false
+		}
+	}
+
+	var names []string
+	for _, m := range pythonMethodPattern.FindAllStringSubmatch(code, -1) {
+		decorators, name := m[1], m[2]
+		if strings.HasPrefix(name, "_") {
+			continue
+		}
+		if abstractOnly && !strings.Contains(decorators, "abstractmethod") {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// pythonBaseClasses returns the base classes in a class header like
+// "class Repo(Protocol[T], Base, metaclass=ABCMeta):", without type
+// arguments and keyword arguments.
+func pythonBaseClasses(code string) []string {
+	open := strings.IndexAny(code, "(:")
+	if open == -1 || code[open] != '(' {
+		return nil // "class Name:" has no bases
+	}
+	var bases []string
+	depth, start := 0, open+1
+	for i := open + 1; i < len(code) && depth >= 0; i++ {
+		switch code[i] {
+		case '(', '[':
+			depth++
+		case ']':
+			depth--
+		case ')', ',':
+			if code[i] == ')' {
+				depth--
+			}
+			if depth > 0 || (depth == 0 && code[i] == ')') {
+				continue
+			}
+			base := strings.TrimSpace(code[start:i])
+			if j := strings.Index(base, "["); j >= 0 {
+				base = base[:j]
+			}
+			if base != "" && !strings.Contains(base, "=") {
+				bases = append(bases, base)
+			}
+			start = i + 1
+		}
+	}
+	return bases
+}
+
 // stripPackagePrefix removes a package qualifier from a type reference.
 // "io.Reader" → "Reader", "Writer" → "Writer".
 func stripPackagePrefix(ref string) string {
@@ -154,23 +243,30 @@ func inheritMethods(target map[string]bool, name string, directMethods map[strin
 	}
 }
 
+// methodSetKey identifies a concrete type by language and name, so a Go
+// struct and a Python class of the same name keep separate method sets.
+type methodSetKey struct {
+	language string
+	name     string
+}
+
 // buildTypeMethodSets builds a map of concrete type → set of method names
 // from function entities with receiver syntax (e.g., "CozoDB.Write").
-func buildTypeMethodSets(functions []FunctionEntity) map[string]map[string]bool {
-	typeMethods := make(map[string]map[string]bool)
+func buildTypeMethodSets(functions []FunctionEntity) map[methodSetKey]map[string]bool {
+	typeMethods := make(map[methodSetKey]map[string]bool)
 
 	for _, fn := range functions {
 		if !strings.Contains(fn.Name, ".") {
 			continue
 		}
 		parts := strings.SplitN(fn.Name, ".", 2)
-		typeName := parts[0]
+		key := methodSetKey{language: detectLanguageFromPath(fn.FilePath), name: parts[0]}
 		methodName := parts[1]
 
-		if typeMethods[typeName] == nil {
-			typeMethods[typeName] = make(map[string]bool)
+		if typeMethods[key] == nil {
+			typeMethods[key] = make(map[string]bool)
 		}
-		typeMethods[typeName][methodName] = true
+		typeMethods[key][methodName] = true
 	}
 
 	return typeMethods
@@ -187,10 +283,10 @@ func hasAllMethods(methods map[string]bool, required []string) bool {
 }
 
 // typeFilePath finds the file path for a concrete type from its methods.
-func typeFilePath(typeName string, functions []FunctionEntity) string {
-	prefix := typeName + "."
+func typeFilePath(key methodSetKey, functions []FunctionEntity) string {
+	prefix := key.name + "."
 	for _, fn := range functions {
-		if strings.HasPrefix(fn.Name, prefix) {
+		if strings.HasPrefix(fn.Name, prefix) && detectLanguageFromPath(fn.FilePath) == key.language {
 			return fn.FilePath
 		}
 	}
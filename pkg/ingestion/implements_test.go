@@ -203,3 +203,70 @@ func TestBuildImplementsIndex_MultipleInterfaces(t *testing.T) {
 	assert.True(t, ifaceMap["Writer"])
 	assert.True(t, ifaceMap["Flusher"])
 }
+
+func TestBuildImplementsIndex_PythonProtocolAndABC(t *testing.T) {
+	types := []TypeEntity{
+		{
+			Name:     "Repository",
+			Kind:     "interface",
+			FilePath: "app/ports.py",
+			CodeText: "class Repository(Protocol):\n    def save(self, item: str) -> None: ...\n\n    def _hook(self) -> None: ...\n",
+		},
+		{
+			Name:     "AuditedRepository",
+			Kind:     "interface",
+			FilePath: "app/ports.py",
+			CodeText: "class AuditedRepository(Repository, Protocol):\n    async def audit(self) -> None: ...\n",
+		},
+		{
+			Name:     "Notifier",
+			Kind:     "interface",
+			FilePath: "app/ports.pyi",
+			CodeText: "class Notifier(metaclass=ABCMeta):\n    @abstractmethod\n    def notify(self) -> None: ...\n    def close(self) -> None: ...\n",
+		},
+	}
+	functions := []FunctionEntity{
+		{Name: "SqlRepository.save", FilePath: "app/sql.py"},
+		{Name: "SqlRepository.audit", FilePath: "app/sql.py"},
+		{Name: "MemoryRepository.save", FilePath: "app/memory.py"},
+		{Name: "EmailNotifier.notify", FilePath: "app/email.py"},
+	}
+
+	implements := make(map[string][]string)
+	for _, e := range BuildImplementsIndex(types, functions) {
+		implements[e.InterfaceName] = append(implements[e.InterfaceName], e.TypeName)
+	}
+	assert.ElementsMatch(t, []string{"SqlRepository", "MemoryRepository"}, implements["Repository"])
+	assert.ElementsMatch(t, []string{"SqlRepository"}, implements["AuditedRepository"], "inherits save from Repository")
+	assert.ElementsMatch(t, []string{"EmailNotifier"}, implements["Notifier"], "only abstract methods are required")
+}
+
+func TestBuildImplementsIndex_SameLanguageOnly(t *testing.T) {
+	types := []TypeEntity{
+		{
+			Name:     "Repository",
+			Kind:     "interface",
+			FilePath: "app/ports.py",
+			CodeText: "class Repository(Protocol):\n    def save(self, item: str) -> None: ...\n",
+		},
+		{
+			Name:     "Saver",
+			Kind:     "interface",
+			FilePath: "store/saver.go",
+			CodeText: "Saver interface {\n\tSave(item string) error\n}",
+		},
+	}
+	functions := []FunctionEntity{
+		{Name: "Memory.Save", FilePath: "store/memory.go"},
+		{Name: "Memory.save", FilePath: "store/memory.go"},
+		{Name: "SqlRepository.save", FilePath: "app/sql.py"},
+		{Name: "SqlRepository.Save", FilePath: "app/sql.py"},
+	}
+
+	implements := make(map[string][]string)
+	for _, e := range BuildImplementsIndex(types, functions) {
+		implements[e.InterfaceName] = append(implements[e.InterfaceName], e.TypeName)
+	}
+	assert.ElementsMatch(t, []string{"SqlRepository"}, implements["Repository"], "Go types never implement Python protocols")
+	assert.ElementsMatch(t, []string{"Memory"}, implements["Saver"], "Python classes never implement Go interfaces")
+}
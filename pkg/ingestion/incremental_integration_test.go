@@ -411,6 +411,77 @@ func main() {
 	t.Log("No-changes test passed!")
 }
 
+// TestIncrementalIndexing_LineEndingsOnly tests that a commit converting a
+// file to CRLF does not re-parse it under the default content_hash mode.
+func TestIncrementalIndexing_LineEndingsOnly(t *testing.T) {
+	testDir := t.TempDir()
+	repoDir := filepath.Join(testDir, "testrepo")
+	dataDir := filepath.Join(testDir, "data")
+
+	if err := os.MkdirAll(repoDir, 0755); err != nil {
+		t.Fatalf("failed to create repo dir: %v", err)
+	}
+
+	runGit(t, repoDir, "init")
+	runGit(t, repoDir, "config", "user.email", "test@example.com")
+	runGit(t, repoDir, "config", "user.name", "Test User")
+	runGit(t, repoDir, "config", "core.autocrlf", "false")
+
+	writeFile(t, filepath.Join(repoDir, "main.go"), "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "Initial commit")
+
+	cfg := Config{
+		ProjectID:  "test-line-endings",
+		RepoSource: RepoSource{Type: "local_path", Value: repoDir},
+		IngestionConfig: IngestionConfig{
+			LocalDataDir:        dataDir,
+			LocalEngine:         "mem",
+			EmbeddingProvider:   "mock",
+			EmbeddingDimensions: 384,
+			MaxFileSizeBytes:    1048576,
+			ExcludeGlobs:        []string{".git/**"},
+			Concurrency: ConcurrencyConfig{
+				ParseWorkers: 2,
+				EmbedWorkers: 2,
+			},
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+
+	pipeline, err := NewLocalPipeline(cfg, logger)
+	if err != nil {
+		t.Fatalf("failed to create pipeline: %v", err)
+	}
+	defer pipeline.Close()
+
+	if _, err := pipeline.Run(ctx); err != nil {
+		t.Fatalf("first run failed: %v", err)
+	}
+
+	writeFile(t, filepath.Join(repoDir, "main.go"), "package main\r\n\r\nfunc main() {\r\n\tprintln(\"hello\")\r\n}\r\n")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "Convert to CRLF")
+
+	result, err := pipeline.Run(ctx)
+	if err != nil {
+		t.Fatalf("second run failed: %v", err)
+	}
+	if result.FilesProcessed != 0 {
+		t.Errorf("expected 0 files processed after a line-ending-only change, got %d", result.FilesProcessed)
+	}
+
+	funcResult, err := pipeline.backend.Query(ctx, `?[name] := *cie_function{name}`)
+	if err != nil {
+		t.Fatalf("failed to query functions: %v", err)
+	}
+	if len(funcResult.Rows) != 1 {
+		t.Errorf("expected 1 function in database, got %d", len(funcResult.Rows))
+	}
+}
+
 // TestIncrementalIndexing_ForceReindex tests that ForceReindex=true forces full indexing.
 func TestIncrementalIndexing_ForceReindex(t *testing.T) {
 	// Create a temporary directory for the test repo
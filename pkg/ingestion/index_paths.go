@@ -0,0 +1,138 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// IndexPaths re-indexes the given files from the working tree, for callers
+// that learn about edits before they are committed (`cie watch`). Paths are
+// relative to the repository root or absolute inside it. Files that exist
+// are parsed and written again; entities of files that no longer exist are
+// deleted, including every indexed file below a removed directory.
+//
+// Unlike Run it consults neither git nor the last indexed commit, and leaves
+// that commit as it is, so the next `cie index` still catches up on
+// everything committed since.
+func (p *LocalPipeline) IndexPaths(ctx context.Context, paths []string) (*IngestionResult, error) {
+	startTime := time.Now()
+	runID := p.generateRunID(startTime)
+
+	loadResult, err := p.repoLoader.LoadRepository(
+		p.config.RepoSource,
+		p.config.IngestionConfig.ExcludeGlobs,
+		p.config.IngestionConfig.MaxFileSizeBytes,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("load repository: %w", err)
+	}
+
+	delta := pathDelta(loadResult.RootPath, paths)
+	delta.Deleted = p.expandDeletedDirs(ctx, delta.Deleted)
+	delta = FilterDeltaWithLimits(delta, p.config.IngestionConfig.ExcludeGlobs, p.config.IngestionConfig.MaxFileSizeBytes,
+		p.config.IngestionConfig.LanguageLimits, loadResult.RootPath)
+	delta.Modified = p.dropUnchangedFiles(ctx, loadResult.RootPath, delta.Modified)
+	if !delta.HasChanges() {
+		return &IngestionResult{
+			ProjectID:     p.config.ProjectID,
+			RunID:         runID,
+			TotalDuration: time.Since(startTime),
+		}, nil
+	}
+	p.logger.Info("local.ingestion.paths",
+		"modified", len(delta.Modified),
+		"deleted", len(delta.Deleted),
+	)
+
+	incCtx := &incrementalContext{
+		runID:     runID,
+		startTime: startTime,
+		delta:     delta,
+		goModules: loadResult.GoModules,
+	}
+	p.processIncrementalDeletions(delta)
+	changedFiles := p.getFilesToProcess(delta, loadResult.Files)
+	if len(changedFiles) == 0 {
+		p.bumpIndexVersion()
+		return p.handleDeletionsOnly(incCtx, len(delta.Deleted))
+	}
+	return p.processIncrementalFiles(ctx, incCtx, changedFiles)
+}
+
+// pathDelta sorts paths under root into files that exist (Modified) and
+// paths that are gone (Deleted), as repository-relative slash paths.
+// Directories and paths outside root are dropped.
+func pathDelta(root string, paths []string) *GitDelta {
+	delta := &GitDelta{Renamed: make(map[string]string)}
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		full := path
+		if !filepath.IsAbs(full) {
+			full = filepath.Join(root, full)
+		}
+		rel, err := filepath.Rel(root, full)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if seen[rel] {
+			continue
+		}
+		seen[rel] = true
+
+		info, err := os.Lstat(full)
+		switch {
+		case os.IsNotExist(err):
+			delta.Deleted = append(delta.Deleted, rel)
+		case err == nil && !info.IsDir():
+			delta.Modified = append(delta.Modified, rel)
+		}
+	}
+	sort.Strings(delta.Modified)
+	sort.Strings(delta.Deleted)
+	return delta
+}
+
+// expandDeletedDirs adds the indexed files below each deleted path, which
+// may have been a directory.
+func (p *LocalPipeline) expandDeletedDirs(ctx context.Context, deleted []string) []string {
+	expanded := deleted
+	for _, path := range deleted {
+		result, err := p.backend.QueryWithParams(ctx,
+			`?[path] := *cie_file { path }, starts_with(path, $prefix)`,
+			map[string]any{"prefix": path + "/"})
+		if err != nil {
+			p.logger.Warn("local.ingestion.paths.expand.error", "path", path, "err", err)
+			continue
+		}
+		for _, row := range result.Rows {
+			if file, ok := row[0].(string); ok {
+				expanded = append(expanded, file)
+			}
+		}
+	}
+	return expanded
+}
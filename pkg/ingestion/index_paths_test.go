@@ -0,0 +1,56 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathDelta(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "pkg", "api"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "pkg", "api", "server.go"), []byte("package api\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644))
+
+	delta := pathDelta(root, []string{
+		filepath.Join(root, "pkg", "api", "server.go"),
+		"main.go",
+		"main.go",                       // duplicate event
+		filepath.Join(root, "pkg"),      // directory
+		filepath.Join(root, "gone.go"),  // removed
+		filepath.Join(root, "..", "x"),  // outside the repository
+		filepath.Join(root, "old", "d"), // removed directory
+	})
+
+	assert.Equal(t, []string{"main.go", "pkg/api/server.go"}, delta.Modified)
+	assert.Equal(t, []string{"gone.go", "old/d"}, delta.Deleted)
+}
+
+func TestExcludedPath(t *testing.T) {
+	globs := DefaultConfig().ExcludeGlobs
+	assert.True(t, ExcludedPath(".git", globs))
+	assert.True(t, ExcludedPath("web/node_modules", globs))
+	assert.True(t, ExcludedPath("main.go.swp", globs))
+	assert.False(t, ExcludedPath("pkg/api", globs))
+}
@@ -20,16 +20,23 @@
 package ingestion
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"log/slog"
+	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/kraklabs/cie/pkg/embedprefix"
+	"github.com/kraklabs/cie/pkg/scripting"
 	"github.com/kraklabs/cie/pkg/storage"
 )
 
@@ -48,10 +55,15 @@ type LocalPipeline struct {
 	repoLoader    *RepoLoader
 	parser        CodeParser
 	embeddingGen  *EmbeddingGenerator
+	embedCache    *cachedEmbeddingProvider // nil when the shared cache is off
+	docPrefix     string                   // Prefix the provider puts in front of documents
+	hashMode      ContentHashMode          // Normalization behind cie_file.hash
+	cacheStore    *storage.EmbeddingCache
 	backend       *storage.EmbeddedBackend
 	checkpointMgr *CheckpointManager
 	datalogBuild  *DatalogBuilder
 	onProgress    ProgressCallback // Optional callback for progress reporting
+	profiler      *Profiler        // Optional; nil unless profiling is enabled
 }
 
 // IngestionResult summarizes the ingestion run.
@@ -95,6 +107,10 @@ type IngestionResult struct {
 	// EmbeddingErrors is the number of functions/types that failed embedding generation.
 	EmbeddingErrors int
 
+	// EmbeddingCacheHits is the number of embeddings reused from the shared
+	// cache instead of being requested from the provider.
+	EmbeddingCacheHits int
+
 	// CodeTextTruncated is the number of functions whose code was truncated due to size limits.
 	CodeTextTruncated int
 
@@ -112,6 +128,10 @@ type IngestionResult struct {
 
 	// TotalDuration is the total time for the entire ingestion run.
 	TotalDuration time.Duration
+
+	// ScriptMetrics holds the metrics reported by AnalysisScripts, keyed
+	// "<script>.<name>" with the script's file name minus extension.
+	ScriptMetrics map[string]float64
 }
 
 // parseFilesResult holds the aggregated results from parallel parsing.
@@ -125,17 +145,65 @@ type parseFilesResult struct {
 	calls           []CallsEdge
 	imports         []ImportEntity
 	unresolvedCalls []UnresolvedCall
+	protoOptions    []ProtoOption
+	templates       []TemplateEntity
+	templateRefs    []TemplateRef
+	renders         []RenderCall
+	tableRefs       []TableRef
+	rpcClients      []RPCLink
+	generated       []GeneratedFile
+	ciJobs          []CIJob
+	ciSteps         []CIStep
+	ciRefs          []CIRef
 	packageNames    map[string]string
+	parseIssues     map[string]storage.ParseIssue // By file path: files that failed or had syntax errors
+}
+
+// addParseIssue records file in r.parseIssues when it failed to parse
+// (err != nil) or parsed with syntax errors.
+func (r *parseFilesResult) addParseIssue(file FileInfo, pr *ParseResult, err error) {
+	switch {
+	case err != nil:
+		r.parseIssues[file.Path] = storage.ParseIssue{Language: file.Language, Failed: true}
+	case pr.SyntaxErrors > 0:
+		r.parseIssues[file.Path] = storage.ParseIssue{Language: file.Language, SyntaxErrors: pr.SyntaxErrors}
+	}
 }
 
 // NewLocalPipeline creates a new local ingestion pipeline.
 func NewLocalPipeline(config Config, logger *slog.Logger) (*LocalPipeline, error) {
+	return newLocalPipeline(config, logger, func() (*storage.EmbeddedBackend, error) {
+		return storage.NewEmbeddedBackend(storage.EmbeddedConfig{
+			DataDir:             config.IngestionConfig.LocalDataDir,
+			Engine:              config.IngestionConfig.LocalEngine,
+			ProjectID:           config.ProjectID,
+			EmbeddingDimensions: config.IngestionConfig.EmbeddingDimensions,
+			HNSWDistance:        config.IngestionConfig.HNSWDistance,
+			Namespace:           config.IngestionConfig.LocalNamespace,
+			Sharded:             config.IngestionConfig.LocalSharded,
+		})
+	})
+}
+
+// NewLocalPipelineWithBackend creates a pipeline that writes through an
+// already open backend instead of opening the database itself, so a
+// long-running process can reindex while it keeps serving queries from the
+// same database. Use storage.NewEmbeddedBackendFromDB for a backend that
+// the pipeline's Close leaves open.
+func NewLocalPipelineWithBackend(config Config, backend *storage.EmbeddedBackend, logger *slog.Logger) (*LocalPipeline, error) {
+	return newLocalPipeline(config, logger, func() (*storage.EmbeddedBackend, error) {
+		return backend, nil
+	})
+}
+
+func newLocalPipeline(config Config, logger *slog.Logger, openBackend func() (*storage.EmbeddedBackend, error)) (*LocalPipeline, error) {
 	if logger == nil {
 		logger = slog.Default()
 	}
 
 	// Create components
 	repoLoader := NewRepoLoader(logger)
+	repoLoader.SetLanguageLimits(config.IngestionConfig.LanguageLimits)
 
 	// Create parser based on mode
 	var parser CodeParser
@@ -172,28 +240,63 @@ func NewLocalPipeline(config Config, logger *slog.Logger) (*LocalPipeline, error
 	if config.IngestionConfig.MaxCodeTextBytes > 0 {
 		parser.SetMaxCodeTextSize(config.IngestionConfig.MaxCodeTextBytes)
 	}
+	for language, limits := range config.IngestionConfig.LanguageLimits {
+		if limits.MaxCodeTextBytes > 0 {
+			parser.SetLanguageMaxCodeTextSize(language, limits.MaxCodeTextBytes)
+		}
+	}
+	hashMode := config.IngestionConfig.ContentHashMode
+	if !ValidContentHashMode(hashMode) {
+		logger.Warn("content_hash.mode.unknown", "mode", hashMode, "fallback", ContentHashLineEndings)
+		hashMode = ContentHashLineEndings
+	}
+	parser.SetContentHashMode(hashMode)
 
 	// Create embedding provider
 	embeddingProvider, err := CreateEmbeddingProvider(config.IngestionConfig.EmbeddingProvider, logger)
 	if err != nil {
 		return nil, fmt.Errorf("create embedding provider: %w", err)
 	}
+	docPrefix := ""
+	if prefixer, ok := embeddingProvider.(DocumentPrefixer); ok {
+		if prefix := config.IngestionConfig.EmbeddingDocumentPrefix; prefix != nil {
+			prefixer.SetDocumentPrefix(*prefix)
+		}
+		docPrefix = prefixer.DocumentPrefix()
+	}
+	var cached *cachedEmbeddingProvider
+	var cache *storage.EmbeddingCache
+	if path := config.IngestionConfig.EmbeddingCachePath; path != "" {
+		// The cache only saves work; index without it rather than fail.
+		// Providers prepend the document prefix inside Embed, so the key
+		// must name it: the same text embeds differently under another one.
+		modelKey := config.IngestionConfig.EmbeddingModelID + "/" + strconv.Quote(docPrefix)
+		cached, cache, err = newCachedEmbeddingProvider(path, modelKey, embeddingProvider, logger)
+		if err != nil {
+			logger.Warn("embedding.cache.open.error", "path", path, "err", err)
+		} else {
+			logger.Info("embedding.cache.enabled", "path", path, "model", config.IngestionConfig.EmbeddingModelID)
+			embeddingProvider = cached
+		}
+	}
 	embeddingGen := NewEmbeddingGenerator(embeddingProvider, config.IngestionConfig.Concurrency.EmbedWorkers, logger)
+	embeddingGen.SetNameEmbeddings(config.IngestionConfig.NameEmbeddings)
 
 	// Create local backend
-	backend, err := storage.NewEmbeddedBackend(storage.EmbeddedConfig{
-		DataDir:             config.IngestionConfig.LocalDataDir,
-		Engine:              config.IngestionConfig.LocalEngine,
-		ProjectID:           config.ProjectID,
-		EmbeddingDimensions: config.IngestionConfig.EmbeddingDimensions,
-	})
+	backend, err := openBackend()
 	if err != nil {
+		if cache != nil {
+			_ = cache.Close()
+		}
 		return nil, fmt.Errorf("create local backend: %w", err)
 	}
 
 	// Ensure schema exists
 	if err := backend.EnsureSchema(); err != nil {
 		_ = backend.Close()
+		if cache != nil {
+			_ = cache.Close()
+		}
 		return nil, fmt.Errorf("ensure schema: %w", err)
 	}
 
@@ -206,15 +309,22 @@ func NewLocalPipeline(config Config, logger *slog.Logger) (*LocalPipeline, error
 	// Checkpoint manager
 	checkpointMgr := NewCheckpointManager(config.IngestionConfig.CheckpointPath)
 
+	datalogBuild := NewDatalogBuilder()
+	datalogBuild.SetCompressCodeText(config.IngestionConfig.CompressCodeText)
+
 	return &LocalPipeline{
 		config:        config,
 		logger:        logger,
 		repoLoader:    repoLoader,
 		parser:        parser,
 		embeddingGen:  embeddingGen,
+		embedCache:    cached,
+		docPrefix:     docPrefix,
+		hashMode:      hashMode,
+		cacheStore:    cache,
 		backend:       backend,
 		checkpointMgr: checkpointMgr,
-		datalogBuild:  NewDatalogBuilder(),
+		datalogBuild:  datalogBuild,
 	}, nil
 }
 
@@ -231,6 +341,11 @@ func (p *LocalPipeline) Close() error {
 			lastErr = err
 		}
 	}
+	if p.cacheStore != nil {
+		if err := p.cacheStore.Close(); err != nil {
+			lastErr = err
+		}
+	}
 	return lastErr
 }
 
@@ -245,6 +360,15 @@ func (p *LocalPipeline) SetProgressCallback(cb ProgressCallback) {
 	}
 }
 
+// SetProfiler enables timing collection for the next run: stage durations,
+// per-file parse times and embedding call latency.
+func (p *LocalPipeline) SetProfiler(prof *Profiler) {
+	p.profiler = prof
+	if p.embeddingGen != nil && prof != nil {
+		p.embeddingGen.provider = &profiledEmbeddingProvider{EmbeddingProvider: p.embeddingGen.provider, profiler: prof}
+	}
+}
+
 // reportProgress safely calls the progress callback if set.
 func (p *LocalPipeline) reportProgress(current, total int64, phase string) {
 	if p.onProgress != nil {
@@ -273,17 +397,25 @@ func (p *LocalPipeline) Run(ctx context.Context) (*IngestionResult, error) {
 
 	// Step 1: Load repository
 	p.logger.Info("local.ingestion.step.load_repo", "run_id", runID)
+	endLoad := p.profiler.StartStage("load_repo")
 	loadResult, err := p.repoLoader.LoadRepository(
 		p.config.RepoSource,
 		p.config.IngestionConfig.ExcludeGlobs,
 		p.config.IngestionConfig.MaxFileSizeBytes,
 	)
+	endLoad()
 	if err != nil {
 		return nil, fmt.Errorf("load repository: %w", err)
 	}
 
-	// Check if incremental indexing is possible
-	if !p.config.IngestionConfig.ForceReindex {
+	// Rebuild selected shards, or check if incremental indexing is possible
+	if shards := p.config.IngestionConfig.ReindexShards; len(shards) > 0 {
+		if err := p.resetShards(shards); err != nil {
+			return nil, err
+		}
+		loadResult.Files = filesInShards(loadResult.Files, shards)
+		p.logger.Info("local.ingestion.shards.reindex", "shards", shards, "file_count", len(loadResult.Files))
+	} else if !p.config.IngestionConfig.ForceReindex {
 		result, err := p.tryIncrementalRun(ctx, loadResult, runID, startTime)
 		if err == nil && result != nil {
 			return result, nil
@@ -310,7 +442,26 @@ func (p *LocalPipeline) Run(ctx context.Context) (*IngestionResult, error) {
 		parseWorkers = 4
 	}
 
-	parseResult, parseErrors := p.parseFilesParallel(ctx, loadResult.Files, parseWorkers)
+	// Queued writes take each file as soon as it is parsed and embedded;
+	// bulk imports wait for the whole run
+	var writes *writeQueue
+	var stream *fileStream
+	var onParsed func(*ParseResult)
+	if p.config.IngestionConfig.ImportBatchFiles < 0 {
+		writes = p.startWriter(ctx, true)
+		defer writes.stop()
+		stream = p.startFileStream(writes)
+		onParsed = stream.add
+	}
+
+	endParse := p.profiler.StartStage("parse")
+	parseResult, parseErrors := p.parseFilesParallel(ctx, loadResult.Files, parseWorkers, onParsed)
+	endParse()
+	if stream != nil {
+		if err := stream.close(); err != nil {
+			return nil, err
+		}
+	}
 
 	parseDuration := time.Since(parseStart)
 	codeTextTruncated := p.parser.GetTruncatedCount()
@@ -328,29 +479,44 @@ func (p *LocalPipeline) Run(ctx context.Context) (*IngestionResult, error) {
 	// Step 2b: Build implements index and resolve cross-package calls
 	allFields := parseResult.fields
 	allImplements := BuildImplementsIndex(allTypes, allFunctions)
+	allContains := BuildContainsIndex(allTypes, allFunctions)
+	allMethodOf := BuildMethodOfIndex(allTypes, allFunctions)
+	allGenerated := ResolveGeneratedSources(parseResult.generated, allFiles)
+	allGeneratedFrom := append(BuildGeneratedFromIndex(allTypes), BuildGeneratedMockIndex(allGenerated, allTypes)...)
+	allGeneratedFunc := BuildGeneratedFuncIndex(allGenerated, allTypes, allFunctions, allGeneratedFrom)
+	allEntryPoints := BuildEntryPointIndex(allFunctions)
+	allRPCLinks := BuildRPCLinkIndex(allTypes, allFunctions, parseResult.rpcClients)
 
 	p.logger.Info("local.ingestion.interface_dispatch",
 		"fields", len(allFields),
 		"implements", len(allImplements),
 	)
 
+	var stillUnresolved []UnresolvedCall
+	var resolvedCalls []CallsEdge
+	var stubFunctions []FunctionEntity
 	if len(allUnresolvedCalls) > 0 {
+		endResolve := p.profiler.StartStage("resolve_calls")
 		resolver := NewCallResolver()
 		resolver.BuildIndex(allFiles, allFunctions, allImports, packageNames)
+		resolver.SetGoModules(loadResult.GoModules)
 		resolver.SetInterfaceIndex(allFields, allImplements)
-		resolvedCalls := resolver.ResolveCalls(allUnresolvedCalls)
+		resolvedCalls = resolver.ResolveCalls(allUnresolvedCalls)
 		allCalls = append(allCalls, resolvedCalls...)
+		stillUnresolved = resolver.UnresolvedCalls()
 
 		// Collect synthetic stubs for external type methods
-		stubFunctions := resolver.StubFunctions()
+		stubFunctions = resolver.StubFunctions()
 		if len(stubFunctions) > 0 {
 			allFunctions = append(allFunctions, stubFunctions...)
 		}
+		endResolve()
 
 		p.logger.Info("local.ingestion.cross_package_calls.resolved",
 			"local_calls", len(allCalls)-len(resolvedCalls),
 			"cross_package_resolved", len(resolvedCalls),
 			"external_stubs", len(stubFunctions),
+			"unresolved", len(stillUnresolved),
 		)
 	}
 
@@ -370,48 +536,78 @@ func (p *LocalPipeline) Run(ctx context.Context) (*IngestionResult, error) {
 		"duration_ms", parseDuration.Milliseconds(),
 	)
 
-	// Step 3: Generate embeddings for functions
-	p.logger.Info("local.ingestion.step.generate_embeddings", "run_id", runID, "function_count", len(allFunctions))
-	embedStart := time.Now()
-
-	embedResult, err := p.embeddingGen.EmbedFunctions(ctx, allFunctions)
-	if err != nil {
-		return nil, fmt.Errorf("generate embeddings: %w", err)
-	}
-	allFunctions = embedResult.Functions
-	embeddingErrors := embedResult.ErrorCount
-
-	embedDuration := time.Since(embedStart)
-	p.logger.Info("local.ingestion.embeddings.functions.complete",
-		"count", len(allFunctions),
-		"errors", embeddingErrors,
-		"duration_ms", embedDuration.Milliseconds(),
-	)
-
-	// Step 3b: Generate embeddings for types
-	if len(allTypes) > 0 {
-		p.logger.Info("local.ingestion.step.generate_type_embeddings", "run_id", runID, "type_count", len(allTypes))
-		typeEmbedStart := time.Now()
-
-		typeEmbedResult, err := p.embeddingGen.EmbedTypes(ctx, allTypes)
+	// Step 3: Generate embeddings (skipped for a structure-only index)
+	var embeddingErrors int
+	var embedDuration time.Duration
+	if p.config.IngestionConfig.SkipEmbeddings {
+		p.logger.Info("local.ingestion.embeddings.skipped", "run_id", runID, "function_count", len(allFunctions), "type_count", len(allTypes))
+	} else if stream != nil {
+		// Parsed files were embedded as they streamed; only the stubs are left
+		embedStart := time.Now()
+		endEmbed := p.profiler.StartStage("embed_functions")
+		embedResult, err := p.embeddingGen.EmbedFunctions(ctx, stubFunctions)
+		endEmbed()
 		if err != nil {
-			return nil, fmt.Errorf("generate type embeddings: %w", err)
+			return nil, fmt.Errorf("generate embeddings: %w", err)
 		}
-		allTypes = typeEmbedResult.Types
-		embeddingErrors += typeEmbedResult.ErrorCount
-
-		typeEmbedDuration := time.Since(typeEmbedStart)
-		p.logger.Info("local.ingestion.embeddings.types.complete",
-			"count", len(allTypes),
-			"errors", typeEmbedResult.ErrorCount,
-			"duration_ms", typeEmbedDuration.Milliseconds(),
+		stubFunctions = embedResult.Functions
+		embeddingErrors = stream.embedErrors + embedResult.ErrorCount
+		embedDuration = stream.embedDuration + time.Since(embedStart)
+	} else {
+		p.logger.Info("local.ingestion.step.generate_embeddings", "run_id", runID, "function_count", len(allFunctions))
+		embedStart := time.Now()
+
+		endEmbed := p.profiler.StartStage("embed_functions")
+		embedResult, err := p.embeddingGen.EmbedFunctions(ctx, allFunctions)
+		endEmbed()
+		if err != nil {
+			return nil, fmt.Errorf("generate embeddings: %w", err)
+		}
+		allFunctions = embedResult.Functions
+		embeddingErrors = embedResult.ErrorCount
+
+		embedDuration = time.Since(embedStart)
+		p.logger.Info("local.ingestion.embeddings.functions.complete",
+			"count", len(allFunctions),
+			"errors", embeddingErrors,
+			"duration_ms", embedDuration.Milliseconds(),
 		)
-		embedDuration += typeEmbedDuration
+
+		// Step 3b: Generate embeddings for types
+		if len(allTypes) > 0 {
+			p.logger.Info("local.ingestion.step.generate_type_embeddings", "run_id", runID, "type_count", len(allTypes))
+			typeEmbedStart := time.Now()
+
+			endTypeEmbed := p.profiler.StartStage("embed_types")
+			typeEmbedResult, err := p.embeddingGen.EmbedTypes(ctx, allTypes)
+			endTypeEmbed()
+			if err != nil {
+				return nil, fmt.Errorf("generate type embeddings: %w", err)
+			}
+			allTypes = typeEmbedResult.Types
+			embeddingErrors += typeEmbedResult.ErrorCount
+
+			typeEmbedDuration := time.Since(typeEmbedStart)
+			p.logger.Info("local.ingestion.embeddings.types.complete",
+				"count", len(allTypes),
+				"errors", typeEmbedResult.ErrorCount,
+				"duration_ms", typeEmbedDuration.Milliseconds(),
+			)
+			embedDuration += typeEmbedDuration
+		}
 	}
 
 	// Step 4: Validate entities
 	p.logger.Info("local.ingestion.step.validate_entities")
-	if err := ValidateEntities(allFiles, allFunctions, allDefines, allCalls); err != nil {
+	endValidate := p.profiler.StartStage("validate")
+	if stream != nil {
+		// Streamed files were validated before they were queued
+		err = ValidateEntities(nil, stubFunctions, nil, resolvedCalls)
+	} else {
+		err = ValidateEntities(allFiles, allFunctions, allDefines, allCalls)
+	}
+	endValidate()
+	if err != nil {
 		return nil, fmt.Errorf("entity validation failed: %w", err)
 	}
 
@@ -425,47 +621,95 @@ func (p *LocalPipeline) Run(ctx context.Context) (*IngestionResult, error) {
 		"imports", len(allImports),
 	)
 	writeStart := time.Now()
-
-	// Generate Datalog mutations
-	mutations := p.datalogBuild.BuildMutationsWithTypes(
-		allFiles,
-		allFunctions,
-		allTypes,
-		allDefines,
-		allDefinesTypes,
-		allCalls,
-		allImports,
-	)
-
-	// Generate field and implements mutations
-	fieldImplMutations := p.datalogBuild.BuildFieldAndImplementsMutations(allFields, allImplements)
-	mutations += fieldImplMutations
-
-	// Execute mutations
-	if err := p.backend.Execute(ctx, mutations); err != nil {
+	endWrite := p.profiler.StartStage("write")
+
+	var entitiesSent int
+	if stream != nil {
+		// What needs every file; the files themselves are queued already
+		derived := &entitySet{
+			functions:     stubFunctions,
+			calls:         resolvedCalls,
+			implements:    allImplements,
+			contains:      allContains,
+			methodOf:      allMethodOf,
+			unresolved:    stillUnresolved,
+			generatedFrom: allGeneratedFrom,
+			generated:     allGenerated,
+			generatedFunc: allGeneratedFunc,
+			rpcLinks:      allRPCLinks,
+			entryPoints:   allEntryPoints,
+			functionPaths: stream.functionPaths,
+		}
+		writes.add(derived)
+		err = writes.wait()
+		entitiesSent = stream.queued + derived.count()
+	} else {
+		entities := &entitySet{
+			files:         allFiles,
+			functions:     allFunctions,
+			types:         allTypes,
+			defines:       allDefines,
+			definesTypes:  allDefinesTypes,
+			calls:         allCalls,
+			imports:       allImports,
+			fields:        allFields,
+			implements:    allImplements,
+			contains:      allContains,
+			methodOf:      allMethodOf,
+			unresolved:    stillUnresolved,
+			protoOptions:  parseResult.protoOptions,
+			generatedFrom: allGeneratedFrom,
+			generated:     allGenerated,
+			generatedFunc: allGeneratedFunc,
+			templates:     parseResult.templates,
+			templateRefs:  parseResult.templateRefs,
+			renders:       parseResult.renders,
+			tableRefs:     parseResult.tableRefs,
+			rpcLinks:      allRPCLinks,
+			ciJobs:        parseResult.ciJobs,
+			ciSteps:       parseResult.ciSteps,
+			ciRefs:        parseResult.ciRefs,
+			entryPoints:   allEntryPoints,
+		}
+		err = p.bulkWriteEntities(ctx, entities)
+		entitiesSent = entities.count()
+	}
+	endWrite()
+	if err != nil {
 		return nil, fmt.Errorf("write to local db: %w", err)
 	}
 
 	writeDuration := time.Since(writeStart)
 	totalDuration := time.Since(startTime)
 
-	entitiesSent := len(allFiles) + len(allFunctions) + len(allTypes) +
-		len(allDefines) + len(allDefinesTypes) + len(allCalls) + len(allImports) +
-		len(allFields) + len(allImplements)
-
 	p.logger.Info("local.ingestion.write.complete",
 		"entities_written", entitiesSent,
 		"duration_ms", writeDuration.Milliseconds(),
 	)
 
-	// Update last indexed SHA for future incremental runs
+	p.recordCodeCompression(true)
+	// A shard rebuild only parsed the files in its shards.
+	if len(p.config.IngestionConfig.ReindexShards) == 0 {
+		p.recordParseIssues(parseResult.parseIssues, nil, nil, true)
+	} else {
+		p.recordParseIssues(parseResult.parseIssues, filePaths(loadResult.Files), nil, false)
+	}
+	p.recordDocumentPrefix()
+	// Scripts run before the version bump and snapshot, so both include
+	// the relations they write.
+	scriptMetrics := p.runAnalysisScripts(ctx, true, nil)
+	p.bumpIndexVersion()
+
+	// Update last indexed SHA for future incremental runs. A shard rebuild
+	// leaves it alone: changes in other shards since then are still pending.
 	deltaDetector := NewDeltaDetector(loadResult.RootPath, p.logger)
-	if deltaDetector.IsGitRepository() {
+	if len(p.config.IngestionConfig.ReindexShards) == 0 && deltaDetector.IsGitRepository() {
 		if headSHA, err := deltaDetector.GetHeadSHA(); err == nil {
 			if err := p.backend.SetLastIndexedSHA(headSHA); err != nil {
 				p.logger.Warn("local.ingestion.update_sha.error", "err", err)
 			} else {
 				p.logger.Info("local.ingestion.sha.saved", "sha", headSHA[:min(8, len(headSHA))])
+				p.snapshotIndex(headSHA)
 			}
 		}
 	}
@@ -485,12 +729,14 @@ func (p *LocalPipeline) Run(ctx context.Context) (*IngestionResult, error) {
 		ParseErrors:        parseErrors,
 		ParseErrorRate:     parseErrorRate,
 		EmbeddingErrors:    embeddingErrors,
+		EmbeddingCacheHits: p.embeddingCacheHits(),
 		CodeTextTruncated:  codeTextTruncated,
 		TopSkipReasons:     loadResult.SkipReasons,
 		ParseDuration:      parseDuration,
 		EmbedDuration:      embedDuration,
 		WriteDuration:      writeDuration,
 		TotalDuration:      totalDuration,
+		ScriptMetrics:      scriptMetrics,
 	}
 
 	p.logger.Info("local.ingestion.complete",
@@ -508,15 +754,17 @@ func (p *LocalPipeline) Run(ctx context.Context) (*IngestionResult, error) {
 	return result, nil
 }
 
-// parseFilesParallel parses files in parallel using a worker pool.
-func (p *LocalPipeline) parseFilesParallel(ctx context.Context, files []FileInfo, numWorkers int) (*parseFilesResult, int) {
+// parseFilesParallel parses files in parallel using a worker pool. When
+// onParsed is set, it is called with each parsed file as soon as the file is
+// done, from a single goroutine.
+func (p *LocalPipeline) parseFilesParallel(ctx context.Context, files []FileInfo, numWorkers int, onParsed func(*ParseResult)) (*parseFilesResult, int) {
 	if len(files) == 0 {
 		return &parseFilesResult{packageNames: make(map[string]string)}, 0
 	}
 
 	// For small file sets, use sequential parsing
 	if len(files) < 10 || numWorkers <= 1 {
-		return p.parseFilesSequential(ctx, files)
+		return p.parseFilesSequential(ctx, files, onParsed)
 	}
 
 	jobs := make(chan int, len(files))
@@ -547,7 +795,7 @@ func (p *LocalPipeline) parseFilesParallel(ctx context.Context, files []FileInfo
 				}
 
 				fileInfo := files[i]
-				pr, err := p.parser.ParseFile(fileInfo)
+				pr, err := p.parseFile(fileInfo)
 				if err != nil {
 					atomic.AddInt32(&errorCount, 1)
 					p.logger.Warn("local.ingestion.parse_file.error", "path", fileInfo.Path, "err", err)
@@ -585,7 +833,12 @@ func (p *LocalPipeline) parseFilesParallel(ctx context.Context, files []FileInfo
 	packageNames := make(map[string]string)
 	var mu sync.Mutex
 
+	result := &parseFilesResult{
+		packageNames: packageNames,
+		parseIssues:  make(map[string]storage.ParseIssue),
+	}
 	for fr := range resultsChan {
+		result.addParseIssue(files[fr.index], fr.result, fr.err)
 		if fr.err != nil {
 			continue
 		}
@@ -595,11 +848,11 @@ func (p *LocalPipeline) parseFilesParallel(ctx context.Context, files []FileInfo
 			packageNames[fr.filePath] = fr.packageName
 			mu.Unlock()
 		}
+		if onParsed != nil {
+			onParsed(fr.result)
+		}
 	}
 
-	result := &parseFilesResult{
-		packageNames: packageNames,
-	}
 	for _, pr := range parseResults {
 		if pr == nil {
 			continue
@@ -613,15 +866,89 @@ func (p *LocalPipeline) parseFilesParallel(ctx context.Context, files []FileInfo
 		result.calls = append(result.calls, pr.Calls...)
 		result.imports = append(result.imports, pr.Imports...)
 		result.unresolvedCalls = append(result.unresolvedCalls, pr.UnresolvedCalls...)
+		result.protoOptions = append(result.protoOptions, pr.ProtoOptions...)
+		if pr.Template != nil {
+			result.templates = append(result.templates, *pr.Template)
+		}
+		result.templateRefs = append(result.templateRefs, pr.TemplateRefs...)
+		result.renders = append(result.renders, pr.Renders...)
+		result.tableRefs = append(result.tableRefs, pr.TableRefs...)
+		result.rpcClients = append(result.rpcClients, pr.RPCClients...)
+		if pr.Generated != nil {
+			result.generated = append(result.generated, *pr.Generated)
+		}
+		result.ciJobs = append(result.ciJobs, pr.CIJobs...)
+		result.ciSteps = append(result.ciSteps, pr.CISteps...)
+		result.ciRefs = append(result.ciRefs, pr.CIRefs...)
 	}
 
 	return result, int(errorCount)
 }
 
+// parseFile parses one file and, when StoreFileText is enabled, attaches its
+// full text to the file entity.
+func (p *LocalPipeline) parseFile(fileInfo FileInfo) (*ParseResult, error) {
+	start := time.Now()
+	pr, err := p.parser.ParseFile(fileInfo)
+	p.profiler.RecordFile(fileInfo, time.Since(start), err != nil)
+	if err != nil || !p.config.IngestionConfig.StoreFileText {
+		return pr, err
+	}
+	content, readErr := os.ReadFile(fileInfo.FullPath)
+	if readErr != nil {
+		p.logger.Warn("local.ingestion.file_text.read_error", "path", fileInfo.Path, "err", readErr)
+		return pr, nil
+	}
+	if isBinaryContent(content) && !hasUTF16BOM(content) {
+		return pr, nil
+	}
+	content, _ = decodeSource(content)
+	if p.config.IngestionConfig.ContentHashMode != ContentHashExact {
+		content = normalizeLineEndings(content)
+	}
+	pr.File.Content = string(content)
+	return pr, nil
+}
+
+// resetShards drops the stores of the shards about to be rebuilt.
+func (p *LocalPipeline) resetShards(shards []string) error {
+	if !p.backend.Sharded() {
+		return fmt.Errorf("reindexing shards requires sharded storage")
+	}
+	for _, name := range shards {
+		if err := p.backend.DropShard(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filesInShards keeps the files stored in one of shards.
+func filesInShards(files []FileInfo, shards []string) []FileInfo {
+	keep := make(map[string]bool, len(shards))
+	for _, name := range shards {
+		keep[name] = true
+	}
+	var out []FileInfo
+	for _, f := range files {
+		if keep[storage.ShardFor(f.Path)] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// isBinaryContent reports whether data looks binary (a NUL byte in the
+// first 8KB, the same heuristic git uses).
+func isBinaryContent(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0
+}
+
 // parseFilesSequential parses files sequentially.
-func (p *LocalPipeline) parseFilesSequential(ctx context.Context, files []FileInfo) (*parseFilesResult, int) {
+func (p *LocalPipeline) parseFilesSequential(ctx context.Context, files []FileInfo, onParsed func(*ParseResult)) (*parseFilesResult, int) {
 	result := &parseFilesResult{
 		packageNames: make(map[string]string),
+		parseIssues:  make(map[string]storage.ParseIssue),
 	}
 	errorCount := 0
 	totalFiles := int64(len(files))
@@ -633,7 +960,8 @@ func (p *LocalPipeline) parseFilesSequential(ctx context.Context, files []FileIn
 		default:
 		}
 
-		pr, err := p.parser.ParseFile(fileInfo)
+		pr, err := p.parseFile(fileInfo)
+		result.addParseIssue(fileInfo, pr, err)
 		if err != nil {
 			errorCount++
 			p.logger.Warn("local.ingestion.parse_file.error", "path", fileInfo.Path, "err", err)
@@ -651,9 +979,26 @@ func (p *LocalPipeline) parseFilesSequential(ctx context.Context, files []FileIn
 		result.calls = append(result.calls, pr.Calls...)
 		result.imports = append(result.imports, pr.Imports...)
 		result.unresolvedCalls = append(result.unresolvedCalls, pr.UnresolvedCalls...)
+		result.protoOptions = append(result.protoOptions, pr.ProtoOptions...)
+		if pr.Template != nil {
+			result.templates = append(result.templates, *pr.Template)
+		}
+		result.templateRefs = append(result.templateRefs, pr.TemplateRefs...)
+		result.renders = append(result.renders, pr.Renders...)
+		result.tableRefs = append(result.tableRefs, pr.TableRefs...)
+		result.rpcClients = append(result.rpcClients, pr.RPCClients...)
+		if pr.Generated != nil {
+			result.generated = append(result.generated, *pr.Generated)
+		}
+		result.ciJobs = append(result.ciJobs, pr.CIJobs...)
+		result.ciSteps = append(result.ciSteps, pr.CISteps...)
+		result.ciRefs = append(result.ciRefs, pr.CIRefs...)
 		if pr.PackageName != "" {
 			result.packageNames[fileInfo.Path] = pr.PackageName
 		}
+		if onParsed != nil {
+			onParsed(pr)
+		}
 		// Report progress after successful parse
 		p.reportProgress(int64(i+1), totalFiles, "parsing")
 	}
@@ -672,13 +1017,16 @@ type incrementalContext struct {
 	startTime time.Time
 	headSHA   string
 	delta     *GitDelta
+	goModules []GoModule
 }
 
 // tryIncrementalRun attempts to run incremental indexing.
 // Returns (result, nil) on success, (nil, nil) if incremental not possible, or (nil, err) on error.
 func (p *LocalPipeline) tryIncrementalRun(ctx context.Context, loadResult *LoadResult, runID string, startTime time.Time) (*IngestionResult, error) {
 	// Detect changes
-	incCtx, earlyResult, err := p.detectIncrementalChanges(loadResult, runID, startTime)
+	endDelta := p.profiler.StartStage("delta")
+	incCtx, earlyResult, err := p.detectIncrementalChanges(ctx, loadResult, runID, startTime)
+	endDelta()
 	if err != nil {
 		return nil, err
 	}
@@ -687,7 +1035,9 @@ func (p *LocalPipeline) tryIncrementalRun(ctx context.Context, loadResult *LoadR
 	}
 
 	// Process deletions
+	endDelete := p.profiler.StartStage("delete")
 	p.processIncrementalDeletions(incCtx.delta)
+	endDelete()
 
 	// Get files to process
 	changedFiles := p.getFilesToProcess(incCtx.delta, loadResult.Files)
@@ -701,7 +1051,7 @@ func (p *LocalPipeline) tryIncrementalRun(ctx context.Context, loadResult *LoadR
 
 // detectIncrementalChanges checks git state and detects delta.
 // Returns (context, nil, nil) to continue, (nil, result, nil) for early return, or (nil, nil, err) on error.
-func (p *LocalPipeline) detectIncrementalChanges(loadResult *LoadResult, runID string, startTime time.Time) (*incrementalContext, *IngestionResult, error) {
+func (p *LocalPipeline) detectIncrementalChanges(ctx context.Context, loadResult *LoadResult, runID string, startTime time.Time) (*incrementalContext, *IngestionResult, error) {
 	deltaDetector := NewDeltaDetector(loadResult.RootPath, p.logger)
 	if !deltaDetector.IsGitRepository() {
 		return nil, nil, fmt.Errorf("not a git repository")
@@ -740,7 +1090,9 @@ func (p *LocalPipeline) detectIncrementalChanges(loadResult *LoadResult, runID s
 		return nil, nil, fmt.Errorf("detect delta: %w", err)
 	}
 
-	delta = FilterDelta(delta, p.config.IngestionConfig.ExcludeGlobs, p.config.IngestionConfig.MaxFileSizeBytes, loadResult.RootPath)
+	delta = FilterDeltaWithLimits(delta, p.config.IngestionConfig.ExcludeGlobs, p.config.IngestionConfig.MaxFileSizeBytes,
+		p.config.IngestionConfig.LanguageLimits, loadResult.RootPath)
+	delta.Modified = p.dropUnchangedFiles(ctx, loadResult.RootPath, delta.Modified)
 
 	if !delta.HasChanges() {
 		p.logger.Info("local.ingestion.incremental.no_changes_after_filter")
@@ -766,6 +1118,7 @@ func (p *LocalPipeline) detectIncrementalChanges(loadResult *LoadResult, runID s
 		startTime: startTime,
 		headSHA:   headSHA,
 		delta:     delta,
+		goModules: loadResult.GoModules,
 	}, nil, nil
 }
 
@@ -784,6 +1137,49 @@ func (p *LocalPipeline) processIncrementalDeletions(delta *GitDelta) {
 	}
 }
 
+// dropUnchangedFiles removes the paths whose content hash, under the
+// configured content_hash mode, still equals the hash stored in cie_file, so
+// a file that only changed line endings or trailing whitespace is neither
+// deleted nor parsed and embedded again.
+func (p *LocalPipeline) dropUnchangedFiles(ctx context.Context, root string, paths []string) []string {
+	if len(paths) == 0 {
+		return paths
+	}
+	rows := make([][]any, len(paths))
+	for i, path := range paths {
+		rows[i] = []any{path}
+	}
+	result, err := p.backend.QueryWithParams(ctx,
+		"paths[path] <- $paths\n?[path, hash] := paths[path], *cie_file { path, hash }",
+		map[string]any{"paths": rows})
+	if err != nil {
+		p.logger.Warn("local.ingestion.incremental.hash_lookup.error", "err", err)
+		return paths
+	}
+	stored := make(map[string]string, len(result.Rows))
+	for _, row := range result.Rows {
+		path, _ := row[0].(string)
+		hash, _ := row[1].(string)
+		stored[path] = hash
+	}
+
+	var changed []string
+	for _, path := range paths {
+		if hash, ok := stored[path]; ok && hash != "" {
+			content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+			if err == nil && ContentHash(content, p.hashMode) == hash {
+				p.logger.Debug("local.ingestion.incremental.unchanged", "path", path)
+				continue
+			}
+		}
+		changed = append(changed, path)
+	}
+	if skipped := len(paths) - len(changed); skipped > 0 {
+		p.logger.Info("local.ingestion.incremental.unchanged_content", "files", skipped, "mode", p.hashMode)
+	}
+	return changed
+}
+
 // getFilesToProcess returns files from loadResult that are in the delta.
 func (p *LocalPipeline) getFilesToProcess(delta *GitDelta, allFiles []FileInfo) []FileInfo {
 	filesToProcess := make(map[string]bool)
@@ -809,9 +1205,8 @@ func (p *LocalPipeline) getFilesToProcess(delta *GitDelta, allFiles []FileInfo)
 // handleDeletionsOnly returns a result when only deletions occurred.
 func (p *LocalPipeline) handleDeletionsOnly(incCtx *incrementalContext, deletedCount int) (*IngestionResult, error) {
 	p.logger.Info("local.ingestion.incremental.deletions_only", "deleted", deletedCount)
-	if err := p.backend.SetLastIndexedSHA(incCtx.headSHA); err != nil {
-		p.logger.Warn("local.ingestion.incremental.update_sha.error", "err", err)
-	}
+	p.recordParseIssues(nil, nil, removedPaths(incCtx.delta), false)
+	p.recordIndexedSHA(incCtx.headSHA)
 	return &IngestionResult{
 		ProjectID:      p.config.ProjectID,
 		RunID:          incCtx.runID,
@@ -831,46 +1226,68 @@ func (p *LocalPipeline) processIncrementalFiles(ctx context.Context, incCtx *inc
 		parseWorkers = 4
 	}
 
-	parseResult, parseErrors := p.parseFilesParallel(ctx, changedFiles, parseWorkers)
+	// Each file is embedded and written as soon as it is parsed
+	writes := p.startWriter(ctx, true)
+	defer writes.stop()
+	stream := p.startFileStream(writes)
+
+	endParse := p.profiler.StartStage("parse")
+	parseResult, parseErrors := p.parseFilesParallel(ctx, changedFiles, parseWorkers, stream.add)
+	endParse()
 	parseDuration := time.Since(parseStart)
+	if err := stream.close(); err != nil {
+		return nil, err
+	}
 
 	// Build implements index and resolve cross-package calls
 	incImplements := BuildImplementsIndex(parseResult.types, parseResult.functions)
-
+	incContains := BuildContainsIndex(parseResult.types, parseResult.functions)
+	incMethodOf := BuildMethodOfIndex(parseResult.types, parseResult.functions)
+	incGenerated := ResolveGeneratedSources(parseResult.generated, parseResult.files)
+	incGeneratedFrom := append(BuildGeneratedFromIndex(parseResult.types), BuildGeneratedMockIndex(incGenerated, parseResult.types)...)
+	incGeneratedFunc := BuildGeneratedFuncIndex(incGenerated, parseResult.types, parseResult.functions, incGeneratedFrom)
+	incEntryPoints := BuildEntryPointIndex(parseResult.functions)
+	incRPCLinks := BuildRPCLinkIndex(parseResult.types, parseResult.functions, parseResult.rpcClients)
+
+	var incUnresolved []UnresolvedCall
+	var resolvedCalls []CallsEdge
+	var stubFunctions []FunctionEntity
 	if len(parseResult.unresolvedCalls) > 0 {
+		endResolve := p.profiler.StartStage("resolve_calls")
 		resolver := NewCallResolver()
 		resolver.BuildIndex(parseResult.files, parseResult.functions, parseResult.imports, parseResult.packageNames)
+		resolver.SetGoModules(incCtx.goModules)
 		resolver.SetInterfaceIndex(parseResult.fields, incImplements)
-		resolvedCalls := resolver.ResolveCalls(parseResult.unresolvedCalls)
+		resolvedCalls = resolver.ResolveCalls(parseResult.unresolvedCalls)
 		parseResult.calls = append(parseResult.calls, resolvedCalls...)
+		incUnresolved = resolver.UnresolvedCalls()
 
 		// Collect synthetic stubs for external type methods
-		stubFunctions := resolver.StubFunctions()
+		stubFunctions = resolver.StubFunctions()
 		if len(stubFunctions) > 0 {
 			parseResult.functions = append(parseResult.functions, stubFunctions...)
 		}
+		endResolve()
 	}
 
-	// Embed
-	p.logger.Info("local.ingestion.incremental.embed", "function_count", len(parseResult.functions))
-	embedStart := time.Now()
-
-	embedResult, err := p.embeddingGen.EmbedFunctions(ctx, parseResult.functions)
-	if err != nil {
-		return nil, fmt.Errorf("generate embeddings: %w", err)
-	}
-	parseResult.functions = embedResult.Functions
-	embeddingErrors := embedResult.ErrorCount
-
-	if len(parseResult.types) > 0 {
-		typeEmbedResult, err := p.embeddingGen.EmbedTypes(ctx, parseResult.types)
+	// Embed the stubs; parsed files were embedded as they streamed
+	embeddingErrors := stream.embedErrors
+	embedDuration := stream.embedDuration
+	if !p.config.IngestionConfig.SkipEmbeddings && len(stubFunctions) > 0 {
+		embedStart := time.Now()
+		endEmbed := p.profiler.StartStage("embed_functions")
+		embedResult, err := p.embeddingGen.EmbedFunctions(ctx, stubFunctions)
+		endEmbed()
 		if err != nil {
-			return nil, fmt.Errorf("generate type embeddings: %w", err)
+			return nil, fmt.Errorf("generate embeddings: %w", err)
 		}
-		parseResult.types = typeEmbedResult.Types
-		embeddingErrors += typeEmbedResult.ErrorCount
+		stubFunctions = embedResult.Functions
+		embeddingErrors += embedResult.ErrorCount
+		embedDuration += time.Since(embedStart)
+	}
+	if err := ValidateEntities(nil, stubFunctions, nil, resolvedCalls); err != nil {
+		return nil, fmt.Errorf("entity validation failed: %w", err)
 	}
-	embedDuration := time.Since(embedStart)
 
 	// Write
 	p.logger.Info("local.ingestion.incremental.write",
@@ -879,30 +1296,41 @@ func (p *LocalPipeline) processIncrementalFiles(ctx context.Context, incCtx *inc
 		"types", len(parseResult.types),
 	)
 	writeStart := time.Now()
-
-	mutations := p.datalogBuild.BuildMutationsWithTypes(
-		parseResult.files, parseResult.functions, parseResult.types,
-		parseResult.defines, parseResult.definesTypes, parseResult.calls, parseResult.imports,
-	)
-
-	// Add field and implements mutations
-	fieldImplMutations := p.datalogBuild.BuildFieldAndImplementsMutations(parseResult.fields, incImplements)
-	mutations += fieldImplMutations
-
-	if err := p.backend.Execute(ctx, mutations); err != nil {
+	endWrite := p.profiler.StartStage("write")
+
+	// What needs every changed file; the files themselves are queued already
+	derived := &entitySet{
+		functions:     stubFunctions,
+		calls:         resolvedCalls,
+		implements:    incImplements,
+		contains:      incContains,
+		methodOf:      incMethodOf,
+		unresolved:    incUnresolved,
+		generatedFrom: incGeneratedFrom,
+		generated:     incGenerated,
+		generatedFunc: incGeneratedFunc,
+		rpcLinks:      incRPCLinks,
+		entryPoints:   incEntryPoints,
+		functionPaths: stream.functionPaths,
+	}
+	writes.add(derived)
+	err := writes.wait()
+	endWrite()
+	if err != nil {
 		return nil, fmt.Errorf("write to local db: %w", err)
 	}
 	writeDuration := time.Since(writeStart)
 
-	// Update SHA
-	if err := p.backend.SetLastIndexedSHA(incCtx.headSHA); err != nil {
-		p.logger.Warn("local.ingestion.incremental.update_sha.error", "err", err)
-	}
+	p.recordCodeCompression(false)
+	p.recordParseIssues(parseResult.parseIssues, filePaths(changedFiles), removedPaths(incCtx.delta), false)
+	p.recordDocumentPrefix()
+	scriptMetrics := p.runAnalysisScripts(ctx, false, filePaths(changedFiles))
+	p.bumpIndexVersion()
+
+	p.recordIndexedSHA(incCtx.headSHA)
 
 	totalDuration := time.Since(incCtx.startTime)
-	entitiesSent := len(parseResult.files) + len(parseResult.functions) + len(parseResult.types) +
-		len(parseResult.defines) + len(parseResult.definesTypes) + len(parseResult.calls) + len(parseResult.imports) +
-		len(parseResult.fields) + len(incImplements)
+	entitiesSent := stream.queued + derived.count()
 
 	result := &IngestionResult{
 		ProjectID:          p.config.ProjectID,
@@ -915,10 +1343,12 @@ func (p *LocalPipeline) processIncrementalFiles(ctx context.Context, incCtx *inc
 		EntitiesSent:       entitiesSent,
 		ParseErrors:        parseErrors,
 		EmbeddingErrors:    embeddingErrors,
+		EmbeddingCacheHits: p.embeddingCacheHits(),
 		ParseDuration:      parseDuration,
 		EmbedDuration:      embedDuration,
 		WriteDuration:      writeDuration,
 		TotalDuration:      totalDuration,
+		ScriptMetrics:      scriptMetrics,
 	}
 
 	p.logger.Info("local.ingestion.incremental.complete",
@@ -932,3 +1362,152 @@ func (p *LocalPipeline) processIncrementalFiles(ctx context.Context, incCtx *inc
 
 	return result, nil
 }
+
+// recordIndexedSHA records headSHA as the last indexed commit and snapshots
+// the index at it. An empty headSHA (IndexPaths) leaves both alone.
+func (p *LocalPipeline) recordIndexedSHA(headSHA string) {
+	if headSHA == "" {
+		return
+	}
+	if err := p.backend.SetLastIndexedSHA(headSHA); err != nil {
+		p.logger.Warn("local.ingestion.incremental.update_sha.error", "err", err)
+		return
+	}
+	p.snapshotIndex(headSHA)
+}
+
+// recordCodeCompression stores the code_text codec in project metadata so
+// query tools know whether code_text can be matched inside CozoDB.
+// A full run rewrites every row, so it can also clear the flag; an incremental
+// run only ever raises it because older rows may still be compressed.
+func (p *LocalPipeline) recordCodeCompression(fullRun bool) {
+	value := "none"
+	if p.config.IngestionConfig.CompressCodeText {
+		value = "zstd"
+	} else if !fullRun {
+		return
+	}
+	if err := p.backend.SetProjectMeta(storage.CodeCompressionMetaKey, value); err != nil {
+		p.logger.Warn("local.ingestion.code_compression.meta.error", "err", err)
+	}
+}
+
+// recordParseIssues keeps the parse issues in project metadata current for
+// cie_index_health. A full run replaces them with issues; other runs drop
+// the entries of the files they parsed or removed and add issues.
+func (p *LocalPipeline) recordParseIssues(issues map[string]storage.ParseIssue, parsed, removed []string, replace bool) {
+	merged := make(map[string]storage.ParseIssue, len(issues))
+	if !replace {
+		value, err := p.backend.GetProjectMeta(storage.ParseIssuesMetaKey)
+		if err == nil {
+			merged, err = storage.DecodeParseIssues(value)
+		}
+		if err != nil {
+			p.logger.Warn("local.ingestion.parse_issues.meta.error", "err", err)
+			return
+		}
+		for _, path := range parsed {
+			delete(merged, path)
+		}
+		for _, path := range removed {
+			delete(merged, path)
+		}
+	}
+	for path, issue := range issues {
+		merged[path] = issue
+	}
+	value, err := storage.EncodeParseIssues(merged)
+	if err == nil {
+		err = p.backend.SetProjectMeta(storage.ParseIssuesMetaKey, value)
+	}
+	if err != nil {
+		p.logger.Warn("local.ingestion.parse_issues.meta.error", "err", err)
+	}
+}
+
+// removedPaths lists the paths delta removes from the index: deleted
+// files and the old paths of renamed ones.
+func removedPaths(delta *GitDelta) []string {
+	removed := append([]string(nil), delta.Deleted...)
+	for oldPath := range delta.Renamed {
+		removed = append(removed, oldPath)
+	}
+	return removed
+}
+
+// recordDocumentPrefix stores the document prefix embeddings were generated
+// with, so semantic search can warn when queries are prefixed for another.
+// Runs that skip embeddings leave the recorded prefix alone.
+func (p *LocalPipeline) recordDocumentPrefix() {
+	if p.config.IngestionConfig.SkipEmbeddings {
+		return
+	}
+	if err := p.backend.SetProjectMeta(storage.DocumentPrefixMetaKey, embedprefix.EncodeDocument(p.docPrefix)); err != nil {
+		p.logger.Warn("local.ingestion.document_prefix.meta.error", "err", err)
+	}
+}
+
+// snapshotIndex saves the index as of sha when KeepSnapshots is set and
+// prunes older snapshots. Failures are logged; the run itself succeeded.
+func (p *LocalPipeline) snapshotIndex(sha string) {
+	keep := p.config.IngestionConfig.KeepSnapshots
+	if keep <= 0 || sha == "" {
+		return
+	}
+	snap, err := p.backend.Snapshot(sha)
+	if err != nil {
+		p.logger.Warn("local.ingestion.snapshot.error", "sha", sha[:min(8, len(sha))], "err", err)
+		return
+	}
+	p.logger.Info("local.ingestion.snapshot.saved", "sha", sha[:min(8, len(sha))], "bytes", snap.Size)
+	if removed, err := storage.PruneSnapshots(p.backend.DataDir(), keep); err != nil {
+		p.logger.Warn("local.ingestion.snapshot.prune.error", "err", err)
+	} else if len(removed) > 0 {
+		p.logger.Info("local.ingestion.snapshot.pruned", "count", len(removed))
+	}
+}
+
+// runAnalysisScripts runs the configured analysis scripts and collects
+// their metrics. Failures are logged; the run itself succeeded.
+func (p *LocalPipeline) runAnalysisScripts(ctx context.Context, fullRun bool, changedFiles []string) map[string]float64 {
+	paths := p.config.IngestionConfig.AnalysisScripts
+	if len(paths) == 0 {
+		return nil
+	}
+	metrics := make(map[string]float64)
+	for _, path := range paths {
+		res, err := scripting.RunFile(ctx, p.backend, path, scripting.Options{
+			ProjectID:    p.config.ProjectID,
+			FullRun:      fullRun,
+			ChangedFiles: changedFiles,
+			Logger:       p.logger,
+		})
+		if err != nil {
+			p.logger.Warn("local.ingestion.script.error", "script", path, "err", err)
+			continue
+		}
+		prefix := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		for name, v := range res.Metrics {
+			metrics[prefix+"."+name] = v
+		}
+		p.logger.Info("local.ingestion.script.done", "script", path, "metrics", len(res.Metrics), "duration_ms", res.Duration.Milliseconds())
+	}
+	return metrics
+}
+
+// filePaths returns the repository-relative paths of files.
+func filePaths(files []FileInfo) []string {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+	return paths
+}
+
+// bumpIndexVersion marks the index as changed so MCP result caches drop
+// entries computed against the previous contents.
+func (p *LocalPipeline) bumpIndexVersion() {
+	if _, err := p.backend.BumpIndexVersion(); err != nil {
+		p.logger.Warn("local.ingestion.index_version.error", "err", err)
+	}
+}
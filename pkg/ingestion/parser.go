@@ -20,8 +20,6 @@
 package ingestion
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"os"
 
@@ -49,7 +47,10 @@ import (
 type Parser struct {
 	logger          *slog.Logger
 	maxCodeTextSize int64
-	truncatedCount  int // Count of truncated CodeTexts (for summary)
+	languageLimits  map[string]int64 // Per-language overrides of maxCodeTextSize
+	hashMode        ContentHashMode  // Normalization applied before hashing files
+	truncatedCount  int              // Count of truncated CodeTexts (for summary)
+	overflow        codeOverflow     // Code cut off by truncateCodeText
 }
 
 // NewParser creates a new code parser.
@@ -69,6 +70,19 @@ func (p *Parser) SetMaxCodeTextSize(size int64) {
 	p.maxCodeTextSize = size
 }
 
+// SetContentHashMode sets how file content is normalized before hashing.
+func (p *Parser) SetContentHashMode(mode ContentHashMode) {
+	p.hashMode = mode
+}
+
+// SetLanguageMaxCodeTextSize overrides the CodeText limit for one language.
+func (p *Parser) SetLanguageMaxCodeTextSize(language string, size int64) {
+	if p.languageLimits == nil {
+		p.languageLimits = make(map[string]int64)
+	}
+	p.languageLimits[language] = size
+}
+
 // GetTruncatedCount returns the number of CodeTexts that were truncated.
 func (p *Parser) GetTruncatedCount() int {
 	return p.truncatedCount
@@ -79,15 +93,32 @@ func (p *Parser) ResetTruncatedCount() {
 	p.truncatedCount = 0
 }
 
-// truncateCodeText truncates CodeText if it exceeds the limit and increments counter.
-func (p *Parser) truncateCodeText(codeText string) string {
-	if p.maxCodeTextSize > 0 && int64(len(codeText)) > p.maxCodeTextSize {
+// truncateCodeText truncates CodeText if it exceeds the limit for the file's
+// language and increments counter. The cut-off code is kept for ParseFile to
+// store in cie_function_code_chunk.
+func (p *Parser) truncateCodeText(filePath, codeText string) string {
+	limit := codeTextLimit(filePath, p.maxCodeTextSize, p.languageLimits)
+	if limit > 0 && int64(len(codeText)) > limit {
 		p.truncatedCount++
-		return codeText[:p.maxCodeTextSize]
+		kept, rest := splitCodeText(codeText, limit)
+		p.overflow.record(filePath, kept, rest)
+		return kept
 	}
 	return codeText
 }
 
+// codeTextLimit returns the CodeText limit for filePath: its language's
+// override when one is set, otherwise the global limit.
+func codeTextLimit(filePath string, global int64, languageLimits map[string]int64) int64 {
+	if len(languageLimits) == 0 {
+		return global
+	}
+	if limit, ok := languageLimits[detectLanguageFromPath(filePath)]; ok {
+		return limit
+	}
+	return global
+}
+
 // ParseResult contains extracted entities from a file.
 type ParseResult struct {
 	// File is the file entity containing metadata (path, hash, language, size).
@@ -111,16 +142,47 @@ type ParseResult struct {
 	// Calls contains function-to-function call relationships discovered within the file.
 	Calls []CallsEdge
 
-	// Imports contains import statements for cross-package resolution (Go-specific).
+	// Imports contains import statements for cross-package resolution (Go
+	// imports and C# using directives).
 	Imports []ImportEntity
 
 	// UnresolvedCalls contains function calls that couldn't be resolved within the file.
 	// These will be resolved later during cross-package call resolution.
 	UnresolvedCalls []UnresolvedCall
 
-	// PackageName is the package name for Go files (e.g., "handlers", "main").
+	// PackageName is the package name for Go files (e.g., "handlers", "main")
+	// and the namespace for C# files (e.g., "MyApp.Services").
 	// Empty for other languages.
 	PackageName string
+
+	// ProtoOptions contains the options set in a .proto file.
+	ProtoOptions []ProtoOption
+
+	// Template is set when the file is a template; TemplateRefs holds what it references.
+	Template     *TemplateEntity
+	TemplateRefs []TemplateRef
+
+	// Renders contains the templates rendered by the file's functions.
+	Renders []RenderCall
+
+	// TableRefs contains the database tables the file's functions use.
+	TableRefs []TableRef
+
+	// RPCClients contains the calls the file's functions make through
+	// generated gRPC clients; servers are linked after parsing.
+	RPCClients []RPCLink
+
+	// Generated is set when the file's header marks it as generated code.
+	Generated *GeneratedFile
+
+	// SyntaxErrors is the number of error nodes the language parser found.
+	// The entities around them are still extracted, but may be incomplete.
+	SyntaxErrors int
+
+	// CIJobs, CISteps and CIRefs are set for CI workflow files.
+	CIJobs  []CIJob
+	CISteps []CIStep
+	CIRefs  []CIRef
 }
 
 // ParseFile parses a source file and extracts functions.
@@ -133,9 +195,21 @@ func (p *Parser) ParseFile(fileInfo FileInfo) (*ParseResult, error) {
 		return nil, fmt.Errorf("read file: %w", err)
 	}
 
-	// Compute content hash
-	hash := sha256.Sum256(content)
-	hashStr := hex.EncodeToString(hash[:])
+	// Compute content hash (of the bytes on disk, so change detection is
+	// unaffected by transcoding)
+	hashStr := ContentHash(content, p.hashMode)
+
+	// Parse UTF-16 and Latin-1 files as UTF-8
+	content, encoding := decodeSource(content)
+	if encoding != encodingUTF8 {
+		p.logger.Debug("parser.transcoded", "path", fileInfo.Path, "encoding", encoding)
+	}
+
+	// Parse CRLF files with LF endings so code text (and with it embeddings
+	// and their cache keys) matches across checkouts
+	if p.hashMode != ContentHashExact {
+		content = normalizeLineEndings(content)
+	}
 
 	// Create file entity
 	fileID := GenerateFileID(fileInfo.Path)
@@ -159,7 +233,9 @@ func (p *Parser) ParseFile(fileInfo FileInfo) (*ParseResult, error) {
 	case "javascript", "typescript":
 		functions, calls = p.parseJSFile(string(content), fileInfo.Path)
 	case "protobuf":
-		functions, calls = parseProtobufContent(string(content), fileInfo.Path, p.truncateCodeText)
+		functions, calls = parseProtobufContent(string(content), fileInfo.Path, func(codeText string) string {
+			return p.truncateCodeText(fileInfo.Path, codeText)
+		})
 	default:
 		// For unsupported languages, return empty result
 		p.logger.Debug("parser.skip_unsupported_language",
@@ -168,6 +244,9 @@ func (p *Parser) ParseFile(fileInfo FileInfo) (*ParseResult, error) {
 		)
 	}
 
+	annotateFunctionLanguage(functions, fileInfo.Language, string(content))
+	p.overflow.attach(fileInfo.Path, functions)
+
 	// Create defines edges
 	defines := make([]DefinesEdge, len(functions))
 	for i, fn := range functions {
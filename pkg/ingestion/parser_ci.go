@@ -0,0 +1,456 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package ingestion
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// =============================================================================
+// CI WORKFLOW PARSER (GitHub Actions, GitLab CI)
+// =============================================================================
+
+// CI reference kinds (CIRef.Kind).
+const (
+	CIRefScript = "script"  // Repository script a step runs ("scripts/test.sh")
+	CIRefMake   = "make"    // Make target a step runs ("integration-test")
+	CIRefAction = "action"  // GitHub action or reusable workflow ("actions/checkout@v4")
+	CIRefEnv    = "env"     // Environment variable set or read
+	CIRefSecret = "secret"  // Secret read (${{ secrets.X }})
+	CIRefExtend = "extends" // GitLab job template the job extends
+)
+
+// ciLanguage returns "github_actions" or "gitlab_ci" for CI workflow files
+// recognized by their location, or "" for other files.
+func ciLanguage(filePath string) string {
+	filePath = strings.ReplaceAll(filePath, "\\", "/")
+	base := path.Base(filePath)
+	ext := path.Ext(base)
+	if (ext == ".yml" || ext == ".yaml") && strings.HasSuffix(path.Dir(filePath), ".github/workflows") {
+		return "github_actions"
+	}
+	if strings.HasSuffix(base, ".gitlab-ci.yml") { // also "templates/build.gitlab-ci.yml"
+		return "gitlab_ci"
+	}
+	return ""
+}
+
+// ciParseResult holds the jobs, steps and references of one CI file.
+type ciParseResult struct {
+	Jobs  []CIJob
+	Steps []CIStep
+	Refs  []CIRef
+}
+
+// parseCIWorkflow parses a GitHub Actions workflow or a GitLab CI file.
+// Every document of a multi-document file is read, with anchors, aliases
+// and "<<" merge keys resolved first.
+func parseCIWorkflow(content []byte, filePath, language string) (*ciParseResult, error) {
+	result := &ciParseResult{}
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return result, nil
+			}
+			return nil, fmt.Errorf("parse yaml: %w", err)
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		root := resolveYAML(doc.Content[0], make(map[*yaml.Node]bool))
+		if root.Kind != yaml.MappingNode {
+			continue
+		}
+		if language == "github_actions" {
+			parseGitHubWorkflow(root, filePath, result)
+		} else {
+			parseGitLabCI(root, filePath, result)
+		}
+	}
+}
+
+// resolveYAML replaces aliases under node with the nodes they refer to and
+// expands "<<" merge keys into the mappings using them, as a YAML loader
+// would. Keys set in a mapping win over merged ones, and earlier merge
+// sources over later ones. Shared nodes are resolved once.
+func resolveYAML(node *yaml.Node, done map[*yaml.Node]bool) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node == nil || done[node] {
+		return node
+	}
+	done[node] = true
+	for i, child := range node.Content {
+		node.Content[i] = resolveYAML(child, done)
+	}
+	if node.Kind == yaml.MappingNode {
+		node.Content = mergeYAMLKeys(node.Content)
+	}
+	return node
+}
+
+// mergeYAMLKeys returns the key/value pairs of a resolved mapping with its
+// merge keys replaced by the pairs of the mappings they name.
+func mergeYAMLKeys(content []*yaml.Node) []*yaml.Node {
+	var own, merged []*yaml.Node
+	for i := 0; i+1 < len(content); i += 2 {
+		key, value := content[i], content[i+1]
+		if key.Kind != yaml.ScalarNode || key.Tag != "!!merge" {
+			own = append(own, key, value)
+			continue
+		}
+		sources := []*yaml.Node{value}
+		if value.Kind == yaml.SequenceNode {
+			sources = value.Content
+		}
+		for _, src := range sources {
+			if src != nil && src.Kind == yaml.MappingNode {
+				merged = append(merged, src.Content...)
+			}
+		}
+	}
+	if merged == nil {
+		return own
+	}
+	seen := make(map[string]bool, len(own)/2)
+	for i := 0; i+1 < len(own); i += 2 {
+		seen[own[i].Value] = true
+	}
+	for i := 0; i+1 < len(merged); i += 2 {
+		if !seen[merged[i].Value] {
+			seen[merged[i].Value] = true
+			own = append(own, merged[i], merged[i+1])
+		}
+	}
+	return own
+}
+
+// parseGitHubWorkflow extracts the jobs of a GitHub Actions workflow.
+func parseGitHubWorkflow(root *yaml.Node, filePath string, result *ciParseResult) {
+	workflow := yamlScalar(yamlMapValue(root, "name"))
+	if workflow == "" {
+		workflow = strings.TrimSuffix(path.Base(filePath), path.Ext(filePath))
+	}
+	triggers := strings.Join(githubTriggers(yamlMapValue(root, "on")), ",")
+	workflowEnv := yamlMapValue(root, "env")
+
+	jobs := yamlMapValue(root, "jobs")
+	if jobs == nil || jobs.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(jobs.Content); i += 2 {
+		key, node := jobs.Content[i], jobs.Content[i+1]
+		job := CIJob{
+			ID:        GenerateCIJobID(filePath, key.Value),
+			FilePath:  filePath,
+			Workflow:  workflow,
+			Name:      key.Value,
+			Title:     yamlScalar(yamlMapValue(node, "name")),
+			RunsOn:    strings.Join(yamlStrings(yamlMapValue(node, "runs-on")), ","),
+			Needs:     strings.Join(yamlStrings(yamlMapValue(node, "needs")), ","),
+			Triggers:  triggers,
+			StartLine: key.Line,
+		}
+		result.Jobs = append(result.Jobs, job)
+		refs := newCIRefSet(job)
+		refs.addEnvKeys(workflowEnv)
+		refs.addEnvKeys(yamlMapValue(node, "env"))
+		if uses := yamlMapValue(node, "uses"); uses != nil { // reusable workflow
+			refs.add(CIRefAction, uses.Value, uses.Line)
+		}
+
+		steps := yamlMapValue(node, "steps")
+		if steps != nil {
+			for idx, step := range steps.Content {
+				s := CIStep{
+					ID:       GenerateCIStepID(job.ID, idx),
+					JobID:    job.ID,
+					FilePath: filePath,
+					Index:    idx,
+					Name:     yamlScalar(yamlMapValue(step, "name")),
+					Line:     step.Line,
+				}
+				if uses := yamlMapValue(step, "uses"); uses != nil {
+					s.Kind, s.Command = "uses", uses.Value
+					refs.add(CIRefAction, uses.Value, uses.Line)
+				} else if run := yamlMapValue(step, "run"); run != nil {
+					s.Kind, s.Command = "run", run.Value
+					refs.scanCommand(run.Value, run.Line)
+				}
+				refs.addEnvKeys(yamlMapValue(step, "env"))
+				refs.scanExpressions(step)
+				result.Steps = append(result.Steps, s)
+			}
+		}
+		refs.scanExpressions(node)
+		result.Refs = append(result.Refs, refs.refs...)
+	}
+}
+
+// githubTriggers lists the events of a workflow's "on" key, which may be a
+// string, a list or a map.
+func githubTriggers(on *yaml.Node) []string {
+	if on == nil {
+		return nil
+	}
+	if on.Kind == yaml.MappingNode {
+		var events []string
+		for i := 0; i < len(on.Content); i += 2 {
+			events = append(events, on.Content[i].Value)
+		}
+		return events
+	}
+	return yamlStrings(on)
+}
+
+// gitlabReservedKeys are top-level .gitlab-ci.yml keys that are not jobs.
+var gitlabReservedKeys = map[string]bool{
+	"stages": true, "variables": true, "include": true, "default": true, "workflow": true,
+	"image": true, "services": true, "before_script": true, "after_script": true,
+	"cache": true,
+}
+
+// parseGitLabCI extracts the jobs of a .gitlab-ci.yml file. Hidden jobs
+// (".name") are templates and are recorded too, since others extend them.
+func parseGitLabCI(root *yaml.Node, filePath string, result *ciParseResult) {
+	workflow := path.Base(filePath)
+	globalVars := yamlMapValue(root, "variables")
+	defaultImage := yamlScalar(yamlMapValue(root, "image"))
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, node := root.Content[i], root.Content[i+1]
+		if gitlabReservedKeys[key.Value] || node.Kind != yaml.MappingNode {
+			continue
+		}
+		image := yamlScalar(yamlMapValue(node, "image"))
+		if image == "" {
+			image = yamlScalar(yamlMapValue(yamlMapValue(node, "image"), "name"))
+		}
+		if image == "" {
+			image = defaultImage
+		}
+		var needs []string
+		if n := yamlMapValue(node, "needs"); n != nil {
+			for _, item := range n.Content {
+				if item.Kind == yaml.MappingNode {
+					needs = append(needs, yamlScalar(yamlMapValue(item, "job")))
+				} else {
+					needs = append(needs, item.Value)
+				}
+			}
+		}
+		job := CIJob{
+			ID:        GenerateCIJobID(filePath, key.Value),
+			FilePath:  filePath,
+			Workflow:  workflow,
+			Name:      key.Value,
+			Stage:     yamlScalar(yamlMapValue(node, "stage")),
+			RunsOn:    image,
+			Needs:     strings.Join(needs, ","),
+			StartLine: key.Line,
+		}
+		result.Jobs = append(result.Jobs, job)
+		refs := newCIRefSet(job)
+		refs.addEnvKeys(globalVars)
+		refs.addEnvKeys(yamlMapValue(node, "variables"))
+		for _, ext := range yamlStrings(yamlMapValue(node, "extends")) {
+			refs.add(CIRefExtend, ext, key.Line)
+		}
+
+		idx := 0
+		for _, section := range []string{"before_script", "script", "after_script"} {
+			lines := yamlMapValue(node, section)
+			if lines == nil {
+				continue
+			}
+			items := lines.Content
+			if lines.Kind == yaml.ScalarNode {
+				items = []*yaml.Node{lines}
+			}
+			for _, line := range items {
+				result.Steps = append(result.Steps, CIStep{
+					ID:       GenerateCIStepID(job.ID, idx),
+					JobID:    job.ID,
+					FilePath: filePath,
+					Index:    idx,
+					Name:     section,
+					Kind:     "script",
+					Command:  line.Value,
+					Line:     line.Line,
+				})
+				refs.scanCommand(line.Value, line.Line)
+				idx++
+			}
+		}
+		result.Refs = append(result.Refs, refs.refs...)
+	}
+}
+
+var (
+	ciScriptPath  = regexp.MustCompile(`(?:^|[\s;&|(])((?:\./)?[\w.-]+(?:/[\w.-]+)*\.(?:sh|bash|py|rb|js|ts|ps1|pl))\b`)
+	ciMakeTarget  = regexp.MustCompile(`(?:^|[\s;&|(])make\s+((?:-\S+\s+)*)([\w./-]+)`)
+	ciShellVar    = regexp.MustCompile(`\$\{?([A-Z][A-Z0-9_]+)\}?`)
+	ciExpression  = regexp.MustCompile(`\$\{\{\s*(env|secrets|vars)\.([A-Za-z_]\w*)\s*\}\}`)
+	ciIgnoredVars = map[string]bool{"HOME": true, "PATH": true, "PWD": true, "USER": true, "SHELL": true}
+)
+
+// ciRefSet collects a job's references, keeping the first line of each.
+type ciRefSet struct {
+	job  CIJob
+	seen map[string]bool
+	refs []CIRef
+}
+
+func newCIRefSet(job CIJob) *ciRefSet {
+	return &ciRefSet{job: job, seen: make(map[string]bool)}
+}
+
+func (s *ciRefSet) add(kind, name string, line int) {
+	if name == "" || s.seen[kind+"|"+name] {
+		return
+	}
+	s.seen[kind+"|"+name] = true
+	s.refs = append(s.refs, CIRef{
+		ID:       GenerateCIRefID(s.job.ID, kind, name),
+		JobID:    s.job.ID,
+		FilePath: s.job.FilePath,
+		Kind:     kind,
+		Name:     name,
+		Line:     line,
+	})
+}
+
+// addEnvKeys records the variables an env:/variables: mapping sets.
+func (s *ciRefSet) addEnvKeys(env *yaml.Node) {
+	if env == nil || env.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i < len(env.Content); i += 2 {
+		s.add(CIRefEnv, env.Content[i].Value, env.Content[i].Line)
+	}
+}
+
+// scanCommand records the scripts, make targets and variables a shell
+// command uses. Lines of a block scalar follow the key's line.
+func (s *ciRefSet) scanCommand(command string, line int) {
+	for i, text := range strings.Split(command, "\n") {
+		if strings.Contains(command, "\n") {
+			i++ // block scalar content starts on the line after "run: |"
+		}
+		for _, m := range ciScriptPath.FindAllStringSubmatch(text, -1) {
+			s.add(CIRefScript, strings.TrimPrefix(m[1], "./"), line+i)
+		}
+		for _, m := range ciMakeTarget.FindAllStringSubmatch(text, -1) {
+			s.add(CIRefMake, m[2], line+i)
+		}
+		for _, m := range ciShellVar.FindAllStringSubmatch(text, -1) {
+			if !ciIgnoredVars[m[1]] {
+				s.add(CIRefEnv, m[1], line+i)
+			}
+		}
+		s.scanExpressionText(text, line+i)
+	}
+}
+
+// scanExpressions records ${{ env.X }}, ${{ vars.X }} and ${{ secrets.X }}
+// in the scalar values of node (with:, env:, if:, ...).
+func (s *ciRefSet) scanExpressions(node *yaml.Node) {
+	if node == nil {
+		return
+	}
+	if node.Kind == yaml.ScalarNode {
+		s.scanExpressionText(node.Value, node.Line)
+		return
+	}
+	for _, child := range node.Content {
+		s.scanExpressions(child)
+	}
+}
+
+func (s *ciRefSet) scanExpressionText(text string, line int) {
+	for _, m := range ciExpression.FindAllStringSubmatch(text, -1) {
+		if m[1] == "secrets" {
+			s.add(CIRefSecret, m[2], line)
+		} else {
+			s.add(CIRefEnv, m[2], line)
+		}
+	}
+}
+
+// yamlMapValue returns the value of key in a mapping node, or nil.
+func yamlMapValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// yamlScalar returns a scalar node's value, or "" for nil and collections.
+func yamlScalar(node *yaml.Node) string {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return node.Value
+}
+
+// yamlStrings returns a scalar or a sequence of scalars as strings, sorted
+// for maps (e.g., runs-on: {group: x}).
+func yamlStrings(node *yaml.Node) []string {
+	if node == nil {
+		return nil
+	}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return []string{node.Value}
+	case yaml.SequenceNode:
+		var out []string
+		for _, item := range node.Content {
+			if item.Kind == yaml.ScalarNode {
+				out = append(out, item.Value)
+			}
+		}
+		return out
+	case yaml.MappingNode:
+		var out []string
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			out = append(out, node.Content[i].Value+"="+yamlScalar(node.Content[i+1]))
+		}
+		sort.Strings(out)
+		return out
+	}
+	return nil
+}
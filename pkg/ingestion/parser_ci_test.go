@@ -0,0 +1,216 @@
+package ingestion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ciRefNames groups a job's references by kind.
+func ciRefNames(refs []CIRef, jobID string) map[string][]string {
+	names := make(map[string][]string)
+	for _, r := range refs {
+		if r.JobID == jobID {
+			names[r.Kind] = append(names[r.Kind], r.Name)
+		}
+	}
+	return names
+}
+
+func TestCILanguage(t *testing.T) {
+	assert.Equal(t, "github_actions", ciLanguage(".github/workflows/ci.yml"))
+	assert.Equal(t, "github_actions", ciLanguage("svc/.github/workflows/release.yaml"))
+	assert.Equal(t, "gitlab_ci", ciLanguage(".gitlab-ci.yml"))
+	assert.Equal(t, "gitlab_ci", ciLanguage("ci/templates/build.gitlab-ci.yml"))
+	assert.Equal(t, "", ciLanguage("config/app.yml"))
+	assert.Equal(t, "", ciLanguage(".github/dependabot.yml"))
+	assert.Equal(t, "gitlab_ci", detectLanguageFromPath(".gitlab-ci.yml"))
+}
+
+func TestParseCIWorkflow_GitHubActions(t *testing.T) {
+	content := `name: CI
+on:
+  push:
+    branches: [main]
+  pull_request:
+env:
+  GO_VERSION: "1.24"
+jobs:
+  unit:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - name: Test
+        run: go test ./...
+  integration-tests:
+    name: Integration tests
+    runs-on: [self-hosted, linux]
+    needs: unit
+    steps:
+      - name: Start database
+        run: ./scripts/start-db.sh
+        env:
+          DATABASE_URL: ${{ secrets.DATABASE_URL }}
+      - name: Run
+        run: |
+          make test-integration
+          echo "$GITHUB_SHA done"
+`
+	result, err := parseCIWorkflow([]byte(content), ".github/workflows/ci.yml", "github_actions")
+	require.NoError(t, err)
+	require.Len(t, result.Jobs, 2)
+
+	integ := result.Jobs[1]
+	assert.Equal(t, "CI", integ.Workflow)
+	assert.Equal(t, "integration-tests", integ.Name)
+	assert.Equal(t, "Integration tests", integ.Title)
+	assert.Equal(t, "self-hosted,linux", integ.RunsOn)
+	assert.Equal(t, "unit", integ.Needs)
+	assert.Equal(t, "push,pull_request", integ.Triggers)
+	assert.Equal(t, 15, integ.StartLine)
+
+	require.Len(t, result.Steps, 4)
+	assert.Equal(t, "uses", result.Steps[0].Kind)
+	assert.Equal(t, "actions/checkout@v4", result.Steps[0].Command)
+	assert.Equal(t, integ.ID, result.Steps[3].JobID)
+	assert.Equal(t, 1, result.Steps[3].Index)
+	assert.Contains(t, result.Steps[3].Command, "make test-integration")
+
+	names := ciRefNames(result.Refs, integ.ID)
+	assert.Equal(t, []string{"scripts/start-db.sh"}, names[CIRefScript])
+	assert.Equal(t, []string{"test-integration"}, names[CIRefMake])
+	assert.Equal(t, []string{"GO_VERSION", "DATABASE_URL", "GITHUB_SHA"}, names[CIRefEnv])
+	assert.Equal(t, []string{"DATABASE_URL"}, names[CIRefSecret])
+	assert.Equal(t, []string{"actions/checkout@v4"}, ciRefNames(result.Refs, result.Jobs[0].ID)[CIRefAction])
+
+	for _, r := range result.Refs {
+		if r.Kind == CIRefMake {
+			assert.Equal(t, 26, r.Line, "block scalar lines are counted from the line after run:")
+		}
+	}
+}
+
+func TestParseCIWorkflow_GitLab(t *testing.T) {
+	content := `stages: [test, deploy]
+variables:
+  POSTGRES_DB: app
+image: golang:1.24
+
+.go-cache:
+  cache:
+    paths: [.cache]
+
+integration:
+  stage: test
+  extends: .go-cache
+  services: [postgres:16]
+  before_script:
+    - bash scripts/wait-for-db.sh
+  script:
+    - go test -tags integration ./...
+
+deploy:
+  stage: deploy
+  image:
+    name: alpine:3
+  needs:
+    - job: integration
+  script: ./deploy.sh $DEPLOY_TOKEN
+`
+	result, err := parseCIWorkflow([]byte(content), ".gitlab-ci.yml", "gitlab_ci")
+	require.NoError(t, err)
+	require.Len(t, result.Jobs, 3, "hidden template jobs are recorded too")
+
+	integ, deploy := result.Jobs[1], result.Jobs[2]
+	assert.Equal(t, "integration", integ.Name)
+	assert.Equal(t, "test", integ.Stage)
+	assert.Equal(t, "golang:1.24", integ.RunsOn, "default image applies")
+	assert.Equal(t, "alpine:3", deploy.RunsOn)
+	assert.Equal(t, "integration", deploy.Needs)
+
+	var integSteps []CIStep
+	for _, s := range result.Steps {
+		if s.JobID == integ.ID {
+			integSteps = append(integSteps, s)
+		}
+	}
+	require.Len(t, integSteps, 2)
+	assert.Equal(t, "before_script", integSteps[0].Name)
+	assert.Equal(t, "script", integSteps[1].Name)
+	assert.Equal(t, "go test -tags integration ./...", integSteps[1].Command)
+	assert.Equal(t, 17, integSteps[1].Line)
+
+	names := ciRefNames(result.Refs, integ.ID)
+	assert.Equal(t, []string{".go-cache"}, names[CIRefExtend])
+	assert.Equal(t, []string{"scripts/wait-for-db.sh"}, names[CIRefScript])
+	assert.Equal(t, []string{"POSTGRES_DB"}, names[CIRefEnv])
+
+	names = ciRefNames(result.Refs, deploy.ID)
+	assert.Equal(t, []string{"deploy.sh"}, names[CIRefScript])
+	assert.Equal(t, []string{"POSTGRES_DB", "DEPLOY_TOKEN"}, names[CIRefEnv])
+}
+
+func TestParseCIWorkflow_AnchorsAndMergeKeys(t *testing.T) {
+	content := `.defaults: &defaults
+  image: golang:1.24
+  before_script:
+    - bash scripts/setup.sh
+  variables: &vars
+    GOFLAGS: -mod=mod
+
+unit:
+  <<: *defaults
+  stage: test
+  script:
+    - make test
+
+lint:
+  <<: [*defaults]
+  image: golangci/golangci-lint
+  variables: *vars
+  script:
+    - make lint
+`
+	result, err := parseCIWorkflow([]byte(content), ".gitlab-ci.yml", "gitlab_ci")
+	require.NoError(t, err)
+	require.Len(t, result.Jobs, 3)
+
+	unit, lint := result.Jobs[1], result.Jobs[2]
+	assert.Equal(t, "golang:1.24", unit.RunsOn, "merged image applies")
+	assert.Equal(t, "golangci/golangci-lint", lint.RunsOn, "own keys win over merged ones")
+
+	var unitSteps []string
+	for _, s := range result.Steps {
+		if s.JobID == unit.ID {
+			unitSteps = append(unitSteps, s.Name+": "+s.Command)
+		}
+	}
+	assert.Equal(t, []string{"before_script: bash scripts/setup.sh", "script: make test"}, unitSteps)
+	assert.Equal(t, []string{"GOFLAGS"}, ciRefNames(result.Refs, lint.ID)[CIRefEnv])
+	assert.Equal(t, []string{"scripts/setup.sh"}, ciRefNames(result.Refs, unit.ID)[CIRefScript])
+}
+
+func TestParseCIWorkflow_MultipleDocuments(t *testing.T) {
+	content := `build:
+  script: make build
+---
+test:
+  script: make test
+`
+	result, err := parseCIWorkflow([]byte(content), ".gitlab-ci.yml", "gitlab_ci")
+	require.NoError(t, err)
+	require.Len(t, result.Jobs, 2)
+	assert.Equal(t, "build", result.Jobs[0].Name)
+	assert.Equal(t, "test", result.Jobs[1].Name)
+	assert.Equal(t, 4, result.Jobs[1].StartLine, "lines count from the top of the file")
+}
+
+func TestParseCIWorkflow_InvalidYAML(t *testing.T) {
+	_, err := parseCIWorkflow([]byte("jobs: [unclosed"), ".github/workflows/ci.yml", "github_actions")
+	assert.Error(t, err)
+
+	result, err := parseCIWorkflow([]byte(""), ".gitlab-ci.yml", "gitlab_ci")
+	require.NoError(t, err)
+	assert.Empty(t, result.Jobs)
+}
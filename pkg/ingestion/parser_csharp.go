@@ -0,0 +1,627 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package ingestion
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// =============================================================================
+// C# PARSER
+// =============================================================================
+
+// csharpParseResult contains all extracted data from C# parsing.
+type csharpParseResult struct {
+	Functions       []FunctionEntity
+	Types           []TypeEntity
+	Fields          []FieldEntity
+	Calls           []CallsEdge
+	Imports         []ImportEntity
+	UnresolvedCalls []UnresolvedCall
+	Namespace       string // First namespace declared in the file
+}
+
+// csharpFunction pairs a function entity with what its calls are resolved
+// against: its AST node, its declaring type and its parameter types.
+type csharpFunction struct {
+	entity   FunctionEntity
+	node     *sitter.Node
+	typeName string
+	params   map[string]string
+}
+
+// csharpWalk carries the state of a declaration walk over one file.
+type csharpWalk struct {
+	p         *TreeSitterParser
+	content   []byte
+	filePath  string
+	result    *csharpParseResult
+	functions []csharpFunction
+	members   map[string]map[string]string // type → field/property → its type
+}
+
+// parseCSharpAST extracts classes, interfaces, methods, properties, and call
+// relationships from C# source using Tree-sitter.
+//
+// Extracts:
+//   - Classes, structs, records, interfaces and enums
+//   - Methods and constructors, named "Type.Method" ("Type.Type" for constructors)
+//   - Properties with accessor bodies or expression bodies, named "Type.Property"
+//   - Fields and properties with their types, as FieldEntity
+//   - using directives, as imports: "." for plain usings, the alias for
+//     "using A = X.Y;" and "static" for "using static X.Y;"
+//   - Calls within the file; other calls are returned as unresolved, with a
+//     receiver of known type replaced by the type ("_repo.Find" → "IRepo.Find")
+//
+// Function IDs include the namespace ("MyApp.Services.UserService.Get"), and
+// the file's namespace is returned so the CallResolver can look calls up
+// through enclosing namespaces and using directives.
+func (p *TreeSitterParser) parseCSharpAST(parser *sitter.Parser, content []byte, filePath string) (*csharpParseResult, error) {
+	tree, err := parser.ParseCtx(context.Background(), nil, content)
+	if err != nil {
+		return nil, fmt.Errorf("tree-sitter parse: %w", err)
+	}
+	defer tree.Close()
+
+	rootNode := tree.RootNode()
+	if rootNode.HasError() {
+		if errorCount := countErrors(rootNode); errorCount > 0 {
+			p.logger.Warn("parser.treesitter.csharp.syntax_errors",
+				"path", filePath,
+				"error_count", errorCount,
+			)
+			p.syntaxErrors.record(filePath, errorCount)
+		}
+	}
+
+	w := &csharpWalk{
+		p:        p,
+		content:  content,
+		filePath: filePath,
+		result:   &csharpParseResult{},
+		members:  make(map[string]map[string]string),
+	}
+	w.walkDeclarations(rootNode, "", "")
+
+	funcNameToID := make(map[string]string)
+	for _, fn := range w.functions {
+		if _, exists := funcNameToID[fn.entity.Name]; !exists {
+			funcNameToID[fn.entity.Name] = fn.entity.ID // First overload wins
+		}
+	}
+	for _, fn := range w.functions {
+		w.result.Functions = append(w.result.Functions, fn.entity)
+		w.extractCalls(fn, funcNameToID)
+	}
+	return w.result, nil
+}
+
+// walkDeclarations walks namespace and type declarations. ns is the
+// enclosing namespace and typeName the enclosing type, if any.
+func (w *csharpWalk) walkDeclarations(node *sitter.Node, ns, typeName string) {
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+		switch child.Type() {
+		case "using_directive":
+			if imp := w.extractUsing(child); imp != nil {
+				w.result.Imports = append(w.result.Imports, *imp)
+			}
+		case "file_scoped_namespace_declaration":
+			// Applies to the declarations that follow it
+			ns = joinCSharpName(ns, w.text(child.ChildByFieldName("name")))
+			w.recordNamespace(ns)
+		case "namespace_declaration":
+			inner := joinCSharpName(ns, w.text(child.ChildByFieldName("name")))
+			w.recordNamespace(inner)
+			if body := child.ChildByFieldName("body"); body != nil {
+				w.walkDeclarations(body, inner, "")
+			}
+		case "class_declaration", "struct_declaration", "record_declaration",
+			"record_struct_declaration", "interface_declaration":
+			w.extractType(child, ns)
+		case "enum_declaration":
+			w.appendType(child, "enum")
+		case "method_declaration", "constructor_declaration":
+			if typeName != "" {
+				w.extractMethod(child, ns, typeName)
+			}
+		case "property_declaration":
+			if typeName != "" {
+				w.extractProperty(child, ns, typeName)
+			}
+		case "field_declaration":
+			if typeName != "" {
+				w.extractFields(child, typeName)
+			}
+		}
+	}
+}
+
+// recordNamespace keeps the first namespace of the file as its namespace.
+func (w *csharpWalk) recordNamespace(ns string) {
+	if w.result.Namespace == "" {
+		w.result.Namespace = ns
+	}
+}
+
+// extractUsing turns a using directive into an import.
+func (w *csharpWalk) extractUsing(node *sitter.Node) *ImportEntity {
+	alias := "."
+	var target *sitter.Node
+	for i := 0; i < int(node.ChildCount()); i++ {
+		child := node.Child(i)
+		switch {
+		case child.Type() == "static":
+			alias = "static"
+		case node.FieldNameForChild(i) == "name":
+			alias = w.text(child)
+		case child.Type() == "identifier" || child.Type() == "qualified_name":
+			target = child
+		}
+	}
+	if target == nil {
+		return nil
+	}
+	importPath := w.text(target)
+	return &ImportEntity{
+		ID:         GenerateImportID(w.filePath, importPath),
+		FilePath:   w.filePath,
+		ImportPath: importPath,
+		Alias:      alias,
+		StartLine:  int(node.StartPoint().Row) + 1,
+	}
+}
+
+// extractType records a class, struct, record or interface and walks its
+// members. Nested types are named without their enclosing type.
+func (w *csharpWalk) extractType(node *sitter.Node, ns string) {
+	kind := strings.TrimSuffix(node.Type(), "_declaration")
+	switch kind {
+	case "record_struct":
+		kind = "struct"
+	case "record":
+		if hasCSharpKeyword(node, "struct") {
+			kind = "struct"
+		}
+	}
+	te := w.appendType(node, kind)
+	if te == nil {
+		return
+	}
+	if w.members[te.Name] == nil {
+		w.members[te.Name] = make(map[string]string)
+	}
+
+	// Positional record parameters are properties
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		if child := node.NamedChild(i); child.Type() == "parameter_list" {
+			for name, typ := range w.parameters(child) {
+				w.addField(te.Name, name, typ, child)
+			}
+		}
+	}
+	if body := node.ChildByFieldName("body"); body != nil {
+		w.walkDeclarations(body, ns, te.Name)
+	}
+}
+
+// appendType adds a TypeEntity of the given kind for node.
+func (w *csharpWalk) appendType(node *sitter.Node, kind string) *TypeEntity {
+	name := w.text(node.ChildByFieldName("name"))
+	if name == "" {
+		return nil
+	}
+	startLine := int(node.StartPoint().Row) + 1
+	endLine := int(node.EndPoint().Row) + 1
+	w.result.Types = append(w.result.Types, TypeEntity{
+		ID:        GenerateTypeID(w.filePath, name, startLine, endLine),
+		Name:      name,
+		Kind:      kind,
+		FilePath:  w.filePath,
+		CodeText:  w.p.truncateCodeText(w.filePath, w.text(node)),
+		StartLine: startLine,
+		EndLine:   endLine,
+		StartCol:  int(node.StartPoint().Column) + 1,
+		EndCol:    int(node.EndPoint().Column) + 1,
+	})
+	return &w.result.Types[len(w.result.Types)-1]
+}
+
+// extractMethod records a method or constructor of typeName.
+func (w *csharpWalk) extractMethod(node *sitter.Node, ns, typeName string) {
+	name := w.text(node.ChildByFieldName("name"))
+	if name == "" {
+		return
+	}
+	stop := node.ChildByFieldName("body")
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		if child := node.NamedChild(i); child.Type() == "constructor_initializer" {
+			stop = child
+		}
+	}
+	var params map[string]string
+	if paramsNode := node.ChildByFieldName("parameters"); paramsNode != nil {
+		params = w.parameters(paramsNode)
+	}
+	w.appendFunction(node, ns, typeName, typeName+"."+name, w.signature(node, stop), params)
+}
+
+// extractProperty records a property as a field of typeName, and as a
+// function too when its accessors have code.
+func (w *csharpWalk) extractProperty(node *sitter.Node, ns, typeName string) {
+	name := w.text(node.ChildByFieldName("name"))
+	if name == "" {
+		return
+	}
+	w.addField(typeName, name, csharpTypeName(node.ChildByFieldName("type"), w.content), node)
+
+	stop := node.ChildByFieldName("value") // => expression
+	if stop == nil {
+		accessors := node.ChildByFieldName("accessors")
+		if accessors == nil || !hasAccessorBody(accessors) {
+			return // auto-property
+		}
+		stop = accessors
+	}
+	w.appendFunction(node, ns, typeName, typeName+"."+name, w.signature(node, stop), nil)
+}
+
+// hasAccessorBody reports whether any get/set/init accessor has code.
+func hasAccessorBody(accessors *sitter.Node) bool {
+	for i := 0; i < int(accessors.NamedChildCount()); i++ {
+		if accessors.NamedChild(i).ChildByFieldName("body") != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// extractFields records every variable of a field declaration.
+func (w *csharpWalk) extractFields(node *sitter.Node, typeName string) {
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		decl := node.NamedChild(i)
+		if decl.Type() != "variable_declaration" {
+			continue
+		}
+		fieldType := csharpTypeName(decl.ChildByFieldName("type"), w.content)
+		for j := 0; j < int(decl.NamedChildCount()); j++ {
+			if v := decl.NamedChild(j); v.Type() == "variable_declarator" {
+				w.addField(typeName, w.text(v.ChildByFieldName("name")), fieldType, v)
+			}
+		}
+	}
+}
+
+// addField records a field or property of typeName.
+func (w *csharpWalk) addField(typeName, name, fieldType string, node *sitter.Node) {
+	if name == "" {
+		return
+	}
+	if w.members[typeName] == nil {
+		w.members[typeName] = make(map[string]string)
+	}
+	w.members[typeName][name] = fieldType
+	w.result.Fields = append(w.result.Fields, FieldEntity{
+		StructName: typeName,
+		FieldName:  name,
+		FieldType:  fieldType,
+		FilePath:   w.filePath,
+		Line:       int(node.StartPoint().Row) + 1,
+	})
+}
+
+// appendFunction adds a function named name, declared in typeName.
+func (w *csharpWalk) appendFunction(node *sitter.Node, ns, typeName, name, signature string, params map[string]string) {
+	startLine := int(node.StartPoint().Row) + 1
+	endLine := int(node.EndPoint().Row) + 1
+	startCol := int(node.StartPoint().Column) + 1
+	endCol := int(node.EndPoint().Column) + 1
+
+	fn := FunctionEntity{
+		ID:        GenerateFunctionID(w.filePath, joinCSharpName(ns, name), signature, startLine, endLine, startCol, endCol),
+		Name:      name,
+		Signature: signature,
+		FilePath:  w.filePath,
+		CodeText:  w.p.truncateCodeText(w.filePath, w.text(node)),
+		StartLine: startLine,
+		EndLine:   endLine,
+		StartCol:  startCol,
+		EndCol:    endCol,
+	}
+	w.functions = append(w.functions, csharpFunction{entity: fn, node: node, typeName: typeName, params: params})
+}
+
+// signature returns the declaration header of node up to stop (the body),
+// without attributes and with whitespace collapsed.
+func (w *csharpWalk) signature(node, stop *sitter.Node) string {
+	start := node.StartByte()
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+		if child.Type() != "attribute_list" {
+			start = child.StartByte()
+			break
+		}
+	}
+	end := node.EndByte()
+	if stop != nil {
+		end = stop.StartByte()
+	}
+	sig := strings.TrimSuffix(strings.TrimSpace(string(w.content[start:end])), ";")
+	return strings.Join(strings.Fields(sig), " ")
+}
+
+// parameters maps parameter names to their type names.
+func (w *csharpWalk) parameters(list *sitter.Node) map[string]string {
+	params := make(map[string]string)
+	for i := 0; i < int(list.NamedChildCount()); i++ {
+		param := list.NamedChild(i)
+		if param.Type() != "parameter" {
+			continue
+		}
+		if name := w.text(param.ChildByFieldName("name")); name != "" {
+			params[name] = csharpTypeName(param.ChildByFieldName("type"), w.content)
+		}
+	}
+	return params
+}
+
+// text returns the source of node, or "" for nil.
+func (w *csharpWalk) text(node *sitter.Node) string {
+	if node == nil {
+		return ""
+	}
+	return string(w.content[node.StartByte():node.EndByte()])
+}
+
+// =============================================================================
+// C# CALL EXTRACTION
+// =============================================================================
+
+// extractCalls records the calls made in fn: an edge when the callee is in
+// this file, an unresolved call otherwise.
+func (w *csharpWalk) extractCalls(fn csharpFunction, funcNameToID map[string]string) {
+	vars := make(map[string]string) // name → type; "" when unknown
+	for name, typ := range w.members[fn.typeName] {
+		vars[name] = typ
+	}
+	for name, typ := range fn.params {
+		vars[name] = typ
+	}
+	w.collectLocals(fn.node, vars)
+
+	seen := make(map[string]bool)
+	var walk func(node *sitter.Node)
+	walk = func(node *sitter.Node) {
+		if callee := w.calleeName(node, fn.typeName, vars); callee != "" && !seen[callee] {
+			seen[callee] = true
+			if calleeID, ok := funcNameToID[callee]; ok {
+				if calleeID != fn.entity.ID {
+					w.result.Calls = append(w.result.Calls, CallsEdge{CallerID: fn.entity.ID, CalleeID: calleeID})
+				}
+			} else if node.Type() == "object_creation_expression" && w.members[strings.SplitN(callee, ".", 2)[0]] != nil {
+				// Implicit constructor of a type declared in this file
+			} else {
+				w.result.UnresolvedCalls = append(w.result.UnresolvedCalls, UnresolvedCall{
+					CallerID:   fn.entity.ID,
+					CalleeName: strings.TrimPrefix(callee, fn.typeName+"."),
+					FilePath:   w.filePath,
+					Line:       int(node.StartPoint().Row) + 1,
+				})
+			}
+		}
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			walk(node.NamedChild(i))
+		}
+	}
+	for i := 0; i < int(fn.node.NamedChildCount()); i++ {
+		child := fn.node.NamedChild(i)
+		switch child.Type() {
+		case "block", "arrow_expression_clause", "accessor_list":
+			walk(child)
+		}
+	}
+}
+
+// collectLocals adds the local variables and foreach variables declared in
+// node, typed by their declaration or by the object they are created from.
+func (w *csharpWalk) collectLocals(node *sitter.Node, vars map[string]string) {
+	switch node.Type() {
+	case "variable_declaration":
+		declType := csharpTypeName(node.ChildByFieldName("type"), w.content)
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			v := node.NamedChild(i)
+			if v.Type() != "variable_declarator" {
+				continue
+			}
+			typ := declType
+			if typ == "" {
+				typ = w.createdType(v)
+			}
+			vars[w.text(v.ChildByFieldName("name"))] = typ
+		}
+	case "foreach_statement":
+		if left := node.ChildByFieldName("left"); left != nil && left.Type() == "identifier" {
+			vars[w.text(left)] = csharpTypeName(node.ChildByFieldName("type"), w.content)
+		}
+	}
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		w.collectLocals(node.NamedChild(i), vars)
+	}
+}
+
+// createdType returns the type of "var x = new T(...)", or "".
+func (w *csharpWalk) createdType(declarator *sitter.Node) string {
+	for i := 0; i < int(declarator.NamedChildCount()); i++ {
+		if child := declarator.NamedChild(i); child.Type() == "object_creation_expression" {
+			return csharpTypeName(child.ChildByFieldName("type"), w.content)
+		}
+	}
+	return ""
+}
+
+// calleeName returns the function a call or object creation refers to,
+// qualified by the type it is looked up on ("Type.Method", "Type.Type" for
+// constructors), or "" when node is neither or the receiver's type is
+// unknown. Unqualified calls are qualified with typeName, the caller's type.
+func (w *csharpWalk) calleeName(node *sitter.Node, typeName string, vars map[string]string) string {
+	switch node.Type() {
+	case "object_creation_expression":
+		typ := csharpTypeName(node.ChildByFieldName("type"), w.content)
+		if typ == "" {
+			return ""
+		}
+		return typ + "." + typ[strings.LastIndex(typ, ".")+1:]
+	case "invocation_expression":
+	default:
+		return ""
+	}
+
+	function := node.ChildByFieldName("function")
+	if function == nil {
+		return ""
+	}
+	switch function.Type() {
+	case "identifier", "generic_name":
+		name := csharpSimpleName(function, w.content)
+		if _, isVar := vars[name]; isVar || name == "nameof" {
+			return "" // delegate invocation or nameof(x)
+		}
+		return typeName + "." + name
+	case "member_access_expression":
+		method := csharpSimpleName(function.ChildByFieldName("name"), w.content)
+		receiver := w.receiverType(function.ChildByFieldName("expression"), typeName, vars)
+		if method == "" || receiver == "" {
+			return ""
+		}
+		return receiver + "." + method
+	}
+	return ""
+}
+
+// receiverType returns the type a member is accessed on: the declared type
+// of a variable, field or property, or the type or namespace named by a
+// capitalized (dotted) name. Returns "" when unknown.
+func (w *csharpWalk) receiverType(expr *sitter.Node, typeName string, vars map[string]string) string {
+	if expr == nil {
+		return ""
+	}
+	switch expr.Type() {
+	case "this":
+		return typeName
+	case "identifier":
+		name := w.text(expr)
+		if typ, isVar := vars[name]; isVar {
+			return typ
+		}
+		if r := []rune(name); len(r) > 0 && unicode.IsUpper(r[0]) {
+			return name // static call on a type
+		}
+	case "member_access_expression":
+		inner := expr.ChildByFieldName("expression")
+		name := w.text(expr.ChildByFieldName("name"))
+		if inner != nil && inner.Type() == "this" {
+			return w.members[typeName][name]
+		}
+		// Namespace- or type-qualified name: MyApp.Util.Log
+		prefix := w.receiverType(inner, typeName, vars)
+		if prefix == "" || prefix != w.text(inner) || name == "" {
+			return ""
+		}
+		return prefix + "." + name
+	}
+	return ""
+}
+
+// csharpTypeName returns the name of a type node without type arguments or
+// nullability ("List<User>" → "List", "IRepo?" → "IRepo"). Returns "" for
+// predefined types, var, arrays, tuples and pointers, whose methods are
+// never project code.
+func csharpTypeName(node *sitter.Node, content []byte) string {
+	if node == nil {
+		return ""
+	}
+	switch node.Type() {
+	case "identifier":
+		return node.Content(content)
+	case "generic_name":
+		return csharpSimpleName(node, content)
+	case "qualified_name":
+		left := csharpTypeName(node.ChildByFieldName("qualifier"), content)
+		right := csharpTypeName(node.ChildByFieldName("name"), content)
+		if left == "" || right == "" {
+			return ""
+		}
+		return left + "." + right
+	case "nullable_type":
+		if inner := node.ChildByFieldName("type"); inner != nil {
+			return csharpTypeName(inner, content)
+		}
+		if node.NamedChildCount() > 0 {
+			return csharpTypeName(node.NamedChild(0), content)
+		}
+	}
+	return ""
+}
+
+// csharpSimpleName returns an identifier, or the identifier of a generic
+// name ("Validate<int>" → "Validate").
+func csharpSimpleName(node *sitter.Node, content []byte) string {
+	if node == nil {
+		return ""
+	}
+	if node.Type() == "generic_name" {
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			if child := node.NamedChild(i); child.Type() == "identifier" {
+				return child.Content(content)
+			}
+		}
+		return ""
+	}
+	if node.Type() == "identifier" {
+		return node.Content(content)
+	}
+	return ""
+}
+
+// hasCSharpKeyword reports whether node has the keyword as a direct child.
+func hasCSharpKeyword(node *sitter.Node, keyword string) bool {
+	for i := 0; i < int(node.ChildCount()); i++ {
+		if node.Child(i).Type() == keyword {
+			return true
+		}
+	}
+	return false
+}
+
+// joinCSharpName joins a namespace and a name with a dot, either possibly empty.
+func joinCSharpName(ns, name string) string {
+	switch {
+	case ns == "":
+		return name
+	case name == "":
+		return ns
+	}
+	return ns + "." + name
+}
@@ -0,0 +1,198 @@
+package ingestion
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseCSharpTestFile is a helper that reads a C# test fixture and parses it.
+func parseCSharpTestFile(t *testing.T, fixturePath string) *ParseResult {
+	t.Helper()
+
+	code, err := os.ReadFile(fixturePath)
+	require.NoError(t, err, "Failed to read test fixture: %s", fixturePath)
+
+	tmpFile := filepath.Join(t.TempDir(), filepath.Base(fixturePath))
+	err = os.WriteFile(tmpFile, code, 0644)
+	require.NoError(t, err, "Failed to write temp file")
+
+	parser := NewTreeSitterParser(nil)
+	result, err := parser.ParseFile(FileInfo{
+		Path:     "src/" + filepath.Base(fixturePath),
+		FullPath: tmpFile,
+		Size:     int64(len(code)),
+		Language: "csharp",
+	})
+	require.NoError(t, err, "Parser should not error on valid C# code")
+
+	return result
+}
+
+// TestCSharpParser_Types tests class, interface, record and enum extraction.
+func TestCSharpParser_Types(t *testing.T) {
+	result := parseCSharpTestFile(t, "testdata/csharp/UserService.cs")
+
+	kinds := make(map[string]string)
+	for _, te := range result.Types {
+		kinds[te.Name] = te.Kind
+	}
+	assert.Equal(t, map[string]string{
+		"IUserService": "interface",
+		"UserService":  "class",
+		"UserCache":    "class",
+		"UserView":     "record",
+		"Role":         "enum",
+	}, kinds)
+	assert.Equal(t, "MyApp.Services", result.PackageName)
+	assert.Equal(t, "csharp", detectLanguageFromPath("src/UserService.cs"))
+}
+
+// TestCSharpParser_Functions tests method, constructor and property extraction.
+func TestCSharpParser_Functions(t *testing.T) {
+	result := parseCSharpTestFile(t, "testdata/csharp/UserService.cs")
+
+	signatures := make(map[string]string)
+	for _, fn := range result.Functions {
+		signatures[fn.Name] = fn.Signature
+		assert.Equal(t, "csharp", fn.Language)
+	}
+	assert.Equal(t, map[string]string{
+		"IUserService.Get":        "User Get(int id)",
+		"UserService.Name":        "public string Name",
+		"UserService.UserService": "public UserService(IUserRepository repo)",
+		"UserService.Get":         "public User Get(int id)",
+		"UserService.Validate":    "private void Validate(int id)",
+		"UserService.Total":       "public int Total",
+		"UserCache.Warm":          "public void Warm()",
+	}, signatures, "auto-properties are fields only; attributes are left out of signatures")
+}
+
+// TestCSharpParser_Fields tests that fields, properties and positional
+// record parameters are recorded with their types.
+func TestCSharpParser_Fields(t *testing.T) {
+	result := parseCSharpTestFile(t, "testdata/csharp/UserService.cs")
+
+	fields := make(map[string]string)
+	for _, f := range result.Fields {
+		fields[f.StructName+"."+f.FieldName] = f.FieldType
+	}
+	assert.Equal(t, "IUserRepository", fields["UserService._repo"])
+	assert.Equal(t, "", fields["UserService.Count"], "predefined types have no type name")
+	assert.Contains(t, fields, "IUserService.Name")
+	assert.Contains(t, fields, "UserView.Id")
+}
+
+// TestCSharpParser_Usings tests that using directives become imports.
+func TestCSharpParser_Usings(t *testing.T) {
+	result := parseCSharpTestFile(t, "testdata/csharp/UserService.cs")
+
+	aliases := make(map[string]string)
+	for _, imp := range result.Imports {
+		aliases[imp.ImportPath] = imp.Alias
+	}
+	assert.Equal(t, map[string]string{
+		"System":            ".",
+		"MyApp.Data":        ".",
+		"MyApp.Util.Logger": "Log",
+		"MyApp.Util.Guard":  "static",
+	}, aliases)
+}
+
+// TestCSharpParser_Calls tests same-file call edges and the calls left to
+// the resolver, with receivers replaced by their types. Implicit
+// constructors of types in the file are not calls.
+func TestCSharpParser_Calls(t *testing.T) {
+	result := parseCSharpTestFile(t, "testdata/csharp/UserService.cs")
+
+	ids := make(map[string]string)
+	names := make(map[string]string)
+	for _, fn := range result.Functions {
+		ids[fn.Name] = fn.ID
+		names[fn.ID] = fn.Name
+	}
+
+	var edges []string
+	for _, call := range result.Calls {
+		edges = append(edges, names[call.CallerID]+" → "+names[call.CalleeID])
+	}
+	assert.ElementsMatch(t, []string{
+		"UserService.Get → UserService.Validate",
+		"UserService.Get → UserCache.Warm",
+	}, edges)
+
+	var unresolved []string
+	for _, call := range result.UnresolvedCalls {
+		unresolved = append(unresolved, names[call.CallerID]+" → "+call.CalleeName)
+		assert.Equal(t, "src/UserService.cs", call.FilePath)
+	}
+	assert.ElementsMatch(t, []string{
+		"UserService.Name → IUserRepository.Describe",
+		"UserService.Get → NotNegative",
+		"UserService.Get → Log.Info",
+		"UserService.Get → Console.WriteLine",
+		"UserService.Get → IUserRepository.Find",
+		"UserService.Validate → Audit",
+	}, unresolved)
+}
+
+// TestCSharpParser_NamespacedIDs tests that function IDs are built from the
+// namespace-qualified name, including nested block namespaces.
+func TestCSharpParser_NamespacedIDs(t *testing.T) {
+	result := parseCSharpTestFile(t, "testdata/csharp/UserService.Audit.cs")
+
+	require.Len(t, result.Functions, 2)
+	for _, fn := range result.Functions {
+		qualified := map[string]string{
+			"UserService.Audit":  "MyApp.Services.UserService.Audit",
+			"AdminService.Reset": "MyApp.Services.Admin.AdminService.Reset",
+		}[fn.Name]
+		require.NotEmpty(t, qualified, "unexpected function %s", fn.Name)
+		assert.Equal(t, GenerateFunctionID(fn.FilePath, qualified, fn.Signature, fn.StartLine, fn.EndLine, fn.StartCol, fn.EndCol), fn.ID)
+	}
+	assert.Equal(t, "MyApp.Services", result.PackageName)
+}
+
+// TestCSharpParser_CrossFileResolution tests that calls resolve across files
+// through namespaces, using directives, aliases and partial classes.
+func TestCSharpParser_CrossFileResolution(t *testing.T) {
+	var files []FileEntity
+	var functions []FunctionEntity
+	var imports []ImportEntity
+	var unresolved []UnresolvedCall
+	packageNames := make(map[string]string)
+	for _, fixture := range []string{"UserService.cs", "UserService.Audit.cs", "UserRepository.cs", "Util.cs"} {
+		result := parseCSharpTestFile(t, "testdata/csharp/"+fixture)
+		files = append(files, result.File)
+		functions = append(functions, result.Functions...)
+		imports = append(imports, result.Imports...)
+		unresolved = append(unresolved, result.UnresolvedCalls...)
+		packageNames[result.File.Path] = result.PackageName
+	}
+	names := make(map[string]string)
+	for _, fn := range functions {
+		names[fn.ID] = fn.Name
+	}
+
+	resolver := NewCallResolver()
+	resolver.BuildIndex(files, functions, imports, packageNames)
+	var edges []string
+	for _, call := range resolver.ResolveCalls(unresolved) {
+		edges = append(edges, names[call.CallerID]+" → "+names[call.CalleeID])
+	}
+	assert.ElementsMatch(t, []string{
+		"UserService.Name → IUserRepository.Describe",
+		"UserService.Get → Guard.NotNegative",
+		"UserService.Get → Logger.Info",
+		"UserService.Get → IUserRepository.Find",
+		"UserService.Validate → UserService.Audit",
+	}, edges)
+
+	stillUnresolved := resolver.UnresolvedCalls()
+	require.Len(t, stillUnresolved, 1)
+	assert.Equal(t, "Console.WriteLine", stillUnresolved[0].CalleeName)
+	assert.Equal(t, UnresolvedReasonExternal, stillUnresolved[0].Reason)
+}
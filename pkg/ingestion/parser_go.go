@@ -83,6 +83,7 @@ func (p *TreeSitterParser) parseGoAST(parser *sitter.Parser, content []byte, fil
 				"path", filePath,
 				"error_count", errorCount,
 			)
+			p.syntaxErrors.record(filePath, errorCount)
 		}
 		// Continue parsing - Tree-sitter is error-tolerant
 	}
@@ -105,6 +106,16 @@ func (p *TreeSitterParser) parseGoAST(parser *sitter.Parser, content []byte, fil
 	// First pass: extract all functions with their AST nodes
 	p.walkGoAST(rootNode, ctx)
 
+	// Name closures after their enclosing function before IDs are used
+	functions := make([]FunctionEntity, len(ctx.functions))
+	for i, fn := range ctx.functions {
+		functions[i] = fn.entity
+	}
+	localizeAnonymousFunctions(functions)
+	for i := range ctx.functions {
+		ctx.functions[i].entity = functions[i]
+	}
+
 	// Second pass: extract calls within each function using V2 (returns unresolved calls)
 	var calls []CallsEdge
 	var unresolvedCalls []UnresolvedCall
@@ -116,12 +127,6 @@ func (p *TreeSitterParser) parseGoAST(parser *sitter.Parser, content []byte, fil
 		unresolvedCalls = append(unresolvedCalls, unresolved...)
 	}
 
-	// Extract just the entities
-	functions := make([]FunctionEntity, len(ctx.functions))
-	for i, fn := range ctx.functions {
-		functions[i] = fn.entity
-	}
-
 	// Extract types (structs, interfaces, type aliases) and struct fields
 	types, fields := p.extractGoTypesAndFields(rootNode, content, filePath)
 
@@ -295,8 +300,8 @@ func (p *TreeSitterParser) extractGoMethodDeclaration(node *sitter.Node, ctx *go
 
 // extractGoFuncLiteral extracts an anonymous function/closure.
 // Handles: func() {}, func(x int) int {}
+// The $anon_N name is provisional; see localizeAnonymousFunctions.
 func (p *TreeSitterParser) extractGoFuncLiteral(node *sitter.Node, ctx *goFunctionContext) *FunctionEntity {
-	// Anonymous functions use position-based naming
 	ctx.anonCounter++
 	name := fmt.Sprintf("$anon_%d", ctx.anonCounter)
 
@@ -337,7 +342,7 @@ func (p *TreeSitterParser) createGoFunctionEntity(node *sitter.Node, ctx *goFunc
 
 	// Get code text
 	codeText := string(ctx.content[node.StartByte():node.EndByte()])
-	codeText = p.truncateCodeText(codeText)
+	codeText = p.truncateCodeText(ctx.filePath, codeText)
 
 	// Generate deterministic ID
 	id := GenerateFunctionID(ctx.filePath, name, signature, startLine, endLine, startCol, endCol)
@@ -770,7 +775,7 @@ func (p *Parser) parseGoFile(content, filePath string) ([]FunctionEntity, []Call
 			if currentFn != nil {
 				currentFn.EndLine = fnStartLine + len(fnLines) - 1
 				codeText := strings.Join(fnLines, "\n")
-				currentFn.CodeText = p.truncateCodeText(codeText)
+				currentFn.CodeText = p.truncateCodeText(filePath, codeText)
 				functions = append(functions, *currentFn)
 			}
 
@@ -805,7 +810,7 @@ func (p *Parser) parseGoFile(content, filePath string) ([]FunctionEntity, []Call
 					if currentFn != nil {
 						currentFn.EndLine = lineNum
 						codeText := strings.Join(fnLines, "\n")
-						currentFn.CodeText = p.truncateCodeText(codeText)
+						currentFn.CodeText = p.truncateCodeText(filePath, codeText)
 						functions = append(functions, *currentFn)
 						currentFn = nil
 					}
@@ -818,7 +823,7 @@ func (p *Parser) parseGoFile(content, filePath string) ([]FunctionEntity, []Call
 	if currentFn != nil {
 		currentFn.EndLine = len(lines)
 		codeText := strings.Join(fnLines, "\n")
-		currentFn.CodeText = p.truncateCodeText(codeText)
+		currentFn.CodeText = p.truncateCodeText(filePath, codeText)
 		functions = append(functions, *currentFn)
 	}
 
@@ -1212,7 +1217,7 @@ func (p *TreeSitterParser) extractGoTypeSpec(node *sitter.Node, content []byte,
 
 	// Get code text
 	codeText := string(content[node.StartByte():node.EndByte()])
-	codeText = p.truncateCodeText(codeText)
+	codeText = p.truncateCodeText(filePath, codeText)
 
 	// Generate deterministic ID
 	id := GenerateTypeID(filePath, name, startLine, endLine)
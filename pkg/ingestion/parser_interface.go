@@ -28,6 +28,14 @@ type CodeParser interface {
 	// SetMaxCodeTextSize sets the maximum size for CodeText (in bytes).
 	SetMaxCodeTextSize(size int64)
 
+	// SetLanguageMaxCodeTextSize overrides the CodeText size for files of one
+	// language (as detected from the path, e.g. "go", "javascript").
+	SetLanguageMaxCodeTextSize(language string, size int64)
+
+	// SetContentHashMode sets how file content is normalized before it is
+	// hashed, and whether CRLF files are parsed with LF line endings.
+	SetContentHashMode(mode ContentHashMode)
+
 	// GetTruncatedCount returns the number of CodeTexts that were truncated.
 	GetTruncatedCount() int
 
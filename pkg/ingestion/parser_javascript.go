@@ -57,6 +57,7 @@ func (p *TreeSitterParser) parseJavaScriptAST(parser *sitter.Parser, content []b
 				"path", filePath,
 				"error_count", errorCount,
 			)
+			p.syntaxErrors.record(filePath, errorCount)
 		}
 	}
 
@@ -65,6 +66,7 @@ func (p *TreeSitterParser) parseJavaScriptAST(parser *sitter.Parser, content []b
 	anonCounter := 0
 
 	p.walkJSFunctions(rootNode, content, filePath, &functions, funcNameToID, &anonCounter)
+	localizeAnonymousFunctions(functions)
 
 	// Extract types (classes in JavaScript)
 	types := p.extractJSTypes(rootNode, content, filePath)
@@ -163,7 +165,7 @@ func (p *TreeSitterParser) extractJSFunction(node *sitter.Node, content []byte,
 	endCol := int(node.EndPoint().Column) + 1
 
 	codeText := string(content[node.StartByte():node.EndByte()])
-	codeText = p.truncateCodeText(codeText)
+	codeText = p.truncateCodeText(filePath, codeText)
 
 	id := GenerateFunctionID(filePath, name, signature, startLine, endLine, startCol, endCol)
 
@@ -222,7 +224,7 @@ func (p *TreeSitterParser) extractJSArrowOrExpressionFunction(nameNode, valueNod
 	}
 
 	codeText := string(content[nameNode.StartByte():valueNode.EndByte()])
-	codeText = p.truncateCodeText(codeText)
+	codeText = p.truncateCodeText(filePath, codeText)
 
 	id := GenerateFunctionID(filePath, name, signature, startLine, endLine, startCol, endCol)
 
@@ -261,7 +263,7 @@ func (p *TreeSitterParser) extractJSMethod(node *sitter.Node, content []byte, fi
 	endCol := int(node.EndPoint().Column) + 1
 
 	codeText := string(content[node.StartByte():node.EndByte()])
-	codeText = p.truncateCodeText(codeText)
+	codeText = p.truncateCodeText(filePath, codeText)
 
 	id := GenerateFunctionID(filePath, name, signature, startLine, endLine, startCol, endCol)
 
@@ -299,7 +301,7 @@ func (p *TreeSitterParser) extractJSAnonymousArrow(node *sitter.Node, content []
 	endCol := int(node.EndPoint().Column) + 1
 
 	codeText := string(content[node.StartByte():node.EndByte()])
-	codeText = p.truncateCodeText(codeText)
+	codeText = p.truncateCodeText(filePath, codeText)
 
 	id := GenerateFunctionID(filePath, name, signature, startLine, endLine, startCol, endCol)
 
@@ -428,7 +430,7 @@ func (p *TreeSitterParser) extractJSClass(node *sitter.Node, content []byte, fil
 	endCol := int(node.EndPoint().Column) + 1
 
 	codeText := string(content[node.StartByte():node.EndByte()])
-	codeText = p.truncateCodeText(codeText)
+	codeText = p.truncateCodeText(filePath, codeText)
 
 	id := GenerateTypeID(filePath, name, startLine, endLine)
 
@@ -477,7 +479,7 @@ func (p *Parser) parseJSFile(content, filePath string) ([]FunctionEntity, []Call
 
 					codeLines := lines[i:endLine]
 					codeText := strings.Join(codeLines, "\n")
-					codeText = p.truncateCodeText(codeText)
+					codeText = p.truncateCodeText(filePath, codeText)
 
 					fn := FunctionEntity{
 						ID:        GenerateFunctionID(filePath, name, signature, lineNum, endLine, 1, len(line)),
@@ -505,7 +507,7 @@ func (p *Parser) parseJSFile(content, filePath string) ([]FunctionEntity, []Call
 
 				codeLines := lines[i:endLine]
 				codeText := strings.Join(codeLines, "\n")
-				codeText = p.truncateCodeText(codeText)
+				codeText = p.truncateCodeText(filePath, codeText)
 
 				fn := FunctionEntity{
 					ID:        GenerateFunctionID(filePath, name, signature, lineNum, endLine, 1, len(line)),
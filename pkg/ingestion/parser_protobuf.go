@@ -20,7 +20,11 @@
 package ingestion
 
 import (
+	"context"
+	"fmt"
 	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
 )
 
 // =============================================================================
@@ -32,14 +36,14 @@ import (
 // Extracts:
 //   - Services (service definitions)
 //   - RPC methods (rpc declarations with request/response types)
-//   - Message types (as TypeEntity)
+//   - Messages and enums (as FunctionEntity, for lack of a type model)
 //
-// Uses regex-based parsing since tree-sitter-proto is not bundled.
+// Line-based parsing used by the simplified Parser; TreeSitterParser uses
+// parseProtobufAST (below) instead.
 // RPC methods are represented as FunctionEntity with signatures like:
 //
 //	"rpc MethodName(RequestType) returns (ResponseType)"
 //
-// This is a shared implementation used by both Parser and TreeSitterParser.
 // protoParseState holds state during protobuf parsing.
 type protoParseState struct {
 	filePath     string
@@ -147,12 +151,6 @@ func (s *protoParseState) handleBlock(idx, lineNum int, trimmed, prefix string)
 	s.functions = append(s.functions, fn)
 }
 
-// parseProtobufSimplified extracts services, RPCs, and messages from .proto files.
-// Uses regex-based parsing since tree-sitter-proto is not bundled.
-func parseProtobufSimplified(content []byte, filePath string, p *TreeSitterParser) ([]FunctionEntity, []CallsEdge) {
-	return parseProtobufContent(string(content), filePath, p.truncateCodeText)
-}
-
 // extractRPCSignature extracts the RPC name and full signature from a proto rpc line.
 func extractRPCSignature(line string) (name, signature string) {
 	trimmed := strings.TrimPrefix(strings.TrimSpace(line), "rpc ")
@@ -211,3 +209,306 @@ func findProtobufBlockEnd(lines []string, startIdx int) int {
 
 	return len(lines)
 }
+
+// =============================================================================
+// PROTOBUF PARSER (tree-sitter)
+// =============================================================================
+
+// protoParseResult holds the entities extracted from a .proto file.
+type protoParseResult struct {
+	Functions []FunctionEntity // services and their RPC methods
+	Types     []TypeEntity     // messages and enums; nested ones as "Outer.Inner"
+	Fields    []FieldEntity    // message fields and enum values
+	Options   []ProtoOption    // file, message, enum, service, RPC and field options
+}
+
+// protoASTContext carries state through the .proto syntax tree walk.
+type protoASTContext struct {
+	content  []byte
+	filePath string
+	truncate func(string) string
+	result   *protoParseResult
+}
+
+// parseProtobufAST extracts services, RPCs, messages, enums, fields and
+// options from a .proto file using the tree-sitter protobuf grammar.
+//
+// Services and RPCs keep the shape of the line-based parser (FunctionEntity
+// named "Service" and "Service.Method") so gRPC tools see the same data.
+// Messages and enums become TypeEntity with kind "message" or "enum"; their
+// fields and enum values become FieldEntity. Field types are the base type
+// as written ("repeated" dropped, maps as "map<K, V>").
+func (p *TreeSitterParser) parseProtobufAST(parser *sitter.Parser, content []byte, filePath string) (*protoParseResult, error) {
+	tree, err := parser.ParseCtx(context.Background(), nil, content)
+	if err != nil {
+		return nil, fmt.Errorf("tree-sitter parse: %w", err)
+	}
+	defer tree.Close()
+
+	rootNode := tree.RootNode()
+	if rootNode.HasError() {
+		if errorCount := countErrors(rootNode); errorCount > 0 {
+			p.logger.Warn("parser.treesitter.protobuf.syntax_errors",
+				"path", filePath,
+				"error_count", errorCount,
+			)
+			p.syntaxErrors.record(filePath, errorCount)
+		}
+	}
+
+	ctx := &protoASTContext{
+		content:  content,
+		filePath: filePath,
+		truncate: func(codeText string) string { return p.truncateCodeText(filePath, codeText) },
+		result:   &protoParseResult{},
+	}
+	for i := 0; i < int(rootNode.NamedChildCount()); i++ {
+		child := rootNode.NamedChild(i)
+		switch child.Type() {
+		case "option":
+			ctx.addOption("file", child)
+		case "message":
+			ctx.walkMessage(child, "")
+		case "enum":
+			ctx.walkEnum(child, "")
+		case "service":
+			ctx.walkService(child)
+		}
+	}
+	return ctx.result, nil
+}
+
+// walkMessage records a message, its fields and options, and recurses into
+// nested messages and enums.
+func (c *protoASTContext) walkMessage(node *sitter.Node, parent string) {
+	name := c.qualify(parent, c.childName(node, "message_name"))
+	if name == "" {
+		return
+	}
+	c.addType(node, name, "message")
+
+	body := protoChild(node, "message_body")
+	if body == nil {
+		return
+	}
+	for i := 0; i < int(body.NamedChildCount()); i++ {
+		child := body.NamedChild(i)
+		switch child.Type() {
+		case "field", "map_field":
+			c.addField(child, name)
+		case "oneof":
+			for j := 0; j < int(child.NamedChildCount()); j++ {
+				if f := child.NamedChild(j); f.Type() == "oneof_field" {
+					c.addField(f, name)
+				} else if f.Type() == "option" {
+					c.addOption(name, f)
+				}
+			}
+		case "option":
+			c.addOption(name, child)
+		case "message":
+			c.walkMessage(child, name)
+		case "enum":
+			c.walkEnum(child, name)
+		}
+	}
+}
+
+// walkEnum records an enum, its values and options.
+func (c *protoASTContext) walkEnum(node *sitter.Node, parent string) {
+	name := c.qualify(parent, c.childName(node, "enum_name"))
+	if name == "" {
+		return
+	}
+	c.addType(node, name, "enum")
+
+	body := protoChild(node, "enum_body")
+	if body == nil {
+		return
+	}
+	for i := 0; i < int(body.NamedChildCount()); i++ {
+		child := body.NamedChild(i)
+		switch child.Type() {
+		case "enum_field":
+			ident := protoChild(child, "identifier")
+			if ident == nil {
+				continue
+			}
+			value := ident.Content(c.content)
+			c.result.Fields = append(c.result.Fields, FieldEntity{
+				StructName: name,
+				FieldName:  value,
+				FieldType:  name,
+				FilePath:   c.filePath,
+				Line:       int(child.StartPoint().Row) + 1,
+			})
+			c.addInlineOptions(name+"."+value, child)
+		case "option":
+			c.addOption(name, child)
+		}
+	}
+}
+
+// walkService records a service and its RPC methods as functions.
+func (c *protoASTContext) walkService(node *sitter.Node) {
+	service := c.childName(node, "service_name")
+	if service == "" {
+		return
+	}
+	c.result.Functions = append(c.result.Functions, c.protoFunction(node, service, "service "+service))
+
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+		switch child.Type() {
+		case "option":
+			c.addOption(service, child)
+		case "rpc":
+			method := c.childName(child, "rpc_name")
+			if method == "" {
+				continue
+			}
+			_, signature := extractRPCSignature(strings.Join(strings.Fields(child.Content(c.content)), " "))
+			qualified := service + "." + method
+			c.result.Functions = append(c.result.Functions, c.protoFunction(child, qualified, signature))
+			for j := 0; j < int(child.NamedChildCount()); j++ {
+				if opt := child.NamedChild(j); opt.Type() == "option" {
+					c.addOption(qualified, opt)
+				}
+			}
+		}
+	}
+}
+
+// addType records a message or enum.
+func (c *protoASTContext) addType(node *sitter.Node, name, kind string) {
+	startLine := int(node.StartPoint().Row) + 1
+	endLine := int(node.EndPoint().Row) + 1
+	c.result.Types = append(c.result.Types, TypeEntity{
+		ID:        GenerateTypeID(c.filePath, name, startLine, endLine),
+		Name:      name,
+		Kind:      kind,
+		FilePath:  c.filePath,
+		CodeText:  c.truncate(node.Content(c.content)),
+		StartLine: startLine,
+		EndLine:   endLine,
+		StartCol:  int(node.StartPoint().Column) + 1,
+		EndCol:    int(node.EndPoint().Column) + 1,
+	})
+}
+
+// addField records a message field (plain, map or oneof member).
+func (c *protoASTContext) addField(node *sitter.Node, message string) {
+	ident := protoChild(node, "identifier")
+	if ident == nil {
+		return
+	}
+	var fieldType string
+	if node.Type() == "map_field" {
+		key, value := protoChild(node, "key_type"), protoChild(node, "type")
+		if key == nil || value == nil {
+			return
+		}
+		fieldType = "map<" + key.Content(c.content) + ", " + value.Content(c.content) + ">"
+	} else if t := protoChild(node, "type"); t != nil {
+		fieldType = t.Content(c.content)
+	}
+	fieldName := ident.Content(c.content)
+	c.result.Fields = append(c.result.Fields, FieldEntity{
+		StructName: message,
+		FieldName:  fieldName,
+		FieldType:  fieldType,
+		FilePath:   c.filePath,
+		Line:       int(node.StartPoint().Row) + 1,
+	})
+	c.addInlineOptions(message+"."+fieldName, node)
+}
+
+// addOption records an "option name = value;" statement under scope.
+func (c *protoASTContext) addOption(scope string, node *sitter.Node) {
+	var name strings.Builder
+	var value string
+	for i := 0; i < int(node.ChildCount()); i++ {
+		child := node.Child(i)
+		switch child.Type() {
+		case "option":
+			continue // the keyword
+		case "=":
+			if i+1 < int(node.ChildCount()) {
+				value = node.Child(i + 1).Content(c.content)
+			}
+			i = int(node.ChildCount())
+		default:
+			name.WriteString(child.Content(c.content))
+		}
+	}
+	c.appendOption(scope, name.String(), value, node)
+}
+
+// addInlineOptions records bracketed options ("[deprecated = true]") of a
+// field or enum value under scope.
+func (c *protoASTContext) addInlineOptions(scope string, node *sitter.Node) {
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+		if child.Type() != "field_options" && child.Type() != "enum_value_option" {
+			continue
+		}
+		opts := []*sitter.Node{child}
+		if child.Type() == "field_options" {
+			opts = opts[:0]
+			for j := 0; j < int(child.NamedChildCount()); j++ {
+				opts = append(opts, child.NamedChild(j))
+			}
+		}
+		for _, opt := range opts {
+			name, value, _ := strings.Cut(opt.Content(c.content), "=")
+			c.appendOption(scope, strings.TrimSpace(name), strings.TrimSpace(value), opt)
+		}
+	}
+}
+
+func (c *protoASTContext) appendOption(scope, name, value string, node *sitter.Node) {
+	if name == "" {
+		return
+	}
+	c.result.Options = append(c.result.Options, ProtoOption{
+		FilePath: c.filePath,
+		Scope:    scope,
+		Name:     name,
+		Value:    strings.Join(strings.Fields(value), " "),
+		Line:     int(node.StartPoint().Row) + 1,
+	})
+}
+
+// protoFunction builds the FunctionEntity for a service or RPC node.
+func (c *protoASTContext) protoFunction(node *sitter.Node, name, signature string) FunctionEntity {
+	return createProtobufEntity(c.filePath, name, signature,
+		int(node.StartPoint().Row)+1, int(node.EndPoint().Row)+1, node.Content(c.content), c.truncate)
+}
+
+// childName returns the identifier inside node's child of the given type
+// (e.g., the name in message_name).
+func (c *protoASTContext) childName(node *sitter.Node, childType string) string {
+	child := protoChild(node, childType)
+	if child == nil {
+		return ""
+	}
+	return strings.TrimSpace(child.Content(c.content))
+}
+
+// qualify prefixes a nested definition with its enclosing message.
+func (c *protoASTContext) qualify(parent, name string) string {
+	if parent == "" || name == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// protoChild returns the first named child of node with the given type.
+func protoChild(node *sitter.Node, childType string) *sitter.Node {
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		if child := node.NamedChild(i); child.Type() == childType {
+			return child
+		}
+	}
+	return nil
+}
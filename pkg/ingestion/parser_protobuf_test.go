@@ -0,0 +1,253 @@
+package ingestion
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const protoFixture = `syntax = "proto3";
+
+package users.v1;
+
+option go_package = "example.com/api/users/v1;usersv1";
+
+message User {
+  string id = 1;
+  repeated string tags = 2;
+  map<string, int64> counters = 3;
+  Status status = 4 [deprecated = true];
+
+  message Address {
+    string city = 1;
+  }
+
+  oneof contact {
+    string email = 5;
+    string phone = 6;
+  }
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_ACTIVE = 1;
+}
+
+service UserService {
+  rpc GetUser(GetUserRequest) returns (User) {
+    option idempotency_level = NO_SIDE_EFFECTS;
+  }
+  rpc ListUsers(ListUsersRequest) returns (stream User);
+}
+`
+
+// parseProtoFixture parses protoFixture with the tree-sitter parser.
+func parseProtoFixture(t *testing.T) *ParseResult {
+	t.Helper()
+
+	tmpFile := filepath.Join(t.TempDir(), "users.proto")
+	require.NoError(t, os.WriteFile(tmpFile, []byte(protoFixture), 0644))
+
+	result, err := NewTreeSitterParser(nil).ParseFile(FileInfo{
+		Path:     "api/users.proto",
+		FullPath: tmpFile,
+		Size:     int64(len(protoFixture)),
+		Language: "protobuf",
+	})
+	require.NoError(t, err)
+	return result
+}
+
+func TestProtobufParser_Types(t *testing.T) {
+	result := parseProtoFixture(t)
+
+	kinds := make(map[string]string)
+	for _, typ := range result.Types {
+		kinds[typ.Name] = typ.Kind
+		assert.Equal(t, "api/users.proto", typ.FilePath)
+	}
+	assert.Equal(t, map[string]string{
+		"User":         "message",
+		"User.Address": "message",
+		"Status":       "enum",
+	}, kinds)
+}
+
+func TestProtobufParser_Fields(t *testing.T) {
+	result := parseProtoFixture(t)
+
+	fields := make(map[string]string)
+	for _, f := range result.Fields {
+		fields[f.StructName+"."+f.FieldName] = f.FieldType
+	}
+	assert.Equal(t, "string", fields["User.id"])
+	assert.Equal(t, "string", fields["User.tags"], "repeated is not part of the type")
+	assert.Equal(t, "map<string, int64>", fields["User.counters"])
+	assert.Equal(t, "Status", fields["User.status"])
+	assert.Equal(t, "string", fields["User.email"], "oneof members belong to the message")
+	assert.Equal(t, "string", fields["User.Address.city"])
+	assert.Equal(t, "Status", fields["Status.STATUS_ACTIVE"])
+	assert.Len(t, fields, 9)
+}
+
+func TestProtobufParser_Options(t *testing.T) {
+	result := parseProtoFixture(t)
+
+	options := make(map[string]string)
+	for _, o := range result.ProtoOptions {
+		options[o.Scope+"|"+o.Name] = o.Value
+	}
+	assert.Equal(t, `"example.com/api/users/v1;usersv1"`, options["file|go_package"])
+	assert.Equal(t, "true", options["User.status|deprecated"])
+	assert.Equal(t, "NO_SIDE_EFFECTS", options["UserService.GetUser|idempotency_level"])
+}
+
+func TestProtobufParser_Services(t *testing.T) {
+	result := parseProtoFixture(t)
+
+	signatures := make(map[string]string)
+	for _, fn := range result.Functions {
+		signatures[fn.Name] = fn.Signature
+	}
+	assert.Equal(t, map[string]string{
+		"UserService":           "service UserService",
+		"UserService.GetUser":   "rpc GetUser(GetUserRequest) returns (User)",
+		"UserService.ListUsers": "rpc ListUsers(ListUsersRequest) returns (stream User)",
+	}, signatures)
+}
+
+func TestBuildGeneratedFromIndex(t *testing.T) {
+	types := []TypeEntity{
+		{ID: "p1", Name: "User", Kind: "message", FilePath: "proto/users.proto"},
+		{ID: "p2", Name: "User.Address", Kind: "message", FilePath: "proto/users.proto"},
+		{ID: "p3", Name: "Status", Kind: "enum", FilePath: "proto/users.proto"},
+		{ID: "p4", Name: "Status", Kind: "enum", FilePath: "proto/orders.proto"},
+		{ID: "p5", Name: "Item", Kind: "message", FilePath: "proto/a.proto"},
+		{ID: "p6", Name: "Item", Kind: "message", FilePath: "proto/b.proto"},
+		{ID: "g1", Name: "User", Kind: "struct", FilePath: "gen/users.pb.go"},
+		{ID: "g2", Name: "User_Address", Kind: "struct", FilePath: "gen/users.pb.go"},
+		{ID: "g3", Name: "Status", Kind: "type", FilePath: "gen/users.pb.go"},
+		{ID: "g4", Name: "Item", Kind: "struct", FilePath: "gen/items.pb.go"},
+		{ID: "g5", Name: "User", Kind: "interface", FilePath: "web/users_pb.d.ts"},
+		{ID: "h1", Name: "User", Kind: "struct", FilePath: "internal/user.go"},
+	}
+
+	links := make(map[string]string)
+	for _, e := range BuildGeneratedFromIndex(types) {
+		links[e.TypeID] = e.ProtoTypeID
+	}
+	assert.Equal(t, map[string]string{
+		"g1": "p1",
+		"g2": "p2",
+		"g3": "p3", // disambiguated by the users.proto base name
+		"g5": "p1",
+	}, links, "ambiguous Item and hand-written User stay unlinked")
+}
+
+// parseFixture parses content as the file at path with the tree-sitter parser.
+func parseFixture(t *testing.T, path, language, content string) *ParseResult {
+	t.Helper()
+
+	tmpFile := filepath.Join(t.TempDir(), filepath.Base(path))
+	require.NoError(t, os.WriteFile(tmpFile, []byte(content), 0644))
+
+	result, err := NewTreeSitterParser(nil).ParseFile(FileInfo{
+		Path:     path,
+		FullPath: tmpFile,
+		Size:     int64(len(content)),
+		Language: language,
+	})
+	require.NoError(t, err)
+	return result
+}
+
+func TestBuildRPCLinkIndex(t *testing.T) {
+	proto := parseProtoFixture(t)
+	server := parseFixture(t, "internal/server/users.go", "go", `package server
+
+type userServer struct {
+	pb.UnimplementedUserServiceServer
+	store Store
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	return s.store.Find(ctx, req.Id)
+}
+
+func (s *userServer) ListUsers(req *pb.ListUsersRequest, stream pb.UserService_ListUsersServer) error {
+	return nil
+}
+
+func (s *userServer) Close() error { return nil }
+
+func (s *userServer) Find(ctx context.Context, id string) (*pb.User, error) { return nil, nil }
+`)
+	client := parseFixture(t, "web/src/api.ts", "typescript", `import { createPromiseClient } from "@connectrpc/connect";
+
+const users = createPromiseClient(UserService, transport);
+
+export async function loadUser(id: string) {
+  const user = await users.getUser({ id });
+  return user;
+}
+`)
+	pyClient := parseFixture(t, "tools/sync.py", "python", `def sync(channel):
+    stub = users_pb2_grpc.UserServiceStub(channel)
+    for user in stub.ListUsers(ListUsersRequest()):
+        print(user)
+`)
+
+	var functions []FunctionEntity
+	var types []TypeEntity
+	var clients []RPCLink
+	for _, r := range []*ParseResult{proto, server, client, pyClient} {
+		functions = append(functions, r.Functions...)
+		types = append(types, r.Types...)
+		clients = append(clients, r.RPCClients...)
+	}
+	names := make(map[string]string)
+	for _, fn := range functions {
+		names[fn.ID] = fn.Name
+	}
+
+	var got []string
+	for _, l := range BuildRPCLinkIndex(types, functions, clients) {
+		got = append(got, l.Role+" "+names[l.FunctionID]+" -> "+l.RPC)
+	}
+	assert.ElementsMatch(t, []string{
+		"server userServer.GetUser -> UserService.GetUser",
+		"server userServer.ListUsers -> UserService.ListUsers",
+		"client loadUser -> UserService.GetUser",
+		"client sync -> UserService.ListUsers",
+	}, got, "Close has no handler signature and Find is not an RPC")
+}
+
+func TestExtractRPCClientCalls(t *testing.T) {
+	content := `package billing
+
+type Service struct {
+	users pb.UserServiceClient
+}
+
+func New(conn *grpc.ClientConn) *Service {
+	return &Service{users: pb.NewUserServiceClient(conn)}
+}
+
+func (s *Service) Charge(ctx context.Context, id string) error {
+	u, err := s.users.GetUser(ctx, &pb.GetUserRequest{Id: id})
+	_ = u
+	return err
+}
+`
+	functions := []FunctionEntity{
+		{ID: "f1", Name: "New", FilePath: "billing/service.go", StartLine: 7, CodeText: "func New(conn *grpc.ClientConn) *Service {\n\treturn &Service{users: pb.NewUserServiceClient(conn)}\n}"},
+		{ID: "f2", Name: "Service.Charge", FilePath: "billing/service.go", StartLine: 11, CodeText: "func (s *Service) Charge(ctx context.Context, id string) error {\n\tu, err := s.users.GetUser(ctx, &pb.GetUserRequest{Id: id})\n\t_ = u\n\treturn err\n}"},
+	}
+	links := extractRPCClientCalls(content, "billing/service.go", functions)
+	assert.Equal(t, []RPCLink{{RPC: "UserService.GetUser", FunctionID: "f2", FilePath: "billing/service.go", Role: RPCRoleClient, Line: 12}}, links)
+
+	assert.Empty(t, extractRPCClientCalls(content, "README.md", functions))
+}
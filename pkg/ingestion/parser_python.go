@@ -39,12 +39,16 @@ import (
 //   - Methods (functions within classes, with class prefix)
 //   - Lambda functions (anonymous functions)
 //   - Function calls within the file
+//   - Method calls on type-annotated parameters ("repo.save" in
+//     `def f(repo: Repository)`), returned as unresolved calls so the
+//     CallResolver can dispatch them through Protocols and ABCs
 //
 // Method names are prefixed with class name (e.g., "ClassName.method_name").
-func (p *TreeSitterParser) parsePythonAST(parser *sitter.Parser, content []byte, filePath string) ([]FunctionEntity, []TypeEntity, []CallsEdge, error) {
+// Stub files (.pyi) are parsed the same way.
+func (p *TreeSitterParser) parsePythonAST(parser *sitter.Parser, content []byte, filePath string) ([]FunctionEntity, []TypeEntity, []CallsEdge, []UnresolvedCall, error) {
 	tree, err := parser.ParseCtx(context.Background(), nil, content)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("tree-sitter parse: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("tree-sitter parse: %w", err)
 	}
 	defer tree.Close()
 
@@ -55,6 +59,7 @@ func (p *TreeSitterParser) parsePythonAST(parser *sitter.Parser, content []byte,
 				"path", filePath,
 				"error_count", errorCount,
 			)
+			p.syntaxErrors.record(filePath, errorCount)
 		}
 	}
 
@@ -63,18 +68,21 @@ func (p *TreeSitterParser) parsePythonAST(parser *sitter.Parser, content []byte,
 	anonCounter := 0
 
 	p.walkPythonFunctions(rootNode, content, filePath, &functions, funcNameToID, "", &anonCounter)
+	localizeAnonymousFunctions(functions)
 
 	// Extract types (classes in Python)
 	types := p.extractPythonTypes(rootNode, content, filePath)
 
 	// Extract calls using stored functions
 	var calls []CallsEdge
+	var unresolved []UnresolvedCall
 	for _, fn := range functions {
-		fnCalls := p.extractPythonCalls(rootNode, content, fn, funcNameToID)
+		fnCalls, fnUnresolved := p.extractPythonCalls(rootNode, content, fn, funcNameToID)
 		calls = append(calls, fnCalls...)
+		unresolved = append(unresolved, fnUnresolved...)
 	}
 
-	return functions, types, calls, nil
+	return functions, types, calls, unresolved, nil
 }
 
 // walkPythonFunctions recursively walks the AST to find function definitions.
@@ -157,11 +165,15 @@ func (p *TreeSitterParser) extractPythonFunction(node *sitter.Node, content []by
 		returnType = string(content[returnNode.StartByte():returnNode.EndByte()])
 	}
 
-	// Build signature
+	// Build signature (annotations spanning several lines are joined)
 	signature := fmt.Sprintf("def %s%s", name, params)
 	if returnType != "" {
 		signature += " -> " + returnType
 	}
+	signature = strings.Join(strings.Fields(signature), " ")
+	if node.ChildCount() > 0 && node.Child(0).Type() == "async" {
+		signature = "async " + signature
+	}
 
 	startLine := int(node.StartPoint().Row) + 1
 	endLine := int(node.EndPoint().Row) + 1
@@ -169,7 +181,7 @@ func (p *TreeSitterParser) extractPythonFunction(node *sitter.Node, content []by
 	endCol := int(node.EndPoint().Column) + 1
 
 	codeText := string(content[node.StartByte():node.EndByte()])
-	codeText = p.truncateCodeText(codeText)
+	codeText = p.truncateCodeText(filePath, codeText)
 
 	id := GenerateFunctionID(filePath, fullName, signature, startLine, endLine, startCol, endCol)
 
@@ -196,7 +208,7 @@ func (p *TreeSitterParser) extractPythonLambda(node *sitter.Node, content []byte
 	endCol := int(node.EndPoint().Column) + 1
 
 	codeText := string(content[node.StartByte():node.EndByte()])
-	codeText = p.truncateCodeText(codeText)
+	codeText = p.truncateCodeText(filePath, codeText)
 
 	signature := codeText
 	if len(signature) > 100 {
@@ -218,21 +230,61 @@ func (p *TreeSitterParser) extractPythonLambda(node *sitter.Node, content []byte
 	}
 }
 
-// extractPythonCalls extracts function calls within a Python function.
-func (p *TreeSitterParser) extractPythonCalls(root *sitter.Node, content []byte, caller FunctionEntity, funcNameToID map[string]string) []CallsEdge {
+// extractPythonCalls extracts function calls within a Python function, plus
+// method calls on its annotated parameters for the CallResolver.
+func (p *TreeSitterParser) extractPythonCalls(root *sitter.Node, content []byte, caller FunctionEntity, funcNameToID map[string]string) ([]CallsEdge, []UnresolvedCall) {
 	var calls []CallsEdge
+	var unresolved []UnresolvedCall
 
 	fnNode := findNodeAtPosition(root, uint32(caller.StartLine-1), uint32(caller.StartCol-1)) //nolint:gosec // G115: line/col from parsed source are bounded
+	// The deepest node at the start is the def/async/lambda keyword; walk the
+	// whole definition instead.
+	for fnNode != nil && fnNode.Type() != "function_definition" && fnNode.Type() != "lambda" {
+		fnNode = fnNode.Parent()
+	}
 	if fnNode == nil {
-		return calls
+		return calls, unresolved
 	}
 
-	p.walkPythonCallExpressions(fnNode, content, caller.ID, funcNameToID, &calls)
-	return calls
+	typedParams := make(map[string]bool)
+	for _, param := range ParseSignatureParams(caller.Signature) {
+		if !pythonBuiltinTypes[param.Type] {
+			typedParams[param.Name] = true
+		}
+	}
+
+	walker := &pythonCallWalker{
+		content:      content,
+		caller:       caller,
+		funcNameToID: funcNameToID,
+		typedParams:  typedParams,
+		calls:        &calls,
+		unresolved:   &unresolved,
+	}
+	walker.walk(p, fnNode)
+	return calls, unresolved
+}
+
+// pythonBuiltinTypes are annotations whose methods are never project code.
+var pythonBuiltinTypes = map[string]bool{
+	"str": true, "int": true, "float": true, "bool": true, "bytes": true, "complex": true,
+	"list": true, "dict": true, "set": true, "frozenset": true, "tuple": true,
+	"List": true, "Dict": true, "Set": true, "Tuple": true, "Sequence": true, "Mapping": true,
+	"Iterable": true, "Iterator": true, "Callable": true, "Any": true, "object": true, "None": true,
 }
 
-// walkPythonCallExpressions finds call expressions in Python.
-func (p *TreeSitterParser) walkPythonCallExpressions(node *sitter.Node, content []byte, callerID string, funcNameToID map[string]string, calls *[]CallsEdge) {
+// pythonCallWalker carries the per-function state of a call expression walk.
+type pythonCallWalker struct {
+	content      []byte
+	caller       FunctionEntity
+	funcNameToID map[string]string
+	typedParams  map[string]bool // annotated parameters worth dispatching on
+	calls        *[]CallsEdge
+	unresolved   *[]UnresolvedCall
+}
+
+// walk finds call expressions in Python.
+func (w *pythonCallWalker) walk(p *TreeSitterParser, node *sitter.Node) {
 	if node == nil {
 		return
 	}
@@ -240,24 +292,43 @@ func (p *TreeSitterParser) walkPythonCallExpressions(node *sitter.Node, content
 	if node.Type() == "call" {
 		funcNode := node.ChildByFieldName("function")
 		if funcNode != nil {
-			calleeName := p.extractPythonCalleeName(funcNode, content)
+			calleeName := p.extractPythonCalleeName(funcNode, w.content)
 			if calleeName != "" {
-				if calleeID, exists := funcNameToID[calleeName]; exists && calleeID != callerID {
-					*calls = append(*calls, CallsEdge{
-						CallerID: callerID,
+				if calleeID, exists := w.funcNameToID[calleeName]; exists && calleeID != w.caller.ID {
+					*w.calls = append(*w.calls, CallsEdge{
+						CallerID: w.caller.ID,
 						CalleeID: calleeID,
 					})
+				} else if receiver := pythonCallReceiver(funcNode, w.content); w.typedParams[receiver] {
+					*w.unresolved = append(*w.unresolved, UnresolvedCall{
+						CallerID:   w.caller.ID,
+						CalleeName: receiver + "." + calleeName,
+						FilePath:   w.caller.FilePath,
+						Line:       int(node.StartPoint().Row) + 1,
+					})
 				}
 			}
 		}
 	}
 
 	for i := 0; i < int(node.ChildCount()); i++ {
-		child := node.Child(i)
-		p.walkPythonCallExpressions(child, content, callerID, funcNameToID, calls)
+		w.walk(p, node.Child(i))
 	}
 }
 
+// pythonCallReceiver returns the variable a method is called on ("repo" in
+// repo.save()), or "" when the call is not on a plain name.
+func pythonCallReceiver(funcNode *sitter.Node, content []byte) string {
+	if funcNode.Type() != "attribute" {
+		return ""
+	}
+	object := funcNode.ChildByFieldName("object")
+	if object == nil || object.Type() != "identifier" {
+		return ""
+	}
+	return object.Content(content)
+}
+
 // extractPythonCalleeName extracts the function name from a Python call.
 func (p *TreeSitterParser) extractPythonCalleeName(node *sitter.Node, content []byte) string {
 	nodeType := node.Type()
@@ -330,14 +401,20 @@ func (p *TreeSitterParser) extractPythonClass(node *sitter.Node, content []byte,
 	endCol := int(node.EndPoint().Column) + 1
 
 	codeText := string(content[node.StartByte():node.EndByte()])
-	codeText = p.truncateCodeText(codeText)
+	codeText = p.truncateCodeText(filePath, codeText)
 
 	id := GenerateTypeID(filePath, name, startLine, endLine)
 
+	// Protocols and ABCs are Python's interfaces
+	kind := "class"
+	if isPythonInterface(node.ChildByFieldName("superclasses"), content) {
+		kind = "interface"
+	}
+
 	return &TypeEntity{
 		ID:        id,
 		Name:      name,
-		Kind:      "class",
+		Kind:      kind,
 		FilePath:  filePath,
 		CodeText:  codeText,
 		StartLine: startLine,
@@ -347,6 +424,34 @@ func (p *TreeSitterParser) extractPythonClass(node *sitter.Node, content []byte,
 	}
 }
 
+// isPythonInterface reports whether a class's base list makes it a Protocol
+// or an abstract base class (ABC base or ABCMeta metaclass).
+func isPythonInterface(superclasses *sitter.Node, content []byte) bool {
+	if superclasses == nil {
+		return false
+	}
+	for i := 0; i < int(superclasses.NamedChildCount()); i++ {
+		arg := superclasses.NamedChild(i)
+		if arg.Type() == "keyword_argument" {
+			arg = arg.ChildByFieldName("value")
+			if arg == nil {
+				continue
+			}
+		}
+		if arg.Type() == "subscript" { // Protocol[T]
+			arg = arg.ChildByFieldName("value")
+			if arg == nil {
+				continue
+			}
+		}
+		switch stripPackagePrefix(arg.Content(content)) {
+		case "Protocol", "ABC", "ABCMeta":
+			return true
+		}
+	}
+	return false
+}
+
 // parsePythonFile extracts functions from Python source code.
 // Uses simplified indentation-based detection.
 // Limitations: May not handle decorators, nested functions, or complex cases correctly.
@@ -384,7 +489,7 @@ func (p *Parser) parsePythonFile(content, filePath string) ([]FunctionEntity, []
 			// Extract code text
 			codeLines := lines[i:endLine]
 			codeText := strings.Join(codeLines, "\n")
-			codeText = p.truncateCodeText(codeText)
+			codeText = p.truncateCodeText(filePath, codeText)
 
 			fn := FunctionEntity{
 				ID:        GenerateFunctionID(filePath, name, signature, lineNum, endLine, 1, len(line)),
@@ -161,6 +161,64 @@ func TestPythonParser_TypeHints(t *testing.T) {
 	assert.Contains(t, processList.Signature, "List[int]", "Should capture type hint")
 }
 
+// TestPythonParser_TypedSignatures tests that multi-line annotated signatures
+// are kept whole, with async and the return annotation.
+func TestPythonParser_TypedSignatures(t *testing.T) {
+	result := parsePythonTestFile(t, "testdata/python/protocols.py")
+
+	signatures := make(map[string]string)
+	for _, fn := range result.Functions {
+		signatures[fn.Name] = fn.Signature
+	}
+	assert.Equal(t, `async def store( repo: Repository, item: str, notifier: Optional["Notifier"] = None, ) -> bool`, signatures["store"])
+	assert.Equal(t, "def load(self, key: str) -> Optional[str]", signatures["Repository.load"])
+}
+
+// TestPythonParser_ProtocolsAndABCs tests that Protocol and ABC classes are
+// indexed as interfaces.
+func TestPythonParser_ProtocolsAndABCs(t *testing.T) {
+	result := parsePythonTestFile(t, "testdata/python/protocols.py")
+
+	kinds := make(map[string]string)
+	for _, typ := range result.Types {
+		kinds[typ.Name] = typ.Kind
+	}
+	assert.Equal(t, map[string]string{
+		"Repository":       "interface",
+		"Notifier":         "interface",
+		"MemoryRepository": "class",
+	}, kinds)
+}
+
+// TestPythonParser_TypedParamCalls tests that method calls on annotated
+// parameters are handed to the resolver, skipping builtin types.
+func TestPythonParser_TypedParamCalls(t *testing.T) {
+	result := parsePythonTestFile(t, "testdata/python/protocols.py")
+
+	var callees []string
+	for _, call := range result.UnresolvedCalls {
+		callees = append(callees, call.CalleeName)
+		assert.Equal(t, "protocols.py", call.FilePath)
+	}
+	assert.ElementsMatch(t, []string{"repo.save", "notifier.notify"}, callees)
+}
+
+// TestPythonParser_StubFile tests that .pyi stubs are parsed as Python.
+func TestPythonParser_StubFile(t *testing.T) {
+	result := parsePythonTestFile(t, "testdata/python/protocols.pyi")
+
+	require.Len(t, result.Types, 1)
+	assert.Equal(t, "interface", result.Types[0].Kind)
+
+	signatures := make(map[string]string)
+	for _, fn := range result.Functions {
+		signatures[fn.Name] = fn.Signature
+	}
+	assert.Equal(t, "def get(self, key: str) -> bytes | None", signatures["Cache.get"])
+	assert.Equal(t, "def warm(cache: Cache, keys: list[str]) -> int", signatures["warm"])
+	assert.Equal(t, "python", detectLanguageFromPath("pkg/api.pyi"))
+}
+
 // TestPythonParser_Inheritance tests class inheritance.
 func TestPythonParser_Inheritance(t *testing.T) {
 	result := parsePythonTestFile(t, "testdata/python/inheritance.py")
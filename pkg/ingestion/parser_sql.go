@@ -0,0 +1,366 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Table reference operations.
+const (
+	TableOpSelect = "select"
+	TableOpInsert = "insert"
+	TableOpUpdate = "update"
+	TableOpDelete = "delete"
+	TableOpDDL    = "ddl" // CREATE/ALTER/DROP TABLE, CREATE INDEX ... ON
+	TableOpUse    = "use" // an ORM reference whose operation is not visible
+)
+
+// Table reference sources.
+const (
+	TableSourceSQL        = "sql" // a SQL string literal (database/sql, sqlx, raw queries)
+	TableSourceGorm       = "gorm"
+	TableSourceSQLAlchemy = "sqlalchemy"
+	TableSourcePrisma     = "prisma"
+)
+
+// sqlStatementStart matches string literals that begin a SQL statement.
+var sqlStatementStart = regexp.MustCompile(`(?is)^\s*(SELECT|INSERT|UPDATE|DELETE|WITH|CREATE|ALTER|DROP|REPLACE|MERGE)\b`)
+
+// sqlHint matches what separates SQL from prose that happens to start with
+// "select" or "update": operators, placeholders and clause keywords.
+var sqlHint = regexp.MustCompile(`(?i)[*,=?;]|\$\d|%s|:\w|\b(WHERE|JOIN|VALUES|SET|LIMIT|ORDER BY|GROUP BY|RETURNING|TABLE|INDEX)\b`)
+
+// sqlTableName matches a possibly schema-qualified, possibly quoted table name.
+const sqlTableName = `((?:[A-Za-z_][\w$]*|"[^"]+"|` + "`[^`]+`" + `|\[[^\]]+\])(?:\.(?:[A-Za-z_][\w$]*|"[^"]+"|` + "`[^`]+`" + `|\[[^\]]+\]))?)`
+
+// sqlTablePatterns find table names in a statement; the last submatch is the
+// table. FROM is handled separately because its operation depends on what
+// precedes it.
+var sqlTablePatterns = []struct {
+	op string
+	re *regexp.Regexp
+}{
+	{TableOpInsert, regexp.MustCompile(`(?i)\b(?:INSERT|REPLACE)\s+(?:OR\s+\w+\s+|IGNORE\s+)?INTO\s+` + sqlTableName)},
+	{TableOpUpdate, regexp.MustCompile(`(?i)\bUPDATE\s+(?:ONLY\s+)?` + sqlTableName + `\s+(?:AS\s+\w+\s+|\w+\s+)?SET\b`)},
+	{TableOpDelete, regexp.MustCompile(`(?i)\bDELETE\s+FROM\s+(?:ONLY\s+)?` + sqlTableName)},
+	{TableOpSelect, regexp.MustCompile(`(?i)\bJOIN\s+(?:LATERAL\s+)?` + sqlTableName)},
+	{TableOpDDL, regexp.MustCompile(`(?i)\b(?:CREATE|ALTER|DROP|TRUNCATE)\s+(?:TEMP(?:ORARY)?\s+)?TABLE\s+(?:IF\s+(?:NOT\s+)?EXISTS\s+)?(?:ONLY\s+)?` + sqlTableName)},
+	{TableOpDDL, regexp.MustCompile(`(?i)\bCREATE\s+(?:UNIQUE\s+)?INDEX\b[^;]*?\bON\s+` + sqlTableName)},
+	{TableOpUpdate, regexp.MustCompile(`(?i)\bMERGE\s+INTO\s+` + sqlTableName)},
+}
+
+var (
+	sqlFromPattern   = regexp.MustCompile(`(?i)\bFROM\s+(?:ONLY\s+)?` + sqlTableName)
+	sqlCTEPattern    = regexp.MustCompile(`(?i)(?:\bWITH\s+(?:RECURSIVE\s+)?|,\s*)(\w+)\s+AS\s*\(`)
+	sqlFromFunction  = regexp.MustCompile(`(?i)\b(EXTRACT|SUBSTRING|TRIM|POSITION|OVERLAY)\s*\([^()]*$`)
+	sqlKeywordTables = map[string]bool{"select": true, "where": true, "set": true, "values": true, "dual": true, "lateral": true, "unnest": true}
+)
+
+// ORM call patterns. Each names the model or table in its last submatch.
+var (
+	gormTableCall  = regexp.MustCompile(`\.Table\(\s*"([^"]+)"`)
+	gormModelCall  = regexp.MustCompile(`\.(Model|Create|Save|Delete|First|Find|Take|Last|FirstOrCreate)\(\s*&(?:\[\])?(?:\w+\.)?([A-Z]\w*)\s*\{`)
+	sqlaQueryCall  = regexp.MustCompile(`\.query\(\s*([A-Z]\w*)\b`)
+	sqlaStmtCall   = regexp.MustCompile(`\b(select|insert|update|delete)\(\s*([A-Z]\w*)\b`)
+	sqlaAddCall    = regexp.MustCompile(`\.add\(\s*([A-Z]\w*)\(`)
+	prismaCall     = regexp.MustCompile(`\bprisma\.([a-z]\w*)\.(\w+)\(`)
+	chainOpPattern = regexp.MustCompile(`\.\s*(Find|First|Take|Last|Scan|Count|Pluck|Rows|Row|Create|CreateInBatches|Save|FirstOrCreate|Update|Updates|UpdateColumn|UpdateColumns|Delete)\(`)
+)
+
+// ormOps maps ORM method names to operations.
+var ormOps = map[string]string{
+	// gorm
+	"Find": TableOpSelect, "First": TableOpSelect, "Take": TableOpSelect, "Last": TableOpSelect,
+	"Scan": TableOpSelect, "Count": TableOpSelect, "Pluck": TableOpSelect, "Rows": TableOpSelect, "Row": TableOpSelect,
+	"Create": TableOpInsert, "CreateInBatches": TableOpInsert, "Save": TableOpInsert, "FirstOrCreate": TableOpInsert,
+	"Update": TableOpUpdate, "Updates": TableOpUpdate, "UpdateColumn": TableOpUpdate, "UpdateColumns": TableOpUpdate,
+	"Delete": TableOpDelete,
+	// SQLAlchemy
+	"select": TableOpSelect, "insert": TableOpInsert, "update": TableOpUpdate, "delete": TableOpDelete,
+	// Prisma
+	"findMany": TableOpSelect, "findUnique": TableOpSelect, "findFirst": TableOpSelect,
+	"findUniqueOrThrow": TableOpSelect, "findFirstOrThrow": TableOpSelect,
+	"count": TableOpSelect, "aggregate": TableOpSelect, "groupBy": TableOpSelect,
+	"create": TableOpInsert, "createMany": TableOpInsert, "upsert": TableOpInsert,
+	"updateMany": TableOpUpdate, "deleteMany": TableOpDelete,
+}
+
+// extractTableRefs finds the database tables each function uses: tables
+// named in SQL string literals, gorm Table/Model calls, SQLAlchemy queries
+// on models and Prisma client calls. ORM models are mapped to the table
+// name their ORM uses by default (gorm: snake_case plural; Flask-SQLAlchemy:
+// snake_case; Prisma: the model name); explicit overrides such as gorm's
+// TableName() are not followed.
+func extractTableRefs(functions []FunctionEntity) []TableRef {
+	var refs []TableRef
+	for _, fn := range functions {
+		seen := make(map[string]bool)
+		add := func(offset int, table, model, op, source string) {
+			key := strings.ToLower(table) + "|" + op + "|" + source
+			if table == "" || seen[key] {
+				return
+			}
+			seen[key] = true
+			refs = append(refs, TableRef{
+				FunctionID: fn.ID,
+				FilePath:   fn.FilePath,
+				Table:      table,
+				Model:      model,
+				Op:         op,
+				Source:     source,
+				Line:       fn.StartLine + strings.Count(fn.CodeText[:offset], "\n"),
+			})
+		}
+		code := fn.CodeText
+
+		for _, lit := range sqlStringLiterals(code) {
+			for _, t := range sqlTables(lit.text) {
+				add(lit.offset, t.table, "", t.op, TableSourceSQL)
+			}
+		}
+
+		switch detectLanguageFromPath(fn.FilePath) {
+		case "go":
+			for _, m := range gormTableCall.FindAllStringSubmatchIndex(code, -1) {
+				table := strings.Fields(code[m[2]:m[3]] + " ")[0] // "users u" -> "users"
+				add(m[0], table, "", chainOp(code, m[1]), TableSourceGorm)
+			}
+			for _, m := range gormModelCall.FindAllStringSubmatchIndex(code, -1) {
+				model := code[m[4]:m[5]]
+				op := ormOps[code[m[2]:m[3]]]
+				if op == "" {
+					op = chainOp(code, m[1])
+				}
+				add(m[0], pluralize(snakeCase(model)), model, op, TableSourceGorm)
+			}
+		case "python":
+			for _, m := range sqlaQueryCall.FindAllStringSubmatchIndex(code, -1) {
+				model := code[m[2]:m[3]]
+				add(m[0], snakeCase(model), model, TableOpSelect, TableSourceSQLAlchemy)
+			}
+			for _, m := range sqlaStmtCall.FindAllStringSubmatchIndex(code, -1) {
+				if m[0] > 0 && code[m[0]-1] == '.' {
+					continue // dict.update(...), not sqlalchemy.update(...)
+				}
+				model := code[m[4]:m[5]]
+				add(m[0], snakeCase(model), model, ormOps[code[m[2]:m[3]]], TableSourceSQLAlchemy)
+			}
+			for _, m := range sqlaAddCall.FindAllStringSubmatchIndex(code, -1) {
+				model := code[m[2]:m[3]]
+				add(m[0], snakeCase(model), model, TableOpInsert, TableSourceSQLAlchemy)
+			}
+		case "javascript", "typescript":
+			for _, m := range prismaCall.FindAllStringSubmatchIndex(code, -1) {
+				op, ok := ormOps[code[m[4]:m[5]]]
+				if !ok {
+					continue
+				}
+				accessor := code[m[2]:m[3]]
+				model := strings.ToUpper(accessor[:1]) + accessor[1:]
+				add(m[0], model, model, op, TableSourcePrisma)
+			}
+		}
+	}
+	return refs
+}
+
+// sqlLiteral is a string literal, or several joined by +, starting a SQL
+// statement.
+type sqlLiteral struct {
+	text   string
+	offset int
+}
+
+// sqlStringLiterals returns the string literals of code that hold SQL.
+// Literals concatenated with + are joined, so "SELECT * " + "FROM users"
+// is read as one statement.
+func sqlStringLiterals(code string) []sqlLiteral {
+	var out []sqlLiteral
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		if c != '"' && c != '\'' && c != '`' {
+			continue
+		}
+		text, end, ok := readStringLiteral(code, i)
+		if !ok {
+			continue
+		}
+		start := i
+		for {
+			j := end
+			for j < len(code) && (code[j] == ' ' || code[j] == '\t' || code[j] == '\n' || code[j] == '\r') {
+				j++
+			}
+			if j >= len(code) || code[j] != '+' {
+				break
+			}
+			j++
+			for j < len(code) && (code[j] == ' ' || code[j] == '\t' || code[j] == '\n' || code[j] == '\r') {
+				j++
+			}
+			if j >= len(code) || (code[j] != '"' && code[j] != '\'' && code[j] != '`') {
+				break
+			}
+			next, nextEnd, ok := readStringLiteral(code, j)
+			if !ok {
+				break
+			}
+			text += next
+			end = nextEnd
+		}
+		i = end - 1
+		if sqlStatementStart.MatchString(text) && sqlHint.MatchString(text) {
+			out = append(out, sqlLiteral{text: text, offset: start})
+		}
+	}
+	return out
+}
+
+// readStringLiteral reads the literal opened at i, including Python triple
+// quotes, and returns its content and the offset past its closing quote.
+// Single and double quoted literals end at a newline.
+func readStringLiteral(code string, i int) (string, int, bool) {
+	quote := code[i]
+	if quote != '`' && strings.HasPrefix(code[i:], strings.Repeat(string(quote), 3)) {
+		delim := strings.Repeat(string(quote), 3)
+		end := strings.Index(code[i+3:], delim)
+		if end < 0 {
+			return "", i + 1, false
+		}
+		return code[i+3 : i+3+end], i + 3 + end + 3, true
+	}
+	for j := i + 1; j < len(code); j++ {
+		switch code[j] {
+		case '\\':
+			if quote != '`' {
+				j++
+			}
+		case '\n':
+			if quote != '`' {
+				return "", i + 1, false
+			}
+		case quote:
+			return code[i+1 : j], j + 1, true
+		}
+	}
+	return "", i + 1, false
+}
+
+// sqlTable is a table a SQL statement uses.
+type sqlTable struct {
+	table, op string
+}
+
+// sqlTables returns the tables a SQL statement reads, writes or alters.
+// CTE names and FROM inside EXTRACT(... FROM x) are not tables.
+func sqlTables(stmt string) []sqlTable {
+	ctes := make(map[string]bool)
+	for _, m := range sqlCTEPattern.FindAllStringSubmatch(stmt, -1) {
+		ctes[strings.ToLower(m[1])] = true
+	}
+	var tables []sqlTable
+	add := func(name, op string) {
+		name = unquoteSQLName(name)
+		if lower := strings.ToLower(name); name != "" && !ctes[lower] && !sqlKeywordTables[lower] {
+			tables = append(tables, sqlTable{table: name, op: op})
+		}
+	}
+	for _, p := range sqlTablePatterns {
+		for _, m := range p.re.FindAllStringSubmatch(stmt, -1) {
+			add(m[len(m)-1], p.op)
+		}
+	}
+	for _, m := range sqlFromPattern.FindAllStringSubmatchIndex(stmt, -1) {
+		before := stmt[:m[0]]
+		if strings.HasSuffix(strings.ToUpper(strings.TrimRight(before, " \t\n")), "DELETE") || sqlFromFunction.MatchString(before) {
+			continue
+		}
+		if rest := strings.TrimLeft(stmt[m[1]:], " \t"); strings.HasPrefix(rest, "(") {
+			continue // a table function such as generate_series(...)
+		}
+		add(stmt[m[2]:m[3]], TableOpSelect)
+	}
+	return tables
+}
+
+// unquoteSQLName strips identifier quotes: "users", `users`, [users].
+func unquoteSQLName(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = strings.Trim(p, "\"`[]")
+	}
+	return strings.Join(parts, ".")
+}
+
+// chainOp returns the operation of the first gorm finisher method chained
+// after pos in the same statement, or TableOpUse.
+func chainOp(code string, pos int) string {
+	end := pos
+	for end < len(code) {
+		nl := strings.IndexByte(code[end:], '\n')
+		if nl < 0 {
+			end = len(code)
+			break
+		}
+		end += nl
+		// A chain continues when the line ends or the next one starts with a dot.
+		next := strings.TrimLeft(code[end+1:], " \t")
+		if !strings.HasSuffix(strings.TrimRight(code[pos:end], " \t\r"), ".") && !strings.HasPrefix(next, ".") {
+			break
+		}
+		end++
+	}
+	if m := chainOpPattern.FindStringSubmatch(code[pos:end]); m != nil {
+		return ormOps[m[1]]
+	}
+	return TableOpUse
+}
+
+// snakeCase converts a CamelCase model name: "OrderItem" -> "order_item",
+// "HTTPLog" -> "http_log".
+func snakeCase(name string) string {
+	var sb strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// pluralize applies the regular English plural rules gorm's default naming
+// strategy uses for table names.
+func pluralize(name string) string {
+	switch {
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	default:
+		return name + "s"
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tableUses renders refs as "table:op:source" for comparison.
+func tableUses(refs []TableRef) []string {
+	out := make([]string, 0, len(refs))
+	for _, r := range refs {
+		out = append(out, r.Table+":"+r.Op+":"+r.Source)
+	}
+	return out
+}
+
+func TestSQLTables(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want []sqlTable
+	}{
+		{"SELECT u.id FROM users u JOIN orders o ON o.user_id = u.id", []sqlTable{{"orders", "select"}, {"users", "select"}}},
+		{"INSERT INTO audit_log (id, msg) SELECT id, msg FROM staging", []sqlTable{{"audit_log", "insert"}, {"staging", "select"}}},
+		{"UPDATE accounts SET balance = $1 WHERE id = $2", []sqlTable{{"accounts", "update"}}},
+		{"DELETE FROM sessions WHERE expires_at < now()", []sqlTable{{"sessions", "delete"}}},
+		{`CREATE TABLE IF NOT EXISTS "public"."events" (id int)`, []sqlTable{{"public.events", "ddl"}}},
+		{"CREATE INDEX idx_users_email ON users (email)", []sqlTable{{"users", "ddl"}}},
+		{"WITH recent AS (SELECT * FROM orders) SELECT * FROM recent", []sqlTable{{"orders", "select"}}},
+		{"SELECT EXTRACT(YEAR FROM created_at) FROM invoices", []sqlTable{{"invoices", "select"}}},
+		{"SELECT * FROM generate_series(1, 10)", nil},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, sqlTables(tt.stmt), tt.stmt)
+	}
+}
+
+func TestExtractTableRefs_SQLStrings(t *testing.T) {
+	functions := []FunctionEntity{
+		{ID: "f1", Name: "GetUser", FilePath: "store/users.go", StartLine: 10, CodeText: "func GetUser(db *sqlx.DB, id int) {\n\tdb.Get(&u, \"SELECT * \" +\n\t\t\"FROM users WHERE id = ?\", id)\n}"},
+		{ID: "f2", Name: "prompt", FilePath: "cli/prompt.go", CodeText: `fmt.Println("Select a file from the list")`},
+		{ID: "f3", Name: "purge", FilePath: "jobs/purge.py", CodeText: "def purge(cur):\n    cur.execute(\"\"\"\n        DELETE FROM sessions\n        WHERE expired\n    \"\"\")"},
+	}
+	refs := extractTableRefs(functions)
+	assert.Equal(t, []string{"users:select:sql", "sessions:delete:sql"}, tableUses(refs))
+	assert.Equal(t, 11, refs[0].Line, "line of the literal")
+}
+
+func TestExtractTableRefs_ORMs(t *testing.T) {
+	functions := []FunctionEntity{
+		{ID: "g1", Name: "Repo.Deactivate", FilePath: "repo/user.go", CodeText: "func (r *Repo) Deactivate(id int) error {\n\treturn r.db.Model(&models.User{}).\n\t\tWhere(\"id = ?\", id).\n\t\tUpdate(\"active\", false).Error\n}"},
+		{ID: "g2", Name: "Repo.Add", FilePath: "repo/category.go", CodeText: "func (r *Repo) Add() {\n\tr.db.Create(&Category{Name: n})\n\tr.db.Table(\"legacy_categories\").Find(&rows)\n}"},
+		{ID: "p1", Name: "list_orders", FilePath: "app/orders.py", CodeText: "def list_orders(session):\n    session.query(OrderItem).all()\n    session.execute(update(Order).where(Order.id == 1))\n    params.update(Extra)"},
+		{ID: "j1", Name: "createPost", FilePath: "src/posts.ts", CodeText: "async function createPost() {\n  await prisma.post.create({ data })\n  await prisma.$transaction([])\n}"},
+	}
+	refs := extractTableRefs(functions)
+	assert.Equal(t, []string{
+		"users:update:gorm",
+		"legacy_categories:select:gorm", "categories:insert:gorm",
+		"order_item:select:sqlalchemy", "order:update:sqlalchemy",
+		"Post:insert:prisma",
+	}, tableUses(refs))
+	assert.Equal(t, "User", refs[0].Model)
+	assert.Equal(t, "", refs[1].Model, "explicit Table() names have no model")
+}
+
+func TestSnakeCaseAndPluralize(t *testing.T) {
+	assert.Equal(t, "order_item", snakeCase("OrderItem"))
+	assert.Equal(t, "http_log", snakeCase("HTTPLog"))
+	assert.Equal(t, "users", pluralize("user"))
+	assert.Equal(t, "categories", pluralize("category"))
+	assert.Equal(t, "addresses", pluralize("address"))
+	assert.Equal(t, "keys", pluralize("key"))
+}
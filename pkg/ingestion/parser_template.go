@@ -0,0 +1,296 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package ingestion
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// =============================================================================
+// TEMPLATE PARSER (Go html/template and text/template, Jinja, ERB)
+// =============================================================================
+
+// Template dialects recorded on TemplateEntity.Dialect.
+const (
+	TemplateDialectGo    = "go"
+	TemplateDialectJinja = "jinja"
+	TemplateDialectERB   = "erb"
+)
+
+// Kinds of TemplateRef.
+const (
+	TemplateRefVariable = "variable" // Data the template reads ("User.Name", "user.name", "@user")
+	TemplateRefBlock    = "block"    // Block the template declares or fills
+	TemplateRefDefine   = "define"   // Named template defined in the file (Go {{define}}, Jinja macro)
+	TemplateRefInclude  = "include"  // Template it renders inside itself
+	TemplateRefExtends  = "extends"  // Layout it extends (Jinja)
+)
+
+var (
+	goTemplateAction = regexp.MustCompile(`\{\{-?\s*(.*?)\s*-?\}\}`)
+	goTemplateNamed  = regexp.MustCompile(`^(define|block|template)\s+"([^"]+)"`)
+	goTemplateField  = regexp.MustCompile(`(?:^|[\s(|])\.([A-Z_a-z][\w.]*)`)
+
+	jinjaStatement  = regexp.MustCompile(`\{%-?\s*(.*?)\s*-?%\}`)
+	jinjaExpression = regexp.MustCompile(`\{\{-?\s*(.*?)\s*-?\}\}`)
+	jinjaTemplate   = regexp.MustCompile(`^(extends|include|import|from)\s+["']([^"']+)["']`)
+	jinjaNamed      = regexp.MustCompile(`^(block|macro)\s+(\w+)`)
+	jinjaFor        = regexp.MustCompile(`^for\s+([\w\s,]+?)\s+in\s+([A-Za-z_][\w.]*)`)
+	jinjaSet        = regexp.MustCompile(`^set\s+(\w+)`)
+	jinjaIf         = regexp.MustCompile(`^(?:el)?if\s+(?:not\s+)?([A-Za-z_][\w.]*)`)
+	jinjaName       = regexp.MustCompile(`^[A-Za-z_][\w.]*`)
+
+	erbTag     = regexp.MustCompile(`<%[=-]?(.*?)-?%>`)
+	erbIvar    = regexp.MustCompile(`@([a-z_]\w*)`)
+	erbRender  = regexp.MustCompile(`render\s*\(?\s*(?:partial:\s*|:partial\s*=>\s*)?["']([^"']+)["']`)
+	erbYield   = regexp.MustCompile(`(?:yield|content_for\??)\s*\(?\s*:(\w+)`)
+	jinjaNames = map[string]bool{"true": true, "false": true, "none": true, "True": true, "False": true, "None": true, "loop": true, "not": true}
+)
+
+// templateDialect returns the dialect of a template file, or "" when an
+// .html file has no template syntax. Go templates and Jinja share "{{ }}";
+// "{%" or a non-dot expression means Jinja.
+func templateDialect(language, content string) string {
+	switch language {
+	case "gotemplate":
+		return TemplateDialectGo
+	case "jinja":
+		return TemplateDialectJinja
+	case "erb":
+		return TemplateDialectERB
+	case "html":
+		if strings.Contains(content, "{%") {
+			return TemplateDialectJinja
+		}
+		for _, m := range goTemplateAction.FindAllStringSubmatch(content, 20) {
+			action := m[1]
+			if strings.HasPrefix(action, ".") || strings.HasPrefix(action, "$") || goTemplateNamed.MatchString(action) ||
+				strings.HasPrefix(action, "end") || strings.HasPrefix(action, "range ") || strings.HasPrefix(action, "if ") {
+				return TemplateDialectGo
+			}
+			return TemplateDialectJinja
+		}
+	}
+	return ""
+}
+
+// parseTemplate extracts a template and the variables, blocks and other
+// templates it references. Returns nil for .html files without template
+// syntax. Regex-based: templates are small and their syntax is regular
+// enough, and no grammar is bundled for these dialects.
+func parseTemplate(content, filePath, language string) (*TemplateEntity, []TemplateRef) {
+	dialect := templateDialect(language, content)
+	if dialect == "" {
+		return nil, nil
+	}
+	tpl := &TemplateEntity{
+		ID:       GenerateTemplateID(filePath),
+		FilePath: filePath,
+		Dialect:  dialect,
+	}
+
+	refs := &templateRefSet{template: tpl, seen: make(map[string]bool)}
+	for i, line := range strings.Split(content, "\n") {
+		refs.line = i + 1
+		switch dialect {
+		case TemplateDialectGo:
+			refs.scanGo(line)
+		case TemplateDialectJinja:
+			refs.scanJinja(line)
+		case TemplateDialectERB:
+			refs.scanERB(line)
+		}
+	}
+	return tpl, refs.finish()
+}
+
+// templateRefSet collects the references of one template, keeping the first
+// occurrence of each kind and name.
+type templateRefSet struct {
+	template *TemplateEntity
+	line     int
+	seen     map[string]bool
+	locals   map[string]bool // Jinja loop and set variables, not template data
+	refs     []TemplateRef
+}
+
+func (s *templateRefSet) add(kind, name string) {
+	if name == "" || s.seen[kind+"|"+name] {
+		return
+	}
+	s.seen[kind+"|"+name] = true
+	s.refs = append(s.refs, TemplateRef{
+		ID:         GenerateTemplateRefID(s.template.FilePath, kind, name),
+		TemplateID: s.template.ID,
+		FilePath:   s.template.FilePath,
+		Kind:       kind,
+		Name:       name,
+		Line:       s.line,
+	})
+}
+
+// addVariable records a data reference, skipping template-local names.
+func (s *templateRefSet) addVariable(path string) {
+	path = strings.TrimSuffix(path, ".")
+	root, _, _ := strings.Cut(path, ".")
+	if jinjaNames[root] || s.locals[root] {
+		return
+	}
+	s.add(TemplateRefVariable, path)
+}
+
+func (s *templateRefSet) scanGo(line string) {
+	for _, m := range goTemplateAction.FindAllStringSubmatch(line, -1) {
+		action := m[1]
+		if strings.HasPrefix(action, "/*") {
+			continue // comment
+		}
+		if named := goTemplateNamed.FindStringSubmatch(action); named != nil {
+			switch named[1] {
+			case "define":
+				s.add(TemplateRefDefine, named[2])
+			case "block":
+				s.add(TemplateRefBlock, named[2])
+			case "template":
+				s.add(TemplateRefInclude, named[2])
+			}
+		}
+		for _, field := range goTemplateField.FindAllStringSubmatch(action, -1) {
+			s.addVariable(field[1])
+		}
+	}
+}
+
+func (s *templateRefSet) scanJinja(line string) {
+	for _, m := range jinjaStatement.FindAllStringSubmatch(line, -1) {
+		stmt := m[1]
+		if t := jinjaTemplate.FindStringSubmatch(stmt); t != nil {
+			kind := TemplateRefInclude
+			if t[1] == "extends" {
+				kind = TemplateRefExtends
+			}
+			s.add(kind, t[2])
+			continue
+		}
+		if named := jinjaNamed.FindStringSubmatch(stmt); named != nil {
+			kind := TemplateRefBlock
+			if named[1] == "macro" {
+				kind = TemplateRefDefine
+			}
+			s.add(kind, named[2])
+			continue
+		}
+		if f := jinjaFor.FindStringSubmatch(stmt); f != nil {
+			s.addVariable(f[2])
+			s.markLocals(strings.Split(f[1], ",")...)
+			continue
+		}
+		if set := jinjaSet.FindStringSubmatch(stmt); set != nil {
+			s.markLocals(set[1])
+			continue
+		}
+		if cond := jinjaIf.FindStringSubmatch(stmt); cond != nil {
+			s.addVariable(cond[1])
+		}
+	}
+	for _, m := range jinjaExpression.FindAllStringSubmatch(line, -1) {
+		if name := jinjaName.FindString(m[1]); name != "" && !strings.HasPrefix(m[1][len(name):], "(") {
+			s.addVariable(name)
+		}
+	}
+}
+
+func (s *templateRefSet) markLocals(names ...string) {
+	if s.locals == nil {
+		s.locals = make(map[string]bool)
+	}
+	for _, n := range names {
+		s.locals[strings.TrimSpace(n)] = true
+	}
+}
+
+func (s *templateRefSet) scanERB(line string) {
+	for _, m := range erbTag.FindAllStringSubmatch(line, -1) {
+		code := m[1]
+		for _, r := range erbRender.FindAllStringSubmatch(code, -1) {
+			s.add(TemplateRefInclude, r[1])
+		}
+		for _, y := range erbYield.FindAllStringSubmatch(code, -1) {
+			s.add(TemplateRefBlock, y[1])
+		}
+		for _, v := range erbIvar.FindAllStringSubmatch(code, -1) {
+			s.addVariable("@" + v[1])
+		}
+	}
+}
+
+// finish returns the references ordered by line, then kind and name.
+func (s *templateRefSet) finish() []TemplateRef {
+	sort.SliceStable(s.refs, func(i, j int) bool {
+		if s.refs[i].Line != s.refs[j].Line {
+			return s.refs[i].Line < s.refs[j].Line
+		}
+		return s.refs[i].Kind+s.refs[i].Name < s.refs[j].Kind+s.refs[j].Name
+	})
+	return s.refs
+}
+
+// renderCallPattern matches calls that render a template by name or path:
+// Go's ExecuteTemplate/ParseFiles and gin's c.HTML, Flask's
+// render_template, Django's render/render_to_string/get_template,
+// FastAPI/Starlette's TemplateResponse and Express's res.render.
+var renderCallPattern = regexp.MustCompile(`\b(ExecuteTemplate|ParseFiles|ParseFS|HTML|render_template|render_to_string|get_template|select_template|TemplateResponse|render|Render)\s*\(`)
+
+// templateNameLiteral matches the first string literal of a call's arguments.
+var templateNameLiteral = regexp.MustCompile("^[^\"'`)]*?[\"'`]([^\"'`\\n]+)[\"'`]")
+
+// extractRenderCalls finds the templates each function renders: the first
+// string literal passed to a known render call. The name is kept as written
+// and resolved against indexed templates at query time, so a handler and its
+// template can be re-indexed independently.
+func extractRenderCalls(functions []FunctionEntity) []RenderCall {
+	var renders []RenderCall
+	for _, fn := range functions {
+		seen := make(map[string]bool)
+		for _, loc := range renderCallPattern.FindAllStringSubmatchIndex(fn.CodeText, -1) {
+			args := fn.CodeText[loc[1]:]
+			m := templateNameLiteral.FindStringSubmatch(args)
+			if m == nil || seen[m[1]] || !looksLikeTemplateName(m[1]) {
+				continue
+			}
+			seen[m[1]] = true
+			renders = append(renders, RenderCall{
+				FunctionID:   fn.ID,
+				FilePath:     fn.FilePath,
+				TemplateName: m[1],
+				Line:         fn.StartLine + strings.Count(fn.CodeText[:loc[0]], "\n"),
+			})
+		}
+	}
+	return renders
+}
+
+// looksLikeTemplateName filters out literals that cannot name a template
+// (format strings, URLs, whitespace).
+func looksLikeTemplateName(s string) bool {
+	return s != "" && !strings.ContainsAny(s, " \t%{}<>") && !strings.Contains(s, "://")
+}
@@ -0,0 +1,120 @@
+package ingestion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// templateRefNames groups a template's references by kind.
+func templateRefNames(refs []TemplateRef) map[string][]string {
+	names := make(map[string][]string)
+	for _, r := range refs {
+		names[r.Kind] = append(names[r.Kind], r.Name)
+	}
+	return names
+}
+
+func TestParseTemplate_Go(t *testing.T) {
+	content := `{{define "users/list"}}
+<h1>{{ .Title }}</h1>
+{{- range .Users }}<li>{{ .Name | html }}</li>{{ end }}
+{{template "partials/footer" .}}
+{{block "sidebar" .}}{{end}}
+{{end}}`
+	tpl, refs := parseTemplate(content, "web/users.gohtml", "gotemplate")
+	require.NotNil(t, tpl)
+	assert.Equal(t, TemplateDialectGo, tpl.Dialect)
+	assert.Equal(t, GenerateTemplateID("web/users.gohtml"), tpl.ID)
+
+	names := templateRefNames(refs)
+	assert.Equal(t, []string{"users/list"}, names[TemplateRefDefine])
+	assert.Equal(t, []string{"partials/footer"}, names[TemplateRefInclude])
+	assert.Equal(t, []string{"sidebar"}, names[TemplateRefBlock])
+	assert.Equal(t, []string{"Title", "Name", "Users"}, names[TemplateRefVariable])
+	assert.Equal(t, 2, refs[1].Line, "references keep their line")
+}
+
+func TestParseTemplate_Jinja(t *testing.T) {
+	content := `{% extends "base.html" %}
+{% block content %}
+{% include 'partials/nav.html' %}
+{% for item in order.items %}{{ item.name }} {{ loop.index }}{% endfor %}
+{% set total = order.total %}
+{% if user.is_admin %}{{ total }} {{ url_for('admin') }}{% endif %}
+{{ user.name | title }}
+{% endblock %}`
+	tpl, refs := parseTemplate(content, "templates/order.html", "html")
+	require.NotNil(t, tpl)
+	assert.Equal(t, TemplateDialectJinja, tpl.Dialect)
+
+	names := templateRefNames(refs)
+	assert.Equal(t, []string{"base.html"}, names[TemplateRefExtends])
+	assert.Equal(t, []string{"partials/nav.html"}, names[TemplateRefInclude])
+	assert.Equal(t, []string{"content"}, names[TemplateRefBlock])
+	assert.Equal(t, []string{"order.items", "user.is_admin", "user.name"}, names[TemplateRefVariable],
+		"loop, set and function names are not template data")
+}
+
+func TestParseTemplate_ERB(t *testing.T) {
+	content := `<% content_for :title do %>Users<% end %>
+<%= render "shared/header" %>
+<% @users.each do |user| %><%= user.name %> <%= @current_user.email %><% end %>
+<%= yield :sidebar %>`
+	tpl, refs := parseTemplate(content, "app/views/users/index.html.erb", "erb")
+	require.NotNil(t, tpl)
+	assert.Equal(t, TemplateDialectERB, tpl.Dialect)
+
+	names := templateRefNames(refs)
+	assert.Equal(t, []string{"shared/header"}, names[TemplateRefInclude])
+	assert.Equal(t, []string{"title", "sidebar"}, names[TemplateRefBlock])
+	assert.Equal(t, []string{"@current_user", "@users"}, names[TemplateRefVariable])
+}
+
+func TestParseTemplate_PlainHTML(t *testing.T) {
+	tpl, refs := parseTemplate("<html><body>Hello</body></html>", "static/index.html", "html")
+	assert.Nil(t, tpl)
+	assert.Empty(t, refs)
+
+	tpl, _ = parseTemplate(`<p>{{ .Name }}</p>`, "web/index.html", "html")
+	require.NotNil(t, tpl)
+	assert.Equal(t, TemplateDialectGo, tpl.Dialect)
+}
+
+func TestExtractRenderCalls(t *testing.T) {
+	functions := []FunctionEntity{
+		{
+			ID: "fn:list", FilePath: "web/handlers.go", StartLine: 10,
+			CodeText: "func list(w http.ResponseWriter, r *http.Request) {\n\tif err := tmpl.ExecuteTemplate(w, \"users/list\", data); err != nil {\n\t\tlog.Printf(\"render %s\", err)\n\t}\n}",
+		},
+		{
+			ID: "fn:show", FilePath: "app/views.py", StartLine: 3,
+			CodeText: "def show(id: int):\n    return render_template('users/show.html', user=load(id))",
+		},
+		{
+			ID: "fn:other", FilePath: "app/util.py", StartLine: 1,
+			CodeText: "def other():\n    return fmt('%s items')",
+		},
+	}
+
+	renders := extractRenderCalls(functions)
+	require.Len(t, renders, 2)
+	assert.Equal(t, RenderCall{FunctionID: "fn:list", FilePath: "web/handlers.go", TemplateName: "users/list", Line: 11}, renders[0])
+	assert.Equal(t, RenderCall{FunctionID: "fn:show", FilePath: "app/views.py", TemplateName: "users/show.html", Line: 4}, renders[1])
+}
+
+func TestBuildTemplateMutations(t *testing.T) {
+	tpl, refs := parseTemplate(`{{ .Title }}`, "web/page.tmpl", "gotemplate")
+	script := NewDatalogBuilder().BuildTemplateMutations([]TemplateEntity{*tpl}, refs, []RenderCall{
+		{FunctionID: "fn:page", FilePath: "web/page.go", TemplateName: "page.tmpl", Line: 7},
+	})
+	for _, want := range []string{
+		":put cie_template { id, file_path, dialect }",
+		`'web/page.tmpl', 'go'`,
+		`'variable', 'Title', 1`,
+		`'fn:page', 'web/page.go', 'page.tmpl', 7`,
+	} {
+		assert.Contains(t, script, want)
+	}
+}
@@ -20,8 +20,6 @@
 package ingestion
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"os"
 	"sync"
@@ -29,8 +27,10 @@ import (
 	"log/slog"
 
 	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/csharp"
 	"github.com/smacker/go-tree-sitter/golang"
 	"github.com/smacker/go-tree-sitter/javascript"
+	"github.com/smacker/go-tree-sitter/protobuf"
 	"github.com/smacker/go-tree-sitter/python"
 	"github.com/smacker/go-tree-sitter/typescript/typescript"
 )
@@ -42,18 +42,24 @@ import (
 //   - Call graph extraction (same-file)
 //   - Proper handling of nested functions, closures, methods
 //
-// Supported languages: Go, Python, JavaScript, TypeScript
+// Supported languages: Go, Python, JavaScript, TypeScript, C#, Protocol Buffers
 type TreeSitterParser struct {
 	logger          *slog.Logger
 	maxCodeTextSize int64
+	languageLimits  map[string]int64 // Per-language overrides of maxCodeTextSize
+	hashMode        ContentHashMode  // Normalization applied before hashing files
 	truncatedCount  int
-	mu              sync.Mutex // Protects truncatedCount
+	mu              sync.Mutex   // Protects truncatedCount
+	overflow        codeOverflow // Code cut off by truncateCodeText
+	syntaxErrors    syntaxErrors // Error nodes found by the language parsers
 
 	// Language parser pools (parsers are not thread-safe)
 	goPool     sync.Pool
 	pyPool     sync.Pool
 	jsPool     sync.Pool
 	tsPool     sync.Pool
+	csPool     sync.Pool
+	protoPool  sync.Pool
 	parserInit sync.Once
 }
 
@@ -91,6 +97,16 @@ func (p *TreeSitterParser) initParsers() {
 			parser.SetLanguage(typescript.GetLanguage())
 			return parser
 		}
+		p.csPool.New = func() any {
+			parser := sitter.NewParser()
+			parser.SetLanguage(csharp.GetLanguage())
+			return parser
+		}
+		p.protoPool.New = func() any {
+			parser := sitter.NewParser()
+			parser.SetLanguage(protobuf.GetLanguage())
+			return parser
+		}
 	})
 }
 
@@ -99,6 +115,21 @@ func (p *TreeSitterParser) SetMaxCodeTextSize(size int64) {
 	p.maxCodeTextSize = size
 }
 
+// SetContentHashMode sets how file content is normalized before hashing.
+func (p *TreeSitterParser) SetContentHashMode(mode ContentHashMode) {
+	p.hashMode = mode
+}
+
+// SetLanguageMaxCodeTextSize overrides the CodeText limit for one language.
+// Call it before parsing starts; the limits are not guarded for concurrent
+// updates.
+func (p *TreeSitterParser) SetLanguageMaxCodeTextSize(language string, size int64) {
+	if p.languageLimits == nil {
+		p.languageLimits = make(map[string]int64)
+	}
+	p.languageLimits[language] = size
+}
+
 // GetTruncatedCount returns the number of CodeTexts that were truncated.
 func (p *TreeSitterParser) GetTruncatedCount() int {
 	p.mu.Lock()
@@ -113,13 +144,18 @@ func (p *TreeSitterParser) ResetTruncatedCount() {
 	p.truncatedCount = 0
 }
 
-// truncateCodeText truncates CodeText if it exceeds the limit.
-func (p *TreeSitterParser) truncateCodeText(codeText string) string {
-	if p.maxCodeTextSize > 0 && int64(len(codeText)) > p.maxCodeTextSize {
+// truncateCodeText truncates CodeText if it exceeds the limit for the file's
+// language. The cut-off code is kept for ParseFile to store in
+// cie_function_code_chunk.
+func (p *TreeSitterParser) truncateCodeText(filePath, codeText string) string {
+	limit := codeTextLimit(filePath, p.maxCodeTextSize, p.languageLimits)
+	if limit > 0 && int64(len(codeText)) > limit {
 		p.mu.Lock()
 		p.truncatedCount++
 		p.mu.Unlock()
-		return codeText[:p.maxCodeTextSize]
+		kept, rest := splitCodeText(codeText, limit)
+		p.overflow.record(filePath, kept, rest)
+		return kept
 	}
 	return codeText
 }
@@ -134,9 +170,21 @@ func (p *TreeSitterParser) ParseFile(fileInfo FileInfo) (*ParseResult, error) {
 		return nil, fmt.Errorf("read file: %w", err)
 	}
 
-	// Compute content hash
-	hash := sha256.Sum256(content)
-	hashStr := hex.EncodeToString(hash[:])
+	// Compute content hash (of the bytes on disk, so change detection is
+	// unaffected by transcoding)
+	hashStr := ContentHash(content, p.hashMode)
+
+	// Parse UTF-16 and Latin-1 files as UTF-8
+	content, encoding := decodeSource(content)
+	if encoding != encodingUTF8 {
+		p.logger.Debug("parser.transcoded", "path", fileInfo.Path, "encoding", encoding)
+	}
+
+	// Parse CRLF files with LF endings so code text (and with it embeddings
+	// and their cache keys) matches across checkouts
+	if p.hashMode != ContentHashExact {
+		content = normalizeLineEndings(content)
+	}
 
 	// Create file entity
 	fileID := GenerateFileID(fileInfo.Path)
@@ -155,6 +203,7 @@ func (p *TreeSitterParser) ParseFile(fileInfo FileInfo) (*ParseResult, error) {
 	var calls []CallsEdge
 	var imports []ImportEntity
 	var unresolvedCalls []UnresolvedCall
+	var protoOptions []ProtoOption
 	var packageName string
 
 	switch fileInfo.Language {
@@ -183,7 +232,7 @@ func (p *TreeSitterParser) ParseFile(fileInfo FileInfo) (*ParseResult, error) {
 			return nil, fmt.Errorf("invalid parser type from python pool")
 		}
 		defer p.pyPool.Put(parser)
-		functions, types, calls, err = p.parsePythonAST(parser, content, fileInfo.Path)
+		functions, types, calls, unresolvedCalls, err = p.parsePythonAST(parser, content, fileInfo.Path)
 	case "javascript":
 		parserObj := p.jsPool.Get()
 		parser, ok := parserObj.(*sitter.Parser)
@@ -200,9 +249,57 @@ func (p *TreeSitterParser) ParseFile(fileInfo FileInfo) (*ParseResult, error) {
 		}
 		defer p.tsPool.Put(parser)
 		functions, types, calls, err = p.parseTypeScriptAST(parser, content, fileInfo.Path)
+	case "csharp":
+		parserObj := p.csPool.Get()
+		parser, ok := parserObj.(*sitter.Parser)
+		if !ok {
+			return nil, fmt.Errorf("invalid parser type from csharp pool")
+		}
+		defer p.csPool.Put(parser)
+		csResult, csErr := p.parseCSharpAST(parser, content, fileInfo.Path)
+		if csErr != nil {
+			return nil, fmt.Errorf("parse csharp AST: %w", csErr)
+		}
+		functions = csResult.Functions
+		types = csResult.Types
+		fields = csResult.Fields
+		calls = csResult.Calls
+		imports = csResult.Imports
+		unresolvedCalls = csResult.UnresolvedCalls
+		packageName = csResult.Namespace
 	case "protobuf":
-		// Use regex-based parsing for protobuf (no tree-sitter grammar bundled)
-		functions, calls = parseProtobufSimplified(content, fileInfo.Path, p)
+		parserObj := p.protoPool.Get()
+		parser, ok := parserObj.(*sitter.Parser)
+		if !ok {
+			return nil, fmt.Errorf("invalid parser type from protobuf pool")
+		}
+		defer p.protoPool.Put(parser)
+		protoResult, protoErr := p.parseProtobufAST(parser, content, fileInfo.Path)
+		if protoErr != nil {
+			return nil, fmt.Errorf("parse protobuf AST: %w", protoErr)
+		}
+		functions = protoResult.Functions
+		types = protoResult.Types
+		fields = protoResult.Fields
+		protoOptions = protoResult.Options
+	case "gotemplate", "jinja", "erb", "html":
+		template, refs := parseTemplate(string(content), fileInfo.Path, fileInfo.Language)
+		return &ParseResult{
+			File:         fileEntity,
+			Template:     template,
+			TemplateRefs: refs,
+		}, nil
+	case "github_actions", "gitlab_ci":
+		ci, ciErr := parseCIWorkflow(content, fileInfo.Path, fileInfo.Language)
+		if ciErr != nil {
+			return nil, fmt.Errorf("parse CI workflow: %w", ciErr)
+		}
+		return &ParseResult{
+			File:    fileEntity,
+			CIJobs:  ci.Jobs,
+			CISteps: ci.Steps,
+			CIRefs:  ci.Refs,
+		}, nil
 	default:
 		// Unsupported language - return empty result without error
 		p.logger.Debug("parser.treesitter.skip_unsupported",
@@ -221,6 +318,10 @@ func (p *TreeSitterParser) ParseFile(fileInfo FileInfo) (*ParseResult, error) {
 		return nil, fmt.Errorf("parse %s AST: %w", fileInfo.Language, err)
 	}
 
+	syntaxErrorCount := p.syntaxErrors.take(fileInfo.Path)
+	annotateFunctionLanguage(functions, fileInfo.Language, string(content))
+	p.overflow.attach(fileInfo.Path, functions)
+
 	// Create defines edges for functions
 	defines := make([]DefinesEdge, len(functions))
 	for i, fn := range functions {
@@ -250,9 +351,42 @@ func (p *TreeSitterParser) ParseFile(fileInfo FileInfo) (*ParseResult, error) {
 		Imports:         imports,
 		UnresolvedCalls: unresolvedCalls,
 		PackageName:     packageName,
+		ProtoOptions:    protoOptions,
+		Renders:         extractRenderCalls(functions),
+		TableRefs:       extractTableRefs(functions),
+		RPCClients:      extractRPCClientCalls(string(content), fileInfo.Path, functions),
+		Generated:       detectGeneratedFile(string(content), fileInfo.Path),
+		SyntaxErrors:    syntaxErrorCount,
 	}, nil
 }
 
+// syntaxErrors holds the error node count each language parser found, by
+// file, until ParseFile reports it on the ParseResult. The zero value is
+// ready to use and safe for concurrent parses.
+type syntaxErrors struct {
+	mu     sync.Mutex
+	byFile map[string]int
+}
+
+// record remembers that filePath parsed with count error nodes.
+func (e *syntaxErrors) record(filePath string, count int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.byFile == nil {
+		e.byFile = make(map[string]int)
+	}
+	e.byFile[filePath] = count
+}
+
+// take returns the error node count recorded for filePath and forgets it.
+func (e *syntaxErrors) take(filePath string) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	count := e.byFile[filePath]
+	delete(e.byFile, filePath)
+	return count
+}
+
 // =============================================================================
 // HELPER FUNCTIONS
 // =============================================================================
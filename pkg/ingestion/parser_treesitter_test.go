@@ -67,16 +67,24 @@ func main() {
 		}
 	}
 
-	// Verify anonymous function is extracted with special name
-	foundAnon := false
+	// Verify the closure is named after its enclosing function. Defining a
+	// closure is not a call: the nesting is recorded in cie_contains.
+	var outerID, closureID string
 	for _, fn := range result.Functions {
-		if strings.HasPrefix(fn.Name, "$anon_") {
-			foundAnon = true
-			break
+		switch fn.Name {
+		case "outer":
+			outerID = fn.ID
+		case "outer.closure#1":
+			closureID = fn.ID
 		}
 	}
-	if !foundAnon {
-		t.Error("expected to find anonymous function with $anon_ prefix")
+	if closureID == "" {
+		t.Fatal("expected to find anonymous function named outer.closure#1")
+	}
+	for _, call := range result.Calls {
+		if call.CallerID == outerID && call.CalleeID == closureID {
+			t.Error("defining a closure should not add a call edge to it")
+		}
 	}
 }
 
@@ -471,6 +479,21 @@ func } syntax {
 	if !foundValid {
 		t.Error("expected to find validFunction even with malformed code")
 	}
+	if result.SyntaxErrors == 0 {
+		t.Error("expected SyntaxErrors to count the error nodes")
+	}
+
+	// The count belongs to the file it was found in.
+	if err := os.WriteFile(tmpFile, []byte("package main\n\nfunc ok() {}\n"), 0600); err != nil {
+		t.Fatalf("write test file: %v", err)
+	}
+	result, err = parser.ParseFile(fileInfo)
+	if err != nil {
+		t.Fatalf("parse file: %v", err)
+	}
+	if result.SyntaxErrors != 0 {
+		t.Errorf("SyntaxErrors = %d for valid code, want 0", result.SyntaxErrors)
+	}
 }
 
 // TestTreeSitterParser_Python tests Python parsing.
@@ -1161,6 +1184,42 @@ func largeFunction() {
 	}
 }
 
+// TestTreeSitterParser_LanguageCodeTextLimit tests per-language truncation limits.
+func TestTreeSitterParser_LanguageCodeTextLimit(t *testing.T) {
+	dir := t.TempDir()
+	goBody := "package main\n\nfunc big() {\n" + strings.Repeat("println(\"line\")\n", 200) + "}\n"
+	jsBody := "function big() {\n" + strings.Repeat("console.log('line');\n", 200) + "}\n"
+	for name, content := range map[string]string{"big.go": goBody, "big.js": jsBody} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("write test file: %v", err)
+		}
+	}
+
+	parser := NewTreeSitterParser(nil)
+	parser.SetMaxCodeTextSize(1000)
+	parser.SetLanguageMaxCodeTextSize("go", 100000)
+	parser.SetLanguageMaxCodeTextSize("javascript", 200)
+
+	goResult, err := parser.ParseFile(FileInfo{Path: "big.go", FullPath: filepath.Join(dir, "big.go"), Language: "go"})
+	if err != nil {
+		t.Fatalf("parse go file: %v", err)
+	}
+	if got := len(goResult.Functions[0].CodeText); got <= 1000 {
+		t.Errorf("Go override should keep the full function, got %d bytes", got)
+	}
+
+	jsResult, err := parser.ParseFile(FileInfo{Path: "big.js", FullPath: filepath.Join(dir, "big.js"), Language: "javascript"})
+	if err != nil {
+		t.Fatalf("parse js file: %v", err)
+	}
+	if got := len(jsResult.Functions[0].CodeText); got != 200 {
+		t.Errorf("JavaScript override should cap code text at 200 bytes, got %d", got)
+	}
+	if parser.GetTruncatedCount() != 1 {
+		t.Errorf("expected truncated count 1, got %d", parser.GetTruncatedCount())
+	}
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
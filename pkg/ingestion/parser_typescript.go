@@ -58,6 +58,7 @@ func (p *TreeSitterParser) parseTypeScriptAST(parser *sitter.Parser, content []b
 				"path", filePath,
 				"error_count", errorCount,
 			)
+			p.syntaxErrors.record(filePath, errorCount)
 		}
 	}
 
@@ -66,6 +67,7 @@ func (p *TreeSitterParser) parseTypeScriptAST(parser *sitter.Parser, content []b
 	anonCounter := 0
 
 	p.walkTSFunctions(rootNode, content, filePath, &functions, funcNameToID, &anonCounter)
+	localizeAnonymousFunctions(functions)
 
 	// Extract types (interfaces, classes, type aliases)
 	types := p.extractTSTypes(rootNode, content, filePath)
@@ -215,7 +217,7 @@ func (p *TreeSitterParser) extractTSMethodSignature(node *sitter.Node, content [
 	endCol := int(node.EndPoint().Column) + 1
 
 	codeText := signature
-	codeText = p.truncateCodeText(codeText)
+	codeText = p.truncateCodeText(filePath, codeText)
 
 	id := GenerateFunctionID(filePath, name, signature, startLine, endLine, startCol, endCol)
 
@@ -248,7 +250,7 @@ func (p *TreeSitterParser) extractTSFunctionSignature(node *sitter.Node, content
 	endCol := int(node.EndPoint().Column) + 1
 
 	codeText := signature
-	codeText = p.truncateCodeText(codeText)
+	codeText = p.truncateCodeText(filePath, codeText)
 
 	id := GenerateFunctionID(filePath, name, signature, startLine, endLine, startCol, endCol)
 
@@ -330,7 +332,7 @@ func (p *TreeSitterParser) extractTSInterface(node *sitter.Node, content []byte,
 	endCol := int(node.EndPoint().Column) + 1
 
 	codeText := string(content[node.StartByte():node.EndByte()])
-	codeText = p.truncateCodeText(codeText)
+	codeText = p.truncateCodeText(filePath, codeText)
 
 	id := GenerateTypeID(filePath, name, startLine, endLine)
 
@@ -361,7 +363,7 @@ func (p *TreeSitterParser) extractTSClass(node *sitter.Node, content []byte, fil
 	endCol := int(node.EndPoint().Column) + 1
 
 	codeText := string(content[node.StartByte():node.EndByte()])
-	codeText = p.truncateCodeText(codeText)
+	codeText = p.truncateCodeText(filePath, codeText)
 
 	id := GenerateTypeID(filePath, name, startLine, endLine)
 
@@ -392,7 +394,7 @@ func (p *TreeSitterParser) extractTSTypeAlias(node *sitter.Node, content []byte,
 	endCol := int(node.EndPoint().Column) + 1
 
 	codeText := string(content[node.StartByte():node.EndByte()])
-	codeText = p.truncateCodeText(codeText)
+	codeText = p.truncateCodeText(filePath, codeText)
 
 	id := GenerateTypeID(filePath, name, startLine, endLine)
 
@@ -0,0 +1,282 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// profileSlowFiles is how many of the slowest files a profile keeps.
+const profileSlowFiles = 20
+
+// embedLatencyBucketsMs are the upper bounds of the embedding latency
+// histogram, in milliseconds. The last bucket catches everything above.
+var embedLatencyBucketsMs = []float64{10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// Profiler collects timings for one indexing run: per-stage durations,
+// per-file parse times and per-call embedding latency. All methods are safe
+// to call on a nil *Profiler, so the pipeline records unconditionally and
+// profiling costs nothing when it is off.
+type Profiler struct {
+	mu     sync.Mutex
+	start  time.Time
+	stages []StageTiming
+	files  []FileTiming
+	embeds []time.Duration
+	embErr int
+}
+
+// NewProfiler returns a profiler whose clock starts now.
+func NewProfiler() *Profiler {
+	return &Profiler{start: time.Now()}
+}
+
+// StageTiming is the wall-clock time spent in one pipeline stage.
+type StageTiming struct {
+	Name       string  `json:"name"`
+	DurationMs float64 `json:"duration_ms"`
+	Percent    float64 `json:"percent"`
+}
+
+// FileTiming is the parse time of one file.
+type FileTiming struct {
+	Path       string  `json:"path"`
+	Language   string  `json:"language"`
+	Bytes      int64   `json:"bytes"`
+	DurationMs float64 `json:"duration_ms"`
+	Failed     bool    `json:"failed,omitempty"`
+}
+
+// LanguageThroughput aggregates parse throughput for one language.
+type LanguageThroughput struct {
+	Language    string  `json:"language"`
+	Files       int     `json:"files"`
+	Bytes       int64   `json:"bytes"`
+	Errors      int     `json:"errors"`
+	DurationMs  float64 `json:"duration_ms"`
+	FilesPerSec float64 `json:"files_per_sec"`
+	MBPerSec    float64 `json:"mb_per_sec"`
+}
+
+// LatencyBucket counts embedding calls at or below LeMs (0 means +Inf).
+type LatencyBucket struct {
+	LeMs  float64 `json:"le_ms"`
+	Count int     `json:"count"`
+}
+
+// EmbeddingLatency summarizes embedding provider calls.
+type EmbeddingLatency struct {
+	Calls     int             `json:"calls"`
+	Errors    int             `json:"errors"`
+	TotalMs   float64         `json:"total_ms"`
+	MeanMs    float64         `json:"mean_ms"`
+	P50Ms     float64         `json:"p50_ms"`
+	P90Ms     float64         `json:"p90_ms"`
+	P99Ms     float64         `json:"p99_ms"`
+	MaxMs     float64         `json:"max_ms"`
+	Histogram []LatencyBucket `json:"histogram"`
+}
+
+// ProfileReport is the JSON document written by `cie index --timings`.
+type ProfileReport struct {
+	TotalMs      float64              `json:"total_ms"`
+	Stages       []StageTiming        `json:"stages"`
+	Languages    []LanguageThroughput `json:"languages"`
+	Embeddings   EmbeddingLatency     `json:"embeddings"`
+	SlowestFiles []FileTiming         `json:"slowest_files"`
+}
+
+// StartStage begins timing a named stage and returns the function that ends
+// it. Stages that run more than once (e.g. function and type embedding) are
+// summed under the same name.
+func (p *Profiler) StartStage(name string) func() {
+	if p == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		d := float64(time.Since(start).Microseconds()) / 1000
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		for i := range p.stages {
+			if p.stages[i].Name == name {
+				p.stages[i].DurationMs += d
+				return
+			}
+		}
+		p.stages = append(p.stages, StageTiming{Name: name, DurationMs: d})
+	}
+}
+
+// RecordFile records how long one file took to parse.
+func (p *Profiler) RecordFile(file FileInfo, d time.Duration, failed bool) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.files = append(p.files, FileTiming{
+		Path:       file.Path,
+		Language:   file.Language,
+		Bytes:      file.Size,
+		DurationMs: float64(d.Microseconds()) / 1000,
+		Failed:     failed,
+	})
+}
+
+// RecordEmbedding records the latency of one embedding provider call.
+func (p *Profiler) RecordEmbedding(d time.Duration, err error) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.embeds = append(p.embeds, d)
+	if err != nil {
+		p.embErr++
+	}
+}
+
+// Report summarizes everything recorded so far.
+func (p *Profiler) Report() *ProfileReport {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	total := float64(time.Since(p.start).Microseconds()) / 1000
+	report := &ProfileReport{TotalMs: total, Stages: append([]StageTiming(nil), p.stages...)}
+	for i := range report.Stages {
+		if total > 0 {
+			report.Stages[i].Percent = report.Stages[i].DurationMs / total * 100
+		}
+	}
+
+	report.Languages = languageThroughput(p.files)
+
+	slowest := append([]FileTiming(nil), p.files...)
+	sort.SliceStable(slowest, func(i, j int) bool { return slowest[i].DurationMs > slowest[j].DurationMs })
+	if len(slowest) > profileSlowFiles {
+		slowest = slowest[:profileSlowFiles]
+	}
+	report.SlowestFiles = slowest
+
+	report.Embeddings = embeddingLatency(p.embeds, p.embErr)
+	return report
+}
+
+// languageThroughput groups file timings by language. Durations are summed
+// parse time, so with several parse workers throughput is per worker.
+func languageThroughput(files []FileTiming) []LanguageThroughput {
+	byLang := map[string]*LanguageThroughput{}
+	for _, f := range files {
+		lang := f.Language
+		if lang == "" {
+			lang = "unknown"
+		}
+		lt := byLang[lang]
+		if lt == nil {
+			lt = &LanguageThroughput{Language: lang}
+			byLang[lang] = lt
+		}
+		lt.Files++
+		lt.Bytes += f.Bytes
+		lt.DurationMs += f.DurationMs
+		if f.Failed {
+			lt.Errors++
+		}
+	}
+	out := make([]LanguageThroughput, 0, len(byLang))
+	for _, lt := range byLang {
+		if lt.DurationMs > 0 {
+			secs := lt.DurationMs / 1000
+			lt.FilesPerSec = float64(lt.Files) / secs
+			lt.MBPerSec = float64(lt.Bytes) / (1 << 20) / secs
+		}
+		out = append(out, *lt)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].DurationMs != out[j].DurationMs {
+			return out[i].DurationMs > out[j].DurationMs
+		}
+		return out[i].Language < out[j].Language
+	})
+	return out
+}
+
+func embeddingLatency(calls []time.Duration, errCount int) EmbeddingLatency {
+	el := EmbeddingLatency{Calls: len(calls), Errors: errCount}
+	el.Histogram = make([]LatencyBucket, len(embedLatencyBucketsMs)+1)
+	for i, le := range embedLatencyBucketsMs {
+		el.Histogram[i].LeMs = le
+	}
+	if len(calls) == 0 {
+		return el
+	}
+
+	ms := make([]float64, len(calls))
+	for i, d := range calls {
+		ms[i] = float64(d.Microseconds()) / 1000
+		el.TotalMs += ms[i]
+		bucket := len(embedLatencyBucketsMs)
+		for b, le := range embedLatencyBucketsMs {
+			if ms[i] <= le {
+				bucket = b
+				break
+			}
+		}
+		el.Histogram[bucket].Count++
+	}
+	sort.Float64s(ms)
+	el.MeanMs = el.TotalMs / float64(len(ms))
+	el.P50Ms = percentile(ms, 50)
+	el.P90Ms = percentile(ms, 90)
+	el.P99Ms = percentile(ms, 99)
+	el.MaxMs = ms[len(ms)-1]
+	return el
+}
+
+// percentile returns the nearest-rank percentile of sorted values.
+func percentile(sorted []float64, pct float64) float64 {
+	idx := int(pct/100*float64(len(sorted))+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+// profiledEmbeddingProvider times every call to the wrapped provider.
+type profiledEmbeddingProvider struct {
+	EmbeddingProvider
+	profiler *Profiler
+}
+
+func (pp *profiledEmbeddingProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	start := time.Now()
+	vec, err := pp.EmbeddingProvider.Embed(ctx, text)
+	pp.profiler.RecordEmbedding(time.Since(start), err)
+	return vec, err
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProfiler_NilIsNoop(t *testing.T) {
+	var p *Profiler
+	p.StartStage("parse")()
+	p.RecordFile(FileInfo{Path: "a.go"}, time.Millisecond, false)
+	p.RecordEmbedding(time.Millisecond, nil)
+	if p.Report() != nil {
+		t.Error("nil profiler should return a nil report")
+	}
+}
+
+func TestProfiler_Report(t *testing.T) {
+	p := NewProfiler()
+	end := p.StartStage("embed")
+	end()
+	p.StartStage("embed")() // repeated stages are summed into one entry
+	p.StartStage("write")()
+
+	for i := 0; i < 25; i++ {
+		p.RecordFile(FileInfo{Path: "f.go", Language: "go", Size: 1 << 20}, time.Duration(i+1)*time.Millisecond, false)
+	}
+	p.RecordFile(FileInfo{Path: "big.py", Language: "python", Size: 2048}, 500*time.Millisecond, true)
+
+	for _, ms := range []int{5, 20, 40, 80, 20000} {
+		p.RecordEmbedding(time.Duration(ms)*time.Millisecond, nil)
+	}
+	p.RecordEmbedding(time.Millisecond, errors.New("boom"))
+
+	r := p.Report()
+	if len(r.Stages) != 2 || r.Stages[0].Name != "embed" || r.Stages[1].Name != "write" {
+		t.Fatalf("stages = %+v", r.Stages)
+	}
+	if len(r.SlowestFiles) != profileSlowFiles || r.SlowestFiles[0].Path != "big.py" || !r.SlowestFiles[0].Failed {
+		t.Errorf("slowest files = %+v", r.SlowestFiles[:1])
+	}
+
+	if len(r.Languages) != 2 || r.Languages[0].Language != "python" {
+		t.Fatalf("languages = %+v", r.Languages)
+	}
+	goStats := r.Languages[1]
+	if goStats.Files != 25 || goStats.Errors != 0 || goStats.DurationMs != 325 {
+		t.Errorf("go stats = %+v", goStats)
+	}
+	if want := 25 / 0.325; goStats.FilesPerSec < want-0.01 || goStats.FilesPerSec > want+0.01 {
+		t.Errorf("files/sec = %v, want %v", goStats.FilesPerSec, want)
+	}
+
+	e := r.Embeddings
+	if e.Calls != 6 || e.Errors != 1 {
+		t.Errorf("calls=%d errors=%d", e.Calls, e.Errors)
+	}
+	if e.P50Ms != 20 || e.MaxMs != 20000 {
+		t.Errorf("p50=%v max=%v", e.P50Ms, e.MaxMs)
+	}
+	if got := e.Histogram[0]; got.LeMs != 10 || got.Count != 2 {
+		t.Errorf("first bucket = %+v", got)
+	}
+	if got := e.Histogram[len(e.Histogram)-1]; got.LeMs != 0 || got.Count != 1 {
+		t.Errorf("overflow bucket = %+v", got)
+	}
+}
+
+func TestProfiledEmbeddingProvider(t *testing.T) {
+	p := NewProfiler()
+	provider := &profiledEmbeddingProvider{EmbeddingProvider: NewMockEmbeddingProvider(8, nil), profiler: p}
+	if _, err := provider.Embed(context.Background(), "func main() {}"); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Report().Embeddings.Calls; got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// batchAndResplit runs the batcher and splits every batch back into
+// statements, in order.
+func batchAndResplit(t *testing.T, b *Batcher, script string) ([]string, []string) {
+	t.Helper()
+	batches, err := b.Batch(script)
+	if err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	var got []string
+	for _, batch := range batches {
+		got = append(got, b.splitStatements(batch)...)
+	}
+	return batches, got
+}
+
+// TestProperty_BatcherPreservesStatements checks that batching never splits
+// or alters a statement: re-splitting the batches yields exactly the
+// statements the builder emitted, in order.
+func TestProperty_BatcherPreservesStatements(t *testing.T) {
+	checkProperty(t, 200, func(t *testing.T, r *rand.Rand) {
+		want := genStatements(r, 1+r.Intn(60))
+		b := NewBatcher(1+r.Intn(20), 64*1024)
+
+		_, got := batchAndResplit(t, b, strings.Join(want, "\n"))
+		if len(got) != len(want) {
+			t.Fatalf("got %d statements, want %d", len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("statement %d changed:\n got: %q\nwant: %q", i, got[i], want[i])
+			}
+		}
+	})
+}
+
+// TestProperty_BatcherRespectsLimits checks every batch stays within the
+// mutation target and the size limit (plus the trailing newline).
+func TestProperty_BatcherRespectsLimits(t *testing.T) {
+	checkProperty(t, 200, func(t *testing.T, r *rand.Rand) {
+		stmts := genStatements(r, 1+r.Intn(80))
+		longest := 0
+		for _, s := range stmts {
+			longest = max(longest, len(s))
+		}
+		target := 1 + r.Intn(10)
+		maxSize := longest + r.Intn(4*longest)
+		b := NewBatcher(target, maxSize)
+
+		batches, _ := batchAndResplit(t, b, strings.Join(stmts, "\n"))
+		for i, batch := range batches {
+			if len(batch) > maxSize+1 {
+				t.Errorf("batch %d is %d bytes, limit %d", i, len(batch), maxSize)
+			}
+			if n := len(b.splitStatements(batch)); n > target {
+				t.Errorf("batch %d has %d statements, target %d", i, n, target)
+			}
+		}
+	})
+}
+
+// TestProperty_FunctionIDsUnique checks that distinct function locations
+// never share an ID, and that the ID ignores the signature and path spelling.
+func TestProperty_FunctionIDsUnique(t *testing.T) {
+	checkProperty(t, 50, func(t *testing.T, r *rand.Rand) {
+		type location struct {
+			path, name                   string
+			start, end, startCol, endCol int
+		}
+		seen := make(map[string]location)
+		for i := 0; i < 2000; i++ {
+			fn := genFunctionEntity(r)
+			loc := location{normalizePath(fn.FilePath), fn.Name, fn.StartLine, fn.EndLine, fn.StartCol, fn.EndCol}
+			if prev, ok := seen[fn.ID]; ok && prev != loc {
+				t.Fatalf("ID collision %s:\n  %+v\n  %+v", fn.ID, prev, loc)
+			}
+			seen[fn.ID] = loc
+
+			again := GenerateFunctionID("./"+fn.FilePath, fn.Name, "different signature", fn.StartLine, fn.EndLine, fn.StartCol, fn.EndCol)
+			if again != fn.ID {
+				t.Fatalf("ID of %+v depends on signature or ./ prefix", loc)
+			}
+		}
+	})
+}
+
+// TestProperty_FileIDsUnique checks that file IDs are stable across path
+// spellings and distinct for distinct files.
+func TestProperty_FileIDsUnique(t *testing.T) {
+	checkProperty(t, 50, func(t *testing.T, r *rand.Rand) {
+		seen := make(map[string]string)
+		for i := 0; i < 2000; i++ {
+			path := genPath(r)
+			id := GenerateFileID(path)
+			for _, variant := range []string{"./" + path, "/" + path, strings.ReplaceAll(path, "/", "//")} {
+				if got := GenerateFileID(variant); got != id {
+					t.Fatalf("GenerateFileID(%q) = %q, want %q", variant, got, id)
+				}
+			}
+			norm := normalizePath(path)
+			if prev, ok := seen[id]; ok && prev != norm {
+				t.Fatalf("ID collision %s: %q vs %q", id, prev, norm)
+			}
+			seen[id] = norm
+		}
+	})
+}
+
+// TestProperty_TypeAndFieldIDsUnique checks the short (64-bit) type, field
+// and import IDs stay collision-free at repository scale.
+func TestProperty_TypeAndFieldIDsUnique(t *testing.T) {
+	checkProperty(t, 10, func(t *testing.T, r *rand.Rand) {
+		seen := make(map[string]string)
+		check := func(id, key string) {
+			if prev, ok := seen[id]; ok && prev != key {
+				t.Fatalf("ID collision %s: %q vs %q", id, prev, key)
+			}
+			seen[id] = key
+		}
+		for i := 0; i < 5000; i++ {
+			path, name, field := genPath(r), genIdentifier(r), genIdentifier(r)
+			start := 1 + r.Intn(5000)
+			end := start + r.Intn(100)
+			check(GenerateTypeID(path, name, start, end), strings.Join([]string{"type", path, name, strconv.Itoa(start), strconv.Itoa(end)}, "|"))
+			check(GenerateFieldID(path, name, field), strings.Join([]string{"field", path, name, field}, "|"))
+			imp := genPath(r)
+			check(GenerateImportID(path, imp), "import|"+path+"|"+imp)
+		}
+	})
+}
+
+// FuzzBatcherPreservesCode feeds arbitrary code text through the builder and
+// batcher and checks it survives unchanged.
+func FuzzBatcherPreservesCode(f *testing.F) {
+	for _, seed := range genCodeFragments {
+		f.Add(seed, uint8(1))
+	}
+	f.Add("func a() {\n\n// note\n\treturn\n}", uint8(2))
+	f.Add("x := \"\\\"\" // }\n'\n", uint8(3))
+
+	f.Fuzz(func(t *testing.T, code string, target uint8) {
+		if strings.ContainsRune(code, 0) {
+			t.Skip("builder drops NUL bytes")
+		}
+		fn := FunctionEntity{ID: "func:x", Name: "x", FilePath: "x.go", CodeText: code, StartLine: 1, EndLine: 2}
+		script := NewDatalogBuilder().BuildMutations(nil, []FunctionEntity{fn}, nil, nil)
+		want := NewBatcher(1000, 1<<20).splitStatements(script)
+
+		_, got := batchAndResplit(t, NewBatcher(int(target%8)+1, 1<<20), script)
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Fatalf("batching changed statements:\n got: %q\nwant: %q", got, want)
+		}
+		if !strings.Contains(strings.Join(got, "\n"), quoteString(code)) {
+			t.Fatalf("code text not preserved: %q", code)
+		}
+	})
+}
+
+// FuzzGenerateFileID checks that IDs are invariant under path normalization.
+func FuzzGenerateFileID(f *testing.F) {
+	for _, seed := range []string{"a/b.go", "./a/b.go", "/a/b.go", "a//b.go", "a/./b.go", "a/../b.go", "テスト/ファイル.py", ""} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, path string) {
+		if norm := normalizePath(path); norm == "" || norm == "." {
+			t.Skip("not a file path")
+		}
+		id := GenerateFileID(path)
+		if got := GenerateFileID(normalizePath(path)); got != id {
+			t.Fatalf("GenerateFileID not idempotent under normalizePath for %q: %q vs %q", path, got, id)
+		}
+		if !strings.HasPrefix(id, "file:") {
+			t.Fatalf("ID %q missing file: prefix", id)
+		}
+	})
+}
@@ -0,0 +1,271 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// generatedProtoSuffixes are the file suffixes protoc plugins give generated
+// code, longest first so "_grpc.pb.go" wins over ".pb.go".
+var generatedProtoSuffixes = []string{"_grpc.pb.go", ".pb.go", "_pb.d.ts", "_pb.ts", "_pb.js", ".pb.ts"}
+
+// generatedProtoBase returns the .proto base name a generated file comes
+// from ("api/v1/users.pb.go" → "users"), or false if it is not generated code.
+func generatedProtoBase(filePath string) (string, bool) {
+	base := path.Base(filePath)
+	for _, suffix := range generatedProtoSuffixes {
+		if strings.HasSuffix(base, suffix) && len(base) > len(suffix) {
+			return strings.TrimSuffix(base, suffix), true
+		}
+	}
+	return "", false
+}
+
+// BuildGeneratedFromIndex links types in protoc-generated Go and TypeScript
+// files to the .proto messages and enums they were generated from. Nested
+// definitions are matched by their generated name ("User.Address" →
+// "User_Address"). When several .proto files define the name, the one with
+// the same base name as the generated file wins; otherwise the type is left
+// unlinked rather than guessed.
+func BuildGeneratedFromIndex(types []TypeEntity) []GeneratedFromEdge {
+	protoByName := make(map[string][]TypeEntity)
+	for _, t := range types {
+		if strings.HasSuffix(t.FilePath, ".proto") && (t.Kind == "message" || t.Kind == "enum") {
+			name := strings.ReplaceAll(t.Name, ".", "_")
+			protoByName[name] = append(protoByName[name], t)
+		}
+	}
+	if len(protoByName) == 0 {
+		return nil
+	}
+
+	var edges []GeneratedFromEdge
+	for _, t := range types {
+		base, ok := generatedProtoBase(t.FilePath)
+		if !ok {
+			continue
+		}
+		candidates := protoByName[t.Name]
+		var match *TypeEntity
+		switch {
+		case len(candidates) == 1:
+			match = &candidates[0]
+		case len(candidates) > 1:
+			for i, c := range candidates {
+				if strings.TrimSuffix(path.Base(c.FilePath), ".proto") == base {
+					if match != nil {
+						match = nil // still ambiguous
+						break
+					}
+					match = &candidates[i]
+				}
+			}
+		}
+		if match != nil {
+			edges = append(edges, GeneratedFromEdge{TypeID: t.ID, ProtoTypeID: match.ID, FilePath: t.FilePath})
+		}
+	}
+	return edges
+}
+
+// RPC link roles.
+const (
+	RPCRoleServer = "server" // implements the RPC
+	RPCRoleClient = "client" // calls the RPC through a generated client
+)
+
+// Generated gRPC server and client shapes.
+var (
+	// Go: a struct embedding pb.UnimplementedUserServiceServer.
+	goServerEmbed = regexp.MustCompile(`\b(?:Unimplemented|Unsafe)(\w+)Server\b`)
+	// Go: pb.RegisterUserServiceServer(s, &userServer{...}) or new(userServer).
+	goServerRegister = regexp.MustCompile(`\bRegister(\w+)Server\(\s*[^,()]+,\s*(?:&\s*(?:\w+\.)?(\w+)\s*\{|new\(\s*(?:\w+\.)?(\w+)\s*\))`)
+	// Go: parameters only gRPC handlers take.
+	goHandlerParams = regexp.MustCompile(`context\.Context|_\w+Server\b|grpc\.\w*Streaming\w*Server`)
+	// Python: class UserService(users_pb2_grpc.UserServiceServicer).
+	pyServicerClass = regexp.MustCompile(`^class\s+\w+\s*\([^)]*?\b(\w+)Servicer\s*[,)]`)
+
+	// Client bindings: the variable or field a generated client is stored in,
+	// and the service it talks to.
+	goClientBinding = regexp.MustCompile(`\b(\w+)\s*(?::=|=|:)\s*(?:\w+\.)?New(\w+)Client\(`)
+	goClientField   = regexp.MustCompile(`(?m)^\s*(\w+)\s+\*?(?:\w+\.)?(\w+)Client\s*(?://.*)?$`)
+	jsClientBinding = regexp.MustCompile(`\b(\w+)\s*(?::\s*[\w.<>]+\s*)?=\s*(?:new\s+(?:\w+\.)?(\w+?)Client(?:Impl)?\s*\(|(?:\w+\.)?create(?:Promise|Callback)?Client\(\s*(?:\w+\.)?(\w+)\s*,)`)
+	pyClientBinding = regexp.MustCompile(`\b(\w+)\s*=\s*(?:\w+\.)?(\w+)Stub\(`)
+)
+
+// extractRPCClientCalls finds calls made through generated gRPC clients in
+// a Go, Python, JavaScript or TypeScript file: a client is bound to a
+// variable or field (pb.NewUserServiceClient(conn), new
+// UserServiceClient(url), createPromiseClient(UserService, transport),
+// users_pb2_grpc.UserServiceStub(channel)) and its methods are called on
+// it. Each call is linked to the RPC "Service.Method"; JavaScript method
+// names are capitalized to match the .proto. The whole file is scanned for
+// bindings because clients are usually created at package or module level.
+func extractRPCClientCalls(content, filePath string, functions []FunctionEntity) []RPCLink {
+	var bindings [][]string
+	switch detectLanguageFromPath(filePath) {
+	case "go":
+		bindings = append(goClientBinding.FindAllStringSubmatch(content, -1), goClientField.FindAllStringSubmatch(content, -1)...)
+	case "python":
+		bindings = pyClientBinding.FindAllStringSubmatch(content, -1)
+	case "javascript", "typescript":
+		bindings = jsClientBinding.FindAllStringSubmatch(content, -1)
+	default:
+		return nil
+	}
+
+	services := make(map[string]string) // variable or field -> service
+	for _, b := range bindings {
+		service := b[2]
+		if len(b) > 3 && service == "" {
+			service = b[3]
+		}
+		if service != "" {
+			services[b[1]] = service
+		}
+	}
+	if len(services) == 0 {
+		return nil
+	}
+
+	calls := make(map[string]*regexp.Regexp, len(services))
+	for variable := range services {
+		calls[variable] = regexp.MustCompile(`(?:^|[^\w])` + regexp.QuoteMeta(variable) + `\s*\.\s*(\w+)\s*\(`)
+	}
+
+	var links []RPCLink
+	for _, fn := range functions {
+		var found []RPCLink
+		seen := make(map[string]bool)
+		for variable, service := range services {
+			for _, m := range calls[variable].FindAllStringSubmatchIndex(fn.CodeText, -1) {
+				method := fn.CodeText[m[2]:m[3]]
+				rpc := service + "." + strings.ToUpper(method[:1]) + method[1:]
+				if seen[rpc] {
+					continue
+				}
+				seen[rpc] = true
+				found = append(found, RPCLink{
+					RPC:        rpc,
+					FunctionID: fn.ID,
+					FilePath:   fn.FilePath,
+					Role:       RPCRoleClient,
+					Line:       fn.StartLine + strings.Count(fn.CodeText[:m[2]], "\n"),
+				})
+			}
+		}
+		sort.Slice(found, func(i, j int) bool {
+			if found[i].Line != found[j].Line {
+				return found[i].Line < found[j].Line
+			}
+			return found[i].RPC < found[j].RPC
+		})
+		links = append(links, found...)
+	}
+	return links
+}
+
+// BuildRPCLinkIndex links gRPC server implementations and client calls to
+// the .proto RPCs they serve or call, so a call can be followed from a
+// client in one language through the .proto to the server in another.
+//
+// Servers are Go types that embed Unimplemented<Service>Server or are
+// passed to Register<Service>Server, whose methods have a gRPC handler
+// signature, and Python classes deriving from <Service>Servicer. Client
+// calls come from extractRPCClientCalls. When the functions include .proto
+// RPCs, links are kept only for RPCs defined there, which drops methods
+// that are not RPCs and clients of non-gRPC services; an incremental run
+// without .proto files keeps them all.
+func BuildRPCLinkIndex(types []TypeEntity, functions []FunctionEntity, clientCalls []RPCLink) []RPCLink {
+	known := make(map[string]bool)
+	for _, fn := range functions {
+		if strings.HasSuffix(fn.FilePath, ".proto") && strings.HasPrefix(fn.Signature, "rpc ") {
+			known[fn.Name] = true
+		}
+	}
+	keep := func(rpc string) bool { return len(known) == 0 || known[rpc] }
+
+	// Implementation type -> service, per language.
+	goImpls := make(map[string]string)
+	pyImpls := make(map[string]string)
+	for _, t := range types {
+		switch detectLanguageFromPath(t.FilePath) {
+		case "go":
+			if m := goServerEmbed.FindStringSubmatch(t.CodeText); m != nil && t.Kind == "struct" {
+				goImpls[t.Name] = m[1]
+			}
+		case "python":
+			if m := pyServicerClass.FindStringSubmatch(t.CodeText); m != nil {
+				pyImpls[t.Name] = m[1]
+			}
+		}
+	}
+	for _, fn := range functions {
+		if detectLanguageFromPath(fn.FilePath) != "go" {
+			continue
+		}
+		for _, m := range goServerRegister.FindAllStringSubmatch(fn.CodeText, -1) {
+			impl := m[2]
+			if impl == "" {
+				impl = m[3]
+			}
+			if _, ok := goImpls[impl]; !ok {
+				goImpls[impl] = m[1]
+			}
+		}
+	}
+
+	var links []RPCLink
+	for _, fn := range functions {
+		typeName, method, ok := strings.Cut(fn.Name, ".")
+		if !ok || method == "" || strings.Contains(method, ".") || testFilePattern.MatchString(fn.FilePath) {
+			continue
+		}
+		var service string
+		switch detectLanguageFromPath(fn.FilePath) {
+		case "go":
+			service = goImpls[typeName]
+			if service == "" || !unicode.IsUpper(rune(method[0])) || !goHandlerParams.MatchString(fn.Signature) {
+				continue
+			}
+		case "python":
+			service = pyImpls[typeName]
+			if service == "" || strings.HasPrefix(method, "_") {
+				continue
+			}
+		default:
+			continue
+		}
+		if rpc := service + "." + method; keep(rpc) {
+			links = append(links, RPCLink{RPC: rpc, FunctionID: fn.ID, FilePath: fn.FilePath, Role: RPCRoleServer, Line: fn.StartLine})
+		}
+	}
+
+	for _, c := range clientCalls {
+		if keep(c.RPC) {
+			links = append(links, c)
+		}
+	}
+	return links
+}
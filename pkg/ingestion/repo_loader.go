@@ -44,9 +44,10 @@ var (
 
 // RepoLoader loads repository contents from git URL or local path.
 type RepoLoader struct {
-	logger     *slog.Logger
-	tempDirs   []string // Track temporary directories for cleanup
-	tempDirsMu sync.Mutex
+	logger         *slog.Logger
+	tempDirs       []string // Track temporary directories for cleanup
+	tempDirsMu     sync.Mutex
+	languageLimits map[string]LanguageLimits
 }
 
 // NewRepoLoader creates a new repository loader.
@@ -60,6 +61,12 @@ func NewRepoLoader(logger *slog.Logger) *RepoLoader {
 	}
 }
 
+// SetLanguageLimits sets per-language size limits and exclude globs, applied
+// on top of the arguments to LoadRepository.
+func (rl *RepoLoader) SetLanguageLimits(limits map[string]LanguageLimits) {
+	rl.languageLimits = limits
+}
+
 // Close cleans up temporary directories created by git clones.
 func (rl *RepoLoader) Close() error {
 	rl.tempDirsMu.Lock()
@@ -97,11 +104,16 @@ type LoadResult struct {
 	// Common reasons: "excluded" (matched exclude glob), "too_large" (exceeds size limit),
 	// "unsupported_language" (no parser available), "binary" (not text).
 	SkipReasons map[string]int
+
+	// GoModules lists the Go modules declared by go.mod and go.work files,
+	// used to map import paths to package directories.
+	GoModules []GoModule
 }
 
 // FileInfo represents a file in the repository.
 type FileInfo struct {
-	// Path is the relative path from the repository root (e.g., "pkg/handlers/auth.go").
+	// Path is the relative path from the repository root with forward slashes
+	// on every platform (e.g., "pkg/handlers/auth.go").
 	Path string
 
 	// FullPath is the absolute filesystem path.
@@ -174,12 +186,14 @@ func (rl *RepoLoader) LoadRepository(source RepoSource, excludeGlobs []string, m
 		TotalSize:   totalSize,
 		Languages:   languages,
 		SkipReasons: skipReasons,
+		GoModules:   DiscoverGoModules(rootPath, files),
 	}
 
 	rl.logger.Info("repo.load.complete",
 		"files", result.FileCount,
 		"total_size", totalSize,
 		"languages", languages,
+		"go_modules", len(result.GoModules),
 	)
 
 	return result, nil
@@ -359,16 +373,25 @@ func (rl *RepoLoader) walkRepository(rootPath string, excludeGlobs []string, max
 			return nil
 		}
 
-		// Check if file should be excluded
+		// Check if file should be excluded. Paths are indexed with forward
+		// slashes on every platform, matching git's output for deltas.
 		relPath, err := filepath.Rel(rootPath, path)
 		if err != nil {
 			return nil //nolint:nilerr // Continue walking on path error
 		}
+		relPath = filepath.ToSlash(relPath)
 		if rl.shouldExclude(relPath, excludeGlobs) {
 			skipReasons["excluded"]++
 			return nil
 		}
 
+		// Detect language from extension
+		language := detectLanguageFromPath(relPath)
+		if excludedForLanguage(relPath, language, rl.languageLimits) {
+			skipReasons["excluded"]++
+			return nil
+		}
+
 		// Get file info
 		info, err := d.Info()
 		if err != nil {
@@ -376,19 +399,17 @@ func (rl *RepoLoader) walkRepository(rootPath string, excludeGlobs []string, max
 		}
 
 		// Check size limit
-		if maxFileSize > 0 && info.Size() > maxFileSize {
+		limit := maxFileSizeFor(language, maxFileSize, rl.languageLimits)
+		if limit > 0 && info.Size() > limit {
 			skipReasons["too_large"]++
 			rl.logger.Warn("repo.walk.skip_large_file",
 				"path", relPath,
 				"size", info.Size(),
-				"limit", maxFileSize,
+				"limit", limit,
 			)
 			return nil
 		}
 
-		// Detect language from extension
-		language := detectLanguageFromPath(relPath)
-
 		files = append(files, FileInfo{
 			Path:     relPath,
 			FullPath: path,
@@ -404,6 +425,12 @@ func (rl *RepoLoader) walkRepository(rootPath string, excludeGlobs []string, max
 
 // shouldExclude checks if a path matches any exclude glob pattern.
 func (rl *RepoLoader) shouldExclude(path string, excludeGlobs []string) bool {
+	return ExcludedPath(path, excludeGlobs)
+}
+
+// ExcludedPath reports whether the repository-relative path matches one of
+// excludeGlobs, as the repository walk decides for files and directories.
+func ExcludedPath(path string, excludeGlobs []string) bool {
 	// Normalize path separators
 	normalized := filepath.ToSlash(path)
 
@@ -415,6 +442,27 @@ func (rl *RepoLoader) shouldExclude(path string, excludeGlobs []string) bool {
 	return false
 }
 
+// excludedForLanguage reports whether path matches an exclude glob set for
+// its language.
+func excludedForLanguage(path, language string, limits map[string]LanguageLimits) bool {
+	normalized := filepath.ToSlash(path)
+	for _, pattern := range limits[language].ExcludeGlobs {
+		if matchesGlob(normalized, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxFileSizeFor returns the file size limit for language: its override when
+// set, otherwise the global limit.
+func maxFileSizeFor(language string, global int64, limits map[string]LanguageLimits) int64 {
+	if l, ok := limits[language]; ok && l.MaxFileSizeBytes > 0 {
+		return l.MaxFileSizeBytes
+	}
+	return global
+}
+
 // matchesGlob performs full glob matching with support for:
 //   - * : matches any sequence of non-separator characters
 //   - ** : matches any sequence of characters including separators (any depth)
@@ -702,12 +750,17 @@ func matchCharClass(c byte, class string) bool {
 }
 
 // detectLanguageFromPath detects programming language from file extension.
+// CI workflow files are recognized by location before the extension check.
 func detectLanguageFromPath(path string) string {
+	if lang := ciLanguage(path); lang != "" {
+		return lang
+	}
 	ext := strings.ToLower(filepath.Ext(path))
 
 	langMap := map[string]string{
 		".go":    "go",
 		".py":    "python",
+		".pyi":   "python",
 		".js":    "javascript",
 		".ts":    "typescript",
 		".jsx":   "javascript",
@@ -732,6 +785,16 @@ func detectLanguageFromPath(path string) string {
 		".zsh":   "bash",
 		".fish":  "bash",
 		".proto": "protobuf",
+		// Templates (.html is checked for template syntax when parsed)
+		".tmpl":   "gotemplate",
+		".gotmpl": "gotemplate",
+		".gohtml": "gotemplate",
+		".jinja":  "jinja",
+		".jinja2": "jinja",
+		".j2":     "jinja",
+		".erb":    "erb",
+		".html":   "html",
+		".htm":    "html",
 	}
 
 	if lang, ok := langMap[ext]; ok {
@@ -739,3 +802,9 @@ func detectLanguageFromPath(path string) string {
 	}
 	return ""
 }
+
+// DetectLanguage returns the language CIE assigns to path from its extension,
+// or "" when the extension is not recognized.
+func DetectLanguage(path string) string {
+	return detectLanguageFromPath(path)
+}
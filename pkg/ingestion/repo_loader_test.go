@@ -0,0 +1,73 @@
+package ingestion
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRepoFiles creates files under dir with the given sizes in bytes.
+func writeRepoFiles(t *testing.T, dir string, sizes map[string]int) {
+	t.Helper()
+	for name, size := range sizes {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("x", size)), 0o600))
+	}
+}
+
+var languageLimitsFixture = map[string]LanguageLimits{
+	"go":         {MaxFileSizeBytes: 4096},
+	"javascript": {MaxFileSizeBytes: 100, ExcludeGlobs: []string{"**/*.min.js"}},
+}
+
+func TestRepoLoader_LanguageLimits(t *testing.T) {
+	dir := t.TempDir()
+	writeRepoFiles(t, dir, map[string]int{
+		"big.go":               2000, // over the global limit, under the Go limit
+		"app.js":               50,
+		"large.js":             500, // under the global limit, over the JavaScript limit
+		"static/vendor.min.js": 10,
+		"notes.py":             2000, // no override: global limit applies
+	})
+
+	rl := NewRepoLoader(nil)
+	rl.SetLanguageLimits(languageLimitsFixture)
+	result, err := rl.LoadRepository(RepoSource{Type: "local_path", Value: dir}, nil, 1000)
+	require.NoError(t, err)
+
+	var paths []string
+	for _, f := range result.Files {
+		paths = append(paths, filepath.ToSlash(f.Path))
+	}
+	assert.ElementsMatch(t, []string{"big.go", "app.js"}, paths)
+	assert.Equal(t, 2, result.SkipReasons["too_large"])
+	assert.Equal(t, 1, result.SkipReasons["excluded"])
+}
+
+func TestFilterDeltaWithLimits(t *testing.T) {
+	dir := t.TempDir()
+	writeRepoFiles(t, dir, map[string]int{
+		"big.go":          2000,
+		"large.js":        500,
+		"dist/app.min.js": 10,
+		"notes.py":        2000,
+	})
+	delta := &GitDelta{
+		Added:    []string{"big.go", "large.js", "dist/app.min.js", "notes.py"},
+		Modified: []string{},
+		Deleted:  []string{"old.min.js", "old.go"},
+		Renamed:  map[string]string{},
+	}
+
+	filtered := FilterDeltaWithLimits(delta, nil, 1000, languageLimitsFixture, dir)
+	assert.Equal(t, []string{"big.go"}, filtered.Added)
+	assert.Equal(t, []string{"old.go"}, filtered.Deleted, "excluded files were never indexed")
+
+	unlimited := FilterDelta(delta, nil, 1000, dir)
+	assert.Equal(t, []string{"dist/app.min.js", "large.js"}, unlimited.Added)
+}
@@ -24,8 +24,19 @@ import (
 	"encoding/hex"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
+
+	"github.com/kraklabs/cie/pkg/goimport"
+)
+
+// Reasons recorded on calls the resolver could not link (UnresolvedCall.Reason).
+const (
+	UnresolvedReasonExternal        = "external"         // Package outside the indexed code (stdlib, dependencies)
+	UnresolvedReasonUnexported      = "unexported"       // Lowercase name behind a package qualifier
+	UnresolvedReasonUnknownReceiver = "unknown_receiver" // Method on a value whose type could not be determined
+	UnresolvedReasonNotFound        = "not_found"        // No function of that name in the file, its dot imports or the target package
 )
 
 // CallResolver resolves cross-package function calls.
@@ -49,6 +60,10 @@ type CallResolver struct {
 	// Maps Go import paths to local directory paths
 	importPathToPackagePath map[string]string
 
+	// goModules: modules declared by go.mod/go.work, longest path first.
+	// When set, import paths are mapped through them instead of by suffix.
+	goModules []GoModule
+
 	// Interface dispatch resolution indexes
 	// fieldIndex: structName → fieldName → fieldType
 	fieldIndex map[string]map[string]string
@@ -61,8 +76,18 @@ type CallResolver struct {
 	// functionIDToSignature: function_id → full signature string
 	functionIDToSignature map[string]string
 
+	// csharpFunctions: "Namespace.Type.Method" → function_id
+	csharpFunctions map[string]string
+	// csharpTypes: namespace-qualified names of C# types that declare functions
+	csharpTypes map[string]bool
+	// csharpFiles: file_path → namespace and using directives of a C# file
+	csharpFiles map[string]*csharpScope
+
 	// stubFunctions: synthetic entries for external type methods (e.g., sql.DB.Query)
 	stubFunctions []FunctionEntity
+
+	// unresolved: calls ResolveCalls produced no edge for, with their reason
+	unresolved []UnresolvedCall
 }
 
 // NewCallResolver creates a new call resolver.
@@ -77,6 +102,9 @@ func NewCallResolver() *CallResolver {
 		qualifiedFunctions:      make(map[string]string),
 		functionIDToName:        make(map[string]string),
 		functionIDToSignature:   make(map[string]string),
+		csharpFunctions:         make(map[string]string),
+		csharpTypes:             make(map[string]bool),
+		csharpFiles:             make(map[string]*csharpScope),
 	}
 }
 
@@ -107,6 +135,8 @@ func (r *CallResolver) BuildIndex(
 	}
 
 	// 2. Build global function registry and qualified function index
+	r.indexPythonFunctions(functions)
+	r.indexCSharp(functions, imports, packageNames)
 	for _, fn := range functions {
 		if !strings.HasSuffix(fn.FilePath, ".go") {
 			continue
@@ -136,6 +166,9 @@ func (r *CallResolver) BuildIndex(
 
 	// 3. Build file imports index
 	for _, imp := range imports {
+		if isCSharpFile(imp.FilePath) {
+			continue // using directives are indexed by indexCSharp
+		}
 		if _, exists := r.fileImports[imp.FilePath]; !exists {
 			r.fileImports[imp.FilePath] = make(map[string]string)
 		}
@@ -143,8 +176,7 @@ func (r *CallResolver) BuildIndex(
 		// Determine the alias used for this import
 		alias := imp.Alias
 		if alias == "" || alias == "_" {
-			// Default alias is the last path component
-			alias = filepath.Base(imp.ImportPath)
+			alias = goimport.DefaultAlias(imp.ImportPath)
 		}
 
 		// Skip blank imports
@@ -160,6 +192,40 @@ func (r *CallResolver) BuildIndex(
 	r.buildImportPathMapping()
 }
 
+// indexPythonFunctions adds Python methods to the interface dispatch indexes,
+// so calls on parameters annotated with a Protocol or ABC reach the classes
+// implementing it. A method in a .py file wins over its .pyi stub as the call
+// target, while the stub's annotated signature is used when the
+// implementation has no type hints.
+func (r *CallResolver) indexPythonFunctions(functions []FunctionEntity) {
+	stubSignatures := make(map[string]string) // "module.py\x00Name" → stub signature
+	for _, fn := range functions {
+		if strings.HasSuffix(fn.FilePath, ".pyi") {
+			stubSignatures[strings.TrimSuffix(fn.FilePath, "i")+"\x00"+fn.Name] = fn.Signature
+		}
+	}
+
+	for _, fn := range functions {
+		isStub := strings.HasSuffix(fn.FilePath, ".pyi")
+		if !isStub && !strings.HasSuffix(fn.FilePath, ".py") {
+			continue
+		}
+		if strings.Contains(fn.Name, ".") {
+			if _, taken := r.qualifiedFunctions[fn.Name]; !taken || !isStub {
+				r.qualifiedFunctions[fn.Name] = fn.ID
+			}
+		}
+		r.functionIDToName[fn.ID] = fn.Name
+		sig := fn.Signature
+		if stubSig, ok := stubSignatures[fn.FilePath+"\x00"+fn.Name]; ok && len(ParseSignatureParams(sig)) == 0 {
+			sig = stubSig
+		}
+		if sig != "" {
+			r.functionIDToSignature[fn.ID] = sig
+		}
+	}
+}
+
 // buildImportPathMapping creates a mapping from Go import paths to local package paths.
 func (r *CallResolver) buildImportPathMapping() {
 	// For each package we have, try to infer the import path
@@ -177,6 +243,22 @@ func (r *CallResolver) buildImportPathMapping() {
 	}
 }
 
+// SetGoModules enables exact import resolution from the repository's
+// go.mod and go.work files (see DiscoverGoModules). Imports that fall
+// outside every module are then treated as external instead of being
+// matched against local packages by name. Must be called after BuildIndex
+// and before ResolveCalls.
+func (r *CallResolver) SetGoModules(modules []GoModule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.goModules = modules
+	if len(modules) > 0 {
+		// Drop the directory and package-name guesses from buildImportPathMapping:
+		// a stdlib "errors" import must not resolve to a local errors package.
+		r.importPathToPackagePath = make(map[string]string)
+	}
+}
+
 // ResolveCalls resolves unresolved calls to their target functions.
 // Returns the resolved call edges.
 // Uses parallel processing for large call sets (>1000 calls).
@@ -214,6 +296,9 @@ func (r *CallResolver) resolveCallsSequential(unresolvedCalls []UnresolvedCall)
 					resolved = append(resolved, edge)
 				}
 			}
+			if len(ifaceEdges) == 0 {
+				r.recordUnresolved(call)
+			}
 		}
 	}
 
@@ -233,8 +318,9 @@ func (r *CallResolver) resolveCallsParallel(unresolvedCalls []UnresolvedCall) []
 
 	// Channel for results
 	type resolveResult struct {
-		callerID string
-		calleeID string
+		callerID   string
+		calleeID   string
+		unresolved *UnresolvedCall // set instead of calleeID when nothing matched
 	}
 	results := make(chan resolveResult, len(unresolvedCalls))
 
@@ -261,6 +347,9 @@ func (r *CallResolver) resolveCallsParallel(unresolvedCalls []UnresolvedCall) []
 							calleeID: edge.CalleeID,
 						}
 					}
+					if len(ifaceEdges) == 0 {
+						results <- resolveResult{unresolved: &call}
+					}
 				}
 			}
 		}()
@@ -282,6 +371,10 @@ func (r *CallResolver) resolveCallsParallel(unresolvedCalls []UnresolvedCall) []
 	seen := make(map[string]bool)
 	var resolved []CallsEdge
 	for result := range results {
+		if result.unresolved != nil {
+			r.recordUnresolved(*result.unresolved)
+			continue
+		}
 		edgeKey := result.callerID + "->" + result.calleeID
 		if !seen[edgeKey] {
 			seen[edgeKey] = true
@@ -295,8 +388,73 @@ func (r *CallResolver) resolveCallsParallel(unresolvedCalls []UnresolvedCall) []
 	return resolved
 }
 
+// UnresolvedCalls returns the calls ResolveCalls could not link, each with its
+// Reason, ordered by file and line. Go builtins (len, append, ...) and
+// conversions to predeclared types are not reported: they have no edge to miss.
+func (r *CallResolver) UnresolvedCalls() []UnresolvedCall {
+	calls := append([]UnresolvedCall(nil), r.unresolved...)
+	sort.Slice(calls, func(i, j int) bool {
+		if calls[i].FilePath != calls[j].FilePath {
+			return calls[i].FilePath < calls[j].FilePath
+		}
+		if calls[i].Line != calls[j].Line {
+			return calls[i].Line < calls[j].Line
+		}
+		return calls[i].CalleeName < calls[j].CalleeName
+	})
+	return calls
+}
+
+// recordUnresolved classifies call and keeps it for UnresolvedCalls.
+// Not safe for concurrent use: only the goroutine collecting results calls it.
+func (r *CallResolver) recordUnresolved(call UnresolvedCall) {
+	if !strings.Contains(call.CalleeName, ".") &&
+		(goBuiltinFuncs[call.CalleeName] || isPrimitiveOrBuiltinType(call.CalleeName)) {
+		return // builtin call or conversion like int(x)
+	}
+	call.Reason = r.unresolvedReason(call)
+	r.unresolved = append(r.unresolved, call)
+}
+
+// unresolvedReason explains why call produced no edge.
+func (r *CallResolver) unresolvedReason(call UnresolvedCall) string {
+	if isCSharpFile(call.FilePath) {
+		return r.csharpUnresolvedReason(call)
+	}
+	if !strings.Contains(call.CalleeName, ".") {
+		return UnresolvedReasonNotFound
+	}
+	parts := strings.SplitN(call.CalleeName, ".", 2)
+	importPath, isImport := r.fileImports[call.FilePath][parts[0]]
+	if !isImport {
+		return UnresolvedReasonUnknownReceiver
+	}
+	if !isExportedName(extractLastComponent(call.CalleeName, parts[1])) {
+		return UnresolvedReasonUnexported
+	}
+	if r.findPackageByImportPath(importPath) == "" {
+		// A package of a known module that was not parsed in this run
+		// (incremental indexing) is still local code.
+		if _, local := modulePackageDir(r.goModules, importPath); !local {
+			return UnresolvedReasonExternal
+		}
+	}
+	return UnresolvedReasonNotFound
+}
+
+// goBuiltinFuncs lists Go's predeclared functions.
+var goBuiltinFuncs = map[string]bool{
+	"append": true, "cap": true, "clear": true, "close": true, "complex": true,
+	"copy": true, "delete": true, "imag": true, "len": true, "make": true,
+	"max": true, "min": true, "new": true, "panic": true, "print": true,
+	"println": true, "real": true, "recover": true,
+}
+
 // resolveCall attempts to resolve a single unresolved call.
 func (r *CallResolver) resolveCall(call UnresolvedCall) string {
+	if isCSharpFile(call.FilePath) {
+		return r.resolveCSharpCall(call)
+	}
 	if strings.Contains(call.CalleeName, ".") {
 		if id := r.resolveQualifiedCall(call); id != "" {
 			return id
@@ -387,6 +545,18 @@ func (r *CallResolver) findPackageByImportPath(importPath string) string {
 		return pkgPath
 	}
 
+	// Module-aware matching: the go.mod module path gives the exact directory
+	if len(r.goModules) > 0 {
+		pkgPath := ""
+		if dir, ok := modulePackageDir(r.goModules, importPath); ok {
+			if _, indexed := r.packageIndex[dir]; indexed {
+				pkgPath = dir
+			}
+		}
+		r.importPathToPackagePath[importPath] = pkgPath // Cache misses too
+		return pkgPath
+	}
+
 	// Try suffix matching: "github.com/org/project/internal/handlers" -> "internal/handlers"
 	for pkgPath := range r.packageIndex {
 		if strings.HasSuffix(importPath, pkgPath) {
@@ -412,6 +582,9 @@ func (r *CallResolver) findPackageByImportPath(importPath string) string {
 func (r *CallResolver) SetInterfaceIndex(fields []FieldEntity, implements []ImplementsEdge) {
 	// Build fieldIndex: structName → fieldName → fieldType
 	for _, f := range fields {
+		if strings.HasSuffix(f.FilePath, ".proto") || isCSharpFile(f.FilePath) {
+			continue // .proto message fields are not Go struct fields; C# receivers are typed by the parser
+		}
 		if r.fieldIndex[f.StructName] == nil {
 			r.fieldIndex[f.StructName] = make(map[string]string)
 		}
@@ -437,8 +610,10 @@ func (r *CallResolver) SetInterfaceIndex(fields []FieldEntity, implements []Impl
 // Handles chained access patterns common in Go:
 //   - "s.querier.StoreFact" → receiver="s", field="querier", method="StoreFact"
 //   - "querier.StoreFact"   → field="querier", method="StoreFact" (no receiver prefix)
+//
+// C# calls are skipped: the parser already replaced their receivers by types.
 func (r *CallResolver) resolveInterfaceCall(call UnresolvedCall) []CallsEdge {
-	if !strings.Contains(call.CalleeName, ".") {
+	if !strings.Contains(call.CalleeName, ".") || isCSharpFile(call.FilePath) {
 		return nil
 	}
 
@@ -487,7 +662,8 @@ func (r *CallResolver) resolveInterfaceCallViaFields(call UnresolvedCall, caller
 }
 
 // resolveInterfaceCallViaParams resolves through function parameter types.
-// For standalone functions like `func storeFact(client Querier, fact string)`,
+// For standalone functions like `func storeFact(client Querier, fact string)`
+// (or Python's `def store_fact(client: Querier, fact: str)`),
 // matches the callee prefix (e.g., "client" from "client.StoreFact") against
 // parameter names, then resolves through the implements index.
 func (r *CallResolver) resolveInterfaceCallViaParams(call UnresolvedCall) []CallsEdge {
@@ -496,7 +672,7 @@ func (r *CallResolver) resolveInterfaceCallViaParams(call UnresolvedCall) []Call
 		return nil
 	}
 
-	params := ParseGoSignatureParams(sig)
+	params := ParseSignatureParams(sig)
 	if len(params) == 0 {
 		return nil
 	}
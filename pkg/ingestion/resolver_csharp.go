@@ -0,0 +1,164 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package ingestion
+
+import "strings"
+
+// csharpScope holds what names in a C# file are looked up through.
+type csharpScope struct {
+	namespace string            // Namespace of the file (the first one when it declares several)
+	usings    []string          // Namespaces from "using X.Y;"
+	statics   []string          // Types from "using static X.Y.Type;"
+	aliases   map[string]string // "using A = X.Y;": A → X.Y
+}
+
+// isCSharpFile reports whether path is a C# source file.
+func isCSharpFile(path string) bool {
+	return strings.HasSuffix(path, ".cs")
+}
+
+// indexCSharp indexes C# functions by their namespace-qualified name
+// ("MyApp.Services.UserService.Get") and records each file's namespace
+// (from packageNames) and using directives (from imports). Overloads share
+// a name; the first one parsed is the call target.
+func (r *CallResolver) indexCSharp(functions []FunctionEntity, imports []ImportEntity, packageNames map[string]string) {
+	for path, ns := range packageNames {
+		if isCSharpFile(path) {
+			r.csharpScopeOf(path).namespace = ns
+		}
+	}
+	for _, imp := range imports {
+		if !isCSharpFile(imp.FilePath) {
+			continue
+		}
+		scope := r.csharpScopeOf(imp.FilePath)
+		switch imp.Alias {
+		case ".":
+			scope.usings = append(scope.usings, imp.ImportPath)
+		case "static":
+			scope.statics = append(scope.statics, imp.ImportPath)
+		default:
+			scope.aliases[imp.Alias] = imp.ImportPath
+		}
+	}
+
+	for _, fn := range functions {
+		if !isCSharpFile(fn.FilePath) {
+			continue
+		}
+		qualified := joinCSharpName(packageNames[fn.FilePath], fn.Name)
+		if _, taken := r.csharpFunctions[qualified]; !taken {
+			r.csharpFunctions[qualified] = fn.ID
+		}
+		if i := strings.LastIndex(qualified, "."); i > 0 {
+			r.csharpTypes[qualified[:i]] = true
+		}
+		r.functionIDToName[fn.ID] = fn.Name
+	}
+}
+
+// csharpScopeOf returns the scope of a C# file, creating it if needed.
+func (r *CallResolver) csharpScopeOf(path string) *csharpScope {
+	scope, ok := r.csharpFiles[path]
+	if !ok {
+		scope = &csharpScope{aliases: make(map[string]string)}
+		r.csharpFiles[path] = scope
+	}
+	return scope
+}
+
+// resolveCSharpCall resolves a call from a C# file. The parser names calls
+// by the type they are made on ("IRepo.Find", "MyApp.Util.Log.Write"), or
+// leaves them unqualified for methods of the caller's own type that are
+// declared in another file (partial classes) or come from a "using static".
+func (r *CallResolver) resolveCSharpCall(call UnresolvedCall) string {
+	scope := r.csharpFiles[call.FilePath]
+	if scope == nil {
+		scope = &csharpScope{}
+	}
+	if strings.Contains(call.CalleeName, ".") {
+		return r.csharpFunctions[r.lookupCSharpName(scope, call.CalleeName, r.hasCSharpFunction)]
+	}
+
+	callerName := r.functionIDToName[call.CallerID]
+	if i := strings.LastIndex(callerName, "."); i > 0 {
+		if qualified := r.lookupCSharpName(scope, callerName[:i]+"."+call.CalleeName, r.hasCSharpFunction); qualified != "" {
+			return r.csharpFunctions[qualified]
+		}
+	}
+	for _, static := range scope.statics {
+		if id, ok := r.csharpFunctions[static+"."+call.CalleeName]; ok {
+			return id
+		}
+	}
+	return ""
+}
+
+// lookupCSharpName finds the namespace-qualified form of name the way the
+// C# compiler does: through the file's namespace and each namespace
+// enclosing it, then through its using directives. A leading using alias is
+// expanded first. Returns "" when exists matches no candidate.
+func (r *CallResolver) lookupCSharpName(scope *csharpScope, name string, exists func(string) bool) string {
+	first, rest, _ := strings.Cut(name, ".")
+	if target, ok := scope.aliases[first]; ok {
+		name = joinCSharpName(target, rest)
+	}
+
+	for ns := scope.namespace; ; {
+		if qualified := joinCSharpName(ns, name); exists(qualified) {
+			return qualified
+		}
+		if ns == "" {
+			break
+		}
+		ns = ns[:max(strings.LastIndex(ns, "."), 0)]
+	}
+	for _, using := range scope.usings {
+		if qualified := using + "." + name; exists(qualified) {
+			return qualified
+		}
+	}
+	return ""
+}
+
+// hasCSharpFunction reports whether a C# function has the qualified name.
+func (r *CallResolver) hasCSharpFunction(qualified string) bool {
+	_, ok := r.csharpFunctions[qualified]
+	return ok
+}
+
+// csharpUnresolvedReason explains why a C# call produced no edge: a call on
+// a type of the indexed code names a method it does not declare (an
+// inherited one, say); a call on any other type leaves the indexed code.
+func (r *CallResolver) csharpUnresolvedReason(call UnresolvedCall) string {
+	i := strings.LastIndex(call.CalleeName, ".")
+	if i < 0 {
+		return UnresolvedReasonNotFound
+	}
+	scope := r.csharpFiles[call.FilePath]
+	if scope == nil {
+		scope = &csharpScope{}
+	}
+	isType := func(qualified string) bool { return r.csharpTypes[qualified] }
+	if r.lookupCSharpName(scope, call.CalleeName[:i], isType) != "" {
+		return UnresolvedReasonNotFound
+	}
+	return UnresolvedReasonExternal
+}
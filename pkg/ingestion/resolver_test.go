@@ -791,3 +791,173 @@ func TestCallResolver_NoDuplicates(t *testing.T) {
 		t.Errorf("expected 1 deduplicated call, got %d", len(resolvedCalls))
 	}
 }
+
+func TestCallResolver_GoModules(t *testing.T) {
+	// Two packages share the "util" suffix; only go.mod tells them apart.
+	files := []FileEntity{
+		{Path: "main.go", Language: "go"},
+		{Path: "internal/util/util.go", Language: "go"},
+		{Path: "tools/internal/util/util.go", Language: "go"},
+		{Path: "errors/errors.go", Language: "go"},
+		{Path: "yaml/yaml.go", Language: "go"},
+	}
+	functions := []FunctionEntity{
+		{ID: "fn:main", Name: "main", FilePath: "main.go"},
+		{ID: "fn:app.Clean", Name: "Clean", FilePath: "internal/util/util.go"},
+		{ID: "fn:tools.Clean", Name: "Clean", FilePath: "tools/internal/util/util.go"},
+		{ID: "fn:errors.New", Name: "New", FilePath: "errors/errors.go"},
+		{ID: "fn:yaml.Marshal", Name: "Marshal", FilePath: "yaml/yaml.go"},
+	}
+	imports := []ImportEntity{
+		{FilePath: "main.go", ImportPath: "github.com/org/app/v2/tools/internal/util"},
+		{FilePath: "main.go", ImportPath: "errors"},
+		{FilePath: "main.go", ImportPath: "gopkg.in/yaml.v3"},
+	}
+	packageNames := map[string]string{
+		"main.go":                     "main",
+		"internal/util/util.go":       "util",
+		"tools/internal/util/util.go": "util",
+		"errors/errors.go":            "errors",
+		"yaml/yaml.go":                "yaml",
+	}
+	calls := []UnresolvedCall{
+		{CallerID: "fn:main", CalleeName: "util.Clean", FilePath: "main.go"},
+		{CallerID: "fn:main", CalleeName: "errors.New", FilePath: "main.go"},
+		{CallerID: "fn:main", CalleeName: "yaml.Marshal", FilePath: "main.go"},
+	}
+
+	resolver := NewCallResolver()
+	resolver.BuildIndex(files, functions, imports, packageNames)
+	resolver.SetGoModules([]GoModule{
+		{Path: "github.com/org/app/v2/tools", Dir: "tools"},
+		{Path: "github.com/org/app/v2", Dir: "."},
+	})
+
+	edges := resolver.ResolveCalls(calls)
+	if len(edges) != 1 || edges[0].CalleeID != "fn:tools.Clean" {
+		t.Errorf("expected only util.Clean resolved to the tools module, got %+v", edges)
+	}
+}
+
+func TestCallResolver_UnresolvedCalls(t *testing.T) {
+	files := []FileEntity{
+		{Path: "main.go", Language: "go"},
+		{Path: "internal/store/store.go", Language: "go"},
+	}
+	functions := []FunctionEntity{
+		{ID: "fn:main", Name: "main", FilePath: "main.go"},
+		{ID: "fn:Open", Name: "Open", FilePath: "internal/store/store.go"},
+	}
+	imports := []ImportEntity{
+		{FilePath: "main.go", ImportPath: "fmt"},
+		{FilePath: "main.go", ImportPath: "example.com/app/internal/store"},
+		{FilePath: "main.go", ImportPath: "example.com/app/internal/cache"},
+	}
+	packageNames := map[string]string{"main.go": "main", "internal/store/store.go": "store"}
+	calls := []UnresolvedCall{
+		{CallerID: "fn:main", CalleeName: "store.Open", FilePath: "main.go", Line: 1},
+		{CallerID: "fn:main", CalleeName: "fmt.Println", FilePath: "main.go", Line: 2},
+		{CallerID: "fn:main", CalleeName: "store.open", FilePath: "main.go", Line: 3},
+		{CallerID: "fn:main", CalleeName: "db.Query", FilePath: "main.go", Line: 4},
+		{CallerID: "fn:main", CalleeName: "store.Close", FilePath: "main.go", Line: 5},
+		{CallerID: "fn:main", CalleeName: "cache.Get", FilePath: "main.go", Line: 6},
+		{CallerID: "fn:main", CalleeName: "helper", FilePath: "main.go", Line: 7},
+		{CallerID: "fn:main", CalleeName: "len", FilePath: "main.go", Line: 8},
+		{CallerID: "fn:main", CalleeName: "string", FilePath: "main.go", Line: 9},
+	}
+
+	resolver := NewCallResolver()
+	resolver.BuildIndex(files, functions, imports, packageNames)
+	resolver.SetGoModules([]GoModule{{Path: "example.com/app", Dir: "."}})
+	edges := resolver.ResolveCalls(calls)
+	if len(edges) != 1 {
+		t.Fatalf("expected 1 resolved edge, got %+v", edges)
+	}
+
+	want := map[string]string{
+		"fmt.Println": UnresolvedReasonExternal,
+		"store.open":  UnresolvedReasonUnexported,
+		"db.Query":    UnresolvedReasonUnknownReceiver,
+		"store.Close": UnresolvedReasonNotFound,
+		"cache.Get":   UnresolvedReasonNotFound, // local package not parsed in this run
+		"helper":      UnresolvedReasonNotFound,
+	}
+	got := resolver.UnresolvedCalls()
+	if len(got) != len(want) {
+		t.Fatalf("expected %d unresolved calls (builtins skipped), got %+v", len(want), got)
+	}
+	for i, c := range got {
+		if c.Reason != want[c.CalleeName] {
+			t.Errorf("%s: reason %q, want %q", c.CalleeName, c.Reason, want[c.CalleeName])
+		}
+		if i > 0 && got[i-1].Line > c.Line {
+			t.Errorf("unresolved calls should be ordered by line")
+		}
+	}
+}
+
+func TestCallResolver_PythonParamDispatch(t *testing.T) {
+	functions := []FunctionEntity{
+		{ID: "fn:store", Name: "store", FilePath: "app/service.py", Signature: "def store(repo, item)"},
+		{ID: "stub:store", Name: "store", FilePath: "app/service.pyi", Signature: "def store(repo: Repository, item: str) -> None"},
+		{ID: "fn:Sql.save", Name: "SqlRepository.save", FilePath: "app/sql.py", Signature: "def save(self, item: str) -> None"},
+		{ID: "stub:Sql.save", Name: "SqlRepository.save", FilePath: "app/sql.pyi", Signature: "def save(self, item: str) -> None"},
+	}
+	implements := []ImplementsEdge{
+		{TypeName: "SqlRepository", InterfaceName: "Repository", FilePath: "app/sql.py"},
+	}
+
+	resolver := NewCallResolver()
+	resolver.BuildIndex(nil, functions, nil, nil)
+	resolver.SetInterfaceIndex(nil, implements)
+
+	resolved := resolver.ResolveCalls([]UnresolvedCall{
+		{CallerID: "fn:store", CalleeName: "repo.save", FilePath: "app/service.py", Line: 3},
+	})
+
+	// The untyped implementation borrows its stub's annotations, and the
+	// call lands on the implementation rather than the stub.
+	if len(resolved) != 1 || resolved[0].CalleeID != "fn:Sql.save" {
+		t.Fatalf("expected store → fn:Sql.save, got %+v", resolved)
+	}
+	if len(resolver.UnresolvedCalls()) != 0 {
+		t.Errorf("expected no unresolved calls, got %+v", resolver.UnresolvedCalls())
+	}
+}
+
+func TestCallResolver_CSharpNamespaces(t *testing.T) {
+	functions := []FunctionEntity{
+		{ID: "fn:Handler.Run", Name: "Handler.Run", FilePath: "src/Api/Handler.cs"},
+		{ID: "fn:Clock.Now", Name: "Clock.Now", FilePath: "src/Clock.cs"},
+		{ID: "fn:Repository.Create", Name: "Repository.Create", FilePath: "src/Data/Repository.cs"},
+	}
+	imports := []ImportEntity{
+		{FilePath: "src/Api/Handler.cs", ImportPath: "Shop.Data.Repository", Alias: "Repo"},
+	}
+	packageNames := map[string]string{
+		"src/Api/Handler.cs":     "Shop.Api",
+		"src/Clock.cs":           "Shop",
+		"src/Data/Repository.cs": "Shop.Data",
+	}
+
+	resolver := NewCallResolver()
+	resolver.BuildIndex(nil, functions, imports, packageNames)
+	resolved := resolver.ResolveCalls([]UnresolvedCall{
+		{CallerID: "fn:Handler.Run", CalleeName: "Clock.Now", FilePath: "src/Api/Handler.cs", Line: 5},   // enclosing namespace
+		{CallerID: "fn:Handler.Run", CalleeName: "Repo.Create", FilePath: "src/Api/Handler.cs", Line: 6}, // using alias
+		{CallerID: "fn:Handler.Run", CalleeName: "Data.Repository.Create", FilePath: "src/Api/Handler.cs", Line: 7},
+		{CallerID: "fn:Handler.Run", CalleeName: "Repository.Create", FilePath: "src/Api/Handler.cs", Line: 8}, // no using
+	})
+
+	var callees []string
+	for _, edge := range resolved {
+		callees = append(callees, edge.CalleeID)
+	}
+	if len(callees) != 2 || callees[0] != "fn:Clock.Now" || callees[1] != "fn:Repository.Create" {
+		t.Fatalf("expected Clock.Now and Repository.Create, got %v", callees)
+	}
+	unresolved := resolver.UnresolvedCalls()
+	if len(unresolved) != 1 || unresolved[0].Line != 8 || unresolved[0].Reason != UnresolvedReasonExternal {
+		t.Errorf("expected the call without using to stay unresolved, got %+v", unresolved)
+	}
+}
@@ -25,7 +25,10 @@ package ingestion
 //   - cie_file: File entities
 //   - cie_function: Function metadata (lightweight, ~500 bytes/row)
 //   - cie_function_code: Function code text (lazy loaded)
+//   - cie_function_code_chunk: Function code past the code text size limit
 //   - cie_function_embedding: Function embeddings (for HNSW only)
+//   - cie_function_name_embedding: Function name and signature embeddings (for HNSW only)
+//   - cie_function_lang: Function language and dialect or framework hint
 //   - cie_type: Type metadata (lightweight)
 //   - cie_type_code: Type code text (lazy loaded)
 //   - cie_type_embedding: Type embeddings (for HNSW only)
@@ -33,6 +36,22 @@ package ingestion
 //   - cie_defines_type: Edge from file to type
 //   - cie_calls: Edge from caller function to callee function
 //   - cie_import: Import statements for cross-package call resolution
+//   - cie_contains: Edge from a function or type to a function nested in it
+//   - cie_method_of: Edge from a method to the type it belongs to
+//   - cie_unresolved_call: Calls the resolver could not link, with the reason
+//   - cie_proto_option: Options set in .proto files
+//   - cie_generated_from: Edge from a generated type to its .proto message or enum, or the interface it mocks
+//   - cie_generated_file: Generator and source file of generated files, from their header comments
+//   - cie_generated_func: Edge from a generated function to the RPC or type it was generated from
+//   - cie_template: Template files (Go templates, Jinja, ERB)
+//   - cie_template_ref: Variables, blocks and templates a template references
+//   - cie_renders: Template names passed to render calls by functions
+//   - cie_ci_job: CI jobs (GitHub Actions workflows, GitLab CI)
+//   - cie_ci_step: Steps and script lines of CI jobs
+//   - cie_ci_ref: Scripts, make targets, actions and variables CI jobs use
+//   - cie_table_ref: Database tables functions use through SQL strings and ORM calls
+//   - cie_rpc_link: gRPC server implementations and client calls of .proto RPCs
+//   - cie_entry_point: Functions where execution starts (main, commands, servers, Lambda handlers, scheduled jobs)
 //
 // All IDs are deterministic and stable across re-runs for idempotency.
 
@@ -40,6 +59,9 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"strconv"
+
+	"github.com/kraklabs/cie/pkg/storage"
 )
 
 // FileEntity represents a source file in the repository.
@@ -49,11 +71,16 @@ type FileEntity struct {
 	Hash     string // Content hash (SHA256) for change detection
 	Language string // Detected language (go, python, javascript, etc.)
 	Size     int64  // File size in bytes
+
+	// Content is the full file text, stored in cie_file_content. It is only
+	// populated when IngestionConfig.StoreFileText is set and the file is text.
+	Content string
 }
 
 // FunctionEntity represents a function/method extracted from code.
-// Note: In the database, CodeText and Embedding are stored in separate tables
-// (cie_function_code, cie_function_embedding) for query performance.
+// Note: In the database, CodeText, Embedding, and Language with Dialect are
+// stored in separate tables (cie_function_code, cie_function_embedding,
+// cie_function_lang) for query performance.
 // The struct keeps all fields for use in the ingestion pipeline.
 type FunctionEntity struct {
 	ID        string    // Deterministic: hash(file_path + name + range) - signature excluded for stability
@@ -66,6 +93,15 @@ type FunctionEntity struct {
 	EndLine   int       // End line (1-indexed)
 	StartCol  int       // Start column (1-indexed)
 	EndCol    int       // End column (1-indexed)
+	Language  string    // Parser language, e.g. "go", "typescript" (stored in cie_function_lang)
+	Dialect   string    // Framework hint, e.g. "gin handler", "react component"; empty when none
+	// CodeOverflow is the code past the CodeText size limit, stored in
+	// cie_function_code_chunk; empty when CodeText is complete. Embeddings
+	// only see CodeText.
+	CodeOverflow string
+	// NameEmbedding embeds the name and signature alone (stored in
+	// cie_function_name_embedding); empty unless name embeddings are on.
+	NameEmbedding []float32
 }
 
 // DefinesEdge represents a "file defines function" relationship.
@@ -125,6 +161,7 @@ type UnresolvedCall struct {
 	CalleeName string // Name of the called function (e.g., "foo" or "pkg.Foo")
 	FilePath   string // File where the call occurs (for import resolution)
 	Line       int    // Line number of the call
+	Reason     string // Why the call stayed unresolved (see UnresolvedReason*); set by CallResolver
 }
 
 // PackageInfo represents a Go package with its files.
@@ -154,6 +191,175 @@ type ImplementsEdge struct {
 	FilePath      string // File containing the concrete type
 }
 
+// ContainsEdge links a function to the definition it is nested in: a closure
+// or nested function to its enclosing function, a method to its class or
+// receiver type.
+type ContainsEdge struct {
+	ParentID   string // FunctionEntity.ID or TypeEntity.ID
+	ParentKind string // "function" or "type"
+	ChildID    string // FunctionEntity.ID of the nested function
+	FilePath   string // File containing the child
+}
+
+// MethodOfEdge records the type a method belongs to. TypeID is empty when
+// the type was not parsed in the same run (a Go method whose type lives in
+// an unchanged file during incremental indexing); TypeName plus the
+// package directory still identify it.
+type MethodOfEdge struct {
+	MethodID string // FunctionEntity.ID of the method
+	TypeID   string // TypeEntity.ID, or "" if unknown
+	TypeName string // e.g., "Server"
+	FilePath string // File containing the method
+}
+
+// ProtoOption is an option set in a .proto file. Scope is "file" for
+// file-level options (go_package, java_package), otherwise the name of the
+// message, enum, service, RPC, field or enum value it applies to
+// (e.g., "User", "User.email", "UserService.GetUser").
+type ProtoOption struct {
+	FilePath string
+	Scope    string
+	Name     string // e.g., "go_package", "deprecated", "(google.api.http)"
+	Value    string // Constant as written (e.g., "true", `"github.com/org/api/v1"`)
+	Line     int
+}
+
+// GeneratedFromEdge links a type generated by protoc (in a .pb.go or _pb.ts
+// file) to the .proto message or enum it was generated from, or a mock
+// generated by mockgen or mockery to the interface it mocks.
+type GeneratedFromEdge struct {
+	TypeID      string // TypeEntity.ID of the generated type
+	ProtoTypeID string // TypeEntity.ID of the message, enum or mocked interface
+	FilePath    string // Generated file
+}
+
+// GeneratedFile records which tool generated a file and from what, as
+// stated in its header comment ("Code generated by protoc-gen-go. DO NOT
+// EDIT.", "source: api/user.proto"). Source is the indexed path of the
+// source file when one matches, else as written; it is empty when the
+// header does not name one.
+type GeneratedFile struct {
+	FilePath  string
+	Generator string // e.g., "protoc-gen-go", "MockGen", "stringer"; empty when unnamed
+	Source    string
+}
+
+// GeneratedFuncLink ties a function in a generated file to the definition it
+// was generated from: a .proto RPC (SourceKind "function") for gRPC client
+// and server stubs, or a type (SourceKind "type") for methods of generated
+// messages and mocks.
+type GeneratedFuncLink struct {
+	FunctionID string
+	SourceID   string
+	SourceKind string
+	FilePath   string
+}
+
+// TemplateEntity is a template file. Dialect is one of the TemplateDialect*
+// constants.
+type TemplateEntity struct {
+	ID       string
+	FilePath string
+	Dialect  string
+}
+
+// TemplateRef is something a template references: a variable it reads, a
+// block, a template it defines, or one it includes or extends (see the
+// TemplateRef* kinds).
+type TemplateRef struct {
+	ID         string
+	TemplateID string
+	FilePath   string
+	Kind       string
+	Name       string // e.g., "User.Name", "content", "partials/nav.html"
+	Line       int
+}
+
+// RenderCall is a template a function renders, named as written in the call
+// (e.g., "users/list.html" in render_template("users/list.html", ...)).
+type RenderCall struct {
+	FunctionID   string
+	FilePath     string // File containing the function
+	TemplateName string
+	Line         int
+}
+
+// CIJob is a job in a CI workflow file. For GitHub Actions, Workflow is the
+// workflow name and RunsOn the runner labels; for GitLab CI, Workflow is the
+// file name and RunsOn the job image. Needs and Triggers are comma-separated.
+type CIJob struct {
+	ID        string
+	FilePath  string
+	Workflow  string
+	Name      string // Job key ("integration-tests")
+	Title     string // Display name, if set
+	Stage     string // GitLab stage
+	RunsOn    string
+	Needs     string
+	Triggers  string // GitHub "on" events ("push,pull_request")
+	StartLine int
+}
+
+// CIStep is one step of a CI job: a GitHub "run"/"uses" step or a GitLab
+// script line.
+type CIStep struct {
+	ID       string
+	JobID    string
+	FilePath string
+	Index    int
+	Name     string // Step name, or the GitLab script section
+	Kind     string // "run", "uses" or "script"
+	Command  string
+	Line     int
+}
+
+// CIRef is something a CI job references. Kind is one of the CIRef*
+// constants.
+type CIRef struct {
+	ID       string
+	JobID    string
+	FilePath string
+	Kind     string
+	Name     string // e.g., "scripts/test.sh", "integration-test", "DATABASE_URL"
+	Line     int
+}
+
+// TableRef is a database table a function uses. Table is the table name as
+// written in SQL, or the default table name of an ORM model; Model is the
+// model for ORM references. Op is one of the TableOp* constants and Source
+// one of the TableSource* constants.
+type TableRef struct {
+	FunctionID string
+	FilePath   string
+	Table      string
+	Model      string
+	Op         string
+	Source     string
+	Line       int
+}
+
+// RPCLink ties a function to a .proto RPC it implements (Role "server") or
+// calls through a generated client (Role "client"). RPC is the RPC's name
+// as indexed from the .proto, "Service.Method"; Line is the function's
+// first line for servers and the call's line for clients.
+type RPCLink struct {
+	RPC        string
+	FunctionID string
+	FilePath   string
+	Role       string
+	Line       int
+}
+
+// EntryPoint is a function where execution starts. Kind is one of the
+// EntryPoint* constants; Detail says how it was recognized (e.g.,
+// "cobra.Command", "ListenAndServe", "cron @hourly").
+type EntryPoint struct {
+	FunctionID string
+	FilePath   string
+	Kind       string
+	Detail     string
+}
+
 // GenerateFieldID generates a deterministic ID for a field entity.
 func GenerateFieldID(filePath, structName, fieldName string) string {
 	h := sha256.New()
@@ -174,6 +380,172 @@ func GenerateImplementsID(typeName, interfaceName string) string {
 	return "impl:" + hex.EncodeToString(h.Sum(nil))[:16]
 }
 
+// GenerateContainsID generates a deterministic ID for a contains edge.
+func GenerateContainsID(parentID, childID string) string {
+	h := sha256.New()
+	h.Write([]byte(parentID))
+	h.Write([]byte("|"))
+	h.Write([]byte(childID))
+	return "cnt:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// GenerateMethodOfID generates a deterministic ID for a method_of edge.
+// A method belongs to one type, so the method ID alone is the key.
+func GenerateMethodOfID(methodID string) string {
+	h := sha256.New()
+	h.Write([]byte(methodID))
+	return "mof:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// GenerateUnresolvedCallID generates a deterministic ID for an unresolved
+// call. The line keeps repeated calls to the same name from one caller apart.
+func GenerateUnresolvedCallID(callerID, calleeName string, line int) string {
+	h := sha256.New()
+	h.Write([]byte(callerID))
+	h.Write([]byte("|"))
+	h.Write([]byte(calleeName))
+	h.Write([]byte("|"))
+	h.Write([]byte(strconv.Itoa(line)))
+	return "unr:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// GenerateProtoOptionID generates a deterministic ID for a .proto option.
+func GenerateProtoOptionID(filePath, scope, name string) string {
+	h := sha256.New()
+	h.Write([]byte(filePath))
+	h.Write([]byte("|"))
+	h.Write([]byte(scope))
+	h.Write([]byte("|"))
+	h.Write([]byte(name))
+	return "popt:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// GenerateGeneratedFromID generates a deterministic ID for a generated_from
+// edge. A generated type comes from one proto definition, so its ID is the key.
+func GenerateGeneratedFromID(typeID string) string {
+	h := sha256.New()
+	h.Write([]byte(typeID))
+	return "gen:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// GenerateGeneratedFileID generates a deterministic ID for a generated file
+// record.
+func GenerateGeneratedFileID(filePath string) string {
+	h := sha256.New()
+	h.Write([]byte(filePath))
+	return "genf:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// GenerateGeneratedFuncID generates a deterministic ID for a generated
+// function link. A function comes from one definition, so its ID is the key.
+func GenerateGeneratedFuncID(functionID string) string {
+	h := sha256.New()
+	h.Write([]byte(functionID))
+	return "genfn:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// GenerateTemplateID generates a deterministic ID for a template file.
+func GenerateTemplateID(filePath string) string {
+	h := sha256.New()
+	h.Write([]byte(filePath))
+	return "tpl:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// GenerateTemplateRefID generates a deterministic ID for a template reference.
+func GenerateTemplateRefID(filePath, kind, name string) string {
+	h := sha256.New()
+	h.Write([]byte(filePath))
+	h.Write([]byte("|"))
+	h.Write([]byte(kind))
+	h.Write([]byte("|"))
+	h.Write([]byte(name))
+	return "tref:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// GenerateRenderID generates a deterministic ID for a render call.
+func GenerateRenderID(functionID, templateName string) string {
+	h := sha256.New()
+	h.Write([]byte(functionID))
+	h.Write([]byte("|"))
+	h.Write([]byte(templateName))
+	return "rnd:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// GenerateCIJobID generates a deterministic ID for a CI job.
+func GenerateCIJobID(filePath, jobName string) string {
+	h := sha256.New()
+	h.Write([]byte(filePath))
+	h.Write([]byte("|"))
+	h.Write([]byte(jobName))
+	return "ci:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// GenerateCIStepID generates a deterministic ID for a CI step.
+func GenerateCIStepID(jobID string, index int) string {
+	h := sha256.New()
+	h.Write([]byte(jobID))
+	h.Write([]byte("|"))
+	h.Write([]byte(strconv.Itoa(index)))
+	return "cis:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// GenerateCIRefID generates a deterministic ID for a CI reference.
+func GenerateCIRefID(jobID, kind, name string) string {
+	h := sha256.New()
+	h.Write([]byte(jobID))
+	h.Write([]byte("|"))
+	h.Write([]byte(kind))
+	h.Write([]byte("|"))
+	h.Write([]byte(name))
+	return "cir:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// GenerateTableRefID generates a deterministic ID for a table reference.
+func GenerateTableRefID(functionID, table, op, source string) string {
+	h := sha256.New()
+	h.Write([]byte(functionID))
+	h.Write([]byte("|"))
+	h.Write([]byte(table))
+	h.Write([]byte("|"))
+	h.Write([]byte(op))
+	h.Write([]byte("|"))
+	h.Write([]byte(source))
+	return "tbl:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// GenerateRPCLinkID generates a deterministic ID for an RPC link.
+func GenerateRPCLinkID(functionID, rpc, role string) string {
+	h := sha256.New()
+	h.Write([]byte(functionID))
+	h.Write([]byte("|"))
+	h.Write([]byte(rpc))
+	h.Write([]byte("|"))
+	h.Write([]byte(role))
+	return "rpc:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// GenerateEntryPointID generates a deterministic ID for an entry point.
+func GenerateEntryPointID(functionID, kind string) string {
+	h := sha256.New()
+	h.Write([]byte(functionID))
+	h.Write([]byte("|"))
+	h.Write([]byte(kind))
+	return "ep:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// SchemaVersion is the major version of the relation layout. SchemaHash
+// tracks finer changes such as added relations.
+const SchemaVersion = 3
+
+// SchemaHash returns a short fingerprint of the relations the storage layer
+// creates (storage.Relations). Indexes written with different hashes do not
+// share a relation layout. Vector dimensions are left out: they belong to
+// the embedding configuration.
+func SchemaHash() string {
+	sum := sha256.Sum256([]byte(storage.SchemaScript(0)))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
 // DatalogSchema returns the Datalog schema definition for all ingestion tables.
 // Schema v3: Vertically partitioned for performance on large datasets.
 func DatalogSchema() string {
@@ -188,6 +560,12 @@ func DatalogSchema() string {
 	size: Int
 }
 
+// File text: full content for file-scope search (optional, see StoreFileText)
+:create cie_file_content {
+	file_id: String =>
+	content: String
+}
+
 // Function entities: lightweight metadata (~500 bytes/row)
 // code_text and embedding are stored in separate tables for performance
 :create cie_function {
@@ -207,6 +585,14 @@ func DatalogSchema() string {
 	code_text: String
 }
 
+// Function code chunks: the code past cie_function_code's size limit, in order
+:create cie_function_code_chunk {
+	function_id: String,
+	chunk: Int =>
+	start_line: Int,
+	code_text: String
+}
+
 // Function embeddings: used only for HNSW semantic search
 // Note: embedding uses CozoDB vector type <F32; 1536> for HNSW index support
 // 1536 dimensions for Qodo-Embed-1-1.5B (768 for nomic-embed-text)
@@ -215,6 +601,20 @@ func DatalogSchema() string {
 	embedding: <F32; 1536>
 }
 
+// Function name and signature embeddings: matched by queries that describe
+// what a function is called rather than what its body does
+:create cie_function_name_embedding {
+	function_id: String =>
+	embedding: <F32; 1536>
+}
+
+// Function language and dialect: filters searches without inferring from paths
+:create cie_function_lang {
+	function_id: String =>
+	language: String,
+	dialect: String
+}
+
 // Defines edges: file -> function (file defines function)
 :create cie_defines {
 	file_id: String,
@@ -285,6 +685,162 @@ func DatalogSchema() string {
 	interface_name: String,
 	file_path: String
 }
+
+// Contains edges: enclosing function or type -> nested function
+:create cie_contains {
+	id: String =>
+	parent_id: String,
+	parent_kind: String,
+	child_id: String,
+	file_path: String
+}
+
+// Method-of edges: method -> the type it belongs to
+:create cie_method_of {
+	id: String =>
+	method_id: String,
+	type_id: String,
+	type_name: String,
+	file_path: String
+}
+
+// Unresolved calls: calls no edge could be created for, with the reason
+:create cie_unresolved_call {
+	id: String =>
+	caller_id: String,
+	callee_name: String,
+	file_path: String,
+	line: Int,
+	reason: String
+}
+
+// Options set in .proto files (file, message, enum, service, RPC or field scope)
+:create cie_proto_option {
+	id: String =>
+	file_path: String,
+	scope: String,
+	name: String,
+	value: String,
+	line: Int
+}
+
+// Generated-from edges: generated type -> .proto message or enum, or mocked interface
+:create cie_generated_from {
+	id: String =>
+	type_id: String,
+	proto_type_id: String,
+	file_path: String
+}
+
+// Generated files: generator and source named in the header comment
+:create cie_generated_file {
+	id: String =>
+	file_path: String,
+	generator: String,
+	source: String
+}
+
+// Generated functions: function -> .proto RPC or type it was generated from
+:create cie_generated_func {
+	id: String =>
+	function_id: String,
+	source_id: String,
+	source_kind: String,
+	file_path: String
+}
+
+// Template files
+:create cie_template {
+	id: String =>
+	file_path: String,
+	dialect: String
+}
+
+// Template references: variables, blocks, defines, includes, extends
+:create cie_template_ref {
+	id: String =>
+	template_id: String,
+	file_path: String,
+	kind: String,
+	name: String,
+	line: Int
+}
+
+// Render calls: function -> template name as written
+:create cie_renders {
+	id: String =>
+	function_id: String,
+	file_path: String,
+	template_name: String,
+	line: Int
+}
+
+// CI jobs (GitHub Actions, GitLab CI)
+:create cie_ci_job {
+	id: String =>
+	file_path: String,
+	workflow: String,
+	name: String,
+	title: String,
+	stage: String,
+	runs_on: String,
+	needs: String,
+	triggers: String,
+	start_line: Int
+}
+
+// CI steps: run/uses steps and script lines
+:create cie_ci_step {
+	id: String =>
+	job_id: String,
+	file_path: String,
+	idx: Int,
+	name: String,
+	kind: String,
+	command: String,
+	line: Int
+}
+
+// CI references: scripts, make targets, actions, env vars, secrets
+:create cie_ci_ref {
+	id: String =>
+	job_id: String,
+	file_path: String,
+	kind: String,
+	name: String,
+	line: Int
+}
+
+// Table references: function -> database table, from SQL strings and ORM calls
+:create cie_table_ref {
+	id: String =>
+	function_id: String,
+	file_path: String,
+	table_name: String,
+	model: String,
+	op: String,
+	source: String,
+	line: Int
+}
+
+// RPC links: function -> .proto RPC it implements or calls
+:create cie_rpc_link {
+	id: String =>
+	rpc: String,
+	function_id: String,
+	role: String,
+	file_path: String,
+	line: Int
+}
+
+// Entry points: functions where execution starts
+:create cie_entry_point {
+	id: String =>
+	function_id: String,
+	file_path: String,
+	kind: String,
+	detail: String
+}
 `
 }
 
@@ -32,3 +32,59 @@ func TestDatalogSchema_ContainsImplementsTable(t *testing.T) {
 		}
 	}
 }
+
+func TestDatalogSchema_ContainsContainsTable(t *testing.T) {
+	schema := DatalogSchema()
+
+	if !strings.Contains(schema, "cie_contains") {
+		t.Error("DatalogSchema() should contain cie_contains table")
+	}
+	for _, col := range []string{"parent_id", "parent_kind", "child_id"} {
+		if !strings.Contains(schema, col) {
+			t.Errorf("cie_contains table should contain column %q", col)
+		}
+	}
+}
+
+func TestDatalogSchema_ContainsMethodOfTable(t *testing.T) {
+	schema := DatalogSchema()
+
+	if !strings.Contains(schema, "cie_method_of") {
+		t.Error("DatalogSchema() should contain cie_method_of table")
+	}
+	for _, col := range []string{"method_id", "type_id", "type_name"} {
+		if !strings.Contains(schema, col) {
+			t.Errorf("cie_method_of table should contain column %q", col)
+		}
+	}
+}
+
+func TestDatalogSchema_ContainsUnresolvedCallTable(t *testing.T) {
+	schema := DatalogSchema()
+
+	if !strings.Contains(schema, ":create cie_unresolved_call") {
+		t.Error("DatalogSchema() should contain cie_unresolved_call table")
+	}
+	for _, col := range []string{"callee_name", "reason"} {
+		if !strings.Contains(schema, col) {
+			t.Errorf("cie_unresolved_call table should contain column %q", col)
+		}
+	}
+}
+
+func TestDatalogSchema_ContainsProtoTables(t *testing.T) {
+	schema := DatalogSchema()
+
+	for _, want := range []string{":create cie_proto_option", ":create cie_generated_from", "proto_type_id"} {
+		if !strings.Contains(schema, want) {
+			t.Errorf("DatalogSchema() should contain %q", want)
+		}
+	}
+}
+
+func TestSchemaHash(t *testing.T) {
+	h := SchemaHash()
+	if len(h) != 16 || h != SchemaHash() {
+		t.Errorf("SchemaHash() = %q, want a stable 16-character hash", h)
+	}
+}
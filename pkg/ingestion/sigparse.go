@@ -19,7 +19,11 @@
 
 package ingestion
 
-import "github.com/kraklabs/cie/pkg/sigparse"
+import (
+	"strings"
+
+	"github.com/kraklabs/cie/pkg/sigparse"
+)
 
 // ParamInfo holds a parsed parameter's name and base type.
 // Re-exported from pkg/sigparse for convenience.
@@ -30,3 +34,12 @@ type ParamInfo = sigparse.ParamInfo
 func ParseGoSignatureParams(signature string) []ParamInfo {
 	return sigparse.ParseGoParams(signature)
 }
+
+// ParseSignatureParams parses a Go or Python signature, picking the parser
+// from the signature's form ("func ..." vs "def ..."/"async def ...").
+func ParseSignatureParams(signature string) []ParamInfo {
+	if strings.HasPrefix(signature, "def ") || strings.HasPrefix(signature, "async def ") {
+		return sigparse.ParsePythonParams(signature)
+	}
+	return sigparse.ParseGoParams(signature)
+}
@@ -226,3 +226,41 @@ func TestExtractParamString(t *testing.T) {
 		}
 	}
 }
+
+func TestParseSignatureParams_Python(t *testing.T) {
+	sig := `async def store(self, repo: "models.Repository", *, limit: int = 10, cache: Cache | None = None, hook=None, **opts: Any) -> bool`
+	params := ParseSignatureParams(sig)
+
+	want := []ParamInfo{
+		{Name: "repo", Type: "Repository"},
+		{Name: "limit", Type: "int"},
+		{Name: "cache", Type: "Cache"},
+		{Name: "opts", Type: "Any"},
+	}
+	if len(params) != len(want) {
+		t.Fatalf("expected %d params, got %d: %+v", len(want), len(params), params)
+	}
+	for i := range want {
+		if params[i] != want[i] {
+			t.Errorf("param %d: got %+v, want %+v", i, params[i], want[i])
+		}
+	}
+}
+
+func TestNormalizePythonType(t *testing.T) {
+	cases := map[string]string{
+		"Repo":                          "Repo",
+		"'Repo'":                        "Repo",
+		"models.Repo":                   "Repo",
+		"Optional[Repo]":                "Repo",
+		"typing.Optional[models.Repo]":  "Repo",
+		"None | Repo":                   "Repo",
+		"Annotated[Repo, Depends(get)]": "Repo",
+		"dict[str, Repo]":               "dict",
+	}
+	for in, want := range cases {
+		if got := sigparse.NormalizePythonType(in); got != want {
+			t.Errorf("NormalizePythonType(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package ingestion
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	cietest "github.com/kraklabs/cie/internal/testing"
+)
+
+// syntheticSizes are the repository shapes used by the scaling benchmarks.
+// Compare ns/op across sizes to see how a stage grows with repository size:
+//
+//	go test ./pkg/ingestion -run '^$' -bench Synthetic -benchmem
+var syntheticSizes = []struct {
+	name string
+	spec cietest.RepoSpec
+}{
+	{"small", cietest.RepoSpec{Packages: 20, FunctionsPerPackage: 20}},
+	{"medium", cietest.RepoSpec{Packages: 100, FunctionsPerPackage: 50, CallDensity: 3}},
+	{"large", cietest.RepoSpec{Packages: 400, FunctionsPerPackage: 50, CallDensity: 3}},
+}
+
+// parsedSynthetic holds the parse output of a synthetic repository.
+type parsedSynthetic struct {
+	files        []FileEntity
+	functions    []FunctionEntity
+	defines      []DefinesEdge
+	calls        []CallsEdge
+	imports      []ImportEntity
+	unresolved   []UnresolvedCall
+	packageNames map[string]string
+}
+
+func syntheticFileInfos(repo *cietest.SyntheticRepo) []FileInfo {
+	infos := make([]FileInfo, 0, len(repo.Files))
+	for _, rel := range repo.Files {
+		infos = append(infos, FileInfo{
+			Path:     rel,
+			FullPath: filepath.Join(repo.Root, rel),
+			Language: detectLanguageFromPath(rel),
+		})
+	}
+	return infos
+}
+
+func parseSynthetic(b *testing.B, parser *TreeSitterParser, infos []FileInfo) *parsedSynthetic {
+	b.Helper()
+	out := &parsedSynthetic{packageNames: make(map[string]string)}
+	for _, info := range infos {
+		res, err := parser.ParseFile(info)
+		if err != nil {
+			b.Fatalf("parse %s: %v", info.Path, err)
+		}
+		out.files = append(out.files, res.File)
+		out.functions = append(out.functions, res.Functions...)
+		out.defines = append(out.defines, res.Defines...)
+		out.calls = append(out.calls, res.Calls...)
+		out.imports = append(out.imports, res.Imports...)
+		out.unresolved = append(out.unresolved, res.UnresolvedCalls...)
+		out.packageNames[info.Path] = res.PackageName
+	}
+	return out
+}
+
+// BenchmarkParseSynthetic measures parsing throughput over generated
+// repositories of increasing size.
+func BenchmarkParseSynthetic(b *testing.B) {
+	for _, size := range syntheticSizes {
+		b.Run(size.name, func(b *testing.B) {
+			repo := cietest.GenerateRepo(b, size.spec)
+			infos := syntheticFileInfos(repo)
+			parser := NewTreeSitterParser(nil)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				parseSynthetic(b, parser, infos)
+			}
+			b.ReportMetric(float64(repo.Functions), "funcs")
+		})
+	}
+}
+
+// BenchmarkResolveCallsSynthetic measures index construction and
+// cross-package call resolution as the number of packages grows.
+func BenchmarkResolveCallsSynthetic(b *testing.B) {
+	for _, size := range syntheticSizes {
+		b.Run(size.name, func(b *testing.B) {
+			repo := cietest.GenerateRepo(b, size.spec)
+			parsed := parseSynthetic(b, NewTreeSitterParser(nil), syntheticFileInfos(repo))
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				resolver := NewCallResolver()
+				resolver.BuildIndex(parsed.files, parsed.functions, parsed.imports, parsed.packageNames)
+				_ = resolver.ResolveCalls(parsed.unresolved)
+			}
+			b.ReportMetric(float64(len(parsed.unresolved)), "calls")
+		})
+	}
+}
+
+// BenchmarkBatchSynthetic measures mutation generation and batching at
+// several batch sizes, for tuning targetMutations against repository size.
+func BenchmarkBatchSynthetic(b *testing.B) {
+	for _, size := range syntheticSizes {
+		repo := cietest.GenerateRepo(b, size.spec)
+		parsed := parseSynthetic(b, NewTreeSitterParser(nil), syntheticFileInfos(repo))
+
+		for _, target := range []int{500, 2000} {
+			b.Run(fmt.Sprintf("%s/target=%d", size.name, target), func(b *testing.B) {
+				batcher := NewBatcher(target, 2*1024*1024)
+				for i := 0; i < b.N; i++ {
+					script := NewDatalogBuilder().BuildMutations(parsed.files, parsed.functions, parsed.defines, parsed.calls, parsed.imports)
+					if _, err := batcher.Batch(script); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,10 @@
+namespace MyApp.Data
+{
+    public interface IUserRepository
+    {
+        User Find(int id);
+        string Describe();
+    }
+
+    public class User { }
+}
@@ -0,0 +1,15 @@
+namespace MyApp.Services
+{
+    public partial class UserService
+    {
+        private void Audit(int id) { }
+    }
+
+    namespace Admin
+    {
+        public class AdminService
+        {
+            public void Reset() { }
+        }
+    }
+}
@@ -0,0 +1,57 @@
+using System;
+using MyApp.Data;
+using Log = MyApp.Util.Logger;
+using static MyApp.Util.Guard;
+
+namespace MyApp.Services;
+
+public interface IUserService
+{
+    User Get(int id);
+    string Name { get; }
+}
+
+public partial class UserService : IUserService
+{
+    private readonly IUserRepository _repo;
+
+    public int Count { get; set; }
+
+    public string Name => _repo.Describe();
+
+    public UserService(IUserRepository repo)
+    {
+        _repo = repo;
+    }
+
+    [Obsolete]
+    public User Get(int id)
+    {
+        NotNegative(id);
+        Validate(id);
+        var cache = new UserCache();
+        cache.Warm();
+        Log.Info("get");
+        Console.WriteLine(nameof(id));
+        return this._repo.Find(id);
+    }
+
+    private void Validate(int id)
+    {
+        Audit(id);
+    }
+
+    public int Total
+    {
+        get { return Count * 2; }
+    }
+}
+
+public class UserCache
+{
+    public void Warm() { }
+}
+
+public record UserView(int Id, string Name);
+
+public enum Role { Admin, Member }
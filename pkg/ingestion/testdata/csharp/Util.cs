@@ -0,0 +1,11 @@
+namespace MyApp.Util;
+
+public static class Logger
+{
+    public static void Info(string message) { }
+}
+
+public static class Guard
+{
+    public static void NotNegative(int value) { }
+}
@@ -0,0 +1,38 @@
+"""Protocols, ABCs and calls through annotated parameters."""
+from abc import ABC, abstractmethod
+from typing import Optional, Protocol
+
+
+class Repository(Protocol):
+    def save(self, item: str) -> None: ...
+
+    def load(self, key: str) -> Optional[str]: ...
+
+
+class Notifier(ABC):
+    @abstractmethod
+    def notify(self, message: str) -> None:
+        pass
+
+    def close(self) -> None:
+        pass
+
+
+class MemoryRepository:
+    def save(self, item: str) -> None:
+        pass
+
+    def load(self, key: str) -> Optional[str]:
+        return None
+
+
+async def store(
+    repo: Repository,
+    item: str,
+    notifier: Optional["Notifier"] = None,
+) -> bool:
+    repo.save(item)
+    item.upper()
+    if notifier:
+        notifier.notify(item)
+    return True
@@ -0,0 +1,6 @@
+from typing import Protocol
+
+class Cache(Protocol):
+    def get(self, key: str) -> bytes | None: ...
+
+def warm(cache: Cache, keys: list[str]) -> int: ...
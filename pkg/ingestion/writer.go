@@ -0,0 +1,362 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package ingestion
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// defaultWriteQueue bounds how many rendered write scripts may wait for the
+// writer, and with it how far rendering runs ahead of the database.
+const defaultWriteQueue = 64
+
+// streamEmbedBatch is how many functions and types a fileStream collects
+// from parsed files before embedding them in one call, so embedding workers
+// get full batches while parsing goes on.
+const streamEmbedBatch = 256
+
+// fileWrite is the script that stores one file's entities.
+type fileWrite struct {
+	path   string
+	script string
+}
+
+// fileJob is one file's entities waiting to be rendered.
+type fileJob struct {
+	path     string
+	entities *entitySet
+}
+
+// writeQueue stores entities one transaction per file, so a file's
+// functions, code, embeddings and edges are committed together or not at
+// all. Builder goroutines render each file's script and feed a single writer
+// goroutine through a bounded queue; the writer sends each file to its shard
+// when storage is sharded. Entities can be added while earlier files are
+// being written. The first failed write stops the queue.
+type writeQueue struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	jobs      chan fileJob
+	done      chan struct{}
+	closeJobs sync.Once
+	queued    atomic.Int64 // Non-empty scripts rendered so far
+	progress  atomic.Bool  // Report "writing" progress
+	err       error        // First write error; read after done is closed
+}
+
+// startWriter starts the builders and the writer of a writeQueue. When
+// streaming, writes run alongside parsing and "writing" progress is only
+// reported once wait is called, so it does not interleave with the parse's.
+func (p *LocalPipeline) startWriter(ctx context.Context, streaming bool) *writeQueue {
+	workers := p.config.IngestionConfig.Concurrency.WriteWorkers
+	if workers <= 0 {
+		workers = max(p.config.IngestionConfig.Concurrency.ParseWorkers, 1)
+	}
+	queueSize := p.config.IngestionConfig.Concurrency.WriteQueue
+	if queueSize <= 0 {
+		queueSize = defaultWriteQueue
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	q := &writeQueue{
+		ctx:    ctx,
+		cancel: cancel,
+		jobs:   make(chan fileJob, queueSize),
+		done:   make(chan struct{}),
+	}
+	q.progress.Store(!streaming)
+
+	scripts := make(chan fileWrite, queueSize)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Keep draining after a failure so add never blocks
+			for job := range q.jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				script := job.entities.mutations(p.datalogBuild)
+				if script == "" {
+					continue
+				}
+				q.queued.Add(1)
+				select {
+				case scripts <- fileWrite{path: job.path, script: script}:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(scripts)
+	}()
+
+	// The writer: drains the queue even after a failure so builders exit
+	go func() {
+		defer close(q.done)
+		var written int64
+		for w := range scripts {
+			if q.err != nil {
+				continue
+			}
+			if err := p.writeFile(ctx, w); err != nil {
+				q.err = err
+				cancel()
+				continue
+			}
+			written++
+			if q.progress.Load() {
+				p.reportProgress(written, q.queued.Load(), "writing")
+			}
+		}
+	}()
+	return q
+}
+
+// add queues entities one file at a time. It returns false once the queue
+// has stopped, after a failed write or cancellation; wait reports why.
+func (q *writeQueue) add(entities *entitySet) bool {
+	files := entities.splitBy(func(path string) string { return path })
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		select {
+		case q.jobs <- fileJob{path: path, entities: files[path]}:
+		case <-q.ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// wait lets the queued files finish writing and returns the first write
+// error, or the context's error if the run was cancelled. Nothing may be
+// added afterwards.
+func (q *writeQueue) wait() error {
+	q.progress.Store(true)
+	q.closeJobs.Do(func() { close(q.jobs) })
+	<-q.done
+	ctxErr := q.ctx.Err()
+	q.cancel()
+	if q.err != nil {
+		return q.err
+	}
+	return ctxErr
+}
+
+// stop abandons the queue: pending files are dropped and the goroutines
+// exit. It is safe to call after wait.
+func (q *writeQueue) stop() {
+	q.cancel()
+	_ = q.wait()
+}
+
+// writeEntities stores entities one transaction per file through a
+// writeQueue.
+func (p *LocalPipeline) writeEntities(ctx context.Context, entities *entitySet) error {
+	q := p.startWriter(ctx, false)
+	q.add(entities)
+	return q.wait()
+}
+
+// writeFile executes one file's script in the store that holds the file.
+func (p *LocalPipeline) writeFile(ctx context.Context, w fileWrite) error {
+	store, err := p.backend.Shard(storage.ShardFor(w.path))
+	if err != nil {
+		return err
+	}
+	if err := store.Execute(ctx, w.script); err != nil {
+		if w.path == "" {
+			return fmt.Errorf("write entities without a file: %w", err)
+		}
+		return fmt.Errorf("write %s: %w", w.path, err)
+	}
+	return nil
+}
+
+// fileStream embeds each parsed file's own entities (the file, its
+// functions and types with code and embeddings, and the edges found inside
+// it) and queues them for writing while the remaining files are still being
+// parsed. What needs every file, such as resolved cross-package calls,
+// implements and generated-code links, is added to the queue after parsing.
+type fileStream struct {
+	p      *LocalPipeline
+	writes *writeQueue
+	embed  *EmbeddingGenerator // nil when embeddings are skipped
+	in     chan *ParseResult
+	done   chan struct{}
+
+	// Owned by the stream goroutine; read after done is closed.
+	batch         []*ParseResult
+	pending       int               // Functions and types in batch
+	functionPaths map[string]string // File of every streamed function, by ID
+	queued        int               // Entities and edges handed to writes
+	embedErrors   int
+	embedDuration time.Duration
+	err           error
+}
+
+// startFileStream starts a fileStream that writes through writes.
+func (p *LocalPipeline) startFileStream(writes *writeQueue) *fileStream {
+	s := &fileStream{
+		p:             p,
+		writes:        writes,
+		in:            make(chan *ParseResult, streamEmbedBatch),
+		done:          make(chan struct{}),
+		functionPaths: make(map[string]string),
+	}
+	if !p.config.IngestionConfig.SkipEmbeddings {
+		// Without the progress callback: while streaming, the progress
+		// shown is the parse's
+		quiet := *p.embeddingGen
+		quiet.onProgress = nil
+		s.embed = &quiet
+	}
+	go s.run()
+	return s
+}
+
+// add hands a parsed file to the stream.
+func (s *fileStream) add(pr *ParseResult) {
+	s.in <- pr
+}
+
+// close queues the last batch and returns the first embedding, validation
+// or write error of the stream.
+func (s *fileStream) close() error {
+	close(s.in)
+	<-s.done
+	if s.err != nil && s.writes.ctx.Err() != nil {
+		// The queue stopped first; its error is the cause
+		if err := s.writes.wait(); err != nil {
+			return fmt.Errorf("write to local db: %w", err)
+		}
+	}
+	return s.err
+}
+
+func (s *fileStream) run() {
+	defer close(s.done)
+	for pr := range s.in {
+		if s.err != nil {
+			continue
+		}
+		for _, fn := range pr.Functions {
+			s.functionPaths[fn.ID] = fn.FilePath
+		}
+		s.batch = append(s.batch, pr)
+		s.pending += len(pr.Functions) + len(pr.Types)
+		if s.pending >= streamEmbedBatch {
+			s.err = s.flush()
+		}
+	}
+	if s.err == nil {
+		s.err = s.flush()
+	}
+}
+
+// flush embeds the batch and queues one entity set per file.
+func (s *fileStream) flush() error {
+	batch := s.batch
+	s.batch, s.pending = nil, 0
+	if len(batch) == 0 {
+		return nil
+	}
+	ctx := s.writes.ctx
+
+	var functions []FunctionEntity
+	var types []TypeEntity
+	for _, pr := range batch {
+		functions = append(functions, pr.Functions...)
+		types = append(types, pr.Types...)
+	}
+	if s.embed != nil {
+		start := time.Now()
+		endEmbed := s.p.profiler.StartStage("embed_functions")
+		fnResult, err := s.embed.EmbedFunctions(ctx, functions)
+		endEmbed()
+		if err != nil {
+			return fmt.Errorf("generate embeddings: %w", err)
+		}
+		functions = fnResult.Functions
+		s.embedErrors += fnResult.ErrorCount
+
+		endTypeEmbed := s.p.profiler.StartStage("embed_types")
+		typeResult, err := s.embed.EmbedTypes(ctx, types)
+		endTypeEmbed()
+		if err != nil {
+			return fmt.Errorf("generate type embeddings: %w", err)
+		}
+		types = typeResult.Types
+		s.embedErrors += typeResult.ErrorCount
+		s.embedDuration += time.Since(start)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for _, pr := range batch {
+		entities := &entitySet{
+			files:        []FileEntity{pr.File},
+			functions:    functions[:len(pr.Functions):len(pr.Functions)],
+			types:        types[:len(pr.Types):len(pr.Types)],
+			defines:      pr.Defines,
+			definesTypes: pr.DefinesTypes,
+			calls:        pr.Calls,
+			imports:      pr.Imports,
+			fields:       pr.Fields,
+			protoOptions: pr.ProtoOptions,
+			templateRefs: pr.TemplateRefs,
+			renders:      pr.Renders,
+			tableRefs:    pr.TableRefs,
+			ciJobs:       pr.CIJobs,
+			ciSteps:      pr.CISteps,
+			ciRefs:       pr.CIRefs,
+		}
+		if pr.Template != nil {
+			entities.templates = []TemplateEntity{*pr.Template}
+		}
+		functions = functions[len(pr.Functions):]
+		types = types[len(pr.Types):]
+
+		if err := ValidateEntities(entities.files, entities.functions, entities.defines, entities.calls); err != nil {
+			return fmt.Errorf("entity validation failed: %w", err)
+		}
+		s.queued += entities.count()
+		if !s.writes.add(entities) {
+			return s.writes.ctx.Err()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package ingestion
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// twoFileEntities returns a set with one embedded function in each of a.go
+// and b.go, where a.go calls into b.go.
+func twoFileEntities() *entitySet {
+	return &entitySet{
+		files: []FileEntity{{ID: "file:a", Path: "a.go"}, {ID: "file:b", Path: "b.go"}},
+		functions: []FunctionEntity{
+			{ID: "fn:a", Name: "A", FilePath: "a.go", CodeText: "func A() { B() }", Embedding: []float32{0.1, 0.2}},
+			{ID: "fn:b", Name: "B", FilePath: "b.go", CodeText: "func B() {}", Embedding: []float32{0.3, 0.4}},
+		},
+		defines: []DefinesEdge{{FileID: "file:a", FunctionID: "fn:a"}, {FileID: "file:b", FunctionID: "fn:b"}},
+		calls:   []CallsEdge{{CallerID: "fn:a", CalleeID: "fn:b"}},
+	}
+}
+
+func TestEntitySet_PerFileScriptsKeepRowsTogether(t *testing.T) {
+	files := twoFileEntities().splitBy(func(path string) string { return path })
+	require.Len(t, files, 2)
+
+	script := files["a.go"].mutations(NewDatalogBuilder())
+	for _, relation := range []string{"cie_file", "cie_function", "cie_function_code", "cie_function_embedding", "cie_defines", "cie_calls"} {
+		assert.Contains(t, script, ":put "+relation+" ", "a.go's transaction should write %s", relation)
+	}
+	assert.NotContains(t, script, `"fn:b", "B"`, "b.go's function belongs to its own transaction")
+}
+
+func TestEntitySet_SplitByPlacesCallsOfEarlierFunctions(t *testing.T) {
+	derived := &entitySet{
+		calls:         []CallsEdge{{CallerID: "fn:a", CalleeID: "fn:b"}},
+		functionPaths: map[string]string{"fn:a": "a.go"},
+	}
+	parts := derived.splitBy(func(path string) string { return path })
+	require.Contains(t, parts, "a.go")
+	assert.Len(t, parts["a.go"].calls, 1, "a resolved call is stored with its caller's file")
+	assert.NotContains(t, parts, "")
+}
+
+func TestWriteEntities_QueuedPerFile(t *testing.T) {
+	cfg := Config{
+		ProjectID: "writer-test",
+		IngestionConfig: IngestionConfig{
+			LocalDataDir:        t.TempDir(),
+			LocalEngine:         "mem",
+			EmbeddingProvider:   "mock",
+			EmbeddingDimensions: 2,
+			Concurrency:         ConcurrencyConfig{WriteWorkers: 3, WriteQueue: 1},
+		},
+	}
+	pipeline, err := NewLocalPipeline(cfg, nil)
+	require.NoError(t, err)
+	defer func() { _ = pipeline.Close() }()
+
+	entities := twoFileEntities()
+	for i := 0; i < 20; i++ {
+		path := fmt.Sprintf("gen/f%02d.go", i)
+		entities.files = append(entities.files, FileEntity{ID: "file:" + path, Path: path})
+	}
+	var phases []string
+	pipeline.SetProgressCallback(func(_, _ int64, phase string) { phases = append(phases, phase) })
+
+	require.NoError(t, pipeline.writeEntities(context.Background(), entities))
+	assert.Len(t, phases, len(entities.files))
+
+	result, err := pipeline.Backend().Query(context.Background(), `?[path] := *cie_file { path }`)
+	require.NoError(t, err)
+	assert.Len(t, result.Rows, len(entities.files))
+
+	result, err = pipeline.Backend().Query(context.Background(), `?[caller, callee] := *cie_calls { caller_id: caller, callee_id: callee }`)
+	require.NoError(t, err)
+	require.Len(t, result.Rows, 1)
+	assert.Equal(t, "fn:a", result.Rows[0][0])
+}
+
+func TestFileStream_WritesFilesAsParsed(t *testing.T) {
+	cfg := Config{
+		ProjectID: "stream-test",
+		IngestionConfig: IngestionConfig{
+			LocalDataDir:        t.TempDir(),
+			LocalEngine:         "mem",
+			EmbeddingProvider:   "mock",
+			EmbeddingDimensions: 8,
+			Concurrency:         ConcurrencyConfig{EmbedWorkers: 2},
+		},
+	}
+	pipeline, err := NewLocalPipeline(cfg, nil)
+	require.NoError(t, err)
+	defer func() { _ = pipeline.Close() }()
+
+	writes := pipeline.startWriter(context.Background(), true)
+	stream := pipeline.startFileStream(writes)
+	for i := 0; i < 3; i++ {
+		path := fmt.Sprintf("pkg/f%d.go", i)
+		fn := FunctionEntity{ID: "fn:" + path, Name: fmt.Sprintf("F%d", i), FilePath: path, CodeText: "func F() {}", StartLine: 1, EndLine: 1}
+		stream.add(&ParseResult{
+			File:      FileEntity{ID: "file:" + path, Path: path, Hash: "h"},
+			Functions: []FunctionEntity{fn},
+			Defines:   []DefinesEdge{{FileID: "file:" + path, FunctionID: fn.ID}},
+		})
+	}
+	require.NoError(t, stream.close())
+	require.True(t, writes.add(&entitySet{
+		calls:         []CallsEdge{{CallerID: "fn:pkg/f0.go", CalleeID: "fn:pkg/f1.go"}},
+		functionPaths: stream.functionPaths,
+	}))
+	require.NoError(t, writes.wait())
+
+	result, err := pipeline.Backend().Query(context.Background(), `?[id] := *cie_function_embedding { function_id: id }`)
+	require.NoError(t, err)
+	assert.Len(t, result.Rows, 3, "streamed functions are written with their embeddings")
+
+	result, err = pipeline.Backend().Query(context.Background(), `?[caller] := *cie_calls { caller_id: caller }`)
+	require.NoError(t, err)
+	assert.Len(t, result.Rows, 1)
+}
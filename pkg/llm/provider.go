@@ -22,12 +22,18 @@ package llm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"os"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/kraklabs/cie/pkg/errcode"
 )
 
 // Provider defines the interface for LLM text generation.
@@ -224,7 +230,7 @@ func (p *ollamaProvider) Models(ctx context.Context) ([]string, error) {
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("ollama list models: %w", err)
+		return nil, fmt.Errorf("ollama list models: %w: %w", errcode.ErrProviderUnreachable, err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
@@ -285,7 +291,7 @@ func (p *ollamaProvider) Generate(ctx context.Context, req GenerateRequest) (*Ge
 	start := time.Now()
 	resp, err := p.client.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("ollama generate: %w", err)
+		return nil, fmt.Errorf("ollama generate: %w: %w", errcode.ErrProviderUnreachable, err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
@@ -367,7 +373,7 @@ func (p *ollamaProvider) Chat(ctx context.Context, req ChatRequest) (*ChatRespon
 	start := time.Now()
 	resp, err := p.client.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("ollama chat: %w", err)
+		return nil, fmt.Errorf("ollama chat: %w: %w", errcode.ErrProviderUnreachable, err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
@@ -460,7 +466,7 @@ func (p *openaiProvider) Models(ctx context.Context) ([]string, error) {
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("openai list models: %w", err)
+		return nil, fmt.Errorf("openai list models: %w: %w", errcode.ErrProviderUnreachable, err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
@@ -549,7 +555,7 @@ func (p *openaiProvider) Chat(ctx context.Context, req ChatRequest) (*ChatRespon
 	start := time.Now()
 	resp, err := p.client.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("openai chat: %w", err)
+		return nil, fmt.Errorf("openai chat: %w: %w", errcode.ErrProviderUnreachable, err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
@@ -728,7 +734,7 @@ func (p *anthropicProvider) Chat(ctx context.Context, req ChatRequest) (*ChatRes
 	start := time.Now()
 	resp, err := p.client.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("anthropic chat: %w", err)
+		return nil, fmt.Errorf("anthropic chat: %w: %w", errcode.ErrProviderUnreachable, err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
@@ -780,13 +786,96 @@ func (p *anthropicProvider) Chat(ctx context.Context, req ChatRequest) (*ChatRes
 
 // MockProvider is a test provider that returns predictable responses.
 // Use it in tests to avoid real API calls. Override GenerateFunc or ChatFunc
-// to customize the mock behavior.
+// to customize the mock behavior, or set Behavior to script latency,
+// failures and canned replies.
 type MockProvider struct {
 	model string
 	// GenerateFunc overrides the default Generate behavior when set.
 	GenerateFunc func(ctx context.Context, req GenerateRequest) (*GenerateResponse, error)
 	// ChatFunc overrides the default Chat behavior when set.
 	ChatFunc func(ctx context.Context, req ChatRequest) (*ChatResponse, error)
+	// Behavior is applied before GenerateFunc/ChatFunc and the defaults.
+	Behavior MockBehavior
+
+	mu    sync.Mutex
+	rng   *rand.Rand
+	calls int
+}
+
+// MockBehavior scripts how a MockProvider responds. The zero value leaves
+// the mock's default behavior unchanged.
+type MockBehavior struct {
+	// Latency delays every call; Jitter adds up to that much more.
+	Latency time.Duration
+	Jitter  time.Duration
+
+	// FailFirst makes the first N calls fail, then calls succeed.
+	FailFirst int
+	// FailureRate makes that fraction (0-1) of the remaining calls fail.
+	FailureRate float64
+	// Err is returned by failing calls. Defaults to ErrMockUnavailable.
+	Err error
+
+	// Responses are matched in order against the prompt (or the last chat
+	// message); the first match replies with its Text or Err.
+	Responses []MockResponse
+
+	// Seed makes jitter and FailureRate reproducible.
+	Seed int64
+}
+
+// MockResponse is a canned reply for prompts matching Pattern.
+type MockResponse struct {
+	Pattern *regexp.Regexp
+	Text    string
+	Err     error
+}
+
+// ErrMockUnavailable is the default error of a scripted MockProvider failure.
+var ErrMockUnavailable = errors.New("mock LLM API error (status 503): service unavailable")
+
+// Calls returns how many Generate and Chat calls the mock has received.
+func (p *MockProvider) Calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+// script applies Behavior to one call. It returns handled=true with a canned
+// reply when a response pattern matched.
+func (p *MockProvider) script(ctx context.Context, prompt string) (text string, handled bool, err error) {
+	p.mu.Lock()
+	p.calls++
+	b := p.Behavior
+	if p.rng == nil {
+		p.rng = rand.New(rand.NewSource(b.Seed)) //nolint:gosec // deterministic test randomness
+	}
+	delay := b.Latency
+	if b.Jitter > 0 {
+		delay += time.Duration(p.rng.Int63n(int64(b.Jitter)))
+	}
+	fail := p.calls <= b.FailFirst || (b.FailureRate > 0 && p.rng.Float64() < b.FailureRate)
+	p.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return "", false, ctx.Err()
+		}
+	}
+	if fail {
+		if b.Err != nil {
+			return "", false, b.Err
+		}
+		return "", false, ErrMockUnavailable
+	}
+	for _, r := range b.Responses {
+		if r.Pattern != nil && r.Pattern.MatchString(prompt) {
+			return r.Text, true, r.Err
+		}
+	}
+	return "", false, nil
 }
 
 func (p *MockProvider) Name() string { return "mock" }
@@ -796,6 +885,13 @@ func (p *MockProvider) Models(ctx context.Context) ([]string, error) {
 }
 
 func (p *MockProvider) Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	text, handled, err := p.script(ctx, req.Prompt)
+	if err != nil {
+		return nil, err
+	}
+	if handled {
+		return &GenerateResponse{Text: text, Model: "mock-model", OutputTokens: len(text) / 4, Done: true}, nil
+	}
 	if p.GenerateFunc != nil {
 		return p.GenerateFunc(ctx, req)
 	}
@@ -811,13 +907,25 @@ func (p *MockProvider) Generate(ctx context.Context, req GenerateRequest) (*Gene
 }
 
 func (p *MockProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
-	if p.ChatFunc != nil {
-		return p.ChatFunc(ctx, req)
-	}
 	lastMsg := ""
 	if len(req.Messages) > 0 {
 		lastMsg = req.Messages[len(req.Messages)-1].Content
 	}
+	text, handled, err := p.script(ctx, lastMsg)
+	if err != nil {
+		return nil, err
+	}
+	if handled {
+		return &ChatResponse{
+			Message:      Message{Role: "assistant", Content: text},
+			Model:        "mock-model",
+			OutputTokens: len(text) / 4,
+			Done:         true,
+		}, nil
+	}
+	if p.ChatFunc != nil {
+		return p.ChatFunc(ctx, req)
+	}
 	return &ChatResponse{
 		Message: Message{
 			Role:    "assistant",
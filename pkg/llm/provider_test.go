@@ -26,6 +26,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/kraklabs/cie/pkg/errcode"
 )
 
 func TestNewProvider_MockType(t *testing.T) {
@@ -352,3 +354,21 @@ func TestBuildChatMessages(t *testing.T) {
 		t.Errorf("expected last message to be user prompt")
 	}
 }
+
+func TestOllamaProvider_Generate_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close() // nothing listens on url any more
+
+	p, err := NewProvider(ProviderConfig{Type: "ollama", BaseURL: url, DefaultModel: "test-model", Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("NewProvider error = %v", err)
+	}
+	_, err = p.Generate(context.Background(), GenerateRequest{Prompt: "Hello"})
+	if err == nil {
+		t.Fatal("expected an error from a closed server")
+	}
+	if code := errcode.Of(err); code != errcode.ProviderUnreachable {
+		t.Errorf("errcode.Of(%v) = %q, want %q", err, code, errcode.ProviderUnreachable)
+	}
+}
@@ -0,0 +1,411 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+// Package scripting runs user-provided Starlark analysis scripts against a
+// CIE index, so teams can compute their own metrics and derived relations
+// without changing CIE.
+//
+// A script sees these globals:
+//
+//	project_id          the indexed project
+//	full_run            True after a full index run
+//	changed_files       paths indexed by an incremental run (empty on full runs)
+//	query(script)       run a read-only CozoScript query; returns a list of dicts
+//	execute(script)     run a CozoScript mutation on custom relations
+//	define(rel, keys, values=[])  create custom relation rel unless it exists
+//	put(rel, rows)      upsert rows (dicts with the same keys) into rel
+//	metric(name, value) report a numeric metric for this run
+//
+// Scripts may only write relations whose names start with "cie_x_", so
+// they cannot corrupt the index; such relations are namespaced like the
+// built-in ones. Example:
+//
+//	rows = query("?[file, count(id)] := *cie_function { id, file_path: file }")
+//	define("cie_x_file_size", ["file"], ["functions"])
+//	put("cie_x_file_size", [{"file": r["file"], "functions": r["count(id)"]} for r in rows])
+//	metric("largest_file", max([r["count(id)"] for r in rows] + [0]))
+package scripting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// CustomRelationPrefix starts the name of every relation a script may write.
+const CustomRelationPrefix = "cie_x_"
+
+// Defaults for Options.
+const (
+	DefaultTimeout  = 5 * time.Minute
+	DefaultMaxSteps = 100_000_000
+)
+
+// Options configures a script run.
+type Options struct {
+	ProjectID    string
+	FullRun      bool
+	ChangedFiles []string
+	Timeout      time.Duration // default DefaultTimeout
+	MaxSteps     uint64        // Starlark execution steps; default DefaultMaxSteps
+	Logger       *slog.Logger  // receives print() output; default slog.Default()
+}
+
+// Result reports what a script produced.
+type Result struct {
+	Script   string
+	Metrics  map[string]float64
+	Duration time.Duration
+}
+
+// fileOptions enables the Starlark extensions scripts commonly need:
+// while loops, sets, top-level if/for and recursion.
+var fileOptions = &syntax.FileOptions{
+	Set:             true,
+	While:           true,
+	TopLevelControl: true,
+	GlobalReassign:  true,
+	Recursion:       true,
+}
+
+// RunFile runs the Starlark script at path against backend.
+func RunFile(ctx context.Context, backend storage.Backend, path string, opts Options) (*Result, error) {
+	src, err := os.ReadFile(path) //nolint:gosec // G304: Scripts are configured by the project
+	if err != nil {
+		return nil, fmt.Errorf("read script: %w", err)
+	}
+	return Run(ctx, backend, filepath.Base(path), src, opts)
+}
+
+// Run runs a Starlark script named name against backend.
+func Run(ctx context.Context, backend storage.Backend, name string, src []byte, opts Options) (*Result, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxSteps == 0 {
+		opts.MaxSteps = DefaultMaxSteps
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	r := &runner{ctx: ctx, backend: backend, result: &Result{Script: name, Metrics: map[string]float64{}}}
+	thread := &starlark.Thread{
+		Name: name,
+		Print: func(_ *starlark.Thread, msg string) {
+			opts.Logger.Info("scripting.print", "script", name, "msg", msg)
+		},
+	}
+	thread.SetMaxExecutionSteps(opts.MaxSteps)
+	stop := context.AfterFunc(ctx, func() { thread.Cancel(ctx.Err().Error()) })
+	defer stop()
+
+	changed := make([]starlark.Value, len(opts.ChangedFiles))
+	for i, f := range opts.ChangedFiles {
+		changed[i] = starlark.String(f)
+	}
+	predeclared := starlark.StringDict{
+		"project_id":    starlark.String(opts.ProjectID),
+		"full_run":      starlark.Bool(opts.FullRun),
+		"changed_files": starlark.NewList(changed),
+		"query":         starlark.NewBuiltin("query", r.query),
+		"execute":       starlark.NewBuiltin("execute", r.execute),
+		"define":        starlark.NewBuiltin("define", r.define),
+		"put":           starlark.NewBuiltin("put", r.put),
+		"metric":        starlark.NewBuiltin("metric", r.metric),
+	}
+	if _, err := starlark.ExecFileOptions(fileOptions, thread, name, src, predeclared); err != nil {
+		if evalErr, ok := err.(*starlark.EvalError); ok {
+			return nil, fmt.Errorf("%s", evalErr.Backtrace())
+		}
+		return nil, err
+	}
+	r.result.Duration = time.Since(start)
+	return r.result, nil
+}
+
+// runner holds the state the builtins of one script run share.
+type runner struct {
+	ctx     context.Context
+	backend storage.Backend
+	result  *Result
+}
+
+func (r *runner) query(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var script string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "script", &script); err != nil {
+		return nil, err
+	}
+	if storage.IsMutation(script) {
+		return nil, fmt.Errorf("%s: script modifies the database; use execute()", b.Name())
+	}
+	res, err := r.backend.Query(r.ctx, script)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+	rows := make([]starlark.Value, len(res.Rows))
+	for i, row := range res.Rows {
+		d := starlark.NewDict(len(res.Headers))
+		for j, h := range res.Headers {
+			if j < len(row) {
+				_ = d.SetKey(starlark.String(h), toStarlark(row[j]))
+			}
+		}
+		rows[i] = d
+	}
+	return starlark.NewList(rows), nil
+}
+
+func (r *runner) execute(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var script string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "script", &script); err != nil {
+		return nil, err
+	}
+	return starlark.None, r.mutate(b.Name(), script)
+}
+
+// mutate runs a mutation after checking it only writes custom relations.
+func (r *runner) mutate(fn, script string) error {
+	if err := CheckMutation(script); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	if err := r.backend.Execute(r.ctx, script); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	return nil
+}
+
+func (r *runner) define(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var rel string
+	var keys, values *starlark.List
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "relation", &rel, "keys", &keys, "values?", &values); err != nil {
+		return nil, err
+	}
+	keyCols, err := columnNames(keys)
+	if err != nil || len(keyCols) == 0 {
+		return nil, fmt.Errorf("%s: keys must be a non-empty list of column names", b.Name())
+	}
+	valueCols, err := columnNames(values)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+	schema := strings.Join(keyCols, ", ")
+	if len(valueCols) > 0 {
+		schema += " => " + strings.Join(valueCols, ", ")
+	}
+	createErr := r.mutate(b.Name(), fmt.Sprintf(":create %s { %s }", rel, schema))
+	if createErr == nil {
+		return starlark.None, nil
+	}
+	// Already defined by an earlier run: the relation can be read.
+	probe := fmt.Sprintf("?[k] := *%s { %s: k } :limit 1", rel, keyCols[0])
+	if _, err := r.backend.Query(r.ctx, probe); err != nil {
+		return nil, createErr
+	}
+	return starlark.None, nil
+}
+
+func (r *runner) put(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var rel string
+	var rows starlark.Iterable
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "relation", &rel, "rows", &rows); err != nil {
+		return nil, err
+	}
+	script, err := putScript(rel, rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+	if script == "" {
+		return starlark.None, nil
+	}
+	return starlark.None, r.mutate(b.Name(), script)
+}
+
+func (r *runner) metric(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	var value starlark.Value
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name, "value", &value); err != nil {
+		return nil, err
+	}
+	f, ok := starlark.AsFloat(value)
+	if !ok {
+		return nil, fmt.Errorf("%s: value for %q must be a number, got %s", b.Name(), name, value.Type())
+	}
+	r.result.Metrics[name] = f
+	return starlark.None, nil
+}
+
+// CheckMutation rejects scripts that write anything but custom relations,
+// including system ops such as ::remove.
+func CheckMutation(script string) error {
+	targets := storage.WriteTargets(script)
+	if len(targets) == 0 {
+		return fmt.Errorf("script does not write a relation")
+	}
+	for _, rel := range targets {
+		if !strings.HasPrefix(rel, CustomRelationPrefix) {
+			return fmt.Errorf("relation %s is not writable: scripts may only write %s* relations", rel, CustomRelationPrefix)
+		}
+	}
+	if strings.Contains(storage.StripLiterals(script), "::") {
+		return fmt.Errorf("system ops are not allowed in scripts")
+	}
+	return nil
+}
+
+// putScript renders an upsert of rows, each a dict with the same keys.
+func putScript(rel string, rows starlark.Iterable) (string, error) {
+	var cols []string
+	var literals []string
+	iter := rows.Iterate()
+	defer iter.Done()
+	var v starlark.Value
+	for iter.Next(&v) {
+		d, ok := v.(*starlark.Dict)
+		if !ok {
+			return "", fmt.Errorf("rows must be dicts, got %s", v.Type())
+		}
+		if cols == nil {
+			for _, k := range d.Keys() {
+				s, ok := starlark.AsString(k)
+				if !ok {
+					return "", fmt.Errorf("column names must be strings, got %s", k.Type())
+				}
+				cols = append(cols, s)
+			}
+			sort.Strings(cols)
+		}
+		if d.Len() != len(cols) {
+			return "", fmt.Errorf("every row needs the columns %s", strings.Join(cols, ", "))
+		}
+		vals := make([]string, len(cols))
+		for i, c := range cols {
+			cell, found, _ := d.Get(starlark.String(c))
+			if !found {
+				return "", fmt.Errorf("every row needs the columns %s", strings.Join(cols, ", "))
+			}
+			lit, err := literal(cell)
+			if err != nil {
+				return "", fmt.Errorf("column %s: %w", c, err)
+			}
+			vals[i] = lit
+		}
+		literals = append(literals, "["+strings.Join(vals, ", ")+"]")
+	}
+	if len(literals) == 0 {
+		return "", nil
+	}
+	header := strings.Join(cols, ", ")
+	return fmt.Sprintf("?[%s] <- [%s]\n:put %s { %s }", header, strings.Join(literals, ", "), rel, header), nil
+}
+
+// columnNames converts an optional list of strings.
+func columnNames(list *starlark.List) ([]string, error) {
+	if list == nil {
+		return nil, nil
+	}
+	names := make([]string, list.Len())
+	for i := range names {
+		s, ok := starlark.AsString(list.Index(i))
+		if !ok {
+			return nil, fmt.Errorf("column names must be strings")
+		}
+		names[i] = s
+	}
+	return names, nil
+}
+
+// literal renders a Starlark value as a CozoScript literal.
+func literal(v starlark.Value) (string, error) {
+	switch x := v.(type) {
+	case starlark.NoneType:
+		return "null", nil
+	case starlark.Bool:
+		return fmt.Sprint(bool(x)), nil
+	case starlark.Int:
+		return x.String(), nil
+	case starlark.Float:
+		if math.IsNaN(float64(x)) || math.IsInf(float64(x), 0) {
+			return "", fmt.Errorf("%v cannot be stored", x)
+		}
+		return fmt.Sprint(float64(x)), nil
+	case starlark.String:
+		b, _ := json.Marshal(string(x))
+		return string(b), nil
+	case starlark.Indexable:
+		parts := make([]string, x.Len())
+		for i := range parts {
+			lit, err := literal(x.Index(i))
+			if err != nil {
+				return "", err
+			}
+			parts[i] = lit
+		}
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	}
+	return "", fmt.Errorf("cannot store a %s", v.Type())
+}
+
+// toStarlark converts a query result cell. Whole numbers become ints.
+func toStarlark(v any) starlark.Value {
+	switch x := v.(type) {
+	case nil:
+		return starlark.None
+	case bool:
+		return starlark.Bool(x)
+	case string:
+		return starlark.String(x)
+	case int:
+		return starlark.MakeInt(x)
+	case int64:
+		return starlark.MakeInt64(x)
+	case float64:
+		if x == math.Trunc(x) && math.Abs(x) < 1<<53 {
+			return starlark.MakeInt64(int64(x))
+		}
+		return starlark.Float(x)
+	case []any:
+		list := make([]starlark.Value, len(x))
+		for i, e := range x {
+			list[i] = toStarlark(e)
+		}
+		return starlark.NewList(list)
+	case map[string]any:
+		d := starlark.NewDict(len(x))
+		for k, e := range x {
+			_ = d.SetKey(starlark.String(k), toStarlark(e))
+		}
+		return d
+	}
+	return starlark.String(fmt.Sprint(v))
+}
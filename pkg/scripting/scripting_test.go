@@ -0,0 +1,144 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package scripting
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// fakeBackend answers every query with the same rows and records the
+// mutations it was asked to run.
+type fakeBackend struct {
+	result   *storage.QueryResult
+	queryErr error
+	executed []string
+}
+
+func (f *fakeBackend) Query(_ context.Context, _ string) (*storage.QueryResult, error) {
+	if f.queryErr != nil {
+		return nil, f.queryErr
+	}
+	return f.result, nil
+}
+
+func (f *fakeBackend) Execute(_ context.Context, script string) error {
+	f.executed = append(f.executed, script)
+	return nil
+}
+
+func (f *fakeBackend) Close() error { return nil }
+
+func TestRun_QueryPutMetric(t *testing.T) {
+	backend := &fakeBackend{result: &storage.QueryResult{
+		Headers: []string{"file", "n"},
+		Rows:    [][]any{{"a.go", float64(3)}, {"b.go", float64(7)}},
+	}}
+	src := `
+rows = query("?[file, n] := *cie_function { file_path: file }")
+define("cie_x_sizes", ["file"], ["functions"])
+put("cie_x_sizes", [{"file": r["file"], "functions": r["n"]} for r in rows])
+metric("largest", max([r["n"] for r in rows]))
+metric("changed", len(changed_files))
+if not full_run:
+    metric("project_" + project_id, 1)
+`
+	res, err := Run(context.Background(), backend, "sizes.star", []byte(src), Options{
+		ProjectID: "demo", ChangedFiles: []string{"a.go"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{"largest": 7, "changed": 1, "project_demo": 1}
+	for k, v := range want {
+		if res.Metrics[k] != v {
+			t.Errorf("metric %s = %v, want %v (all: %v)", k, res.Metrics[k], v, res.Metrics)
+		}
+	}
+	if len(backend.executed) != 2 {
+		t.Fatalf("executed = %q", backend.executed)
+	}
+	if backend.executed[0] != ":create cie_x_sizes { file => functions }" {
+		t.Errorf("define = %s", backend.executed[0])
+	}
+	put := backend.executed[1]
+	if !strings.Contains(put, `[["a.go", 3], ["b.go", 7]]`) || !strings.Contains(put, ":put cie_x_sizes { file, functions }") {
+		t.Errorf("put = %s", put)
+	}
+}
+
+func TestRun_RejectsWritesOutsideCustomRelations(t *testing.T) {
+	for _, src := range []string{
+		`execute("?[id] <- [['x']] :rm cie_function { id }")`,
+		`put("cie_file", [{"id": "x"}])`,
+		`execute("::remove cie_x_sizes")`,
+		`query("?[k, v] <- [['a', 'b']] :put cie_x_meta { k => v }")`,
+	} {
+		backend := &fakeBackend{result: &storage.QueryResult{}}
+		if _, err := Run(context.Background(), backend, "bad.star", []byte(src), Options{}); err == nil {
+			t.Errorf("%s: expected error", src)
+		}
+		if len(backend.executed) > 0 {
+			t.Errorf("%s: ran %q", src, backend.executed)
+		}
+	}
+}
+
+func TestRun_DefineExisting(t *testing.T) {
+	backend := &fakeBackend{result: &storage.QueryResult{}}
+	src := `define("cie_x_sizes", ["file"])`
+	if _, err := Run(context.Background(), backend, "s.star", []byte(src), Options{}); err != nil {
+		t.Fatalf("define: %v", err)
+	}
+
+	// Created by an earlier run: the create fails but the relation reads.
+	if _, err := Run(context.Background(), &failingCreate{backend}, "s.star", []byte(src), Options{}); err != nil {
+		t.Errorf("define of an existing relation: %v", err)
+	}
+	backend.queryErr = errors.New("relation not found")
+	if _, err := Run(context.Background(), &failingCreate{backend}, "s.star", []byte(src), Options{}); err == nil {
+		t.Error("expected the create error when the relation cannot be read either")
+	}
+}
+
+// failingCreate fails every mutation, as creating an existing relation does.
+type failingCreate struct{ *fakeBackend }
+
+func (f *failingCreate) Execute(context.Context, string) error {
+	return errors.New("conflicts with an existing one")
+}
+
+func TestRun_Limits(t *testing.T) {
+	backend := &fakeBackend{result: &storage.QueryResult{}}
+	loop := []byte("while True:\n    pass\n")
+	_, err := Run(context.Background(), backend, "loop.star", loop, Options{MaxSteps: 1000})
+	if err == nil || !strings.Contains(err.Error(), "too many steps") {
+		t.Errorf("step limit: %v", err)
+	}
+	_, err = Run(context.Background(), backend, "loop.star", loop, Options{Timeout: 20 * time.Millisecond})
+	if err == nil {
+		t.Error("expected timeout")
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package sigparse
+
+import "strings"
+
+// ParsePythonParams parses a Python function signature and returns the
+// annotated parameters with their base types. Unannotated parameters and the
+// self/cls receiver are omitted: they carry no type to dispatch on.
+//
+// It handles:
+//   - Annotated params: "repo: Repository" → {repo, Repository}
+//   - Defaults: "limit: int = 10" → {limit, int}
+//   - Optional types: "Optional[Repo]", "Repo | None" → "Repo"
+//   - Qualified and quoted types: "models.Repo", "'Repo'" → "Repo"
+//   - Star params: "*args: Repo" → {args, Repo}
+//   - Markers: "*" and "/" → skipped
+//
+// The signature should look like "def save(self, repo: Repo) -> None"
+// (optionally prefixed with "async").
+func ParsePythonParams(signature string) []ParamInfo {
+	idx := strings.Index(signature, "def ")
+	if idx == -1 {
+		return nil
+	}
+	open := strings.Index(signature[idx:], "(")
+	if open == -1 {
+		return nil
+	}
+	open += idx
+	end := findMatchingParen(signature, open)
+	if end == -1 {
+		return nil
+	}
+
+	var params []ParamInfo
+	for i, part := range splitPythonTopLevel(signature[open+1:end], ',') {
+		part = strings.TrimSpace(part)
+		name, annotation, typed := strings.Cut(part, ":")
+		name = strings.TrimLeft(strings.TrimSpace(name), "*")
+		if i == 0 && (name == "self" || name == "cls") {
+			continue
+		}
+		if !typed || name == "" {
+			continue
+		}
+		annotation = splitPythonTopLevel(annotation, '=')[0]
+		if t := NormalizePythonType(annotation); t != "" {
+			params = append(params, ParamInfo{Name: name, Type: t})
+		}
+	}
+	return params
+}
+
+// NormalizePythonType extracts the base class name from a Python annotation.
+//
+//	"Repo" → "Repo"
+//	"'Repo'" → "Repo"
+//	"models.Repo" → "Repo"
+//	"Optional[Repo]" → "Repo"
+//	"Repo | None" → "Repo"
+//	"Annotated[Repo, Depends()]" → "Repo"
+//	"list[Repo]" → "list"
+func NormalizePythonType(t string) string {
+	t = strings.Trim(strings.TrimSpace(t), `"'`)
+	if alts := splitPythonTopLevel(t, '|'); len(alts) > 1 {
+		for _, alt := range alts {
+			if alt = strings.TrimSpace(alt); alt != "None" {
+				return NormalizePythonType(alt)
+			}
+		}
+		return ""
+	}
+	if open := strings.Index(t, "["); open > 0 && strings.HasSuffix(t, "]") {
+		base := t[:open]
+		if dot := strings.LastIndex(base, "."); dot >= 0 {
+			base = base[dot+1:]
+		}
+		if base == "Optional" || base == "Annotated" {
+			return NormalizePythonType(splitPythonTopLevel(t[open+1:len(t)-1], ',')[0])
+		}
+		t = t[:open]
+	}
+	if dot := strings.LastIndex(t, "."); dot >= 0 {
+		t = t[dot+1:]
+	}
+	return strings.TrimSpace(t)
+}
+
+// splitPythonTopLevel splits s at sep outside brackets, parentheses, braces
+// and string literals.
+func splitPythonTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
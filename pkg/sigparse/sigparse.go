@@ -17,7 +17,7 @@
 //
 // SPDX-License-Identifier: AGPL-3.0-or-later
 
-// Package sigparse provides Go function signature parsing utilities.
+// Package sigparse provides Go and Python function signature parsing utilities.
 // It is a dependency-free package that can be imported by both
 // pkg/ingestion (for ingestion-time dispatch) and pkg/tools (for query-time dispatch).
 package sigparse
@@ -0,0 +1,205 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	cozo "github.com/kraklabs/cie/pkg/cozodb"
+)
+
+// auditSchema is the relation holding one row per MCP tool invocation.
+const auditSchema = `:create cie_audit_log { id: String => ts: Float, session: String, tool: String, args_hash: String, duration_ms: Int, rows: Int, is_error: Bool }`
+
+// AuditEntry records a single tool invocation.
+type AuditEntry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	SessionID  string    `json:"session_id"`
+	Tool       string    `json:"tool"`
+	ArgsHash   string    `json:"args_hash"`
+	DurationMS int64     `json:"duration_ms"`
+	Rows       int       `json:"rows"`
+	IsError    bool      `json:"is_error"`
+}
+
+// AuditFilter narrows the entries returned by AuditLog.Entries.
+type AuditFilter struct {
+	Tool    string    // exact tool name; empty for all tools
+	Session string    // exact session ID; empty for all sessions
+	Since   time.Time // zero for no lower bound
+	Limit   int       // 0 for no limit
+}
+
+// AuditLog stores tool invocations in a small CozoDB database kept apart
+// from the index. It uses the SQLite engine so `cie audit` can read the log
+// while an MCP server (which holds the index's RocksDB lock) keeps writing.
+type AuditLog struct {
+	db  *cozo.CozoDB
+	mu  sync.Mutex
+	seq int64
+}
+
+// DefaultAuditLogPath returns ~/.cie/audit/<project_id>/audit.db.
+// The log lives outside ~/.cie/data so `cie reset` does not erase it.
+func DefaultAuditLogPath(projectID string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home dir: %w", err)
+	}
+	return filepath.Join(home, ".cie", "audit", projectID, "audit.db"), nil
+}
+
+// OpenAuditLog opens (or creates) the audit log at path.
+func OpenAuditLog(path string) (*AuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("create audit dir: %w", err)
+	}
+	db, err := cozo.New("sqlite", path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	if _, err := db.Run(auditSchema, nil); err != nil {
+		errStr := err.Error()
+		if !strings.Contains(errStr, "already exists") && !strings.Contains(errStr, "conflicts with an existing one") {
+			db.Close()
+			return nil, fmt.Errorf("create audit relation: %w", err)
+		}
+	}
+	return &AuditLog{db: &db}, nil
+}
+
+// Record appends an entry to the log.
+func (a *AuditLog) Record(e AuditEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.seq++
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	params := map[string]any{
+		"id":          fmt.Sprintf("%s-%06d", e.SessionID, a.seq),
+		"ts":          float64(e.Timestamp.UnixNano()) / 1e9,
+		"session":     e.SessionID,
+		"tool":        e.Tool,
+		"args_hash":   e.ArgsHash,
+		"duration_ms": e.DurationMS,
+		"rows":        e.Rows,
+		"is_error":    e.IsError,
+	}
+	_, err := a.db.Run(`?[id, ts, session, tool, args_hash, duration_ms, rows, is_error] <- [[$id, $ts, $session, $tool, $args_hash, $duration_ms, $rows, $is_error]]
+		:put cie_audit_log { id => ts, session, tool, args_hash, duration_ms, rows, is_error }`, params)
+	if err != nil {
+		return fmt.Errorf("record audit entry: %w", err)
+	}
+	return nil
+}
+
+// Entries returns matching entries, newest first.
+func (a *AuditLog) Entries(filter AuditFilter) ([]AuditEntry, error) {
+	conditions := []string{"*cie_audit_log { ts, session, tool, args_hash, duration_ms, rows, is_error }"}
+	params := map[string]any{}
+	if filter.Tool != "" {
+		conditions = append(conditions, "tool == $tool")
+		params["tool"] = filter.Tool
+	}
+	if filter.Session != "" {
+		conditions = append(conditions, "session == $session")
+		params["session"] = filter.Session
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "ts >= $since")
+		params["since"] = float64(filter.Since.UnixNano()) / 1e9
+	}
+	script := "?[ts, session, tool, args_hash, duration_ms, rows, is_error] := " + strings.Join(conditions, ", ") + " :order -ts"
+	if filter.Limit > 0 {
+		script += fmt.Sprintf(" :limit %d", filter.Limit)
+	}
+
+	a.mu.Lock()
+	result, err := a.db.Run(script, params)
+	a.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+
+	entries := make([]AuditEntry, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		if len(row) < 7 {
+			continue
+		}
+		entries = append(entries, auditEntryFromRow(row))
+	}
+	return entries, nil
+}
+
+// Clear deletes every entry from the log.
+func (a *AuditLog) Clear() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err := a.db.Run(`?[id] := *cie_audit_log { id } :rm cie_audit_log { id }`, nil)
+	if err != nil {
+		return fmt.Errorf("clear audit log: %w", err)
+	}
+	return nil
+}
+
+// Close closes the audit database.
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.db != nil {
+		a.db.Close()
+		a.db = nil
+	}
+	return nil
+}
+
+func auditEntryFromRow(row []any) AuditEntry {
+	e := AuditEntry{}
+	if ts, ok := row[0].(float64); ok {
+		sec := int64(ts)
+		e.Timestamp = time.Unix(sec, int64((ts-float64(sec))*1e9))
+	}
+	e.SessionID, _ = row[1].(string)
+	e.Tool, _ = row[2].(string)
+	e.ArgsHash, _ = row[3].(string)
+	e.DurationMS = toInt64(row[4])
+	e.Rows = int(toInt64(row[5]))
+	e.IsError, _ = row[6].(bool)
+	return e
+}
+
+// toInt64 converts JSON-decoded CozoDB numbers (float64 or int64) to int64.
+func toInt64(v any) int64 {
+	switch n := v.(type) {
+	case float64:
+		return int64(n)
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	}
+	return 0
+}
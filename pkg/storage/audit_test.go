@@ -0,0 +1,84 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+//go:build cgo
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestAuditLog(t *testing.T) *AuditLog {
+	t.Helper()
+	audit, err := OpenAuditLog(filepath.Join(t.TempDir(), "audit.db"))
+	if err != nil {
+		t.Fatalf("OpenAuditLog: %v", err)
+	}
+	t.Cleanup(func() { _ = audit.Close() })
+	return audit
+}
+
+func TestAuditLog_RecordAndFilter(t *testing.T) {
+	audit := openTestAuditLog(t)
+	now := time.Now()
+
+	entries := []AuditEntry{
+		{Timestamp: now.Add(-2 * time.Hour), SessionID: "s1", Tool: "cie_grep", ArgsHash: "aa", DurationMS: 5, Rows: 3},
+		{Timestamp: now.Add(-time.Minute), SessionID: "s1", Tool: "cie_find_function", ArgsHash: "bb", DurationMS: 7, Rows: 1},
+		{Timestamp: now, SessionID: "s2", Tool: "cie_grep", ArgsHash: "cc", DurationMS: 9, IsError: true},
+	}
+	for _, e := range entries {
+		if err := audit.Record(e); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	all, err := audit.Entries(AuditFilter{})
+	if err != nil {
+		t.Fatalf("Entries: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("got %d entries, want 3", len(all))
+	}
+	if all[0].ArgsHash != "cc" || !all[0].IsError {
+		t.Errorf("newest entry = %+v, want error entry cc first", all[0])
+	}
+
+	grep, _ := audit.Entries(AuditFilter{Tool: "cie_grep"})
+	if len(grep) != 2 {
+		t.Errorf("tool filter: got %d, want 2", len(grep))
+	}
+	recent, _ := audit.Entries(AuditFilter{Since: now.Add(-time.Hour)})
+	if len(recent) != 2 {
+		t.Errorf("since filter: got %d, want 2", len(recent))
+	}
+	limited, _ := audit.Entries(AuditFilter{Session: "s1", Limit: 1})
+	if len(limited) != 1 || limited[0].ArgsHash != "bb" {
+		t.Errorf("session+limit filter: got %+v", limited)
+	}
+
+	if err := audit.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if left, _ := audit.Entries(AuditFilter{}); len(left) != 0 {
+		t.Errorf("after Clear: got %d entries", len(left))
+	}
+}
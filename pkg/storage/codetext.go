@@ -0,0 +1,95 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// CodeTextZstdPrefix marks a code_text value that holds zstd-compressed,
+// base64-encoded source rather than plain text. Values without the prefix
+// are returned unchanged by DecompressCodeText, so compressed and plain rows
+// can coexist in the same relation (e.g. after an incremental run with the
+// toggle flipped).
+const CodeTextZstdPrefix = "zstd:"
+
+// CodeCompressionMetaKey is the cie_project_meta key recording which codec
+// the last index run used for code_text ("zstd" or "none"). Readers check it
+// to decide whether code_text can be matched server-side with regex_matches.
+const CodeCompressionMetaKey = "code_compression"
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdInitErr error
+)
+
+func initZstd() {
+	zstdEncoder, zstdInitErr = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+	if zstdInitErr != nil {
+		return
+	}
+	zstdDecoder, zstdInitErr = zstd.NewReader(nil)
+}
+
+// CompressCodeText compresses source text for storage in code_text.
+// Empty input is returned as-is since there is nothing to gain.
+func CompressCodeText(text string) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	zstdOnce.Do(initZstd)
+	if zstdInitErr != nil {
+		return "", fmt.Errorf("init zstd: %w", zstdInitErr)
+	}
+	compressed := zstdEncoder.EncodeAll([]byte(text), nil)
+	return CodeTextZstdPrefix + base64.StdEncoding.EncodeToString(compressed), nil
+}
+
+// DecompressCodeText returns the plain source for a stored code_text value.
+// Plain (uncompressed) values pass through untouched.
+func DecompressCodeText(stored string) (string, error) {
+	if !IsCompressedCodeText(stored) {
+		return stored, nil
+	}
+	zstdOnce.Do(initZstd)
+	if zstdInitErr != nil {
+		return "", fmt.Errorf("init zstd: %w", zstdInitErr)
+	}
+	raw, err := base64.StdEncoding.DecodeString(stored[len(CodeTextZstdPrefix):])
+	if err != nil {
+		return "", fmt.Errorf("decode code_text: %w", err)
+	}
+	plain, err := zstdDecoder.DecodeAll(raw, nil)
+	if err != nil {
+		return "", fmt.Errorf("decompress code_text: %w", err)
+	}
+	return string(plain), nil
+}
+
+// IsCompressedCodeText reports whether a stored code_text value is compressed.
+func IsCompressedCodeText(stored string) bool {
+	return strings.HasPrefix(stored, CodeTextZstdPrefix)
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompressCodeText_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"empty", ""},
+		{"short", "func main() {}"},
+		{"unicode", "func héllo() string { return \"日本語\" }"},
+		{"large", strings.Repeat("if err != nil {\n\treturn err\n}\n", 500)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored, err := CompressCodeText(tt.text)
+			if err != nil {
+				t.Fatalf("CompressCodeText() error = %v", err)
+			}
+			if tt.text != "" && !IsCompressedCodeText(stored) {
+				t.Errorf("CompressCodeText() missing %q prefix: %q", CodeTextZstdPrefix, stored)
+			}
+			got, err := DecompressCodeText(stored)
+			if err != nil {
+				t.Fatalf("DecompressCodeText() error = %v", err)
+			}
+			if got != tt.text {
+				t.Errorf("round trip mismatch: got %q, want %q", got, tt.text)
+			}
+		})
+	}
+}
+
+func TestCompressCodeText_ShrinksRepetitiveCode(t *testing.T) {
+	text := strings.Repeat("if err != nil {\n\treturn err\n}\n", 500)
+	stored, err := CompressCodeText(text)
+	if err != nil {
+		t.Fatalf("CompressCodeText() error = %v", err)
+	}
+	if len(stored) >= len(text)/4 {
+		t.Errorf("expected significant compression, got %d bytes from %d", len(stored), len(text))
+	}
+}
+
+func TestDecompressCodeText_PlainPassthrough(t *testing.T) {
+	plain := "func Foo() { return }"
+	got, err := DecompressCodeText(plain)
+	if err != nil {
+		t.Fatalf("DecompressCodeText() error = %v", err)
+	}
+	if got != plain {
+		t.Errorf("DecompressCodeText() = %q, want %q", got, plain)
+	}
+}
+
+func TestDecompressCodeText_Corrupt(t *testing.T) {
+	if _, err := DecompressCodeText(CodeTextZstdPrefix + "not base64!!"); err == nil {
+		t.Error("expected error for invalid base64")
+	}
+	if _, err := DecompressCodeText(CodeTextZstdPrefix + "aGVsbG8="); err == nil {
+		t.Error("expected error for non-zstd payload")
+	}
+}
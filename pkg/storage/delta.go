@@ -0,0 +1,204 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	cozo "github.com/kraklabs/cie/pkg/cozodb"
+)
+
+// fileScope selects the rows of a relation that belong to the files bound
+// by the rule paths[path]. rule binds key, the relation's key column.
+type fileScope struct {
+	relation string
+	key      string
+	rule     string
+	keep     bool // exported, but never deleted when a file is re-indexed
+}
+
+// fileScopes mirrors DeleteEntitiesForFile: the rows an incremental run
+// deletes for a changed file, edges before the entities they join on. The
+// rows it writes back are the same ones, plus cie_implements, which
+// incremental runs add to but never delete from.
+var fileScopes = []fileScope{
+	{"cie_calls", "id", "*cie_calls{id, caller_id}, *cie_function{id: caller_id, file_path}, paths[file_path]", false},
+	{"cie_calls", "id", "*cie_calls{id, callee_id}, *cie_function{id: callee_id, file_path}, paths[file_path]", false},
+	{"cie_contains", "id", "*cie_contains{id, file_path}, paths[file_path]", false},
+	{"cie_contains", "id", "*cie_contains{id, parent_id}, *cie_type{id: parent_id, file_path}, paths[file_path]", false},
+	{"cie_method_of", "id", "*cie_method_of{id, file_path}, paths[file_path]", false},
+	{"cie_unresolved_call", "id", "*cie_unresolved_call{id, file_path}, paths[file_path]", false},
+	{"cie_field", "id", "*cie_field{id, file_path}, paths[file_path]", false},
+	{"cie_proto_option", "id", "*cie_proto_option{id, file_path}, paths[file_path]", false},
+	{"cie_generated_from", "id", "*cie_generated_from{id, file_path}, paths[file_path]", false},
+	{"cie_generated_from", "id", "*cie_generated_from{id, proto_type_id}, *cie_type{id: proto_type_id, file_path}, paths[file_path]", false},
+	{"cie_generated_file", "id", "*cie_generated_file{id, file_path}, paths[file_path]", false},
+	{"cie_generated_func", "id", "*cie_generated_func{id, file_path}, paths[file_path]", false},
+	{"cie_generated_func", "id", "*cie_generated_func{id, source_id}, *cie_function{id: source_id, file_path}, paths[file_path]", false},
+	{"cie_generated_func", "id", "*cie_generated_func{id, source_id}, *cie_type{id: source_id, file_path}, paths[file_path]", false},
+	{"cie_template_ref", "id", "*cie_template_ref{id, file_path}, paths[file_path]", false},
+	{"cie_template", "id", "*cie_template{id, file_path}, paths[file_path]", false},
+	{"cie_renders", "id", "*cie_renders{id, file_path}, paths[file_path]", false},
+	{"cie_ci_step", "id", "*cie_ci_step{id, file_path}, paths[file_path]", false},
+	{"cie_ci_ref", "id", "*cie_ci_ref{id, file_path}, paths[file_path]", false},
+	{"cie_ci_job", "id", "*cie_ci_job{id, file_path}, paths[file_path]", false},
+	{"cie_table_ref", "id", "*cie_table_ref{id, file_path}, paths[file_path]", false},
+	{"cie_rpc_link", "id", "*cie_rpc_link{id, file_path}, paths[file_path]", false},
+	{"cie_entry_point", "id", "*cie_entry_point{id, file_path}, paths[file_path]", false},
+	{"cie_defines", "id", "*cie_defines{id, file_id}, *cie_file{id: file_id, path}, paths[path]", false},
+	{"cie_defines_type", "id", "*cie_defines_type{id, file_id}, *cie_file{id: file_id, path}, paths[path]", false},
+	{"cie_function_embedding", "function_id", "*cie_function{id: function_id, file_path}, paths[file_path]", false},
+	{"cie_function_name_embedding", "function_id", "*cie_function{id: function_id, file_path}, paths[file_path]", false},
+	{"cie_function_code", "function_id", "*cie_function{id: function_id, file_path}, paths[file_path]", false},
+	{"cie_function_code_chunk", "function_id, chunk", "*cie_function_code_chunk{function_id, chunk}, *cie_function{id: function_id, file_path}, paths[file_path]", false},
+	{"cie_function_lang", "function_id", "*cie_function{id: function_id, file_path}, paths[file_path]", false},
+	{"cie_function", "id", "*cie_function{id, file_path}, paths[file_path]", false},
+	{"cie_type_embedding", "type_id", "*cie_type{id: type_id, file_path}, paths[file_path]", false},
+	{"cie_type_code", "type_id", "*cie_type{id: type_id, file_path}, paths[file_path]", false},
+	{"cie_type", "id", "*cie_type{id, file_path}, paths[file_path]", false},
+	{"cie_import", "id", "*cie_import{id, file_path}, paths[file_path]", false},
+	{"cie_file_content", "file_id", "*cie_file{id: file_id, path}, paths[path]", false},
+	{"cie_file", "id", "*cie_file{id, path}, paths[path]", false},
+	{"cie_implements", "id", "*cie_implements{id, file_path}, paths[file_path]", true},
+}
+
+// pathRows turns paths into the rows of the paths input relation.
+func pathRows(paths []string) [][]any {
+	rows := make([][]any, len(paths))
+	for i, p := range paths {
+		rows[i] = []any{p}
+	}
+	return rows
+}
+
+// ExportFileRows reads every row an index run derived from the files at
+// paths, by relation. Together with the list of paths it is a delta that
+// ApplyFileRows brings another copy of the index up to date with.
+// Relations without rows for the files are left out.
+func (b *EmbeddedBackend) ExportFileRows(ctx context.Context, paths []string) (map[string]cozo.NamedRows, error) {
+	if b.shards != nil {
+		return nil, fmt.Errorf("export: sharded storage is not supported")
+	}
+	params := map[string]any{"paths": pathRows(paths)}
+	out := make(map[string]cozo.NamedRows)
+	for _, rel := range Relations {
+		var rules []string
+		var key string
+		for _, s := range fileScopes {
+			if s.relation == rel.Name {
+				rules = append(rules, fmt.Sprintf("sel[%s] := %s", s.key, s.rule))
+				key = s.key
+			}
+		}
+		if rules == nil {
+			continue
+		}
+		cols := strings.Join(rel.Columns(), ", ")
+		script := fmt.Sprintf("paths[path] <- $paths\n%s\n?[%s] := sel[%s], *%s{%s}",
+			strings.Join(rules, "\n"), cols, key, rel.Name, cols)
+
+		rows, err := b.run(ctx, script, params, true)
+		if err != nil {
+			return nil, fmt.Errorf("export %s: %w", rel.Name, err)
+		}
+		if len(rows.Rows) > 0 {
+			out[rel.Name] = rows
+		}
+	}
+	return out, nil
+}
+
+// ApplyFileRows replaces what the index holds for the files at paths with
+// rows, as exported by ExportFileRows from an index of a later commit. The
+// deletes and writes run in one transaction, so a failed apply leaves the
+// index as it was. Rows are written with :put so vector indexes are kept
+// up to date.
+func (b *EmbeddedBackend) ApplyFileRows(ctx context.Context, paths []string, rows map[string]cozo.NamedRows) error {
+	if b.shards != nil {
+		return fmt.Errorf("apply: sharded storage is not supported")
+	}
+	for name := range rows {
+		if _, ok := LookupRelation(name); !ok {
+			return fmt.Errorf("apply: unknown relation %q", name)
+		}
+	}
+	var script strings.Builder
+	params := map[string]any{"paths": pathRows(paths)}
+	for _, s := range fileScopes {
+		if s.keep {
+			continue
+		}
+		fmt.Fprintf(&script, "{\npaths[path] <- $paths\n?[%s] := %s\n:rm %s {%s}\n}\n", s.key, s.rule, s.relation, s.key)
+	}
+	for i, rel := range Relations {
+		named, ok := rows[rel.Name]
+		if !ok || len(named.Rows) == 0 {
+			continue
+		}
+		if err := checkHeaders(rel, named.Headers); err != nil {
+			return err
+		}
+		param := fmt.Sprintf("rows%d", i) // named apart from relations, which qualify rewrites
+		params[param] = named.Rows
+		list := strings.Join(named.Headers, ", ")
+		fmt.Fprintf(&script, "{\n?[%s] <- $%s\n:put %s {%s}\n}\n", list, param, rel.Name, list)
+	}
+	if _, err := b.run(ctx, script.String(), params, false); err != nil {
+		return fmt.Errorf("apply: %w", err)
+	}
+	return nil
+}
+
+// checkHeaders reports an error unless headers names each column of rel
+// exactly once.
+func checkHeaders(rel Relation, headers []string) error {
+	want := rel.Columns()
+	if len(headers) != len(want) {
+		return fmt.Errorf("apply %s: got columns %v, want %v", rel.Name, headers, want)
+	}
+	seen := make(map[string]bool, len(headers))
+	for _, h := range headers {
+		if _, ok := rel.column(h); !ok || seen[h] {
+			return fmt.Errorf("apply %s: got columns %v, want %v", rel.Name, headers, want)
+		}
+		seen[h] = true
+	}
+	return nil
+}
+
+// run executes a parameterized script against the backend's own store.
+func (b *EmbeddedBackend) run(ctx context.Context, script string, params map[string]any, readOnly bool) (cozo.NamedRows, error) {
+	if err := b.handle.rlock(); err != nil {
+		return cozo.NamedRows{}, err
+	}
+	defer b.handle.runlock()
+
+	if err := ctx.Err(); err != nil {
+		return cozo.NamedRows{}, err
+	}
+	if readOnly {
+		return b.handle.db.RunReadOnly(b.qualify(script), params)
+	}
+	if b.handle.readOnly {
+		return cozo.NamedRows{}, errReadOnly
+	}
+	return b.handle.db.Run(b.qualify(script), params)
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+//go:build cgo
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cozo "github.com/kraklabs/cie/pkg/cozodb"
+)
+
+// newDeltaTestBackend opens an in-memory index holding files a.go and
+// b.go, with a call from b.go into a.go.
+func newDeltaTestBackend(t *testing.T) *EmbeddedBackend {
+	t.Helper()
+	b, err := NewEmbeddedBackend(EmbeddedConfig{DataDir: t.TempDir(), Engine: "mem", EmbeddingDimensions: 4})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = b.Close() })
+	require.NoError(t, b.EnsureSchema())
+
+	ctx := context.Background()
+	require.NoError(t, b.Put(ctx, "cie_file",
+		Row{"id": "file:a", "path": "a.go", "hash": "h1", "language": "go", "size": 10},
+		Row{"id": "file:b", "path": "b.go", "hash": "h2", "language": "go", "size": 20}))
+	require.NoError(t, b.Put(ctx, "cie_function",
+		fnRow("fn:a1", "A1", "a.go"), fnRow("fn:b1", "B1", "b.go")))
+	require.NoError(t, b.Put(ctx, "cie_function_embedding",
+		Row{"function_id": "fn:a1", "embedding": []float32{1, 0, 0, 0}}))
+	require.NoError(t, b.Put(ctx, "cie_calls", Row{"id": "call:b1|a1", "caller_id": "fn:b1", "callee_id": "fn:a1"}))
+	return b
+}
+
+func fnRow(id, name, path string) Row {
+	return Row{"id": id, "name": name, "signature": "func " + name + "()", "file_path": path,
+		"start_line": 1, "end_line": 3, "start_col": 0, "end_col": 1}
+}
+
+func functionNames(t *testing.T, b *EmbeddedBackend) []any {
+	t.Helper()
+	res, err := b.Query(context.Background(), "?[name] := *cie_function{name} :order name")
+	require.NoError(t, err)
+	var names []any
+	for _, row := range res.Rows {
+		names = append(names, row[0])
+	}
+	return names
+}
+
+func TestExportApplyFileRows(t *testing.T) {
+	ctx := context.Background()
+	source := newDeltaTestBackend(t)
+	target := newDeltaTestBackend(t)
+
+	// a.go changes on the source: A1 is replaced by A2.
+	require.NoError(t, source.DeleteEntitiesForFile("a.go"))
+	require.NoError(t, source.Put(ctx, "cie_file", Row{"id": "file:a", "path": "a.go", "hash": "h3", "language": "go", "size": 12}))
+	require.NoError(t, source.Put(ctx, "cie_function", fnRow("fn:a2", "A2", "a.go")))
+	require.NoError(t, source.Put(ctx, "cie_function_embedding",
+		Row{"function_id": "fn:a2", "embedding": []float32{0, 1, 0, 0}}))
+
+	rows, err := source.ExportFileRows(ctx, []string{"a.go"})
+	require.NoError(t, err)
+	assert.Len(t, rows["cie_function"].Rows, 1)
+	assert.NotContains(t, rows, "cie_calls", "the call into A1 went with it")
+
+	// The delta survives a JSON round trip, as it does in a delta bundle.
+	data, err := json.Marshal(rows)
+	require.NoError(t, err)
+	var decoded map[string]cozo.NamedRows
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	require.NoError(t, target.ApplyFileRows(ctx, []string{"a.go"}, decoded))
+	assert.Equal(t, []any{"A2", "B1"}, functionNames(t, target))
+
+	res, err := target.Query(ctx, "?[count(id)] := *cie_calls{id}")
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, res.Rows[0][0])
+	res, err = target.Query(ctx, "?[function_id] := *cie_function_embedding{function_id}")
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{"fn:a2"}}, res.Rows)
+	res, err = target.Query(ctx, `?[hash, size] := *cie_file{path: "a.go", hash, size}`)
+	require.NoError(t, err)
+	assert.Equal(t, "h3", res.Rows[0][0])
+	assert.EqualValues(t, 12, res.Rows[0][1])
+}
+
+func TestApplyFileRows_RejectsWrongColumns(t *testing.T) {
+	b := newDeltaTestBackend(t)
+	err := b.ApplyFileRows(context.Background(), []string{"a.go"}, map[string]cozo.NamedRows{
+		"cie_function": {Headers: []string{"id", "name"}, Rows: [][]any{{"fn:x", "X"}}},
+	})
+	require.Error(t, err)
+	assert.Equal(t, []any{"A1", "B1"}, functionNames(t, b), "a rejected delta changes nothing")
+}
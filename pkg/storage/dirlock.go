@@ -0,0 +1,159 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// Processes coordinate access to a data directory through two advisory
+// locks next to it, since RocksDB lets only one process open it at a time:
+//
+//   - <dir>.lock is held exclusively by whichever process has the database
+//     open.
+//   - <dir>.writer is held shared by processes that open the database for
+//     good (`cie index` and other commands) from the moment they start
+//     waiting until they close it. Shared backends (EmbeddedConfig.Shared),
+//     such as the MCP server's, check it after every request and give the
+//     database up while it is held.
+//
+// The locks are flock(2) locks, so they go away with the process holding
+// them and a crash never leaves the directory locked.
+
+// ErrDataDirLocked is returned when another process keeps a data directory
+// open for longer than the lock timeout, and by a shared backend while an
+// index run is writing.
+var ErrDataDirLocked = errors.New("data directory is locked by another CIE process")
+
+// DefaultLockTimeout is how long opening a data directory waits for other
+// processes to release it when EmbeddedConfig.LockTimeout is not set.
+const DefaultLockTimeout = 30 * time.Second
+
+// lockPollInterval is how often a waiting process retries a held lock.
+const lockPollInterval = 50 * time.Millisecond
+
+// LockPath returns the lock file held by the process that has dataDir open.
+func LockPath(dataDir string) string {
+	return filepath.Clean(dataDir) + ".lock"
+}
+
+// writerPath returns the lock file announcing processes that want dataDir
+// for good.
+func writerPath(dataDir string) string {
+	return filepath.Clean(dataDir) + ".writer"
+}
+
+// DataDirLock is held by a process that has a data directory to itself.
+type DataDirLock struct {
+	lock   *os.File
+	writer *os.File
+}
+
+// LockDataDir announces a writer on dataDir, so shared backends of other
+// processes release it, and waits up to timeout for it to be free. Work on
+// the directory that bypasses NewEmbeddedBackend, such as swapping in a
+// rebuilt index, holds this lock for its duration.
+func LockDataDir(dataDir string, timeout time.Duration) (*DataDirLock, error) {
+	if timeout <= 0 {
+		timeout = DefaultLockTimeout
+	}
+	writer, err := tryFlock(writerPath(dataDir), syscall.LOCK_SH)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := waitFlock(LockPath(dataDir), timeout)
+	if err != nil {
+		_ = writer.Close()
+		return nil, err
+	}
+	return &DataDirLock{lock: lock, writer: writer}, nil
+}
+
+// Unlock releases the data directory. It is safe to call on a nil lock.
+func (l *DataDirLock) Unlock() {
+	if l == nil {
+		return
+	}
+	if l.lock != nil {
+		_ = l.lock.Close()
+	}
+	if l.writer != nil {
+		_ = l.writer.Close()
+	}
+}
+
+// writerWaiting reports whether another process holds or waits for
+// dataDir through LockDataDir.
+func writerWaiting(dataDir string) bool {
+	f, err := tryFlock(writerPath(dataDir), syscall.LOCK_EX)
+	if err != nil {
+		return errors.Is(err, ErrDataDirLocked)
+	}
+	_ = f.Close()
+	return false
+}
+
+// waitForWriter waits up to timeout until no process holds or waits for
+// dataDir through LockDataDir. It returns ErrDataDirLocked if one still
+// does then.
+func waitForWriter(dataDir string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for writerWaiting(dataDir) {
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("%w: an index run is still writing to it after %s; retry when it finishes", ErrDataDirLocked, timeout)
+		}
+		time.Sleep(lockPollInterval)
+	}
+	return nil
+}
+
+// tryFlock opens path, creating it if needed, and takes a flock of kind
+// without blocking. It returns ErrDataDirLocked when another process holds
+// a conflicting lock. Closing the file releases the lock.
+func tryFlock(path string, kind int) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), kind|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, ErrDataDirLocked
+		}
+		return nil, fmt.Errorf("lock %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// waitFlock takes an exclusive flock on path, retrying for up to timeout.
+func waitFlock(path string, timeout time.Duration) (*os.File, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := tryFlock(path, syscall.LOCK_EX)
+		if !errors.Is(err, ErrDataDirLocked) || !time.Now().Before(deadline) {
+			return f, err
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
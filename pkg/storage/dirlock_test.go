@@ -0,0 +1,108 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestLockDataDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "proj")
+
+	if writerWaiting(dir) {
+		t.Fatal("no writer should be reported before anyone locks")
+	}
+	first, err := LockDataDir(dir, time.Second)
+	if err != nil {
+		t.Fatalf("LockDataDir: %v", err)
+	}
+	if !writerWaiting(dir) {
+		t.Error("a held lock should be reported to shared backends")
+	}
+
+	start := time.Now()
+	if _, err := LockDataDir(dir, 200*time.Millisecond); !errors.Is(err, ErrDataDirLocked) {
+		t.Fatalf("second LockDataDir = %v, want ErrDataDirLocked", err)
+	}
+	if waited := time.Since(start); waited < 200*time.Millisecond {
+		t.Errorf("second LockDataDir gave up after %v, want the full timeout", waited)
+	}
+
+	first.Unlock()
+	if writerWaiting(dir) {
+		t.Error("Unlock should withdraw the writer")
+	}
+	second, err := LockDataDir(dir, time.Second)
+	if err != nil {
+		t.Fatalf("LockDataDir after Unlock: %v", err)
+	}
+	second.Unlock()
+}
+
+func TestLockDataDir_WaitsForRelease(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "proj")
+
+	// A shared backend holds only the open lock, not the writer lock.
+	held, err := tryFlock(LockPath(dir), syscall.LOCK_EX)
+	if err != nil {
+		t.Fatalf("tryFlock: %v", err)
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = held.Close()
+	}()
+
+	lock, err := LockDataDir(dir, 2*time.Second)
+	if err != nil {
+		t.Fatalf("LockDataDir should get the directory once it is released: %v", err)
+	}
+	lock.Unlock()
+}
+
+func TestWaitForWriter(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "proj")
+	if err := waitForWriter(dir, 0); err != nil {
+		t.Fatalf("waitForWriter without a writer = %v", err)
+	}
+
+	writer, err := LockDataDir(dir, time.Second)
+	if err != nil {
+		t.Fatalf("LockDataDir: %v", err)
+	}
+	if err := waitForWriter(dir, 100*time.Millisecond); !errors.Is(err, ErrDataDirLocked) {
+		t.Fatalf("waitForWriter with a writer = %v, want ErrDataDirLocked", err)
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		writer.Unlock()
+	}()
+	if err := waitForWriter(dir, 2*time.Second); err != nil {
+		t.Fatalf("waitForWriter should return once the writer finishes: %v", err)
+	}
+}
+
+func TestDataDirLock_UnlockNil(t *testing.T) {
+	var lock *DataDirLock
+	lock.Unlock() // must not panic
+}
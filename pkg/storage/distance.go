@@ -0,0 +1,104 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Distance metrics for the HNSW vector indexes, as set by embedding.distance.
+// Embeddings are stored L2-normalized, so the three rank alike; they differ
+// in index build and search cost, and in the scale of the distances CozoDB
+// reports (see CosineDistanceScale).
+const (
+	DistanceCosine = "cosine"
+	DistanceL2     = "l2"
+	DistanceIP     = "ip"
+)
+
+// HNSWDistanceMetaKey is the cie_project_meta key recording the metric the
+// vector indexes were created with. Indexes created before it was recorded
+// use cosine.
+const HNSWDistanceMetaKey = "hnsw_distance"
+
+// NormTolerance is how far the squared length of a stored embedding may be
+// from 1 before it counts as not normalized.
+const NormTolerance = 1e-3
+
+// ErrHNSWDistanceMismatch is returned by CreateHNSWIndex when the vector
+// indexes exist with another metric than the one configured. An HNSW index
+// cannot change metric in place; it has to be rebuilt.
+var ErrHNSWDistanceMismatch = errors.New("vector index distance mismatch")
+
+// ParseHNSWDistance validates a configured metric name. An empty name is
+// returned as is and means "keep the metric of the existing indexes, or
+// cosine for new ones".
+func ParseHNSWDistance(name string) (string, error) {
+	switch metric := strings.ToLower(strings.TrimSpace(name)); metric {
+	case "", DistanceCosine, DistanceL2, DistanceIP:
+		return metric, nil
+	default:
+		return "", fmt.Errorf("unknown distance %q (want cosine, l2 or ip)", name)
+	}
+}
+
+// CosineDistanceScale returns the factor that converts a distance reported
+// by an index with the given metric to the cosine scale the tools use, from
+// 0 (same direction) to 2 (opposite). For unit vectors CozoDB's IP distance,
+// 1 - a·b, already equals the cosine distance, and its squared L2 distance,
+// 2 - 2a·b, is twice it.
+func CosineDistanceScale(metric string) float64 {
+	if metric == DistanceL2 {
+		return 0.5
+	}
+	return 1
+}
+
+// cozoDistance is the CozoDB name of metric.
+func cozoDistance(metric string) string {
+	switch metric {
+	case DistanceL2:
+		return "L2"
+	case DistanceIP:
+		return "IP"
+	default:
+		return "Cosine"
+	}
+}
+
+// NormalizeVector returns v scaled to unit length, and false when v is all
+// zeros and has no direction to keep. v itself is not modified.
+func NormalizeVector[T ~float32 | ~float64](v []T) ([]T, bool) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v, false
+	}
+	norm := math.Sqrt(sum)
+	out := make([]T, len(v))
+	for i, x := range v {
+		out[i] = T(float64(x) / norm)
+	}
+	return out, true
+}
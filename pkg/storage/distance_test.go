@@ -0,0 +1,62 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseHNSWDistance(t *testing.T) {
+	for in, want := range map[string]string{"": "", "cosine": DistanceCosine, " L2 ": DistanceL2, "IP": DistanceIP} {
+		got, err := ParseHNSWDistance(in)
+		if err != nil || got != want {
+			t.Errorf("ParseHNSWDistance(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseHNSWDistance("manhattan"); err == nil {
+		t.Error("ParseHNSWDistance accepted an unknown metric")
+	}
+}
+
+func TestCosineDistanceScale(t *testing.T) {
+	// Two unit vectors at 60 degrees: cosine distance 0.5.
+	a, b := []float64{1, 0}, []float64{0.5, math.Sqrt(3) / 2}
+	dot := a[0]*b[0] + a[1]*b[1]
+	l2 := (a[0]-b[0])*(a[0]-b[0]) + (a[1]-b[1])*(a[1]-b[1])
+	for metric, d := range map[string]float64{DistanceCosine: 1 - dot, DistanceIP: 1 - dot, DistanceL2: l2} {
+		if got := d * CosineDistanceScale(metric); math.Abs(got-0.5) > 1e-9 {
+			t.Errorf("%s: scaled distance = %v, want 0.5", metric, got)
+		}
+	}
+}
+
+func TestNormalizeVector(t *testing.T) {
+	in := []float32{3, 4}
+	out, ok := NormalizeVector(in)
+	if !ok || math.Abs(float64(out[0])-0.6) > 1e-6 || math.Abs(float64(out[1])-0.8) > 1e-6 {
+		t.Errorf("NormalizeVector([3 4]) = %v, %v", out, ok)
+	}
+	if in[0] != 3 {
+		t.Error("NormalizeVector modified its input")
+	}
+	if _, ok := NormalizeVector([]float64{0, 0}); ok {
+		t.Error("NormalizeVector reported a zero vector as normalized")
+	}
+}
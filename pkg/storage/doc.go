@@ -89,6 +89,21 @@
 //	// Mutation (uses Run internally)
 //	err := backend.Execute(ctx, `:rm cie_function { id: "fn123" }`)
 //
+// # Writing Rows
+//
+// Put, Upsert and Delete write rows of a CIE relation without hand-built
+// scripts. Rows are checked against Relations and passed as parameters, so
+// no quoting is involved:
+//
+//	// Replace whole rows; an existing key is overwritten, not an error
+//	err := backend.Put(ctx, "cie_project_meta", storage.Row{"key": "k", "value": "v"})
+//
+//	// Change some columns; the others keep their stored values
+//	err = backend.Upsert(ctx, "cie_function", storage.Row{"id": "fn123", "end_line": 42})
+//
+//	// Remove by key
+//	err = backend.Delete(ctx, "cie_calls", storage.Row{"id": "call:a|b"})
+//
 // # Configuration
 //
 // EmbeddedConfig controls the backend behavior:
@@ -103,6 +118,24 @@
 //   - DataDir: ~/.cie/data/<project_id>
 //   - Engine: "rocksdb" (recommended for production)
 //
+// # Sharding
+//
+// With EmbeddedConfig.Sharded, each top-level directory of the repository
+// gets its own store under DataDir/shards. Write a directory's entities
+// through Shard(ShardFor(path)); Query runs on every store and merges the
+// rows: aggregates are combined and :order, :offset and :limit apply to the
+// whole index, but joins stay within one shard. DropShard removes a store so
+// its directory can be reindexed alone.
+//
+// # Snapshots
+//
+// Snapshot saves a CozoDB backup of the database under DataDir/snapshots,
+// usually named after the commit just indexed. OpenSnapshot opens one again
+// as a read-only backend, so tools can answer questions about the index as
+// it was at that commit:
+//
+//	past, err := storage.OpenSnapshot(storage.EmbeddedConfig{DataDir: dir}, "3f2c9e1")
+//
 // # Thread Safety
 //
 // EmbeddedBackend is safe for concurrent use. Read operations use a read
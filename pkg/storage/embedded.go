@@ -21,22 +21,49 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	cozo "github.com/kraklabs/cie/pkg/cozodb"
 )
 
 // EmbeddedBackend implements Backend using a local CozoDB instance.
 // This is the default backend for standalone/open-source CIE.
+//
+// Several projects can share one database: each project's relations are
+// prefixed with its namespace (see EmbeddedConfig.Namespace and WithNamespace).
+//
+// A sharded backend (EmbeddedConfig.Sharded) keeps each top-level directory
+// of the repository in its own store; see Shard.
 type EmbeddedBackend struct {
-	db                  *cozo.CozoDB
-	mu                  sync.RWMutex
-	closed              bool
+	handle              *dbHandle
+	namespace           string
+	view                bool // true for WithNamespace views; Close is a no-op
 	embeddingDimensions int
+	hnswDistance        string    // configured metric; "" keeps the existing one
+	shards              *shardSet // nil unless sharded
+}
+
+// dbHandle is the CozoDB instance shared by a backend and its namespace views.
+type dbHandle struct {
+	db       *cozo.CozoDB
+	mu       sync.RWMutex
+	closed   bool
+	dataDir  string // "" for databases opened by the caller
+	readOnly bool   // snapshots; see OpenSnapshot
+
+	// dirLock is held while the database is open; nil for databases
+	// opened by the caller, snapshots and the mem engine.
+	dirLock *DataDirLock
+
+	// shared is set for EmbeddedConfig.Shared. db is nil while released.
+	shared *sharedOpen
 }
 
 // EmbeddedConfig configures the embedded backend.
@@ -55,10 +82,57 @@ type EmbeddedConfig struct {
 	// EmbeddingDimensions is the vector size for embeddings.
 	// Defaults to 768 (nomic-embed-text). Use 1536 for OpenAI.
 	EmbeddingDimensions int
+
+	// HNSWDistance is the metric CreateHNSWIndex builds the vector indexes
+	// with: DistanceCosine, DistanceL2 or DistanceIP. Empty keeps the metric
+	// of existing indexes and uses cosine for new ones.
+	HNSWDistance string
+
+	// Namespace isolates this project inside a database shared with other
+	// projects. When set, every cie_* relation is stored as <ns>__cie_*.
+	// Leave empty for the default one-database-per-project layout.
+	Namespace string
+
+	// Sharded stores each top-level directory of the repository in its own
+	// CozoDB store under DataDir/shards/<dir>, keeping root-level files and
+	// project metadata in the main store. Queries run against every store
+	// and concatenate the rows, so a store only scans its own directory and
+	// one directory can be reindexed without touching the others. Joins do
+	// not cross shards: a call into another top-level directory is stored
+	// with the caller but its callee is not visible to the same query.
+	Sharded bool
+
+	// Shared lets other processes open the database between requests, for
+	// long-running readers such as the MCP server. The database is opened
+	// by the first request, closed again after SharedIdle without requests
+	// or as soon as `cie index` waits for it, and reopened by the next
+	// request. While an index run holds it, requests wait up to LockTimeout
+	// for it to finish, then fail with ErrDataDirLocked. Ignored by the mem
+	// engine.
+	Shared bool
+
+	// SharedIdle is how long a shared backend keeps the database open after
+	// the last request. Defaults to DefaultSharedIdle.
+	SharedIdle time.Duration
+
+	// LockTimeout is how long opening a backend that is not shared waits
+	// for other processes to release the data directory (see LockDataDir),
+	// and how long a request to a shared backend waits for an index run.
+	// Defaults to DefaultLockTimeout.
+	LockTimeout time.Duration
 }
 
+// SQLiteFileName is the database file created inside DataDir when Engine is
+// "sqlite".
+const SQLiteFileName = "cie.sqlite"
+
 // NewEmbeddedBackend creates a new embedded CozoDB backend.
 func NewEmbeddedBackend(config EmbeddedConfig) (*EmbeddedBackend, error) {
+	distance, err := ParseHNSWDistance(config.HNSWDistance)
+	if err != nil {
+		return nil, err
+	}
+
 	// Set defaults
 	if config.Engine == "" {
 		config.Engine = "rocksdb"
@@ -79,10 +153,14 @@ func NewEmbeddedBackend(config EmbeddedConfig) (*EmbeddedBackend, error) {
 		return nil, fmt.Errorf("create data dir: %w", err)
 	}
 
-	// Open CozoDB
-	db, err := cozo.New(config.Engine, config.DataDir, nil)
+	// Open CozoDB. RocksDB owns the whole directory; SQLite needs a file in it.
+	dbPath := config.DataDir
+	if config.Engine == "sqlite" {
+		dbPath = filepath.Join(config.DataDir, SQLiteFileName)
+	}
+	handle, err := openHandle(config, dbPath)
 	if err != nil {
-		return nil, fmt.Errorf("open cozodb: %w", err)
+		return nil, err
 	}
 
 	// Default embedding dimensions to 768 (nomic-embed-text)
@@ -91,20 +169,102 @@ func NewEmbeddedBackend(config EmbeddedConfig) (*EmbeddedBackend, error) {
 		embeddingDim = 768
 	}
 
+	backend := &EmbeddedBackend{
+		handle:              handle,
+		namespace:           NormalizeNamespace(config.Namespace),
+		embeddingDimensions: embeddingDim,
+		hnswDistance:        distance,
+	}
+	if config.Sharded {
+		config.EmbeddingDimensions = embeddingDim
+		shards, err := openShards(config)
+		if err != nil {
+			_ = backend.Close()
+			return nil, err
+		}
+		backend.shards = shards
+	}
+	return backend, nil
+}
+
+// openHandle opens the database at dbPath under the data directory lock.
+// A shared handle starts released when an index run holds the directory.
+func openHandle(config EmbeddedConfig, dbPath string) (*dbHandle, error) {
+	if config.Engine == "mem" {
+		db, err := cozo.New(config.Engine, dbPath, nil)
+		if err != nil {
+			return nil, fmt.Errorf("open cozodb: %w", err)
+		}
+		return &dbHandle{db: &db, dataDir: config.DataDir}, nil
+	}
+
+	if config.Shared {
+		idle := config.SharedIdle
+		if idle <= 0 {
+			idle = DefaultSharedIdle
+		}
+		lockTimeout := config.LockTimeout
+		if lockTimeout <= 0 {
+			lockTimeout = DefaultLockTimeout
+		}
+		handle := &dbHandle{
+			dataDir: config.DataDir,
+			shared:  &sharedOpen{engine: config.Engine, dbPath: dbPath, idle: idle, lockTimeout: lockTimeout},
+		}
+		// Do not hold up startup for an index run; the first request waits.
+		if err := handle.reopenLocked(0); err != nil && !errors.Is(err, ErrDataDirLocked) {
+			return nil, err
+		}
+		handle.used()
+		return handle, nil
+	}
+
+	lock, err := LockDataDir(config.DataDir, config.LockTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("lock data dir: %w", err)
+	}
+	db, err := cozo.New(config.Engine, dbPath, nil)
+	if err != nil {
+		lock.Unlock()
+		return nil, fmt.Errorf("open cozodb: %w", err)
+	}
+	return &dbHandle{db: &db, dataDir: config.DataDir, dirLock: lock}, nil
+}
+
+// NewEmbeddedBackendFromDB wraps a database the caller already has open, such
+// as the one `cie serve` queries, so an index run can write through it while
+// queries continue. Only Namespace, EmbeddingDimensions and HNSWDistance are
+// read from config. Closing the returned backend does not close db.
+func NewEmbeddedBackendFromDB(db *cozo.CozoDB, config EmbeddedConfig) *EmbeddedBackend {
+	embeddingDim := config.EmbeddingDimensions
+	if embeddingDim <= 0 {
+		embeddingDim = 768
+	}
 	return &EmbeddedBackend{
-		db:                  &db,
+		handle:              &dbHandle{db: db},
+		namespace:           NormalizeNamespace(config.Namespace),
+		view:                true,
 		embeddingDimensions: embeddingDim,
-	}, nil
+		hnswDistance:        config.HNSWDistance,
+	}
 }
 
 // Query executes a read-only Datalog query.
 func (b *EmbeddedBackend) Query(ctx context.Context, datalog string) (*QueryResult, error) {
-	b.mu.RLock()
-	defer b.mu.RUnlock()
+	return b.QueryWithParams(ctx, datalog, nil)
+}
 
-	if b.closed {
-		return nil, fmt.Errorf("backend is closed")
+// QueryWithParams executes a read-only Datalog query with params bound to
+// its $name placeholders. Values are passed to CozoDB as data, never
+// spliced into the script.
+func (b *EmbeddedBackend) QueryWithParams(ctx context.Context, datalog string, params map[string]any) (*QueryResult, error) {
+	if b.shards != nil {
+		return b.queryShards(ctx, datalog, params)
+	}
+	if err := b.handle.rlock(); err != nil {
+		return nil, err
 	}
+	defer b.handle.runlock()
 
 	// Check context cancellation
 	select {
@@ -113,7 +273,7 @@ func (b *EmbeddedBackend) Query(ctx context.Context, datalog string) (*QueryResu
 	default:
 	}
 
-	result, err := b.db.RunReadOnly(datalog, nil)
+	result, err := b.handle.db.RunReadOnly(b.qualify(datalog), params)
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
@@ -122,13 +282,23 @@ func (b *EmbeddedBackend) Query(ctx context.Context, datalog string) (*QueryResu
 }
 
 // Execute runs a Datalog mutation.
+// On a sharded backend it writes to the main store; use Shard to write the
+// entities of a top-level directory.
+//
+// Like Query it only holds the handle's read lock, which guards against
+// Close: CozoDB isolates concurrent transactions itself, so queries keep
+// running while an index run writes.
 func (b *EmbeddedBackend) Execute(ctx context.Context, datalog string) error {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	return b.ExecuteWithParams(ctx, datalog, nil)
+}
 
-	if b.closed {
-		return fmt.Errorf("backend is closed")
+// ExecuteWithParams runs a Datalog mutation with params bound to its $name
+// placeholders, like QueryWithParams.
+func (b *EmbeddedBackend) ExecuteWithParams(ctx context.Context, datalog string, params map[string]any) error {
+	if err := b.handle.rlock(); err != nil {
+		return err
 	}
+	defer b.handle.runlock()
 
 	// Check context cancellation
 	select {
@@ -137,7 +307,11 @@ func (b *EmbeddedBackend) Execute(ctx context.Context, datalog string) error {
 	default:
 	}
 
-	_, err := b.db.Run(datalog, nil)
+	if b.handle.readOnly {
+		return errReadOnly
+	}
+
+	_, err := b.handle.db.Run(b.qualify(datalog), params)
 	if err != nil {
 		return fmt.Errorf("execute failed: %w", err)
 	}
@@ -145,24 +319,75 @@ func (b *EmbeddedBackend) Execute(ctx context.Context, datalog string) error {
 	return nil
 }
 
+// Import bulk-loads rows into stored relations in one transaction,
+// bypassing Datalog script generation. Relation names are unqualified; the
+// backend's namespace is applied. Like Execute, it writes to the main store
+// only — import into a shard through Shard.
+func (b *EmbeddedBackend) Import(ctx context.Context, relations map[string]cozo.NamedRows) error {
+	if err := b.handle.rlock(); err != nil {
+		return err
+	}
+	defer b.handle.runlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	if b.handle.readOnly {
+		return errReadOnly
+	}
+
+	qualified := make(map[string]cozo.NamedRows, len(relations))
+	for rel, rows := range relations {
+		qualified[QualifiedRelation(rel, b.namespace)] = rows
+	}
+	if err := b.handle.db.Import(qualified); err != nil {
+		return fmt.Errorf("import failed: %w", err)
+	}
+	return nil
+}
+
 // Close closes the database connection.
+// Closing a namespace view does nothing; close the backend it came from.
 func (b *EmbeddedBackend) Close() error {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	b.handle.mu.Lock()
+	defer b.handle.mu.Unlock()
 
-	if b.closed {
+	if b.view || b.handle.closed {
 		return nil
 	}
 
-	b.closed = true
-	b.db.Close()
+	if b.shards != nil {
+		b.shards.closeAll()
+	}
+	b.handle.closed = true
+	if b.handle.shared != nil {
+		b.handle.shared.mu.Lock()
+		if b.handle.shared.timer != nil {
+			b.handle.shared.timer.Stop()
+		}
+		b.handle.shared.mu.Unlock()
+	}
+	if b.handle.db != nil {
+		b.handle.db.Close()
+	}
+	b.handle.dirLock.Unlock()
 	return nil
 }
 
 // DB returns the underlying CozoDB instance for advanced operations.
 // Use with caution - prefer the Backend interface methods.
+// Scripts sent directly to it are not namespace-qualified. It is nil while
+// a shared backend is released.
 func (b *EmbeddedBackend) DB() *cozo.CozoDB {
-	return b.db
+	return b.handle.db
+}
+
+// EmbeddingDimensions returns the vector size used for embedding relations.
+func (b *EmbeddedBackend) EmbeddingDimensions() int {
+	return b.embeddingDimensions
 }
 
 // EnsureSchema creates the CIE tables if they don't exist.
@@ -175,31 +400,18 @@ func (b *EmbeddedBackend) EnsureSchema() error {
 	}
 
 	// Create each table individually, ignoring "already exists" errors
-	tables := []string{
-		`:create cie_file { id: String => path: String, hash: String, language: String, size: Int }`,
-		`:create cie_function { id: String => name: String, signature: String, file_path: String, start_line: Int, end_line: Int, start_col: Int, end_col: Int }`,
-		`:create cie_function_code { function_id: String => code_text: String }`,
-		fmt.Sprintf(`:create cie_function_embedding { function_id: String => embedding: <F32; %d> }`, dim),
-		`:create cie_defines { id: String => file_id: String, function_id: String }`,
-		`:create cie_calls { id: String => caller_id: String, callee_id: String }`,
-		`:create cie_import { id: String => file_path: String, import_path: String, alias: String, start_line: Int }`,
-		`:create cie_type { id: String => name: String, kind: String, file_path: String, start_line: Int, end_line: Int, start_col: Int, end_col: Int }`,
-		`:create cie_type_code { type_id: String => code_text: String }`,
-		fmt.Sprintf(`:create cie_type_embedding { type_id: String => embedding: <F32; %d> }`, dim),
-		`:create cie_defines_type { id: String => file_id: String, type_id: String }`,
-		// Struct field entities for interface dispatch resolution
-		`:create cie_field { id: String => struct_name: String, field_name: String, field_type: String, file_path: String, line: Int }`,
-		// Implements edges: concrete type -> interface
-		`:create cie_implements { id: String => type_name: String, interface_name: String, file_path: String }`,
-		// Project metadata for incremental indexing
-		`:create cie_project_meta { key: String => value: String }`,
-	}
-
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	tables := make([]string, len(Relations))
+	for i, rel := range Relations {
+		tables[i] = rel.createScript(dim)
+	}
+
+	if err := b.handle.lock(); err != nil {
+		return err
+	}
+	defer b.handle.unlock()
 
 	for _, table := range tables {
-		_, err := b.db.Run(table, nil)
+		_, err := b.handle.db.Run(b.qualify(table), nil)
 		if err != nil {
 			// Ignore "already exists" errors, but log others
 			errStr := err.Error()
@@ -218,39 +430,74 @@ func (b *EmbeddedBackend) EnsureSchema() error {
 // CreateHNSWIndex creates HNSW indexes for semantic search.
 // Should be called after schema creation.
 // dimensions: embedding vector size (768 for nomic-embed-text, 1536 for OpenAI)
+//
+// The indexes use the configured distance metric (EmbeddedConfig.HNSWDistance),
+// which is recorded under HNSWDistanceMetaKey so queries can read distances
+// on the right scale. When the function index already exists with another
+// metric, nothing is created and ErrHNSWDistanceMismatch is returned.
 func (b *EmbeddedBackend) CreateHNSWIndex(dimensions int) error {
 	if dimensions <= 0 {
 		dimensions = 768 // default for nomic-embed-text
 	}
-	// Use Cosine distance for semantic similarity (returns 0-2, where 0 = identical)
-	indexes := []string{
-		fmt.Sprintf(`::hnsw create cie_function_embedding:embedding_idx { dim: %d, m: 16, ef_construction: 200, distance: Cosine, fields: [embedding] }`, dimensions),
-		fmt.Sprintf(`::hnsw create cie_type_embedding:embedding_idx { dim: %d, m: 16, ef_construction: 200, distance: Cosine, fields: [embedding] }`, dimensions),
-	}
-
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	stored, _ := b.GetProjectMeta(HNSWDistanceMetaKey)
+	metric := b.hnswDistance
 
-	for _, idx := range indexes {
-		_, err := b.db.Run(idx, nil)
-		if err != nil {
-			// Ignore "already exists" errors
-			continue
+	if err := b.handle.lock(); err != nil {
+		return err
+	}
+	created := false
+	for i, relation := range hnswRelations {
+		idx := fmt.Sprintf(`::hnsw create %s:embedding_idx { dim: %d, m: 16, ef_construction: 200, distance: %s, fields: [embedding] }`,
+			relation, dimensions, cozoDistance(metric))
+		// Errors other than an existing function index are ignored: HNSW is
+		// optional for basic functionality.
+		_, err := b.handle.db.Run(b.qualify(idx), nil)
+		switch {
+		case err == nil:
+			created = true
+		case i == 0 && (strings.Contains(err.Error(), "already exists") || strings.Contains(err.Error(), "conflicts with an existing one")):
+			// The function index predates this call; the others follow its metric.
+			existing := stored
+			if existing == "" {
+				existing = DistanceCosine
+			}
+			if metric != "" && metric != existing {
+				b.handle.unlock()
+				return fmt.Errorf("%w: the vector indexes use %s distance, the configuration asks for %s; run 'cie reset' and 'cie index' to rebuild them",
+					ErrHNSWDistanceMismatch, existing, metric)
+			}
+			metric = existing
 		}
 	}
+	b.handle.unlock()
 
+	if metric == "" {
+		metric = DistanceCosine
+	}
+	b.hnswDistance = metric
+	if created && stored != metric {
+		return b.SetProjectMeta(HNSWDistanceMetaKey, metric)
+	}
 	return nil
 }
 
+// HNSWDistance returns the metric of the vector indexes once CreateHNSWIndex
+// has run, and the configured one before.
+func (b *EmbeddedBackend) HNSWDistance() string {
+	return b.hnswDistance
+}
+
 // GetProjectMeta retrieves a metadata value by key.
 // Returns empty string if key doesn't exist.
 func (b *EmbeddedBackend) GetProjectMeta(key string) (string, error) {
 	query := `?[value] := *cie_project_meta{key, value}, key = $key`
 	params := map[string]interface{}{"key": key}
 
-	b.mu.RLock()
-	result, err := b.db.Run(query, params)
-	b.mu.RUnlock()
+	if err := b.handle.rlock(); err != nil {
+		return "", err
+	}
+	result, err := b.handle.db.Run(b.qualify(query), params)
+	b.handle.runlock()
 
 	if err != nil {
 		return "", err
@@ -268,14 +515,7 @@ func (b *EmbeddedBackend) GetProjectMeta(key string) (string, error) {
 
 // SetProjectMeta sets a metadata value by key.
 func (b *EmbeddedBackend) SetProjectMeta(key, value string) error {
-	query := `?[key, value] <- [[$key, $value]] :put cie_project_meta { key, value }`
-	params := map[string]interface{}{"key": key, "value": value}
-
-	b.mu.Lock()
-	_, err := b.db.Run(query, params)
-	b.mu.Unlock()
-
-	return err
+	return b.Put(context.Background(), "cie_project_meta", Row{"key": key, "value": value})
 }
 
 // GetLastIndexedSHA retrieves the last successfully indexed git SHA.
@@ -288,9 +528,38 @@ func (b *EmbeddedBackend) SetLastIndexedSHA(sha string) error {
 	return b.SetProjectMeta("last_indexed_sha", sha)
 }
 
+// IndexVersionMetaKey is the cie_project_meta key that changes after every
+// index write. Readers compare it to invalidate cached query results.
+const IndexVersionMetaKey = "index_version"
+
+// DocumentPrefixMetaKey is the cie_project_meta key recording the prefix the
+// last index run put in front of every embedded document, quoted with
+// strconv.Quote. Semantic search compares it to the configured prefixes.
+const DocumentPrefixMetaKey = "embedding_document_prefix"
+
+// BumpIndexVersion records a new index version and returns it.
+func (b *EmbeddedBackend) BumpIndexVersion() (string, error) {
+	version := newIndexVersion()
+	return version, b.SetProjectMeta(IndexVersionMetaKey, version)
+}
+
+// newIndexVersion returns a value for IndexVersionMetaKey that differs from
+// every earlier one.
+func newIndexVersion() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 10)
+}
+
 // DeleteEntitiesForFile removes all entities associated with a file path.
 // This is used during incremental indexing when files are deleted or modified.
+// On a sharded backend it deletes from the shard that stores filePath.
 func (b *EmbeddedBackend) DeleteEntitiesForFile(filePath string) error {
+	if b.shards != nil && ShardFor(filePath) != "" {
+		if store := b.shards.get(ShardFor(filePath)); store != nil {
+			return store.DeleteEntitiesForFile(filePath)
+		}
+		return nil
+	}
+
 	// Delete in order: edges first, then entities
 	queries := []string{
 		// Delete call edges where caller or callee is in this file
@@ -298,6 +567,60 @@ func (b *EmbeddedBackend) DeleteEntitiesForFile(filePath string) error {
 		 :rm cie_calls {id}`,
 		`?[id] := *cie_calls{id, callee_id}, *cie_function{id: callee_id, file_path}, file_path = $path
 		 :rm cie_calls {id}`,
+		// Delete contains edges whose child or parent type is in this file
+		`?[id] := *cie_contains{id, file_path}, file_path = $path
+		 :rm cie_contains {id}`,
+		`?[id] := *cie_contains{id, parent_id}, *cie_type{id: parent_id, file_path}, file_path = $path
+		 :rm cie_contains {id}`,
+		// Delete method_of edges for methods in this file
+		`?[id] := *cie_method_of{id, file_path}, file_path = $path
+		 :rm cie_method_of {id}`,
+		// Delete unresolved calls made from this file
+		`?[id] := *cie_unresolved_call{id, file_path}, file_path = $path
+		 :rm cie_unresolved_call {id}`,
+		// Delete struct, message and enum fields declared in this file
+		`?[id] := *cie_field{id, file_path}, file_path = $path
+		 :rm cie_field {id}`,
+		// Delete .proto options of this file
+		`?[id] := *cie_proto_option{id, file_path}, file_path = $path
+		 :rm cie_proto_option {id}`,
+		// Delete generated_from edges of generated types in this file, or to its messages
+		`?[id] := *cie_generated_from{id, file_path}, file_path = $path
+		 :rm cie_generated_from {id}`,
+		`?[id] := *cie_generated_from{id, proto_type_id}, *cie_type{id: proto_type_id, file_path}, file_path = $path
+		 :rm cie_generated_from {id}`,
+		// Delete this file's generator record, and generated function links from or to it
+		`?[id] := *cie_generated_file{id, file_path}, file_path = $path
+		 :rm cie_generated_file {id}`,
+		`?[id] := *cie_generated_func{id, file_path}, file_path = $path
+		 :rm cie_generated_func {id}`,
+		`?[id] := *cie_generated_func{id, source_id}, *cie_function{id: source_id, file_path}, file_path = $path
+		 :rm cie_generated_func {id}`,
+		`?[id] := *cie_generated_func{id, source_id}, *cie_type{id: source_id, file_path}, file_path = $path
+		 :rm cie_generated_func {id}`,
+		// Delete the template in this file, its references, and render calls made from it
+		`?[id] := *cie_template_ref{id, file_path}, file_path = $path
+		 :rm cie_template_ref {id}`,
+		`?[id] := *cie_template{id, file_path}, file_path = $path
+		 :rm cie_template {id}`,
+		`?[id] := *cie_renders{id, file_path}, file_path = $path
+		 :rm cie_renders {id}`,
+		// Delete CI jobs defined in this file with their steps and references
+		`?[id] := *cie_ci_step{id, file_path}, file_path = $path
+		 :rm cie_ci_step {id}`,
+		`?[id] := *cie_ci_ref{id, file_path}, file_path = $path
+		 :rm cie_ci_ref {id}`,
+		`?[id] := *cie_ci_job{id, file_path}, file_path = $path
+		 :rm cie_ci_job {id}`,
+		// Delete table references made from this file
+		`?[id] := *cie_table_ref{id, file_path}, file_path = $path
+		 :rm cie_table_ref {id}`,
+		// Delete RPC links of functions in this file
+		`?[id] := *cie_rpc_link{id, file_path}, file_path = $path
+		 :rm cie_rpc_link {id}`,
+		// Delete entry points in this file
+		`?[id] := *cie_entry_point{id, file_path}, file_path = $path
+		 :rm cie_entry_point {id}`,
 		// Delete defines edges for this file
 		`?[id] := *cie_defines{id, file_id}, *cie_file{id: file_id, path}, path = $path
 		 :rm cie_defines {id}`,
@@ -307,9 +630,19 @@ func (b *EmbeddedBackend) DeleteEntitiesForFile(filePath string) error {
 		// Delete function embeddings
 		`?[function_id] := *cie_function{id: function_id, file_path}, file_path = $path
 		 :rm cie_function_embedding {function_id}`,
+		// Delete function name embeddings
+		`?[function_id] := *cie_function{id: function_id, file_path}, file_path = $path
+		 :rm cie_function_name_embedding {function_id}`,
 		// Delete function code
 		`?[function_id] := *cie_function{id: function_id, file_path}, file_path = $path
 		 :rm cie_function_code {function_id}`,
+		// Delete function code chunks
+		`?[function_id, chunk] := *cie_function{id: function_id, file_path}, file_path = $path,
+		 *cie_function_code_chunk{function_id, chunk}
+		 :rm cie_function_code_chunk {function_id, chunk}`,
+		// Delete function language rows
+		`?[function_id] := *cie_function{id: function_id, file_path}, file_path = $path
+		 :rm cie_function_lang {function_id}`,
 		// Delete functions
 		`?[id] := *cie_function{id, file_path}, file_path = $path
 		 :rm cie_function {id}`,
@@ -325,6 +658,9 @@ func (b *EmbeddedBackend) DeleteEntitiesForFile(filePath string) error {
 		// Delete imports for this file
 		`?[id] := *cie_import{id, file_path}, file_path = $path
 		 :rm cie_import {id}`,
+		// Delete stored file text
+		`?[file_id] := *cie_file{id: file_id, path}, path = $path
+		 :rm cie_file_content {file_id}`,
 		// Delete the file itself
 		`?[id] := *cie_file{id, path}, path = $path
 		 :rm cie_file {id}`,
@@ -332,11 +668,13 @@ func (b *EmbeddedBackend) DeleteEntitiesForFile(filePath string) error {
 
 	params := map[string]interface{}{"path": filePath}
 
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	if err := b.handle.rlock(); err != nil {
+		return err
+	}
+	defer b.handle.runlock()
 
 	for _, query := range queries {
-		if _, err := b.db.Run(query, params); err != nil {
+		if _, err := b.handle.db.Run(b.qualify(query), params); err != nil {
 			// Log but continue - some queries may fail if entities don't exist
 			continue
 		}
@@ -23,6 +23,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"sync"
 	"testing"
@@ -63,10 +64,10 @@ func TestNewEmbeddedBackend_Success(t *testing.T) {
 	if backend == nil {
 		t.Fatal("expected non-nil backend")
 	}
-	if backend.db == nil {
+	if backend.handle.db == nil {
 		t.Fatal("expected non-nil db")
 	}
-	if backend.closed {
+	if backend.handle.closed {
 		t.Error("expected backend to not be closed initially")
 	}
 }
@@ -262,7 +263,7 @@ func TestEmbeddedBackend_Close_Idempotent(t *testing.T) {
 	}
 
 	// Verify backend is closed
-	if !backend.closed {
+	if !backend.handle.closed {
 		t.Error("expected backend.closed to be true")
 	}
 }
@@ -375,6 +376,96 @@ func TestEmbeddedBackend_CreateHNSWIndex_Idempotent(t *testing.T) {
 	}
 }
 
+// TestEmbeddedBackend_CreateHNSWIndex_Distance tests that the index metric is
+// recorded and that a different configured metric is refused afterwards.
+func TestEmbeddedBackend_CreateHNSWIndex_Distance(t *testing.T) {
+	backend, err := NewEmbeddedBackend(EmbeddedConfig{DataDir: t.TempDir(), Engine: "mem", EmbeddingDimensions: 3, HNSWDistance: "ip"})
+	if err != nil {
+		t.Fatalf("NewEmbeddedBackend failed: %v", err)
+	}
+	defer func() {
+		_ = backend.Close()
+	}()
+	if err := backend.EnsureSchema(); err != nil {
+		t.Fatalf("EnsureSchema failed: %v", err)
+	}
+	if err := backend.CreateHNSWIndex(3); err != nil {
+		t.Fatalf("CreateHNSWIndex failed: %v", err)
+	}
+	if got, _ := backend.GetProjectMeta(HNSWDistanceMetaKey); got != DistanceIP {
+		t.Errorf("recorded distance = %q, want %q", got, DistanceIP)
+	}
+
+	unset := NewEmbeddedBackendFromDB(backend.DB(), EmbeddedConfig{EmbeddingDimensions: 3})
+	if err := unset.CreateHNSWIndex(3); err != nil || unset.HNSWDistance() != DistanceIP {
+		t.Errorf("unset distance: err = %v, distance = %q, want the existing ip", err, unset.HNSWDistance())
+	}
+	l2 := NewEmbeddedBackendFromDB(backend.DB(), EmbeddedConfig{EmbeddingDimensions: 3, HNSWDistance: DistanceL2})
+	if err := l2.CreateHNSWIndex(3); !errors.Is(err, ErrHNSWDistanceMismatch) {
+		t.Errorf("CreateHNSWIndex with another distance: err = %v, want ErrHNSWDistanceMismatch", err)
+	}
+
+	if _, err := NewEmbeddedBackend(EmbeddedConfig{DataDir: t.TempDir(), Engine: "mem", HNSWDistance: "manhattan"}); err == nil {
+		t.Error("NewEmbeddedBackend accepted an unknown distance")
+	}
+}
+
+// TestEmbeddedBackend_NormalizeEmbeddings tests that vectors off unit length
+// are found and rescaled, and that all-zero vectors are left alone.
+func TestEmbeddedBackend_NormalizeEmbeddings(t *testing.T) {
+	ctx := context.Background()
+	backend, err := NewEmbeddedBackend(EmbeddedConfig{DataDir: t.TempDir(), Engine: "mem", EmbeddingDimensions: 3})
+	if err != nil {
+		t.Fatalf("NewEmbeddedBackend failed: %v", err)
+	}
+	defer func() {
+		_ = backend.Close()
+	}()
+	if err := backend.EnsureSchema(); err != nil {
+		t.Fatalf("EnsureSchema failed: %v", err)
+	}
+	if err := backend.CreateHNSWIndex(3); err != nil {
+		t.Fatalf("CreateHNSWIndex failed: %v", err)
+	}
+	err = backend.Put(ctx, "cie_function_embedding",
+		Row{"function_id": "unit", "embedding": []float32{0.6, 0.8, 0}},
+		Row{"function_id": "long", "embedding": []float32{3, 4, 0}},
+		Row{"function_id": "zero", "embedding": []float32{0, 0, 0}},
+	)
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	report, err := backend.CheckEmbeddingNorms(ctx)
+	if err != nil {
+		t.Fatalf("CheckEmbeddingNorms failed: %v", err)
+	}
+	if report.Unnormalized != 2 {
+		t.Errorf("Unnormalized = %d, want 2", report.Unnormalized)
+	}
+
+	before, _ := backend.GetProjectMeta(IndexVersionMetaKey)
+	report, err = backend.NormalizeEmbeddings(ctx)
+	if err != nil {
+		t.Fatalf("NormalizeEmbeddings failed: %v", err)
+	}
+	if report.Normalized != 1 {
+		t.Errorf("Normalized = %d, want 1", report.Normalized)
+	}
+	if after, _ := backend.GetProjectMeta(IndexVersionMetaKey); after == "" || after == before {
+		t.Errorf("index version after normalizing = %q, want a new one (was %q)", after, before)
+	}
+	report, err = backend.CheckEmbeddingNorms(ctx)
+	if err != nil {
+		t.Fatalf("CheckEmbeddingNorms failed: %v", err)
+	}
+	for _, rel := range report.Relations {
+		if rel.Relation == "cie_function_embedding" && (rel.Unnormalized != 1 || rel.Zero != 1 || rel.MaxNorm > 1+NormTolerance) {
+			t.Errorf("after normalizing: %+v, want only the zero vector left", rel)
+		}
+	}
+}
+
 // TestEmbeddedBackend_ConcurrentReads tests that concurrent reads don't block each other.
 func TestEmbeddedBackend_ConcurrentReads(t *testing.T) {
 	backend := setupTestStorage(t)
@@ -0,0 +1,163 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	cozo "github.com/kraklabs/cie/pkg/cozodb"
+)
+
+// embeddingCacheSchema holds one vector per (model, text) key.
+const embeddingCacheSchema = `:create cie_embedding_cache { key: String => embedding: [Float], created: Float }`
+
+// EmbeddingCache is a content-addressed store of embedding vectors shared by
+// every project on the machine. Keys hash the model identity together with
+// the exact text sent to the provider, so forks, vendored copies and
+// re-clones of the same code reuse vectors instead of re-embedding them,
+// and switching models never returns a vector from another model.
+//
+// Like AuditLog it uses the SQLite engine, which lets several indexing
+// processes share the file.
+type EmbeddingCache struct {
+	db *cozo.CozoDB
+	mu sync.Mutex
+}
+
+// DefaultEmbeddingCachePath returns ~/.cie/cache/embeddings.db. It lives
+// outside ~/.cie/data so `cie reset` keeps it.
+func DefaultEmbeddingCachePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home dir: %w", err)
+	}
+	return filepath.Join(home, ".cie", "cache", "embeddings.db"), nil
+}
+
+// OpenEmbeddingCache opens (or creates) the embedding cache at path.
+func OpenEmbeddingCache(path string) (*EmbeddingCache, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("create embedding cache dir: %w", err)
+	}
+	db, err := cozo.New("sqlite", path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("open embedding cache: %w", err)
+	}
+	if _, err := db.Run(embeddingCacheSchema, nil); err != nil {
+		errStr := err.Error()
+		if !strings.Contains(errStr, "already exists") && !strings.Contains(errStr, "conflicts with an existing one") {
+			db.Close()
+			return nil, fmt.Errorf("create embedding cache relation: %w", err)
+		}
+	}
+	return &EmbeddingCache{db: &db}, nil
+}
+
+// EmbeddingCacheKey addresses the vector that model produces for text.
+// model should identify everything that changes the vector: provider,
+// model name, dimensions and document prefix.
+func EmbeddingCacheKey(model, text string) string {
+	h := sha256.New()
+	h.Write([]byte(model))
+	h.Write([]byte{0})
+	h.Write([]byte(text))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the cached vector for key, if any.
+func (c *EmbeddingCache) Get(key string) ([]float32, bool, error) {
+	c.mu.Lock()
+	result, err := c.db.Run(`?[embedding] := *cie_embedding_cache { key: $key, embedding }`, map[string]any{"key": key})
+	c.mu.Unlock()
+	if err != nil {
+		return nil, false, fmt.Errorf("read embedding cache: %w", err)
+	}
+	if len(result.Rows) == 0 {
+		return nil, false, nil
+	}
+	values, ok := result.Rows[0][0].([]any)
+	if !ok || len(values) == 0 {
+		return nil, false, nil
+	}
+	vec := make([]float32, len(values))
+	for i, v := range values {
+		f, ok := v.(float64)
+		if !ok {
+			return nil, false, nil
+		}
+		vec[i] = float32(f)
+	}
+	return vec, true, nil
+}
+
+// Put stores vec under key. Empty vectors (failed embeddings) are ignored.
+func (c *EmbeddingCache) Put(key string, vec []float32) error {
+	if len(vec) == 0 {
+		return nil
+	}
+	values := make([]float64, len(vec))
+	for i, v := range vec {
+		values[i] = float64(v)
+	}
+	params := map[string]any{
+		"key":       key,
+		"embedding": values,
+		"created":   float64(time.Now().Unix()),
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.db.Run(`?[key, embedding, created] <- [[$key, $embedding, $created]]
+		:put cie_embedding_cache { key => embedding, created }`, params)
+	if err != nil {
+		return fmt.Errorf("write embedding cache: %w", err)
+	}
+	return nil
+}
+
+// Len returns the number of cached vectors.
+func (c *EmbeddingCache) Len() (int, error) {
+	c.mu.Lock()
+	result, err := c.db.Run(`?[count(key)] := *cie_embedding_cache { key }`, nil)
+	c.mu.Unlock()
+	if err != nil {
+		return 0, fmt.Errorf("count embedding cache: %w", err)
+	}
+	if len(result.Rows) == 0 {
+		return 0, nil
+	}
+	return int(toInt64(result.Rows[0][0])), nil
+}
+
+// Close closes the cache database.
+func (c *EmbeddingCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.db != nil {
+		c.db.Close()
+		c.db = nil
+	}
+	return nil
+}
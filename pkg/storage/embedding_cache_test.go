@@ -0,0 +1,73 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+//go:build cgo
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestEmbeddingCache_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "embeddings.db")
+	cache, err := OpenEmbeddingCache(path)
+	if err != nil {
+		t.Fatalf("OpenEmbeddingCache: %v", err)
+	}
+
+	key := EmbeddingCacheKey("ollama/nomic-embed-text/768", "func A() {}")
+	if _, ok, err := cache.Get(key); err != nil || ok {
+		t.Fatalf("Get on empty cache = %v, %v", ok, err)
+	}
+	if err := cache.Put(key, []float32{0.25, -0.5}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := cache.Put("empty", nil); err != nil {
+		t.Fatalf("Put empty: %v", err)
+	}
+	_ = cache.Close()
+
+	// A second process (here: a reopen) sees the vector.
+	cache, err = OpenEmbeddingCache(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+	vec, ok, err := cache.Get(key)
+	if err != nil || !ok || len(vec) != 2 || vec[0] != 0.25 || vec[1] != -0.5 {
+		t.Fatalf("Get = %v, %v, %v", vec, ok, err)
+	}
+	if n, err := cache.Len(); err != nil || n != 1 {
+		t.Errorf("Len = %d, %v; empty vectors must not be stored", n, err)
+	}
+}
+
+func TestEmbeddingCacheKey(t *testing.T) {
+	a := EmbeddingCacheKey("openai/text-embedding-3-small/1536", "x")
+	if a != EmbeddingCacheKey("openai/text-embedding-3-small/1536", "x") {
+		t.Error("key must be deterministic")
+	}
+	if a == EmbeddingCacheKey("ollama/nomic-embed-text/768", "x") {
+		t.Error("different models must not share keys")
+	}
+	if EmbeddingCacheKey("m", "ab") == EmbeddingCacheKey("ma", "b") {
+		t.Error("model and text must be separated in the key")
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	cozo "github.com/kraklabs/cie/pkg/cozodb"
+)
+
+// feedbackSchema is the relation holding one row per search result rated by
+// an MCP client.
+const feedbackSchema = `:create cie_search_feedback { id: String => ts: Float, session: String, tool: String, query: String, query_type: String, result: String, rank: Int, similarity: Float, useful: Bool }`
+
+// FeedbackEntry records whether one search result was useful. Rank is the
+// 1-based position of the result (0 if unknown) and Similarity its score
+// (0 if the tool reports none).
+type FeedbackEntry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	SessionID  string    `json:"session_id"`
+	Tool       string    `json:"tool"`
+	Query      string    `json:"query"`
+	QueryType  string    `json:"query_type"`
+	Result     string    `json:"result"`
+	Rank       int       `json:"rank"`
+	Similarity float64   `json:"similarity"`
+	Useful     bool      `json:"useful"`
+}
+
+// FeedbackLog stores search feedback in a small CozoDB database kept apart
+// from the index, like AuditLog, so `cie bench --feedback` can read it
+// while an MCP server keeps writing.
+type FeedbackLog struct {
+	db  *cozo.CozoDB
+	mu  sync.Mutex
+	seq int64
+}
+
+// DefaultFeedbackLogPath returns ~/.cie/feedback/<project_id>/feedback.db.
+// Feedback outlives the index, so `cie reset` does not erase it.
+func DefaultFeedbackLogPath(projectID string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home dir: %w", err)
+	}
+	return filepath.Join(home, ".cie", "feedback", projectID, "feedback.db"), nil
+}
+
+// OpenFeedbackLog opens (or creates) the feedback log at path.
+func OpenFeedbackLog(path string) (*FeedbackLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("create feedback dir: %w", err)
+	}
+	db, err := cozo.New("sqlite", path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("open feedback log: %w", err)
+	}
+	if _, err := db.Run(feedbackSchema, nil); err != nil {
+		errStr := err.Error()
+		if !strings.Contains(errStr, "already exists") && !strings.Contains(errStr, "conflicts with an existing one") {
+			db.Close()
+			return nil, fmt.Errorf("create feedback relation: %w", err)
+		}
+	}
+	return &FeedbackLog{db: &db}, nil
+}
+
+// Record appends an entry to the log.
+func (f *FeedbackLog) Record(e FeedbackEntry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.seq++
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	params := map[string]any{
+		"id":         fmt.Sprintf("%s-%d-%06d", e.SessionID, e.Timestamp.UnixNano(), f.seq),
+		"ts":         float64(e.Timestamp.UnixNano()) / 1e9,
+		"session":    e.SessionID,
+		"tool":       e.Tool,
+		"query":      e.Query,
+		"query_type": e.QueryType,
+		"result":     e.Result,
+		"rank":       e.Rank,
+		"similarity": e.Similarity,
+		"useful":     e.Useful,
+	}
+	_, err := f.db.Run(`?[id, ts, session, tool, query, query_type, result, rank, similarity, useful] <- [[$id, $ts, $session, $tool, $query, $query_type, $result, $rank, $similarity, $useful]]
+		:put cie_search_feedback { id => ts, session, tool, query, query_type, result, rank, similarity, useful }`, params)
+	if err != nil {
+		return fmt.Errorf("record feedback: %w", err)
+	}
+	return nil
+}
+
+// Entries returns the entries recorded at or after since (all entries for
+// the zero time), oldest first.
+func (f *FeedbackLog) Entries(since time.Time) ([]FeedbackEntry, error) {
+	params := map[string]any{"since": 0.0}
+	if !since.IsZero() {
+		params["since"] = float64(since.UnixNano()) / 1e9
+	}
+	script := `?[ts, session, tool, query, query_type, result, rank, similarity, useful] :=
+		*cie_search_feedback { ts, session, tool, query, query_type, result, rank, similarity, useful }, ts >= $since
+		:order ts`
+
+	f.mu.Lock()
+	result, err := f.db.Run(script, params)
+	f.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("read feedback log: %w", err)
+	}
+
+	entries := make([]FeedbackEntry, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		if len(row) < 9 {
+			continue
+		}
+		e := FeedbackEntry{}
+		if ts, ok := row[0].(float64); ok {
+			sec := int64(ts)
+			e.Timestamp = time.Unix(sec, int64((ts-float64(sec))*1e9))
+		}
+		e.SessionID, _ = row[1].(string)
+		e.Tool, _ = row[2].(string)
+		e.Query, _ = row[3].(string)
+		e.QueryType, _ = row[4].(string)
+		e.Result, _ = row[5].(string)
+		e.Rank = int(toInt64(row[6]))
+		e.Similarity, _ = row[7].(float64)
+		e.Useful, _ = row[8].(bool)
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// Close closes the feedback database.
+func (f *FeedbackLog) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.db != nil {
+		f.db.Close()
+		f.db = nil
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+//go:build cgo
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFeedbackLog_RecordAndEntries(t *testing.T) {
+	log, err := OpenFeedbackLog(filepath.Join(t.TempDir(), "feedback.db"))
+	if err != nil {
+		t.Fatalf("OpenFeedbackLog: %v", err)
+	}
+	t.Cleanup(func() { _ = log.Close() })
+	now := time.Now()
+
+	entries := []FeedbackEntry{
+		{Timestamp: now.Add(-2 * time.Hour), SessionID: "s1", Tool: "cie_semantic_search", Query: "retry logic", QueryType: "natural_language", Result: "RetryWithBackoff", Rank: 1, Similarity: 0.81, Useful: true},
+		{Timestamp: now, SessionID: "s1", Tool: "cie_semantic_search", Query: "retry logic", QueryType: "natural_language", Result: "NewClient", Rank: 4},
+	}
+	for _, e := range entries {
+		if err := log.Record(e); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	all, err := log.Entries(time.Time{})
+	if err != nil {
+		t.Fatalf("Entries: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("got %d entries, want 2", len(all))
+	}
+	if first := all[0]; first.Result != "RetryWithBackoff" || !first.Useful || first.Rank != 1 || first.Similarity != 0.81 {
+		t.Errorf("oldest entry = %+v", first)
+	}
+	recent, _ := log.Entries(now.Add(-time.Hour))
+	if len(recent) != 1 || recent[0].Useful {
+		t.Errorf("since filter: got %+v", recent)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+
+package storage
+
+import "regexp"
+
+var (
+	// writeOpPattern matches mutation directives such as ":put cie_file".
+	writeOpPattern = regexp.MustCompile(`(^|[^:\w]):(put|rm|create|replace|insert|update|delete|ensure|ensure_not)\b`)
+	// sysOpPattern matches system ops such as "::remove" or "::relations".
+	sysOpPattern = regexp.MustCompile(`::(\w+)`)
+	// writeTargetPattern captures the relation a mutation writes to.
+	writeTargetPattern = regexp.MustCompile(`(?:^|[^:\w]):(?:put|rm|create|replace|insert|update|delete|ensure|ensure_not)\s+([A-Za-z]\w*)`)
+)
+
+// readOnlySysOps are system ops that only inspect the database.
+var readOnlySysOps = map[string]bool{
+	"relations": true,
+	"columns":   true,
+	"indices":   true,
+	"explain":   true,
+	"running":   true,
+}
+
+// IsMutation reports whether a script writes to the database.
+func IsMutation(script string) bool {
+	stripped := StripLiterals(script)
+	if writeOpPattern.MatchString(stripped) {
+		return true
+	}
+	for _, m := range sysOpPattern.FindAllStringSubmatch(stripped, -1) {
+		if !readOnlySysOps[m[1]] {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteTargets returns the relations a script's mutations write to, in
+// order of appearance.
+func WriteTargets(script string) []string {
+	var targets []string
+	for _, m := range writeTargetPattern.FindAllStringSubmatch(StripLiterals(script), -1) {
+		targets = append(targets, m[1])
+	}
+	return targets
+}
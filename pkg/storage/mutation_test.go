@@ -0,0 +1,59 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+
+package storage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsMutation(t *testing.T) {
+	tests := []struct {
+		script string
+		want   bool
+	}{
+		{"?[name] := *cie_function { name } :limit 10", false},
+		{"?[name] := *cie_function { name } :order name :offset 5", false},
+		{"::relations", false},
+		{"::columns cie_function", false},
+		{"?[id] <- [['x']] :put cie_file { id }", true},
+		{"?[id] <- [['x']] :rm cie_file { id }", true},
+		{":create evil { a: Int }", true},
+		{"::remove cie_function", true},
+		{"::hnsw drop cie_function_embedding:embedding_idx", true},
+		{`?[x] := *cie_function { name: x }, regex_matches(x, ":put")`, false},
+		{`?[x] := x = ___"::remove cie_file"___`, false},
+	}
+	for _, tt := range tests {
+		if got := IsMutation(tt.script); got != tt.want {
+			t.Errorf("IsMutation(%q) = %v, want %v", tt.script, got, tt.want)
+		}
+	}
+}
+
+func TestWriteTargets(t *testing.T) {
+	script := `?[k] <- [['a']] :put cie_x_size { k }
+{ ?[k] <- [['b']] :rm cie_x_other { k } }
+?[x] := x = ":put cie_file"`
+	if got, want := WriteTargets(script), []string{"cie_x_size", "cie_x_other"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("WriteTargets() = %v, want %v", got, want)
+	}
+	if got := WriteTargets("?[name] := *cie_function { name }"); len(got) != 0 {
+		t.Errorf("read-only script has write targets %v", got)
+	}
+}
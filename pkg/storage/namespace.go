@@ -0,0 +1,416 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// namespaceSeparator joins a namespace and a relation name, e.g.
+// "acme__cie_function". Double underscore keeps the boundary
+// unambiguous because NormalizeNamespace never emits it.
+const namespaceSeparator = "__"
+
+// cieRelationPrefix is the prefix shared by every CIE relation.
+const cieRelationPrefix = "cie_"
+
+// CIERelations lists every stored relation in the CIE schema.
+var CIERelations = []string{
+	"cie_file",
+	"cie_file_content",
+	"cie_function",
+	"cie_function_code",
+	"cie_function_code_chunk",
+	"cie_function_embedding",
+	"cie_function_name_embedding",
+	"cie_function_lang",
+	"cie_defines",
+	"cie_calls",
+	"cie_import",
+	"cie_type",
+	"cie_type_code",
+	"cie_type_embedding",
+	"cie_defines_type",
+	"cie_field",
+	"cie_implements",
+	"cie_contains",
+	"cie_method_of",
+	"cie_unresolved_call",
+	"cie_proto_option",
+	"cie_generated_from",
+	"cie_generated_file",
+	"cie_generated_func",
+	"cie_template",
+	"cie_template_ref",
+	"cie_renders",
+	"cie_ci_job",
+	"cie_ci_step",
+	"cie_ci_ref",
+	"cie_table_ref",
+	"cie_rpc_link",
+	"cie_entry_point",
+	"cie_note",
+	"cie_collection",
+	"cie_fact",
+	"cie_fact_embedding",
+	"cie_project_meta",
+}
+
+// UserRelations lists relations holding what people and agents record
+// rather than what indexing derives from the source. Full rebuilds carry
+// them over (see CarryOverUserData and RebuildDropScripts).
+var UserRelations = []string{"cie_note", "cie_collection", "cie_fact", "cie_fact_embedding"}
+
+// hnswRelations lists relations carrying an HNSW index named embedding_idx.
+var hnswRelations = []string{"cie_function_embedding", "cie_function_name_embedding", "cie_type_embedding"}
+
+// NormalizeNamespace turns a project ID into a valid relation-name prefix.
+// An ID of letters and digits that does not start with a digit is used as
+// is. Any other ID is rewritten: characters outside [A-Za-z0-9] become '_',
+// runs of '_' are collapsed, a leading digit is prefixed with 'p', and '_'
+// plus a hash of the raw ID is appended. Rewritten namespaces always contain
+// '_' and plain ones never do, and the hash keeps IDs that differ only in
+// punctuation ("my-project", "my.project", "my_project") apart, so distinct
+// project IDs never share a namespace. Pass the project ID itself: a
+// rewritten namespace is rewritten again. Returns "" for an empty project ID.
+func NormalizeNamespace(projectID string) string {
+	if projectID == "" {
+		return ""
+	}
+	var sb strings.Builder
+	lastUnderscore := false
+	for _, r := range projectID {
+		isAlnum := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+		if !isAlnum {
+			if !lastUnderscore && sb.Len() > 0 {
+				sb.WriteByte('_')
+				lastUnderscore = true
+			}
+			continue
+		}
+		sb.WriteRune(r)
+		lastUnderscore = false
+	}
+	ns := strings.TrimSuffix(sb.String(), "_")
+	if ns == "" || ns[0] >= '0' && ns[0] <= '9' {
+		ns = "p" + ns
+	}
+	if ns == projectID && !strings.Contains(ns, "_") {
+		return ns
+	}
+	sum := sha256.Sum256([]byte(projectID))
+	return ns + "_" + hex.EncodeToString(sum[:namespaceHashBytes])
+}
+
+// namespaceHashBytes is the length of the hash NormalizeNamespace appends
+// to rewritten project IDs (16 hex digits).
+const namespaceHashBytes = 8
+
+// QualifyRelations rewrites every cie_* relation reference in a CozoScript
+// so it targets the given namespace. String literals (single, double and
+// raw ___"..."___ quoting) and # comments are left untouched, so patterns
+// that mention relation names are not altered. An empty namespace returns script as-is.
+func QualifyRelations(script, namespace string) string {
+	if namespace == "" || !strings.Contains(script, cieRelationPrefix) {
+		return script
+	}
+
+	var out strings.Builder
+	out.Grow(len(script) + 64)
+	prefix := namespace + namespaceSeparator
+
+	for i := 0; i < len(script); {
+		c := script[i]
+		switch {
+		case c == '#':
+			// Line comment: copy verbatim so stray quotes don't open a literal
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			out.WriteString(script[i : i+end])
+			i += end
+		case c == '\'' || c == '"':
+			end := skipQuoted(script, i)
+			out.WriteString(script[i:end])
+			i = end
+		case c == '_' && (i == 0 || !isIdentByte(script[i-1])):
+			// Possible raw string: one or more '_' followed by '"'
+			j := i
+			for j < len(script) && script[j] == '_' {
+				j++
+			}
+			if j < len(script) && script[j] == '"' {
+				end := skipRaw(script, i, j-i)
+				out.WriteString(script[i:end])
+				i = end
+				continue
+			}
+			end := skipIdent(script, i)
+			out.WriteString(script[i:end])
+			i = end
+		case isIdentByte(c) && (i == 0 || !isIdentByte(script[i-1])):
+			end := skipIdent(script, i)
+			ident := script[i:end]
+			if strings.HasPrefix(ident, cieRelationPrefix) {
+				out.WriteString(prefix)
+			}
+			out.WriteString(ident)
+			i = end
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.String()
+}
+
+// CheckNamespaceScript reports an error when a script, already qualified
+// with QualifyRelations, reads a stored relation outside namespace or runs a
+// system op (::relations, ::remove, ...). Shared servers run it on every
+// tenant script so one project cannot reach another's relations.
+func CheckNamespaceScript(script, namespace string) error {
+	stripped := StripLiterals(script)
+	if strings.Contains(stripped, "::") {
+		return fmt.Errorf("system ops are not allowed in a shared database")
+	}
+	prefix := namespace + namespaceSeparator
+	for i := 0; i < len(stripped); i++ {
+		// Stored relations are read as *name{...} or *name[...], and
+		// searched through their indexes as ~name:index{...}.
+		if c := stripped[i]; c != '*' && c != '~' {
+			continue
+		}
+		end := skipIdent(stripped, i+1)
+		if end == i+1 {
+			continue
+		}
+		rest := strings.TrimLeft(stripped[end:], " \t\r\n")
+		if rest == "" || !strings.ContainsRune("{[:", rune(rest[0])) {
+			continue
+		}
+		if name := stripped[i+1 : end]; !strings.HasPrefix(name, prefix) {
+			return fmt.Errorf("relation %q is outside project namespace %q", name, namespace)
+		}
+		i = end - 1
+	}
+	return nil
+}
+
+// StripLiterals returns the script with the contents of string literals and
+// # comments blanked out, leaving only CozoScript structure. It lets callers
+// inspect operators and relation names without tripping over user text.
+func StripLiterals(script string) string {
+	var out strings.Builder
+	out.Grow(len(script))
+	for i := 0; i < len(script); {
+		c := script[i]
+		switch {
+		case c == '#':
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			i += end
+		case c == '\'' || c == '"':
+			i = skipQuoted(script, i)
+			out.WriteString(`""`)
+		case c == '_' && (i == 0 || !isIdentByte(script[i-1])):
+			j := i
+			for j < len(script) && script[j] == '_' {
+				j++
+			}
+			if j < len(script) && script[j] == '"' {
+				i = skipRaw(script, i, j-i)
+				out.WriteString(`""`)
+				continue
+			}
+			end := skipIdent(script, i)
+			out.WriteString(script[i:end])
+			i = end
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.String()
+}
+
+// QualifiedRelation returns the stored name of a relation in a namespace.
+func QualifiedRelation(relation, namespace string) string {
+	if namespace == "" {
+		return relation
+	}
+	return namespace + namespaceSeparator + relation
+}
+
+// DropNamespaceScripts returns the statements that remove every relation (and
+// HNSW index) belonging to a namespace. Run them one by one and ignore
+// "not found" errors for relations that were never created.
+func DropNamespaceScripts(namespace string) []string {
+	return dropScripts(namespace, nil)
+}
+
+// RebuildDropScripts is DropNamespaceScripts for a full re-index: it keeps
+// UserRelations, which indexing cannot recreate.
+func RebuildDropScripts(namespace string) []string {
+	return dropScripts(namespace, UserRelations)
+}
+
+func dropScripts(namespace string, keep []string) []string {
+	var scripts []string
+	for _, rel := range hnswRelations {
+		scripts = append(scripts, fmt.Sprintf("::hnsw drop %s:embedding_idx", QualifiedRelation(rel, namespace)))
+	}
+	for _, rel := range CIERelations {
+		if !slices.Contains(keep, rel) {
+			scripts = append(scripts, "::remove "+QualifiedRelation(rel, namespace))
+		}
+	}
+	return scripts
+}
+
+// NamespacesFromRelations extracts the namespaces present in a list of stored
+// relation names (as returned by ::relations). Only namespaces that own a
+// cie_project_meta relation are reported.
+func NamespacesFromRelations(relations []string) []string {
+	suffix := namespaceSeparator + "cie_project_meta"
+	var namespaces []string
+	for _, rel := range relations {
+		if ns, ok := strings.CutSuffix(rel, suffix); ok && ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// WithNamespace returns a view of the backend whose queries and mutations are
+// confined to the given project namespace. The view shares the underlying
+// database and lock; closing it does not close the database.
+func (b *EmbeddedBackend) WithNamespace(projectID string) *EmbeddedBackend {
+	return &EmbeddedBackend{
+		handle:              b.handle,
+		namespace:           NormalizeNamespace(projectID),
+		view:                true,
+		embeddingDimensions: b.embeddingDimensions,
+		hnswDistance:        b.hnswDistance,
+	}
+}
+
+// Namespace returns the namespace this backend is confined to ("" if none).
+func (b *EmbeddedBackend) Namespace() string {
+	return b.namespace
+}
+
+// Namespaces lists the project namespaces stored in the database.
+func (b *EmbeddedBackend) Namespaces() ([]string, error) {
+	names, err := b.storedRelations()
+	if err != nil {
+		return nil, err
+	}
+	return NamespacesFromRelations(names), nil
+}
+
+// storedRelations lists every relation in the database, of all namespaces.
+func (b *EmbeddedBackend) storedRelations() ([]string, error) {
+	if err := b.handle.rlock(); err != nil {
+		return nil, err
+	}
+	result, err := b.handle.db.Run("::relations", nil)
+	b.handle.runlock()
+	if err != nil {
+		return nil, fmt.Errorf("list relations: %w", err)
+	}
+
+	names := make([]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		if len(row) > 0 {
+			if name, ok := row[0].(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// DropNamespace removes all relations belonging to this backend's namespace.
+// It refuses to run on a backend without a namespace, where it would wipe
+// the whole database.
+func (b *EmbeddedBackend) DropNamespace() error {
+	if b.namespace == "" {
+		return fmt.Errorf("drop namespace: backend has no namespace")
+	}
+
+	if err := b.handle.lock(); err != nil {
+		return err
+	}
+	defer b.handle.unlock()
+
+	for _, script := range DropNamespaceScripts(b.namespace) {
+		// Relations or indexes that were never created fail; that's fine.
+		_, _ = b.handle.db.Run(script, nil)
+	}
+	return nil
+}
+
+// qualify applies the backend namespace to a script.
+func (b *EmbeddedBackend) qualify(script string) string {
+	return QualifyRelations(script, b.namespace)
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func skipIdent(s string, i int) int {
+	for i < len(s) && isIdentByte(s[i]) {
+		i++
+	}
+	return i
+}
+
+// skipQuoted returns the index just past the string literal starting at i.
+func skipQuoted(s string, i int) int {
+	quote := s[i]
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case quote:
+			return j + 1
+		}
+	}
+	return len(s)
+}
+
+// skipRaw returns the index just past a raw string that opens with n
+// underscores at i, e.g. ___"text"___ for n == 3.
+func skipRaw(s string, i, n int) int {
+	closing := "\"" + strings.Repeat("_", n)
+	if end := strings.Index(s[i+n+1:], closing); end >= 0 {
+		return i + n + 1 + end + len(closing)
+	}
+	return len(s)
+}
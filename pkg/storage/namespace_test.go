@@ -0,0 +1,238 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeNamespace(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string // for rewritten IDs, the namespace before the hash
+	}{
+		{"", ""},
+		{"myproject", "myproject"},
+		{"my-project", "my_project_"},
+		{"acme/api.v2", "acme_api_v2_"},
+		{"--weird--", "weird_"},
+		{"2024-app", "p2024_app_"},
+		{"a__b", "a_b_"},
+		{"a_b", "a_b_"},
+		{"--", "p_"},
+	}
+	for _, tt := range tests {
+		got := NormalizeNamespace(tt.in)
+		if !strings.HasSuffix(tt.want, "_") {
+			if got != tt.want {
+				t.Errorf("NormalizeNamespace(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			continue
+		}
+		hash, ok := strings.CutPrefix(got, tt.want)
+		if !ok || len(hash) != 2*namespaceHashBytes || strings.Trim(hash, "0123456789abcdef") != "" {
+			t.Errorf("NormalizeNamespace(%q) = %q, want %q followed by a hash", tt.in, got, tt.want)
+		}
+		if strings.Contains(got, namespaceSeparator) {
+			t.Errorf("NormalizeNamespace(%q) = %q contains the separator", tt.in, got)
+		}
+	}
+}
+
+func TestNormalizeNamespace_DistinctIDsStayIsolated(t *testing.T) {
+	ids := []string{"my-project", "my.project", "my_project", "my__project", "my project", "myproject", "my/project/"}
+	seen := map[string]string{}
+	for _, id := range ids {
+		ns := NormalizeNamespace(id)
+		if other, ok := seen[ns]; ok {
+			t.Fatalf("project IDs %q and %q share namespace %q", other, id, ns)
+		}
+		seen[ns] = id
+	}
+
+	// A script scoped to one project must not pass as another's.
+	script := QualifyRelations(`?[name] := *cie_function { name }`, NormalizeNamespace("my-project"))
+	if err := CheckNamespaceScript(script, NormalizeNamespace("my.project")); err == nil {
+		t.Error("my-project's relations are readable from my.project's namespace")
+	}
+	if err := CheckNamespaceScript(script, NormalizeNamespace("my-project")); err != nil {
+		t.Errorf("my-project cannot read its own relations: %v", err)
+	}
+}
+
+func TestQualifyRelations(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{
+			name:   "stored relation reads",
+			script: `?[name] := *cie_function { id, name }, *cie_function_code { function_id: id, code_text }`,
+			want:   `?[name] := *acme__cie_function { id, name }, *acme__cie_function_code { function_id: id, code_text }`,
+		},
+		{
+			name:   "put and rm",
+			script: `{ ?[id] <- [['a']] :put cie_file { id } } { ?[id] <- [['b']] :rm cie_calls {id} }`,
+			want:   `{ ?[id] <- [['a']] :put acme__cie_file { id } } { ?[id] <- [['b']] :rm acme__cie_calls {id} }`,
+		},
+		{
+			name:   "create and hnsw",
+			script: `::hnsw create cie_function_embedding:embedding_idx { dim: 768 }`,
+			want:   `::hnsw create acme__cie_function_embedding:embedding_idx { dim: 768 }`,
+		},
+		{
+			name:   "vector search",
+			script: `?[d] := ~cie_function_embedding:embedding_idx { function_id | query: q, k: 5, bind_distance: d }`,
+			want:   `?[d] := ~acme__cie_function_embedding:embedding_idx { function_id | query: q, k: 5, bind_distance: d }`,
+		},
+		{
+			name:   "string literals untouched",
+			script: `?[x] := *cie_file { path: x }, regex_matches(x, "cie_file"), y = 'cie_calls', z = ___"cie_type"___`,
+			want:   `?[x] := *acme__cie_file { path: x }, regex_matches(x, "cie_file"), y = 'cie_calls', z = ___"cie_type"___`,
+		},
+		{
+			name:   "escaped quote inside literal",
+			script: `?[x] := x = 'it\'s cie_file', *cie_type { name: x }`,
+			want:   `?[x] := x = 'it\'s cie_file', *acme__cie_type { name: x }`,
+		},
+		{
+			name:   "comment with apostrophe",
+			script: "# don't touch\n?[id] := *cie_file { id }",
+			want:   "# don't touch\n?[id] := *acme__cie_file { id }",
+		},
+		{
+			name:   "identifier suffix not rewritten",
+			script: `?[my_cie_file] := *cie_file { id: my_cie_file }`,
+			want:   `?[my_cie_file] := *acme__cie_file { id: my_cie_file }`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := QualifyRelations(tt.script, "acme"); got != tt.want {
+				t.Errorf("QualifyRelations()\n got: %s\nwant: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQualifyRelations_EmptyNamespace(t *testing.T) {
+	script := `?[id] := *cie_file { id }`
+	if got := QualifyRelations(script, ""); got != script {
+		t.Errorf("empty namespace should not rewrite, got %q", got)
+	}
+}
+
+func TestDropNamespaceScripts(t *testing.T) {
+	scripts := DropNamespaceScripts("acme")
+	if len(scripts) != len(CIERelations)+len(hnswRelations) {
+		t.Fatalf("got %d scripts, want %d", len(scripts), len(CIERelations)+len(hnswRelations))
+	}
+	// Indexes must be dropped before their relations
+	if scripts[0] != "::hnsw drop acme__cie_function_embedding:embedding_idx" {
+		t.Errorf("first script = %q", scripts[0])
+	}
+	if scripts[len(scripts)-1] != "::remove acme__cie_project_meta" {
+		t.Errorf("last script = %q", scripts[len(scripts)-1])
+	}
+}
+
+func TestRebuildDropScripts_KeepsUserRelations(t *testing.T) {
+	scripts := RebuildDropScripts("acme")
+	if len(scripts) != len(CIERelations)+len(hnswRelations)-len(UserRelations) {
+		t.Fatalf("got %d scripts", len(scripts))
+	}
+	for _, script := range scripts {
+		if script == "::remove acme__cie_note" {
+			t.Error("a rebuild must not drop notes")
+		}
+	}
+}
+
+func TestNamespacesFromRelations(t *testing.T) {
+	relations := []string{
+		"cie_project_meta",
+		"beta__cie_function",
+		"beta__cie_project_meta",
+		"alpha__cie_project_meta",
+		"unrelated",
+	}
+	got := NamespacesFromRelations(relations)
+	want := []string{"alpha", "beta"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NamespacesFromRelations() = %v, want %v", got, want)
+	}
+}
+
+func TestWithNamespace_SharesHandle(t *testing.T) {
+	root := &EmbeddedBackend{handle: &dbHandle{}, embeddingDimensions: 768}
+	view := root.WithNamespace("my-project")
+
+	if view.handle != root.handle {
+		t.Error("view should share the database handle")
+	}
+	if want := NormalizeNamespace("my-project"); view.Namespace() != want {
+		t.Errorf("Namespace() = %q, want %q", view.Namespace(), want)
+	}
+	if err := view.Close(); err != nil {
+		t.Fatalf("Close() on view: %v", err)
+	}
+	if root.handle.closed {
+		t.Error("closing a view must not close the shared database")
+	}
+	if err := root.DropNamespace(); err == nil {
+		t.Error("DropNamespace without a namespace should fail")
+	}
+}
+
+func TestStripLiterals(t *testing.T) {
+	script := "# it's a comment\n?[x] := *cie_file { path: x }, regex_matches(x, \":put cie_x\"), y = ':rm', z = ___\"::remove\"___"
+	want := "\n?[x] := *cie_file { path: x }, regex_matches(x, \"\"), y = \"\", z = \"\""
+	if got := StripLiterals(script); got != want {
+		t.Errorf("StripLiterals()\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestCheckNamespaceScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		wantErr bool
+	}{
+		{"own relations", "?[id] := *cie_function { id }, *cie_file[id, path]", false},
+		{"vector search", "?[f] := ~cie_function_embedding:embedding_idx { function_id: f | query: q, k: 5 }", false},
+		{"literal mentions", `?[x] := x = "*other__cie_function{"`, false},
+		{"multiplication", "?[x] := x = 2 * y, y = 3", false},
+		{"other tenant", "?[id] := *other__cie_function { id }", true},
+		{"other tenant index", "?[f] := ~other__cie_function_embedding:embedding_idx { function_id: f | query: q, k: 5 }", true},
+		{"unnamespaced relation", "?[k] := *secrets { k }", true},
+		{"system op", "::relations", true},
+		{"remove", "::remove other__cie_function", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckNamespaceScript(QualifyRelations(tt.script, "acme"), "acme")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckNamespaceScript() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
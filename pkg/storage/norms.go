@@ -0,0 +1,132 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// RelationNorms reports the lengths of the vectors stored in one relation.
+type RelationNorms struct {
+	Relation string `json:"relation"`
+	Vectors  int    `json:"vectors"`
+	// Unnormalized counts vectors whose squared length is off 1 by more
+	// than NormTolerance, including the all-zero ones counted in Zero.
+	Unnormalized int     `json:"unnormalized"`
+	Zero         int     `json:"zero"`
+	MinNorm      float64 `json:"min_norm"`
+	MaxNorm      float64 `json:"max_norm"`
+	// Normalized counts the vectors NormalizeEmbeddings rewrote.
+	Normalized int `json:"normalized,omitempty"`
+}
+
+// NormReport is the result of CheckEmbeddingNorms and NormalizeEmbeddings.
+type NormReport struct {
+	Relations    []RelationNorms `json:"relations"`
+	Unnormalized int             `json:"unnormalized"`
+	Normalized   int             `json:"normalized"`
+}
+
+// CheckEmbeddingNorms measures the length of every stored embedding.
+// Embeddings are written at unit length, but ones written by older versions
+// or brought in from another provider may not be: their similarity scores
+// are then on another scale than the rest, and L2 and inner product indexes
+// rank them wrongly.
+func (b *EmbeddedBackend) CheckEmbeddingNorms(ctx context.Context) (*NormReport, error) {
+	stored, err := b.storedRelations()
+	if err != nil {
+		return nil, err
+	}
+	report := &NormReport{Relations: []RelationNorms{}}
+	for _, rel := range Relations {
+		if !rel.hasVector() || !slices.Contains(stored, QualifiedRelation(rel.Name, b.namespace)) {
+			continue
+		}
+		script := fmt.Sprintf("?[key, norm_sq] := *%s { %s: key, embedding: e }, norm_sq = 1 - ip_dist(e, e)",
+			rel.Name, rel.Keys[0].Name)
+		result, err := b.Query(ctx, script)
+		if err != nil {
+			return nil, fmt.Errorf("measure %s: %w", rel.Name, err)
+		}
+		norms := RelationNorms{Relation: rel.Name, Vectors: len(result.Rows)}
+		for i, row := range result.Rows {
+			normSq, _ := row[1].(float64)
+			norm := math.Sqrt(max(normSq, 0))
+			if i == 0 || norm < norms.MinNorm {
+				norms.MinNorm = norm
+			}
+			norms.MaxNorm = max(norms.MaxNorm, norm)
+			if math.Abs(normSq-1) > NormTolerance {
+				norms.Unnormalized++
+				if normSq <= 0 {
+					norms.Zero++
+				}
+			}
+		}
+		report.Unnormalized += norms.Unnormalized
+		report.Relations = append(report.Relations, norms)
+	}
+	return report, nil
+}
+
+// NormalizeEmbeddings rescales every stored embedding that is not at unit
+// length, in the main store and every shard; the vector indexes follow the
+// rewritten rows. All-zero vectors have no direction and are left as they
+// are. A rewrite records a new index version. The returned report describes
+// the vectors before the rewrite, with Normalized filled in.
+func (b *EmbeddedBackend) NormalizeEmbeddings(ctx context.Context) (*NormReport, error) {
+	report, err := b.CheckEmbeddingNorms(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stores := []*EmbeddedBackend{b}
+	for _, name := range b.ShardNames() {
+		shard, err := b.Shard(name)
+		if err != nil {
+			return nil, err
+		}
+		stores = append(stores, shard)
+	}
+	for i, norms := range report.Relations {
+		if norms.Unnormalized == norms.Zero {
+			continue
+		}
+		rel, _ := LookupRelation(norms.Relation)
+		key := rel.Keys[0].Name
+		script := fmt.Sprintf(`?[%s, embedding] := *%s { %s, embedding: e }, n = 1 - ip_dist(e, e),
+	n > 0, abs(n - 1) > %g, embedding = l2_normalize(e)
+:put %s { %s => embedding }`, key, rel.Name, key, NormTolerance, rel.Name, key)
+		for _, store := range stores {
+			if err := store.Execute(ctx, script); err != nil {
+				return nil, fmt.Errorf("normalize %s: %w", rel.Name, err)
+			}
+		}
+		report.Relations[i].Normalized = norms.Unnormalized - norms.Zero
+		report.Normalized += report.Relations[i].Normalized
+	}
+	if report.Normalized > 0 {
+		if _, err := b.BumpIndexVersion(); err != nil {
+			return nil, fmt.Errorf("bump index version: %w", err)
+		}
+	}
+	return report, nil
+}
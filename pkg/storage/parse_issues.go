@@ -0,0 +1,55 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import "encoding/json"
+
+// ParseIssuesMetaKey is the cie_project_meta key listing the indexed files
+// that did not parse cleanly, as JSON (see EncodeParseIssues). Index runs
+// keep it current for the files they parse and delete.
+const ParseIssuesMetaKey = "parse_issues"
+
+// ParseIssue describes one file that did not parse cleanly.
+type ParseIssue struct {
+	Language     string `json:"language"`
+	SyntaxErrors int    `json:"syntax_errors,omitempty"` // Error nodes in the syntax tree; the rest of the file was indexed
+	Failed       bool   `json:"failed,omitempty"`        // Nothing was indexed from the file
+}
+
+// EncodeParseIssues renders issues, keyed by file path, for ParseIssuesMetaKey.
+func EncodeParseIssues(issues map[string]ParseIssue) (string, error) {
+	if issues == nil {
+		issues = map[string]ParseIssue{}
+	}
+	data, err := json.Marshal(issues)
+	return string(data), err
+}
+
+// DecodeParseIssues parses a ParseIssuesMetaKey value. An empty value
+// (indexes built before parse issues were recorded) decodes to an empty map.
+func DecodeParseIssues(value string) (map[string]ParseIssue, error) {
+	issues := map[string]ParseIssue{}
+	if value == "" {
+		return issues, nil
+	}
+	if err := json.Unmarshal([]byte(value), &issues); err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseIssues_RoundTrip(t *testing.T) {
+	issues := map[string]ParseIssue{
+		"a.go": {Language: "go", SyntaxErrors: 3},
+		"b.py": {Language: "python", Failed: true},
+	}
+	value, err := EncodeParseIssues(issues)
+	if err != nil {
+		t.Fatalf("EncodeParseIssues: %v", err)
+	}
+	got, err := DecodeParseIssues(value)
+	if err != nil {
+		t.Fatalf("DecodeParseIssues: %v", err)
+	}
+	if !reflect.DeepEqual(got, issues) {
+		t.Errorf("round trip = %v, want %v", got, issues)
+	}
+}
+
+func TestDecodeParseIssues_Empty(t *testing.T) {
+	got, err := DecodeParseIssues("")
+	if err != nil || len(got) != 0 {
+		t.Errorf("DecodeParseIssues(\"\") = %v, %v; want empty map", got, err)
+	}
+	if value, _ := EncodeParseIssues(nil); value != "{}" {
+		t.Errorf("EncodeParseIssues(nil) = %q, want {}", value)
+	}
+	if _, err := DecodeParseIssues("not json"); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
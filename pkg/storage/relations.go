@@ -0,0 +1,438 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ColumnType is the stored type of a relation column.
+type ColumnType int
+
+const (
+	// ColumnString holds a String.
+	ColumnString ColumnType = iota
+	// ColumnInt holds an Int.
+	ColumnInt
+	// ColumnVector holds an <F32; dim> embedding.
+	ColumnVector
+)
+
+// Column is one column of a stored relation.
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Relation describes a stored CIE relation: the key columns that identify a
+// row and the value columns that describe it.
+type Relation struct {
+	Name   string
+	Keys   []Column
+	Values []Column
+}
+
+// Columns returns the key and value column names in schema order.
+func (r Relation) Columns() []string {
+	names := make([]string, 0, len(r.Keys)+len(r.Values))
+	for _, c := range r.Keys {
+		names = append(names, c.Name)
+	}
+	for _, c := range r.Values {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// createScript returns the :create statement for r with vectors of dim.
+func (r Relation) createScript(dim int) string {
+	decl := func(cols []Column) string {
+		parts := make([]string, len(cols))
+		for i, c := range cols {
+			switch c.Type {
+			case ColumnInt:
+				parts[i] = c.Name + ": Int"
+			case ColumnVector:
+				parts[i] = fmt.Sprintf("%s: <F32; %d>", c.Name, dim)
+			default:
+				parts[i] = c.Name + ": String"
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprintf(":create %s { %s => %s }", r.Name, decl(r.Keys), decl(r.Values))
+}
+
+// SchemaScript returns the :create statements of Relations, one per line,
+// with vectors of dim.
+func SchemaScript(dim int) string {
+	scripts := make([]string, len(Relations))
+	for i, rel := range Relations {
+		scripts[i] = rel.createScript(dim)
+	}
+	return strings.Join(scripts, "\n")
+}
+
+func stringCol(name string) Column { return Column{Name: name, Type: ColumnString} }
+func intCol(name string) Column    { return Column{Name: name, Type: ColumnInt} }
+
+// idKey is the key of relations identified by a string id.
+var idKey = []Column{stringCol("id")}
+
+// Relations is the CIE schema, in the order EnsureSchema creates it.
+var Relations = []Relation{
+	{Name: "cie_file", Keys: idKey, Values: []Column{stringCol("path"), stringCol("hash"), stringCol("language"), intCol("size")}},
+	{Name: "cie_file_content", Keys: []Column{stringCol("file_id")}, Values: []Column{stringCol("content")}},
+	{Name: "cie_function", Keys: idKey, Values: []Column{stringCol("name"), stringCol("signature"), stringCol("file_path"), intCol("start_line"), intCol("end_line"), intCol("start_col"), intCol("end_col")}},
+	{Name: "cie_function_code", Keys: []Column{stringCol("function_id")}, Values: []Column{stringCol("code_text")}},
+	{Name: "cie_function_code_chunk", Keys: []Column{stringCol("function_id"), intCol("chunk")}, Values: []Column{intCol("start_line"), stringCol("code_text")}},
+	{Name: "cie_function_embedding", Keys: []Column{stringCol("function_id")}, Values: []Column{{Name: "embedding", Type: ColumnVector}}},
+	{Name: "cie_function_name_embedding", Keys: []Column{stringCol("function_id")}, Values: []Column{{Name: "embedding", Type: ColumnVector}}},
+	{Name: "cie_function_lang", Keys: []Column{stringCol("function_id")}, Values: []Column{stringCol("language"), stringCol("dialect")}},
+	{Name: "cie_defines", Keys: idKey, Values: []Column{stringCol("file_id"), stringCol("function_id")}},
+	{Name: "cie_calls", Keys: idKey, Values: []Column{stringCol("caller_id"), stringCol("callee_id")}},
+	{Name: "cie_import", Keys: idKey, Values: []Column{stringCol("file_path"), stringCol("import_path"), stringCol("alias"), intCol("start_line")}},
+	{Name: "cie_type", Keys: idKey, Values: []Column{stringCol("name"), stringCol("kind"), stringCol("file_path"), intCol("start_line"), intCol("end_line"), intCol("start_col"), intCol("end_col")}},
+	{Name: "cie_type_code", Keys: []Column{stringCol("type_id")}, Values: []Column{stringCol("code_text")}},
+	{Name: "cie_type_embedding", Keys: []Column{stringCol("type_id")}, Values: []Column{{Name: "embedding", Type: ColumnVector}}},
+	{Name: "cie_defines_type", Keys: idKey, Values: []Column{stringCol("file_id"), stringCol("type_id")}},
+	// Struct field entities for interface dispatch resolution
+	{Name: "cie_field", Keys: idKey, Values: []Column{stringCol("struct_name"), stringCol("field_name"), stringCol("field_type"), stringCol("file_path"), intCol("line")}},
+	// Implements edges: concrete type -> interface
+	{Name: "cie_implements", Keys: idKey, Values: []Column{stringCol("type_name"), stringCol("interface_name"), stringCol("file_path")}},
+	// Contains edges: enclosing function or type -> nested function
+	{Name: "cie_contains", Keys: idKey, Values: []Column{stringCol("parent_id"), stringCol("parent_kind"), stringCol("child_id"), stringCol("file_path")}},
+	// Method-of edges: method -> its type
+	{Name: "cie_method_of", Keys: idKey, Values: []Column{stringCol("method_id"), stringCol("type_id"), stringCol("type_name"), stringCol("file_path")}},
+	// Calls the resolver could not link, with the reason
+	{Name: "cie_unresolved_call", Keys: idKey, Values: []Column{stringCol("caller_id"), stringCol("callee_name"), stringCol("file_path"), intCol("line"), stringCol("reason")}},
+	// Options set in .proto files
+	{Name: "cie_proto_option", Keys: idKey, Values: []Column{stringCol("file_path"), stringCol("scope"), stringCol("name"), stringCol("value"), intCol("line")}},
+	// Generated-from edges: generated type -> .proto message or enum, or mocked interface
+	{Name: "cie_generated_from", Keys: idKey, Values: []Column{stringCol("type_id"), stringCol("proto_type_id"), stringCol("file_path")}},
+	// Generated files and the RPC or type each generated function comes from
+	{Name: "cie_generated_file", Keys: idKey, Values: []Column{stringCol("file_path"), stringCol("generator"), stringCol("source")}},
+	{Name: "cie_generated_func", Keys: idKey, Values: []Column{stringCol("function_id"), stringCol("source_id"), stringCol("source_kind"), stringCol("file_path")}},
+	// Template files, what they reference, and the functions rendering them
+	{Name: "cie_template", Keys: idKey, Values: []Column{stringCol("file_path"), stringCol("dialect")}},
+	{Name: "cie_template_ref", Keys: idKey, Values: []Column{stringCol("template_id"), stringCol("file_path"), stringCol("kind"), stringCol("name"), intCol("line")}},
+	{Name: "cie_renders", Keys: idKey, Values: []Column{stringCol("function_id"), stringCol("file_path"), stringCol("template_name"), intCol("line")}},
+	{Name: "cie_ci_job", Keys: idKey, Values: []Column{stringCol("file_path"), stringCol("workflow"), stringCol("name"), stringCol("title"), stringCol("stage"), stringCol("runs_on"), stringCol("needs"), stringCol("triggers"), intCol("start_line")}},
+	{Name: "cie_ci_step", Keys: idKey, Values: []Column{stringCol("job_id"), stringCol("file_path"), intCol("idx"), stringCol("name"), stringCol("kind"), stringCol("command"), intCol("line")}},
+	{Name: "cie_ci_ref", Keys: idKey, Values: []Column{stringCol("job_id"), stringCol("file_path"), stringCol("kind"), stringCol("name"), intCol("line")}},
+	// Database tables functions use, from SQL strings and ORM calls
+	{Name: "cie_table_ref", Keys: idKey, Values: []Column{stringCol("function_id"), stringCol("file_path"), stringCol("table_name"), stringCol("model"), stringCol("op"), stringCol("source"), intCol("line")}},
+	// gRPC servers and client calls linked to .proto RPCs
+	{Name: "cie_rpc_link", Keys: idKey, Values: []Column{stringCol("rpc"), stringCol("function_id"), stringCol("role"), stringCol("file_path"), intCol("line")}},
+	// Entry points: main, commands, servers, Lambda handlers, scheduled jobs
+	{Name: "cie_entry_point", Keys: idKey, Values: []Column{stringCol("function_id"), stringCol("file_path"), stringCol("kind"), stringCol("detail")}},
+	// Notes people and agents attach to functions and files; kept across rebuilds
+	{Name: "cie_note", Keys: idKey, Values: []Column{stringCol("kind"), stringCol("name"), stringCol("file_path"), stringCol("text"), stringCol("author"), stringCol("created")}},
+	// Members of named collections of functions and files; kept across rebuilds
+	{Name: "cie_collection", Keys: idKey, Values: []Column{stringCol("collection"), stringCol("kind"), stringCol("name"), stringCol("file_path"), stringCol("added")}},
+	// Facts agents store for later sessions, and their embeddings; kept across rebuilds
+	{Name: "cie_fact", Keys: idKey, Values: []Column{stringCol("text"), stringCol("tags"), stringCol("author"), stringCol("created")}},
+	{Name: "cie_fact_embedding", Keys: []Column{stringCol("fact_id")}, Values: []Column{{Name: "embedding", Type: ColumnVector}}},
+	// Project metadata for incremental indexing
+	{Name: "cie_project_meta", Keys: []Column{stringCol("key")}, Values: []Column{stringCol("value")}},
+}
+
+// LookupRelation returns the schema of a CIE relation.
+func LookupRelation(name string) (Relation, bool) {
+	for _, r := range Relations {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Relation{}, false
+}
+
+// hasVector reports whether the relation stores an embedding.
+func (r Relation) hasVector() bool {
+	for _, c := range r.Values {
+		if c.Type == ColumnVector {
+			return true
+		}
+	}
+	return false
+}
+
+// Row holds the values of one relation row by column name.
+type Row map[string]any
+
+// Put writes complete rows to relation. A row whose key is already stored
+// replaces it, so re-indexing never fails on a duplicate key; when rows
+// repeat a key, the last one wins. Every column must be present with a value
+// of its type.
+func (b *EmbeddedBackend) Put(ctx context.Context, relation string, rows ...Row) error {
+	return b.write(ctx, relation, rows, Relation.putScript)
+}
+
+// Upsert writes partial rows to relation. Value columns a row leaves out
+// keep their stored values; for a key not yet stored they start empty ("" or
+// 0). Rows repeating a key are merged, later columns winning. Vector columns
+// cannot be left out.
+func (b *EmbeddedBackend) Upsert(ctx context.Context, relation string, rows ...Row) error {
+	return b.write(ctx, relation, rows, Relation.upsertScript)
+}
+
+// Delete removes the rows of relation with the given keys. Only key columns
+// are read from each row; keys that are not stored are ignored.
+func (b *EmbeddedBackend) Delete(ctx context.Context, relation string, keys ...Row) error {
+	return b.write(ctx, relation, keys, Relation.deleteScript)
+}
+
+// write builds a parameterized mutation for rows and runs it against the
+// backend's own store.
+func (b *EmbeddedBackend) write(ctx context.Context, relation string, rows []Row, build func(Relation, []Row) (string, map[string]any, error)) error {
+	rel, ok := LookupRelation(relation)
+	if !ok {
+		return fmt.Errorf("unknown relation %q", relation)
+	}
+	script, params, err := build(rel, rows)
+	if err != nil || script == "" {
+		return err
+	}
+
+	if err := b.handle.rlock(); err != nil {
+		return err
+	}
+	defer b.handle.runlock()
+
+	if b.handle.readOnly {
+		return errReadOnly
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := b.handle.db.Run(b.qualify(script), params); err != nil {
+		return fmt.Errorf("write %s: %w", rel.Name, err)
+	}
+	return nil
+}
+
+// putScript returns the :put of complete rows.
+func (r Relation) putScript(rows []Row) (string, map[string]any, error) {
+	rows, err := r.prepare(rows, true)
+	if err != nil || len(rows) == 0 {
+		return "", nil, err
+	}
+	cols := r.Columns()
+	list := strings.Join(cols, ", ")
+	script := fmt.Sprintf("?[%s] <- $rows :put %s { %s }", list, r.Name, list)
+	return script, map[string]any{"rows": table(rows, cols)}, nil
+}
+
+// upsertScript returns the :put of partial rows. Rows setting the same
+// columns share an input rule, which feeds one rule completing stored rows
+// from the relation and one completing new rows with zero values.
+func (r Relation) upsertScript(rows []Row) (string, map[string]any, error) {
+	rows, err := r.prepare(rows, false)
+	if err != nil || len(rows) == 0 {
+		return "", nil, err
+	}
+
+	groups := map[string][]Row{}
+	var order []string
+	for _, row := range rows {
+		sig := strings.Join(row.columns(r.Values), ", ")
+		if _, ok := groups[sig]; !ok {
+			order = append(order, sig)
+		}
+		groups[sig] = append(groups[sig], row)
+	}
+
+	keys := columnNames(r.Keys)
+	all := strings.Join(r.Columns(), ", ")
+	params := make(map[string]any, len(order))
+	var script strings.Builder
+	for i, sig := range order {
+		first := groups[sig][0]
+		present := append(columnNames(r.Keys), first.columns(r.Values)...)
+		stored := columnNames(r.Keys)
+		var defaults []string
+		for _, c := range r.Values {
+			if _, ok := first[c.Name]; ok {
+				continue
+			}
+			switch c.Type {
+			case ColumnVector:
+				return "", nil, fmt.Errorf("%s: column %s cannot be left out", r.Name, c.Name)
+			case ColumnInt:
+				defaults = append(defaults, c.Name+" = 0")
+			default:
+				defaults = append(defaults, c.Name+" = ''")
+			}
+			stored = append(stored, c.Name)
+		}
+
+		params[fmt.Sprintf("rows%d", i)] = table(groups[sig], present)
+		input := fmt.Sprintf("in%d[%s]", i, strings.Join(present, ", "))
+		fmt.Fprintf(&script, "%s <- $rows%d\n", input, i)
+		if len(defaults) == 0 {
+			fmt.Fprintf(&script, "?[%s] := %s\n", all, input)
+			continue
+		}
+		fmt.Fprintf(&script, "?[%s] := %s, *%s{ %s }\n", all, input, r.Name, strings.Join(stored, ", "))
+		fmt.Fprintf(&script, "?[%s] := %s, not *%s{ %s }, %s\n", all, input, r.Name, strings.Join(keys, ", "), strings.Join(defaults, ", "))
+	}
+	fmt.Fprintf(&script, ":put %s { %s }", r.Name, all)
+	return script.String(), params, nil
+}
+
+// deleteScript returns the :rm of the keys of rows.
+func (r Relation) deleteScript(rows []Row) (string, map[string]any, error) {
+	keys := make([]Row, len(rows))
+	for i, row := range rows {
+		keys[i] = Row{}
+		for _, c := range r.Keys {
+			if v, ok := row[c.Name]; ok {
+				keys[i][c.Name] = v
+			}
+		}
+	}
+	keys, err := r.prepare(keys, false)
+	if err != nil || len(keys) == 0 {
+		return "", nil, err
+	}
+	cols := columnNames(r.Keys)
+	list := strings.Join(cols, ", ")
+	script := fmt.Sprintf("?[%s] <- $rows :rm %s { %s }", list, r.Name, list)
+	return script, map[string]any{"rows": table(keys, cols)}, nil
+}
+
+// prepare checks rows against the schema and merges rows sharing a key,
+// keeping first-seen order. With complete set, every value column is
+// required.
+func (r Relation) prepare(rows []Row, complete bool) ([]Row, error) {
+	keyCols := columnNames(r.Keys)
+	byKey := make(map[string]int, len(rows))
+	out := make([]Row, 0, len(rows))
+	for _, row := range rows {
+		for name, v := range row {
+			c, ok := r.column(name)
+			if !ok {
+				return nil, fmt.Errorf("%s has no column %s", r.Name, name)
+			}
+			if err := checkType(c, v); err != nil {
+				return nil, fmt.Errorf("%s: %w", r.Name, err)
+			}
+		}
+		required := r.Keys
+		if complete {
+			required = append(append([]Column(nil), r.Keys...), r.Values...)
+		}
+		for _, c := range required {
+			if _, ok := row[c.Name]; !ok {
+				return nil, fmt.Errorf("%s: missing column %s", r.Name, c.Name)
+			}
+		}
+
+		key := fmt.Sprintf("%#v", table([]Row{row}, keyCols)[0])
+		i, seen := byKey[key]
+		if !seen {
+			byKey[key] = len(out)
+			out = append(out, row)
+			continue
+		}
+		merged := make(Row, len(out[i])+len(row))
+		for k, v := range out[i] {
+			merged[k] = v
+		}
+		for k, v := range row {
+			merged[k] = v
+		}
+		out[i] = merged
+	}
+	return out, nil
+}
+
+func (r Relation) column(name string) (Column, bool) {
+	for _, c := range r.Keys {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	for _, c := range r.Values {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+// columns returns the names of cols that row sets, in order.
+func (row Row) columns(cols []Column) []string {
+	var names []string
+	for _, c := range cols {
+		if _, ok := row[c.Name]; ok {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// table lays rows out as positional tuples of cols.
+func table(rows []Row, cols []string) [][]any {
+	out := make([][]any, len(rows))
+	for i, row := range rows {
+		values := make([]any, len(cols))
+		for j, c := range cols {
+			values[j] = row[c]
+		}
+		out[i] = values
+	}
+	return out
+}
+
+func columnNames(cols []Column) []string {
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// checkType reports whether v can be stored in column c.
+func checkType(c Column, v any) error {
+	ok := false
+	switch c.Type {
+	case ColumnString:
+		_, ok = v.(string)
+	case ColumnInt:
+		switch reflect.ValueOf(v).Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			ok = true
+		}
+	case ColumnVector:
+		switch v.(type) {
+		case []float32, []float64:
+			ok = true
+		}
+	}
+	if !ok {
+		return fmt.Errorf("column %s: unexpected value of type %T", c.Name, v)
+	}
+	return nil
+}
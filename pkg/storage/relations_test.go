@@ -0,0 +1,104 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelations_MatchCIERelations(t *testing.T) {
+	names := make([]string, len(Relations))
+	for i, r := range Relations {
+		names[i] = r.Name
+	}
+	assert.Equal(t, CIERelations, names)
+}
+
+func TestRelation_CreateScript(t *testing.T) {
+	file, ok := LookupRelation("cie_file")
+	require.True(t, ok)
+	assert.Equal(t, `:create cie_file { id: String => path: String, hash: String, language: String, size: Int }`, file.createScript(768))
+
+	emb, _ := LookupRelation("cie_function_embedding")
+	assert.Equal(t, `:create cie_function_embedding { function_id: String => embedding: <F32; 384> }`, emb.createScript(384))
+}
+
+func TestSchemaScript(t *testing.T) {
+	lines := strings.Split(SchemaScript(384), "\n")
+	require.Len(t, lines, len(Relations))
+	assert.Equal(t, Relations[0].createScript(384), lines[0])
+}
+
+func TestRelation_PutScript(t *testing.T) {
+	meta, _ := LookupRelation("cie_project_meta")
+
+	script, params, err := meta.putScript([]Row{
+		{"key": "a", "value": "1"},
+		{"key": "b", "value": "2"},
+		{"key": "a", "value": "3"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "?[key, value] <- $rows :put cie_project_meta { key, value }", script)
+	assert.Equal(t, [][]any{{"a", "3"}, {"b", "2"}}, params["rows"], "the last row for a key wins")
+
+	_, _, err = meta.putScript([]Row{{"key": "a"}})
+	assert.ErrorContains(t, err, "missing column value")
+	_, _, err = meta.putScript([]Row{{"key": "a", "value": 1}})
+	assert.ErrorContains(t, err, "unexpected value of type int")
+	_, _, err = meta.putScript([]Row{{"key": "a", "value": "1", "extra": "x"}})
+	assert.ErrorContains(t, err, "has no column extra")
+
+	script, _, err = meta.putScript(nil)
+	require.NoError(t, err)
+	assert.Empty(t, script)
+}
+
+func TestRelation_UpsertScript(t *testing.T) {
+	fn, _ := LookupRelation("cie_function")
+
+	script, params, err := fn.upsertScript([]Row{
+		{"id": "fn:a", "name": "A"},
+		{"id": "fn:a", "end_line": 12},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{"fn:a", "A", 12}}, params["rows0"], "rows for one key are merged")
+	assert.Contains(t, script, "in0[id, name, end_line] <- $rows0")
+	assert.Contains(t, script, "*cie_function{ id, signature, file_path, start_line, start_col, end_col }")
+	assert.Contains(t, script, "not *cie_function{ id }, signature = '', file_path = '', start_line = 0, start_col = 0, end_col = 0")
+	assert.Contains(t, script, ":put cie_function { id, name, signature, file_path, start_line, end_line, start_col, end_col }")
+
+	emb, _ := LookupRelation("cie_type_embedding")
+	_, _, err = emb.upsertScript([]Row{{"type_id": "t"}})
+	assert.ErrorContains(t, err, "embedding cannot be left out")
+	_, _, err = emb.upsertScript([]Row{{"embedding": []float32{1}}})
+	assert.ErrorContains(t, err, "missing column type_id")
+}
+
+func TestRelation_DeleteScript(t *testing.T) {
+	calls, _ := LookupRelation("cie_calls")
+
+	script, params, err := calls.deleteScript([]Row{{"id": "call:a|b", "caller_id": "a"}, {"id": "call:a|b"}})
+	require.NoError(t, err)
+	assert.Equal(t, "?[id] <- $rows :rm cie_calls { id }", script)
+	assert.Equal(t, [][]any{{"call:a|b"}}, params["rows"])
+}
@@ -0,0 +1,403 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cozo "github.com/kraklabs/cie/pkg/cozodb"
+	"github.com/kraklabs/cie/pkg/errcode"
+)
+
+// RepairOutcome says how RepairDatabase left the data directory.
+type RepairOutcome string
+
+const (
+	// RepairHealthy means every relation read cleanly; nothing was changed.
+	RepairHealthy RepairOutcome = "healthy"
+	// RepairRestored means a full backup could be taken and was restored
+	// into a fresh database.
+	RepairRestored RepairOutcome = "restored"
+	// RepairSalvaged means the backup failed and the readable relations
+	// were copied into a fresh database one by one.
+	RepairSalvaged RepairOutcome = "salvaged"
+	// RepairReset means the database could not be opened; it was moved
+	// aside and an empty directory put in its place.
+	RepairReset RepairOutcome = "reset"
+)
+
+// repairCopyBatch is the number of rows written per :put while salvaging.
+const repairCopyBatch = 1000
+
+// RepairOptions configures RepairDatabase.
+type RepairOptions struct {
+	DataDir             string // database directory, e.g. ~/.cie/data/<project>
+	Engine              string // "rocksdb" (default) or "sqlite"
+	EmbeddingDimensions int    // vector size for recreated embedding relations
+	HNSWDistance        string // metric for the recreated vector indexes ("" for cosine)
+	// Force rebuilds a database that reads cleanly, and resets one that
+	// fails to open for reasons other than corruption or a lock.
+	Force bool
+	// LockTimeout is how long to wait for other CIE processes to release
+	// the directory (DefaultLockTimeout when zero).
+	LockTimeout time.Duration
+}
+
+// RepairResult reports what RepairDatabase did.
+type RepairResult struct {
+	Outcome RepairOutcome  `json:"outcome"`
+	Copied  map[string]int `json:"copied,omitempty"` // rows per relation (salvage only)
+	Lost    []string       `json:"lost,omitempty"`   // relations that could not be read
+	// Quarantine is where the damaged database was moved. It is kept so
+	// nothing is deleted without the user's say-so.
+	Quarantine string `json:"quarantine,omitempty"`
+	Reason     string `json:"reason,omitempty"` // the error that triggered the repair
+}
+
+// IsCorruption reports whether an error from opening or reading a database
+// points at damaged files rather than a lock or permission problem.
+func IsCorruption(err error) bool {
+	return errcode.Classify(err) == errcode.DBCorrupt
+}
+
+// RepairDatabase checks the database in opts.DataDir and rebuilds it when it
+// is damaged. It first tries a CozoDB backup and restores it into a fresh
+// database. If the backup fails, it copies every CIE relation that can still
+// be read. If the database cannot be opened at all, the directory is reset.
+// In every rebuild the old directory is kept as the Quarantine path, and a
+// restored or salvaged database gets a new index version.
+//
+// The data directory is locked for the whole repair (LockDataDir), so CIE
+// processes that share it give it up first and none opens it meanwhile.
+// A database that still fails to open because it is locked is never reset,
+// even with Force.
+func RepairDatabase(opts RepairOptions) (*RepairResult, error) {
+	if opts.Engine == "" {
+		opts.Engine = "rocksdb"
+	}
+	if _, err := os.Stat(opts.DataDir); err != nil {
+		return nil, fmt.Errorf("no local data at %s: %w", opts.DataDir, err)
+	}
+
+	lock, err := LockDataDir(opts.DataDir, opts.LockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	db, err := cozo.New(opts.Engine, engineDBPath(opts.Engine, opts.DataDir), nil)
+	if err != nil {
+		if errcode.Classify(err) == errcode.DBLocked {
+			return nil, fmt.Errorf("open database: %w", err)
+		}
+		if !IsCorruption(err) && !opts.Force {
+			return nil, fmt.Errorf("open database (not recognised as corruption; close other CIE processes or use --force): %w", err)
+		}
+		return resetDataDir(opts.DataDir, err)
+	}
+
+	relations, checkErr := checkRelations(&db)
+	if checkErr == nil && !opts.Force {
+		db.Close()
+		return &RepairResult{Outcome: RepairHealthy}, nil
+	}
+	reason := "forced rebuild"
+	if checkErr != nil {
+		reason = checkErr.Error()
+	}
+
+	staging := StagingDir(opts.DataDir)
+	if err := os.RemoveAll(staging); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("clear %s: %w", staging, err)
+	}
+
+	result, err := restoreFromBackup(&db, opts, staging)
+	if err != nil {
+		result, err = salvageRelations(&db, relations, opts, staging)
+	}
+	db.Close()
+	if err != nil {
+		_ = os.RemoveAll(staging)
+		return nil, err
+	}
+	result.Reason = reason
+
+	quarantine, err := installRepaired(opts.DataDir, staging)
+	if err != nil {
+		return nil, err
+	}
+	result.Quarantine = quarantine
+	return result, nil
+}
+
+// engineDBPath returns the path CozoDB opens for an engine and data dir.
+func engineDBPath(engine, dataDir string) string {
+	if engine == "sqlite" {
+		return filepath.Join(dataDir, SQLiteFileName)
+	}
+	return dataDir
+}
+
+// checkRelations lists the stored relations and scans each CIE relation in
+// full, which forces every data block to be read and checksummed.
+func checkRelations(db *cozo.CozoDB) ([]string, error) {
+	result, err := db.Run("::relations", nil)
+	if err != nil {
+		return nil, fmt.Errorf("list relations: %w", err)
+	}
+	var names []string
+	for _, row := range result.Rows {
+		if len(row) > 0 {
+			if name, ok := row[0].(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	for _, name := range names {
+		if !isCIERelation(name) {
+			continue
+		}
+		if _, err := readRelation(db, name); err != nil {
+			return names, fmt.Errorf("read %s: %w", name, err)
+		}
+	}
+	return names, nil
+}
+
+// isCIERelation reports whether a stored relation name is a CIE relation,
+// with or without a namespace prefix.
+func isCIERelation(name string) bool {
+	name = baseRelation(name)
+	for _, rel := range CIERelations {
+		if name == rel {
+			return true
+		}
+	}
+	return false
+}
+
+// baseRelation strips a namespace prefix from a stored relation name.
+func baseRelation(name string) string {
+	if i := strings.LastIndex(name, namespaceSeparator); i >= 0 {
+		return name[i+len(namespaceSeparator):]
+	}
+	return name
+}
+
+// readRelation returns the column names and every row of a relation.
+func readRelation(db *cozo.CozoDB, name string) (*cozo.NamedRows, error) {
+	cols, err := db.Run("::columns "+name, nil)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(cols.Rows))
+	for _, row := range cols.Rows {
+		if len(row) > 0 {
+			if col, ok := row[0].(string); ok {
+				names = append(names, col)
+			}
+		}
+	}
+	list := strings.Join(names, ", ")
+	rows, err := db.Run(fmt.Sprintf("?[%s] := *%s{%s}", list, name, list), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &rows, nil
+}
+
+// restoreFromBackup writes a backup of db and restores it into a new
+// database at staging.
+func restoreFromBackup(db *cozo.CozoDB, opts RepairOptions, staging string) (*RepairResult, error) {
+	backupPath := filepath.Clean(opts.DataDir) + ".repair-backup"
+	_ = os.Remove(backupPath)
+	defer func() { _ = os.Remove(backupPath) }()
+
+	if err := db.Backup(backupPath); err != nil {
+		return nil, fmt.Errorf("backup: %w", err)
+	}
+	fresh, err := openFresh(opts.Engine, staging)
+	if err != nil {
+		return nil, err
+	}
+	err = fresh.Restore(backupPath)
+	if err == nil {
+		err = bumpIndexVersions(&fresh)
+	}
+	fresh.Close()
+	if err != nil {
+		_ = os.RemoveAll(staging)
+		return nil, fmt.Errorf("restore: %w", err)
+	}
+	return &RepairResult{Outcome: RepairRestored}, nil
+}
+
+// salvageRelations creates the CIE schema in a new database at staging for
+// every namespace found in relations and copies over each relation that
+// can still be read.
+func salvageRelations(db *cozo.CozoDB, relations []string, opts RepairOptions, staging string) (*RepairResult, error) {
+	fresh, err := openFresh(opts.Engine, staging)
+	if err != nil {
+		return nil, err
+	}
+	defer fresh.Close()
+
+	present := make(map[string]bool, len(relations))
+	for _, name := range relations {
+		present[name] = true
+	}
+	namespaces := NamespacesFromRelations(relations)
+	if present["cie_project_meta"] || present["cie_function"] {
+		namespaces = append([]string{""}, namespaces...)
+	}
+
+	result := &RepairResult{Outcome: RepairSalvaged, Copied: make(map[string]int)}
+	for _, ns := range namespaces {
+		target := NewEmbeddedBackendFromDB(&fresh, EmbeddedConfig{
+			EmbeddingDimensions: opts.EmbeddingDimensions,
+			HNSWDistance:        opts.HNSWDistance,
+		})
+		// ns comes from the stored relation names, so it is normalized
+		// already; EmbeddedConfig.Namespace would rewrite it again.
+		target.namespace = ns
+		if err := target.EnsureSchema(); err != nil {
+			return nil, fmt.Errorf("create schema for %q: %w", ns, err)
+		}
+		if err := target.CreateHNSWIndex(target.EmbeddingDimensions()); err != nil {
+			return nil, fmt.Errorf("create vector indexes for %q: %w", ns, err)
+		}
+		for _, rel := range CIERelations {
+			name := QualifyRelations(rel, ns)
+			if !present[name] {
+				continue
+			}
+			n, err := copyRelation(db, &fresh, name)
+			if err != nil {
+				result.Lost = append(result.Lost, name)
+				continue
+			}
+			result.Copied[name] = n
+		}
+		// The copied metadata describes the old indexes, not the new ones.
+		if present[QualifyRelations("cie_project_meta", ns)] {
+			if err := target.SetProjectMeta(HNSWDistanceMetaKey, target.HNSWDistance()); err != nil {
+				return nil, fmt.Errorf("record vector index distance for %q: %w", ns, err)
+			}
+		}
+	}
+	if err := bumpIndexVersions(&fresh); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// bumpIndexVersions records a new index version in every namespace of db,
+// for writers that replace a whole database (repair, restore) instead of
+// going through an EmbeddedBackend. MCP result caches keyed on the old
+// version are dropped.
+func bumpIndexVersions(db *cozo.CozoDB) error {
+	result, err := db.Run("::relations", nil)
+	if err != nil {
+		return fmt.Errorf("list relations: %w", err)
+	}
+	row := []any{IndexVersionMetaKey, newIndexVersion()}
+	for _, r := range result.Rows {
+		if len(r) == 0 {
+			continue
+		}
+		name, ok := r[0].(string)
+		if !ok || baseRelation(name) != "cie_project_meta" {
+			continue
+		}
+		script := fmt.Sprintf("?[key, value] <- $rows :put %s { key, value }", name)
+		if _, err := db.Run(script, map[string]any{"rows": [][]any{row}}); err != nil {
+			return fmt.Errorf("bump index version in %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// copyRelation copies every row of a relation between databases. Rows are
+// written with :put so the target's vector indexes are maintained.
+func copyRelation(from, to *cozo.CozoDB, name string) (int, error) {
+	rows, err := readRelation(from, name)
+	if err != nil {
+		return 0, err
+	}
+	list := strings.Join(rows.Headers, ", ")
+	script := fmt.Sprintf("?[%s] <- $rows :put %s {%s}", list, name, list)
+	for start := 0; start < len(rows.Rows); start += repairCopyBatch {
+		end := min(start+repairCopyBatch, len(rows.Rows))
+		if _, err := to.Run(script, map[string]any{"rows": rows.Rows[start:end]}); err != nil {
+			return start, err
+		}
+	}
+	return len(rows.Rows), nil
+}
+
+// openFresh creates an empty database at dir.
+func openFresh(engine, dir string) (cozo.CozoDB, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return cozo.CozoDB{}, fmt.Errorf("create %s: %w", dir, err)
+	}
+	db, err := cozo.New(engine, engineDBPath(engine, dir), nil)
+	if err != nil {
+		return cozo.CozoDB{}, fmt.Errorf("create repaired database: %w", err)
+	}
+	return db, nil
+}
+
+// quarantineDir returns a fresh path to move a damaged database to.
+func quarantineDir(dataDir string) string {
+	return fmt.Sprintf("%s%s%s", filepath.Clean(dataDir), corruptInfix, time.Now().Format("20060102-150405"))
+}
+
+// installRepaired moves the damaged database aside and the repaired one into
+// its place, undoing the first move if the second fails.
+func installRepaired(dataDir, staging string) (string, error) {
+	quarantine := quarantineDir(dataDir)
+	if err := os.Rename(dataDir, quarantine); err != nil {
+		return "", fmt.Errorf("move damaged index aside: %w", err)
+	}
+	if err := os.Rename(staging, dataDir); err != nil {
+		if rbErr := os.Rename(quarantine, dataDir); rbErr != nil {
+			return "", fmt.Errorf("install repaired index: %w (restoring the original also failed: %v; it is at %s)", err, rbErr, quarantine)
+		}
+		return "", fmt.Errorf("install repaired index: %w", err)
+	}
+	return quarantine, nil
+}
+
+// resetDataDir moves an unopenable database aside and leaves an empty
+// directory for the next index run.
+func resetDataDir(dataDir string, cause error) (*RepairResult, error) {
+	quarantine := quarantineDir(dataDir)
+	if err := os.Rename(dataDir, quarantine); err != nil {
+		return nil, fmt.Errorf("move damaged index aside: %w", err)
+	}
+	if err := os.MkdirAll(dataDir, 0750); err != nil {
+		return nil, fmt.Errorf("recreate %s: %w", dataDir, err)
+	}
+	return &RepairResult{Outcome: RepairReset, Quarantine: quarantine, Reason: cause.Error()}, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIsCorruption(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("Corruption: block checksum mismatch: stored = 1, computed = 2"), true},
+		{errors.New("Corruption: Can't access /data/000123.sst: IO error: No such file or directory"), true},
+		{errors.New("IO error: While open a file for random read: /data/000123.sst: Too many open files"), false},
+		{errors.New("database disk image is malformed"), true},
+		{errors.New("IO error: While lock file: /data/LOCK: Resource temporarily unavailable"), false},
+		{errors.New("permission denied"), false},
+	}
+	for _, tt := range tests {
+		if got := IsCorruption(tt.err); got != tt.want {
+			t.Errorf("IsCorruption(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestIsCIERelation(t *testing.T) {
+	for name, want := range map[string]bool{
+		"cie_function":          true,
+		"acme_api__cie_calls":   true,
+		"cie_embedding_cache":   false,
+		"acme__my_own_relation": false,
+	} {
+		if got := isCIERelation(name); got != want {
+			t.Errorf("isCIERelation(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestRepairDatabase_LocksDataDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "proj")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	held, err := LockDataDir(dir, time.Second)
+	if err != nil {
+		t.Fatalf("LockDataDir: %v", err)
+	}
+	defer held.Unlock()
+
+	_, err = RepairDatabase(RepairOptions{DataDir: dir, Force: true, LockTimeout: 100 * time.Millisecond})
+	if !errors.Is(err, ErrDataDirLocked) {
+		t.Fatalf("RepairDatabase on a locked directory: err = %v, want ErrDataDirLocked", err)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("locked directory must be left in place: %v", err)
+	}
+}
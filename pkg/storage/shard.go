@@ -0,0 +1,249 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ShardsDirName is the directory inside DataDir that holds one CozoDB store
+// per shard when EmbeddedConfig.Sharded is set.
+const ShardsDirName = "shards"
+
+// ShardFor returns the shard that stores a repository path: its top-level
+// directory, or "" (the main store) for files at the repository root.
+func ShardFor(path string) string {
+	path = strings.TrimPrefix(strings.ReplaceAll(path, `\`, "/"), "./")
+	if i := strings.IndexByte(path, '/'); i > 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// validShardName rejects names that would escape the shards directory.
+func validShardName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// shardSet holds the per-directory stores of a sharded backend. Stores are
+// opened when the backend opens (for shards already on disk) or on first
+// write.
+type shardSet struct {
+	config EmbeddedConfig // of the main store, with DataDir and Engine resolved
+	mu     sync.Mutex
+	stores map[string]*EmbeddedBackend
+}
+
+// openShards opens every shard already present under config.DataDir.
+func openShards(config EmbeddedConfig) (*shardSet, error) {
+	set := &shardSet{config: config, stores: make(map[string]*EmbeddedBackend)}
+	entries, err := os.ReadDir(filepath.Join(config.DataDir, ShardsDirName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("list shards: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := set.open(entry.Name()); err != nil {
+			set.closeAll()
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+// open returns the store for name, creating it with the CIE schema if needed.
+func (s *shardSet) open(name string) (*EmbeddedBackend, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if store, ok := s.stores[name]; ok {
+		return store, nil
+	}
+	store, err := NewEmbeddedBackend(EmbeddedConfig{
+		DataDir:             filepath.Join(s.config.DataDir, ShardsDirName, name),
+		Engine:              s.config.Engine,
+		EmbeddingDimensions: s.config.EmbeddingDimensions,
+		HNSWDistance:        s.config.HNSWDistance,
+		Namespace:           s.config.Namespace,
+		Shared:              s.config.Shared,
+		SharedIdle:          s.config.SharedIdle,
+		LockTimeout:         s.config.LockTimeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open shard %q: %w", name, err)
+	}
+	if store.Released() {
+		// Shared and held by an index run, which creates the schema itself.
+		s.stores[name] = store
+		return store, nil
+	}
+	if err := store.EnsureSchema(); err != nil {
+		_ = store.Close()
+		return nil, fmt.Errorf("create schema in shard %q: %w", name, err)
+	}
+	_ = store.CreateHNSWIndex(store.embeddingDimensions)
+	s.stores[name] = store
+	return store, nil
+}
+
+// get returns the store for name, or nil if the shard does not exist.
+func (s *shardSet) get(name string) *EmbeddedBackend {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stores[name]
+}
+
+// list returns the open stores ordered by shard name.
+func (s *shardSet) list() []*EmbeddedBackend {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.stores))
+	for name := range s.stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	stores := make([]*EmbeddedBackend, len(names))
+	for i, name := range names {
+		stores[i] = s.stores[name]
+	}
+	return stores
+}
+
+func (s *shardSet) closeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, store := range s.stores {
+		_ = store.Close()
+		delete(s.stores, name)
+	}
+}
+
+// Sharded reports whether each top-level directory is stored separately.
+func (b *EmbeddedBackend) Sharded() bool {
+	return b.shards != nil
+}
+
+// Shard returns the store holding the files of one top-level directory,
+// creating it on first use. The empty name returns b itself, which holds
+// root-level files and project metadata. On an unsharded backend every name
+// returns b.
+func (b *EmbeddedBackend) Shard(name string) (*EmbeddedBackend, error) {
+	if b.shards == nil || name == "" {
+		return b, nil
+	}
+	if !validShardName(name) {
+		return nil, fmt.Errorf("invalid shard name %q", name)
+	}
+	return b.shards.open(name)
+}
+
+// ShardNames lists the shards that exist, sorted. The main store is not
+// included.
+func (b *EmbeddedBackend) ShardNames() []string {
+	if b.shards == nil {
+		return nil
+	}
+	b.shards.mu.Lock()
+	defer b.shards.mu.Unlock()
+
+	names := make([]string, 0, len(b.shards.stores))
+	for name := range b.shards.stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DropShard deletes a shard's store and its files, so the directory can be
+// reindexed from scratch. Dropping a shard that does not exist is a no-op.
+func (b *EmbeddedBackend) DropShard(name string) error {
+	if b.shards == nil {
+		return fmt.Errorf("storage is not sharded")
+	}
+	if !validShardName(name) {
+		return fmt.Errorf("invalid shard name %q", name)
+	}
+
+	b.shards.mu.Lock()
+	defer b.shards.mu.Unlock()
+
+	if store, ok := b.shards.stores[name]; ok {
+		_ = store.Close()
+		delete(b.shards.stores, name)
+	}
+	if err := os.RemoveAll(filepath.Join(b.shards.config.DataDir, ShardsDirName, name)); err != nil {
+		return fmt.Errorf("remove shard %q: %w", name, err)
+	}
+	return nil
+}
+
+// View returns a backend that shares b's database and shards but whose
+// Close does nothing, for handing to code that closes what it is given.
+func (b *EmbeddedBackend) View() *EmbeddedBackend {
+	return &EmbeddedBackend{
+		handle:              b.handle,
+		namespace:           b.namespace,
+		view:                true,
+		embeddingDimensions: b.embeddingDimensions,
+		hnswDistance:        b.hnswDistance,
+		shards:              b.shards,
+	}
+}
+
+// queryShards runs datalog with params against the main store and every
+// shard, and merges their rows into what the query would return on a single
+// store (see planShardMerge): aggregates are combined, and :order, :offset
+// and :limit apply to the merged rows, so counts and top-k searches cover
+// the whole index. Joins still never cross shards.
+func (b *EmbeddedBackend) queryShards(ctx context.Context, datalog string, params map[string]any) (*QueryResult, error) {
+	plan, script, mergeable := planShardMerge(datalog)
+	result := &QueryResult{}
+	for _, store := range append([]*EmbeddedBackend{b.mainStore()}, b.shards.list()...) {
+		rows, err := store.QueryWithParams(ctx, script, params)
+		if err != nil {
+			return nil, err
+		}
+		if len(result.Headers) == 0 {
+			result.Headers = rows.Headers
+		}
+		result.Rows = append(result.Rows, rows.Rows...)
+	}
+	if mergeable {
+		result.Rows = plan.merge(result.Headers, result.Rows)
+	}
+	return result, nil
+}
+
+// mainStore returns b without its shards, for querying the main store alone.
+func (b *EmbeddedBackend) mainStore() *EmbeddedBackend {
+	main := b.View()
+	main.shards = nil
+	return main
+}
@@ -0,0 +1,280 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// shardMerge describes how the rows of one query, run on every store of a
+// sharded backend, combine into the rows the query would return on a single
+// store: aggregates are combined per group, rows are deduplicated as CozoDB
+// does for ? rules, then :order, :offset and :limit apply to the whole.
+type shardMerge struct {
+	aggregates []string // Per head column: aggregation name, or "" for a group key
+	order      []shardOrder
+	offset     int
+	limit      int // -1 when the query sets none
+}
+
+// shardOrder is one :order term.
+type shardOrder struct {
+	column string
+	desc   bool
+}
+
+// mergeableAggregates are the aggregations whose per-shard results combine
+// exactly.
+var mergeableAggregates = map[string]bool{
+	"count": true, "sum": true, "min": true, "max": true,
+	"collect": true, "union": true, "or": true, "and": true,
+}
+
+var (
+	queryHeadPattern    = regexp.MustCompile(`\?\[([^\]]*)\]`)
+	headColumnPattern   = regexp.MustCompile(`^(\w+)\s*\(`)
+	queryOptionPattern  = regexp.MustCompile(`:(order|sort|limit|offset)\b([^:\n]*)`)
+	limitOptionPattern  = regexp.MustCompile(`:limit\s+\d+`)
+	offsetOptionPattern = regexp.MustCompile(`:offset\s+\d+`)
+)
+
+// planShardMerge works out how to merge a query across shards. ok is false
+// for scripts it cannot merge (chained queries, aggregations such as mean
+// that do not combine, non-literal limits): their rows are concatenated.
+// shardScript is the script to run on each store, with :offset dropped and
+// :limit raised to cover it.
+func planShardMerge(script string) (plan shardMerge, shardScript string, ok bool) {
+	stripped := StripLiterals(script)
+	if strings.HasPrefix(strings.TrimSpace(stripped), "{") {
+		return plan, script, false // chained queries
+	}
+	head := queryHeadPattern.FindStringSubmatch(stripped)
+	if head == nil {
+		return plan, script, false
+	}
+	for _, column := range splitHead(head[1]) {
+		agg := ""
+		if m := headColumnPattern.FindStringSubmatch(column); m != nil {
+			agg = m[1]
+			if !mergeableAggregates[agg] {
+				return plan, script, false
+			}
+		}
+		plan.aggregates = append(plan.aggregates, agg)
+	}
+
+	plan.limit = -1
+	for _, m := range queryOptionPattern.FindAllStringSubmatch(stripped, -1) {
+		value := strings.TrimSpace(m[2])
+		switch m[1] {
+		case "order", "sort":
+			for _, term := range strings.Split(value, ",") {
+				term = strings.TrimSpace(term)
+				if term == "" {
+					continue
+				}
+				o := shardOrder{column: strings.TrimPrefix(strings.TrimPrefix(term, "+"), "-")}
+				o.desc = strings.HasPrefix(term, "-")
+				plan.order = append(plan.order, o)
+			}
+		case "limit", "offset":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return plan, script, false
+			}
+			if m[1] == "limit" {
+				plan.limit = n
+			} else {
+				plan.offset = n
+			}
+		}
+	}
+
+	shardScript = script
+	if plan.offset > 0 {
+		shardScript = offsetOptionPattern.ReplaceAllString(shardScript, "")
+		if plan.limit >= 0 {
+			shardScript = limitOptionPattern.ReplaceAllString(shardScript, fmt.Sprintf(":limit %d", plan.limit+plan.offset))
+		}
+	}
+	return plan, shardScript, true
+}
+
+// splitHead splits a rule head on its top-level commas.
+func splitHead(head string) []string {
+	var columns []string
+	depth, start := 0, 0
+	for i, c := range head {
+		switch c {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				columns = append(columns, strings.TrimSpace(head[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(head[start:]); last != "" {
+		columns = append(columns, last)
+	}
+	return columns
+}
+
+// merge combines the rows every store returned for the query.
+func (m shardMerge) merge(headers []string, rows [][]any) [][]any {
+	if len(m.aggregates) != len(headers) {
+		return rows
+	}
+	rows = m.combine(rows)
+	m.sort(headers, rows)
+	if m.offset > 0 {
+		rows = rows[min(m.offset, len(rows)):]
+	}
+	if m.limit >= 0 && len(rows) > m.limit {
+		rows = rows[:m.limit]
+	}
+	return rows
+}
+
+// combine folds rows with the same group keys into one, combining their
+// aggregate columns. Without aggregates it drops duplicate rows.
+func (m shardMerge) combine(rows [][]any) [][]any {
+	groups := make(map[string]int, len(rows))
+	out := make([][]any, 0, len(rows))
+	for _, row := range rows {
+		if len(row) != len(m.aggregates) {
+			out = append(out, row)
+			continue
+		}
+		keys := make([]any, 0, len(row))
+		for i, agg := range m.aggregates {
+			if agg == "" {
+				keys = append(keys, row[i])
+			}
+		}
+		data, _ := json.Marshal(keys)
+		key := string(data)
+		i, seen := groups[key]
+		if !seen {
+			groups[key] = len(out)
+			out = append(out, append([]any(nil), row...))
+			continue
+		}
+		for c, agg := range m.aggregates {
+			if agg != "" {
+				out[i][c] = combineAggregate(agg, out[i][c], row[c])
+			}
+		}
+	}
+	return out
+}
+
+// combineAggregate merges two per-shard values of one aggregation.
+func combineAggregate(agg string, a, b any) any {
+	switch agg {
+	case "count", "sum":
+		x, xok := a.(float64)
+		y, yok := b.(float64)
+		if xok && yok {
+			return x + y
+		}
+	case "min":
+		if compareValues(b, a) < 0 {
+			return b
+		}
+	case "max":
+		if compareValues(b, a) > 0 {
+			return b
+		}
+	case "or":
+		x, _ := a.(bool)
+		y, _ := b.(bool)
+		return x || y
+	case "and":
+		x, _ := a.(bool)
+		y, _ := b.(bool)
+		return x && y
+	case "collect", "union":
+		x, _ := a.([]any)
+		y, _ := b.([]any)
+		return append(append([]any(nil), x...), y...)
+	}
+	return a
+}
+
+// sort orders rows by the :order terms that name a column.
+func (m shardMerge) sort(headers []string, rows [][]any) {
+	type key struct {
+		col  int
+		desc bool
+	}
+	var keys []key
+	for _, o := range m.order {
+		for i, h := range headers {
+			if h == o.column {
+				keys = append(keys, key{i, o.desc})
+				break
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, k := range keys {
+			if k.col >= len(rows[i]) || k.col >= len(rows[j]) {
+				continue
+			}
+			if c := compareValues(rows[i][k.col], rows[j][k.col]); c != 0 {
+				return (c < 0) != k.desc
+			}
+		}
+		return false
+	})
+}
+
+// compareValues orders two query values: numbers numerically, strings
+// lexically, anything else by its printed form.
+func compareValues(a, b any) int {
+	if x, ok := a.(float64); ok {
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	if x, ok := a.(string); ok {
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y)
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShardMerge_Aggregates(t *testing.T) {
+	plan, script, ok := planShardMerge(`?[lang, count(f)] := *cie_file { id: f, language: lang } :order -count(f) :limit 2`)
+	if !ok {
+		t.Fatal("expected a mergeable query")
+	}
+	if script != `?[lang, count(f)] := *cie_file { id: f, language: lang } :order -count(f) :limit 2` {
+		t.Errorf("script without :offset should be unchanged, got %s", script)
+	}
+	rows := plan.merge([]string{"lang", "count(f)"}, [][]any{
+		{"go", float64(3)}, {"python", float64(1)}, // main store
+		{"go", float64(4)}, {"rust", float64(5)}, // shard a
+		{"python", float64(2)}, // shard b
+	})
+	want := [][]any{{"go", float64(7)}, {"rust", float64(5)}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("merge = %v, want %v", rows, want)
+	}
+}
+
+func TestShardMerge_TotalCount(t *testing.T) {
+	plan, _, ok := planShardMerge(`?[count(f)] := *cie_function { id: f }`)
+	if !ok {
+		t.Fatal("expected a mergeable query")
+	}
+	rows := plan.merge([]string{"count(f)"}, [][]any{{float64(10)}, {float64(0)}, {float64(32)}})
+	if want := [][]any{{float64(42)}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("merge = %v, want %v", rows, want)
+	}
+}
+
+func TestShardMerge_TopK(t *testing.T) {
+	plan, script, ok := planShardMerge(`?[name, distance] := ~cie_function_embedding:embedding_idx { function_id | query: q, k: 3, bind_distance: distance }, *cie_function { id: function_id, name }
+		:order distance
+		:limit 2
+		:offset 1`)
+	if !ok {
+		t.Fatal("expected a mergeable query")
+	}
+	if !strings.Contains(script, ":limit 3") || strings.Contains(script, ":offset") {
+		t.Errorf("shard script should fetch limit+offset rows without :offset, got %s", script)
+	}
+	rows := plan.merge([]string{"name", "distance"}, [][]any{
+		{"a", 0.4}, {"b", 0.1}, {"c", 0.3},
+		{"d", 0.2}, {"b", 0.1},
+	})
+	want := [][]any{{"d", 0.2}, {"c", 0.3}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("merge = %v, want %v", rows, want)
+	}
+}
+
+func TestShardMerge_Unmergeable(t *testing.T) {
+	for _, script := range []string{
+		`?[mean(x)] := *cie_function { start_line: x }`,
+		`{ ?[a] := a = 1 } { ?[b] := b = 2 }`,
+		`?[id] := *cie_function { id } :limit $n`,
+	} {
+		if _, got, ok := planShardMerge(script); ok || got != script {
+			t.Errorf("planShardMerge(%q) should leave the script alone and not merge", script)
+		}
+	}
+}
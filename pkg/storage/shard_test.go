@@ -0,0 +1,139 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShardFor(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"main.go", ""},
+		{"./main.go", ""},
+		{"services/api/handler.go", "services"},
+		{"./web/src/app.ts", "web"},
+		{`tools\gen\main.go`, "tools"},
+		{".github/workflows/ci.yml", ".github"},
+	}
+	for _, tt := range tests {
+		if got := ShardFor(tt.path); got != tt.want {
+			t.Errorf("ShardFor(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestShard_Unsharded(t *testing.T) {
+	root := &EmbeddedBackend{handle: &dbHandle{}, embeddingDimensions: 768}
+	if root.Sharded() {
+		t.Fatal("backend without shards reported Sharded")
+	}
+	store, err := root.Shard("services")
+	if err != nil || store != root {
+		t.Errorf("Shard on unsharded backend = %v, %v; want the backend itself", store, err)
+	}
+	if err := root.DropShard("services"); err == nil {
+		t.Error("DropShard on unsharded backend should fail")
+	}
+}
+
+func TestShard_RejectsEscapingNames(t *testing.T) {
+	root := &EmbeddedBackend{handle: &dbHandle{}, shards: &shardSet{stores: map[string]*EmbeddedBackend{}}}
+	for _, name := range []string{"..", ".", "a/b", `a\b`} {
+		if _, err := root.Shard(name); err == nil {
+			t.Errorf("Shard(%q) should fail", name)
+		}
+		if err := root.DropShard(name); err == nil {
+			t.Errorf("DropShard(%q) should fail", name)
+		}
+	}
+}
+
+func TestShardedBackend_RoutesAndFansOut(t *testing.T) {
+	dir := t.TempDir()
+	backend, err := NewEmbeddedBackend(EmbeddedConfig{DataDir: dir, Engine: "sqlite", Sharded: true})
+	if err != nil {
+		t.Fatalf("NewEmbeddedBackend: %v", err)
+	}
+	if err := backend.EnsureSchema(); err != nil {
+		t.Fatalf("EnsureSchema: %v", err)
+	}
+
+	ctx := context.Background()
+	put := func(store *EmbeddedBackend, id, path string) {
+		t.Helper()
+		script := `?[id, path, hash, language, size] <- [["` + id + `", "` + path + `", "", "go", 0]] :put cie_file { id => path, hash, language, size }`
+		if err := store.Execute(ctx, script); err != nil {
+			t.Fatalf("put %s: %v", path, err)
+		}
+	}
+	for _, path := range []string{"main.go", "api/server.go", "web/app.go"} {
+		store, err := backend.Shard(ShardFor(path))
+		if err != nil {
+			t.Fatalf("Shard(%q): %v", ShardFor(path), err)
+		}
+		put(store, "f:"+path, path)
+	}
+
+	countFiles := func(b *EmbeddedBackend) int {
+		t.Helper()
+		result, err := b.Query(ctx, `?[path] := *cie_file { path }`)
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		return len(result.Rows)
+	}
+	if got := countFiles(backend); got != 3 {
+		t.Errorf("fan-out query returned %d files, want 3", got)
+	}
+	if got := backend.ShardNames(); len(got) != 2 || got[0] != "api" || got[1] != "web" {
+		t.Errorf("ShardNames() = %v, want [api web]", got)
+	}
+
+	if err := backend.DeleteEntitiesForFile("api/server.go"); err != nil {
+		t.Fatalf("DeleteEntitiesForFile: %v", err)
+	}
+	if err := backend.DropShard("web"); err != nil {
+		t.Fatalf("DropShard: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ShardsDirName, "web")); !os.IsNotExist(err) {
+		t.Errorf("dropped shard directory still exists: %v", err)
+	}
+	if got := countFiles(backend); got != 1 {
+		t.Errorf("after delete and drop, query returned %d files, want 1", got)
+	}
+	if err := backend.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Shards on disk are found again when the backend is reopened
+	reopened, err := NewEmbeddedBackend(EmbeddedConfig{DataDir: dir, Engine: "sqlite", Sharded: true})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer func() { _ = reopened.Close() }()
+	if got := reopened.ShardNames(); len(got) != 1 || got[0] != "api" {
+		t.Errorf("ShardNames() after reopen = %v, want [api]", got)
+	}
+}
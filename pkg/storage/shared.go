@@ -0,0 +1,179 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	cozo "github.com/kraklabs/cie/pkg/cozodb"
+)
+
+// DefaultSharedIdle is how long a shared backend keeps its database open
+// after the last request when EmbeddedConfig.SharedIdle is not set.
+const DefaultSharedIdle = 2 * time.Second
+
+// sharedLockTimeout bounds how long a shared backend waits to reopen its
+// database once no index run is writing. It covers another shared backend's
+// idle period.
+const sharedLockTimeout = 5 * time.Second
+
+// errBackendClosed is returned by operations on a closed backend.
+var errBackendClosed = errors.New("backend is closed")
+
+// sharedOpen is the state of a shared handle (EmbeddedConfig.Shared): the
+// database is opened by the first request after it was released, and
+// released when no request came for the idle period or as soon as a writer
+// waits for the data directory.
+type sharedOpen struct {
+	engine      string
+	dbPath      string
+	idle        time.Duration
+	lockTimeout time.Duration // how long a request waits for an index run
+
+	mu       sync.Mutex
+	lastUsed time.Time
+	timer    *time.Timer
+}
+
+// rlock takes h's read lock for one operation, reopening a released shared
+// database first. Call runlock when done.
+func (h *dbHandle) rlock() error {
+	for {
+		h.mu.RLock()
+		if h.closed {
+			h.mu.RUnlock()
+			return errBackendClosed
+		}
+		if h.db != nil {
+			return nil
+		}
+		h.mu.RUnlock()
+
+		// Released: reopen under the write lock, then retry for a read lock.
+		if err := h.lock(); err != nil {
+			return err
+		}
+		h.mu.Unlock()
+	}
+}
+
+// runlock releases the read lock taken by rlock.
+func (h *dbHandle) runlock() {
+	h.mu.RUnlock()
+	h.used()
+}
+
+// lock takes h's write lock, reopening a released shared database first.
+// Call unlock when done.
+func (h *dbHandle) lock() error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return errBackendClosed
+	}
+	if h.db == nil {
+		if err := h.reopenLocked(h.shared.lockTimeout); err != nil {
+			h.mu.Unlock()
+			return err
+		}
+	}
+	return nil
+}
+
+// unlock releases the write lock taken by lock.
+func (h *dbHandle) unlock() {
+	h.mu.Unlock()
+	h.used()
+}
+
+// reopenLocked opens a shared handle's database, waiting up to wait for an
+// index run that is writing to finish. h.mu must be held for writing.
+func (h *dbHandle) reopenLocked(wait time.Duration) error {
+	if err := waitForWriter(h.dataDir, wait); err != nil {
+		return err
+	}
+	lock, err := waitFlock(LockPath(h.dataDir), sharedLockTimeout)
+	if err != nil {
+		return err
+	}
+	db, err := cozo.New(h.shared.engine, h.shared.dbPath, nil)
+	if err != nil {
+		_ = lock.Close()
+		return fmt.Errorf("open cozodb: %w", err)
+	}
+	h.db = &db
+	h.dirLock = &DataDirLock{lock: lock}
+	return nil
+}
+
+// used restarts a shared handle's idle timer, or has the database released
+// right away if a writer is waiting for it.
+func (h *dbHandle) used() {
+	s := h.shared
+	if s == nil {
+		return
+	}
+	delay := s.idle
+	if writerWaiting(h.dataDir) {
+		delay = 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastUsed = time.Now()
+	if s.timer == nil {
+		s.timer = time.AfterFunc(delay, h.releaseIdle)
+		return
+	}
+	s.timer.Reset(delay)
+}
+
+// releaseIdle closes a shared handle's database and gives up the data
+// directory, unless a request came in since the timer was set and no writer
+// is waiting. The next request reopens it.
+func (h *dbHandle) releaseIdle() {
+	s := h.shared
+	s.mu.Lock()
+	recent := time.Since(s.lastUsed) < s.idle
+	s.mu.Unlock()
+	if recent && !writerWaiting(h.dataDir) {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed || h.db == nil {
+		return
+	}
+	h.db.Close()
+	h.db = nil
+	h.dirLock.Unlock()
+	h.dirLock = nil
+}
+
+// Released reports whether a shared backend has closed its database until
+// the next request. It is always false for other backends.
+func (b *EmbeddedBackend) Released() bool {
+	b.handle.mu.RLock()
+	defer b.handle.mu.RUnlock()
+	return b.handle.shared != nil && b.handle.db == nil && !b.handle.closed
+}
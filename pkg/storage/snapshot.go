@@ -0,0 +1,251 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	cozo "github.com/kraklabs/cie/pkg/cozodb"
+)
+
+// SnapshotsDirName is the directory inside DataDir that holds index
+// snapshots, one CozoDB backup file per snapshot.
+const SnapshotsDirName = "snapshots"
+
+// errReadOnly is returned by writes to a snapshot.
+var errReadOnly = errors.New("snapshot is read-only")
+
+// snapshotExt is the extension of snapshot files. CozoDB backups are SQLite
+// databases, so a snapshot opens with the sqlite engine as it is.
+const snapshotExt = ".db"
+
+// Snapshot describes a saved copy of the index.
+type Snapshot struct {
+	Name    string    `json:"name"` // usually the commit SHA it was taken at
+	Path    string    `json:"path"`
+	Created time.Time `json:"created"`
+	Size    int64     `json:"size"`
+}
+
+// validSnapshotName accepts commit SHAs, tags and other plain names.
+func validSnapshotName(name string) bool {
+	if name == "" || strings.HasPrefix(name, ".") {
+		return false
+	}
+	for _, c := range name {
+		if c > 0x7f || !(isIdentByte(byte(c)) || c == '.' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// Snapshot saves a copy of the whole database, all namespaces included, as
+// snapshot name (typically the commit SHA just indexed). An existing
+// snapshot of that name is replaced. Sharded backends cannot be
+// snapshotted: the shards are separate databases.
+func (b *EmbeddedBackend) Snapshot(name string) (Snapshot, error) {
+	if !validSnapshotName(name) {
+		return Snapshot{}, fmt.Errorf("invalid snapshot name %q", name)
+	}
+	if b.handle.dataDir == "" {
+		return Snapshot{}, fmt.Errorf("snapshot %s: backend has no data directory", name)
+	}
+	if b.shards != nil {
+		return Snapshot{}, fmt.Errorf("snapshot %s: sharded storage is not supported", name)
+	}
+	dir := filepath.Join(b.handle.dataDir, SnapshotsDirName)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return Snapshot{}, fmt.Errorf("create snapshots dir: %w", err)
+	}
+
+	// CozoDB refuses to back up over an existing file, and a reader must
+	// never see half a backup, so write aside and rename into place.
+	path := filepath.Join(dir, name+snapshotExt)
+	tmp := path + ".tmp"
+	_ = os.Remove(tmp)
+
+	if err := b.Backup(tmp); err != nil {
+		_ = os.Remove(tmp)
+		return Snapshot{}, fmt.Errorf("snapshot %s: %w", name, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return Snapshot{}, fmt.Errorf("snapshot %s: %w", name, err)
+	}
+	return snapshotAt(path)
+}
+
+// Backup writes a copy of the whole database, all namespaces included, to
+// path, which must not exist. The copy is a SQLite database whichever
+// engine the backend uses; RestoreBackup loads it into a new data directory.
+func (b *EmbeddedBackend) Backup(path string) error {
+	if b.shards != nil {
+		return fmt.Errorf("backup: sharded storage is not supported")
+	}
+	if err := b.handle.rlock(); err != nil {
+		return err
+	}
+	defer b.handle.runlock()
+	return b.handle.db.Backup(path)
+}
+
+// RestoreBackup creates a database with engine in dataDir, which must not
+// hold one yet, and loads the backup at backupPath into it under a new
+// index version.
+func RestoreBackup(backupPath, dataDir, engine string) error {
+	if engine == "" {
+		engine = "rocksdb"
+	}
+	db, err := openFresh(engine, dataDir)
+	if err != nil {
+		return err
+	}
+	err = db.Restore(backupPath)
+	if err == nil {
+		err = bumpIndexVersions(&db)
+	}
+	db.Close()
+	if err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+	return nil
+}
+
+// DataDir returns the directory the backend was opened from, or "" for a
+// database the caller opened.
+func (b *EmbeddedBackend) DataDir() string {
+	return b.handle.dataDir
+}
+
+// ListSnapshots returns the snapshots under dataDir, newest first.
+func ListSnapshots(dataDir string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(filepath.Join(dataDir, SnapshotsDirName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list snapshots: %w", err)
+	}
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != snapshotExt {
+			continue
+		}
+		s, err := snapshotAt(filepath.Join(dataDir, SnapshotsDirName, entry.Name()))
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, s)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		if !snapshots[i].Created.Equal(snapshots[j].Created) {
+			return snapshots[i].Created.After(snapshots[j].Created)
+		}
+		return snapshots[i].Name < snapshots[j].Name
+	})
+	return snapshots, nil
+}
+
+// PruneSnapshots deletes all but the keep newest snapshots under dataDir
+// and returns the names it deleted.
+func PruneSnapshots(dataDir string, keep int) ([]string, error) {
+	snapshots, err := ListSnapshots(dataDir)
+	if err != nil || len(snapshots) <= keep {
+		return nil, err
+	}
+	var removed []string
+	for _, s := range snapshots[max(keep, 0):] {
+		if err := os.Remove(s.Path); err != nil {
+			return removed, fmt.Errorf("remove snapshot %s: %w", s.Name, err)
+		}
+		removed = append(removed, s.Name)
+	}
+	return removed, nil
+}
+
+// FindSnapshot resolves ref to a snapshot under dataDir: an exact name, or
+// a prefix of at least four characters matching exactly one snapshot, so a
+// short commit SHA works.
+func FindSnapshot(dataDir, ref string) (Snapshot, error) {
+	snapshots, err := ListSnapshots(dataDir)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	var matches []Snapshot
+	for _, s := range snapshots {
+		if s.Name == ref {
+			return s, nil
+		}
+		if len(ref) >= 4 && strings.HasPrefix(s.Name, ref) {
+			matches = append(matches, s)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Snapshot{}, fmt.Errorf("no snapshot matches %q", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return Snapshot{}, fmt.Errorf("snapshot %q is ambiguous: %d snapshots match", ref, len(matches))
+	}
+}
+
+// OpenSnapshot opens the snapshot ref (see FindSnapshot) under
+// config.DataDir as a read-only backend. Namespace and EmbeddingDimensions
+// are taken from config; Engine and Sharded are ignored.
+func OpenSnapshot(config EmbeddedConfig, ref string) (*EmbeddedBackend, error) {
+	s, err := FindSnapshot(config.DataDir, ref)
+	if err != nil {
+		return nil, err
+	}
+	db, err := cozo.New("sqlite", s.Path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("open snapshot %s: %w", s.Name, err)
+	}
+	dim := config.EmbeddingDimensions
+	if dim <= 0 {
+		dim = 768
+	}
+	return &EmbeddedBackend{
+		handle:              &dbHandle{db: &db, readOnly: true},
+		namespace:           NormalizeNamespace(config.Namespace),
+		embeddingDimensions: dim,
+	}, nil
+}
+
+func snapshotAt(path string) (Snapshot, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{
+		Name:    strings.TrimSuffix(filepath.Base(path), snapshotExt),
+		Path:    path,
+		Created: info.ModTime(),
+		Size:    info.Size(),
+	}, nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSnapshotFile fakes a snapshot taken age ago.
+func writeSnapshotFile(t *testing.T, dataDir, name string, age time.Duration) {
+	t.Helper()
+	dir := filepath.Join(dataDir, SnapshotsDirName)
+	require.NoError(t, os.MkdirAll(dir, 0o750))
+	path := filepath.Join(dir, name+snapshotExt)
+	require.NoError(t, os.WriteFile(path, []byte("backup"), 0o600))
+	when := time.Now().Add(-age)
+	require.NoError(t, os.Chtimes(path, when, when))
+}
+
+func TestListSnapshots_NewestFirst(t *testing.T) {
+	dataDir := t.TempDir()
+	snapshots, err := ListSnapshots(dataDir)
+	require.NoError(t, err)
+	assert.Empty(t, snapshots, "no snapshots directory is not an error")
+
+	writeSnapshotFile(t, dataDir, "aaaa111", 2*time.Hour)
+	writeSnapshotFile(t, dataDir, "bbbb222", time.Hour)
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, SnapshotsDirName, "cccc333.db.tmp"), nil, 0o600))
+
+	snapshots, err = ListSnapshots(dataDir)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2, "unfinished backups are not listed")
+	assert.Equal(t, "bbbb222", snapshots[0].Name)
+	assert.Equal(t, "aaaa111", snapshots[1].Name)
+}
+
+func TestPruneSnapshots_KeepsNewest(t *testing.T) {
+	dataDir := t.TempDir()
+	writeSnapshotFile(t, dataDir, "old", 3*time.Hour)
+	writeSnapshotFile(t, dataDir, "mid", 2*time.Hour)
+	writeSnapshotFile(t, dataDir, "new", time.Hour)
+
+	removed, err := PruneSnapshots(dataDir, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old"}, removed)
+
+	snapshots, err := ListSnapshots(dataDir)
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 2)
+}
+
+func TestFindSnapshot_ByPrefix(t *testing.T) {
+	dataDir := t.TempDir()
+	writeSnapshotFile(t, dataDir, "3f2c9e1d", time.Hour)
+	writeSnapshotFile(t, dataDir, "3f2d0000", time.Hour)
+
+	s, err := FindSnapshot(dataDir, "3f2c")
+	require.NoError(t, err)
+	assert.Equal(t, "3f2c9e1d", s.Name)
+
+	_, err = FindSnapshot(dataDir, "3f2")
+	assert.ErrorContains(t, err, "no snapshot", "prefixes shorter than four characters are not matched")
+	_, err = FindSnapshot(dataDir, "3f2c9e1d0")
+	assert.Error(t, err)
+
+	writeSnapshotFile(t, dataDir, "3f2c0000", time.Hour)
+	_, err = FindSnapshot(dataDir, "3f2c")
+	assert.ErrorContains(t, err, "ambiguous")
+}
+
+func TestValidSnapshotName(t *testing.T) {
+	for _, name := range []string{"3f2c9e1", "v1.2.0", "release-2025_10"} {
+		assert.True(t, validSnapshotName(name), name)
+	}
+	for _, name := range []string{"", ".hidden", "../escape", "a/b", "naïve"} {
+		assert.False(t, validSnapshotName(name), name)
+	}
+}
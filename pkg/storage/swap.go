@@ -0,0 +1,177 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	cozo "github.com/kraklabs/cie/pkg/cozodb"
+)
+
+const (
+	stagingSuffix  = ".staging"
+	previousSuffix = ".previous"
+	corruptInfix   = ".corrupt-" // followed by a timestamp; see RepairDatabase
+)
+
+// StagingDir returns the directory a full rebuild of dataDir is written to
+// before SwapDataDir moves it into place.
+func StagingDir(dataDir string) string {
+	return filepath.Clean(dataDir) + stagingSuffix
+}
+
+// IsSwapDir reports whether a data directory entry is a staged, retired or
+// quarantined index rather than a project.
+func IsSwapDir(name string) bool {
+	return strings.HasSuffix(name, stagingSuffix) || strings.HasSuffix(name, previousSuffix) ||
+		strings.Contains(name, corruptInfix)
+}
+
+// SwapDataDir replaces the index in live with the one built in staging.
+//
+// The old index is renamed aside and only deleted once staging is in place.
+// If moving staging in fails, the old index is renamed back, so live never
+// ends up missing or half-built. Both directories must be closed.
+func SwapDataDir(live, staging string) error {
+	if _, err := os.Stat(staging); err != nil {
+		return fmt.Errorf("staged index: %w", err)
+	}
+	previous := filepath.Clean(live) + previousSuffix
+	if err := os.RemoveAll(previous); err != nil {
+		return fmt.Errorf("remove leftover %s: %w", previous, err)
+	}
+
+	hadLive := true
+	if err := os.Rename(live, previous); err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("move old index aside: %w", err)
+		}
+		hadLive = false
+	}
+	if err := os.Rename(staging, live); err != nil {
+		if hadLive {
+			if rbErr := os.Rename(previous, live); rbErr != nil {
+				return fmt.Errorf("install staged index: %w (restoring old index also failed: %v; it is at %s)", err, rbErr, previous)
+			}
+		}
+		return fmt.Errorf("install staged index: %w", err)
+	}
+	if hadLive {
+		// The new index is live; a leftover copy only wastes disk.
+		_ = os.RemoveAll(previous)
+	}
+	return nil
+}
+
+// CarryOverUserData copies what a full rebuild cannot recreate from live
+// into staging before SwapDataDir: the rows of UserRelations, in every
+// namespace, and the snapshots. Snapshots are merged with those staging
+// already holds, then pruned to the newest keepSnapshots (no pruning when
+// keepSnapshots <= 0). Both directories must be closed. A missing live
+// directory means there is nothing to keep.
+func CarryOverUserData(live, staging, engine string, keepSnapshots int) error {
+	if _, err := os.Stat(live); os.IsNotExist(err) {
+		return nil
+	}
+	if err := carryRelations(live, staging, engine); err != nil {
+		return err
+	}
+	// Moved last: if copying fails the snapshots stay where they were.
+	return carrySnapshots(live, staging, keepSnapshots)
+}
+
+// carryRelations copies the UserRelations rows from live into staging.
+func carryRelations(live, staging, engine string) error {
+	from, err := cozo.New(engine, engineDBPath(engine, live), nil)
+	if err != nil {
+		return fmt.Errorf("open current index: %w", err)
+	}
+	defer from.Close()
+	result, err := from.Run("::relations", nil)
+	if err != nil {
+		return fmt.Errorf("list relations: %w", err)
+	}
+	var names []string
+	for _, row := range result.Rows {
+		if len(row) > 0 {
+			if name, ok := row[0].(string); ok && slices.Contains(UserRelations, baseRelation(name)) {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	to, err := cozo.New(engine, engineDBPath(engine, staging), nil)
+	if err != nil {
+		return fmt.Errorf("open staged index: %w", err)
+	}
+	defer to.Close()
+	for _, name := range names {
+		// The staged index only has the namespaces that were rebuilt.
+		if _, err := to.Run("::columns "+name, nil); err != nil {
+			continue
+		}
+		if _, err := copyRelation(&from, &to, name); err != nil {
+			// A rebuild may change the embedding size; stored vectors of
+			// the old size cannot be kept, the rows they belong to can.
+			if rel, ok := LookupRelation(baseRelation(name)); ok && rel.hasVector() {
+				continue
+			}
+			return fmt.Errorf("copy %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// carrySnapshots moves live's snapshots into staging's snapshots directory.
+// A snapshot staging already has, such as the one the rebuild just took of
+// the same commit, wins over the older copy in live. The merged set is then
+// pruned to the newest keep.
+func carrySnapshots(live, staging string, keep int) error {
+	src := filepath.Join(live, SnapshotsDirName)
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return nil
+	}
+	dst := filepath.Join(staging, SnapshotsDirName)
+	if err := os.MkdirAll(dst, 0750); err != nil {
+		return fmt.Errorf("create staged snapshots: %w", err)
+	}
+	for _, entry := range entries {
+		target := filepath.Join(dst, entry.Name())
+		if _, err := os.Stat(target); err == nil {
+			continue
+		}
+		if err := os.Rename(filepath.Join(src, entry.Name()), target); err != nil {
+			return fmt.Errorf("move snapshot %s: %w", entry.Name(), err)
+		}
+	}
+	if keep > 0 {
+		if _, err := PruneSnapshots(staging, keep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
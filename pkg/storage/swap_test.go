@@ -0,0 +1,154 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSwapDataDir(t *testing.T) {
+	root := t.TempDir()
+	live := filepath.Join(root, "proj")
+	staging := StagingDir(live)
+	writeMarker(t, live, "old")
+	writeMarker(t, staging, "new")
+
+	if err := SwapDataDir(live, staging); err != nil {
+		t.Fatalf("SwapDataDir: %v", err)
+	}
+	if got := readMarker(t, live); got != "new" {
+		t.Errorf("live marker = %q, want new", got)
+	}
+	for _, dir := range []string{staging, live + ".previous"} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("%s should be gone, stat err = %v", dir, err)
+		}
+	}
+}
+
+func TestSwapDataDir_NoLiveIndex(t *testing.T) {
+	root := t.TempDir()
+	live := filepath.Join(root, "proj")
+	writeMarker(t, StagingDir(live), "new")
+
+	if err := SwapDataDir(live, StagingDir(live)); err != nil {
+		t.Fatalf("SwapDataDir: %v", err)
+	}
+	if got := readMarker(t, live); got != "new" {
+		t.Errorf("live marker = %q, want new", got)
+	}
+}
+
+func TestSwapDataDir_MissingStagingKeepsLive(t *testing.T) {
+	root := t.TempDir()
+	live := filepath.Join(root, "proj")
+	writeMarker(t, live, "old")
+
+	if err := SwapDataDir(live, StagingDir(live)); err == nil {
+		t.Fatal("expected an error without a staged index")
+	}
+	if got := readMarker(t, live); got != "old" {
+		t.Errorf("live marker = %q, want old", got)
+	}
+}
+
+func TestIsSwapDir(t *testing.T) {
+	for name, want := range map[string]bool{
+		"my-project":                         false,
+		"my-project.staging":                 true,
+		"my-project.previous":                true,
+		"my-project.corrupt-20260101-120000": true,
+	} {
+		if got := IsSwapDir(name); got != want {
+			t.Errorf("IsSwapDir(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func writeMarker(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "marker"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readMarker(t *testing.T, dir string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "marker"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCarrySnapshots_MergesAndPrunes(t *testing.T) {
+	root := t.TempDir()
+	live := filepath.Join(root, "proj")
+	staging := StagingDir(live)
+	base := time.Now().Add(-time.Hour)
+	write := func(dir, name, content string, age time.Duration) {
+		t.Helper()
+		path := filepath.Join(dir, SnapshotsDirName, name+snapshotExt)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, base.Add(-age), base.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(live, "old", "live", 3*time.Minute)
+	write(live, "older", "live", 4*time.Minute)
+	write(live, "head", "live", 2*time.Minute)
+	write(staging, "head", "staged", 0) // taken by the rebuild
+
+	if err := carrySnapshots(live, staging, 3); err != nil {
+		t.Fatalf("carrySnapshots: %v", err)
+	}
+	snapshots, err := ListSnapshots(staging)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range snapshots {
+		names = append(names, s.Name)
+	}
+	if want := []string{"head", "old", "older"}; !slices.Equal(names, want) {
+		t.Errorf("snapshots = %v, want %v", names, want)
+	}
+	if data, _ := os.ReadFile(filepath.Join(staging, SnapshotsDirName, "head"+snapshotExt)); string(data) != "staged" {
+		t.Errorf("the rebuild's snapshot was replaced by the live copy: %q", data)
+	}
+
+	if err := carrySnapshots(live, staging, 1); err != nil {
+		t.Fatalf("carrySnapshots: %v", err)
+	}
+	if snapshots, _ := ListSnapshots(staging); len(snapshots) != 1 || snapshots[0].Name != "head" {
+		t.Errorf("prune should keep only the newest snapshot, got %v", snapshots)
+	}
+}
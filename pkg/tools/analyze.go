@@ -31,7 +31,8 @@ import (
 type AnalyzeArgs struct {
 	Question    string
 	PathPattern string
-	Role        string // "source" (default, excludes tests), "test", "any"
+	Role        string                 // "source" (default, excludes tests), "test", "any" or a custom role
+	CustomRoles map[string]RolePattern // Custom roles from project.yaml
 }
 
 // relevantFunction holds a function found via semantic search with its code
@@ -242,13 +243,16 @@ type analyzeState struct {
 // Analyze uses semantic search to answer architectural questions about the codebase
 func Analyze(ctx context.Context, client Querier, args AnalyzeArgs) (*ToolResult, error) {
 	if args.Question == "" {
-		return NewError("Error: 'question' is required"), nil
+		return NewInputError("Error: 'question' is required"), nil
 	}
 
 	// Default to source (exclude tests) for architectural analysis
 	if args.Role == "" {
 		args.Role = "source"
 	}
+	if _, err := NewRoleFilter(args.Role, args.CustomRoles); err != nil {
+		return NewInputError(fmt.Sprintf("Error: %v", err)), nil
+	}
 
 	state := &analyzeState{args: args}
 
@@ -304,7 +308,7 @@ func (s *analyzeState) performSemanticSearch(ctx context.Context, client Querier
 
 	// Localized search (if path specified)
 	if s.args.PathPattern != "" {
-		funcs, err := findRelevantFunctionsLocalized(ctx, client, s.args.Question, s.args.PathPattern, s.args.Role, 10)
+		funcs, err := findRelevantFunctionsLocalized(ctx, client, s.args.Question, s.args.PathPattern, s.args.Role, s.args.CustomRoles, 10)
 		if err != nil {
 			s.errors = append(s.errors, fmt.Sprintf("localized semantic search: %v", err))
 		} else if len(funcs) > 0 {
@@ -317,7 +321,7 @@ func (s *analyzeState) performSemanticSearch(ctx context.Context, client Querier
 	if len(s.localizedFuncs) > 0 {
 		globalLimit = 5
 	}
-	funcs, err := findRelevantFunctions(ctx, client, s.args.Question, "", s.args.Role, globalLimit)
+	funcs, err := findRelevantFunctions(ctx, client, s.args.Question, "", s.args.Role, s.args.CustomRoles, globalLimit)
 	if err != nil {
 		s.errors = append(s.errors, fmt.Sprintf("global semantic search: %v", err))
 	} else {
@@ -333,7 +337,7 @@ func (s *analyzeState) performSemanticSearch(ctx context.Context, client Querier
 // formatSemanticResults formats the semantic search results into sections.
 func (s *analyzeState) formatSemanticResults() {
 	if len(s.localizedFuncs) > 0 {
-		section := fmt.Sprintf("## Semantically Relevant (in %s)\n\n", s.args.PathPattern)
+		section := fmt.Sprintf("## Semantically Relevant (in %s)\n\n", displayPattern(s.args.PathPattern))
 		section += formatFunctionList(s.localizedFuncs)
 		s.sections = append(s.sections, section)
 	}
@@ -410,6 +414,10 @@ func (s *analyzeState) runKeywordNameSearch(ctx context.Context, client Querier,
 
 // runKeywordCodeSearch searches function code for keywords.
 func (s *analyzeState) runKeywordCodeSearch(ctx context.Context, client Querier, pattern string) {
+	if isCodeCompressed(ctx, client) {
+		s.runDecodedCodeQuery(ctx, client, "keyword code search", "## Functions Matching Keywords (code)\n", pattern, "", 30)
+		return
+	}
 	query := fmt.Sprintf(`?[name, file_path, start_line] := *cie_function { id, name, file_path, start_line }, *cie_function_code { function_id: id, code_text }, regex_matches(code_text, %q) :limit 30`, pattern)
 	if s.args.PathPattern != "" {
 		query = fmt.Sprintf(`?[name, file_path, start_line] := *cie_function { id, name, file_path, start_line }, *cie_function_code { function_id: id, code_text }, regex_matches(code_text, %q), regex_matches(file_path, %q) :limit 30`, pattern, s.args.PathPattern)
@@ -461,6 +469,10 @@ func (s *analyzeState) runEntryPointQuery(ctx context.Context, client Querier, t
 
 // runRouteQuery searches for HTTP route definitions.
 func (s *analyzeState) runRouteQuery(ctx context.Context, client Querier, testFilter string) {
+	if isCodeCompressed(ctx, client) {
+		s.runDecodedCodeQuery(ctx, client, "route functions", "## Functions with Route Definitions\n", "[.](GET|POST|PUT|DELETE|PATCH|Handle)[(]", testFilter, 20)
+		return
+	}
 	query := fmt.Sprintf(`?[name, file_path, start_line] := *cie_function { id, name, file_path, start_line }, *cie_function_code { function_id: id, code_text }, regex_matches(code_text, "[.](GET|POST|PUT|DELETE|PATCH|Handle)[(]")%s :limit 20`, testFilter)
 	if s.args.PathPattern != "" {
 		query = fmt.Sprintf(`?[name, file_path, start_line] := *cie_function { id, name, file_path, start_line }, *cie_function_code { function_id: id, code_text }, regex_matches(code_text, "[.](GET|POST|PUT|DELETE|PATCH|Handle)[(]"), regex_matches(file_path, %q)%s :limit 20`, s.args.PathPattern, testFilter)
@@ -470,6 +482,40 @@ func (s *analyzeState) runRouteQuery(ctx context.Context, client Querier, testFi
 	}
 }
 
+// runDecodedCodeQuery is the code search of runKeywordCodeSearch and
+// runRouteQuery for indexes with compressed code_text: functions in scope
+// are decompressed and matched against pattern client-side. extra holds
+// further conditions in the ", cond" form of getTestExcludeFilter.
+func (s *analyzeState) runDecodedCodeQuery(ctx context.Context, client Querier, name, heading, pattern, extra string, limit int) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		s.errors = append(s.errors, fmt.Sprintf("%s: %v", name, err))
+		return
+	}
+	var conditions []string
+	if s.args.PathPattern != "" {
+		conditions = append(conditions, fmt.Sprintf("regex_matches(file_path, %q)", s.args.PathPattern))
+	}
+	if extra = strings.TrimPrefix(extra, ", "); extra != "" {
+		conditions = append(conditions, extra)
+	}
+	rows, err := collectDecodedCode(ctx, client, conditions, limit, func(row []any) bool {
+		return re.MatchString(AnyToString(row[4]))
+	})
+	if err != nil {
+		s.errors = append(s.errors, fmt.Sprintf("%s: %v", name, err))
+		return
+	}
+	if len(rows) == 0 {
+		return
+	}
+	matches := make([][]any, len(rows))
+	for i, row := range rows {
+		matches[i] = []any{row[1], row[0], row[2]}
+	}
+	s.sections = append(s.sections, heading+FormatRows(matches))
+}
+
 // runArchitectureQuery extracts directory structure.
 func (s *analyzeState) runArchitectureQuery(ctx context.Context, client Querier) {
 	query := `?[path] := *cie_file { path } :limit 100`
@@ -510,10 +556,14 @@ func (s *analyzeState) runQuery(ctx context.Context, client Querier, name, query
 func (s *analyzeState) buildOutput(ctx context.Context, client Querier) (*ToolResult, error) {
 	output := fmt.Sprintf("# Analysis: %s\n\n", s.args.Question)
 	if s.args.PathPattern != "" {
-		output += fmt.Sprintf("_Scope: `%s`_\n\n", s.args.PathPattern)
+		output += fmt.Sprintf("_Scope: `%s`_\n\n", displayPattern(s.args.PathPattern))
 	}
-	if s.args.Role == "source" {
+	switch s.args.Role {
+	case "source":
 		output += "_Filtering: excluding test files_\n\n"
+	case "any", "test":
+	default:
+		output += fmt.Sprintf("_Filtering: role `%s`_\n\n", s.args.Role)
 	}
 
 	// Check if we have meaningful results
@@ -540,7 +590,12 @@ func (s *analyzeState) buildOutput(ctx context.Context, client Querier) (*ToolRe
 }
 
 // findRelevantFunctions uses semantic search to find the most relevant functions for a question
-func findRelevantFunctions(ctx context.Context, client Querier, question, pathPattern, role string, limit int) ([]relevantFunction, error) {
+func findRelevantFunctions(ctx context.Context, client Querier, question, pathPattern, role string, custom map[string]RolePattern, limit int) ([]relevantFunction, error) {
+	roles, err := NewRoleFilter(role, custom)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get embedding config from CIEClient if available
 	embeddingURL, embeddingModel := "", ""
 	if cieClient, ok := client.(*CIEClient); ok {
@@ -577,9 +632,10 @@ func findRelevantFunctions(ctx context.Context, client Querier, question, pathPa
 	if len(result.Rows) == 0 {
 		return nil, nil
 	}
+	scaleDistances(result.Rows, 4, hnswDistanceScale(ctx, client))
 
 	// Post-filter by path and role
-	result.Rows = postFilterByPath(result.Rows, pathPattern, role, question, "", true)
+	result.Rows = postFilterRows(result.Rows, pathPattern, roles, question, "", true)
 
 	// Limit results
 	if len(result.Rows) > limit {
@@ -634,10 +690,14 @@ func findRelevantFunctions(ctx context.Context, client Querier, question, pathPa
 // findRelevantFunctionsLocalized does semantic search restricted to a specific path pattern.
 // Uses a very high k value to ensure we capture functions from the specific path.
 // Applies keyword boosting to re-rank results based on question terms in function names.
-func findRelevantFunctionsLocalized(ctx context.Context, client Querier, question, pathPattern, role string, limit int) ([]relevantFunction, error) {
+func findRelevantFunctionsLocalized(ctx context.Context, client Querier, question, pathPattern, role string, custom map[string]RolePattern, limit int) ([]relevantFunction, error) {
 	if pathPattern == "" {
 		return nil, nil // No path pattern, nothing to localize
 	}
+	roles, err := NewRoleFilter(role, custom)
+	if err != nil {
+		return nil, err
+	}
 
 	// Get embedding config from CIEClient if available
 	embeddingURL, embeddingModel := "", ""
@@ -675,9 +735,10 @@ func findRelevantFunctionsLocalized(ctx context.Context, client Querier, questio
 	if len(result.Rows) == 0 {
 		return nil, nil
 	}
+	scaleDistances(result.Rows, 4, hnswDistanceScale(ctx, client))
 
 	// STRICT filter by path pattern
-	result.Rows = postFilterByPath(result.Rows, pathPattern, role, question, "", true)
+	result.Rows = postFilterRows(result.Rows, pathPattern, roles, question, "", true)
 
 	// Get MORE candidates than requested for re-ranking (2x limit)
 	candidateLimit := limit * 2
@@ -775,7 +836,7 @@ func getFunctionCodeByName(ctx context.Context, client Querier, name, filePath s
 		return "", nil
 	}
 
-	return AnyToString(result.Rows[0][0]), nil
+	return decodeCodeText(result.Rows[0][0]), nil
 }
 
 
@@ -627,7 +627,7 @@ func TestFindRelevantFunctions_NoEmbedding(t *testing.T) {
 	ctx, client := setupTestWithEmptyMock(t)
 
 	// Should return error when embedding not configured
-	_, err := findRelevantFunctions(ctx, client, "test question", "", "source", 10)
+	_, err := findRelevantFunctions(ctx, client, "test question", "", "source", nil, 10)
 
 	if err == nil {
 		t.Fatal("expected error when embedding not configured")
@@ -645,7 +645,7 @@ func TestFindRelevantFunctionsLocalized_NoEmbedding(t *testing.T) {
 	ctx, client := setupTestWithEmptyMock(t)
 
 	// Should return error when embedding not configured
-	_, err := findRelevantFunctionsLocalized(ctx, client, "test question", "internal/auth", "source", 10)
+	_, err := findRelevantFunctionsLocalized(ctx, client, "test question", "internal/auth", "source", nil, 10)
 
 	if err == nil {
 		t.Fatal("expected error when embedding not configured")
@@ -663,7 +663,7 @@ func TestFindRelevantFunctionsLocalized_EmptyPath(t *testing.T) {
 	ctx, client := setupTestWithEmptyMock(t)
 
 	// Should return nil when path is empty
-	result, err := findRelevantFunctionsLocalized(ctx, client, "test question", "", "source", 10)
+	result, err := findRelevantFunctionsLocalized(ctx, client, "test question", "", "source", nil, 10)
 
 	assertNoError(t, err)
 	if result != nil {
@@ -0,0 +1,294 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ArchitectureArgs holds inputs for the architecture overview.
+type ArchitectureArgs struct {
+	Title         string
+	Depth         int // path segments that make up a component (default 2)
+	MaxComponents int // components shown in the diagram and matrix (default 12)
+	MaxHotspots   int // functions in the hotspot list (default 15)
+}
+
+// archComponent is a group of source directories sharing a path prefix.
+type archComponent struct {
+	Name      string
+	Files     map[string]bool
+	Functions int
+	Inbound   int
+	Outbound  int
+}
+
+// archHotspot is a function with high fan-in and fan-out.
+type archHotspot struct {
+	Name    string
+	File    string
+	Line    int
+	Lines   int
+	Callers int
+	Callees int
+}
+
+// ArchitectureReport builds a markdown architecture overview from the index:
+// a Mermaid component diagram, a component dependency matrix and a list of
+// hotspot functions. It is cheap to regenerate, so checked-in architecture
+// docs can be refreshed whenever the index is.
+func ArchitectureReport(ctx context.Context, client Querier, args ArchitectureArgs) (string, error) {
+	if args.Depth <= 0 {
+		args.Depth = 2
+	}
+	if args.MaxComponents <= 0 {
+		args.MaxComponents = 12
+	}
+	if args.MaxHotspots <= 0 {
+		args.MaxHotspots = 15
+	}
+
+	components, err := architectureComponents(ctx, client, args.Depth)
+	if err != nil {
+		return "", err
+	}
+	edges, err := architectureEdges(ctx, client, args.Depth, components)
+	if err != nil {
+		return "", err
+	}
+
+	ranked := make([]*archComponent, 0, len(components))
+	for _, c := range components {
+		ranked = append(ranked, c)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		wi, wj := ranked[i].Inbound+ranked[i].Outbound, ranked[j].Inbound+ranked[j].Outbound
+		if wi != wj {
+			return wi > wj
+		}
+		if ranked[i].Functions != ranked[j].Functions {
+			return ranked[i].Functions > ranked[j].Functions
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+	hidden := 0
+	if len(ranked) > args.MaxComponents {
+		hidden = len(ranked) - args.MaxComponents
+		ranked = ranked[:args.MaxComponents]
+	}
+
+	var sb strings.Builder
+	title := args.Title
+	if title == "" {
+		title = "Architecture Overview"
+	}
+	fmt.Fprintf(&sb, "# %s\n\n", title)
+	sb.WriteString("_Generated by `cie architecture` from the code index. Do not edit by hand; regenerate instead._\n\n")
+
+	if len(ranked) == 0 {
+		sb.WriteString("_No source files in the index. Run `cie index` first._\n")
+		return sb.String(), nil
+	}
+
+	fmt.Fprintf(&sb, "## Components\n\nSource directories grouped to depth %d. Tests and generated code are excluded.\n\n", args.Depth)
+	sb.WriteString("| Component | Files | Functions | Calls in | Calls out |\n|-----------|-------|-----------|----------|-----------|\n")
+	for _, c := range ranked {
+		fmt.Fprintf(&sb, "| `%s` | %d | %d | %d | %d |\n", c.Name, len(c.Files), c.Functions, c.Inbound, c.Outbound)
+	}
+	if hidden > 0 {
+		fmt.Fprintf(&sb, "\n%d smaller components not shown. Raise the component limit to include them.\n", hidden)
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(architectureDiagram(ranked, edges))
+	sb.WriteString(architectureMatrix(ranked, edges))
+	sb.WriteString(architectureHotspots(ctx, client, args.MaxHotspots))
+	return sb.String(), nil
+}
+
+// componentOf maps a file to its component: the first depth segments of its
+// directory, or "." for files at the repository root.
+func componentOf(filePath string, depth int) string {
+	dir := path.Dir(filePath)
+	if dir == "." {
+		return "."
+	}
+	parts := strings.Split(dir, "/")
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return strings.Join(parts, "/")
+}
+
+func architectureComponents(ctx context.Context, client Querier, depth int) (map[string]*archComponent, error) {
+	result, err := client.Query(ctx, "?[file_path, count(id)] := *cie_function { id, file_path }")
+	if err != nil {
+		return nil, fmt.Errorf("list functions: %w", err)
+	}
+	components := map[string]*archComponent{}
+	for _, row := range result.Rows {
+		file := AnyToString(row[0])
+		if !MatchesRoleFilter(file, "source") {
+			continue
+		}
+		name := componentOf(file, depth)
+		c := components[name]
+		if c == nil {
+			c = &archComponent{Name: name, Files: map[string]bool{}}
+			components[name] = c
+		}
+		c.Files[file] = true
+		c.Functions += int(toFloat64(row[1]))
+	}
+	return components, nil
+}
+
+// architectureEdges counts calls between components, keyed "from\x00to".
+// Calls within a component are kept too; they fill the matrix diagonal.
+func architectureEdges(ctx context.Context, client Querier, depth int, components map[string]*archComponent) (map[string]int, error) {
+	calls, err := callsBetween(ctx, client, func(file string) string { return componentOf(file, depth) })
+	if err != nil {
+		return nil, err
+	}
+	edges := map[string]int{}
+	for edge, n := range calls {
+		from, to := components[edge.from], components[edge.to]
+		if from == nil || to == nil {
+			continue
+		}
+		edges[from.Name+"\x00"+to.Name] += n
+		if from != to {
+			from.Outbound += n
+			to.Inbound += n
+		}
+	}
+	return edges, nil
+}
+
+func architectureDiagram(components []*archComponent, edges map[string]int) string {
+	ids := make(map[string]string, len(components))
+	var sb strings.Builder
+	sb.WriteString("## Component Diagram\n\nArrows point from caller to callee and are labelled with the number of calls.\n\n```mermaid\ngraph LR\n")
+	for i, c := range components {
+		ids[c.Name] = fmt.Sprintf("c%d", i)
+		fmt.Fprintf(&sb, "    c%d[\"%s<br/>%d funcs\"]\n", i, c.Name, c.Functions)
+	}
+	for _, from := range components {
+		for _, to := range components {
+			if from == to {
+				continue
+			}
+			if n := edges[from.Name+"\x00"+to.Name]; n > 0 {
+				fmt.Fprintf(&sb, "    %s -->|%d| %s\n", ids[from.Name], n, ids[to.Name])
+			}
+		}
+	}
+	sb.WriteString("```\n\n")
+	return sb.String()
+}
+
+func architectureMatrix(components []*archComponent, edges map[string]int) string {
+	var sb strings.Builder
+	sb.WriteString("## Dependency Matrix\n\nCalls from the row component into the column component. The diagonal counts calls inside a component.\n\n")
+	sb.WriteString("| From \\ To |")
+	for i := range components {
+		fmt.Fprintf(&sb, " %d |", i+1)
+	}
+	sb.WriteString("\n|---|")
+	sb.WriteString(strings.Repeat("---|", len(components)))
+	sb.WriteString("\n")
+	for i, from := range components {
+		fmt.Fprintf(&sb, "| %d. `%s` |", i+1, from.Name)
+		for _, to := range components {
+			if n := edges[from.Name+"\x00"+to.Name]; n > 0 {
+				fmt.Fprintf(&sb, " %d |", n)
+			} else {
+				sb.WriteString(" · |")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// architectureHotspots ranks functions by fan-in plus fan-out: the places
+// where a change ripples furthest.
+func architectureHotspots(ctx context.Context, client Querier, limit int) string {
+	fanIn, err := client.Query(ctx, "?[callee_id, name, file_path, start_line, end_line, count(caller_id)] := *cie_calls { caller_id, callee_id }, *cie_function { id: callee_id, name, file_path, start_line, end_line }")
+	if err != nil {
+		return ""
+	}
+	hotspots := map[string]*archHotspot{}
+	for _, row := range fanIn.Rows {
+		file := AnyToString(row[2])
+		if !MatchesRoleFilter(file, "source") {
+			continue
+		}
+		start, end := int(toFloat64(row[3])), int(toFloat64(row[4]))
+		hotspots[AnyToString(row[0])] = &archHotspot{
+			Name:    AnyToString(row[1]),
+			File:    file,
+			Line:    start,
+			Lines:   end - start + 1,
+			Callers: int(toFloat64(row[5])),
+		}
+	}
+	if len(hotspots) == 0 {
+		return ""
+	}
+	if fanOut, err := client.Query(ctx, "?[caller_id, count(callee_id)] := *cie_calls { caller_id, callee_id }"); err == nil {
+		for _, row := range fanOut.Rows {
+			if h := hotspots[AnyToString(row[0])]; h != nil {
+				h.Callees = int(toFloat64(row[1]))
+			}
+		}
+	}
+
+	ranked := make([]*archHotspot, 0, len(hotspots))
+	for _, h := range hotspots {
+		ranked = append(ranked, h)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		si, sj := ranked[i].Callers+ranked[i].Callees, ranked[j].Callers+ranked[j].Callees
+		if si != sj {
+			return si > sj
+		}
+		if ranked[i].Lines != ranked[j].Lines {
+			return ranked[i].Lines > ranked[j].Lines
+		}
+		return ranked[i].File+ranked[i].Name < ranked[j].File+ranked[j].Name
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	var sb strings.Builder
+	sb.WriteString("## Hotspots\n\nFunctions with the most callers plus callees. Changes here ripple furthest; keep them well tested.\n\n")
+	sb.WriteString("| Function | Location | Callers | Callees | Lines |\n|----------|----------|---------|---------|-------|\n")
+	for _, h := range ranked {
+		fmt.Fprintf(&sb, "| `%s` | `%s:%d` | %d | %d | %d |\n", h.Name, h.File, h.Line, h.Callers, h.Callees, h.Lines)
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestArchitectureReport(t *testing.T) {
+	ctx := setupTest(t)
+	client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		switch {
+		case strings.Contains(script, "?[file_path, count(id)]"):
+			return NewMockQueryResult([]string{"file_path", "count"}, [][]any{
+				{"cmd/app/main.go", int64(2)},
+				{"internal/store/store.go", int64(10)},
+				{"internal/store/store_test.go", int64(6)},
+				{"internal/store/sql/query.go", int64(3)},
+			}), nil
+		case strings.Contains(script, "?[caller_file, callee_file"):
+			return NewMockQueryResult([]string{"caller_file", "callee_file", "count"}, [][]any{
+				{"cmd/app/main.go", "internal/store/store.go", int64(4)},
+				{"internal/store/store.go", "internal/store/sql/query.go", int64(7)},
+				{"internal/store/store_test.go", "internal/store/store.go", int64(30)},
+			}), nil
+		case strings.Contains(script, "?[callee_id, name"):
+			return NewMockQueryResult([]string{"callee_id", "name", "file_path", "start_line", "end_line", "count"}, [][]any{
+				{"f1", "Open", "internal/store/store.go", int64(10), int64(40), int64(4)},
+				{"f2", "Exec", "internal/store/sql/query.go", int64(5), int64(9), int64(7)},
+				{"f3", "helper", "internal/store/store_test.go", int64(1), int64(3), int64(30)},
+			}), nil
+		case strings.Contains(script, "?[caller_id, count(callee_id)]"):
+			return NewMockQueryResult([]string{"caller_id", "count"}, [][]any{
+				{"f1", int64(5)},
+			}), nil
+		}
+		return NewMockQueryResult(nil, nil), nil
+	}, nil)
+
+	report, err := ArchitectureReport(ctx, client, ArchitectureArgs{Title: "demo"})
+	assertNoError(t, err)
+
+	for _, want := range []string{
+		"# demo",
+		"| `internal/store` | 2 | 13 | 4 | 0 |",
+		"| `cmd/app` | 1 | 2 | 0 | 4 |",
+		"```mermaid\ngraph LR\n",
+		"c0[\"internal/store<br/>13 funcs\"]",
+		"c1 -->|4| c0",
+		"| 1. `internal/store` | 7 | · |",
+		"| `Open` | `internal/store/store.go:10` | 4 | 5 | 31 |",
+	} {
+		assertContains(t, report, want)
+	}
+	if strings.Contains(report, "helper") {
+		t.Error("test functions should not be listed as hotspots")
+	}
+	if strings.Index(report, "`Open`") > strings.Index(report, "`Exec`") {
+		t.Error("hotspots should be ordered by callers plus callees")
+	}
+}
+
+func TestArchitectureReport_CallQueryError(t *testing.T) {
+	ctx := setupTest(t)
+	client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		if strings.Contains(script, "?[caller_file, callee_file") {
+			return nil, errors.New("query timed out")
+		}
+		return NewMockQueryResult([]string{"file_path", "count"}, [][]any{{"cmd/app/main.go", int64(2)}}), nil
+	}, nil)
+
+	if _, err := ArchitectureReport(ctx, client, ArchitectureArgs{}); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("err = %v, want the call query error", err)
+	}
+}
+
+func TestComponentOf(t *testing.T) {
+	tests := []struct {
+		file  string
+		depth int
+		want  string
+	}{
+		{"main.go", 2, "."},
+		{"pkg/tools/grep.go", 2, "pkg/tools"},
+		{"pkg/tools/internal/x.go", 2, "pkg/tools"},
+		{"pkg/tools/grep.go", 1, "pkg"},
+	}
+	for _, tt := range tests {
+		if got := componentOf(tt.file, tt.depth); got != tt.want {
+			t.Errorf("componentOf(%q, %d) = %q, want %q", tt.file, tt.depth, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BenchTarget is one file and/or function a query is expected to retrieve.
+// File matches exactly, by path suffix, or as a directory prefix; Function
+// matches the bare name or the method part of "Type.Method".
+type BenchTarget struct {
+	File     string `yaml:"file,omitempty" json:"file,omitempty"`
+	Function string `yaml:"function,omitempty" json:"function,omitempty"`
+}
+
+// BenchQuery pairs a natural-language query with the code it should find.
+type BenchQuery struct {
+	Query  string        `yaml:"query" json:"query"`
+	Expect []BenchTarget `yaml:"expect" json:"expect"`
+}
+
+// BenchSuite is a retrieval benchmark, usually loaded from a YAML file.
+type BenchSuite struct {
+	K       int          `yaml:"k,omitempty" json:"k,omitempty"` // cutoff for recall@k (default 10)
+	Queries []BenchQuery `yaml:"queries" json:"queries"`
+}
+
+// BenchConfig names one index + embedding setup under evaluation.
+type BenchConfig struct {
+	Name           string
+	EmbeddingURL   string
+	EmbeddingModel string
+	Ranking        RankingWeights // Boosts applied as in cie_semantic_search (zero value: similarity only)
+	Git            GitRunner      // Source of file recency for Ranking.Recency (may be nil)
+}
+
+// BenchQueryResult records how one query fared.
+type BenchQueryResult struct {
+	Query    string   `json:"query"`
+	Rank     int      `json:"rank"`  // 1-based rank of the first expected hit, 0 if missed
+	Found    int      `json:"found"` // expected targets found in the top k
+	Expected int      `json:"expected"`
+	Top      []string `json:"top,omitempty"` // top results as "file:function"
+	Error    string   `json:"error,omitempty"`
+}
+
+// BenchResult aggregates a suite run against one configuration.
+type BenchResult struct {
+	Name       string             `json:"name"`
+	K          int                `json:"k"`
+	Queries    int                `json:"queries"`
+	RecallAt   map[int]float64    `json:"recall_at"`
+	MRR        float64            `json:"mrr"`
+	Errors     int                `json:"errors"`
+	DurationMs int64              `json:"duration_ms"`
+	Results    []BenchQueryResult `json:"results"`
+}
+
+// benchHit is one ranked semantic search result.
+type benchHit struct {
+	Name string
+	File string
+}
+
+// BenchCutoffs returns the recall cutoffs reported for a given k.
+func BenchCutoffs(k int) []int {
+	var cutoffs []int
+	for _, c := range []int{1, 5, 10} {
+		if c < k {
+			cutoffs = append(cutoffs, c)
+		}
+	}
+	return append(cutoffs, k)
+}
+
+// RunRetrievalBench runs every query in the suite through semantic search and
+// scores the ranked results against the expected targets. Queries whose
+// embedding or search fails count as misses and are reported with the error;
+// there is no keyword fallback, so the numbers reflect the embeddings alone.
+func RunRetrievalBench(ctx context.Context, client Querier, suite BenchSuite, cfg BenchConfig) (*BenchResult, error) {
+	if len(suite.Queries) == 0 {
+		return nil, fmt.Errorf("benchmark has no queries")
+	}
+	k := suite.K
+	if k <= 0 {
+		k = 10
+	}
+	cutoffs := BenchCutoffs(k)
+
+	start := time.Now()
+	res := &BenchResult{Name: cfg.Name, K: k, Queries: len(suite.Queries), RecallAt: map[int]float64{}}
+	for _, q := range suite.Queries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		qr := BenchQueryResult{Query: q.Query, Expected: len(q.Expect)}
+		hits, err := benchRank(ctx, client, cfg, q.Query, k)
+		if err != nil {
+			qr.Error = err.Error()
+			res.Errors++
+			res.Results = append(res.Results, qr)
+			continue
+		}
+		for _, h := range hits {
+			qr.Top = append(qr.Top, h.File+":"+h.Name)
+		}
+
+		firstRank := make([]int, len(q.Expect)) // per target, 0 = not found
+		for i, h := range hits {
+			for t, target := range q.Expect {
+				if firstRank[t] == 0 && benchTargetMatches(target, h) {
+					firstRank[t] = i + 1
+				}
+			}
+		}
+		for _, r := range firstRank {
+			if r == 0 {
+				continue
+			}
+			qr.Found++
+			if qr.Rank == 0 || r < qr.Rank {
+				qr.Rank = r
+			}
+		}
+		if qr.Rank > 0 {
+			res.MRR += 1 / float64(qr.Rank)
+		}
+		for _, c := range cutoffs {
+			res.RecallAt[c] += benchRecall(firstRank, c)
+		}
+		res.Results = append(res.Results, qr)
+	}
+
+	n := float64(len(suite.Queries))
+	res.MRR /= n
+	for c := range res.RecallAt {
+		res.RecallAt[c] /= n
+	}
+	res.DurationMs = time.Since(start).Milliseconds()
+	return res, nil
+}
+
+// benchRecall is the fraction of targets found within the top cutoff results.
+func benchRecall(firstRank []int, cutoff int) float64 {
+	if len(firstRank) == 0 {
+		return 0
+	}
+	found := 0
+	for _, r := range firstRank {
+		if r > 0 && r <= cutoff {
+			found++
+		}
+	}
+	return float64(found) / float64(len(firstRank))
+}
+
+func benchRank(ctx context.Context, client Querier, cfg BenchConfig, query string, k int) ([]benchHit, error) {
+	embedding, err := generateEmbedding(ctx, cfg.EmbeddingURL, cfg.EmbeddingModel, query)
+	if err != nil {
+		return nil, err
+	}
+	args := normalizeSemanticArgs(SemanticSearchArgs{Query: query, Limit: k})
+	args.Limit = k // the bench may ask for more than the interactive cap
+	result, err := executeHNSWQuery(ctx, client, embedding, args)
+	if err != nil {
+		return nil, err
+	}
+	rows := postFilterByPath(result.Rows, "", args.Role, query, "", true)
+	rows = rerankRows(ctx, client, cfg.Git, rows, query, k, cfg.Ranking)
+	if len(rows) > k {
+		rows = rows[:k]
+	}
+	hits := make([]benchHit, 0, len(rows))
+	for _, row := range rows {
+		hits = append(hits, benchHit{Name: AnyToString(row[0]), File: AnyToString(row[1])})
+	}
+	return hits, nil
+}
+
+func benchTargetMatches(target BenchTarget, hit benchHit) bool {
+	if target.File == "" && target.Function == "" {
+		return false
+	}
+	if target.File != "" {
+		file := strings.TrimPrefix(target.File, "./")
+		if hit.File != file && !strings.HasSuffix(hit.File, "/"+file) && !strings.HasPrefix(hit.File, strings.TrimSuffix(file, "/")+"/") {
+			return false
+		}
+	}
+	if target.Function != "" && hit.Name != target.Function && !strings.HasSuffix(hit.Name, "."+target.Function) {
+		return false
+	}
+	return true
+}
+
+// FormatBenchResults renders a comparison table of one or more runs. With
+// verbose set, queries that missed or ranked below first are listed per run.
+func FormatBenchResults(results []*BenchResult, verbose bool) string {
+	if len(results) == 0 {
+		return "No benchmark results.\n"
+	}
+	cutoffs := BenchCutoffs(results[0].K)
+
+	var sb strings.Builder
+	sb.WriteString("| Config | Queries |")
+	for _, c := range cutoffs {
+		fmt.Fprintf(&sb, " Recall@%d |", c)
+	}
+	sb.WriteString(" MRR | Errors | Time |\n|---|---|")
+	sb.WriteString(strings.Repeat("---|", len(cutoffs)+3))
+	sb.WriteString("\n")
+	for _, r := range results {
+		fmt.Fprintf(&sb, "| %s | %d |", r.Name, r.Queries)
+		for _, c := range cutoffs {
+			fmt.Fprintf(&sb, " %.2f |", r.RecallAt[c])
+		}
+		fmt.Fprintf(&sb, " %.3f | %d | %s |\n", r.MRR, r.Errors, (time.Duration(r.DurationMs) * time.Millisecond).String())
+	}
+
+	if !verbose {
+		return sb.String()
+	}
+	for _, r := range results {
+		var misses []BenchQueryResult
+		for _, q := range r.Results {
+			if q.Rank != 1 {
+				misses = append(misses, q)
+			}
+		}
+		if len(misses) == 0 {
+			continue
+		}
+		sort.SliceStable(misses, func(i, j int) bool { return benchSortRank(misses[i]) > benchSortRank(misses[j]) })
+		fmt.Fprintf(&sb, "\n### %s — %d queries not ranked first\n\n", r.Name, len(misses))
+		for _, q := range misses {
+			switch {
+			case q.Error != "":
+				fmt.Fprintf(&sb, "- %q — error: %s\n", q.Query, q.Error)
+			case q.Rank == 0:
+				fmt.Fprintf(&sb, "- %q — not in top %d", q.Query, r.K)
+				if len(q.Top) > 0 {
+					fmt.Fprintf(&sb, " (got `%s`)", q.Top[0])
+				}
+				sb.WriteString("\n")
+			default:
+				fmt.Fprintf(&sb, "- %q — rank %d, %d/%d targets found\n", q.Query, q.Rank, q.Found, q.Expected)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// benchSortRank orders misses before low ranks so the worst queries lead.
+func benchSortRank(q BenchQueryResult) int {
+	if q.Rank == 0 {
+		return 1 << 30
+	}
+	return q.Rank
+}
@@ -0,0 +1,118 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRunRetrievalBench(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"embedding": []float64{0.1, 0.2}})
+	}))
+	defer server.Close()
+
+	ctx := setupTest(t)
+	client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		cols := []string{"name", "file_path", "signature", "start_line", "distance", "code_text"}
+		return NewMockQueryResult(cols, [][]any{
+			{"Router.Register", "internal/http/router.go", "", int64(1), 0.1, ""},
+			{"Open", "internal/store/store.go", "", int64(1), 0.2, ""},
+			{"TestOpen", "internal/store/store_test.go", "", int64(1), 0.25, ""},
+			{"Migrate", "internal/store/migrate.go", "", int64(1), 0.3, ""},
+		}), nil
+	}, nil)
+
+	suite := BenchSuite{K: 3, Queries: []BenchQuery{
+		{Query: "register routes", Expect: []BenchTarget{{Function: "Register"}}},
+		{Query: "run migrations", Expect: []BenchTarget{{File: "store/migrate.go"}, {Function: "Missing"}}},
+		{Query: "payments", Expect: []BenchTarget{{File: "internal/payments/"}}},
+	}}
+	res, err := RunRetrievalBench(ctx, client, suite, BenchConfig{Name: "nomic", EmbeddingURL: server.URL, EmbeddingModel: "nomic-embed-text"})
+	assertNoError(t, err)
+
+	if got := res.Results[1].Rank; got != 3 {
+		t.Errorf("migrate rank = %d, want 3 (test files are filtered out)", got)
+	}
+	if wantMRR := (1.0 + 1.0/3) / 3; math.Abs(res.MRR-wantMRR) > 1e-9 {
+		t.Errorf("MRR = %v, want %v", res.MRR, wantMRR)
+	}
+	if want := (1.0 + 0 + 0) / 3; math.Abs(res.RecallAt[1]-want) > 1e-9 {
+		t.Errorf("recall@1 = %v, want %v", res.RecallAt[1], want)
+	}
+	if want := (1.0 + 0.5 + 0) / 3; math.Abs(res.RecallAt[3]-want) > 1e-9 {
+		t.Errorf("recall@3 = %v, want %v", res.RecallAt[3], want)
+	}
+
+	out := FormatBenchResults([]*BenchResult{res}, true)
+	assertContains(t, out, "| Config | Queries | Recall@1 | Recall@3 | MRR | Errors | Time |")
+	assertContains(t, out, `- "payments" — not in top 3 (got `+"`internal/http/router.go:Router.Register`)")
+	assertContains(t, out, `- "run migrations" — rank 3, 1/2 targets found`)
+	if strings.Index(out, `"payments"`) > strings.Index(out, `"run migrations"`) {
+		t.Error("misses should be listed before low ranks")
+	}
+}
+
+func TestRunRetrievalBench_EmbeddingError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not loaded", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	ctx := setupTest(t)
+	client := NewMockClientWithResults(nil, nil)
+	suite := BenchSuite{Queries: []BenchQuery{{Query: "anything", Expect: []BenchTarget{{Function: "X"}}}}}
+	res, err := RunRetrievalBench(ctx, client, suite, BenchConfig{Name: "broken", EmbeddingURL: server.URL, EmbeddingModel: "m"})
+	assertNoError(t, err)
+	if res.Errors != 1 || res.MRR != 0 {
+		t.Errorf("errors=%d mrr=%v, want 1 and 0", res.Errors, res.MRR)
+	}
+	if res.K != 10 {
+		t.Errorf("default k = %d, want 10", res.K)
+	}
+}
+
+func TestBenchTargetMatches(t *testing.T) {
+	hit := benchHit{Name: "Server.Start", File: "internal/server/server.go"}
+	tests := []struct {
+		target BenchTarget
+		want   bool
+	}{
+		{BenchTarget{Function: "Start"}, true},
+		{BenchTarget{Function: "Server.Start"}, true},
+		{BenchTarget{Function: "art"}, false},
+		{BenchTarget{File: "server/server.go"}, true},
+		{BenchTarget{File: "./internal/server/server.go"}, true},
+		{BenchTarget{File: "internal/server"}, true},
+		{BenchTarget{File: "internal/serv"}, false},
+		{BenchTarget{File: "server.go", Function: "Stop"}, false},
+		{BenchTarget{}, false},
+	}
+	for _, tt := range tests {
+		if got := benchTargetMatches(tt.target, hit); got != tt.want {
+			t.Errorf("benchTargetMatches(%+v) = %v, want %v", tt.target, got, tt.want)
+		}
+	}
+}
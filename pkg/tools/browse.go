@@ -0,0 +1,198 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Structured lookups for interactive browsers (cie browse, the serve web
+// UI), which need navigable records rather than the markdown the MCP tools
+// return.
+
+// PackageRef is a directory of indexed files.
+type PackageRef struct {
+	Path      string `json:"path"`
+	Files     int    `json:"files"`
+	Functions int    `json:"functions"`
+}
+
+// FunctionRef is a function a browser can list and open.
+type FunctionRef struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	FilePath   string  `json:"file_path"`
+	Signature  string  `json:"signature,omitempty"`
+	StartLine  int     `json:"start_line"`
+	EndLine    int     `json:"end_line"`
+	Similarity float64 `json:"similarity,omitempty"` // set by semantic search
+}
+
+// browseFunctionColumns are the cie_function columns scanned into a
+// FunctionRef, name and file_path first as postFilterByPath expects.
+const browseFunctionColumns = "name, file_path, id, signature, start_line, end_line"
+
+// BrowsePackages lists the directories holding indexed files with their
+// file and function counts, sorted by path.
+func BrowsePackages(ctx context.Context, client Querier) ([]PackageRef, error) {
+	files, err := client.Query(ctx, `?[path] := *cie_file { path }`)
+	if err != nil {
+		return nil, err
+	}
+	funcs, err := client.Query(ctx, `?[file_path, count(id)] := *cie_function { id, file_path }`)
+	if err != nil {
+		return nil, err
+	}
+	byDir := map[string]*PackageRef{}
+	pkg := func(file string) *PackageRef {
+		dir := ExtractDir(file)
+		if byDir[dir] == nil {
+			byDir[dir] = &PackageRef{Path: dir}
+		}
+		return byDir[dir]
+	}
+	for _, row := range files.Rows {
+		pkg(AnyToString(row[0])).Files++
+	}
+	for _, row := range funcs.Rows {
+		n, _ := strconv.Atoi(AnyToString(row[1]))
+		pkg(AnyToString(row[0])).Functions += n
+	}
+	packages := make([]PackageRef, 0, len(byDir))
+	for _, p := range byDir {
+		packages = append(packages, *p)
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Path < packages[j].Path })
+	return packages, nil
+}
+
+// BrowseFunctions lists the functions of the files directly in dir, by
+// file and line.
+func BrowseFunctions(ctx context.Context, client Querier, dir string) ([]FunctionRef, error) {
+	pattern := "^" + EscapeRegex(dir) + "/[^/]+$"
+	if dir == "" || dir == "." {
+		pattern = "^[^/]+$"
+	}
+	script := fmt.Sprintf(`?[%s] := *cie_function { %s }, regex_matches(file_path, %s)
+:order file_path, start_line`, browseFunctionColumns, browseFunctionColumns, QuoteCozoPattern(pattern))
+	res, err := client.Query(ctx, script)
+	if err != nil {
+		return nil, err
+	}
+	return functionRefs(res.Rows), nil
+}
+
+// BrowseSearch finds functions by meaning when an embedding provider is
+// reachable and the index has embeddings, and by name otherwise. semantic
+// reports which one answered.
+func BrowseSearch(ctx context.Context, client Querier, query, embeddingURL, embeddingModel string, limit int) (refs []FunctionRef, semantic bool, err error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if embedding, embedErr := generateEmbedding(ctx, embeddingURL, embeddingModel, query); embedErr == nil {
+		args := normalizeSemanticArgs(SemanticSearchArgs{Query: query, Limit: limit})
+		queryK, ef := buildHNSWParams(args.Limit, args.Role, "")
+		script := fmt.Sprintf(`?[%s, distance] :=
+	~cie_function_embedding:embedding_idx { function_id: id | query: q, k: %d, ef: %d, bind_distance: distance },
+	q = %s,
+	*cie_function { %s }
+:order distance`, browseFunctionColumns, queryK, ef, formatEmbeddingForCozoDB(embedding), browseFunctionColumns)
+		if res, qErr := client.Query(ctx, script); qErr == nil && len(res.Rows) > 0 {
+			scaleDistances(res.Rows, 6, hnswDistanceScale(ctx, client))
+			rows := postFilterByPath(res.Rows, "", args.Role, query, "", true)
+			refs = functionRefs(rows)
+			for i, row := range rows {
+				if d, ok := row[6].(float64); ok {
+					refs[i].Similarity = max(0, 1-d/2)
+				}
+			}
+			if len(refs) > limit {
+				refs = refs[:limit]
+			}
+			return refs, true, nil
+		}
+	}
+	script := fmt.Sprintf(`?[%s] := *cie_function { %s }, regex_matches(lowercase(name), %s)
+:order name
+:limit %d`, browseFunctionColumns, browseFunctionColumns, QuoteCozoPattern(EscapeRegex(strings.ToLower(query))), limit)
+	res, err := client.Query(ctx, script)
+	if err != nil {
+		return nil, false, err
+	}
+	return functionRefs(res.Rows), false, nil
+}
+
+// BrowseCode returns the whole source of a function, including the code
+// stored past code_text.
+func BrowseCode(ctx context.Context, client Querier, id string) (string, error) {
+	res, err := client.Query(ctx, fmt.Sprintf(`?[name, file_path, start_line] := *cie_function { id, name, file_path, start_line }, id = %q`, id))
+	if err != nil {
+		return "", err
+	}
+	if len(res.Rows) == 0 || len(res.Rows[0]) != 3 {
+		return "", nil
+	}
+	row := res.Rows[0]
+	return fullFunctionCode(ctx, client, AnyToString(row[0]), AnyToString(row[1]), row[2]), nil
+}
+
+// BrowseCallers lists the functions that call the function id.
+func BrowseCallers(ctx context.Context, client Querier, id string) ([]FunctionRef, error) {
+	return browseEdges(ctx, client, fmt.Sprintf("*cie_calls { caller_id: id, callee_id: %q }", id))
+}
+
+// BrowseCallees lists the functions the function id calls.
+func BrowseCallees(ctx context.Context, client Querier, id string) ([]FunctionRef, error) {
+	return browseEdges(ctx, client, fmt.Sprintf("*cie_calls { caller_id: %q, callee_id: id }", id))
+}
+
+func browseEdges(ctx context.Context, client Querier, edge string) ([]FunctionRef, error) {
+	script := fmt.Sprintf(`?[%s] := %s, *cie_function { %s }
+:order name`, browseFunctionColumns, edge, browseFunctionColumns)
+	res, err := client.Query(ctx, script)
+	if err != nil {
+		return nil, err
+	}
+	return functionRefs(res.Rows), nil
+}
+
+// functionRefs scans rows selected with browseFunctionColumns.
+func functionRefs(rows [][]any) []FunctionRef {
+	refs := make([]FunctionRef, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < 6 {
+			continue
+		}
+		start, _ := strconv.Atoi(AnyToString(row[4]))
+		end, _ := strconv.Atoi(AnyToString(row[5]))
+		refs = append(refs, FunctionRef{
+			Name:      AnyToString(row[0]),
+			FilePath:  AnyToString(row[1]),
+			ID:        AnyToString(row[2]),
+			Signature: AnyToString(row[3]),
+			StartLine: start,
+			EndLine:   end,
+		})
+	}
+	return refs
+}
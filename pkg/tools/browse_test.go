@@ -0,0 +1,89 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestBrowsePackages(t *testing.T) {
+	client := &MockCIEClient{QueryFunc: func(_ context.Context, script string) (*QueryResult, error) {
+		if strings.Contains(script, "*cie_file") {
+			return NewMockQueryResult([]string{"path"}, [][]any{{"main.go"}, {"internal/a/a.go"}, {"internal/a/b.go"}}), nil
+		}
+		return NewMockQueryResult([]string{"file_path", "count(id)"}, [][]any{{"internal/a/a.go", float64(3)}, {"internal/a/b.go", float64(2)}}), nil
+	}}
+	pkgs, err := BrowsePackages(context.Background(), client)
+	assertNoError(t, err)
+	if len(pkgs) != 2 {
+		t.Fatalf("packages = %+v", pkgs)
+	}
+	assertEqual(t, pkgs[0], PackageRef{Path: ".", Files: 1})
+	assertEqual(t, pkgs[1], PackageRef{Path: "internal/a", Files: 2, Functions: 5})
+}
+
+func TestBrowseSearch_NameFallback(t *testing.T) {
+	var script string
+	client := &MockCIEClient{QueryFunc: func(_ context.Context, s string) (*QueryResult, error) {
+		script = s
+		return NewMockQueryResult(nil, [][]any{{"ServeHTTP", "internal/http/server.go", "fn:1", "func ServeHTTP()", float64(12), float64(30)}}), nil
+	}}
+	// No embedding provider is listening on this URL.
+	refs, semantic, err := BrowseSearch(context.Background(), client, "serve.http", "http://127.0.0.1:1", "nomic-embed-text", 5)
+	assertNoError(t, err)
+	if semantic {
+		t.Error("expected the name fallback")
+	}
+	assertContains(t, script, `regex_matches(lowercase(name), ___"serve[.]http"___)`)
+	assertContains(t, script, ":limit 5")
+	if len(refs) != 1 {
+		t.Fatalf("refs = %+v", refs)
+	}
+	assertEqual(t, refs[0], FunctionRef{ID: "fn:1", Name: "ServeHTTP", FilePath: "internal/http/server.go", Signature: "func ServeHTTP()", StartLine: 12, EndLine: 30})
+}
+
+func TestBrowseCallers(t *testing.T) {
+	var script string
+	client := &MockCIEClient{QueryFunc: func(_ context.Context, s string) (*QueryResult, error) {
+		script = s
+		return NewMockQueryResult(nil, nil), nil
+	}}
+	_, err := BrowseCallers(context.Background(), client, "fn:1")
+	assertNoError(t, err)
+	assertContains(t, script, `*cie_calls { caller_id: id, callee_id: "fn:1" }`)
+}
+
+func TestBrowseCode_IncludesOverflow(t *testing.T) {
+	client := &MockCIEClient{QueryFunc: func(_ context.Context, s string) (*QueryResult, error) {
+		switch {
+		case strings.Contains(s, "*cie_function_code_chunk"):
+			return NewMockQueryResult(nil, [][]any{{float64(0), float64(40), "\treturn nil\n}"}}), nil
+		case strings.Contains(s, "*cie_function_code"):
+			return NewMockQueryResult(nil, [][]any{{"func Long() error {\n"}}), nil
+		default:
+			return NewMockQueryResult(nil, [][]any{{"Long", "long.go", float64(10)}}), nil
+		}
+	}}
+	code, err := BrowseCode(context.Background(), client, "fn:1")
+	assertNoError(t, err)
+	assertEqual(t, code, "func Long() error {\n\treturn nil\n}")
+}
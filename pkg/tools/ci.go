@@ -0,0 +1,291 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CIJobsArgs holds arguments for the CI workflow lookup.
+type CIJobsArgs struct {
+	Query string // optional: text to find in job names, step commands and references
+	Job   string // optional: job name; shows its steps and references
+	Limit int    // jobs to show (default 20)
+}
+
+// ciJob is an indexed CI job.
+type ciJob struct {
+	ID, FilePath, Workflow, Name, Title, Stage, RunsOn, Needs, Triggers, Line string
+}
+
+// label returns "workflow / job".
+func (j ciJob) label() string {
+	return j.Workflow + " / " + j.Name
+}
+
+// CIJobs shows the jobs of indexed CI workflows (GitHub Actions, GitLab CI).
+// With a query, it lists the jobs whose name, steps or references mention it,
+// answering questions like "which workflow runs the integration tests".
+// With a job name, it shows that job's steps, scripts and variables.
+func CIJobs(ctx context.Context, client Querier, args CIJobsArgs) (*ToolResult, error) {
+	if args.Limit <= 0 {
+		args.Limit = 20
+	}
+
+	result, err := client.Query(ctx, "?[id, file_path, workflow, name, title, stage, runs_on, needs, triggers, start_line] := *cie_ci_job { id, file_path, workflow, name, title, stage, runs_on, needs, triggers, start_line } :order file_path, start_line :limit 2000")
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v (indexes built before cie_ci_job need 'cie index')", err)), nil
+	}
+	var jobs []ciJob
+	for _, r := range result.Rows {
+		if len(r) >= 10 {
+			jobs = append(jobs, ciJob{
+				ID: AnyToString(r[0]), FilePath: AnyToString(r[1]), Workflow: AnyToString(r[2]), Name: AnyToString(r[3]), Title: AnyToString(r[4]),
+				Stage: AnyToString(r[5]), RunsOn: AnyToString(r[6]), Needs: AnyToString(r[7]), Triggers: AnyToString(r[8]), Line: AnyToString(r[9]),
+			})
+		}
+	}
+	if len(jobs) == 0 {
+		return NewResult("No CI jobs indexed. GitHub Actions workflows (.github/workflows/*.yml) and GitLab CI files (.gitlab-ci.yml) are indexed."), nil
+	}
+
+	if args.Job != "" {
+		var selected []ciJob
+		for _, j := range jobs {
+			if strings.EqualFold(j.Name, args.Job) {
+				selected = append(selected, j)
+			}
+		}
+		if len(selected) == 0 {
+			for _, j := range jobs {
+				if strings.Contains(strings.ToLower(j.Name), strings.ToLower(args.Job)) {
+					selected = append(selected, j)
+				}
+			}
+		}
+		if len(selected) == 0 {
+			return NewResult(fmt.Sprintf("No CI job name contains '%s'.", args.Job)), nil
+		}
+		var sb strings.Builder
+		for i, j := range selected {
+			if i == 3 {
+				fmt.Fprintf(&sb, "_%d more jobs match '%s'._\n", len(selected)-3, args.Job)
+				break
+			}
+			formatCIJobDetail(ctx, client, &sb, j)
+		}
+		return NewResult(sb.String()), nil
+	}
+
+	if args.Query != "" {
+		return NewResult(searchCIJobs(ctx, client, jobs, args.Query, args.Limit)), nil
+	}
+
+	counts := make(map[string]string)
+	if steps, err := client.Query(ctx, "?[job_id, count(idx)] := *cie_ci_step { job_id, idx }"); err == nil {
+		for _, r := range steps.Rows {
+			if len(r) >= 2 {
+				counts[AnyToString(r[0])] = AnyToString(r[1])
+			}
+		}
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## CI jobs (%d)\n\n| Workflow | Job | Runs on | Triggers / Stage | Steps | File |\n|----------|-----|---------|------------------|-------|------|\n", len(jobs))
+	for i, j := range jobs {
+		if i == args.Limit {
+			fmt.Fprintf(&sb, "\n_%d more; pass `query` or `job` to narrow down._\n", len(jobs)-args.Limit)
+			break
+		}
+		when := j.Triggers
+		if j.Stage != "" {
+			when = j.Stage
+		}
+		steps := counts[j.ID]
+		if steps == "" {
+			steps = "0"
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s | %s:%s |\n", j.Workflow, j.Name, orDash(j.RunsOn), orDash(when), steps, j.FilePath, j.Line)
+	}
+	return NewResult(sb.String()), nil
+}
+
+// searchCIJobs lists the jobs whose name, step names, step commands or
+// references match query, with the matching steps.
+func searchCIJobs(ctx context.Context, client Querier, jobs []ciJob, query string, limit int) string {
+	pattern := "(?i)" + EscapeRegex(query)
+	matchedSteps := make(map[string][]string)
+	steps, err := client.Query(ctx, fmt.Sprintf(`?[job_id, idx, name, command, line] := *cie_ci_step { job_id, idx, name, command, line }, regex_matches(command, %[1]q)
+?[job_id, idx, name, command, line] := *cie_ci_step { job_id, idx, name, command, line }, regex_matches(name, %[1]q)
+:order job_id, idx`, pattern))
+	if err == nil {
+		for _, r := range steps.Rows {
+			if len(r) >= 5 {
+				jobID := AnyToString(r[0])
+				matchedSteps[jobID] = append(matchedSteps[jobID], formatCIStepMatch(AnyToString(r[2]), AnyToString(r[3]), AnyToString(r[4]), query))
+			}
+		}
+	}
+	matchedRefs := make(map[string][]string)
+	refs, err := client.Query(ctx, fmt.Sprintf("?[job_id, kind, name] := *cie_ci_ref { job_id, kind, name }, regex_matches(name, %q) :order job_id, kind, name", pattern))
+	if err == nil {
+		for _, r := range refs.Rows {
+			if len(r) >= 3 {
+				jobID := AnyToString(r[0])
+				matchedRefs[jobID] = append(matchedRefs[jobID], fmt.Sprintf("%s `%s`", AnyToString(r[1]), AnyToString(r[2])))
+			}
+		}
+	}
+
+	lower := strings.ToLower(query)
+	var matched []ciJob
+	for _, j := range jobs {
+		nameMatch := strings.Contains(strings.ToLower(j.Name+" "+j.Title+" "+j.Workflow), lower)
+		if nameMatch || len(matchedSteps[j.ID]) > 0 || len(matchedRefs[j.ID]) > 0 {
+			matched = append(matched, j)
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## CI jobs matching '%s' (%d)\n\n", query, len(matched))
+	if len(matched) == 0 {
+		sb.WriteString("No job name, step or reference mentions it.\n")
+		return sb.String()
+	}
+	for i, j := range matched {
+		if i == limit {
+			fmt.Fprintf(&sb, "_%d more jobs match._\n", len(matched)-limit)
+			break
+		}
+		fmt.Fprintf(&sb, "### %s (%s:%s)\n", j.label(), j.FilePath, j.Line)
+		if j.Triggers != "" {
+			fmt.Fprintf(&sb, "**Triggers**: %s\n", j.Triggers)
+		}
+		if j.Stage != "" {
+			fmt.Fprintf(&sb, "**Stage**: %s\n", j.Stage)
+		}
+		for _, s := range matchedSteps[j.ID] {
+			sb.WriteString(s)
+		}
+		if refs := matchedRefs[j.ID]; len(refs) > 0 {
+			fmt.Fprintf(&sb, "- references %s\n", strings.Join(refs, ", "))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// formatCIStepMatch renders a matching step as a bullet, showing only the
+// command lines that mention query.
+func formatCIStepMatch(name, command, line, query string) string {
+	var lines []string
+	lower := strings.ToLower(query)
+	for _, l := range strings.Split(command, "\n") {
+		if l = strings.TrimSpace(l); l != "" && strings.Contains(strings.ToLower(l), lower) {
+			lines = append(lines, "`"+truncateCICommand(l)+"`")
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "`"+truncateCICommand(strings.TrimSpace(strings.SplitN(command, "\n", 2)[0]))+"`")
+	}
+	if name != "" {
+		return fmt.Sprintf("- step \"%s\" (line %s): %s\n", name, line, strings.Join(lines, "; "))
+	}
+	return fmt.Sprintf("- step (line %s): %s\n", line, strings.Join(lines, "; "))
+}
+
+func formatCIJobDetail(ctx context.Context, client Querier, sb *strings.Builder, j ciJob) {
+	fmt.Fprintf(sb, "## %s\n\n", j.label())
+	fmt.Fprintf(sb, "**File**: %s:%s\n", j.FilePath, j.Line)
+	if j.Title != "" {
+		fmt.Fprintf(sb, "**Name**: %s\n", j.Title)
+	}
+	if j.Triggers != "" {
+		fmt.Fprintf(sb, "**Triggers**: %s\n", j.Triggers)
+	}
+	if j.Stage != "" {
+		fmt.Fprintf(sb, "**Stage**: %s\n", j.Stage)
+	}
+	if j.RunsOn != "" {
+		fmt.Fprintf(sb, "**Runs on**: %s\n", j.RunsOn)
+	}
+	if j.Needs != "" {
+		fmt.Fprintf(sb, "**Needs**: %s\n", strings.ReplaceAll(j.Needs, ",", ", "))
+	}
+
+	steps, err := client.Query(ctx, fmt.Sprintf("?[idx, name, kind, command, line] := *cie_ci_step { job_id, idx, name, kind, command, line }, job_id = %q :order idx", j.ID))
+	if err == nil && len(steps.Rows) > 0 {
+		sb.WriteString("\n**Steps**:\n")
+		for _, r := range steps.Rows {
+			if len(r) < 5 {
+				continue
+			}
+			name, kind, command := AnyToString(r[1]), AnyToString(r[2]), strings.TrimSpace(AnyToString(r[3]))
+			if strings.Contains(command, "\n") {
+				command = strings.SplitN(command, "\n", 2)[0] + " …"
+			}
+			label := kind
+			if name != "" {
+				label = kind + " \"" + name + "\""
+			}
+			fmt.Fprintf(sb, "%s. %s (line %s): `%s`\n", AnyToString(r[0]), label, AnyToString(r[4]), truncateCICommand(command))
+		}
+	}
+
+	refs, err := client.Query(ctx, fmt.Sprintf("?[kind, name, line] := *cie_ci_ref { job_id, kind, name, line }, job_id = %q :order line", j.ID))
+	if err == nil && len(refs.Rows) > 0 {
+		byKind := make(map[string][]string)
+		for _, r := range refs.Rows {
+			if len(r) >= 3 {
+				kind := AnyToString(r[0])
+				byKind[kind] = append(byKind[kind], fmt.Sprintf("`%s` (line %s)", AnyToString(r[1]), AnyToString(r[2])))
+			}
+		}
+		for _, section := range []struct{ kind, title string }{
+			{"extends", "Extends"},
+			{"action", "Actions"},
+			{"script", "Scripts"},
+			{"make", "Make targets"},
+			{"env", "Variables"},
+			{"secret", "Secrets"},
+		} {
+			if items := byKind[section.kind]; len(items) > 0 {
+				fmt.Fprintf(sb, "\n**%s**: %s\n", section.title, strings.Join(items, ", "))
+			}
+		}
+	}
+	sb.WriteString("\n")
+}
+
+// truncateCICommand shortens a command line for display.
+func truncateCICommand(command string) string {
+	if len(command) > 120 {
+		return command[:117] + "..."
+	}
+	return command
+}
+
+// orDash returns s, or "—" when it is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
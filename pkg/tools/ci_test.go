@@ -0,0 +1,127 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func ciMock(t *testing.T) Querier {
+	return NewMockClientScripted(t,
+		MockQuery{
+			Match: []string{"?[id, file_path, workflow, name"},
+			Want:  []string{"*cie_ci_job {", ":order file_path, start_line"},
+			Rows: [][]any{
+				{"ci:1", ".github/workflows/ci.yml", "CI", "unit", "", "", "ubuntu-latest", "", "push,pull_request", float64(9)},
+				{"ci:2", ".github/workflows/ci.yml", "CI", "e2e", "End-to-end", "", "ubuntu-latest", "unit", "push,pull_request", float64(15)},
+				{"ci:3", ".gitlab-ci.yml", ".gitlab-ci.yml", "deploy", "", "deploy", "alpine:3", "", "", float64(20)},
+			},
+		},
+		MockQuery{
+			Match: []string{"?[job_id, count(idx)]"},
+			Want:  []string{"*cie_ci_step { job_id, idx }"},
+			Rows:  [][]any{{"ci:1", float64(2)}, {"ci:2", float64(2)}},
+		},
+		MockQuery{
+			Match: []string{"?[job_id, idx, name, command, line]"},
+			Want:  []string{"*cie_ci_step {", `regex_matches(command, "(?i)integration")`, `regex_matches(name, "(?i)integration")`},
+			Rows:  [][]any{{"ci:2", float64(1), "Run", "./scripts/start-db.sh\ngo test -tags integration ./...\n", float64(21)}},
+		},
+		MockQuery{
+			Match: []string{"?[job_id, kind, name]"},
+			Want:  []string{"*cie_ci_ref {", `regex_matches(name, "(?i)integration")`},
+			Rows:  [][]any{{"ci:2", "make", "integration-test"}},
+		},
+		MockQuery{
+			Match: []string{"?[idx, name, kind, command, line]"},
+			Want:  []string{"*cie_ci_step {", `job_id = "ci:2"`, ":order idx"},
+			Rows: [][]any{
+				{float64(0), "", "uses", "actions/checkout@v4", float64(19)},
+				{float64(1), "Run", "run", "./scripts/start-db.sh\nmake integration-test\n", float64(21)},
+			},
+		},
+		MockQuery{
+			Match: []string{"?[kind, name, line]"},
+			Want:  []string{"*cie_ci_ref {", `job_id = "ci:2"`},
+			Rows: [][]any{
+				{"action", "actions/checkout@v4", float64(19)},
+				{"script", "scripts/start-db.sh", float64(22)},
+				{"secret", "DATABASE_URL", float64(24)},
+			},
+		},
+	)
+}
+
+func TestCIJobs(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("list", func(t *testing.T) {
+		result, err := CIJobs(ctx, ciMock(t), CIJobsArgs{})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "## CI jobs (3)")
+		assertContains(t, result.Text, "| CI | e2e | ubuntu-latest | push,pull_request | 2 | .github/workflows/ci.yml:15 |")
+		assertContains(t, result.Text, "| .gitlab-ci.yml | deploy | alpine:3 | deploy | 0 | .gitlab-ci.yml:20 |")
+	})
+
+	t.Run("query", func(t *testing.T) {
+		result, err := CIJobs(ctx, ciMock(t), CIJobsArgs{Query: "integration"})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "## CI jobs matching 'integration' (1)")
+		assertContains(t, result.Text, "### CI / e2e (.github/workflows/ci.yml:15)")
+		assertContains(t, result.Text, "- step \"Run\" (line 21): `go test -tags integration ./...`")
+		assertContains(t, result.Text, "- references make `integration-test`")
+		if strings.Contains(result.Text, "start-db") {
+			t.Error("only the command lines mentioning the query should be shown")
+		}
+	})
+
+	t.Run("job", func(t *testing.T) {
+		result, err := CIJobs(ctx, ciMock(t), CIJobsArgs{Job: "E2E"})
+		assertNoError(t, err)
+		for _, want := range []string{
+			"## CI / e2e",
+			"**Name**: End-to-end",
+			"**Needs**: unit",
+			"0. uses (line 19): `actions/checkout@v4`",
+			"1. run \"Run\" (line 21): `./scripts/start-db.sh …`",
+			"**Scripts**: `scripts/start-db.sh` (line 22)",
+			"**Secrets**: `DATABASE_URL` (line 24)",
+		} {
+			assertContains(t, result.Text, want)
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		result, err := CIJobs(ctx, ciMock(t), CIJobsArgs{Job: "lint"})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "No CI job name contains 'lint'")
+	})
+
+	t.Run("empty index", func(t *testing.T) {
+		empty := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+			return NewMockQueryResult(nil, nil), nil
+		}, nil)
+		result, err := CIJobs(ctx, empty, CIJobsArgs{Query: "test"})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "No CI jobs indexed")
+	})
+}
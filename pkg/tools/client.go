@@ -66,10 +66,20 @@ type QueryResult struct {
 
 // Query executes a CozoScript query against the CIE Edge Cache.
 func (c *CIEClient) Query(ctx context.Context, script string) (*QueryResult, error) {
-	reqBody, _ := json.Marshal(map[string]any{
+	return c.QueryWithParams(ctx, script, nil)
+}
+
+// QueryWithParams executes a CozoScript query with params bound to its
+// $name placeholders by the server.
+func (c *CIEClient) QueryWithParams(ctx context.Context, script string, params map[string]any) (*QueryResult, error) {
+	request := map[string]any{
 		"project_id": c.ProjectID,
 		"script":     script,
-	})
+	}
+	if len(params) > 0 {
+		request["params"] = params
+	}
+	reqBody, _ := json.Marshal(request)
 
 	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/v1/query", bytes.NewReader(reqBody))
 	if err != nil {
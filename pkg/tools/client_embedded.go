@@ -38,6 +38,11 @@ func NewEmbeddedQuerier(backend *storage.EmbeddedBackend) *EmbeddedQuerier {
 	return &EmbeddedQuerier{backend: backend}
 }
 
+// Backend returns the wrapped backend.
+func (q *EmbeddedQuerier) Backend() *storage.EmbeddedBackend {
+	return q.backend
+}
+
 // Query executes a Datalog query against the embedded backend.
 func (q *EmbeddedQuerier) Query(ctx context.Context, script string) (*QueryResult, error) {
 	result, err := q.backend.Query(ctx, script)
@@ -51,6 +56,34 @@ func (q *EmbeddedQuerier) Query(ctx context.Context, script string) (*QueryResul
 	}, nil
 }
 
+// QueryWithParams executes a Datalog query with params bound to its $name
+// placeholders.
+func (q *EmbeddedQuerier) QueryWithParams(ctx context.Context, script string, params map[string]any) (*QueryResult, error) {
+	result, err := q.backend.QueryWithParams(ctx, script, params)
+	if err != nil {
+		return nil, fmt.Errorf("embedded query: %w", err)
+	}
+	return &QueryResult{Headers: result.Headers, Rows: result.Rows}, nil
+}
+
+// ExecuteWithParams runs a mutating script with params bound to its $name
+// placeholders.
+func (q *EmbeddedQuerier) ExecuteWithParams(ctx context.Context, script string, params map[string]any) (*QueryResult, error) {
+	if err := q.backend.ExecuteWithParams(ctx, script, params); err != nil {
+		return nil, fmt.Errorf("embedded execute: %w", err)
+	}
+	return &QueryResult{Headers: []string{}, Rows: [][]any{}}, nil
+}
+
+// Execute runs a mutating script against the embedded backend.
+// Query is read-only, so writes permitted by a RawQueryPolicy come through here.
+func (q *EmbeddedQuerier) Execute(ctx context.Context, script string) (*QueryResult, error) {
+	if err := q.backend.Execute(ctx, script); err != nil {
+		return nil, fmt.Errorf("embedded execute: %w", err)
+	}
+	return &QueryResult{Headers: []string{}, Rows: [][]any{}}, nil
+}
+
 // QueryRaw executes a query and returns raw results as a map.
 func (q *EmbeddedQuerier) QueryRaw(ctx context.Context, script string) (map[string]any, error) {
 	result, err := q.backend.Query(ctx, script)
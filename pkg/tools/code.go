@@ -29,52 +29,128 @@ import (
 type GetFunctionCodeArgs struct {
 	FunctionName string
 	FullCode     bool // If true, return complete code without truncation
+
+	// Live, when set, is searched for functions in files that are not
+	// indexed yet before reporting "not found".
+	Live LiveSource
 }
 
+// maxAmbiguousMatches caps the candidates listed when a name is ambiguous.
+const maxAmbiguousMatches = 20
+
 // GetFunctionCode retrieves the full source code of a function.
 // Schema v3: code_text is in separate cie_function_code table
 func GetFunctionCode(ctx context.Context, client Querier, args GetFunctionCodeArgs) (*ToolResult, error) {
 	funcName := strings.TrimSpace(args.FunctionName)
 	if funcName == "" {
-		return NewError("Error: function_name cannot be empty"), nil
+		return NewInputError("Error: function_name cannot be empty"), nil
+	}
+
+	row, res := resolveFunctionCode(ctx, client, funcName)
+	if res != nil {
+		return res, nil
+	}
+	if row == nil {
+		if args.Live != nil {
+			if fn := findLiveFunction(ctx, args.Live, funcName); fn != nil {
+				args.Live.Queue([]string{fn.FilePath})
+				text := formatFunctionCode(fn.Name, fn.FilePath, fn.Signature, fn.CodeText, fn.StartLine, fn.EndLine, args.FullCode)
+				return NewResult(liveNote + "\n\n" + text), nil
+			}
+		}
+		return NewResult(fmt.Sprintf("Function '%s' not found.", funcName)), nil
 	}
 
-	// Try exact match first - join with cie_function_code for code_text
-	script := fmt.Sprintf(`?[name, file_path, signature, code_text, start_line, end_line] := *cie_function { id, name, file_path, signature, start_line, end_line }, *cie_function_code { function_id: id, code_text }, regex_matches(name, "(?i)^%s$") :limit 1`, EscapeRegex(funcName))
+	name, filePath := anyToStr(row[1]), anyToStr(row[2])
+	if args.Live != nil {
+		if fn := overlayFunction(ctx, args.Live, name, filePath); fn != nil {
+			text := formatFunctionCode(fn.Name, fn.FilePath, fn.Signature, fn.CodeText, fn.StartLine, fn.EndLine, args.FullCode)
+			return NewResult(overlayNote + "\n\n" + text), nil
+		}
+	}
+	code := decodeCodeText(row[4]) + functionOverflow(ctx, client, name, filePath, row[5])
+	text := formatFunctionCode(name, filePath, anyToStr(row[3]), code, row[5], row[6], args.FullCode)
+	text += functionNesting(ctx, client, name, filePath, anyToStr(row[5]))
+	return NewResult(text + functionProvenance(ctx, client, name, filePath)), nil
+}
 
-	result, err := client.Query(ctx, script)
+// resolveFunctionCode looks a function up by name: an exact or
+// package-qualified match first, then a partial one. The row holds
+// [id, name, file_path, signature, code_text, start_line, end_line] and is
+// nil when nothing matches. A non-nil result is returned to the caller as
+// is: the query failed or the name is ambiguous.
+func resolveFunctionCode(ctx context.Context, client Querier, funcName string) ([]any, *ToolResult) {
+	const head = `?[id, name, file_path, signature, code_text, start_line, end_line] := *cie_function { id, name, file_path, signature, start_line, end_line }, *cie_function_code { function_id: id, code_text }`
+	condition := fmt.Sprintf(`regex_matches(name, "(?i)^%s$")`, EscapeRegex(funcName))
+	if qualified, ok := qualifiedCondition(funcName); ok {
+		condition = fmt.Sprintf("(%s or %s)", condition, qualified)
+	}
+	result, err := client.Query(ctx, fmt.Sprintf("%s, %s :limit %d", head, condition, maxAmbiguousMatches))
 	if err != nil {
-		return NewError(fmt.Sprintf("Query error: %v", err)), nil
+		return nil, NewError(fmt.Sprintf("Query error: %v", err))
+	}
+
+	// The same simple name in several places: let the caller choose.
+	if matches := distinctFunctionMatches(result.Rows, 1, 2, 5, 3); len(matches) > 1 {
+		return nil, NewResult(fmt.Sprintf("Function '%s' is ambiguous (%d matches). Call again with one of these qualified names:\n\n%s",
+			funcName, len(matches), formatDisambiguation(matches)))
 	}
 
 	if len(result.Rows) == 0 {
-		// Try partial match
-		script = fmt.Sprintf(`?[name, file_path, signature, code_text, start_line, end_line] := *cie_function { id, name, file_path, signature, start_line, end_line }, *cie_function_code { function_id: id, code_text }, regex_matches(name, "(?i)%s") :limit 1`, EscapeRegex(funcName))
-		result, err = client.Query(ctx, script)
+		result, err = client.Query(ctx, fmt.Sprintf(`%s, regex_matches(name, "(?i)%s") :limit 1`, head, EscapeRegex(funcName)))
 		if err != nil {
-			return NewError(fmt.Sprintf("Query error: %v", err)), nil
+			return nil, NewError(fmt.Sprintf("Query error: %v", err))
 		}
 	}
-
 	if len(result.Rows) == 0 {
-		return NewResult(fmt.Sprintf("Function '%s' not found.", funcName)), nil
+		return nil, nil
 	}
+	return result.Rows[0], nil
+}
 
-	row := result.Rows[0]
-	name := anyToStr(row[0])
-	filePath := anyToStr(row[1])
-	signature := anyToStr(row[2])
-	codeText := anyToStr(row[3])
-	startLine := row[4]
-	endLine := row[5]
+// functionNesting lists the definition a function is nested in and the
+// functions nested in it, from cie_contains. It returns "" when there are
+// none or the index predates cie_contains.
+func functionNesting(ctx context.Context, client Querier, name, filePath, startLine string) string {
+	self := fmt.Sprintf(`*cie_function { id: self, name: self_name, file_path: self_path, start_line: self_line }, self_name = %q, self_path = %q, self_line = %s`,
+		name, filePath, startLine)
+	parentScript := fmt.Sprintf(`?[name, kind] := %[1]s, *cie_contains { parent_id, parent_kind: kind, child_id: self }, *cie_function { id: parent_id, name }
+?[name, kind] := %[1]s, *cie_contains { parent_id, parent_kind: kind, child_id: self }, *cie_type { id: parent_id, name }`, self)
+	childScript := fmt.Sprintf(`?[name, start_line] := %s, *cie_contains { parent_id: self, child_id }, *cie_function { id: child_id, name, start_line } :order start_line :limit 50`, self)
 
+	var sb strings.Builder
+	if parents, err := client.Query(ctx, parentScript); err == nil && len(parents.Rows) > 0 {
+		p := parents.Rows[0]
+		fmt.Fprintf(&sb, "\n\n**Nested in**: %s (%s)", anyToStr(p[0]), anyToStr(p[1]))
+	}
+	if children, err := client.Query(ctx, childScript); err == nil && len(children.Rows) > 0 {
+		names := make([]string, 0, len(children.Rows))
+		for _, r := range children.Rows {
+			names = append(names, fmt.Sprintf("`%s`", anyToStr(r[0])))
+		}
+		if sb.Len() == 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "\n**Contains**: %s", strings.Join(names, ", "))
+	}
+	return sb.String()
+}
+
+// formatFunctionCode renders a function's location, signature and code,
+// truncating long bodies unless fullCode is set.
+func formatFunctionCode(name, filePath, signature, codeText string, startLine, endLine any, fullCode bool) string {
 	// Determine language for syntax highlighting
 	lang := detectLanguage(filePath)
 
+	// Code text cut at the index's size limit ends before the function does
+	start, end := int(toFloat64(startLine)), int(toFloat64(endLine))
+	storedEnd := start + strings.Count(strings.TrimRight(codeText, "\n"), "\n")
+	cutAtIndex := start > 0 && storedEnd < end
+
 	// Truncate very long code unless full_code is requested
 	truncated := false
 	const maxCodeLen = 3000
-	if !args.FullCode && len(codeText) > maxCodeLen {
+	if !fullCode && len(codeText) > maxCodeLen {
 		codeText = codeText[:maxCodeLen]
 		truncated = true
 	}
@@ -90,20 +166,41 @@ func GetFunctionCode(ctx context.Context, client Querier, args GetFunctionCodeAr
 		sb.WriteString(fmt.Sprintf("- Use `Read` tool: `%s` (lines %v-%v)\n", filePath, startLine, endLine))
 		sb.WriteString("- Or call this tool with `full_code: true`")
 	}
+	if cutAtIndex {
+		fmt.Fprintf(&sb, "\n\n⚠️ **Stored code ends at line %d of %d** (cut at the indexed code-text size limit). "+
+			"Call `cie_get_lines` with `path: %q, start_line: %d, end_line: %d` for the rest.", storedEnd, end, filePath, storedEnd+1, end)
+	}
 
-	return NewResult(sb.String()), nil
+	return sb.String()
 }
 
 // ListFunctionsInFileArgs holds arguments for listing functions in a file.
 type ListFunctionsInFileArgs struct {
 	FilePath string
+	Outline  bool // one line per function with its full signature
+
+	// Live, when set and serving the file, lists its on-disk functions
+	// instead of the indexed ones.
+	Live LiveSource
 }
 
 // ListFunctionsInFile lists all functions defined in a specific file.
 func ListFunctionsInFile(ctx context.Context, client Querier, args ListFunctionsInFileArgs) (*ToolResult, error) {
 	filePath := strings.TrimSpace(args.FilePath)
 	if filePath == "" {
-		return NewError("Error: file_path cannot be empty"), nil
+		return NewInputError("Error: file_path cannot be empty"), nil
+	}
+	if args.Live != nil {
+		if path, fns := liveFunctionsInFile(ctx, args.Live, filePath); path != "" {
+			if args.Outline {
+				rows := make([][]any, len(fns))
+				for i, fn := range fns {
+					rows[i] = []any{fn.Name, fn.Signature, fn.StartLine}
+				}
+				return NewResult(formatFunctionOutline(path+" (working tree)", rows)), nil
+			}
+			return NewResult(formatLiveFunctionList(path, fns)), nil
+		}
 	}
 
 	// Try exact suffix match first (most reliable)
@@ -162,6 +259,9 @@ func ListFunctionsInFile(ctx context.Context, client Querier, args ListFunctions
 	if len(result.Rows) > 0 && len(result.Rows[0]) > 3 {
 		actualPath = anyToStr(result.Rows[0][3])
 	}
+	if args.Outline {
+		return NewResult(formatFunctionOutline(actualPath, result.Rows)), nil
+	}
 
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("**Functions in %s** (%d found):\n\n", actualPath, len(result.Rows)))
@@ -179,6 +279,20 @@ func ListFunctionsInFile(ctx context.Context, client Querier, args ListFunctions
 	return NewResult(sb.String()), nil
 }
 
+// formatLiveFunctionList lists the functions of a file read from disk.
+func formatLiveFunctionList(path string, fns []LiveFunction) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("**Functions in %s** (%d found, working tree):\n\n", path, len(fns)))
+	for _, fn := range fns {
+		sb.WriteString(fmt.Sprintf("• Line %d: **%s**\n", fn.StartLine, fn.Name))
+		if len(fn.Signature) < 80 {
+			sb.WriteString(fmt.Sprintf("  `%s`\n", fn.Signature))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
 // extractFileName extracts the file name from a path for fuzzy matching.
 func extractFileName(path string) string {
 	parts := strings.Split(path, "/")
@@ -197,7 +311,7 @@ type GetCallGraphArgs struct {
 func GetCallGraph(ctx context.Context, client Querier, args GetCallGraphArgs) (*ToolResult, error) {
 	funcName := strings.TrimSpace(args.FunctionName)
 	if funcName == "" {
-		return NewError("Error: function_name cannot be empty"), nil
+		return NewInputError("Error: function_name cannot be empty"), nil
 	}
 
 	// Get callers
@@ -231,7 +345,7 @@ type FindSimilarFunctionsArgs struct {
 func FindSimilarFunctions(ctx context.Context, client Querier, args FindSimilarFunctionsArgs) (*ToolResult, error) {
 	pattern := strings.TrimSpace(args.Pattern)
 	if pattern == "" {
-		return NewError("Error: pattern cannot be empty"), nil
+		return NewInputError("Error: pattern cannot be empty"), nil
 	}
 
 	script := fmt.Sprintf(`?[name, file_path, signature] := *cie_function { name, file_path, signature }, regex_matches(name, "(?i)%s") :limit 20`, EscapeRegex(pattern))
@@ -264,13 +378,14 @@ func FindSimilarFunctions(ctx context.Context, client Querier, args FindSimilarF
 // GetFileSummaryArgs holds arguments for getting a file summary.
 type GetFileSummaryArgs struct {
 	FilePath string
+	Outline  bool // declarations in line order with signatures and field names
 }
 
 // GetFileSummary gets a summary of all entities in a file (functions, types, methods).
 func GetFileSummary(ctx context.Context, client Querier, args GetFileSummaryArgs) (*ToolResult, error) {
 	filePath := strings.TrimSpace(args.FilePath)
 	if filePath == "" {
-		return NewError("Error: file_path cannot be empty"), nil
+		return NewInputError("Error: file_path cannot be empty"), nil
 	}
 
 	typeResult, funcResult, err := queryFileSummaryEntities(ctx, client, filePath)
@@ -282,12 +397,15 @@ func GetFileSummary(ctx context.Context, client Querier, args GetFileSummaryArgs
 		return NewResult(fmt.Sprintf("No entities found in '%s'.", filePath)), nil
 	}
 
+	if args.Outline {
+		return NewResult(formatFileOutline(ctx, client, filePath, typeResult.Rows, funcResult.Rows)), nil
+	}
 	return NewResult(formatFileSummary(filePath, typeResult.Rows, funcResult.Rows)), nil
 }
 
 func queryFileSummaryEntities(ctx context.Context, client Querier, filePath string) (*QueryResult, *QueryResult, error) {
 	escapedPath := EscapeRegex(filePath)
-	typeScript := fmt.Sprintf(`?[name, kind, start_line] := *cie_type { name, kind, file_path, start_line }, regex_matches(file_path, "(?i)%s") :order start_line :limit 100`, escapedPath)
+	typeScript := fmt.Sprintf(`?[name, kind, start_line, file_path] := *cie_type { name, kind, file_path, start_line }, regex_matches(file_path, "(?i)%s") :order start_line :limit 100`, escapedPath)
 	typeResult, _ := client.Query(ctx, typeScript)
 	if typeResult == nil {
 		typeResult = &QueryResult{}
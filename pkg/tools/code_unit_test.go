@@ -40,13 +40,13 @@ func TestGetFunctionCode_Unit(t *testing.T) {
 				FunctionName: "HandleRequest",
 			},
 			setupMock: func() Querier {
-				headers := []string{"name", "file_path", "signature", "code_text", "start_line", "end_line"}
+				headers := []string{"id", "name", "file_path", "signature", "code_text", "start_line", "end_line"}
 				rows := [][]any{
-					{"HandleRequest", "api/handler.go", "func HandleRequest()", "func HandleRequest() {\n\treturn nil\n}", int64(10), int64(12)},
+					{"fn:1", "HandleRequest", "api/handler.go", "func HandleRequest()", "func HandleRequest() {\n\treturn nil\n}", int64(10), int64(12)},
 				}
 				return NewMockClientWithResults(headers, rows)
 			},
-			wantContain: []string{"HandleRequest", "api/handler.go", "func HandleRequest"},
+			wantContain: []string{"fn:1", "HandleRequest", "api/handler.go", "func HandleRequest"},
 		},
 		{
 			name: "partial_match_fallback",
@@ -63,15 +63,15 @@ func TestGetFunctionCode_Unit(t *testing.T) {
 						if callCount == 1 {
 							// Exact match query returns nothing
 							return &QueryResult{
-								Headers: []string{"name", "file_path", "signature", "code_text", "start_line", "end_line"},
+								Headers: []string{"id", "name", "file_path", "signature", "code_text", "start_line", "end_line"},
 								Rows:    [][]any{},
 							}, nil
 						}
 						// Partial match query returns result
 						return &QueryResult{
-							Headers: []string{"name", "file_path", "signature", "code_text", "start_line", "end_line"},
+							Headers: []string{"id", "name", "file_path", "signature", "code_text", "start_line", "end_line"},
 							Rows: [][]any{
-								{"HandleRequest", "api/handler.go", "func HandleRequest()", "func HandleRequest() { return nil }", int64(10), int64(12)},
+								{"fn:1", "HandleRequest", "api/handler.go", "func HandleRequest()", "func HandleRequest() { return nil }", int64(10), int64(12)},
 							},
 						}, nil
 					},
@@ -93,9 +93,9 @@ func TestGetFunctionCode_Unit(t *testing.T) {
 					longCode += "\t// This is a very long comment that will push the code over 3000 characters\n"
 				}
 				longCode += "}"
-				headers := []string{"name", "file_path", "signature", "code_text", "start_line", "end_line"}
+				headers := []string{"id", "name", "file_path", "signature", "code_text", "start_line", "end_line"}
 				rows := [][]any{
-					{"VeryLongFunction", "utils/long.go", "func VeryLongFunction()", longCode, int64(1), int64(200)},
+					{"fn:1", "VeryLongFunction", "utils/long.go", "func VeryLongFunction()", longCode, int64(1), int64(200)},
 				}
 				return NewMockClientWithResults(headers, rows)
 			},
@@ -114,9 +114,9 @@ func TestGetFunctionCode_Unit(t *testing.T) {
 					longCode += "\t// This is a very long comment that will push the code over 3000 characters\n"
 				}
 				longCode += "}"
-				headers := []string{"name", "file_path", "signature", "code_text", "start_line", "end_line"}
+				headers := []string{"id", "name", "file_path", "signature", "code_text", "start_line", "end_line"}
 				rows := [][]any{
-					{"VeryLongFunction", "utils/long.go", "func VeryLongFunction()", longCode, int64(1), int64(200)},
+					{"fn:1", "VeryLongFunction", "utils/long.go", "func VeryLongFunction()", longCode, int64(1), int64(200)},
 				}
 				return NewMockClientWithResults(headers, rows)
 			},
@@ -180,6 +180,62 @@ func TestGetFunctionCode_Unit(t *testing.T) {
 	}
 }
 
+func TestGetFunctionCode_Nesting(t *testing.T) {
+	client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		switch {
+		case strings.Contains(script, "parent_kind: kind"):
+			return NewMockQueryResult([]string{"name", "kind"}, [][]any{{"Server", "type"}}), nil
+		case strings.Contains(script, "parent_id: self"):
+			return NewMockQueryResult([]string{"name", "start_line"}, [][]any{
+				{"Server.Start.closure#1", 12},
+				{"Server.Start.closure#2", 20},
+			}), nil
+		default:
+			return NewMockQueryResult(
+				[]string{"id", "name", "file_path", "signature", "code_text", "start_line", "end_line"},
+				[][]any{{"fn:1", "Server.Start", "api/server.go", "func (s *Server) Start()", "func (s *Server) Start() {}", 10, 30}},
+			), nil
+		}
+	}, nil)
+
+	result, err := GetFunctionCode(context.Background(), client, GetFunctionCodeArgs{FunctionName: "Server.Start"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"**Nested in**: Server (type)", "**Contains**: `Server.Start.closure#1`, `Server.Start.closure#2`"} {
+		if !strings.Contains(result.Text, want) {
+			t.Errorf("result should contain %q, got:\n%s", want, result.Text)
+		}
+	}
+}
+
+func TestGetFunctionCode_CutAtIndex(t *testing.T) {
+	headers := []string{"id", "name", "file_path", "signature", "code_text", "start_line", "end_line"}
+	cut := NewMockClientWithResults(headers, [][]any{
+		{"fn:1", "Build", "api/build.go", "func Build()", "func Build() {\n\ta()\n\tb()", int64(10), int64(40)},
+	})
+	result, err := GetFunctionCode(context.Background(), cut, GetFunctionCodeArgs{FunctionName: "Build"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Stored code ends at line 12 of 40", "`cie_get_lines`", `path: "api/build.go", start_line: 13, end_line: 40`} {
+		if !strings.Contains(result.Text, want) {
+			t.Errorf("result should contain %q, got:\n%s", want, result.Text)
+		}
+	}
+
+	whole := NewMockClientWithResults(headers, [][]any{
+		{"fn:1", "Build", "api/build.go", "func Build()", "func Build() {\n\ta()\n}\n", int64(10), int64(12)},
+	})
+	result, err = GetFunctionCode(context.Background(), whole, GetFunctionCodeArgs{FunctionName: "Build"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(result.Text, "Stored code ends") {
+		t.Errorf("complete code should not point at cie_get_lines:\n%s", result.Text)
+	}
+}
+
 func TestListFunctionsInFile_Unit(t *testing.T) {
 	tests := []struct {
 		name        string
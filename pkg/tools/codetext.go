@@ -0,0 +1,197 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// maxCompressedScanRows caps how many rows a client-side scan of compressed
+// file text or structural matches pulls in one query.
+const maxCompressedScanRows = 20000
+
+// compressedScanPageRows is how many function bodies scanDecodedCode pulls
+// from cie_function_code per query.
+const compressedScanPageRows = 2000
+
+// decodeCodeText converts a code_text cell to plain source, decompressing it
+// when the index was built with compression enabled.
+func decodeCodeText(v any) string {
+	stored := AnyToString(v)
+	code, err := storage.DecompressCodeText(stored)
+	if err != nil {
+		return ""
+	}
+	return code
+}
+
+// isCodeCompressed reports whether the index stores code_text compressed, in
+// which case regex_matches on code_text cannot be evaluated inside CozoDB.
+func isCodeCompressed(ctx context.Context, client Querier) bool {
+	return projectMeta(ctx, client, storage.CodeCompressionMetaKey) == "zstd"
+}
+
+// scanDecodedCode visits functions (filtered by path only) together with
+// their decompressed code_text until visit returns false. Rows are
+// [file_path, name, start_line, end_line, code_text, signature]. Functions
+// are read a page at a time in id order, so a scan covers the whole index
+// instead of stopping at a fixed row cap.
+func scanDecodedCode(ctx context.Context, client Querier, path, excludePattern, langCondition string, visit func(row []any) bool) error {
+	var conditions []string
+	if path != "" {
+		conditions = append(conditions, fmt.Sprintf("regex_matches(file_path, %s)", QuoteCozoPattern(EscapeRegex(path))))
+	}
+	if excludePattern != "" {
+		conditions = append(conditions, fmt.Sprintf("!regex_matches(file_path, %s)", QuoteCozoPattern(excludePattern)))
+	}
+	if langCondition != "" {
+		conditions = append(conditions, langCondition)
+	}
+	return scanDecodedCodeWhere(ctx, client, conditions, visit)
+}
+
+// scanDecodedCodeWhere is scanDecodedCode with arbitrary conditions on the
+// cie_function columns id, file_path, name, signature, start_line and
+// end_line. Conditions on code_text belong in visit.
+func scanDecodedCodeWhere(ctx context.Context, client Querier, conditions []string, visit func(row []any) bool) error {
+	filter := ""
+	if len(conditions) > 0 {
+		filter = ", " + strings.Join(conditions, ", ")
+	}
+
+	overflow := scanOverflow(ctx, client, filter)
+	cursor := ""
+	for {
+		result, err := client.Query(ctx, decodedCodePageScript(cursor, filter))
+		if err != nil {
+			return err
+		}
+		for _, row := range result.Rows {
+			if len(row) < 7 {
+				continue
+			}
+			code := decodeCodeText(row[6]) + overflow[codeKey(row[1], row[2], row[3])]
+			if !visit([]any{row[1], row[2], row[3], row[4], code, row[5]}) {
+				return nil
+			}
+		}
+		if len(result.Rows) < compressedScanPageRows {
+			return nil
+		}
+		cursor = fmt.Sprintf(", id > %q", AnyToString(result.Rows[len(result.Rows)-1][0]))
+	}
+}
+
+// decodedCodePageScript is the query for one page of scanDecodedCodeWhere:
+// functions after cursor (an "id >" condition, or "" for the first page)
+// that pass filter.
+func decodedCodePageScript(cursor, filter string) string {
+	return fmt.Sprintf(
+		"?[id, file_path, name, start_line, end_line, signature, code_text] := *cie_function { id, file_path, name, signature, start_line, end_line }%s, *cie_function_code { function_id: id, code_text }%s :order id :limit %d",
+		cursor, filter, compressedScanPageRows,
+	)
+}
+
+// decodedCodeScript describes a scan with conditions whose code is matched
+// against pattern after decompression, for tools that echo their query.
+func decodedCodeScript(conditions []string, pattern string) string {
+	filter := ""
+	if len(conditions) > 0 {
+		filter = ", " + strings.Join(conditions, ", ")
+	}
+	return fmt.Sprintf("%s\n# code_text is compressed: %s is matched after decompression, a page at a time", decodedCodePageScript("", filter), pattern)
+}
+
+// collectDecodedCode returns the rows of scanDecodedCodeWhere that keep
+// accepts, stopping after limit of them (0 for no limit).
+func collectDecodedCode(ctx context.Context, client Querier, conditions []string, limit int, keep func(row []any) bool) ([][]any, error) {
+	var rows [][]any
+	err := scanDecodedCodeWhere(ctx, client, conditions, func(row []any) bool {
+		if keep(row) {
+			rows = append(rows, row)
+		}
+		return limit <= 0 || len(rows) < limit
+	})
+	return rows, err
+}
+
+// scanOverflow reassembles the code past the stored code_text of the
+// functions a scan with filter reads, keyed by codeKey.
+func scanOverflow(ctx context.Context, client Querier, filter string) map[string]string {
+	script := fmt.Sprintf(
+		`?[key, chunk, code_text] := *cie_function { id, file_path, name, signature, start_line, end_line }, *cie_function_code_chunk { function_id: id, chunk, code_text }%s, key = concat(file_path, "|", name, "|", to_string(start_line)) :order key, chunk`,
+		filter,
+	)
+	result, err := client.Query(ctx, script)
+	if err != nil {
+		return nil
+	}
+	overflow := make(map[string]string)
+	for _, row := range result.Rows {
+		if len(row) == 3 {
+			overflow[AnyToString(row[0])] += decodeCodeText(row[2])
+		}
+	}
+	return overflow
+}
+
+// codeKey identifies a function by file, name and start line, the way
+// scanOverflow keys it.
+func codeKey(filePath, name, startLine any) string {
+	return fmt.Sprintf("%s|%s|%d", AnyToString(filePath), AnyToString(name), int(toFloat64(startLine)))
+}
+
+// functionOverflow returns the code past a function's stored code_text,
+// reassembled from cie_function_code_chunk. It returns "" when code_text is
+// complete or the index predates chunked storage.
+func functionOverflow(ctx context.Context, client Querier, name, filePath string, startLine any) string {
+	script := fmt.Sprintf(
+		`?[chunk, start_line, code_text] := *cie_function { id, name, file_path, start_line: fn_start }, name = %q, file_path = %q, fn_start = %d, *cie_function_code_chunk { function_id: id, chunk, start_line, code_text } :order chunk`,
+		name, filePath, int(toFloat64(startLine)),
+	)
+	result, err := client.Query(ctx, script)
+	if err != nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, row := range result.Rows {
+		if len(row) == 3 {
+			sb.WriteString(decodeCodeText(row[2]))
+		}
+	}
+	return sb.String()
+}
+
+// fullFunctionCode returns a function's whole code: its stored code_text
+// followed by the code past it.
+func fullFunctionCode(ctx context.Context, client Querier, name, filePath string, startLine any) string {
+	script := fmt.Sprintf(
+		`?[code_text] := *cie_function { id, name, file_path, start_line }, name = %q, file_path = %q, start_line = %d, *cie_function_code { function_id: id, code_text }`,
+		name, filePath, int(toFloat64(startLine)),
+	)
+	result, err := client.Query(ctx, script)
+	if err != nil || len(result.Rows) == 0 || len(result.Rows[0]) != 1 {
+		return ""
+	}
+	return decodeCodeText(result.Rows[0][0]) + functionOverflow(ctx, client, name, filePath, startLine)
+}
@@ -0,0 +1,408 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// newCompressedMockClient returns a mock that reports a zstd-compressed index
+// and serves the given rows (with plain code_text compressed on the fly).
+func newCompressedMockClient(t *testing.T, rows [][]any) *MockCIEClient {
+	t.Helper()
+	stored := make([][]any, 0, len(rows))
+	for _, row := range rows {
+		code, err := storage.CompressCodeText(row[len(row)-1].(string))
+		if err != nil {
+			t.Fatalf("compress: %v", err)
+		}
+		copied := append([]any{}, row[:len(row)-1]...)
+		stored = append(stored, append(copied, code))
+	}
+	return NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		if strings.Contains(script, "cie_project_meta") {
+			return NewMockQueryResult([]string{"value"}, [][]any{{"zstd"}}), nil
+		}
+		if strings.Contains(script, "regex_matches(code_text") {
+			t.Errorf("code_text regex must not run server-side on compressed index: %s", script)
+		}
+		out := make([][]any, len(stored))
+		for i, row := range stored {
+			out[i] = append([]any{}, row...)
+		}
+		return NewMockQueryResult(nil, out), nil
+	}, nil)
+}
+
+func TestDecodeCodeText(t *testing.T) {
+	compressed, err := storage.CompressCodeText("func A() {}")
+	if err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	if got := decodeCodeText(compressed); got != "func A() {}" {
+		t.Errorf("decodeCodeText(compressed) = %q", got)
+	}
+	if got := decodeCodeText("func B() {}"); got != "func B() {}" {
+		t.Errorf("decodeCodeText(plain) = %q", got)
+	}
+	if got := decodeCodeText(storage.CodeTextZstdPrefix + "!!"); got != "" {
+		t.Errorf("decodeCodeText(corrupt) = %q, want empty", got)
+	}
+}
+
+func TestIsCodeCompressed(t *testing.T) {
+	ctx := setupTest(t)
+	if isCodeCompressed(ctx, NewMockClientEmpty()) {
+		t.Error("empty meta should report uncompressed")
+	}
+	if !isCodeCompressed(ctx, NewMockClientWithResults([]string{"value"}, [][]any{{"zstd"}})) {
+		t.Error("zstd meta should report compressed")
+	}
+}
+
+func TestGetFunctionCode_Compressed(t *testing.T) {
+	ctx := setupTest(t)
+	code, err := storage.CompressCodeText("func HandleAuth() {\n\tcheck()\n}")
+	if err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	client := NewMockClientWithResults(
+		[]string{"id", "name", "file_path", "signature", "code_text", "start_line", "end_line"},
+		[][]any{{"fn:1", "HandleAuth", "auth.go", "func HandleAuth()", code, int64(1), int64(3)}},
+	)
+
+	result, err := GetFunctionCode(ctx, client, GetFunctionCodeArgs{FunctionName: "HandleAuth"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "check()")
+	assertNotContains(t, result.Text, storage.CodeTextZstdPrefix)
+}
+
+func TestGrep_Compressed(t *testing.T) {
+	ctx := setupTest(t)
+	client := newCompressedMockClient(t, [][]any{
+		{"fn:1", "auth.go", "HandleAuth", int64(10), int64(20), "func HandleAuth()", "func HandleAuth() {\n\tvalidateToken()\n}"},
+		{"fn:2", "user.go", "GetUser", int64(5), int64(9), "func GetUser()", "func GetUser() {\n\tloadUser()\n}"},
+	})
+
+	result, err := Grep(ctx, client, GrepArgs{Text: "validatetoken", ContextLines: 1, Limit: 10})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "Found 1 matches")
+	assertContains(t, result.Text, "HandleAuth")
+	assertContains(t, result.Text, "validateToken()")
+	assertNotContains(t, result.Text, "GetUser")
+
+	result, err = Grep(ctx, client, GrepArgs{Text: "nothing-here", Limit: 10})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "No matches found")
+}
+
+func TestGrepMulti_Compressed(t *testing.T) {
+	ctx := setupTest(t)
+	client := newCompressedMockClient(t, [][]any{
+		{"fn:1", "auth.go", "HandleAuth", int64(10), int64(20), "func HandleAuth()", "validateToken()"},
+		{"fn:2", "user.go", "GetUser", int64(5), int64(9), "func GetUser()", "loadUser()"},
+	})
+
+	result, err := Grep(ctx, client, GrepArgs{Texts: []string{"validateToken", "loadUser", "missing"}, Limit: 10})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "| `validateToken` | ✓ 1 |")
+	assertContains(t, result.Text, "| `loadUser` | ✓ 1 |")
+	assertContains(t, result.Text, "| `missing` | ✗ 0 |")
+}
+
+func TestScanDecodedCode_Pages(t *testing.T) {
+	ctx := setupTest(t)
+	var scripts []string
+	client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		if !strings.Contains(script, "*cie_function_code {") {
+			return NewMockQueryResult(nil, nil), nil
+		}
+		scripts = append(scripts, script)
+		n := compressedScanPageRows
+		if len(scripts) > 1 {
+			n = 1
+		}
+		rows := make([][]any, n)
+		for i := range rows {
+			rows[i] = []any{fmt.Sprintf("fn:%d:%05d", len(scripts), i), "a.go", "F", int64(1), int64(2), "func F()", "x"}
+		}
+		return NewMockQueryResult(nil, rows), nil
+	}, nil)
+
+	visited := 0
+	err := scanDecodedCode(ctx, client, "", "", "", func(row []any) bool {
+		visited++
+		return true
+	})
+	assertNoError(t, err)
+	if want := compressedScanPageRows + 1; visited != want {
+		t.Errorf("visited %d rows, want %d", visited, want)
+	}
+	if len(scripts) != 2 || !strings.Contains(scripts[1], fmt.Sprintf(`id > "fn:1:%05d"`, compressedScanPageRows-1)) {
+		t.Errorf("second page does not continue after the first: %v", scripts[1:])
+	}
+
+	visited, scripts = 0, nil
+	err = scanDecodedCode(ctx, client, "", "", "", func(row []any) bool {
+		visited++
+		return visited < 3
+	})
+	assertNoError(t, err)
+	if visited != 3 || len(scripts) != 1 {
+		t.Errorf("stopping visit read %d rows in %d queries, want 3 in 1", visited, len(scripts))
+	}
+}
+
+// overflowMock serves one function, Build at build.go:10-13, whose stored
+// code_text stops after its first line and whose last lines are in
+// cie_function_code_chunk.
+func overflowMock() Querier {
+	return NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		switch {
+		case strings.Contains(script, "?[file_path, name, start_line, end_line] := ") && strings.Contains(script, "*cie_function_code_chunk"):
+			if !strings.Contains(script, "flushAll") {
+				return NewMockQueryResult(nil, nil), nil
+			}
+			return NewMockQueryResult([]string{"file_path", "name", "start_line", "end_line"}, [][]any{{"build.go", "Build", int64(10), int64(13)}}), nil
+		case strings.Contains(script, "*cie_function_code_chunk"):
+			return NewMockQueryResult([]string{"chunk", "start_line", "code_text"}, [][]any{
+				{int64(0), int64(11), "\tprepare()\n"},
+				{int64(1), int64(12), "\tflushAll()\n}"},
+			}), nil
+		case strings.Contains(script, "?[code_text] := "):
+			return NewMockQueryResult([]string{"code_text"}, [][]any{{"func Build() {\n"}}), nil
+		case strings.Contains(script, "?[id, name, file_path, signature, code_text, start_line, end_line]"):
+			return NewMockQueryResult([]string{"id", "name", "file_path", "signature", "code_text", "start_line", "end_line"},
+				[][]any{{"fn:1", "Build", "build.go", "func Build()", "func Build() {\n", int64(10), int64(13)}}), nil
+		}
+		return NewMockQueryResult(nil, nil), nil
+	}, nil)
+}
+
+func TestGetFunctionCode_ReassemblesChunks(t *testing.T) {
+	result, err := GetFunctionCode(setupTest(t), overflowMock(), GetFunctionCodeArgs{FunctionName: "Build"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "func Build() {\n\tprepare()\n\tflushAll()\n}")
+	assertNotContains(t, result.Text, "Stored code ends")
+}
+
+func TestGrep_MatchesInChunks(t *testing.T) {
+	result, err := Grep(setupTest(t), overflowMock(), GrepArgs{Text: "flushAll", ContextLines: 1, Limit: 10})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "Found 1 matches")
+	assertContains(t, result.Text, "**Build** in `build.go:10`")
+	assertContains(t, result.Text, ">  12: \tflushAll()")
+}
+
+// compressedIndexClient is a compressed index holding funcs, rows of
+// [id, file_path, name, start_line, end_line, signature, code_text]. It
+// serves them to code scans, include_code lookups and vector searches with
+// code_text compressed, records every script it is sent and fails the test
+// when one would run a regex on code_text inside CozoDB.
+func compressedIndexClient(t *testing.T, funcs [][]any) (*MockCIEClient, *[]string) {
+	t.Helper()
+	stored := make([][]any, len(funcs))
+	for i, f := range funcs {
+		code, err := storage.CompressCodeText(f[6].(string))
+		if err != nil {
+			t.Fatalf("compress: %v", err)
+		}
+		stored[i] = append(append([]any{}, f[:6]...), code)
+	}
+	var scripts []string
+	client := NewMockClientCustom(func(_ context.Context, script string) (*QueryResult, error) {
+		scripts = append(scripts, script)
+		if strings.Contains(script, "regex_matches(code_text") {
+			t.Errorf("code_text regex must not run server-side on compressed index: %s", script)
+		}
+		var headers []string
+		var rows [][]any
+		for i, f := range stored {
+			switch {
+			case strings.Contains(script, storage.CodeCompressionMetaKey):
+				return NewMockQueryResult([]string{"value"}, [][]any{{"zstd"}}), nil
+			case strings.Contains(script, "~cie_function_embedding"):
+				rows = append(rows, []any{f[2], f[1], f[5], f[3], 0.1 * float64(i+1), f[6], f[0]})
+			case strings.Contains(script, ":order id"):
+				rows = append(rows, append([]any{}, f...))
+			case strings.HasPrefix(script, "?[file_path, name, signature, start_line, end_line, code_text]"):
+				headers = []string{"file_path", "name", "signature", "start_line", "end_line", "code_text"}
+				rows = append(rows, []any{f[1], f[2], f[5], f[3], f[4], f[6]})
+			case strings.HasPrefix(script, "?[id, name, file_path, start_line, signature]") && strings.Contains(script, QuoteCozoPattern(f[0].(string))):
+				rows = append(rows, []any{f[0], f[2], f[1], f[3], f[5]})
+			}
+		}
+		return NewMockQueryResult(headers, rows), nil
+	}, nil)
+	return client, &scripts
+}
+
+// compressedRoutes is a compressed index fixture: a gin route setup and a
+// plain helper.
+var compressedRoutes = [][]any{
+	{"fn:1", "api/routes.go", "Setup", int64(10), int64(14), "func Setup(r *gin.Engine)",
+		"func Setup(r *gin.Engine) {\n\tr.GET(\"/users\", listUsers)\n\tr.POST(\"/users\", createUser)\n}"},
+	{"fn:2", "api/users.go", "loadUser", int64(20), int64(22), "func loadUser(id string) *User",
+		"func loadUser(id string) *User {\n\treturn db.Find(id)\n}"},
+}
+
+// scanScripts returns the code scans among scripts.
+func scanScripts(scripts []string) []string {
+	var scans []string
+	for _, s := range scripts {
+		if strings.Contains(s, ":order id") {
+			scans = append(scans, s)
+		}
+	}
+	return scans
+}
+
+func TestSearchText_Compressed(t *testing.T) {
+	ctx := setupTest(t)
+
+	client, scripts := compressedIndexClient(t, compressedRoutes)
+	result, err := SearchText(ctx, client, SearchTextArgs{Pattern: "db.Find(", SearchIn: "code", Literal: true, FilePattern: "api/"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "loadUser")
+	assertNotContains(t, result.Text, "Setup")
+	scans := scanScripts(*scripts)
+	if len(scans) == 0 || !strings.Contains(scans[0], `regex_matches(file_path, "api/")`) {
+		t.Errorf("scan does not keep the file filter: %v", scans)
+	}
+
+	client, _ = compressedIndexClient(t, compressedRoutes)
+	result, err = SearchText(ctx, client, SearchTextArgs{Pattern: "createUser|loadUser", SearchIn: "all"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "Setup")
+	assertContains(t, result.Text, "loadUser")
+}
+
+func TestListEndpoints_Compressed(t *testing.T) {
+	ctx := setupTest(t)
+	client, scripts := compressedIndexClient(t, compressedRoutes)
+
+	result, err := ListEndpoints(ctx, client, ListEndpointsArgs{PathPattern: "api"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "/users")
+	assertContains(t, result.Text, "GET")
+	assertContains(t, result.Text, "POST")
+	assertNotContains(t, result.Text, "loadUser")
+	scans := scanScripts(*scripts)
+	if len(scans) == 0 || !strings.Contains(scans[0], "_test[.]go") || !strings.Contains(scans[0], "api") {
+		t.Errorf("scan does not keep the path and test-file filters: %v", scans)
+	}
+}
+
+func TestAnalyze_Compressed(t *testing.T) {
+	ctx := setupTest(t)
+	client, scripts := compressedIndexClient(t, compressedRoutes)
+
+	result, err := Analyze(ctx, client, AnalyzeArgs{Question: "which api route creates users"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "## Functions Matching Keywords (code)\n")
+	assertContains(t, result.Text, "## Functions with Route Definitions\n")
+	routes := result.Text[strings.Index(result.Text, "## Functions with Route Definitions"):]
+	assertContains(t, routes, "Setup")
+	assertNotContains(t, routes, "loadUser")
+	scans := scanScripts(*scripts)
+	if len(scans) == 0 || !strings.Contains(scans[len(scans)-1], "negate(regex_matches(file_path") {
+		t.Errorf("route scan does not exclude tests: %v", scans)
+	}
+}
+
+func TestRoleFiltersForIndex_Compressed(t *testing.T) {
+	ctx := setupTest(t)
+	client, _ := compressedIndexClient(t, compressedRoutes)
+
+	conditions, matchCode, err := RoleFiltersForIndex(ctx, client, "router", nil)
+	assertNoError(t, err)
+	for _, c := range conditions {
+		assertNotContains(t, c, "code_text")
+	}
+	if matchCode == nil || !matchCode("Setup", `r.GET("/users", listUsers)`) || matchCode("loadUser", "return db.Find(id)") {
+		t.Error("router code matcher does not match route registrations only")
+	}
+
+	custom := map[string]RolePattern{"dao": {FilePattern: "api/", CodePattern: `db[.]Find`}}
+	conditions, matchCode, err = RoleFiltersForIndex(ctx, client, "dao", custom)
+	assertNoError(t, err)
+	for _, c := range conditions {
+		assertNotContains(t, c, "code_text")
+	}
+	if matchCode == nil || !matchCode("loadUser", "return db.Find(id)") {
+		t.Error("custom code_pattern is not matched client-side")
+	}
+
+	conditions, matchCode, err = RoleFiltersForIndex(ctx, NewMockClientEmpty(), "router", nil)
+	assertNoError(t, err)
+	if matchCode != nil || !strings.Contains(strings.Join(conditions, " "), "regex_matches(code_text") {
+		t.Error("uncompressed index should keep code conditions server-side")
+	}
+}
+
+func TestSemanticSearch_Compressed(t *testing.T) {
+	ctx := setupTest(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"embedding": []float64{0.1, 0.2, 0.3}})
+	}))
+	defer server.Close()
+	client, _ := compressedIndexClient(t, compressedRoutes)
+
+	result, err := SemanticSearch(ctx, client, SemanticSearchArgs{
+		Query:          "register user routes",
+		Role:           "routes",
+		CustomRoles:    map[string]RolePattern{"routes": {CodePattern: `[.](GET|POST)[(]`}},
+		EmbeddingURL:   server.URL,
+		EmbeddingModel: "nomic-embed-text",
+	})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "Setup")
+	assertContains(t, result.Text, `r.GET("/users", listUsers)`)
+	assertNotContains(t, result.Text, "loadUser")
+	assertNotContains(t, result.Text, storage.CodeTextZstdPrefix)
+}
+
+func TestSimilarToFunction_Compressed(t *testing.T) {
+	ctx := setupTest(t)
+	client, _ := compressedIndexClient(t, compressedRoutes)
+
+	result, err := SimilarToFunction(ctx, client, SimilarToFunctionArgs{FunctionID: "fn:2"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "Functions similar to loadUser")
+	assertContains(t, result.Text, "Setup")
+	assertNotContains(t, result.Text, storage.CodeTextZstdPrefix)
+}
+
+func TestFindFunction_IncludeCodeCompressed(t *testing.T) {
+	ctx := setupTest(t)
+	client, _ := compressedIndexClient(t, compressedRoutes)
+
+	result, err := FindFunction(ctx, client, FindFunctionArgs{Name: "loadUser", IncludeCode: true})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "return db.Find(id)")
+	assertNotContains(t, result.Text, storage.CodeTextZstdPrefix)
+}
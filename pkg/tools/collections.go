@@ -0,0 +1,247 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxCollectionFiles caps the files a collection can scope a search to,
+// keeping the generated path pattern a reasonable size.
+const maxCollectionFiles = 500
+
+// collectionNamePattern restricts collection names to something that reads
+// well in tool arguments and output.
+var collectionNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// CollectionArgs holds arguments for adding or removing collection members.
+type CollectionArgs struct {
+	Collection string   // e.g. "payment-critical-path"
+	Targets    []string // function names or file paths
+	FilePath   string   // optional: narrows function names matched in several files
+}
+
+// ListCollectionsArgs holds arguments for listing collections.
+type ListCollectionsArgs struct {
+	Collection string // optional: list this collection's members
+}
+
+// CollectionMember is a function or file in a collection.
+type CollectionMember struct {
+	EntityRef
+	ID, Collection, Added string
+}
+
+// collectionMemberID derives a stable ID, so adding a member twice stores it once.
+func collectionMemberID(collection string, ref EntityRef) string {
+	h := sha256.New()
+	h.Write([]byte(collection + "|" + ref.Kind + "|" + ref.Name + "|" + ref.FilePath))
+	return "col:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// AddToCollection adds functions or files to a named collection, creating
+// it on first use. Collections are kept when the project is re-indexed.
+func AddToCollection(ctx context.Context, client Querier, args CollectionArgs) (*ToolResult, error) {
+	return updateCollection(ctx, client, args, false)
+}
+
+// RemoveFromCollection removes functions or files from a collection.
+func RemoveFromCollection(ctx context.Context, client Querier, args CollectionArgs) (*ToolResult, error) {
+	return updateCollection(ctx, client, args, true)
+}
+
+func updateCollection(ctx context.Context, client Querier, args CollectionArgs, remove bool) (*ToolResult, error) {
+	if !collectionNamePattern.MatchString(args.Collection) {
+		return NewInputError("Error: 'collection' must be a name of letters, digits, '.', '_' or '-' (e.g., 'payment-critical-path')"), nil
+	}
+	var targets []string
+	for _, t := range args.Targets {
+		if t = strings.TrimSpace(t); t != "" {
+			targets = append(targets, t)
+		}
+	}
+	if len(targets) == 0 {
+		return NewInputError("Error: 'targets' is required"), nil
+	}
+	exec, ok := client.(Executor)
+	if !ok {
+		return NewError("Collections can only be changed with a local index (this connection is read-only)"), nil
+	}
+
+	var refs []EntityRef
+	var problems []string
+	for _, target := range targets {
+		ref, candidates, err := resolveTarget(ctx, client, target, args.FilePath)
+		switch {
+		case err != nil:
+			return NewError(fmt.Sprintf("Query error: %v", err)), nil
+		case len(candidates) > 1:
+			problems = append(problems, fmt.Sprintf("'%s' matches %d functions; pass `file_path` or a qualified name", target, len(candidates)))
+		case ref == nil:
+			problems = append(problems, unknownTargetMessage(target))
+		default:
+			refs = append(refs, *ref)
+		}
+	}
+
+	var sb strings.Builder
+	if len(refs) > 0 {
+		var script string
+		if remove {
+			var rows []string
+			for _, ref := range refs {
+				rows = append(rows, fmt.Sprintf("[%q]", collectionMemberID(args.Collection, ref)))
+			}
+			script = fmt.Sprintf("?[id] <- [%s] :rm cie_collection { id }", strings.Join(rows, ", "))
+		} else {
+			added := time.Now().UTC().Format(time.RFC3339)
+			var rows []string
+			for _, ref := range refs {
+				rows = append(rows, fmt.Sprintf("[%q, %q, %q, %q, %q, %q]", collectionMemberID(args.Collection, ref), args.Collection, ref.Kind, ref.Name, ref.FilePath, added))
+			}
+			script = fmt.Sprintf("?[id, collection, kind, name, file_path, added] <- [%s] :put cie_collection { id => collection, kind, name, file_path, added }", strings.Join(rows, ", "))
+		}
+		if _, err := exec.Execute(ctx, script); err != nil {
+			return NewError(fmt.Sprintf("Cannot update collection: %v (indexes built before cie_collection need 'cie index')", err)), nil
+		}
+		verb := "Added to"
+		if remove {
+			verb = "Removed from"
+		}
+		fmt.Fprintf(&sb, "%s collection '%s':\n", verb, args.Collection)
+		for _, ref := range refs {
+			fmt.Fprintf(&sb, "- %s\n", ref.label())
+		}
+	}
+	if len(problems) > 0 {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("Skipped:\n")
+		for _, p := range problems {
+			fmt.Fprintf(&sb, "- %s\n", p)
+		}
+		if len(refs) == 0 {
+			return NewInputError(sb.String()), nil
+		}
+	}
+	return NewResult(sb.String()), nil
+}
+
+// loadCollection reads a collection's members, or every member when
+// collection is empty.
+func loadCollection(ctx context.Context, client Querier, collection string) ([]CollectionMember, error) {
+	script := "?[id, collection, kind, name, file_path, added] := *cie_collection { id, collection, kind, name, file_path, added }"
+	if collection != "" {
+		script += fmt.Sprintf(", collection = %q", collection)
+	}
+	result, err := client.Query(ctx, script+" :order collection, file_path, name")
+	if err != nil {
+		return nil, err
+	}
+	members := make([]CollectionMember, 0, len(result.Rows))
+	for _, r := range result.Rows {
+		if len(r) >= 6 {
+			members = append(members, CollectionMember{
+				EntityRef:  EntityRef{Kind: AnyToString(r[2]), Name: AnyToString(r[3]), FilePath: AnyToString(r[4])},
+				ID:         AnyToString(r[0]),
+				Collection: AnyToString(r[1]),
+				Added:      AnyToString(r[5]),
+			})
+		}
+	}
+	return members, nil
+}
+
+// ListCollections lists the collections with their sizes, or the members of
+// one collection.
+func ListCollections(ctx context.Context, client Querier, args ListCollectionsArgs) (*ToolResult, error) {
+	members, err := loadCollection(ctx, client, args.Collection)
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v (indexes built before cie_collection need 'cie index')", err)), nil
+	}
+
+	var sb strings.Builder
+	if args.Collection != "" {
+		if len(members) == 0 {
+			return NewResult(fmt.Sprintf("Collection '%s' is empty or does not exist. Use `cie_collection_list` to see all collections.", args.Collection)), nil
+		}
+		fmt.Fprintf(&sb, "### Collection '%s' (%d)\n\n", args.Collection, len(members))
+		for _, m := range members {
+			fmt.Fprintf(&sb, "- %s\n", m.label())
+		}
+		return NewResult(sb.String()), nil
+	}
+
+	if len(members) == 0 {
+		return NewResult("No collections yet. Use `cie_collection_add` to create one."), nil
+	}
+	functions := map[string]int{}
+	files := map[string]int{}
+	var names []string
+	for _, m := range members {
+		if functions[m.Collection]+files[m.Collection] == 0 {
+			names = append(names, m.Collection)
+		}
+		if m.Kind == EntityKindFunction {
+			functions[m.Collection]++
+		} else {
+			files[m.Collection]++
+		}
+	}
+	sort.Strings(names)
+	fmt.Fprintf(&sb, "### Collections (%d)\n\n| Collection | Functions | Files |\n|------------|-----------|-------|\n", len(names))
+	for _, name := range names {
+		fmt.Fprintf(&sb, "| %s | %d | %d |\n", name, functions[name], files[name])
+	}
+	sb.WriteString("\nPass `collection` to a search or analysis tool to scope it to a collection's files.\n")
+	return NewResult(sb.String()), nil
+}
+
+// CollectionPathPattern returns a path_pattern regex matching the files of a
+// collection: its file members and the files of its function members. It
+// lets any tool that filters by path be scoped to a collection.
+func CollectionPathPattern(ctx context.Context, client Querier, collection string) (string, error) {
+	members, err := loadCollection(ctx, client, collection)
+	if err != nil {
+		return "", fmt.Errorf("read collection %q: %w", collection, err)
+	}
+	seen := map[string]bool{}
+	var alternatives []string
+	for _, m := range members {
+		if !seen[m.FilePath] {
+			seen[m.FilePath] = true
+			alternatives = append(alternatives, EscapeRegex(m.FilePath))
+		}
+	}
+	switch {
+	case len(alternatives) == 0:
+		return "", fmt.Errorf("collection %q is empty or does not exist", collection)
+	case len(alternatives) > maxCollectionFiles:
+		return "", fmt.Errorf("collection %q spans %d files; scoping supports at most %d", collection, len(alternatives), maxCollectionFiles)
+	}
+	return "^(" + strings.Join(alternatives, "|") + ")$", nil
+}
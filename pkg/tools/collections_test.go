@@ -0,0 +1,144 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"regexp"
+	"testing"
+)
+
+var testCollection = [][]any{
+	{"col:1", "payments", "function", "ChargeCard", "internal/billing/charge.go", "2026-01-01T10:00:00Z"},
+	{"col:2", "payments", "function", "Ledger.Post", "internal/billing/ledger.go", "2026-01-01T10:00:00Z"},
+	{"col:3", "payments", "file", "", "internal/billing/charge.go", "2026-01-01T10:00:00Z"},
+	{"col:4", "auth", "file", "", "internal/auth/login.go", "2026-01-01T10:00:00Z"},
+}
+
+// collectionMock answers the target and member queries of the collection
+// tools, filtering testCollection by the requested collection.
+func collectionMock(t *testing.T) *mockExecClient {
+	membersWant := []string{"*cie_collection { id, collection, kind, name, file_path, added }", ":order collection, file_path, name"}
+	queries := []MockQuery{
+		{
+			Match: []string{"?[path] := *cie_file", `path = "internal/auth/login.go"`},
+			Rows:  [][]any{{"internal/auth/login.go"}},
+		},
+		{
+			Match: []string{"?[path] := *cie_file"},
+		},
+		{
+			Match: []string{"?[name, file_path] := *cie_function", `(name = "ChargeCard" or ends_with(name, ".ChargeCard"))`},
+			Want:  []string{"*cie_function { name, file_path }"},
+			Rows:  [][]any{{"ChargeCard", "internal/billing/charge.go"}},
+		},
+		{
+			Match: []string{"?[name, file_path] := *cie_function"},
+		},
+	}
+	for _, name := range []string{"payments", "auth"} {
+		var rows [][]any
+		for _, r := range testCollection {
+			if r[1] == name {
+				rows = append(rows, r)
+			}
+		}
+		queries = append(queries, MockQuery{
+			Match: []string{"*cie_collection", `collection = "` + name + `"`},
+			Want:  membersWant,
+			Rows:  rows,
+		})
+	}
+	queries = append(queries,
+		MockQuery{
+			Match: []string{"*cie_collection", "collection = "},
+			Want:  membersWant,
+		},
+		MockQuery{
+			Match: []string{"*cie_collection"},
+			Want:  membersWant,
+			Rows:  testCollection,
+		},
+	)
+	return &mockExecClient{MockCIEClient: *NewMockClientScripted(t, queries...)}
+}
+
+func TestAddToCollection(t *testing.T) {
+	ctx := context.Background()
+
+	client := collectionMock(t)
+	result, err := AddToCollection(ctx, client, CollectionArgs{Collection: "payments", Targets: []string{"ChargeCard", "internal/auth/login.go", "Missing"}})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "Added to collection 'payments':")
+	assertContains(t, result.Text, "- `ChargeCard` (internal/billing/charge.go)")
+	assertContains(t, result.Text, "No function or file named 'Missing'")
+	assertContains(t, client.executed, ":put cie_collection")
+	assertContains(t, client.executed, `"payments", "file", "", "internal/auth/login.go"`)
+
+	client = collectionMock(t)
+	result, err = RemoveFromCollection(ctx, client, CollectionArgs{Collection: "payments", Targets: []string{"ChargeCard"}})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "Removed from collection 'payments'")
+	assertContains(t, client.executed, ":rm cie_collection { id }")
+	assertContains(t, client.executed, collectionMemberID("payments", EntityRef{Kind: EntityKindFunction, Name: "ChargeCard", FilePath: "internal/billing/charge.go"}))
+
+	result, err = AddToCollection(ctx, collectionMock(t), CollectionArgs{Collection: "bad name", Targets: []string{"ChargeCard"}})
+	assertNoError(t, err)
+	if !result.IsError {
+		t.Error("names with spaces should be rejected")
+	}
+}
+
+func TestListCollections(t *testing.T) {
+	ctx := context.Background()
+
+	result, err := ListCollections(ctx, collectionMock(t), ListCollectionsArgs{})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "### Collections (2)")
+	assertContains(t, result.Text, "| auth | 0 | 1 |")
+	assertContains(t, result.Text, "| payments | 2 | 1 |")
+
+	result, err = ListCollections(ctx, collectionMock(t), ListCollectionsArgs{Collection: "payments"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "### Collection 'payments' (3)")
+	assertContains(t, result.Text, "- `Ledger.Post` (internal/billing/ledger.go)")
+}
+
+func TestCollectionPathPattern(t *testing.T) {
+	ctx := context.Background()
+
+	pattern, err := CollectionPathPattern(ctx, collectionMock(t), "payments")
+	assertNoError(t, err)
+	re := regexp.MustCompile(pattern)
+	for path, want := range map[string]bool{
+		"internal/billing/charge.go":     true,
+		"internal/billing/ledger.go":     true,
+		"internal/billing/ledger.go.bak": false,
+		"internal/auth/login.go":         false,
+	} {
+		if got := re.MatchString(path); got != want {
+			t.Errorf("%s: match = %v, want %v (pattern %s)", path, got, want, pattern)
+		}
+	}
+
+	if _, err := CollectionPathPattern(ctx, collectionMock(t), "unknown"); err == nil {
+		t.Error("an unknown collection should be an error")
+	}
+}
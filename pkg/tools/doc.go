@@ -60,11 +60,13 @@
 //   - FindCallers: Find functions that call a given function
 //   - FindCallees: Find functions called by a given function
 //   - GetFunctionCode: Get the full source code of a function
-//   - GetTypeCode: Get the source code of a type/class/interface
+//   - GetTypeCode: Get the source code of a type/class/interface, optionally with its methods
+//   - TypeAPI: List a type's methods grouped by file
 //   - FindType: Find type definitions by name
 //   - FindImplementations: Find types implementing an interface
 //   - ListFunctionsInFile: List all functions defined in a file
 //   - FindSimilarFunctions: Find functions with similar names
+//   - SimilarToFunction: Nearest neighbors of a function by its stored embedding
 //
 // Analysis Tools:
 //   - Analyze: Answer architectural questions using semantic search
@@ -79,7 +81,7 @@
 //   - ListFiles: List indexed files with filtering options
 //
 // Utility Tools:
-//   - GetSchema: Get CIE database schema information
+//   - GetSchema: Get CIE database schema documentation, for all relations or one
 //   - IndexStatus: Check indexing status and health
 //   - VerifyAbsence: Verify patterns don't exist (security audits)
 //   - RawQuery: Execute raw CozoScript queries
@@ -0,0 +1,204 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnclosingArgs holds arguments for finding the entities that contain a line.
+type EnclosingArgs struct {
+	FilePath    string // repository-relative path or a unique suffix of it
+	Line        int
+	Location    string // alternative to FilePath+Line: "path/to/file.go:42"
+	IncludeCode bool
+}
+
+// locationPattern matches "file:line" with an optional ":col" suffix.
+var locationPattern = regexp.MustCompile(`^(.+?):(\d+)(?::\d+)?$`)
+
+// ParseLocation splits "path/to/file.go:42" (optionally with a trailing
+// ":col") into path and line.
+func ParseLocation(loc string) (string, int, bool) {
+	m := locationPattern.FindStringSubmatch(strings.TrimSpace(loc))
+	if m == nil {
+		return "", 0, false
+	}
+	line, err := strconv.Atoi(m[2])
+	if err != nil || line <= 0 {
+		return "", 0, false
+	}
+	return m[1], line, true
+}
+
+// Enclosing returns the innermost function and type that contain a source
+// line, plus the package (directory) they belong to. It turns stack-trace
+// frames and diff hunk headers into indexed entities.
+func Enclosing(ctx context.Context, client Querier, args EnclosingArgs) (*ToolResult, error) {
+	if args.Location != "" && args.FilePath == "" {
+		file, line, ok := ParseLocation(args.Location)
+		if !ok {
+			return NewInputError(fmt.Sprintf("Error: cannot parse location %q (expected path/to/file.go:42)", args.Location)), nil
+		}
+		args.FilePath, args.Line = file, line
+	}
+	filePath := strings.TrimPrefix(strings.TrimSpace(args.FilePath), "./")
+	if filePath == "" {
+		return NewInputError("Error: 'file_path' (or 'location') is required"), nil
+	}
+	if args.Line <= 0 {
+		return NewInputError("Error: 'line' must be a positive line number"), nil
+	}
+
+	pathCond := fmt.Sprintf("(file_path = %q or ends_with(file_path, %q))", filePath, "/"+filePath)
+
+	funcScript := fmt.Sprintf(`?[name, signature, file_path, start_line, end_line] := *cie_function { name, signature, file_path, start_line, end_line }, %s, start_line <= %d, end_line >= %d`,
+		pathCond, args.Line, args.Line)
+	funcs, err := client.Query(ctx, funcScript)
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v", err)), nil
+	}
+
+	typeScript := fmt.Sprintf(`?[name, kind, file_path, start_line, end_line] := *cie_type { name, kind, file_path, start_line, end_line }, %s, start_line <= %d, end_line >= %d`,
+		pathCond, args.Line, args.Line)
+	types, err := client.Query(ctx, typeScript)
+	if err != nil {
+		types = &QueryResult{} // cie_type is absent in old indexes
+	}
+
+	files := make(map[string]bool)
+	for _, r := range append(append([][]any{}, funcs.Rows...), types.Rows...) {
+		files[AnyToString(r[2])] = true
+	}
+	if len(files) > 1 {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "'%s' matches %d indexed files. Pass a longer path:\n\n", filePath, len(files))
+		for _, f := range sortedKeys(files) {
+			fmt.Fprintf(&sb, "- %s\n", f)
+		}
+		return NewResult(sb.String()), nil
+	}
+
+	funcRows := innermostFirst(funcs.Rows)
+	typeRows := innermostFirst(types.Rows)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## %s:%d\n\n", filePath, args.Line)
+	resolved := filePath
+	for f := range files {
+		resolved = f
+	}
+	fmt.Fprintf(&sb, "**Package**: %s (%s)\n", packageDir(resolved), detectLanguage(resolved))
+	if resolved != filePath {
+		fmt.Fprintf(&sb, "**File**: %s\n", resolved)
+	}
+
+	if len(funcRows) == 0 && len(typeRows) == 0 {
+		sb.WriteString("\nNo indexed function or type contains this line (package-level code, imports, or the file is not indexed).\n")
+		return NewResult(sb.String()), nil
+	}
+
+	if len(funcRows) > 0 {
+		inner := funcRows[0]
+		fmt.Fprintf(&sb, "\n### Function: %s (lines %s-%s)\n", AnyToString(inner[0]), AnyToString(inner[3]), AnyToString(inner[4]))
+		fmt.Fprintf(&sb, "**Signature**: `%s`\n", AnyToString(inner[1]))
+		if len(funcRows) > 1 {
+			outer := make([]string, 0, len(funcRows)-1)
+			for _, r := range funcRows[1:] {
+				outer = append(outer, AnyToString(r[0]))
+			}
+			fmt.Fprintf(&sb, "**Nested in**: %s\n", strings.Join(outer, " → "))
+		}
+		if args.IncludeCode {
+			code := enclosingCode(ctx, client, "cie_function", "cie_function_code", "function_id", inner)
+			writeEnclosingCode(&sb, resolved, code)
+		}
+	}
+
+	if len(typeRows) > 0 {
+		inner := typeRows[0]
+		fmt.Fprintf(&sb, "\n### Type: %s (%s, lines %s-%s)\n", AnyToString(inner[0]), AnyToString(inner[1]), AnyToString(inner[3]), AnyToString(inner[4]))
+		// A method's body is not inside its type declaration in Go, but a class
+		// body contains its methods; only show type code if no function matched.
+		if args.IncludeCode && len(funcRows) == 0 {
+			code := enclosingCode(ctx, client, "cie_type", "cie_type_code", "type_id", inner)
+			writeEnclosingCode(&sb, resolved, code)
+		}
+	}
+
+	return NewResult(sb.String()), nil
+}
+
+// innermostFirst orders entities containing a line by span, smallest first.
+// Rows are [name, _, file_path, start_line, end_line].
+func innermostFirst(rows [][]any) [][]any {
+	out := append([][]any{}, rows...)
+	span := func(r []any) float64 { return toFloat64(r[4]) - toFloat64(r[3]) }
+	sort.SliceStable(out, func(i, j int) bool { return span(out[i]) < span(out[j]) })
+	return out
+}
+
+// enclosingCode fetches the code of the entity identified by row.
+func enclosingCode(ctx context.Context, client Querier, rel, codeRel, idField string, row []any) string {
+	script := fmt.Sprintf(`?[code_text] := *%s { id, name, file_path, start_line }, *%s { %s: id, code_text }, name = %q, file_path = %q, start_line = %s :limit 1`,
+		rel, codeRel, idField, AnyToString(row[0]), AnyToString(row[2]), AnyToString(row[3]))
+	result, err := client.Query(ctx, script)
+	if err != nil || len(result.Rows) == 0 {
+		return ""
+	}
+	return decodeCodeText(result.Rows[0][0])
+}
+
+func writeEnclosingCode(sb *strings.Builder, filePath, code string) {
+	if code == "" {
+		return
+	}
+	const maxCodeLen = 3000
+	truncated := len(code) > maxCodeLen
+	if truncated {
+		code = code[:maxCodeLen]
+	}
+	fmt.Fprintf(sb, "\n```%s\n%s\n```\n", detectLanguage(filePath), code)
+	if truncated {
+		sb.WriteString("⚠️ Code truncated. Use `cie_get_function_code` with `full_code: true` for the rest.\n")
+	}
+}
+
+func packageDir(filePath string) string {
+	dir := path.Dir(filePath)
+	if dir == "." {
+		return "(root)"
+	}
+	return dir
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
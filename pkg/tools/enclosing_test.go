@@ -0,0 +1,110 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		in       string
+		wantFile string
+		wantLine int
+		ok       bool
+	}{
+		{"pkg/tools/grep.go:42", "pkg/tools/grep.go", 42, true},
+		{"pkg/tools/grep.go:42:7", "pkg/tools/grep.go", 42, true},
+		{`C:\src\main.go:10`, `C:\src\main.go`, 10, true},
+		{"pkg/tools/grep.go", "", 0, false},
+		{"grep.go:0", "", 0, false},
+	}
+	for _, tt := range tests {
+		file, line, ok := ParseLocation(tt.in)
+		if file != tt.wantFile || line != tt.wantLine || ok != tt.ok {
+			t.Errorf("ParseLocation(%q) = %q, %d, %v", tt.in, file, line, ok)
+		}
+	}
+}
+
+func enclosingMock(funcRows, typeRows [][]any) *MockCIEClient {
+	return NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		switch {
+		case strings.Contains(script, "?[code_text]") && strings.Contains(script, "cie_function_code"):
+			return NewMockQueryResult([]string{"code_text"}, [][]any{{"func inner() {\n\treturn\n}"}}), nil
+		case strings.Contains(script, "?[code_text]"):
+			return NewMockQueryResult([]string{"code_text"}, [][]any{{"type Server struct {}"}}), nil
+		case strings.Contains(script, "*cie_function {"):
+			return NewMockQueryResult([]string{"name", "signature", "file_path", "start_line", "end_line"}, funcRows), nil
+		case strings.Contains(script, "*cie_type {"):
+			return NewMockQueryResult([]string{"name", "kind", "file_path", "start_line", "end_line"}, typeRows), nil
+		}
+		return NewMockQueryResult(nil, nil), nil
+	}, nil)
+}
+
+func TestEnclosing_InnermostFunction(t *testing.T) {
+	client := enclosingMock([][]any{
+		{"Server.Run", "func (s *Server) Run()", "internal/http/server.go", float64(10), float64(60)},
+		{"Server.Run.$func1", "func()", "internal/http/server.go", float64(20), float64(30)},
+	}, nil)
+	ctx := setupTest(t)
+
+	result, err := Enclosing(ctx, client, EnclosingArgs{Location: "http/server.go:25", IncludeCode: true})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "### Function: Server.Run.$func1 (lines 20-30)")
+	assertContains(t, result.Text, "**Nested in**: Server.Run")
+	assertContains(t, result.Text, "**Package**: internal/http (go)")
+	assertContains(t, result.Text, "func inner()")
+}
+
+func TestEnclosing_TypeOnly(t *testing.T) {
+	client := enclosingMock(nil, [][]any{
+		{"Server", "struct", "internal/http/server.go", float64(3), float64(8)},
+	})
+	ctx := setupTest(t)
+
+	result, err := Enclosing(ctx, client, EnclosingArgs{FilePath: "internal/http/server.go", Line: 5, IncludeCode: true})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "### Type: Server (struct, lines 3-8)")
+	assertContains(t, result.Text, "type Server struct {}")
+}
+
+func TestEnclosing_AmbiguousAndErrors(t *testing.T) {
+	client := enclosingMock([][]any{
+		{"main", "func main()", "cmd/a/main.go", float64(1), float64(9)},
+		{"main", "func main()", "cmd/b/main.go", float64(1), float64(9)},
+	}, nil)
+	ctx := setupTest(t)
+
+	result, err := Enclosing(ctx, client, EnclosingArgs{FilePath: "main.go", Line: 3})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "matches 2 indexed files")
+
+	result, _ = Enclosing(ctx, client, EnclosingArgs{Location: "main.go"})
+	if !result.IsError {
+		t.Error("location without line should be an error")
+	}
+	result, _ = Enclosing(ctx, client, EnclosingArgs{FilePath: "main.go"})
+	if !result.IsError {
+		t.Error("missing line should be an error")
+	}
+}
@@ -78,13 +78,15 @@ type ListEndpointsArgs struct {
 //   - Generic: mux.Handle("/path", handler), r.Group("/api")
 //
 // The function searches for HTTP method patterns in function code and extracts
-// the endpoint path, method, and handler information.
+// the endpoint path, method, and handler information. For gin, echo, chi and
+// net/http it also reconstructs the ordered middleware chain of each route
+// from Use, Group, With, Route and handler wrapping in the same function.
 //
 // Results can be filtered by file path (PathPattern), endpoint path (PathFilter),
 // or HTTP method (Method). Test files are automatically excluded from results.
 //
 // Returns a ToolResult containing a formatted table of endpoints with columns:
-// [Method] [Path] [Handler] [File:Line]
+// [Method] [Path] [Handler] [Middleware] [File:Line]
 //
 // Returns an error if the query execution fails.
 func ListEndpoints(ctx context.Context, client Querier, args ListEndpointsArgs) (*ToolResult, error) {
@@ -92,35 +94,10 @@ func ListEndpoints(ctx context.Context, client Querier, args ListEndpointsArgs)
 		args.Limit = 100
 	}
 
-	// Query functions that contain HTTP method patterns
-	conditionStr := buildEndpointQueryConditions(args)
-	queryLimit := args.Limit * 3
-	if queryLimit > 500 {
-		queryLimit = 500
-	}
-
-	script := fmt.Sprintf(
-		"?[file_path, name, start_line, code_text] := *cie_function { id, file_path, name, start_line }, *cie_function_code { function_id: id, code_text }, %s :limit %d",
-		conditionStr, queryLimit,
-	)
-
-	result, err := client.Query(ctx, script)
+	endpoints, err := collectEndpoints(ctx, client, args)
 	if err != nil {
-		return nil, fmt.Errorf("query endpoints: %w", err)
+		return nil, err
 	}
-
-	// Parse endpoints from matching functions
-	var endpoints []endpoint
-	for _, row := range result.Rows {
-		filePath := AnyToString(row[0])
-		funcName := AnyToString(row[1])
-		startLine := AnyToString(row[2])
-		codeText := AnyToString(row[3])
-		endpoints = append(endpoints, parseEndpointsFromCode(codeText, filePath, funcName, startLine, args)...)
-	}
-
-	// Deduplicate and check for empty results
-	endpoints = deduplicateEndpoints(endpoints)
 	if len(endpoints) == 0 {
 		return NewResult(formatNoEndpointsFound()), nil
 	}
@@ -143,6 +120,50 @@ func ListEndpoints(ctx context.Context, client Querier, args ListEndpointsArgs)
 	return NewResult(output), nil
 }
 
+// collectEndpoints finds route registrations in function bodies and returns
+// them deduplicated, before args.Limit is applied.
+func collectEndpoints(ctx context.Context, client Querier, args ListEndpointsArgs) ([]endpoint, error) {
+	// Query functions that contain HTTP method patterns
+	queryLimit := args.Limit * 3
+	if queryLimit > 500 {
+		queryLimit = 500
+	}
+
+	var rows [][]any
+	if isCodeCompressed(ctx, client) {
+		decoded, err := collectDecodedCode(ctx, client, endpointFileConditions(args), queryLimit, func(row []any) bool {
+			return httpMethodCodeRegex.MatchString(AnyToString(row[4]))
+		})
+		if err != nil {
+			return nil, fmt.Errorf("query endpoints: %w", err)
+		}
+		for _, row := range decoded {
+			rows = append(rows, []any{row[0], row[1], row[2], row[4]})
+		}
+	} else {
+		script := fmt.Sprintf(
+			"?[file_path, name, start_line, code_text] := *cie_function { id, file_path, name, start_line }, *cie_function_code { function_id: id, code_text }, %s :limit %d",
+			buildEndpointQueryConditions(args), queryLimit,
+		)
+		result, err := client.Query(ctx, script)
+		if err != nil {
+			return nil, fmt.Errorf("query endpoints: %w", err)
+		}
+		rows = result.Rows
+	}
+
+	// Parse endpoints from matching functions
+	var endpoints []endpoint
+	for _, row := range rows {
+		filePath := AnyToString(row[0])
+		funcName := AnyToString(row[1])
+		startLine := AnyToString(row[2])
+		codeText := AnyToString(row[3])
+		endpoints = append(endpoints, parseEndpointsFromCode(codeText, filePath, funcName, startLine, args)...)
+	}
+	return deduplicateEndpoints(endpoints), nil
+}
+
 // formatNoEndpointsFound returns the message when no endpoints are found.
 func formatNoEndpointsFound() string {
 	return "No HTTP endpoints found.\n\n" +
@@ -158,7 +179,7 @@ func formatEndpointHeader(args ListEndpointsArgs, count int) string {
 		return fmt.Sprintf("## HTTP Endpoints matching `%s` (%d found)\n\n", args.PathFilter, count)
 	}
 	if args.PathPattern != "" {
-		return fmt.Sprintf("## HTTP Endpoints in `%s` (%d found)\n\n", args.PathPattern, count)
+		return fmt.Sprintf("## HTTP Endpoints in `%s` (%d found)\n\n", displayPattern(args.PathPattern), count)
 	}
 	return fmt.Sprintf("## HTTP Endpoints (%d found)\n\n", count)
 }
@@ -166,11 +187,11 @@ func formatEndpointHeader(args ListEndpointsArgs, count int) string {
 // formatEndpointTable generates the table of endpoints.
 func formatEndpointTable(endpoints []endpoint) string {
 	var sb strings.Builder
-	sb.WriteString("| Method | Path | Handler | File |\n")
-	sb.WriteString("|--------|------|---------|------|\n")
+	sb.WriteString("| Method | Path | Handler | Middleware | File |\n")
+	sb.WriteString("|--------|------|---------|------------|------|\n")
 	for _, ep := range endpoints {
 		fileName := ExtractFileName(ep.FilePath)
-		fmt.Fprintf(&sb, "| %s | `%s` | %s | %s:%s |\n", ep.Method, ep.Path, ep.Handler, fileName, ep.Line)
+		fmt.Fprintf(&sb, "| %s | `%s` | %s | %s | %s:%s |\n", ep.Method, ep.Path, ep.Handler, formatMiddlewareChain(ep.Middleware), fileName, ep.Line)
 	}
 	return sb.String()
 }
@@ -195,23 +216,38 @@ type endpoint struct {
 	Handler  string
 	FilePath string
 	Line     string
+
+	// Middleware is the chain applied to the route, outermost first, as far
+	// as it can be seen from the registering function.
+	Middleware []string
 }
 
+// httpMethodPattern matches function code that registers routes.
+const httpMethodPattern = `([.](GET|POST|PUT|DELETE|PATCH|Get|Post|Put|Delete|Patch|Group|Any)[(]|Handle(Func)?[(])`
+
+// httpMethodCodeRegex is httpMethodPattern for matching decompressed code.
+var httpMethodCodeRegex = regexp.MustCompile(httpMethodPattern)
+
 // buildEndpointQueryConditions builds query conditions for endpoint search.
 func buildEndpointQueryConditions(args ListEndpointsArgs) string {
-	httpMethodPattern := `([.](GET|POST|PUT|DELETE|PATCH|Get|Post|Put|Delete|Patch|Group|Any)[(]|Handle(Func)?[(])`
+	conditions := append([]string{fmt.Sprintf("regex_matches(code_text, %s)", QuoteCozoPattern(httpMethodPattern))}, endpointFileConditions(args)...)
+	return strings.Join(conditions, ", ")
+}
+
+// endpointFileConditions are the path conditions of an endpoint search:
+// args.PathPattern, and no test files.
+func endpointFileConditions(args ListEndpointsArgs) []string {
 	var conditions []string
-	conditions = append(conditions, fmt.Sprintf("regex_matches(code_text, %s)", QuoteCozoPattern(httpMethodPattern)))
 	if args.PathPattern != "" {
 		conditions = append(conditions, fmt.Sprintf("regex_matches(file_path, %s)", QuoteCozoPattern(args.PathPattern)))
 	}
-	conditions = append(conditions, `!regex_matches(file_path, ___"(_test[.]go|/tests?/|_test/|/test_)"___)`)
-	return strings.Join(conditions, ", ")
+	return append(conditions, `!regex_matches(file_path, ___"(_test[.]go|/tests?/|_test/|/test_)"___)`)
 }
 
 // parseEndpointsFromCode extracts endpoints from function code using HTTP patterns.
 func parseEndpointsFromCode(codeText, filePath, funcName, startLine string, args ListEndpointsArgs) []endpoint {
 	var endpoints []endpoint
+	chains := routeMiddleware(codeText)
 	for _, p := range httpMethodPatterns {
 		matches := p.pattern.FindAllStringSubmatch(codeText, -1)
 		for _, match := range matches {
@@ -222,6 +258,7 @@ func parseEndpointsFromCode(codeText, filePath, funcName, startLine string, args
 			if !endpointMatchesFilters(ep, args) {
 				continue
 			}
+			ep.Middleware = chains[ep.Method+"|"+ep.Path]
 			endpoints = append(endpoints, *ep)
 		}
 	}
@@ -314,6 +351,8 @@ func formatEndpointSummary(endpoints []endpoint) string {
 		sb.WriteString("\n")
 	}
 
+	sb.WriteString(formatMiddlewareCoverage(endpoints))
+
 	// Group by file
 	fileCounts := make(map[string]int)
 	for _, ep := range endpoints {
@@ -335,3 +374,54 @@ func formatEndpointSummary(endpoints []endpoint) string {
 
 	return sb.String()
 }
+
+// formatMiddlewareChain renders a middleware chain for the endpoint table.
+func formatMiddlewareChain(chain []string) string {
+	if len(chain) == 0 {
+		return "—"
+	}
+	return "`" + strings.ReplaceAll(strings.Join(chain, " → "), "|", "\\|") + "`"
+}
+
+// formatMiddlewareCoverage lists how many endpoints each middleware covers,
+// so routes missing auth or logging stand out. It is empty when no
+// middleware was found.
+func formatMiddlewareCoverage(endpoints []endpoint) string {
+	counts := make(map[string]int)
+	bare := 0
+	for _, ep := range endpoints {
+		if len(ep.Middleware) == 0 {
+			bare++
+		}
+		seen := make(map[string]bool, len(ep.Middleware))
+		for _, mw := range ep.Middleware {
+			if !seen[mw] {
+				seen[mw] = true
+				counts[mw]++
+			}
+		}
+	}
+	if len(counts) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(counts))
+	for mw := range counts {
+		names = append(names, mw)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	var sb strings.Builder
+	sb.WriteString("**By Middleware:**\n")
+	for _, mw := range names {
+		fmt.Fprintf(&sb, "- `%s`: %d of %d endpoints\n", mw, counts[mw], len(endpoints))
+	}
+	if bare > 0 {
+		fmt.Fprintf(&sb, "- no middleware detected: %d\n", bare)
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
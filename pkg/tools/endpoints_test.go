@@ -111,6 +111,13 @@ func TestListEndpoints_Integration(t *testing.T) {
 	}
 }
 
+func TestFormatEndpointHeader_ShowsPatternAsWritten(t *testing.T) {
+	got := formatEndpointHeader(ListEndpointsArgs{PathPattern: NormalizePathPattern("apps/gateway")}, 2)
+	if want := "## HTTP Endpoints in `apps/gateway` (2 found)\n\n"; got != want {
+		t.Errorf("formatEndpointHeader() = %q, want %q", got, want)
+	}
+}
+
 func TestExtractPathPrefix(t *testing.T) {
 	tests := []struct {
 		path string
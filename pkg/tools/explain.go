@@ -0,0 +1,141 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kraklabs/cie/pkg/llm"
+)
+
+// ExplainArgs holds arguments for ExplainFunction.
+type ExplainArgs struct {
+	FunctionName string
+	FullCode     bool         // do not truncate long bodies
+	MaxRelated   int          // callers and callees listed each (default 20)
+	LLM          llm.Provider // writes the summary; nil leaves it out
+	MaxTokens    int          // summary budget (default and cap 600)
+}
+
+// maxExplainPromptCode caps the code sent to the LLM for a summary.
+const maxExplainPromptCode = 8000
+
+// ExplainFunction gathers what the index knows about one function: its
+// code, its direct callers and callees and, with an LLM configured, a
+// written summary of what it does and how it is used. Names resolve as in
+// GetFunctionCode, including package-qualified names and disambiguation.
+func ExplainFunction(ctx context.Context, client Querier, args ExplainArgs) (*ToolResult, error) {
+	funcName := strings.TrimSpace(args.FunctionName)
+	if funcName == "" {
+		return NewInputError("Error: function_name cannot be empty"), nil
+	}
+	if args.MaxRelated <= 0 {
+		args.MaxRelated = 20
+	}
+
+	row, res := resolveFunctionCode(ctx, client, funcName)
+	if res != nil {
+		return res, nil
+	}
+	if row == nil {
+		return NewResult(fmt.Sprintf("Function '%s' not found.", funcName)), nil
+	}
+
+	id, name, filePath, signature := AnyToString(row[0]), AnyToString(row[1]), AnyToString(row[2]), AnyToString(row[3])
+	code := decodeCodeText(row[4]) + functionOverflow(ctx, client, name, filePath, row[5])
+
+	callers, err := BrowseCallers(ctx, client, id)
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v", err)), nil
+	}
+	callees, err := BrowseCallees(ctx, client, id)
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v", err)), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", name)
+	sb.WriteString(explainSummary(ctx, args, name, filePath, signature, code, callers, callees))
+	sb.WriteString("\n## Definition\n\n")
+	sb.WriteString(formatFunctionCode(name, filePath, signature, code, row[5], row[6], args.FullCode))
+	sb.WriteString("\n\n")
+	writeRelated(&sb, "Called by", callers, args.MaxRelated)
+	writeRelated(&sb, "Calls", callees, args.MaxRelated)
+	return NewResult(sb.String()), nil
+}
+
+// writeRelated lists callers or callees with their locations.
+func writeRelated(sb *strings.Builder, title string, refs []FunctionRef, limit int) {
+	fmt.Fprintf(sb, "## %s (%d)\n\n", title, len(refs))
+	if len(refs) == 0 {
+		sb.WriteString("_None in the index._\n\n")
+		return
+	}
+	for i, r := range refs {
+		if i == limit {
+			fmt.Fprintf(sb, "- ... %d more\n", len(refs)-limit)
+			break
+		}
+		fmt.Fprintf(sb, "- `%s` — %s:%d\n", r.Name, r.FilePath, r.StartLine)
+	}
+	sb.WriteString("\n")
+}
+
+// explainSummary asks the LLM what the function does, given its code and
+// call neighbourhood. Failures are reported inline so the structured
+// sections still print.
+func explainSummary(ctx context.Context, args ExplainArgs, name, filePath, signature, code string, callers, callees []FunctionRef) string {
+	if args.LLM == nil {
+		return "## Summary\n\n_No LLM configured. Set `llm.enabled` and `llm.base_url` in .cie/project.yaml for a written summary._\n"
+	}
+	maxTokens := args.MaxTokens
+	if maxTokens <= 0 || maxTokens > 600 {
+		maxTokens = 600
+	}
+	if len(code) > maxExplainPromptCode {
+		cut := maxExplainPromptCode
+		for cut > 0 && !utf8.RuneStart(code[cut]) {
+			cut--
+		}
+		code = code[:cut] + "\n... (truncated)"
+	}
+	names := func(refs []FunctionRef) string {
+		if len(refs) == 0 {
+			return "none"
+		}
+		list := make([]string, 0, min(len(refs), 30))
+		for _, r := range refs[:min(len(refs), 30)] {
+			list = append(list, r.Name)
+		}
+		return strings.Join(list, ", ")
+	}
+	prompt := fmt.Sprintf("Explain the function `%s` in %s to a developer new to the codebase.\n"+
+		"In at most 150 words, say what it does, its inputs and outputs, notable side effects or error cases, "+
+		"and where it fits given who calls it. Only state what the code and call lists support.\n\n"+
+		"Signature: %s\nCalled by: %s\nCalls: %s\n\nCode:\n%s",
+		name, filePath, signature, names(callers), names(callees), code)
+	resp, err := args.LLM.Generate(ctx, llm.GenerateRequest{Prompt: prompt, MaxTokens: maxTokens, Temperature: 0.2})
+	if err != nil {
+		return fmt.Sprintf("## Summary\n\n_LLM summary unavailable: %v_\n", err)
+	}
+	return "## Summary\n\n" + strings.TrimSpace(resp.Text) + "\n"
+}
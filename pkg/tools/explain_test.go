@@ -0,0 +1,126 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/kraklabs/cie/pkg/llm"
+)
+
+func explainClient(defs [][]any) *MockCIEClient {
+	return NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		switch {
+		case strings.HasPrefix(script, "?[id, name, file_path, signature, code_text"):
+			return NewMockQueryResult([]string{"id", "name", "file_path", "signature", "code_text", "start_line", "end_line"}, defs), nil
+		case strings.Contains(script, `callee_id: "fn-save"`):
+			return NewMockQueryResult([]string{"name", "file_path", "id", "signature", "start_line", "end_line"}, [][]any{
+				{"HandleUpdate", "internal/api/user.go", "fn-h", "", int64(40), int64(60)},
+			}), nil
+		case strings.Contains(script, `caller_id: "fn-save"`):
+			return NewMockQueryResult([]string{"name", "file_path", "id", "signature", "start_line", "end_line"}, [][]any{
+				{"validate", "pkg/store/user.go", "fn-v", "", int64(5), int64(9)},
+				{"DB.Exec", "pkg/store/db.go", "fn-e", "", int64(11), int64(30)},
+			}), nil
+		}
+		return NewMockQueryResult(nil, nil), nil
+	}, nil)
+}
+
+var saveUserRow = []any{"fn-save", "SaveUser", "pkg/store/user.go", "func SaveUser(u *User) error", "func SaveUser(u *User) error {\n\treturn db.Exec(u)\n}", int64(12), int64(14)}
+
+func TestExplainFunction(t *testing.T) {
+	ctx := setupTest(t)
+	var prompt string
+	provider := &llm.MockProvider{GenerateFunc: func(ctx context.Context, req llm.GenerateRequest) (*llm.GenerateResponse, error) {
+		prompt = req.Prompt
+		return &llm.GenerateResponse{Text: "Persists a user."}, nil
+	}}
+
+	result, err := ExplainFunction(ctx, explainClient([][]any{saveUserRow}), ExplainArgs{FunctionName: "SaveUser", LLM: provider})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "# SaveUser")
+	assertContains(t, result.Text, "## Summary\n\nPersists a user.")
+	assertContains(t, result.Text, "**File**: pkg/store/user.go:12-14")
+	assertContains(t, result.Text, "## Called by (1)\n\n- `HandleUpdate` — internal/api/user.go:40")
+	assertContains(t, result.Text, "## Calls (2)")
+	assertContains(t, result.Text, "- `DB.Exec` — pkg/store/db.go:11")
+	assertContains(t, prompt, "Called by: HandleUpdate")
+	assertContains(t, prompt, "Calls: validate, DB.Exec")
+	assertContains(t, prompt, "return db.Exec(u)")
+}
+
+func TestExplainFunction_TruncatesOnRuneBoundary(t *testing.T) {
+	ctx := setupTest(t)
+	var prompt string
+	provider := &llm.MockProvider{GenerateFunc: func(ctx context.Context, req llm.GenerateRequest) (*llm.GenerateResponse, error) {
+		prompt = req.Prompt
+		return &llm.GenerateResponse{Text: "ok"}, nil
+	}}
+	// "é" is two bytes, so byte maxExplainPromptCode falls inside one.
+	code := "x" + strings.Repeat("é", maxExplainPromptCode)
+	row := []any{"fn-save", "SaveUser", "pkg/store/user.go", "func SaveUser()", code, int64(12), int64(14)}
+
+	_, err := ExplainFunction(ctx, explainClient([][]any{row}), ExplainArgs{FunctionName: "SaveUser", LLM: provider})
+	assertNoError(t, err)
+	if !utf8.ValidString(prompt) {
+		t.Error("prompt was cut inside a UTF-8 sequence")
+	}
+	assertContains(t, prompt, "... (truncated)")
+}
+
+func TestExplainFunction_NoLLM(t *testing.T) {
+	ctx := setupTest(t)
+	result, err := ExplainFunction(ctx, explainClient([][]any{saveUserRow}), ExplainArgs{FunctionName: "SaveUser"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "No LLM configured")
+	assertContains(t, result.Text, "## Definition")
+
+	failing := &llm.MockProvider{GenerateFunc: func(ctx context.Context, req llm.GenerateRequest) (*llm.GenerateResponse, error) {
+		return nil, errors.New("connection refused")
+	}}
+	result, err = ExplainFunction(ctx, explainClient([][]any{saveUserRow}), ExplainArgs{FunctionName: "SaveUser", LLM: failing})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "LLM summary unavailable: connection refused")
+	assertContains(t, result.Text, "## Calls (2)")
+}
+
+func TestExplainFunction_Resolution(t *testing.T) {
+	ctx := setupTest(t)
+
+	other := []any{"fn-save2", "SaveUser", "pkg/cache/user.go", "func SaveUser(u *User)", "", int64(3), int64(8)}
+	result, err := ExplainFunction(ctx, explainClient([][]any{saveUserRow, other}), ExplainArgs{FunctionName: "SaveUser"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "is ambiguous (2 matches)")
+
+	result, err = ExplainFunction(ctx, explainClient(nil), ExplainArgs{FunctionName: "Missing"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "Function 'Missing' not found.")
+
+	result, err = ExplainFunction(ctx, explainClient(nil), ExplainArgs{FunctionName: " "})
+	assertNoError(t, err)
+	if !result.IsError {
+		t.Error("empty name should be an input error")
+	}
+}
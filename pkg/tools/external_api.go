@@ -0,0 +1,236 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/kraklabs/cie/pkg/goimport"
+)
+
+// ExternalAPIArgs holds arguments for the external dependency usage view.
+type ExternalAPIArgs struct {
+	PathPattern string // optional regex on the calling file path
+	Package     string // optional substring of the external import path (e.g., "net/http")
+	Limit       int    // local packages to show (default 30)
+}
+
+// maxExternalMethodsShown caps the methods listed per external package.
+const maxExternalMethodsShown = 8
+
+// stubFilePath is the file_path the indexer gives external type method stubs.
+const stubFilePath = "<external>"
+
+// externalUsage counts the calls one local package makes into one external package.
+type externalUsage struct {
+	calls   int
+	methods map[string]int
+}
+
+// ExternalAPI aggregates the calls each local package makes into stdlib and
+// third-party packages. It combines Go calls recorded as external in
+// cie_unresolved_call (mapped to import paths through cie_import) with calls
+// into external type stubs such as DB.Query, whose package is unknown.
+func ExternalAPI(ctx context.Context, client Querier, args ExternalAPIArgs) (*ToolResult, error) {
+	if args.Limit <= 0 {
+		args.Limit = 30
+	}
+	pathFilter := ""
+	if args.PathPattern != "" {
+		pathFilter = fmt.Sprintf(", regex_matches(file_path, %q)", args.PathPattern)
+	}
+
+	calls, err := client.Query(ctx, fmt.Sprintf(`?[file_path, callee_name, count(id)] := *cie_unresolved_call { id, callee_name, file_path, reason }, reason = "external"%s`, pathFilter))
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v (indexes built before cie_unresolved_call need 'cie index')", err)), nil
+	}
+	imports, err := client.Query(ctx, fmt.Sprintf(`?[file_path, import_path, alias] := *cie_import { file_path, import_path, alias }, *cie_unresolved_call { file_path, reason }, reason = "external"%s`, pathFilter))
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v", err)), nil
+	}
+	aliases := map[string]map[string]string{} // file → alias → import path
+	for _, row := range imports.Rows {
+		file, importPath := AnyToString(row[0]), AnyToString(row[1])
+		if aliases[file] == nil {
+			aliases[file] = map[string]string{}
+		}
+		aliases[file][importAlias(importPath, AnyToString(row[2]))] = importPath
+	}
+
+	// local package → external package → usage
+	usage := map[string]map[string]*externalUsage{}
+	record := func(file, extPkg, method string, n int) {
+		if args.Package != "" && !strings.Contains(extPkg, args.Package) {
+			return
+		}
+		local := path.Dir(file)
+		if usage[local] == nil {
+			usage[local] = map[string]*externalUsage{}
+		}
+		u := usage[local][extPkg]
+		if u == nil {
+			u = &externalUsage{methods: map[string]int{}}
+			usage[local][extPkg] = u
+		}
+		u.calls += n
+		u.methods[method] += n
+	}
+	for _, row := range calls.Rows {
+		file, callee := AnyToString(row[0]), AnyToString(row[1])
+		qualifier, _, _ := strings.Cut(callee, ".")
+		extPkg, ok := aliases[file][qualifier]
+		if !ok {
+			extPkg = qualifier
+		}
+		record(file, extPkg, callee, int(toFloat64(row[2])))
+	}
+
+	stubs, err := client.Query(ctx, fmt.Sprintf(`?[file_path, callee, count(caller_id)] := *cie_calls { caller_id, callee_id }, *cie_function { id: callee_id, name: callee, file_path: ext }, ext = %q, *cie_function { id: caller_id, file_path }%s`, stubFilePath, pathFilter))
+	if err == nil {
+		for _, row := range stubs.Rows {
+			record(AnyToString(row[0]), "(external types)", AnyToString(row[1]), int(toFloat64(row[2])))
+		}
+	}
+
+	if len(usage) == 0 {
+		return NewResult("No external calls recorded for this scope. Only Go calls are tracked; indexes built before cie_unresolved_call need 'cie index'."), nil
+	}
+	return NewResult(formatExternalAPI(usage, args.Limit)), nil
+}
+
+// formatExternalAPI renders the per-package usage and a package summary table.
+func formatExternalAPI(usage map[string]map[string]*externalUsage, limit int) string {
+	type extTotal struct {
+		calls  int
+		locals int
+	}
+	totals := map[string]*extTotal{}
+	callSites := 0
+	for _, byExt := range usage {
+		for ext, u := range byExt {
+			t := totals[ext]
+			if t == nil {
+				t = &extTotal{}
+				totals[ext] = t
+			}
+			t.calls += u.calls
+			t.locals++
+			callSites += u.calls
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# External API Usage\n\n")
+	fmt.Fprintf(&sb, "**Local packages**: %d · **External packages**: %d · **Call sites**: %d\n", len(usage), len(totals), callSites)
+
+	locals := make([]string, 0, len(usage))
+	for local := range usage {
+		locals = append(locals, local)
+	}
+	sort.Strings(locals)
+	for i, local := range locals {
+		if i == limit {
+			fmt.Fprintf(&sb, "\n_%d more local packages; narrow with `path_pattern`._\n", len(locals)-limit)
+			break
+		}
+		fmt.Fprintf(&sb, "\n## %s\n\n", local)
+		exts := make([]string, 0, len(usage[local]))
+		for ext := range usage[local] {
+			exts = append(exts, ext)
+		}
+		sort.Strings(exts)
+		for _, ext := range exts {
+			u := usage[local][ext]
+			fmt.Fprintf(&sb, "- `%s`%s: %s\n", ext, externalKindSuffix(ext), formatMethodCounts(u.methods))
+		}
+	}
+
+	names := make([]string, 0, len(totals))
+	for name := range totals {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if totals[names[i]].calls != totals[names[j]].calls {
+			return totals[names[i]].calls > totals[names[j]].calls
+		}
+		return names[i] < names[j]
+	})
+	sb.WriteString("\n## External Packages\n\n| Package | Kind | Call sites | Used by |\n|---------|------|------------|---------|\n")
+	for _, name := range names {
+		fmt.Fprintf(&sb, "| `%s` | %s | %d | %d package(s) |\n", name, externalKind(name), totals[name].calls, totals[name].locals)
+	}
+	return sb.String()
+}
+
+// formatMethodCounts lists methods by descending call count ("`http.Get` ×3").
+func formatMethodCounts(methods map[string]int) string {
+	names := make([]string, 0, len(methods))
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if methods[names[i]] != methods[names[j]] {
+			return methods[names[i]] > methods[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, 0, maxExternalMethodsShown+1)
+	for i, name := range names {
+		if i == maxExternalMethodsShown {
+			parts = append(parts, fmt.Sprintf("+%d more", len(names)-i))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("`%s` ×%d", name, methods[name]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// externalKind classifies an import path: standard library paths have no
+// dot in their first element.
+func externalKind(importPath string) string {
+	switch {
+	case strings.HasPrefix(importPath, "("):
+		return "unknown"
+	case !strings.Contains(strings.SplitN(importPath, "/", 2)[0], "."):
+		return "stdlib"
+	default:
+		return "third-party"
+	}
+}
+
+// externalKindSuffix marks stdlib packages in the per-package listing.
+func externalKindSuffix(importPath string) string {
+	if externalKind(importPath) == "stdlib" {
+		return " (stdlib)"
+	}
+	return ""
+}
+
+// importAlias returns the name a Go import is referenced by: its explicit
+// alias, or the last path element without a major-version suffix.
+func importAlias(importPath, alias string) string {
+	if alias != "" && alias != "_" && alias != "." {
+		return alias
+	}
+	return goimport.DefaultAlias(importPath)
+}
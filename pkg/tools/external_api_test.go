@@ -0,0 +1,86 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func externalAPIMock() Querier {
+	return NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		switch {
+		case strings.HasPrefix(script, "?[file_path, callee_name, count(id)]"):
+			return NewMockQueryResult(nil, [][]any{
+				{"api/server.go", "http.Error", float64(2)},
+				{"api/server.go", "json.NewEncoder", float64(1)},
+				{"api/client.go", "http.Get", float64(3)},
+				{"store/db.go", "pgx.Connect", float64(1)},
+			}), nil
+		case strings.HasPrefix(script, "?[file_path, import_path, alias]"):
+			return NewMockQueryResult(nil, [][]any{
+				{"api/server.go", "net/http", ""},
+				{"api/server.go", "encoding/json", ""},
+				{"api/client.go", "net/http", ""},
+				{"store/db.go", "github.com/jackc/pgx/v5", ""},
+			}), nil
+		default: // external type stubs
+			return NewMockQueryResult(nil, [][]any{{"store/db.go", "DB.Query", float64(4)}}), nil
+		}
+	}, nil)
+}
+
+func TestExternalAPI(t *testing.T) {
+	ctx := context.Background()
+
+	result, err := ExternalAPI(ctx, externalAPIMock(), ExternalAPIArgs{})
+	assertNoError(t, err)
+	for _, want := range []string{
+		"**Local packages**: 2 · **External packages**: 4 · **Call sites**: 11",
+		"## api\n\n- `encoding/json` (stdlib): `json.NewEncoder` ×1\n- `net/http` (stdlib): `http.Get` ×3, `http.Error` ×2\n",
+		"- `github.com/jackc/pgx/v5`: `pgx.Connect` ×1",
+		"- `(external types)`: `DB.Query` ×4",
+		"| `net/http` | stdlib | 5 | 1 package(s) |",
+		"| `github.com/jackc/pgx/v5` | third-party | 1 | 1 package(s) |",
+	} {
+		assertContains(t, result.Text, want)
+	}
+
+	result, err = ExternalAPI(ctx, externalAPIMock(), ExternalAPIArgs{Package: "pgx"})
+	assertNoError(t, err)
+	if strings.Contains(result.Text, "net/http") || !strings.Contains(result.Text, "pgx.Connect") {
+		t.Errorf("package filter not applied:\n%s", result.Text)
+	}
+}
+
+func TestImportAlias(t *testing.T) {
+	tests := []struct{ importPath, alias, want string }{
+		{"net/http", "", "http"},
+		{"github.com/jackc/pgx/v5", "", "pgx"},
+		{"gopkg.in/yaml.v3", "", "yaml"},
+		{"github.com/org/lib", "mylib", "mylib"},
+	}
+	for _, tt := range tests {
+		if got := importAlias(tt.importPath, tt.alias); got != tt.want {
+			t.Errorf("importAlias(%q, %q) = %q, want %q", tt.importPath, tt.alias, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StoreFactArgs holds arguments for storing a fact.
+type StoreFactArgs struct {
+	Text           string   // e.g. "Handlers never call the DB directly; go through service/"
+	Tags           []string // optional: e.g. "conventions", "testing"
+	Author         string   // optional: defaults to "agent"
+	EmbeddingURL   string
+	EmbeddingModel string
+}
+
+// RecallFactsArgs holds arguments for recalling facts.
+type RecallFactsArgs struct {
+	Query          string  // optional: what to recall; without it facts are listed newest first
+	Tag            string  // optional: only facts with this tag
+	Limit          int     // facts to show (default 10)
+	MinSimilarity  float64 // optional: embedded facts below this similarity need a keyword match (default 0.7)
+	EmbeddingURL   string
+	EmbeddingModel string
+}
+
+// defaultFactMinSimilarity is the similarity an embedded fact needs to be
+// recalled without a keyword match. On the 0-1 scale of search results,
+// unrelated sentences often score 0.6 or more.
+const defaultFactMinSimilarity = 0.7
+
+// Fact is a piece of project knowledge stored for later sessions.
+type Fact struct {
+	ID, Text, Author, Created string
+	Tags                      []string
+	Similarity                float64 // set by RecallFacts when the fact has an embedding
+}
+
+// factID derives a stable ID from the text, so storing a fact twice keeps one copy.
+func factID(text string) string {
+	h := sha256.Sum256([]byte(text))
+	return "fact:" + hex.EncodeToString(h[:])[:16]
+}
+
+// normalizeTags lowercases and trims tags, splits "a,b" and drops empty and
+// repeated tags. Tags are stored comma-separated.
+func normalizeTags(tags []string) []string {
+	var out []string
+	for _, tag := range tags {
+		for _, t := range strings.Split(tag, ",") {
+			t = strings.ToLower(strings.TrimSpace(t))
+			if t != "" && !slices.Contains(out, t) {
+				out = append(out, t)
+			}
+		}
+	}
+	return out
+}
+
+// StoreFact saves project knowledge (conventions, gotchas, decisions) that
+// later sessions can find with RecallFacts. The fact is embedded for
+// semantic recall; if the embedding provider is unavailable it is stored
+// anyway and found by keyword.
+func StoreFact(ctx context.Context, client Querier, args StoreFactArgs) (*ToolResult, error) {
+	args.Text = strings.TrimSpace(args.Text)
+	if args.Text == "" {
+		return NewInputError("Error: 'text' is required"), nil
+	}
+	exec, ok := client.(Executor)
+	if !ok {
+		return NewError("Facts can only be stored with a local index (this connection is read-only)"), nil
+	}
+	if args.Author == "" {
+		args.Author = "agent"
+	}
+
+	id := factID(args.Text)
+	tags := strings.Join(normalizeTags(args.Tags), ",")
+	script := fmt.Sprintf("?[id, text, tags, author, created] <- [[%q, %q, %q, %q, %q]] :put cie_fact { id => text, tags, author, created }",
+		id, args.Text, tags, args.Author, time.Now().UTC().Format(time.RFC3339))
+	if _, err := exec.Execute(ctx, script); err != nil {
+		return NewError(fmt.Sprintf("Cannot store fact: %v (indexes built before cie_fact need 'cie index')", err)), nil
+	}
+
+	out := "Stored fact " + id
+	if tags != "" {
+		out += " [" + tags + "]"
+	}
+	embedding, err := generateDocumentEmbedding(ctx, args.EmbeddingURL, args.EmbeddingModel, args.Text)
+	if err == nil {
+		script = fmt.Sprintf("?[fact_id, embedding] := fact_id = %q, embedding = %s :put cie_fact_embedding { fact_id => embedding }", id, formatEmbeddingForCozoDB(embedding))
+		_, err = exec.Execute(ctx, script)
+	}
+	if err != nil {
+		out += fmt.Sprintf("\n\n⚠️ Not embedded (%v); cie_recall_facts will find it by keyword only.", err)
+	}
+	return NewResult(out), nil
+}
+
+// loadFacts reads every fact, newest first, optionally only those tagged tag.
+func loadFacts(ctx context.Context, client Querier, tag string) ([]Fact, error) {
+	result, err := client.Query(ctx, "?[id, text, tags, author, created] := *cie_fact { id, text, tags, author, created } :order -created :limit 5000")
+	if err != nil {
+		return nil, err
+	}
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	var facts []Fact
+	for _, r := range result.Rows {
+		if len(r) < 5 {
+			continue
+		}
+		var tags []string
+		if s := AnyToString(r[2]); s != "" {
+			tags = strings.Split(s, ",")
+		}
+		if tag != "" && !slices.Contains(tags, tag) {
+			continue
+		}
+		facts = append(facts, Fact{ID: AnyToString(r[0]), Text: AnyToString(r[1]), Tags: tags, Author: AnyToString(r[3]), Created: AnyToString(r[4])})
+	}
+	return facts, nil
+}
+
+// factDistances returns the cosine distance from embedding to every
+// embedded fact. There are few facts, so they are scanned rather than
+// indexed.
+func factDistances(ctx context.Context, client Querier, embedding []float64) (map[string]float64, error) {
+	script := fmt.Sprintf("?[fact_id, distance] := *cie_fact_embedding { fact_id, embedding }, q = %s, distance = cos_dist(embedding, q)", formatEmbeddingForCozoDB(embedding))
+	result, err := client.Query(ctx, script)
+	if err != nil {
+		return nil, err
+	}
+	distances := make(map[string]float64, len(result.Rows))
+	for _, r := range result.Rows {
+		if len(r) >= 2 {
+			if d, ok := r[1].(float64); ok {
+				distances[AnyToString(r[0])] = d
+			}
+		}
+	}
+	return distances, nil
+}
+
+// RecallFacts finds stored facts relevant to a query: by meaning for
+// embedded facts at least args.MinSimilarity similar, and by keyword for
+// the rest, including facts stored without an embedding or all facts when
+// the embedding provider is unavailable.
+func RecallFacts(ctx context.Context, client Querier, args RecallFactsArgs) (*ToolResult, error) {
+	if args.Limit <= 0 {
+		args.Limit = 10
+	}
+	if args.MinSimilarity <= 0 {
+		args.MinSimilarity = defaultFactMinSimilarity
+	}
+	facts, err := loadFacts(ctx, client, args.Tag)
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v (indexes built before cie_fact need 'cie index')", err)), nil
+	}
+	if len(facts) == 0 {
+		if args.Tag != "" {
+			return NewResult(fmt.Sprintf("No facts tagged '%s'.", args.Tag)), nil
+		}
+		return NewResult("No facts stored yet. Use `cie_store_fact` to save conventions and gotchas for later sessions."), nil
+	}
+
+	var sb strings.Builder
+	query := strings.TrimSpace(args.Query)
+	if query == "" {
+		fmt.Fprintf(&sb, "### Facts (%d)\n\n", len(facts))
+		writeFacts(&sb, facts, args.Limit)
+		return NewResult(sb.String()), nil
+	}
+
+	var note string
+	distances := map[string]float64{}
+	embedding, err := generateEmbedding(ctx, args.EmbeddingURL, args.EmbeddingModel, query)
+	if err == nil {
+		distances, err = factDistances(ctx, client, embedding)
+	}
+	if err != nil {
+		note = fmt.Sprintf("⚠️ Keyword match only: %v\n\n", err)
+	}
+
+	words := strings.Fields(strings.ToLower(query))
+	var matched []Fact
+	for _, f := range facts {
+		if d, ok := distances[f.ID]; ok {
+			f.Similarity = 1 - d/2
+		}
+		if f.Similarity >= args.MinSimilarity || keywordMatch(f, words) {
+			matched = append(matched, f)
+		}
+	}
+	// Embedded facts by similarity, then keyword matches newest first.
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].Similarity > matched[j].Similarity })
+	if len(matched) == 0 {
+		return NewResult(note + fmt.Sprintf("No facts match '%s'. Call with no query to list all %d facts.", query, len(facts))), nil
+	}
+
+	sb.WriteString(note)
+	fmt.Fprintf(&sb, "### Facts recalled for '%s'\n\n", query)
+	writeFacts(&sb, matched, args.Limit)
+	return NewResult(sb.String()), nil
+}
+
+// keywordMatch reports whether a fact's text or tags contain every word.
+func keywordMatch(f Fact, words []string) bool {
+	haystack := strings.ToLower(f.Text + " " + strings.Join(f.Tags, " "))
+	for _, w := range words {
+		if !strings.Contains(haystack, w) {
+			return false
+		}
+	}
+	return len(words) > 0
+}
+
+// writeFacts writes up to limit facts as a markdown list.
+func writeFacts(sb *strings.Builder, facts []Fact, limit int) {
+	for i, f := range facts {
+		if i == limit {
+			fmt.Fprintf(sb, "\n_%d more; raise `limit` or filter by `tag`._\n", len(facts)-limit)
+			return
+		}
+		sb.WriteString("- ")
+		if f.Similarity > 0 {
+			fmt.Fprintf(sb, "**%.0f%%** ", f.Similarity*100)
+		}
+		sb.WriteString(f.Text)
+		meta := f.Author + ", " + f.Created
+		if len(f.Tags) > 0 {
+			meta = strings.Join(f.Tags, ", ") + "; " + meta
+		}
+		fmt.Fprintf(sb, " _(%s)_\n", meta)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/embedprefix"
+)
+
+func embeddingServer(t *testing.T) string {
+	url, _ := recordingEmbeddingServer(t)
+	return url
+}
+
+// recordingEmbeddingServer is an Ollama-style embedding server that keeps
+// the last prompt it was sent.
+func recordingEmbeddingServer(t *testing.T) (string, *string) {
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Prompt string `json:"prompt"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		prompt = req.Prompt
+		_ = json.NewEncoder(w).Encode(map[string]any{"embedding": []float64{0.1, 0.2}})
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, &prompt
+}
+
+// factsMock answers the fact listing and the fact embedding ranking.
+func factsMock(t *testing.T) *mockExecClient {
+	return &mockExecClient{MockCIEClient: *NewMockClientScripted(t,
+		MockQuery{
+			Match: []string{"?[id, text, tags, author, created] := *cie_fact"},
+			Want:  []string{"*cie_fact { id, text, tags, author, created }", ":order -created"},
+			Rows: [][]any{
+				{"fact:1", "Handlers never call the DB directly; go through service/", "conventions", "agent", "2026-01-03T10:00:00Z"},
+				{"fact:2", "Integration tests need TEST_DATABASE_URL", "testing,gotchas", "alice", "2026-01-02T10:00:00Z"},
+				{"fact:3", "Migrations run on startup", "", "agent", "2026-01-01T10:00:00Z"},
+			},
+		},
+		MockQuery{
+			Match: []string{"?[fact_id, distance] := *cie_fact_embedding"},
+			// Facts are ranked by cosine distance to the normalized query embedding.
+			Want: []string{"*cie_fact_embedding { fact_id, embedding }", "q = vec([0.447214,0.894427])", "distance = cos_dist(embedding, q)"},
+			Rows: [][]any{{"fact:1", 0.8}, {"fact:2", 0.2}},
+		},
+	)}
+}
+
+func TestStoreFact(t *testing.T) {
+	ctx := context.Background()
+
+	client := factsMock(t)
+	url, prompt := recordingEmbeddingServer(t)
+	SetEmbeddingPrefixes("test-facts-model", embedprefix.Prefixes{Query: "search_query: ", Document: "search_document: "})
+	result, err := StoreFact(ctx, client, StoreFactArgs{
+		Text:         "Integration tests need TEST_DATABASE_URL",
+		Tags:         []string{"Testing", "gotchas,testing"},
+		EmbeddingURL: url, EmbeddingModel: "test-facts-model",
+	})
+	assertNoError(t, err)
+	if *prompt != "search_document: Integration tests need TEST_DATABASE_URL" {
+		t.Errorf("facts should be embedded as documents, got prompt %q", *prompt)
+	}
+	assertContains(t, result.Text, "Stored fact "+factID("Integration tests need TEST_DATABASE_URL")+" [testing,gotchas]")
+	assertNotContains(t, result.Text, "Not embedded")
+	assertContains(t, client.executed, ":put cie_fact_embedding")
+
+	client = factsMock(t)
+	result, err = StoreFact(ctx, client, StoreFactArgs{Text: "Migrations run on startup", EmbeddingURL: "http://127.0.0.1:1", EmbeddingModel: "nomic-embed-text"})
+	assertNoError(t, err)
+	if result.IsError {
+		t.Fatalf("a fact should be stored without an embedding: %s", result.Text)
+	}
+	assertContains(t, result.Text, "keyword only")
+	assertContains(t, client.executed, ":put cie_fact {")
+
+	result, err = StoreFact(ctx, NewMockClientEmpty(), StoreFactArgs{Text: "x"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "read-only")
+}
+
+func TestRecallFacts(t *testing.T) {
+	ctx := context.Background()
+	url := embeddingServer(t)
+
+	t.Run("semantic", func(t *testing.T) {
+		result, err := RecallFacts(ctx, factsMock(t), RecallFactsArgs{Query: "how to run tests", EmbeddingURL: url, EmbeddingModel: "nomic-embed-text"})
+		assertNoError(t, err)
+		text := result.Text
+		assertContains(t, text, "**90%** Integration tests need TEST_DATABASE_URL _(testing, gotchas; alice, 2026-01-02T10:00:00Z)_")
+		assertNotContains(t, text, "Handlers never")
+		assertNotContains(t, text, "Migrations")
+	})
+
+	t.Run("min similarity", func(t *testing.T) {
+		result, err := RecallFacts(ctx, factsMock(t), RecallFactsArgs{Query: "how to run tests", MinSimilarity: 0.5, EmbeddingURL: url, EmbeddingModel: "nomic-embed-text"})
+		assertNoError(t, err)
+		text := result.Text
+		assertContains(t, text, "**60%** Handlers never")
+		if strings.Index(text, "TEST_DATABASE_URL") > strings.Index(text, "Handlers never") {
+			t.Error("facts should be ordered by similarity")
+		}
+	})
+
+	t.Run("keyword fallback", func(t *testing.T) {
+		result, err := RecallFacts(ctx, factsMock(t), RecallFactsArgs{Query: "migrations startup", EmbeddingURL: "http://127.0.0.1:1", EmbeddingModel: "nomic-embed-text"})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "Keyword match only")
+		assertContains(t, result.Text, "- Migrations run on startup")
+		assertNotContains(t, result.Text, "Handlers")
+	})
+
+	t.Run("list by tag", func(t *testing.T) {
+		result, err := RecallFacts(ctx, factsMock(t), RecallFactsArgs{Tag: "Testing"})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "### Facts (1)")
+		assertContains(t, result.Text, "TEST_DATABASE_URL")
+	})
+}
@@ -0,0 +1,239 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// Query types that search feedback is aggregated by.
+const (
+	QueryIdentifier      = "identifier"       // a bare name: HandleLogin, pkg.Func
+	QueryCode            = "code"             // a code fragment: http.Client{Timeout
+	QueryQuestion        = "question"         // where are routes registered?
+	QueryNaturalLanguage = "natural_language" // retry with backoff
+)
+
+// minFeedbackForSuggestion is the number of useful ratings a group needs
+// before its ratings suggest a k or a min_similarity.
+const minFeedbackForSuggestion = 5
+
+var (
+	identifierQueryPattern = regexp.MustCompile(`^[A-Za-z_$][\w$]*(\.[A-Za-z_$][\w$]*)*$`)
+	codeQueryPattern       = regexp.MustCompile(`[(){}\[\];]|:=|->|=>|::|==|!=`)
+	questionWordPattern    = regexp.MustCompile(`(?i)^(how|where|what|why|which|who|when|does|do|is|are|can)\b`)
+)
+
+// ClassifyQuery returns the query type of a search query, so that feedback
+// on bare names, code fragments and prose is tuned separately: the settings
+// that help one kind of query rarely help the others.
+func ClassifyQuery(query string) string {
+	q := strings.TrimSpace(query)
+	switch {
+	case identifierQueryPattern.MatchString(q):
+		return QueryIdentifier
+	case codeQueryPattern.MatchString(q):
+		return QueryCode
+	case strings.HasSuffix(q, "?") || questionWordPattern.MatchString(q):
+		return QueryQuestion
+	default:
+		return QueryNaturalLanguage
+	}
+}
+
+// SearchFeedbackArgs holds the arguments of cie_search_feedback.
+type SearchFeedbackArgs struct {
+	Tool       string  // search tool that returned the result (default cie_semantic_search)
+	Query      string  // query the result was returned for
+	Result     string  // function name, file path or file:line of the result
+	Useful     bool    // whether the result helped
+	Rank       int     // 1-based position in the results, 0 if unknown
+	Similarity float64 // score reported with the result (0-1 or a percentage), 0 if none
+	SessionID  string
+}
+
+// RecordSearchFeedback validates a rating and appends it to log.
+func RecordSearchFeedback(log *storage.FeedbackLog, args SearchFeedbackArgs) (*ToolResult, error) {
+	if log == nil {
+		return NewError("Search feedback is unavailable: the feedback log could not be opened (see the server's stderr)"), nil
+	}
+	if strings.TrimSpace(args.Query) == "" || strings.TrimSpace(args.Result) == "" {
+		return NewInputError("Error: 'query' and 'result' are required"), nil
+	}
+	if args.Rank < 0 {
+		return NewInputError("Error: 'rank' must be 1 or more"), nil
+	}
+	if args.Similarity > 1 {
+		args.Similarity /= 100 // reported as a percentage
+	}
+	if args.Similarity < 0 || args.Similarity > 1 {
+		return NewInputError("Error: 'similarity' must be between 0 and 1"), nil
+	}
+	if args.Tool == "" {
+		args.Tool = "cie_semantic_search"
+	}
+	queryType := ClassifyQuery(args.Query)
+	err := log.Record(storage.FeedbackEntry{
+		SessionID:  args.SessionID,
+		Tool:       args.Tool,
+		Query:      args.Query,
+		QueryType:  queryType,
+		Result:     args.Result,
+		Rank:       args.Rank,
+		Similarity: args.Similarity,
+		Useful:     args.Useful,
+	})
+	if err != nil {
+		return nil, err
+	}
+	verdict := "not useful"
+	if args.Useful {
+		verdict = "useful"
+	}
+	return NewResult(fmt.Sprintf("Recorded %s as %s for %s query %q.", args.Result, verdict, strings.ReplaceAll(queryType, "_", " "), args.Query)), nil
+}
+
+// FeedbackSummary aggregates the ratings of one search tool and query type.
+// The suggestions are zero until the group has enough useful ratings.
+type FeedbackSummary struct {
+	Tool                   string  `json:"tool"`
+	QueryType              string  `json:"query_type"`
+	Ratings                int     `json:"ratings"`
+	Useful                 int     `json:"useful"`
+	UsefulRate             float64 `json:"useful_rate"`
+	MeanUsefulRank         float64 `json:"mean_useful_rank,omitempty"`
+	MeanSimilarityUseful   float64 `json:"mean_similarity_useful,omitempty"`
+	MeanSimilarityUnuseful float64 `json:"mean_similarity_unuseful,omitempty"`
+
+	// SuggestedK is the rank within which 90% of useful results were found.
+	SuggestedK int `json:"suggested_k,omitempty"`
+	// SuggestedMinSimilarity keeps 90% of the useful results.
+	SuggestedMinSimilarity float64 `json:"suggested_min_similarity,omitempty"`
+}
+
+// SummarizeFeedback aggregates entries by tool and query type, sorted by
+// tool, then by number of ratings.
+func SummarizeFeedback(entries []storage.FeedbackEntry) []FeedbackSummary {
+	type group struct {
+		summary                   FeedbackSummary
+		usefulRanks, usefulScores []float64
+		unusefulScores            []float64
+	}
+	groups := map[[2]string]*group{}
+	for _, e := range entries {
+		key := [2]string{e.Tool, e.QueryType}
+		g := groups[key]
+		if g == nil {
+			g = &group{summary: FeedbackSummary{Tool: e.Tool, QueryType: e.QueryType}}
+			groups[key] = g
+		}
+		g.summary.Ratings++
+		switch {
+		case e.Useful:
+			g.summary.Useful++
+			if e.Rank > 0 {
+				g.usefulRanks = append(g.usefulRanks, float64(e.Rank))
+			}
+			if e.Similarity > 0 {
+				g.usefulScores = append(g.usefulScores, e.Similarity)
+			}
+		case e.Similarity > 0:
+			g.unusefulScores = append(g.unusefulScores, e.Similarity)
+		}
+	}
+
+	summaries := make([]FeedbackSummary, 0, len(groups))
+	for _, g := range groups {
+		s := g.summary
+		s.UsefulRate = float64(s.Useful) / float64(s.Ratings)
+		s.MeanUsefulRank = mean(g.usefulRanks)
+		s.MeanSimilarityUseful = mean(g.usefulScores)
+		s.MeanSimilarityUnuseful = mean(g.unusefulScores)
+		if len(g.usefulRanks) >= minFeedbackForSuggestion {
+			s.SuggestedK = int(percentile(g.usefulRanks, 0.9))
+		}
+		if len(g.usefulScores) >= minFeedbackForSuggestion {
+			s.SuggestedMinSimilarity = math.Floor(percentile(g.usefulScores, 0.1)*100) / 100
+		}
+		summaries = append(summaries, s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Tool != summaries[j].Tool {
+			return summaries[i].Tool < summaries[j].Tool
+		}
+		if summaries[i].Ratings != summaries[j].Ratings {
+			return summaries[i].Ratings > summaries[j].Ratings
+		}
+		return summaries[i].QueryType < summaries[j].QueryType
+	})
+	return summaries
+}
+
+// FormatFeedbackSummary renders aggregated feedback as a markdown table.
+func FormatFeedbackSummary(summaries []FeedbackSummary) string {
+	if len(summaries) == 0 {
+		return "No search feedback recorded yet. MCP clients report it with cie_search_feedback.\n"
+	}
+	var sb strings.Builder
+	sb.WriteString("| Tool | Query type | Ratings | Useful | Mean useful rank | Similarity useful / not | Suggested k | Suggested min_similarity |\n")
+	sb.WriteString("|---|---|---|---|---|---|---|---|\n")
+	for _, s := range summaries {
+		fmt.Fprintf(&sb, "| %s | %s | %d | %.0f%% | %s | %s / %s | %s | %s |\n",
+			s.Tool, s.QueryType, s.Ratings, s.UsefulRate*100,
+			formatOptional(s.MeanUsefulRank, "%.1f"),
+			formatOptional(s.MeanSimilarityUseful, "%.2f"), formatOptional(s.MeanSimilarityUnuseful, "%.2f"),
+			formatOptional(float64(s.SuggestedK), "%.0f"), formatOptional(s.SuggestedMinSimilarity, "%.2f"))
+	}
+	fmt.Fprintf(&sb, "\nSuggestions need %d useful ratings with a rank or similarity. A small gap between the useful and unhelpful similarity means the embedding score separates them poorly; prefer keyword or path filters for that query type.\n", minFeedbackForSuggestion)
+	return sb.String()
+}
+
+// formatOptional formats v, or "—" when it is zero (no data).
+func formatOptional(v float64, format string) string {
+	if v == 0 {
+		return "—"
+	}
+	return fmt.Sprintf(format, v)
+}
+
+// mean returns the arithmetic mean of values, or 0 when there are none.
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// percentile returns the nearest-rank p-th percentile (0-1) of values.
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(i, 0)]
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+func TestClassifyQuery(t *testing.T) {
+	tests := map[string]string{
+		"HandleLogin":                    QueryIdentifier,
+		"store.Save":                     QueryIdentifier,
+		"http.Client{ Timeout":           QueryCode,
+		"err != nil":                     QueryCode,
+		"where are routes registered":    QueryQuestion,
+		"retry logic for uploads?":       QueryQuestion,
+		"retry with exponential backoff": QueryNaturalLanguage,
+	}
+	for query, want := range tests {
+		if got := ClassifyQuery(query); got != want {
+			t.Errorf("ClassifyQuery(%q) = %s, want %s", query, got, want)
+		}
+	}
+}
+
+func TestSummarizeFeedback(t *testing.T) {
+	var entries []storage.FeedbackEntry
+	for i := 1; i <= 10; i++ {
+		entries = append(entries, storage.FeedbackEntry{
+			Tool: "cie_semantic_search", QueryType: QueryQuestion,
+			Rank: i, Similarity: 0.5 + float64(i)/100, Useful: true,
+		})
+	}
+	entries = append(entries,
+		storage.FeedbackEntry{Tool: "cie_semantic_search", QueryType: QueryQuestion, Rank: 2, Similarity: 0.4},
+		storage.FeedbackEntry{Tool: "cie_grep", QueryType: QueryIdentifier, Useful: true},
+	)
+
+	got := SummarizeFeedback(entries)
+	if len(got) != 2 || got[0].Tool != "cie_grep" {
+		t.Fatalf("summaries = %+v", got)
+	}
+	s := got[1]
+	if s.Ratings != 11 || s.Useful != 10 || s.MeanUsefulRank != 5.5 {
+		t.Errorf("counts = %+v", s)
+	}
+	if s.SuggestedK != 9 || s.SuggestedMinSimilarity != 0.51 {
+		t.Errorf("suggested k %d, min_similarity %.2f; want 9 and 0.51", s.SuggestedK, s.SuggestedMinSimilarity)
+	}
+	if got[0].SuggestedK != 0 {
+		t.Error("one rating must not suggest a k")
+	}
+
+	out := FormatFeedbackSummary(got)
+	if !strings.Contains(out, "| cie_semantic_search | question | 11 | 91% | 5.5 |") {
+		t.Errorf("table:\n%s", out)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// maxFileLinesPerMatch caps the matching lines shown for a single file.
+const maxFileLinesPerMatch = 10
+
+// fileSearch describes a search over stored file text (cie_file_content).
+type fileSearch struct {
+	Label          string         // pattern as shown to the user
+	CozoPattern    string         // regex evaluated by CozoDB on plain content
+	LineRegexp     *regexp.Regexp // regex applied to individual lines client-side
+	Path           string         // substring filter on file path (escaped)
+	FilePattern    string         // regex filter on file path
+	ExcludePattern string
+	Language       string // language stored on the file
+	ContextLines   int
+	Limit          int // max files
+}
+
+// searchFileContent searches the full text of indexed files, reaching code
+// outside functions (package-level declarations, comments) and non-code files.
+func searchFileContent(ctx context.Context, client Querier, fs fileSearch) (*ToolResult, error) {
+	if fs.Limit <= 0 {
+		fs.Limit = 20
+	}
+	compressed := isCodeCompressed(ctx, client)
+
+	var conditions []string
+	if !compressed {
+		conditions = append(conditions, fmt.Sprintf("regex_matches(content, %s)", QuoteCozoPattern(fs.CozoPattern)))
+	}
+	if fs.Path != "" {
+		conditions = append(conditions, fmt.Sprintf("regex_matches(path, %s)", QuoteCozoPattern(EscapeRegex(fs.Path))))
+	}
+	if fs.FilePattern != "" {
+		conditions = append(conditions, fmt.Sprintf("regex_matches(path, %s)", QuoteCozoPattern(fs.FilePattern)))
+	}
+	if fs.ExcludePattern != "" {
+		conditions = append(conditions, fmt.Sprintf("!regex_matches(path, %s)", QuoteCozoPattern(fs.ExcludePattern)))
+	}
+	if fs.Language != "" {
+		conditions = append(conditions, fmt.Sprintf("language == %q", normalizeLanguage(fs.Language)))
+	}
+	filter := ""
+	if len(conditions) > 0 {
+		filter = ", " + strings.Join(conditions, ", ")
+	}
+
+	// Compressed content can only be matched after decoding, so scan more rows.
+	limit := fs.Limit
+	if compressed {
+		limit = maxCompressedScanRows
+	}
+	script := fmt.Sprintf("?[path, content] := *cie_file { id, path, language }, *cie_file_content { file_id: id, content }%s :order path :limit %d", filter, limit)
+
+	result, err := client.Query(ctx, script)
+	if err != nil {
+		if strings.Contains(err.Error(), "cie_file_content") {
+			return NewError(fileTextMissingHint), nil
+		}
+		return nil, fmt.Errorf("file search query: %w", err)
+	}
+
+	var sb strings.Builder
+	files := 0
+	for _, row := range result.Rows {
+		if files >= fs.Limit {
+			break
+		}
+		path := AnyToString(row[0])
+		content, err := storage.DecompressCodeText(AnyToString(row[1]))
+		if err != nil {
+			continue
+		}
+		snippet, matches := matchFileLines(content, fs.LineRegexp, fs.ContextLines)
+		if matches == 0 {
+			continue
+		}
+		files++
+		fmt.Fprintf(&sb, "%d. `%s` (%d matching lines)\n```\n%s```\n\n", files, path, matches, snippet)
+	}
+
+	if files == 0 {
+		if len(result.Rows) == 0 && !hasFileText(ctx, client) {
+			return NewResult(fileTextMissingHint), nil
+		}
+		return NewResult(fmt.Sprintf("No file matches for `%s`\n", fs.Label)), nil
+	}
+	header := fmt.Sprintf("Found `%s` in %d files (full file text):\n\n", fs.Label, files)
+	return NewResult(header + sb.String()), nil
+}
+
+const fileTextMissingHint = "File text is not stored in this index.\n\n" +
+	"Set `indexing.store_file_text: true` in .cie/project.yaml and run `cie index --full` to enable file-scope search."
+
+// hasFileText reports whether any file text is stored.
+func hasFileText(ctx context.Context, client Querier) bool {
+	result, err := client.Query(ctx, "?[file_id] := *cie_file_content { file_id } :limit 1")
+	return err == nil && len(result.Rows) > 0
+}
+
+// matchFileLines returns numbered matching lines (with context) and the
+// number of matching lines. Line numbers are absolute within the file.
+func matchFileLines(content string, re *regexp.Regexp, contextLines int) (string, int) {
+	lines := strings.Split(content, "\n")
+	var hits []int
+	for i, line := range lines {
+		if re.MatchString(line) {
+			hits = append(hits, i)
+		}
+	}
+	if len(hits) == 0 {
+		return "", 0
+	}
+
+	var sb strings.Builder
+	shown := make(map[int]bool)
+	last := -1
+	for n, hit := range hits {
+		if n >= maxFileLinesPerMatch {
+			fmt.Fprintf(&sb, "  ... %d more matching lines\n", len(hits)-n)
+			break
+		}
+		start, end := max(0, hit-contextLines), min(len(lines)-1, hit+contextLines)
+		if last >= 0 && start > last+1 {
+			sb.WriteString("  ...\n")
+		}
+		for j := start; j <= end; j++ {
+			if shown[j] {
+				continue
+			}
+			shown[j] = true
+			last = j
+			prefix := "  "
+			if j == hit {
+				prefix = "> "
+			}
+			fmt.Fprintf(&sb, "%s%4d: %s\n", prefix, j+1, strings.TrimRight(lines[j], "\r"))
+		}
+	}
+	return sb.String(), len(hits)
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func fileContentClient(rows [][]any) *MockCIEClient {
+	return NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		switch {
+		case strings.Contains(script, "cie_project_meta"):
+			return NewMockQueryResult([]string{"value"}, nil), nil
+		case strings.Contains(script, "*cie_file {"):
+			return NewMockQueryResult([]string{"path", "content"}, rows), nil
+		case strings.Contains(script, "cie_file_content"):
+			return NewMockQueryResult([]string{"file_id"}, [][]any{{"file:1"}}), nil
+		}
+		return NewMockQueryResult(nil, nil), nil
+	}, nil)
+}
+
+func TestGrep_FileScope(t *testing.T) {
+	ctx := setupTest(t)
+	client := fileContentClient([][]any{
+		{"config.go", "package config\n\n// DefaultTimeout is used when unset.\nconst DefaultTimeout = 30\n"},
+	})
+
+	result, err := Grep(ctx, client, GrepArgs{Text: "defaulttimeout", Scope: "files", Limit: 10})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "`config.go` (2 matching lines)")
+	assertContains(t, result.Text, ">    4: const DefaultTimeout = 30")
+}
+
+func TestGrep_FileScopeMultiplePatterns(t *testing.T) {
+	ctx := setupTest(t)
+	client := fileContentClient([][]any{{"a.go", "var token = 1\nvar secret = 2\n"}})
+
+	result, err := Grep(ctx, client, GrepArgs{Texts: []string{"token", "secret"}, Scope: "files", Limit: 10})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "Found `token`")
+	assertContains(t, result.Text, "Found `secret`")
+}
+
+func TestSearchText_Files(t *testing.T) {
+	ctx := setupTest(t)
+	var script string
+	client := NewMockClientCustom(func(ctx context.Context, s string) (*QueryResult, error) {
+		if strings.Contains(s, "*cie_file {") {
+			script = s
+			return NewMockQueryResult([]string{"path", "content"}, [][]any{{"README.md", "# Title\n\nTODO: write docs\n"}}), nil
+		}
+		return NewMockQueryResult(nil, nil), nil
+	}, nil)
+
+	result, err := SearchText(ctx, client, SearchTextArgs{Pattern: "TODO:.*docs", SearchIn: "files", FilePattern: `\.md$`})
+	assertNoError(t, err)
+	assertContains(t, result.Text, ">    3: TODO: write docs")
+	assertContains(t, script, "cie_file_content")
+	assertContains(t, script, `regex_matches(path, ___"\.md$"___)`)
+}
+
+func TestSearchFileContent_NoStoredText(t *testing.T) {
+	ctx := setupTest(t)
+	client := NewMockClientWithResults([]string{"path", "content"}, nil)
+
+	result, err := Grep(ctx, client, GrepArgs{Text: "x", Scope: "files"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "store_file_text")
+}
+
+func TestMatchFileLines(t *testing.T) {
+	content := "a\nb\nmatch one\nc\nd\ne\nf\nmatch two\ng"
+	snippet, n := matchFileLines(content, regexp.MustCompile("match"), 1)
+	if n != 2 {
+		t.Fatalf("matches = %d, want 2", n)
+	}
+	for _, want := range []string{"     2: b", ">    3: match one", "  ...", ">    8: match two", "     9: g"} {
+		assertContains(t, snippet, want)
+	}
+	if strings.Contains(snippet, "5: d") {
+		t.Errorf("line outside context shown:\n%s", snippet)
+	}
+}
@@ -31,18 +31,29 @@ type FindTypeArgs struct {
 	Kind        string // Filter by kind: "any", "struct", "interface", "class", "type_alias"
 	PathPattern string // Optional file path filter
 	Limit       int    // Max results (default 20)
+	Fuzzy       bool   // Rank all names by fuzzy/camelCase similarity
 }
 
 // FindType searches for types/interfaces/classes/structs by name.
 // Works across all languages (Go structs/interfaces, Python classes, TypeScript interfaces/classes).
 func FindType(ctx context.Context, client Querier, args FindTypeArgs) (*ToolResult, error) {
 	if args.Name == "" {
-		return NewError("Error: 'name' is required"), nil
+		return NewInputError("Error: 'name' is required"), nil
 	}
 
 	if args.Limit <= 0 {
 		args.Limit = 20
 	}
+	if args.Fuzzy {
+		matches, locations, err := fuzzyLookup(ctx, client, "cie_type", args.Name, args.Limit)
+		if err != nil {
+			return NewError(fmt.Sprintf("Query failed: %v", err)), nil
+		}
+		if len(matches) == 0 {
+			return NewResult(fmt.Sprintf("No type names resemble '%s'", args.Name)), nil
+		}
+		return NewResult(fmt.Sprintf("### Types fuzzy-matching '%s'\n\n%s", args.Name, formatFuzzyMatches(matches, locations))), nil
+	}
 
 	// Build query conditions
 	var conditions []string
@@ -81,6 +92,10 @@ func FindType(ctx context.Context, client Querier, args FindTypeArgs) (*ToolResu
 	}
 
 	if len(result.Rows) == 0 {
+		if matches, locations, err := fuzzyLookup(ctx, client, "cie_type", args.Name, 10); err == nil && len(matches) > 0 {
+			return NewResult(fmt.Sprintf("No types found matching '%s'\n\n**Similar type names:**\n%s",
+				args.Name, formatFuzzyMatches(matches, locations))), nil
+		}
 		return NewResult(fmt.Sprintf("No types found matching '%s'\n\n"+
 			"### Tips:\n"+
 			"- Use **cie_semantic_search** for concept-based search\n"+
@@ -117,33 +132,26 @@ type TypeInfo struct {
 	CodeText  string
 }
 
+// GetTypeCodeArgs holds arguments for retrieving a type's code.
+type GetTypeCodeArgs struct {
+	Name           string
+	FilePath       string // optional: exact file of the type
+	IncludeMethods bool   // append the code of every method of the type
+	Outline        bool   // fields and method signatures instead of code
+}
+
+// maxTypeCodeMethods caps the methods whose code GetTypeCode appends.
+const maxTypeCodeMethods = 30
+
 // GetTypeCode retrieves the code of a specific type.
 // Schema v3: code_text is in cie_type_code table
-func GetTypeCode(ctx context.Context, client Querier, name, filePath string) (*ToolResult, error) {
+func GetTypeCode(ctx context.Context, client Querier, args GetTypeCodeArgs) (*ToolResult, error) {
+	name := args.Name
 	if name == "" {
-		return NewError("Error: 'name' is required"), nil
-	}
-
-	// Build query - use exact match with ==
-	// Schema v3: Join with cie_type_code for code_text
-	var query string
-	if filePath != "" {
-		query = fmt.Sprintf(
-			"?[name, kind, file_path, code_text, start_line, end_line] := "+
-				"*cie_type { id, name, kind, file_path, start_line, end_line }, "+
-				"*cie_type_code { type_id: id, code_text }, "+
-				"name == %q, file_path == %q :limit 1",
-			name, filePath)
-	} else {
-		query = fmt.Sprintf(
-			"?[name, kind, file_path, code_text, start_line, end_line] := "+
-				"*cie_type { id, name, kind, file_path, start_line, end_line }, "+
-				"*cie_type_code { type_id: id, code_text }, "+
-				"name == %q :limit 1",
-			name)
+		return NewInputError("Error: 'name' is required"), nil
 	}
 
-	result, err := client.Query(ctx, query)
+	result, err := client.Query(ctx, typeLookupScript(name, args.FilePath, true))
 	if err != nil {
 		return NewError(fmt.Sprintf("Query failed: %v", err)), nil
 	}
@@ -153,12 +161,17 @@ func GetTypeCode(ctx context.Context, client Querier, name, filePath string) (*T
 	}
 
 	row := result.Rows[0]
-	typeName := AnyToString(row[0])
-	kind := AnyToString(row[1])
-	path := AnyToString(row[2])
-	codeText := AnyToString(row[3])
+	typeID := AnyToString(row[0])
+	typeName := AnyToString(row[1])
+	kind := AnyToString(row[2])
+	path := AnyToString(row[3])
 	startLine := AnyToString(row[4])
 	endLine := AnyToString(row[5])
+	codeText := AnyToString(row[6])
+
+	if args.Outline {
+		return NewResult(formatTypeOutline(ctx, client, typeID, typeName, kind, path, startLine, endLine, codeText)), nil
+	}
 
 	// Determine language for syntax highlighting
 	lang := detectLanguage(path)
@@ -167,9 +180,31 @@ func GetTypeCode(ctx context.Context, client Querier, name, filePath string) (*T
 	output += fmt.Sprintf("**File:** %s:%s-%s\n\n", path, startLine, endLine)
 	output += fmt.Sprintf("```%s\n%s\n```\n", lang, codeText)
 
+	if args.IncludeMethods {
+		output += formatTypeMethodsCode(ctx, client, typeID, typeName, path)
+	}
+
 	return NewResult(output), nil
 }
 
+// formatTypeMethodsCode renders the code of a type's methods for GetTypeCode.
+func formatTypeMethodsCode(ctx context.Context, client Querier, typeID, typeName, typeFile string) string {
+	methods, err := typeMethods(ctx, client, typeID, typeName, typeFile, true)
+	if err != nil || len(methods) == 0 {
+		return "\nNo methods indexed for this type.\n"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\n#### Methods (%d)\n", len(methods))
+	for i, m := range methods {
+		if i == maxTypeCodeMethods {
+			fmt.Fprintf(&sb, "\n⚠️ %d more methods not shown. Use `cie_type_api` for the full list.\n", len(methods)-maxTypeCodeMethods)
+			break
+		}
+		fmt.Fprintf(&sb, "\n**%s** — %s:%s\n```%s\n%s\n```\n", m.Name, m.FilePath, m.StartLine, detectLanguage(m.FilePath), m.Code)
+	}
+	return sb.String()
+}
+
 // detectLanguage detects the programming language from file extension.
 // Uses strings.HasSuffix for efficiency (no regex compilation).
 func detectLanguage(filePath string) string {
@@ -177,7 +212,7 @@ func detectLanguage(filePath string) string {
 	switch {
 	case strings.HasSuffix(filePath, ".go"):
 		return "go"
-	case strings.HasSuffix(filePath, ".py"):
+	case strings.HasSuffix(filePath, ".py"), strings.HasSuffix(filePath, ".pyi"):
 		return "python"
 	case strings.HasSuffix(filePath, ".ts"), strings.HasSuffix(filePath, ".tsx"):
 		return "typescript"
@@ -187,6 +222,8 @@ func detectLanguage(filePath string) string {
 		return "rust"
 	case strings.HasSuffix(filePath, ".java"):
 		return "java"
+	case strings.HasSuffix(filePath, ".cs"):
+		return "csharp"
 	default:
 		return "unknown"
 	}
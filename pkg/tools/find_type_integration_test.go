@@ -214,7 +214,7 @@ func (s *UserService) GetUser(id string) (*User, error) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := GetTypeCode(ctx, client, tt.typeName, tt.filePath)
+			result, err := GetTypeCode(ctx, client, GetTypeCodeArgs{Name: tt.typeName, FilePath: tt.filePath})
 			if err != nil {
 				t.Fatalf("GetTypeCode() error = %v", err)
 			}
@@ -0,0 +1,122 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Freshness describes how far the working tree has moved since the index
+// was built, so agents can tell when an answer may be outdated.
+type Freshness struct {
+	IndexedCommit string `json:"indexed_commit"`
+	HeadCommit    string `json:"head_commit"`
+	// CommitsBehind is how many commits HEAD is ahead of the indexed commit,
+	// or -1 when git cannot relate the two (e.g. after a history rewrite).
+	CommitsBehind int `json:"commits_behind"`
+	// ChangedFiles counts files that differ from the indexed commit,
+	// including uncommitted and untracked ones.
+	ChangedFiles int `json:"changed_files"`
+	// Uncommitted counts files with staged, unstaged or untracked changes.
+	Uncommitted int `json:"uncommitted_files"`
+}
+
+// Stale reports whether the index may not reflect the working tree.
+func (f *Freshness) Stale() bool {
+	return f.CommitsBehind != 0 || f.ChangedFiles > 0
+}
+
+// Header returns a one-line warning for stale indexes, or "" when fresh.
+func (f *Freshness) Header() string {
+	if !f.Stale() {
+		return ""
+	}
+	var parts []string
+	switch {
+	case f.CommitsBehind > 0:
+		parts = append(parts, fmt.Sprintf("HEAD is %d commit(s) past the indexed commit %s", f.CommitsBehind, ShortCommit(f.IndexedCommit)))
+	case f.CommitsBehind < 0:
+		parts = append(parts, fmt.Sprintf("HEAD %s is not a descendant of the indexed commit %s", ShortCommit(f.HeadCommit), ShortCommit(f.IndexedCommit)))
+	}
+	if f.ChangedFiles > 0 {
+		parts = append(parts, fmt.Sprintf("%d file(s) changed since indexing (%d uncommitted)", f.ChangedFiles, f.Uncommitted))
+	}
+	return "⚠️ _Index may be stale: " + strings.Join(parts, "; ") + ". Run `cie index` to refresh._"
+}
+
+// CheckFreshness compares the commit recorded by the last index run with the
+// repository's HEAD and working tree. It returns nil when the index has no
+// recorded commit, since there is then nothing to compare against.
+func CheckFreshness(ctx context.Context, client Querier, git GitRunner) (*Freshness, error) {
+	indexed := IndexedCommit(ctx, client)
+	if indexed == "" {
+		return nil, nil
+	}
+	head, err := git.Run(ctx, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	f := &Freshness{IndexedCommit: indexed, HeadCommit: strings.TrimSpace(head)}
+
+	changed := map[string]bool{}
+	if f.HeadCommit != indexed {
+		f.CommitsBehind = -1
+		if out, err := git.Run(ctx, "rev-list", "--count", indexed+"..HEAD"); err == nil {
+			if n, err := strconv.Atoi(strings.TrimSpace(out)); err == nil {
+				f.CommitsBehind = n
+			}
+		}
+	}
+	// Diffing the working tree against the indexed commit covers committed,
+	// staged and unstaged changes in one call; untracked files come from
+	// git status below.
+	if out, err := git.Run(ctx, "diff", "--name-only", indexed); err == nil {
+		for _, name := range strings.Split(out, "\n") {
+			if name = strings.TrimSpace(name); name != "" {
+				changed[name] = true
+			}
+		}
+	}
+	status, err := git.Run(ctx, "status", "--porcelain", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(status, "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		f.Uncommitted++
+		if strings.HasPrefix(line, "??") {
+			changed[line[3:]] = true
+		}
+	}
+	f.ChangedFiles = len(changed)
+	return f, nil
+}
+
+// ShortCommit abbreviates a git SHA for display.
+func ShortCommit(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func freshnessGit(head, revList, diff, status string) *MockGitRunner {
+	git := newMockGitRunner("/repo")
+	git.RunFunc = func(_ context.Context, args ...string) (string, error) {
+		switch args[0] {
+		case "rev-parse":
+			return head + "\n", nil
+		case "rev-list":
+			if revList == "" {
+				return "", errors.New("bad revision")
+			}
+			return revList + "\n", nil
+		case "diff":
+			return diff, nil
+		case "status":
+			return status, nil
+		}
+		return "", nil
+	}
+	return git
+}
+
+func indexedCommitClient(sha string) *MockCIEClient {
+	return NewMockClientWithResults([]string{"value"}, [][]any{{sha}})
+}
+
+func TestCheckFreshness(t *testing.T) {
+	ctx := setupTest(t)
+
+	t.Run("fresh", func(t *testing.T) {
+		f, err := CheckFreshness(ctx, indexedCommitClient("aaaaaaa1"), freshnessGit("aaaaaaa1", "", "", ""))
+		assertNoError(t, err)
+		if f.Stale() || f.Header() != "" {
+			t.Errorf("expected fresh index, got %+v", f)
+		}
+	})
+
+	t.Run("behind with local edits", func(t *testing.T) {
+		diff := "pkg/a.go\npkg/b.go\n"
+		status := " M pkg/b.go\n?? pkg/new.go\n"
+		f, err := CheckFreshness(ctx, indexedCommitClient("aaaaaaa1"), freshnessGit("bbbbbbb2", "3", diff, status))
+		assertNoError(t, err)
+		if f.CommitsBehind != 3 || f.ChangedFiles != 3 || f.Uncommitted != 2 {
+			t.Errorf("freshness = %+v", f)
+		}
+		header := f.Header()
+		for _, want := range []string{"3 commit(s) past the indexed commit aaaaaaa", "3 file(s) changed", "2 uncommitted", "cie index"} {
+			if !strings.Contains(header, want) {
+				t.Errorf("header %q missing %q", header, want)
+			}
+		}
+	})
+
+	t.Run("diverged", func(t *testing.T) {
+		f, err := CheckFreshness(ctx, indexedCommitClient("aaaaaaa1"), freshnessGit("ccccccc3", "", "", ""))
+		assertNoError(t, err)
+		if f.CommitsBehind != -1 || !strings.Contains(f.Header(), "not a descendant") {
+			t.Errorf("freshness = %+v, header %q", f, f.Header())
+		}
+	})
+
+	t.Run("no indexed commit", func(t *testing.T) {
+		f, err := CheckFreshness(ctx, NewMockClientEmpty(), freshnessGit("aaaaaaa1", "", "", ""))
+		assertNoError(t, err)
+		if f != nil {
+			t.Errorf("expected nil without an indexed commit, got %+v", f)
+		}
+	})
+}
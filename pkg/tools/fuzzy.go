@@ -0,0 +1,272 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxFuzzyCandidates bounds how many distinct names are pulled client-side
+// for fuzzy ranking.
+const maxFuzzyCandidates = 50000
+
+// minFuzzyScore is the lowest score reported as a suggestion.
+const minFuzzyScore = 0.3
+
+// fuzzyMatch is a name ranked against a fuzzy query.
+type fuzzyMatch struct {
+	Name   string
+	Score  float64
+	Reason string // "exact", "tokens", "subsequence" or "typo"
+}
+
+// SplitIdentifier breaks an identifier into lowercase words at camelCase,
+// digit, underscore, dash, dot and space boundaries:
+// "HandleUserRequest" -> [handle user request], "parse_HTTPHeader" -> [parse http header].
+func SplitIdentifier(s string) []string {
+	var words []string
+	var cur []rune
+	flush := func() {
+		if len(cur) > 0 {
+			words = append(words, strings.ToLower(string(cur)))
+			cur = cur[:0]
+		}
+	}
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if i > 0 && len(cur) > 0 {
+			prev := runes[i-1]
+			lowerToUpper := unicode.IsLower(prev) && unicode.IsUpper(r)
+			acronymEnd := unicode.IsUpper(prev) && unicode.IsUpper(r) &&
+				i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			digitEdge := unicode.IsDigit(prev) != unicode.IsDigit(r)
+			if lowerToUpper || acronymEnd || digitEdge {
+				flush()
+			}
+		}
+		cur = append(cur, r)
+	}
+	flush()
+	return words
+}
+
+// fuzzyScore rates how well candidate matches query, from 0 (no match) to 1
+// (case-insensitive exact). The receiver part of a method ("Type.") is
+// ignored when it helps, so "handle" can find "Server.Handle".
+func fuzzyScore(query, candidate string) (float64, string) {
+	best, reason := scoreName(query, candidate)
+	if i := strings.LastIndex(candidate, "."); i >= 0 && i+1 < len(candidate) {
+		if s, r := scoreName(query, candidate[i+1:]); s > best {
+			best, reason = s, r
+		}
+	}
+	return best, reason
+}
+
+func scoreName(query, candidate string) (float64, string) {
+	q := strings.ToLower(strings.TrimSpace(query))
+	c := strings.ToLower(candidate)
+	if q == "" || c == "" {
+		return 0, ""
+	}
+	if q == c {
+		return 1, "exact"
+	}
+
+	// Every query word is a prefix of a distinct candidate word.
+	if qWords := SplitIdentifier(query); len(qWords) > 0 {
+		if matched, ok := matchWordPrefixes(qWords, SplitIdentifier(candidate)); ok {
+			return 0.7 + 0.25*float64(matched)/float64(len(c)), "tokens"
+		}
+	}
+
+	// Query letters appear in order in the candidate.
+	compact := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '_' || r == '-' || r == '.' {
+			return -1
+		}
+		return r
+	}, q)
+	if span, ok := subsequenceSpan(compact, c); ok {
+		return 0.4 + 0.3*float64(len(compact))/float64(span), "subsequence"
+	}
+
+	// Small typos: edit distance within a quarter of the query length.
+	maxDist := len(compact) / 4
+	if maxDist < 1 {
+		maxDist = 1
+	}
+	if d := levenshtein(compact, c); d <= maxDist {
+		return 0.6 - 0.1*float64(d), "typo"
+	}
+	return 0, ""
+}
+
+// matchWordPrefixes reports whether each query word shares a prefix with a
+// different candidate word (either one may be the longer: "handler" matches
+// "Handle"), returning the number of matched characters.
+func matchWordPrefixes(qWords, cWords []string) (int, bool) {
+	used := make([]bool, len(cWords))
+	matched := 0
+	for _, qw := range qWords {
+		found := false
+		for i, cw := range cWords {
+			if used[i] {
+				continue
+			}
+			if strings.HasPrefix(cw, qw) || (len(cw) >= 3 && strings.HasPrefix(qw, cw)) {
+				used[i], found = true, true
+				matched += min(len(qw), len(cw))
+				break
+			}
+		}
+		if !found {
+			return 0, false
+		}
+	}
+	return matched, true
+}
+
+// subsequenceSpan greedily finds q as a subsequence of c and returns the
+// length of the window it spans.
+func subsequenceSpan(q, c string) (int, bool) {
+	if q == "" {
+		return 0, false
+	}
+	qi, start := 0, -1
+	for i := 0; i < len(c) && qi < len(q); i++ {
+		if c[i] == q[qi] {
+			if start < 0 {
+				start = i
+			}
+			qi++
+			if qi == len(q) {
+				return i - start + 1, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// levenshtein returns the edit distance between a and b (bytes).
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// rankFuzzy scores names against query and returns the best matches,
+// highest score first (ties broken by shorter, then alphabetical name).
+func rankFuzzy(query string, names []string, limit int) []fuzzyMatch {
+	var matches []fuzzyMatch
+	for _, name := range names {
+		if score, reason := fuzzyScore(query, name); score >= minFuzzyScore {
+			matches = append(matches, fuzzyMatch{Name: name, Score: score, Reason: reason})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		if len(matches[i].Name) != len(matches[j].Name) {
+			return len(matches[i].Name) < len(matches[j].Name)
+		}
+		return matches[i].Name < matches[j].Name
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// fuzzyLookup ranks the distinct names of a relation ("cie_function" or
+// "cie_type") against query and returns the top matches together with one
+// location each. Rows are [name, file_path, start_line].
+func fuzzyLookup(ctx context.Context, client Querier, relation, query string, limit int) ([]fuzzyMatch, map[string][]any, error) {
+	namesScript := fmt.Sprintf("?[name] := *%s { name } :limit %d", relation, maxFuzzyCandidates)
+	result, err := client.Query(ctx, namesScript)
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make([]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		if len(row) > 0 {
+			names = append(names, AnyToString(row[0]))
+		}
+	}
+	matches := rankFuzzy(query, names, limit)
+	if len(matches) == 0 {
+		return nil, nil, nil
+	}
+
+	quoted := make([]string, len(matches))
+	for i, m := range matches {
+		quoted[i] = fmt.Sprintf("%q", m.Name)
+	}
+	locScript := fmt.Sprintf("?[name, file_path, start_line] := *%s { name, file_path, start_line }, is_in(name, [%s])",
+		relation, strings.Join(quoted, ", "))
+	locations := make(map[string][]any)
+	if locResult, err := client.Query(ctx, locScript); err == nil {
+		for _, row := range locResult.Rows {
+			if len(row) < 3 {
+				continue
+			}
+			name := AnyToString(row[0])
+			if _, seen := locations[name]; !seen {
+				locations[name] = row
+			}
+		}
+	}
+	return matches, locations, nil
+}
+
+// formatFuzzyMatches renders ranked suggestions as a markdown list.
+func formatFuzzyMatches(matches []fuzzyMatch, locations map[string][]any) string {
+	var sb strings.Builder
+	for i, m := range matches {
+		fmt.Fprintf(&sb, "%d. **%s** (%.0f%%, %s)", i+1, m.Name, m.Score*100, m.Reason)
+		if loc, ok := locations[m.Name]; ok {
+			fmt.Fprintf(&sb, " — %s:%s", AnyToString(loc[1]), AnyToString(loc[2]))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
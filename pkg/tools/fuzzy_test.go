@@ -0,0 +1,122 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitIdentifier(t *testing.T) {
+	tests := map[string][]string{
+		"HandleUserRequest": {"handle", "user", "request"},
+		"parse_HTTPHeader":  {"parse", "http", "header"},
+		"Server.Handle":     {"server", "handle"},
+		"user handler":      {"user", "handler"},
+		"v2Client":          {"v", "2", "client"},
+	}
+	for in, want := range tests {
+		if got := SplitIdentifier(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("SplitIdentifier(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		query, candidate string
+		wantReason       string
+	}{
+		{"handleuserrequest", "HandleUserRequest", "exact"},
+		{"handle", "Server.Handle", "exact"},
+		{"user handler", "HandleUserRequest", "tokens"},
+		{"hndlusr", "HandleUserRequest", "subsequence"},
+		{"NewBatchr", "NewBatcher", "subsequence"},
+		{"NewBacther", "NewBatcher", "typo"},
+		{"zebra", "HandleUserRequest", ""},
+	}
+	for _, tt := range tests {
+		_, reason := fuzzyScore(tt.query, tt.candidate)
+		if reason != tt.wantReason {
+			t.Errorf("fuzzyScore(%q, %q) reason = %q, want %q", tt.query, tt.candidate, reason, tt.wantReason)
+		}
+	}
+}
+
+func TestRankFuzzy(t *testing.T) {
+	names := []string{"HandleUserRequest", "HandleUser", "UserHandlerFactory", "ParseConfig"}
+	got := rankFuzzy("user handler", names, 2)
+	if len(got) != 2 {
+		t.Fatalf("got %d matches, want 2: %+v", len(got), got)
+	}
+	if got[0].Name != "HandleUser" {
+		t.Errorf("best match = %s, want HandleUser (fewest extra characters)", got[0].Name)
+	}
+	for _, m := range got {
+		if m.Name == "ParseConfig" {
+			t.Error("ParseConfig should not match")
+		}
+	}
+}
+
+func TestFindFunction_Fuzzy(t *testing.T) {
+	client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		switch {
+		case strings.Contains(script, "is_in(name"):
+			return NewMockQueryResult([]string{"name", "file_path", "start_line"},
+				[][]any{{"HandleUserRequest", "internal/http/user.go", float64(42)}}), nil
+		case strings.HasPrefix(script, "?[name] := *cie_function"):
+			return NewMockQueryResult([]string{"name"},
+				[][]any{{"HandleUserRequest"}, {"ParseConfig"}}), nil
+		}
+		return NewMockQueryResult(nil, nil), nil
+	}, nil)
+	ctx := setupTest(t)
+
+	result, err := FindFunction(ctx, client, FindFunctionArgs{Name: "user handler", Fuzzy: true})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "HandleUserRequest")
+	assertContains(t, result.Text, "internal/http/user.go:42")
+	if strings.Contains(result.Text, "ParseConfig") {
+		t.Errorf("unrelated name suggested:\n%s", result.Text)
+	}
+
+	// A regular lookup that misses falls back to suggestions.
+	result, err = FindFunction(ctx, client, FindFunctionArgs{Name: "HandleUsrRequest"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "Similar function names")
+	assertContains(t, result.Text, "HandleUserRequest")
+}
+
+func TestFindType_FuzzySuggestions(t *testing.T) {
+	client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		if strings.HasPrefix(script, "?[name] := *cie_type") {
+			return NewMockQueryResult([]string{"name"}, [][]any{{"UserService"}}), nil
+		}
+		return NewMockQueryResult(nil, nil), nil
+	}, nil)
+	ctx := setupTest(t)
+
+	result, err := FindType(ctx, client, FindTypeArgs{Name: "UsrService"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "Similar type names")
+	assertContains(t, result.Text, "UserService")
+}
@@ -37,7 +37,7 @@ type FunctionHistoryArgs struct {
 // It uses git log -L to track line-based history.
 func FunctionHistory(ctx context.Context, client Querier, git GitRunner, args FunctionHistoryArgs) (*ToolResult, error) {
 	if args.FunctionName == "" {
-		return NewError("Error: 'function_name' is required"), nil
+		return NewInputError("Error: 'function_name' is required"), nil
 	}
 	if args.Limit <= 0 {
 		args.Limit = 10
@@ -155,7 +155,7 @@ type FindIntroductionArgs struct {
 // Uses git log -S (pickaxe) to find when the pattern was first added.
 func FindIntroduction(ctx context.Context, client Querier, git GitRunner, args FindIntroductionArgs) (*ToolResult, error) {
 	if args.CodeSnippet == "" {
-		return NewError("Error: 'code_snippet' is required"), nil
+		return NewInputError("Error: 'code_snippet' is required"), nil
 	}
 
 	// Build git log -S command
@@ -236,7 +236,7 @@ type BlameAuthor struct {
 // BlameFunction provides aggregated blame analysis showing who owns what percentage of a function.
 func BlameFunction(ctx context.Context, client Querier, git GitRunner, args BlameFunctionArgs) (*ToolResult, error) {
 	if args.FunctionName == "" {
-		return NewError("Error: 'function_name' is required"), nil
+		return NewInputError("Error: 'function_name' is required"), nil
 	}
 
 	// Find function location
@@ -34,6 +34,17 @@ type GrepArgs struct {
 	CaseSensitive  bool
 	ContextLines   int
 	Limit          int
+	Scope          string // "functions" (default) or "files" for full file text
+	GroupBy        string // "", "file", "package" or "function"
+	Language       string // Only functions (or files) of this language, e.g. "go", "ts"
+	Dialect        string // Only functions with this framework hint, e.g. "express handler"
+
+	// Live, when set, adds matches from files not indexed yet to
+	// single-pattern function searches. Indexed matches in files it serves
+	// are dropped in favor of the on-disk version.
+	Live LiveSource
+
+	shadowed map[string]bool // paths whose indexed matches are superseded by Live
 }
 
 // GrepMultiResult holds results grouped by pattern
@@ -47,27 +58,62 @@ type GrepMultiResult struct {
 type GrepMatch struct {
 	FilePath  string
 	Name      string
-	StartLine string
-	Context   string
+	StartLine string // first line of the function
+	Line      int    // file line of the first match, 0 if unknown
+	Context   string // text of that line
 }
 
 // Grep performs ultra-fast literal text search with optional context
 // Schema v3: code_text is in separate cie_function_code table
 // Supports multiple patterns via 'texts' parameter for batch searches
 func Grep(ctx context.Context, client Querier, args GrepArgs) (*ToolResult, error) {
+	if args.Scope == "files" {
+		return grepFiles(ctx, client, args)
+	}
 	if len(args.Texts) > 0 {
 		return grepMulti(ctx, client, args)
 	}
 	if args.Text == "" {
-		return NewError("Error: 'text' or 'texts' is required"), nil
+		return NewInputError("Error: 'text' or 'texts' is required"), nil
 	}
 
+	if !validGroupBy(args.GroupBy) {
+		return NewInputError(fmt.Sprintf("Error: invalid group_by %q (use file, package or function)", args.GroupBy)), nil
+	}
+	if args.GroupBy != "" {
+		return grepGrouped(ctx, client, args)
+	}
+
+	// Unindexed files carry no language, so a language filter skips them.
+	if args.Language != "" || args.Dialect != "" {
+		args.Live = nil
+	}
+	if args.Live != nil {
+		args.shadowed = livePaths(ctx, args.Live)
+	}
+	result, err := grepFunctions(ctx, client, args)
+	if err != nil || args.Live == nil {
+		return result, err
+	}
+	if section := liveGrepSection(ctx, args.Live, args); section != "" {
+		result.Text += section
+	}
+	return result, nil
+}
+
+// grepFunctions runs a single-pattern search over indexed function bodies.
+func grepFunctions(ctx context.Context, client Querier, args GrepArgs) (*ToolResult, error) {
 	needsCode := args.ContextLines > 0
+	if isCodeCompressed(ctx, client) {
+		return grepCompressed(ctx, client, args, needsCode)
+	}
+
 	script := buildGrepQuery(args, needsCode)
 	result, err := client.Query(ctx, script)
 	if err != nil {
 		return nil, fmt.Errorf("grep query: %w", err)
 	}
+	result.Rows = grepOverflow(ctx, client, args, needsCode, result.Rows)
 
 	if len(result.Rows) == 0 {
 		return NewResult(formatGrepNoResults(ctx, client, args)), nil
@@ -76,29 +122,213 @@ func Grep(ctx context.Context, client Querier, args GrepArgs) (*ToolResult, erro
 	return NewResult(formatGrepResults(result.Rows, args, needsCode)), nil
 }
 
-func buildGrepQuery(args GrepArgs, needsCode bool) string {
-	pattern := EscapeRegex(args.Text)
-	if !args.CaseSensitive {
-		pattern = "(?i)" + pattern
+// dropShadowed removes rows (file_path first) for files in shadowed. Only
+// use it on results of queries without :limit; limited queries exclude the
+// files with shadowCondition instead, so the limit counts kept rows.
+func dropShadowed(rows [][]any, shadowed map[string]bool) [][]any {
+	if len(shadowed) == 0 {
+		return rows
+	}
+	kept := rows[:0]
+	for _, row := range rows {
+		if len(row) > 0 && shadowed[AnyToString(row[0])] {
+			continue
+		}
+		kept = append(kept, row)
+	}
+	return kept
+}
+
+// grepGrouped counts matching lines across a large sample of functions and
+// reports them per file, package or function. args.Limit caps the number of
+// groups rather than the number of matches.
+func grepGrouped(ctx context.Context, client Querier, args GrepArgs) (*ToolResult, error) {
+	maxGroups := args.Limit
+	if maxGroups <= 0 {
+		maxGroups = 30
+	}
+	args.Limit = maxGroupedRows
+
+	var rows [][]any
+	if isCodeCompressed(ctx, client) {
+		err := scanDecodedCode(ctx, client, args.Path, args.ExcludePattern, languageCondition("id", args.Language, args.Dialect), func(row []any) bool {
+			if matchesGrepPattern(AnyToString(row[4]), args.Text, args.CaseSensitive) {
+				rows = append(rows, row)
+			}
+			return len(rows) < maxGroupedRows
+		})
+		if err != nil {
+			return nil, fmt.Errorf("grep query: %w", err)
+		}
+	} else {
+		result, err := client.Query(ctx, buildGrepQuery(args, true))
+		if err != nil {
+			return nil, fmt.Errorf("grep query: %w", err)
+		}
+		rows = grepOverflow(ctx, client, args, true, result.Rows)
+	}
+
+	var hits []searchHit
+	for _, row := range rows {
+		if len(row) < 5 || len(hits) >= maxGroupedRows {
+			continue
+		}
+		file, name := AnyToString(row[0]), AnyToString(row[1])
+		start := int(toFloat64(row[2]))
+		for i, line := range strings.Split(AnyToString(row[4]), "\n") {
+			if matchesGrepPattern(line, args.Text, args.CaseSensitive) {
+				hits = append(hits, searchHit{File: file, Function: name, Line: start + i, Preview: strings.TrimSpace(line)})
+			}
+		}
+	}
+
+	if len(hits) == 0 {
+		return NewResult(formatGrepNoResults(ctx, client, args)), nil
+	}
+	header := fmt.Sprintf("Found `%s`: ", args.Text)
+	return NewResult(header + formatGroupedHits(hits, args.GroupBy, maxGroups)), nil
+}
+
+// grepCompressed runs the literal search client-side for indexes that store
+// code_text compressed, where CozoDB cannot see the plain source.
+func grepCompressed(ctx context.Context, client Querier, args GrepArgs, needsCode bool) (*ToolResult, error) {
+	var matched [][]any
+	err := scanDecodedCode(ctx, client, args.Path, args.ExcludePattern, languageCondition("id", args.Language, args.Dialect), func(row []any) bool {
+		if !matchesGrepPattern(AnyToString(row[4]), args.Text, args.CaseSensitive) || args.shadowed[AnyToString(row[0])] {
+			return true
+		}
+		if !needsCode {
+			row = row[:4]
+		}
+		matched = append(matched, row)
+		return len(matched) < args.Limit
+	})
+	if err != nil {
+		return nil, fmt.Errorf("grep query: %w", err)
+	}
+
+	if len(matched) == 0 {
+		output := fmt.Sprintf("No matches found for: `%s`\n", args.Text)
+		if args.Path != "" {
+			output += fmt.Sprintf("In path: `%s`\n", args.Path)
+		}
+		return NewResult(output), nil
 	}
+	return NewResult(formatGrepResults(matched, args, needsCode)), nil
+}
 
+// grepFiles runs the literal search over stored file text instead of
+// function bodies. Multiple patterns are searched one after another.
+func grepFiles(ctx context.Context, client Querier, args GrepArgs) (*ToolResult, error) {
+	texts := args.Texts
+	if len(texts) == 0 && args.Text != "" {
+		texts = []string{args.Text}
+	}
+	if len(texts) == 0 {
+		return NewInputError("Error: 'text' or 'texts' is required"), nil
+	}
+	if args.Dialect != "" {
+		return NewInputError("Error: 'dialect' applies to functions and cannot be used with scope=\"files\""), nil
+	}
+
+	var sb strings.Builder
+	for i, text := range texts {
+		pattern, linePattern := EscapeRegex(text), regexp.QuoteMeta(text)
+		if !args.CaseSensitive {
+			pattern, linePattern = "(?i)"+pattern, "(?i)"+linePattern
+		}
+		result, err := searchFileContent(ctx, client, fileSearch{
+			Label:          text,
+			CozoPattern:    pattern,
+			LineRegexp:     regexp.MustCompile(linePattern),
+			Path:           args.Path,
+			ExcludePattern: args.ExcludePattern,
+			Language:       args.Language,
+			ContextLines:   args.ContextLines,
+			Limit:          args.Limit,
+		})
+		if err != nil || result.IsError || len(texts) == 1 {
+			return result, err
+		}
+		if i > 0 {
+			sb.WriteString("---\n\n")
+		}
+		sb.WriteString(result.Text)
+	}
+	return NewResult(sb.String()), nil
+}
+
+func buildGrepQuery(args GrepArgs, needsCode bool) string {
 	selectFields := "file_path, name, start_line, end_line"
 	if needsCode {
 		selectFields += ", code_text"
 	}
 
-	conditions := []string{fmt.Sprintf("regex_matches(code_text, %s)", QuoteCozoPattern(pattern))}
+	return fmt.Sprintf(
+		"?[%s] := *cie_function { id, file_path, name, start_line, end_line }, *cie_function_code { function_id: id, code_text }, %s :limit %d",
+		selectFields, strings.Join(grepConditions(args, "code_text"), ", "), args.Limit,
+	)
+}
+
+// grepConditions returns the query conditions of a single-pattern search
+// matching the text in codeVar.
+func grepConditions(args GrepArgs, codeVar string) []string {
+	pattern := EscapeRegex(args.Text)
+	if !args.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+
+	conditions := []string{fmt.Sprintf("regex_matches(%s, %s)", codeVar, QuoteCozoPattern(pattern))}
 	if args.Path != "" {
 		conditions = append(conditions, fmt.Sprintf("regex_matches(file_path, %s)", QuoteCozoPattern(EscapeRegex(args.Path))))
 	}
 	if args.ExcludePattern != "" {
 		conditions = append(conditions, fmt.Sprintf("!regex_matches(file_path, %s)", QuoteCozoPattern(args.ExcludePattern)))
 	}
+	if lang := languageCondition("id", args.Language, args.Dialect); lang != "" {
+		conditions = append(conditions, lang)
+	}
+	if shadow := shadowCondition(args.shadowed); shadow != "" {
+		conditions = append(conditions, shadow)
+	}
+	return conditions
+}
 
-	return fmt.Sprintf(
-		"?[%s] := *cie_function { id, file_path, name, start_line, end_line }, *cie_function_code { function_id: id, code_text }, %s :limit %d",
-		selectFields, strings.Join(conditions, ", "), args.Limit,
+// grepOverflow adds to rows the functions that match only past their stored
+// code_text, in the code kept in cie_function_code_chunk, up to args.Limit
+// rows. Added rows are shaped like buildGrepQuery's; with needsCode they
+// carry the whole reassembled function.
+func grepOverflow(ctx context.Context, client Querier, args GrepArgs, needsCode bool, rows [][]any) [][]any {
+	if len(rows) >= args.Limit {
+		return rows
+	}
+	script := fmt.Sprintf(
+		"?[file_path, name, start_line, end_line] := *cie_function { id, file_path, name, start_line, end_line }, *cie_function_code_chunk { function_id: id, code_text: chunk_text }, %s :limit %d",
+		strings.Join(grepConditions(args, "chunk_text"), ", "), args.Limit,
 	)
+	result, err := client.Query(ctx, script)
+	if err != nil {
+		return rows
+	}
+	seen := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		seen[codeKey(row[0], row[1], row[2])] = true
+	}
+	for _, r := range result.Rows {
+		if len(rows) >= args.Limit {
+			break
+		}
+		if len(r) < 4 || seen[codeKey(r[0], r[1], r[2])] {
+			continue
+		}
+		seen[codeKey(r[0], r[1], r[2])] = true
+		row := append([]any{}, r[:4]...)
+		if needsCode {
+			row = append(row, fullFunctionCode(ctx, client, AnyToString(r[1]), AnyToString(r[0]), r[2]))
+		}
+		rows = append(rows, row)
+	}
+	return rows
 }
 
 func formatGrepNoResults(ctx context.Context, client Querier, args GrepArgs) string {
@@ -136,7 +366,7 @@ func formatGrepResults(rows [][]any, args GrepArgs, needsCode bool) string {
 	for i, row := range rows {
 		output += fmt.Sprintf("%d. **%s** in `%s:%s`\n", i+1, AnyToString(row[1]), AnyToString(row[0]), AnyToString(row[2]))
 		if needsCode && len(row) > 4 {
-			if matchContext := extractMatchContext(AnyToString(row[4]), args.Text, args.CaseSensitive, args.ContextLines); matchContext != "" {
+			if matchContext := extractMatchContext(AnyToString(row[4]), args.Text, args.CaseSensitive, args.ContextLines, int(toFloat64(row[2]))); matchContext != "" {
 				output += "```\n" + matchContext + "```\n"
 			}
 		}
@@ -145,8 +375,9 @@ func formatGrepResults(rows [][]any, args GrepArgs, needsCode bool) string {
 	return output
 }
 
-// extractMatchContext finds matching lines and returns them with context
-func extractMatchContext(code, searchText string, caseSensitive bool, contextLines int) string {
+// extractMatchContext finds matching lines and returns them with context,
+// numbered with file line numbers (startLine is the line of the first line of code).
+func extractMatchContext(code, searchText string, caseSensitive bool, contextLines, startLine int) string {
 	lines := splitLines(code)
 	var matchingLineNums []int
 
@@ -201,8 +432,7 @@ func extractMatchContext(code, searchText string, caseSensitive bool, contextLin
 			if j == matchLine {
 				prefix = "> " // Highlight matching line
 			}
-			// Show line number relative to function start
-			result += fmt.Sprintf("%s%3d: %s\n", prefix, j+1, lines[j])
+			result += fmt.Sprintf("%s%3d: %s\n", prefix, startLine+j, lines[j])
 		}
 	}
 
@@ -235,16 +465,33 @@ func splitLines(s string) []string {
 // grepMulti searches for multiple patterns and returns grouped results
 func grepMulti(ctx context.Context, client Querier, args GrepArgs) (*ToolResult, error) {
 	if len(args.Texts) == 0 {
-		return NewError("Error: 'texts' array is empty"), nil
+		return NewInputError("Error: 'texts' array is empty"), nil
 	}
 
-	script := buildGrepMultiQuery(args)
-	result, err := client.Query(ctx, script)
-	if err != nil {
-		return nil, fmt.Errorf("grep multi query: %w", err)
+	var rows [][]any
+	if isCodeCompressed(ctx, client) {
+		err := scanDecodedCode(ctx, client, args.Path, args.ExcludePattern, languageCondition("id", args.Language, args.Dialect), func(row []any) bool {
+			for _, text := range args.Texts {
+				if matchesGrepPattern(AnyToString(row[4]), text, args.CaseSensitive) {
+					// Reshape to the [file_path, name, start_line, code_text] layout of buildGrepMultiQuery
+					rows = append(rows, []any{row[0], row[1], row[2], row[4]})
+					break
+				}
+			}
+			return len(rows) < args.Limit*len(args.Texts)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("grep multi query: %w", err)
+		}
+	} else {
+		result, err := client.Query(ctx, buildGrepMultiQuery(args))
+		if err != nil {
+			return nil, fmt.Errorf("grep multi query: %w", err)
+		}
+		rows = result.Rows
 	}
 
-	patternCounts, patternMatches := groupGrepMultiResults(result.Rows, args)
+	patternCounts, patternMatches := groupGrepMultiResults(rows, args)
 	return NewResult(formatGrepMultiOutput(args, patternCounts, patternMatches)), nil
 }
 
@@ -265,6 +512,9 @@ func buildGrepMultiQuery(args GrepArgs) string {
 	if args.ExcludePattern != "" {
 		conditions = append(conditions, fmt.Sprintf("!regex_matches(file_path, %s)", QuoteCozoPattern(args.ExcludePattern)))
 	}
+	if lang := languageCondition("id", args.Language, args.Dialect); lang != "" {
+		conditions = append(conditions, lang)
+	}
 
 	return fmt.Sprintf(
 		"?[file_path, name, start_line, code_text] := *cie_function { id, file_path, name, start_line }, *cie_function_code { function_id: id, code_text }, %s :limit %d",
@@ -282,9 +532,14 @@ func groupGrepMultiResults(rows [][]any, args GrepArgs) (map[string]int, map[str
 			if matchesGrepPattern(codeText, text, args.CaseSensitive) {
 				patternCounts[text]++
 				if len(patternMatches[text]) < args.Limit {
-					patternMatches[text] = append(patternMatches[text], GrepMatch{
-						FilePath: AnyToString(row[0]), Name: AnyToString(row[1]), StartLine: AnyToString(row[2]),
-					})
+					m := GrepMatch{FilePath: AnyToString(row[0]), Name: AnyToString(row[1]), StartLine: AnyToString(row[2])}
+					for i, line := range strings.Split(codeText, "\n") {
+						if matchesGrepPattern(line, text, args.CaseSensitive) {
+							m.Line, m.Context = int(toFloat64(row[2]))+i, strings.TrimSpace(line)
+							break
+						}
+					}
+					patternMatches[text] = append(patternMatches[text], m)
 				}
 			}
 		}
@@ -339,6 +594,10 @@ func formatGrepMultiDetails(output *strings.Builder, texts []string, patternCoun
 				_, _ = fmt.Fprintf(output, "  ... and %d more\n", len(matches)-5)
 				break
 			}
+			if match.Line > 0 {
+				_, _ = fmt.Fprintf(output, "- **%s** in `%s:%d`: `%s`\n", match.Name, match.FilePath, match.Line, truncateBinding(match.Context))
+				continue
+			}
 			_, _ = fmt.Fprintf(output, "- **%s** in `%s:%s`\n", match.Name, match.FilePath, match.StartLine)
 		}
 		output.WriteString("\n")
@@ -587,7 +846,7 @@ type AbsenceCheckSummary struct {
 // Useful for security audits (no secrets, no hardcoded tokens, etc.)
 func VerifyAbsence(ctx context.Context, client Querier, args VerifyAbsenceArgs) (*ToolResult, error) {
 	if len(args.Patterns) == 0 {
-		return NewError("Error: 'patterns' array is required"), nil
+		return NewInputError("Error: 'patterns' array is required"), nil
 	}
 	if args.Severity == "" {
 		args.Severity = "warning"
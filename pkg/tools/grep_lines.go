@@ -0,0 +1,250 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// GrepLine is one line reported by GrepLines: a matching line or, with
+// context, a line around one.
+type GrepLine struct {
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Text     string `json:"text"`
+	Function string `json:"function,omitempty"` // enclosing function, empty for file text
+	Context  bool   `json:"context,omitempty"`  // a context line rather than a match
+}
+
+// LineSearchArgs describes a line-oriented search over the index, the form
+// `cie grep` prints.
+type LineSearchArgs struct {
+	Pattern        string // regex matched against each line, e.g. "(?i)jwt\\.Parse"
+	Path           string // only files whose path contains this text
+	ExcludePattern string // skip files whose path matches this regex
+	Files          bool   // search stored file text instead of function bodies
+	ContextLines   int
+	Limit          int // max matching lines
+}
+
+// GrepLines returns the lines matching args.Pattern, ordered by path and
+// line, followed by context lines when args.ContextLines is set. Unlike
+// Grep it reports every matching line rather than the functions holding
+// them, so the result prints as ordinary grep output.
+func GrepLines(ctx context.Context, client Querier, args LineSearchArgs) ([]GrepLine, error) {
+	re, err := regexp.Compile(args.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	if args.Limit <= 0 {
+		args.Limit = 30
+	}
+
+	var bodies []codeBody
+	if args.Files {
+		bodies, err = fileBodies(ctx, client, args)
+	} else {
+		bodies, err = functionBodies(ctx, client, args)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(bodies, func(i, j int) bool {
+		if bodies[i].path != bodies[j].path {
+			return bodies[i].path < bodies[j].path
+		}
+		return bodies[i].startLine < bodies[j].startLine
+	})
+
+	var lines []GrepLine
+	seen := make(map[string]bool)
+	matches := 0
+	for _, body := range bodies {
+		text := strings.Split(body.code, "\n")
+		for i, line := range text {
+			if matches >= args.Limit {
+				return lines, nil
+			}
+			// Nested functions repeat their enclosing function's lines.
+			key := fmt.Sprintf("%s:%d", body.path, body.startLine+i)
+			if seen[key] || !re.MatchString(line) {
+				continue
+			}
+			matches++
+			for j := max(0, i-args.ContextLines); j <= min(len(text)-1, i+args.ContextLines); j++ {
+				key := fmt.Sprintf("%s:%d", body.path, body.startLine+j)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				lines = append(lines, GrepLine{
+					Path:     body.path,
+					Line:     body.startLine + j,
+					Text:     strings.TrimRight(text[j], "\r"),
+					Function: body.function,
+					Context:  j != i,
+				})
+			}
+		}
+	}
+	return lines, nil
+}
+
+// codeBody is a stretch of source searched by GrepLines.
+type codeBody struct {
+	path      string
+	function  string
+	startLine int // file line of the first line of code
+	code      string
+}
+
+// lineSearchPathConditions returns the path filters of a line search on
+// pathVar.
+func lineSearchPathConditions(args LineSearchArgs, pathVar string) []string {
+	var conditions []string
+	if args.Path != "" {
+		conditions = append(conditions, fmt.Sprintf("regex_matches(%s, %s)", pathVar, QuoteCozoPattern(EscapeRegex(args.Path))))
+	}
+	if args.ExcludePattern != "" {
+		conditions = append(conditions, fmt.Sprintf("!regex_matches(%s, %s)", pathVar, QuoteCozoPattern(args.ExcludePattern)))
+	}
+	return conditions
+}
+
+// functionBodies returns the whole code of up to args.Limit functions
+// matching args.Pattern.
+func functionBodies(ctx context.Context, client Querier, args LineSearchArgs) ([]codeBody, error) {
+	if isCodeCompressed(ctx, client) {
+		re, err := regexp.Compile(args.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		// Keep every matching function: GrepLines orders them by path
+		// before applying the limit.
+		var rows [][]any
+		err = scanDecodedCode(ctx, client, args.Path, args.ExcludePattern, "", func(row []any) bool {
+			if re.MatchString(AnyToString(row[4])) {
+				rows = append(rows, row)
+			}
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("grep query: %w", err)
+		}
+		return codeBodies(rows, nil), nil
+	}
+
+	conditions := lineSearchPathConditions(args, "file_path")
+	script := fmt.Sprintf(
+		"?[file_path, name, start_line, end_line, code_text] := *cie_function { id, file_path, name, start_line, end_line }, *cie_function_code { function_id: id, code_text }, %s :order file_path, start_line :limit %d",
+		strings.Join(append([]string{fmt.Sprintf("regex_matches(code_text, %s)", QuoteCozoPattern(args.Pattern))}, conditions...), ", "), args.Limit,
+	)
+	result, err := client.Query(ctx, script)
+	if err != nil {
+		return nil, fmt.Errorf("grep query: %w", err)
+	}
+	rows := result.Rows
+
+	// Functions whose stored code_text is cut short may match only in the
+	// code kept in cie_function_code_chunk.
+	script = fmt.Sprintf(
+		"?[file_path, name, start_line, end_line] := *cie_function { id, file_path, name, start_line, end_line }, *cie_function_code_chunk { function_id: id, code_text: chunk_text }, %s :order file_path, start_line :limit %d",
+		strings.Join(append([]string{fmt.Sprintf("regex_matches(chunk_text, %s)", QuoteCozoPattern(args.Pattern))}, conditions...), ", "), args.Limit,
+	)
+	if chunked, err := client.Query(ctx, script); err == nil {
+		seen := make(map[string]bool, len(rows))
+		for _, row := range rows {
+			seen[codeKey(row[0], row[1], row[2])] = true
+		}
+		for _, row := range chunked.Rows {
+			if len(row) == 4 && !seen[codeKey(row[0], row[1], row[2])] {
+				rows = append(rows, append(append([]any{}, row...), ""))
+			}
+		}
+	}
+	return codeBodies(rows, func(row []any) string {
+		return functionOverflow(ctx, client, AnyToString(row[1]), AnyToString(row[0]), row[2])
+	}), nil
+}
+
+// codeBodies converts rows shaped [file_path, name, start_line, end_line,
+// code_text] to code bodies, appending overflow(row) to the code when set.
+func codeBodies(rows [][]any, overflow func(row []any) string) []codeBody {
+	bodies := make([]codeBody, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < 5 {
+			continue
+		}
+		code := decodeCodeText(row[4])
+		if overflow != nil {
+			code += overflow(row)
+		}
+		bodies = append(bodies, codeBody{
+			path:      AnyToString(row[0]),
+			function:  AnyToString(row[1]),
+			startLine: int(toFloat64(row[2])),
+			code:      code,
+		})
+	}
+	return bodies
+}
+
+// fileBodies returns the stored text of up to args.Limit files matching
+// args.Pattern.
+func fileBodies(ctx context.Context, client Querier, args LineSearchArgs) ([]codeBody, error) {
+	compressed := isCodeCompressed(ctx, client)
+	conditions := lineSearchPathConditions(args, "path")
+	limit := args.Limit
+	if compressed {
+		limit = maxCompressedScanRows
+	} else {
+		conditions = append([]string{fmt.Sprintf("regex_matches(content, %s)", QuoteCozoPattern(args.Pattern))}, conditions...)
+	}
+	filter := ""
+	if len(conditions) > 0 {
+		filter = ", " + strings.Join(conditions, ", ")
+	}
+	script := fmt.Sprintf("?[path, content] := *cie_file { id, path }, *cie_file_content { file_id: id, content }%s :order path :limit %d", filter, limit)
+	result, err := client.Query(ctx, script)
+	if err != nil {
+		if strings.Contains(err.Error(), "cie_file_content") {
+			return nil, fmt.Errorf("file text is not stored in this index; set indexing.store_file_text: true and run 'cie index --full'")
+		}
+		return nil, fmt.Errorf("file search query: %w", err)
+	}
+
+	bodies := make([]codeBody, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		if len(row) < 2 {
+			continue
+		}
+		content, err := storage.DecompressCodeText(AnyToString(row[1]))
+		if err != nil {
+			continue
+		}
+		bodies = append(bodies, codeBody{path: AnyToString(row[0]), startLine: 1, code: content})
+	}
+	return bodies, nil
+}
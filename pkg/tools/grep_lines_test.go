@@ -0,0 +1,75 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGrepLines(t *testing.T) {
+	code := "func Exit() {\n\tlog.Print(\"bye\")\n\tos.Exit(1)\n}"
+	client := NewMockClientCustom(func(_ context.Context, script string) (*QueryResult, error) {
+		if strings.Contains(script, "*cie_function_code {") {
+			return NewMockQueryResult(
+				[]string{"file_path", "name", "start_line", "end_line", "code_text"},
+				[][]any{{"cmd/main.go", "Exit", float64(20), float64(23), code}},
+			), nil
+		}
+		return NewMockQueryResult(nil, nil), nil
+	}, nil)
+
+	lines, err := GrepLines(context.Background(), client, LineSearchArgs{Pattern: `(?i)os\.exit|log\.`, Limit: 10})
+	if err != nil {
+		t.Fatalf("GrepLines: %v", err)
+	}
+	want := []GrepLine{
+		{Path: "cmd/main.go", Line: 21, Text: "\tlog.Print(\"bye\")", Function: "Exit"},
+		{Path: "cmd/main.go", Line: 22, Text: "\tos.Exit(1)", Function: "Exit"},
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("lines = %#v\nwant %#v", lines, want)
+	}
+
+	t.Run("context and limit", func(t *testing.T) {
+		lines, err := GrepLines(context.Background(), client, LineSearchArgs{Pattern: `os\.Exit`, ContextLines: 1, Limit: 1})
+		if err != nil {
+			t.Fatalf("GrepLines: %v", err)
+		}
+		var got []string
+		for _, l := range lines {
+			got = append(got, strings.TrimSpace(l.Text))
+			if l.Context == (l.Line == 22) {
+				t.Errorf("line %d: Context = %v", l.Line, l.Context)
+			}
+		}
+		if want := []string{`log.Print("bye")`, "os.Exit(1)", "}"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("lines = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		if _, err := GrepLines(context.Background(), client, LineSearchArgs{Pattern: "("}); err == nil {
+			t.Error("expected an error for an invalid pattern")
+		}
+	})
+}
@@ -149,7 +149,7 @@ func TestExtractMatchContext(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := extractMatchContext(code, tt.searchText, tt.caseSensitive, tt.contextLines)
+			got := extractMatchContext(code, tt.searchText, tt.caseSensitive, tt.contextLines, 1)
 
 			if tt.wantNotEmpty && got == "" {
 				t.Error("extractMatchContext() returned empty, want non-empty")
@@ -172,7 +172,7 @@ func TestExtractMatchContext(t *testing.T) {
 func TestExtractMatchContext_Highlighting(t *testing.T) {
 	code := "line1\nmatch here\nline3"
 
-	got := extractMatchContext(code, "match", false, 0)
+	got := extractMatchContext(code, "match", false, 0, 1)
 
 	// Matching line should be highlighted with "> "
 	if !strings.Contains(got, "> ") {
@@ -184,11 +184,23 @@ func TestExtractMatchContext_Highlighting(t *testing.T) {
 	}
 }
 
+func TestExtractMatchContext_FileLineNumbers(t *testing.T) {
+	code := "func F() {\n\tx := 1\n\tmatch(x)\n}"
+
+	got := extractMatchContext(code, "match", false, 1, 120)
+
+	for _, want := range []string{"  121: \tx := 1", "> 122: \tmatch(x)", "  123: }"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("extractMatchContext() should number lines from the function start, missing %q in:\n%s", want, got)
+		}
+	}
+}
+
 func TestExtractMatchContext_Separator(t *testing.T) {
 	// Code with matches far apart
 	code := "line1\nmatch1\nline3\nline4\nline5\nline6\nline7\nmatch2\nline9"
 
-	got := extractMatchContext(code, "match", false, 1)
+	got := extractMatchContext(code, "match", false, 1, 1)
 
 	// Should have separator (...) between distant matches
 	if !strings.Contains(got, "...") {
@@ -204,7 +216,7 @@ func TestExtractMatchContext_Truncation(t *testing.T) {
 	}
 	code := builder.String()
 
-	got := extractMatchContext(code, "match", false, 2)
+	got := extractMatchContext(code, "match", false, 2, 1)
 
 	// Should be truncated to ~2000 chars
 	if len(got) > 2100 { // Allow some buffer for truncation message
@@ -0,0 +1,137 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxGroupedRows is how many raw matches a grouped search inspects. Groups
+	// are built from this larger sample so one noisy file cannot use up the
+	// whole result limit.
+	maxGroupedRows = 5000
+	// groupPreviews is how many matches are shown under each group.
+	groupPreviews = 3
+)
+
+// searchHit is one match fed into result grouping.
+type searchHit struct {
+	File     string
+	Function string
+	Line     int
+	Preview  string
+}
+
+// validGroupBy reports whether groupBy is a supported grouping ("" means none).
+func validGroupBy(groupBy string) bool {
+	switch groupBy {
+	case "", "file", "package", "function":
+		return true
+	}
+	return false
+}
+
+func groupKey(hit searchHit, groupBy string) string {
+	switch groupBy {
+	case "package":
+		return ExtractDir(hit.File)
+	case "function":
+		return hit.File + "\x00" + hit.Function
+	}
+	return hit.File
+}
+
+func groupLabel(key, groupBy string) string {
+	if groupBy == "function" {
+		file, fn, _ := strings.Cut(key, "\x00")
+		return fmt.Sprintf("**%s** in `%s`", fn, file)
+	}
+	return fmt.Sprintf("`%s`", key)
+}
+
+// formatGroupedHits renders hits as per-group counts with a few collapsed
+// previews each. Groups with the most matches come first; at most maxGroups
+// are listed and the rest are summarised in a single line.
+func formatGroupedHits(hits []searchHit, groupBy string, maxGroups int) string {
+	groups := make(map[string][]searchHit)
+	var keys []string
+	for _, hit := range hits {
+		key := groupKey(hit, groupBy)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], hit)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		if len(groups[keys[i]]) != len(groups[keys[j]]) {
+			return len(groups[keys[i]]) > len(groups[keys[j]])
+		}
+		return keys[i] < keys[j]
+	})
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d matches in %d %s groups", len(hits), len(keys), groupBy)
+	if len(hits) >= maxGroupedRows {
+		sb.WriteString(" (sample capped; counts are lower bounds)")
+	}
+	sb.WriteString(":\n\n")
+
+	// Summary table first so small groups are visible even after large ones.
+	for i, key := range keys {
+		if i >= maxGroups {
+			break
+		}
+		fmt.Fprintf(&sb, "- %s — %d\n", groupLabel(key, groupBy), len(groups[key]))
+	}
+	if len(keys) > maxGroups {
+		fmt.Fprintf(&sb, "- ... %d more groups\n", len(keys)-maxGroups)
+	}
+	sb.WriteString("\n")
+
+	for i, key := range keys {
+		if i >= maxGroups {
+			break
+		}
+		members := groups[key]
+		fmt.Fprintf(&sb, "### %s (%d)\n", groupLabel(key, groupBy), len(members))
+		for j, hit := range members {
+			if j >= groupPreviews {
+				fmt.Fprintf(&sb, "  ... %d more\n", len(members)-j)
+				break
+			}
+			loc := fmt.Sprintf("line %d", hit.Line)
+			if groupBy == "package" {
+				loc = fmt.Sprintf("%s:%d", ExtractFileName(hit.File), hit.Line)
+			}
+			entry := fmt.Sprintf("  - %s", loc)
+			if groupBy != "function" && hit.Function != "" {
+				entry += fmt.Sprintf(" **%s**", hit.Function)
+			}
+			if hit.Preview != "" {
+				entry += fmt.Sprintf(": `%s`", truncateBinding(hit.Preview))
+			}
+			sb.WriteString(entry + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFormatGroupedHits(t *testing.T) {
+	var hits []searchHit
+	for i := 0; i < 500; i++ {
+		hits = append(hits, searchHit{File: "api/gen.pb.go", Function: "init", Line: i + 1, Preview: "token"})
+	}
+	hits = append(hits,
+		searchHit{File: "auth/login.go", Function: "Login", Line: 10, Preview: "token := issue()"},
+		searchHit{File: "auth/refresh.go", Function: "Refresh", Line: 4, Preview: "return token"},
+	)
+
+	byFile := formatGroupedHits(hits, "file", 10)
+	assertContains(t, byFile, "502 matches in 3 file groups")
+	assertContains(t, byFile, "- `api/gen.pb.go` — 500")
+	assertContains(t, byFile, "- `auth/login.go` — 1")
+	assertContains(t, byFile, "  ... 497 more")
+	assertContains(t, byFile, "  - line 10 **Login**: `token := issue()`")
+
+	byPackage := formatGroupedHits(hits, "package", 10)
+	assertContains(t, byPackage, "- `auth` — 2")
+	assertContains(t, byPackage, "  - login.go:10 **Login**")
+
+	capped := formatGroupedHits(hits, "function", 1)
+	assertContains(t, capped, "**init** in `api/gen.pb.go` — 500")
+	assertContains(t, capped, "- ... 2 more groups")
+	if strings.Contains(capped, "### **Login**") {
+		t.Error("groups beyond the limit should not be expanded")
+	}
+}
+
+func TestGrep_GroupBy(t *testing.T) {
+	ctx := setupTest(t)
+	var script string
+	client := NewMockClientCustom(func(ctx context.Context, s string) (*QueryResult, error) {
+		if strings.Contains(s, "cie_project_meta") {
+			return NewMockQueryResult([]string{"value"}, nil), nil
+		}
+		script = s
+		return NewMockQueryResult(
+			[]string{"file_path", "name", "start_line", "end_line", "code_text"},
+			[][]any{
+				{"gen/api.pb.go", "init", int64(1), int64(3), "Token\nToken\nToken"},
+				{"auth/login.go", "Login", int64(20), int64(22), "func Login() {\n\tuse(token)\n}"},
+			},
+		), nil
+	}, nil)
+
+	result, err := Grep(ctx, client, GrepArgs{Text: "token", GroupBy: "file", Limit: 5})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "4 matches in 2 file groups")
+	assertContains(t, result.Text, "- `gen/api.pb.go` — 3")
+	assertContains(t, result.Text, "line 21 **Login**: `use(token)`")
+	assertContains(t, script, fmt.Sprintf(":limit %d", maxGroupedRows))
+}
+
+func TestSearchText_GroupBy(t *testing.T) {
+	ctx := setupTest(t)
+	client := NewMockClientWithResults(
+		[]string{"file_path", "name", "signature", "start_line", "end_line"},
+		[][]any{
+			{"pkg/a/x.go", "A", "func A()", int64(1), int64(2)},
+			{"pkg/a/y.go", "B", "func B()", int64(5), int64(9)},
+			{"pkg/b/z.go", "C", "func C()", int64(3), int64(4)},
+		},
+	)
+
+	result, err := SearchText(ctx, client, SearchTextArgs{Pattern: "func", GroupBy: "package"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "3 matches in 2 package groups")
+	assertContains(t, result.Text, "- `pkg/a` — 2")
+
+	result, err = SearchText(ctx, client, SearchTextArgs{Pattern: "func", GroupBy: "dir"})
+	assertNoError(t, err)
+	if !result.IsError {
+		t.Error("expected error for unknown group_by")
+	}
+}
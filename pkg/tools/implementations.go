@@ -38,7 +38,7 @@ type FindImplementationsArgs struct {
 // For TypeScript: searches for classes with "implements InterfaceName".
 func FindImplementations(ctx context.Context, client Querier, args FindImplementationsArgs) (*ToolResult, error) {
 	if args.InterfaceName == "" {
-		return NewError("Error: 'interface_name' is required"), nil
+		return NewInputError("Error: 'interface_name' is required"), nil
 	}
 	if args.Limit <= 0 {
 		args.Limit = 20
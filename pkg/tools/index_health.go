@@ -0,0 +1,364 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// Thresholds separating healthy, degraded and failing index checks.
+const (
+	healthEmbeddingOK      = 95.0 // % of functions with an embedding
+	healthEmbeddingWarn    = 50.0
+	healthParseErrorOK     = 1.0 // % of files that failed or had syntax errors
+	healthParseErrorWarn   = 5.0
+	healthUnresolvedOK     = 20.0 // % of project calls (external excluded) left unresolved
+	healthUnresolvedWarn   = 50.0
+	healthStaleFilesFailAt = 50 // changed files from which the index is failing
+)
+
+// healthStatus grades one index health check.
+type healthStatus int
+
+const (
+	healthUnknown healthStatus = iota
+	healthOK
+	healthWarn
+	healthFail
+)
+
+// icon returns the marker shown for s.
+func (s healthStatus) icon() string {
+	switch s {
+	case healthOK:
+		return "✅"
+	case healthWarn:
+		return "⚠️"
+	case healthFail:
+		return "❌"
+	default:
+		return "➖"
+	}
+}
+
+// healthCheck is one row of the index health report.
+type healthCheck struct {
+	name   string
+	status healthStatus
+	value  string
+	fix    string // What to do when the check is not OK
+}
+
+// IndexHealthArgs holds arguments for cie_index_health.
+type IndexHealthArgs struct {
+	Freshness *Freshness // Working tree against the indexed commit; nil when unknown
+}
+
+// IndexHealth grades the quality of the index: embedding coverage, HNSW
+// index presence, parse errors by language, unresolved calls and files
+// changed since indexing. Agents call it to decide how far to trust
+// answers from the index.
+func IndexHealth(ctx context.Context, client Querier, args IndexHealthArgs) (*ToolResult, error) {
+	functions, err := countRows(ctx, client, "cie_function")
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v", err)), nil
+	}
+	files, _ := countRows(ctx, client, "cie_file")
+	if files == 0 && functions == 0 {
+		return NewResult("# Index Health\n\n❌ **The index is empty.** Run `cie index` from the project root.\n"), nil
+	}
+
+	languages := fileLanguages(ctx, client)
+	parseCheck, byLanguage := parseErrorCheck(ctx, client, languages, files)
+	checks := []healthCheck{
+		embeddingCheck(ctx, client, functions),
+		hnswCheck(ctx, client),
+		parseCheck,
+		unresolvedCallCheck(ctx, client),
+		staleFilesCheck(args.Freshness),
+	}
+	return NewResult(formatIndexHealth(checks, byLanguage)), nil
+}
+
+// embeddingCheck grades the share of functions that have an embedding.
+func embeddingCheck(ctx context.Context, client Querier, functions int) healthCheck {
+	check := healthCheck{name: "Embedding coverage"}
+	if functions == 0 {
+		check.value = "no functions indexed"
+		return check
+	}
+	result, err := client.Query(ctx, `?[count(f)] := *cie_function_embedding { function_id: f, embedding }, embedding != null`)
+	if err != nil {
+		check.value = fmt.Sprintf("unknown (%v)", err)
+		return check
+	}
+	embedded := 0
+	if len(result.Rows) > 0 {
+		embedded = int(toFloat64(result.Rows[0][0]))
+	}
+	pct := float64(embedded) / float64(functions) * 100
+	check.value = fmt.Sprintf("%.1f%% (%d of %d functions)", pct, embedded, functions)
+	check.status = grade(pct, healthEmbeddingOK, healthEmbeddingWarn, true)
+	if check.status != healthOK {
+		check.fix = "Semantic search misses functions without embeddings. Run `cie embed-backfill`."
+	}
+	return check
+}
+
+// hnswCheck reports whether the function embeddings carry an HNSW index.
+func hnswCheck(ctx context.Context, client Querier) healthCheck {
+	check := healthCheck{name: "HNSW index"}
+	result, err := client.Query(ctx, `::indices cie_function_embedding`)
+	switch {
+	case err != nil:
+		check.value = fmt.Sprintf("unknown (%v)", err)
+	case len(result.Rows) > 0:
+		check.status = healthOK
+		check.value = "present on cie_function_embedding"
+	default:
+		check.status = healthFail
+		check.value = "missing on cie_function_embedding"
+		check.fix = "Semantic search cannot run without it. Run `cie index --force-full-reindex`."
+	}
+	return check
+}
+
+// languageHealth is the parse error tally of one language.
+type languageHealth struct {
+	language            string
+	files, syntax, fail int
+}
+
+// fileLanguages counts indexed files by language.
+func fileLanguages(ctx context.Context, client Querier) map[string]int {
+	counts := map[string]int{}
+	result, err := client.Query(ctx, `?[lang, count(f)] := *cie_file { id: f, language: lang }`)
+	if err != nil {
+		return counts
+	}
+	for _, row := range result.Rows {
+		counts[AnyToString(row[0])] = int(toFloat64(row[1]))
+	}
+	return counts
+}
+
+// parseErrorCheck grades the share of files that failed to parse or parsed
+// with syntax errors, from the issues the last index runs recorded, and
+// breaks them down by language.
+func parseErrorCheck(ctx context.Context, client Querier, languages map[string]int, files int) (healthCheck, []languageHealth) {
+	check := healthCheck{name: "Parse errors"}
+	value := projectMeta(ctx, client, storage.ParseIssuesMetaKey)
+	if value == "" {
+		check.value = "unknown (not recorded by this index)"
+		check.fix = "Re-run `cie index` to record parse errors."
+		return check, nil
+	}
+	issues, err := storage.DecodeParseIssues(value)
+	if err != nil {
+		check.value = fmt.Sprintf("unknown (%v)", err)
+		return check, nil
+	}
+
+	byLanguage := map[string]*languageHealth{}
+	for lang, n := range languages {
+		byLanguage[lang] = &languageHealth{language: lang, files: n}
+	}
+	for _, issue := range issues {
+		lh := byLanguage[issue.Language]
+		if lh == nil {
+			lh = &languageHealth{language: issue.Language}
+			byLanguage[issue.Language] = lh
+		}
+		if issue.Failed {
+			lh.fail++
+		} else {
+			lh.syntax++
+		}
+	}
+	var rows []languageHealth
+	for _, lh := range byLanguage {
+		if lh.syntax+lh.fail > 0 {
+			rows = append(rows, *lh)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if ei, ej := rows[i].syntax+rows[i].fail, rows[j].syntax+rows[j].fail; ei != ej {
+			return ei > ej
+		}
+		return rows[i].language < rows[j].language
+	})
+
+	// Files that failed outright are not in cie_file.
+	total := files
+	for _, lh := range rows {
+		total += lh.fail
+	}
+	pct := 0.0
+	if total > 0 {
+		pct = float64(len(issues)) / float64(total) * 100
+	}
+	check.value = fmt.Sprintf("%.1f%% (%d of %d files)", pct, len(issues), total)
+	check.status = grade(pct, healthParseErrorOK, healthParseErrorWarn, false)
+	if check.status != healthOK {
+		check.fix = "Entities in these files may be missing or incomplete. See the breakdown by language below."
+	}
+	return check, rows
+}
+
+// unresolvedCallCheck grades the share of calls into project code that did
+// not resolve to a call edge. Calls into external packages are expected to
+// stay unresolved and are reported but not graded.
+func unresolvedCallCheck(ctx context.Context, client Querier) healthCheck {
+	check := healthCheck{name: "Unresolved calls"}
+	byReason, err := client.Query(ctx, `?[reason, count(id)] := *cie_unresolved_call { id, reason }`)
+	if err != nil {
+		check.value = "unknown (not recorded by this index)"
+		return check
+	}
+	resolved := 0
+	if result, err := client.Query(ctx, `?[count(caller_id)] := *cie_calls { caller_id, callee_id }`); err == nil && len(result.Rows) > 0 {
+		resolved = int(toFloat64(result.Rows[0][0]))
+	}
+	unresolved, external := 0, 0
+	for _, row := range byReason.Rows {
+		n := int(toFloat64(row[1]))
+		unresolved += n
+		if AnyToString(row[0]) == "external" {
+			external = n
+		}
+	}
+	total := resolved + unresolved
+	if total == 0 {
+		check.value = "no calls indexed"
+		return check
+	}
+	internal := unresolved - external
+	pct := 0.0
+	if resolved+internal > 0 {
+		pct = float64(internal) / float64(resolved+internal) * 100
+	}
+	check.value = fmt.Sprintf("%.1f%% of project calls (%d of %d); %.1f%% overall including %d external",
+		pct, internal, resolved+internal, float64(unresolved)/float64(total)*100, external)
+	check.status = grade(pct, healthUnresolvedOK, healthUnresolvedWarn, false)
+	if check.status != healthOK {
+		check.fix = "Callers, callees and traced paths may be incomplete. See cie_resolution_report."
+	}
+	return check
+}
+
+// staleFilesCheck grades how far the working tree has moved since indexing.
+func staleFilesCheck(f *Freshness) healthCheck {
+	check := healthCheck{name: "Stale files"}
+	if f == nil {
+		check.value = "unknown (no indexed commit recorded, or git unavailable)"
+		return check
+	}
+	if !f.Stale() {
+		check.status = healthOK
+		check.value = fmt.Sprintf("none (indexed at %s)", ShortCommit(f.IndexedCommit))
+		return check
+	}
+	check.value = fmt.Sprintf("%d file(s) changed (%d uncommitted)", f.ChangedFiles, f.Uncommitted)
+	switch {
+	case f.CommitsBehind > 0:
+		check.value += fmt.Sprintf(", HEAD %d commit(s) past %s", f.CommitsBehind, ShortCommit(f.IndexedCommit))
+	case f.CommitsBehind < 0:
+		check.value += fmt.Sprintf(", HEAD not a descendant of %s", ShortCommit(f.IndexedCommit))
+	}
+	check.status = healthWarn
+	if f.ChangedFiles >= healthStaleFilesFailAt || f.CommitsBehind < 0 {
+		check.status = healthFail
+	}
+	check.fix = "Answers about changed files may be outdated. Run `cie index` to refresh."
+	return check
+}
+
+// grade rates pct against the ok and warn thresholds. When higherIsBetter
+// is false, lower values are healthier.
+func grade(pct, ok, warn float64, higherIsBetter bool) healthStatus {
+	if !higherIsBetter {
+		pct, ok, warn = -pct, -ok, -warn
+	}
+	switch {
+	case pct >= ok:
+		return healthOK
+	case pct >= warn:
+		return healthWarn
+	default:
+		return healthFail
+	}
+}
+
+// formatIndexHealth renders the verdict, the checks and the parse errors by
+// language.
+func formatIndexHealth(checks []healthCheck, byLanguage []languageHealth) string {
+	worst, unknown := healthOK, 0
+	for _, c := range checks {
+		if c.status == healthUnknown {
+			unknown++
+		} else if c.status > worst {
+			worst = c.status
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Index Health\n\n")
+	switch worst {
+	case healthFail:
+		sb.WriteString("**Verdict:** ❌ degraded: answers from the index may be wrong or incomplete. Fix the failing checks first.\n")
+	case healthWarn:
+		sb.WriteString("**Verdict:** ⚠️ usable with caveats: mention the warnings below when they affect an answer.\n")
+	default:
+		sb.WriteString("**Verdict:** ✅ healthy\n")
+	}
+	if unknown > 0 {
+		fmt.Fprintf(&sb, "_%d check(s) could not be evaluated._\n", unknown)
+	}
+
+	sb.WriteString("\n| Check | Status | Value |\n|-------|--------|-------|\n")
+	for _, c := range checks {
+		fmt.Fprintf(&sb, "| %s | %s | %s |\n", c.name, c.status.icon(), c.value)
+	}
+
+	if len(byLanguage) > 0 {
+		sb.WriteString("\n## Parse Errors by Language\n\n| Language | Files | Syntax errors | Failed | Rate |\n|----------|-------|---------------|--------|------|\n")
+		for _, lh := range byLanguage {
+			total := lh.files + lh.fail
+			fmt.Fprintf(&sb, "| %s | %d | %d | %d | %.1f%% |\n",
+				lh.language, total, lh.syntax, lh.fail, float64(lh.syntax+lh.fail)/float64(total)*100)
+		}
+	}
+
+	var fixes []string
+	for _, c := range checks {
+		if c.fix != "" && c.status != healthOK {
+			fixes = append(fixes, fmt.Sprintf("- **%s:** %s", c.name, c.fix))
+		}
+	}
+	if len(fixes) > 0 {
+		sb.WriteString("\n## What to Do\n\n" + strings.Join(fixes, "\n") + "\n")
+	}
+	return sb.String()
+}
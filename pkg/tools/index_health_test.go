@@ -0,0 +1,150 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// indexHealthMock answers the index health queries. parseIssues is the
+// recorded parse_issues value; "" means none was recorded.
+func indexHealthMock(t *testing.T, embeddings float64, hnsw bool, parseIssues string) Querier {
+	var indices, meta [][]any
+	if hnsw {
+		indices = [][]any{{"embedding_idx"}}
+	}
+	if parseIssues != "" {
+		meta = [][]any{{parseIssues}}
+	}
+	return NewMockClientScripted(t,
+		MockQuery{
+			Match: []string{"?[count(k)] := *cie_function "},
+			Rows:  [][]any{{float64(100)}},
+		},
+		MockQuery{
+			Match: []string{"?[count(k)] := *cie_file "},
+			Rows:  [][]any{{float64(40)}},
+		},
+		MockQuery{
+			Match: []string{"*cie_function_embedding {"},
+			Want:  []string{"?[count(f)]", "embedding != null"},
+			Rows:  [][]any{{embeddings}},
+		},
+		MockQuery{
+			Match: []string{"::indices cie_function_embedding"},
+			Rows:  indices,
+		},
+		MockQuery{
+			Match: []string{"?[lang, count(f)]"},
+			Want:  []string{"*cie_file { id: f, language: lang }"},
+			Rows:  [][]any{{"go", float64(30)}, {"python", float64(10)}},
+		},
+		MockQuery{
+			Match: []string{"*cie_project_meta"},
+			Want:  []string{fmt.Sprintf("key = %q", storage.ParseIssuesMetaKey)},
+			Rows:  meta,
+		},
+		MockQuery{
+			Match: []string{"?[reason, count(id)]"},
+			Want:  []string{"*cie_unresolved_call { id, reason }"},
+			Rows:  [][]any{{"external", float64(50)}, {"not_found", float64(5)}},
+		},
+		MockQuery{
+			Match: []string{"*cie_calls"},
+			Want:  []string{"?[count(caller_id)]"},
+			Rows:  [][]any{{float64(95)}},
+		},
+	)
+}
+
+func TestIndexHealth_Healthy(t *testing.T) {
+	fresh := &Freshness{IndexedCommit: "abcdef123456", HeadCommit: "abcdef123456"}
+	result, err := IndexHealth(context.Background(), indexHealthMock(t, 100, true, "{}"), IndexHealthArgs{Freshness: fresh})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "**Verdict:** ✅ healthy")
+	assertContains(t, result.Text, "| Embedding coverage | ✅ | 100.0% (100 of 100 functions) |")
+	assertContains(t, result.Text, "| HNSW index | ✅ | present on cie_function_embedding |")
+	assertContains(t, result.Text, "| Parse errors | ✅ | 0.0% (0 of 40 files) |")
+	assertContains(t, result.Text, "| Unresolved calls | ✅ | 5.0% of project calls (5 of 100); 36.7% overall including 50 external |")
+	assertContains(t, result.Text, "| Stale files | ✅ | none (indexed at abcdef1) |")
+	assertNotContains(t, result.Text, "What to Do")
+}
+
+func TestIndexHealth_Degraded(t *testing.T) {
+	issues := `{"a.go":{"language":"go","syntax_errors":2},"b.py":{"language":"python","failed":true},"c.py":{"language":"python","syntax_errors":1}}`
+	stale := &Freshness{IndexedCommit: "abcdef123456", HeadCommit: "0123456789ab", CommitsBehind: 3, ChangedFiles: 4, Uncommitted: 1}
+	result, err := IndexHealth(context.Background(), indexHealthMock(t, 60, false, issues), IndexHealthArgs{Freshness: stale})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "**Verdict:** ❌ degraded")
+	assertContains(t, result.Text, "| Embedding coverage | ⚠️ | 60.0% (60 of 100 functions) |")
+	assertContains(t, result.Text, "| HNSW index | ❌ | missing on cie_function_embedding |")
+	// The file that failed is not in cie_file: 3 issues out of 41 files.
+	assertContains(t, result.Text, "| Parse errors | ❌ | 7.3% (3 of 41 files) |")
+	assertContains(t, result.Text, "| python | 11 | 1 | 1 | 18.2% |")
+	assertContains(t, result.Text, "| go | 30 | 1 | 0 | 3.3% |")
+	if strings.Index(result.Text, "| python |") > strings.Index(result.Text, "| go |") {
+		t.Error("languages should be sorted by error count")
+	}
+	assertContains(t, result.Text, "| Stale files | ⚠️ | 4 file(s) changed (1 uncommitted), HEAD 3 commit(s) past abcdef1 |")
+	assertContains(t, result.Text, "- **HNSW index:**")
+}
+
+func TestIndexHealth_Unknown(t *testing.T) {
+	result, err := IndexHealth(context.Background(), indexHealthMock(t, 100, true, ""), IndexHealthArgs{})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "**Verdict:** ✅ healthy")
+	assertContains(t, result.Text, "_2 check(s) could not be evaluated._")
+	assertContains(t, result.Text, "| Parse errors | ➖ | unknown (not recorded by this index) |")
+	assertContains(t, result.Text, "| Stale files | ➖ | unknown")
+}
+
+func TestIndexHealth_EmptyIndex(t *testing.T) {
+	client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		return NewMockQueryResult(nil, [][]any{{float64(0)}}), nil
+	}, nil)
+	result, err := IndexHealth(context.Background(), client, IndexHealthArgs{})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "The index is empty")
+}
+
+func TestGrade(t *testing.T) {
+	tests := []struct {
+		pct, ok, warn float64
+		higher        bool
+		want          healthStatus
+	}{
+		{99, 95, 50, true, healthOK},
+		{60, 95, 50, true, healthWarn},
+		{10, 95, 50, true, healthFail},
+		{0.5, 1, 5, false, healthOK},
+		{3, 1, 5, false, healthWarn},
+		{8, 1, 5, false, healthFail},
+	}
+	for _, tt := range tests {
+		if got := grade(tt.pct, tt.ok, tt.warn, tt.higher); got != tt.want {
+			t.Errorf("grade(%v, %v, %v, %v) = %v, want %v", tt.pct, tt.ok, tt.warn, tt.higher, got, tt.want)
+		}
+	}
+}
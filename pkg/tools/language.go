@@ -0,0 +1,81 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// languageAliases maps common short names to the language names the
+// indexer stores.
+var languageAliases = map[string]string{
+	"golang": "go",
+	"py":     "python",
+	"js":     "javascript",
+	"jsx":    "javascript",
+	"ts":     "typescript",
+	"tsx":    "typescript",
+	"proto":  "protobuf",
+	"cs":     "csharp",
+	"c#":     "csharp",
+}
+
+// normalizeLanguage lowercases a language name and resolves aliases.
+func normalizeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if alias, ok := languageAliases[language]; ok {
+		return alias
+	}
+	return language
+}
+
+// languageCondition returns the clauses restricting the function bound to
+// idVar to a language and a dialect (a case-insensitive substring of hints
+// like "gin handler"), or "" when neither is set. Functions indexed before
+// languages were stored have no cie_function_lang row and never match.
+func languageCondition(idVar, language, dialect string) string {
+	if language == "" && dialect == "" {
+		return ""
+	}
+	clauses := []string{fmt.Sprintf("*cie_function_lang { function_id: %s, language: fn_language, dialect: fn_dialect }", idVar)}
+	if language != "" {
+		clauses = append(clauses, fmt.Sprintf("fn_language == %q", normalizeLanguage(language)))
+	}
+	if dialect != "" {
+		clauses = append(clauses, fmt.Sprintf("regex_matches(fn_dialect, %q)", "(?i)"+EscapeRegex(dialect)))
+	}
+	return strings.Join(clauses, ", ")
+}
+
+// languageLabel describes a language filter for result headers, e.g.
+// " [go, gin handler]", or "" when there is none.
+func languageLabel(language, dialect string) string {
+	var parts []string
+	if language != "" {
+		parts = append(parts, normalizeLanguage(language))
+	}
+	if dialect != "" {
+		parts = append(parts, dialect)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(parts, ", ") + "]"
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLanguageCondition(t *testing.T) {
+	if got := languageCondition("id", "", ""); got != "" {
+		t.Errorf("no filter: got %q, want empty", got)
+	}
+
+	got := languageCondition("id", "TS", "")
+	for _, want := range []string{"*cie_function_lang { function_id: id,", `fn_language == "typescript"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("language only: %q should contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "fn_dialect,") || strings.Contains(got, "regex_matches") {
+		t.Errorf("language only: %q should not filter the dialect", got)
+	}
+
+	got = languageCondition("function_id", "", "gin.handler")
+	for _, want := range []string{"function_id: function_id", `regex_matches(fn_dialect, "(?i)gin[.]handler")`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("dialect only: %q should contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "fn_language ==") {
+		t.Errorf("dialect only: %q should not filter the language", got)
+	}
+}
+
+// captureScripts returns a mock that records every script it runs.
+func captureScripts(scripts *[]string) Querier {
+	return NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		*scripts = append(*scripts, script)
+		return NewMockQueryResult([]string{"file_path", "name", "signature", "start_line", "end_line"}, nil), nil
+	}, nil)
+}
+
+func TestSearchTools_LanguageFilter(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		run  func(Querier) (*ToolResult, error)
+	}{
+		{"search_text", func(c Querier) (*ToolResult, error) {
+			return SearchText(ctx, c, SearchTextArgs{Pattern: "Handle", SearchIn: "name", Language: "go", Dialect: "gin"})
+		}},
+		{"find_function", func(c Querier) (*ToolResult, error) {
+			return FindFunction(ctx, c, FindFunctionArgs{Name: "Handle", Language: "go", Dialect: "gin"})
+		}},
+		{"find_by_signature", func(c Querier) (*ToolResult, error) {
+			return FindBySignature(ctx, c, FindBySignatureArgs{ParamType: "Context", Language: "go", Dialect: "gin"})
+		}},
+		{"grep", func(c Querier) (*ToolResult, error) {
+			return Grep(ctx, c, GrepArgs{Text: "c.JSON", Limit: 10, Language: "go", Dialect: "gin"})
+		}},
+		{"grep_multi", func(c Querier) (*ToolResult, error) {
+			return Grep(ctx, c, GrepArgs{Texts: []string{"c.JSON", "c.Bind"}, Limit: 10, Language: "go", Dialect: "gin"})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var scripts []string
+			_, err := tt.run(captureScripts(&scripts))
+			assertNoError(t, err)
+			var script string
+			for _, s := range scripts {
+				if strings.Contains(s, "*cie_function {") {
+					script = s
+					break
+				}
+			}
+			if script == "" {
+				t.Fatalf("no function query was run: %v", scripts)
+			}
+			for _, want := range []string{"*cie_function_lang { function_id: id,", `fn_language == "go"`, `regex_matches(fn_dialect, "(?i)gin")`} {
+				if !strings.Contains(script, want) {
+					t.Errorf("query should contain %q, got:\n%s", want, script)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchTools_NoLanguageFilter(t *testing.T) {
+	var scripts []string
+	_, err := SearchText(context.Background(), captureScripts(&scripts), SearchTextArgs{Pattern: "Handle", SearchIn: "name"})
+	assertNoError(t, err)
+	if strings.Contains(scripts[0], "cie_function_lang") {
+		t.Errorf("query without a filter should not join cie_function_lang:\n%s", scripts[0])
+	}
+}
+
+func TestSearchText_FilesLanguage(t *testing.T) {
+	var scripts []string
+	_, err := SearchText(context.Background(), captureScripts(&scripts), SearchTextArgs{Pattern: "TODO", SearchIn: "files", Language: "py"})
+	assertNoError(t, err)
+	if !strings.Contains(strings.Join(scripts, "\n"), `language == "python"`) {
+		t.Errorf("file search should filter the file language, got:\n%s", strings.Join(scripts, "\n"))
+	}
+
+	result, err := SearchText(context.Background(), captureScripts(&scripts), SearchTextArgs{Pattern: "TODO", SearchIn: "files", Dialect: "flask route"})
+	assertNoError(t, err)
+	if !result.IsError {
+		t.Error("dialect with search_in=files should be rejected")
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+const (
+	// defaultLineSpan is how many lines GetLines returns without an end line.
+	defaultLineSpan = 50
+	// maxLineSpan caps the lines returned by one GetLines call.
+	maxLineSpan = 500
+)
+
+// GetLinesArgs holds arguments for GetLines.
+type GetLinesArgs struct {
+	Path      string // repository-relative path or a unique suffix of it
+	StartLine int
+	EndLine   int // inclusive; 0 means StartLine+49
+	// Root is the repository directory, read when the index holds no file
+	// text. Empty disables reading from disk.
+	Root string
+	// Hash computes the index's content hash of a file read from disk, to
+	// tell whether it changed since it was indexed. Nil skips the check.
+	Hash func(content []byte) string
+}
+
+// GetLines returns a line range of a file verbatim, from the stored file
+// text or, without it, from disk. It reaches code that function results
+// cut off at the code text size limit.
+func GetLines(ctx context.Context, client Querier, args GetLinesArgs) (*ToolResult, error) {
+	filePath := strings.TrimPrefix(strings.TrimSpace(args.Path), "./")
+	if filePath == "" {
+		return NewInputError("Error: 'path' is required"), nil
+	}
+	if args.StartLine <= 0 {
+		return NewInputError("Error: 'start_line' must be a positive line number"), nil
+	}
+	if args.EndLine == 0 {
+		args.EndLine = args.StartLine + defaultLineSpan - 1
+	}
+	if args.EndLine < args.StartLine {
+		return NewInputError(fmt.Sprintf("Error: 'end_line' (%d) is before 'start_line' (%d)", args.EndLine, args.StartLine)), nil
+	}
+	truncated := false
+	if args.EndLine-args.StartLine+1 > maxLineSpan {
+		args.EndLine = args.StartLine + maxLineSpan - 1
+		truncated = true
+	}
+
+	script := fmt.Sprintf("?[id, path, hash] := *cie_file { id, path, hash }, (path = %q or ends_with(path, %q)) :limit 10", filePath, "/"+filePath)
+	files, err := client.Query(ctx, script)
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v\n\nGenerated query:\n%s", err, script)), nil
+	}
+	if len(files.Rows) == 0 {
+		return NewResult(fmt.Sprintf("File `%s` is not indexed. Use `cie_list_files` to find the indexed path.", filePath)), nil
+	}
+	row := files.Rows[0]
+	if len(files.Rows) > 1 {
+		var exact []any
+		var sb strings.Builder
+		for _, r := range files.Rows {
+			if AnyToString(r[1]) == filePath {
+				exact = r
+			}
+			fmt.Fprintf(&sb, "- `%s`\n", AnyToString(r[1]))
+		}
+		if exact == nil {
+			return NewInputError(fmt.Sprintf("Error: `%s` matches several files; pass the full path:\n%s", filePath, sb.String())), nil
+		}
+		row = exact
+	}
+	fileID, indexedPath, indexedHash := AnyToString(row[0]), AnyToString(row[1]), AnyToString(row[2])
+
+	content, source, note := storedFileText(ctx, client, fileID), "stored file text", ""
+	if content == "" {
+		if args.Root == "" {
+			return NewResult(fmt.Sprintf("No text for `%s`: the index stores no file text and no repository is available to read it from.\n\n%s", indexedPath, fileTextMissingHint)), nil
+		}
+		data, err := readRepoFile(args.Root, indexedPath)
+		if err != nil {
+			return NewError(fmt.Sprintf("Cannot read `%s`: %v", indexedPath, err)), nil
+		}
+		content, source = string(data), "disk"
+		if args.Hash != nil && indexedHash != "" && args.Hash(data) != indexedHash {
+			note = "⚠️ The file changed since it was indexed; line numbers may not match other results until `cie index` runs.\n\n"
+		}
+	}
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if args.StartLine > len(lines) {
+		return NewInputError(fmt.Sprintf("Error: `%s` has %d lines; 'start_line' %d is past the end", indexedPath, len(lines), args.StartLine)), nil
+	}
+	if args.EndLine > len(lines) {
+		args.EndLine = len(lines)
+		truncated = false
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "### %s:%d-%d (%d lines, from %s)\n\n", indexedPath, args.StartLine, args.EndLine, len(lines), source)
+	sb.WriteString(note)
+	fmt.Fprintf(&sb, "```%s\n%s\n```\n", detectLanguage(indexedPath), strings.Join(lines[args.StartLine-1:args.EndLine], "\n"))
+	if truncated {
+		fmt.Fprintf(&sb, "\n_Showing %d lines; request `start_line: %d` for the next ones._\n", maxLineSpan, args.EndLine+1)
+	}
+	return NewResult(sb.String()), nil
+}
+
+// storedFileText returns the stored text of a file, or "" when the index
+// holds none.
+func storedFileText(ctx context.Context, client Querier, fileID string) string {
+	result, err := client.Query(ctx, fmt.Sprintf("?[content] := *cie_file_content { file_id, content }, file_id = %q", fileID))
+	if err != nil || len(result.Rows) == 0 {
+		return ""
+	}
+	content, err := storage.DecompressCodeText(AnyToString(result.Rows[0][0]))
+	if err != nil {
+		return ""
+	}
+	return content
+}
+
+// readRepoFile reads a repository-relative path under root, refusing paths
+// that leave it.
+func readRepoFile(root, relPath string) ([]byte, error) {
+	full := filepath.Join(root, filepath.FromSlash(relPath))
+	rel, err := filepath.Rel(root, full)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("path %q is outside the repository", relPath)
+	}
+	return os.ReadFile(full) //nolint:gosec // G304: indexed path inside the repository
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// linesMock serves one indexed file, with stored text when stored is set.
+func linesMock(stored string) Querier {
+	return NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		switch {
+		case strings.Contains(script, "*cie_file_content"):
+			if stored == "" {
+				return NewMockQueryResult([]string{"content"}, nil), nil
+			}
+			return NewMockQueryResult([]string{"content"}, [][]any{{stored}}), nil
+		case strings.Contains(script, "*cie_file {"):
+			if !strings.Contains(script, `"/batcher.go"`) && !strings.Contains(script, `"pkg/batcher.go"`) {
+				return NewMockQueryResult([]string{"id", "path", "hash"}, nil), nil
+			}
+			return NewMockQueryResult([]string{"id", "path", "hash"}, [][]any{{"file:1", "pkg/batcher.go", "h1"}}), nil
+		}
+		return NewMockQueryResult(nil, nil), nil
+	}, nil)
+}
+
+func TestGetLines_StoredText(t *testing.T) {
+	content := "package pkg\n\nfunc A() {\n\treturn\n}\n"
+	result, err := GetLines(context.Background(), linesMock(content), GetLinesArgs{Path: "batcher.go", StartLine: 3, EndLine: 4})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "### pkg/batcher.go:3-4 (5 lines, from stored file text)")
+	assertContains(t, result.Text, "```go\nfunc A() {\n\treturn\n```")
+	if strings.Contains(result.Text, "package pkg") {
+		t.Errorf("lines outside the range should not be returned:\n%s", result.Text)
+	}
+}
+
+func TestGetLines_Disk(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "pkg", "batcher.go"), []byte("a\nb\nc\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	hash := func(content []byte) string { return "h1" }
+	result, err := GetLines(context.Background(), linesMock(""), GetLinesArgs{Path: "pkg/batcher.go", StartLine: 2, Root: root, Hash: hash})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "### pkg/batcher.go:2-3 (3 lines, from disk)")
+	assertContains(t, result.Text, "```go\nb\nc\n```")
+	if strings.Contains(result.Text, "changed since it was indexed") {
+		t.Errorf("matching hash should not warn:\n%s", result.Text)
+	}
+
+	changed := func(content []byte) string { return "h2" }
+	result, err = GetLines(context.Background(), linesMock(""), GetLinesArgs{Path: "pkg/batcher.go", StartLine: 1, Root: root, Hash: changed})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "changed since it was indexed")
+}
+
+func TestGetLines_NoText(t *testing.T) {
+	result, err := GetLines(context.Background(), linesMock(""), GetLinesArgs{Path: "pkg/batcher.go", StartLine: 1})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "store_file_text")
+}
+
+func TestGetLines_Limits(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 1000; i++ {
+		sb.WriteString("x\n")
+	}
+	client := linesMock(sb.String())
+
+	result, err := GetLines(context.Background(), client, GetLinesArgs{Path: "batcher.go", StartLine: 1, EndLine: 900})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "pkg/batcher.go:1-500")
+	assertContains(t, result.Text, "`start_line: 501`")
+
+	result, err = GetLines(context.Background(), client, GetLinesArgs{Path: "batcher.go", StartLine: 1001})
+	assertNoError(t, err)
+	if !result.IsError {
+		t.Errorf("a start line past the end should be rejected:\n%s", result.Text)
+	}
+
+	result, err = GetLines(context.Background(), client, GetLinesArgs{Path: "batcher.go", StartLine: 10, EndLine: 5})
+	assertNoError(t, err)
+	if !result.IsError {
+		t.Errorf("an end line before the start line should be rejected:\n%s", result.Text)
+	}
+
+	result, err = GetLines(context.Background(), client, GetLinesArgs{Path: "other.go", StartLine: 1})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "is not indexed")
+}
+
+func TestReadRepoFile_OutsideRoot(t *testing.T) {
+	if _, err := readRepoFile(t.TempDir(), "../etc/passwd"); err == nil {
+		t.Error("paths leaving the repository should be refused")
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// LiveFunction is a function parsed at query time from a file that is not
+// in the index yet.
+type LiveFunction struct {
+	Name      string
+	FilePath  string
+	Signature string
+	CodeText  string
+	StartLine int
+	EndLine   int
+}
+
+// LiveFile is a file on disk that is not in the index yet.
+type LiveFile struct {
+	Path    string
+	Content string
+}
+
+// LiveSource exposes files whose on-disk content the index does not reflect:
+// files created since the last `cie index` and, in overlay mode, files with
+// uncommitted edits. Tools consult it so answers given mid-development match
+// the working tree rather than the last index run.
+type LiveSource interface {
+	// Functions parses the live files and returns their functions.
+	Functions(ctx context.Context) ([]LiveFunction, error)
+	// Files returns the live files with their contents.
+	Files(ctx context.Context) ([]LiveFile, error)
+	// Queue asks for paths to be indexed properly; it must not block.
+	Queue(paths []string)
+	// Omitted returns how many files Files leaves out because the source
+	// caps the number of files it reads.
+	Omitted(ctx context.Context) int
+}
+
+// Notes that label results read from disk instead of the index.
+const (
+	liveNote    = "_Not in the index yet: parsed from disk on the fly and queued for indexing._"
+	overlayNote = "_Working-tree version: this file has edits the index does not reflect yet._"
+)
+
+// livePaths returns the paths the live source serves. Indexed results for
+// these paths are superseded by the on-disk version.
+func livePaths(ctx context.Context, live LiveSource) map[string]bool {
+	files, err := live.Files(ctx)
+	if err != nil {
+		return nil
+	}
+	paths := make(map[string]bool, len(files))
+	for _, f := range files {
+		paths[f.Path] = true
+	}
+	return paths
+}
+
+// shadowCondition returns a query condition excluding the files in shadowed,
+// or "" when there are none. Excluding them in the query rather than from
+// its rows keeps :limit counting only rows that are shown.
+func shadowCondition(shadowed map[string]bool) string {
+	if len(shadowed) == 0 {
+		return ""
+	}
+	quoted := make([]string, 0, len(shadowed))
+	for _, p := range sortedKeys(shadowed) {
+		quoted = append(quoted, fmt.Sprintf("%q", p))
+	}
+	return fmt.Sprintf("!is_in(file_path, [%s])", strings.Join(quoted, ", "))
+}
+
+// liveOmittedNote tells the reader that live files were left out by the
+// source's file cap, or returns "" when none were.
+func liveOmittedNote(ctx context.Context, live LiveSource) string {
+	n := live.Omitted(ctx)
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n\n_%d more new or edited files were not read from disk (working-tree file limit reached). Run `cie index` to include them._", n)
+}
+
+// overlayFunction returns the on-disk version of an indexed function when
+// its file is served by the live source.
+func overlayFunction(ctx context.Context, live LiveSource, name, filePath string) *LiveFunction {
+	if !livePaths(ctx, live)[filePath] {
+		return nil
+	}
+	fns, err := live.Functions(ctx)
+	if err != nil {
+		return nil
+	}
+	for i := range fns {
+		if fns[i].FilePath == filePath && fns[i].Name == name {
+			return &fns[i]
+		}
+	}
+	return nil
+}
+
+// liveFunctionsInFile lists the on-disk functions of the live file whose
+// path ends with filePath.
+func liveFunctionsInFile(ctx context.Context, live LiveSource, filePath string) (string, []LiveFunction) {
+	files, err := live.Files(ctx)
+	if err != nil {
+		return "", nil
+	}
+	match := ""
+	for _, f := range files {
+		if strings.HasSuffix(f.Path, filePath) {
+			match = f.Path
+			break
+		}
+	}
+	if match == "" {
+		return "", nil
+	}
+	fns, err := live.Functions(ctx)
+	if err != nil {
+		return "", nil
+	}
+	var out []LiveFunction
+	for _, fn := range fns {
+		if fn.FilePath == match {
+			out = append(out, fn)
+		}
+	}
+	return match, out
+}
+
+// findLiveFunction returns the unindexed function matching name, comparing
+// case-insensitively against both the full and the unqualified name.
+func findLiveFunction(ctx context.Context, live LiveSource, name string) *LiveFunction {
+	fns, err := live.Functions(ctx)
+	if err != nil {
+		return nil
+	}
+	short := name
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		short = name[i+1:]
+	}
+	for i := range fns {
+		if strings.EqualFold(fns[i].Name, name) {
+			return &fns[i]
+		}
+	}
+	for i := range fns {
+		if strings.EqualFold(shortName(fns[i].Name), short) {
+			return &fns[i]
+		}
+	}
+	return nil
+}
+
+// liveGrepSection searches the unindexed files for args.Text, honoring the
+// path and exclude filters, and formats the hits as an extra section. It
+// returns "" when nothing matched and no files were left out.
+func liveGrepSection(ctx context.Context, live LiveSource, args GrepArgs) string {
+	var exclude *regexp.Regexp
+	if args.ExcludePattern != "" {
+		exclude, _ = regexp.Compile(args.ExcludePattern)
+	}
+	return liveLineSection(ctx, live, args.Limit,
+		func(path string) bool {
+			return (args.Path == "" || matchesGrepPattern(path, args.Path, true)) &&
+				(exclude == nil || !exclude.MatchString(path))
+		},
+		func(line string) bool { return matchesGrepPattern(line, args.Text, args.CaseSensitive) },
+	)
+}
+
+// liveLineSection lists the lines of the live files accepted by keepFile
+// that satisfy match, up to limit, queues the files it reports and adds the
+// file cap note. It returns "" when nothing matched and no files were left
+// out.
+func liveLineSection(ctx context.Context, live LiveSource, limit int, keepFile func(path string) bool, match func(line string) bool) string {
+	files, err := live.Files(ctx)
+	if err != nil {
+		return ""
+	}
+	if limit <= 0 {
+		limit = 30
+	}
+
+	var sb strings.Builder
+	var served []string
+	hits := 0
+	for _, f := range files {
+		if !keepFile(f.Path) {
+			continue
+		}
+		matched := false
+		for i, line := range strings.Split(f.Content, "\n") {
+			if hits >= limit {
+				break
+			}
+			if match(line) {
+				sb.WriteString(fmt.Sprintf("- `%s:%d` %s\n", f.Path, i+1, strings.TrimSpace(line)))
+				hits++
+				matched = true
+			}
+		}
+		if matched {
+			served = append(served, f.Path)
+		}
+	}
+	note := liveOmittedNote(ctx, live)
+	if hits == 0 {
+		return note
+	}
+	live.Queue(served)
+	return fmt.Sprintf("\n\n### Working tree (%d matches)\n_Files that are new or edited since indexing, searched on disk._\n%s", hits, sb.String()) + note
+}
+
+// liveFunctionSection lists the live functions named name (or, unless
+// exact, methods ending with .name, ignoring case) as an extra section and
+// queues their files. It returns the section, "" when none match and no
+// files were left out, and the number of functions listed.
+func liveFunctionSection(ctx context.Context, live LiveSource, name string, exact bool) (string, int) {
+	fns, err := live.Functions(ctx)
+	if err != nil {
+		return "", 0
+	}
+	var sb strings.Builder
+	var served []string
+	seen := make(map[string]bool)
+	hits := 0
+	for _, fn := range fns {
+		if exact && fn.Name != name {
+			continue
+		}
+		if !exact && !strings.EqualFold(fn.Name, name) && !strings.EqualFold(shortName(fn.Name), name) {
+			continue
+		}
+		fmt.Fprintf(&sb, "- **%s** `%s:%d-%d` `%s`\n", fn.Name, fn.FilePath, fn.StartLine, fn.EndLine, fn.Signature)
+		hits++
+		if !seen[fn.FilePath] {
+			seen[fn.FilePath] = true
+			served = append(served, fn.FilePath)
+		}
+	}
+	note := liveOmittedNote(ctx, live)
+	if hits == 0 {
+		return note, 0
+	}
+	live.Queue(served)
+	return fmt.Sprintf("\n\n### Working tree (%d functions)\n_Files that are new or edited since indexing, parsed on disk._\n%s", hits, sb.String()) + note, hits
+}
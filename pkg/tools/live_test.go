@@ -0,0 +1,199 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// fakeLiveSource serves canned unindexed files and records queue requests.
+type fakeLiveSource struct {
+	fns     []LiveFunction
+	files   []LiveFile
+	queued  []string
+	omitted int
+}
+
+func (f *fakeLiveSource) Functions(context.Context) ([]LiveFunction, error) { return f.fns, nil }
+func (f *fakeLiveSource) Files(context.Context) ([]LiveFile, error)         { return f.files, nil }
+func (f *fakeLiveSource) Queue(paths []string)                              { f.queued = append(f.queued, paths...) }
+func (f *fakeLiveSource) Omitted(context.Context) int                       { return f.omitted }
+
+func TestGetFunctionCode_LiveFallback(t *testing.T) {
+	ctx := setupTest(t)
+	live := &fakeLiveSource{fns: []LiveFunction{{
+		Name: "Server.Start", FilePath: "pkg/srv/new.go", Signature: "func (s *Server) Start() error",
+		CodeText: "func (s *Server) Start() error {\n\treturn nil\n}", StartLine: 5, EndLine: 7,
+	}}}
+
+	result, err := GetFunctionCode(ctx, NewMockClientEmpty(), GetFunctionCodeArgs{FunctionName: "Start", Live: live})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "Not in the index yet")
+	assertContains(t, result.Text, "**File**: pkg/srv/new.go:5-7")
+	if len(live.queued) != 1 || live.queued[0] != "pkg/srv/new.go" {
+		t.Errorf("queued = %v", live.queued)
+	}
+
+	result, err = GetFunctionCode(ctx, NewMockClientEmpty(), GetFunctionCodeArgs{FunctionName: "Stop", Live: live})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "Function 'Stop' not found.")
+}
+
+func TestGrep_LiveSection(t *testing.T) {
+	ctx := setupTest(t)
+	live := &fakeLiveSource{files: []LiveFile{
+		{Path: "pkg/srv/new.go", Content: "package srv\n\nfunc Start() {\n\tconnectDB()\n}\n"},
+		{Path: "web/app.ts", Content: "connectDB()\n"},
+	}}
+
+	result, err := Grep(ctx, NewMockClientEmpty(), GrepArgs{Text: "connectdb", Path: "pkg/", Limit: 10, Live: live})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "Working tree (1 matches)")
+	assertContains(t, result.Text, "`pkg/srv/new.go:4` connectDB()")
+	if strings.Contains(result.Text, "web/app.ts") {
+		t.Error("path filter should exclude web/app.ts")
+	}
+	if len(live.queued) != 1 {
+		t.Errorf("queued = %v", live.queued)
+	}
+}
+
+func TestGetFunctionCode_Overlay(t *testing.T) {
+	ctx := setupTest(t)
+	client := NewMockClientWithResults(
+		[]string{"id", "name", "file_path", "signature", "code_text", "start_line", "end_line"},
+		[][]any{{"fn:1", "Start", "pkg/srv/server.go", "func Start()", "func Start() {\n\told()\n}", int64(3), int64(5)}},
+	)
+	live := &fakeLiveSource{
+		files: []LiveFile{{Path: "pkg/srv/server.go"}},
+		fns: []LiveFunction{{
+			Name: "Start", FilePath: "pkg/srv/server.go", Signature: "func Start()",
+			CodeText: "func Start() {\n\tedited()\n}", StartLine: 3, EndLine: 5,
+		}},
+	}
+
+	result, err := GetFunctionCode(ctx, client, GetFunctionCodeArgs{FunctionName: "Start", Live: live})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "Working-tree version")
+	assertContains(t, result.Text, "edited()")
+	if len(live.queued) != 0 {
+		t.Errorf("edited files must not be queued by the overlay, got %v", live.queued)
+	}
+
+	result, err = GetFunctionCode(ctx, client, GetFunctionCodeArgs{FunctionName: "Start"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "old()")
+}
+
+func TestGrep_OverlayShadowsIndexedFile(t *testing.T) {
+	ctx := setupTest(t)
+	// The edited file must be excluded by the query itself, before :limit.
+	client := NewMockClientCustom(func(_ context.Context, script string) (*QueryResult, error) {
+		rows := [][]any{{"pkg/db/db.go", "Open", int64(8), "func Open() {\n\tconnectDB()\n}"}}
+		if !strings.Contains(script, `!is_in(file_path, ["pkg/srv/server.go"])`) {
+			rows = append(rows, []any{"pkg/srv/server.go", "Start", int64(3), "func Start() {\n\tconnectDB()\n}"})
+		}
+		return NewMockQueryResult([]string{"file_path", "name", "start_line", "code_text"}, rows), nil
+	}, nil)
+	live := &fakeLiveSource{files: []LiveFile{
+		{Path: "pkg/srv/server.go", Content: "package srv\n\nfunc Start() {\n}\n"},
+	}}
+
+	result, err := Grep(ctx, client, GrepArgs{Text: "connectDB", Limit: 10, Live: live})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "pkg/db/db.go")
+	if strings.Contains(result.Text, "pkg/srv/server.go") {
+		t.Errorf("indexed match in an edited file should be dropped:\n%s", result.Text)
+	}
+}
+
+func TestListFunctionsInFile_Live(t *testing.T) {
+	ctx := setupTest(t)
+	live := &fakeLiveSource{
+		files: []LiveFile{{Path: "pkg/srv/server.go"}},
+		fns: []LiveFunction{
+			{Name: "Start", FilePath: "pkg/srv/server.go", Signature: "func Start()", StartLine: 3},
+			{Name: "Other", FilePath: "pkg/other.go", Signature: "func Other()", StartLine: 1},
+		},
+	}
+
+	result, err := ListFunctionsInFile(ctx, NewMockClientEmpty(), ListFunctionsInFileArgs{FilePath: "srv/server.go", Live: live})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "**Functions in pkg/srv/server.go** (1 found, working tree)")
+	assertContains(t, result.Text, "Line 3: **Start**")
+	if strings.Contains(result.Text, "Other") {
+		t.Error("functions from other files should not be listed")
+	}
+}
+
+func TestFindFunction_Live(t *testing.T) {
+	ctx := setupTest(t)
+	client := NewMockClientWithResults(
+		[]string{"file_path", "name", "signature", "start_line", "end_line"},
+		[][]any{{"pkg/srv/server.go", "Server.Start", "func (s *Server) Start()", int64(3), int64(5)}},
+	)
+	live := &fakeLiveSource{
+		files: []LiveFile{{Path: "pkg/srv/server.go"}, {Path: "pkg/job/new.go"}},
+		fns: []LiveFunction{
+			{Name: "Server.Start", FilePath: "pkg/srv/server.go", Signature: "func (s *Server) Start() error", StartLine: 3, EndLine: 9},
+			{Name: "Start", FilePath: "pkg/job/new.go", Signature: "func Start()", StartLine: 1, EndLine: 2},
+		},
+	}
+
+	result, err := FindFunction(ctx, client, FindFunctionArgs{Name: "start", Live: live})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "Working tree (2 functions)")
+	assertContains(t, result.Text, "`pkg/srv/server.go:3-9` `func (s *Server) Start() error`")
+	assertContains(t, result.Text, "`pkg/job/new.go:1-2`")
+	if strings.Contains(result.Text, "file_path: pkg/srv/server.go") {
+		t.Errorf("indexed row of an edited file should be dropped:\n%s", result.Text)
+	}
+}
+
+func TestSearchText_LiveSection(t *testing.T) {
+	ctx := setupTest(t)
+	var script string
+	client := NewMockClientCustom(func(_ context.Context, s string) (*QueryResult, error) {
+		script = s
+		return NewMockQueryResult(nil, nil), nil
+	}, nil)
+	live := &fakeLiveSource{files: []LiveFile{
+		{Path: "pkg/srv/server.go", Content: "func Start() {\n\tconnectDB()\n}\n"},
+		{Path: "pkg/srv/server_test.go", Content: "connectDB()\n"},
+	}}
+
+	result, err := SearchText(ctx, client, SearchTextArgs{Pattern: `connect\w+\(`, SearchIn: "code", ExcludePattern: "_test", Limit: 10, Live: live})
+	assertNoError(t, err)
+	assertContains(t, script, `!is_in(file_path, ["pkg/srv/server.go", "pkg/srv/server_test.go"])`)
+	assertContains(t, result.Text, "`pkg/srv/server.go:2` connectDB()")
+	if strings.Contains(result.Text, "server_test.go:") {
+		t.Errorf("exclude pattern should apply to live files:\n%s", result.Text)
+	}
+}
+
+func TestGrep_LiveOmittedNote(t *testing.T) {
+	ctx := setupTest(t)
+	live := &fakeLiveSource{omitted: 12}
+
+	result, err := Grep(ctx, NewMockClientEmpty(), GrepArgs{Text: "connectDB", Limit: 10, Live: live})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "12 more new or edited files were not read from disk")
+}
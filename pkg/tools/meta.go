@@ -0,0 +1,70 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// projectMeta reads one value from cie_project_meta, returning "" when the
+// key is missing or the query fails.
+func projectMeta(ctx context.Context, client Querier, key string) string {
+	script := fmt.Sprintf(`?[value] := *cie_project_meta { key, value }, key = %q`, key)
+	result, err := client.Query(ctx, script)
+	if err != nil || result == nil || len(result.Rows) == 0 || len(result.Rows[0]) == 0 {
+		return ""
+	}
+	return AnyToString(result.Rows[0][0])
+}
+
+// IndexVersion returns the identifier of the last index write, or "" for
+// indexes built before versions were recorded.
+func IndexVersion(ctx context.Context, client Querier) string {
+	return projectMeta(ctx, client, storage.IndexVersionMetaKey)
+}
+
+// IndexedCommit returns the git SHA recorded by the last index run, or ""
+// when none was recorded.
+func IndexedCommit(ctx context.Context, client Querier) string {
+	return projectMeta(ctx, client, "last_indexed_sha")
+}
+
+// IndexSnapshot summarizes the state of an index, so two snapshots can
+// describe what a rebuild changed.
+type IndexSnapshot struct {
+	Version   string `json:"index_version"`
+	Commit    string `json:"commit,omitempty"` // last indexed git SHA
+	Files     int    `json:"files"`
+	Functions int    `json:"functions"`
+}
+
+// ReadIndexSnapshot reads the index version, last indexed commit and entity
+// counts. Values that cannot be read are left zero.
+func ReadIndexSnapshot(ctx context.Context, client Querier) IndexSnapshot {
+	snap := IndexSnapshot{
+		Version: IndexVersion(ctx, client),
+		Commit:  IndexedCommit(ctx, client),
+	}
+	snap.Files, _ = countRows(ctx, client, "cie_file")
+	snap.Functions, _ = countRows(ctx, client, "cie_function")
+	return snap
+}
@@ -0,0 +1,335 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// routerCallPattern matches the router calls that build middleware chains
+// and register routes: r.Use(, api := r.Group(, r.With(, r.GET(,
+// mux.Handle(, gin.Default( and http.ListenAndServe(.
+var routerCallPattern = regexp.MustCompile(`\b(\w+)\.(Use|Group|Route|With|GET|POST|PUT|DELETE|PATCH|HEAD|OPTIONS|Any|Get|Post|Put|Delete|Patch|Head|Options|Handle|HandleFunc|Default|New|NewRouter|NewMux|NewServeMux|ListenAndServe|ListenAndServeTLS)\s*\(`)
+
+// assignTargetPattern finds the variable a call is assigned to.
+var assignTargetPattern = regexp.MustCompile(`(\w+)\s*:?=\s*$`)
+
+// chainedCallPattern matches the call chained after r.With(...).
+var chainedCallPattern = regexp.MustCompile(`^\s*\.\s*(\w+)\s*\(`)
+
+// wrapperNamePattern matches the function name of a handler wrapper call.
+var wrapperNamePattern = regexp.MustCompile(`^[\w.]+$`)
+
+// closureParamPattern finds the router parameter of a chi Route/Group closure.
+var closureParamPattern = regexp.MustCompile(`^func\s*\(\s*(\w+)\b`)
+
+// routerConstructors are the calls that create a router, by package. Their
+// value is the middleware the constructor installs.
+var routerConstructors = map[string]map[string][]string{
+	"gin":  {"Default": {"gin.Logger()", "gin.Recovery()"}, "New": nil},
+	"echo": {"New": nil},
+	"chi":  {"NewRouter": nil, "NewMux": nil},
+	"http": {"NewServeMux": nil},
+	"mux":  {"NewRouter": nil},
+}
+
+// handlerConstructorPattern matches calls that build a handler rather than
+// wrap one, so peelWrappers stops at them.
+var handlerConstructorPattern = regexp.MustCompile(`^(New|new|Make|make)|^(FileServer|NotFoundHandler|RedirectHandler)$`)
+
+// routerScope is the middleware a router variable applies to the routes
+// registered through it, outermost first.
+type routerScope struct {
+	framework  string // "gin", "echo", "chi", "http" or "mux"; "" if unknown
+	middleware []string
+}
+
+func (s *routerScope) with(extra ...string) *routerScope {
+	mw := make([]string, 0, len(s.middleware)+len(extra))
+	mw = append(append(mw, s.middleware...), extra...)
+	return &routerScope{framework: s.framework, middleware: mw}
+}
+
+// routeChain is a route registration with the middleware applied to it.
+type routeChain struct {
+	key        string // method|path
+	router     string // variable the route was registered on
+	middleware []string
+}
+
+// middlewareScanner reconstructs middleware chains from one function's code.
+type middlewareScanner struct {
+	defaultFramework string
+	routes           []routeChain
+	serverWrappers   map[string][]string // router variable -> handlers wrapping it at ListenAndServe
+}
+
+// routeMiddleware reconstructs, for the routes a function registers, the
+// ordered middleware chain applied to each (outermost first), keyed by
+// "METHOD|path" as in endpoint. It follows gin/echo Use and Group, chi Use,
+// With, Route and Group closures, and net/http handler wrapping such as
+// mux.Handle("/x", auth(logging(h))) or http.ListenAndServe(addr, cors(mux)).
+// Middleware installed outside the function, on a router passed in, is not
+// seen.
+func routeMiddleware(code string) map[string][]string {
+	s := &middlewareScanner{serverWrappers: map[string][]string{}}
+	if strings.Contains(code, "echo.") {
+		s.defaultFramework = "echo"
+	}
+	s.scan(code, map[string]*routerScope{})
+
+	chains := make(map[string][]string, len(s.routes))
+	for _, r := range s.routes {
+		if _, seen := chains[r.key]; seen {
+			continue
+		}
+		mw := append(append([]string{}, s.serverWrappers[r.router]...), r.middleware...)
+		chains[r.key] = mw
+	}
+	return chains
+}
+
+// scan walks code in order, updating scopes as routers are created and
+// configured and recording each route registration.
+func (s *middlewareScanner) scan(code string, scopes map[string]*routerScope) {
+	for pos := 0; pos < len(code); {
+		loc := routerCallPattern.FindStringSubmatchIndex(code[pos:])
+		if loc == nil {
+			return
+		}
+		recv := code[pos+loc[2] : pos+loc[3]]
+		method := code[pos+loc[4] : pos+loc[5]]
+		open := pos + loc[1] - 1
+		args, end := splitCallArgs(code, open)
+		target := ""
+		if m := assignTargetPattern.FindStringSubmatch(code[lineStart(code, pos+loc[0]) : pos+loc[0]]); m != nil {
+			target = m[1]
+		}
+		pos = s.call(code, end, scopes, recv, method, target, args, nil)
+	}
+}
+
+// call handles one router call whose arguments end at end, returning where
+// scanning resumes. extra is middleware added by a preceding With.
+func (s *middlewareScanner) call(code string, end int, scopes map[string]*routerScope, recv, method, target string, args, extra []string) int {
+	if ctors, ok := routerConstructors[recv]; ok && scopes[recv] == nil {
+		if mw, ok := ctors[method]; ok {
+			if target != "" {
+				scopes[target] = &routerScope{framework: recv, middleware: append([]string{}, mw...)}
+			}
+			return end
+		}
+	}
+	scope := scopes[recv]
+	if scope == nil {
+		framework := s.defaultFramework
+		if recv == "http" {
+			framework = "http"
+		}
+		scope = &routerScope{framework: framework}
+		scopes[recv] = scope
+	}
+	if len(extra) > 0 {
+		scope = scope.with(extra...)
+	}
+
+	switch method {
+	case "Use":
+		scope.middleware = append(scope.middleware, middlewareNames(args)...)
+	case "With":
+		// r.With(a, b).Get("/x", h): the middleware applies to the chained call.
+		next := chainedCallPattern.FindStringSubmatchIndex(code[end:])
+		if next == nil {
+			return end
+		}
+		nextArgs, nextEnd := splitCallArgs(code, end+next[1]-1)
+		return s.call(code, nextEnd, scopes, recv, code[end+next[2]:end+next[3]], "", nextArgs, append(append([]string{}, extra...), middlewareNames(args)...))
+	case "Group", "Route":
+		if len(args) > 0 {
+			if body := args[len(args)-1]; closureParamPattern.MatchString(body) {
+				// chi: r.Route("/api", func(r chi.Router) { ... }) scopes
+				// the middleware used inside to the closure.
+				child := make(map[string]*routerScope, len(scopes)+1)
+				for k, v := range scopes {
+					child[k] = v
+				}
+				child[closureParamPattern.FindStringSubmatch(body)[1]] = scope.with()
+				if brace := strings.Index(body, "{"); brace >= 0 {
+					s.scan(body[brace:], child)
+				}
+				if path, ok := routePath(args); ok {
+					s.routes = append(s.routes, routeChain{key: "ANY|" + path, router: recv, middleware: scope.middleware})
+				}
+				return end
+			}
+		}
+		// gin/echo: api := r.Group("/api", mw...)
+		group := scope.with(middlewareNames(tail(args, 1))...)
+		if target != "" {
+			scopes[target] = group
+		}
+		if path, ok := routePath(args); ok {
+			s.routes = append(s.routes, routeChain{key: "ANY|" + path, router: recv, middleware: group.middleware})
+		}
+	case "Handle", "HandleFunc":
+		path, ok := routePath(args)
+		if !ok || len(args) < 2 {
+			return end
+		}
+		wrappers, _ := peelWrappers(args[1])
+		s.routes = append(s.routes, routeChain{key: "ANY|" + path, router: recv, middleware: append(append([]string{}, scope.middleware...), wrappers...)})
+	case "ListenAndServe", "ListenAndServeTLS":
+		handler := 1
+		if method == "ListenAndServeTLS" {
+			handler = 3
+		}
+		if recv != "http" || len(args) <= handler {
+			return end
+		}
+		wrappers, inner := peelWrappers(args[handler])
+		if inner == "nil" {
+			inner = "http"
+		}
+		s.serverWrappers[inner] = wrappers
+	default: // GET, Get, ...: a route
+		path, ok := routePath(args)
+		if !ok {
+			return end
+		}
+		var mw []string
+		switch {
+		case scope.framework == "echo" && method == strings.ToUpper(method):
+			mw = tail(args, 2) // e.GET(path, handler, mw...)
+		case len(args) > 2:
+			mw = args[1 : len(args)-1] // r.GET(path, mw..., handler)
+		}
+		s.routes = append(s.routes, routeChain{key: strings.ToUpper(method) + "|" + path, router: recv, middleware: append(append([]string{}, scope.middleware...), middlewareNames(mw)...)})
+	}
+	return end
+}
+
+// routePath returns the path of a route or group registration, the string
+// literal first argument starting with "/".
+func routePath(args []string) (string, bool) {
+	if len(args) == 0 {
+		return "", false
+	}
+	path, err := strconv.Unquote(args[0])
+	if err != nil || !strings.HasPrefix(path, "/") {
+		return "", false
+	}
+	return path, true
+}
+
+// peelWrappers splits a net/http handler expression such as
+// auth(logging(h)) into its wrappers, outermost first, and the inner
+// handler. Conversions (http.HandlerFunc) are skipped and handler
+// constructors (NewUserHandler(db)) end the chain.
+func peelWrappers(expr string) (wrappers []string, inner string) {
+	expr = strings.TrimSpace(expr)
+	for depth := 0; depth < 10; depth++ {
+		open := strings.IndexByte(expr, '(')
+		if open <= 0 || !strings.HasSuffix(expr, ")") {
+			break
+		}
+		name := expr[:open]
+		if !wrapperNamePattern.MatchString(name) || handlerConstructorPattern.MatchString(shortName(name)) {
+			break
+		}
+		args, end := splitCallArgs(expr, open)
+		if end != len(expr) || len(args) == 0 {
+			break
+		}
+		if name != "http.HandlerFunc" && name != "http.Handler" {
+			wrappers = append(wrappers, name)
+		}
+		// The wrapped handler is the first argument, unless that is a
+		// literal as in http.StripPrefix("/static", h).
+		expr = args[0]
+		if strings.HasPrefix(expr, `"`) || strings.HasPrefix(expr, "`") {
+			expr = args[len(args)-1]
+		}
+	}
+	return wrappers, expr
+}
+
+// middlewareNames cleans middleware arguments for display: whitespace is
+// collapsed and function literals are shown as "func literal".
+func middlewareNames(args []string) []string {
+	names := make([]string, 0, len(args))
+	for _, a := range args {
+		switch {
+		case a == "":
+		case strings.HasPrefix(a, "func("), strings.HasPrefix(a, "func ("):
+			names = append(names, "func literal")
+		default:
+			names = append(names, strings.Join(strings.Fields(a), " "))
+		}
+	}
+	return names
+}
+
+// tail returns args from index i on.
+func tail(args []string, i int) []string {
+	if len(args) <= i {
+		return nil
+	}
+	return args[i:]
+}
+
+// lineStart returns the offset of the start of the line containing pos.
+func lineStart(code string, pos int) int {
+	return strings.LastIndexByte(code[:pos], '\n') + 1
+}
+
+// splitCallArgs splits the arguments of the call whose opening parenthesis
+// is at open, respecting nesting and string literals. It returns the
+// trimmed arguments and the offset just past the closing parenthesis (or
+// len(code) if it is missing).
+func splitCallArgs(code string, open int) ([]string, int) {
+	var args []string
+	depth, start := 0, open+1
+	for i := open; i < len(code); i++ {
+		switch c := code[i]; c {
+		case '"', '\'', '`':
+			if e := skipStringLiteral(code, i); e > i {
+				i = e - 1
+			}
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				if a := strings.TrimSpace(code[start:i]); a != "" || len(args) > 0 {
+					args = append(args, a)
+				}
+				return args, i + 1
+			}
+		case ',':
+			if depth == 1 {
+				args = append(args, strings.TrimSpace(code[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return args, len(code)
+}
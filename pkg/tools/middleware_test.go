@@ -0,0 +1,168 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRouteMiddleware(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want map[string][]string
+	}{
+		{
+			name: "gin",
+			code: `func SetupRouter() *gin.Engine {
+	r := gin.Default()
+	r.Use(middleware.RequestID())
+	r.GET("/health", health)
+	api := r.Group("/api", middleware.Auth())
+	{
+		api.Use(middleware.RateLimit(100))
+		api.POST("/users", middleware.Audit("create"), createUser)
+	}
+	return r
+}`,
+			want: map[string][]string{
+				"GET|/health": {"gin.Logger()", "gin.Recovery()", "middleware.RequestID()"},
+				"ANY|/api":    {"gin.Logger()", "gin.Recovery()", "middleware.RequestID()", "middleware.Auth()"},
+				"POST|/users": {"gin.Logger()", "gin.Recovery()", "middleware.RequestID()", "middleware.Auth()", "middleware.RateLimit(100)", `middleware.Audit("create")`},
+			},
+		},
+		{
+			name: "echo",
+			code: `func routes() {
+	e := echo.New()
+	e.Use(middleware.Logger())
+	admin := e.Group("/admin", requireAdmin)
+	admin.GET("/stats", stats, cache(time.Minute))
+}`,
+			want: map[string][]string{
+				"ANY|/admin": {"middleware.Logger()", "requireAdmin"},
+				"GET|/stats": {"middleware.Logger()", "requireAdmin", "cache(time.Minute)"},
+			},
+		},
+		{
+			name: "chi",
+			code: `func Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Use(middleware.Logger)
+	r.Get("/", index)
+	r.Route("/articles", func(r chi.Router) {
+		r.Use(ArticleCtx)
+		r.With(paginate).Get("/", listArticles)
+	})
+	r.Get("/about", about)
+	return r
+}`,
+			want: map[string][]string{
+				"GET|/":         {"middleware.Logger"},
+				"ANY|/articles": {"middleware.Logger"},
+				"GET|/about":    {"middleware.Logger"},
+			},
+		},
+		{
+			name: "net/http",
+			code: `func main() {
+	mux := http.NewServeMux()
+	mux.Handle("/admin", auth(logging(http.HandlerFunc(admin))))
+	mux.Handle("/users", NewUserHandler(db))
+	mux.Handle("/static/", http.StripPrefix("/static/", fs))
+	http.ListenAndServe(":8080", cors(mux))
+}`,
+			want: map[string][]string{
+				"ANY|/admin":   {"cors", "auth", "logging"},
+				"ANY|/users":   {"cors"},
+				"ANY|/static/": {"cors", "http.StripPrefix"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := routeMiddleware(tt.code)
+			for key, want := range tt.want {
+				if !reflect.DeepEqual(got[key], want) {
+					t.Errorf("routeMiddleware()[%q] = %q, want %q", key, got[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRouteMiddleware_ChiScopes(t *testing.T) {
+	// The route inside the closure sees the closure's Use; the one after it
+	// does not.
+	code := `r := chi.NewRouter()
+r.Route("/articles", func(r chi.Router) {
+	r.Use(ArticleCtx)
+	r.With(paginate).Get("/{id}", getArticle)
+})
+r.Get("/about", about)`
+	got := routeMiddleware(code)
+	if want := []string{"ArticleCtx", "paginate"}; !reflect.DeepEqual(got["GET|/{id}"], want) {
+		t.Errorf("GET /{id} = %q, want %q", got["GET|/{id}"], want)
+	}
+	if len(got["GET|/about"]) != 0 {
+		t.Errorf("GET /about = %q, want no middleware", got["GET|/about"])
+	}
+}
+
+func TestParseEndpointsFromCode_Middleware(t *testing.T) {
+	code := `func setup(r *gin.Engine) {
+	r.Use(gin.Logger())
+	r.GET("/health", health)
+}`
+	eps := parseEndpointsFromCode(code, "router.go", "setup", "1", ListEndpointsArgs{})
+	if len(eps) != 1 {
+		t.Fatalf("parseEndpointsFromCode() = %d endpoints, want 1", len(eps))
+	}
+	if !reflect.DeepEqual(eps[0].Middleware, []string{"gin.Logger()"}) {
+		t.Errorf("Middleware = %q, want [gin.Logger()]", eps[0].Middleware)
+	}
+
+	table := formatEndpointTable(eps)
+	if !strings.Contains(table, "| Middleware |") || !strings.Contains(table, "`gin.Logger()`") {
+		t.Errorf("formatEndpointTable() missing middleware:\n%s", table)
+	}
+}
+
+func TestFormatMiddlewareCoverage(t *testing.T) {
+	eps := []endpoint{
+		{Method: "GET", Path: "/a", Middleware: []string{"auth", "log"}},
+		{Method: "GET", Path: "/b", Middleware: []string{"log"}},
+		{Method: "GET", Path: "/c"},
+	}
+	got := formatMiddlewareCoverage(eps)
+	for _, want := range []string{"- `log`: 2 of 3 endpoints", "- `auth`: 1 of 3 endpoints", "- no middleware detected: 1"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatMiddlewareCoverage() missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Index(got, "`log`") > strings.Index(got, "`auth`") {
+		t.Errorf("formatMiddlewareCoverage() should list the widest middleware first:\n%s", got)
+	}
+	if formatMiddlewareCoverage([]endpoint{{Method: "GET", Path: "/"}}) != "" {
+		t.Error("formatMiddlewareCoverage() should be empty without middleware")
+	}
+}
@@ -21,6 +21,8 @@ package tools
 
 import (
 	"context"
+	"strings"
+	"testing"
 )
 
 // MockCIEClient is a mock implementation of the Querier interface for unit testing.
@@ -157,3 +159,58 @@ func NewMockClientCustom(
 		QueryRawFunc: queryRawFunc,
 	}
 }
+
+// MockQuery is one canned answer of NewMockClientScripted.
+type MockQuery struct {
+	// Match selects the scripts this answer is for: the first MockQuery
+	// whose fragments all appear in a script answers it.
+	Match []string
+	// Want lists fragments a script answered here must also contain, such
+	// as the relations it reads and the filters it applies. A missing one
+	// fails the test.
+	Want []string
+	// Rows is the result returned for the script.
+	Rows [][]any
+}
+
+// NewMockClientScripted creates a mock client that answers each script with
+// the first matching MockQuery and checks the script against its Want
+// fragments. A script no MockQuery matches fails the test.
+//
+// Example:
+//
+//	client := NewMockClientScripted(t,
+//	    MockQuery{
+//	        Match: []string{"?[name, file_path]"},
+//	        Want:  []string{"*cie_function", `regex_matches(file_path, "^api/")`},
+//	        Rows:  [][]any{{"Serve", "api/server.go"}},
+//	    },
+//	)
+func NewMockClientScripted(t *testing.T, queries ...MockQuery) *MockCIEClient {
+	t.Helper()
+	return NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		for _, q := range queries {
+			if !containsAll(script, q.Match) {
+				continue
+			}
+			for _, want := range q.Want {
+				if !strings.Contains(script, want) {
+					t.Errorf("query missing %q: %s", want, script)
+				}
+			}
+			return NewMockQueryResult(nil, q.Rows), nil
+		}
+		t.Errorf("unexpected query: %s", script)
+		return NewMockQueryResult(nil, nil), nil
+	}, nil)
+}
+
+// containsAll reports whether s contains every fragment.
+func containsAll(s string, fragments []string) bool {
+	for _, f := range fragments {
+		if !strings.Contains(s, f) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"sort"
+)
+
+// DefaultNameWeight is the share of the name/signature match in a semantic
+// search score when the caller does not choose one. Body matches stay the
+// main signal; names lift functions whose name says what the query asks.
+const DefaultNameWeight = 0.3
+
+// blendNameMatches searches the name and signature vectors
+// (cie_function_name_embedding) with the same query and blends them with
+// the body matches in rows, weighting names by args.NameWeight. It reports
+// false, leaving rows as they are, when the index has no name vectors.
+func blendNameMatches(ctx context.Context, client Querier, embedding []float64, args SemanticSearchArgs, rows [][]any) ([][]any, bool) {
+	names, err := executeHNSWQueryOn(ctx, client, "cie_function_name_embedding", embedding, args)
+	if err != nil || len(names.Rows) == 0 {
+		return rows, false
+	}
+	return fuseSimilarities(rows, names.Rows, args.NameWeight), true
+}
+
+// fuseSimilarities merges two lists of semantic search rows for the same
+// query, keyed by function_id, into one ordered by blended similarity:
+// (1-weight)*body + weight*name. A function found by one search only is
+// given the lowest similarity the other search returned, the most it can
+// have scored there. The blend is written back to the distance column.
+func fuseSimilarities(bodyRows, nameRows [][]any, weight float64) [][]any {
+	bodyFloor, nameFloor := lowestSimilarity(bodyRows), lowestSimilarity(nameRows)
+	type candidate struct {
+		row        []any
+		body, name float64
+	}
+	byID := make(map[string]*candidate)
+	var order []string
+	add := func(row []any, isName bool) {
+		if len(row) < 7 {
+			return
+		}
+		id := AnyToString(row[6])
+		c, ok := byID[id]
+		if !ok {
+			c = &candidate{row: row, body: bodyFloor, name: nameFloor}
+			byID[id] = c
+			order = append(order, id)
+		}
+		if isName {
+			c.name = rowSimilarity(row)
+		} else {
+			c.body = rowSimilarity(row)
+		}
+	}
+	for _, row := range bodyRows {
+		add(row, false)
+	}
+	for _, row := range nameRows {
+		add(row, true)
+	}
+
+	fused := make([][]any, 0, len(order))
+	for _, id := range order {
+		c := byID[id]
+		row := append([]any(nil), c.row...)
+		row[4] = 2 * (1 - ((1-weight)*c.body + weight*c.name))
+		fused = append(fused, row)
+	}
+	sort.SliceStable(fused, func(i, j int) bool {
+		return fused[i][4].(float64) < fused[j][4].(float64)
+	})
+	return fused
+}
+
+// lowestSimilarity returns the smallest similarity among rows, or 0.
+func lowestSimilarity(rows [][]any) float64 {
+	lowest := 1.0
+	for _, row := range rows {
+		lowest = min(lowest, rowSimilarity(row))
+	}
+	if len(rows) == 0 {
+		return 0
+	}
+	return lowest
+}
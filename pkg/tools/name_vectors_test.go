@@ -0,0 +1,100 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+// semanticRow builds a semantic search row for function id at the given
+// similarity.
+func semanticRow(id string, similarity float64) []any {
+	return []any{id, "a.go", "func " + id + "()", 1, 2 * (1 - similarity), "", id}
+}
+
+func TestFuseSimilarities(t *testing.T) {
+	t.Parallel()
+	body := [][]any{semanticRow("parseConfig", 0.80), semanticRow("loadFile", 0.78)}
+	names := [][]any{semanticRow("loadFile", 0.95), semanticRow("readSettings", 0.90)}
+
+	fused := fuseSimilarities(body, names, 0.5)
+	if len(fused) != 3 {
+		t.Fatalf("got %d rows, want 3", len(fused))
+	}
+	var order []string
+	for _, row := range fused {
+		order = append(order, row[6].(string))
+	}
+	if got := strings.Join(order, ","); got != "loadFile,parseConfig,readSettings" {
+		t.Errorf("order = %s", got)
+	}
+	// loadFile is in both lists. parseConfig takes the name floor 0.90,
+	// readSettings the body floor 0.78.
+	for i, want := range []float64{0.865, 0.85, 0.84} {
+		if sim := rowSimilarity(fused[i]); math.Abs(sim-want) > 1e-9 {
+			t.Errorf("%s similarity = %v, want %v", order[i], sim, want)
+		}
+	}
+	if body[0][4] != semanticRow("parseConfig", 0.80)[4] {
+		t.Error("input rows were modified")
+	}
+}
+
+func TestSemanticSearch_NameWeight(t *testing.T) {
+	t.Parallel()
+	url := embeddingServer(t)
+	search := func(nameRows [][]any) string {
+		client := NewMockClientCustom(func(_ context.Context, script string) (*QueryResult, error) {
+			cols := []string{"name", "file_path", "signature", "start_line", "distance", "code_text", "function_id"}
+			switch {
+			case strings.Contains(script, "~cie_function_name_embedding"):
+				return NewMockQueryResult(cols, nameRows), nil
+			case strings.Contains(script, "~cie_function_embedding"):
+				return NewMockQueryResult(cols, [][]any{semanticRow("HandleAuth", 0.9)}), nil
+			}
+			return &QueryResult{}, nil
+		}, nil)
+		result, err := SemanticSearch(context.Background(), client, SemanticSearchArgs{
+			Query:          "auth",
+			NameWeight:     DefaultNameWeight,
+			EmbeddingURL:   url,
+			EmbeddingModel: "nomic-embed-text",
+		})
+		assertNoError(t, err)
+		return result.Text
+	}
+
+	t.Run("blended", func(t *testing.T) {
+		text := search([][]any{semanticRow("Authenticate", 0.95)})
+		assertContains(t, text, "names weighted 30%")
+		assertContains(t, text, "Authenticate")
+		assertContains(t, text, "HandleAuth")
+	})
+	t.Run("no name vectors", func(t *testing.T) {
+		text := search(nil)
+		assertContains(t, text, "HandleAuth")
+		if strings.Contains(text, "names weighted") {
+			t.Errorf("header mentions name weighting without name vectors:\n%s", text)
+		}
+	})
+}
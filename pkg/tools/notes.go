@@ -0,0 +1,282 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Entity kinds: what a note or collection member refers to.
+const (
+	EntityKindFunction = "function"
+	EntityKindFile     = "file"
+)
+
+// maxNotesPerResult caps the notes appended to a search result.
+const maxNotesPerResult = 10
+
+// AddNoteArgs holds arguments for attaching a note.
+type AddNoteArgs struct {
+	Target   string // function name (e.g. "Store.Save") or file path
+	FilePath string // optional: narrows a function name matched in several files
+	Text     string
+	Author   string // optional: defaults to "agent"
+}
+
+// GetNotesArgs holds arguments for listing notes.
+type GetNotesArgs struct {
+	Target string // optional: function name or file path
+	Query  string // optional: text to find in the notes
+	Limit  int    // notes to show (default 50)
+}
+
+// EntityRef names a function (Name and FilePath) or a file (FilePath only)
+// that notes and collections refer to.
+type EntityRef struct {
+	Kind, Name, FilePath string
+}
+
+// label returns "name (file)" for functions and the path for files.
+func (r EntityRef) label() string {
+	if r.Kind == EntityKindFunction {
+		return fmt.Sprintf("`%s` (%s)", r.Name, r.FilePath)
+	}
+	return "`" + r.FilePath + "`"
+}
+
+// Note is a note attached to a function or file.
+type Note struct {
+	EntityRef
+	ID, Text, Author, Created string
+}
+
+// noteID derives a stable ID, so adding the same note twice stores it once.
+func noteID(kind, name, filePath, text string) string {
+	h := sha256.New()
+	h.Write([]byte(kind + "|" + name + "|" + filePath + "|" + text))
+	return "note:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// AddNote attaches a persistent note, such as "legacy path, don't extend",
+// to a function or file. Notes are kept when the project is re-indexed and
+// are shown with the function or file in later search results.
+func AddNote(ctx context.Context, client Querier, args AddNoteArgs) (*ToolResult, error) {
+	args.Target = strings.TrimSpace(args.Target)
+	args.Text = strings.TrimSpace(args.Text)
+	if args.Target == "" || args.Text == "" {
+		return NewInputError("Error: 'target' and 'text' are required"), nil
+	}
+	exec, ok := client.(Executor)
+	if !ok {
+		return NewError("Notes can only be added with a local index (this connection is read-only)"), nil
+	}
+	if args.Author == "" {
+		args.Author = "agent"
+	}
+
+	ref, candidates, err := resolveTarget(ctx, client, args.Target, args.FilePath)
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v", err)), nil
+	}
+	if len(candidates) > 1 {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "'%s' matches %d functions. Pass `file_path` to pick one:\n", args.Target, len(candidates))
+		for _, c := range candidates {
+			fmt.Fprintf(&sb, "- %s\n", c.label())
+		}
+		return NewInputError(sb.String()), nil
+	}
+	if ref == nil {
+		return NewInputError(unknownTargetMessage(args.Target)), nil
+	}
+
+	note := Note{EntityRef: *ref, Text: args.Text, Author: args.Author, Created: time.Now().UTC().Format(time.RFC3339)}
+	note.ID = noteID(note.Kind, note.Name, note.FilePath, note.Text)
+	script := fmt.Sprintf("?[id, kind, name, file_path, text, author, created] <- [[%q, %q, %q, %q, %q, %q, %q]] :put cie_note { id => kind, name, file_path, text, author, created }",
+		note.ID, note.Kind, note.Name, note.FilePath, note.Text, note.Author, note.Created)
+	if _, err := exec.Execute(ctx, script); err != nil {
+		return NewError(fmt.Sprintf("Cannot store note: %v (indexes built before cie_note need 'cie index')", err)), nil
+	}
+	return NewResult(fmt.Sprintf("Added note to %s:\n> %s", note.label(), note.Text)), nil
+}
+
+// unknownTargetMessage explains that target matched nothing in the index.
+func unknownTargetMessage(target string) string {
+	return fmt.Sprintf("No function or file named '%s' is indexed. Use `cie_find_function` or `cie_list_files` to find the exact name.", target)
+}
+
+// resolveTarget finds the function or file a target names. A file path wins
+// over a function name; several matching functions are returned as
+// candidates instead.
+func resolveTarget(ctx context.Context, client Querier, target, filePath string) (*EntityRef, []EntityRef, error) {
+	files, err := client.Query(ctx, fmt.Sprintf("?[path] := *cie_file { path }, path = %q", target))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(files.Rows) > 0 {
+		return &EntityRef{Kind: EntityKindFile, FilePath: target}, nil, nil
+	}
+
+	condition := fmt.Sprintf("(name = %q or ends_with(name, %q))", target, "."+target)
+	if filePath != "" {
+		condition += fmt.Sprintf(", str_includes(file_path, %q)", filePath)
+	}
+	funcs, err := client.Query(ctx, fmt.Sprintf("?[name, file_path] := *cie_function { name, file_path }, %s :order file_path :limit 20", condition))
+	if err != nil {
+		return nil, nil, err
+	}
+	var matches []EntityRef
+	for _, r := range funcs.Rows {
+		if len(r) >= 2 {
+			matches = append(matches, EntityRef{Kind: EntityKindFunction, Name: AnyToString(r[0]), FilePath: AnyToString(r[1])})
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, nil, nil
+	case 1:
+		return &matches[0], nil, nil
+	}
+	// An exact name beats methods that merely end with it.
+	var exact []EntityRef
+	for _, m := range matches {
+		if m.Name == target {
+			exact = append(exact, m)
+		}
+	}
+	if len(exact) == 1 {
+		return &exact[0], nil, nil
+	}
+	return nil, matches, nil
+}
+
+// GetNotes lists notes, optionally only those on a function or file or
+// containing some text.
+func GetNotes(ctx context.Context, client Querier, args GetNotesArgs) (*ToolResult, error) {
+	if args.Limit <= 0 {
+		args.Limit = 50
+	}
+	notes, err := loadNotes(ctx, client)
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v (indexes built before cie_note need 'cie index')", err)), nil
+	}
+
+	var selected []Note
+	for _, n := range notes {
+		if args.Target != "" && !n.matchesTarget(args.Target) {
+			continue
+		}
+		if args.Query != "" && !strings.Contains(strings.ToLower(n.Text), strings.ToLower(args.Query)) {
+			continue
+		}
+		selected = append(selected, n)
+	}
+	if len(selected) == 0 {
+		if len(notes) == 0 {
+			return NewResult("No notes yet. Use `cie_add_note` to attach one to a function or file."), nil
+		}
+		return NewResult("No notes match."), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "### Notes (%d)\n\n", len(selected))
+	for i, n := range selected {
+		if i == args.Limit {
+			fmt.Fprintf(&sb, "\n_%d more; narrow with `target` or `query`._\n", len(selected)-args.Limit)
+			break
+		}
+		fmt.Fprintf(&sb, "- %s: %s _(%s, %s)_\n", n.label(), n.Text, n.Author, n.Created)
+	}
+	return NewResult(sb.String()), nil
+}
+
+// matchesTarget reports whether r is the named function or file.
+func (r EntityRef) matchesTarget(target string) bool {
+	if r.Kind == EntityKindFile {
+		return r.FilePath == target || strings.HasSuffix(r.FilePath, "/"+target)
+	}
+	return r.Name == target || strings.HasSuffix(r.Name, "."+target) || r.FilePath == target
+}
+
+// loadNotes reads every note, newest first.
+func loadNotes(ctx context.Context, client Querier) ([]Note, error) {
+	result, err := client.Query(ctx, "?[id, kind, name, file_path, text, author, created] := *cie_note { id, kind, name, file_path, text, author, created } :order -created :limit 5000")
+	if err != nil {
+		return nil, err
+	}
+	notes := make([]Note, 0, len(result.Rows))
+	for _, r := range result.Rows {
+		if len(r) >= 7 {
+			notes = append(notes, Note{
+				EntityRef: EntityRef{Kind: AnyToString(r[1]), Name: AnyToString(r[2]), FilePath: AnyToString(r[3])},
+				ID:        AnyToString(r[0]),
+				Text:      AnyToString(r[4]),
+				Author:    AnyToString(r[5]),
+				Created:   AnyToString(r[6]),
+			})
+		}
+	}
+	return notes, nil
+}
+
+// appendNotes adds the notes on the given functions and files to a search
+// result. rows are [name, file_path] pairs. Indexes without notes, or
+// built before cie_note, leave the output unchanged.
+func appendNotes(ctx context.Context, client Querier, output string, rows [][2]string) string {
+	if len(rows) == 0 {
+		return output
+	}
+	notes, err := loadNotes(ctx, client)
+	if err != nil || len(notes) == 0 {
+		return output
+	}
+	functions := make(map[[2]string]bool, len(rows))
+	files := make(map[string]bool, len(rows))
+	for _, r := range rows {
+		functions[r] = true
+		files[r[1]] = true
+	}
+
+	var sb strings.Builder
+	count := 0
+	for _, n := range notes {
+		on := files[n.FilePath]
+		if n.Kind == EntityKindFunction {
+			on = functions[[2]string{n.Name, n.FilePath}]
+		}
+		if !on {
+			continue
+		}
+		if count == maxNotesPerResult {
+			sb.WriteString("- _more notes: see `cie_get_notes`_\n")
+			break
+		}
+		fmt.Fprintf(&sb, "- %s: %s _(%s)_\n", n.label(), n.Text, n.Author)
+		count++
+	}
+	if count == 0 {
+		return output
+	}
+	return output + "\n\n**Notes:**\n" + sb.String()
+}
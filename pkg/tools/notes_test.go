@@ -0,0 +1,138 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"testing"
+)
+
+var testNotes = [][]any{
+	{"note:1", "function", "Store.Save", "internal/store/store.go", "Legacy path, don't extend", "alice", "2026-01-02T10:00:00Z"},
+	{"note:2", "file", "", "internal/auth/legacy.go", "Scheduled for removal in v3", "agent", "2026-01-01T10:00:00Z"},
+}
+
+// notesMock answers the target and note queries of the notes tools with
+// functions for the function lookup, which must contain filters.
+func notesMock(t *testing.T, functions [][]any, filters ...string) *mockExecClient {
+	return &mockExecClient{MockCIEClient: *NewMockClientScripted(t,
+		MockQuery{
+			Match: []string{"?[path] := *cie_file", `path = "internal/auth/legacy.go"`},
+			Rows:  [][]any{{"internal/auth/legacy.go"}},
+		},
+		MockQuery{
+			Match: []string{"?[path] := *cie_file"},
+		},
+		MockQuery{
+			Match: []string{"?[name, file_path] := *cie_function"},
+			Want:  append([]string{"*cie_function { name, file_path }", ":limit 20"}, filters...),
+			Rows:  functions,
+		},
+		MockQuery{
+			Match: []string{"*cie_note"},
+			Want:  []string{"*cie_note { id, kind, name, file_path, text, author, created }", ":order -created"},
+			Rows:  testNotes,
+		},
+	)}
+}
+
+func TestAddNote(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("function", func(t *testing.T) {
+		client := notesMock(t, [][]any{{"Store.Save", "internal/store/store.go"}}, `(name = "Save" or ends_with(name, ".Save"))`)
+		result, err := AddNote(ctx, client, AddNoteArgs{Target: "Save", Text: "Legacy path, don't extend"})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "Added note to `Store.Save` (internal/store/store.go)")
+		assertContains(t, client.executed, `:put cie_note`)
+		assertContains(t, client.executed, `"function", "Store.Save", "internal/store/store.go", "Legacy path, don't extend", "agent"`)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		client := notesMock(t, nil)
+		result, err := AddNote(ctx, client, AddNoteArgs{Target: "internal/auth/legacy.go", Text: "Scheduled for removal", Author: "bob"})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "Added note to `internal/auth/legacy.go`")
+		assertContains(t, client.executed, `"file", "", "internal/auth/legacy.go", "Scheduled for removal", "bob"`)
+	})
+
+	t.Run("ambiguous", func(t *testing.T) {
+		client := notesMock(t, [][]any{{"A.Save", "a.go"}, {"B.Save", "b.go"}}, `(name = "Save" or ends_with(name, ".Save"))`)
+		result, err := AddNote(ctx, client, AddNoteArgs{Target: "Save", Text: "x"})
+		assertNoError(t, err)
+		if !result.IsError {
+			t.Fatal("expected an error asking for file_path")
+		}
+		assertContains(t, result.Text, "matches 2 functions")
+		assertEqual(t, client.executed, "")
+	})
+
+	t.Run("unknown target", func(t *testing.T) {
+		client := notesMock(t, nil, `(name = "Nope" or ends_with(name, ".Nope"))`)
+		result, err := AddNote(ctx, client, AddNoteArgs{Target: "Nope", Text: "x"})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "No function or file named 'Nope'")
+	})
+
+	t.Run("read-only client", func(t *testing.T) {
+		result, err := AddNote(ctx, NewMockClientEmpty(), AddNoteArgs{Target: "Save", Text: "x"})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "read-only")
+	})
+}
+
+func TestNoteID_Stable(t *testing.T) {
+	a := noteID("function", "Save", "a.go", "text")
+	assertEqual(t, a, noteID("function", "Save", "a.go", "text"))
+	if a == noteID("function", "Save", "b.go", "text") {
+		t.Error("notes on different targets must get different IDs")
+	}
+}
+
+func TestGetNotes(t *testing.T) {
+	ctx := context.Background()
+
+	result, err := GetNotes(ctx, notesMock(t, nil), GetNotesArgs{})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "### Notes (2)")
+	assertContains(t, result.Text, "- `Store.Save` (internal/store/store.go): Legacy path, don't extend _(alice, 2026-01-02T10:00:00Z)_")
+
+	result, err = GetNotes(ctx, notesMock(t, nil), GetNotesArgs{Target: "Save"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "### Notes (1)")
+
+	result, err = GetNotes(ctx, notesMock(t, nil), GetNotesArgs{Query: "REMOVAL"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "`internal/auth/legacy.go`: Scheduled for removal in v3")
+	assertNotContains(t, result.Text, "Store.Save")
+}
+
+func TestAppendNotes(t *testing.T) {
+	ctx := context.Background()
+	client := notesMock(t, nil)
+
+	out := appendNotes(ctx, client, "results", [][2]string{{"Store.Save", "internal/store/store.go"}, {"Check", "internal/auth/legacy.go"}})
+	assertContains(t, out, "**Notes:**")
+	assertContains(t, out, "Legacy path, don't extend")
+	assertContains(t, out, "Scheduled for removal in v3")
+
+	out = appendNotes(ctx, client, "results", [][2]string{{"Store.Load", "internal/store/store.go"}})
+	assertEqual(t, out, "results")
+}
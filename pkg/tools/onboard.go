@@ -0,0 +1,253 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/kraklabs/cie/pkg/llm"
+)
+
+// BuildCommand is a build, test or run command found in the repository's
+// own tooling files (Makefile targets, package.json scripts, ...).
+type BuildCommand struct {
+	Command string `json:"command"`
+	Source  string `json:"source"` // file the command was detected from
+}
+
+// OnboardingArgs holds inputs for the repository tour.
+type OnboardingArgs struct {
+	Title         string
+	BuildCommands []BuildCommand
+	MaxPackages   int          // main packages to list (default 8)
+	LLM           llm.Provider // when set, main packages get a written overview
+	MaxTokens     int
+}
+
+// dataModelPathPattern marks directories that usually hold a data model.
+const dataModelPathPattern = `(?i)(^|/)(models?|entit(y|ies)|domain|schemas?|dto|types)(/|[.])`
+
+// OnboardingReport builds a markdown "repo tour" for someone new to the
+// codebase: layout, entry points, the packages everything else depends on,
+// HTTP endpoints, data model types and how to build and test.
+func OnboardingReport(ctx context.Context, client Querier, args OnboardingArgs) (string, error) {
+	if args.MaxPackages <= 0 {
+		args.MaxPackages = 8
+	}
+
+	tree, err := Tree(ctx, client, TreeArgs{Depth: 2})
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	title := args.Title
+	if title == "" {
+		title = "Repository Tour"
+	}
+	fmt.Fprintf(&sb, "# %s\n\n", title)
+	sb.WriteString("_Generated by `cie onboard` from the code index. Regenerate after large changes._\n\n")
+
+	// Keep only the fenced tree, not the tool's heading and hint.
+	layout := tree.Text
+	if start, end := strings.Index(layout, "```"), strings.LastIndex(layout, "```"); start >= 0 && end > start {
+		layout = layout[start:end+3] + "\n"
+	}
+	sb.WriteString("## Layout\n\n" + layout + "\n")
+
+	sb.WriteString(onboardingEntryPoints(ctx, client))
+	packages, err := rankPackagesByInboundCalls(ctx, client, args.MaxPackages)
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString(onboardingPackages(ctx, client, packages, args))
+	sb.WriteString(onboardingEndpoints(ctx, client))
+	sb.WriteString(onboardingDataModel(ctx, client))
+	sb.WriteString(onboardingBuildCommands(args.BuildCommands))
+	return sb.String(), nil
+}
+
+func onboardingEntryPoints(ctx context.Context, client Querier) string {
+	query := `?[file_path, start_line] := *cie_function { name, file_path, start_line }, name = "main" :order file_path :limit 50`
+	result, err := client.Query(ctx, query)
+	if err != nil {
+		return ""
+	}
+	var lines []string
+	for _, row := range result.Rows {
+		file := AnyToString(row[0])
+		if testFilePattern.MatchString(file) || generatedFilePattern.MatchString(file) {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("- `%s:%s` — `%s`", file, AnyToString(row[1]), path.Dir(file)))
+	}
+	if len(lines) == 0 {
+		return "## Entry Points\n\n_No `main` functions found. Look for framework bootstrap files (e.g. `index.ts`, `app.py`)._\n\n"
+	}
+	return formatCappedSection("## Entry Points", lines, 20)
+}
+
+// rankedPackage is a directory ranked by how many calls reach it from elsewhere.
+type rankedPackage struct {
+	Dir     string
+	Inbound int
+}
+
+// rankPackagesByInboundCalls returns the directories with the most calls from
+// other directories, ignoring tests and generated code.
+func rankPackagesByInboundCalls(ctx context.Context, client Querier, limit int) ([]rankedPackage, error) {
+	calls, err := callsBetween(ctx, client, path.Dir)
+	if err != nil {
+		return nil, err
+	}
+	inbound := map[string]int{}
+	for edge, n := range calls {
+		if edge.from != edge.to {
+			inbound[edge.to] += n
+		}
+	}
+	ranked := make([]rankedPackage, 0, len(inbound))
+	for dir, n := range inbound {
+		ranked = append(ranked, rankedPackage{Dir: dir, Inbound: n})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Inbound != ranked[j].Inbound {
+			return ranked[i].Inbound > ranked[j].Inbound
+		}
+		return ranked[i].Dir < ranked[j].Dir
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked, nil
+}
+
+// callEdge is a pair of file groups linked by calls.
+type callEdge struct {
+	from, to string
+}
+
+// callsBetween counts the calls between source files, grouped by group of
+// the caller's and the callee's file. Tests and generated code are ignored.
+func callsBetween(ctx context.Context, client Querier, group func(file string) string) (map[callEdge]int, error) {
+	query := "?[caller_file, callee_file, count(caller_id)] := *cie_calls { caller_id, callee_id }, *cie_function { id: caller_id, file_path: caller_file }, *cie_function { id: callee_id, file_path: callee_file }"
+	result, err := client.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("count calls: %w", err)
+	}
+	calls := map[callEdge]int{}
+	for _, row := range result.Rows {
+		caller, callee := AnyToString(row[0]), AnyToString(row[1])
+		if !MatchesRoleFilter(caller, "source") || !MatchesRoleFilter(callee, "source") {
+			continue
+		}
+		calls[callEdge{from: group(caller), to: group(callee)}] += int(toFloat64(row[2]))
+	}
+	return calls, nil
+}
+
+func onboardingPackages(ctx context.Context, client Querier, packages []rankedPackage, args OnboardingArgs) string {
+	if len(packages) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("## Main Packages\n\nRanked by calls from other packages — the code most of the repository depends on.\n\n")
+	for _, pkg := range packages {
+		fmt.Fprintf(&sb, "### `%s` (%d inbound calls)\n\n", pkg.Dir, pkg.Inbound)
+		scope := packageScope{label: pkg.Dir, regex: "^" + EscapeRegex(pkg.Dir) + "/[^/]+$"}
+		key := packageKeyFunctionsSection(ctx, client, scope)
+		key = strings.Replace(key, "## Key Functions (by callers)\n\n", "", 1)
+		if args.LLM != nil {
+			summary := packageTypesSection(ctx, client, scope) + key
+			sb.WriteString(strings.TrimPrefix(packageNarrative(ctx, PackageSummaryArgs{LLM: args.LLM, MaxTokens: args.MaxTokens}, pkg.Dir, summary), "## Overview\n\n"))
+			sb.WriteString("\n")
+		}
+		sb.WriteString(capLines(key, 5))
+	}
+	return sb.String()
+}
+
+// capLines keeps the first n list items of a section.
+func capLines(section string, n int) string {
+	lines := strings.Split(strings.TrimRight(section, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[:n]
+	}
+	return strings.Join(lines, "\n") + "\n\n"
+}
+
+func onboardingEndpoints(ctx context.Context, client Querier) string {
+	endpoints, err := collectEndpoints(ctx, client, ListEndpointsArgs{Limit: 100})
+	if err != nil || len(endpoints) == 0 {
+		return ""
+	}
+	var prod []endpoint
+	for _, ep := range endpoints {
+		if MatchesRoleFilter(ep.FilePath, "source") {
+			prod = append(prod, ep)
+		}
+	}
+	if len(prod) == 0 {
+		return ""
+	}
+	note := ""
+	if len(prod) > 50 {
+		note = fmt.Sprintf("\nShowing 50 of %d. Run `cie_list_endpoints` for the full list.\n", len(prod))
+		prod = prod[:50]
+	}
+	return fmt.Sprintf("## HTTP Endpoints (%d)\n\n%s%s\n", len(prod), formatEndpointTable(prod), note)
+}
+
+func onboardingDataModel(ctx context.Context, client Querier) string {
+	query := fmt.Sprintf(
+		`?[name, kind, file_path, start_line] := *cie_type { name, kind, file_path, start_line }, kind != "type_alias", regex_matches(file_path, %s) :order file_path :limit 200`,
+		QuoteCozoPattern(dataModelPathPattern))
+	result, err := client.Query(ctx, query)
+	if err != nil {
+		return ""
+	}
+	var lines []string
+	for _, row := range result.Rows {
+		name, file := AnyToString(row[0]), AnyToString(row[2])
+		if !MatchesRoleFilter(file, "source") || !isExportedName(name, file) {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("- **%s** (%s) — `%s:%s`", name, AnyToString(row[1]), file, AnyToString(row[3])))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return formatCappedSection("## Data Model", lines, 40)
+}
+
+func onboardingBuildCommands(commands []BuildCommand) string {
+	if len(commands) == 0 {
+		return "## Build & Test\n\n_No Makefile, package.json, go.mod, pyproject.toml or Cargo.toml found at the repository root._\n"
+	}
+	var sb strings.Builder
+	sb.WriteString("## Build & Test\n\n| Command | From |\n|---------|------|\n")
+	for _, c := range commands {
+		fmt.Fprintf(&sb, "| `%s` | %s |\n", c.Command, c.Source)
+	}
+	return sb.String()
+}
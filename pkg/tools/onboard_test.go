@@ -0,0 +1,76 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestOnboardingReport(t *testing.T) {
+	ctx := setupTest(t)
+	client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		switch {
+		case strings.Contains(script, "?[path, language]"):
+			return NewMockQueryResult([]string{"path", "language"}, [][]any{
+				{"cmd/app/main.go", "go"}, {"internal/store/store.go", "go"}, {"internal/models/user.go", "go"},
+			}), nil
+		case strings.Contains(script, `name = "main"`):
+			return NewMockQueryResult([]string{"file_path", "start_line"}, [][]any{
+				{"cmd/app/main.go", int64(9)}, {"cmd/app/main_test.go", int64(3)},
+			}), nil
+		case strings.Contains(script, "?[caller_file, callee_file"):
+			return NewMockQueryResult([]string{"caller_file", "callee_file", "count"}, [][]any{
+				{"cmd/app/main.go", "internal/store/store.go", int64(4)},
+				{"internal/store/a.go", "internal/store/store.go", int64(50)},
+				{"cmd/app/main.go", "internal/store/store_test.go", int64(9)},
+			}), nil
+		case strings.Contains(script, "*cie_type") && strings.Contains(script, "models"):
+			return NewMockQueryResult([]string{"name", "kind", "file_path", "start_line"}, [][]any{
+				{"User", "struct", "internal/models/user.go", int64(5)},
+			}), nil
+		}
+		return NewMockQueryResult(nil, nil), nil
+	}, nil)
+
+	report, err := OnboardingReport(ctx, client, OnboardingArgs{
+		Title:         "demo",
+		BuildCommands: []BuildCommand{{Command: "make test", Source: "Makefile"}},
+	})
+	assertNoError(t, err)
+
+	for _, want := range []string{
+		"# demo",
+		"## Layout",
+		"├── internal/",
+		"- `cmd/app/main.go:9`",
+		"### `internal/store` (4 inbound calls)",
+		"- **User** (struct) — `internal/models/user.go:5`",
+		"| `make test` | Makefile |",
+	} {
+		assertContains(t, report, want)
+	}
+	if strings.Contains(report, "main_test.go") {
+		t.Error("test entry points should be skipped")
+	}
+	if strings.Contains(report, "HTTP Endpoints") {
+		t.Error("endpoint section should be omitted when none are found")
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Outline mode of ListFunctionsInFile, GetFileSummary and GetTypeCode:
+// one line per declaration with its signature and no bodies, for models
+// with small context windows.
+
+// outlineEntry is one declaration of an outline.
+type outlineEntry struct {
+	Line   int
+	Text   string   // signature, or kind and name for types
+	Fields []string // field names of a type
+}
+
+// writeOutline renders entries as a fenced block, one declaration per line
+// prefixed with its line number.
+func writeOutline(sb *strings.Builder, lang string, entries []outlineEntry) {
+	fmt.Fprintf(sb, "```%s\n", lang)
+	for _, e := range entries {
+		fmt.Fprintf(sb, "%5d  %s\n", e.Line, e.Text)
+		if len(e.Fields) > 0 {
+			fmt.Fprintf(sb, "       { %s }\n", strings.Join(e.Fields, ", "))
+		}
+	}
+	sb.WriteString("```\n")
+}
+
+// functionOutlineEntry returns the outline entry of a function.
+func functionOutlineEntry(name, signature string, line any) outlineEntry {
+	return outlineEntry{Line: int(toFloat64(line)), Text: outlineSignature(name, signature)}
+}
+
+// outlineSignature returns a signature on one line, or the name when the
+// index has no signature.
+func outlineSignature(name, signature string) string {
+	if text := strings.Join(strings.Fields(signature), " "); text != "" {
+		return text
+	}
+	return name
+}
+
+// formatFunctionOutline renders ListFunctionsInFile rows ([name, signature,
+// start_line, ...]) as an outline.
+func formatFunctionOutline(path string, rows [][]any) string {
+	entries := make([]outlineEntry, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < 3 {
+			continue
+		}
+		entries = append(entries, functionOutlineEntry(anyToStr(row[0]), anyToStr(row[1]), row[2]))
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**Outline of %s** (%d functions)\n\n", path, len(entries))
+	writeOutline(&sb, detectLanguage(path), entries)
+	return sb.String()
+}
+
+// formatFileOutline renders GetFileSummary's types ([name, kind,
+// start_line, file_path]) and functions ([name, signature, start_line]) as
+// one outline in line order, listing the field names of each type.
+func formatFileOutline(ctx context.Context, client Querier, filePath string, typeRows, funcRows [][]any) string {
+	paths := make(map[string]bool)
+	for _, row := range typeRows {
+		paths[anyToStr(row[3])] = true
+	}
+	fields := fileFieldNames(ctx, client, sortedKeys(paths))
+	entries := make([]outlineEntry, 0, len(typeRows)+len(funcRows))
+	for _, row := range typeRows {
+		name := anyToStr(row[0])
+		entries = append(entries, outlineEntry{Line: int(toFloat64(row[2])), Text: anyToStr(row[1]) + " " + name, Fields: fields[fieldKey(row[3], name)]})
+	}
+	for _, row := range funcRows {
+		entries = append(entries, functionOutlineEntry(anyToStr(row[0]), anyToStr(row[1]), row[2]))
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Line < entries[j].Line })
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Outline of %s\n\n", filePath)
+	writeOutline(&sb, detectLanguage(filePath), entries)
+	fmt.Fprintf(&sb, "\n**Total**: %d types, %d functions/methods\n", len(typeRows), len(funcRows))
+	return sb.String()
+}
+
+// fileFieldNames returns the field names of the structs in the given
+// files, keyed by fieldKey and in line order.
+func fileFieldNames(ctx context.Context, client Querier, paths []string) map[string][]string {
+	if len(paths) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(paths))
+	for i, p := range paths {
+		quoted[i] = fmt.Sprintf("%q", p)
+	}
+	script := fmt.Sprintf(`?[file_path, struct_name, field_name, line] := *cie_field { struct_name, field_name, file_path, line }, is_in(file_path, [%s]) :order line :limit 500`, strings.Join(quoted, ", "))
+	result, err := client.Query(ctx, script)
+	if err != nil {
+		return nil
+	}
+	fields := make(map[string][]string)
+	for _, row := range result.Rows {
+		if len(row) == 4 {
+			key := fieldKey(row[0], anyToStr(row[1]))
+			fields[key] = append(fields[key], anyToStr(row[2]))
+		}
+	}
+	return fields
+}
+
+// fieldKey identifies a struct by file and name.
+func fieldKey(filePath any, structName string) string {
+	return anyToStr(filePath) + "|" + structName
+}
+
+// formatTypeOutline renders a type without bodies: its declaration with
+// field names and types, or an interface's code without comments, followed
+// by the signatures of its methods.
+func formatTypeOutline(ctx context.Context, client Querier, typeID, typeName, kind, path, startLine, endLine, codeText string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "### %s (%s, outline)\n\n", typeName, kind)
+	fmt.Fprintf(&sb, "**File:** %s:%s-%s\n\n", path, startLine, endLine)
+	fmt.Fprintf(&sb, "```%s\n%s\n```\n", detectLanguage(path), typeDeclarationOutline(ctx, client, typeName, kind, path, codeText))
+
+	methods, err := typeMethods(ctx, client, typeID, typeName, path, false)
+	if err == nil && len(methods) > 0 {
+		fmt.Fprintf(&sb, "\n**Methods (%d):**\n", len(methods))
+		for _, m := range methods {
+			fmt.Fprintf(&sb, "- `%s` — %s:%s\n", outlineSignature(m.Name, m.Signature), m.FilePath, m.StartLine)
+		}
+	}
+	return sb.String()
+}
+
+// typeDeclarationOutline returns the bodiless declaration of a type: the
+// first line of its code followed by its indexed fields, the code of an
+// interface without comments and blank lines, or the first line alone.
+func typeDeclarationOutline(ctx context.Context, client Querier, typeName, kind, path, codeText string) string {
+	lines := strings.Split(strings.TrimSpace(codeText), "\n")
+	header := strings.TrimRight(lines[0], " \t")
+
+	script := fmt.Sprintf(`?[field_name, field_type, line] := *cie_field { struct_name, field_name, field_type, file_path, line }, struct_name = %q, file_path = %q :order line :limit 200`, typeName, path)
+	if result, err := client.Query(ctx, script); err == nil && len(result.Rows) > 0 {
+		var sb strings.Builder
+		sb.WriteString(header)
+		for _, row := range result.Rows {
+			if len(row) == 3 {
+				fmt.Fprintf(&sb, "\n\t%s", strings.TrimSpace(anyToStr(row[0])+" "+anyToStr(row[1])))
+			}
+		}
+		if strings.HasSuffix(header, "{") {
+			sb.WriteString("\n}")
+		}
+		return sb.String()
+	}
+
+	if kind != "interface" {
+		return header
+	}
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "*") || strings.HasPrefix(trimmed, "/*") {
+			continue
+		}
+		kept = append(kept, strings.TrimRight(line, " \t"))
+	}
+	return strings.Join(kept, "\n")
+}
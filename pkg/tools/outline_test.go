@@ -0,0 +1,97 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// outlineMock serves server.go: a Server struct with two fields, a
+// constructor and a method.
+func outlineMock() Querier {
+	return NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		switch {
+		case strings.Contains(script, "?[file_path, struct_name, field_name, line]"):
+			if !strings.Contains(script, `is_in(file_path, ["server.go"])`) {
+				return NewMockQueryResult(nil, nil), nil
+			}
+			return NewMockQueryResult([]string{"file_path", "struct_name", "field_name", "line"}, [][]any{{"server.go", "Server", "addr", int64(11)}, {"server.go", "Server", "logger", int64(12)}}), nil
+		case strings.Contains(script, "?[field_name, field_type, line]"):
+			return NewMockQueryResult([]string{"field_name", "field_type", "line"}, [][]any{{"addr", "string", int64(11)}, {"logger", "*slog.Logger", int64(12)}}), nil
+		case strings.Contains(script, "*cie_method_of"):
+			return NewMockQueryResult([]string{"name", "signature", "file_path", "start_line"}, [][]any{{"Server.Start", "func (s *Server) Start(\n\tctx context.Context,\n) error", "server.go", int64(20)}}), nil
+		case strings.Contains(script, "?[name, kind, start_line, file_path]"):
+			return NewMockQueryResult([]string{"name", "kind", "start_line", "file_path"}, [][]any{{"Server", "struct", int64(10), "server.go"}}), nil
+		case strings.Contains(script, "?[name, signature, start_line]"):
+			return NewMockQueryResult([]string{"name", "signature", "start_line"}, [][]any{
+				{"NewServer", "func NewServer(addr string) *Server", int64(15)},
+				{"Server.Start", "func (s *Server) Start(ctx context.Context) error", int64(20)},
+			}), nil
+		case strings.Contains(script, "?[name, signature, start_line, file_path]"):
+			return NewMockQueryResult([]string{"name", "signature", "start_line", "file_path"}, [][]any{
+				{"NewServer", "func NewServer(addr string) *Server", int64(15), "server.go"},
+				{"Server.Start", "func (s *Server) Start(ctx context.Context) error", int64(20), "server.go"},
+			}), nil
+		case strings.Contains(script, "*cie_type {"):
+			return NewMockQueryResult([]string{"id", "name", "kind", "file_path", "start_line", "end_line", "code_text"}, [][]any{
+				{"type:server", "Server", "struct", "server.go", int64(10), int64(13), "type Server struct {\n\t// addr to listen on\n\taddr string\n\tlogger *slog.Logger\n}"},
+			}), nil
+		}
+		return NewMockQueryResult(nil, nil), nil
+	}, nil)
+}
+
+func TestListFunctionsInFile_Outline(t *testing.T) {
+	result, err := ListFunctionsInFile(context.Background(), outlineMock(), ListFunctionsInFileArgs{FilePath: "server.go", Outline: true})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "**Outline of server.go** (2 functions)")
+	assertContains(t, result.Text, "```go\n   15  func NewServer(addr string) *Server\n   20  func (s *Server) Start(ctx context.Context) error\n```")
+}
+
+func TestGetFileSummary_Outline(t *testing.T) {
+	result, err := GetFileSummary(context.Background(), outlineMock(), GetFileSummaryArgs{FilePath: "server.go", Outline: true})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "   10  struct Server\n       { addr, logger }\n   15  func NewServer(addr string) *Server\n")
+	assertContains(t, result.Text, "**Total**: 1 types, 2 functions/methods")
+	assertNotContains(t, result.Text, "## Types")
+}
+
+func TestGetTypeCode_Outline(t *testing.T) {
+	result, err := GetTypeCode(context.Background(), outlineMock(), GetTypeCodeArgs{Name: "Server", Outline: true})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "### Server (struct, outline)")
+	assertContains(t, result.Text, "type Server struct {\n\taddr string\n\tlogger *slog.Logger\n}")
+	assertContains(t, result.Text, "- `func (s *Server) Start( ctx context.Context, ) error` — server.go:20")
+	assertNotContains(t, result.Text, "addr to listen on")
+}
+
+func TestTypeDeclarationOutline_Interface(t *testing.T) {
+	code := "type Store interface {\n\t// Get loads a user.\n\tGet(id string) (*User, error)\n\n\tPut(u *User) error\n}"
+	got := typeDeclarationOutline(context.Background(), NewMockClientEmpty(), "Store", "interface", "store.go", code)
+	want := "type Store interface {\n\tGet(id string) (*User, error)\n\tPut(u *User) error\n}"
+	if got != want {
+		t.Errorf("typeDeclarationOutline() =\n%s\nwant\n%s", got, want)
+	}
+	if got := typeDeclarationOutline(context.Background(), NewMockClientEmpty(), "User", "class", "user.py", "class User:\n    def save(self):\n        pass"); got != "class User:" {
+		t.Errorf("class outline = %q, want the declaration line", got)
+	}
+}
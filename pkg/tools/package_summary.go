@@ -0,0 +1,282 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/kraklabs/cie/pkg/llm"
+)
+
+// PackageSummaryArgs holds arguments for summarising one package or module.
+type PackageSummaryArgs struct {
+	Path      string       // package directory (Go) or module file/directory (TS/JS, Python)
+	Narrative bool         // ask the LLM for a short overview paragraph
+	LLM       llm.Provider // required for Narrative; nil disables it
+	MaxTokens int
+}
+
+const (
+	packageSummaryTypes     = 25
+	packageSummaryFunctions = 10
+	packageSummaryDeps      = 15
+)
+
+// packageScope is the set of files that make up the package being summarised.
+type packageScope struct {
+	label string
+	regex string // Cozo/Go regex matching the package's file paths
+}
+
+// PackageSummary lists a package's exported types, its most-called functions,
+// what it imports, and which packages call into it or are called by it.
+// With Narrative set and an LLM configured it adds a short written overview.
+func PackageSummary(ctx context.Context, client Querier, args PackageSummaryArgs) (*ToolResult, error) {
+	if strings.TrimSpace(args.Path) == "" {
+		return NewInputError("Error: 'path' is required"), nil
+	}
+	scope, files, err := resolvePackageScope(ctx, client, args.Path)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return NewResult(fmt.Sprintf("No indexed files for package `%s`\n\nUse `cie_tree` to see available directories.", args.Path)), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Package Summary: `%s`\n\n", scope.label)
+	fmt.Fprintf(&sb, "%d files", len(files))
+	if lang := dominantFileLanguage(files); lang != "" {
+		fmt.Fprintf(&sb, " (%s)", lang)
+	}
+	sb.WriteString("\n\n")
+
+	sections := []func(context.Context, Querier, packageScope) string{
+		packageTypesSection,
+		packageKeyFunctionsSection,
+		packageImportsSection,
+		packageCallersSection,
+		packageCalleesSection,
+	}
+	for _, section := range sections {
+		sb.WriteString(section(ctx, client, scope))
+	}
+
+	if args.Narrative {
+		sb.WriteString(packageNarrative(ctx, args, scope.label, sb.String()))
+	}
+	return NewResult(sb.String()), nil
+}
+
+// resolvePackageScope maps the user's path to a package: an exact file is a
+// module on its own, otherwise the files directly inside the directory.
+func resolvePackageScope(ctx context.Context, client Querier, p string) (packageScope, []string, error) {
+	p = strings.Trim(p, "/")
+	exact := fmt.Sprintf("?[path] := *cie_file { path }, path = %q", p)
+	if result, err := client.Query(ctx, exact); err == nil && len(result.Rows) > 0 {
+		return packageScope{label: p, regex: "^" + EscapeRegex(p) + "$"}, []string{p}, nil
+	}
+
+	scope := packageScope{label: p, regex: "^" + EscapeRegex(p) + "/[^/]+$"}
+	query := fmt.Sprintf("?[path] := *cie_file { path }, regex_matches(path, %s) :order path", QuoteCozoPattern(scope.regex))
+	result, err := client.Query(ctx, query)
+	if err != nil {
+		return scope, nil, fmt.Errorf("package files query: %w", err)
+	}
+	files := make([]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		files = append(files, AnyToString(row[0]))
+	}
+	return scope, files, nil
+}
+
+func dominantFileLanguage(files []string) string {
+	counts := map[string]int{}
+	for _, f := range files {
+		if lang := detectLanguage(f); lang != "unknown" {
+			counts[lang]++
+		}
+	}
+	best := ""
+	for lang, n := range counts {
+		if n > counts[best] || n == counts[best] && lang < best {
+			best = lang
+		}
+	}
+	return best
+}
+
+// isExportedName reports whether a symbol is part of the public API by the
+// naming rules of its language. Languages without a naming convention
+// (TypeScript, JavaScript) count every top-level name.
+func isExportedName(name, filePath string) bool {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" || strings.HasPrefix(name, "$") || anonymousFunctionPattern.MatchString(name) {
+		return false
+	}
+	switch detectLanguage(filePath) {
+	case "go":
+		return unicode.IsUpper([]rune(name)[0])
+	case "python":
+		return !strings.HasPrefix(name, "_")
+	}
+	return true
+}
+
+func packageTypesSection(ctx context.Context, client Querier, scope packageScope) string {
+	query := fmt.Sprintf("?[name, kind, file_path, start_line] := *cie_type { name, kind, file_path, start_line }, regex_matches(file_path, %s) :order name", QuoteCozoPattern(scope.regex))
+	result, err := client.Query(ctx, query)
+	if err != nil {
+		return ""
+	}
+	var lines []string
+	for _, row := range result.Rows {
+		name, file := AnyToString(row[0]), AnyToString(row[2])
+		if !isExportedName(name, file) {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("- **%s** (%s) — `%s:%s`", name, AnyToString(row[1]), ExtractFileName(file), AnyToString(row[3])))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return formatCappedSection(fmt.Sprintf("## Exported Types (%d)", len(lines)), lines, packageSummaryTypes)
+}
+
+// packageKeyFunctionsSection ranks exported functions by how many call sites
+// reach them, a cheap proxy for centrality.
+func packageKeyFunctionsSection(ctx context.Context, client Querier, scope packageScope) string {
+	query := fmt.Sprintf(
+		"?[name, signature, file_path, start_line, count(caller_id)] := *cie_function { id, name, signature, file_path, start_line }, regex_matches(file_path, %s), *cie_calls { caller_id, callee_id: id }",
+		QuoteCozoPattern(scope.regex))
+	result, err := client.Query(ctx, query)
+	if err != nil {
+		return ""
+	}
+	rows := result.Rows
+	sort.SliceStable(rows, func(i, j int) bool { return toFloat64(rows[i][4]) > toFloat64(rows[j][4]) })
+
+	var lines []string
+	for _, row := range rows {
+		name, file := AnyToString(row[0]), AnyToString(row[2])
+		if !isExportedName(name, file) {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("- **%s** — %d callers — `%s` (`%s:%s`)",
+			name, int(toFloat64(row[4])), truncateBinding(AnyToString(row[1])), ExtractFileName(file), AnyToString(row[3])))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return formatCappedSection("## Key Functions (by callers)", lines, packageSummaryFunctions)
+}
+
+func packageImportsSection(ctx context.Context, client Querier, scope packageScope) string {
+	query := fmt.Sprintf("?[import_path, count(file_path)] := *cie_import { file_path, import_path }, regex_matches(file_path, %s)", QuoteCozoPattern(scope.regex))
+	result, err := client.Query(ctx, query)
+	if err != nil || len(result.Rows) == 0 {
+		return ""
+	}
+	counts := map[string]int{}
+	for _, row := range result.Rows {
+		counts[AnyToString(row[0])] = int(toFloat64(row[1]))
+	}
+	return formatCountSection("## Imports (outbound)", counts, "files")
+}
+
+// packageCallersSection lists the directories whose functions call into the package.
+func packageCallersSection(ctx context.Context, client Querier, scope packageScope) string {
+	re := QuoteCozoPattern(scope.regex)
+	query := fmt.Sprintf(
+		"?[caller_file, count(caller_id)] := *cie_calls { caller_id, callee_id }, *cie_function { id: callee_id, file_path: callee_file }, regex_matches(callee_file, %s), *cie_function { id: caller_id, file_path: caller_file }, !regex_matches(caller_file, %s)",
+		re, re)
+	return packageEdgeSection(ctx, client, query, "## Used By (inbound calls)")
+}
+
+// packageCalleesSection lists the indexed directories the package calls into.
+func packageCalleesSection(ctx context.Context, client Querier, scope packageScope) string {
+	re := QuoteCozoPattern(scope.regex)
+	query := fmt.Sprintf(
+		"?[callee_file, count(callee_id)] := *cie_calls { caller_id, callee_id }, *cie_function { id: caller_id, file_path: caller_file }, regex_matches(caller_file, %s), *cie_function { id: callee_id, file_path: callee_file }, !regex_matches(callee_file, %s)",
+		re, re)
+	return packageEdgeSection(ctx, client, query, "## Depends On (outbound calls)")
+}
+
+func packageEdgeSection(ctx context.Context, client Querier, query, title string) string {
+	result, err := client.Query(ctx, query)
+	if err != nil || len(result.Rows) == 0 {
+		return ""
+	}
+	counts := map[string]int{}
+	for _, row := range result.Rows {
+		counts[path.Dir(AnyToString(row[0]))] += int(toFloat64(row[1]))
+	}
+	return formatCountSection(title, counts, "calls")
+}
+
+func formatCountSection(title string, counts map[string]int, unit string) string {
+	keys := sortedKeys(counts)
+	sort.SliceStable(keys, func(i, j int) bool { return counts[keys[i]] > counts[keys[j]] })
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("- `%s` (%d %s)", k, counts[k], unit))
+	}
+	return formatCappedSection(title, lines, packageSummaryDeps)
+}
+
+func formatCappedSection(title string, lines []string, limit int) string {
+	var sb strings.Builder
+	sb.WriteString(title + "\n\n")
+	for i, line := range lines {
+		if i >= limit {
+			fmt.Fprintf(&sb, "- ... %d more\n", len(lines)-limit)
+			break
+		}
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// packageNarrative asks the LLM to turn the structured summary into a short
+// overview. Failures are reported inline; the structured part still stands.
+func packageNarrative(ctx context.Context, args PackageSummaryArgs, label, summary string) string {
+	if args.LLM == nil {
+		return "## Overview\n\n_No LLM configured. Set `llm.enabled` and `llm.base_url` in .cie/project.yaml for a written overview._\n"
+	}
+	maxTokens := args.MaxTokens
+	if maxTokens <= 0 || maxTokens > 400 {
+		maxTokens = 400
+	}
+	prompt := fmt.Sprintf("Below is a structural summary of the code package `%s`, extracted from a code index.\n"+
+		"Write one paragraph (at most 120 words) explaining what the package is responsible for, "+
+		"its main abstractions, and how the rest of the codebase uses it. Only state what the summary supports.\n\n%s", label, summary)
+	resp, err := args.LLM.Generate(ctx, llm.GenerateRequest{Prompt: prompt, MaxTokens: maxTokens, Temperature: 0.2})
+	if err != nil {
+		return fmt.Sprintf("## Overview\n\n_LLM overview unavailable: %v_\n", err)
+	}
+	return "## Overview\n\n" + strings.TrimSpace(resp.Text) + "\n"
+}
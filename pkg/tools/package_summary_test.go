@@ -0,0 +1,124 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/llm"
+)
+
+func packageSummaryClient() *MockCIEClient {
+	return NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		switch {
+		case strings.Contains(script, `path = "pkg/store"`):
+			return NewMockQueryResult([]string{"path"}, nil), nil
+		case strings.Contains(script, "?[path] := *cie_file"):
+			return NewMockQueryResult([]string{"path"}, [][]any{{"pkg/store/store.go"}, {"pkg/store/cache.go"}}), nil
+		case strings.Contains(script, "*cie_type"):
+			return NewMockQueryResult([]string{"name", "kind", "file_path", "start_line"}, [][]any{
+				{"Store", "struct", "pkg/store/store.go", int64(10)},
+				{"entry", "struct", "pkg/store/cache.go", int64(5)},
+			}), nil
+		case strings.Contains(script, "?[name, signature"):
+			return NewMockQueryResult([]string{"name", "signature", "file_path", "start_line", "count"}, [][]any{
+				{"Store.Get", "func (s *Store) Get(k string) []byte", "pkg/store/store.go", int64(20), int64(3)},
+				{"New", "func New() *Store", "pkg/store/store.go", int64(12), int64(9)},
+				{"evict", "func evict()", "pkg/store/cache.go", int64(30), int64(12)},
+			}), nil
+		case strings.Contains(script, "*cie_import"):
+			return NewMockQueryResult([]string{"import_path", "count"}, [][]any{{"sync", int64(2)}, {"os", int64(1)}}), nil
+		case strings.Contains(script, "?[caller_file"):
+			return NewMockQueryResult([]string{"caller_file", "count"}, [][]any{
+				{"cmd/app/main.go", int64(2)}, {"cmd/app/run.go", int64(3)}, {"pkg/api/h.go", int64(1)},
+			}), nil
+		case strings.Contains(script, "?[callee_file"):
+			return NewMockQueryResult([]string{"callee_file", "count"}, [][]any{{"pkg/codec/codec.go", int64(4)}}), nil
+		}
+		return NewMockQueryResult(nil, nil), nil
+	}, nil)
+}
+
+func TestPackageSummary(t *testing.T) {
+	ctx := setupTest(t)
+	result, err := PackageSummary(ctx, packageSummaryClient(), PackageSummaryArgs{Path: "pkg/store/"})
+	assertNoError(t, err)
+
+	for _, want := range []string{
+		"# Package Summary: `pkg/store`",
+		"2 files (go)",
+		"## Exported Types (1)",
+		"- **Store** (struct) — `store.go:10`",
+		"- **New** — 9 callers",
+		"- `sync` (2 files)",
+		"- `cmd/app` (5 calls)",
+		"- `pkg/codec` (4 calls)",
+	} {
+		assertContains(t, result.Text, want)
+	}
+	if strings.Contains(result.Text, "entry") || strings.Contains(result.Text, "evict") {
+		t.Errorf("unexported names should be omitted:\n%s", result.Text)
+	}
+	if strings.Index(result.Text, "**New**") > strings.Index(result.Text, "**Store.Get**") {
+		t.Error("key functions should be ordered by caller count")
+	}
+	if strings.Contains(result.Text, "## Overview") {
+		t.Error("overview should only be added when requested")
+	}
+}
+
+func TestPackageSummary_Narrative(t *testing.T) {
+	ctx := setupTest(t)
+	var prompt string
+	provider := &llm.MockProvider{GenerateFunc: func(ctx context.Context, req llm.GenerateRequest) (*llm.GenerateResponse, error) {
+		prompt = req.Prompt
+		return &llm.GenerateResponse{Text: "Store persists blobs."}, nil
+	}}
+
+	result, err := PackageSummary(ctx, packageSummaryClient(), PackageSummaryArgs{Path: "pkg/store", Narrative: true, LLM: provider})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "## Overview\n\nStore persists blobs.")
+	assertContains(t, prompt, "**Store** (struct)")
+
+	result, err = PackageSummary(ctx, packageSummaryClient(), PackageSummaryArgs{Path: "pkg/store", Narrative: true})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "No LLM configured")
+}
+
+func TestIsExportedName(t *testing.T) {
+	tests := []struct {
+		name, file string
+		want       bool
+	}{
+		{"Store", "a.go", true},
+		{"Store.get", "a.go", false},
+		{"store", "a.go", false},
+		{"_private", "a.py", false},
+		{"handler", "a.py", true},
+		{"handler", "a.ts", true},
+		{"$anon_1", "a.ts", false},
+	}
+	for _, tt := range tests {
+		if got := isExportedName(tt.name, tt.file); got != tt.want {
+			t.Errorf("isExportedName(%q, %q) = %v, want %v", tt.name, tt.file, got, tt.want)
+		}
+	}
+}
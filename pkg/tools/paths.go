@@ -0,0 +1,91 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import "strings"
+
+// NormalizePath converts a file path argument to the form paths are indexed
+// in: repository-relative, forward slashes, no leading "./". Indexes built on
+// Windows store the same paths as on Unix, so "pkg\tools\grep.go" and
+// "pkg/tools/grep.go" name the same file.
+func NormalizePath(p string) string {
+	p = strings.ReplaceAll(p, `\`, "/")
+	for strings.HasPrefix(p, "./") {
+		p = p[2:]
+	}
+	return p
+}
+
+// NormalizePathPattern prepares a regex path filter for matching indexed
+// paths. Windows separators become "/": a backslash before a letter, digit,
+// "_", "-" or another backslash separates path segments, while a backslash
+// before punctuation stays a regex escape ("_test\.go"), as do \d, \w, \s and
+// \b when not followed by more letters. Matching is case-insensitive, as in
+// cie_semantic_search, so patterns work whatever the casing on disk.
+func NormalizePathPattern(p string) string {
+	if p == "" {
+		return p
+	}
+	var sb strings.Builder
+	sb.Grow(len(p) + 4)
+	if !strings.HasPrefix(p, "(?i)") {
+		sb.WriteString("(?i)")
+	}
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if c != '\\' || i+1 == len(p) {
+			sb.WriteByte(c)
+			continue
+		}
+		next := p[i+1]
+		switch {
+		case next == '\\':
+			sb.WriteByte('/')
+			i++
+		case isRegexClassEscape(p, i+1):
+			sb.WriteByte(c)
+		case isPathSegmentByte(next):
+			sb.WriteByte('/')
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// displayPattern returns a path filter for headings and notes without the
+// case-insensitive flag NormalizePathPattern adds, so output shows the
+// pattern as the user wrote it.
+func displayPattern(p string) string {
+	return strings.TrimPrefix(p, "(?i)")
+}
+
+// isRegexClassEscape reports whether p[i] is a character class letter
+// (\d, \w, \s, \b) that is not the start of a longer name.
+func isRegexClassEscape(p string, i int) bool {
+	if !strings.ContainsRune("dDwWsSbB", rune(p[i])) {
+		return false
+	}
+	return i+1 == len(p) || !isPathSegmentByte(p[i+1])
+}
+
+func isPathSegmentByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import "testing"
+
+func TestNormalizePath(t *testing.T) {
+	for in, want := range map[string]string{
+		`pkg\tools\grep.go`: "pkg/tools/grep.go",
+		`.\cmd\cie`:         "cmd/cie",
+		"./internal/http":   "internal/http",
+		"pkg/tools":         "pkg/tools",
+	} {
+		if got := NormalizePath(in); got != want {
+			t.Errorf("NormalizePath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNormalizePathPattern(t *testing.T) {
+	for in, want := range map[string]string{
+		`internal\http`:       "(?i)internal/http",
+		`pkg\\tools`:          "(?i)pkg/tools",
+		`_test\.go$`:          `(?i)_test\.go$`,
+		`apps\gateway\.*\.go`: `(?i)apps/gateway\.*\.go`,
+		`v\d+\api`:            `(?i)v\d+/api`,
+		`src\db\models`:       "(?i)src/db/models",
+		"(?i)Handlers":        "(?i)Handlers",
+		"":                    "",
+	} {
+		if got := NormalizePathPattern(in); got != want {
+			t.Errorf("NormalizePathPattern(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := displayPattern(NormalizePathPattern("apps/gateway")); got != "apps/gateway" {
+		t.Errorf("displayPattern = %q, want the pattern as written", got)
+	}
+}
@@ -0,0 +1,435 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Precommit check names, as accepted in PrecommitOptions.Checks.
+const (
+	CheckAbsence    = "absence"
+	CheckTests      = "tests"
+	CheckCallers    = "callers"
+	CheckComplexity = "complexity"
+)
+
+// AllPrecommitChecks lists every check `cie precommit` can run.
+var AllPrecommitChecks = []string{CheckAbsence, CheckTests, CheckCallers, CheckComplexity}
+
+// Finding severities. Critical findings fail a commit; warnings fail it only
+// in strict mode.
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// StagedLine is a line added by the staged diff.
+type StagedLine struct {
+	FilePath string
+	Line     int // 1-indexed line in the staged file
+	Text     string
+}
+
+// StagedFunction is a function whose staged body overlaps an added line.
+type StagedFunction struct {
+	Name      string
+	FilePath  string
+	StartLine int
+	EndLine   int
+	CodeText  string
+}
+
+// StagedChange is the part of a commit that precommit analyzes: the added
+// lines and the functions they touch, parsed from the staged file contents.
+type StagedChange struct {
+	Lines     []StagedLine
+	Functions []StagedFunction
+}
+
+// AbsenceRule is a pattern that must not appear in added lines.
+type AbsenceRule struct {
+	Pattern  string // Go regular expression
+	Path     string // optional regex the file path must match
+	Severity string // critical (default), warning or info
+	Message  string // optional explanation shown with each violation
+}
+
+// PrecommitOptions configures Precommit.
+type PrecommitOptions struct {
+	Checks []string // subset of AllPrecommitChecks; empty means all
+	Rules  []AbsenceRule
+	// MaxComplexityIncrease is how much a function's complexity may grow
+	// before it is reported (default 5).
+	MaxComplexityIncrease int
+}
+
+// PrecommitFinding is one problem found in the staged change.
+type PrecommitFinding struct {
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+	Function string `json:"function,omitempty"`
+	Message  string `json:"message"`
+}
+
+// PrecommitReport is the result of Precommit.
+type PrecommitReport struct {
+	Findings         []PrecommitFinding `json:"findings"`
+	LinesChecked     int                `json:"lines_checked"`
+	FunctionsChecked int                `json:"functions_checked"`
+	NewFunctions     int                `json:"new_functions"`
+}
+
+// Failed reports whether the findings should block the commit.
+func (r *PrecommitReport) Failed(strict bool) bool {
+	for _, f := range r.Findings {
+		if f.Severity == SeverityCritical || (strict && f.Severity == SeverityWarning) {
+			return true
+		}
+	}
+	return false
+}
+
+// branchPattern matches the tokens that add a path through a function.
+var branchPattern = regexp.MustCompile(`\b(if|for|while|case|catch|except|elif)\b|&&|\|\|`)
+
+// Complexity approximates the cyclomatic complexity of a function body: one
+// plus the number of branch keywords and short-circuit operators. It works on
+// any of the indexed languages without a parser, which is enough to spot a
+// function that grew noticeably harder to follow.
+func Complexity(code string) int {
+	return 1 + len(branchPattern.FindAllStringIndex(code, -1))
+}
+
+// indexedFunction is the indexed version of a staged function.
+type indexedFunction struct {
+	id       string
+	codeText string
+}
+
+// Precommit checks a staged change against the index.
+//
+// Only added lines and the functions they touch are examined, so the cost
+// scales with the size of the commit rather than the repository:
+//   - absence: added lines must not match any rule
+//   - tests: functions not yet in the index need a test that names them
+//   - callers: functions not yet in the index should be called somewhere
+//   - complexity: indexed functions must not grow more complex than allowed
+func Precommit(ctx context.Context, client Querier, change StagedChange, opts PrecommitOptions) (*PrecommitReport, error) {
+	enabled := map[string]bool{}
+	for _, c := range opts.Checks {
+		enabled[c] = true
+	}
+	if len(enabled) == 0 {
+		for _, c := range AllPrecommitChecks {
+			enabled[c] = true
+		}
+	}
+	if opts.MaxComplexityIncrease <= 0 {
+		opts.MaxComplexityIncrease = 5
+	}
+
+	report := &PrecommitReport{LinesChecked: len(change.Lines)}
+	if enabled[CheckAbsence] {
+		findings, err := checkAbsenceRules(change.Lines, opts.Rules)
+		if err != nil {
+			return nil, err
+		}
+		report.Findings = append(report.Findings, findings...)
+	}
+
+	needIndex := enabled[CheckTests] || enabled[CheckCallers] || enabled[CheckComplexity]
+	if !needIndex || len(change.Functions) == 0 {
+		return report, nil
+	}
+	report.FunctionsChecked = len(change.Functions)
+
+	indexed, err := indexedFunctions(ctx, client, change.Functions)
+	if err != nil {
+		return nil, err
+	}
+	var fresh []StagedFunction
+	for _, fn := range change.Functions {
+		old, ok := indexed[functionKey(fn.FilePath, fn.Name)]
+		if !ok {
+			fresh = append(fresh, fn)
+			continue
+		}
+		if enabled[CheckComplexity] {
+			if f, ok := complexityRegression(fn, old.codeText, opts.MaxComplexityIncrease); ok {
+				report.Findings = append(report.Findings, f)
+			}
+		}
+	}
+	report.NewFunctions = len(fresh)
+
+	var candidates []StagedFunction
+	for _, fn := range fresh {
+		if needsCoverage(fn) {
+			candidates = append(candidates, fn)
+		}
+	}
+	if enabled[CheckTests] && len(candidates) > 0 {
+		tested, err := testedNames(ctx, client, candidates)
+		if err != nil {
+			return nil, err
+		}
+		for _, fn := range candidates {
+			if !tested[shortName(fn.Name)] && !stagedTestMentions(change, fn) {
+				report.Findings = append(report.Findings, PrecommitFinding{
+					Check: CheckTests, Severity: SeverityWarning, FilePath: fn.FilePath, Line: fn.StartLine, Function: fn.Name,
+					Message: "new function has no test that names it",
+				})
+			}
+		}
+	}
+	if enabled[CheckCallers] {
+		for _, fn := range candidates {
+			if stagedCallers(change, fn) > 0 {
+				continue
+			}
+			severity := SeverityWarning
+			if isExportedName(fn.Name, fn.FilePath) {
+				severity = SeverityInfo // may be called from outside the repository
+			}
+			report.Findings = append(report.Findings, PrecommitFinding{
+				Check: CheckCallers, Severity: severity, FilePath: fn.FilePath, Line: fn.StartLine, Function: fn.Name,
+				Message: "new function is not called anywhere in the staged change",
+			})
+		}
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.FilePath != b.FilePath {
+			return a.FilePath < b.FilePath
+		}
+		return a.Line < b.Line
+	})
+	return report, nil
+}
+
+// checkAbsenceRules matches every rule against the added lines.
+func checkAbsenceRules(lines []StagedLine, rules []AbsenceRule) ([]PrecommitFinding, error) {
+	var findings []PrecommitFinding
+	for _, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("absence rule %q: %w", rule.Pattern, err)
+		}
+		var pathRe *regexp.Regexp
+		if rule.Path != "" {
+			if pathRe, err = regexp.Compile(rule.Path); err != nil {
+				return nil, fmt.Errorf("absence rule %q path: %w", rule.Pattern, err)
+			}
+		}
+		severity := rule.Severity
+		if severity == "" {
+			severity = SeverityCritical
+		}
+		msg := fmt.Sprintf("added line matches forbidden pattern %q", rule.Pattern)
+		if rule.Message != "" {
+			msg = rule.Message
+		}
+		for _, l := range lines {
+			if pathRe != nil && !pathRe.MatchString(l.FilePath) {
+				continue
+			}
+			if re.MatchString(l.Text) {
+				findings = append(findings, PrecommitFinding{
+					Check: CheckAbsence, Severity: severity, FilePath: l.FilePath, Line: l.Line, Message: msg,
+				})
+			}
+		}
+	}
+	return findings, nil
+}
+
+// indexedFunctions looks up the staged functions' files in the index and
+// returns their functions keyed by file path and name.
+func indexedFunctions(ctx context.Context, client Querier, fns []StagedFunction) (map[string]indexedFunction, error) {
+	seen := map[string]bool{}
+	var files []string
+	for _, fn := range fns {
+		if !seen[fn.FilePath] {
+			seen[fn.FilePath] = true
+			files = append(files, fmt.Sprintf("%q", fn.FilePath))
+		}
+	}
+	script := fmt.Sprintf(
+		"?[file_path, name, id, code_text] := *cie_function { id, name, file_path }, is_in(file_path, [%s]), *cie_function_code { function_id: id, code_text }",
+		strings.Join(files, ", "))
+	result, err := client.Query(ctx, script)
+	if err != nil {
+		return nil, fmt.Errorf("precommit function lookup: %w", err)
+	}
+	out := make(map[string]indexedFunction, len(result.Rows))
+	for _, row := range result.Rows {
+		if len(row) < 4 {
+			continue
+		}
+		out[functionKey(AnyToString(row[0]), AnyToString(row[1]))] = indexedFunction{
+			id:       AnyToString(row[2]),
+			codeText: decodeCodeText(row[3]),
+		}
+	}
+	return out, nil
+}
+
+// testedNames returns the short names of candidates that an indexed test
+// function mentions in its own name (TestParseConfig, test_parse_config).
+func testedNames(ctx context.Context, client Querier, candidates []StagedFunction) (map[string]bool, error) {
+	var alts []string
+	for _, fn := range candidates {
+		alts = append(alts, nameWordsPattern(shortName(fn.Name)))
+	}
+	script := fmt.Sprintf(
+		"?[name, file_path] := *cie_function { name, file_path }, regex_matches(name, %s) :limit 5000",
+		QuoteCozoPattern("(?i)("+strings.Join(alts, "|")+")"))
+	result, err := client.Query(ctx, script)
+	if err != nil {
+		return nil, fmt.Errorf("precommit test lookup: %w", err)
+	}
+	tested := map[string]bool{}
+	for _, row := range result.Rows {
+		if len(row) < 2 || !isTestPath(AnyToString(row[1])) {
+			continue
+		}
+		testName := normalizeName(AnyToString(row[0]))
+		for _, fn := range candidates {
+			if strings.Contains(testName, normalizeName(shortName(fn.Name))) {
+				tested[shortName(fn.Name)] = true
+			}
+		}
+	}
+	return tested, nil
+}
+
+// stagedTestMentions reports whether a staged test function names fn or
+// calls it.
+func stagedTestMentions(change StagedChange, fn StagedFunction) bool {
+	short := shortName(fn.Name)
+	for _, other := range change.Functions {
+		if !isTestPath(other.FilePath) {
+			continue
+		}
+		if strings.Contains(normalizeName(other.Name), normalizeName(short)) || strings.Contains(other.CodeText, short+"(") {
+			return true
+		}
+	}
+	return false
+}
+
+// stagedCallers counts added lines outside fn that call it by name.
+func stagedCallers(change StagedChange, fn StagedFunction) int {
+	call := regexp.MustCompile(`\b` + regexp.QuoteMeta(shortName(fn.Name)) + `\s*\(`)
+	n := 0
+	for _, l := range change.Lines {
+		if l.FilePath == fn.FilePath && l.Line >= fn.StartLine && l.Line <= fn.EndLine {
+			continue
+		}
+		if call.MatchString(l.Text) {
+			n++
+		}
+	}
+	return n
+}
+
+// complexityRegression reports fn when its staged body is more complex than
+// the indexed one by more than maxIncrease.
+func complexityRegression(fn StagedFunction, indexedCode string, maxIncrease int) (PrecommitFinding, bool) {
+	if indexedCode == "" {
+		return PrecommitFinding{}, false
+	}
+	before, after := Complexity(indexedCode), Complexity(fn.CodeText)
+	if after-before <= maxIncrease {
+		return PrecommitFinding{}, false
+	}
+	return PrecommitFinding{
+		Check: CheckComplexity, Severity: SeverityWarning, FilePath: fn.FilePath, Line: fn.StartLine, Function: fn.Name,
+		Message: fmt.Sprintf("complexity rose from %d to %d (allowed increase: %d)", before, after, maxIncrease),
+	}, true
+}
+
+// needsCoverage reports whether a new function should have tests and callers.
+// Entry points, test code and anonymous functions are exempt.
+func needsCoverage(fn StagedFunction) bool {
+	name := shortName(fn.Name)
+	switch {
+	case isTestPath(fn.FilePath):
+		return false
+	case name == "main" || name == "init" || name == "__init__":
+		return false
+	case strings.HasPrefix(name, "$"), anonymousFunctionPattern.MatchString(name): // closure#N, $anon_N
+		return false
+	}
+	for _, prefix := range []string{"Test", "Benchmark", "Example", "Fuzz", "test_"} {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// isTestPath reports whether a file holds tests.
+func isTestPath(p string) bool {
+	return testFilePattern.MatchString(p) || strings.HasPrefix(path.Base(p), "test_")
+}
+
+// shortName strips a receiver or class prefix ("Server.Start" -> "Start").
+func shortName(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// nameWordsPattern builds a regex matching name in either CamelCase or
+// snake_case ("ParseConfig" -> "parse_?config").
+func nameWordsPattern(name string) string {
+	var words []string
+	start := 0
+	for i := 1; i <= len(name); i++ {
+		if i == len(name) || name[i] == '_' || (name[i] >= 'A' && name[i] <= 'Z' && name[i-1] >= 'a' && name[i-1] <= 'z') {
+			if w := strings.Trim(name[start:i], "_"); w != "" {
+				words = append(words, EscapeRegex(strings.ToLower(w)))
+			}
+			start = i
+		}
+	}
+	return strings.Join(words, "_?")
+}
+
+// normalizeName lowercases a name and drops underscores so ParseConfig and
+// test_parse_config compare equal.
+func normalizeName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "_", "")
+}
+
+func functionKey(filePath, name string) string {
+	return filePath + "\x00" + name
+}
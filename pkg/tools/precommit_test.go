@@ -0,0 +1,144 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func precommitClient() *MockCIEClient {
+	return NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		switch {
+		case strings.Contains(script, "*cie_function_code"):
+			return NewMockQueryResult([]string{"file_path", "name", "id", "code_text"}, [][]any{
+				{"pkg/store/store.go", "Get", "fn1", "func Get() {\n\treturn\n}"},
+			}), nil
+		case strings.Contains(script, "regex_matches(name"):
+			return NewMockQueryResult([]string{"name", "file_path"}, [][]any{
+				{"TestParseKey", "pkg/store/store_test.go"},
+				{"parseKeyHelper", "pkg/store/key.go"},
+			}), nil
+		}
+		return NewMockQueryResult(nil, nil), nil
+	}, nil)
+}
+
+func TestPrecommit(t *testing.T) {
+	ctx := setupTest(t)
+	change := StagedChange{
+		Lines: []StagedLine{
+			{FilePath: "pkg/store/store.go", Line: 3, Text: "\tif a && b { fmt.Println(a) }"},
+			{FilePath: "pkg/store/key.go", Line: 10, Text: "func ParseKey(s string) Key {"},
+			{FilePath: "pkg/store/key.go", Line: 20, Text: "func splitKey(s string) []string {"},
+			{FilePath: "pkg/store/key.go", Line: 30, Text: "func orphan() {}"},
+			{FilePath: "pkg/store/key.go", Line: 13, Text: "\tparts := splitKey(s)"},
+		},
+		Functions: []StagedFunction{
+			{Name: "Get", FilePath: "pkg/store/store.go", StartLine: 1, EndLine: 9,
+				CodeText: "func Get() {\n\tif a && b {}\n\tif c || d {}\n\tfor {}\n\tswitch { case 1: case 2: }\n}"},
+			{Name: "ParseKey", FilePath: "pkg/store/key.go", StartLine: 10, EndLine: 15},
+			{Name: "splitKey", FilePath: "pkg/store/key.go", StartLine: 20, EndLine: 25},
+			{Name: "orphan", FilePath: "pkg/store/key.go", StartLine: 30, EndLine: 30},
+		},
+	}
+	opts := PrecommitOptions{
+		Rules:                 []AbsenceRule{{Pattern: `fmt\.Print`, Path: `^pkg/`, Message: "use the logger"}},
+		MaxComplexityIncrease: 3,
+	}
+
+	report, err := Precommit(ctx, precommitClient(), change, opts)
+	assertNoError(t, err)
+
+	got := map[string]PrecommitFinding{}
+	for _, f := range report.Findings {
+		got[f.Check+":"+f.Function] = f
+	}
+	if f, ok := got["absence:"]; !ok || f.Severity != SeverityCritical || f.Message != "use the logger" {
+		t.Errorf("absence finding = %+v", f)
+	}
+	if f := got["complexity:Get"]; f.Severity != SeverityWarning {
+		t.Errorf("expected complexity warning for Get, got %+v", report.Findings)
+	}
+	if _, ok := got["tests:ParseKey"]; ok {
+		t.Error("ParseKey is named by TestParseKey and should count as tested")
+	}
+	if _, ok := got["tests:splitKey"]; !ok {
+		t.Error("splitKey has no test and should be reported")
+	}
+	if _, ok := got["callers:splitKey"]; ok {
+		t.Error("splitKey is called from a staged line")
+	}
+	if f := got["callers:orphan"]; f.Severity != SeverityWarning {
+		t.Errorf("unexported orphan should be a warning, got %+v", f)
+	}
+	if f := got["callers:ParseKey"]; f.Severity != SeverityInfo {
+		t.Errorf("exported ParseKey without callers should be info, got %+v", f)
+	}
+	if report.NewFunctions != 3 {
+		t.Errorf("NewFunctions = %d, want 3", report.NewFunctions)
+	}
+	if !report.Failed(false) {
+		t.Error("a critical finding should fail the commit")
+	}
+}
+
+func TestPrecommit_ChecksSubset(t *testing.T) {
+	ctx := setupTest(t)
+	client := NewMockClientWithError(context.Canceled)
+	change := StagedChange{
+		Lines:     []StagedLine{{FilePath: "a.go", Line: 1, Text: "x := 1"}},
+		Functions: []StagedFunction{{Name: "f", FilePath: "a.go", StartLine: 1, EndLine: 2}},
+	}
+	report, err := Precommit(ctx, client, change, PrecommitOptions{Checks: []string{CheckAbsence}})
+	assertNoError(t, err)
+	if len(report.Findings) != 0 || report.Failed(true) {
+		t.Errorf("unexpected findings: %+v", report.Findings)
+	}
+}
+
+func TestComplexity(t *testing.T) {
+	tests := []struct {
+		code string
+		want int
+	}{
+		{"func f() {}", 1},
+		{"if a && b { } else if c || d { }", 5},
+		{"for x in y:\n    if z: pass\n    elif w: pass", 4},
+		{"verify := iffy", 1},
+	}
+	for _, tt := range tests {
+		if got := Complexity(tt.code); got != tt.want {
+			t.Errorf("Complexity(%q) = %d, want %d", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestNameWordsPattern(t *testing.T) {
+	for name, want := range map[string]string{
+		"ParseConfig":  "parse_?config",
+		"parse_config": "parse_?config",
+		"load":         "load",
+	} {
+		if got := nameWordsPattern(name); got != want {
+			t.Errorf("nameWordsPattern(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/kraklabs/cie/pkg/embedprefix"
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+var (
+	prefixesMu         sync.RWMutex
+	configuredPrefixes = make(map[string]embedprefix.Prefixes)
+)
+
+// SetEmbeddingPrefixes makes queries embedded with model use prefixes
+// instead of the defaults, and lets semantic search check the index against
+// prefixes.Document. The CLI calls it for every model it loads from the
+// configuration.
+func SetEmbeddingPrefixes(model string, prefixes embedprefix.Prefixes) {
+	prefixesMu.Lock()
+	defer prefixesMu.Unlock()
+	configuredPrefixes[model] = prefixes
+}
+
+// configuredEmbeddingPrefixes returns the prefixes set for model, if any.
+func configuredEmbeddingPrefixes(model string) (embedprefix.Prefixes, bool) {
+	prefixesMu.RLock()
+	defer prefixesMu.RUnlock()
+	prefixes, ok := configuredPrefixes[model]
+	return prefixes, ok
+}
+
+// embeddingPrefixes returns override when it is set, otherwise the prefixes
+// configured for model. ok is false when neither is known.
+func embeddingPrefixes(model string, override *embedprefix.Prefixes) (embedprefix.Prefixes, bool) {
+	if override != nil {
+		return *override, true
+	}
+	return configuredEmbeddingPrefixes(model)
+}
+
+// queryPrefix returns the prefix queries embedded with model start with.
+func queryPrefix(model string) string {
+	if prefixes, ok := configuredEmbeddingPrefixes(model); ok {
+		return prefixes.Query
+	}
+	return embedprefix.Defaults("", model).Query
+}
+
+// documentPrefix returns the prefix documents embedded with model start
+// with.
+func documentPrefix(model string) string {
+	if prefixes, ok := configuredEmbeddingPrefixes(model); ok {
+		return prefixes.Document
+	}
+	return embedprefix.Defaults("", model).Document
+}
+
+// prefixMismatchWarning warns when the index was embedded with another
+// document prefix than the one in override or configured for model. Nothing
+// is reported for models without configured prefixes or indexes that
+// recorded none.
+func prefixMismatchWarning(ctx context.Context, client Querier, model string, override *embedprefix.Prefixes) string {
+	prefixes, ok := embeddingPrefixes(model, override)
+	if !ok {
+		return ""
+	}
+	stored, err := strconv.Unquote(projectMeta(ctx, client, storage.DocumentPrefixMetaKey))
+	if err != nil || stored == prefixes.Document {
+		return ""
+	}
+	return fmt.Sprintf("⚠️ **Embedding prefix mismatch:** the index was embedded with document prefix %q, "+
+		"but the configuration pairs query prefix %q with document prefix %q. Results may be poor: "+
+		"re-index with `cie index --full`, or set `embedding.document_prefix` to match the index.\n\n",
+		stored, prefixes.Query, prefixes.Document)
+}
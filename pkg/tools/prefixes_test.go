@@ -0,0 +1,86 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kraklabs/cie/pkg/embedprefix"
+)
+
+func TestSetEmbeddingPrefixes(t *testing.T) {
+	t.Parallel()
+	SetEmbeddingPrefixes("test-prefixes-bge", embedprefix.Prefixes{Query: "Represent this query: ", Document: ""})
+
+	if got := preprocessQueryForCode("auth handler", "test-prefixes-bge"); got != "Represent this query: auth handler" {
+		t.Errorf("configured query prefix not applied: %q", got)
+	}
+	if got := preprocessQueryForCode("auth handler", "test-prefixes-unset"); got != "search_query: auth handler" {
+		t.Errorf("unconfigured model should keep the default prefix: %q", got)
+	}
+}
+
+func TestPrefixMismatchWarning(t *testing.T) {
+	t.Parallel()
+	SetEmbeddingPrefixes("test-prefixes-nomic", embedprefix.Prefixes{Query: "search_query: ", Document: "search_document: "})
+	metaClient := func(value string) *MockCIEClient {
+		return NewMockClientCustom(func(_ context.Context, script string) (*QueryResult, error) {
+			if !strings.Contains(script, "embedding_document_prefix") {
+				t.Errorf("unexpected query: %s", script)
+			}
+			if value == "" {
+				return &QueryResult{}, nil
+			}
+			return NewMockQueryResult([]string{"value"}, [][]any{{value}}), nil
+		}, nil)
+	}
+	ctx := context.Background()
+
+	t.Run("mismatch", func(t *testing.T) {
+		warning := prefixMismatchWarning(ctx, metaClient(embedprefix.EncodeDocument("")), "test-prefixes-nomic", nil)
+		assertContains(t, warning, "Embedding prefix mismatch")
+		assertContains(t, warning, `document prefix ""`)
+		assertContains(t, warning, "cie index --full")
+	})
+
+	t.Run("match", func(t *testing.T) {
+		if warning := prefixMismatchWarning(ctx, metaClient(embedprefix.EncodeDocument("search_document: ")), "test-prefixes-nomic", nil); warning != "" {
+			t.Errorf("matching prefixes should not warn: %q", warning)
+		}
+	})
+
+	t.Run("not recorded", func(t *testing.T) {
+		if warning := prefixMismatchWarning(ctx, metaClient(""), "test-prefixes-nomic", nil); warning != "" {
+			t.Errorf("an index without a recorded prefix should not warn: %q", warning)
+		}
+	})
+
+	t.Run("model not configured", func(t *testing.T) {
+		client := NewMockClientCustom(func(context.Context, string) (*QueryResult, error) {
+			t.Error("no query expected for a model without configured prefixes")
+			return &QueryResult{}, nil
+		}, nil)
+		if warning := prefixMismatchWarning(ctx, client, "test-prefixes-unknown", nil); warning != "" {
+			t.Errorf("unexpected warning: %q", warning)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxGeneratedNotes caps the generated functions annotated per result.
+const maxGeneratedNotes = 10
+
+// generatedInfo is where a function in a generated file comes from.
+type generatedInfo struct {
+	Generator string // e.g., "protoc-gen-go-grpc"; "" when the header names none
+	Source    string // source file named in the header
+	Def       string // "UserService.GetUser (api/users.proto:14)"; "" when not linked
+}
+
+// origin describes the generator and source file, e.g. "generated by
+// protoc-gen-go from api/users.proto".
+func (g generatedInfo) origin() string {
+	s := "generated"
+	if g.Generator != "" {
+		s += " by " + g.Generator
+	}
+	if g.Source != "" {
+		s += " from " + g.Source
+	}
+	return s
+}
+
+// lookupGenerated returns the provenance of the functions in refs (name,
+// file path pairs) that live in generated files, from cie_generated_file
+// and cie_generated_func. Indexes built before those relations return none.
+func lookupGenerated(ctx context.Context, client Querier, refs [][2]string) map[[2]string]generatedInfo {
+	paths := make(map[string]bool)
+	for _, r := range refs {
+		paths[r[1]] = true
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	quoted := make([]string, 0, len(paths))
+	for _, p := range sortedKeys(paths) {
+		quoted = append(quoted, fmt.Sprintf("%q", p))
+	}
+	inPaths := fmt.Sprintf("is_in(file_path, [%s])", strings.Join(quoted, ", "))
+
+	files, err := client.Query(ctx, fmt.Sprintf("?[file_path, generator, source] := *cie_generated_file { file_path, generator, source }, %s", inPaths))
+	if err != nil || len(files.Rows) == 0 {
+		return nil
+	}
+	byFile := make(map[string]generatedInfo, len(files.Rows))
+	for _, r := range files.Rows {
+		if len(r) >= 3 {
+			byFile[AnyToString(r[0])] = generatedInfo{Generator: AnyToString(r[1]), Source: AnyToString(r[2])}
+		}
+	}
+
+	defs := make(map[[2]string]string)
+	script := fmt.Sprintf(`?[name, file_path, source_name, source_file, source_line] := *cie_generated_func { function_id, source_id, source_kind: "function", file_path }, %[1]s,
+			*cie_function { id: function_id, name }, *cie_function { id: source_id, name: source_name, file_path: source_file, start_line: source_line }
+		?[name, file_path, source_name, source_file, source_line] := *cie_generated_func { function_id, source_id, source_kind: "type", file_path }, %[1]s,
+			*cie_function { id: function_id, name }, *cie_type { id: source_id, name: source_name, file_path: source_file, start_line: source_line }`, inPaths)
+	if result, err := client.Query(ctx, script); err == nil {
+		for _, r := range result.Rows {
+			if len(r) >= 5 {
+				defs[[2]string{AnyToString(r[0]), AnyToString(r[1])}] = fmt.Sprintf("%s (%s:%s)", AnyToString(r[2]), AnyToString(r[3]), AnyToString(r[4]))
+			}
+		}
+	}
+
+	out := make(map[[2]string]generatedInfo)
+	for _, r := range refs {
+		info, ok := byFile[r[1]]
+		if !ok {
+			continue
+		}
+		info.Def = defs[r]
+		out[r] = info
+	}
+	return out
+}
+
+// functionProvenance is the note get_function_code adds to a function in a
+// generated file, pointing at the definition to read or edit instead.
+func functionProvenance(ctx context.Context, client Querier, name, filePath string) string {
+	ref := [2]string{name, filePath}
+	info, ok := lookupGenerated(ctx, client, [][2]string{ref})[ref]
+	if !ok {
+		return ""
+	}
+	s := fmt.Sprintf("\n\n**Generated code**: %s. Edit the source, not this file.", info.origin())
+	if info.Def != "" {
+		s += fmt.Sprintf("\n**Source definition**: `%s`", info.Def)
+	}
+	return s
+}
+
+// appendGenerated lists the functions of a result that are generated code,
+// with the source definitions to look at instead.
+func appendGenerated(ctx context.Context, client Querier, output string, refs [][2]string) string {
+	found := lookupGenerated(ctx, client, refs)
+	if len(found) == 0 {
+		return output
+	}
+	keys := make([][2]string, 0, len(found))
+	for k := range found {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][1] != keys[j][1] {
+			return keys[i][1] < keys[j][1]
+		}
+		return keys[i][0] < keys[j][0]
+	})
+
+	var sb strings.Builder
+	sb.WriteString(output)
+	sb.WriteString("\n\n**Generated code** (prefer the source definitions):\n")
+	for i, k := range keys {
+		if i == maxGeneratedNotes {
+			fmt.Fprintf(&sb, "- _%d more generated functions_\n", len(keys)-maxGeneratedNotes)
+			break
+		}
+		info := found[k]
+		fmt.Fprintf(&sb, "- `%s` (%s): %s", k[0], k[1], info.origin())
+		if info.Def != "" {
+			fmt.Fprintf(&sb, " → `%s`", info.Def)
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// provenanceMock serves a gRPC client stub in a generated file next to a
+// hand-written server of the same RPC.
+func provenanceMock() Querier {
+	return NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		switch {
+		case strings.Contains(script, "*cie_generated_file"):
+			if strings.Contains(script, `"gen/users_grpc.pb.go"`) {
+				return NewMockQueryResult(nil, [][]any{{"gen/users_grpc.pb.go", "protoc-gen-go-grpc", "api/users.proto"}}), nil
+			}
+			return NewMockQueryResult(nil, nil), nil
+		case strings.Contains(script, "*cie_generated_func"):
+			return NewMockQueryResult(nil, [][]any{{"userServiceClient.GetUser", "gen/users_grpc.pb.go", "UserService.GetUser", "api/users.proto", float64(14)}}), nil
+		case strings.Contains(script, "*cie_function_code"):
+			return NewMockQueryResult(nil, [][]any{{"fn:1", "userServiceClient.GetUser", "gen/users_grpc.pb.go", "func (c *userServiceClient) GetUser(...)", "func (c *userServiceClient) GetUser() {}", float64(40), float64(48)}}), nil
+		case strings.HasPrefix(script, "?[file_path, name, signature, start_line, end_line]"):
+			return NewMockQueryResult([]string{"file_path", "name", "signature", "start_line", "end_line"}, [][]any{
+				{"gen/users_grpc.pb.go", "userServiceClient.GetUser", "func (c *userServiceClient) GetUser(...)", float64(40), float64(48)},
+				{"internal/server.go", "userServer.GetUser", "func (s *userServer) GetUser(...)", float64(12), float64(30)},
+			}), nil
+		}
+		return NewMockQueryResult(nil, nil), nil
+	}, nil)
+}
+
+func TestGetFunctionCode_GeneratedProvenance(t *testing.T) {
+	result, err := GetFunctionCode(context.Background(), provenanceMock(), GetFunctionCodeArgs{FunctionName: "userServiceClient.GetUser"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "**Generated code**: generated by protoc-gen-go-grpc from api/users.proto. Edit the source, not this file.")
+	assertContains(t, result.Text, "**Source definition**: `UserService.GetUser (api/users.proto:14)`")
+}
+
+func TestFindFunction_GeneratedProvenance(t *testing.T) {
+	result, err := FindFunction(context.Background(), provenanceMock(), FindFunctionArgs{Name: "GetUser"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "**Generated code** (prefer the source definitions):\n- `userServiceClient.GetUser` (gen/users_grpc.pb.go): generated by protoc-gen-go-grpc from api/users.proto → `UserService.GetUser (api/users.proto:14)`")
+	if strings.Contains(result.Text, "`userServer.GetUser` (internal/server.go)") {
+		t.Errorf("hand-written functions should not be listed as generated:\n%s", result.Text)
+	}
+}
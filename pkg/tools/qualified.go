@@ -0,0 +1,166 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// qualifiedName is one reading of a qualified function reference: the
+// stored function name plus the package directory it must live in.
+type qualifiedName struct {
+	Name   string // as stored in cie_function.name, e.g. "Type.Method"
+	PkgDir string // "" = any directory; else directory (or file stem) suffix
+}
+
+// parseQualifiedName interprets references such as "pkg.Func",
+// "pkg.Type.Method", "path/to/pkg.Func" or Python-style "app.services.user.get_user".
+// The final one or two dot-separated parts form the stored name; anything
+// before them is the package path. Returns nil for unqualified names.
+func parseQualifiedName(ref string) []qualifiedName {
+	ref = strings.TrimSpace(ref)
+	if !strings.ContainsAny(ref, "./") {
+		return nil
+	}
+
+	dirPrefix, rest := "", ref
+	if i := strings.LastIndex(ref, "/"); i >= 0 {
+		dirPrefix, rest = ref[:i], ref[i+1:]
+	}
+	parts := strings.Split(rest, ".")
+	for _, p := range parts {
+		if p == "" {
+			return nil
+		}
+	}
+
+	var out []qualifiedName
+	for nameParts := 1; nameParts <= 2 && nameParts <= len(parts); nameParts++ {
+		pkg := strings.Join(parts[:len(parts)-nameParts], "/")
+		pkgDir := strings.Trim(dirPrefix+"/"+pkg, "/")
+		if pkgDir == "" && nameParts == 1 {
+			continue // plain name, not qualified
+		}
+		out = append(out, qualifiedName{
+			Name:   strings.Join(parts[len(parts)-nameParts:], "."),
+			PkgDir: pkgDir,
+		})
+	}
+	return out
+}
+
+// condition renders the reading as a CozoScript filter over name and file_path.
+func (q qualifiedName) condition() string {
+	if q.PkgDir == "" {
+		return fmt.Sprintf("name = %q", q.Name)
+	}
+	// The file sits directly in PkgDir, or PkgDir ends in the file's stem
+	// (Python/TS modules: "services/user" matches services/user.py).
+	dirPattern := fmt.Sprintf("(^|/)%s(/[^/]+|[.][A-Za-z0-9]+)$", EscapeRegex(q.PkgDir))
+	return fmt.Sprintf("(name = %q and regex_matches(file_path, %q))", q.Name, dirPattern)
+}
+
+// qualifiedCondition returns a filter matching any reading of ref, or
+// ok=false when ref is not qualified.
+func qualifiedCondition(ref string) (cond string, ok bool) {
+	readings := parseQualifiedName(ref)
+	if len(readings) == 0 {
+		return "", false
+	}
+	conds := make([]string, len(readings))
+	for i, r := range readings {
+		conds[i] = r.condition()
+	}
+	return "(" + strings.Join(conds, " or ") + ")", true
+}
+
+// qualifiedLabel returns the package-qualified form of a function, e.g.
+// "pkg/tools.FindFunction", which can be passed back to narrow a lookup.
+func qualifiedLabel(filePath, name string) string {
+	dir := path.Dir(filePath)
+	if dir == "." || dir == "/" {
+		return name
+	}
+	return dir + "." + name
+}
+
+// functionMatch identifies one function in a disambiguation list.
+type functionMatch struct {
+	Name      string
+	FilePath  string
+	StartLine string
+	Signature string
+}
+
+// distinctFunctionMatches de-duplicates rows by location. The idx arguments
+// give the column positions of name, file_path, start_line and signature
+// (sigIdx may be -1).
+func distinctFunctionMatches(rows [][]any, nameIdx, pathIdx, lineIdx, sigIdx int) []functionMatch {
+	seen := make(map[string]bool)
+	var out []functionMatch
+	for _, row := range rows {
+		m := functionMatch{
+			Name:      AnyToString(row[nameIdx]),
+			FilePath:  AnyToString(row[pathIdx]),
+			StartLine: AnyToString(row[lineIdx]),
+		}
+		if sigIdx >= 0 {
+			m.Signature = AnyToString(row[sigIdx])
+		}
+		key := m.FilePath + ":" + m.StartLine + ":" + m.Name
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].FilePath != out[j].FilePath {
+			return out[i].FilePath < out[j].FilePath
+		}
+		return out[i].StartLine < out[j].StartLine
+	})
+	return out
+}
+
+// packageCount returns how many directories the matches span.
+func packageCount(matches []functionMatch) int {
+	dirs := make(map[string]bool)
+	for _, m := range matches {
+		dirs[path.Dir(m.FilePath)] = true
+	}
+	return len(dirs)
+}
+
+// formatDisambiguation lists ambiguous matches with the qualified names
+// that select each one.
+func formatDisambiguation(matches []functionMatch) string {
+	var sb strings.Builder
+	for i, m := range matches {
+		fmt.Fprintf(&sb, "%d. `%s` — %s:%s", i+1, qualifiedLabel(m.FilePath, m.Name), m.FilePath, m.StartLine)
+		if m.Signature != "" {
+			fmt.Fprintf(&sb, "\n   `%s`", m.Signature)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
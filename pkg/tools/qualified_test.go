@@ -0,0 +1,124 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseQualifiedName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []qualifiedName
+	}{
+		{"Parse", nil},
+		{"tools.FindFunction", []qualifiedName{
+			{Name: "FindFunction", PkgDir: "tools"},
+			{Name: "tools.FindFunction"},
+		}},
+		{"storage.EmbeddedBackend.Close", []qualifiedName{
+			{Name: "Close", PkgDir: "storage/EmbeddedBackend"},
+			{Name: "EmbeddedBackend.Close", PkgDir: "storage"},
+		}},
+		{"pkg/tools.FindFunction", []qualifiedName{
+			{Name: "FindFunction", PkgDir: "pkg/tools"},
+			{Name: "tools.FindFunction", PkgDir: "pkg"},
+		}},
+		{"app.services.user.get_user", []qualifiedName{
+			{Name: "get_user", PkgDir: "app/services/user"},
+			{Name: "user.get_user", PkgDir: "app/services"},
+		}},
+		{"bad..name", nil},
+	}
+	for _, tt := range tests {
+		if got := parseQualifiedName(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseQualifiedName(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestQualifiedCondition(t *testing.T) {
+	cond, ok := qualifiedCondition("pkg/tools.FindFunction")
+	if !ok {
+		t.Fatal("expected qualified")
+	}
+	for _, want := range []string{`name = "FindFunction"`, `(^|/)pkg/tools(/[^/]+|[.][A-Za-z0-9]+)$`} {
+		if !strings.Contains(cond, want) {
+			t.Errorf("condition %s should contain %s", cond, want)
+		}
+	}
+	if _, ok := qualifiedCondition("FindFunction"); ok {
+		t.Error("simple name should not be qualified")
+	}
+}
+
+func TestGetFunctionCode_Ambiguous(t *testing.T) {
+	client := NewMockClientWithResults(
+		[]string{"id", "name", "file_path", "signature", "code_text", "start_line", "end_line"},
+		[][]any{
+			{"fn:1", "Parse", "pkg/config/parse.go", "func Parse() error", "func Parse() error {}", float64(10), float64(20)},
+			{"fn:1", "Parse", "pkg/ingestion/parse.go", "func Parse(path string)", "func Parse(path string) {}", float64(5), float64(9)},
+		},
+	)
+	ctx := setupTest(t)
+
+	result, err := GetFunctionCode(ctx, client, GetFunctionCodeArgs{FunctionName: "Parse"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "ambiguous (2 matches)")
+	assertContains(t, result.Text, "`pkg/config.Parse`")
+	assertContains(t, result.Text, "`pkg/ingestion.Parse`")
+}
+
+func TestGetFunctionCode_QualifiedQuery(t *testing.T) {
+	var scripts []string
+	client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		scripts = append(scripts, script)
+		return NewMockQueryResult(
+			[]string{"id", "name", "file_path", "signature", "code_text", "start_line", "end_line"},
+			[][]any{{"fn:1", "Parse", "pkg/config/parse.go", "func Parse() error", "func Parse() error {}", float64(10), float64(20)}},
+		), nil
+	}, nil)
+	ctx := setupTest(t)
+
+	result, err := GetFunctionCode(ctx, client, GetFunctionCodeArgs{FunctionName: "config.Parse"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "func Parse() error {}")
+	if len(scripts) == 0 || !strings.Contains(scripts[0], `regex_matches(file_path, "(^|/)config(/[^/]+|[.][A-Za-z0-9]+)$")`) {
+		t.Errorf("qualified lookup should filter by package directory, got %v", scripts)
+	}
+}
+
+func TestFindFunction_DisambiguationHint(t *testing.T) {
+	client := NewMockClientWithResults(
+		[]string{"file_path", "name", "signature", "start_line", "end_line"},
+		[][]any{
+			{"pkg/config/parse.go", "Parse", "func Parse() error", float64(10), float64(20)},
+			{"pkg/ingestion/parse.go", "Parse", "func Parse(path string)", float64(5), float64(9)},
+		},
+	)
+	ctx := setupTest(t)
+
+	result, err := FindFunction(ctx, client, FindFunctionArgs{Name: "Parse"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "2 matches in 2 packages")
+	assertContains(t, result.Text, "`pkg/ingestion.Parse`")
+}
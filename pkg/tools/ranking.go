@@ -0,0 +1,264 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRecencyWindow is how far back git history counts towards the
+// recency boost when RankingWeights.RecencyWindow is unset.
+const DefaultRecencyWindow = 90 * 24 * time.Hour
+
+// rerankPoolFactor bounds reranking to the closest limit*factor candidates,
+// so boosts reorder good matches instead of promoting distant ones.
+const rerankPoolFactor = 5
+
+// recencyCacheTTL is how long the git recency of files is reused across
+// searches.
+const recencyCacheTTL = 5 * time.Minute
+
+// RankingWeights combines vector similarity with boosts into the score that
+// orders semantic search results. With the zero boosts of
+// DefaultRankingWeights results are ordered by similarity alone.
+type RankingWeights struct {
+	// Similarity multiplies the vector similarity (0-1).
+	Similarity float64
+
+	// NameMatch is added in proportion to the share of query terms that
+	// appear in the function name.
+	NameMatch float64
+
+	// PathBoosts add their boost to results under their prefix. The longest
+	// matching prefix wins.
+	PathBoosts []PathBoost
+
+	// Recency is added in proportion to how recently the file changed in
+	// git: fully for a change today, nothing for one older than
+	// RecencyWindow.
+	Recency       float64
+	RecencyWindow time.Duration
+
+	// Centrality is added in proportion to the function's callers, relative
+	// to the most-called candidate (log scale).
+	Centrality float64
+}
+
+// PathBoost adds Boost to results whose file path starts with Prefix.
+type PathBoost struct {
+	Prefix string
+	Boost  float64
+}
+
+// DefaultRankingWeights ranks by similarity alone.
+func DefaultRankingWeights() RankingWeights {
+	return RankingWeights{Similarity: 1, RecencyWindow: DefaultRecencyWindow}
+}
+
+// Boosted reports whether w can reorder results, that is whether any boost
+// is set.
+func (w RankingWeights) Boosted() bool {
+	return w.NameMatch != 0 || len(w.PathBoosts) > 0 || w.Recency != 0 || w.Centrality != 0
+}
+
+// pathBoost returns the boost of the longest prefix matching path.
+func (w RankingWeights) pathBoost(path string) float64 {
+	best, boost := -1, 0.0
+	for _, pb := range w.PathBoosts {
+		if strings.HasPrefix(path, pb.Prefix) && len(pb.Prefix) > best {
+			best, boost = len(pb.Prefix), pb.Boost
+		}
+	}
+	return boost
+}
+
+// rerankRows reorders semantic search rows (name, file_path, signature,
+// start_line, distance, code_text, function_id) by the score of w. Only the
+// closest limit*rerankPoolFactor rows are reranked; the rest keep their
+// order after them. Signals that cannot be computed (no git repository,
+// failed caller query) contribute nothing.
+func rerankRows(ctx context.Context, client Querier, git GitRunner, rows [][]any, query string, limit int, w RankingWeights) [][]any {
+	if !w.Boosted() || len(rows) < 2 {
+		return rows
+	}
+	pool := rows
+	if n := limit * rerankPoolFactor; limit > 0 && len(pool) > n {
+		pool = rows[:n]
+	}
+
+	var recency map[string]float64
+	if w.Recency != 0 && git != nil {
+		recency = FileRecency(ctx, git, w.RecencyWindow)
+	}
+	var centrality map[string]float64
+	if w.Centrality != 0 {
+		centrality = callerCentrality(ctx, client, pool)
+	}
+	terms := ExtractKeyTerms(strings.ToLower(query))
+
+	scores := make([]float64, len(pool))
+	for i, row := range pool {
+		name := strings.ToLower(AnyToString(row[0]))
+		path := AnyToString(row[1])
+		score := w.Similarity * rowSimilarity(row)
+		if w.NameMatch != 0 && len(terms) > 0 {
+			matched := 0
+			for _, t := range terms {
+				if strings.Contains(name, t) {
+					matched++
+				}
+			}
+			score += w.NameMatch * float64(matched) / float64(len(terms))
+		}
+		score += w.pathBoost(path)
+		score += w.Recency * recency[path]
+		if len(row) > 6 {
+			score += w.Centrality * centrality[AnyToString(row[6])]
+		}
+		scores[i] = score
+	}
+
+	order := make([]int, len(pool))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+	ranked := make([][]any, 0, len(rows))
+	for _, i := range order {
+		ranked = append(ranked, pool[i])
+	}
+	return append(ranked, rows[len(pool):]...)
+}
+
+// rowSimilarity converts the cosine distance of a result row to a 0-1
+// similarity.
+func rowSimilarity(row []any) float64 {
+	if len(row) < 5 {
+		return 0
+	}
+	d, ok := row[4].(float64)
+	if !ok {
+		return 0
+	}
+	return max(0, 1-d/2)
+}
+
+// callerCentrality returns, for each function of rows, log(1+callers)
+// relative to the most-called function among them.
+func callerCentrality(ctx context.Context, client Querier, rows [][]any) map[string]float64 {
+	quoted := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if len(row) > 6 {
+			quoted = append(quoted, fmt.Sprintf("%q", AnyToString(row[6])))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	script := fmt.Sprintf("?[id, count(caller_id)] := *cie_calls { caller_id, callee_id: id }, is_in(id, [%s])", strings.Join(quoted, ", "))
+	res, err := client.Query(ctx, script)
+	if err != nil {
+		return nil
+	}
+	counts := make(map[string]float64, len(res.Rows))
+	most := 0.0
+	for _, row := range res.Rows {
+		c := math.Log1p(toFloat64(row[1]))
+		counts[AnyToString(row[0])] = c
+		most = max(most, c)
+	}
+	if most == 0 {
+		return nil
+	}
+	for id, c := range counts {
+		counts[id] = c / most
+	}
+	return counts
+}
+
+var recencyCache = struct {
+	sync.Mutex
+	entries map[string]recencyEntry
+}{entries: map[string]recencyEntry{}}
+
+type recencyEntry struct {
+	at    time.Time
+	files map[string]float64
+}
+
+// FileRecency returns, for each file changed in git within window, 1 for a
+// change now falling linearly to 0 at the edge of the window. Results are
+// cached for a few minutes per repository. It returns nil if git fails.
+func FileRecency(ctx context.Context, git GitRunner, window time.Duration) map[string]float64 {
+	if window <= 0 {
+		window = DefaultRecencyWindow
+	}
+	key := git.RepoPath() + "|" + window.String()
+	recencyCache.Lock()
+	if e, ok := recencyCache.entries[key]; ok && time.Since(e.at) < recencyCacheTTL {
+		recencyCache.Unlock()
+		return e.files
+	}
+	recencyCache.Unlock()
+
+	now := time.Now()
+	out, err := git.Run(ctx, "log", fmt.Sprintf("--since=%d.hours.ago", int(window.Hours())), "--name-only", "--format=@%ct")
+	if err != nil {
+		return nil
+	}
+	files := parseFileRecency(out, now, window)
+
+	recencyCache.Lock()
+	recencyCache.entries[key] = recencyEntry{at: now, files: files}
+	recencyCache.Unlock()
+	return files
+}
+
+// parseFileRecency reads `git log --name-only --format=@%ct` output, newest
+// commit first, into per-file recency scores.
+func parseFileRecency(out string, now time.Time, window time.Duration) map[string]float64 {
+	files := map[string]float64{}
+	var score float64
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "@"):
+			ts, err := strconv.ParseInt(line[1:], 10, 64)
+			if err != nil {
+				score = 0
+				continue
+			}
+			age := now.Sub(time.Unix(ts, 0))
+			score = max(0, 1-float64(age)/float64(window))
+		default:
+			if _, seen := files[line]; !seen {
+				files[line] = score // the newest change comes first
+			}
+		}
+	}
+	return files
+}
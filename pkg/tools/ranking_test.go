@@ -0,0 +1,111 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func rankingRows() [][]any {
+	return [][]any{
+		{"parseConfig", "legacy/config.go", "", 1, 0.20, "", "f1"},
+		{"LoadSettings", "internal/core/settings.go", "", 1, 0.24, "", "f2"},
+		{"readConfigFile", "internal/util/io.go", "", 1, 0.26, "", "f3"},
+	}
+}
+
+func rankedNames(rows [][]any) string {
+	names := make([]string, len(rows))
+	for i, row := range rows {
+		names[i] = AnyToString(row[0])
+	}
+	return strings.Join(names, ",")
+}
+
+func TestRerankRows(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		w    RankingWeights
+		want string
+	}{
+		{"similarity only", DefaultRankingWeights(), "parseConfig,LoadSettings,readConfigFile"},
+		{"path prefix", RankingWeights{Similarity: 1, PathBoosts: []PathBoost{{Prefix: "internal/", Boost: 0.01}, {Prefix: "internal/core/", Boost: 0.05}}}, "LoadSettings,parseConfig,readConfigFile"},
+		{"negative path prefix", RankingWeights{Similarity: 1, PathBoosts: []PathBoost{{Prefix: "legacy/", Boost: -0.1}}}, "LoadSettings,readConfigFile,parseConfig"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rankedNames(rerankRows(ctx, nil, nil, rankingRows(), "load settings", 10, tt.w))
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRerankRows_Centrality(t *testing.T) {
+	client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		if !strings.Contains(script, "cie_calls") {
+			return nil, fmt.Errorf("unexpected query: %s", script)
+		}
+		return NewMockQueryResult([]string{"id", "count"}, [][]any{{"f3", float64(40)}, {"f1", float64(1)}}), nil
+	}, nil)
+	w := RankingWeights{Similarity: 1, Centrality: 0.1}
+	got := rankedNames(rerankRows(context.Background(), client, nil, rankingRows(), "config", 10, w))
+	if want := "readConfigFile,parseConfig,LoadSettings"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestRerankRows_PoolLimit(t *testing.T) {
+	var rows [][]any
+	for i := 0; i <= rerankPoolFactor; i++ {
+		rows = append(rows, []any{fmt.Sprintf("fn%d", i), fmt.Sprintf("pkg/f%d.go", i), "", 1, 0.1 * float64(i), "", ""})
+	}
+	last := fmt.Sprintf("pkg/f%d.go", rerankPoolFactor)
+	w := RankingWeights{Similarity: 1, PathBoosts: []PathBoost{{Prefix: last, Boost: 5}}}
+
+	// Outside the pool of a limit of 1, the boost cannot promote the row.
+	got := rerankRows(context.Background(), nil, nil, rows, "x", 1, w)
+	if AnyToString(got[len(got)-1][1]) != last {
+		t.Errorf("row outside the pool was reranked: %s", rankedNames(got))
+	}
+	got = rerankRows(context.Background(), nil, nil, rows, "x", 2, w)
+	if AnyToString(got[0][1]) != last {
+		t.Errorf("boosted row not first: %s", rankedNames(got))
+	}
+}
+
+func TestParseFileRecency(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	day := int64(24 * 60 * 60)
+	out := fmt.Sprintf("@%d\n\na.go\nb.go\n\n@%d\n\nb.go\nc.go\n", now.Unix(), now.Unix()-45*day)
+	got := parseFileRecency(out, now, 90*24*time.Hour)
+	want := map[string]float64{"a.go": 1, "b.go": 1, "c.go": 0.5}
+	for file, score := range want {
+		if math.Abs(got[file]-score) > 1e-9 {
+			t.Errorf("%s = %v, want %v", file, got[file], score)
+		}
+	}
+}
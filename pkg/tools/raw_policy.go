@@ -0,0 +1,423 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// Default guardrails applied to raw queries issued by AI agents.
+const (
+	DefaultRawQueryMaxRows = 1000
+	DefaultRawQueryTimeout = 30 * time.Second
+)
+
+// RawQueryPolicy restricts what cie_raw_query may run. A zero AllowWrites
+// keeps the tool read-only, which is the default for the MCP server.
+type RawQueryPolicy struct {
+	// AllowWrites permits mutations (:put, :rm, :create, ...) and
+	// state-changing system ops (::remove, ::hnsw, ...). Off by default.
+	AllowWrites bool
+
+	// AllowedRelations limits which stored relations a script may reference.
+	// Empty means every relation is allowed.
+	AllowedRelations []string
+
+	// MaxRows caps the number of rows returned (0 = unlimited).
+	MaxRows int
+
+	// Timeout bounds how long a query may run (0 = no limit).
+	Timeout time.Duration
+
+	// AutoLimit appends a :limit just above MaxRows to read queries that
+	// set none, so CozoDB stops early instead of materializing every match.
+	AutoLimit bool
+
+	// RejectUnboundedScans refuses scripts that read every row of a
+	// source-text relation (see unboundedScans). Otherwise they run and the
+	// result carries a warning.
+	RejectUnboundedScans bool
+}
+
+// DefaultRawQueryPolicy returns the read-only policy used by the MCP server.
+// It refuses unbounded scans up front, before they can tie up the database.
+func DefaultRawQueryPolicy() RawQueryPolicy {
+	return RawQueryPolicy{
+		MaxRows:              DefaultRawQueryMaxRows,
+		Timeout:              DefaultRawQueryTimeout,
+		AutoLimit:            true,
+		RejectUnboundedScans: true,
+	}
+}
+
+// Executor is implemented by clients that can run mutating scripts.
+// Querier.Query may be read-only (the embedded backend is), so writes that a
+// policy allows are routed here when available.
+type Executor interface {
+	Execute(ctx context.Context, script string) (*QueryResult, error)
+}
+
+// ParamQuerier is implemented by clients that bind named parameters to the
+// $name placeholders of a script, so values reach CozoDB as data instead of
+// being spliced into CozoScript.
+type ParamQuerier interface {
+	QueryWithParams(ctx context.Context, script string, params map[string]any) (*QueryResult, error)
+}
+
+// ParamExecutor is the Executor counterpart of ParamQuerier.
+type ParamExecutor interface {
+	ExecuteWithParams(ctx context.Context, script string, params map[string]any) (*QueryResult, error)
+}
+
+// paramNamePattern matches the names CozoScript accepts after $.
+var paramNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// checkParamNames rejects parameter names a script could not refer to.
+func checkParamNames(params map[string]any) error {
+	for name := range params {
+		if !paramNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid parameter name %q: use letters, digits and underscores, and refer to it as $%s", name, name)
+		}
+	}
+	return nil
+}
+
+// formatQueryParams renders params for an error message, after the query.
+func formatQueryParams(params map[string]any) string {
+	if len(params) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(params) // keys sorted
+	if err != nil {
+		return fmt.Sprintf("\n\nParams: %v", params)
+	}
+	return "\n\nParams: " + string(data)
+}
+
+// queryWithParams runs a read-only script, binding params when there are
+// any.
+func queryWithParams(ctx context.Context, client Querier, script string, params map[string]any) (*QueryResult, error) {
+	if len(params) == 0 {
+		return client.Query(ctx, script)
+	}
+	pq, ok := client.(ParamQuerier)
+	if !ok {
+		return nil, fmt.Errorf("this client does not support query parameters; write the values into the script")
+	}
+	return pq.QueryWithParams(ctx, script, params)
+}
+
+// relationRefPattern matches stored relation reads (*rel) and index
+// searches (~rel:idx), but not arithmetic like a*b.
+var relationRefPattern = regexp.MustCompile(`(?:^|[^\w)\]])[*~]([A-Za-z]\w*)`)
+
+// ReferencedRelations returns the stored relations a script reads or writes,
+// sorted and de-duplicated.
+func ReferencedRelations(script string) []string {
+	stripped := storage.StripLiterals(script)
+	seen := make(map[string]bool)
+	for _, m := range relationRefPattern.FindAllStringSubmatch(stripped, -1) {
+		seen[m[1]] = true
+	}
+	for _, rel := range storage.WriteTargets(script) {
+		seen[rel] = true
+	}
+	relations := make([]string, 0, len(seen))
+	for rel := range seen {
+		relations = append(relations, rel)
+	}
+	sort.Strings(relations)
+	return relations
+}
+
+// Check returns an error describing why the policy rejects a script.
+func (p RawQueryPolicy) Check(script string) error {
+	if !p.AllowWrites && storage.IsMutation(script) {
+		return fmt.Errorf("script modifies the database, but cie_raw_query is read-only. " +
+			"Set mcp.raw_query.allow_writes: true in .cie/project.yaml to opt in")
+	}
+	if len(p.AllowedRelations) == 0 {
+		return nil
+	}
+	allowed := make(map[string]bool, len(p.AllowedRelations))
+	for _, rel := range p.AllowedRelations {
+		allowed[rel] = true
+	}
+	var denied []string
+	for _, rel := range ReferencedRelations(script) {
+		if !allowed[rel] {
+			denied = append(denied, rel)
+		}
+	}
+	if len(denied) > 0 {
+		return fmt.Errorf("relation(s) not allowed: %s (allowed: %s)",
+			strings.Join(denied, ", "), strings.Join(p.AllowedRelations, ", "))
+	}
+	return nil
+}
+
+// heavyRelation is a relation holding source text. Reading one without its
+// key bound loads the text of every row, which takes minutes on a large
+// index.
+type heavyRelation struct {
+	key  string // key column
+	bind string // atoms that bind key cheaply, for hints
+}
+
+// sourceTextColumns are the value columns holding source text. A relation
+// storing one is a heavyRelation.
+var sourceTextColumns = map[string]bool{"code_text": true, "content": true}
+
+// keyBindHints shows, per key column, atoms that bind it cheaply.
+var keyBindHints = map[string]string{
+	"function_id": "*cie_function { id: function_id, name }, name == $name",
+	"type_id":     "*cie_type { id: type_id, name }, name == $name",
+	"file_id":     "*cie_file { id: file_id, path }, path == $path",
+}
+
+// heavyRelations lists the source-text relations of storage.Relations by
+// name.
+var heavyRelations = sourceTextRelations()
+
+func sourceTextRelations() map[string]heavyRelation {
+	heavy := make(map[string]heavyRelation)
+	for _, rel := range storage.Relations {
+		for _, c := range rel.Values {
+			if sourceTextColumns[c.Name] {
+				key := rel.Keys[0].Name
+				heavy[rel.Name] = heavyRelation{key: key, bind: keyBindHints[key]}
+			}
+		}
+	}
+	return heavy
+}
+
+// heavyAtomPattern builds the pattern matching a stored read of a
+// heavyRelations entry, optionally under a shared-server namespace
+// ("<ns>__cie_function_code"), and its opening bracket.
+func heavyAtomPattern() *regexp.Regexp {
+	names := make([]string, 0, len(heavyRelations))
+	for name := range heavyRelations {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	sort.Strings(names)
+	return regexp.MustCompile(`\*(?:\w+__)?(` + strings.Join(names, "|") + `)\s*([{\[])`)
+}
+
+var (
+	// heavyAtomRegexp matches a stored read of a heavyRelations entry.
+	heavyAtomRegexp = heavyAtomPattern()
+	// limitOptionPattern matches a :limit query option.
+	limitOptionPattern = regexp.MustCompile(`(^|[^:\w]):limit\b`)
+	// timeoutOptionPattern matches a :timeout query option.
+	timeoutOptionPattern = regexp.MustCompile(`(^|[^:\w]):timeout\b`)
+)
+
+// unboundedScans returns the source-text relations a script reads without
+// binding their key first: to a literal or $param in the atom, or to a
+// variable an earlier atom of the same rule binds. CozoDB evaluates a rule
+// body left to right, so such a read walks the whole relation.
+func unboundedScans(script string) []string {
+	stripped := storage.StripLiterals(script)
+	var scans []string
+	for _, m := range heavyAtomRegexp.FindAllStringSubmatchIndex(stripped, -1) {
+		rel := stripped[m[2]:m[3]]
+		end := closingBracket(stripped, m[4])
+		if end < 0 {
+			continue
+		}
+		keyVar := atomKeyVar(stripped[m[4]+1:end], stripped[m[4]] == '[', heavyRelations[rel].key)
+		if keyVar == "" {
+			continue
+		}
+		body := stripped[ruleBodyStart(stripped, m[0]):m[0]]
+		if keyVar != "_" && containsWord(body, keyVar) {
+			continue
+		}
+		if !containsString(scans, rel) {
+			scans = append(scans, rel)
+		}
+	}
+	return scans
+}
+
+// containsWord reports whether word occurs in s as a whole identifier.
+func containsWord(s, word string) bool {
+	for i := 0; ; {
+		j := strings.Index(s[i:], word)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(word)
+		if (start == 0 || !isWordByte(s[start-1])) && (end == len(s) || !isWordByte(s[end])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+// closingBracket returns the index of the bracket closing the one at open,
+// or -1 when it is not closed.
+func closingBracket(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{', '[', '(':
+			depth++
+		case '}', ']', ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// ruleBodyStart returns where the body of the rule containing pos starts.
+func ruleBodyStart(s string, pos int) int {
+	if i := strings.LastIndex(s[:pos], ":="); i >= 0 {
+		return i + 2
+	}
+	return 0
+}
+
+// atomKeyVar returns the variable an atom binds its key column to: "_" when
+// the atom leaves the key out, and "" when the key is bound to a literal,
+// a $param or an expression. positional atoms (*rel[...]) list the key
+// first.
+func atomKeyVar(inner string, positional bool, key string) string {
+	parts := strings.Split(inner, ",")
+	value := ""
+	if positional {
+		value = strings.TrimSpace(parts[0])
+	} else {
+		found := false
+		for _, part := range parts {
+			part = strings.TrimSpace(part)
+			if part == key {
+				return key
+			}
+			if name, v, ok := strings.Cut(part, ":"); ok && strings.TrimSpace(name) == key {
+				value, found = strings.TrimSpace(v), true
+				break
+			}
+		}
+		if !found {
+			return "_"
+		}
+	}
+	if value == "" || value == "_" {
+		return "_"
+	}
+	if !paramNamePattern.MatchString(value) {
+		return "" // $param, literal or expression
+	}
+	return value
+}
+
+// withAutoLimit appends `:limit n` to a read query that sets no limit. It
+// leaves alone writes, system ops, chained and imperative scripts, whose
+// options are per block.
+func withAutoLimit(script string, n int) (string, bool) {
+	stripped := strings.TrimSpace(storage.StripLiterals(script))
+	if n <= 0 || limitOptionPattern.MatchString(stripped) || storage.IsMutation(script) ||
+		strings.Contains(stripped, "::") || strings.HasPrefix(stripped, "{") ||
+		strings.HasPrefix(stripped, "%") || !strings.Contains(stripped, "?[") {
+		return script, false
+	}
+	return script + "\n:limit " + strconv.Itoa(n), true
+}
+
+// withTimeoutOption appends `:timeout` to a single query that sets none, so
+// CozoDB kills the query when the policy's time limit passes instead of
+// running it to completion in the background. System ops, chained and
+// imperative scripts take options per block and are left alone.
+func withTimeoutOption(script string, d time.Duration) string {
+	stripped := strings.TrimSpace(storage.StripLiterals(script))
+	if d <= 0 || timeoutOptionPattern.MatchString(stripped) ||
+		strings.Contains(stripped, "::") || strings.HasPrefix(stripped, "{") ||
+		strings.HasPrefix(stripped, "%") || !strings.Contains(stripped, "?[") {
+		return script
+	}
+	return script + "\n:timeout " + strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
+
+// scanHint explains how to avoid an unbounded scan of rel.
+func scanHint(rel string) string {
+	h := heavyRelations[rel]
+	return fmt.Sprintf("reads every row of %s because %s is not bound before it. "+
+		"Bind %s first, e.g. put `%s` before *%s, or use cie_grep / cie_search_text for text search",
+		rel, h.key, h.key, h.bind, rel)
+}
+
+// runRawScript executes a script with params under the policy's timeout,
+// routing allowed mutations to an Executor when the client provides one.
+// The timeout is passed to CozoDB as a `:timeout` option where the script
+// allows one; the context deadline still bounds how long the caller waits.
+func (p RawQueryPolicy) runRawScript(ctx context.Context, client Querier, script string, params map[string]any) (*QueryResult, error) {
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+		script = withTimeoutOption(script, p.Timeout)
+	}
+
+	type outcome struct {
+		result *QueryResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		if p.AllowWrites && storage.IsMutation(script) {
+			if exec, ok := client.(ParamExecutor); ok && len(params) > 0 {
+				r, err := exec.ExecuteWithParams(ctx, script, params)
+				done <- outcome{r, err}
+				return
+			}
+			if exec, ok := client.(Executor); ok && len(params) == 0 {
+				r, err := exec.Execute(ctx, script)
+				done <- outcome{r, err}
+				return
+			}
+		}
+		r, err := queryWithParams(ctx, client, script, params)
+		done <- outcome{r, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("query exceeded time limit of %s", p.Timeout)
+	case o := <-done:
+		if o.err != nil && ctx.Err() != nil {
+			// CozoDB killed the query at its :timeout.
+			return nil, fmt.Errorf("query exceeded time limit of %s", p.Timeout)
+		}
+		return o.result, o.err
+	}
+}
@@ -0,0 +1,355 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReferencedRelations(t *testing.T) {
+	script := `?[n, d] := *cie_function { id, name: n }, ~cie_function_embedding:embedding_idx { function_id: id | query: q, k: 5, bind_distance: d }, x = 2*y, regex_matches(n, "*cie_secret")`
+	got := ReferencedRelations(script)
+	want := []string{"cie_function", "cie_function_embedding"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReferencedRelations() = %v, want %v", got, want)
+	}
+
+	got = ReferencedRelations("?[id] <- [['a']] :put cie_file { id }")
+	if !reflect.DeepEqual(got, []string{"cie_file"}) {
+		t.Errorf("write target not detected: %v", got)
+	}
+}
+
+func TestRawQueryPolicy_Check(t *testing.T) {
+	readOnly := DefaultRawQueryPolicy()
+	if err := readOnly.Check("?[name] := *cie_function { name }"); err != nil {
+		t.Errorf("read query rejected: %v", err)
+	}
+	if err := readOnly.Check("?[k, v] <- [['a', 'b']] :put cie_project_meta { key: k, value: v }"); err == nil {
+		t.Error("write allowed under read-only policy")
+	}
+
+	writable := RawQueryPolicy{AllowWrites: true}
+	if err := writable.Check("::remove cie_function"); err != nil {
+		t.Errorf("write rejected under allow_writes: %v", err)
+	}
+
+	restricted := RawQueryPolicy{AllowedRelations: []string{"cie_function", "cie_file"}}
+	if err := restricted.Check("?[n] := *cie_function { name: n }"); err != nil {
+		t.Errorf("allowed relation rejected: %v", err)
+	}
+	err := restricted.Check("?[c] := *cie_function_code { code_text: c }")
+	if err == nil || !strings.Contains(err.Error(), "cie_function_code") {
+		t.Errorf("expected cie_function_code to be denied, got %v", err)
+	}
+}
+
+func TestUnboundedScans(t *testing.T) {
+	tests := []struct {
+		script string
+		want   []string
+	}{
+		{`?[c] := *cie_function_code { code_text: c }, regex_matches(c, "TODO")`, []string{"cie_function_code"}},
+		{`?[c] := *cie_function_code { function_id, code_text: c }`, []string{"cie_function_code"}},
+		{`?[c] := *cie_function_code { function_id: $id, code_text: c }`, nil},
+		{`?[c] := *cie_function_code { function_id: "abc", code_text: c }`, nil},
+		{`?[c] := *cie_function { id, name }, name == $name, *cie_function_code { function_id: id, code_text: c }`, nil},
+		{`?[c] := *cie_function_code { function_id: id, code_text: c }, *cie_function { id, name }`, []string{"cie_function_code"}},
+		{`?[c] := *cie_type_code[_, c]`, []string{"cie_type_code"}},
+		{`?[c] := *cie_file { id, path }, path == $path, *cie_file_content[id, c]`, nil},
+		{`?[n] := *cie_function { name: n }, regex_matches(n, "*cie_function_code { }")`, nil},
+		{`?[c] := *acme_api__cie_function_code { code_text: c }`, []string{"cie_function_code"}},
+		{`?[c] := *acme_api__cie_function { id, name }, name == $name, *acme_api__cie_function_code { function_id: id, code_text: c }`, nil},
+		{`?[c] := *cie_function { id: function_id_x }, *cie_function_code { function_id, code_text: c }`, []string{"cie_function_code"}},
+	}
+	for _, tt := range tests {
+		if got := unboundedScans(tt.script); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("unboundedScans(%q) = %v, want %v", tt.script, got, tt.want)
+		}
+	}
+}
+
+func TestHeavyRelations(t *testing.T) {
+	want := map[string]string{
+		"cie_function_code":       "function_id",
+		"cie_function_code_chunk": "function_id",
+		"cie_type_code":           "type_id",
+		"cie_file_content":        "file_id",
+	}
+	if len(heavyRelations) != len(want) {
+		t.Errorf("heavyRelations = %v, want %v", heavyRelations, want)
+	}
+	for rel, key := range want {
+		h, ok := heavyRelations[rel]
+		if !ok || h.key != key || h.bind == "" {
+			t.Errorf("heavyRelations[%q] = %+v, want key %q with a hint", rel, h, key)
+		}
+	}
+}
+
+func TestWithAutoLimit(t *testing.T) {
+	tests := []struct {
+		script string
+		want   bool
+	}{
+		{"?[name] := *cie_function { name }", true},
+		{"?[name] := *cie_function { name } :order name", true},
+		{"?[name] := *cie_function { name } :limit 10", false},
+		{`?[name] := *cie_function { name }, name == ":limit 5"`, true},
+		{"::relations", false},
+		{"?[id] <- [['x']] :put cie_file { id }", false},
+		{"{ ?[a] <- [[1]] } { ?[a] <- [[2]] }", false},
+	}
+	for _, tt := range tests {
+		got, limited := withAutoLimit(tt.script, 1001)
+		if limited != tt.want {
+			t.Errorf("withAutoLimit(%q) limited = %v, want %v", tt.script, limited, tt.want)
+		}
+		if limited && got != tt.script+"\n:limit 1001" {
+			t.Errorf("withAutoLimit(%q) = %q", tt.script, got)
+		}
+	}
+}
+
+func TestWithTimeoutOption(t *testing.T) {
+	tests := []struct {
+		script string
+		want   bool
+	}{
+		{"?[name] := *cie_function { name }", true},
+		{"?[name] := *cie_function { name } :limit 10", true},
+		{"?[name] := *cie_function { name } :timeout 5", false},
+		{`?[name] := *cie_function { name }, name == ":timeout 5"`, true},
+		{"?[id] <- [['x']] :put cie_file { id }", true},
+		{"::relations", false},
+		{"{ ?[a] <- [[1]] } { ?[a] <- [[2]] }", false},
+	}
+	for _, tt := range tests {
+		got := withTimeoutOption(tt.script, 1500*time.Millisecond)
+		if want := tt.script + "\n:timeout 1.5"; (got == want) != tt.want {
+			t.Errorf("withTimeoutOption(%q) = %q", tt.script, got)
+		}
+	}
+	if got := withTimeoutOption("?[a] <- [[1]]", 0); got != "?[a] <- [[1]]" {
+		t.Errorf("zero timeout changed the script: %q", got)
+	}
+}
+
+// mockExecClient records whether writes went through Execute.
+type mockExecClient struct {
+	MockCIEClient
+	executed string
+}
+
+func (m *mockExecClient) Execute(_ context.Context, script string) (*QueryResult, error) {
+	m.executed = script
+	return &QueryResult{}, nil
+}
+
+func TestRawQuery_Policy(t *testing.T) {
+	ctx := setupTest(t)
+	rows := [][]any{{"a"}, {"b"}, {"c"}, {"d"}}
+
+	t.Run("read-only rejects writes", func(t *testing.T) {
+		policy := DefaultRawQueryPolicy()
+		client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+			t.Fatal("rejected script must not reach the database")
+			return nil, nil
+		}, nil)
+		result, err := RawQuery(ctx, client, RawQueryArgs{Script: "::remove cie_file", Policy: &policy})
+		assertNoError(t, err)
+		if !result.IsError {
+			t.Fatal("expected error result")
+		}
+		assertContains(t, result.Text, "read-only")
+	})
+
+	t.Run("row limit", func(t *testing.T) {
+		policy := RawQueryPolicy{MaxRows: 2}
+		client := NewMockClientWithResults([]string{"name"}, rows)
+		result, err := RawQuery(ctx, client, RawQueryArgs{Script: "?[name] := *cie_function { name }", Policy: &policy})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "Found 2 results")
+		assertContains(t, result.Text, "Showing first 2 of 4 rows")
+	})
+
+	t.Run("auto limit", func(t *testing.T) {
+		policy := RawQueryPolicy{MaxRows: 2, AutoLimit: true}
+		var ran string
+		client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+			ran = script
+			return &QueryResult{Headers: []string{"name"}, Rows: rows[:3]}, nil
+		}, nil)
+		result, err := RawQuery(ctx, client, RawQueryArgs{Script: "?[name] := *cie_function { name }", Policy: &policy})
+		assertNoError(t, err)
+		if !strings.HasSuffix(ran, ":limit 3") {
+			t.Errorf("script ran without the automatic limit: %q", ran)
+		}
+		assertContains(t, result.Text, "Showing first 2 rows; more matched")
+	})
+
+	t.Run("unbounded scan refused by default", func(t *testing.T) {
+		policy := DefaultRawQueryPolicy()
+		client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+			t.Fatal("rejected script must not reach the database")
+			return nil, nil
+		}, nil)
+		result, err := RawQuery(ctx, client, RawQueryArgs{Script: `?[c] := *cie_file_content { content: c }`, Policy: &policy})
+		assertNoError(t, err)
+		if !result.IsError {
+			t.Fatal("expected the scan to be rejected")
+		}
+		assertContains(t, result.Text, "allow_unbounded_scans")
+	})
+
+	t.Run("unbounded scan", func(t *testing.T) {
+		script := `?[c] := *cie_function_code { code_text: c }, regex_matches(c, "TODO")`
+		policy := RawQueryPolicy{}
+		client := NewMockClientWithResults([]string{"c"}, rows[:1])
+		result, err := RawQuery(ctx, client, RawQueryArgs{Script: script, Policy: &policy})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "Unbounded scan")
+
+		policy.RejectUnboundedScans = true
+		client = NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+			t.Fatal("rejected script must not reach the database")
+			return nil, nil
+		}, nil)
+		result, err = RawQuery(ctx, client, RawQueryArgs{Script: script, Policy: &policy})
+		assertNoError(t, err)
+		if !result.IsError {
+			t.Fatal("expected the scan to be rejected")
+		}
+		assertContains(t, result.Text, "*cie_function { id: function_id, name }")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		policy := RawQueryPolicy{Timeout: 20 * time.Millisecond}
+		client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+			time.Sleep(200 * time.Millisecond)
+			return &QueryResult{}, nil
+		}, nil)
+		result, err := RawQuery(ctx, client, RawQueryArgs{Script: "?[name] := *cie_function { name }", Policy: &policy})
+		assertNoError(t, err)
+		if !result.IsError {
+			t.Fatal("expected timeout error")
+		}
+		assertContains(t, result.Text, "time limit")
+	})
+
+	t.Run("timeout option", func(t *testing.T) {
+		policy := RawQueryPolicy{Timeout: 30 * time.Second}
+		var ran string
+		client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+			ran = script
+			return &QueryResult{}, nil
+		}, nil)
+		_, err := RawQuery(ctx, client, RawQueryArgs{Script: "?[name] := *cie_function { name }", Policy: &policy})
+		assertNoError(t, err)
+		if !strings.HasSuffix(ran, ":timeout 30") {
+			t.Errorf("script ran without a CozoDB timeout: %q", ran)
+		}
+	})
+
+	t.Run("allowed writes use Executor", func(t *testing.T) {
+		policy := RawQueryPolicy{AllowWrites: true}
+		client := &mockExecClient{}
+		script := "?[id] <- [['x']] :rm cie_file { id }"
+		result, err := RawQuery(ctx, client, RawQueryArgs{Script: script, Policy: &policy})
+		assertNoError(t, err)
+		if result.IsError {
+			t.Fatalf("unexpected error: %s", result.Text)
+		}
+		if client.executed != script {
+			t.Errorf("write was not routed to Execute")
+		}
+	})
+}
+
+// mockParamClient records the params bound to queries and writes.
+type mockParamClient struct {
+	mockExecClient
+	queried  map[string]any
+	executed map[string]any
+}
+
+func (m *mockParamClient) QueryWithParams(_ context.Context, script string, params map[string]any) (*QueryResult, error) {
+	m.queried = params
+	return NewMockQueryResult([]string{"file_path"}, [][]any{{"batcher.go"}}), nil
+}
+
+func (m *mockParamClient) ExecuteWithParams(_ context.Context, script string, params map[string]any) (*QueryResult, error) {
+	m.executed = params
+	return &QueryResult{}, nil
+}
+
+func TestRawQuery_Params(t *testing.T) {
+	ctx := setupTest(t)
+	script := `?[file_path] := *cie_function { name, file_path }, name = $name`
+	params := map[string]any{"name": `x" }, *cie_file { path }`}
+
+	t.Run("bound by the client", func(t *testing.T) {
+		policy := DefaultRawQueryPolicy()
+		client := &mockParamClient{}
+		result, err := RawQuery(ctx, client, RawQueryArgs{Script: script, Params: params, Policy: &policy})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "batcher.go")
+		if !reflect.DeepEqual(client.queried, params) {
+			t.Errorf("params = %v, want %v", client.queried, params)
+		}
+	})
+
+	t.Run("writes", func(t *testing.T) {
+		policy := RawQueryPolicy{AllowWrites: true}
+		client := &mockParamClient{}
+		write := "?[id] <- [[$id]] :rm cie_file { id }"
+		result, err := RawQuery(ctx, client, RawQueryArgs{Script: write, Params: map[string]any{"id": "x"}, Policy: &policy})
+		assertNoError(t, err)
+		if result.IsError {
+			t.Fatalf("unexpected error: %s", result.Text)
+		}
+		if client.executed["id"] != "x" {
+			t.Errorf("write was not routed to ExecuteWithParams")
+		}
+	})
+
+	t.Run("unsupported client", func(t *testing.T) {
+		client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+			t.Fatal("params must not be dropped")
+			return nil, nil
+		}, nil)
+		result, err := RawQuery(ctx, client, RawQueryArgs{Script: script, Params: params})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "does not support query parameters")
+		assertContains(t, result.Text, `Params: {"name":`)
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		result, err := RawQuery(ctx, &mockParamClient{}, RawQueryArgs{Script: script, Params: map[string]any{"na me": 1}})
+		assertNoError(t, err)
+		if !result.IsError {
+			t.Fatal("expected an input error")
+		}
+		assertContains(t, result.Text, "invalid parameter name")
+	})
+}
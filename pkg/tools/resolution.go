@@ -0,0 +1,148 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ResolutionReportArgs holds arguments for the call resolution report.
+type ResolutionReportArgs struct {
+	PathPattern string // optional regex on the caller's file path
+	Reason      string // optional: only this reason, and list example calls
+	Limit       int    // top callees / examples to show (default 20)
+}
+
+// unresolvedReasons explains each reason recorded in cie_unresolved_call.
+var unresolvedReasons = map[string]string{
+	"external":         "Package outside the indexed code (stdlib, dependencies)",
+	"unexported":       "Lowercase name called through a package qualifier",
+	"unknown_receiver": "Method on a variable whose type could not be determined",
+	"not_found":        "No function of that name in the file, its package or the imported package",
+}
+
+// ResolutionReport summarizes call-graph coverage: how many calls resolved to
+// an edge, how many did not, and why, using the cie_unresolved_call relation.
+func ResolutionReport(ctx context.Context, client Querier, args ResolutionReportArgs) (*ToolResult, error) {
+	if args.Reason != "" {
+		if _, ok := unresolvedReasons[args.Reason]; !ok {
+			return NewInputError(fmt.Sprintf("Error: unknown reason %q (use one of: %s)", args.Reason, strings.Join(sortedReasonNames(), ", "))), nil
+		}
+	}
+	if args.Limit <= 0 {
+		args.Limit = 20
+	}
+
+	unresolvedFilter := ""
+	resolvedFilter := ""
+	if args.PathPattern != "" {
+		unresolvedFilter = fmt.Sprintf(", regex_matches(file_path, %q)", args.PathPattern)
+		resolvedFilter = fmt.Sprintf(", *cie_function { id: caller_id, file_path }, regex_matches(file_path, %q)", args.PathPattern)
+	}
+
+	byReason, err := client.Query(ctx, fmt.Sprintf("?[reason, count(id)] := *cie_unresolved_call { id, reason, file_path }%s", unresolvedFilter))
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v (indexes built before cie_unresolved_call need 'cie index')", err)), nil
+	}
+	resolvedCount := 0
+	if resolved, err := client.Query(ctx, fmt.Sprintf("?[count(caller_id)] := *cie_calls { caller_id, callee_id }%s", resolvedFilter)); err == nil && len(resolved.Rows) > 0 {
+		resolvedCount = int(toFloat64(resolved.Rows[0][0]))
+	}
+
+	reasonCounts := map[string]int{}
+	unresolvedCount := 0
+	for _, row := range byReason.Rows {
+		n := int(toFloat64(row[1]))
+		reasonCounts[AnyToString(row[0])] = n
+		unresolvedCount += n
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Call Resolution Report\n\n")
+	if args.PathPattern != "" {
+		fmt.Fprintf(&sb, "**Scope**: `%s`\n", displayPattern(args.PathPattern))
+	}
+	coverage := 100.0
+	if total := resolvedCount + unresolvedCount; total > 0 {
+		coverage = float64(resolvedCount) / float64(total) * 100
+	}
+	fmt.Fprintf(&sb, "**Call edges**: %d resolved, %d unresolved (%.1f%% coverage)\n\n", resolvedCount, unresolvedCount, coverage)
+	if unresolvedCount == 0 {
+		sb.WriteString("No unresolved calls recorded. Only Go cross-package calls are tracked; builtins and conversions are never counted.\n")
+		return NewResult(sb.String()), nil
+	}
+
+	sb.WriteString("## Unresolved by Reason\n\n| Reason | Calls | Meaning |\n|--------|-------|---------|\n")
+	for _, reason := range sortedReasonNames() {
+		if n := reasonCounts[reason]; n > 0 {
+			fmt.Fprintf(&sb, "| %s | %d | %s |\n", reason, n, unresolvedReasons[reason])
+		}
+	}
+
+	reasonFilter := ""
+	if args.Reason != "" {
+		reasonFilter = fmt.Sprintf(", reason = %q", args.Reason)
+	}
+	callees, err := client.Query(ctx, fmt.Sprintf("?[callee_name, reason, count(id)] := *cie_unresolved_call { id, callee_name, reason, file_path }%s%s", unresolvedFilter, reasonFilter))
+	if err == nil && len(callees.Rows) > 0 {
+		rows := callees.Rows
+		sort.SliceStable(rows, func(i, j int) bool {
+			ci, cj := toFloat64(rows[i][2]), toFloat64(rows[j][2])
+			if ci != cj {
+				return ci > cj
+			}
+			return AnyToString(rows[i][0]) < AnyToString(rows[j][0])
+		})
+		if len(rows) > args.Limit {
+			rows = rows[:args.Limit]
+		}
+		sb.WriteString("\n## Top Unresolved Callees\n\n| Callee | Reason | Calls |\n|--------|--------|-------|\n")
+		for _, row := range rows {
+			fmt.Fprintf(&sb, "| `%s` | %s | %d |\n", AnyToString(row[0]), AnyToString(row[1]), int(toFloat64(row[2])))
+		}
+	}
+
+	if args.Reason != "" {
+		examples, err := client.Query(ctx, fmt.Sprintf(
+			"?[file_path, line, caller, callee_name] := *cie_unresolved_call { caller_id, callee_name, file_path, line, reason }, *cie_function { id: caller_id, name: caller }%s%s :order file_path, line :limit %d",
+			unresolvedFilter, reasonFilter, args.Limit))
+		if err == nil && len(examples.Rows) > 0 {
+			fmt.Fprintf(&sb, "\n## Examples (%s)\n\n", args.Reason)
+			for _, row := range examples.Rows {
+				fmt.Fprintf(&sb, "- %s:%s `%s` → `%s`\n", AnyToString(row[0]), AnyToString(row[1]), AnyToString(row[2]), AnyToString(row[3]))
+			}
+		}
+	} else {
+		sb.WriteString("\n_Pass `reason` to list example call sites._\n")
+	}
+	return NewResult(sb.String()), nil
+}
+
+// sortedReasonNames returns the known unresolved-call reasons in order.
+func sortedReasonNames() []string {
+	names := make([]string, 0, len(unresolvedReasons))
+	for name := range unresolvedReasons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,87 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// resolutionMock serves a call-resolution report. Every query must carry
+// filters, the scope conditions of the call.
+func resolutionMock(t *testing.T, filters ...string) Querier {
+	return NewMockClientScripted(t,
+		MockQuery{
+			Match: []string{"?[reason, count(id)]"},
+			Want:  append([]string{"*cie_unresolved_call { id, reason, file_path }"}, filters...),
+			Rows:  [][]any{{"external", float64(6)}, {"unknown_receiver", float64(2)}},
+		},
+		MockQuery{
+			Match: []string{"?[count(caller_id)]"},
+			Want:  append([]string{"*cie_calls { caller_id, callee_id }"}, filters...),
+			Rows:  [][]any{{float64(24)}},
+		},
+		MockQuery{
+			Match: []string{"?[callee_name, reason, count(id)]"},
+			Want:  append([]string{"*cie_unresolved_call { id, callee_name, reason, file_path }"}, filters...),
+			Rows: [][]any{
+				{"db.Query", "unknown_receiver", float64(2)},
+				{"fmt.Println", "external", float64(5)},
+				{"os.Exit", "external", float64(1)},
+			},
+		},
+		MockQuery{
+			Match: []string{"?[file_path, line, caller, callee_name]"},
+			Want:  append([]string{"*cie_unresolved_call {", "*cie_function { id: caller_id, name: caller }", `reason = "external"`}, filters...),
+			Rows:  [][]any{{"main.go", float64(12), "main", "fmt.Println"}},
+		},
+	)
+}
+
+func TestResolutionReport(t *testing.T) {
+	ctx := context.Background()
+
+	result, err := ResolutionReport(ctx, resolutionMock(t), ResolutionReportArgs{Limit: 2})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "**Call edges**: 24 resolved, 8 unresolved (75.0% coverage)")
+	assertContains(t, result.Text, "| external | 6 |")
+	assertContains(t, result.Text, "| `fmt.Println` | external | 5 |")
+	if strings.Contains(result.Text, "os.Exit") {
+		t.Error("limit should cap the top callees")
+	}
+	if strings.Index(result.Text, "fmt.Println") > strings.Index(result.Text, "db.Query") {
+		t.Error("callees should be sorted by count")
+	}
+
+	result, err = ResolutionReport(ctx, resolutionMock(t), ResolutionReportArgs{Reason: "external"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "- main.go:12 `main` → `fmt.Println`")
+
+	result, err = ResolutionReport(ctx, resolutionMock(t, `regex_matches(file_path, "^internal/")`), ResolutionReportArgs{PathPattern: "^internal/"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "**Scope**: `^internal/`")
+
+	result, err = ResolutionReport(ctx, resolutionMock(t), ResolutionReportArgs{Reason: "bogus"})
+	assertNoError(t, err)
+	if !result.IsError {
+		t.Error("unknown reason should be an input error")
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// builtinRoles are the role filters that do not need configuration.
+var builtinRoles = map[string]bool{
+	"any": true, "source": true, "test": true, "generated": true,
+	"entry_point": true, "router": true, "handler": true,
+}
+
+// RoleFilter decides whether a function belongs to a role. It combines the
+// built-in test/generated classification with the custom roles of
+// project.yaml:
+//
+//   - a custom role named "test" or "generated" adds files to that built-in
+//     role, which also removes them from "source";
+//   - a custom role with ExcludeFromSource removes its matches from "source";
+//   - any other custom role name selects the functions matching its patterns.
+type RoleFilter struct {
+	role      string
+	custom    *compiledRole   // set when role is a custom role
+	test      *compiledRole   // extra test files
+	generated *compiledRole   // extra generated files
+	hidden    []*compiledRole // roles excluded from source
+}
+
+// compiledRole is a RolePattern with its regexes compiled. Every set
+// pattern must match.
+type compiledRole struct {
+	file, name, code *regexp.Regexp
+}
+
+// NewRoleFilter compiles the filter for role. An empty role means "source".
+// It returns an error for an unknown role or an invalid custom pattern.
+func NewRoleFilter(role string, custom map[string]RolePattern) (*RoleFilter, error) {
+	if role == "" {
+		role = "source"
+	}
+	f := &RoleFilter{role: role}
+	names := make([]string, 0, len(custom))
+	for name := range custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c, err := compileRole(name, custom[name])
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case name == "test":
+			f.test = c
+		case name == "generated":
+			f.generated = c
+		case custom[name].ExcludeFromSource:
+			f.hidden = append(f.hidden, c)
+		}
+		if name == role && !builtinRoles[name] {
+			f.custom = c
+		}
+	}
+	if f.custom == nil && !builtinRoles[role] {
+		return nil, fmt.Errorf("unknown role %q (built-in: %s%s)", role, strings.Join(BuiltinRoleNames(), ", "), customRoleList(names))
+	}
+	return f, nil
+}
+
+// ValidateRoles reports the first custom role with an invalid pattern.
+func ValidateRoles(custom map[string]RolePattern) error {
+	_, err := NewRoleFilter("any", custom)
+	return err
+}
+
+// BuiltinRoleNames returns the built-in role names, sorted.
+func BuiltinRoleNames() []string {
+	names := make([]string, 0, len(builtinRoles))
+	for name := range builtinRoles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func customRoleList(names []string) string {
+	var custom []string
+	for _, name := range names {
+		if !builtinRoles[name] {
+			custom = append(custom, name)
+		}
+	}
+	if len(custom) == 0 {
+		return ""
+	}
+	return "; custom: " + strings.Join(custom, ", ")
+}
+
+func compileRole(name string, p RolePattern) (*compiledRole, error) {
+	var filePatterns []string
+	if p.FilePattern != "" {
+		filePatterns = append(filePatterns, p.FilePattern)
+	}
+	for _, glob := range p.Paths {
+		filePatterns = append(filePatterns, GlobToRegex(glob))
+	}
+	if len(filePatterns) == 0 && p.NamePattern == "" && p.CodePattern == "" {
+		return nil, fmt.Errorf("role %q needs paths, file_pattern, name_pattern or code_pattern", name)
+	}
+	c := &compiledRole{}
+	var err error
+	if len(filePatterns) > 0 {
+		if c.file, err = regexp.Compile("(" + strings.Join(filePatterns, ")|(") + ")"); err != nil {
+			return nil, fmt.Errorf("role %q: invalid paths or file_pattern: %w", name, err)
+		}
+	}
+	if p.NamePattern != "" {
+		if c.name, err = regexp.Compile(p.NamePattern); err != nil {
+			return nil, fmt.Errorf("role %q: invalid name_pattern: %w", name, err)
+		}
+	}
+	if p.CodePattern != "" {
+		if c.code, err = regexp.Compile(p.CodePattern); err != nil {
+			return nil, fmt.Errorf("role %q: invalid code_pattern: %w", name, err)
+		}
+	}
+	return c, nil
+}
+
+// match reports whether a function matches every pattern of the role.
+// Patterns on data the caller does not have (empty name or code) are
+// skipped; a role none of whose patterns could be checked does not match.
+func (c *compiledRole) match(name, filePath, code string) bool {
+	if c == nil {
+		return false
+	}
+	checked := false
+	for _, p := range []struct {
+		re   *regexp.Regexp
+		text string
+	}{{c.file, filePath}, {c.name, name}, {c.code, code}} {
+		if p.re == nil || p.text == "" {
+			continue
+		}
+		if !p.re.MatchString(p.text) {
+			return false
+		}
+		checked = true
+	}
+	return checked
+}
+
+// fileOnly reports whether the role can be decided from the file path.
+func (c *compiledRole) fileOnly() bool {
+	return c != nil && c.file != nil && c.name == nil && c.code == nil
+}
+
+// Role returns the role the filter selects.
+func (f *RoleFilter) Role() string {
+	return f.role
+}
+
+// Match reports whether a function belongs to the role. name and code may
+// be empty when only the file is known.
+func (f *RoleFilter) Match(name, filePath, code string) bool {
+	if f.custom != nil {
+		return f.custom.match(name, filePath, code)
+	}
+	isTest := testFilePattern.MatchString(filePath) || (f.test.fileOnly() && f.test.match(name, filePath, code))
+	isGenerated := generatedFilePattern.MatchString(filePath) || (f.generated.fileOnly() && f.generated.match(name, filePath, code))
+	switch f.role {
+	case "any":
+		return true
+	case "test":
+		return isTest || f.test.match(name, filePath, code)
+	case "generated":
+		return isGenerated || f.generated.match(name, filePath, code)
+	case "source":
+		for _, h := range f.hidden {
+			if h.match(name, filePath, code) {
+				return false
+			}
+		}
+		return !isTest && !isGenerated
+	default: // entry_point, router, handler: matched by the query, not the path
+		return !isTest && !isGenerated
+	}
+}
+
+// IsCustom reports whether the filter selects a custom role.
+func (f *RoleFilter) IsCustom() bool {
+	return f.custom != nil
+}
+
+// CustomFilePattern returns the file regex of a custom role, or "" for
+// built-in roles and custom roles without paths or file_pattern.
+func (f *RoleFilter) CustomFilePattern() string {
+	if f.custom == nil || f.custom.file == nil {
+		return ""
+	}
+	return f.custom.file.String()
+}
+
+// MatchFile reports whether a file belongs to the role, for tools that list
+// files rather than functions. Name and code patterns are not checked.
+func (f *RoleFilter) MatchFile(filePath string) bool {
+	return f.Match("", filePath, "")
+}
+
+// GlobToRegex converts a path glob to an anchored regex: ** matches across
+// directories, * and ? within one. A trailing /** also matches the
+// directory itself. Metacharacters are written as classes ([.]) so the
+// result is valid in both Go and CozoDB regexes.
+func GlobToRegex(glob string) string {
+	glob = strings.TrimPrefix(glob, "./")
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			sb.WriteString("(/.*)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '\\' || c == '^':
+			sb.WriteString(`\` + string(c))
+		case strings.IndexByte(".+()|{}$[]", c) >= 0:
+			sb.WriteString("[" + string(c) + "]")
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	sb.WriteString("$")
+	return sb.String()
+}
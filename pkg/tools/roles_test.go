@@ -0,0 +1,118 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestGlobToRegex(t *testing.T) {
+	tests := []struct {
+		glob  string
+		path  string
+		match bool
+	}{
+		{"internal/experimental/**", "internal/experimental/flags/flags.go", true},
+		{"internal/experimental/**", "internal/experimental", true},
+		{"internal/experimental/**", "internal/experimentalist/x.go", false},
+		{"**/testdata/**", "pkg/parser/testdata/a.go", true},
+		{"**/testdata/**", "testdata/a.go", true},
+		{"cmd/*/main.go", "cmd/cie/main.go", true},
+		{"cmd/*/main.go", "cmd/cie/sub/main.go", false},
+		{"*.pb.go", "api.pb.go", true},
+		{"*.pb.go", "apixpbxgo", false},
+		{"./gen/?.go", "gen/a.go", true},
+	}
+	for _, tt := range tests {
+		re := regexp.MustCompile(GlobToRegex(tt.glob))
+		if got := re.MatchString(tt.path); got != tt.match {
+			t.Errorf("%s (%s) on %s = %v, want %v", tt.glob, GlobToRegex(tt.glob), tt.path, got, tt.match)
+		}
+	}
+}
+
+func TestRoleFilter(t *testing.T) {
+	custom := map[string]RolePattern{
+		"experimental": {Paths: []string{"internal/experimental/**"}, ExcludeFromSource: true},
+		"repository":   {FilePattern: "/store/", NamePattern: "Repo"},
+		"generated":    {Paths: []string{"gen/**"}},
+		"test":         {Paths: []string{"e2e/**"}},
+	}
+	tests := []struct {
+		role, name, path string
+		want             bool
+	}{
+		{"source", "Run", "cmd/app/main.go", true},
+		{"source", "Flag", "internal/experimental/flag.go", false},
+		{"source", "Model", "gen/model.go", false},
+		{"source", "TestLogin", "e2e/login.go", false},
+		{"source", "TestX", "pkg/x_test.go", false},
+		{"experimental", "Flag", "internal/experimental/flag.go", true},
+		{"experimental", "Run", "cmd/app/main.go", false},
+		{"repository", "UserRepo", "internal/store/user.go", true},
+		{"repository", "Open", "internal/store/db.go", false},
+		{"generated", "Model", "gen/model.go", true},
+		{"generated", "Msg", "api/v1/api.pb.go", true},
+		{"test", "TestLogin", "e2e/login.go", true},
+		{"any", "Flag", "internal/experimental/flag.go", true},
+		{"handler", "HandleLogin", "internal/experimental/http.go", true},
+	}
+	for _, tt := range tests {
+		f, err := NewRoleFilter(tt.role, custom)
+		if err != nil {
+			t.Fatalf("NewRoleFilter(%q): %v", tt.role, err)
+		}
+		if got := f.Match(tt.name, tt.path, ""); got != tt.want {
+			t.Errorf("role %s on %s %s = %v, want %v", tt.role, tt.path, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRoleFilter_Errors(t *testing.T) {
+	if _, err := NewRoleFilter("experimental", nil); err == nil {
+		t.Error("unknown role: want error")
+	}
+	if _, err := NewRoleFilter("source", map[string]RolePattern{"bad": {FilePattern: "("}}); err == nil {
+		t.Error("invalid file_pattern: want error")
+	}
+	if err := ValidateRoles(map[string]RolePattern{"empty": {Description: "no patterns"}}); err == nil {
+		t.Error("role without patterns: want error")
+	}
+	if err := ValidateRoles(nil); err != nil {
+		t.Errorf("no custom roles: %v", err)
+	}
+}
+
+func TestPostFilterRows_CustomRole(t *testing.T) {
+	rows := [][]any{
+		{"Flag", "internal/experimental/flag.go", "", 1, 0.1, ""},
+		{"Run", "cmd/app/main.go", "", 1, 0.2, ""},
+	}
+	roles, err := NewRoleFilter("source", map[string]RolePattern{
+		"experimental": {Paths: []string{"internal/experimental/**"}, ExcludeFromSource: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := postFilterRows(rows, "", roles, "flags", "", true)
+	if len(got) != 1 || AnyToString(got[0][0]) != "Run" {
+		t.Errorf("got %v, want only Run", got)
+	}
+}
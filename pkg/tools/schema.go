@@ -19,118 +19,45 @@
 
 package tools
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// GetSchemaArgs contains arguments for GetSchema.
+type GetSchemaArgs struct {
+	// Relation narrows the output to one relation, with example queries
+	// generated from its columns and joins. The cie_ prefix is optional.
+	Relation string
+}
 
 // GetSchema returns the CIE database schema documentation.
-func GetSchema(ctx context.Context) (*ToolResult, error) {
-	return NewResult(SchemaDocumentation), nil
+func GetSchema(ctx context.Context, args GetSchemaArgs) (*ToolResult, error) {
+	if args.Relation == "" {
+		return NewResult(SchemaDocumentation), nil
+	}
+	rel, doc, ok := lookupRelationDoc(args.Relation)
+	if !ok {
+		return NewInputError(fmt.Sprintf("Error: unknown relation %q. Relations: %s", args.Relation, strings.Join(relationNames(), ", "))), nil
+	}
+	return NewResult(renderRelationSchema(rel, doc)), nil
 }
 
-// SchemaDocumentation contains the CIE schema docs (Schema v3).
-const SchemaDocumentation = `# CIE Database Schema (v3)
+// SchemaDocumentation contains the CIE schema docs (Schema v3). The tables
+// are generated from storage.Relations and relationDocs.
+var SchemaDocumentation = schemaIntro + renderSchemaTables() + schemaReference
+
+const schemaIntro = `# CIE Database Schema (v3)
 
 Schema v3 uses vertical partitioning for performance: heavy columns (code_text, embedding) are in separate tables.
 
-## Core Tables
-
-### cie_file
-Stores indexed source files.
-| Field    | Type   | Description |
-|----------|--------|-------------|
-| id       | string | Unique file ID (hash) |
-| path     | string | File path relative to repo root |
-| hash     | string | Content hash |
-| language | string | Programming language (go, typescript, python, etc.) |
-| size     | int    | File size in bytes |
-
-### cie_function
-Stores function/method metadata (lightweight, ~500 bytes/row).
-| Field      | Type   | Description |
-|------------|--------|-------------|
-| id         | string | Unique function ID (hash) |
-| name       | string | Function name (includes receiver for methods, e.g., "Batcher.Batch") |
-| signature  | string | Full function signature |
-| file_path  | string | Path to containing file |
-| start_line | int    | Starting line number |
-| end_line   | int    | Ending line number |
-| start_col  | int    | Starting column |
-| end_col    | int    | Ending column |
-
-### cie_function_code
-Stores function source code (JOIN with cie_function when needed).
-| Field       | Type   | Description |
-|-------------|--------|-------------|
-| function_id | string | Function ID (foreign key) |
-| code_text   | string | Function source code (may be truncated) |
-
-### cie_function_embedding
-Stores function embeddings for semantic search (HNSW index here).
-| Field       | Type       | Description |
-|-------------|------------|-------------|
-| function_id | string     | Function ID (foreign key) |
-| embedding   | <F32; 1536> | Vector embedding (1536 dimensions) |
-
-### cie_type
-Stores type/struct/interface metadata.
-| Field      | Type   | Description |
-|------------|--------|-------------|
-| id         | string | Unique type ID (hash) |
-| name       | string | Type name |
-| kind       | string | Type kind (struct, interface, class, type_alias) |
-| file_path  | string | Path to containing file |
-| start_line | int    | Starting line number |
-| end_line   | int    | Ending line number |
-| start_col  | int    | Starting column |
-| end_col    | int    | Ending column |
-
-### cie_type_code
-Stores type source code.
-| Field    | Type   | Description |
-|----------|--------|-------------|
-| type_id  | string | Type ID (foreign key) |
-| code_text| string | Type source code |
-
-### cie_type_embedding
-Stores type embeddings for semantic search.
-| Field    | Type       | Description |
-|----------|------------|-------------|
-| type_id  | string     | Type ID (foreign key) |
-| embedding| <F32; 1536> | Vector embedding |
-
-## Edge Tables
-
-### cie_defines
-Links files to their functions.
-| Field       | Type   | Description |
-|-------------|--------|-------------|
-| file_id     | string | File ID |
-| function_id | string | Function ID |
-
-### cie_defines_type
-Links files to their types.
-| Field   | Type   | Description |
-|---------|--------|-------------|
-| file_id | string | File ID |
-| type_id | string | Type ID |
-
-### cie_calls
-Function call relationships.
-| Field     | Type   | Description |
-|-----------|--------|-------------|
-| caller_id | string | ID of calling function |
-| callee_id | string | ID of called function |
-
-### cie_import
-Import statements.
-| Field       | Type   | Description |
-|-------------|--------|-------------|
-| id          | string | Import ID |
-| file_path   | string | File containing import |
-| import_path | string | Imported package/module |
-| alias       | string | Import alias (if any) |
-| start_line  | int    | Line number |
-
-## CozoScript Operators
+Key columns are in **bold**; a key identifies a row. An arrow (→) marks a column that joins to a column of another relation. Call cie_schema with ` + "`relation`" + ` for one relation's ready-to-run example queries, and pass values to cie_raw_query through ` + "`params`" + ` ($name placeholders) instead of writing them into the script.
+
+`
+
+// schemaReference documents operators, example queries and the tools.
+const schemaReference = `## CozoScript Operators
 
 ### String Operations
 - ` + "`starts_with(str, prefix)`" + ` - Check if string starts with prefix
@@ -214,6 +141,7 @@ Import statements.
 | ` + "`cie_semantic_search`" + ` | Natural language search | ` + "`query`" + `, ` + "`min_similarity`" + ` |
 | ` + "`cie_find_function`" + ` | Find by function name | ` + "`name`" + `, ` + "`include_code`" + ` |
 | ` + "`cie_find_type`" + ` | Find structs/interfaces | ` + "`name`" + `, ` + "`kind`" + ` |
+| ` + "`cie_type_api`" + ` | All methods of a type | ` + "`type_name`" + `, ` + "`file_path`" + ` |
 
 ### Analysis Tools
 
@@ -221,6 +149,11 @@ Import statements.
 |------|----------|----------------|
 | ` + "`cie_analyze`" + ` | Architecture questions | ` + "`question`" + ` (natural language) |
 | ` + "`cie_list_endpoints`" + ` | HTTP API routes | ` + "`path_pattern`" + `, ` + "`method`" + ` |
+| ` + "`cie_templates`" + ` | Templates and the handlers rendering them | ` + "`template`" + `, ` + "`function`" + ` |
+| ` + "`cie_ci_jobs`" + ` | Which CI workflow runs something | ` + "`query`" + `, ` + "`job`" + ` |
+| ` + "`cie_get_notes`" + ` | Notes left on functions and files | ` + "`target`" + `, ` + "`query`" + ` |
+| ` + "`cie_collection_list`" + ` | Curated sets of functions and files | ` + "`collection`" + ` |
+| ` + "`cie_recall_facts`" + ` | Conventions and gotchas from earlier sessions | ` + "`query`" + `, ` + "`tag`" + ` |
 | ` + "`cie_find_callers`" + ` | Who calls this function? | ` + "`function_name`" + ` |
 | ` + "`cie_find_callees`" + ` | What does this call? | ` + "`function_name`" + ` |
 | ` + "`cie_trace_path`" + ` | Call path from A to B | ` + "`target`" + `, ` + "`source`" + ` |
@@ -241,6 +174,9 @@ Import statements.
 | ` + "`cie_directory_summary`" + ` | Module overview | ` + "`path`" + ` |
 | ` + "`cie_get_file_summary`" + ` | File contents summary | ` + "`file_path`" + ` |
 | ` + "`cie_index_status`" + ` | Check indexing health | ` + "`path_pattern`" + ` |
+| ` + "`cie_index_health`" + ` | Grade index quality before answering | - |
+| ` + "`cie_resolution_report`" + ` | Why call edges are missing | ` + "`path_pattern`" + `, ` + "`reason`" + ` |
+| ` + "`cie_external_api`" + ` | External packages used per package | ` + "`path_pattern`" + `, ` + "`package`" + ` |
 
 ### Tips
 
@@ -0,0 +1,733 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// relationDoc documents a stored relation for cie_schema. Column names,
+// types and keys come from storage.Relations, so the documentation cannot
+// drift from the schema; a test checks every relation and column is
+// described.
+type relationDoc struct {
+	Group   string
+	Summary string
+	Columns map[string]string // description by column
+	// Refs maps a column to the "relation.column" it joins to.
+	Refs map[string]string
+	// Label lists the columns that name a row, bound when an example joins
+	// another relation to this one.
+	Label []string
+	// Examples are hand-written queries shown after the generated ones.
+	Examples []schemaExample
+}
+
+// schemaExample is a titled query that runs as written, binding $params
+// through cie_raw_query's params where it has any.
+type schemaExample struct {
+	Title  string
+	Script string
+	Params string // JSON for cie_raw_query's params; "" for none
+}
+
+// schemaGroups is the order the relation groups are documented in.
+var schemaGroups = []string{
+	"Core Tables",
+	"Edge Tables",
+	"Indexed Artifacts",
+	"User Data (kept across re-indexing)",
+	"Metadata",
+}
+
+const fnID = "cie_function.id"
+
+// relationDocs documents every relation in storage.Relations.
+var relationDocs = map[string]relationDoc{
+	"cie_file": {
+		Group:   "Core Tables",
+		Summary: "Stores indexed source files.",
+		Columns: map[string]string{
+			"id":       "Unique file ID (hash)",
+			"path":     "File path relative to repo root",
+			"hash":     "Content hash",
+			"language": "Programming language (go, typescript, python, etc.)",
+			"size":     "File size in bytes",
+		},
+		Label: []string{"path", "language"},
+	},
+	"cie_file_content": {
+		Group:   "Core Tables",
+		Summary: "Full text of indexed files, when indexing.store_file_text is on.",
+		Columns: map[string]string{
+			"file_id": "File ID",
+			"content": "File text as indexed",
+		},
+		Refs: map[string]string{"file_id": "cie_file.id"},
+	},
+	"cie_function": {
+		Group:   "Core Tables",
+		Summary: "Stores function/method metadata (lightweight, ~500 bytes/row).",
+		Columns: map[string]string{
+			"id":         "Unique function ID (hash)",
+			"name":       `Function name (includes receiver for methods, e.g., "Batcher.Batch")`,
+			"signature":  "Full function signature",
+			"file_path":  "Path to containing file",
+			"start_line": "Starting line number",
+			"end_line":   "Ending line number",
+			"start_col":  "Starting column",
+			"end_col":    "Ending column",
+		},
+		Label: []string{"name", "file_path"},
+		Examples: []schemaExample{{
+			Title:  "Functions in a directory",
+			Script: `?[name, file_path, start_line] := *cie_function { name, file_path, start_line }, starts_with(file_path, $dir) :order file_path, start_line :limit 50`,
+			Params: `{"dir": "pkg/ingestion/"}`,
+		}},
+	},
+	"cie_function_code": {
+		Group:   "Core Tables",
+		Summary: "Stores function source code (JOIN with cie_function when needed).",
+		Columns: map[string]string{
+			"function_id": "Function ID",
+			"code_text":   "Function source code, up to the code text size limit",
+		},
+		Refs: map[string]string{"function_id": fnID},
+		Examples: []schemaExample{{
+			Title:  "Search in code text",
+			Script: `?[name, file_path] := *cie_function { id, name, file_path }, *cie_function_code { function_id: id, code_text }, regex_matches(code_text, $pattern) :limit 20`,
+			Params: `{"pattern": "(?i)http\\.Get"}`,
+		}},
+	},
+	"cie_function_code_chunk": {
+		Group:   "Core Tables",
+		Summary: "Stores the source past cie_function_code's size limit, for functions longer than it. The whole function is code_text followed by its chunks in chunk order.",
+		Columns: map[string]string{
+			"function_id": "Function ID",
+			"chunk":       "Position of the chunk, from 0",
+			"start_line":  "File line the chunk starts on",
+			"code_text":   "Source code of the chunk",
+		},
+		Refs: map[string]string{"function_id": fnID},
+	},
+	"cie_function_embedding": {
+		Group:   "Core Tables",
+		Summary: "Stores function embeddings for semantic search (HNSW index embedding_idx here).",
+		Columns: map[string]string{
+			"function_id": "Function ID",
+			"embedding":   "Vector embedding",
+		},
+		Refs: map[string]string{"function_id": fnID},
+		Examples: []schemaExample{{
+			Title:  "Functions nearest to an embedding (HNSW)",
+			Script: `?[name, file_path, distance] := ~cie_function_embedding:embedding_idx { function_id | query: q, k: 10, ef: 50, bind_distance: distance }, q = vec($vector), *cie_function { id: function_id, name, file_path } :order distance`,
+			Params: `{"vector": [0.12, -0.03, "...one float per embedding dimension"]}`,
+		}},
+	},
+	"cie_function_name_embedding": {
+		Group:   "Core Tables",
+		Summary: "Stores embeddings of function names and signatures, written when embedding.name_embeddings is on (HNSW index embedding_idx here).",
+		Columns: map[string]string{
+			"function_id": "Function ID",
+			"embedding":   "Vector embedding of the name and signature",
+		},
+		Refs: map[string]string{"function_id": fnID},
+	},
+	"cie_function_lang": {
+		Group:   "Core Tables",
+		Summary: "Stores each function's language and dialect (framework hint) for search filters.",
+		Columns: map[string]string{
+			"function_id": "Function ID",
+			"language":    "Parser language (go, python, typescript, javascript, protobuf)",
+			"dialect":     `Framework hint (e.g., "gin handler", "react component"); empty when none`,
+		},
+		Refs: map[string]string{"function_id": fnID},
+	},
+	"cie_defines": {
+		Group:   "Edge Tables",
+		Summary: "Links files to their functions.",
+		Columns: map[string]string{
+			"id":          "Edge ID",
+			"file_id":     "File ID",
+			"function_id": "Function ID",
+		},
+		Refs: map[string]string{"file_id": "cie_file.id", "function_id": fnID},
+	},
+	"cie_calls": {
+		Group:   "Edge Tables",
+		Summary: "Function call relationships.",
+		Columns: map[string]string{
+			"id":        "Edge ID",
+			"caller_id": "ID of calling function",
+			"callee_id": "ID of called function",
+		},
+		Refs: map[string]string{"caller_id": fnID, "callee_id": fnID},
+		Examples: []schemaExample{{
+			Title:  "Callers of a function",
+			Script: `?[caller_name, caller_file] := *cie_function { id: callee_id, name: callee }, callee = $name, *cie_calls { caller_id, callee_id }, *cie_function { id: caller_id, name: caller_name, file_path: caller_file } :limit 50`,
+			Params: `{"name": "Batcher.Batch"}`,
+		}},
+	},
+	"cie_import": {
+		Group:   "Core Tables",
+		Summary: "Import statements.",
+		Columns: map[string]string{
+			"id":          "Import ID",
+			"file_path":   "File containing import",
+			"import_path": "Imported package/module",
+			"alias":       "Import alias (if any)",
+			"start_line":  "Line number",
+		},
+	},
+	"cie_type": {
+		Group:   "Core Tables",
+		Summary: "Stores type/struct/interface metadata.",
+		Columns: map[string]string{
+			"id":         "Unique type ID (hash)",
+			"name":       "Type name",
+			"kind":       "Type kind (struct, interface, class, type_alias)",
+			"file_path":  "Path to containing file",
+			"start_line": "Starting line number",
+			"end_line":   "Ending line number",
+			"start_col":  "Starting column",
+			"end_col":    "Ending column",
+		},
+		Label: []string{"name", "file_path"},
+	},
+	"cie_type_code": {
+		Group:   "Core Tables",
+		Summary: "Stores type source code.",
+		Columns: map[string]string{
+			"type_id":   "Type ID",
+			"code_text": "Type source code",
+		},
+		Refs: map[string]string{"type_id": "cie_type.id"},
+	},
+	"cie_type_embedding": {
+		Group:   "Core Tables",
+		Summary: "Stores type embeddings for semantic search (HNSW index embedding_idx here).",
+		Columns: map[string]string{
+			"type_id":   "Type ID",
+			"embedding": "Vector embedding",
+		},
+		Refs: map[string]string{"type_id": "cie_type.id"},
+	},
+	"cie_defines_type": {
+		Group:   "Edge Tables",
+		Summary: "Links files to their types.",
+		Columns: map[string]string{
+			"id":      "Edge ID",
+			"file_id": "File ID",
+			"type_id": "Type ID",
+		},
+		Refs: map[string]string{"file_id": "cie_file.id", "type_id": "cie_type.id"},
+	},
+	"cie_field": {
+		Group:   "Core Tables",
+		Summary: "Struct fields, used to resolve calls through interface-typed fields.",
+		Columns: map[string]string{
+			"id":          "Field ID",
+			"struct_name": "Name of the struct declaring the field",
+			"field_name":  "Field name (the type name for embedded fields)",
+			"field_type":  "Field type as written",
+			"file_path":   "File containing the struct",
+			"line":        "Line of the field",
+		},
+		Refs: map[string]string{"struct_name": "cie_type.name"},
+	},
+	"cie_implements": {
+		Group:   "Edge Tables",
+		Summary: "Concrete types and the interfaces they implement, by name.",
+		Columns: map[string]string{
+			"id":             "Edge ID",
+			"type_name":      "Implementing type",
+			"interface_name": "Implemented interface",
+			"file_path":      "File containing the implementing type",
+		},
+		Refs: map[string]string{"type_name": "cie_type.name", "interface_name": "cie_type.name"},
+		Examples: []schemaExample{{
+			Title:  "Implementations of an interface",
+			Script: `?[type_name, file_path] := *cie_implements { type_name, interface_name, file_path }, interface_name = $iface`,
+			Params: `{"iface": "Querier"}`,
+		}},
+	},
+	"cie_contains": {
+		Group:   "Edge Tables",
+		Summary: "Links nested functions to their enclosing function, and methods to their class or receiver type.",
+		Columns: map[string]string{
+			"id":          "Edge ID",
+			"parent_id":   "ID of the enclosing function (cie_function.id) or type (cie_type.id), per parent_kind",
+			"parent_kind": `"function" or "type"`,
+			"child_id":    "ID of the nested function or method",
+			"file_path":   "File containing the child",
+		},
+		Refs: map[string]string{"child_id": fnID},
+	},
+	"cie_method_of": {
+		Group:   "Edge Tables",
+		Summary: "Links each method to the type it belongs to.",
+		Columns: map[string]string{
+			"id":        "Edge ID",
+			"method_id": "ID of the method",
+			"type_id":   `ID of the type ("" when defined in another, unchanged file)`,
+			"type_name": "Receiver or class name",
+			"file_path": "File containing the method",
+		},
+		Refs: map[string]string{"method_id": fnID, "type_id": "cie_type.id"},
+	},
+	"cie_unresolved_call": {
+		Group:   "Edge Tables",
+		Summary: "Go calls the resolver could not link to a function.",
+		Columns: map[string]string{
+			"id":          "Call ID",
+			"caller_id":   "ID of the calling function",
+			"callee_name": `Called name as written (e.g., "fmt.Println")`,
+			"file_path":   "File containing the call",
+			"line":        "Line of the call",
+			"reason":      `"external", "unexported", "unknown_receiver" or "not_found"`,
+		},
+		Refs: map[string]string{"caller_id": fnID},
+	},
+	"cie_proto_option": {
+		Group:   "Indexed Artifacts",
+		Summary: "Options declared in .proto files.",
+		Columns: map[string]string{
+			"id":        "Option ID",
+			"file_path": ".proto file",
+			"scope":     `"file", or the message, enum, service, RPC or field it applies to (e.g., "User.email")`,
+			"name":      `Option name (e.g., "go_package", "(google.api.http)")`,
+			"value":     "Option value as written",
+			"line":      "Line of the option",
+		},
+	},
+	"cie_generated_from": {
+		Group:   "Edge Tables",
+		Summary: "Links protoc-generated Go and TypeScript types to their .proto message or enum, and mockgen/mockery mocks to the interface they mock.",
+		Columns: map[string]string{
+			"id":            "Edge ID",
+			"type_id":       "ID of the generated type",
+			"proto_type_id": "ID of the .proto message or enum, or of the mocked interface",
+			"file_path":     "Generated file",
+		},
+		Refs: map[string]string{"type_id": "cie_type.id", "proto_type_id": "cie_type.id"},
+	},
+	"cie_generated_file": {
+		Group:   "Indexed Artifacts",
+		Summary: "Generated files, with the generator and source named in their header comment.",
+		Columns: map[string]string{
+			"id":        "Record ID",
+			"file_path": "Generated file",
+			"generator": `e.g., "protoc-gen-go", "MockGen", "stringer"; empty when the header names none`,
+			"source":    `Indexed path of the source file (e.g., "api/user.proto"), else as written`,
+		},
+	},
+	"cie_generated_func": {
+		Group:   "Edge Tables",
+		Summary: "Links functions in generated files to the definition they were generated from.",
+		Columns: map[string]string{
+			"id":          "Edge ID",
+			"function_id": "ID of the generated function",
+			"source_id":   "ID of the .proto RPC (a cie_function) or the source type (a cie_type), per source_kind",
+			"source_kind": `"function" or "type"`,
+			"file_path":   "Generated file",
+		},
+		Refs: map[string]string{"function_id": fnID},
+	},
+	"cie_template": {
+		Group:   "Indexed Artifacts",
+		Summary: "Template files.",
+		Columns: map[string]string{
+			"id":        "Template ID",
+			"file_path": "Template file",
+			"dialect":   `"go", "jinja" or "erb"`,
+		},
+		Label: []string{"file_path"},
+	},
+	"cie_template_ref": {
+		Group:   "Indexed Artifacts",
+		Summary: "Variables, blocks and templates a template references.",
+		Columns: map[string]string{
+			"id":          "Reference ID",
+			"template_id": "ID of the template",
+			"file_path":   "Template file",
+			"kind":        `"variable", "block", "define", "include" or "extends"`,
+			"name":        `e.g., "User.Name", "content", "partials/nav.html"`,
+			"line":        "First line referencing it",
+		},
+		Refs: map[string]string{"template_id": "cie_template.id"},
+	},
+	"cie_renders": {
+		Group:   "Edge Tables",
+		Summary: "Templates rendered by functions, named as written in the render call.",
+		Columns: map[string]string{
+			"id":            "Render ID",
+			"function_id":   "ID of the rendering function",
+			"file_path":     "File containing the function",
+			"template_name": "Name or path passed to the render call",
+			"line":          "Line of the call",
+		},
+		Refs: map[string]string{"function_id": fnID},
+	},
+	"cie_ci_job": {
+		Group:   "Indexed Artifacts",
+		Summary: "CI jobs from GitHub Actions workflows and GitLab CI files.",
+		Columns: map[string]string{
+			"id":         "Job ID",
+			"file_path":  "Workflow file",
+			"workflow":   "Workflow name (GitLab: file name)",
+			"name":       "Job key",
+			"title":      "Display name, if set",
+			"stage":      "GitLab stage",
+			"runs_on":    "Runner labels (GitLab: image)",
+			"needs":      "Comma-separated jobs it needs",
+			"triggers":   "Comma-separated GitHub events",
+			"start_line": "Line of the job key",
+		},
+		Label: []string{"workflow", "name"},
+	},
+	"cie_ci_step": {
+		Group:   "Indexed Artifacts",
+		Summary: "Steps of CI jobs; each GitLab script line is a step.",
+		Columns: map[string]string{
+			"id":        "Step ID",
+			"job_id":    "ID of the job",
+			"file_path": "Workflow file",
+			"idx":       "Position in the job",
+			"name":      `Step name (GitLab: "before_script", "script" or "after_script")`,
+			"kind":      `"run", "uses" or "script"`,
+			"command":   `Shell command, or the action for "uses"`,
+			"line":      "Line of the step",
+		},
+		Refs: map[string]string{"job_id": "cie_ci_job.id"},
+	},
+	"cie_ci_ref": {
+		Group:   "Indexed Artifacts",
+		Summary: "Scripts, make targets, actions and variables CI jobs use.",
+		Columns: map[string]string{
+			"id":        "Reference ID",
+			"job_id":    "ID of the job",
+			"file_path": "Workflow file",
+			"kind":      `"script", "make", "action", "env", "secret" or "extends"`,
+			"name":      `e.g., "scripts/test.sh", "integration-test", "DATABASE_URL"`,
+			"line":      "First line referencing it",
+		},
+		Refs: map[string]string{"job_id": "cie_ci_job.id"},
+	},
+	"cie_table_ref": {
+		Group:   "Indexed Artifacts",
+		Summary: "Database tables functions use, from SQL string literals and ORM calls.",
+		Columns: map[string]string{
+			"id":          "Reference ID",
+			"function_id": "ID of the function",
+			"file_path":   "File containing the function",
+			"table_name":  "Table as written in SQL, or the ORM model's default table name",
+			"model":       "ORM model (empty for SQL strings)",
+			"op":          `"select", "insert", "update", "delete", "ddl" or "use"`,
+			"source":      `"sql", "gorm", "sqlalchemy" or "prisma"`,
+			"line":        "First line referencing it",
+		},
+		Refs: map[string]string{"function_id": fnID},
+	},
+	"cie_rpc_link": {
+		Group:   "Edge Tables",
+		Summary: "gRPC server implementations and client calls of .proto RPCs, across languages.",
+		Columns: map[string]string{
+			"id":          "Link ID",
+			"rpc":         `RPC as named in cie_function for the .proto, e.g., "UserService.GetUser"`,
+			"function_id": "ID of the implementing or calling function",
+			"role":        `"server" or "client"`,
+			"file_path":   "File containing the function",
+			"line":        "Function start (server) or call line (client)",
+		},
+		Refs: map[string]string{"function_id": fnID},
+	},
+	"cie_entry_point": {
+		Group:   "Indexed Artifacts",
+		Summary: "Functions where execution starts, detected during indexing.",
+		Columns: map[string]string{
+			"id":          "Entry point ID",
+			"function_id": "ID of the function",
+			"file_path":   "File containing the function",
+			"kind":        `"main", "cli", "server", "lambda" or "cron"`,
+			"detail":      `How it was recognized, e.g. "cobra.Command", "ListenAndServe", "cron @hourly"`,
+		},
+		Refs: map[string]string{"function_id": fnID},
+	},
+	"cie_note": {
+		Group:   "User Data (kept across re-indexing)",
+		Summary: "Notes attached to functions and files with cie_add_note.",
+		Columns: map[string]string{
+			"id":        "Note ID",
+			"kind":      `"function" or "file"`,
+			"name":      "Function name (empty for file notes)",
+			"file_path": "File the note is attached to",
+			"text":      "Note text",
+			"author":    `Who wrote it, e.g. "alice" or "agent"`,
+			"created":   "RFC 3339 timestamp",
+		},
+	},
+	"cie_collection": {
+		Group:   "User Data (kept across re-indexing)",
+		Summary: "Members of named collections (cie_collection_add).",
+		Columns: map[string]string{
+			"id":         "Member ID",
+			"collection": `Collection name, e.g. "payment-critical-path"`,
+			"kind":       `"function" or "file"`,
+			"name":       "Function name (empty for files)",
+			"file_path":  "File of the member",
+			"added":      "RFC 3339 timestamp",
+		},
+	},
+	"cie_fact": {
+		Group:   "User Data (kept across re-indexing)",
+		Summary: "Project knowledge stored with cie_store_fact.",
+		Columns: map[string]string{
+			"id":      "Fact ID",
+			"text":    "The fact",
+			"tags":    "Comma-separated tags",
+			"author":  "Who stored it",
+			"created": "RFC 3339 timestamp",
+		},
+		Label: []string{"text"},
+	},
+	"cie_fact_embedding": {
+		Group:   "User Data (kept across re-indexing)",
+		Summary: "Embeddings of stored facts; compare with cos_dist.",
+		Columns: map[string]string{
+			"fact_id":   "Fact ID",
+			"embedding": "Embedding of the text",
+		},
+		Refs: map[string]string{"fact_id": "cie_fact.id"},
+	},
+	"cie_project_meta": {
+		Group:   "Metadata",
+		Summary: "Project metadata such as the last indexed commit (last_indexed_sha) and the index version.",
+		Columns: map[string]string{
+			"key":   "Metadata key",
+			"value": "Metadata value",
+		},
+	},
+}
+
+// lookupRelationDoc returns the schema and documentation of a relation,
+// accepting the name without its cie_ prefix.
+func lookupRelationDoc(name string) (storage.Relation, relationDoc, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !strings.HasPrefix(name, "cie_") {
+		name = "cie_" + name
+	}
+	rel, ok := storage.LookupRelation(name)
+	if !ok {
+		return storage.Relation{}, relationDoc{}, false
+	}
+	return rel, relationDocs[name], true
+}
+
+// columnTypeName is how a column type reads in the schema tables.
+func columnTypeName(t storage.ColumnType) string {
+	switch t {
+	case storage.ColumnInt:
+		return "int"
+	case storage.ColumnVector:
+		return "vector"
+	default:
+		return "string"
+	}
+}
+
+// writeRelationDoc renders a relation's description, columns and joins.
+func writeRelationDoc(sb *strings.Builder, rel storage.Relation, doc relationDoc) {
+	fmt.Fprintf(sb, "### %s\n", rel.Name)
+	if doc.Summary != "" {
+		fmt.Fprintf(sb, "%s\n", doc.Summary)
+	}
+	sb.WriteString("| Field | Type | Description |\n|-------|------|-------------|\n")
+	for _, c := range rel.Keys {
+		fmt.Fprintf(sb, "| **%s** | %s | Key. %s |\n", c.Name, columnTypeName(c.Type), columnDescription(doc, c.Name))
+	}
+	for _, c := range rel.Values {
+		fmt.Fprintf(sb, "| %s | %s | %s |\n", c.Name, columnTypeName(c.Type), columnDescription(doc, c.Name))
+	}
+	sb.WriteString("\n")
+	joins, refs := relationJoins(rel, doc), referencedBy(rel.Name)
+	if len(joins) > 0 {
+		fmt.Fprintf(sb, "- Joins: %s\n", strings.Join(joins, ", "))
+	}
+	if len(refs) > 0 {
+		fmt.Fprintf(sb, "- Referenced by: %s\n", strings.Join(refs, ", "))
+	}
+	if len(joins)+len(refs) > 0 {
+		sb.WriteString("\n")
+	}
+}
+
+// columnDescription returns a column's description, with its join target.
+func columnDescription(doc relationDoc, column string) string {
+	desc := doc.Columns[column]
+	if ref := doc.Refs[column]; ref != "" {
+		desc = strings.TrimSpace(desc + " → " + ref)
+	}
+	return desc
+}
+
+// relationJoins lists rel's join columns as "column → relation.column", in
+// schema order.
+func relationJoins(rel storage.Relation, doc relationDoc) []string {
+	var joins []string
+	for _, col := range rel.Columns() {
+		if ref := doc.Refs[col]; ref != "" {
+			joins = append(joins, fmt.Sprintf("`%s` → `%s`", col, ref))
+		}
+	}
+	return joins
+}
+
+// referencedBy lists the "relation.column"s joining to a column of name.
+func referencedBy(name string) []string {
+	var refs []string
+	for _, rel := range storage.Relations {
+		doc := relationDocs[rel.Name]
+		for _, col := range rel.Columns() {
+			if target, _, _ := strings.Cut(doc.Refs[col], "."); target == name {
+				refs = append(refs, "`"+rel.Name+"."+col+"`")
+			}
+		}
+	}
+	return refs
+}
+
+// relationExamples returns the queries for one relation: its rows, each of
+// its joins with the label columns of the joined relation, then the
+// hand-written examples.
+func relationExamples(rel storage.Relation, doc relationDoc) []schemaExample {
+	var cols []string
+	for _, col := range rel.Columns() {
+		if !isVectorColumn(rel, col) {
+			cols = append(cols, col)
+		}
+	}
+	pattern := fmt.Sprintf("*%s { %s }", rel.Name, strings.Join(cols, ", "))
+	examples := []schemaExample{{
+		Title:  "Rows of " + rel.Name,
+		Script: fmt.Sprintf("?[%s] := %s :limit 10", strings.Join(cols, ", "), pattern),
+	}}
+
+	for _, col := range rel.Columns() {
+		ref := doc.Refs[col]
+		if ref == "" {
+			continue
+		}
+		target, targetCol, _ := strings.Cut(ref, ".")
+		var head, binds []string
+		for _, label := range relationDocs[target].Label {
+			if label == targetCol {
+				continue
+			}
+			v := joinVar(col, label, cols)
+			head = append(head, v)
+			binds = append(binds, label+": "+v)
+		}
+		if len(head) == 0 {
+			continue
+		}
+		examples = append(examples, schemaExample{
+			Title: fmt.Sprintf("%s joined to %s on %s", rel.Name, target, col),
+			Script: fmt.Sprintf("?[%s] := %s, *%s { %s: %s, %s } :limit 10",
+				strings.Join(append(append([]string{}, cols...), head...), ", "), pattern, target, targetCol, col, strings.Join(binds, ", ")),
+		})
+	}
+	return append(examples, doc.Examples...)
+}
+
+// joinVar names the variable binding a label column of the relation col
+// joins to: "caller_id" and "name" give "caller_name". Names already bound
+// by the relation's own columns get a "ref_" prefix so they do not turn
+// into join conditions.
+func joinVar(col, label string, taken []string) string {
+	v := strings.TrimSuffix(col, "_id") + "_" + label
+	if strings.HasSuffix(col, "_name") {
+		v = strings.TrimSuffix(col, "_name") + "_" + label
+	}
+	for _, t := range taken {
+		if t == v {
+			return "ref_" + v
+		}
+	}
+	return v
+}
+
+// isVectorColumn reports whether col of rel stores an embedding.
+func isVectorColumn(rel storage.Relation, col string) bool {
+	for _, c := range rel.Values {
+		if c.Name == col {
+			return c.Type == storage.ColumnVector
+		}
+	}
+	return false
+}
+
+// writeSchemaExample renders an example as a query block, with its params.
+func writeSchemaExample(sb *strings.Builder, ex schemaExample) {
+	fmt.Fprintf(sb, "#### %s\n```\n%s\n```\n", ex.Title, ex.Script)
+	if ex.Params != "" {
+		fmt.Fprintf(sb, "params: `%s`\n", ex.Params)
+	}
+	sb.WriteString("\n")
+}
+
+// renderSchemaTables documents every relation, grouped.
+func renderSchemaTables() string {
+	var sb strings.Builder
+	for _, group := range schemaGroups {
+		fmt.Fprintf(&sb, "## %s\n\n", group)
+		for _, rel := range storage.Relations {
+			if doc := relationDocs[rel.Name]; doc.Group == group {
+				writeRelationDoc(&sb, rel, doc)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// renderRelationSchema documents one relation with its example queries.
+func renderRelationSchema(rel storage.Relation, doc relationDoc) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Relation %s (%s)\n\n", rel.Name, doc.Group)
+	writeRelationDoc(&sb, rel, doc)
+	sb.WriteString("## Example Queries\n\n")
+	for _, ex := range relationExamples(rel, doc) {
+		writeSchemaExample(&sb, ex)
+	}
+	sb.WriteString("Run them with cie_raw_query, passing `params` for the $placeholders.\n")
+	return sb.String()
+}
+
+// relationNames lists the documented relations, sorted.
+func relationNames() []string {
+	names := make([]string, 0, len(storage.Relations))
+	for _, rel := range storage.Relations {
+		names = append(names, rel.Name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -21,7 +21,10 @@ package tools
 
 import (
 	"context"
+	"strings"
 	"testing"
+
+	"github.com/kraklabs/cie/pkg/storage"
 )
 
 func TestGetSchema(t *testing.T) {
@@ -34,7 +37,7 @@ func TestGetSchema(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
-			result, err := GetSchema(ctx)
+			result, err := GetSchema(ctx, GetSchemaArgs{})
 
 			assertNoError(t, err)
 			if result == nil {
@@ -55,7 +58,7 @@ func TestGetSchema_WithCancelledContext(t *testing.T) {
 	cancel() // Cancel immediately
 
 	// Schema should still work since it returns a constant
-	result, err := GetSchema(ctx)
+	result, err := GetSchema(ctx, GetSchemaArgs{})
 	assertNoError(t, err)
 	if result == nil {
 		t.Fatal("expected result even with canceled context")
@@ -64,7 +67,7 @@ func TestGetSchema_WithCancelledContext(t *testing.T) {
 
 func TestGetSchema_OutputContainsExpectedSections(t *testing.T) {
 	ctx := context.Background()
-	result, err := GetSchema(ctx)
+	result, err := GetSchema(ctx, GetSchemaArgs{})
 	assertNoError(t, err)
 
 	// Verify key sections are present
@@ -90,10 +93,91 @@ func TestGetSchema_OutputContainsExpectedSections(t *testing.T) {
 
 func TestGetSchema_ContainsSchemav3Description(t *testing.T) {
 	ctx := context.Background()
-	result, err := GetSchema(ctx)
+	result, err := GetSchema(ctx, GetSchemaArgs{})
 	assertNoError(t, err)
 
 	// Verify schema version is documented
 	assertContains(t, result.Text, "Schema v3")
 	assertContains(t, result.Text, "vertical partitioning")
 }
+
+func TestRelationDocs_CoverSchema(t *testing.T) {
+	groups := make(map[string]bool)
+	for _, g := range schemaGroups {
+		groups[g] = true
+	}
+	stored := make(map[string]bool)
+	for _, rel := range storage.Relations {
+		stored[rel.Name] = true
+		doc, ok := relationDocs[rel.Name]
+		if !ok {
+			t.Errorf("%s is not documented in relationDocs", rel.Name)
+			continue
+		}
+		if !groups[doc.Group] || doc.Summary == "" {
+			t.Errorf("%s needs a summary and one of schemaGroups, got group %q", rel.Name, doc.Group)
+		}
+		cols := make(map[string]bool)
+		for _, col := range rel.Columns() {
+			cols[col] = true
+			if doc.Columns[col] == "" {
+				t.Errorf("%s.%s has no description", rel.Name, col)
+			}
+		}
+		for col := range doc.Columns {
+			if !cols[col] {
+				t.Errorf("%s documents %s, which is not a column", rel.Name, col)
+			}
+		}
+		for col, ref := range doc.Refs {
+			target, targetCol, _ := strings.Cut(ref, ".")
+			targetRel, ok := storage.LookupRelation(target)
+			if !cols[col] || !ok || !containsString(targetRel.Columns(), targetCol) {
+				t.Errorf("%s.%s joins to %s, which does not exist", rel.Name, col, ref)
+			}
+		}
+		for _, label := range doc.Label {
+			if !cols[label] {
+				t.Errorf("%s labels rows with %s, which is not a column", rel.Name, label)
+			}
+		}
+	}
+	for name := range relationDocs {
+		if !stored[name] {
+			t.Errorf("relationDocs documents %s, which is not in storage.Relations", name)
+		}
+	}
+}
+
+func TestGetSchema_GeneratedTables(t *testing.T) {
+	result, err := GetSchema(context.Background(), GetSchemaArgs{})
+	assertNoError(t, err)
+	for _, rel := range storage.Relations {
+		assertContains(t, result.Text, "### "+rel.Name+"\n")
+	}
+	assertContains(t, result.Text, "| **function_id** | string | Key. Function ID → cie_function.id |")
+	assertContains(t, result.Text, "- Joins: `caller_id` → `cie_function.id`, `callee_id` → `cie_function.id`")
+	assertContains(t, result.Text, "`cie_calls.caller_id`")
+}
+
+func TestGetSchema_Relation(t *testing.T) {
+	ctx := context.Background()
+	result, err := GetSchema(ctx, GetSchemaArgs{Relation: "calls"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "# Relation cie_calls (Edge Tables)")
+	assertContains(t, result.Text, "?[id, caller_id, callee_id] := *cie_calls { id, caller_id, callee_id } :limit 10")
+	assertContains(t, result.Text, "?[id, caller_id, callee_id, caller_name, caller_file_path] := *cie_calls { id, caller_id, callee_id }, *cie_function { id: caller_id, name: caller_name, file_path: caller_file_path } :limit 10")
+	assertContains(t, result.Text, "callee = $name")
+	assertContains(t, result.Text, `params: `+"`"+`{"name": "Batcher.Batch"}`+"`")
+
+	result, err = GetSchema(ctx, GetSchemaArgs{Relation: "cie_function_embedding"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "?[function_id] := *cie_function_embedding { function_id } :limit 10")
+
+	result, err = GetSchema(ctx, GetSchemaArgs{Relation: "cie_nope"})
+	assertNoError(t, err)
+	if !result.IsError {
+		t.Fatal("an unknown relation should be an input error")
+	}
+	assertContains(t, result.Text, "cie_calls")
+}
@@ -25,24 +25,33 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/kraklabs/cie/pkg/errcode"
 	"github.com/kraklabs/cie/pkg/sigparse"
 )
 
 // SearchTextArgs holds arguments for text search.
 type SearchTextArgs struct {
 	Pattern        string
-	SearchIn       string // "code", "signature", "name", "all"
+	SearchIn       string // "code", "signature", "name", "all", "files"
 	FilePattern    string
 	ExcludePattern string // Pattern to exclude (uses negate())
 	Literal        bool   // If true, treat pattern as literal string (escape regex chars)
 	Limit          int
+	GroupBy        string // "", "file", "package" or "function"; Limit then caps groups
+	Language       string // Only functions (or files) of this language, e.g. "go", "ts"
+	Dialect        string // Only functions with this framework hint, e.g. "gin handler"
+
+	// Live, when set, adds matching lines from files not indexed yet to
+	// ungrouped code searches. Indexed matches in files it serves are
+	// dropped in favor of the on-disk version.
+	Live LiveSource
 }
 
 // SearchText searches for text patterns in function code, signatures, or names.
 // Schema v3: code_text is in separate cie_function_code table
 func SearchText(ctx context.Context, client Querier, args SearchTextArgs) (*ToolResult, error) {
 	if args.Pattern == "" {
-		return NewError("Error: 'pattern' is required"), nil
+		return NewInputError("Error: 'pattern' is required"), nil
 	}
 
 	if args.SearchIn == "" {
@@ -51,12 +60,15 @@ func SearchText(ctx context.Context, client Querier, args SearchTextArgs) (*Tool
 	if args.Limit <= 0 {
 		args.Limit = 20
 	}
+	if !validGroupBy(args.GroupBy) {
+		return NewInputError(fmt.Sprintf("Error: invalid group_by %q (use file, package or function)", args.GroupBy)), nil
+	}
 
 	// Validate regex if not in literal mode
 	// Validate regex if not in literal mode
 	if !args.Literal {
 		if _, err := regexp.Compile(args.Pattern); err != nil {
-			return NewError(fmt.Sprintf(
+			return NewInputError(fmt.Sprintf(
 				"**Invalid Regex Pattern:**\n```\n%v\n```\n\n"+
 					"The pattern `%s` is not valid regex: %v\n\n"+
 					"### Solutions:\n"+
@@ -79,17 +91,40 @@ func SearchText(ctx context.Context, client Querier, args SearchTextArgs) (*Tool
 		pattern = EscapeRegex(pattern)
 	}
 
+	if args.SearchIn == "files" {
+		if args.Dialect != "" {
+			return NewInputError("Error: 'dialect' applies to functions and cannot be used with search_in=\"files\""), nil
+		}
+		linePattern := args.Pattern
+		if args.Literal {
+			linePattern = regexp.QuoteMeta(linePattern)
+		}
+		return searchFileContent(ctx, client, fileSearch{
+			Label:          args.Pattern,
+			CozoPattern:    pattern,
+			LineRegexp:     regexp.MustCompile(linePattern),
+			FilePattern:    args.FilePattern,
+			ExcludePattern: args.ExcludePattern,
+			Language:       args.Language,
+			Limit:          args.Limit,
+		})
+	}
+
 	// Determine if we need to join with cie_function_code (only for code/all search)
 	needsCodeJoin := args.SearchIn == "code" || args.SearchIn == "all"
+	// Compressed code_text is matched client-side, so the code condition
+	// stays out of the query.
+	compressed := needsCodeJoin && isCodeCompressed(ctx, client)
 
 	// Build query based on search target
 	var conditions []string
-	switch args.SearchIn {
-	case "code":
+	switch {
+	case compressed:
+	case args.SearchIn == "code":
 		conditions = append(conditions, fmt.Sprintf("regex_matches(code_text, %q)", pattern))
-	case "signature":
+	case args.SearchIn == "signature":
 		conditions = append(conditions, fmt.Sprintf("regex_matches(signature, %q)", pattern))
-	case "name":
+	case args.SearchIn == "name":
 		conditions = append(conditions, fmt.Sprintf("regex_matches(name, %q)", pattern))
 	default: // "all"
 		conditions = append(conditions, fmt.Sprintf("(regex_matches(name, %q) or regex_matches(signature, %q) or regex_matches(code_text, %q))", pattern, pattern, pattern))
@@ -103,6 +138,28 @@ func SearchText(ctx context.Context, client Querier, args SearchTextArgs) (*Tool
 	if args.ExcludePattern != "" {
 		conditions = append(conditions, fmt.Sprintf("negate(regex_matches(file_path, %q))", args.ExcludePattern))
 	}
+	if lang := languageCondition("id", args.Language, args.Dialect); lang != "" {
+		conditions = append(conditions, lang)
+	}
+	// Unindexed files carry no language and no name or signature index.
+	live := args.Live
+	if !needsCodeJoin || args.GroupBy != "" || args.Language != "" || args.Dialect != "" {
+		live = nil
+	}
+	if live != nil {
+		if shadow := shadowCondition(livePaths(ctx, live)); shadow != "" {
+			conditions = append(conditions, shadow)
+		}
+	}
+
+	limit := args.Limit
+	if args.GroupBy != "" {
+		limit = maxGroupedRows
+	}
+
+	if compressed {
+		return searchDecodedText(ctx, client, args, conditions, limit, live)
+	}
 
 	// Schema v3: Join with cie_function_code only when searching in code
 	var script string
@@ -110,13 +167,13 @@ func SearchText(ctx context.Context, client Querier, args SearchTextArgs) (*Tool
 		script = fmt.Sprintf(
 			"?[file_path, name, signature, start_line, end_line] := *cie_function { id, file_path, name, signature, start_line, end_line }, *cie_function_code { function_id: id, code_text }, %s :limit %d",
 			strings.Join(conditions, ", "),
-			args.Limit,
+			limit,
 		)
 	} else {
 		script = fmt.Sprintf(
-			"?[file_path, name, signature, start_line, end_line] := *cie_function { file_path, name, signature, start_line, end_line }, %s :limit %d",
+			"?[file_path, name, signature, start_line, end_line] := *cie_function { id, file_path, name, signature, start_line, end_line }, %s :limit %d",
 			strings.Join(conditions, ", "),
-			args.Limit,
+			limit,
 		)
 	}
 
@@ -124,8 +181,83 @@ func SearchText(ctx context.Context, client Querier, args SearchTextArgs) (*Tool
 	if err != nil {
 		return NewError(fmt.Sprintf("Query error: %v\n\nGenerated query:\n%s", err, script)), nil
 	}
+	return formatSearchText(ctx, result, script, pattern, args, live), nil
+}
 
-	return NewResult(FormatQueryResult(result, script)), nil
+// searchDecodedText is SearchText for code and all searches on an index with
+// compressed code_text: functions passing conditions are decompressed and
+// matched client-side until limit of them match.
+func searchDecodedText(ctx context.Context, client Querier, args SearchTextArgs, conditions []string, limit int, live LiveSource) (*ToolResult, error) {
+	linePattern := args.Pattern
+	if args.Literal {
+		linePattern = regexp.QuoteMeta(linePattern)
+	}
+	re := regexp.MustCompile(linePattern)
+	script := decodedCodeScript(conditions, "`"+args.Pattern+"`")
+	rows, err := collectDecodedCode(ctx, client, conditions, limit, func(row []any) bool {
+		if re.MatchString(AnyToString(row[4])) {
+			return true
+		}
+		return args.SearchIn == "all" && (re.MatchString(AnyToString(row[1])) || re.MatchString(AnyToString(row[5])))
+	})
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v\n\nGenerated query:\n%s", err, script)), nil
+	}
+	result := &QueryResult{Headers: []string{"file_path", "name", "signature", "start_line", "end_line"}}
+	for _, row := range rows {
+		result.Rows = append(result.Rows, []any{row[0], row[1], row[5], row[2], row[3]})
+	}
+	pattern := args.Pattern
+	if args.Literal {
+		pattern = EscapeRegex(pattern)
+	}
+	return formatSearchText(ctx, result, script, pattern, args, live), nil
+}
+
+// formatSearchText formats the functions SearchText found, grouped when
+// args.GroupBy is set, with live matches appended when live is non-nil.
+func formatSearchText(ctx context.Context, result *QueryResult, script, pattern string, args SearchTextArgs, live LiveSource) *ToolResult {
+	if args.GroupBy != "" && len(result.Rows) > 0 {
+		hits := make([]searchHit, 0, len(result.Rows))
+		for _, row := range result.Rows {
+			hits = append(hits, searchHit{
+				File:     AnyToString(row[0]),
+				Function: AnyToString(row[1]),
+				Line:     int(toFloat64(row[3])),
+				Preview:  AnyToString(row[2]),
+			})
+		}
+		header := fmt.Sprintf("Functions matching `%s`: ", args.Pattern)
+		return NewResult(header + formatGroupedHits(hits, args.GroupBy, args.Limit))
+	}
+
+	output := FormatQueryResult(result, script)
+	if live != nil {
+		output += liveSearchTextSection(ctx, live, pattern, args)
+	}
+	return NewResult(output)
+}
+
+// liveSearchTextSection matches pattern against the lines of the live files
+// that pass the file filters of args.
+func liveSearchTextSection(ctx context.Context, live LiveSource, pattern string, args SearchTextArgs) string {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return ""
+	}
+	var include, exclude *regexp.Regexp
+	if args.FilePattern != "" {
+		include, _ = regexp.Compile(args.FilePattern)
+	}
+	if args.ExcludePattern != "" {
+		exclude, _ = regexp.Compile(args.ExcludePattern)
+	}
+	return liveLineSection(ctx, live, args.Limit,
+		func(path string) bool {
+			return (include == nil || include.MatchString(path)) && (exclude == nil || !exclude.MatchString(path))
+		},
+		re.MatchString,
+	)
 }
 
 // FindFunctionArgs holds arguments for finding functions.
@@ -133,13 +265,24 @@ type FindFunctionArgs struct {
 	Name        string
 	ExactMatch  bool
 	IncludeCode bool
+	Fuzzy       bool   // rank all names by fuzzy/camelCase similarity instead of matching
+	Language    string // only functions of this language, e.g. "go", "ts"
+	Dialect     string // only functions with this framework hint, e.g. "react component"
+
+	// Live, when set, adds functions from files not indexed yet, and in
+	// overlay mode replaces indexed matches in edited files with their
+	// on-disk version.
+	Live LiveSource
 }
 
 // FindFunction finds functions by name.
 // Schema v3: code_text is in separate cie_function_code table
 func FindFunction(ctx context.Context, client Querier, args FindFunctionArgs) (*ToolResult, error) {
 	if args.Name == "" {
-		return NewError("Error: 'name' is required"), nil
+		return NewInputError("Error: 'name' is required"), nil
+	}
+	if args.Fuzzy {
+		return findFunctionFuzzy(ctx, client, args.Name)
 	}
 
 	var condition string
@@ -150,6 +293,13 @@ func FindFunction(ctx context.Context, client Querier, args FindFunctionArgs) (*
 		namePattern := fmt.Sprintf("(?i)^%s$", EscapeRegex(args.Name))
 		methodPattern := fmt.Sprintf("(?i)[.]%s$", EscapeRegex(args.Name))
 		condition = fmt.Sprintf("(regex_matches(name, %q) or regex_matches(name, %q))", namePattern, methodPattern)
+		// Package-qualified forms: "pkg.Func", "pkg.Type.Method", "path/to/pkg.Func"
+		if qualified, ok := qualifiedCondition(args.Name); ok {
+			condition = fmt.Sprintf("(%s or %s)", condition, qualified)
+		}
+	}
+	if lang := languageCondition("id", args.Language, args.Dialect); lang != "" {
+		condition += ", " + lang
 	}
 
 	// Schema v3: Join with cie_function_code only when include_code is true
@@ -157,15 +307,45 @@ func FindFunction(ctx context.Context, client Querier, args FindFunctionArgs) (*
 	if args.IncludeCode {
 		script = fmt.Sprintf("?[file_path, name, signature, start_line, end_line, code_text] := *cie_function { id, file_path, name, signature, start_line, end_line }, *cie_function_code { function_id: id, code_text }, %s", condition)
 	} else {
-		script = fmt.Sprintf("?[file_path, name, signature, start_line, end_line] := *cie_function { file_path, name, signature, start_line, end_line }, %s", condition)
+		script = fmt.Sprintf("?[file_path, name, signature, start_line, end_line] := *cie_function { id, file_path, name, signature, start_line, end_line }, %s", condition)
 	}
 
 	result, err := client.Query(ctx, script)
 	if err != nil {
 		return NewError(fmt.Sprintf("Query error: %v\n\nGenerated query:\n%s", err, script)), nil
 	}
+	if args.IncludeCode {
+		for _, row := range result.Rows {
+			if len(row) > 5 {
+				row[5] = decodeCodeText(row[5])
+			}
+		}
+	}
+
+	// Unindexed files carry no language, so a language filter skips them.
+	liveSection, liveHits := "", 0
+	if args.Live != nil && args.Language == "" && args.Dialect == "" {
+		// The query has no :limit, so dropping rows afterwards is safe.
+		result.Rows = dropShadowed(result.Rows, livePaths(ctx, args.Live))
+		liveSection, liveHits = liveFunctionSection(ctx, args.Live, args.Name, args.ExactMatch)
+	}
+	if len(result.Rows) == 0 && liveHits > 0 {
+		return NewResult(strings.TrimPrefix(liveSection, "\n\n")), nil
+	}
 
 	if len(result.Rows) == 0 {
+		var sb strings.Builder
+		sb.WriteString(FormatQueryResult(result, script))
+
+		// Close names: typos, camelCase words ("user handler"), abbreviations ("hndlusr")
+		if !args.ExactMatch {
+			matches, locations, err := fuzzyLookup(ctx, client, "cie_function", args.Name, 10)
+			if err == nil && len(matches) > 0 {
+				sb.WriteString("\n\n**Similar function names:**\n")
+				sb.WriteString(formatFuzzyMatches(matches, locations))
+			}
+		}
+
 		// Check if the name matches a type (struct, interface, etc.)
 		typeScript := fmt.Sprintf(
 			`?[name, kind] := *cie_type { name, kind }, regex_matches(name, "(?i)%s") :limit 3`,
@@ -173,17 +353,37 @@ func FindFunction(ctx context.Context, client Querier, args FindFunctionArgs) (*
 		)
 		typeResult, typeErr := client.Query(ctx, typeScript)
 		if typeErr == nil && len(typeResult.Rows) > 0 {
-			var sb strings.Builder
-			sb.WriteString(FormatQueryResult(result, script))
 			sb.WriteString("\n\n**Did you mean a type?** Try `cie_find_type` instead:\n")
 			for _, row := range typeResult.Rows {
 				fmt.Fprintf(&sb, "- `%s` (%s)\n", AnyToString(row[0]), AnyToString(row[1]))
 			}
-			return NewResult(sb.String()), nil
 		}
+		return NewResult(sb.String() + liveSection), nil
 	}
 
-	return NewResult(FormatQueryResult(result, script)), nil
+	output := FormatQueryResult(result, script)
+	if matches := distinctFunctionMatches(result.Rows, 1, 0, 3, 2); len(matches) > 1 && packageCount(matches) > 1 {
+		output += fmt.Sprintf("\n\n**%d matches in %d packages.** Pass a qualified name to select one:\n%s",
+			len(matches), packageCount(matches), formatDisambiguation(matches))
+	}
+	refs := make([][2]string, 0, len(result.Rows))
+	for _, r := range result.Rows {
+		refs = append(refs, [2]string{AnyToString(r[1]), AnyToString(r[0])})
+	}
+	output = appendGenerated(ctx, client, output, refs)
+	return NewResult(appendNotes(ctx, client, output, refs) + liveSection), nil
+}
+
+// findFunctionFuzzy returns function names ranked by similarity to name.
+func findFunctionFuzzy(ctx context.Context, client Querier, name string) (*ToolResult, error) {
+	matches, locations, err := fuzzyLookup(ctx, client, "cie_function", name, 20)
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v", err)), nil
+	}
+	if len(matches) == 0 {
+		return NewResult(fmt.Sprintf("No function names resemble '%s'. Try `cie_semantic_search` to search by meaning.", name)), nil
+	}
+	return NewResult(fmt.Sprintf("### Functions fuzzy-matching '%s'\n\n%s", name, formatFuzzyMatches(matches, locations))), nil
 }
 
 // FindCallersArgs holds arguments for finding callers.
@@ -196,7 +396,7 @@ type FindCallersArgs struct {
 // Includes both direct callers and callers through interface dispatch.
 func FindCallers(ctx context.Context, client Querier, args FindCallersArgs) (*ToolResult, error) {
 	if args.FunctionName == "" {
-		return NewError("Error: 'function_name' is required"), nil
+		return NewInputError("Error: 'function_name' is required"), nil
 	}
 
 	condition := fmt.Sprintf("(callee_name = %q or ends_with(callee_name, %q))", args.FunctionName, "."+args.FunctionName)
@@ -250,7 +450,7 @@ type FindCalleesArgs struct {
 // Includes both direct call edges and interface dispatch results.
 func FindCallees(ctx context.Context, client Querier, args FindCalleesArgs) (*ToolResult, error) {
 	if args.FunctionName == "" {
-		return NewError("Error: 'function_name' is required"), nil
+		return NewInputError("Error: 'function_name' is required"), nil
 	}
 
 	condition := fmt.Sprintf("(caller_name = %q or ends_with(caller_name, %q))", args.FunctionName, "."+args.FunctionName)
@@ -376,6 +576,8 @@ func findCalleesViaParams(ctx context.Context, client Querier, funcName string)
 type ListFilesArgs struct {
 	PathPattern string
 	Language    string
+	Role        string                 // "any" (default), "source", "test", "generated" or a custom role
+	CustomRoles map[string]RolePattern // Custom roles from project.yaml
 	Limit       int
 }
 
@@ -384,25 +586,52 @@ func ListFiles(ctx context.Context, client Querier, args ListFilesArgs) (*ToolRe
 	if args.Limit <= 0 {
 		args.Limit = 50
 	}
+	if args.Role == "" {
+		args.Role = "any"
+	}
+	roles, err := NewRoleFilter(args.Role, args.CustomRoles)
+	if err != nil {
+		return NewInputError(fmt.Sprintf("Error: %v", err)), nil
+	}
+	if roles.IsCustom() && roles.CustomFilePattern() == "" {
+		return NewInputError(fmt.Sprintf("Error: role %q has no paths or file_pattern, so it cannot select files", args.Role)), nil
+	}
 
 	var conditions []string
 	if args.PathPattern != "" {
 		conditions = append(conditions, fmt.Sprintf("regex_matches(path, %q)", args.PathPattern))
 	}
 	if args.Language != "" {
-		conditions = append(conditions, fmt.Sprintf("language = %q", args.Language))
+		conditions = append(conditions, fmt.Sprintf("language = %q", normalizeLanguage(args.Language)))
+	}
+
+	if p := roles.CustomFilePattern(); p != "" {
+		conditions = append(conditions, fmt.Sprintf("regex_matches(path, %q)", p))
 	}
 
 	script := "?[path, language, size] := *cie_file { path, language, size }"
 	if len(conditions) > 0 {
 		script += ", " + strings.Join(conditions, ", ")
 	}
-	script += fmt.Sprintf(" :limit %d", args.Limit)
+	// Built-in roles are classified in Go, so the limit applies afterwards.
+	filterRoles := args.Role != "any" && roles.CustomFilePattern() == ""
+	if !filterRoles {
+		script += fmt.Sprintf(" :limit %d", args.Limit)
+	}
 
 	result, err := client.Query(ctx, script)
 	if err != nil {
 		return NewError(fmt.Sprintf("Query error: %v\n\nGenerated query:\n%s", err, script)), nil
 	}
+	if filterRoles {
+		rows := result.Rows[:0]
+		for _, row := range result.Rows {
+			if len(rows) < args.Limit && roles.MatchFile(AnyToString(row[0])) {
+				rows = append(rows, row)
+			}
+		}
+		result.Rows = rows
+	}
 
 	return NewResult(FormatQueryResult(result, script)), nil
 }
@@ -441,6 +670,8 @@ type FindBySignatureArgs struct {
 	ReturnType     string // Filter: functions returning this type (e.g., "error", "Client")
 	PathPattern    string // Scope to path
 	ExcludePattern string // Exclude files matching pattern
+	Language       string // Only functions of this language, e.g. "go"
+	Dialect        string // Only functions with this framework hint, e.g. "http handler"
 	Limit          int
 }
 
@@ -459,7 +690,7 @@ type sigMatchInfo struct {
 // sigparse.ParseGoParams for precise parameter type matching.
 func FindBySignature(ctx context.Context, client Querier, args FindBySignatureArgs) (*ToolResult, error) {
 	if args.ParamType == "" && args.ReturnType == "" {
-		return NewError("Error: at least one of 'param_type' or 'return_type' is required"), nil
+		return NewInputError("Error: at least one of 'param_type' or 'return_type' is required"), nil
 	}
 	if args.Limit <= 0 {
 		args.Limit = 20
@@ -490,6 +721,9 @@ func buildSignatureQuery(args FindBySignatureArgs) string {
 	if args.ExcludePattern != "" {
 		conditions = append(conditions, fmt.Sprintf("negate(regex_matches(file_path, %q))", args.ExcludePattern))
 	}
+	if lang := languageCondition("id", args.Language, args.Dialect); lang != "" {
+		conditions = append(conditions, lang)
+	}
 
 	fetchLimit := args.Limit * 5
 	if fetchLimit < 100 {
@@ -497,7 +731,7 @@ func buildSignatureQuery(args FindBySignatureArgs) string {
 	}
 
 	return fmt.Sprintf(
-		"?[name, file_path, signature, start_line] := *cie_function { name, file_path, signature, start_line }, %s :limit %d",
+		"?[name, file_path, signature, start_line] := *cie_function { id, name, file_path, signature, start_line }, %s :limit %d",
 		strings.Join(conditions, ", "),
 		fetchLimit,
 	)
@@ -659,18 +893,69 @@ func containsCaseInsensitive(s, substr string) bool {
 // RawQueryArgs holds arguments for raw queries.
 type RawQueryArgs struct {
 	Script string
+	// Params are bound to the script's $name placeholders by CozoDB, so
+	// values from users or agents never become CozoScript text.
+	Params map[string]any
+	// Policy applies guardrails (read-only, relation allowlist, limits).
+	// Nil runs the script unrestricted.
+	Policy *RawQueryPolicy
 }
 
 // RawQuery executes a raw CozoScript query.
 func RawQuery(ctx context.Context, client Querier, args RawQueryArgs) (*ToolResult, error) {
 	if args.Script == "" {
-		return NewError("Error: 'script' is required"), nil
+		return NewInputError("Error: 'script' is required"), nil
+	}
+	if err := checkParamNames(args.Params); err != nil {
+		return NewInputError("Error: " + err.Error()), nil
+	}
+
+	if args.Policy == nil {
+		result, err := queryWithParams(ctx, client, args.Script, args.Params)
+		if err != nil {
+			return NewError(fmt.Sprintf("Query error: %v\n\nQuery:\n%s%s", err, args.Script, formatQueryParams(args.Params))), nil
+		}
+		return NewResult(FormatQueryResult(result, args.Script)), nil
+	}
+
+	policy := *args.Policy
+	if err := policy.Check(args.Script); err != nil {
+		return NewCodedError(errcode.QueryRejected, fmt.Sprintf("Query rejected: %v\n\nQuery:\n%s", err, args.Script)), nil
+	}
+	scans := unboundedScans(args.Script)
+	if len(scans) > 0 && policy.RejectUnboundedScans {
+		return NewCodedError(errcode.QueryRejected, fmt.Sprintf("Query rejected: the script %s. To run it anyway, set mcp.raw_query.allow_unbounded_scans: true\n\nQuery:\n%s",
+			scanHint(scans[0]), args.Script)), nil
+	}
+
+	script, limited := args.Script, false
+	if policy.AutoLimit && policy.MaxRows > 0 {
+		// One row past MaxRows tells a full result from a truncated one.
+		script, limited = withAutoLimit(script, policy.MaxRows+1)
 	}
 
-	result, err := client.Query(ctx, args.Script)
+	result, err := policy.runRawScript(ctx, client, script, args.Params)
 	if err != nil {
-		return NewError(fmt.Sprintf("Query error: %v\n\nQuery:\n%s", err, args.Script)), nil
+		return NewError(fmt.Sprintf("Query error: %v\n\nQuery:\n%s%s", err, script, formatQueryParams(args.Params))), nil
+	}
+	if result == nil {
+		result = &QueryResult{}
 	}
 
-	return NewResult(FormatQueryResult(result, args.Script)), nil
+	totalRows := len(result.Rows)
+	if policy.MaxRows > 0 && totalRows > policy.MaxRows {
+		result.Rows = result.Rows[:policy.MaxRows]
+	}
+	output := FormatQueryResult(result, script)
+	switch {
+	case limited && len(result.Rows) < totalRows:
+		output += fmt.Sprintf("\n⚠️ Showing first %d rows; more matched. The query had no `:limit`, so CIE added `:limit %d`. Add your own `:limit` or narrow the query.\n",
+			len(result.Rows), policy.MaxRows+1)
+	case len(result.Rows) < totalRows:
+		output += fmt.Sprintf("\n⚠️ Showing first %d of %d rows (row limit). Add `:limit` or narrow the query.\n", len(result.Rows), totalRows)
+	}
+	for _, rel := range scans {
+		output += "\n⚠️ Unbounded scan: the script " + scanHint(rel) + ".\n"
+	}
+	return NewResult(output), nil
 }
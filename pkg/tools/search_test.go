@@ -714,7 +714,9 @@ func TestFindFunction_CaseInsensitive(t *testing.T) {
 	var capturedScript string
 	client := NewMockClientCustom(
 		func(ctx context.Context, script string) (*QueryResult, error) {
-			capturedScript = script
+			if !strings.Contains(script, "*cie_note") && !strings.Contains(script, "*cie_generated") { // annotation lookups run last
+				capturedScript = script
+			}
 			return &QueryResult{
 				Headers: []string{"file_path", "name", "signature", "start_line", "end_line"},
 				Rows:    [][]any{{"pkg/db.go", "CozoDB.runQuery", "func (c *CozoDB) runQuery()", 42, 60}},
@@ -741,7 +743,9 @@ func TestFindFunction_ExactMatchStillExact(t *testing.T) {
 	var capturedScript string
 	client := NewMockClientCustom(
 		func(ctx context.Context, script string) (*QueryResult, error) {
-			capturedScript = script
+			if !strings.Contains(script, "*cie_note") && !strings.Contains(script, "*cie_generated") { // annotation lookups run last
+				capturedScript = script
+			}
 			return &QueryResult{
 				Headers: []string{"file_path", "name", "signature", "start_line", "end_line"},
 				Rows:    [][]any{{"pkg/db.go", "RunQuery", "func RunQuery()", 10, 20}},
@@ -29,6 +29,10 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/kraklabs/cie/pkg/embedprefix"
+	"github.com/kraklabs/cie/pkg/errcode"
+	"github.com/kraklabs/cie/pkg/storage"
 )
 
 // SemanticSearchArgs holds arguments for semantic search.
@@ -42,6 +46,13 @@ type SemanticSearchArgs struct {
 	MinSimilarity    float64 // Minimum similarity threshold (0.0-1.0, e.g., 0.5 = 50%)
 	EmbeddingURL     string
 	EmbeddingModel   string
+	Prefixes         *embedprefix.Prefixes  // Prefixes of EmbeddingModel (nil: SetEmbeddingPrefixes or the defaults)
+	Ranking          RankingWeights         // Boosts applied on top of similarity (zero value: similarity only)
+	Git              GitRunner              // Source of file recency for Ranking.Recency (may be nil)
+	CustomRoles      map[string]RolePattern // Custom roles from project.yaml, usable as Role
+	Language         string                 // Only functions of this language, e.g. "go", "ts"
+	Dialect          string                 // Only functions with this framework hint, e.g. "react component"
+	NameWeight       float64                // Share of the name/signature match in the score, 0-1 (0: body only)
 }
 
 // Compiled regex patterns for role-based file filtering (Go regexp syntax).
@@ -60,40 +71,66 @@ var (
 	noiseTermsPattern = regexp.MustCompile(
 		`(?i)\b(mock[s]?|fixture[s]?|example[s]?|vendor)\b`)
 	// anonymousFunctionPattern matches anonymous/generated function names that pollute search results
-	// Matches: $anon_123, $arrow_456, $lambda_789, anonymous, <anonymous>
-	anonymousFunctionPattern = regexp.MustCompile(`(?i)(^\$anon_\d+$|^\$arrow_\d+$|^\$lambda_\d+$|^anonymous$|^<anonymous>$)`)
+	// Matches: Run.closure#1, handler.arrow#2, lambda#3, and the names used by older
+	// indexes: $anon_123, $arrow_456, $lambda_789, anonymous, <anonymous>
+	anonymousFunctionPattern = regexp.MustCompile(`(?i)(^\$anon_\d+$|^\$arrow_\d+$|^\$lambda_\d+$|^anonymous$|^<anonymous>$|(^|\.)(closure|arrow|lambda)#\d+$)`)
 )
 
 // SemanticSearch performs semantic search using embeddings
 func SemanticSearch(ctx context.Context, client Querier, args SemanticSearchArgs) (*ToolResult, error) {
 	args = normalizeSemanticArgs(args)
 	if args.Query == "" {
-		return NewError("Error: 'query' is required"), nil
+		return NewInputError("Error: 'query' is required"), nil
+	}
+	roles, err := NewRoleFilter(args.Role, args.CustomRoles)
+	if err != nil {
+		return NewInputError(fmt.Sprintf("Error: %v", err)), nil
+	}
+	// The text fallback only filters by path; a custom role narrows it by
+	// its file patterns.
+	fallbackPath := args.PathPattern
+	if fallbackPath == "" {
+		fallbackPath = roles.CustomFilePattern()
 	}
 
 	// Generate embedding
-	embedding, err := generateEmbedding(ctx, args.EmbeddingURL, args.EmbeddingModel, args.Query)
+	prefix := queryPrefix(args.EmbeddingModel)
+	if args.Prefixes != nil {
+		prefix = args.Prefixes.Query
+	}
+	embedding, err := embedWithPrefix(ctx, args.EmbeddingURL, args.EmbeddingModel, prefix, args.Query)
 	if err != nil {
-		return semanticSearchFallback(ctx, client, args.Query, args.Limit, args.Role, args.PathPattern, args.ExcludePaths, fmt.Sprintf("embedding generation failed: %v", err))
+		return semanticSearchFallback(ctx, client, args.Query, args.Limit, args.Role, fallbackPath, args.ExcludePaths, args.Language, args.Dialect, fmt.Sprintf("embedding generation failed: %v", err))
 	}
 
 	// Execute HNSW query
 	result, err := executeHNSWQuery(ctx, client, embedding, args)
 	if err != nil {
-		return semanticSearchFallback(ctx, client, args.Query, args.Limit, args.Role, args.PathPattern, args.ExcludePaths, fmt.Sprintf("HNSW query failed: %v", err))
+		return semanticSearchFallback(ctx, client, args.Query, args.Limit, args.Role, fallbackPath, args.ExcludePaths, args.Language, args.Dialect, fmt.Sprintf("HNSW query failed: %v", err))
 	}
 	if len(result.Rows) == 0 {
-		return semanticSearchFallback(ctx, client, args.Query, args.Limit, args.Role, args.PathPattern, args.ExcludePaths, "no vectors found in HNSW index (embeddings may not be generated)")
+		return semanticSearchFallback(ctx, client, args.Query, args.Limit, args.Role, fallbackPath, args.ExcludePaths, args.Language, args.Dialect, "no vectors found in HNSW index (embeddings may not be generated)")
+	}
+	if args.NameWeight > 0 {
+		args.NameWeight = min(args.NameWeight, 1)
+		var blended bool
+		result.Rows, blended = blendNameMatches(ctx, client, embedding, args, result.Rows)
+		if !blended {
+			args.NameWeight = 0
+		}
 	}
 
 	// Post-filter results
-	result.Rows = postFilterByPath(result.Rows, args.PathPattern, args.Role, args.Query, args.ExcludePaths, true)
+	result.Rows = postFilterRows(result.Rows, args.PathPattern, roles, args.Query, args.ExcludePaths, true)
+	if args.Role == "entry_point" {
+		result.Rows = filterEntryPointRows(ctx, client, result.Rows)
+	}
 	if len(result.Rows) == 0 {
 		reason := "no results matching filters in semantic search results"
 		if args.PathPattern != "" {
-			reason = fmt.Sprintf("no results matching path '%s' in semantic search results", args.PathPattern)
+			reason = fmt.Sprintf("no results matching path '%s' in semantic search results", displayPattern(args.PathPattern))
 		}
-		return semanticSearchFallback(ctx, client, args.Query, args.Limit, args.Role, args.PathPattern, args.ExcludePaths, reason)
+		return semanticSearchFallback(ctx, client, args.Query, args.Limit, args.Role, fallbackPath, args.ExcludePaths, args.Language, args.Dialect, reason)
 	}
 
 	// Apply min_similarity filter
@@ -102,11 +139,17 @@ func SemanticSearch(ctx context.Context, client Querier, args SemanticSearchArgs
 		return NewResult(fmt.Sprintf("No results with similarity >= %.0f%% for '%s'", args.MinSimilarity*100, args.Query)), nil
 	}
 
-	// Limit and format results
+	// Rerank with the configured boosts, then limit and format results
+	result.Rows = rerankRows(ctx, client, args.Git, result.Rows, args.Query, args.Limit, args.Ranking)
 	if len(result.Rows) > args.Limit {
 		result.Rows = result.Rows[:args.Limit]
 	}
-	return NewResult(formatSemanticResults(result.Rows, args)), nil
+	refs := make([][2]string, 0, len(result.Rows))
+	for _, r := range result.Rows {
+		refs = append(refs, [2]string{AnyToString(r[0]), AnyToString(r[1])})
+	}
+	output := prefixMismatchWarning(ctx, client, args.EmbeddingModel, args.Prefixes) + formatSemanticResults(result.Rows, args)
+	return NewResult(appendNotes(ctx, client, output, refs)), nil
 }
 
 func normalizeSemanticArgs(args SemanticSearchArgs) SemanticSearchArgs {
@@ -123,16 +166,55 @@ func normalizeSemanticArgs(args SemanticSearchArgs) SemanticSearchArgs {
 }
 
 func executeHNSWQuery(ctx context.Context, client Querier, embedding []float64, args SemanticSearchArgs) (*QueryResult, error) {
+	return executeHNSWQueryOn(ctx, client, "cie_function_embedding", embedding, args)
+}
+
+// executeHNSWQueryOn searches the embedding_idx index of relation, which is
+// keyed by function_id.
+func executeHNSWQueryOn(ctx context.Context, client Querier, relation string, embedding []float64, args SemanticSearchArgs) (*QueryResult, error) {
 	vecLiteral := formatEmbeddingForCozoDB(embedding)
 	queryK, ef := buildHNSWParams(args.Limit, args.Role, args.PathPattern)
-	script := fmt.Sprintf(`?[name, file_path, signature, start_line, distance, code_text] :=
-		~cie_function_embedding:embedding_idx { function_id | query: q, k: %d, ef: %d, bind_distance: distance },
+	lang := languageCondition("function_id", args.Language, args.Dialect)
+	if lang != "" {
+		// The language join drops neighbors after the k nearest are taken.
+		queryK, ef = semanticSearchPathFilterK, semanticSearchPathFilterK
+		lang = ",\n\t\t" + lang
+	}
+	script := fmt.Sprintf(`?[name, file_path, signature, start_line, distance, code_text, function_id] :=
+		~%s:embedding_idx { function_id | query: q, k: %d, ef: %d, bind_distance: distance },
 		q = %s,
 		*cie_function { id: function_id, name, file_path, signature, start_line },
-		*cie_function_code { function_id: function_id, code_text }
+		*cie_function_code { function_id: function_id, code_text }%s
 		:order distance
-		:limit %d`, queryK, ef, vecLiteral, queryK)
-	return client.Query(ctx, script)
+		:limit %d`, relation, queryK, ef, vecLiteral, lang, queryK)
+	result, err := client.Query(ctx, script)
+	if err != nil {
+		return nil, err
+	}
+	scaleDistances(result.Rows, 4, hnswDistanceScale(ctx, client))
+	return result, nil
+}
+
+// hnswDistanceScale returns the factor that converts distances from the
+// project's vector indexes to the cosine scale, 0 to 2, that similarity
+// scores are computed from. It depends on the recorded index metric; see
+// storage.CosineDistanceScale.
+func hnswDistanceScale(ctx context.Context, client Querier) float64 {
+	return storage.CosineDistanceScale(projectMeta(ctx, client, storage.HNSWDistanceMetaKey))
+}
+
+// scaleDistances multiplies the distance in column col of rows by scale.
+func scaleDistances(rows [][]any, col int, scale float64) {
+	if scale == 1 {
+		return
+	}
+	for _, row := range rows {
+		if col < len(row) {
+			if d, ok := row[col].(float64); ok {
+				row[col] = d * scale
+			}
+		}
+	}
 }
 
 func filterByMinSimilarity(rows [][]any, minSimilarity float64) [][]any {
@@ -158,19 +240,27 @@ func filterByMinSimilarity(rows [][]any, minSimilarity float64) [][]any {
 
 func formatSemanticResults(rows [][]any, args SemanticSearchArgs) string {
 	var sb strings.Builder
+	label := languageLabel(args.Language, args.Dialect)
+	using := "using embeddings"
+	if args.NameWeight > 0 {
+		using = fmt.Sprintf("using embeddings, names weighted %.0f%%", args.NameWeight*100)
+	}
 	if args.PathPattern != "" {
-		fmt.Fprintf(&sb, "🔍 **Semantic search** for '%s' in '%s' (using embeddings):\n\n", args.Query, args.PathPattern)
+		fmt.Fprintf(&sb, "🔍 **Semantic search** for '%s'%s in '%s' (%s):\n\n", args.Query, label, displayPattern(args.PathPattern), using)
 	} else {
-		fmt.Fprintf(&sb, "🔍 **Semantic search** for '%s' (using embeddings):\n\n", args.Query)
+		fmt.Fprintf(&sb, "🔍 **Semantic search** for '%s'%s (%s):\n\n", args.Query, label, using)
 	}
 
+	terms := ExtractKeyTerms(args.Query)
 	for i, row := range rows {
-		formatSemanticResultRow(&sb, i+1, row)
+		formatSemanticResultRow(&sb, i+1, row, terms)
 	}
 	return sb.String()
 }
 
-func formatSemanticResultRow(sb *strings.Builder, num int, row []any) {
+// formatSemanticResultRow writes one result. The code snippet is anchored on
+// the lines matching the query terms.
+func formatSemanticResultRow(sb *strings.Builder, num int, row []any, terms []string) {
 	name := AnyToString(row[0])
 	filePath := AnyToString(row[1])
 	signature := AnyToString(row[2])
@@ -194,8 +284,8 @@ func formatSemanticResultRow(sb *strings.Builder, num int, row []any) {
 	}
 
 	if len(row) > 5 {
-		codeText := AnyToString(row[5])
-		snippet := extractCodeSnippet(codeText, 3)
+		codeText := decodeCodeText(row[5])
+		snippet := extractAnchoredSnippet(codeText, int(toFloat64(row[3])), terms, 3)
 		if snippet != "" {
 			sb.WriteString("   ```\n")
 			for _, line := range strings.Split(snippet, "\n") {
@@ -218,11 +308,11 @@ func getConfidenceIcon(similarity float64) string {
 }
 
 // semanticSearchFallback uses text search when semantic search is unavailable
-func semanticSearchFallback(ctx context.Context, client Querier, query string, limit int, role, pathPattern, excludePaths, reason string) (*ToolResult, error) {
+func semanticSearchFallback(ctx context.Context, client Querier, query string, limit int, role, pathPattern, excludePaths, language, dialect, reason string) (*ToolResult, error) {
 	// Extract key terms and use regex search
 	terms := ExtractKeyTerms(query)
 	if len(terms) == 0 {
-		return NewError("No searchable terms found in query"), nil
+		return NewInputError("No searchable terms found in query"), nil
 	}
 
 	pattern := "(?i)(" + terms[0]
@@ -264,6 +354,8 @@ func semanticSearchFallback(ctx context.Context, client Querier, query string, l
 		SearchIn:       "all", // Search name, signature, AND code
 		FilePattern:    filePattern,
 		ExcludePattern: excludePattern,
+		Language:       language,
+		Dialect:        dialect,
 		Limit:          limit,
 	})
 	if err != nil {
@@ -291,20 +383,12 @@ func semanticSearchFallback(ctx context.Context, client Querier, query string, l
 	return NewResult(output), nil
 }
 
-// preprocessQueryForCode applies model-specific preprocessing to queries.
-// Different models use different formats:
-//   - Qodo-Embed-1: Uses gte-Qwen2-instruct format (Instruct + Query)
-//   - Nomic: "search_query: <query>" prefix for asymmetric search
-//
-// The embeddingModel parameter is used to detect the model type.
+// preprocessQueryForCode prepends the query prefix of the embedding model:
+// the configured one (see SetEmbeddingPrefixes) or embedprefix.Defaults.
+// Qodo-Embed-1 uses the gte-Qwen2-instruct format (Instruct + Query), Nomic
+// and other models "search_query: <query>" for asymmetric search.
 func preprocessQueryForCode(query, embeddingModel string) string {
-	// Qodo-Embed models: use gte-Qwen2-instruct format
-	// Base model uses "Instruct: <task>\nQuery: <query>" format
-	if embeddingModel == "" || isQodoModel(embeddingModel) {
-		return "Instruct: Given a code search query, retrieve relevant code that matches the query\nQuery: " + query
-	}
-	// Nomic and other models: asymmetric search prefix
-	return "search_query: " + query
+	return queryPrefix(embeddingModel) + query
 }
 
 // isQodoModel checks if the model name indicates a Qodo embedding model.
@@ -312,13 +396,35 @@ func isQodoModel(model string) bool {
 	return strings.Contains(strings.ToLower(model), "qodo")
 }
 
-// generateEmbedding generates an embedding using the configured provider.
+// generateEmbedding embeds a search query with the configured provider and
+// returns it at unit length, like the stored embeddings, so that L2 and
+// inner product indexes report distances on the expected scale.
+func generateEmbedding(ctx context.Context, embeddingURL, embeddingModel, text string) ([]float64, error) {
+	return embedWithPrefix(ctx, embeddingURL, embeddingModel, queryPrefix(embeddingModel), text)
+}
+
+// generateDocumentEmbedding embeds text that is stored and searched for,
+// such as a fact, with the document prefix instead of the query prefix.
+func generateDocumentEmbedding(ctx context.Context, embeddingURL, embeddingModel, text string) ([]float64, error) {
+	return embedWithPrefix(ctx, embeddingURL, embeddingModel, documentPrefix(embeddingModel), text)
+}
+
+// embedWithPrefix embeds prefix+text and returns the vector at unit length.
+func embedWithPrefix(ctx context.Context, embeddingURL, embeddingModel, prefix, text string) ([]float64, error) {
+	embedding, err := requestEmbedding(ctx, embeddingURL, embeddingModel, prefix+text)
+	if err != nil {
+		return nil, err
+	}
+	embedding, _ = storage.NormalizeVector(embedding)
+	return embedding, nil
+}
+
+// requestEmbedding requests an embedding of processedText, prefix included,
+// from the configured provider.
 // Supports Ollama API (/api/embeddings), llama.cpp server (/embedding), and OpenAI-compatible (/v1/embeddings).
 //
 //nolint:gocyclo // Embedding provider detection has inherent complexity
-func generateEmbedding(ctx context.Context, embeddingURL, embeddingModel, text string) ([]float64, error) {
-	// Preprocess the query for better code matching
-	processedText := preprocessQueryForCode(text, embeddingModel)
+func requestEmbedding(ctx context.Context, embeddingURL, embeddingModel, processedText string) ([]float64, error) {
 
 	// Detect API type based on URL patterns
 	isLlamaCpp := strings.Contains(embeddingURL, ":8090") || embeddingModel == ""
@@ -371,7 +477,7 @@ func generateEmbedding(ctx context.Context, embeddingURL, embeddingModel, text s
 	client := &http.Client{Timeout: 60 * time.Second} // Longer timeout for local models
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("embedding http request: %w", err)
+		return nil, fmt.Errorf("embedding http request: %w: %w", errcode.ErrProviderUnreachable, err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
@@ -448,13 +554,15 @@ func formatEmbeddingForCozoDB(embedding []float64) string {
 	return buf.String()
 }
 
+// semanticSearchPathFilterK is the candidate count fetched when results are
+// filtered after the HNSW search.
+const semanticSearchPathFilterK = 2000
+
 // buildHNSWParams determines the HNSW query parameters based on filtering requirements.
 // We always retrieve extra candidates and post-filter in Go for reliability.
 // HNSW in-query filters have parsing issues with complex regex patterns.
 // Returns: queryK (number of candidates), ef (exploration factor)
 func buildHNSWParams(limit int, role, pathPattern string) (queryK, ef int) {
-	// Constants
-	const semanticSearchPathFilterK = 2000
 	const semanticSearchMinEf = 50
 
 	// Determine if we need post-filtering (which requires more candidates)
@@ -483,6 +591,17 @@ func buildHNSWParams(limit int, role, pathPattern string) (queryK, ef int) {
 //   - excludePaths: additional regex pattern to exclude (agent-specified, case-by-case)
 //   - excludeAnonymous: if true, filters out anonymous/arrow functions
 func postFilterByPath(rows [][]any, pathPattern, role, query, excludePaths string, excludeAnonymous bool) [][]any {
+	roles, err := NewRoleFilter(role, nil)
+	if err != nil {
+		roles = &RoleFilter{role: role} // unknown roles exclude tests and generated files
+	}
+	return postFilterRows(rows, pathPattern, roles, query, excludePaths, excludeAnonymous)
+}
+
+// postFilterRows is postFilterByPath with a compiled role filter, which may
+// include custom roles.
+func postFilterRows(rows [][]any, pathPattern string, roles *RoleFilter, query, excludePaths string, excludeAnonymous bool) [][]any {
+	role := roles.Role()
 	var pathRegex *regexp.Regexp
 	if pathPattern != "" {
 		// Build case-insensitive regex for path matching
@@ -518,7 +637,11 @@ func postFilterByPath(rows [][]any, pathPattern, role, query, excludePaths strin
 		}
 
 		// Apply role filter
-		if !MatchesRoleFilter(filePath, role) {
+		code := ""
+		if len(row) > 5 {
+			code = decodeCodeText(row[5])
+		}
+		if !roles.Match(name, filePath, code) {
 			continue
 		}
 
@@ -537,6 +660,22 @@ func postFilterByPath(rows [][]any, pathPattern, role, query, excludePaths strin
 	return filtered
 }
 
+// filterEntryPointRows keeps the rows whose function was recorded as an entry
+// point at index time. Indexes without cie_entry_point are left unfiltered.
+func filterEntryPointRows(ctx context.Context, client Querier, rows [][]any) [][]any {
+	ids := indexedEntryPointIDs(ctx, client)
+	if ids == nil {
+		return rows
+	}
+	filtered := make([][]any, 0, len(rows))
+	for _, row := range rows {
+		if len(row) > 6 && ids[AnyToString(row[6])] {
+			filtered = append(filtered, row)
+		}
+	}
+	return filtered
+}
+
 // MatchesRoleFilter checks if a file path matches the given role filter.
 // Returns true if the file should be included in results.
 func MatchesRoleFilter(filePath, role string) bool {
@@ -583,6 +722,21 @@ func RoleFiltersForHNSW(role string) string {
 	}
 }
 
+// Router detection: function names OR code patterns for Go frameworks (Gin,
+// Echo, Fiber, Chi, Mux). Use [.] for literal dots, avoid \\s which may not
+// work in CozoDB.
+const (
+	routerNames = `(?i)(RegisterRoutes|SetupRoutes|InitRoutes|NewRouter|Routes|SetupRouter|SetupHandlers|RegisterAPI)`
+	routerCode  = `(?i)([.](GET|POST|PUT|DELETE|PATCH|Group|Handle|Use)[(]|RouterGroup|gin[.]Engine|echo[.]Echo|fiber[.]App|chi[.]Router|mux[.]Router)`
+)
+
+// routerNameRegex and routerCodeRegex match routerNames and routerCode
+// client-side, for code CozoDB cannot read (see RoleFiltersForIndex).
+var (
+	routerNameRegex = regexp.MustCompile(routerNames)
+	routerCodeRegex = regexp.MustCompile(routerCode)
+)
+
 // roleFilters returns CozoScript filter conditions for a given role (for normal queries)
 func RoleFilters(role string) []string {
 	// Note: Use [.] for literal dot in regex - CozoDB interprets \. differently
@@ -590,8 +744,6 @@ func RoleFilters(role string) []string {
 	generatedPattern := `"(?i)([.]pb[.]go|_generated[.]go|[.]gen[.]go|_gen[.]go|[.]generated[.]|/generated/)"`
 	entryPointPattern := `"(?i)^main$"`
 
-	// Router detection: function names OR code patterns for Go frameworks (Gin, Echo, Fiber, Chi, Mux)
-	routerNamePattern := `"(?i)(RegisterRoutes|SetupRoutes|InitRoutes|NewRouter|Routes|SetupRouter|SetupHandlers|RegisterAPI)"`
 	// Handler detection: function names OR signature patterns for Go frameworks
 	handlerNamePattern := `"(?i)(Handler|Controller|handle[A-Z])"`
 
@@ -612,9 +764,8 @@ func RoleFilters(role string) []string {
 		}
 	case "router":
 		// Match by name OR by code content (Gin/Echo/Fiber/Chi route patterns)
-		// Note: Use [.] for literal dots, avoid \\s which may not work in CozoDB
 		return []string{
-			fmt.Sprintf(`(regex_matches(name, %s) or regex_matches(code_text, "(?i)([.](GET|POST|PUT|DELETE|PATCH|Group|Handle|Use)[(]|RouterGroup|gin[.]Engine|echo[.]Echo|fiber[.]App|chi[.]Router|mux[.]Router)"))`, routerNamePattern),
+			fmt.Sprintf(`(regex_matches(name, %q) or regex_matches(code_text, %q))`, routerNames, routerCode),
 			fmt.Sprintf(`negate(regex_matches(file_path, %s))`, testPattern),
 		}
 	case "handler":
@@ -628,37 +779,75 @@ func RoleFilters(role string) []string {
 	}
 }
 
-// extractCodeSnippet extracts the first N meaningful lines from code text.
-// Skips empty lines and trims whitespace for a clean preview.
-func extractCodeSnippet(code string, maxLines int) string {
-	if code == "" {
-		return ""
-	}
+// snippetTermPrefix is how much of a query term must appear in a line for
+// it to count, so "authentication" also finds "authenticate".
+const snippetTermPrefix = 5
 
+// extractAnchoredSnippet extracts up to maxLines non-empty lines of code
+// centered on the body line sharing the most terms with the query, marked
+// with "> ". Lines carry file line numbers, startLine being the line of the
+// first line of code. Without a matching line the snippet starts at the top.
+func extractAnchoredSnippet(code string, startLine int, terms []string, maxLines int) string {
 	lines := strings.Split(code, "\n")
-	var result []string
-	count := 0
+	var kept []int // indexes of non-empty lines
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			kept = append(kept, i)
+		}
+	}
+	if len(kept) == 0 || maxLines <= 0 {
+		return ""
+	}
 
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		// Skip empty lines and comment-only lines for better preview
-		if trimmed == "" {
-			continue
+	// The signature is skipped: it names the function, not the region.
+	anchor, best := -1, 0
+	for k := 1; k < len(kept); k++ {
+		if hits := countTermHits(lines[kept[k]], terms); hits > best {
+			anchor, best = k, hits
 		}
-		// Include the line (preserve original indentation but limit length)
+	}
+	from := 0
+	if anchor >= 0 {
+		from = anchor - (maxLines-1)/2
+		if from+maxLines > len(kept) {
+			from = len(kept) - maxLines
+		}
+		if from < 0 {
+			from = 0
+		}
+	}
+	to := from + maxLines
+	if to > len(kept) {
+		to = len(kept)
+	}
+
+	var result []string
+	for k := from; k < to; k++ {
+		line := lines[kept[k]]
 		if len(line) > 80 {
 			line = line[:77] + "..."
 		}
-		result = append(result, line)
-		count++
-		if count >= maxLines {
-			break
+		prefix := "  "
+		if k == anchor {
+			prefix = "> "
 		}
+		result = append(result, fmt.Sprintf("%s%d: %s", prefix, startLine+kept[k], line))
 	}
+	return strings.Join(result, "\n")
+}
 
-	if len(result) == 0 {
-		return ""
+// countTermHits counts the terms whose prefix appears in line, ignoring case.
+func countTermHits(line string, terms []string) int {
+	line = strings.ToLower(line)
+	hits := 0
+	for _, term := range terms {
+		term = strings.ToLower(term)
+		if len(term) > snippetTermPrefix {
+			term = term[:snippetTermPrefix]
+		}
+		if strings.Contains(line, term) {
+			hits++
+		}
 	}
-
-	return strings.Join(result, "\n")
+	return hits
 }
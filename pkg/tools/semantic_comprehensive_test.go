@@ -22,6 +22,7 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -61,11 +62,24 @@ func TestGetConfidenceIcon(t *testing.T) {
 	}
 }
 
-func TestExtractCodeSnippet(t *testing.T) {
+func TestExtractAnchoredSnippet(t *testing.T) {
 	t.Parallel()
+	code := "func Login(w http.ResponseWriter, r *http.Request) {\n" +
+		"\tuser := r.FormValue(\"user\")\n" +
+		"\n" +
+		"\tif user == \"\" {\n" +
+		"\t\treturn\n" +
+		"\t}\n" +
+		"\ttoken, err := authenticate(user)\n" +
+		"\tif err != nil {\n" +
+		"\t\treturn\n" +
+		"\t}\n" +
+		"\twriteToken(w, token)\n" +
+		"}"
 	tests := []struct {
 		name     string
 		code     string
+		terms    []string
 		maxLines int
 		want     string
 	}{
@@ -76,43 +90,45 @@ func TestExtractCodeSnippet(t *testing.T) {
 			want:     "",
 		},
 		{
-			name:     "single line",
-			code:     "func main() {",
+			name:     "only empty lines",
+			code:     "\n\n\n",
 			maxLines: 3,
-			want:     "func main() {",
+			want:     "",
 		},
 		{
-			name:     "multiple lines with limit",
-			code:     "func main() {\n\tfmt.Println(\"hello\")\n\treturn\n}\n",
-			maxLines: 2,
-			want:     "func main() {\n\tfmt.Println(\"hello\")",
+			name:     "no terms starts at the top and skips empty lines",
+			code:     code,
+			maxLines: 3,
+			want:     "  40: func Login(w http.ResponseWriter, r *http.Request) {\n  41: \tuser := r.FormValue(\"user\")\n  43: \tif user == \"\" {",
+		},
+		{
+			name:     "centered on the best matching line",
+			code:     code,
+			terms:    []string{"authentication", "token"},
+			maxLines: 3,
+			want:     "  45: \t}\n> 46: \ttoken, err := authenticate(user)\n  47: \tif err != nil {",
 		},
 		{
-			name:     "skip empty lines",
-			code:     "func main() {\n\n\n\tfmt.Println(\"hello\")\n\n\treturn\n}",
+			name:     "anchor near the end keeps the window full",
+			code:     code,
+			terms:    []string{"writeToken"},
 			maxLines: 3,
-			want:     "func main() {\n\tfmt.Println(\"hello\")\n\treturn",
+			want:     "  49: \t}\n> 50: \twriteToken(w, token)\n  51: }",
 		},
 		{
 			name:     "truncate long lines",
 			code:     "func main() { " + strings.Repeat("x", 100) + " }",
 			maxLines: 1,
-			want:     "func main() { " + strings.Repeat("x", 63) + "...",
-		},
-		{
-			name:     "only empty lines",
-			code:     "\n\n\n",
-			maxLines: 3,
-			want:     "",
+			want:     "  40: func main() { " + strings.Repeat("x", 63) + "...",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			got := extractCodeSnippet(tt.code, tt.maxLines)
+			got := extractAnchoredSnippet(tt.code, 40, tt.terms, tt.maxLines)
 			if got != tt.want {
-				t.Errorf("extractCodeSnippet() = %q, want %q", got, tt.want)
+				t.Errorf("extractAnchoredSnippet() = %q, want %q", got, tt.want)
 			}
 		})
 	}
@@ -442,7 +458,7 @@ func TestFormatSemanticResultRow(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 			var sb strings.Builder
-			formatSemanticResultRow(&sb, 1, tt.row)
+			formatSemanticResultRow(&sb, 1, tt.row, nil)
 			got := sb.String()
 			for _, want := range tt.wantContains {
 				if !strings.Contains(got, want) {
@@ -495,8 +511,9 @@ func TestGenerateEmbedding_Ollama(t *testing.T) {
 		t.Errorf("expected 5 dimensions, got %d", len(embedding))
 	}
 
-	expectedFirst := 0.1
-	if embedding[0] != expectedFirst {
+	// Query vectors are returned at unit length.
+	expectedFirst := 0.1 / math.Sqrt(0.55)
+	if math.Abs(embedding[0]-expectedFirst) > 1e-9 {
 		t.Errorf("embedding[0] = %f, want %f", embedding[0], expectedFirst)
 	}
 }
@@ -801,6 +818,9 @@ func TestExecuteHNSWQuery(t *testing.T) {
 
 	client := NewMockClientCustom(
 		func(ctx context.Context, script string) (*QueryResult, error) {
+			if strings.Contains(script, "*cie_project_meta") {
+				return &QueryResult{}, nil // index metric lookup
+			}
 			// Verify the query structure
 			if !strings.Contains(script, "~cie_function_embedding:embedding_idx") {
 				t.Error("Query should use HNSW index")
@@ -853,7 +873,7 @@ func TestSemanticSearchFallback(t *testing.T) {
 		nil,
 	)
 
-	result, err := semanticSearchFallback(ctx, client, "authentication handler", 10, "source", "", "", "test reason")
+	result, err := semanticSearchFallback(ctx, client, "authentication handler", 10, "source", "", "", "", "", "test reason")
 
 	assertNoError(t, err)
 	assertContains(t, result.Text, "⚠️ **Text search fallback**")
@@ -875,7 +895,7 @@ func TestSemanticSearchFallback_NoResults(t *testing.T) {
 		nil,
 	)
 
-	result, err := semanticSearchFallback(ctx, client, "nonexistent query", 10, "source", "", "", "no matches")
+	result, err := semanticSearchFallback(ctx, client, "nonexistent query", 10, "source", "", "", "", "", "no matches")
 
 	assertNoError(t, err)
 	assertContains(t, result.Text, "⚠️ **Text search fallback**")
@@ -0,0 +1,80 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+//go:build cozodb
+// +build cozodb
+
+// Integration tests for semantic search against seeded vectors.
+// Run with: go test -tags=cozodb ./pkg/tools/...
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cietest "github.com/kraklabs/cie/internal/testing"
+)
+
+func TestSemanticSearch_Integration(t *testing.T) {
+	const dim = 8
+	backend := cietest.SetupTestBackendWithHNSW(t, dim)
+
+	login := "func Login(user, password string) error {\n\treturn auth.Check(user, password)\n}"
+	cietest.SeedSemanticFunction(t, backend, "f1", "Login", "internal/auth/login.go", login)
+	cietest.SeedSemanticFunction(t, backend, "f2", "RenderPage", "internal/web/render.go", "func RenderPage(w io.Writer) {}")
+	cietest.SeedSemanticFunction(t, backend, "f3", "TestLogin", "internal/auth/login_test.go", login+" // test")
+
+	// The embedding server maps any query mentioning "login" onto the seeded
+	// vector of Login, so the nearest neighbour is known in advance.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Prompt string `json:"prompt"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		text := req.Prompt
+		if strings.Contains(strings.ToLower(text), "login") {
+			text = login
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"embedding": cietest.TestVector(text, dim)})
+	}))
+	defer server.Close()
+
+	result, err := SemanticSearch(context.Background(), NewEmbeddedQuerier(backend), SemanticSearchArgs{
+		Query:          "user login",
+		Limit:          2,
+		EmbeddingURL:   server.URL,
+		EmbeddingModel: "nomic-embed-text",
+	})
+	if err != nil {
+		t.Fatalf("SemanticSearch() error = %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("SemanticSearch() returned error result: %s", result.Text)
+	}
+	first, render := strings.Index(result.Text, "Login"), strings.Index(result.Text, "RenderPage")
+	if first < 0 || (render >= 0 && render < first) {
+		t.Errorf("expected Login ranked first, got:\n%s", result.Text)
+	}
+	if strings.Contains(result.Text, "TestLogin") {
+		t.Errorf("test files should be filtered by the default role, got:\n%s", result.Text)
+	}
+}
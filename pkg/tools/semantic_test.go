@@ -406,10 +406,16 @@ func TestAnonymousFunctionPattern(t *testing.T) {
 		{"$lambda_789", "$lambda_789", true},
 		{"anonymous", "anonymous", true},
 		{"<anonymous>", "<anonymous>", true},
+		{"go closure", "Server.Start.closure#3", true},
+		{"nested closure", "run.closure#1.closure#2", true},
+		{"js arrow", "register.arrow#1", true},
+		{"top-level lambda", "lambda#2", true},
 
 		// Should not match
 		{"normal function", "HandleRequest", false},
 		{"contains anon", "handleAnonData", false},
+		{"closure in name", "NewClosure", false},
+		{"method of closure type", "closure.Run", false},
 		{"$anon without number", "$anon_", false},
 		{"prefix $anon", "prefix$anon_1", false},
 		{"suffix $anon", "$anon_1suffix", false},
@@ -22,6 +22,8 @@ package tools
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"strings"
 )
 
 // ListServices lists gRPC services and RPC methods from .proto files.
@@ -84,6 +86,8 @@ func ListServices(ctx context.Context, client Querier, pathPattern, serviceName
 		output += fmt.Sprintf("- `%s`\n", row[0])
 	}
 
+	links := rpcLinksByRPC(ctx, client)
+
 	if len(result.Rows) > 0 {
 		output += "\n## Service Definitions\n"
 
@@ -96,6 +100,11 @@ func ListServices(ctx context.Context, client Querier, pathPattern, serviceName
 			startLine := AnyToString(row[3])
 
 			entry := fmt.Sprintf("- **%s** (line %s)\n  `%s`", name, startLine, signature)
+			for _, role := range []struct{ role, label string }{{"server", "Implemented by"}, {"client", "Called from"}} {
+				if fns := links[name+"|"+role.role]; len(fns) > 0 {
+					entry += fmt.Sprintf("\n  %s: %s", role.label, strings.Join(fns, ", "))
+				}
+			}
 			fileServices[filePath] = append(fileServices[filePath], entry)
 		}
 
@@ -113,6 +122,25 @@ func ListServices(ctx context.Context, client Querier, pathPattern, serviceName
 	return NewResult(output), nil
 }
 
+// rpcLinksByRPC returns the functions implementing and calling each RPC,
+// keyed by "Service.Method|role", as "`name` (file:line)". Indexes built
+// before cie_rpc_link return none.
+func rpcLinksByRPC(ctx context.Context, client Querier) map[string][]string {
+	result, err := client.Query(ctx, `?[rpc, role, name, file_path, line] := *cie_rpc_link { rpc, role, function_id, file_path, line }, *cie_function { id: function_id, name } :order rpc, file_path, line :limit 1000`)
+	if err != nil {
+		return nil
+	}
+	links := make(map[string][]string)
+	for _, row := range result.Rows {
+		if len(row) < 5 {
+			continue
+		}
+		key := AnyToString(row[0]) + "|" + AnyToString(row[1])
+		links[key] = append(links[key], fmt.Sprintf("`%s` (%s:%s)", AnyToString(row[2]), AnyToString(row[3]), AnyToString(row[4])))
+	}
+	return links
+}
+
 // RoleFiltersWithCustom returns CozoScript filter conditions for a given role, supporting custom roles.
 //
 // It first checks if the role exists in the customRoles map. If found, it builds filter conditions
@@ -128,8 +156,19 @@ func RoleFiltersWithCustom(role string, customRoles map[string]RolePattern) []st
 	// Check for custom role first
 	if customRole, ok := customRoles[role]; ok {
 		var conditions []string
+		filePatterns := make([]string, 0, len(customRole.Paths)+1)
 		if customRole.FilePattern != "" {
-			conditions = append(conditions, fmt.Sprintf(`regex_matches(file_path, %q)`, customRole.FilePattern))
+			filePatterns = append(filePatterns, customRole.FilePattern)
+		}
+		for _, glob := range customRole.Paths {
+			filePatterns = append(filePatterns, GlobToRegex(glob))
+		}
+		switch len(filePatterns) {
+		case 0:
+		case 1:
+			conditions = append(conditions, fmt.Sprintf(`regex_matches(file_path, %q)`, filePatterns[0]))
+		default:
+			conditions = append(conditions, fmt.Sprintf(`regex_matches(file_path, %q)`, "("+strings.Join(filePatterns, ")|(")+")"))
 		}
 		if customRole.NamePattern != "" {
 			conditions = append(conditions, fmt.Sprintf(`regex_matches(name, %q)`, customRole.NamePattern))
@@ -143,3 +182,36 @@ func RoleFiltersWithCustom(role string, customRoles map[string]RolePattern) []st
 	// Fall back to built-in roles
 	return RoleFilters(role)
 }
+
+// RoleFiltersForIndex is RoleFiltersWithCustom for the index behind client.
+// CozoDB cannot run regexes on compressed code_text, so on such an index the
+// conditions on code move out of conditions into matchCode, which callers
+// apply to each row's name and decompressed code, for example in the visit
+// function of a code scan. matchCode is nil when nothing is left to check.
+func RoleFiltersForIndex(ctx context.Context, client Querier, role string, customRoles map[string]RolePattern) (conditions []string, matchCode func(name, code string) bool, err error) {
+	if !isCodeCompressed(ctx, client) {
+		return RoleFiltersWithCustom(role, customRoles), nil, nil
+	}
+	if custom, ok := customRoles[role]; ok {
+		if custom.CodePattern == "" {
+			return RoleFiltersWithCustom(role, customRoles), nil, nil
+		}
+		code, err := regexp.Compile(custom.CodePattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf("role %q: invalid code_pattern: %w", role, err)
+		}
+		custom.CodePattern = ""
+		return RoleFiltersWithCustom(role, map[string]RolePattern{role: custom}), func(_, c string) bool { return code.MatchString(c) }, nil
+	}
+	if role != "router" {
+		return RoleFilters(role), nil, nil
+	}
+	for _, c := range RoleFilters(role) {
+		if !strings.Contains(c, "code_text") {
+			conditions = append(conditions, c)
+		}
+	}
+	return conditions, func(name, code string) bool {
+		return routerNameRegex.MatchString(name) || routerCodeRegex.MatchString(code)
+	}, nil
+}
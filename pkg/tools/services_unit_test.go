@@ -22,6 +22,7 @@ package tools
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 )
 
@@ -47,3 +48,27 @@ func TestListServices_Error(t *testing.T) {
 		t.Error("expected error for failed query")
 	}
 }
+
+func TestListServices_RPCLinks(t *testing.T) {
+	client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		switch {
+		case strings.HasPrefix(script, "?[path]"):
+			return NewMockQueryResult([]string{"path"}, [][]any{{"api/users.proto"}}), nil
+		case strings.HasPrefix(script, "?[file_path, name, signature, start_line]"):
+			return NewMockQueryResult(nil, [][]any{
+				{"api/users.proto", "UserService.GetUser", "rpc GetUser(GetUserRequest) returns (User)", float64(12)},
+			}), nil
+		case strings.HasPrefix(script, "?[rpc, role, name, file_path, line]"):
+			return NewMockQueryResult(nil, [][]any{
+				{"UserService.GetUser", "client", "loadUser", "web/src/api.ts", float64(6)},
+				{"UserService.GetUser", "server", "userServer.GetUser", "internal/server/users.go", float64(8)},
+			}), nil
+		}
+		return NewMockQueryResult(nil, nil), nil
+	}, nil)
+
+	result, err := ListServices(context.Background(), client, "", "")
+	assertNoError(t, err)
+	assertContains(t, result.Text, "Implemented by: `userServer.GetUser` (internal/server/users.go:8)")
+	assertContains(t, result.Text, "Called from: `loadUser` (web/src/api.ts:6)")
+}
@@ -0,0 +1,165 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// nearDuplicateSimilarity is the similarity from which a neighbor is
+// flagged as a likely duplicate.
+const nearDuplicateSimilarity = 0.95
+
+// SimilarToFunctionArgs holds arguments for cie_similar_to_function.
+type SimilarToFunctionArgs struct {
+	FunctionID    string  // ID of the indexed function to start from
+	FunctionName  string  // Used when FunctionID is empty; resolved like cie_get_function_code
+	Limit         int     // Neighbors to return (default 10, max 50)
+	PathPattern   string  // Only neighbors whose path matches this regex
+	Role          string  // source (default), test, generated or any
+	MinSimilarity float64 // Drop neighbors below this similarity (0.0-1.0)
+}
+
+// similarSource is the function whose neighbors are searched.
+type similarSource struct {
+	id, name, filePath, startLine string
+}
+
+// SimilarToFunction finds the nearest neighbors of an indexed function by
+// its stored embedding. The vector never leaves the database and no
+// embedding provider is called, so it works while the provider is offline.
+func SimilarToFunction(ctx context.Context, client Querier, args SimilarToFunctionArgs) (*ToolResult, error) {
+	args.FunctionID = strings.TrimSpace(args.FunctionID)
+	args.FunctionName = strings.TrimSpace(args.FunctionName)
+	if args.FunctionID == "" && args.FunctionName == "" {
+		return NewInputError("Error: 'function_id' or 'function_name' is required"), nil
+	}
+	semantic := normalizeSemanticArgs(SemanticSearchArgs{Limit: args.Limit, Role: args.Role, PathPattern: args.PathPattern})
+	roles, err := NewRoleFilter(semantic.Role, nil)
+	if err != nil {
+		return NewInputError(fmt.Sprintf("Error: %v", err)), nil
+	}
+
+	source, res := resolveSimilarSource(ctx, client, args)
+	if res != nil {
+		return res, nil
+	}
+
+	// One extra neighbor: the function itself is filtered out below.
+	queryK, ef := buildHNSWParams(semantic.Limit+1, semantic.Role, semantic.PathPattern)
+	id := QuoteCozoPattern(source.id)
+	script := fmt.Sprintf(`?[name, file_path, signature, start_line, distance, code_text, function_id] :=
+		*cie_function_embedding { function_id: %s, embedding: q },
+		~cie_function_embedding:embedding_idx { function_id | query: q, k: %d, ef: %d, bind_distance: distance },
+		function_id != %s,
+		*cie_function { id: function_id, name, file_path, signature, start_line },
+		*cie_function_code { function_id, code_text }
+		:order distance
+		:limit %d`, id, queryK, ef, id, queryK)
+	result, err := client.Query(ctx, script)
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v", err)), nil
+	}
+	if len(result.Rows) == 0 {
+		return NewResult(noSimilarResult(ctx, client, source)), nil
+	}
+	scaleDistances(result.Rows, 4, hnswDistanceScale(ctx, client))
+
+	result.Rows = postFilterRows(result.Rows, semantic.PathPattern, roles, "", "", true)
+	result.Rows = filterByMinSimilarity(result.Rows, args.MinSimilarity)
+	if len(result.Rows) > semantic.Limit {
+		result.Rows = result.Rows[:semantic.Limit]
+	}
+	if len(result.Rows) == 0 {
+		return NewResult(fmt.Sprintf("No functions similar to **%s** matched the filters.", source.name)), nil
+	}
+	return NewResult(formatSimilarResults(source, result.Rows)), nil
+}
+
+// resolveSimilarSource looks up the function to start from by ID, or by
+// name when no ID is given. A non-nil result is returned to the caller as
+// is: the function was not found or the name is ambiguous.
+func resolveSimilarSource(ctx context.Context, client Querier, args SimilarToFunctionArgs) (similarSource, *ToolResult) {
+	var condition string
+	if args.FunctionID != "" {
+		condition = "id == " + QuoteCozoPattern(args.FunctionID)
+	} else {
+		condition = fmt.Sprintf(`regex_matches(name, "(?i)^%s$")`, EscapeRegex(args.FunctionName))
+		if qualified, ok := qualifiedCondition(args.FunctionName); ok {
+			condition = fmt.Sprintf("(%s or %s)", condition, qualified)
+		}
+	}
+	script := fmt.Sprintf(`?[id, name, file_path, start_line, signature] := *cie_function { id, name, file_path, start_line, signature }, %s :limit %d`,
+		condition, maxAmbiguousMatches)
+	result, err := client.Query(ctx, script)
+	if err != nil {
+		return similarSource{}, NewError(fmt.Sprintf("Query error: %v", err))
+	}
+	if len(result.Rows) == 0 {
+		if args.FunctionID != "" {
+			return similarSource{}, NewResult(fmt.Sprintf("No indexed function has ID '%s'.", args.FunctionID))
+		}
+		return similarSource{}, NewResult(fmt.Sprintf("Function '%s' not found.", args.FunctionName))
+	}
+	if matches := distinctFunctionMatches(result.Rows, 1, 2, 3, 4); len(matches) > 1 {
+		return similarSource{}, NewResult(fmt.Sprintf("Function '%s' is ambiguous (%d matches). Call again with one of these qualified names:\n\n%s",
+			args.FunctionName, len(matches), formatDisambiguation(matches)))
+	}
+	row := result.Rows[0]
+	return similarSource{
+		id:        AnyToString(row[0]),
+		name:      AnyToString(row[1]),
+		filePath:  AnyToString(row[2]),
+		startLine: AnyToString(row[3]),
+	}, nil
+}
+
+// noSimilarResult explains an empty neighbor search: usually the function
+// has no stored embedding.
+func noSimilarResult(ctx context.Context, client Querier, source similarSource) string {
+	script := fmt.Sprintf(`?[function_id] := *cie_function_embedding { function_id }, function_id == %s`, QuoteCozoPattern(source.id))
+	if res, err := client.Query(ctx, script); err == nil && len(res.Rows) == 0 {
+		return fmt.Sprintf("**%s** (%s:%s) has no stored embedding, so it has no neighbors to compare.\n\n"+
+			"It was indexed without embeddings (`cie index --skip-embeddings`) or its embedding failed. "+
+			"Run `cie embed-backfill`, or use cie_find_similar_functions to match by name.",
+			source.name, source.filePath, source.startLine)
+	}
+	return fmt.Sprintf("No functions similar to **%s** were found.", source.name)
+}
+
+// formatSimilarResults lists the neighbors of source, closest first.
+func formatSimilarResults(source similarSource, rows [][]any) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🔍 **Functions similar to %s** (%s:%s, ID `%s`), by its stored embedding:\n\n",
+		source.name, source.filePath, source.startLine, source.id)
+	duplicates := 0
+	for i, row := range rows {
+		formatSemanticResultRow(&sb, i+1, row, nil)
+		if d, ok := row[4].(float64); ok && 1-d/2 >= nearDuplicateSimilarity {
+			duplicates++
+		}
+	}
+	if duplicates > 0 {
+		fmt.Fprintf(&sb, "⚠️ %d of these are at least %.0f%% similar: likely duplicates or copies worth consolidating.\n",
+			duplicates, nearDuplicateSimilarity*100)
+	}
+	return sb.String()
+}
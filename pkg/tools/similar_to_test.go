@@ -0,0 +1,99 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// similarClient answers the lookup of the source function with sources and
+// the neighbor search with neighbors.
+func similarClient(t *testing.T, sources, neighbors [][]any) *MockCIEClient {
+	t.Helper()
+	return NewMockClientCustom(func(_ context.Context, script string) (*QueryResult, error) {
+		switch {
+		case strings.Contains(script, "~cie_function_embedding:embedding_idx"):
+			if !strings.Contains(script, "*cie_function_embedding { function_id: ___\"fn-parse\"___, embedding: q }") {
+				t.Errorf("neighbor search does not start from the stored embedding:\n%s", script)
+			}
+			return &QueryResult{Rows: neighbors}, nil
+		case strings.HasPrefix(script, "?[function_id] := *cie_function_embedding"):
+			return &QueryResult{}, nil
+		default:
+			return &QueryResult{Rows: sources}, nil
+		}
+	}, nil)
+}
+
+func TestSimilarToFunction(t *testing.T) {
+	ctx := setupTest(t)
+	source := [][]any{{"fn-parse", "ParseConfig", "internal/config/parse.go", 12, "func ParseConfig() error"}}
+	neighbors := [][]any{
+		{"LoadConfig", "internal/config/load.go", "func LoadConfig() error", 8, 0.02, "func LoadConfig() error {}", "fn-load"},
+		{"ParseFlags", "cmd/app/flags.go", "func ParseFlags()", 20, 0.5, "func ParseFlags() {}", "fn-flags"},
+		{"TestParse", "internal/config/parse_test.go", "func TestParse(t *testing.T)", 5, 0.1, "", "fn-test"},
+	}
+
+	t.Run("by name", func(t *testing.T) {
+		result, err := SimilarToFunction(ctx, similarClient(t, source, neighbors), SimilarToFunctionArgs{FunctionName: "ParseConfig"})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "Functions similar to ParseConfig")
+		assertContains(t, result.Text, "ID `fn-parse`")
+		assertContains(t, result.Text, "LoadConfig")
+		assertContains(t, result.Text, "ParseFlags")
+		assertContains(t, result.Text, "1 of these are at least 95% similar")
+		if strings.Contains(result.Text, "TestParse") {
+			t.Error("test functions should be dropped for the source role")
+		}
+	})
+
+	t.Run("by ID with min similarity", func(t *testing.T) {
+		result, err := SimilarToFunction(ctx, similarClient(t, source, neighbors), SimilarToFunctionArgs{FunctionID: "fn-parse", MinSimilarity: 0.9})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "LoadConfig")
+		if strings.Contains(result.Text, "ParseFlags") {
+			t.Error("neighbors below min_similarity should be dropped")
+		}
+	})
+
+	t.Run("no embedding", func(t *testing.T) {
+		result, err := SimilarToFunction(ctx, similarClient(t, source, nil), SimilarToFunctionArgs{FunctionID: "fn-parse"})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "has no stored embedding")
+		assertContains(t, result.Text, "cie embed-backfill")
+	})
+
+	t.Run("ambiguous name", func(t *testing.T) {
+		twins := append(source, []any{"fn-other", "ParseConfig", "internal/legacy/parse.go", 40, ""})
+		result, err := SimilarToFunction(ctx, similarClient(t, twins, neighbors), SimilarToFunctionArgs{FunctionName: "ParseConfig"})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "is ambiguous (2 matches)")
+	})
+
+	t.Run("missing arguments", func(t *testing.T) {
+		result, err := SimilarToFunction(ctx, NewMockClientEmpty(), SimilarToFunctionArgs{})
+		assertNoError(t, err)
+		if !result.IsError {
+			t.Fatal("expected an input error")
+		}
+	})
+}
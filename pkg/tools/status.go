@@ -127,14 +127,14 @@ func formatEmptyIndexHelp() string {
 }
 
 func (s *indexStatusState) formatPathStats(pathPattern string, total indexCounts) string {
-	output := fmt.Sprintf("\n## Path: `%s`\n", pathPattern)
+	output := fmt.Sprintf("\n## Path: `%s`\n", displayPattern(pathPattern))
 	pathFiles := s.countEntities("path files", fmt.Sprintf(`?[count(f)] := *cie_file { id: f, path }, regex_matches(path, %q)`, pathPattern), fmt.Sprintf(`?[id] := *cie_file { id, path }, regex_matches(path, %q) :limit 10000`, pathPattern))
 	pathFuncs := s.countEntities("path functions", fmt.Sprintf(`?[count(f)] := *cie_function { id: f, file_path }, regex_matches(file_path, %q)`, pathPattern), fmt.Sprintf(`?[id] := *cie_function { id, file_path }, regex_matches(file_path, %q) :limit 10000`, pathPattern))
 
 	output += fmt.Sprintf("- **Files:** %d\n- **Functions:** %d\n", pathFiles, pathFuncs)
 
 	if pathFiles == 0 && pathFuncs == 0 {
-		output += fmt.Sprintf("\n⚠️ **No files indexed for this path!**\n\n### Possible causes:\n1. Path pattern `%s` doesn't match any files in the project\n2. Files in this path were excluded by `.cie/project.yaml` exclude patterns\n3. Files are in a format CIE doesn't support (binary files, images, etc.)\n\n### How to check:\n- Use `cie_list_files` to see what paths are actually indexed\n- Check your `.cie/project.yaml` for exclude patterns\n- Try a broader path pattern (e.g., 'apps' instead of 'apps/gateway')\n", pathPattern)
+		output += fmt.Sprintf("\n⚠️ **No files indexed for this path!**\n\n### Possible causes:\n1. Path pattern `%s` doesn't match any files in the project\n2. Files in this path were excluded by `.cie/project.yaml` exclude patterns\n3. Files are in a format CIE doesn't support (binary files, images, etc.)\n\n### How to check:\n- Use `cie_list_files` to see what paths are actually indexed\n- Check your `.cie/project.yaml` for exclude patterns\n- Try a broader path pattern (e.g., 'apps' instead of 'apps/gateway')\n", displayPattern(pathPattern))
 	} else {
 		filePct, funcPct := 0.0, 0.0
 		if total.files > 0 {
@@ -0,0 +1,502 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/kraklabs/cie/pkg/storage"
+)
+
+// StructuralSearchArgs holds arguments for template-based code search.
+type StructuralSearchArgs struct {
+	Pattern        string // template such as "http.Client{ Timeout: :[t] }"
+	Path           string // file path substring filter
+	ExcludePattern string // regex of file paths to skip
+	Scope          string // "functions" (default) or "files"
+	Limit          int    // max matches
+}
+
+const (
+	maxStructuralMatchesPerDoc = 20
+	// maxStructuralSteps bounds backtracking per document so pathological
+	// templates cannot stall a query.
+	maxStructuralSteps = 200000
+)
+
+type templateTokenKind int
+
+const (
+	tokLiteral   templateTokenKind = iota
+	tokSpace                       // whitespace in the template; matches any run of whitespace
+	tokHole                        // :[name] - balanced text, may span lines
+	tokIdentHole                   // :[[name]] - a single identifier
+)
+
+type templateToken struct {
+	kind     templateTokenKind
+	text     string // literal text or hole name ("_" for anonymous)
+	required bool   // for tokSpace: at least one whitespace char must match
+}
+
+// compileTemplate splits a comby-style template into literals, whitespace
+// runs and holes. Whitespace in the template matches any whitespace
+// (including newlines), so templates are layout-insensitive.
+func compileTemplate(pattern string) ([]templateToken, error) {
+	var tokens []templateToken
+	var lit strings.Builder
+	flush := func() {
+		if lit.Len() > 0 {
+			tokens = append(tokens, templateToken{kind: tokLiteral, text: lit.String()})
+			lit.Reset()
+		}
+	}
+
+	for i := 0; i < len(pattern); {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], ":[["):
+			end := strings.Index(pattern[i:], "]]")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated hole at offset %d", i)
+			}
+			name := pattern[i+3 : i+end]
+			if !isHoleName(name) {
+				return nil, fmt.Errorf("invalid hole name %q", name)
+			}
+			flush()
+			tokens = append(tokens, templateToken{kind: tokIdentHole, text: name})
+			i += end + 2
+		case strings.HasPrefix(pattern[i:], ":["):
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated hole at offset %d", i)
+			}
+			name := pattern[i+2 : i+end]
+			if !isHoleName(name) {
+				return nil, fmt.Errorf("invalid hole name %q", name)
+			}
+			flush()
+			tokens = append(tokens, templateToken{kind: tokHole, text: name})
+			i += end + 1
+		case isSpace(c):
+			flush()
+			for i < len(pattern) && isSpace(pattern[i]) {
+				i++
+			}
+			tokens = append(tokens, templateToken{kind: tokSpace})
+		default:
+			lit.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+
+	// Leading and trailing whitespace carries no meaning.
+	for len(tokens) > 0 && tokens[0].kind == tokSpace {
+		tokens = tokens[1:]
+	}
+	for len(tokens) > 0 && tokens[len(tokens)-1].kind == tokSpace {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("pattern is empty")
+	}
+	if _, ok := longestLiteral(tokens); !ok {
+		return nil, fmt.Errorf("pattern needs at least one literal part")
+	}
+
+	// Whitespace between two word characters separates tokens ("func main"
+	// must not match "funcmain"); elsewhere it is optional.
+	for i := range tokens {
+		if tokens[i].kind != tokSpace || i == 0 || i == len(tokens)-1 {
+			continue
+		}
+		prev, next := tokens[i-1], tokens[i+1]
+		tokens[i].required = prev.kind == tokLiteral && next.kind == tokLiteral &&
+			isWordByte(prev.text[len(prev.text)-1]) && isWordByte(next.text[0])
+	}
+	return tokens, nil
+}
+
+func isHoleName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// longestLiteral returns the longest literal part of a template, used to
+// pre-filter candidates inside the database.
+func longestLiteral(tokens []templateToken) (string, bool) {
+	best := ""
+	for _, t := range tokens {
+		if t.kind == tokLiteral && len(t.text) > len(best) {
+			best = t.text
+		}
+	}
+	return best, best != ""
+}
+
+// structuralMatch is one occurrence of a template in a document.
+type structuralMatch struct {
+	Start, End int
+	Bindings   map[string]string
+}
+
+type templateMatcher struct {
+	src    string
+	tokens []templateToken
+	binds  map[string]string
+	steps  int
+}
+
+// matchTemplate returns non-overlapping matches of tokens in src, at most max.
+func matchTemplate(tokens []templateToken, src string, max int) []structuralMatch {
+	m := &templateMatcher{src: src, tokens: tokens}
+	var out []structuralMatch
+	for pos := 0; pos < len(src) && len(out) < max; {
+		if tokens[0].kind == tokLiteral {
+			idx := strings.Index(src[pos:], tokens[0].text)
+			if idx < 0 {
+				break
+			}
+			pos += idx
+		}
+		m.binds = map[string]string{}
+		end, ok := m.match(pos, 0)
+		if m.steps > maxStructuralSteps {
+			break
+		}
+		if !ok || end == pos {
+			pos++
+			continue
+		}
+		out = append(out, structuralMatch{Start: pos, End: end, Bindings: m.binds})
+		pos = end
+	}
+	return out
+}
+
+func (m *templateMatcher) match(pos, ti int) (int, bool) {
+	m.steps++
+	if m.steps > maxStructuralSteps {
+		return 0, false
+	}
+	if ti == len(m.tokens) {
+		return pos, true
+	}
+	tok := m.tokens[ti]
+	switch tok.kind {
+	case tokLiteral:
+		if !strings.HasPrefix(m.src[pos:], tok.text) {
+			return 0, false
+		}
+		return m.match(pos+len(tok.text), ti+1)
+	case tokSpace:
+		end := pos
+		for end < len(m.src) && isSpace(m.src[end]) {
+			end++
+		}
+		if tok.required && end == pos {
+			return 0, false
+		}
+		return m.match(end, ti+1)
+	case tokIdentHole:
+		end := pos
+		for end < len(m.src) && isWordByte(m.src[end]) {
+			end++
+		}
+		for ; end > pos; end-- {
+			if e, ok := m.bindAndMatch(tok.text, pos, end, ti); ok {
+				return e, true
+			}
+		}
+		return 0, false
+	default:
+		return m.matchHole(tok.text, pos, ti)
+	}
+}
+
+// matchHole tries the shortest balanced text first. Brackets must nest
+// and string literals are skipped whole, so a hole never ends inside
+// "a(b" or half way through a string. A trailing hole runs to the end of
+// the line (or the enclosing block) instead of matching nothing.
+func (m *templateMatcher) matchHole(name string, pos, ti int) (int, bool) {
+	last := ti == len(m.tokens)-1
+	var stack []byte
+	for p := pos; ; {
+		if len(stack) == 0 && !last {
+			if e, ok := m.bindAndMatch(name, pos, p, ti); ok {
+				return e, true
+			}
+			if m.steps > maxStructuralSteps {
+				return 0, false
+			}
+		}
+		if p >= len(m.src) {
+			break
+		}
+		c := m.src[p]
+		switch c {
+		case '(', '[', '{':
+			stack = append(stack, c)
+		case ')', ']', '}':
+			if len(stack) == 0 || stack[len(stack)-1] != openerFor(c) {
+				return m.finishTrailingHole(name, pos, p, last, ti)
+			}
+			stack = stack[:len(stack)-1]
+		case '"', '\'', '`':
+			if end := skipStringLiteral(m.src, p); end > p {
+				p = end
+				continue
+			}
+		case '\n':
+			if last && len(stack) == 0 {
+				return m.finishTrailingHole(name, pos, p, last, ti)
+			}
+		}
+		p++
+	}
+	return m.finishTrailingHole(name, pos, len(m.src), last, ti)
+}
+
+func (m *templateMatcher) finishTrailingHole(name string, pos, end int, last bool, ti int) (int, bool) {
+	if !last {
+		return 0, false
+	}
+	return m.bindAndMatch(name, pos, end, ti)
+}
+
+// bindAndMatch binds src[start:end] to the hole and matches the rest of the
+// template. A name used twice must capture the same text both times.
+func (m *templateMatcher) bindAndMatch(name string, start, end, ti int) (int, bool) {
+	value := strings.TrimSpace(m.src[start:end])
+	if name == "_" {
+		return m.match(end, ti+1)
+	}
+	if prev, ok := m.binds[name]; ok {
+		if prev != value {
+			return 0, false
+		}
+		return m.match(end, ti+1)
+	}
+	m.binds[name] = value
+	if e, ok := m.match(end, ti+1); ok {
+		return e, true
+	}
+	delete(m.binds, name)
+	return 0, false
+}
+
+func openerFor(c byte) byte {
+	switch c {
+	case ')':
+		return '('
+	case ']':
+		return '['
+	}
+	return '{'
+}
+
+// skipStringLiteral returns the offset just past the string literal that
+// starts at p, or p when the quote is not closed on the same line (for
+// example a Rust lifetime or an apostrophe in a comment).
+func skipStringLiteral(src string, p int) int {
+	quote := src[p]
+	for i := p + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			return i + 1
+		case '\n':
+			if quote != '`' {
+				return p
+			}
+		}
+	}
+	return p
+}
+
+// structuralDoc is a unit of searchable text: a function body or a file.
+type structuralDoc struct {
+	Path      string
+	Name      string
+	StartLine int
+	Text      string
+}
+
+// StructuralSearch finds code matching a comby-style template. Holes
+// ":[name]" match balanced text (across lines), ":[[name]]" matches one
+// identifier and ":[_]" matches without binding. Whitespace in the template
+// matches any whitespace, so formatting differences do not matter.
+func StructuralSearch(ctx context.Context, client Querier, args StructuralSearchArgs) (*ToolResult, error) {
+	if strings.TrimSpace(args.Pattern) == "" {
+		return NewInputError("Error: 'pattern' is required"), nil
+	}
+	tokens, err := compileTemplate(args.Pattern)
+	if err != nil {
+		return NewInputError(fmt.Sprintf("Invalid pattern `%s`: %v\n\n"+
+			"Use `:[name]` for any balanced text, `:[[name]]` for an identifier and `:[_]` for an unnamed hole.", args.Pattern, err)), nil
+	}
+	if args.Limit <= 0 {
+		args.Limit = 20
+	}
+
+	docs, err := structuralCandidates(ctx, client, args, tokens)
+	if err != nil {
+		return nil, fmt.Errorf("structural search query: %w", err)
+	}
+
+	var sb strings.Builder
+	found := 0
+	for _, doc := range docs {
+		if found >= args.Limit {
+			break
+		}
+		for _, match := range matchTemplate(tokens, doc.Text, min(maxStructuralMatchesPerDoc, args.Limit-found)) {
+			found++
+			line := doc.StartLine + strings.Count(doc.Text[:match.Start], "\n")
+			label := fmt.Sprintf("`%s:%d`", doc.Path, line)
+			if doc.Name != "" {
+				label = fmt.Sprintf("`%s` — %s", doc.Name, label)
+			}
+			fmt.Fprintf(&sb, "%d. %s\n```\n%s\n```\n", found, label, truncateLines(doc.Text[match.Start:match.End], 8))
+			for _, name := range sortedKeys(match.Bindings) {
+				fmt.Fprintf(&sb, "   - `%s` = `%s`\n", name, truncateBinding(match.Bindings[name]))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	if found == 0 {
+		output := fmt.Sprintf("No structural matches for `%s`", args.Pattern)
+		if args.Path != "" {
+			output += fmt.Sprintf(" in `%s`", args.Path)
+		}
+		return NewResult(output + "\n"), nil
+	}
+	header := fmt.Sprintf("## Structural matches for `%s`\n\nFound %d matches", args.Pattern, found)
+	if found >= args.Limit {
+		header += " (limit reached)"
+	}
+	return NewResult(header + ":\n\n" + sb.String()), nil
+}
+
+// structuralCandidates loads the documents that contain the template's
+// longest literal. Compressed indexes are filtered client-side.
+func structuralCandidates(ctx context.Context, client Querier, args StructuralSearchArgs, tokens []templateToken) ([]structuralDoc, error) {
+	literal, _ := longestLiteral(tokens)
+	compressed := isCodeCompressed(ctx, client)
+	files := args.Scope == "files"
+
+	pathField, textField := "file_path", "code_text"
+	if files {
+		pathField, textField = "path", "content"
+	}
+	var conditions []string
+	if !compressed {
+		conditions = append(conditions, fmt.Sprintf("regex_matches(%s, %s)", textField, QuoteCozoPattern(EscapeRegex(literal))))
+	}
+	if args.Path != "" {
+		conditions = append(conditions, fmt.Sprintf("regex_matches(%s, %s)", pathField, QuoteCozoPattern(EscapeRegex(args.Path))))
+	}
+	if args.ExcludePattern != "" {
+		conditions = append(conditions, fmt.Sprintf("!regex_matches(%s, %s)", pathField, QuoteCozoPattern(args.ExcludePattern)))
+	}
+	filter := ""
+	if len(conditions) > 0 {
+		filter = ", " + strings.Join(conditions, ", ")
+	}
+
+	var script string
+	if files {
+		script = fmt.Sprintf("?[path, name, start_line, content] := *cie_file { id, path }, *cie_file_content { file_id: id, content }, name = '', start_line = 1%s :order path :limit %d", filter, maxCompressedScanRows)
+	} else {
+		script = fmt.Sprintf("?[file_path, name, start_line, code_text] := *cie_function { id, file_path, name, start_line }, *cie_function_code { function_id: id, code_text }%s :order file_path, start_line :limit %d", filter, maxCompressedScanRows)
+	}
+
+	result, err := client.Query(ctx, script)
+	if err != nil {
+		if files && strings.Contains(err.Error(), "cie_file_content") {
+			return nil, fmt.Errorf("%w (%s)", err, strings.ReplaceAll(fileTextMissingHint, "\n\n", " "))
+		}
+		return nil, err
+	}
+
+	docs := make([]structuralDoc, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		if len(row) < 4 {
+			continue
+		}
+		text, err := storage.DecompressCodeText(AnyToString(row[3]))
+		if err != nil || !strings.Contains(text, literal) {
+			continue
+		}
+		docs = append(docs, structuralDoc{
+			Path:      AnyToString(row[0]),
+			Name:      AnyToString(row[1]),
+			StartLine: int(toFloat64(row[2])),
+			Text:      text,
+		})
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		if docs[i].Path != docs[j].Path {
+			return docs[i].Path < docs[j].Path
+		}
+		return docs[i].StartLine < docs[j].StartLine
+	})
+	return docs, nil
+}
+
+func truncateLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) <= n {
+		return s
+	}
+	return strings.Join(lines[:n], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-n)
+}
+
+func truncateBinding(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > 80 {
+		return s[:77] + "..."
+	}
+	return s
+}
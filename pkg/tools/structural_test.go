@@ -0,0 +1,145 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCompileTemplate_Errors(t *testing.T) {
+	for _, pattern := range []string{"", "   ", ":[x]", "foo(:[x)", "foo(:[a-b])", ":[[x]] :[y]"} {
+		if _, err := compileTemplate(pattern); err == nil {
+			t.Errorf("compileTemplate(%q) should fail", pattern)
+		}
+	}
+}
+
+func TestMatchTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		src      string
+		want     []string
+		bindings map[string]string
+	}{
+		{
+			name:     "struct literal across lines",
+			pattern:  "http.Client{ Timeout: :[t] }",
+			src:      "c := &http.Client{\n\tTimeout:   30 * time.Second,\n}\n",
+			want:     []string{"http.Client{\n\tTimeout:   30 * time.Second,\n}"},
+			bindings: map[string]string{"t": "30 * time.Second,"},
+		},
+		{
+			name:     "balanced hole stops at matching paren",
+			pattern:  "errors.Wrap(:[err], :[msg])",
+			src:      `return errors.Wrap(f(a, b), "failed: (x)")`,
+			want:     []string{`errors.Wrap(f(a, b), "failed: (x)")`},
+			bindings: map[string]string{"err": "f(a, b)", "msg": `"failed: (x)"`},
+		},
+		{
+			name:    "repeated hole must bind the same text",
+			pattern: "if :[x] != nil { return :[x] }",
+			src:     "if err != nil { return nil }\nif err != nil {\n\treturn err\n}",
+			want:    []string{"if err != nil {\n\treturn err\n}"},
+		},
+		{
+			name:     "identifier hole",
+			pattern:  "defer :[[v]].Close()",
+			src:      "defer resp.Body.Close()\ndefer f.Close()",
+			want:     []string{"defer f.Close()"},
+			bindings: map[string]string{"v": "f"},
+		},
+		{
+			name:    "required whitespace between words",
+			pattern: "func main",
+			src:     "funcmain(); func  main()",
+			want:    []string{"func  main"},
+		},
+		{
+			name:     "trailing hole runs to end of line",
+			pattern:  "x := :[v]",
+			src:      "x := f(1,\n\t2)\ny := 3",
+			want:     []string{"x := f(1,\n\t2)"},
+			bindings: map[string]string{"v": "f(1,\n\t2)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := compileTemplate(tt.pattern)
+			assertNoError(t, err)
+			matches := matchTemplate(tokens, tt.src, 10)
+			var got []string
+			for _, m := range matches {
+				got = append(got, tt.src[m.Start:m.End])
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Fatalf("matches = %q, want %q", got, tt.want)
+			}
+			for k, v := range tt.bindings {
+				if matches[0].Bindings[k] != v {
+					t.Errorf("binding %s = %q, want %q", k, matches[0].Bindings[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchTemplate_StepBudget(t *testing.T) {
+	tokens, err := compileTemplate("a(:[x], :[y], :[z], :[w]) b")
+	assertNoError(t, err)
+	src := "a(" + strings.Repeat("1, ", 2000) + ")"
+	if got := matchTemplate(tokens, src, 10); len(got) != 0 {
+		t.Errorf("expected no match, got %d", len(got))
+	}
+}
+
+func TestStructuralSearch(t *testing.T) {
+	ctx := setupTest(t)
+	var script string
+	client := NewMockClientCustom(func(ctx context.Context, s string) (*QueryResult, error) {
+		if strings.Contains(s, "cie_project_meta") {
+			return NewMockQueryResult([]string{"value"}, nil), nil
+		}
+		script = s
+		return NewMockQueryResult(
+			[]string{"file_path", "name", "start_line", "code_text"},
+			[][]any{{"pkg/api/client.go", "NewClient", int64(10), "func NewClient() *Client {\n\treturn &Client{http: &http.Client{Timeout: 5 * time.Second}}\n}"}},
+		), nil
+	}, nil)
+
+	result, err := StructuralSearch(ctx, client, StructuralSearchArgs{Pattern: "http.Client{Timeout: :[t]}", Path: "pkg/api"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "Found 1 matches")
+	assertContains(t, result.Text, "`NewClient` — `pkg/api/client.go:11`")
+	assertContains(t, result.Text, "`t` = `5 * time.Second`")
+	assertContains(t, script, "regex_matches(code_text, ___\"http[.]Client[{]Timeout:\"___)")
+}
+
+func TestStructuralSearch_InvalidPattern(t *testing.T) {
+	ctx := setupTest(t)
+	result, err := StructuralSearch(ctx, NewMockClientWithResults(nil, nil), StructuralSearchArgs{Pattern: "foo(:[x"})
+	assertNoError(t, err)
+	if !result.IsError {
+		t.Fatal("expected an error result")
+	}
+	assertContains(t, result.Text, "unterminated hole")
+}
@@ -46,7 +46,7 @@ import (
 //	- **Query** (line 45): `func (c *Client) Query(ctx context.Context, script string) (*Result, error)`
 func DirectorySummary(ctx context.Context, client Querier, path string, maxFuncsPerFile int) (*ToolResult, error) {
 	if path == "" {
-		return NewError("Error: 'path' is required"), nil
+		return NewInputError("Error: 'path' is required"), nil
 	}
 	path = normalizeDirPath(path)
 
@@ -0,0 +1,248 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TablesArgs holds arguments for the table usage lookup.
+type TablesArgs struct {
+	Table       string // optional: substring of a table or model name; lists the functions using it
+	Function    string // optional: substring of a function name; lists the tables it uses
+	PathPattern string // optional: regex on the file path
+	Limit       int    // tables to show (default 20)
+}
+
+// tableUse is one function's use of a table.
+type tableUse struct {
+	Function string
+	FilePath string
+	Line     string
+	Table    string
+	Model    string
+	Op       string
+	Source   string
+}
+
+// tableOpOrder lists operations in display order.
+var tableOpOrder = []string{"select", "insert", "update", "delete", "ddl", "use"}
+
+// Tables maps functions to the database tables they use, from SQL string
+// literals and ORM calls (gorm, sqlx, SQLAlchemy, Prisma) found at index
+// time. Without arguments it summarizes every table; with a table it lists
+// the functions reading and writing it; with a function it lists the tables
+// that function touches.
+func Tables(ctx context.Context, client Querier, args TablesArgs) (*ToolResult, error) {
+	if args.Limit <= 0 {
+		args.Limit = 20
+	}
+
+	var conditions []string
+	if args.Function != "" {
+		conditions = append(conditions, fmt.Sprintf("regex_matches(name, %q)", "(?i)"+EscapeRegex(args.Function)))
+	}
+	if args.PathPattern != "" {
+		conditions = append(conditions, fmt.Sprintf("regex_matches(file_path, %s)", QuoteCozoPattern(args.PathPattern)))
+	}
+	filter := ""
+	if len(conditions) > 0 {
+		filter = ", " + strings.Join(conditions, ", ")
+	}
+	result, err := client.Query(ctx, fmt.Sprintf(
+		"?[name, file_path, line, table_name, model, op, source] := *cie_table_ref { function_id, file_path, line, table_name, model, op, source }, *cie_function { id: function_id, name }%s :order table_name, file_path, line :limit 5000",
+		filter))
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v (indexes built before cie_table_ref need 'cie index')", err)), nil
+	}
+
+	var uses []tableUse
+	for _, r := range result.Rows {
+		if len(r) < 7 {
+			continue
+		}
+		u := tableUse{Function: AnyToString(r[0]), FilePath: AnyToString(r[1]), Line: AnyToString(r[2]), Table: AnyToString(r[3]), Model: AnyToString(r[4]), Op: AnyToString(r[5]), Source: AnyToString(r[6])}
+		if args.Table != "" && !strings.Contains(strings.ToLower(u.Table), strings.ToLower(args.Table)) && !strings.Contains(strings.ToLower(u.Model), strings.ToLower(args.Table)) {
+			continue
+		}
+		uses = append(uses, u)
+	}
+	if len(uses) == 0 {
+		switch {
+		case args.Table != "":
+			return NewResult(fmt.Sprintf("No function uses a table matching '%s'.", args.Table)), nil
+		case args.Function != "":
+			return NewResult(fmt.Sprintf("No table usage found in functions matching '%s'.", args.Function)), nil
+		}
+		return NewResult("No table usage indexed. SQL string literals and gorm, sqlx, SQLAlchemy and Prisma calls are detected during indexing."), nil
+	}
+
+	switch {
+	case args.Function != "":
+		return NewResult(formatFunctionTables(args.Function, uses)), nil
+	case args.Table != "":
+		return NewResult(formatTableUsers(args.Table, uses, args.Limit)), nil
+	}
+	return NewResult(formatTableSummary(uses, args.Limit)), nil
+}
+
+// formatTableSummary lists tables by the number of functions using them.
+func formatTableSummary(uses []tableUse, limit int) string {
+	type tableStats struct {
+		name    string
+		models  map[string]bool
+		readers map[string]bool
+		writers map[string]bool
+		ddl     map[string]bool
+		funcs   map[string]bool
+	}
+	byTable := make(map[string]*tableStats)
+	for _, u := range uses {
+		st := byTable[u.Table]
+		if st == nil {
+			st = &tableStats{name: u.Table, models: map[string]bool{}, readers: map[string]bool{}, writers: map[string]bool{}, ddl: map[string]bool{}, funcs: map[string]bool{}}
+			byTable[u.Table] = st
+		}
+		if u.Model != "" {
+			st.models[u.Model] = true
+		}
+		switch u.Op {
+		case "select":
+			st.readers[u.Function] = true
+		case "insert", "update", "delete":
+			st.writers[u.Function] = true
+		case "ddl":
+			st.ddl[u.Function] = true
+		}
+		st.funcs[u.Function] = true
+	}
+	tables := make([]*tableStats, 0, len(byTable))
+	for _, st := range byTable {
+		tables = append(tables, st)
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		if len(tables[i].funcs) != len(tables[j].funcs) {
+			return len(tables[i].funcs) > len(tables[j].funcs)
+		}
+		return tables[i].name < tables[j].name
+	})
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## Tables (%d)\n\n| Table | Models | Readers | Writers | DDL |\n|-------|--------|---------|---------|-----|\n", len(tables))
+	for i, st := range tables {
+		if i == limit {
+			fmt.Fprintf(&sb, "\n_%d more; pass `table` to narrow down._\n", len(tables)-limit)
+			break
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %d | %d | %d |\n", st.name, joinSorted(st.models, "—"), len(st.readers), len(st.writers), len(st.ddl))
+	}
+	sb.WriteString("\nPass `table` to see which functions read and write a table, or `function` to see the tables a function uses.\n")
+	return sb.String()
+}
+
+// formatTableUsers lists the functions using each matching table, grouped by operation.
+func formatTableUsers(query string, uses []tableUse, limit int) string {
+	byTable := make(map[string][]tableUse)
+	var names []string
+	for _, u := range uses {
+		if _, ok := byTable[u.Table]; !ok {
+			names = append(names, u.Table)
+		}
+		byTable[u.Table] = append(byTable[u.Table], u)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for i, name := range names {
+		if i == limit {
+			fmt.Fprintf(&sb, "_%d more tables match '%s'._\n", len(names)-limit, query)
+			break
+		}
+		fmt.Fprintf(&sb, "## %s\n", name)
+		models := make(map[string]bool)
+		for _, u := range byTable[name] {
+			if u.Model != "" {
+				models[u.Model] = true
+			}
+		}
+		if len(models) > 0 {
+			fmt.Fprintf(&sb, "\n**Models**: %s\n", joinSorted(models, ""))
+		}
+		for _, op := range tableOpOrder {
+			var lines []string
+			for _, u := range byTable[name] {
+				if u.Op == op {
+					lines = append(lines, fmt.Sprintf("- `%s` (%s:%s) via %s", u.Function, u.FilePath, u.Line, u.Source))
+				}
+			}
+			if len(lines) > 0 {
+				fmt.Fprintf(&sb, "\n**%s** (%d):\n%s\n", tableOpTitle(op), len(lines), strings.Join(lines, "\n"))
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// formatFunctionTables lists the tables each matching function uses.
+func formatFunctionTables(query string, uses []tableUse) string {
+	byFunc := make(map[string][]tableUse)
+	var names []string
+	for _, u := range uses {
+		if _, ok := byFunc[u.Function]; !ok {
+			names = append(names, u.Function)
+		}
+		byFunc[u.Function] = append(byFunc[u.Function], u)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## Tables used by functions matching '%s'\n", query)
+	for _, name := range names {
+		fn := byFunc[name]
+		fmt.Fprintf(&sb, "\n**%s** (%s)\n", name, fn[0].FilePath)
+		for _, u := range fn {
+			model := ""
+			if u.Model != "" {
+				model = fmt.Sprintf(" (model `%s`)", u.Model)
+			}
+			fmt.Fprintf(&sb, "- %s `%s`%s at line %s via %s\n", u.Op, u.Table, model, u.Line, u.Source)
+		}
+	}
+	return sb.String()
+}
+
+func tableOpTitle(op string) string {
+	switch op {
+	case "select":
+		return "Reads"
+	case "insert":
+		return "Inserts"
+	case "update":
+		return "Updates"
+	case "delete":
+		return "Deletes"
+	case "ddl":
+		return "Schema changes"
+	}
+	return "Other uses"
+}
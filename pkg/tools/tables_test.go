@@ -0,0 +1,103 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// tablesMock serves four table references. The query must carry filters,
+// the conditions of the call.
+func tablesMock(t *testing.T, filters ...string) Querier {
+	return NewMockClientScripted(t, MockQuery{
+		Match: []string{"?[name, file_path, line, table_name, model, op, source]"},
+		Want: append([]string{
+			"*cie_table_ref { function_id, file_path, line, table_name, model, op, source }",
+			"*cie_function { id: function_id, name }",
+		}, filters...),
+		Rows: [][]any{
+			{"GetUser", "store/users.go", float64(12), "users", "", "select", "sql"},
+			{"Repo.Deactivate", "repo/user.go", float64(30), "users", "User", "update", "gorm"},
+			{"migrate", "db/migrate.go", float64(5), "users", "", "ddl", "sql"},
+			{"listOrders", "store/orders.go", float64(8), "orders", "", "select", "sql"},
+		},
+	})
+}
+
+func TestTables(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("summary", func(t *testing.T) {
+		result, err := Tables(ctx, tablesMock(t), TablesArgs{})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "## Tables (2)")
+		assertContains(t, result.Text, "| users | `User` | 1 | 1 | 1 |")
+		assertContains(t, result.Text, "| orders | — | 1 | 0 | 0 |")
+		if strings.Index(result.Text, "| users") > strings.Index(result.Text, "| orders") {
+			t.Errorf("tables used by more functions should come first:\n%s", result.Text)
+		}
+	})
+
+	t.Run("table", func(t *testing.T) {
+		result, err := Tables(ctx, tablesMock(t), TablesArgs{Table: "user"})
+		assertNoError(t, err)
+		for _, want := range []string{
+			"## users",
+			"**Models**: `User`",
+			"**Reads** (1):\n- `GetUser` (store/users.go:12) via sql",
+			"**Updates** (1):\n- `Repo.Deactivate` (repo/user.go:30) via gorm",
+			"**Schema changes** (1):",
+		} {
+			assertContains(t, result.Text, want)
+		}
+		if strings.Contains(result.Text, "orders") {
+			t.Errorf("orders should be filtered out:\n%s", result.Text)
+		}
+	})
+
+	t.Run("function", func(t *testing.T) {
+		client := tablesMock(t, `regex_matches(name, "(?i)Deactivate")`, `regex_matches(file_path, ___"^repo/"___)`)
+		result, err := Tables(ctx, client, TablesArgs{Function: "Deactivate", PathPattern: "^repo/"})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "## Tables used by functions matching 'Deactivate'")
+		assertContains(t, result.Text, "- update `users` (model `User`) at line 30 via gorm")
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		result, err := Tables(ctx, tablesMock(t), TablesArgs{Table: "invoices"})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "No function uses a table matching 'invoices'.")
+	})
+
+	t.Run("old index", func(t *testing.T) {
+		client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+			return nil, errors.New("relation cie_table_ref not found")
+		}, nil)
+		result, err := Tables(ctx, client, TablesArgs{})
+		assertNoError(t, err)
+		if !result.IsError {
+			t.Fatal("expected an error result")
+		}
+		assertContains(t, result.Text, "cie index")
+	})
+}
@@ -0,0 +1,251 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// TemplatesArgs holds arguments for the template lookup.
+type TemplatesArgs struct {
+	Template string // optional: substring of the template path
+	Function string // optional: substring of a function name; lists the templates it renders
+	Limit    int    // templates to show (default 20)
+}
+
+// templateInfo is an indexed template with the names it can be rendered by.
+type templateInfo struct {
+	ID      string
+	Path    string
+	Dialect string
+	Defines []string // {{define}} / macro names, which Go's ExecuteTemplate also accepts
+}
+
+// renderSite is a function call that renders a template by name.
+type renderSite struct {
+	Function string
+	FilePath string
+	Line     string
+	Name     string
+}
+
+// Templates shows indexed templates (Go templates, Jinja, ERB): the variables,
+// blocks and templates each one references, and the functions that render it.
+// With a function, it lists the templates that function renders instead.
+// Render calls store the template name as written, so they are matched to
+// template files here: by exact path, path suffix or defined template name.
+func Templates(ctx context.Context, client Querier, args TemplatesArgs) (*ToolResult, error) {
+	if args.Limit <= 0 {
+		args.Limit = 20
+	}
+
+	templates, err := loadTemplates(ctx, client)
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v (indexes built before cie_template need 'cie index')", err)), nil
+	}
+	if len(templates) == 0 {
+		return NewResult("No templates indexed. Go templates (.tmpl, .gohtml, or .html with {{ }}), Jinja (.jinja, .j2, or .html with {% %}) and ERB (.erb) files are indexed."), nil
+	}
+
+	renderFilter := ""
+	if args.Function != "" {
+		renderFilter = fmt.Sprintf(", regex_matches(name, %q)", "(?i)"+EscapeRegex(args.Function))
+	}
+	renders, err := client.Query(ctx, fmt.Sprintf(
+		"?[name, file_path, line, template_name] := *cie_renders { function_id, file_path, line, template_name }, *cie_function { id: function_id, name }%s :order file_path, line :limit 2000",
+		renderFilter))
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v", err)), nil
+	}
+	var sites []renderSite
+	for _, r := range renders.Rows {
+		if len(r) >= 4 {
+			sites = append(sites, renderSite{Function: AnyToString(r[0]), FilePath: AnyToString(r[1]), Line: AnyToString(r[2]), Name: AnyToString(r[3])})
+		}
+	}
+
+	if args.Function != "" {
+		return NewResult(formatRenderedTemplates(args.Function, sites, templates)), nil
+	}
+
+	var selected []templateInfo
+	for _, t := range templates {
+		if args.Template == "" || strings.Contains(strings.ToLower(t.Path), strings.ToLower(args.Template)) {
+			selected = append(selected, t)
+		}
+	}
+	if len(selected) == 0 {
+		return NewResult(fmt.Sprintf("No template path contains '%s'.", args.Template)), nil
+	}
+	if args.Template == "" || len(selected) > 3 {
+		return NewResult(formatTemplateList(selected, sites, args.Limit)), nil
+	}
+
+	var sb strings.Builder
+	for _, t := range selected {
+		formatTemplateDetail(ctx, client, &sb, t, sites)
+	}
+	return NewResult(sb.String()), nil
+}
+
+// loadTemplates reads every template with its defined names.
+func loadTemplates(ctx context.Context, client Querier) ([]templateInfo, error) {
+	result, err := client.Query(ctx, "?[id, file_path, dialect] := *cie_template { id, file_path, dialect } :order file_path")
+	if err != nil {
+		return nil, err
+	}
+	templates := make([]templateInfo, 0, len(result.Rows))
+	byID := make(map[string]int)
+	for _, r := range result.Rows {
+		if len(r) < 3 {
+			continue
+		}
+		byID[AnyToString(r[0])] = len(templates)
+		templates = append(templates, templateInfo{ID: AnyToString(r[0]), Path: AnyToString(r[1]), Dialect: AnyToString(r[2])})
+	}
+	defines, err := client.Query(ctx, `?[template_id, name] := *cie_template_ref { template_id, kind, name }, kind = "define"`)
+	if err == nil {
+		for _, r := range defines.Rows {
+			if i, ok := byID[AnyToString(r[0])]; ok && len(r) > 1 {
+				templates[i].Defines = append(templates[i].Defines, AnyToString(r[1]))
+			}
+		}
+	}
+	return templates, nil
+}
+
+// rendersTemplate reports whether a render call naming name reaches t.
+func rendersTemplate(name string, t templateInfo) bool {
+	name = strings.TrimPrefix(name, "./")
+	if t.Path == name || strings.HasSuffix(t.Path, "/"+name) {
+		return true
+	}
+	for _, d := range t.Defines {
+		if d == name {
+			return true
+		}
+	}
+	// ERB partials: render "shared/header" → shared/_header.html.erb
+	if t.Dialect == "erb" {
+		dir, base := path.Split(name)
+		return strings.Contains(t.Path, "/"+dir+"_"+base+".") || strings.Contains(t.Path, "/"+name+".")
+	}
+	return false
+}
+
+func formatTemplateList(templates []templateInfo, sites []renderSite, limit int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## Templates (%d)\n\n| Template | Dialect | Rendered by |\n|----------|---------|-------------|\n", len(templates))
+	for i, t := range templates {
+		if i == limit {
+			fmt.Fprintf(&sb, "\n_%d more; pass `template` to narrow down._\n", len(templates)-limit)
+			break
+		}
+		renderers := make(map[string]bool)
+		for _, s := range sites {
+			if rendersTemplate(s.Name, t) {
+				renderers[s.Function] = true
+			}
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %s |\n", t.Path, t.Dialect, joinSorted(renderers, "—"))
+	}
+	return sb.String()
+}
+
+func formatTemplateDetail(ctx context.Context, client Querier, sb *strings.Builder, t templateInfo, sites []renderSite) {
+	fmt.Fprintf(sb, "## %s (%s)\n\n", t.Path, t.Dialect)
+
+	sb.WriteString("**Rendered by**:")
+	found := false
+	for _, s := range sites {
+		if rendersTemplate(s.Name, t) {
+			fmt.Fprintf(sb, "\n- `%s` (%s:%s) as \"%s\"", s.Function, s.FilePath, s.Line, s.Name)
+			found = true
+		}
+	}
+	if !found {
+		sb.WriteString(" no render call found (it may be included by another template, or named dynamically)")
+	}
+	sb.WriteString("\n")
+
+	refs, err := client.Query(ctx, fmt.Sprintf("?[kind, name, line] := *cie_template_ref { template_id, kind, name, line }, template_id = %q :order line", t.ID))
+	if err != nil || len(refs.Rows) == 0 {
+		sb.WriteString("\nNo references extracted.\n\n")
+		return
+	}
+	byKind := make(map[string][]string)
+	for _, r := range refs.Rows {
+		if len(r) >= 3 {
+			kind := AnyToString(r[0])
+			byKind[kind] = append(byKind[kind], fmt.Sprintf("`%s` (line %s)", AnyToString(r[1]), AnyToString(r[2])))
+		}
+	}
+	for _, section := range []struct{ kind, title string }{
+		{"extends", "Extends"},
+		{"include", "Includes"},
+		{"define", "Defines"},
+		{"block", "Blocks"},
+		{"variable", "Variables"},
+	} {
+		if items := byKind[section.kind]; len(items) > 0 {
+			fmt.Fprintf(sb, "\n**%s**: %s\n", section.title, strings.Join(items, ", "))
+		}
+	}
+	sb.WriteString("\n")
+}
+
+func formatRenderedTemplates(function string, sites []renderSite, templates []templateInfo) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## Templates rendered by functions matching '%s'\n\n", function)
+	if len(sites) == 0 {
+		sb.WriteString("No render calls found.\n")
+		return sb.String()
+	}
+	for _, s := range sites {
+		var matches []string
+		for _, t := range templates {
+			if rendersTemplate(s.Name, t) {
+				matches = append(matches, t.Path)
+			}
+		}
+		target := "not indexed"
+		if len(matches) > 0 {
+			target = strings.Join(matches, ", ")
+		}
+		fmt.Fprintf(&sb, "- `%s` (%s:%s) renders \"%s\" → %s\n", s.Function, s.FilePath, s.Line, s.Name, target)
+	}
+	return sb.String()
+}
+
+// joinSorted joins the keys of set in order, or returns empty when there are none.
+func joinSorted(set map[string]bool, empty string) string {
+	if len(set) == 0 {
+		return empty
+	}
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, "`"+k+"`")
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"testing"
+)
+
+func templatesMock(t *testing.T) Querier {
+	renders := [][]any{
+		{"ListUsers", "web/users.go", float64(20), "users/list"},
+		{"index", "app/views.py", float64(5), "base.html"},
+		{"HeaderComponent.render", "app/header.rb", float64(3), "shared/header"},
+	}
+	rendersWant := []string{"*cie_renders { function_id, file_path, line, template_name }", "*cie_function { id: function_id, name }"}
+	return NewMockClientScripted(t,
+		MockQuery{
+			Match: []string{"?[id, file_path, dialect]"},
+			Want:  []string{"*cie_template { id, file_path, dialect }"},
+			Rows: [][]any{
+				{"tpl:1", "web/templates/users.gohtml", "go"},
+				{"tpl:2", "app/templates/base.html", "jinja"},
+				{"tpl:3", "app/views/shared/_header.html.erb", "erb"},
+			},
+		},
+		MockQuery{
+			Match: []string{"?[template_id, name]"},
+			Want:  []string{"*cie_template_ref { template_id, kind, name }", `kind = "define"`},
+			Rows:  [][]any{{"tpl:1", "users/list"}},
+		},
+		MockQuery{
+			Match: []string{"?[name, file_path, line, template_name]", "regex_matches(name"},
+			Want:  append([]string{`regex_matches(name, "(?i)listusers")`}, rendersWant...),
+			Rows:  renders[:1],
+		},
+		MockQuery{
+			Match: []string{"?[name, file_path, line, template_name]"},
+			Want:  rendersWant,
+			Rows:  renders,
+		},
+		MockQuery{
+			Match: []string{"?[kind, name, line]"},
+			Want:  []string{"*cie_template_ref {", `template_id = "tpl:1"`},
+			Rows: [][]any{
+				{"define", "users/list", float64(1)},
+				{"variable", "Users", float64(3)},
+				{"variable", "Name", float64(3)},
+			},
+		},
+	)
+}
+
+func TestTemplates(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("list", func(t *testing.T) {
+		result, err := Templates(ctx, templatesMock(t), TemplatesArgs{})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "## Templates (3)")
+		assertContains(t, result.Text, "| web/templates/users.gohtml | go | `ListUsers` |")
+		assertContains(t, result.Text, "| app/templates/base.html | jinja | `index` |")
+		assertContains(t, result.Text, "| app/views/shared/_header.html.erb | erb | `HeaderComponent.render` |")
+	})
+
+	t.Run("detail", func(t *testing.T) {
+		result, err := Templates(ctx, templatesMock(t), TemplatesArgs{Template: "users"})
+		assertNoError(t, err)
+		for _, want := range []string{
+			"## web/templates/users.gohtml (go)",
+			"- `ListUsers` (web/users.go:20) as \"users/list\"",
+			"**Defines**: `users/list` (line 1)",
+			"**Variables**: `Users` (line 3), `Name` (line 3)",
+		} {
+			assertContains(t, result.Text, want)
+		}
+	})
+
+	t.Run("by function", func(t *testing.T) {
+		result, err := Templates(ctx, templatesMock(t), TemplatesArgs{Function: "listusers"})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "- `ListUsers` (web/users.go:20) renders \"users/list\" → web/templates/users.gohtml")
+	})
+}
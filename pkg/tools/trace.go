@@ -48,7 +48,7 @@ type TracePathArgs struct {
 // If waypoints are specified, chains BFS segments through each waypoint in order.
 func TracePath(ctx context.Context, client Querier, args TracePathArgs) (*ToolResult, error) {
 	if args.Target == "" {
-		return NewError("Error: 'target' function name is required"), nil
+		return NewInputError("Error: 'target' function name is required"), nil
 	}
 
 	// If waypoints are provided, use segmented tracing
@@ -402,6 +402,7 @@ func formatTraceOutput(sources []TraceFuncInfo, args TracePathArgs, result trace
 }
 
 // detectEntryPoints finds entry point functions based on language conventions
+// and the entry points recorded at index time (cie_entry_point)
 func detectEntryPoints(ctx context.Context, client Querier, pathPattern string) []TraceFuncInfo {
 	var results []TraceFuncInfo
 
@@ -453,7 +454,56 @@ func detectEntryPoints(ctx context.Context, client Querier, pathPattern string)
 		}
 	}
 
-	return results
+	// Entry points detected at index time: commands, servers, Lambda
+	// handlers and scheduled jobs. Older indexes lack the relation.
+	pathFilter := ""
+	if pathPattern != "" {
+		pathFilter = fmt.Sprintf(", regex_matches(file_path, %q)", pathPattern)
+	}
+	script := fmt.Sprintf(
+		"?[name, file_path, start_line] := *cie_entry_point { function_id }, *cie_function { id: function_id, name, file_path, start_line }%s :limit 50",
+		pathFilter,
+	)
+	if result, err := client.Query(ctx, script); err == nil {
+		for _, row := range result.Rows {
+			results = append(results, TraceFuncInfo{
+				Name:     AnyToString(row[0]),
+				FilePath: AnyToString(row[1]),
+				Line:     AnyToString(row[2]),
+			})
+		}
+	}
+
+	return dedupeTraceFuncs(results)
+}
+
+// dedupeTraceFuncs drops repeated functions, keeping the first occurrence.
+func dedupeTraceFuncs(funcs []TraceFuncInfo) []TraceFuncInfo {
+	seen := make(map[string]bool, len(funcs))
+	out := funcs[:0]
+	for _, f := range funcs {
+		key := f.FilePath + ":" + f.Line + ":" + f.Name
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, f)
+	}
+	return out
+}
+
+// indexedEntryPointIDs returns the IDs of the functions recorded in
+// cie_entry_point, or nil if the index has none (or predates the relation).
+func indexedEntryPointIDs(ctx context.Context, client Querier) map[string]bool {
+	result, err := client.Query(ctx, "?[function_id] := *cie_entry_point { function_id }")
+	if err != nil || len(result.Rows) == 0 {
+		return nil
+	}
+	ids := make(map[string]bool, len(result.Rows))
+	for _, row := range result.Rows {
+		ids[AnyToString(row[0])] = true
+	}
+	return ids
 }
 
 // findFunctionsByName finds functions matching a name pattern
@@ -601,6 +651,53 @@ func getCallees(ctx context.Context, client Querier, funcName string) []TraceFun
 	paramCallees := getCalleesViaParams(ctx, client, funcName, seen, directCalleeNames)
 	ret = append(ret, paramCallees...)
 
+	// 4. Cross-language RPC links (cie_rpc_link)
+	// A gRPC client call leads to the .proto RPC, and the RPC to its server
+	// implementations, so traces continue from a TypeScript or Python client
+	// into a Go server.
+	ret = append(ret, getRPCCallees(ctx, client, funcName, seen)...)
+
+	return ret
+}
+
+// getRPCCallees follows cie_rpc_link edges: from a function calling RPCs
+// through a generated client to the .proto RPCs, and from a .proto RPC to
+// the functions implementing it. Indexes without cie_rpc_link return nothing.
+func getRPCCallees(ctx context.Context, client Querier, funcName string, seen map[string]bool) []TraceFuncInfo {
+	script := fmt.Sprintf(
+		`?[callee_name, callee_file, callee_line] :=
+			*cie_function { id: caller_id, name: caller_name },
+			(caller_name = %q or ends_with(caller_name, %q)),
+			*cie_rpc_link { function_id: caller_id, rpc, role: "client" },
+			*cie_function { name: rpc, file_path: callee_file, start_line: callee_line },
+			ends_with(callee_file, ".proto"),
+			callee_name = rpc
+		?[callee_name, callee_file, callee_line] :=
+			*cie_rpc_link { rpc: %q, role: "server", function_id },
+			*cie_function { id: function_id, name: callee_name, file_path: callee_file, start_line: callee_line }
+		:limit 50`,
+		funcName, "."+funcName, funcName,
+	)
+	result, err := client.Query(ctx, script)
+	if err != nil {
+		return nil
+	}
+	var ret []TraceFuncInfo
+	for _, row := range result.Rows {
+		if len(row) < 3 {
+			continue
+		}
+		name := AnyToString(row[0])
+		if name == funcName || seen[name] {
+			continue
+		}
+		seen[name] = true
+		ret = append(ret, TraceFuncInfo{
+			Name:     name,
+			FilePath: AnyToString(row[1]),
+			Line:     AnyToString(row[2]),
+		})
+	}
 	return ret
 }
 
@@ -693,6 +693,33 @@ func TestDetectEntryPoints_Unit_ExcludeTestFiles(t *testing.T) {
 	_ = sources
 }
 
+// Test detectEntryPoints adds the entry points recorded at index time
+func TestDetectEntryPoints_Unit_IndexedEntryPoints(t *testing.T) {
+	client := NewMockClientCustom(
+		func(ctx context.Context, script string) (*QueryResult, error) {
+			rows := [][]any{}
+			switch {
+			case strings.Contains(script, "cie_entry_point"):
+				rows = [][]any{{"runServe", "cmd/cli/serve.go", 12}, {"main", "cmd/cli/main.go", 1}}
+			case strings.Contains(script, "[.]go"):
+				rows = [][]any{{"main", "cmd/cli/main.go", 1}}
+			}
+			return &QueryResult{Headers: []string{"name", "file_path", "start_line"}, Rows: rows}, nil
+		},
+		nil,
+	)
+
+	sources := detectEntryPoints(context.Background(), client, "")
+
+	var names []string
+	for _, s := range sources {
+		names = append(names, s.Name)
+	}
+	if strings.Join(names, ",") != "main,runServe" {
+		t.Errorf("detectEntryPoints() = %v, want [main runServe]", names)
+	}
+}
+
 // Test TracePath with auto-detect entry points (no source specified)
 func TestTracePath_Unit_AutoDetectEntryPoints(t *testing.T) {
 	functions := map[string]TraceFuncInfo{
@@ -994,10 +1021,10 @@ func TestGetCallees_InterfaceDispatch_NonMethod(t *testing.T) {
 
 	_ = getCallees(ctx, client, "main") // plain function, not a method
 
-	// Should issue: 1) cie_calls query, 2) signature query for param dispatch
-	// (no field dispatch since main is not a method)
-	if queryCalls != 2 {
-		t.Errorf("getCallees(\"main\") issued %d queries, want 2 (cie_calls + signature lookup)", queryCalls)
+	// Should issue: 1) cie_calls query, 2) signature query for param dispatch,
+	// 3) RPC link query (no field dispatch since main is not a method)
+	if queryCalls != 3 {
+		t.Errorf("getCallees(\"main\") issued %d queries, want 3 (cie_calls + signature lookup + RPC links)", queryCalls)
 	}
 }
 
@@ -1436,3 +1463,62 @@ func TestFindFunctionsByName_CaseInsensitive(t *testing.T) {
 // Note: Integration tests have been moved to trace_integration_test.go with //go:build cozodb tag.
 // This allows unit tests to run without CozoDB while keeping integration tests for e2e validation.
 // To run integration tests: go test -tags=cozodb ./modules/cie/pkg/tools -run TestTrace
+
+// Test that TracePath crosses from a client call through the .proto RPC into
+// the server implementation via cie_rpc_link
+func TestTracePath_Unit_CrossesRPCBoundary(t *testing.T) {
+	functions := map[string]TraceFuncInfo{
+		"loadUser":            {Name: "loadUser", FilePath: "web/src/api.ts", Line: "5"},
+		"UserService.GetUser": {Name: "UserService.GetUser", FilePath: "api/users.proto", Line: "12"},
+		"userServer.GetUser":  {Name: "userServer.GetUser", FilePath: "internal/server/users.go", Line: "8"},
+		"Store.Find":          {Name: "Store.Find", FilePath: "internal/store/store.go", Line: "20"},
+	}
+	rpcEdges := map[string]string{
+		"loadUser":            "UserService.GetUser",
+		"UserService.GetUser": "userServer.GetUser",
+	}
+	client := NewMockClientCustom(
+		func(ctx context.Context, script string) (*QueryResult, error) {
+			switch {
+			case strings.Contains(script, "cie_rpc_link"):
+				for caller, callee := range rpcEdges {
+					if strings.Contains(script, fmt.Sprintf("%q", caller)) {
+						return mockTraceCalleesResult(functions[callee]), nil
+					}
+				}
+				return mockTraceCalleesResult(), nil
+			case strings.Contains(script, "cie_calls"):
+				if strings.Contains(script, `"userServer.GetUser"`) {
+					return mockTraceCalleesResult(functions["Store.Find"]), nil
+				}
+				return mockTraceCalleesResult(), nil
+			case strings.Contains(script, "regex_matches"):
+				for name, fn := range functions {
+					if strings.Contains(script, EscapeRegex(name)) {
+						return mockTraceFunctionResult(fn), nil
+					}
+				}
+			}
+			return &QueryResult{Headers: []string{}, Rows: [][]any{}}, nil
+		},
+		nil,
+	)
+
+	result, err := TracePath(context.Background(), client, TracePathArgs{
+		Source:   "loadUser",
+		Target:   "Store.Find",
+		MaxPaths: 1,
+		MaxDepth: 10,
+	})
+	if err != nil {
+		t.Fatalf("TracePath() error = %v", err)
+	}
+	if strings.Contains(result.Text, "No path found") {
+		t.Fatalf("TracePath() should cross the RPC boundary, got:\n%s", result.Text)
+	}
+	for _, want := range []string{"loadUser", "UserService.GetUser", "userServer.GetUser", "Store.Find"} {
+		if !strings.Contains(result.Text, want) {
+			t.Errorf("TracePath() path should include %s, got:\n%s", want, result.Text)
+		}
+	}
+}
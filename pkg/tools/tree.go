@@ -0,0 +1,199 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TreeArgs holds arguments for the directory tree overview.
+type TreeArgs struct {
+	Path  string // root directory ("" for the whole repository)
+	Depth int    // directory levels below Path to expand (default 3)
+}
+
+// maxTreeFiles caps the files a tree query reads.
+const maxTreeFiles = 50000
+
+// treeNode aggregates everything indexed at or below one directory.
+type treeNode struct {
+	name      string
+	files     int
+	functions int
+	languages map[string]int
+	tests     int
+	generated int
+	children  map[string]*treeNode
+}
+
+func newTreeNode(name string) *treeNode {
+	return &treeNode{name: name, languages: map[string]int{}, children: map[string]*treeNode{}}
+}
+
+// Tree renders the indexed directory tree with per-directory file and
+// function counts, the dominant language and the test/generated share.
+// Directories deeper than args.Depth are folded into their ancestors.
+func Tree(ctx context.Context, client Querier, args TreeArgs) (*ToolResult, error) {
+	root := strings.Trim(args.Path, "/")
+	if root == "." {
+		root = ""
+	}
+	if args.Depth <= 0 {
+		args.Depth = 3
+	}
+
+	filter := ""
+	if root != "" {
+		filter = fmt.Sprintf(", starts_with(path, %q)", root+"/")
+	}
+	files, err := client.Query(ctx, fmt.Sprintf("?[path, language] := *cie_file { path, language }%s :limit %d", filter, maxTreeFiles))
+	if err != nil {
+		return nil, fmt.Errorf("tree files query: %w", err)
+	}
+	if len(files.Rows) == 0 {
+		if root == "" {
+			return NewResult("No files indexed. Run `cie index` first."), nil
+		}
+		return NewResult(fmt.Sprintf("No indexed files under `%s`\n\nUse `cie_tree` without a path to see the top-level layout.", root)), nil
+	}
+
+	funcFilter := ""
+	if root != "" {
+		funcFilter = fmt.Sprintf(", starts_with(file_path, %q)", root+"/")
+	}
+	funcCounts := map[string]int{}
+	if counts, err := client.Query(ctx, fmt.Sprintf("?[file_path, count(id)] := *cie_function { id, file_path }%s", funcFilter)); err == nil {
+		for _, row := range counts.Rows {
+			funcCounts[AnyToString(row[0])] = int(toFloat64(row[1]))
+		}
+	}
+
+	top := newTreeNode(root)
+	for _, row := range files.Rows {
+		path := AnyToString(row[0])
+		lang := AnyToString(row[1])
+		if lang == "" {
+			lang = detectLanguage(path)
+		}
+		rel := strings.TrimPrefix(path, root+"/")
+		if root == "" {
+			rel = path
+		}
+		dirs := strings.Split(rel, "/")
+		dirs = dirs[:len(dirs)-1]
+		if len(dirs) > args.Depth {
+			dirs = dirs[:args.Depth]
+		}
+
+		node := top
+		top.add(path, lang, funcCounts[path])
+		for _, dir := range dirs {
+			child, ok := node.children[dir]
+			if !ok {
+				child = newTreeNode(dir)
+				node.children[dir] = child
+			}
+			child.add(path, lang, funcCounts[path])
+			node = child
+		}
+	}
+
+	var sb strings.Builder
+	label := root
+	if label == "" {
+		label = "."
+	}
+	fmt.Fprintf(&sb, "# Tree: `%s` (depth %d)\n\n```\n", label, args.Depth)
+	fmt.Fprintf(&sb, "%s/  %s\n", label, top.stats())
+	top.render(&sb, "")
+	sb.WriteString("```\n\nUse `cie_directory_summary` on a directory for its files and key functions.\n")
+	return NewResult(sb.String()), nil
+}
+
+func (n *treeNode) add(path, lang string, functions int) {
+	n.files++
+	n.functions += functions
+	if lang != "" && lang != "unknown" {
+		n.languages[lang]++
+	}
+	switch {
+	case testFilePattern.MatchString(path):
+		n.tests++
+	case generatedFilePattern.MatchString(path):
+		n.generated++
+	}
+}
+
+// stats formats "12 files, 140 funcs, go 92%, 25% test".
+func (n *treeNode) stats() string {
+	parts := []string{fmt.Sprintf("%d files, %d funcs", n.files, n.functions)}
+	if lang, count := n.dominantLanguage(); lang != "" {
+		parts = append(parts, fmt.Sprintf("%s %d%%", lang, percent(count, n.files)))
+	}
+	if n.tests > 0 {
+		parts = append(parts, fmt.Sprintf("%d%% test", percent(n.tests, n.files)))
+	}
+	if n.generated > 0 {
+		parts = append(parts, fmt.Sprintf("%d%% generated", percent(n.generated, n.files)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (n *treeNode) dominantLanguage() (string, int) {
+	best, bestCount := "", 0
+	for lang, count := range n.languages {
+		if count > bestCount || count == bestCount && lang < best {
+			best, bestCount = lang, count
+		}
+	}
+	return best, bestCount
+}
+
+// render writes the children of n, largest first, with box-drawing prefixes.
+func (n *treeNode) render(sb *strings.Builder, indent string) {
+	children := make([]*treeNode, 0, len(n.children))
+	for _, child := range n.children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		if children[i].files != children[j].files {
+			return children[i].files > children[j].files
+		}
+		return children[i].name < children[j].name
+	})
+
+	for i, child := range children {
+		branch, next := "├── ", "│   "
+		if i == len(children)-1 {
+			branch, next = "└── ", "    "
+		}
+		fmt.Fprintf(sb, "%s%s%s/  %s\n", indent, branch, child.name, child.stats())
+		child.render(sb, indent+next)
+	}
+}
+
+func percent(part, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return part * 100 / total
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func treeClient(files [][]any, funcs [][]any) *MockCIEClient {
+	return NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		if strings.Contains(script, "cie_function") {
+			return NewMockQueryResult([]string{"file_path", "count"}, funcs), nil
+		}
+		return NewMockQueryResult([]string{"path", "language"}, files), nil
+	}, nil)
+}
+
+func TestTree(t *testing.T) {
+	ctx := setupTest(t)
+	client := treeClient(
+		[][]any{
+			{"main.go", "go"},
+			{"pkg/tools/grep.go", "go"},
+			{"pkg/tools/grep_test.go", "go"},
+			{"pkg/tools/testdata/deep/x.py", "python"},
+			{"pkg/storage/db.go", "go"},
+			{"api/v1/api.pb.go", "go"},
+		},
+		[][]any{
+			{"main.go", int64(2)},
+			{"pkg/tools/grep.go", int64(10)},
+			{"pkg/tools/grep_test.go", int64(5)},
+			{"pkg/storage/db.go", int64(4)},
+		},
+	)
+
+	result, err := Tree(ctx, client, TreeArgs{Depth: 2})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "./  6 files, 21 funcs, go 83%, 16% test, 16% generated")
+	assertContains(t, result.Text, "├── pkg/  4 files, 19 funcs")
+	assertContains(t, result.Text, "│   ├── tools/  3 files, 15 funcs, go 66%, 33% test")
+	assertContains(t, result.Text, "└── api/  1 files, 0 funcs, go 100%, 100% generated")
+	if strings.Contains(result.Text, "testdata/") {
+		t.Error("directories below the depth limit should be folded into their parent")
+	}
+}
+
+func TestTree_Path(t *testing.T) {
+	ctx := setupTest(t)
+	var scripts []string
+	client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		scripts = append(scripts, script)
+		return NewMockQueryResult([]string{"path", "language"}, nil), nil
+	}, nil)
+
+	result, err := Tree(ctx, client, TreeArgs{Path: "pkg/missing/"})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "No indexed files under `pkg/missing`")
+	assertContains(t, scripts[0], `starts_with(path, "pkg/missing/")`)
+}
@@ -0,0 +1,208 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// TypeAPIArgs holds arguments for listing a type's methods.
+type TypeAPIArgs struct {
+	TypeName string
+	FilePath string // optional: picks one type when the name is defined in several files
+}
+
+// maxTypeMethods caps the methods listed for a type.
+const maxTypeMethods = 500
+
+// typeMethod is a method linked to a type through cie_method_of.
+type typeMethod struct {
+	Name      string
+	Signature string
+	FilePath  string
+	StartLine string
+	Code      string
+}
+
+// TypeAPI lists the full method surface of a type, grouped by the file each
+// method is declared in, along with the interfaces the type implements.
+func TypeAPI(ctx context.Context, client Querier, args TypeAPIArgs) (*ToolResult, error) {
+	name := strings.TrimSpace(args.TypeName)
+	if name == "" {
+		return NewInputError("Error: 'type_name' is required"), nil
+	}
+
+	types, err := client.Query(ctx, typeLookupScript(name, args.FilePath, false))
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v", err)), nil
+	}
+	if len(types.Rows) == 0 {
+		return NewResult(fmt.Sprintf("Type '%s' not found.", name)), nil
+	}
+	if files := distinctColumn(types.Rows, 3); len(files) > 1 {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Type '%s' is defined in %d files. Call again with one of these as `file_path`:\n\n", name, len(files))
+		for _, f := range files {
+			fmt.Fprintf(&sb, "- %s\n", f)
+		}
+		return NewResult(sb.String()), nil
+	}
+
+	row := types.Rows[0]
+	typeID, kind, typeFile := AnyToString(row[0]), AnyToString(row[2]), AnyToString(row[3])
+	methods, err := typeMethods(ctx, client, typeID, name, typeFile, false)
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v (indexes built before cie_method_of need 'cie index')", err)), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## %s (%s)\n\n", name, kind)
+	fmt.Fprintf(&sb, "**Defined in**: %s:%s-%s\n", typeFile, AnyToString(row[4]), AnyToString(row[5]))
+	if ifaces := typeInterfaces(ctx, client, name); len(ifaces) > 0 {
+		fmt.Fprintf(&sb, "**Implements**: %s\n", strings.Join(ifaces, ", "))
+	}
+	if source := typeGeneratedFrom(ctx, client, typeID); source != "" {
+		fmt.Fprintf(&sb, "**Generated from**: %s\n", source)
+	}
+	if len(methods) == 0 {
+		sb.WriteString("\nNo methods indexed for this type.\n")
+		return NewResult(sb.String()), nil
+	}
+
+	byFile := make(map[string][]typeMethod)
+	for _, m := range methods {
+		byFile[m.FilePath] = append(byFile[m.FilePath], m)
+	}
+	fmt.Fprintf(&sb, "**Methods**: %d in %d file(s)\n", len(methods), len(byFile))
+	for _, file := range sortedKeys(byFile) {
+		fmt.Fprintf(&sb, "\n### %s\n\n", file)
+		for _, m := range byFile[file] {
+			fmt.Fprintf(&sb, "- `%s` (line %s)", methodShortName(m.Name, name), m.StartLine)
+			if m.Signature != "" {
+				fmt.Fprintf(&sb, " — `%s`", m.Signature)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	if len(methods) == maxTypeMethods {
+		fmt.Fprintf(&sb, "\n⚠️ Only the first %d methods are shown.\n", maxTypeMethods)
+	}
+	return NewResult(sb.String()), nil
+}
+
+// typeLookupScript finds types named name, optionally in filePath, as rows
+// of [id, name, kind, file_path, start_line, end_line] plus code_text when
+// withCode is set.
+func typeLookupScript(name, filePath string, withCode bool) string {
+	head, code := "?[id, name, kind, file_path, start_line, end_line]", ""
+	if withCode {
+		head, code = "?[id, name, kind, file_path, start_line, end_line, code_text]", ", *cie_type_code { type_id: id, code_text }"
+	}
+	cond := fmt.Sprintf("name == %q", name)
+	if filePath != "" {
+		cond += fmt.Sprintf(", file_path == %q", filePath)
+	}
+	return fmt.Sprintf("%s := *cie_type { id, name, kind, file_path, start_line, end_line }%s, %s :limit 20", head, code, cond)
+}
+
+// typeMethods returns the methods of a type, ordered by file and line. Go
+// methods are matched by receiver name within the type's package
+// directory, since they may live in files other than the type's; methods
+// of other languages are matched by type ID.
+func typeMethods(ctx context.Context, client Querier, typeID, typeName, typeFile string, withCode bool) ([]typeMethod, error) {
+	cond := fmt.Sprintf("type_id = %q", typeID)
+	if detectLanguage(typeFile) == "go" {
+		dirPattern := "^[^/]+$"
+		if dir := path.Dir(typeFile); dir != "." {
+			dirPattern = "^" + EscapeRegex(dir) + "/[^/]+$"
+		}
+		cond = fmt.Sprintf("type_name = %q, regex_matches(file_path, %q)", typeName, dirPattern)
+	}
+	head, code := "?[name, signature, file_path, start_line]", ""
+	if withCode {
+		head, code = "?[name, signature, file_path, start_line, code_text]", ", *cie_function_code { function_id: method_id, code_text }"
+	}
+	script := fmt.Sprintf("%s := *cie_method_of { method_id, type_id, type_name, file_path }, %s, *cie_function { id: method_id, name, signature, file_path, start_line }%s :order file_path, start_line :limit %d",
+		head, cond, code, maxTypeMethods)
+
+	result, err := client.Query(ctx, script)
+	if err != nil {
+		return nil, err
+	}
+	methods := make([]typeMethod, 0, len(result.Rows))
+	for _, r := range result.Rows {
+		if len(r) < 4 {
+			continue
+		}
+		m := typeMethod{Name: AnyToString(r[0]), Signature: AnyToString(r[1]), FilePath: AnyToString(r[2]), StartLine: AnyToString(r[3])}
+		if withCode && len(r) > 4 {
+			m.Code = decodeCodeText(r[4])
+		}
+		methods = append(methods, m)
+	}
+	return methods, nil
+}
+
+// typeInterfaces lists the interfaces a type implements, or nil.
+func typeInterfaces(ctx context.Context, client Querier, typeName string) []string {
+	script := fmt.Sprintf("?[interface_name] := *cie_implements { type_name, interface_name }, type_name = %q", typeName)
+	result, err := client.Query(ctx, script)
+	if err != nil {
+		return nil
+	}
+	return distinctColumn(result.Rows, 0)
+}
+
+// typeGeneratedFrom returns the .proto message or enum a generated type comes
+// from, or the interface a generated mock implements, as "Name (file:line)",
+// or "" when it is not generated code.
+func typeGeneratedFrom(ctx context.Context, client Querier, typeID string) string {
+	script := fmt.Sprintf("?[name, file_path, start_line] := *cie_generated_from { type_id, proto_type_id }, type_id = %q, *cie_type { id: proto_type_id, name, file_path, start_line } :limit 1", typeID)
+	result, err := client.Query(ctx, script)
+	if err != nil || len(result.Rows) == 0 || len(result.Rows[0]) < 3 {
+		return ""
+	}
+	r := result.Rows[0]
+	return fmt.Sprintf("%s (%s:%s)", AnyToString(r[0]), AnyToString(r[1]), AnyToString(r[2]))
+}
+
+// distinctColumn returns the sorted distinct values of column col.
+func distinctColumn(rows [][]any, col int) []string {
+	seen := make(map[string]bool)
+	for _, r := range rows {
+		if len(r) > col {
+			seen[AnyToString(r[col])] = true
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for v := range seen {
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// methodShortName strips the "Type." prefix from a method name.
+func methodShortName(name, typeName string) string {
+	return strings.TrimPrefix(name, typeName+".")
+}
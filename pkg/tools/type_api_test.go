@@ -0,0 +1,134 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// typeAPIMock answers the type, method, implements and generated_from queries
+// of TypeAPI, with typeRows for the lookup of Server.
+func typeAPIMock(t *testing.T, typeRows [][]any) Querier {
+	methods := [][]any{
+		{"Server.Start", "func (s *Server) Start() error", "api/server.go", 10},
+		{"Server.Stop", "func (s *Server) Stop()", "api/server.go", 30},
+		{"Server.ServeHTTP", "func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request)", "api/http.go", 5},
+	}
+	withCode := make([][]any, len(methods))
+	for i, m := range methods {
+		withCode[i] = append(append([]any{}, m...), "func body")
+	}
+	// Go methods match by receiver name in the package directory.
+	methodsWant := []string{
+		"*cie_method_of { method_id, type_id, type_name, file_path }",
+		`type_name = "Server"`,
+		`regex_matches(file_path, "^api/[^/]+$")`,
+		"*cie_function { id: method_id, name, signature, file_path, start_line }",
+	}
+	return NewMockClientScripted(t,
+		MockQuery{
+			Match: []string{"*cie_method_of", "code_text"},
+			Want:  append([]string{"*cie_function_code { function_id: method_id, code_text }"}, methodsWant...),
+			Rows:  withCode,
+		},
+		MockQuery{
+			Match: []string{"*cie_method_of"},
+			Want:  methodsWant,
+			Rows:  methods,
+		},
+		MockQuery{
+			Match: []string{"*cie_generated_from", `type_id = "type:server"`},
+			Want:  []string{"*cie_type { id: proto_type_id, name, file_path, start_line }"},
+			Rows:  [][]any{{"Server", "proto/api.proto", 4}},
+		},
+		MockQuery{
+			Match: []string{"*cie_generated_from"},
+		},
+		MockQuery{
+			Match: []string{"*cie_implements"},
+			Want:  []string{"*cie_implements { type_name, interface_name }", `type_name = "Server"`},
+			Rows:  [][]any{{"Handler"}},
+		},
+		MockQuery{
+			Match: []string{"?[id, name, kind, file_path, start_line, end_line"},
+			Want:  []string{"*cie_type { id, name, kind, file_path, start_line, end_line }", `name == "Server"`},
+			Rows:  typeRows,
+		},
+	)
+}
+
+func TestTypeAPI(t *testing.T) {
+	ctx := context.Background()
+	server := []any{"type:server", "Server", "struct", "api/types.go", 3, 8, "type Server struct{}"}
+
+	t.Run("groups methods by file", func(t *testing.T) {
+		result, err := TypeAPI(ctx, typeAPIMock(t, [][]any{server}), TypeAPIArgs{TypeName: "Server"})
+		assertNoError(t, err)
+		for _, want := range []string{
+			"## Server (struct)",
+			"**Defined in**: api/types.go:3-8",
+			"**Implements**: Handler",
+			"**Generated from**: Server (proto/api.proto:4)",
+			"**Methods**: 3 in 2 file(s)",
+			"### api/http.go",
+			"- `Start` (line 10) — `func (s *Server) Start() error`",
+		} {
+			assertContains(t, result.Text, want)
+		}
+		if strings.Index(result.Text, "### api/http.go") > strings.Index(result.Text, "### api/server.go") {
+			t.Error("files should be sorted")
+		}
+	})
+
+	t.Run("ambiguous name", func(t *testing.T) {
+		other := []any{"type:other", "Server", "struct", "cmd/types.go", 1, 2, ""}
+		result, err := TypeAPI(ctx, typeAPIMock(t, [][]any{server, other}), TypeAPIArgs{TypeName: "Server"})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "defined in 2 files")
+		assertContains(t, result.Text, "- cmd/types.go")
+	})
+
+	t.Run("missing name", func(t *testing.T) {
+		result, err := TypeAPI(ctx, typeAPIMock(t, nil), TypeAPIArgs{})
+		assertNoError(t, err)
+		if !result.IsError {
+			t.Error("expected an input error")
+		}
+	})
+}
+
+func TestGetTypeCode_IncludeMethods(t *testing.T) {
+	ctx := context.Background()
+	server := []any{"type:server", "Server", "struct", "api/types.go", 3, 8, "type Server struct{}"}
+
+	result, err := GetTypeCode(ctx, typeAPIMock(t, [][]any{server}), GetTypeCodeArgs{Name: "Server", IncludeMethods: true})
+	assertNoError(t, err)
+	assertContains(t, result.Text, "type Server struct{}")
+	assertContains(t, result.Text, "#### Methods (3)")
+	assertContains(t, result.Text, "**Server.ServeHTTP** — api/http.go:5")
+
+	result, err = GetTypeCode(ctx, typeAPIMock(t, [][]any{server}), GetTypeCodeArgs{Name: "Server"})
+	assertNoError(t, err)
+	if strings.Contains(result.Text, "Methods") {
+		t.Errorf("methods should only be included on request:\n%s", result.Text)
+	}
+}
@@ -0,0 +1,361 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TypeGraphArgs holds arguments for the type dependency graph.
+type TypeGraphArgs struct {
+	TypeName  string
+	Direction string // "uses", "used_by" or "both" (default)
+	Depth     int    // levels to follow (default 2, max 5)
+}
+
+// maxTypeGraphNodes caps the types shown per direction.
+const maxTypeGraphNodes = 100
+
+// typeEdge is a reference from one type to another through fields or
+// embedding.
+type typeEdge struct {
+	Type     string
+	Fields   []string
+	Embedded bool
+}
+
+// typeGraphNode is a type in the rendered dependency tree.
+type typeGraphNode struct {
+	Edge     typeEdge
+	Children []*typeGraphNode
+	Repeat   bool // already shown higher up
+}
+
+// goEmbeddedLine matches an embedded field in a Go struct or interface body:
+// "Base", "*Base", "pb.UnimplementedUserServiceServer", with an optional tag.
+var goEmbeddedLine = regexp.MustCompile("^\\*?(?:\\w+\\.)?([A-Za-z_]\\w*)\\s*(?:`[^`]*`)?$")
+
+// protoScalarTypes are .proto field types that are not messages or enums.
+var protoScalarTypes = map[string]bool{
+	"double": true, "float": true, "int32": true, "int64": true, "uint32": true, "uint64": true,
+	"sint32": true, "sint64": true, "fixed32": true, "fixed64": true, "sfixed32": true, "sfixed64": true,
+	"bytes": true, "map": true, "repeated": true, "optional": true,
+}
+
+// TypeGraph shows the data-model dependencies of a type: the types it
+// references through fields or embeds, and the types that reference it,
+// followed Depth levels deep. It is the type counterpart of GetCallGraph.
+// Go embedded fields are read from the type's code; other references come
+// from cie_field, so they are matched by type name across packages.
+func TypeGraph(ctx context.Context, client Querier, args TypeGraphArgs) (*ToolResult, error) {
+	name := strings.TrimSpace(args.TypeName)
+	if name == "" {
+		return NewInputError("Error: 'type_name' is required"), nil
+	}
+	switch args.Direction {
+	case "":
+		args.Direction = "both"
+	case "both", "uses", "used_by":
+	default:
+		return NewInputError(fmt.Sprintf("Error: unknown direction '%s' (use uses, used_by or both)", args.Direction)), nil
+	}
+	if args.Depth <= 0 {
+		args.Depth = 2
+	}
+	if args.Depth > 5 {
+		args.Depth = 5
+	}
+
+	defs, err := client.Query(ctx, typeLookupScript(name, "", false))
+	if err != nil {
+		return NewError(fmt.Sprintf("Query error: %v", err)), nil
+	}
+	if len(defs.Rows) == 0 {
+		return NewResult(fmt.Sprintf("Type '%s' not found.", name)), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Type Graph for '%s'\n\n", name)
+	for _, r := range defs.Rows {
+		if len(r) >= 5 {
+			fmt.Fprintf(&sb, "**Defined in**: %s:%s (%s)\n", AnyToString(r[3]), AnyToString(r[4]), AnyToString(r[2]))
+		}
+	}
+	if len(defs.Rows) > 1 {
+		sb.WriteString("\n_Several types share this name; references are matched by name, so they are combined._\n")
+	}
+
+	locations := make(map[string]string)
+	sections := []struct {
+		direction, title, empty string
+		expand                  func(string) []typeEdge
+	}{
+		{"uses", "Uses (types it references)", "No field or embedded type references found.", func(t string) []typeEdge { return typeUses(ctx, client, t) }},
+		{"used_by", "Used by (types referencing it)", "No type references it through fields or embedding.", func(t string) []typeEdge { return typeUsedBy(ctx, client, t) }},
+	}
+	for _, s := range sections {
+		if args.Direction != "both" && args.Direction != s.direction {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n## %s\n\n", s.title)
+		tree, truncated := buildTypeTree(ctx, client, name, args.Depth, s.expand, locations)
+		if len(tree) == 0 {
+			sb.WriteString(s.empty + "\n")
+			continue
+		}
+		writeTypeTree(&sb, tree, 0, locations)
+		if truncated {
+			fmt.Fprintf(&sb, "\n⚠️ Stopped after %d types; lower `depth` for a complete view.\n", maxTypeGraphNodes)
+		}
+	}
+	return NewResult(sb.String()), nil
+}
+
+// buildTypeTree expands root level by level up to depth. Each type is
+// expanded once, where it first appears; types that are not indexed
+// (standard library, dependencies) are shown but not expanded.
+func buildTypeTree(ctx context.Context, client Querier, root string, depth int, expand func(string) []typeEdge, locations map[string]string) ([]*typeGraphNode, bool) {
+	toNodes := func(edges []typeEdge) []*typeGraphNode {
+		nodes := make([]*typeGraphNode, 0, len(edges))
+		for _, e := range edges {
+			nodes = append(nodes, &typeGraphNode{Edge: e})
+		}
+		return nodes
+	}
+
+	tree := toNodes(expand(root))
+	seen := map[string]bool{root: true}
+	level, count, truncated := tree, 0, false
+	for d := 1; len(level) > 0; d++ {
+		locateTypes(ctx, client, level, locations)
+		var next []*typeGraphNode
+		for _, n := range level {
+			if seen[n.Edge.Type] {
+				n.Repeat = true
+				continue
+			}
+			seen[n.Edge.Type] = true
+			count++
+			if count >= maxTypeGraphNodes {
+				truncated = true
+				continue
+			}
+			if d < depth && locations[n.Edge.Type] != "" {
+				n.Children = toNodes(expand(n.Edge.Type))
+				next = append(next, n.Children...)
+			}
+		}
+		level = next
+	}
+	return tree, truncated
+}
+
+// locateTypes records "file:line" for the indexed types among nodes.
+func locateTypes(ctx context.Context, client Querier, nodes []*typeGraphNode, locations map[string]string) {
+	var quoted []string
+	for _, n := range nodes {
+		if _, ok := locations[n.Edge.Type]; !ok {
+			locations[n.Edge.Type] = ""
+			quoted = append(quoted, fmt.Sprintf("%q", n.Edge.Type))
+		}
+	}
+	if len(quoted) == 0 {
+		return
+	}
+	result, err := client.Query(ctx, fmt.Sprintf("?[name, file_path, start_line] := *cie_type { name, file_path, start_line }, is_in(name, [%s])", strings.Join(quoted, ", ")))
+	if err != nil {
+		return
+	}
+	for _, r := range result.Rows {
+		if len(r) >= 3 && locations[AnyToString(r[0])] == "" {
+			locations[AnyToString(r[0])] = fmt.Sprintf("%s:%s", AnyToString(r[1]), AnyToString(r[2]))
+		}
+	}
+}
+
+func writeTypeTree(sb *strings.Builder, nodes []*typeGraphNode, indent int, locations map[string]string) {
+	for _, n := range nodes {
+		fmt.Fprintf(sb, "%s- `%s`", strings.Repeat("  ", indent), n.Edge.Type)
+		switch {
+		case n.Edge.Embedded && len(n.Edge.Fields) > 0:
+			fmt.Fprintf(sb, " embedded, and via %s", formatFieldList(n.Edge.Fields))
+		case n.Edge.Embedded:
+			sb.WriteString(" embedded")
+		default:
+			fmt.Fprintf(sb, " via %s", formatFieldList(n.Edge.Fields))
+		}
+		switch {
+		case n.Repeat:
+			sb.WriteString(" (shown above)")
+		case locations[n.Edge.Type] != "":
+			fmt.Fprintf(sb, " — %s", locations[n.Edge.Type])
+		default:
+			sb.WriteString(" — not indexed")
+		}
+		sb.WriteString("\n")
+		writeTypeTree(sb, n.Children, indent+1, locations)
+	}
+}
+
+func formatFieldList(fields []string) string {
+	quoted := make([]string, len(fields))
+	for i, f := range fields {
+		quoted[i] = "`" + f + "`"
+	}
+	if len(fields) == 1 {
+		return "field " + quoted[0]
+	}
+	return "fields " + strings.Join(quoted, ", ")
+}
+
+// typeEdgeSet collects the edges of one type, merging the fields that
+// reference the same type.
+type typeEdgeSet struct {
+	self  string
+	edges []typeEdge
+	index map[string]int
+}
+
+func newTypeEdgeSet(self string) *typeEdgeSet {
+	return &typeEdgeSet{self: self, index: make(map[string]int)}
+}
+
+func (s *typeEdgeSet) add(t, field string, embedded bool) {
+	if t == "" || t == s.self {
+		return
+	}
+	i, ok := s.index[t]
+	if !ok {
+		i = len(s.edges)
+		s.index[t] = i
+		s.edges = append(s.edges, typeEdge{Type: t})
+	}
+	if embedded {
+		s.edges[i].Embedded = true
+	} else {
+		s.edges[i].Fields = appendUniqueStrings(s.edges[i].Fields, field)
+	}
+}
+
+// typeUses returns the types typeName references through its fields and,
+// for Go, the types it embeds.
+func typeUses(ctx context.Context, client Querier, typeName string) []typeEdge {
+	edges := newTypeEdgeSet(typeName)
+
+	if code, err := client.Query(ctx, typeLookupScript(typeName, "", true)); err == nil {
+		for _, r := range code.Rows {
+			if len(r) >= 7 && detectLanguage(AnyToString(r[3])) == "go" {
+				for _, t := range goEmbeddedTypes(decodeCodeText(r[6])) {
+					edges.add(t, "", true)
+				}
+			}
+		}
+	}
+
+	fields, err := client.Query(ctx, fmt.Sprintf("?[field_name, field_type, line] := *cie_field { struct_name, field_name, field_type, line }, struct_name = %q :order line :limit 500", typeName))
+	if err == nil {
+		for _, r := range fields.Rows {
+			if len(r) >= 2 {
+				for _, t := range fieldTypeNames(AnyToString(r[1])) {
+					edges.add(t, AnyToString(r[0]), false)
+				}
+			}
+		}
+	}
+	return edges.edges
+}
+
+// typeUsedBy returns the types that reference typeName through fields or,
+// in Go, embed it.
+func typeUsedBy(ctx context.Context, client Querier, typeName string) []typeEdge {
+	edges := newTypeEdgeSet(typeName)
+
+	embedPattern := "(?m)^\\s*\\*?(\\w+\\.)?" + EscapeRegex(typeName) + "\\s*(`.*)?$"
+	embeds, err := client.Query(ctx, fmt.Sprintf("?[name] := *cie_type { id, name, file_path }, ends_with(file_path, \".go\"), *cie_type_code { type_id: id, code_text }, regex_matches(code_text, %q) :limit 200", embedPattern))
+	if err == nil {
+		for _, r := range embeds.Rows {
+			if len(r) >= 1 {
+				edges.add(AnyToString(r[0]), "", true)
+			}
+		}
+	}
+
+	// Proto fields keep the type as written ("map<string, User>", "pkg.User").
+	fieldPattern := "[.<, ]" + EscapeRegex(typeName) + ">?$"
+	fields, err := client.Query(ctx, fmt.Sprintf("?[struct_name, field_name, field_type] := *cie_field { struct_name, field_name, field_type }, field_type == %q || regex_matches(field_type, %q) :order struct_name :limit 500", typeName, fieldPattern))
+	if err == nil {
+		for _, r := range fields.Rows {
+			if len(r) >= 3 && containsString(fieldTypeNames(AnyToString(r[2])), typeName) {
+				edges.add(AnyToString(r[0]), AnyToString(r[1]), false)
+			}
+		}
+	}
+	return edges.edges
+}
+
+// goEmbeddedTypes returns the types embedded in a Go struct or interface
+// declaration.
+func goEmbeddedTypes(code string) []string {
+	start, end := strings.Index(code, "{"), strings.LastIndex(code, "}")
+	if start < 0 || end <= start {
+		return nil
+	}
+	var types []string
+	for _, line := range strings.Split(code[start+1:end], "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		if m := goEmbeddedLine.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			types = append(types, m[1])
+		}
+	}
+	return types
+}
+
+// fieldTypeNames returns the named types in a field type: Go fields are
+// stored as a base name ("Writer"), .proto fields as written
+// ("map<string, User>", "google.protobuf.Timestamp"). Builtin and scalar
+// types are dropped.
+func fieldTypeNames(fieldType string) []string {
+	var names []string
+	for _, part := range strings.FieldsFunc(fieldType, func(r rune) bool {
+		return r == '<' || r == '>' || r == ',' || r == ' ' || r == '*' || r == '[' || r == ']'
+	}) {
+		if i := strings.LastIndex(part, "."); i >= 0 {
+			part = part[i+1:]
+		}
+		if part == "" || isPrimitiveType(part) || protoScalarTypes[part] {
+			continue
+		}
+		names = append(names, part)
+	}
+	return names
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
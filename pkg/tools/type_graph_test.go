@@ -0,0 +1,167 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// typeGraphMock models:
+//
+//	type Base struct{ ID string }
+//	type Address struct{ City string }
+//	type User struct { Base; Home, Work Address; mu sync.Mutex }
+//	type Order struct { Customer User }
+func typeGraphMock() Querier {
+	types := map[string][]any{
+		"Base":    {"t1", "Base", "struct", "models/base.go", float64(3), float64(5), "type Base struct {\n\tID string\n}"},
+		"Address": {"t2", "Address", "struct", "models/address.go", float64(3), float64(5), "type Address struct {\n\tCity string\n}"},
+		"User":    {"t3", "User", "struct", "models/user.go", float64(7), float64(12), "type User struct {\n\tBase\n\tHome Address `json:\"home\"`\n\tWork Address\n\tmu   sync.Mutex // guards Home\n}"},
+		"Order":   {"t4", "Order", "struct", "models/order.go", float64(5), float64(7), "type Order struct {\n\tCustomer User\n}"},
+	}
+	fields := map[string][][]any{
+		"Base":    {{"ID", "string", float64(4)}},
+		"Address": {{"City", "string", float64(4)}},
+		"User":    {{"Home", "Address", float64(9)}, {"Work", "Address", float64(10)}, {"mu", "Mutex", float64(11)}},
+		"Order":   {{"Customer", "User", float64(6)}},
+	}
+	return NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		switch {
+		case strings.HasPrefix(script, "?[id, name, kind"):
+			for name, row := range types {
+				if strings.Contains(script, `name == "`+name+`"`) {
+					if strings.Contains(script, "code_text") {
+						return NewMockQueryResult(nil, [][]any{row}), nil
+					}
+					return NewMockQueryResult(nil, [][]any{row[:6]}), nil
+				}
+			}
+		case strings.HasPrefix(script, "?[name, file_path, start_line]"):
+			var rows [][]any
+			for name, row := range types {
+				if strings.Contains(script, `"`+name+`"`) {
+					rows = append(rows, []any{name, row[3], row[4]})
+				}
+			}
+			return NewMockQueryResult(nil, rows), nil
+		case strings.HasPrefix(script, "?[field_name, field_type, line]"):
+			for name, rows := range fields {
+				if strings.Contains(script, `struct_name = "`+name+`"`) {
+					return NewMockQueryResult(nil, rows), nil
+				}
+			}
+		case strings.HasPrefix(script, "?[struct_name, field_name, field_type]"):
+			var rows [][]any
+			for owner, fs := range fields {
+				for _, f := range fs {
+					if strings.Contains(script, `field_type == "`+f[1].(string)+`"`) {
+						rows = append(rows, []any{owner, f[0], f[1]})
+					}
+				}
+			}
+			return NewMockQueryResult(nil, rows), nil
+		case strings.HasPrefix(script, "?[name] := *cie_type"):
+			if strings.Contains(script, `Base\\s*`) {
+				return NewMockQueryResult(nil, [][]any{{"User"}}), nil
+			}
+		}
+		return NewMockQueryResult(nil, nil), nil
+	}, nil)
+}
+
+func TestTypeGraph(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("uses", func(t *testing.T) {
+		result, err := TypeGraph(ctx, typeGraphMock(), TypeGraphArgs{TypeName: "User", Direction: "uses"})
+		assertNoError(t, err)
+		for _, want := range []string{
+			"# Type Graph for 'User'",
+			"**Defined in**: models/user.go:7 (struct)",
+			"- `Base` embedded — models/base.go:3",
+			"- `Address` via fields `Home`, `Work` — models/address.go:3",
+			"- `Mutex` via field `mu` — not indexed",
+		} {
+			assertContains(t, result.Text, want)
+		}
+		if strings.Contains(result.Text, "Used by") {
+			t.Errorf("used_by section should be omitted:\n%s", result.Text)
+		}
+	})
+
+	t.Run("used by with depth", func(t *testing.T) {
+		result, err := TypeGraph(ctx, typeGraphMock(), TypeGraphArgs{TypeName: "Address", Direction: "used_by"})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "- `User` via fields `Home`, `Work` — models/user.go:7\n  - `Order` via field `Customer` — models/order.go:5")
+
+		result, err = TypeGraph(ctx, typeGraphMock(), TypeGraphArgs{TypeName: "Address", Direction: "used_by", Depth: 1})
+		assertNoError(t, err)
+		if strings.Contains(result.Text, "Order") {
+			t.Errorf("depth 1 should stop at direct references:\n%s", result.Text)
+		}
+	})
+
+	t.Run("embedding", func(t *testing.T) {
+		result, err := TypeGraph(ctx, typeGraphMock(), TypeGraphArgs{TypeName: "Base"})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "No field or embedded type references found.")
+		assertContains(t, result.Text, "- `User` embedded — models/user.go:7")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		result, err := TypeGraph(ctx, typeGraphMock(), TypeGraphArgs{TypeName: "Invoice"})
+		assertNoError(t, err)
+		assertContains(t, result.Text, "Type 'Invoice' not found.")
+	})
+
+	t.Run("bad direction", func(t *testing.T) {
+		result, err := TypeGraph(ctx, typeGraphMock(), TypeGraphArgs{TypeName: "User", Direction: "down"})
+		assertNoError(t, err)
+		if !result.IsError {
+			t.Fatalf("expected an input error, got %q", result.Text)
+		}
+	})
+}
+
+func TestGoEmbeddedTypes(t *testing.T) {
+	code := "type Server struct {\n\t*Base\n\tpb.UnimplementedUserServiceServer\n\tsync.Mutex `json:\"-\"`\n\tname string\n\tlog  *Logger // embedded? no\n}"
+	want := []string{"Base", "UnimplementedUserServiceServer", "Mutex"}
+	if got := goEmbeddedTypes(code); !reflect.DeepEqual(got, want) {
+		t.Errorf("goEmbeddedTypes() = %v, want %v", got, want)
+	}
+}
+
+func TestFieldTypeNames(t *testing.T) {
+	tests := map[string][]string{
+		"Address":                   {"Address"},
+		"string":                    nil,
+		"map<string, User>":         {"User"},
+		"google.protobuf.Timestamp": {"Timestamp"},
+		"int64":                     nil,
+	}
+	for in, want := range tests {
+		if got := fieldTypeNames(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("fieldTypeNames(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -19,12 +19,17 @@
 
 package tools
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/kraklabs/cie/pkg/errcode"
+)
 
 // ToolResult represents the result of a tool execution.
 type ToolResult struct {
 	Text    string
 	IsError bool
+	Code    errcode.Code // Failure kind when IsError is set
 }
 
 // NewResult creates a successful tool result.
@@ -32,9 +37,26 @@ func NewResult(text string) *ToolResult {
 	return &ToolResult{Text: text}
 }
 
-// NewError creates an error tool result.
+// NewError creates an error tool result. Its code is inferred from the
+// first line of text, which by convention carries the underlying error;
+// the rest may quote a query whose words would mislead the classifier.
 func NewError(text string) *ToolResult {
-	return &ToolResult{Text: text, IsError: true}
+	first, _, _ := strings.Cut(text, "\n")
+	code := errcode.ClassifyMessage(first)
+	if code == "" {
+		code = errcode.ToolFailed
+	}
+	return NewCodedError(code, text)
+}
+
+// NewInputError creates an error tool result for invalid arguments.
+func NewInputError(text string) *ToolResult {
+	return NewCodedError(errcode.InvalidInput, text)
+}
+
+// NewCodedError creates an error tool result with an explicit code.
+func NewCodedError(code errcode.Code, text string) *ToolResult {
+	return &ToolResult{Text: text, IsError: true, Code: code}
 }
 
 // FunctionInfo represents a function found in the codebase.
@@ -214,6 +236,11 @@ type RolePattern struct {
 	NamePattern string
 	// CodePattern is a regex to match code content (e.g., "\\.GET\\(")
 	CodePattern string
+	// Paths are globs matching file paths (e.g., "internal/experimental/**"),
+	// combined with FilePattern: a file matching either matches
+	Paths []string
+	// ExcludeFromSource removes the role's functions from the "source" role
+	ExcludeFromSource bool
 	// Description explains what this role represents
 	Description string
 }
@@ -22,6 +22,8 @@ package tools
 import (
 	"strings"
 	"testing"
+
+	"github.com/kraklabs/cie/pkg/errcode"
 )
 
 func TestNewResult(t *testing.T) {
@@ -76,6 +78,28 @@ func TestNewError(t *testing.T) {
 	}
 }
 
+func TestNewError_Code(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *ToolResult
+		want   errcode.Code
+	}{
+		{"classified from text", NewError("Query error: database is locked\n\nQuery:\n?[x] := x = 1"), errcode.DBLocked},
+		{"unclassified falls back", NewError("Something went wrong"), errcode.ToolFailed},
+		{"input error", NewInputError("Error: 'name' is required"), errcode.InvalidInput},
+		{"explicit code", NewCodedError(errcode.QueryRejected, "Query rejected"), errcode.QueryRejected},
+		{"success has no code", NewResult("ok"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.result.Code != tt.want {
+				t.Errorf("Code = %q, want %q", tt.result.Code, tt.want)
+			}
+		})
+	}
+}
+
 func TestTruncate(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -256,3 +256,20 @@ func AnyToString(v any) string {
 		return fmt.Sprintf("%v", v)
 	}
 }
+
+// toFloat64 converts a numeric query cell to float64, returning 0 for
+// non-numeric values.
+func toFloat64(v any) float64 {
+	switch val := v.(type) {
+	case float64:
+		return val
+	case float32:
+		return float64(val)
+	case int:
+		return float64(val)
+	case int64:
+		return float64(val)
+	default:
+		return 0
+	}
+}
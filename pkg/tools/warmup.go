@@ -0,0 +1,146 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// warmupRelations are scanned during warm-up so RocksDB pulls their blocks
+// into the page cache before the first real query.
+var warmupRelations = []string{
+	"cie_file",
+	"cie_function",
+	"cie_function_code",
+	"cie_calls",
+	"cie_type",
+}
+
+// WarmupStep reports one unit of warm-up work.
+type WarmupStep struct {
+	Name     string
+	Duration time.Duration
+	Rows     int
+	Err      error
+}
+
+// RunWarmup touches every hot relation and runs one probe against each HNSW
+// index so that later queries do not pay the cold-start cost. It stops early
+// if ctx is cancelled.
+func RunWarmup(ctx context.Context, client Querier) []WarmupStep {
+	var steps []WarmupStep
+	for _, rel := range warmupRelations {
+		if ctx.Err() != nil {
+			return steps
+		}
+		steps = append(steps, timedStep("scan "+rel, func() (int, error) {
+			return countRows(ctx, client, rel)
+		}))
+	}
+	for _, idx := range []struct{ rel, key string }{
+		{"cie_function_embedding", "function_id"},
+		{"cie_type_embedding", "type_id"},
+	} {
+		if ctx.Err() != nil {
+			return steps
+		}
+		steps = append(steps, timedStep("hnsw "+idx.rel, func() (int, error) {
+			return probeHNSW(ctx, client, idx.rel, idx.key)
+		}))
+	}
+	return steps
+}
+
+func timedStep(name string, fn func() (int, error)) WarmupStep {
+	start := time.Now()
+	rows, err := fn()
+	return WarmupStep{Name: name, Duration: time.Since(start), Rows: rows, Err: err}
+}
+
+func countRows(ctx context.Context, client Querier, rel string) (int, error) {
+	result, err := client.Query(ctx, fmt.Sprintf("?[count(k)] := *%s { %s: k }", rel, firstKey(rel)))
+	if err != nil {
+		return 0, err
+	}
+	if len(result.Rows) == 0 || len(result.Rows[0]) == 0 {
+		return 0, nil
+	}
+	return int(toFloat64(result.Rows[0][0])), nil
+}
+
+// firstKey returns the key column of a warm-up relation.
+func firstKey(rel string) string {
+	if rel == "cie_function_code" {
+		return "function_id"
+	}
+	return "id"
+}
+
+// probeHNSW loads one stored vector and searches the index with it, which
+// forces the graph's upper layers to be read from disk.
+func probeHNSW(ctx context.Context, client Querier, rel, key string) (int, error) {
+	seed, err := client.Query(ctx, fmt.Sprintf("?[embedding] := *%s { embedding } :limit 1", rel))
+	if err != nil {
+		return 0, err
+	}
+	if len(seed.Rows) == 0 || len(seed.Rows[0]) == 0 {
+		return 0, nil // no embeddings indexed
+	}
+	vector, ok := seed.Rows[0][0].([]any)
+	if !ok || len(vector) == 0 {
+		return 0, fmt.Errorf("unexpected embedding value %T", seed.Rows[0][0])
+	}
+	floats := make([]float64, len(vector))
+	for i, v := range vector {
+		floats[i] = toFloat64(v)
+	}
+
+	script := fmt.Sprintf(`?[%s, distance] :=
+		~%s:embedding_idx { %s | query: q, k: 10, ef: 50, bind_distance: distance },
+		q = %s`, key, rel, key, formatEmbeddingForCozoDB(floats))
+	result, err := client.Query(ctx, script)
+	if err != nil {
+		return 0, err
+	}
+	return len(result.Rows), nil
+}
+
+// FormatWarmup renders warm-up steps for display.
+func FormatWarmup(steps []WarmupStep, total time.Duration) string {
+	var sb strings.Builder
+	sb.WriteString("## Warm-up\n\n")
+	failed := 0
+	for _, s := range steps {
+		if s.Err != nil {
+			failed++
+			sb.WriteString(fmt.Sprintf("- %s: ❌ %v (%s)\n", s.Name, s.Err, s.Duration.Round(time.Millisecond)))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("- %s: %d rows (%s)\n", s.Name, s.Rows, s.Duration.Round(time.Millisecond)))
+	}
+	sb.WriteString(fmt.Sprintf("\nCompleted %d steps in %s", len(steps), total.Round(time.Millisecond)))
+	if failed > 0 {
+		sb.WriteString(fmt.Sprintf(" (%d failed)", failed))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 KrakLabs
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// For commercial licensing, contact: licensing@kraklabs.com
+//
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunWarmup(t *testing.T) {
+	var hnswQueries []string
+	client := NewMockClientCustom(func(ctx context.Context, script string) (*QueryResult, error) {
+		switch {
+		case strings.Contains(script, "count("):
+			return NewMockQueryResult([]string{"count(k)"}, [][]any{{float64(42)}}), nil
+		case strings.Contains(script, "*cie_function_embedding { embedding }"):
+			return NewMockQueryResult([]string{"embedding"}, [][]any{{[]any{0.1, 0.2, 0.3}}}), nil
+		case strings.Contains(script, "*cie_type_embedding { embedding }"):
+			return NewMockQueryResult([]string{"embedding"}, nil), nil // no type embeddings
+		case strings.Contains(script, ":embedding_idx"):
+			hnswQueries = append(hnswQueries, script)
+			return NewMockQueryResult([]string{"function_id", "distance"}, [][]any{{"f1", 0.0}, {"f2", 0.1}}), nil
+		}
+		return nil, errors.New("unexpected query: " + script)
+	}, nil)
+
+	steps := RunWarmup(context.Background(), client)
+	if len(steps) != len(warmupRelations)+2 {
+		t.Fatalf("got %d steps, want %d", len(steps), len(warmupRelations)+2)
+	}
+	for _, s := range steps[:len(warmupRelations)] {
+		if s.Err != nil || s.Rows != 42 {
+			t.Errorf("%s: rows=%d err=%v", s.Name, s.Rows, s.Err)
+		}
+	}
+	if fn := steps[len(steps)-2]; fn.Rows != 2 || fn.Err != nil {
+		t.Errorf("function HNSW probe: %+v", fn)
+	}
+	if len(hnswQueries) != 1 || !strings.Contains(hnswQueries[0], "vec([0.100000,0.200000,0.300000])") {
+		t.Errorf("expected one HNSW probe seeded with stored vector, got %v", hnswQueries)
+	}
+
+	out := FormatWarmup(steps, time.Second)
+	if !strings.Contains(out, "scan cie_function: 42 rows") || strings.Contains(out, "failed") {
+		t.Errorf("unexpected report:\n%s", out)
+	}
+}
+
+func TestRunWarmup_Errors(t *testing.T) {
+	client := NewMockClientWithError(errors.New("db closed"))
+	steps := RunWarmup(context.Background(), client)
+	out := FormatWarmup(steps, time.Millisecond)
+	if !strings.Contains(out, "db closed") || !strings.Contains(out, "failed)") {
+		t.Errorf("errors should be reported:\n%s", out)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if steps := RunWarmup(ctx, client); len(steps) != 0 {
+		t.Errorf("cancelled warm-up ran %d steps", len(steps))
+	}
+}